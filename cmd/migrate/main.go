@@ -0,0 +1,72 @@
+// Command migrate shows and changes the schema version of a co-atc database. The server
+// migrates its databases up on startup; this tool is for checking a database and for
+// rolling one back before downgrading co-atc.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/yegors/co-atc/internal/storage/sqlite"
+	"github.com/yegors/co-atc/pkg/logger"
+)
+
+func main() {
+	dbPath := flag.String("db", "", "Path to the database file (co-atc.db or co-atc-YYYY-MM-DD.db)")
+	schema := flag.String("schema", "", "Schema of the database: \"settings\" or \"daily\" (default: from the file name)")
+	down := flag.Int("down", -1, "Roll the database back to this version instead of migrating it up")
+	status := flag.Bool("status", false, "Only print the database's schema version")
+	flag.Parse()
+
+	if *dbPath == "" {
+		fmt.Fprintln(os.Stderr, "Usage: migrate -db <path> [-schema settings|daily] [-status | -down <version>]")
+		os.Exit(2)
+	}
+	if _, err := os.Stat(*dbPath); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	s := sqlite.Schema(*schema)
+	if s == "" {
+		s = sqlite.DailySchema
+		if filepath.Base(*dbPath) == "co-atc.db" {
+			s = sqlite.SettingsSchema
+		}
+	}
+
+	log, err := logger.New(logger.Config{Level: "info", Format: "console"})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error creating logger: %v\n", err)
+		os.Exit(1)
+	}
+	defer log.Sync()
+
+	db, err := sqlite.OpenDatabase(*dbPath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	defer db.Close()
+
+	switch {
+	case *status:
+	case *down >= 0:
+		err = sqlite.MigrateDown(db, s, *down, log)
+	default:
+		err = sqlite.Migrate(db, s, log)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	current, latest, err := sqlite.SchemaVersion(db, s)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Printf("%s schema version %d (latest %d)\n", s, current, latest)
+}
@@ -8,18 +8,40 @@ import (
 	"os"
 	"os/signal"
 	"path/filepath"
+	"strings"
 	"sync"
 	"syscall"
 	"time"
 
 	"github.com/yegors/co-atc/internal/adsb"
 	"github.com/yegors/co-atc/internal/api"
+	"github.com/yegors/co-atc/internal/approach"
 	"github.com/yegors/co-atc/internal/atcchat"
+	"github.com/yegors/co-atc/internal/atis"
+	"github.com/yegors/co-atc/internal/board"
+	"github.com/yegors/co-atc/internal/briefing"
 	"github.com/yegors/co-atc/internal/config"
+	"github.com/yegors/co-atc/internal/deviation"
+	"github.com/yegors/co-atc/internal/events"
+	"github.com/yegors/co-atc/internal/feeder"
 	"github.com/yegors/co-atc/internal/frequencies"
+	"github.com/yegors/co-atc/internal/intent"
+	"github.com/yegors/co-atc/internal/movements"
+	"github.com/yegors/co-atc/internal/mqtt"
+	"github.com/yegors/co-atc/internal/noise"
+	"github.com/yegors/co-atc/internal/notify"
+	"github.com/yegors/co-atc/internal/occupancy"
+	"github.com/yegors/co-atc/internal/push"
+	"github.com/yegors/co-atc/internal/records"
+	"github.com/yegors/co-atc/internal/retention"
+	"github.com/yegors/co-atc/internal/sequence"
 	"github.com/yegors/co-atc/internal/simulation"
+	"github.com/yegors/co-atc/internal/station"
+	"github.com/yegors/co-atc/internal/stats"
 	"github.com/yegors/co-atc/internal/storage/sqlite"
 	"github.com/yegors/co-atc/internal/templating"
+	"github.com/yegors/co-atc/internal/terrain"
+	"github.com/yegors/co-atc/internal/usage"
 	"github.com/yegors/co-atc/internal/weather"
 	"github.com/yegors/co-atc/internal/websocket"
 	"github.com/yegors/co-atc/pkg/logger"
@@ -31,12 +53,22 @@ func main() {
 	flag.Parse()
 
 	// Load configuration with fallback logic
-	cfg, err := config.LoadWithFallback(*configPath)
+	cfg, loadedConfigPath, err := config.LoadWithFallbackPath(*configPath)
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "Error loading configuration: %v\n", err)
 		os.Exit(1)
 	}
 
+	// Fill in the station from the receiver before validation needs it
+	var detection *station.Detection
+	if cfg.Station.AutoDetect {
+		detection, err = station.Detect(context.Background(), cfg)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Station auto-detection failed: %v\n", err)
+			os.Exit(1)
+		}
+	}
+
 	// Validate configuration
 	if err := cfg.Validate(); err != nil {
 		fmt.Fprintf(os.Stderr, "Invalid configuration: %v\n", err)
@@ -47,6 +79,16 @@ func main() {
 	log, err := logger.New(logger.Config{
 		Level:  cfg.Logging.Level,
 		Format: "console", // Always use console format for better readability
+		Levels: cfg.Logging.Levels,
+		File: logger.FileConfig{
+			Path:       cfg.Logging.File.Path,
+			Format:     cfg.Logging.File.Format,
+			MaxSizeMB:  cfg.Logging.File.MaxSizeMB,
+			MaxBackups: cfg.Logging.File.MaxBackups,
+			MaxAgeDays: cfg.Logging.File.MaxAgeDays,
+			Compress:   cfg.Logging.File.Compress,
+		},
+		Buffer: max(cfg.Logging.BufferSize, 0),
 	})
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "Error creating logger: %v\n", err)
@@ -56,8 +98,24 @@ func main() {
 
 	log.Info("Starting Co-ATC server",
 		logger.String("version", "0.1.0"),
-		logger.String("config_path", *configPath),
+		logger.String("config_path", loadedConfigPath),
 	)
+	if detection != nil {
+		fields := []logger.Field{
+			logger.Float64("latitude", detection.Latitude),
+			logger.Float64("longitude", detection.Longitude),
+		}
+		if detection.ReceiverURL != "" {
+			fields = append(fields, logger.String("receiver", detection.ReceiverURL))
+		}
+		if detection.Airport != nil {
+			fields = append(fields,
+				logger.String("airport", detection.Airport.ICAO),
+				logger.String("airport_name", detection.Airport.Name),
+				logger.Float64("distance_nm", detection.DistanceNM))
+		}
+		log.Info("Station auto-detected", fields...)
+	}
 
 	// Create ADS-B components
 	adsbClient := adsb.NewClient(
@@ -72,6 +130,18 @@ func main() {
 		time.Duration(cfg.Server.ReadTimeoutSecs)*time.Second,
 		log,
 	)
+	if cfg.ADSB.SourceType == "external" {
+		adsbClient.SetExternalPolling(cfg.ADSB)
+	}
+	if cfg.ADSB.UATSourceURL != "" {
+		adsbClient.SetUATSource(cfg.ADSB.UATSourceURL)
+	}
+	// The raw source decodes the receiver's Beast or AVR output itself
+	var rawReceiver *adsb.RawReceiver
+	if cfg.ADSB.SourceType == "raw" {
+		rawReceiver = adsb.NewRawReceiver(cfg.ADSB.RawSourceAddress, cfg.ADSB.RawFormat, cfg.Station.Latitude, cfg.Station.Longitude, log)
+		adsbClient.SetRawReceiver(rawReceiver)
+	}
 	// Processor has been moved into the service
 
 	// Create SQLite storage
@@ -91,10 +161,16 @@ func main() {
 
 	log.Info("Using daily database", logger.String("path", dbPath))
 
-	// Create SQLite storage with no retention settings
+	// Create SQLite storage; aircraft writes go through a queue so the poll loop does not wait on disk I/O
 	sqliteStorage, err := sqlite.NewAircraftStorage(
 		dbPath,
 		cfg.Storage.MaxPositionsInAPI,
+		sqlite.WriteOptions{
+			QueueSize:          max(cfg.Storage.WriteQueueSize, 0),
+			BatchSize:          cfg.Storage.WriteBatchSize,
+			WALAutocheckpoint:  cfg.Storage.WALAutocheckpointPages,
+			CheckpointInterval: time.Duration(max(cfg.Storage.CheckpointIntervalSecs, 0)) * time.Second,
+		},
 		log,
 	)
 	if err != nil {
@@ -111,6 +187,47 @@ func main() {
 	// Create clearance storage
 	clearanceStorage := sqlite.NewClearanceStorage(sqliteStorage.GetDB(), log)
 
+	// Open the settings database. Unlike the daily database it is not rotated,
+	// so changes made at runtime survive across days and restarts.
+	settingsDBPath := filepath.Join(cfg.Storage.SQLiteBasePath, "co-atc.db")
+	settingsDB, err := sqlite.OpenMigratedDatabase(settingsDBPath, sqlite.SettingsSchema, log)
+	if err != nil {
+		log.Error("Failed to open settings database", logger.Error(err))
+		os.Exit(1)
+	}
+	defer settingsDB.Close()
+
+	// Create frequency storage
+	frequencyStorage := sqlite.NewFrequencyStorage(settingsDB, log)
+
+	// Create recording storage
+	recordingStorage := sqlite.NewRecordingStorage(settingsDB, log)
+
+	// Create push storage
+	pushStorage := sqlite.NewPushStorage(settingsDB, log)
+
+	// Create chat summary storage
+	chatSummaryStorage := sqlite.NewChatSummaryStorage(settingsDB, log)
+	chatHistoryStorage := sqlite.NewChatHistoryStorage(settingsDB, log)
+
+	// Create ATIS and weather history storage
+	atisStorage := sqlite.NewATISStorage(settingsDB, log)
+	weatherHistoryStorage := sqlite.NewWeatherHistoryStorage(settingsDB, log)
+
+	// Create aircraft watchlist storage
+	watchlistStorage := sqlite.NewWatchlistStorage(settingsDB, log)
+
+	// Create station records storage
+	recordStorage := sqlite.NewRecordStorage(settingsDB, log)
+
+	// Create API usage storage
+	usageStorage := sqlite.NewUsageStorage(settingsDB, log)
+
+	// Create backup storage covering both databases
+	backupStorage := sqlite.NewBackupStorage(cfg.Storage.BackupDir, log)
+	backupStorage.AddDatabase(strings.TrimSuffix(filepath.Base(dbPath), ".db"), sqliteStorage.GetDB())
+	backupStorage.AddDatabase("co-atc", settingsDB)
+
 	// Create WebSocket server
 	wsServer := websocket.NewServer(log)
 
@@ -119,6 +236,8 @@ func main() {
 
 	// Create simulation service
 	simulationService := simulation.NewService(log)
+	// Replays read recorded tracks and transcriptions from today's database and METARs from history
+	simulationService.SetReplaySources(sqliteStorage, transcriptionStorage, weatherHistoryStorage, cfg.Station.AirportCode, wsServer)
 
 	adsbService := adsb.NewService(
 		adsbClient,
@@ -134,14 +253,207 @@ func main() {
 		simulationService,
 	)
 
+	// Aircraft lined up with a runway get their glidepath and localizer deviation
+	adsbService.SetApproachGuidance(cfg.Approaches)
+
+	// Simulated aircraft fly approaches to the station's runways
+	simulationService.SetAirport(adsbService.RunwayEnds(), float64(cfg.Station.ElevationFeet))
+	// Background traffic follows the runway configuration
+	simulationService.SetTrafficGenerator(cfg.TrafficGenerator, adsbService)
+	// Simulated radio calls go through post-processing like transcribed audio
+	radioFrequencyIDs := make([]string, 0, len(cfg.Frequencies.Sources))
+	for _, freq := range cfg.Frequencies.Sources {
+		radioFrequencyIDs = append(radioFrequencyIDs, freq.ID)
+	}
+	simulationService.SetRadio(transcriptionStorage, radioFrequencyIDs, wsServer)
+
+	// Flag blocked aircraft, and keep military and blocked ones off the public WebSocket feed if configured
+	if err := adsbService.SetBlocklist(cfg.Privacy); err != nil {
+		log.Error("Failed to load blocked aircraft", logger.Error(err))
+		os.Exit(1)
+	}
+	wsServer.SetHiddenAircraft(cfg.Privacy.HideMilitary, cfg.Privacy.HideBlocked)
+
 	// Create and set WebSocket message handler for ADSB
 	wsHandler := adsb.NewWebSocketHandler(adsbService, log)
 	wsServer.SetMessageHandler(wsHandler)
 
-	// Start ADS-B service
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
 
+	// Create push notification service (if enabled)
+	var pushService *push.Service
+	if cfg.Push.Enabled {
+		pushService, err = push.NewService(cfg.Push, pushStorage, log)
+		if err != nil {
+			log.Error("Failed to create push service", logger.Error(err))
+			// Continue without push notifications rather than failing
+			pushService = nil
+		} else {
+			pushService.Start(ctx)
+		}
+	} else {
+		log.Info("Push notifications disabled in configuration")
+	}
+
+	// Create notification service for webhooks, chat services and MQTT (if enabled)
+	var notifyService *notify.Service
+	if cfg.Notify.Enabled {
+		notifyService, err = notify.NewService(cfg.Notify, cfg.Station.AirportCode, log)
+		if err != nil {
+			log.Error("Failed to create notification service", logger.Error(err))
+			// Continue without notification channels rather than failing
+			notifyService = nil
+		} else {
+			notifyService.Start(ctx)
+		}
+	}
+
+	// Publish aircraft, transcriptions, events and alerts to an MQTT broker (if enabled)
+	var mqttPublisher *mqtt.Publisher
+	if cfg.MQTT.Enabled {
+		mqttPublisher = mqtt.NewPublisher(cfg.MQTT, cfg.Station.AirportCode, log)
+		adsbService.OnUpdate(mqttPublisher.HandleAircraft)
+		wsServer.OnBroadcast(mqttPublisher.HandleBroadcast)
+		mqttPublisher.Start(ctx)
+	}
+
+	// Every alert is recorded on the event timeline
+	eventsService := events.NewService(sqlite.NewEventStorage(sqliteStorage.GetDB(), log), wsServer, log)
+
+	// Alerts go to the event timeline, browsers, notification channels and MQTT alike
+	alertNotifiers := notify.Fanout{eventsService}
+	if pushService != nil {
+		alertNotifiers = append(alertNotifiers, pushService)
+	}
+	if notifyService != nil {
+		alertNotifiers = append(alertNotifiers, notifyService)
+	}
+	if mqttPublisher != nil {
+		alertNotifiers = append(alertNotifiers, mqttPublisher)
+	}
+	adsbService.SetAlertNotifier(alertNotifiers) // Emergency squawk and runway alerts
+
+	// Generate simulated arrivals and departures (when enabled here or through the API)
+	simulationService.StartTraffic(ctx)
+
+	// Account for API tokens, audio minutes and cost, with budget alerts
+	usageTracker := usage.NewTracker(cfg.Usage, usageStorage, wsServer, log)
+	if err := usageTracker.Start(ctx); err != nil {
+		log.Error("Failed to start usage tracking", logger.Error(err))
+		os.Exit(1)
+	}
+
+	// Track station records (fastest aircraft, busiest hour, ...) from every poll cycle
+	recordsService := records.NewService(adsbService, recordStorage, cfg.Station.AirportCode, log)
+	if err := recordsService.Start(ctx); err != nil {
+		log.Error("Failed to start records service", logger.Error(err))
+		os.Exit(1)
+	}
+
+	// Traffic statistics from the stored phase changes and tracks
+	statsService := stats.NewService(sqlite.NewStatsStorage(sqliteStorage.GetDB(), log), log)
+
+	// Watch whether aircraft follow the altitude and heading clearances extracted from transcriptions
+	var deviationService *deviation.Service
+	if cfg.Deviations.Enabled {
+		deviationService = deviation.NewService(cfg.Deviations, adsbService, clearanceStorage, wsServer, log)
+		deviationService.SetAlertNotifier(alertNotifiers)
+		deviationService.Start(ctx)
+	}
+
+	// Warn of descending aircraft predicted too close to terrain or obstacles
+	var terrainService *terrain.Service
+	if cfg.Terrain.Enabled {
+		terrainService = terrain.NewService(cfg.Terrain, adsbService, wsServer, log)
+		terrainService.SetAlertNotifier(alertNotifiers)
+		if err := terrainService.Start(ctx); err != nil {
+			log.Error("Failed to start terrain warnings", logger.Error(err))
+			os.Exit(1)
+		}
+	}
+
+	// Flag aircraft on final approach that don't meet stabilized approach criteria
+	var approachService *approach.Service
+	if cfg.Approaches.Enabled {
+		approachService = approach.NewService(cfg.Approaches, adsbService, wsServer, log)
+		approachService.SetAlertNotifier(alertNotifiers)
+		approachService.Start(ctx)
+	}
+
+	// Sequence arrivals to each runway by their estimated time to the threshold
+	var sequenceService *sequence.Service
+	if cfg.Sequencing.Enabled {
+		sequenceService = sequence.NewService(cfg.Sequencing, adsbService, wsServer, log)
+		sequenceService.Start(ctx)
+	}
+
+	// Infer what aircraft have been cleared to do and are doing from clearances and tracks
+	var intentService *intent.Service
+	if cfg.Intents.Enabled {
+		intentService = intent.NewService(cfg.Intents, adsbService, clearanceStorage, log)
+		intentService.Start(ctx)
+	}
+
+	// Record and alert aircraft overflying noise-sensitive zones too low during curfew hours
+	var noiseService *noise.Service
+	if cfg.Noise.Enabled {
+		noiseService = noise.NewService(cfg.Noise, adsbService, sqlite.NewNoiseStorage(settingsDB, log), wsServer, log)
+		noiseService.SetAlertNotifier(alertNotifiers)
+		if err := noiseService.Start(ctx); err != nil {
+			log.Error("Failed to start noise monitoring", logger.Error(err))
+			os.Exit(1)
+		}
+	}
+
+	// Stitch aircraft tracks into flight sessions and log the airport's departures and arrivals
+	var movementsService *movements.Service
+	if cfg.Movements.Enabled {
+		movementsService = movements.NewService(cfg.Movements, cfg.FlightPhases.AirportRangeNM, adsbService, sqlite.NewFlightSessionStorage(settingsDB, log), log)
+		if err := movementsService.Start(ctx); err != nil {
+			log.Error("Failed to start flight session recording", logger.Error(err))
+			os.Exit(1)
+		}
+	}
+
+	// Measure how long landings and takeoffs occupy the runway
+	var occupancyService *occupancy.Service
+	if cfg.Occupancy.Enabled {
+		occupancyService = occupancy.NewService(cfg.Occupancy, cfg.Station.ElevationFeet, adsbService, sqlite.NewRunwayOccupancyStorage(settingsDB, log), log)
+		if err := occupancyService.Start(ctx); err != nil {
+			log.Error("Failed to start runway occupancy measurement", logger.Error(err))
+			os.Exit(1)
+		}
+	}
+
+	// Build the arrivals and departures boards from the movement log
+	var boardService *board.Service
+	if movementsService != nil {
+		boardService = board.NewService(cfg.Board, cfg.Station.AirportCode, movementsService, log)
+		if err := boardService.Start(ctx); err != nil {
+			log.Error("Failed to start board service", logger.Error(err))
+			os.Exit(1)
+		}
+	}
+
+	// Load watchlists before the first poll cycle, so watched aircraft are tagged from the start
+	if err := adsbService.SetWatchlistStore(watchlistStorage); err != nil {
+		log.Error("Failed to load watchlists", logger.Error(err))
+		os.Exit(1)
+	}
+
+	// Feed locally received aircraft to community aggregators. Started before the raw
+	// receiver, which it takes Beast frames from.
+	var feederService *feeder.Service
+	if cfg.Feeder.Enabled {
+		feederService = feeder.NewService(cfg.Feeder, adsbService, rawReceiver, log)
+		feederService.Start(ctx)
+	}
+
+	// Start ADS-B service
+	if rawReceiver != nil {
+		rawReceiver.Start(ctx)
+	}
 	if err := adsbService.Start(ctx); err != nil {
 		log.Error("Failed to start ADS-B service", logger.Error(err))
 		os.Exit(1)
@@ -150,44 +462,93 @@ func main() {
 	// Create weather service first (needed for templating)
 	weatherConfigConverted := weather.ConfigWeatherConfig{
 		RefreshIntervalMinutes: cfg.Weather.RefreshIntervalMinutes,
+		Providers:              cfg.Weather.Providers,
 		APIBaseURL:             cfg.Weather.APIBaseURL,
+		CheckWXAPIKey:          cfg.Weather.CheckWXAPIKey,
 		RequestTimeoutSeconds:  cfg.Weather.RequestTimeoutSeconds,
 		MaxRetries:             cfg.Weather.MaxRetries,
 		FetchMETAR:             cfg.Weather.FetchMETAR,
 		FetchTAF:               cfg.Weather.FetchTAF,
 		FetchNOTAMs:            cfg.Weather.FetchNOTAMs,
+		FetchWindsAloft:        cfg.Weather.FetchWindsAloft,
+		WindsAloftRadiusNM:     cfg.Weather.WindsAloftRadiusNM,
+		FetchHazards:           cfg.Weather.FetchHazards,
+		HazardsRadiusNM:        cfg.Weather.HazardsRadiusNM,
+		StationLatitude:        cfg.Station.Latitude,
+		StationLongitude:       cfg.Station.Longitude,
 		CacheExpiryMinutes:     cfg.Weather.CacheExpiryMinutes,
 	}
 	weatherService := weather.NewService(weatherConfigConverted, cfg.Station.AirportCode, log)
+	if cfg.Weather.StoreHistory {
+		weatherService.SetHistoryStorage(weatherHistoryStorage, time.Duration(cfg.Weather.HistoryRetentionDays)*24*time.Hour)
+	}
 
 	// Start weather service
 	if err := weatherService.Start(); err != nil {
 		log.Error("Failed to start weather service", logger.Error(err))
 		os.Exit(1)
 	}
+	if cfg.Weather.FetchWindsAloft {
+		adsb.SetWindModel(weatherService)
+	}
+
+	// Follow the ATIS and announce new information letters (if enabled)
+	var atisService *atis.Service
+	if cfg.ATIS.Enabled {
+		atisService = atis.NewService(cfg.ATIS, cfg.Station.AirportCode, atisStorage, wsServer, log)
+	}
 
 	// Create templating service
 	templateService := templating.NewService(
 		adsbService,
 		weatherService,
 		transcriptionStorage,
-		nil, // frequencies service not available yet
+		nil, // frequencies service is set once created
 		cfg,
 		log,
 	)
+	if approachService != nil {
+		templateService.SetApproachService(approachService)
+	}
+	if sequenceService != nil {
+		templateService.SetSequenceService(sequenceService)
+	}
+	if atisService != nil {
+		templateService.SetATISService(atisService)
+
+		// A synthesized ATIS is rendered from the template context and read out with the
+		// briefing voice
+		if cfg.ATIS.Source == config.ATISSourceSynthesized {
+			var speaker atis.Speaker
+			if cfg.Briefing.OpenAIAPIKey != "" {
+				speaker = briefing.NewSpeaker(cfg.Briefing, usage.SubsystemATIS, usageTracker)
+			}
+			atisService.SetSynthesizer(templateService, speaker)
+		}
+		if err := atisService.Start(ctx); err != nil {
+			log.Error("Failed to start ATIS service", logger.Error(err))
+			os.Exit(1)
+		}
+	}
 
 	// Create frequencies service
-	frequenciesService := frequencies.NewService(cfg, log, wsServer, transcriptionStorage, sqliteStorage, clearanceStorage, templateService)
+	frequenciesService := frequencies.NewService(cfg, log, wsServer, transcriptionStorage, sqliteStorage, clearanceStorage, frequencyStorage, recordingStorage, templateService, usageTracker)
 
-	// Update templating service with frequencies service
-	templateService = templating.NewService(
-		adsbService,
-		weatherService,
-		transcriptionStorage,
-		frequenciesService,
-		cfg,
-		log,
-	)
+	// Delayed audio feeds hold back the map instead of moving transcripts back
+	if cfg.Frequencies.AudioDelayMode == "adsb" {
+		adsbService.SetBroadcastDelay(frequenciesService.MaxAudioDelay)
+	}
+
+	// Transcription history in templates names frequencies
+	templateService.SetFrequenciesService(frequenciesService)
+	// Prompts edited through the API replace their files
+	if err := templateService.SetPromptStorage(sqlite.NewPromptStorage(settingsDB, log)); err != nil {
+		log.Error("Failed to load stored prompt versions", logger.Error(err))
+	}
+	if cfg.Templating.ReloadTemplates {
+		// Prompt edits apply without a restart
+		go templateService.WatchTemplates(ctx, time.Duration(cfg.Templating.ReloadIntervalSecs)*time.Second)
+	}
 
 	// Start frequencies service
 	if err := frequenciesService.Start(ctx); err != nil {
@@ -195,12 +556,32 @@ func main() {
 		os.Exit(1)
 	}
 
+	// Prune data past its retention window
+	retentionJanitor := retention.NewJanitor(retention.Config{
+		Interval:       time.Duration(cfg.Storage.Retention.IntervalMinutes) * time.Minute,
+		Transcriptions: time.Duration(cfg.Storage.Retention.TranscriptionDays) * 24 * time.Hour,
+		Clearances:     time.Duration(cfg.Storage.Retention.ClearanceDays) * 24 * time.Hour,
+		Tracks:         time.Duration(cfg.Storage.Retention.TrackDays) * 24 * time.Hour,
+		Events:         time.Duration(cfg.Storage.Retention.EventDays) * 24 * time.Hour,
+		Audio:          time.Duration(cfg.Recording.RetentionHours) * time.Hour,
+		Export:         cfg.Storage.Retention.Export,
+		ExportDir:      cfg.Storage.Retention.ExportDir,
+	}, sqlite.NewRetentionStorage(sqliteStorage.GetDB(), log), frequenciesService, log)
+	if err := retentionJanitor.Start(ctx); err != nil {
+		log.Error("Failed to start data retention", logger.Error(err))
+		os.Exit(1)
+	}
+
 	// Create ATC Chat service (if enabled)
 	var atcChatService *atcchat.Service
 	if cfg.ATCChat.Enabled {
 		log.Info("Creating ATC Chat service")
 		atcChatService, err = atcchat.NewService(
 			templateService,
+			chatSummaryStorage,
+			chatHistoryStorage,
+			transcriptionStorage,
+			wsServer,
 			cfg,
 			log,
 		)
@@ -215,8 +596,23 @@ func main() {
 		log.Info("ATC Chat service disabled in configuration")
 	}
 
+	// Generate spoken airspace briefings (if enabled)
+	var briefingService *briefing.Service
+	if cfg.Briefing.Enabled {
+		briefingService = briefing.NewService(cfg.Briefing, templateService, wsServer, usageTracker, log)
+		briefingService.Start(ctx)
+	}
+
+	// Reload runtime settings on SIGHUP, when the config file changes, or via PATCH /api/v1/config
+	configReloader := config.NewReloader(loadedConfigPath, cfg, log)
+	configReloader.OnChange(func(settings config.RuntimeSettings) {
+		adsbService.UpdateSettings(settings)
+		weatherService.SetRefreshInterval(settings.Weather.RefreshIntervalMinutes)
+	})
+	go configReloader.Watch(ctx, 5*time.Second)
+
 	// Create API router
-	router := api.NewRouter(adsbService, frequenciesService, weatherService, atcChatService, simulationService, cfg, log, wsServer, transcriptionStorage, clearanceStorage)
+	router := api.NewRouter(adsbService, frequenciesService, weatherService, atcChatService, simulationService, pushService, notifyService, recordsService, statsService, deviationService, briefingService, atisService, templateService, cfg, configReloader, log, wsServer, transcriptionStorage, clearanceStorage, backupStorage, usageTracker, eventsService, terrainService, approachService, noiseService, feederService, movementsService, boardService, sequenceService, occupancyService)
 
 	// --- Setup for multiple HTTP servers ---
 	var servers []*http.Server
@@ -249,6 +645,26 @@ func main() {
 		}(server)
 	}
 
+	// The public view gets its own server with the reduced routes
+	if cfg.Server.Public.Enabled {
+		server := &http.Server{
+			Addr:         fmt.Sprintf("%s:%d", cfg.Server.Public.Host, cfg.Server.Public.Port),
+			Handler:      router.PublicRoutes(),
+			ReadTimeout:  time.Duration(cfg.Server.ReadTimeoutSecs) * time.Second,
+			WriteTimeout: time.Duration(cfg.Server.WriteTimeoutSecs) * time.Second,
+			IdleTimeout:  time.Duration(cfg.Server.IdleTimeoutSecs) * time.Second,
+		}
+		servers = append(servers, server)
+
+		go func(s *http.Server) {
+			log.Info("Starting public HTTP server", logger.String("addr", s.Addr),
+				logger.Int("transcription_delay_seconds", cfg.Server.Public.TranscriptionDelaySeconds))
+			if err := s.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+				log.Error("Public HTTP server error on startup", logger.String("addr", s.Addr), logger.Error(err))
+			}
+		}(server)
+	}
+
 	// Wait for interrupt signal
 	sigCh := make(chan os.Signal, 1)
 	signal.Notify(sigCh, syscall.SIGINT, syscall.SIGTERM)
@@ -261,6 +677,8 @@ func main() {
 	weatherService.Stop()
 	log.Info("Weather service stopped.")
 
+	retentionJanitor.Stop()
+
 	log.Info("Stopping frequencies service...")
 	frequenciesService.Stop()
 	log.Info("Frequencies service stopped.")
@@ -282,8 +700,64 @@ func main() {
 
 	log.Info("Stopping ADS-B service...")
 	adsbService.Stop()
+	if rawReceiver != nil {
+		rawReceiver.Stop()
+	}
 	log.Info("ADS-B service stopped.")
 
+	recordsService.Stop()
+	if briefingService != nil {
+		briefingService.Stop()
+	}
+	if atisService != nil {
+		atisService.Stop()
+	}
+	if deviationService != nil {
+		deviationService.Stop()
+	}
+	if terrainService != nil {
+		terrainService.Stop()
+	}
+	if approachService != nil {
+		approachService.Stop()
+	}
+	if sequenceService != nil {
+		sequenceService.Stop()
+	}
+	if intentService != nil {
+		intentService.Stop()
+	}
+	if occupancyService != nil {
+		occupancyService.Stop()
+	}
+	if noiseService != nil {
+		noiseService.Stop()
+	}
+	if feederService != nil {
+		feederService.Stop()
+	}
+	if boardService != nil {
+		boardService.Stop()
+	}
+	if movementsService != nil {
+		movementsService.Stop()
+	}
+
+	// Write the usage not flushed yet, after everything that records usage has stopped
+	usageTracker.Stop()
+
+	if pushService != nil {
+		log.Info("Stopping push service...")
+		pushService.Stop()
+		log.Info("Push service stopped.")
+	}
+	if notifyService != nil {
+		notifyService.Stop()
+	}
+	if mqttPublisher != nil {
+		mqttPublisher.Stop()
+	}
+
 	// Cancel the main context
 	cancel()
 
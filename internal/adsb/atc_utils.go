@@ -6,6 +6,7 @@ import (
 	"regexp"
 	"strconv"
 	"strings"
+	"sync/atomic"
 	"time"
 
 	"github.com/yegors/co-atc/internal/config"
@@ -21,6 +22,7 @@ const (
 	METERS_PER_NM  = 1852.0  // Meters per nautical mile
 	FEET_PER_NM    = 6076.12 // Feet per nautical mile
 	FEET_PER_METER = 3.28084 // Feet per meter
+	FEET_PER_HPA   = 27.3    // Feet of altitude per hPa of pressure difference near sea level
 
 	// Speed adjustment constants for trajectory prediction
 	SPEED_ADJUST_RANGE_NM = 10.0 // Range in nautical miles where speed adjustments apply
@@ -138,6 +140,18 @@ func Haversine(lat1, lon1, lat2, lon2 float64) float64 {
 	return R * c
 }
 
+// AltitudeMSL returns an aircraft's altitude above mean sea level: its barometric altitude
+// corrected to the QNH set on board when it's sent, else the barometric or GNSS altitude
+func AltitudeMSL(target *ADSBTarget) float64 {
+	if target.AltBaro == 0 {
+		return target.AltGeom
+	}
+	if target.NavQNH >= 900 && target.NavQNH <= 1100 {
+		return target.AltBaro + (target.NavQNH-1013.25)*FEET_PER_HPA
+	}
+	return target.AltBaro
+}
+
 // CalculateBearing calculates the bearing in degrees from point 1 to point 2
 // Returns a value between 0 and 360 degrees (0 = North, 90 = East, etc.)
 func CalculateBearing(lat1, lon1, lat2, lon2 float64) float64 {
@@ -158,6 +172,34 @@ func CalculateBearing(lat1, lon1, lat2, lon2 float64) float64 {
 	return bearing
 }
 
+// DestinationPoint returns the point a distance in NM from a start point along a true bearing,
+// on the same spherical Earth as Haversine
+func DestinationPoint(lat, lon, bearing, distanceNM float64) (float64, float64) {
+	angular := NMToMeters(distanceNM) / 6371000
+	lat1 := lat * math.Pi / 180
+	lon1 := lon * math.Pi / 180
+	theta := bearing * math.Pi / 180
+
+	lat2 := math.Asin(math.Sin(lat1)*math.Cos(angular) + math.Cos(lat1)*math.Sin(angular)*math.Cos(theta))
+	lon2 := lon1 + math.Atan2(math.Sin(theta)*math.Sin(angular)*math.Cos(lat1), math.Cos(angular)-math.Sin(lat1)*math.Sin(lat2))
+	return lat2 * 180 / math.Pi, math.Mod(lon2*180/math.Pi+540, 360) - 180
+}
+
+// HeadingDifference returns the smallest angle between two headings, in degrees (0-180)
+func HeadingDifference(a, b float64) float64 {
+	diff := math.Mod(math.Abs(a-b), 360)
+	if diff > 180 {
+		diff = 360 - diff
+	}
+	return diff
+}
+
+// TurnAngle returns the turn from one heading to another, in degrees from -180 to 180
+// (positive to the right)
+func TurnAngle(from, to float64) float64 {
+	return normalizeAngle(to - from)
+}
+
 // CalculateRelativeBearing calculates the relative bearing from aircraft 1 to aircraft 2
 // based on aircraft 1's heading. Returns a value between 0 and 360 degrees.
 // This is the standard aviation "clock position" relative to the aircraft's heading.
@@ -442,11 +484,41 @@ func IcaoToTailNumber(icao string) (string, error) {
 	}
 }
 
+// WindModel gives the forecast wind at a position and altitude: the direction it blows from
+// in degrees true and its speed in knots
+type WindModel interface {
+	WindAt(lat, lon, altitudeFt float64) (float64, float64, bool)
+}
+
+// windModel holds the WindModel used by trajectory prediction, if any
+var windModel atomic.Value
+
+// windModelHolder lets windModel store a nil or changing WindModel type
+type windModelHolder struct {
+	model WindModel
+}
+
+// SetWindModel sets the winds aloft used to predict where aircraft flying by airspeed will be
+func SetWindModel(model WindModel) {
+	windModel.Store(windModelHolder{model: model})
+}
+
+// windAt returns the forecast wind at a position and altitude, if a wind model is set
+func windAt(lat, lon, altitudeFt float64) (float64, float64, bool) {
+	holder, ok := windModel.Load().(windModelHolder)
+	if !ok || holder.model == nil {
+		return 0, 0, false
+	}
+	return holder.model.WindAt(lat, lon, altitudeFt)
+}
+
 // PredictFuturePositions calculates predicted future positions for an aircraft
 // based on its current position, heading, speed, and vertical rate.
 // It returns an array of predicted positions at 1-minute intervals for the next 5 minutes.
 // The function also adjusts speed based on proximity to the airport (station).
-func PredictFuturePositions(lat, lon, altBaro, trueHeading, magHeading, speedKnots, verticalRateFtMin float64) []Position {
+// When the speed is the true airspeed along the true heading and winds aloft are available, the wind at the
+// aircraft's altitude is added, so predictions follow the ground track rather than the heading.
+func PredictFuturePositions(lat, lon, altBaro, trueHeading, magHeading, speedKnots, verticalRateFtMin float64, trueAirspeed bool) []Position {
 	predictions := make([]Position, 5) // 5 predictions (1-5 minutes ahead)
 	now := time.Now().UTC()
 
@@ -486,6 +558,18 @@ func PredictFuturePositions(lat, lon, altBaro, trueHeading, magHeading, speedKno
 
 	approachingStation := headingDiff < 90
 
+	// Drift of the air mass, in km per minute north and east. The wind direction is where it
+	// blows from, so the air moves the opposite way.
+	var driftNorthKmPerMin, driftEastKmPerMin float64
+	if trueAirspeed {
+		if windDirection, windSpeed, ok := windAt(lat, lon, altBaro); ok {
+			windRad := windDirection * math.Pi / 180.0
+			windKmPerMin := windSpeed * 1.852 / 60
+			driftNorthKmPerMin = -windKmPerMin * math.Cos(windRad)
+			driftEastKmPerMin = -windKmPerMin * math.Sin(windRad)
+		}
+	}
+
 	for i := 0; i < 5; i++ {
 		minutesAhead := float64(i + 1)
 
@@ -494,8 +578,11 @@ func PredictFuturePositions(lat, lon, altBaro, trueHeading, magHeading, speedKno
 		adjustedSpeedKmPerMin := speedKmPerMin
 
 		// Calculate new position
-		latChange := (adjustedSpeedKmPerMin * minutesAhead * math.Cos(headingRad)) / latKmPerDegree
-		lonChange := (adjustedSpeedKmPerMin * minutesAhead * math.Sin(headingRad)) / lonKmPerDegree
+		northKmPerMin := adjustedSpeedKmPerMin*math.Cos(headingRad) + driftNorthKmPerMin
+		eastKmPerMin := adjustedSpeedKmPerMin*math.Sin(headingRad) + driftEastKmPerMin
+		latChange := (northKmPerMin * minutesAhead) / latKmPerDegree
+		lonChange := (eastKmPerMin * minutesAhead) / lonKmPerDegree
+		groundSpeed := math.Hypot(northKmPerMin, eastKmPerMin) * 60 / 1.852
 
 		newLat := lat + latChange
 		newLon := lon + lonChange
@@ -532,6 +619,9 @@ func PredictFuturePositions(lat, lon, altBaro, trueHeading, magHeading, speedKno
 			}
 		}
 
+		// Headwinds and tailwinds change the ground speed by the same amount at the adjusted speed
+		windEffect := groundSpeed - speedKnots
+
 		// Create prediction
 		timestamp := now.Add(time.Duration(minutesAhead) * time.Minute)
 
@@ -540,7 +630,7 @@ func PredictFuturePositions(lat, lon, altBaro, trueHeading, magHeading, speedKno
 			Lon:         newLon,
 			Altitude:    newAltitude,
 			SpeedTrue:   adjustedSpeed,
-			SpeedGS:     adjustedSpeed,
+			SpeedGS:     adjustedSpeed + windEffect,
 			TrueHeading: trueHeading, // Assuming constant true heading
 			MagHeading:  magHeading,  // Assuming constant magnetic heading
 			Timestamp:   timestamp,
@@ -612,13 +702,20 @@ type RunwayThreshold struct {
 	Longitude float64 `json:"longitude"`
 }
 
+// RunwayThresholdData is a runway end from the runway data. The coordinates are those of the
+// end of the runway; with a displaced threshold, landings cross the threshold further along.
+type RunwayThresholdData struct {
+	Latitude               float64 `json:"latitude"`
+	Longitude              float64 `json:"longitude"`
+	ElevationFeet          float64 `json:"elevation_ft,omitempty"`           // Threshold elevation; 0 when unknown
+	DisplacedThresholdFeet float64 `json:"displaced_threshold_ft,omitempty"` // From the runway end to the landing threshold
+	MagneticHeading        float64 `json:"magnetic_heading,omitempty"`       // Published heading; 0 when unknown
+}
+
 // RunwayData represents the structure of runway data from runways.json
 type RunwayData struct {
-	Airport          string                         `json:"airport"`
-	RunwayThresholds map[string]map[string]struct { // e.g., "05-23" -> "05" -> {lat, lon}
-		Latitude  float64 `json:"latitude"`
-		Longitude float64 `json:"longitude"`
-	} `json:"runway_thresholds"`
+	Airport          string                                    `json:"airport"`
+	RunwayThresholds map[string]map[string]RunwayThresholdData `json:"runway_thresholds"` // e.g., "05-23" -> "05"
 }
 
 // DetectRunwayApproach determines if aircraft is on approach to any runway
@@ -629,8 +726,18 @@ func DetectRunwayApproach(lat, lon, heading, altitude float64, runways RunwayDat
 	// Check each runway threshold
 	for runwayPair, thresholds := range runways.RunwayThresholds {
 		for thresholdID, threshold := range thresholds {
+			// Landings cross the threshold past the end of the runway when it's displaced, so
+			// the approach is measured from there
+			oppositeThresholdID := getOppositeThreshold(thresholdID, runwayPair)
+			oppositeThreshold, exists := thresholds[oppositeThresholdID]
+			if !exists {
+				// If we can't find opposite threshold, skip this one
+				continue
+			}
+			thresholdLat, thresholdLon := LandingThreshold(threshold, oppositeThreshold)
+
 			// Calculate distance to threshold
-			distanceMeters := Haversine(lat, lon, threshold.Latitude, threshold.Longitude)
+			distanceMeters := Haversine(lat, lon, thresholdLat, thresholdLon)
 			distanceNM := MetersToNM(distanceMeters)
 
 			// Skip if too far from threshold
@@ -639,15 +746,8 @@ func DetectRunwayApproach(lat, lon, heading, altitude float64, runways RunwayDat
 			}
 
 			// Calculate runway heading (from opposite threshold to this threshold)
-			var runwayHeading float64
-			oppositeThresholdID := getOppositeThreshold(thresholdID, runwayPair)
-			if oppositeThreshold, exists := thresholds[oppositeThresholdID]; exists {
-				runwayHeading = CalculateBearing(oppositeThreshold.Latitude, oppositeThreshold.Longitude,
-					threshold.Latitude, threshold.Longitude)
-			} else {
-				// If we can't find opposite threshold, skip this one
-				continue
-			}
+			runwayHeading := CalculateBearing(oppositeThreshold.Latitude, oppositeThreshold.Longitude,
+				threshold.Latitude, threshold.Longitude)
 
 			// Calculate heading alignment
 			headingDiff := math.Abs(heading - runwayHeading)
@@ -663,8 +763,8 @@ func DetectRunwayApproach(lat, lon, heading, altitude float64, runways RunwayDat
 			// Calculate distance from runway centerline
 			runwayThreshold := RunwayThreshold{
 				ID:        thresholdID,
-				Latitude:  threshold.Latitude,
-				Longitude: threshold.Longitude,
+				Latitude:  thresholdLat,
+				Longitude: thresholdLon,
 			}
 			centerlineDistance := CalculateRunwayCenterlineDistance(lat, lon, runwayThreshold, runwayHeading)
 
@@ -759,26 +859,28 @@ func DetectRunwayDeparture(lat, lon, heading float64, runways RunwayData, statio
 	// Check each runway threshold
 	for runwayPair, thresholds := range runways.RunwayThresholds {
 		for thresholdID, threshold := range thresholds {
-			// Calculate distance from threshold
+			oppositeThresholdID := getOppositeThreshold(thresholdID, runwayPair)
+			oppositeThreshold, exists := thresholds[oppositeThresholdID]
+			if !exists {
+				// If we can't find opposite threshold, skip this one
+				continue
+			}
+
+			// Calculate distance from threshold; takeoffs may start from the end of the
+			// runway, displaced threshold or not
 			distanceMeters := Haversine(lat, lon, threshold.Latitude, threshold.Longitude)
 			distanceNM := MetersToNM(distanceMeters)
 
-			// Skip if too far from threshold (use larger tolerance for departures)
+			// Skip if too far past the far end of the runway, where departures are once
+			// airborne (use larger tolerance for departures)
 			maxDepartureDistance := float64(config.ApproachMaxDistanceNM) * 1.5 // 1.5x approach distance
-			if distanceNM > maxDepartureDistance {
+			if MetersToNM(Haversine(lat, lon, oppositeThreshold.Latitude, oppositeThreshold.Longitude)) > maxDepartureDistance {
 				continue
 			}
 
 			// Calculate runway heading (from this threshold outbound)
-			var runwayHeading float64
-			oppositeThresholdID := getOppositeThreshold(thresholdID, runwayPair)
-			if oppositeThreshold, exists := thresholds[oppositeThresholdID]; exists {
-				runwayHeading = CalculateBearing(threshold.Latitude, threshold.Longitude,
-					oppositeThreshold.Latitude, oppositeThreshold.Longitude)
-			} else {
-				// If we can't find opposite threshold, skip this one
-				continue
-			}
+			runwayHeading := CalculateBearing(threshold.Latitude, threshold.Longitude,
+				oppositeThreshold.Latitude, oppositeThreshold.Longitude)
 
 			// Calculate heading alignment (more lenient for departures)
 			headingDiff := math.Abs(heading - runwayHeading)
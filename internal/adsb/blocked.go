@@ -0,0 +1,113 @@
+package adsb
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/yegors/co-atc/internal/config"
+	"github.com/yegors/co-atc/pkg/logger"
+)
+
+// Database flags readsb and aggregators send as dbFlags
+const (
+	dbFlagMilitary = 1
+	dbFlagPIA      = 4 // Privacy ICAO Address, a temporary address hiding the aircraft's own
+	dbFlagLADD     = 8 // Limiting Aircraft Data Displayed: the owner asked not to be tracked
+)
+
+// blocklist holds the aircraft whose owners asked not to be tracked publicly
+type blocklist struct {
+	hexCodes      map[string]bool // Lower case
+	registrations map[string]bool // Upper case, without dashes
+}
+
+// SetBlocklist sets the aircraft flagged as blocked besides those sent with the LADD or PIA
+// flag, reading the list file if one is configured. Must be called before Start.
+func (s *Service) SetBlocklist(cfg config.PrivacyConfig) error {
+	list := blocklist{
+		hexCodes:      make(map[string]bool),
+		registrations: make(map[string]bool),
+	}
+	for _, hex := range cfg.BlockedHexCodes {
+		list.hexCodes[strings.ToLower(hex)] = true
+	}
+	for _, registration := range cfg.BlockedRegistrations {
+		list.registrations[normalizeRegistration(registration)] = true
+	}
+	if cfg.BlockedListPath != "" {
+		if err := list.load(cfg.BlockedListPath); err != nil {
+			return err
+		}
+	}
+
+	s.blocked = list
+	s.logger.Info("Loaded blocked aircraft",
+		logger.Int("hex_codes", len(list.hexCodes)),
+		logger.Int("registrations", len(list.registrations)))
+	return nil
+}
+
+// load adds the entries of a list file: one hex code or registration per line, with blank
+// lines and lines starting with # skipped. Entries of 6 hex digits are taken as hex codes.
+func (b *blocklist) load(path string) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("failed to open blocked list: %w", err)
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		entry := strings.TrimSpace(scanner.Text())
+		if entry == "" || strings.HasPrefix(entry, "#") {
+			continue
+		}
+		if _, err := strconv.ParseUint(entry, 16, 32); err == nil && len(entry) == 6 {
+			b.hexCodes[strings.ToLower(entry)] = true
+		} else {
+			b.registrations[normalizeRegistration(entry)] = true
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("failed to read blocked list: %w", err)
+	}
+	return nil
+}
+
+// contains reports whether an aircraft is on the blocklist
+func (b *blocklist) contains(hex, registration string) bool {
+	if b.hexCodes[strings.ToLower(hex)] {
+		return true
+	}
+	return registration != "" && b.registrations[normalizeRegistration(registration)]
+}
+
+// classifyAircraft flags military aircraft, by ICAO address or the source's military flag, and
+// blocked aircraft, by the LADD and PIA flags or the blocklist
+func (s *Service) classifyAircraft(aircraft []*Aircraft) {
+	for _, a := range aircraft {
+		if a == nil {
+			continue
+		}
+		flags, registration := 0, ""
+		if a.ADSB != nil {
+			flags, registration = a.ADSB.DBFlags, a.ADSB.Registration
+		}
+		a.Military = flags&dbFlagMilitary != 0 || IsMilitaryHex(a.Hex)
+		a.Blocked = flags&(dbFlagPIA|dbFlagLADD) != 0 || s.blocked.contains(a.Hex, registration)
+	}
+}
+
+// ClassifyHex reports whether an aircraft is military, by ICAO address, and blocked, by the
+// blocklist, for aircraft that may no longer be tracked. Aircraft that are tracked also
+// carry the flags sent by the source.
+func (s *Service) ClassifyHex(hex string) (military, blocked bool) {
+	if aircraft, found := s.storage.GetByHex(hex); found && aircraft != nil {
+		s.classifyAircraft([]*Aircraft{aircraft})
+		return aircraft.Military, aircraft.Blocked
+	}
+	return IsMilitaryHex(hex), s.blocked.contains(hex, "")
+}
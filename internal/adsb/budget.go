@@ -0,0 +1,204 @@
+package adsb
+
+import (
+	"math"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/yegors/co-atc/internal/config"
+)
+
+// Budget mode defaults
+const (
+	DefaultBudgetMaxPredictions      = 25 // Aircraft nearest the station that get future positions
+	DefaultBudgetPositionSampleEvery = 3  // Poll cycles per stored position of an aircraft
+)
+
+// Coarse change thresholds used by the change detector in budget mode
+const (
+	budgetPositionThresholdDeg     = 0.0005 // About 50 m
+	budgetAltitudeThresholdFt      = 100.0
+	budgetTrackThresholdDeg        = 2.0
+	budgetSpeedThresholdKts        = 2.0
+	budgetVerticalRateThresholdFpm = 200.0
+)
+
+// BudgetStats shows how much work budget mode has shed since startup
+type BudgetStats struct {
+	Enabled             bool    `json:"enabled"`
+	MaxPredictions      int     `json:"max_predictions"`
+	PositionSampleEvery int     `json:"position_sample_every"`
+	Cycles              int64   `json:"cycles"`
+	PredictionsSkipped  int64   `json:"predictions_skipped"`
+	PositionsSampledOut int64   `json:"positions_sampled_out"`
+	ChangesSuppressed   int64   `json:"changes_suppressed"`
+	LastCycleAircraft   int     `json:"last_cycle_aircraft"`
+	LastCycleMs         float64 `json:"last_cycle_ms"`
+}
+
+// processingBudget caps the work of a poll cycle on constrained hosts like a Raspberry Pi:
+// predictions only for the nearest aircraft, and positions stored every few cycles
+type processingBudget struct {
+	enabled        bool
+	maxPredictions int
+	sampleEvery    int
+	positionCycles map[string]int // Cycles since each aircraft's position was last stored, only used by the fetch loop
+	stats          BudgetStats
+	mu             sync.Mutex
+}
+
+// newProcessingBudget creates the processing budget for the ADS-B configuration
+func newProcessingBudget(adsbCfg config.ADSBConfig) *processingBudget {
+	b := &processingBudget{
+		enabled:        adsbCfg.BudgetMode,
+		maxPredictions: adsbCfg.BudgetMaxPredictions,
+		sampleEvery:    adsbCfg.BudgetPositionSampleEvery,
+		positionCycles: make(map[string]int),
+	}
+	if b.maxPredictions <= 0 {
+		b.maxPredictions = DefaultBudgetMaxPredictions
+	}
+	if b.sampleEvery <= 0 {
+		b.sampleEvery = DefaultBudgetPositionSampleEvery
+	}
+	b.stats = BudgetStats{
+		Enabled:             b.enabled,
+		MaxPredictions:      b.maxPredictions,
+		PositionSampleEvery: b.sampleEvery,
+	}
+	return b
+}
+
+// predictionSet returns the aircraft that get future positions this cycle: the ones nearest
+// the station. Nil means every aircraft.
+func (b *processingBudget) predictionSet(targets []ADSBTarget, stationLat, stationLon float64) map[string]bool {
+	if !b.enabled || len(targets) <= b.maxPredictions {
+		return nil
+	}
+
+	type ranked struct {
+		hex      string
+		distance float64
+	}
+	candidates := make([]ranked, 0, len(targets))
+	for _, t := range targets {
+		if t.Lat == 0 && t.Lon == 0 {
+			continue
+		}
+		candidates = append(candidates, ranked{hex: t.Hex, distance: Haversine(stationLat, stationLon, t.Lat, t.Lon)})
+	}
+	sort.Slice(candidates, func(i, j int) bool {
+		return candidates[i].distance < candidates[j].distance
+	})
+
+	set := make(map[string]bool, b.maxPredictions)
+	for i := 0; i < len(candidates) && i < b.maxPredictions; i++ {
+		set[candidates[i].hex] = true
+	}
+	return set
+}
+
+// skipPrediction counts a prediction that was shed
+func (b *processingBudget) skipPrediction() {
+	b.mu.Lock()
+	b.stats.PredictionsSkipped++
+	b.mu.Unlock()
+}
+
+// samplePositions returns the aircraft whose position is stored this cycle. Aircraft with a
+// ground state transition are always stored. Nil means every aircraft.
+func (b *processingBudget) samplePositions(aircraft []*Aircraft, transitions []PhaseChangeInsert) map[string]bool {
+	if !b.enabled {
+		return nil
+	}
+
+	forced := make(map[string]bool, len(transitions))
+	for _, change := range transitions {
+		forced[change.Hex] = true
+	}
+
+	// Rebuilding the counters each cycle forgets aircraft that are gone
+	cycles := make(map[string]int, len(aircraft))
+	store := make(map[string]bool, len(aircraft))
+	sampledOut := 0
+	for _, a := range aircraft {
+		count, seen := b.positionCycles[a.Hex]
+		if !seen || forced[a.Hex] || count+1 >= b.sampleEvery {
+			store[a.Hex] = true
+			cycles[a.Hex] = 0
+			continue
+		}
+		cycles[a.Hex] = count + 1
+		sampledOut++
+	}
+	b.positionCycles = cycles
+
+	b.mu.Lock()
+	b.stats.PositionsSampledOut += int64(sampledOut)
+	b.mu.Unlock()
+
+	return store
+}
+
+// recordCycle records the size and duration of a poll cycle
+func (b *processingBudget) recordCycle(aircraftCount int, duration time.Duration, changesSuppressed int64) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.stats.Cycles++
+	b.stats.LastCycleAircraft = aircraftCount
+	b.stats.LastCycleMs = float64(duration.Microseconds()) / 1000
+	b.stats.ChangesSuppressed = changesSuppressed
+}
+
+// snapshot returns a copy of the statistics
+func (b *processingBudget) snapshot() BudgetStats {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.stats
+}
+
+// BudgetStats returns how much work budget mode has shed
+func (s *Service) BudgetStats() BudgetStats {
+	return s.budget.snapshot()
+}
+
+// hasSignificantChanges is the coarse version of hasAnyChanges used in budget mode: small
+// movements and last_seen ticks aren't worth a broadcast, state changes still are
+func (cd *ChangeDetector) hasSignificantChanges(previous, current *Aircraft) bool {
+	if previous.ADSB != nil && current.ADSB != nil {
+		p, c := previous.ADSB, current.ADSB
+		if math.Abs(p.Lat-c.Lat) >= budgetPositionThresholdDeg || math.Abs(p.Lon-c.Lon) >= budgetPositionThresholdDeg {
+			return true
+		}
+		if math.Abs(p.AltBaro-c.AltBaro) >= budgetAltitudeThresholdFt {
+			return true
+		}
+		if trackDelta := math.Abs(math.Mod(p.Track-c.Track+540, 360) - 180); trackDelta >= budgetTrackThresholdDeg {
+			return true
+		}
+		if math.Abs(p.GS-c.GS) >= budgetSpeedThresholdKts || math.Abs(p.TAS-c.TAS) >= budgetSpeedThresholdKts {
+			return true
+		}
+		if math.Abs(p.BaroRate-c.BaroRate) >= budgetVerticalRateThresholdFpm {
+			return true
+		}
+	} else if (previous.ADSB == nil) != (current.ADSB == nil) {
+		return true
+	}
+
+	return previous.Flight != current.Flight ||
+		previous.Status != current.Status ||
+		previous.OnGround != current.OnGround ||
+		!phasesEqual(previous.Phase, current.Phase) ||
+		!intentsEqual(previous.Intent, current.Intent)
+}
+
+// phasesEqual compares the current phase of two aircraft
+func phasesEqual(a, b *PhaseData) bool {
+	if a == nil || b == nil || len(a.Current) == 0 || len(b.Current) == 0 {
+		return (a == nil || len(a.Current) == 0) == (b == nil || len(b.Current) == 0)
+	}
+	return a.Current[0].Phase == b.Current[0].Phase
+}
@@ -0,0 +1,169 @@
+package adsb
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// callsignNumberPadding matches an airline callsign whose flight number has leading zeros (ACA0123)
+var callsignNumberPadding = regexp.MustCompile(`^([A-Z]{3})0+([0-9][0-9A-Z]*)$`)
+
+// nonCallsignChars matches everything that isn't part of a normalized callsign
+var nonCallsignChars = regexp.MustCompile(`[^A-Z0-9]`)
+
+// CallsignEntry links an active aircraft's callsign, hex and registration
+type CallsignEntry struct {
+	Callsign     string    `json:"callsign"`
+	Hex          string    `json:"hex"`
+	Registration string    `json:"registration,omitempty"`
+	LastSeen     time.Time `json:"last_seen"`
+}
+
+// NormalizeCallsign reduces a callsign or registration to the form the registry compares:
+// upper case, without padding, spaces or dashes, and without leading zeros in an airline
+// flight number. "aca 0123" and "ACA123 " both become "ACA123", "C-FABC" becomes "CFABC".
+func NormalizeCallsign(callsign string) string {
+	normalized := nonCallsignChars.ReplaceAllString(strings.ToUpper(callsign), "")
+	return callsignNumberPadding.ReplaceAllString(normalized, "$1$2")
+}
+
+// CallsignRegistry maps the callsigns, hex codes and registrations of active aircraft to each
+// other, so transcriptions, clearances and chat all resolve a callsign to the same aircraft
+type CallsignRegistry struct {
+	byCallsign     map[string]*CallsignEntry // Normalized callsign -> entry
+	byRegistration map[string]*CallsignEntry // Normalized registration -> entry
+	byHex          map[string]*CallsignEntry // Lower case hex -> entry
+	mu             sync.RWMutex
+}
+
+// NewCallsignRegistry creates an empty callsign registry
+func NewCallsignRegistry() *CallsignRegistry {
+	return &CallsignRegistry{
+		byCallsign:     make(map[string]*CallsignEntry),
+		byRegistration: make(map[string]*CallsignEntry),
+		byHex:          make(map[string]*CallsignEntry),
+	}
+}
+
+// Update records the aircraft of a poll cycle and forgets aircraft not seen within maxAge.
+// When a callsign moves to another hex (e.g. a swapped transponder), the latest one wins.
+func (r *CallsignRegistry) Update(aircraft []*Aircraft, maxAge time.Duration) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now().UTC()
+	for _, a := range aircraft {
+		if a == nil || a.Hex == "" {
+			continue
+		}
+
+		entry := &CallsignEntry{
+			Callsign: strings.TrimSpace(a.Flight),
+			Hex:      strings.ToLower(a.Hex),
+			LastSeen: now,
+		}
+		if a.ADSB != nil {
+			entry.Registration = strings.TrimSpace(a.ADSB.Registration)
+		}
+
+		// Keep what an earlier cycle learned if this one doesn't carry it
+		if previous, ok := r.byHex[entry.Hex]; ok {
+			if entry.Callsign == "" {
+				entry.Callsign = previous.Callsign
+			}
+			if entry.Registration == "" {
+				entry.Registration = previous.Registration
+			}
+			r.remove(previous)
+		}
+
+		r.byHex[entry.Hex] = entry
+		if key := NormalizeCallsign(entry.Callsign); key != "" {
+			if other, ok := r.byCallsign[key]; ok && other.Hex != entry.Hex {
+				r.remove(other)
+			}
+			r.byCallsign[key] = entry
+		}
+		if key := NormalizeCallsign(entry.Registration); key != "" {
+			r.byRegistration[key] = entry
+		}
+	}
+
+	for _, entry := range r.byHex {
+		if now.Sub(entry.LastSeen) > maxAge {
+			r.remove(entry)
+		}
+	}
+}
+
+// remove drops an entry from every index. Must be called with the lock held.
+func (r *CallsignRegistry) remove(entry *CallsignEntry) {
+	if r.byHex[entry.Hex] == entry {
+		delete(r.byHex, entry.Hex)
+	}
+	if key := NormalizeCallsign(entry.Callsign); r.byCallsign[key] == entry {
+		delete(r.byCallsign, key)
+	}
+	if key := NormalizeCallsign(entry.Registration); r.byRegistration[key] == entry {
+		delete(r.byRegistration, key)
+	}
+}
+
+// Lookup finds the active aircraft a callsign, registration or hex code refers to
+func (r *CallsignRegistry) Lookup(identifier string) (CallsignEntry, bool) {
+	key := NormalizeCallsign(identifier)
+	if key == "" {
+		return CallsignEntry{}, false
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	if entry, ok := r.byCallsign[key]; ok {
+		return *entry, true
+	}
+	if entry, ok := r.byRegistration[key]; ok {
+		return *entry, true
+	}
+	if entry, ok := r.byHex[strings.ToLower(key)]; ok {
+		return *entry, true
+	}
+	return CallsignEntry{}, false
+}
+
+// Canonical returns the callsign, or failing that the registration, of the active aircraft
+// an identifier refers to. If no active aircraft matches, it returns the ICAO form of a
+// spoken callsign or the normalized identifier.
+func (r *CallsignRegistry) Canonical(identifier string) string {
+	if entry, ok := r.Correlate(identifier); ok {
+		if entry.Callsign != "" {
+			return NormalizeCallsign(entry.Callsign)
+		}
+		return NormalizeCallsign(entry.Registration)
+	}
+	if spoken := SpokenCallsign(identifier); spoken != "" {
+		return spoken
+	}
+	return NormalizeCallsign(identifier)
+}
+
+// Entries returns every active aircraft, sorted by callsign
+func (r *CallsignRegistry) Entries() []CallsignEntry {
+	r.mu.RLock()
+	entries := make([]CallsignEntry, 0, len(r.byHex))
+	for _, entry := range r.byHex {
+		entries = append(entries, *entry)
+	}
+	r.mu.RUnlock()
+
+	sort.Slice(entries, func(i, j int) bool {
+		if entries[i].Callsign != entries[j].Callsign {
+			return entries[i].Callsign < entries[j].Callsign
+		}
+		return entries[i].Hex < entries[j].Hex
+	})
+	return entries
+}
@@ -9,6 +9,8 @@ import (
 // ChangeDetector tracks aircraft changes between polling cycles
 type ChangeDetector struct {
 	previousAircraft map[string]*Aircraft
+	coarse           bool  // Budget mode: ignore small movements and last_seen ticks
+	suppressed       int64 // Updates not broadcast because of coarse detection
 	logger           *logger.Logger
 }
 
@@ -25,6 +27,8 @@ type AircraftChange struct {
 	Type     string // "added", "updated", "removed"
 	Aircraft *Aircraft
 	Hex      string
+	// CorrelationID identifies the poll cycle that produced the change
+	CorrelationID string
 	// Removed Changes field - we now always send full aircraft data
 }
 
@@ -43,6 +47,14 @@ func (cd *ChangeDetector) DetectChanges(currentAircraft []*Aircraft) []AircraftC
 		if previous, exists := cd.previousAircraft[hex]; exists {
 			// Check for ANY updates (no thresholds)
 			if cd.hasAnyChanges(previous, current) {
+				if cd.coarse && !cd.hasSignificantChanges(previous, current) {
+					// Keep comparing against what clients last received, so slow drift
+					// is still broadcast once it adds up
+					cd.suppressed++
+					currentMap[hex] = previous
+					continue
+				}
+
 				changes = append(changes, AircraftChange{
 					Type:     "updated",
 					Aircraft: current,
@@ -74,6 +86,60 @@ func (cd *ChangeDetector) DetectChanges(currentAircraft []*Aircraft) []AircraftC
 	return changes
 }
 
+// Suppressed returns how many updates coarse detection has not broadcast
+func (cd *ChangeDetector) Suppressed() int64 {
+	return cd.suppressed
+}
+
+// coalesceChanges merges the changes from consecutive poll cycles into one change
+// per aircraft, keeping the most recent aircraft state. An aircraft added and then
+// removed before the batch is sent is dropped entirely.
+func coalesceChanges(cycles ...[]AircraftChange) []AircraftChange {
+	if len(cycles) == 1 {
+		return cycles[0]
+	}
+
+	merged := make(map[string]AircraftChange)
+	var order []string
+
+	for _, changes := range cycles {
+		for _, change := range changes {
+			previous, exists := merged[change.Hex]
+			if !exists {
+				merged[change.Hex] = change
+				order = append(order, change.Hex)
+				continue
+			}
+
+			switch {
+			case previous.Type == "added" && change.Type == "removed":
+				// Clients never saw the aircraft
+				delete(merged, change.Hex)
+			case previous.Type == "added" && change.Type == "updated":
+				change.Type = "added"
+				merged[change.Hex] = change
+			case previous.Type == "removed" && change.Type == "added":
+				// Clients still have the aircraft from before the removal
+				change.Type = "updated"
+				merged[change.Hex] = change
+			default:
+				merged[change.Hex] = change
+			}
+		}
+	}
+
+	result := make([]AircraftChange, 0, len(merged))
+	for _, hex := range order {
+		if change, ok := merged[hex]; ok {
+			result = append(result, change)
+			// Only emit each aircraft once, even if it was dropped and re-added
+			delete(merged, hex)
+		}
+	}
+
+	return result
+}
+
 // hasAnyChanges compares two aircraft and returns true if ANY field changed (no thresholds)
 func (cd *ChangeDetector) hasAnyChanges(previous, current *Aircraft) bool {
 	// Compare ADSB data - detect ANY change, no matter how small
@@ -140,6 +206,11 @@ func (cd *ChangeDetector) hasAnyChanges(previous, current *Aircraft) bool {
 		return true
 	}
 
+	// Compare inferred intent
+	if !intentsEqual(previous.Intent, current.Intent) {
+		return true
+	}
+
 	// Compare distance - ANY change
 	if (previous.Distance == nil) != (current.Distance == nil) ||
 		(previous.Distance != nil && current.Distance != nil && *previous.Distance != *current.Distance) {
@@ -8,6 +8,7 @@ import (
 	"net/http"
 	"time"
 
+	"github.com/yegors/co-atc/internal/config"
 	"github.com/yegors/co-atc/pkg/logger"
 )
 
@@ -24,6 +25,9 @@ type Client struct {
 	stationLat        float64
 	stationLon        float64
 	searchRadiusNM    float64
+	raw               *RawReceiver    // Decodes the "raw" source; nil for the others
+	poll              *externalPoller // Throttles the "external" source; nil requests every poll cycle
+	uat               *uatSource      // dump978 aircraft merged into every poll cycle; nil for none
 	logger            *logger.Logger
 }
 
@@ -56,12 +60,58 @@ func NewClient(
 	}
 }
 
-// FetchData fetches ADS-B data from the configured source
+// SetRawReceiver sets the receiver the "raw" source reads from
+func (c *Client) SetRawReceiver(raw *RawReceiver) {
+	c.raw = raw
+}
+
+// SetExternalPolling throttles requests to the external API with the ADS-B configuration's
+// polling settings
+func (c *Client) SetExternalPolling(adsbCfg config.ADSBConfig) {
+	c.poll = newExternalPoller(adsbCfg)
+}
+
+// ExternalPollStats returns how the external API is being polled, or false if it isn't
+// throttled
+func (c *Client) ExternalPollStats() (ExternalPollStats, bool) {
+	if c == nil || c.poll == nil {
+		return ExternalPollStats{}, false
+	}
+	return c.poll.Stats(), true
+}
+
+// RawReceiver returns the receiver of the "raw" source, or nil for the other sources
+func (c *Client) RawReceiver() *RawReceiver {
+	if c == nil {
+		return nil
+	}
+	return c.raw
+}
+
+// FetchData fetches ADS-B data from the configured source, with the UAT source's aircraft
+// merged in if there's one
 func (c *Client) FetchData(ctx context.Context) (*RawAircraftData, error) {
+	data, err := c.fetchSourceData(ctx)
+	if err != nil || c.uat == nil {
+		return data, err
+	}
+	return c.withUATData(ctx, data), nil
+}
+
+// fetchSourceData fetches ADS-B data from the configured source
+func (c *Client) fetchSourceData(ctx context.Context) (*RawAircraftData, error) {
 	if c.sourceType == "local" {
 		return c.fetchLocalData(ctx)
 	} else if c.sourceType == "external" {
-		return c.fetchExternalData(ctx)
+		return c.fetchExternalPolled(ctx)
+	} else if c.sourceType == "raw" {
+		if c.raw == nil {
+			return nil, fmt.Errorf("raw source has no receiver")
+		}
+		return c.raw.Snapshot(), nil
+	} else if c.sourceType == "none" {
+		// No receiver; only simulated and generated traffic
+		return &RawAircraftData{Now: float64(time.Now().Unix()), Aircraft: []ADSBTarget{}}, nil
 	}
 	return nil, fmt.Errorf("unknown source type: %s", c.sourceType)
 }
@@ -118,6 +168,26 @@ func (c *Client) fetchLocalData(ctx context.Context) (*RawAircraftData, error) {
 	return &data, nil
 }
 
+// fetchExternalPolled requests the external API when the poller allows it, and otherwise
+// returns its last response
+func (c *Client) fetchExternalPolled(ctx context.Context) (*RawAircraftData, error) {
+	if c.poll == nil {
+		return c.fetchExternalData(ctx)
+	}
+
+	now := time.Now()
+	if !c.poll.begin(now) {
+		return c.poll.cachedData(now), nil
+	}
+	data, err := c.fetchExternalData(ctx)
+	if err != nil {
+		c.poll.failed(now, err)
+		return nil, err
+	}
+	c.poll.succeeded(now, data)
+	return data, nil
+}
+
 // fetchExternalData fetches data from the external API
 func (c *Client) fetchExternalData(ctx context.Context) (*RawAircraftData, error) {
 	// Format URL with station coordinates and search radius
@@ -149,12 +219,21 @@ func (c *Client) fetchExternalData(ctx context.Context) (*RawAircraftData, error
 	}
 	defer resp.Body.Close()
 
+	if c.poll != nil {
+		c.poll.observeQuota(resp.Header)
+	}
+
 	// Check response status
 	if resp.StatusCode != http.StatusOK {
+		statusErr := &ExternalStatusError{
+			StatusCode: resp.StatusCode,
+			RetryAfter: parseRetryAfter(resp.Header.Get("Retry-After"), time.Now()),
+		}
 		c.logger.Error("Unexpected status code",
 			logger.Int("status_code", resp.StatusCode),
+			logger.Duration("retry_after", statusErr.RetryAfter),
 			logger.String("url", url))
-		return nil, fmt.Errorf("unexpected status code: %d", resp.StatusCode)
+		return nil, statusErr
 	}
 
 	// Read response body
@@ -233,6 +312,9 @@ func (c *Client) fetchExternalData(ctx context.Context) (*RawAircraftData, error
 func (c *Client) UpdateStationCoords(lat, lon float64) {
 	c.stationLat = lat
 	c.stationLon = lon
+	if c.poll != nil {
+		c.poll.reset() // The last response was for the old location
+	}
 
 	c.logger.Debug("Station coordinates updated",
 		logger.Float64("latitude", lat),
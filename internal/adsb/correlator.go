@@ -0,0 +1,202 @@
+package adsb
+
+import (
+	"regexp"
+	"strings"
+)
+
+// minAbbreviatedRegistration is the fewest characters an abbreviated registration is matched
+// on ("Alpha Bravo Charlie" for C-FABC)
+const minAbbreviatedRegistration = 3
+
+// spokenWordChars matches everything that isn't part of a spoken word or number
+var spokenWordChars = regexp.MustCompile(`[^a-z0-9 ]`)
+
+// telephonyDesignators maps telephony designators, lower case without spaces, back to ICAO
+// airline designators ("aircanada" is "ACA")
+var telephonyDesignators = func() map[string]string {
+	designators := make(map[string]string, len(airlineTelephony))
+	for designator, telephony := range airlineTelephony {
+		designators[strings.ToLower(strings.ReplaceAll(telephony, " ", ""))] = designator
+	}
+	return designators
+}()
+
+// maxTelephonyWords is the most words in a telephony designator ("Royal Air Maroc")
+const maxTelephonyWords = 3
+
+// phoneticLetters maps the ICAO spelling alphabet to letters
+var phoneticLetters = map[string]byte{
+	"alpha": 'A', "alfa": 'A', "bravo": 'B', "charlie": 'C', "delta": 'D', "echo": 'E',
+	"foxtrot": 'F', "golf": 'G', "hotel": 'H', "india": 'I', "juliet": 'J', "juliett": 'J',
+	"kilo": 'K', "lima": 'L', "mike": 'M', "november": 'N', "oscar": 'O', "papa": 'P',
+	"quebec": 'Q', "romeo": 'R', "sierra": 'S', "tango": 'T', "uniform": 'U', "victor": 'V',
+	"whiskey": 'W', "whisky": 'W', "xray": 'X', "yankee": 'Y', "zulu": 'Z',
+}
+
+// Spoken numbers, including the ICAO pronunciations ("tree", "fife", "niner")
+var (
+	spokenDigits = map[string]byte{
+		"zero": '0', "oh": '0', "o": '0', "one": '1', "two": '2', "three": '3', "tree": '3',
+		"four": '4', "fower": '4', "five": '5', "fife": '5', "six": '6', "seven": '7',
+		"eight": '8', "nine": '9', "niner": '9',
+	}
+	spokenTeens = map[string]string{
+		"ten": "10", "eleven": "11", "twelve": "12", "thirteen": "13", "fourteen": "14",
+		"fifteen": "15", "sixteen": "16", "seventeen": "17", "eighteen": "18", "nineteen": "19",
+	}
+	spokenTens = map[string]byte{
+		"twenty": '2', "thirty": '3', "forty": '4', "fifty": '5', "sixty": '6', "seventy": '7',
+		"eighty": '8', "ninety": '9',
+	}
+)
+
+// callsignSuffixes are said after a callsign but aren't part of it
+var callsignSuffixes = map[string]bool{"heavy": true, "super": true}
+
+// SpokenCallsign converts a callsign as spoken on the radio to its ICAO form: "Air Canada
+// one twenty three" is "ACA123", "WestJet 7 52 heavy" is "WJA752" and "Charlie Foxtrot
+// Alpha Bravo Charlie" is "CFABC". It returns an empty string if the words aren't a
+// callsign it knows how to read.
+func SpokenCallsign(spoken string) string {
+	text := strings.ToLower(spoken)
+	text = strings.ReplaceAll(text, "x-ray", "xray")
+	text = strings.ReplaceAll(text, "-", " ")
+	words := strings.Fields(spokenWordChars.ReplaceAllString(text, ""))
+	for len(words) > 0 && callsignSuffixes[words[len(words)-1]] {
+		words = words[:len(words)-1]
+	}
+	if len(words) == 0 {
+		return ""
+	}
+
+	// An airline's telephony followed by a flight number, which may end in letters
+	for n := min(maxTelephonyWords, len(words)-1); n >= 1; n-- {
+		designator, ok := telephonyDesignators[strings.Join(words[:n], "")]
+		if !ok {
+			continue
+		}
+		if number, ok := spokenCharacters(words[n:]); ok && number[0] >= '0' && number[0] <= '9' {
+			return designator + number
+		}
+	}
+
+	// A registration spelled out, such as "November one two three alpha bravo"
+	if registration, ok := spokenCharacters(words); ok && len(registration) >= minAbbreviatedRegistration {
+		return registration
+	}
+	return ""
+}
+
+// spokenCharacters reads words as spoken letters and numbers. Numbers may be said digit
+// by digit ("one two three"), in groups ("one twenty three", "eight seventy") or with
+// "hundred" and "double"/"triple". It fails on any other word.
+func spokenCharacters(words []string) (string, bool) {
+	var out []byte
+	fill := 0   // Trailing zeros of a "twenty" or "hundred" the next number word fills in
+	repeat := 1 // Times the next digit is said, after "double" or "triple"
+
+	for _, word := range words {
+		if digit, ok := spokenDigits[word]; ok {
+			if fill > 0 && repeat == 1 {
+				out[len(out)-1] = digit
+			} else {
+				for i := 0; i < repeat; i++ {
+					out = append(out, digit)
+				}
+			}
+			fill, repeat = 0, 1
+			continue
+		}
+		if repeat != 1 {
+			return "", false // "double" must be followed by a digit
+		}
+
+		switch {
+		case word == "double":
+			repeat = 2
+		case word == "triple":
+			repeat = 3
+		case word == "hundred":
+			if len(out) == 0 || fill != 0 {
+				return "", false
+			}
+			out = append(out, '0', '0')
+			fill = 2
+		case spokenTeens[word] != "":
+			if fill == 2 {
+				out = out[:len(out)-2]
+			}
+			out = append(out, spokenTeens[word]...)
+			fill = 0
+		case spokenTens[word] != 0:
+			if fill == 2 {
+				out = out[:len(out)-2]
+			}
+			out = append(out, spokenTens[word], '0')
+			fill = 1
+		case phoneticLetters[word] != 0:
+			out = append(out, phoneticLetters[word])
+			fill = 0
+		case isDigits(word):
+			out = append(out, word...)
+			fill = 0
+		default:
+			return "", false
+		}
+	}
+	if repeat != 1 || len(out) == 0 {
+		return "", false
+	}
+	return string(out), true
+}
+
+// isDigits reports whether a word is a number written in digits
+func isDigits(word string) bool {
+	for i := 0; i < len(word); i++ {
+		if word[i] < '0' || word[i] > '9' {
+			return false
+		}
+	}
+	return word != ""
+}
+
+// Correlate finds the active aircraft a callsign as transcribed refers to: an ICAO
+// callsign, registration or hex code, a spoken airline callsign ("Air Canada one twenty
+// three"), a spelled registration, or a registration abbreviated to its last characters
+// ("Alpha Bravo Charlie" for C-FABC) when only one active aircraft matches
+func (r *CallsignRegistry) Correlate(callsign string) (CallsignEntry, bool) {
+	if entry, ok := r.Lookup(callsign); ok {
+		return entry, true
+	}
+
+	key := SpokenCallsign(callsign)
+	if key != "" {
+		if entry, ok := r.Lookup(key); ok {
+			return entry, true
+		}
+	} else {
+		key = NormalizeCallsign(callsign)
+	}
+	if len(key) < minAbbreviatedRegistration {
+		return CallsignEntry{}, false
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var match *CallsignEntry
+	for registration, entry := range r.byRegistration {
+		if len(registration) <= len(key) || !strings.HasSuffix(registration, key) {
+			continue
+		}
+		if match != nil && match != entry {
+			return CallsignEntry{}, false // Ambiguous
+		}
+		match = entry
+	}
+	if match == nil {
+		return CallsignEntry{}, false
+	}
+	return *match, true
+}
@@ -153,6 +153,7 @@ type ExternalADSBTarget struct {
 	Messages       FlexibleField `json:"messages"`
 	Seen           FlexibleField `json:"seen"`
 	RSSI           FlexibleField `json:"rssi"`
+	DBFlags        FlexibleField `json:"dbFlags"`
 }
 
 // ExternalAPIResponse represents the raw JSON data from the external ADS-B API
@@ -221,6 +222,7 @@ func (e *ExternalADSBTarget) Convert() ADSBTarget {
 	target.Alert = e.Alert.Int()
 	target.SPI = e.SPI.Int()
 	target.Messages = e.Messages.Int()
+	target.DBFlags = e.DBFlags.Int()
 
 	return target
 }
@@ -0,0 +1,256 @@
+package adsb
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/yegors/co-atc/internal/config"
+)
+
+// Defaults of the external API polling settings
+const (
+	DefaultExternalIdleInterval = 60 * time.Second
+	DefaultExternalMaxBackoff   = 10 * time.Minute
+)
+
+// ExternalStatusError is a non-200 response from the external API
+type ExternalStatusError struct {
+	StatusCode int
+	RetryAfter time.Duration // From the Retry-After header, zero if absent
+}
+
+func (e *ExternalStatusError) Error() string {
+	return fmt.Sprintf("unexpected status code: %d", e.StatusCode)
+}
+
+// ExternalPollStats shows how the external API is being polled
+type ExternalPollStats struct {
+	IntervalSeconds float64    `json:"interval_seconds"` // Current time between requests
+	NextRequestAt   time.Time  `json:"next_request_at"`
+	BackoffUntil    *time.Time `json:"backoff_until,omitempty"` // Set after a rate limit or failure
+	RequestsToday   int        `json:"requests_today"`
+	DailyBudget     int        `json:"daily_budget"` // 0 = unlimited
+	Requests        int64      `json:"requests"`
+	RateLimited     int64      `json:"rate_limited"` // 429 responses
+	Failures        int64      `json:"failures"`
+	CachedResponses int64      `json:"cached_responses"` // Poll cycles served from the last response
+	EmptyResponses  int        `json:"empty_responses"`  // Responses in a row without aircraft
+	QuotaRemaining  *int       `json:"quota_remaining,omitempty"`
+	LastStatus      int        `json:"last_status,omitempty"`
+}
+
+// externalPoller decides when the external API is actually requested. Poll cycles in between
+// are served the last response, so simulated traffic keeps moving without spending quota.
+// Requests slow down while there's no traffic, back off after rate limits and failures, and
+// are spread over the day to stay within the daily budget.
+type externalPoller struct {
+	interval     time.Duration // Minimum time between requests
+	idleInterval time.Duration
+	maxBackoff   time.Duration
+	dailyBudget  int
+
+	mu           sync.Mutex
+	nextAt       time.Time
+	backoffUntil time.Time
+	failures     int // Failed requests in a row
+	day          string
+	cached       *RawAircraftData
+	cachedAt     time.Time
+	stats        ExternalPollStats
+}
+
+// newExternalPoller creates the poller for the ADS-B configuration
+func newExternalPoller(adsbCfg config.ADSBConfig) *externalPoller {
+	p := &externalPoller{
+		interval:     time.Duration(adsbCfg.ExternalPollIntervalSecs) * time.Second,
+		idleInterval: time.Duration(adsbCfg.ExternalIdleIntervalSecs) * time.Second,
+		maxBackoff:   time.Duration(adsbCfg.ExternalMaxBackoffSecs) * time.Second,
+		dailyBudget:  adsbCfg.ExternalDailyRequestBudget,
+	}
+	if p.interval <= 0 {
+		p.interval = time.Duration(adsbCfg.FetchIntervalSecs) * time.Second
+	}
+	if p.idleInterval <= 0 {
+		p.idleInterval = DefaultExternalIdleInterval
+	}
+	p.idleInterval = max(p.idleInterval, p.interval)
+	if p.maxBackoff <= 0 {
+		p.maxBackoff = DefaultExternalMaxBackoff
+	}
+	p.stats.DailyBudget = p.dailyBudget
+	p.stats.IntervalSeconds = p.interval.Seconds()
+	return p
+}
+
+// begin reports whether a request should be made now, counting it against the budget
+func (p *externalPoller) begin(now time.Time) bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.rollDay(now)
+	if now.Before(p.nextAt) || now.Before(p.backoffUntil) {
+		return false
+	}
+	if p.dailyBudget > 0 && p.stats.RequestsToday >= p.dailyBudget {
+		p.nextAt = nextMidnight(now)
+		return false
+	}
+	p.stats.RequestsToday++
+	p.stats.Requests++
+	return true
+}
+
+// succeeded caches a response and schedules the next request
+func (p *externalPoller) succeeded(now time.Time, data *RawAircraftData) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.cached = data
+	p.cachedAt = now
+	p.failures = 0
+	p.backoffUntil = time.Time{}
+	p.stats.LastStatus = http.StatusOK
+	if len(data.Aircraft) == 0 {
+		p.stats.EmptyResponses++
+	} else {
+		p.stats.EmptyResponses = 0
+	}
+
+	// Each empty response in a row doubles the interval, up to the idle interval
+	interval := p.interval
+	for i := 0; i < p.stats.EmptyResponses && interval < p.idleInterval; i++ {
+		interval *= 2
+	}
+	interval = min(interval, p.idleInterval)
+
+	// Spread the rest of the day's budget over the rest of the day
+	if p.dailyBudget > 0 {
+		remaining := p.dailyBudget - p.stats.RequestsToday
+		untilMidnight := nextMidnight(now).Sub(now)
+		if remaining <= 0 {
+			interval = untilMidnight
+		} else {
+			interval = max(interval, untilMidnight/time.Duration(remaining))
+		}
+	}
+
+	p.nextAt = now.Add(interval)
+	p.stats.IntervalSeconds = interval.Seconds()
+}
+
+// failed backs off after a failed request: for as long as a rate limit's Retry-After asks,
+// otherwise doubling the interval with each failure in a row
+func (p *externalPoller) failed(now time.Time, err error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.failures++
+	p.stats.Failures++
+
+	wait := p.interval
+	for i := 0; i < p.failures && wait < p.maxBackoff; i++ {
+		wait *= 2
+	}
+	wait = min(wait, p.maxBackoff)
+	if statusErr, ok := err.(*ExternalStatusError); ok {
+		p.stats.LastStatus = statusErr.StatusCode
+		if statusErr.StatusCode == http.StatusTooManyRequests {
+			p.stats.RateLimited++
+		}
+		if statusErr.RetryAfter > 0 {
+			wait = statusErr.RetryAfter // The API knows best, even beyond the maximum backoff
+		}
+	}
+	p.backoffUntil = now.Add(wait)
+}
+
+// observeQuota records the quota left, from the headers RapidAPI sends with every response
+func (p *externalPoller) observeQuota(header http.Header) {
+	remaining, err := strconv.Atoi(header.Get("X-RateLimit-Requests-Remaining"))
+	if err != nil {
+		return
+	}
+	p.mu.Lock()
+	p.stats.QuotaRemaining = &remaining
+	p.mu.Unlock()
+}
+
+// cachedData returns the last response with its aircraft aged to now, so they go stale and
+// are lost as they would without new data. Before the first response it's empty.
+func (p *externalPoller) cachedData(now time.Time) *RawAircraftData {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.stats.CachedResponses++
+	data := &RawAircraftData{Now: float64(now.Unix()), Aircraft: []ADSBTarget{}}
+	if p.cached == nil {
+		return data
+	}
+
+	age := now.Sub(p.cachedAt).Seconds()
+	data.Messages = p.cached.Messages
+	data.Aircraft = make([]ADSBTarget, len(p.cached.Aircraft))
+	copy(data.Aircraft, p.cached.Aircraft)
+	for i := range data.Aircraft {
+		data.Aircraft[i].Seen += age
+		data.Aircraft[i].SeenPos += age
+	}
+	return data
+}
+
+// reset drops the cached response and requests again on the next poll cycle, e.g. after the
+// station moved
+func (p *externalPoller) reset() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.cached = nil
+	p.nextAt = time.Time{}
+	p.stats.EmptyResponses = 0
+}
+
+// Stats returns the polling state
+func (p *externalPoller) Stats() ExternalPollStats {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	stats := p.stats
+	stats.NextRequestAt = p.nextAt
+	if p.backoffUntil.After(time.Now()) {
+		until := p.backoffUntil
+		stats.BackoffUntil = &until
+		stats.NextRequestAt = until
+	}
+	return stats
+}
+
+// rollDay starts a new day's budget at local midnight
+func (p *externalPoller) rollDay(now time.Time) {
+	day := now.Format("2006-01-02")
+	if day != p.day {
+		p.day = day
+		p.stats.RequestsToday = 0
+	}
+}
+
+// parseRetryAfter parses a Retry-After header, in seconds or as an HTTP date
+func parseRetryAfter(value string, now time.Time) time.Duration {
+	if value == "" {
+		return 0
+	}
+	if seconds, err := strconv.Atoi(value); err == nil && seconds > 0 {
+		return time.Duration(seconds) * time.Second
+	}
+	if at, err := http.ParseTime(value); err == nil && at.After(now) {
+		return at.Sub(now)
+	}
+	return 0
+}
+
+// nextMidnight returns the start of the local day after a time
+func nextMidnight(t time.Time) time.Time {
+	year, month, day := t.Date()
+	return time.Date(year, month, day+1, 0, 0, 0, 0, t.Location())
+}
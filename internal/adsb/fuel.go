@@ -0,0 +1,163 @@
+package adsb
+
+import (
+	"strings"
+	"time"
+)
+
+// Fuel states, from the pilot's point of view
+const (
+	FuelStateNormal    = "normal"
+	FuelStateMinimum   = "minimum_fuel"   // Any further delay would cut into the final reserve
+	FuelStateEmergency = "emergency_fuel" // The aircraft will land with less than its final reserve
+)
+
+// minimumFuelMargin is how close to the final reserve an aircraft is considered to be at minimum fuel
+const minimumFuelMargin = 15 * time.Minute
+
+// Burn rate factors relative to cruise, by phase of flight
+const (
+	taxiBurnFactor     = 0.2
+	climbBurnFactor    = 1.5
+	descentBurnFactor  = 0.4
+	lowLevelBurnFactor = 1.15 // Level flight below 10,000 ft
+)
+
+// FuelProfile is the rough fuel performance of an aircraft type
+type FuelProfile struct {
+	AircraftType     string  `json:"aircraft_type"`
+	CruiseBurnKgHour float64 `json:"cruise_burn_kg_per_hour"` // Typical cruise burn
+	UsableFuelKg     float64 `json:"usable_fuel_kg"`          // Usable fuel with full tanks
+	ReserveMinutes   float64 `json:"reserve_minutes"`         // Final reserve
+}
+
+// fuelProfiles holds rough figures for common types by ICAO type designator. They're for
+// training and situational awareness only, not for planning.
+var fuelProfiles = map[string]FuelProfile{
+	"A319": {CruiseBurnKgHour: 2400, UsableFuelKg: 18700, ReserveMinutes: 30},
+	"A320": {CruiseBurnKgHour: 2500, UsableFuelKg: 18700, ReserveMinutes: 30},
+	"A321": {CruiseBurnKgHour: 2800, UsableFuelKg: 18600, ReserveMinutes: 30},
+	"A20N": {CruiseBurnKgHour: 2100, UsableFuelKg: 18700, ReserveMinutes: 30},
+	"A21N": {CruiseBurnKgHour: 2400, UsableFuelKg: 18600, ReserveMinutes: 30},
+	"A333": {CruiseBurnKgHour: 5700, UsableFuelKg: 109000, ReserveMinutes: 30},
+	"A359": {CruiseBurnKgHour: 5800, UsableFuelKg: 110500, ReserveMinutes: 30},
+	"B737": {CruiseBurnKgHour: 2300, UsableFuelKg: 20800, ReserveMinutes: 30},
+	"B738": {CruiseBurnKgHour: 2500, UsableFuelKg: 20800, ReserveMinutes: 30},
+	"B38M": {CruiseBurnKgHour: 2200, UsableFuelKg: 20700, ReserveMinutes: 30},
+	"B39M": {CruiseBurnKgHour: 2300, UsableFuelKg: 20700, ReserveMinutes: 30},
+	"B788": {CruiseBurnKgHour: 5300, UsableFuelKg: 101300, ReserveMinutes: 30},
+	"B789": {CruiseBurnKgHour: 5600, UsableFuelKg: 101300, ReserveMinutes: 30},
+	"B77W": {CruiseBurnKgHour: 7500, UsableFuelKg: 145500, ReserveMinutes: 30},
+	"E75L": {CruiseBurnKgHour: 1600, UsableFuelKg: 9300, ReserveMinutes: 30},
+	"CRJ9": {CruiseBurnKgHour: 1500, UsableFuelKg: 8800, ReserveMinutes: 30},
+	"DH8D": {CruiseBurnKgHour: 1000, UsableFuelKg: 5300, ReserveMinutes: 30},
+	"AT76": {CruiseBurnKgHour: 750, UsableFuelKg: 5000, ReserveMinutes: 30},
+	"C208": {CruiseBurnKgHour: 150, UsableFuelKg: 1000, ReserveMinutes: 45},
+	"PC12": {CruiseBurnKgHour: 200, UsableFuelKg: 1200, ReserveMinutes: 45},
+	"C172": {CruiseBurnKgHour: 30, UsableFuelKg: 120, ReserveMinutes: 45},
+	"PA28": {CruiseBurnKgHour: 28, UsableFuelKg: 140, ReserveMinutes: 45},
+	"SR22": {CruiseBurnKgHour: 45, UsableFuelKg: 220, ReserveMinutes: 45},
+}
+
+// defaultFuelProfile is used for simulated aircraft of unknown types
+var defaultFuelProfile = fuelProfiles["A320"]
+
+// FuelEstimate is the estimated fuel state of an aircraft. Simulated aircraft carry a
+// known amount of fuel; for real aircraft only the burn since first seen is estimated.
+type FuelEstimate struct {
+	Source            string   `json:"source"` // "simulated" or "estimated"
+	AircraftType      string   `json:"aircraft_type"`
+	BurnRateKgPerHour float64  `json:"burn_rate_kg_per_hour"`
+	BurnedKg          float64  `json:"burned_kg"`                   // Burned since created (simulated) or first seen (real)
+	RemainingKg       *float64 `json:"remaining_kg,omitempty"`      // Known for simulated aircraft only
+	EnduranceMinutes  *float64 `json:"endurance_minutes,omitempty"` // Known for simulated aircraft only
+	State             string   `json:"state,omitempty"`             // Known for simulated aircraft only
+}
+
+// FuelProfileFor returns the fuel profile of an ICAO aircraft type designator
+func FuelProfileFor(aircraftType string) (FuelProfile, bool) {
+	aircraftType = strings.ToUpper(strings.TrimSpace(aircraftType))
+	profile, ok := fuelProfiles[aircraftType]
+	if !ok {
+		return FuelProfile{}, false
+	}
+	profile.AircraftType = aircraftType
+	return profile, true
+}
+
+// DefaultFuelProfile returns the profile for aircraft types without one of their own
+func DefaultFuelProfile(aircraftType string) FuelProfile {
+	profile := defaultFuelProfile
+	profile.AircraftType = strings.ToUpper(strings.TrimSpace(aircraftType))
+	return profile
+}
+
+// BurnRate estimates the fuel burn in kg per hour for the phase of flight
+func (p FuelProfile) BurnRate(altitude, verticalRate float64, onGround bool) float64 {
+	switch {
+	case onGround:
+		return p.CruiseBurnKgHour * taxiBurnFactor
+	case verticalRate > 500:
+		return p.CruiseBurnKgHour * climbBurnFactor
+	case verticalRate < -500:
+		return p.CruiseBurnKgHour * descentBurnFactor
+	case altitude < 10000:
+		return p.CruiseBurnKgHour * lowLevelBurnFactor
+	default:
+		return p.CruiseBurnKgHour
+	}
+}
+
+// FuelForEndurance returns the fuel that lasts the given time at cruise burn
+func (p FuelProfile) FuelForEndurance(endurance time.Duration) float64 {
+	return p.CruiseBurnKgHour * endurance.Hours()
+}
+
+// FuelState classifies the remaining endurance against the final reserve
+func (p FuelProfile) FuelState(endurance time.Duration) string {
+	reserve := time.Duration(p.ReserveMinutes * float64(time.Minute))
+	switch {
+	case endurance <= reserve:
+		return FuelStateEmergency
+	case endurance <= reserve+minimumFuelMargin:
+		return FuelStateMinimum
+	default:
+		return FuelStateNormal
+	}
+}
+
+// updateFuelEstimates attaches fuel estimates: the simulated fuel state of simulated
+// aircraft, and for real airborne aircraft of known types (if enabled) the estimated
+// burn since they were first seen
+func (s *Service) updateFuelEstimates(aircraft []*Aircraft) {
+	for _, a := range aircraft {
+		if a.IsSimulated {
+			if s.simulationService != nil {
+				if fuel, ok := s.simulationService.FuelEstimate(a.Hex); ok {
+					a.Fuel = fuel
+				}
+			}
+			continue
+		}
+
+		if !s.estimateFuel || a.ADSB == nil || a.OnGround {
+			continue
+		}
+		profile, ok := FuelProfileFor(a.ADSB.AircraftType)
+		if !ok {
+			continue
+		}
+
+		burnRate := profile.BurnRate(a.ADSB.AltBaro, a.ADSB.BaroRate, false)
+		estimate := &FuelEstimate{
+			Source:            "estimated",
+			AircraftType:      profile.AircraftType,
+			BurnRateKgPerHour: burnRate,
+		}
+		if !a.CreatedAt.IsZero() && a.LastSeen.After(a.CreatedAt) {
+			// Rough: assumes cruise burn throughout
+			estimate.BurnedKg = profile.CruiseBurnKgHour * a.LastSeen.Sub(a.CreatedAt).Hours()
+		}
+		a.Fuel = estimate
+	}
+}
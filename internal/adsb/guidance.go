@@ -0,0 +1,167 @@
+package adsb
+
+import (
+	"math"
+	"strings"
+	"sync"
+
+	"github.com/yegors/co-atc/internal/config"
+)
+
+const (
+	// thresholdCrossingHeight is the height the glidepath crosses the threshold at, in feet
+	thresholdCrossingHeight = 50.0
+	// guidanceAlignment is how far the course may be from the runway heading for guidance
+	guidanceAlignment = 30.0
+	// localizerCoverage is the angle either side of the centerline guidance is given in, as
+	// seen from the localizer at the far end of the runway
+	localizerCoverage = 10.0
+)
+
+// papiSettings are the angles of the four PAPI lights relative to the glidepath, from the
+// light that turns red last to the one that turns red first
+var papiSettings = [4]float64{-0.5, -1.0 / 6, 1.0 / 6, 0.5}
+
+// ApproachGuidance is an aircraft's deviation from the approach path of the runway it's
+// lined up with, as a glidepath and localizer would show it
+type ApproachGuidance struct {
+	Runway               string  `json:"runway"` // Threshold ID, e.g. "24R"
+	GlidepathDeg         float64 `json:"glidepath_deg"`
+	DistanceNM           float64 `json:"distance_nm"`            // To the threshold along the extended centerline
+	HeightFeet           float64 `json:"height_ft"`              // Above the field, corrected to the QNH the aircraft sends
+	VerticalDeviationDeg float64 `json:"vertical_deviation_deg"` // Positive above the glidepath
+	VerticalDeviationFt  float64 `json:"vertical_deviation_ft"`
+	LateralDeviationDeg  float64 `json:"lateral_deviation_deg"` // Angle from the localizer at the far end; positive right of the centerline looking toward the runway
+	LateralDeviationFt   float64 `json:"lateral_deviation_ft"`
+	PAPI                 string  `json:"papi"` // "WWRR" on the glidepath, "WWWW" high, "RRRR" low
+}
+
+// guidanceSettings are the glidepaths guidance is computed with
+type guidanceSettings struct {
+	mu            sync.RWMutex
+	glidepathDeg  float64            // 0 = guidance disabled
+	runways       map[string]float64 // Glidepath of runways that differ, by threshold ID
+	maxDistanceNM float64
+}
+
+// SetApproachGuidance sets the glidepaths used to compute the approach guidance of aircraft
+// lined up with a runway
+func (s *Service) SetApproachGuidance(cfg config.ApproachesConfig) {
+	runways := make(map[string]float64, len(cfg.RunwayGlidepaths))
+	for id, angle := range cfg.RunwayGlidepaths {
+		runways[strings.ToUpper(id)] = angle
+	}
+
+	s.guidance.mu.Lock()
+	defer s.guidance.mu.Unlock()
+	s.guidance.glidepathDeg = cfg.GlidepathDeg
+	s.guidance.runways = runways
+	s.guidance.maxDistanceNM = cfg.MaxDistanceNM
+}
+
+// attachGuidance sets the approach guidance of airborne aircraft lined up with a runway
+func (s *Service) attachGuidance(aircraft []*Aircraft) {
+	s.guidance.mu.RLock()
+	defer s.guidance.mu.RUnlock()
+	if s.guidance.glidepathDeg <= 0 {
+		return
+	}
+
+	ends := s.RunwayEnds()
+	declination := s.Declination()
+	for _, a := range aircraft {
+		if a == nil {
+			continue
+		}
+		a.Guidance = nil
+		if a.ADSB == nil || a.OnGround || (a.ADSB.Lat == 0 && a.ADSB.Lon == 0) {
+			continue
+		}
+		a.Guidance = s.approachGuidance(a.ADSB, ends, declination)
+	}
+}
+
+// approachGuidance returns the guidance of an aircraft toward the nearest threshold within
+// max_distance_nm it's lined up with, or nil if there's none. Must be called with the
+// guidance lock held.
+func (s *Service) approachGuidance(target *ADSBTarget, ends []RunwayEnd, declination float64) *ApproachGuidance {
+	course := TrueCourse(target, declination)
+
+	var best *RunwayEnd
+	var bestAlong, bestRight float64
+	for i := range ends {
+		end := &ends[i]
+		if math.Abs(normalizeAngle(course-end.Heading)) > guidanceAlignment {
+			continue
+		}
+
+		// Position relative to the approach path, which extends from the landing threshold
+		// away from the runway
+		along, right := RunwayPosition(*end, target.Lat, target.Lon)
+		if along <= 0 || along > s.guidance.maxDistanceNM {
+			continue
+		}
+		if math.Abs(math.Atan2(right, along+end.LandingLengthNM))*180/math.Pi > localizerCoverage {
+			continue
+		}
+		if best == nil || along < bestAlong {
+			best, bestAlong, bestRight = end, along, right
+		}
+	}
+	if best == nil {
+		return nil
+	}
+
+	glidepath := s.guidance.glidepathDeg
+	if angle, ok := s.guidance.runways[strings.ToUpper(best.ID)]; ok {
+		glidepath = angle
+	}
+
+	height := AltitudeMSL(target) - best.ElevationFeet
+	alongFt := bestAlong * FEET_PER_NM
+	pathAngle := math.Atan2(height-thresholdCrossingHeight, alongFt) * 180 / math.Pi
+	expected := thresholdCrossingHeight + alongFt*math.Tan(glidepath*math.Pi/180)
+
+	papi := make([]byte, 0, len(papiSettings))
+	for _, setting := range papiSettings {
+		if pathAngle > glidepath+setting {
+			papi = append(papi, 'W')
+		}
+	}
+	for len(papi) < len(papiSettings) {
+		papi = append(papi, 'R')
+	}
+
+	return &ApproachGuidance{
+		Runway:               best.ID,
+		GlidepathDeg:         glidepath,
+		DistanceNM:           roundTo(bestAlong, 2),
+		HeightFeet:           roundTo(height, 0),
+		VerticalDeviationDeg: roundTo(pathAngle-glidepath, 2),
+		VerticalDeviationFt:  roundTo(height-expected, 0),
+		LateralDeviationDeg:  roundTo(math.Atan2(bestRight, bestAlong+best.LandingLengthNM)*180/math.Pi, 2),
+		LateralDeviationFt:   roundTo(bestRight*FEET_PER_NM, 0),
+		PAPI:                 string(papi),
+	}
+}
+
+// roundTo rounds a value to a number of decimals, without negative zeros
+func roundTo(value float64, decimals int) float64 {
+	scale := math.Pow(10, float64(decimals))
+	rounded := math.Round(value*scale) / scale
+	if rounded == 0 {
+		return 0
+	}
+	return rounded
+}
+
+// normalizeAngle returns an angle in degrees between -180 and 180
+func normalizeAngle(angle float64) float64 {
+	angle = math.Mod(angle, 360)
+	if angle > 180 {
+		angle -= 360
+	} else if angle < -180 {
+		angle += 360
+	}
+	return angle
+}
@@ -0,0 +1,50 @@
+package adsb
+
+import (
+	"strings"
+	"time"
+)
+
+// Intent is what an aircraft has been cleared to do and is doing, inferred from the recent
+// clearances extracted from transcriptions and its track
+type Intent struct {
+	Summary      string    `json:"summary"` // e.g. "cleared ILS 24R, 8 NM final" or "taxiing to 15L via Bravo"
+	Action       string    `json:"action"`  // "approach", "landing", "takeoff", "taxi", "final", "climb", "descent", "level" or "heading"
+	Runway       string    `json:"runway,omitempty"`
+	ClearanceIDs []int64   `json:"clearance_ids,omitempty"` // Clearances it's inferred from; none when it's from the track alone
+	UpdatedAt    time.Time `json:"updated_at"`              // When the latest of its clearances was issued, or the track last changed it
+}
+
+// IntentSource gives the intent inferred for an aircraft
+type IntentSource interface {
+	Intent(hex string) (*Intent, bool)
+}
+
+// SetIntentSource sets where the intents of aircraft come from. Must be called before Start.
+func (s *Service) SetIntentSource(source IntentSource) {
+	s.intents = source
+}
+
+// attachIntents sets the inferred intent of aircraft
+func (s *Service) attachIntents(aircraft []*Aircraft) {
+	if s.intents == nil {
+		return
+	}
+	for _, a := range aircraft {
+		if a == nil {
+			continue
+		}
+		a.Intent = nil
+		if intent, ok := s.intents.Intent(strings.ToLower(a.Hex)); ok {
+			a.Intent = intent
+		}
+	}
+}
+
+// intentsEqual compares the intent summaries of two aircraft
+func intentsEqual(a, b *Intent) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return a.Summary == b.Summary
+}
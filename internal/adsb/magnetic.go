@@ -0,0 +1,71 @@
+package adsb
+
+import (
+	"sync"
+	"time"
+
+	"github.com/yegors/co-atc/internal/geomag"
+	"github.com/yegors/co-atc/pkg/logger"
+)
+
+// declinationCache holds the magnetic declination at the station for a day
+type declinationCache struct {
+	mu       sync.Mutex
+	lat, lon float64
+	day      time.Time
+	value    float64
+	computed bool
+}
+
+// Declination returns the magnetic declination at the station in degrees, positive east.
+// It's recomputed when the station moves or the UTC day changes.
+func (s *Service) Declination() float64 {
+	lat, lon := s.GetEffectiveStationCoords()
+	day := time.Now().UTC().Truncate(24 * time.Hour)
+
+	c := &s.declination
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if !c.computed || c.lat != lat || c.lon != lon || !c.day.Equal(day) {
+		c.lat, c.lon, c.day = lat, lon, day
+		c.value = geomag.Declination(lat, lon, s.stationElevFeet, day)
+		c.computed = true
+
+		s.logger.Info("Computed magnetic declination at the station",
+			logger.String("model", geomag.Model),
+			logger.Float64("declination_deg", c.value))
+		if geomag.Expired(day) {
+			s.logger.Warn("Magnetic model is past its validity; declinations lose accuracy until it's updated",
+				logger.String("model", geomag.Model))
+		}
+	}
+	return c.value
+}
+
+// TrueCourse returns the true direction an aircraft is moving: its track, else its true
+// heading, else its magnetic heading converted with the declination. 0 when none is sent.
+func TrueCourse(target *ADSBTarget, declination float64) float64 {
+	switch {
+	case target.Track != 0:
+		return target.Track
+	case target.TrueHeading != 0:
+		return target.TrueHeading
+	case target.MagHeading != 0:
+		return geomag.ToTrue(target.MagHeading, declination)
+	}
+	return 0
+}
+
+// MagneticHeading returns an aircraft's magnetic heading, else its true heading or track
+// converted with the declination. 0 when none is sent.
+func MagneticHeading(target *ADSBTarget, declination float64) float64 {
+	switch {
+	case target.MagHeading != 0:
+		return target.MagHeading
+	case target.TrueHeading != 0:
+		return geomag.ToMagnetic(target.TrueHeading, declination)
+	case target.Track != 0:
+		return geomag.ToMagnetic(target.Track, declination)
+	}
+	return 0
+}
@@ -64,6 +64,7 @@ type ADSBTarget struct {
 	Messages       int      `json:"messages"`
 	Seen           float64  `json:"seen"`
 	RSSI           float64  `json:"rssi"`
+	DBFlags        int      `json:"dbFlags,omitempty"`     // Database flags of readsb and aggregators: 1 military, 4 PIA, 8 LADD
 	SourceType     string   `json:"source_type,omitempty"` // Indicates whether data came from "local" or "external" source
 }
 
@@ -85,12 +86,13 @@ type PhaseChange struct {
 
 // PhaseChangeInsert represents a phase change to be inserted in batch
 type PhaseChangeInsert struct {
-	Hex       string    `json:"hex"`
-	Flight    string    `json:"flight"`
-	Phase     string    `json:"phase"`
-	Timestamp time.Time `json:"timestamp"`
-	ADSBId    *int      `json:"adsb_id"`
-	EventType string    `json:"event_type"` // "takeoff", "landing", or "" for normal phase changes
+	Hex           string    `json:"hex"`
+	Flight        string    `json:"flight"`
+	Phase         string    `json:"phase"`
+	Timestamp     time.Time `json:"timestamp"`
+	ADSBId        *int      `json:"adsb_id"`
+	EventType     string    `json:"event_type"`               // "takeoff", "landing", or "" for normal phase changes
+	CorrelationID string    `json:"correlation_id,omitempty"` // Poll cycle that detected the change
 }
 
 // PhaseData represents the phase information for an aircraft
@@ -121,6 +123,13 @@ type Aircraft struct {
 	Clearances         []ClearanceData     `json:"clearances,omitempty"`          // Recent clearances for this aircraft
 	IsSimulated        bool                `json:"is_simulated"`                  // Whether this is a simulated aircraft
 	SimulationControls *SimulationControls `json:"simulation_controls,omitempty"` // Simulation control parameters
+	Fuel               *FuelEstimate       `json:"fuel,omitempty"`                // Estimated fuel state (simulated aircraft, or real ones if enabled)
+	Watchlists         []int64             `json:"watchlists,omitempty"`          // IDs of the watchlists the aircraft is on
+	Reception          *ReceptionStats     `json:"reception,omitempty"`           // How well the raw source receives the aircraft
+	Guidance           *ApproachGuidance   `json:"guidance,omitempty"`            // Deviation from the approach path of the runway the aircraft is lined up with
+	Intent             *Intent             `json:"intent,omitempty"`              // What the aircraft has been cleared to do and is doing
+	Military           bool                `json:"military,omitempty"`            // Military ICAO address or flagged military by the source
+	Blocked            bool                `json:"blocked,omitempty"`             // Owner asked not to be tracked publicly (LADD, PIA or the blocklist)
 }
 
 // SimulationControls represents the control parameters for simulated aircraft
@@ -133,9 +142,11 @@ type SimulationControls struct {
 // ClearanceData represents clearance information in API responses
 type ClearanceData struct {
 	ID              int64     `json:"id"`
-	Type            string    `json:"type"` // "takeoff" or "landing"
+	Type            string    `json:"type"` // "takeoff", "landing", "approach", "taxi", "altitude" or "heading"
 	Text            string    `json:"text"` // Full clearance text
 	Runway          string    `json:"runway,omitempty"`
+	Altitude        int       `json:"altitude,omitempty"` // Assigned altitude in feet
+	Heading         int       `json:"heading,omitempty"`  // Assigned magnetic heading
 	Timestamp       time.Time `json:"timestamp"`
 	Status          string    `json:"status"`            // "issued", "complied", "deviation"
 	TimeSinceIssued string    `json:"time_since_issued"` // Human readable time since issued
@@ -0,0 +1,179 @@
+package adsb
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"time"
+)
+
+const (
+	// defaultGlidepathDeg is the glidepath profiles are compared to when approach guidance
+	// isn't configured
+	defaultGlidepathDeg = 3.0
+	// profileRange is how far from a threshold positions count toward choosing the runway of
+	// a profile, in nautical miles
+	profileRange = 15.0
+)
+
+// ProfilePoint is a stored position of an aircraft relative to a runway threshold
+type ProfilePoint struct {
+	Timestamp       time.Time `json:"timestamp"`
+	DistanceNM      float64   `json:"distance_nm"`  // To the threshold along the extended centerline; negative past it
+	OffsetNM        float64   `json:"offset_nm"`    // From the extended centerline, positive right looking toward the runway
+	AltitudeFt      float64   `json:"altitude_ft"`  // Barometric
+	HeightFt        float64   `json:"height_ft"`    // Above the threshold
+	GlidepathFt     *float64  `json:"glidepath_ft"` // Height of the glidepath at this distance; nil past the threshold
+	GroundSpeedKt   float64   `json:"ground_speed_kt"`
+	VerticalRateFpm float64   `json:"vertical_rate_fpm"`
+}
+
+// VerticalProfile is the altitude and speed of an aircraft against its distance to a runway
+// threshold, oldest position first
+type VerticalProfile struct {
+	Hex          string         `json:"hex"`
+	Flight       string         `json:"flight,omitempty"`
+	Runway       string         `json:"runway"` // Threshold ID, e.g. "24R"
+	GlidepathDeg float64        `json:"glidepath_deg"`
+	QNH          float64        `json:"qnh,omitempty"` // Setting altitudes were corrected to, hPa; 0 when uncorrected
+	Points       []ProfilePoint `json:"points"`
+}
+
+// VerticalProfile builds the profile of an aircraft's stored positions of the last hour, up to
+// limit, against a runway threshold. Without a threshold ID, the one the track was lined up
+// with the longest is used, else the one nearest its lowest position. Returns nil when no
+// positions are stored.
+func (s *Service) VerticalProfile(hex, threshold string, limit int) (*VerticalProfile, error) {
+	history, err := s.storage.GetPositionHistoryWithLimit(hex, limit)
+	if err != nil {
+		return nil, err
+	}
+	if len(history) == 0 {
+		return nil, nil
+	}
+	// Oldest first
+	for i, j := 0, len(history)-1; i < j; i, j = i+1, j-1 {
+		history[i], history[j] = history[j], history[i]
+	}
+
+	ends := s.RunwayEnds()
+	if len(ends) == 0 {
+		return nil, fmt.Errorf("%w: no runways with both thresholds known", ErrUnknownRunway)
+	}
+	var end *RunwayEnd
+	if threshold = strings.ToUpper(strings.TrimSpace(threshold)); threshold != "" {
+		for i := range ends {
+			if ends[i].ID == threshold {
+				end = &ends[i]
+			}
+		}
+		if end == nil {
+			return nil, fmt.Errorf("%w: %s", ErrUnknownRunway, threshold)
+		}
+	} else {
+		end = profileRunway(ends, history)
+	}
+
+	profile := &VerticalProfile{
+		Hex:          hex,
+		Runway:       end.ID,
+		GlidepathDeg: defaultGlidepathDeg,
+		Points:       make([]ProfilePoint, 0, len(history)),
+	}
+	s.guidance.mu.RLock()
+	if angle, ok := s.guidance.runways[end.ID]; ok {
+		profile.GlidepathDeg = angle
+	} else if s.guidance.glidepathDeg > 0 {
+		profile.GlidepathDeg = s.guidance.glidepathDeg
+	}
+	s.guidance.mu.RUnlock()
+
+	// Stored altitudes are pressure altitudes; correct them to the QNH the aircraft sends now
+	correction := 0.0
+	if aircraft, found := s.GetAircraftByHex(hex); found {
+		profile.Flight = strings.TrimSpace(aircraft.Flight)
+		if aircraft.ADSB != nil && aircraft.ADSB.NavQNH >= 900 && aircraft.ADSB.NavQNH <= 1100 {
+			profile.QNH = aircraft.ADSB.NavQNH
+			correction = (aircraft.ADSB.NavQNH - 1013.25) * FEET_PER_HPA
+		}
+	}
+
+	slope := math.Tan(profile.GlidepathDeg * math.Pi / 180)
+	for _, pos := range history {
+		if pos.Lat == 0 && pos.Lon == 0 {
+			continue
+		}
+		along, right := RunwayPosition(*end, pos.Lat, pos.Lon)
+		point := ProfilePoint{
+			Timestamp:       pos.Timestamp,
+			DistanceNM:      math.Round(along*100) / 100,
+			OffsetNM:        math.Round(right*100) / 100,
+			AltitudeFt:      end.ElevationFeet, // On the ground
+			GroundSpeedKt:   pos.SpeedGS,
+			VerticalRateFpm: pos.VerticalSpeed,
+		}
+		if pos.Altitude != 0 {
+			point.AltitudeFt = pos.Altitude + correction
+		}
+		point.HeightFt = max(math.Round(point.AltitudeFt-end.ElevationFeet), 0)
+		if along >= 0 {
+			glidepath := math.Round(thresholdCrossingHeight + along*FEET_PER_NM*slope)
+			point.GlidepathFt = &glidepath
+		}
+		profile.Points = append(profile.Points, point)
+	}
+	return profile, nil
+}
+
+// RunwayPosition returns a position's distance to a landing threshold along the extended
+// centerline, negative past the threshold, and its offset from the centerline, positive right
+// looking toward the runway, in NM
+func RunwayPosition(end RunwayEnd, lat, lon float64) (float64, float64) {
+	distance := MetersToNM(Haversine(end.ThresholdLatitude, end.ThresholdLongitude, lat, lon))
+	offset := normalizeAngle(CalculateBearing(end.ThresholdLatitude, end.ThresholdLongitude, lat, lon)-(end.Heading+180)) * math.Pi / 180
+	return distance * math.Cos(offset), -distance * math.Sin(offset)
+}
+
+// profileRunway returns the threshold a track was lined up with for the most positions, else
+// the one nearest its lowest position
+func profileRunway(ends []RunwayEnd, history []Position) *RunwayEnd {
+	var best *RunwayEnd
+	bestCount := 0
+	for i := range ends {
+		count := 0
+		for _, pos := range history {
+			if math.Abs(normalizeAngle(pos.TrueHeading-ends[i].Heading)) > guidanceAlignment {
+				continue
+			}
+			along, right := RunwayPosition(ends[i], pos.Lat, pos.Lon)
+			if along < -ends[i].LandingLengthNM || along > profileRange {
+				continue
+			}
+			if math.Abs(math.Atan2(right, along+ends[i].LandingLengthNM))*180/math.Pi > localizerCoverage {
+				continue
+			}
+			count++
+		}
+		if count > bestCount {
+			best, bestCount = &ends[i], count
+		}
+	}
+	if best != nil {
+		return best
+	}
+
+	lowest := history[len(history)-1]
+	for _, pos := range history {
+		if pos.Altitude < lowest.Altitude && !(pos.Lat == 0 && pos.Lon == 0) {
+			lowest = pos
+		}
+	}
+	best = &ends[0]
+	for i := range ends[1:] {
+		if Haversine(ends[i+1].ThresholdLatitude, ends[i+1].ThresholdLongitude, lowest.Lat, lowest.Lon) <
+			Haversine(best.ThresholdLatitude, best.ThresholdLongitude, lowest.Lat, lowest.Lon) {
+			best = &ends[i+1]
+		}
+	}
+	return best
+}
@@ -0,0 +1,665 @@
+package adsb
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"net"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/yegors/co-atc/internal/modes"
+	"github.com/yegors/co-atc/pkg/logger"
+)
+
+const (
+	rawTargetTimeout    = 5 * time.Minute  // Aircraft not heard for this long are forgotten
+	rawActiveWindow     = 60 * time.Second // Aircraft heard this recently count as received
+	cprPairWindow       = 10 * time.Second // Longest time between an even and odd position decoded together
+	cprLocalMaxAge      = 30 * time.Second // Oldest position later positions are decoded relative to
+	rawMaxRangeNM       = 400.0            // Positions further from the station than this are rejected
+	surfaceMaxRangeNM   = 45.0             // Surface positions are decoded relative to the station within this range
+	rawMaxSpeedKts      = 1000.0           // Positions an aircraft couldn't have moved to at this speed are rejected
+	rateTimeConstant    = 10.0             // Seconds message rates are averaged over
+	rssiSmoothing       = 0.2              // Weight of each message in the average signal level
+	coverageSectors     = 12               // Bearing sectors coverage is reported in
+	rawDialTimeout      = 10 * time.Second
+	rawMinReconnectWait = 2 * time.Second
+	rawMaxReconnectWait = 30 * time.Second
+)
+
+// Target types of aircraft from the raw source, as readsb names them
+const (
+	rawTypeADSBICAO   = "adsb_icao"    // DF17
+	rawTypeADSBNonTx  = "adsb_icao_nt" // DF18 from a non-transponder device or rebroadcast
+	rawTypeADSBOther  = "adsb_other"   // DF18 with an anonymous address
+	rawTypeModeS      = "mode_s"       // Mode S replies only
+	rawSourceTypeName = "raw"
+)
+
+// ReceptionStats describes how well an aircraft's messages are received from the raw source
+type ReceptionStats struct {
+	Messages        int       `json:"messages"`
+	MessageRate     float64   `json:"message_rate"`        // Messages per second, averaged over about 10 seconds
+	RSSI            float64   `json:"rssi,omitempty"`      // Average signal level of recent messages (dBFS)
+	PeakRSSI        float64   `json:"peak_rssi,omitempty"` // Strongest signal level (dBFS)
+	LastDF          int       `json:"last_df"`             // Downlink format of the last message
+	LastMessageType string    `json:"last_message_type"`   // "airborne_position", "identity_reply", ...
+	LastMessageAt   time.Time `json:"last_message_at"`
+	CPRGlobal       int       `json:"cpr_global"` // Positions decoded from an even and odd pair
+	CPRLocal        int       `json:"cpr_local"`  // Positions decoded relative to a recent position
+	CPRFailed       int       `json:"cpr_failed"` // Pairs that didn't decode and implausible positions
+}
+
+// ReceiverStats describes the raw source connection and the health of its decoding
+type ReceiverStats struct {
+	Address        string            `json:"address"`
+	Format         string            `json:"format"`
+	Connected      bool              `json:"connected"`
+	ConnectedSince *time.Time        `json:"connected_since,omitempty"`
+	Reconnects     int               `json:"reconnects"`
+	LastError      string            `json:"last_error,omitempty"`
+	Frames         int64             `json:"frames"`          // Frames received
+	ModeAC         int64             `json:"mode_ac"`         // Mode A/C replies, which aren't decoded
+	Accepted       int64             `json:"accepted"`        // Messages decoded and attributed to an aircraft
+	BadCRC         int64             `json:"bad_crc"`         // Messages failing the parity check
+	UnknownAddress int64             `json:"unknown_address"` // Replies from addresses not confirmed by a squitter
+	Unsupported    int64             `json:"unsupported"`     // Downlink formats that aren't decoded
+	ByDF           map[string]int64  `json:"by_df"`           // Accepted messages by downlink format ("DF17")
+	ByType         map[string]int64  `json:"by_type"`         // Accepted messages by type
+	CPRGlobal      int64             `json:"cpr_global"`
+	CPRLocal       int64             `json:"cpr_local"`
+	CPRFailed      int64             `json:"cpr_failed"`
+	MessageRate    float64           `json:"message_rate"` // Accepted messages per second
+	Aircraft       int               `json:"aircraft"`     // Aircraft heard in the last minute
+	WithPosition   int               `json:"with_position"`
+	Coverage       []CoverageSector  `json:"coverage"`
+	Targets        []ReceptionTarget `json:"targets"` // Aircraft heard in the last minute, farthest first
+	Timestamp      time.Time         `json:"timestamp"`
+}
+
+// CoverageSector is the reception in a 30 degree sector of bearings from the station
+type CoverageSector struct {
+	Bearing    int     `json:"bearing"` // Start of the sector (degrees true)
+	Aircraft   int     `json:"aircraft"`
+	MaxRangeNM float64 `json:"max_range_nm"`
+	RSSI       float64 `json:"rssi,omitempty"` // Average signal level of the sector's aircraft (dBFS)
+}
+
+// ReceptionTarget is the reception of one aircraft
+type ReceptionTarget struct {
+	Hex        string   `json:"hex"`
+	Flight     string   `json:"flight,omitempty"`
+	DistanceNM *float64 `json:"distance_nm,omitempty"`
+	Bearing    *float64 `json:"bearing,omitempty"`
+	ReceptionStats
+}
+
+// cprReport is a position report kept until its even or odd counterpart arrives
+type cprReport struct {
+	pos modes.CPRPosition
+	at  time.Time
+}
+
+// rawTarget is an aircraft tracked from raw messages
+type rawTarget struct {
+	adsb      ADSBTarget
+	confirmed bool // Heard in a message with a checked parity (DF11, DF17 or DF18)
+	lastSeen  time.Time
+	posAt     time.Time // Zero until a position is decoded
+	even, odd *cprReport
+	rateAt    time.Time // When MessageRate was last decayed
+	reception ReceptionStats
+}
+
+// RawReceiver connects to a receiver's raw Beast or AVR output, decodes the Mode S messages
+// itself and keeps per-aircraft reception statistics. It's the "raw" ADS-B source.
+type RawReceiver struct {
+	address    string
+	format     string
+	stationLat float64
+	stationLon float64
+	logger     *logger.Logger
+
+	mu             sync.RWMutex
+	targets        map[string]*rawTarget
+	connected      bool
+	connectedSince time.Time
+	reconnects     int
+	lastError      string
+	frames         int64
+	modeAC         int64
+	accepted       int64
+	badCRC         int64
+	unknownAddress int64
+	unsupported    int64
+	byDF           map[int]int64
+	byType         map[string]int64
+	cprGlobal      int64
+	cprLocal       int64
+	cprFailed      int64
+	rate           float64
+	rateAt         time.Time
+
+	frameListeners []func(modes.Frame) // Called with every accepted Mode S frame
+
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// NewRawReceiver creates a raw receiver. The station is where the antenna is: it bounds
+// decoded positions and anchors surface positions and coverage. Nothing connects until Start.
+func NewRawReceiver(address, format string, stationLat, stationLon float64, logger *logger.Logger) *RawReceiver {
+	return &RawReceiver{
+		address:    address,
+		format:     format,
+		stationLat: stationLat,
+		stationLon: stationLon,
+		targets:    make(map[string]*rawTarget),
+		byDF:       make(map[int]int64),
+		byType:     make(map[string]int64),
+		logger:     logger.Named("adsb-raw"),
+	}
+}
+
+// OnFrame registers a function that is called with every Mode S frame accepted from the
+// receiver, e.g. to forward it. Listeners must not block. Must be called before Start.
+func (r *RawReceiver) OnFrame(fn func(frame modes.Frame)) {
+	r.frameListeners = append(r.frameListeners, fn)
+}
+
+// Start connects to the receiver in the background, reconnecting whenever the connection is lost
+func (r *RawReceiver) Start(ctx context.Context) {
+	ctx, r.cancel = context.WithCancel(ctx)
+
+	r.wg.Add(1)
+	go func() {
+		defer r.wg.Done()
+		r.run(ctx)
+	}()
+
+	r.logger.Info("Raw ADS-B receiver started",
+		logger.String("address", r.address),
+		logger.String("format", r.format))
+}
+
+// Stop disconnects from the receiver
+func (r *RawReceiver) Stop() {
+	if r.cancel != nil {
+		r.cancel()
+	}
+	r.wg.Wait()
+	r.logger.Info("Raw ADS-B receiver stopped")
+}
+
+// run keeps a connection open and decodes its frames until the receiver stops
+func (r *RawReceiver) run(ctx context.Context) {
+	wait := rawMinReconnectWait
+	for {
+		dialer := net.Dialer{Timeout: rawDialTimeout}
+		conn, err := dialer.DialContext(ctx, "tcp", r.address)
+		if err == nil {
+			wait = rawMinReconnectWait
+			err = r.serve(ctx, conn)
+		}
+		if ctx.Err() != nil {
+			return
+		}
+
+		r.mu.Lock()
+		r.lastError = err.Error()
+		r.reconnects++
+		r.mu.Unlock()
+		r.logger.Warn("Raw ADS-B connection failed",
+			logger.String("address", r.address),
+			logger.Duration("retry_in", wait),
+			logger.Error(err))
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(wait):
+		}
+		wait = min(wait*2, rawMaxReconnectWait)
+	}
+}
+
+// serve reads frames from a connection until it fails or the receiver stops
+func (r *RawReceiver) serve(ctx context.Context, conn net.Conn) error {
+	reader, err := modes.NewFrameReader(r.format, conn)
+	if err != nil {
+		conn.Close()
+		return err
+	}
+
+	r.mu.Lock()
+	r.connected = true
+	r.connectedSince = time.Now()
+	r.lastError = ""
+	r.mu.Unlock()
+	r.logger.Info("Connected to raw ADS-B source", logger.String("address", r.address))
+
+	// Closing the connection unblocks the read when the receiver stops
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		select {
+		case <-ctx.Done():
+		case <-done:
+		}
+		conn.Close()
+	}()
+
+	defer func() {
+		r.mu.Lock()
+		r.connected = false
+		r.mu.Unlock()
+	}()
+
+	for {
+		frame, err := reader.ReadFrame()
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				return fmt.Errorf("connection closed by receiver")
+			}
+			return err
+		}
+		if r.handleFrame(frame, time.Now()) {
+			for _, fn := range r.frameListeners {
+				fn(frame)
+			}
+		}
+	}
+}
+
+// handleFrame decodes a frame and applies it to its aircraft. It returns whether the frame
+// was accepted.
+func (r *RawReceiver) handleFrame(frame modes.Frame, now time.Time) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.frames++
+	if len(frame.Data) == 2 {
+		r.modeAC++
+		return false
+	}
+
+	msg, err := modes.Decode(frame.Data)
+	switch {
+	case errors.Is(err, modes.ErrBadCRC):
+		r.badCRC++
+		return false
+	case err != nil:
+		r.unsupported++
+		return false
+	}
+
+	hex := msg.Hex()
+	t, known := r.targets[hex]
+	if msg.Parity && (!known || !t.confirmed) {
+		// The address of a reply is only as good as its parity, so it's trusted only for
+		// aircraft already heard in a checked message
+		r.unknownAddress++
+		return false
+	}
+	if !known {
+		t = &rawTarget{adsb: ADSBTarget{Hex: hex, Type: rawTypeModeS}}
+		r.targets[hex] = t
+	}
+	if !msg.Parity {
+		t.confirmed = true
+	}
+
+	r.accepted++
+	r.byDF[msg.DF]++
+	r.byType[msg.Kind]++
+	r.rate = decayRate(r.rate, r.rateAt, now) + 1/rateTimeConstant
+	r.rateAt = now
+
+	t.lastSeen = now
+	t.adsb.Messages++
+	t.reception.Messages++
+	t.reception.MessageRate = decayRate(t.reception.MessageRate, t.rateAt, now) + 1/rateTimeConstant
+	t.rateAt = now
+	t.reception.LastDF = msg.DF
+	t.reception.LastMessageType = msg.Kind
+	t.reception.LastMessageAt = now
+	if rssi := frame.RSSI(); frame.Signal > 0 {
+		if t.reception.RSSI == 0 {
+			t.reception.RSSI = rssi
+		} else {
+			t.reception.RSSI += rssiSmoothing * (rssi - t.reception.RSSI)
+		}
+		if t.reception.PeakRSSI == 0 || rssi > t.reception.PeakRSSI {
+			t.reception.PeakRSSI = rssi
+		}
+		t.adsb.RSSI = math.Round(t.reception.RSSI*10) / 10
+	}
+
+	r.applyMessage(t, msg, now)
+	return true
+}
+
+// applyMessage updates an aircraft with what a message carries
+func (r *RawReceiver) applyMessage(t *rawTarget, msg *modes.Message, now time.Time) {
+	switch msg.DF {
+	case 17:
+		t.adsb.Type = rawTypeADSBICAO
+	case 18:
+		if msg.NonICAO {
+			t.adsb.Type = rawTypeADSBOther
+		} else if t.adsb.Type != rawTypeADSBICAO {
+			t.adsb.Type = rawTypeADSBNonTx
+		}
+	}
+
+	if msg.Callsign != "" {
+		t.adsb.Flight = msg.Callsign
+	}
+	if msg.Category != "" {
+		t.adsb.Category = msg.Category
+	}
+	if msg.Squawk != "" {
+		t.adsb.Squawk = msg.Squawk
+	}
+	if msg.Altitude != nil {
+		t.adsb.AltBaro = float64(*msg.Altitude)
+	}
+	if msg.AltitudeGNSS != nil {
+		t.adsb.AltGeom = float64(*msg.AltitudeGNSS)
+	}
+	if msg.GroundSpeed != nil {
+		t.adsb.GS = *msg.GroundSpeed
+	}
+	if msg.Track != nil {
+		t.adsb.Track = *msg.Track
+	}
+	if msg.Heading != nil {
+		t.adsb.MagHeading = *msg.Heading
+	}
+	if msg.IAS != nil {
+		t.adsb.IAS = *msg.IAS
+	}
+	if msg.TAS != nil {
+		t.adsb.TAS = *msg.TAS
+	}
+	if msg.VerticalRate != nil {
+		if msg.VerticalGNSS {
+			t.adsb.GeomRate = float64(*msg.VerticalRate)
+		} else {
+			t.adsb.BaroRate = float64(*msg.VerticalRate)
+		}
+	}
+
+	if msg.Position == nil {
+		return
+	}
+	if msg.Position.Surface {
+		// Surface positions carry no altitude; zero keeps the aircraft on the ground
+		t.adsb.AltBaro = 0
+		r.decodeSurface(t, *msg.Position, now)
+	} else {
+		r.decodeAirborne(t, *msg.Position, now)
+	}
+}
+
+// decodeAirborne works out an airborne position: globally from an even and odd pair when
+// both are recent, otherwise relative to the aircraft's last position
+func (r *RawReceiver) decodeAirborne(t *rawTarget, pos modes.CPRPosition, now time.Time) {
+	report := &cprReport{pos: pos, at: now}
+	if pos.Odd {
+		t.odd = report
+	} else {
+		t.even = report
+	}
+
+	if t.even != nil && t.odd != nil && !t.even.pos.Surface && !t.odd.pos.Surface &&
+		absDuration(t.even.at.Sub(t.odd.at)) <= cprPairWindow {
+		lat, lon, ok := modes.DecodeGlobal(t.even.pos, t.odd.pos, pos.Odd)
+		if ok && r.inRange(lat, lon, rawMaxRangeNM) && r.plausible(t, lat, lon, now) {
+			r.setPosition(t, lat, lon, now)
+			t.reception.CPRGlobal++
+			r.cprGlobal++
+			return
+		}
+		// A bad pair is discarded so the next one starts clean
+		t.even, t.odd = nil, nil
+		t.reception.CPRFailed++
+		r.cprFailed++
+		return
+	}
+
+	if t.posAt.IsZero() || now.Sub(t.posAt) > cprLocalMaxAge {
+		return // Waiting for a pair
+	}
+	lat, lon := modes.DecodeLocal(pos, t.adsb.Lat, t.adsb.Lon)
+	if !r.plausible(t, lat, lon, now) {
+		t.reception.CPRFailed++
+		r.cprFailed++
+		return
+	}
+	r.setPosition(t, lat, lon, now)
+	t.reception.CPRLocal++
+	r.cprLocal++
+}
+
+// decodeSurface works out a surface position relative to the aircraft's last position, or
+// the station for an aircraft that hasn't got one yet
+func (r *RawReceiver) decodeSurface(t *rawTarget, pos modes.CPRPosition, now time.Time) {
+	refLat, refLon := r.stationLat, r.stationLon
+	if !t.posAt.IsZero() && now.Sub(t.posAt) <= cprLocalMaxAge {
+		refLat, refLon = t.adsb.Lat, t.adsb.Lon
+	}
+	lat, lon := modes.DecodeLocal(pos, refLat, refLon)
+	if !r.inRange(lat, lon, surfaceMaxRangeNM) || !r.plausible(t, lat, lon, now) {
+		t.reception.CPRFailed++
+		r.cprFailed++
+		return
+	}
+	r.setPosition(t, lat, lon, now)
+	t.reception.CPRLocal++
+	r.cprLocal++
+}
+
+// inRange reports whether a position is within a range of the station
+func (r *RawReceiver) inRange(lat, lon, rangeNM float64) bool {
+	if lat < -90 || lat > 90 || lon < -180 || lon > 180 {
+		return false
+	}
+	if r.stationLat == 0 && r.stationLon == 0 {
+		return true // No station to check against
+	}
+	return MetersToNM(Haversine(r.stationLat, r.stationLon, lat, lon)) <= rangeNM
+}
+
+// plausible reports whether an aircraft could have moved to a position since its last one
+func (r *RawReceiver) plausible(t *rawTarget, lat, lon float64, now time.Time) bool {
+	if t.posAt.IsZero() || now.Sub(t.posAt) > cprLocalMaxAge {
+		return true
+	}
+	moved := MetersToNM(Haversine(t.adsb.Lat, t.adsb.Lon, lat, lon))
+	// A little slack for the resolution of the position and jitter in arrival times
+	return moved <= rawMaxSpeedKts*now.Sub(t.posAt).Hours()+0.5
+}
+
+// setPosition records a decoded position
+func (r *RawReceiver) setPosition(t *rawTarget, lat, lon float64, now time.Time) {
+	t.adsb.Lat = lat
+	t.adsb.Lon = lon
+	t.posAt = now
+	if r.stationLat != 0 || r.stationLon != 0 {
+		t.adsb.RDst = MetersToNM(Haversine(r.stationLat, r.stationLon, lat, lon))
+		t.adsb.RDir = CalculateBearing(r.stationLat, r.stationLon, lat, lon)
+	}
+}
+
+// Snapshot returns the aircraft heard recently, in the form the other sources return them,
+// and forgets aircraft not heard for a while
+func (r *RawReceiver) Snapshot() *RawAircraftData {
+	now := time.Now()
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	data := &RawAircraftData{
+		Now:      float64(now.UnixMilli()) / 1000,
+		Messages: int(r.accepted),
+		Aircraft: make([]ADSBTarget, 0, len(r.targets)),
+	}
+	for hex, t := range r.targets {
+		if now.Sub(t.lastSeen) > rawTargetTimeout {
+			delete(r.targets, hex)
+			continue
+		}
+		target := t.adsb
+		target.Seen = now.Sub(t.lastSeen).Seconds()
+		if !t.posAt.IsZero() {
+			target.SeenPos = now.Sub(t.posAt).Seconds()
+		}
+		target.SourceType = rawSourceTypeName
+		data.Aircraft = append(data.Aircraft, target)
+	}
+	return data
+}
+
+// Reception returns the reception statistics of an aircraft heard in the last minute
+func (r *RawReceiver) Reception(hex string) (ReceptionStats, bool) {
+	now := time.Now()
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	t, ok := r.targets[hex]
+	if !ok || now.Sub(t.lastSeen) > rawActiveWindow {
+		return ReceptionStats{}, false
+	}
+	return t.currentReception(now), true
+}
+
+// Stats returns the connection, decoding counters, coverage and the reception of each
+// aircraft heard in the last minute
+func (r *RawReceiver) Stats() *ReceiverStats {
+	now := time.Now()
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	stats := &ReceiverStats{
+		Address:        r.address,
+		Format:         r.format,
+		Connected:      r.connected,
+		Reconnects:     r.reconnects,
+		LastError:      r.lastError,
+		Frames:         r.frames,
+		ModeAC:         r.modeAC,
+		Accepted:       r.accepted,
+		BadCRC:         r.badCRC,
+		UnknownAddress: r.unknownAddress,
+		Unsupported:    r.unsupported,
+		ByDF:           make(map[string]int64, len(r.byDF)),
+		ByType:         make(map[string]int64, len(r.byType)),
+		CPRGlobal:      r.cprGlobal,
+		CPRLocal:       r.cprLocal,
+		CPRFailed:      r.cprFailed,
+		MessageRate:    roundRate(decayRate(r.rate, r.rateAt, now)),
+		Coverage:       make([]CoverageSector, coverageSectors),
+		Targets:        []ReceptionTarget{},
+		Timestamp:      now.UTC(),
+	}
+	if r.connected {
+		since := r.connectedSince.UTC()
+		stats.ConnectedSince = &since
+	}
+	for df, count := range r.byDF {
+		stats.ByDF[fmt.Sprintf("DF%d", df)] = count
+	}
+	for kind, count := range r.byType {
+		stats.ByType[kind] = count
+	}
+
+	sectorWidth := 360 / coverageSectors
+	rssiSums := make([]float64, coverageSectors)
+	rssiCounts := make([]int, coverageSectors)
+	for i := range stats.Coverage {
+		stats.Coverage[i].Bearing = i * sectorWidth
+	}
+
+	for hex, t := range r.targets {
+		if now.Sub(t.lastSeen) > rawActiveWindow {
+			continue
+		}
+		stats.Aircraft++
+		target := ReceptionTarget{
+			Hex:            hex,
+			Flight:         t.adsb.Flight,
+			ReceptionStats: t.currentReception(now),
+		}
+		if !t.posAt.IsZero() && now.Sub(t.posAt) <= rawActiveWindow {
+			stats.WithPosition++
+			if r.stationLat != 0 || r.stationLon != 0 {
+				distance := math.Round(t.adsb.RDst*10) / 10
+				bearing := math.Round(t.adsb.RDir)
+				target.DistanceNM = &distance
+				target.Bearing = &bearing
+
+				sector := int(t.adsb.RDir) / sectorWidth % coverageSectors
+				stats.Coverage[sector].Aircraft++
+				stats.Coverage[sector].MaxRangeNM = max(stats.Coverage[sector].MaxRangeNM, distance)
+				if t.reception.RSSI != 0 {
+					rssiSums[sector] += t.reception.RSSI
+					rssiCounts[sector]++
+				}
+			}
+		}
+		stats.Targets = append(stats.Targets, target)
+	}
+	for i := range stats.Coverage {
+		if rssiCounts[i] > 0 {
+			stats.Coverage[i].RSSI = math.Round(rssiSums[i]/float64(rssiCounts[i])*10) / 10
+		}
+	}
+
+	sort.Slice(stats.Targets, func(i, j int) bool {
+		a, b := stats.Targets[i].DistanceNM, stats.Targets[j].DistanceNM
+		if (a == nil) != (b == nil) {
+			return a != nil
+		}
+		if a != nil && *a != *b {
+			return *a > *b
+		}
+		return stats.Targets[i].Hex < stats.Targets[j].Hex
+	})
+	return stats
+}
+
+// currentReception returns an aircraft's reception statistics with the message rate decayed
+// to now
+func (t *rawTarget) currentReception(now time.Time) ReceptionStats {
+	reception := t.reception
+	reception.MessageRate = roundRate(decayRate(reception.MessageRate, t.rateAt, now))
+	reception.RSSI = math.Round(reception.RSSI*10) / 10
+	reception.PeakRSSI = math.Round(reception.PeakRSSI*10) / 10
+	reception.LastMessageAt = reception.LastMessageAt.UTC()
+	return reception
+}
+
+// decayRate returns an exponentially averaged message rate, last updated at a time, as of now
+func decayRate(rate float64, at, now time.Time) float64 {
+	if at.IsZero() {
+		return 0
+	}
+	return rate * math.Exp(-now.Sub(at).Seconds()/rateTimeConstant)
+}
+
+// roundRate rounds a message rate to a hundredth of a message per second
+func roundRate(rate float64) float64 {
+	return math.Round(rate*100) / 100
+}
+
+// absDuration returns the absolute value of a duration
+func absDuration(d time.Duration) time.Duration {
+	if d < 0 {
+		return -d
+	}
+	return d
+}
@@ -0,0 +1,165 @@
+package adsb
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/yegors/co-atc/internal/geomag"
+)
+
+// LoadRunwayData reads runway data from a runways.json file or an OurAirports runways.csv
+// file, chosen by extension. Runways are taken from the CSV for the airport code, leaving out
+// closed runways and runways without coordinates for both ends.
+func LoadRunwayData(path, airportCode string) (RunwayData, error) {
+	if strings.EqualFold(filepath.Ext(path), ".csv") {
+		return loadOurAirportsRunways(path, airportCode)
+	}
+
+	var runways RunwayData
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return runways, fmt.Errorf("failed to read runway data file: %w", err)
+	}
+	if err := json.Unmarshal(data, &runways); err != nil {
+		return runways, fmt.Errorf("failed to parse runway data: %w", err)
+	}
+	return runways, nil
+}
+
+// loadOurAirportsRunways reads the runways of an airport from an OurAirports runways.csv file
+func loadOurAirportsRunways(path, airportCode string) (RunwayData, error) {
+	runways := RunwayData{
+		Airport:          strings.ToUpper(airportCode),
+		RunwayThresholds: make(map[string]map[string]RunwayThresholdData),
+	}
+	if airportCode == "" {
+		return runways, fmt.Errorf("an airport code is required to read runways from %s", path)
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return runways, fmt.Errorf("failed to read runway data file: %w", err)
+	}
+	defer file.Close()
+
+	reader := csv.NewReader(file)
+	header, err := reader.Read()
+	if err != nil {
+		return runways, fmt.Errorf("failed to parse runway data: %w", err)
+	}
+	columns := make(map[string]int, len(header))
+	for i, name := range header {
+		columns[strings.TrimSpace(name)] = i
+	}
+	for _, name := range []string{"airport_ident", "le_ident", "le_latitude_deg", "le_longitude_deg", "he_ident", "he_latitude_deg", "he_longitude_deg"} {
+		if _, ok := columns[name]; !ok {
+			return runways, fmt.Errorf("failed to parse runway data: missing column %s", name)
+		}
+	}
+	field := func(record []string, name string) string {
+		if i, ok := columns[name]; ok && i < len(record) {
+			return strings.TrimSpace(record[i])
+		}
+		return ""
+	}
+	number := func(record []string, name string) (float64, bool) {
+		value, err := strconv.ParseFloat(field(record, name), 64)
+		return value, err == nil
+	}
+
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return runways, fmt.Errorf("failed to parse runway data: %w", err)
+		}
+		if !strings.EqualFold(field(record, "airport_ident"), airportCode) || field(record, "closed") == "1" {
+			continue
+		}
+
+		ends := make(map[string]RunwayThresholdData, 2)
+		ids := make([]string, 0, 2)
+		for _, prefix := range []string{"le_", "he_"} {
+			id := strings.ToUpper(field(record, prefix+"ident"))
+			lat, latOK := number(record, prefix+"latitude_deg")
+			lon, lonOK := number(record, prefix+"longitude_deg")
+			if id == "" || !latOK || !lonOK {
+				break
+			}
+			end := RunwayThresholdData{Latitude: lat, Longitude: lon}
+			end.ElevationFeet, _ = number(record, prefix+"elevation_ft")
+			end.DisplacedThresholdFeet, _ = number(record, prefix+"displaced_threshold_ft")
+			ends[id] = end
+			ids = append(ids, id)
+		}
+		if len(ends) != 2 {
+			continue
+		}
+		runways.RunwayThresholds[ids[0]+"-"+ids[1]] = ends
+	}
+
+	if len(runways.RunwayThresholds) == 0 {
+		return runways, fmt.Errorf("no runways for %s in %s", runways.Airport, path)
+	}
+	return runways, nil
+}
+
+// LandingThreshold returns the position of the threshold landings cross at a runway end: the
+// end itself, or past it toward the opposite end by the displacement
+func LandingThreshold(end, opposite RunwayThresholdData) (float64, float64) {
+	lengthFt := MetersToFeet(Haversine(end.Latitude, end.Longitude, opposite.Latitude, opposite.Longitude))
+	if end.DisplacedThresholdFeet <= 0 || lengthFt <= 0 {
+		return end.Latitude, end.Longitude
+	}
+	// Runways are short enough to interpolate along
+	fraction := min(end.DisplacedThresholdFeet/lengthFt, 1)
+	return end.Latitude + (opposite.Latitude-end.Latitude)*fraction,
+		end.Longitude + (opposite.Longitude-end.Longitude)*fraction
+}
+
+// Ends returns every runway end with a known opposite end, by threshold ID. Unknown threshold
+// elevations are the station elevation, and unknown magnetic headings the true heading
+// corrected for the declination.
+func (d RunwayData) Ends(stationElevFeet, declination float64) []RunwayEnd {
+	ends := make([]RunwayEnd, 0)
+	for pair, thresholds := range d.RunwayThresholds {
+		for id, threshold := range thresholds {
+			opposite, ok := thresholds[getOppositeThreshold(id, pair)]
+			if !ok {
+				continue
+			}
+			end := RunwayEnd{
+				ID:                     id,
+				Runway:                 pair,
+				Latitude:               threshold.Latitude,
+				Longitude:              threshold.Longitude,
+				Heading:                CalculateBearing(threshold.Latitude, threshold.Longitude, opposite.Latitude, opposite.Longitude),
+				MagneticHeading:        threshold.MagneticHeading,
+				LengthNM:               MetersToNM(Haversine(threshold.Latitude, threshold.Longitude, opposite.Latitude, opposite.Longitude)),
+				DisplacedThresholdFeet: threshold.DisplacedThresholdFeet,
+				ElevationFeet:          threshold.ElevationFeet,
+			}
+			end.ThresholdLatitude, end.ThresholdLongitude = LandingThreshold(threshold, opposite)
+			end.LandingLengthNM = MetersToNM(Haversine(end.ThresholdLatitude, end.ThresholdLongitude, opposite.Latitude, opposite.Longitude))
+			if end.MagneticHeading == 0 {
+				end.MagneticHeading = math.Round(geomag.ToMagnetic(end.Heading, declination)*10) / 10
+			}
+			if end.ElevationFeet == 0 {
+				end.ElevationFeet = stationElevFeet
+			}
+			ends = append(ends, end)
+		}
+	}
+	sort.Slice(ends, func(i, j int) bool { return ends[i].ID < ends[j].ID })
+	return ends
+}
@@ -0,0 +1,469 @@
+package adsb
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/yegors/co-atc/internal/websocket"
+	"github.com/yegors/co-atc/pkg/logger"
+)
+
+// ErrUnknownRunway is returned when a runway or threshold isn't in the runway data
+var ErrUnknownRunway = errors.New("unknown runway")
+
+// RunwayClosure marks a runway closed until it is reopened or the closure expires
+type RunwayClosure struct {
+	Runway   string     `json:"runway"` // Runway pair, e.g. "05-23"
+	Reason   string     `json:"reason,omitempty"`
+	ClosedAt time.Time  `json:"closed_at"`
+	Until    *time.Time `json:"until,omitempty"` // nil = until reopened
+}
+
+// RunwayConfiguration forces the thresholds in use for arrivals and departures
+type RunwayConfiguration struct {
+	Arrivals   []string   `json:"arrivals"`   // Threshold IDs, e.g. "05", "06L"
+	Departures []string   `json:"departures"` // Threshold IDs
+	SetAt      time.Time  `json:"set_at"`
+	Until      *time.Time `json:"until,omitempty"` // nil = until cleared
+}
+
+// RunwayStatus is the effective state of a runway threshold
+type RunwayStatus struct {
+	Threshold     string     `json:"threshold"` // e.g. "05"
+	Runway        string     `json:"runway"`    // e.g. "05-23"
+	Closed        bool       `json:"closed"`
+	ClosureReason string     `json:"closure_reason,omitempty"`
+	ClosedUntil   *time.Time `json:"closed_until,omitempty"`
+	Arrivals      bool       `json:"arrivals"`   // In use for arrivals
+	Departures    bool       `json:"departures"` // In use for departures
+	Source        string     `json:"source"`     // "override" when a configuration is forced, otherwise "default"
+}
+
+// RunwayState is the runway overrides and the resulting status of each threshold
+type RunwayState struct {
+	Airport       string               `json:"airport"`
+	Closures      []RunwayClosure      `json:"closures"`
+	Configuration *RunwayConfiguration `json:"configuration,omitempty"`
+	Runways       []RunwayStatus       `json:"runways"`
+}
+
+// RunwayEnd is a runway end, its landing threshold and the heading of the runway from it
+type RunwayEnd struct {
+	ID                     string  `json:"id"`       // Threshold ID, e.g. "06L"
+	Runway                 string  `json:"runway"`   // Runway pair, e.g. "06L-24R"
+	Latitude               float64 `json:"latitude"` // End of the runway
+	Longitude              float64 `json:"longitude"`
+	ThresholdLatitude      float64 `json:"threshold_latitude"` // Landing threshold, past the end when displaced
+	ThresholdLongitude     float64 `json:"threshold_longitude"`
+	DisplacedThresholdFeet float64 `json:"displaced_threshold_ft"`
+	ElevationFeet          float64 `json:"elevation_ft"`      // Threshold elevation; the station elevation when unknown
+	Heading                float64 `json:"heading"`           // True heading toward the opposite end
+	MagneticHeading        float64 `json:"magnetic_heading"`  // Published, else the true heading converted with the declination
+	LengthNM               float64 `json:"length_nm"`         // Distance to the opposite end
+	LandingLengthNM        float64 `json:"landing_length_nm"` // Distance from the landing threshold to the opposite end
+}
+
+// runwayOverrides holds the manual runway closures and forced configuration
+type runwayOverrides struct {
+	closures      map[string]*RunwayClosure // By runway pair
+	configuration *RunwayConfiguration
+	alerted       map[string]bool // hex+threshold of aircraft already alerted for the runway they're using
+	mu            sync.RWMutex
+}
+
+// resolveRunway maps a runway pair ("05-23") or threshold ("05") to its runway pair
+func (s *Service) resolveRunway(id string) (string, error) {
+	id = strings.ToUpper(strings.TrimSpace(id))
+	for pair, thresholds := range s.runwayData.RunwayThresholds {
+		if strings.EqualFold(pair, id) {
+			return pair, nil
+		}
+		if _, ok := thresholds[id]; ok {
+			return pair, nil
+		}
+	}
+	return "", fmt.Errorf("%w: %s", ErrUnknownRunway, id)
+}
+
+// resolveThresholds validates a list of threshold IDs
+func (s *Service) resolveThresholds(ids []string) ([]string, error) {
+	resolved := make([]string, 0, len(ids))
+	for _, id := range ids {
+		id = strings.ToUpper(strings.TrimSpace(id))
+		found := false
+		for _, thresholds := range s.runwayData.RunwayThresholds {
+			if _, ok := thresholds[id]; ok {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("%w: %s", ErrUnknownRunway, id)
+		}
+		resolved = append(resolved, id)
+	}
+	return resolved, nil
+}
+
+// CloseRunway marks a runway closed. The runway is a pair ("05-23") or either of its thresholds.
+func (s *Service) CloseRunway(runway, reason string, until *time.Time) (*RunwayClosure, error) {
+	pair, err := s.resolveRunway(runway)
+	if err != nil {
+		return nil, err
+	}
+
+	closure := &RunwayClosure{
+		Runway:   pair,
+		Reason:   reason,
+		ClosedAt: time.Now().UTC(),
+		Until:    until,
+	}
+
+	s.runways.mu.Lock()
+	s.runways.closures[pair] = closure
+	s.runways.mu.Unlock()
+
+	s.logger.Info("Runway closed", logger.String("runway", pair), logger.String("reason", reason))
+	s.broadcastRunwayState()
+	return closure, nil
+}
+
+// OpenRunway reopens a closed runway
+func (s *Service) OpenRunway(runway string) error {
+	pair, err := s.resolveRunway(runway)
+	if err != nil {
+		return err
+	}
+
+	s.runways.mu.Lock()
+	delete(s.runways.closures, pair)
+	s.runways.mu.Unlock()
+
+	s.logger.Info("Runway opened", logger.String("runway", pair))
+	s.broadcastRunwayState()
+	return nil
+}
+
+// SetRunwayConfiguration forces the thresholds in use, overriding the default of every open runway
+func (s *Service) SetRunwayConfiguration(arrivals, departures []string, until *time.Time) (*RunwayConfiguration, error) {
+	if len(arrivals) == 0 && len(departures) == 0 {
+		return nil, fmt.Errorf("%w: configuration needs at least one arrival or departure runway", ErrUnknownRunway)
+	}
+	arrivals, err := s.resolveThresholds(arrivals)
+	if err != nil {
+		return nil, err
+	}
+	departures, err = s.resolveThresholds(departures)
+	if err != nil {
+		return nil, err
+	}
+
+	configuration := &RunwayConfiguration{
+		Arrivals:   arrivals,
+		Departures: departures,
+		SetAt:      time.Now().UTC(),
+		Until:      until,
+	}
+
+	s.runways.mu.Lock()
+	s.runways.configuration = configuration
+	s.runways.mu.Unlock()
+
+	s.logger.Info("Runway configuration set",
+		logger.String("arrivals", strings.Join(arrivals, ",")),
+		logger.String("departures", strings.Join(departures, ",")))
+	s.broadcastRunwayState()
+	return configuration, nil
+}
+
+// ClearRunwayConfiguration removes the forced runway configuration
+func (s *Service) ClearRunwayConfiguration() {
+	s.runways.mu.Lock()
+	s.runways.configuration = nil
+	s.runways.mu.Unlock()
+
+	s.logger.Info("Runway configuration cleared")
+	s.broadcastRunwayState()
+}
+
+// expireRunwayOverrides drops closures and configurations whose end time has passed.
+// Must be called with the runway lock held.
+func (s *Service) expireRunwayOverrides(now time.Time) bool {
+	changed := false
+	for pair, closure := range s.runways.closures {
+		if closure.Until != nil && now.After(*closure.Until) {
+			delete(s.runways.closures, pair)
+			changed = true
+		}
+	}
+	if c := s.runways.configuration; c != nil && c.Until != nil && now.After(*c.Until) {
+		s.runways.configuration = nil
+		changed = true
+	}
+	return changed
+}
+
+// GetRunwayState returns the runway overrides and the effective status of each threshold
+func (s *Service) GetRunwayState() *RunwayState {
+	s.runways.mu.Lock()
+	defer s.runways.mu.Unlock()
+	s.expireRunwayOverrides(time.Now())
+
+	state := &RunwayState{
+		Airport:  s.runwayData.Airport,
+		Closures: make([]RunwayClosure, 0, len(s.runways.closures)),
+		Runways:  []RunwayStatus{},
+	}
+	for _, closure := range s.runways.closures {
+		state.Closures = append(state.Closures, *closure)
+	}
+	sort.Slice(state.Closures, func(i, j int) bool { return state.Closures[i].Runway < state.Closures[j].Runway })
+	if s.runways.configuration != nil {
+		configuration := *s.runways.configuration
+		state.Configuration = &configuration
+	}
+
+	for pair, thresholds := range s.runwayData.RunwayThresholds {
+		for threshold := range thresholds {
+			state.Runways = append(state.Runways, s.thresholdStatus(pair, threshold))
+		}
+	}
+	sort.Slice(state.Runways, func(i, j int) bool { return state.Runways[i].Threshold < state.Runways[j].Threshold })
+
+	return state
+}
+
+// GetRunwayStatus returns the effective status of a threshold, or nil if it isn't known
+func (s *Service) GetRunwayStatus(threshold string) *RunwayStatus {
+	threshold = strings.ToUpper(threshold)
+	for pair, thresholds := range s.runwayData.RunwayThresholds {
+		if _, ok := thresholds[threshold]; ok {
+			s.runways.mu.RLock()
+			status := s.thresholdStatus(pair, threshold)
+			s.runways.mu.RUnlock()
+			return &status
+		}
+	}
+	return nil
+}
+
+// RunwayHeadings returns the true heading of each threshold, from the threshold toward the
+// opposite one, by threshold ID
+func (s *Service) RunwayHeadings() map[string]float64 {
+	headings := make(map[string]float64)
+	for _, end := range s.RunwayEnds() {
+		headings[end.ID] = end.Heading
+	}
+	return headings
+}
+
+// RunwayEnds returns every threshold with a known opposite threshold, by threshold ID
+func (s *Service) RunwayEnds() []RunwayEnd {
+	return s.runwayData.Ends(s.stationElevFeet, s.Declination())
+}
+
+// thresholdStatus works out the status of a threshold. Must be called with the runway lock held.
+func (s *Service) thresholdStatus(pair, threshold string) RunwayStatus {
+	status := RunwayStatus{
+		Threshold:  threshold,
+		Runway:     pair,
+		Arrivals:   true,
+		Departures: true,
+		Source:     "default",
+	}
+
+	now := time.Now()
+	if c := s.runways.configuration; c != nil && (c.Until == nil || now.Before(*c.Until)) {
+		status.Arrivals = containsString(c.Arrivals, threshold)
+		status.Departures = containsString(c.Departures, threshold)
+		status.Source = "override"
+	}
+
+	if closure, ok := s.runways.closures[pair]; ok && (closure.Until == nil || now.Before(*closure.Until)) {
+		status.Closed = true
+		status.ClosureReason = closure.Reason
+		status.ClosedUntil = closure.Until
+		status.Arrivals = false
+		status.Departures = false
+	}
+
+	return status
+}
+
+// hasRunwayOverrides reports whether any runway is closed or a configuration is forced
+func (s *Service) hasRunwayOverrides() bool {
+	s.runways.mu.RLock()
+	defer s.runways.mu.RUnlock()
+	return len(s.runways.closures) > 0 || s.runways.configuration != nil
+}
+
+// broadcastRunwayState sends the runway state to WebSocket clients
+func (s *Service) broadcastRunwayState() {
+	if s.wsServer == nil {
+		return
+	}
+	s.wsServer.Broadcast(&websocket.Message{
+		Type: "runway_status",
+		Data: map[string]interface{}{
+			"state": s.GetRunwayState(),
+		},
+	})
+}
+
+// checkRunwayUse alerts when an aircraft approaches or departs a closed runway, or a runway
+// the forced configuration doesn't use for that operation. Each aircraft is alerted once
+// per threshold.
+func (s *Service) checkRunwayUse(aircraft []*Aircraft) {
+	if s.runwayData.Airport == "" {
+		return
+	}
+
+	s.runways.mu.Lock()
+	expired := s.expireRunwayOverrides(time.Now())
+	s.runways.mu.Unlock()
+	if expired {
+		s.broadcastRunwayState()
+	}
+
+	if !s.hasRunwayOverrides() {
+		s.runways.mu.Lock()
+		s.runways.alerted = make(map[string]bool)
+		s.runways.mu.Unlock()
+		return
+	}
+
+	config := s.flightPhasesConfig
+	current := make(map[string]bool)
+	declination := s.Declination()
+
+	for _, a := range aircraft {
+		if a.ADSB == nil || a.OnGround || a.Status != "active" {
+			continue
+		}
+
+		operation := ""
+		threshold := ""
+		course := TrueCourse(a.ADSB, declination)
+		if a.ADSB.AltBaro <= float64(config.TakeoffAltitudeThresholdFt) && a.ADSB.BaroRate < 0 {
+			if info := DetectRunwayApproach(a.ADSB.Lat, a.ADSB.Lon, course, a.ADSB.AltBaro, s.runwayData, config); info != nil {
+				operation, threshold = "arrival", runwayThresholdID(info.RunwayID)
+			}
+		} else if a.ADSB.AltBaro <= float64(config.DepartureAltitudeFt) && a.ADSB.BaroRate > 0 {
+			if info := DetectRunwayDeparture(a.ADSB.Lat, a.ADSB.Lon, course, s.runwayData, s.stationLat, s.stationLon, config); info != nil && info.OnDeparture {
+				operation, threshold = "departure", runwayThresholdID(info.RunwayID)
+			}
+		}
+		if threshold == "" {
+			continue
+		}
+
+		status := s.GetRunwayStatus(threshold)
+		if status == nil {
+			continue
+		}
+		inUse := status.Arrivals
+		if operation == "departure" {
+			inUse = status.Departures
+		}
+		if inUse {
+			continue
+		}
+
+		key := a.Hex + "/" + threshold
+		current[key] = true
+
+		s.runways.mu.RLock()
+		alreadyAlerted := s.runways.alerted[key]
+		s.runways.mu.RUnlock()
+		if alreadyAlerted {
+			continue
+		}
+
+		s.sendRunwayAlert(a, operation, status)
+	}
+
+	s.runways.mu.Lock()
+	s.runways.alerted = current
+	s.runways.mu.Unlock()
+}
+
+// sendRunwayAlert reports an aircraft using a runway that isn't in use for its operation
+func (s *Service) sendRunwayAlert(a *Aircraft, operation string, status *RunwayStatus) {
+	callsign := strings.TrimSpace(a.Flight)
+	if callsign == "" {
+		callsign = strings.ToUpper(a.Hex)
+	}
+
+	reason := "not in use for " + operation + "s"
+	if status.Closed {
+		reason = "closed"
+		if status.ClosureReason != "" {
+			reason += " (" + status.ClosureReason + ")"
+		}
+	}
+
+	s.logger.Warn("Aircraft using runway that is not in use",
+		logger.String("hex", a.Hex),
+		logger.String("flight", callsign),
+		logger.String("runway", status.Threshold),
+		logger.String("operation", operation),
+		logger.String("reason", reason))
+
+	data := map[string]interface{}{
+		"hex":       a.Hex,
+		"flight":    callsign,
+		"runway":    status.Threshold,
+		"operation": operation,
+		"closed":    status.Closed,
+		"reason":    reason,
+		"lat":       a.ADSB.Lat,
+		"lon":       a.ADSB.Lon,
+		"alt":       a.ADSB.AltBaro,
+	}
+
+	if s.wsServer != nil {
+		s.wsServer.Broadcast(&websocket.Message{
+			Type: "runway_alert",
+			Data: data,
+		})
+	}
+
+	if s.alertNotifier != nil && !isReplayed(a) {
+		s.alertNotifier.NotifyAlert(
+			"runway",
+			fmt.Sprintf("%s %s runway %s", callsign, operationVerb(operation), status.Threshold),
+			fmt.Sprintf("Runway %s is %s; %s at %.0f ft", status.Threshold, reason, callsign, a.ADSB.AltBaro),
+			data,
+		)
+	}
+}
+
+// runwayThresholdID returns the threshold of a detected runway ID ("05-23/05" -> "05")
+func runwayThresholdID(runwayID string) string {
+	if i := strings.LastIndex(runwayID, "/"); i >= 0 {
+		return runwayID[i+1:]
+	}
+	return runwayID
+}
+
+// operationVerb describes an aircraft's runway operation for alerts
+func operationVerb(operation string) string {
+	if operation == "departure" {
+		return "departing"
+	}
+	return "approaching"
+}
+
+// containsString reports whether a slice contains a string
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
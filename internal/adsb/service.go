@@ -56,6 +56,7 @@ user notifications.
 import (
 	"context"
 	"encoding/json"
+	"fmt"
 	"math"
 	"os"
 	"strings"
@@ -63,6 +64,7 @@ import (
 	"time"
 
 	"github.com/yegors/co-atc/internal/config"
+	"github.com/yegors/co-atc/internal/geomag"
 	"github.com/yegors/co-atc/internal/websocket"
 	"github.com/yegors/co-atc/pkg/logger"
 )
@@ -72,6 +74,11 @@ type WebSocketServer interface {
 	Broadcast(message *websocket.Message)
 }
 
+// AlertNotifier receives alerts that should reach users outside the web UI, such as push notifications
+type AlertNotifier interface {
+	NotifyAlert(alertType, title, body string, data map[string]interface{})
+}
+
 // Airline represents an airline from the airlines.json file
 type Airline struct {
 	ID       string `json:"id"`
@@ -94,6 +101,7 @@ type Storage interface {
 		tookOffAfter, tookOffBefore, landedAfter, landedBefore *time.Time,
 	) []*Aircraft
 	Upsert(aircraft *Aircraft)
+	UpsertWithoutPosition(aircraft *Aircraft) // Updates the aircraft without storing an ADS-B target row
 	Count() int
 	GetAllPositionHistory(hex string) ([]Position, error)
 	GetPositionHistoryWithLimit(hex string, limit int) ([]Position, error)
@@ -112,13 +120,31 @@ type Storage interface {
 	InsertPhaseChangesBatch(changes []PhaseChangeInsert) error
 }
 
+// Target types of aircraft that aren't live traffic
+const (
+	TargetTypeSimulated = "sim"    // Simulated aircraft
+	TargetTypeReplay    = "replay" // Aircraft replayed from recorded history
+)
+
+// isReplayed reports whether an aircraft is replayed from recorded history. Replayed aircraft
+// go through the whole pipeline, but their alerts stay in the web UI.
+func isReplayed(a *Aircraft) bool {
+	return a.ADSB != nil && a.ADSB.Type == TargetTypeReplay
+}
+
+// isSimulatedTarget reports whether ADS-B data is simulated or replayed rather than received
+func isSimulatedTarget(targetType string) bool {
+	return targetType == TargetTypeSimulated || targetType == TargetTypeReplay
+}
+
 // SimulationService defines the interface for simulation service
 type SimulationService interface {
 	UpdatePositions()
 	GenerateADSBData() []ADSBTarget
 	IsSimulated(hex string) bool
-	GetAllAircraft() interface{}                // Returns simulation aircraft data
-	GetAircraft(hex string) (interface{}, bool) // Returns specific simulated aircraft
+	GetAllAircraft() interface{}                   // Returns simulation aircraft data
+	GetAircraft(hex string) (interface{}, bool)    // Returns specific simulated aircraft
+	FuelEstimate(hex string) (*FuelEstimate, bool) // Returns the fuel state of a simulated aircraft
 }
 
 // Service is the main service for ADS-B data processing
@@ -141,6 +167,9 @@ type Service struct {
 	overrideLat        *float64                  // Override station latitude (nil = use config)
 	overrideLon        *float64                  // Override station longitude (nil = use config)
 	overrideMutex      sync.RWMutex              // Protect override coordinates
+	declination        declinationCache          // Magnetic declination at the station
+	guidance           guidanceSettings          // Glidepaths for approach guidance
+	intents            IntentSource              // Intents inferred from clearances and tracks (nil = none)
 	wsServer           WebSocketServer           // WebSocket server for broadcasting events
 	signalLostTimeout  time.Duration             // Time after which aircraft is marked as signal_lost
 	runwayData         RunwayData                // Runway data for approach detection
@@ -148,6 +177,20 @@ type Service struct {
 	changeDetector     *ChangeDetector           // Tracks aircraft changes
 	broadcastChan      chan []AircraftChange     // Channel for broadcasting changes
 	simulationService  SimulationService         // Simulation service for simulated aircraft
+	cycleID            string                    // Correlation ID of the poll cycle being processed
+	pendingSettings    *config.RuntimeSettings   // Settings to apply at the start of the next poll cycle
+	settingsCh         chan struct{}             // Wakes the fetch loop when settings change
+	alertNotifier      AlertNotifier             // Receives emergency alerts (nil = disabled)
+	emergencySquawks   map[string]string         // Emergency squawk of each aircraft currently squawking one
+	trackHistory       *TrackHistory             // Recent track/altitude/speed samples per aircraft
+	runways            *runwayOverrides          // Manual runway closures and forced configuration
+	callsigns          *CallsignRegistry         // Callsign, hex and registration of active aircraft
+	updateListeners    []func([]*Aircraft)       // Called with the aircraft of every poll cycle
+	estimateFuel       bool                      // Estimate fuel burn of real aircraft with known types
+	budget             *processingBudget         // Caps per-cycle work in budget mode
+	watchlists         watchlistState            // User watchlists and the aircraft seen for watchlist events
+	blocked            blocklist                 // Aircraft whose owners asked not to be tracked publicly
+	broadcastDelay     func() time.Duration      // How long WebSocket updates are held back (nil = none)
 }
 
 // AircraftBulkResponse represents server response with bulk aircraft data
@@ -184,6 +227,11 @@ func NewService(
 		maxPositionsInAPI:  maxPositionsInAPI,
 		logger:             logger.Named("adsb"),
 		stopCh:             make(chan struct{}),
+		settingsCh:         make(chan struct{}, 1),
+		emergencySquawks:   make(map[string]string),
+		runways:            &runwayOverrides{closures: make(map[string]*RunwayClosure), alerted: make(map[string]bool)},
+		trackHistory:       NewTrackHistory(DefaultTrackHistorySamples, DefaultTrackHistoryMaxAge),
+		callsigns:          NewCallsignRegistry(),
 		airlineMap:         make(map[string]string),
 		airlineDBPath:      airlineDBPath,
 		stationLat:         stationCfg.Latitude,
@@ -193,15 +241,18 @@ func NewService(
 		signalLostTimeout:  signalLostTimeout,
 		flightPhasesConfig: flightPhasesConfig,
 		simulationService:  simulationService,
+		estimateFuel:       adsbCfg.EstimateFuel,
+		budget:             newProcessingBudget(adsbCfg),
 	}
 
 	// CRITICAL FIX: Only enable WebSocket streaming if configured
 	if adsbCfg.WebSocketAircraftUpdates {
 		logger.Info("Aircraft streaming ENABLED - initializing WebSocket change detection")
 		service.changeDetector = NewChangeDetector(logger)
+		service.changeDetector.coarse = adsbCfg.BudgetMode
 		service.broadcastChan = make(chan []AircraftChange, 100)
 		// Start broadcast worker
-		service.startBroadcastWorker()
+		service.startBroadcastWorker(service.broadcastChan)
 	} else {
 		logger.Info("Aircraft streaming DISABLED - using HTTP polling only")
 		// No change detection or broadcasting
@@ -230,7 +281,7 @@ func NewService(
 
 	// Load runway data
 	if stationCfg.RunwaysDBPath != "" {
-		if err := service.loadRunwayData(stationCfg.RunwaysDBPath); err != nil {
+		if err := service.loadRunwayData(stationCfg.RunwaysDBPath, stationCfg.AirportCode); err != nil {
 			service.logger.Error("Failed to load runway data: " + err.Error())
 		}
 	}
@@ -238,49 +289,119 @@ func NewService(
 	return service
 }
 
-// startBroadcastWorker starts the worker that broadcasts aircraft changes via WebSocket
-func (s *Service) startBroadcastWorker() {
-	go func() {
-		for changes := range s.broadcastChan {
-			for _, change := range changes {
-				s.broadcastAircraftChange(change)
-			}
-		}
-	}()
+// delayedChanges are the changes of a poll cycle held back until they are due
+type delayedChanges struct {
+	due     time.Time
+	changes []AircraftChange
 }
 
-// broadcastAircraftChange broadcasts a single aircraft change via WebSocket
-func (s *Service) broadcastAircraftChange(change AircraftChange) {
-	var messageType string
-	switch change.Type {
-	case "added":
-		messageType = "aircraft_added"
-	case "updated":
-		messageType = "aircraft_update"
-	case "removed":
-		messageType = "aircraft_removed"
-	}
+// SetBroadcastDelay holds back WebSocket aircraft updates by the duration the function
+// returns, so the map matches audio that lags the air. HTTP responses stay live.
+func (s *Service) SetBroadcastDelay(delay func() time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.broadcastDelay = delay
+}
 
-	data := map[string]interface{}{
-		"type": change.Type,
-		"hex":  change.Hex,
+// currentBroadcastDelay returns how long WebSocket updates are held back
+func (s *Service) currentBroadcastDelay() time.Duration {
+	s.mu.RLock()
+	delay := s.broadcastDelay
+	s.mu.RUnlock()
+	if delay == nil {
+		return 0
 	}
+	return max(delay(), 0)
+}
 
-	if change.Aircraft != nil {
-		data["aircraft"] = change.Aircraft
-	}
+// startBroadcastWorker starts the worker that broadcasts aircraft changes via WebSocket.
+// Poll cycles are held back by the broadcast delay; cycles that are due together, or that
+// the worker fell behind on, are coalesced into a single batch.
+func (s *Service) startBroadcastWorker(broadcastChan chan []AircraftChange) {
+	go func() {
+		var queue []delayedChanges
+		timer := time.NewTimer(time.Hour)
+		timer.Stop()
+		defer timer.Stop()
+
+		for {
+			// Wait for a poll cycle, or for the oldest held-back one to be due
+			var wake <-chan time.Time
+			if len(queue) > 0 {
+				timer.Reset(time.Until(queue[0].due))
+				wake = timer.C
+			}
 
-	// Removed "changes" field - we now always send full aircraft data
-	// This aligns WebSocket payloads with HTTP API responses
+			select {
+			case changes, ok := <-broadcastChan:
+				if !ok {
+					return
+				}
+				due := time.Now().Add(s.currentBroadcastDelay())
+				queue = append(queue, delayedChanges{due: due, changes: changes})
+			drain:
+				for {
+					select {
+					case more, ok := <-broadcastChan:
+						if !ok {
+							break drain
+						}
+						queue = append(queue, delayedChanges{due: due, changes: more})
+					default:
+						break drain
+					}
+				}
+			case <-wake:
+			}
+			timer.Stop()
 
-	message := &websocket.Message{
-		Type: messageType,
-		Data: data,
-	}
+			now := time.Now()
+			var pending [][]AircraftChange
+			for len(queue) > 0 && !queue[0].due.After(now) {
+				pending = append(pending, queue[0].changes)
+				queue = queue[1:]
+			}
+			if len(pending) > 0 {
+				s.broadcastAircraftBatch(coalesceChanges(pending...))
+			}
+		}
+	}()
+}
 
-	if s.wsServer != nil {
-		s.wsServer.Broadcast(message)
+// broadcastAircraftBatch broadcasts the changes from a poll cycle as a single aircraft_batch message
+func (s *Service) broadcastAircraftBatch(changes []AircraftChange) {
+	if len(changes) == 0 || s.wsServer == nil {
+		return
 	}
+
+	adds := make([]*Aircraft, 0)
+	updates := make([]*Aircraft, 0)
+	removes := make([]string, 0)
+	correlationID := ""
+
+	for _, change := range changes {
+		switch change.Type {
+		case "added":
+			adds = append(adds, change.Aircraft)
+		case "updated":
+			updates = append(updates, change.Aircraft)
+		case "removed":
+			removes = append(removes, change.Hex)
+		}
+		// The batch is attributed to the most recent poll cycle it contains
+		correlationID = change.CorrelationID
+	}
+
+	// Always send full aircraft data so WebSocket payloads match HTTP API responses
+	s.wsServer.Broadcast(&websocket.Message{
+		Type: websocket.MessageTypeAircraftBatch,
+		Data: map[string]interface{}{
+			"adds":           adds,
+			"updates":        updates,
+			"removes":        removes,
+			"correlation_id": correlationID,
+		},
+	})
 }
 
 // loadAirlineData loads airline data from the airlines.json file
@@ -323,20 +444,15 @@ func (s *Service) loadAirlineData() error {
 	return nil
 }
 
-// loadRunwayData loads runway data from the runways.json file
-func (s *Service) loadRunwayData(runwayDBPath string) error {
+// loadRunwayData loads runway data from a runways.json or OurAirports runways.csv file
+func (s *Service) loadRunwayData(runwayDBPath, airportCode string) error {
 	s.logger.Info("Loading runway data from: " + runwayDBPath)
 
-	// Read the file
-	data, err := os.ReadFile(runwayDBPath)
+	runwayData, err := LoadRunwayData(runwayDBPath, airportCode)
 	if err != nil {
 		return err
 	}
-
-	// Parse the JSON
-	if err := json.Unmarshal(data, &s.runwayData); err != nil {
-		return err
-	}
+	s.runwayData = runwayData
 
 	s.logger.Info("Loaded runway data",
 		logger.String("airport", s.runwayData.Airport),
@@ -390,6 +506,19 @@ func (s *Service) sendPhaseChangeAlertWithEvent(aircraft *Aircraft, fromPhase, t
 	}
 }
 
+// SetAlertNotifier sets the notifier that receives emergency alerts. Must be called before Start.
+func (s *Service) SetAlertNotifier(notifier AlertNotifier) {
+	s.alertNotifier = notifier
+}
+
+// OnUpdate registers a function that is called with the aircraft seen in every poll cycle,
+// after they have been stored. Listeners must not modify the aircraft.
+func (s *Service) OnUpdate(fn func(aircraft []*Aircraft)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.updateListeners = append(s.updateListeners, fn)
+}
+
 // Start starts the ADS-B service
 func (s *Service) Start(ctx context.Context) error {
 	s.logger.Info("Starting ADS-B service",
@@ -435,6 +564,10 @@ func (s *Service) fetchLoop(ctx context.Context) {
 			} else {
 				s.setFetchStatus(true)
 			}
+		case <-s.settingsCh:
+			if s.applyPendingSettings() {
+				ticker.Reset(s.fetchInterval)
+			}
 		case <-s.stopCh:
 			return
 		case <-ctx.Done():
@@ -443,12 +576,80 @@ func (s *Service) fetchLoop(ctx context.Context) {
 	}
 }
 
+// UpdateSettings schedules new runtime settings. They are applied by the fetch loop
+// between poll cycles, so processing never sees a half-applied configuration.
+func (s *Service) UpdateSettings(settings config.RuntimeSettings) {
+	s.mu.Lock()
+	s.pendingSettings = &settings
+	s.mu.Unlock()
+
+	select {
+	case s.settingsCh <- struct{}{}:
+	default:
+		// Fetch loop has already been signalled
+	}
+}
+
+// applyPendingSettings applies settings scheduled by UpdateSettings. It must only be
+// called from the fetch loop. Returns true if the fetch interval changed.
+func (s *Service) applyPendingSettings() bool {
+	s.mu.Lock()
+	settings := s.pendingSettings
+	s.pendingSettings = nil
+	s.mu.Unlock()
+
+	if settings == nil {
+		return false
+	}
+
+	s.flightPhasesConfig = settings.FlightPhases
+
+	signalLostTimeout := time.Duration(settings.ADSB.SignalLostTimeoutSecs) * time.Second
+	if signalLostTimeout == 0 {
+		signalLostTimeout = 60 * time.Second // Default to 60 seconds
+	}
+	s.signalLostTimeout = signalLostTimeout
+
+	// Toggle WebSocket aircraft streaming
+	if settings.ADSB.WebSocketAircraftUpdates && s.changeDetector == nil {
+		s.logger.Info("Aircraft streaming ENABLED at runtime")
+		s.changeDetector = NewChangeDetector(s.logger)
+		s.broadcastChan = make(chan []AircraftChange, 100)
+		s.startBroadcastWorker(s.broadcastChan)
+	} else if !settings.ADSB.WebSocketAircraftUpdates && s.changeDetector != nil {
+		s.logger.Info("Aircraft streaming DISABLED at runtime")
+		close(s.broadcastChan)
+		s.changeDetector = nil
+		s.broadcastChan = nil
+	}
+
+	fetchInterval := time.Duration(settings.ADSB.FetchIntervalSecs) * time.Second
+	intervalChanged := fetchInterval > 0 && fetchInterval != s.fetchInterval
+	if intervalChanged {
+		s.fetchInterval = fetchInterval
+	}
+
+	s.logger.Info("Applied runtime settings",
+		logger.Duration("fetch_interval", s.fetchInterval),
+		logger.Duration("signal_lost_timeout", s.signalLostTimeout),
+		logger.Bool("websocket_aircraft_updates", s.changeDetector != nil))
+
+	return intervalChanged
+}
+
 // fetchAndProcess fetches and processes ADS-B data
 func (s *Service) fetchAndProcess(ctx context.Context) error {
+	// Every poll cycle gets its own correlation ID so phase changes and
+	// WebSocket updates can be traced back to the fetch that produced them
+	cycleID := logger.NewCorrelationID("poll")
+	s.setCycleID(cycleID)
+	cycleStart := time.Now()
+	cycleLogger := s.logger.WithCorrelationID(cycleID)
+
 	// Fetch raw data
 	rawData, err := s.client.FetchData(ctx)
 	if err != nil {
-		return err
+		return fmt.Errorf("poll cycle %s: %w", cycleID, err)
 	}
 
 	// Update simulated aircraft positions and inject simulated data
@@ -459,7 +660,7 @@ func (s *Service) fetchAndProcess(ctx context.Context) error {
 		// Append simulated aircraft to raw data
 		rawData.Aircraft = append(rawData.Aircraft, simulatedTargets...)
 
-		s.logger.Debug("Injected simulated aircraft into ADSB data",
+		cycleLogger.Debug("Injected simulated aircraft into ADSB data",
 			logger.Int("count", len(simulatedTargets)))
 	}
 
@@ -545,7 +746,7 @@ func (s *Service) fetchAndProcess(ctx context.Context) error {
 	if len(immediatePhaseChanges) > 0 {
 		err := s.storage.InsertPhaseChangesBatch(immediatePhaseChanges)
 		if err != nil {
-			s.logger.Error("Failed to insert immediate ground transition phases", logger.Error(err))
+			cycleLogger.Error("Failed to insert immediate ground transition phases", logger.Error(err))
 		} else {
 			// Send immediate alerts for takeoff/landing events
 			s.sendImmediateGroundTransitionAlerts(immediatePhaseChanges)
@@ -585,10 +786,21 @@ func (s *Service) fetchAndProcess(ctx context.Context) error {
 	}
 
 	// NOW update all aircraft in the database after ground state transitions have been detected
+	storePositions := s.budget.samplePositions(newAircraft, immediatePhaseChanges)
 	for _, a := range newAircraft {
-		s.storage.Upsert(a)
+		if storePositions == nil || storePositions[a.Hex] {
+			s.storage.Upsert(a)
+		} else {
+			s.storage.UpsertWithoutPosition(a)
+		}
 	}
 
+	s.recordTrackSamples(newAircraft)
+	s.callsigns.Update(newAircraft, s.signalLostTimeout)
+	s.detectEmergencies(newAircraft)
+	s.checkRunwayUse(newAircraft)
+	s.checkWatchlists(newAircraft, immediatePhaseChanges)
+
 	// Update status of existing aircraft that are no longer active
 	s.updateAircraftStatus(activeAircraft)
 
@@ -603,30 +815,170 @@ func (s *Service) fetchAndProcess(ctx context.Context) error {
 		changes := s.changeDetector.DetectChanges(allAircraft)
 
 		if len(changes) > 0 {
-			s.logger.Debug("Detected aircraft changes",
+			for i := range changes {
+				changes[i].CorrelationID = cycleID
+			}
+
+			cycleLogger.Debug("Detected aircraft changes",
 				logger.Int("change_count", len(changes)))
 
 			select {
 			case s.broadcastChan <- changes:
 			default:
-				s.logger.Warn("Broadcast channel full, dropping changes")
+				cycleLogger.Warn("Broadcast channel full, dropping changes")
 			}
 		}
 	}
 
-	s.logger.Debug("Updated aircraft data",
+	cycleLogger.Debug("Updated aircraft data",
 		logger.Int("count", len(newAircraft)),
 		logger.Int("total", s.storage.Count()),
 	)
 
+	var suppressed int64
+	if s.changeDetector != nil {
+		suppressed = s.changeDetector.Suppressed()
+	}
+	s.budget.recordCycle(len(newAircraft), time.Since(cycleStart), suppressed)
+
+	s.mu.RLock()
+	listeners := make([]func([]*Aircraft), len(s.updateListeners))
+	copy(listeners, s.updateListeners)
+	s.mu.RUnlock()
+
+	s.updateSimulationFields(newAircraft)
+	s.classifyAircraft(newAircraft)
+	s.attachGuidance(newAircraft)
+	s.attachIntents(newAircraft)
+	for _, fn := range listeners {
+		fn(newAircraft)
+	}
+
 	return nil
 }
 
+// recordTrackSamples adds the aircraft of a poll cycle to the track history and drops
+// aircraft that have not reported recently
+func (s *Service) recordTrackSamples(aircraft []*Aircraft) {
+	now := time.Now().UTC()
+
+	for _, a := range aircraft {
+		if a.ADSB == nil {
+			continue
+		}
+
+		// Date the sample by when the data was received, so repeated polls of
+		// the same message are recognized as duplicates
+		timestamp := now.Add(-time.Duration(a.ADSB.Seen * float64(time.Second)))
+
+		s.trackHistory.Add(a.Hex, TrackSample{
+			Timestamp: timestamp,
+			Lat:       a.ADSB.Lat,
+			Lon:       a.ADSB.Lon,
+			Track:     a.ADSB.Track,
+			AltBaro:   a.ADSB.AltBaro,
+			GS:        a.ADSB.GS,
+			TAS:       a.ADSB.TAS,
+			BaroRate:  a.ADSB.BaroRate,
+			OnGround:  a.OnGround,
+		})
+	}
+
+	s.trackHistory.Prune(now)
+}
+
+// TrackHistory returns the in-memory track history of all aircraft
+func (s *Service) TrackHistory() *TrackHistory {
+	return s.trackHistory
+}
+
+// detectEmergencies notifies when an aircraft starts squawking an emergency code.
+// An aircraft is only alerted again after it changes code or leaves and reappears.
+func (s *Service) detectEmergencies(aircraft []*Aircraft) {
+	current := make(map[string]string)
+
+	for _, a := range aircraft {
+		if a.ADSB == nil || !isEmergencySquawk(a.ADSB.Squawk, s.flightPhasesConfig.EmergencySquawkCodes) {
+			continue
+		}
+		current[a.Hex] = a.ADSB.Squawk
+
+		if s.emergencySquawks[a.Hex] == a.ADSB.Squawk {
+			continue
+		}
+
+		s.logger.Warn("Aircraft started squawking emergency",
+			logger.String("hex", a.Hex),
+			logger.String("flight", a.Flight),
+			logger.String("squawk", a.ADSB.Squawk))
+
+		if s.alertNotifier != nil && !isReplayed(a) {
+			callsign := strings.TrimSpace(a.Flight)
+			if callsign == "" {
+				callsign = strings.ToUpper(a.Hex)
+			}
+			s.alertNotifier.NotifyAlert(
+				"emergency",
+				fmt.Sprintf("%s squawking %s", callsign, a.ADSB.Squawk),
+				fmt.Sprintf("%s (%s) at %.0f ft, %.0f kts", callsign, emergencySquawkMeaning(a.ADSB.Squawk), a.ADSB.AltBaro, a.ADSB.GS),
+				map[string]interface{}{
+					"hex":    a.Hex,
+					"flight": callsign,
+					"squawk": a.ADSB.Squawk,
+					"lat":    a.ADSB.Lat,
+					"lon":    a.ADSB.Lon,
+					"alt":    a.ADSB.AltBaro,
+				},
+			)
+		}
+	}
+
+	s.emergencySquawks = current
+}
+
+// isEmergencySquawk checks if a squawk code is one of the configured emergency codes
+func isEmergencySquawk(squawk string, codes []string) bool {
+	for _, code := range codes {
+		if squawk == code {
+			return true
+		}
+	}
+	return false
+}
+
+// emergencySquawkMeaning describes a standard emergency squawk code
+func emergencySquawkMeaning(squawk string) string {
+	switch squawk {
+	case "7500":
+		return "unlawful interference"
+	case "7600":
+		return "radio failure"
+	case "7700":
+		return "general emergency"
+	default:
+		return "emergency"
+	}
+}
+
+// setCycleID records the correlation ID of the poll cycle being processed
+func (s *Service) setCycleID(id string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.cycleID = id
+}
+
+// currentCycleID returns the correlation ID of the poll cycle being processed
+func (s *Service) currentCycleID() string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.cycleID
+}
+
 // updateSimulationFields updates the IsSimulated field and simulation controls for aircraft
 func (s *Service) updateSimulationFields(aircraft []*Aircraft) {
 	for _, a := range aircraft {
 		if a.ADSB != nil {
-			a.IsSimulated = (s.simulationService != nil && s.simulationService.IsSimulated(a.Hex)) || a.ADSB.Type == "sim"
+			a.IsSimulated = (s.simulationService != nil && s.simulationService.IsSimulated(a.Hex)) || isSimulatedTarget(a.ADSB.Type)
 
 			// Update simulation controls if this is a simulated aircraft
 			if a.IsSimulated && a.SimulationControls == nil {
@@ -644,6 +996,12 @@ func (s *Service) updateSimulationFields(aircraft []*Aircraft) {
 func (s *Service) GetAllAircraft() []*Aircraft {
 	aircraft := s.storage.GetAll()
 	s.updateSimulationFields(aircraft)
+	s.updateFuelEstimates(aircraft)
+	s.tagWatchlists(aircraft)
+	s.classifyAircraft(aircraft)
+	s.attachReception(aircraft)
+	s.attachGuidance(aircraft)
+	s.attachIntents(aircraft)
 	return aircraft
 }
 
@@ -652,10 +1010,78 @@ func (s *Service) GetAircraftByHex(hex string) (*Aircraft, bool) {
 	aircraft, found := s.storage.GetByHex(hex)
 	if found && aircraft != nil {
 		s.updateSimulationFields([]*Aircraft{aircraft})
+		s.updateFuelEstimates([]*Aircraft{aircraft})
+		s.tagWatchlists([]*Aircraft{aircraft})
+		s.classifyAircraft([]*Aircraft{aircraft})
+		s.attachReception([]*Aircraft{aircraft})
+		s.attachGuidance([]*Aircraft{aircraft})
+		s.attachIntents([]*Aircraft{aircraft})
 	}
 	return aircraft, found
 }
 
+// attachReception sets the reception statistics of aircraft heard by the raw source
+func (s *Service) attachReception(aircraft []*Aircraft) {
+	raw := s.client.RawReceiver()
+	if raw == nil {
+		return
+	}
+	for _, a := range aircraft {
+		if a == nil {
+			continue
+		}
+		a.Reception = nil
+		if reception, ok := raw.Reception(a.Hex); ok {
+			a.Reception = &reception
+		}
+	}
+}
+
+// ExternalPollStats returns how the external API is being polled, or false if the source
+// isn't external
+func (s *Service) ExternalPollStats() (ExternalPollStats, bool) {
+	return s.client.ExternalPollStats()
+}
+
+// UATStats returns how the UAT source is being fetched, or false if there's none
+func (s *Service) UATStats() (UATStats, bool) {
+	return s.client.UATStats()
+}
+
+// ReceiverStats returns the raw source's connection and decoding statistics, or false if
+// the source isn't raw
+func (s *Service) ReceiverStats() (*ReceiverStats, bool) {
+	raw := s.client.RawReceiver()
+	if raw == nil {
+		return nil, false
+	}
+	return raw.Stats(), true
+}
+
+// Callsigns returns the registry of active callsigns, hex codes and registrations
+func (s *Service) Callsigns() *CallsignRegistry {
+	return s.callsigns
+}
+
+// GetAircraftByCallsign returns the active aircraft a callsign, registration or hex code refers to
+func (s *Service) GetAircraftByCallsign(identifier string) (*Aircraft, bool) {
+	if entry, ok := s.callsigns.Lookup(identifier); ok {
+		return s.GetAircraftByHex(entry.Hex)
+	}
+
+	// Fall back to aircraft that are no longer active
+	normalized := NormalizeCallsign(identifier)
+	if normalized == "" {
+		return nil, false
+	}
+	for _, a := range s.GetAllAircraft() {
+		if NormalizeCallsign(a.Flight) == normalized {
+			return a, true
+		}
+	}
+	return nil, false
+}
+
 // GetAllPositionHistory returns all position history for an aircraft
 func (s *Service) GetAllPositionHistory(hex string) ([]Position, error) {
 	return s.storage.GetAllPositionHistory(hex)
@@ -1113,12 +1539,13 @@ func (s *Service) detectGroundStateTransitions(aircraft []*Aircraft) []PhaseChan
 				adsbId, _ := s.storage.GetLatestADSBTargetID(a.Hex)
 
 				immediatePhaseChanges = append(immediatePhaseChanges, PhaseChangeInsert{
-					Hex:       a.Hex,
-					Flight:    a.Flight,
-					Phase:     newPhase,
-					Timestamp: now,
-					ADSBId:    adsbId,
-					EventType: eventType, // New field to track the type of transition
+					Hex:           a.Hex,
+					Flight:        a.Flight,
+					Phase:         newPhase,
+					Timestamp:     now,
+					ADSBId:        adsbId,
+					EventType:     eventType, // New field to track the type of transition
+					CorrelationID: s.currentCycleID(),
 				})
 			}
 		}
@@ -1174,12 +1601,13 @@ func (s *Service) detectSignalLostLandings(inactiveAircraft []*Aircraft) []Phase
 			// Create T/D phase record
 			adsbId, _ := s.storage.GetLatestADSBTargetID(aircraft.Hex)
 			landingPhaseChanges = append(landingPhaseChanges, PhaseChangeInsert{
-				Hex:       aircraft.Hex,
-				Flight:    aircraft.Flight,
-				Phase:     "T/D",
-				Timestamp: now,
-				ADSBId:    adsbId,
-				EventType: "signal_lost_landing",
+				Hex:           aircraft.Hex,
+				Flight:        aircraft.Flight,
+				Phase:         "T/D",
+				Timestamp:     now,
+				ADSBId:        adsbId,
+				EventType:     "signal_lost_landing",
+				CorrelationID: s.currentCycleID(),
 			})
 
 			s.logger.Info("Signal lost aircraft marked as landed",
@@ -1266,7 +1694,7 @@ func (s *Service) detectRunwayDeparture(aircraft *Aircraft) *RunwayDepartureInfo
 	return DetectRunwayDeparture(
 		aircraft.ADSB.Lat,
 		aircraft.ADSB.Lon,
-		aircraft.ADSB.Track,
+		TrueCourse(aircraft.ADSB, s.Declination()),
 		s.runwayData,
 		s.stationLat,
 		s.stationLon,
@@ -1363,14 +1791,16 @@ func (s *Service) determineFlightPhase(aircraft *Aircraft) string {
 	// Only check approach phase for low altitude aircraft (below typical pattern altitude)
 	if altitude <= float64(config.TakeoffAltitudeThresholdFt) {
 		// Check if aircraft is aligned with any runway (extended centerline)
-		runwayInfo := DetectRunwayApproach(adsb.Lat, adsb.Lon, adsb.Track, altitude, s.runwayData, config)
+		// Runway alignment is checked against the true course; magnetic headings are converted
+		course := TrueCourse(adsb, s.Declination())
+		runwayInfo := DetectRunwayApproach(adsb.Lat, adsb.Lon, course, altitude, s.runwayData, config)
 		if runwayInfo != nil && runwayInfo.OnApproach {
 			onRunwayCenterline = true
 
 			// IMPORTANT: Verify aircraft is flying TOWARDS the airport, not away
 			// This prevents departing aircraft from being marked as approaching
 			bearingToStation := CalculateBearing(adsb.Lat, adsb.Lon, s.stationLat, s.stationLon)
-			headingDiff := math.Abs(adsb.Track - bearingToStation)
+			headingDiff := math.Abs(course - bearingToStation)
 			if headingDiff > 180 {
 				headingDiff = 360 - headingDiff
 			}
@@ -1480,11 +1910,12 @@ func (s *Service) processPhaseChangesBatch(aircraft []*Aircraft, immediatePhaseC
 
 		if shouldInsert {
 			phaseChanges = append(phaseChanges, PhaseChangeInsert{
-				Hex:       a.Hex,
-				Flight:    a.Flight,
-				Phase:     finalPhase,
-				Timestamp: time.Now().UTC(),
-				ADSBId:    adsbTargetIDs[a.Hex],
+				Hex:           a.Hex,
+				Flight:        a.Flight,
+				Phase:         finalPhase,
+				Timestamp:     time.Now().UTC(),
+				ADSBId:        adsbTargetIDs[a.Hex],
+				CorrelationID: s.currentCycleID(),
 			})
 		}
 	}
@@ -1668,6 +2099,7 @@ func (s *Service) sendPhaseChangeAlerts(phaseChanges []PhaseChangeInsert, curren
 
 		if previousPhase == "" {
 			s.logger.Info("New aircraft phase detected",
+				logger.String("correlation_id", change.CorrelationID),
 				logger.String("hex", aircraft.Hex),
 				logger.String("flight", aircraft.Flight),
 				logger.String("phase", change.Phase),
@@ -1679,6 +2111,7 @@ func (s *Service) sendPhaseChangeAlerts(phaseChanges []PhaseChangeInsert, curren
 			)
 		} else {
 			s.logger.Info("Phase change detected",
+				logger.String("correlation_id", change.CorrelationID),
 				logger.String("hex", aircraft.Hex),
 				logger.String("flight", aircraft.Flight),
 				logger.String("transition", previousPhase+" → "+change.Phase),
@@ -1694,14 +2127,15 @@ func (s *Service) sendPhaseChangeAlerts(phaseChanges []PhaseChangeInsert, curren
 		if s.wsServer != nil {
 			// Create message data for phase change
 			data := map[string]interface{}{
-				"hex":        aircraft.Hex,
-				"flight":     aircraft.Flight,
-				"phase":      change.Phase,
-				"prev_phase": previousPhase,
-				"transition": previousPhase + " → " + change.Phase,
-				"altitude":   aircraft.ADSB.AltBaro,
-				"on_ground":  aircraft.OnGround,
-				"timestamp":  change.Timestamp.Format(time.RFC3339),
+				"hex":            aircraft.Hex,
+				"flight":         aircraft.Flight,
+				"phase":          change.Phase,
+				"prev_phase":     previousPhase,
+				"transition":     previousPhase + " → " + change.Phase,
+				"altitude":       aircraft.ADSB.AltBaro,
+				"on_ground":      aircraft.OnGround,
+				"timestamp":      change.Timestamp.Format(time.RFC3339),
+				"correlation_id": change.CorrelationID,
 			}
 
 			// Broadcast the phase change message
@@ -1714,6 +2148,7 @@ func (s *Service) sendPhaseChangeAlerts(phaseChanges []PhaseChangeInsert, curren
 		// Handle special takeoff/landing phases
 		if change.Phase == "T/O" {
 			s.logger.Info("Aircraft TOOK OFF",
+				logger.String("correlation_id", change.CorrelationID),
 				logger.String("hex", aircraft.Hex),
 				logger.String("flight", aircraft.Flight),
 				logger.String("transition", previousPhase+" → T/O"),
@@ -1724,6 +2159,7 @@ func (s *Service) sendPhaseChangeAlerts(phaseChanges []PhaseChangeInsert, curren
 			// T/O phase change message is sent by the main phase change handler
 		} else if change.Phase == "T/D" {
 			s.logger.Info("Aircraft LANDED",
+				logger.String("correlation_id", change.CorrelationID),
 				logger.String("hex", aircraft.Hex),
 				logger.String("flight", aircraft.Flight),
 				logger.String("transition", previousPhase+" → T/D"),
@@ -1754,6 +2190,7 @@ func (s *Service) sendImmediateGroundTransitionAlerts(phaseChanges []PhaseChange
 
 		if change.Phase == "T/O" {
 			s.logger.Info("Aircraft TOOK OFF (IMMEDIATE)",
+				logger.String("correlation_id", change.CorrelationID),
 				logger.String("hex", aircraft.Hex),
 				logger.String("flight", aircraft.Flight),
 				logger.Float64("altitude", aircraft.ADSB.AltBaro),
@@ -1764,14 +2201,15 @@ func (s *Service) sendImmediateGroundTransitionAlerts(phaseChanges []PhaseChange
 			if s.wsServer != nil {
 				// Send phase_change message
 				phaseData := map[string]interface{}{
-					"hex":        aircraft.Hex,
-					"flight":     aircraft.Flight,
-					"phase":      change.Phase,
-					"prev_phase": previousPhase,
-					"transition": previousPhase + " → " + change.Phase,
-					"altitude":   aircraft.ADSB.AltBaro,
-					"on_ground":  aircraft.OnGround,
-					"timestamp":  change.Timestamp.Format(time.RFC3339),
+					"hex":            aircraft.Hex,
+					"flight":         aircraft.Flight,
+					"phase":          change.Phase,
+					"prev_phase":     previousPhase,
+					"transition":     previousPhase + " → " + change.Phase,
+					"altitude":       aircraft.ADSB.AltBaro,
+					"on_ground":      aircraft.OnGround,
+					"timestamp":      change.Timestamp.Format(time.RFC3339),
+					"correlation_id": change.CorrelationID,
 				}
 
 				s.wsServer.Broadcast(&websocket.Message{
@@ -1784,6 +2222,7 @@ func (s *Service) sendImmediateGroundTransitionAlerts(phaseChanges []PhaseChange
 
 		} else if change.Phase == "T/D" {
 			s.logger.Info("Aircraft LANDED (IMMEDIATE)",
+				logger.String("correlation_id", change.CorrelationID),
 				logger.String("hex", aircraft.Hex),
 				logger.String("flight", aircraft.Flight),
 				logger.Float64("altitude", aircraft.ADSB.AltBaro),
@@ -1794,14 +2233,15 @@ func (s *Service) sendImmediateGroundTransitionAlerts(phaseChanges []PhaseChange
 			if s.wsServer != nil {
 				// Send phase_change message
 				phaseData := map[string]interface{}{
-					"hex":        aircraft.Hex,
-					"flight":     aircraft.Flight,
-					"phase":      change.Phase,
-					"prev_phase": previousPhase,
-					"transition": previousPhase + " → " + change.Phase,
-					"altitude":   aircraft.ADSB.AltBaro,
-					"on_ground":  aircraft.OnGround,
-					"timestamp":  change.Timestamp.Format(time.RFC3339),
+					"hex":            aircraft.Hex,
+					"flight":         aircraft.Flight,
+					"phase":          change.Phase,
+					"prev_phase":     previousPhase,
+					"transition":     previousPhase + " → " + change.Phase,
+					"altitude":       aircraft.ADSB.AltBaro,
+					"on_ground":      aircraft.OnGround,
+					"timestamp":      change.Timestamp.Format(time.RFC3339),
+					"correlation_id": change.CorrelationID,
 				}
 
 				s.wsServer.Broadcast(&websocket.Message{
@@ -1823,6 +2263,7 @@ func (s *Service) ProcessRawData(rawData *RawAircraftData) []*Aircraft {
 
 	aircraft := make([]*Aircraft, 0, len(rawData.Aircraft))
 	now := time.Now().UTC() // Ensure we use UTC time
+	declination := s.Declination()
 
 	// Create a map of active aircraft hex codes
 	activeAircraft := make(map[string]bool)
@@ -1837,6 +2278,10 @@ func (s *Service) ProcessRawData(rawData *RawAircraftData) []*Aircraft {
 		existingAircraftMap[a.Hex] = true
 	}
 
+	// In budget mode only the aircraft nearest the station get future positions
+	stationLat, stationLon := s.GetEffectiveStationCoords()
+	predict := s.budget.predictionSet(rawData.Aircraft, stationLat, stationLon)
+
 	for _, raw := range rawData.Aircraft {
 		// Skip aircraft without position data
 		if raw.Lat == 0 && raw.Lon == 0 {
@@ -1947,7 +2392,7 @@ func (s *Service) ProcessRawData(rawData *RawAircraftData) []*Aircraft {
 		aircraftStatus := "active" // Always set to active for aircraft in current ADSB data
 
 		// Check if this is a simulated aircraft
-		isSimulated := (s.simulationService != nil && s.simulationService.IsSimulated(raw.Hex)) || raw.Type == "sim"
+		isSimulated := (s.simulationService != nil && s.simulationService.IsSimulated(raw.Hex)) || isSimulatedTarget(raw.Type)
 		var simulationControls *SimulationControls
 
 		if isSimulated {
@@ -2007,13 +2452,13 @@ func (s *Service) ProcessRawData(rawData *RawAircraftData) []*Aircraft {
 
 		// Calculate future positions if we have the necessary data
 		if raw.Lat != 0 && raw.Lon != 0 && raw.AltBaro != 0 {
-			// Get heading (use true_heading, track, or mag_heading, whichever is available)
+			// Get heading (use true_heading, track, or mag_heading converted to true, whichever is available)
 			heading := raw.TrueHeading
 			if heading == 0 {
 				heading = raw.Track
 			}
-			if heading == 0 {
-				heading = raw.MagHeading
+			if heading == 0 && raw.MagHeading != 0 {
+				heading = geomag.ToTrue(raw.MagHeading, declination)
 			}
 
 			// Get speed (use TAS or GS, whichever is available)
@@ -2029,13 +2474,12 @@ func (s *Service) ProcessRawData(rawData *RawAircraftData) []*Aircraft {
 			}
 
 			// Only predict if we have valid heading and speed
-			if heading != 0 && speed != 0 {
+			if heading != 0 && speed != 0 && predict != nil && !predict[raw.Hex] {
+				s.budget.skipPrediction()
+			} else if heading != 0 && speed != 0 {
 				// Calculate future positions
-				// Get magnetic heading for predictions
-				magHeading := raw.MagHeading
-				if magHeading == 0 {
-					magHeading = heading // fallback to whatever heading we found
-				}
+				// Get magnetic heading for predictions, converting the true heading if none is sent
+				magHeading := MagneticHeading(&raw, declination)
 
 				futurePredictions := PredictFuturePositions(
 					raw.Lat,
@@ -2045,6 +2489,7 @@ func (s *Service) ProcessRawData(rawData *RawAircraftData) []*Aircraft {
 					magHeading, // magnetic heading
 					speed,
 					verticalRate,
+					raw.TAS != 0 && raw.TrueHeading != 0,
 				)
 
 				// Add future predictions to the aircraft
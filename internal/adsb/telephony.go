@@ -0,0 +1,124 @@
+package adsb
+
+import (
+	"regexp"
+)
+
+// airlineCallsign matches an airline callsign: a three letter ICAO designator and a flight number
+var airlineCallsign = regexp.MustCompile(`^([A-Z]{3})([0-9][0-9A-Z]*)$`)
+
+// airlineTelephony maps ICAO airline designators to the telephony designator controllers
+// and pilots say on the radio ("JZA" is "Jazz", "SWA" is "Southwest")
+var airlineTelephony = map[string]string{
+	// Canada
+	"ACA": "Air Canada",
+	"JZA": "Jazz",
+	"ROU": "Rouge",
+	"WJA": "WestJet",
+	"WEN": "Encore",
+	"SWG": "Sunwing",
+	"TSC": "Air Transat",
+	"POE": "Porter",
+	"FLE": "Flair",
+	"CJT": "Cargojet",
+	"PAG": "Perimeter",
+	"MAL": "Morningstar",
+	"NRL": "Nolinor",
+	"GGN": "Georgian",
+	"PVL": "Provincial",
+	"CRQ": "Creebec",
+	"CNK": "Canadian North",
+
+	// United States
+	"AAL": "American",
+	"DAL": "Delta",
+	"UAL": "United",
+	"SWA": "Southwest",
+	"JBU": "JetBlue",
+	"ASA": "Alaska",
+	"NKS": "Spirit Wings",
+	"FFT": "Frontier Flight",
+	"AAY": "Allegiant",
+	"SKW": "SkyWest",
+	"RPA": "Brickyard",
+	"ENY": "Envoy",
+	"EDV": "Endeavor",
+	"JIA": "Blue Streak",
+	"PDT": "Piedmont",
+	"ASH": "Air Shuttle",
+	"GJS": "Lindbergh",
+	"UPS": "UPS",
+	"FDX": "FedEx",
+	"GTI": "Giant",
+	"ABX": "Abex",
+	"ATN": "Air Transport",
+	"NAC": "Northern Air Cargo",
+	"EJA": "Execjet",
+	"LXJ": "Flexjet",
+
+	// International
+	"BAW": "Speedbird",
+	"VIR": "Virgin",
+	"AFR": "Air France",
+	"KLM": "KLM",
+	"DLH": "Lufthansa",
+	"SWR": "Swiss",
+	"AUA": "Austrian",
+	"IBE": "Iberia",
+	"TAP": "Air Portugal",
+	"AZA": "Alitalia",
+	"EIN": "Shamrock",
+	"ICE": "Ice Air",
+	"SAS": "Scandinavian",
+	"FIN": "Finnair",
+	"LOT": "LOT",
+	"THY": "Turkish",
+	"UAE": "Emirates",
+	"QTR": "Qatari",
+	"ETD": "Etihad",
+	"ELY": "El Al",
+	"ETH": "Ethiopian",
+	"RAM": "Royal Air Maroc",
+	"PIA": "Pakistan",
+	"AIC": "Air India",
+	"CPA": "Cathay",
+	"CCA": "Air China",
+	"CES": "China Eastern",
+	"CSN": "China Southern",
+	"HDA": "Dragon",
+	"JAL": "Japan Air",
+	"ANA": "All Nippon",
+	"KAL": "Korean Air",
+	"AAR": "Asiana",
+	"EVA": "EVA",
+	"SIA": "Singapore",
+	"QFA": "Qantas",
+	"ANZ": "New Zealand",
+	"AMX": "Aeromexico",
+	"VOI": "Volaris",
+	"CMP": "Copa",
+	"AVA": "Avianca",
+	"LAN": "LAN",
+	"TAM": "TAM",
+	"BWA": "Caribbean",
+	"CXP": "Caribbean Express",
+	"BHS": "Bahamas",
+	"CLX": "Cargolux",
+	"GEC": "Lufthansa Cargo",
+	"BOX": "Germancargo",
+}
+
+// Telephony returns how an airline callsign is spoken on the radio ("ACA123" is
+// "Air Canada 123"), or an empty string if the callsign isn't an airline's or the
+// airline is unknown
+func Telephony(callsign string) string {
+	match := airlineCallsign.FindStringSubmatch(NormalizeCallsign(callsign))
+	if match == nil {
+		return ""
+	}
+	designator, ok := airlineTelephony[match[1]]
+	if !ok {
+		return ""
+	}
+	return designator + " " + match[2]
+}
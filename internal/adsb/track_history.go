@@ -0,0 +1,271 @@
+package adsb
+
+import (
+	"math"
+	"sync"
+	"time"
+)
+
+// Defaults for the per-aircraft track history. At a 1 second poll interval 300 samples
+// cover five minutes, enough for a full holding pattern circuit.
+const (
+	DefaultTrackHistorySamples = 300
+	DefaultTrackHistoryMaxAge  = 10 * time.Minute
+
+	// minSampleInterval absorbs jitter in receive times derived from polls of the same message
+	minSampleInterval = 500 * time.Millisecond
+)
+
+// TrackSample is a single observation of an aircraft's track, altitude and speed
+type TrackSample struct {
+	Timestamp time.Time // When the data was received, not when it was polled
+	Lat       float64
+	Lon       float64
+	Track     float64 // Degrees true
+	AltBaro   float64 // Feet
+	GS        float64 // Knots
+	TAS       float64 // Knots
+	BaroRate  float64 // Feet per minute
+	OnGround  bool
+}
+
+// TrackHistory keeps a short in-memory ring buffer of recent samples for each aircraft.
+// Unlike the position history in SQLite it is cheap to query every poll cycle, and is
+// meant for derived values such as turn rate, smoothing and anomaly checks.
+// It is safe for concurrent use.
+type TrackHistory struct {
+	capacity int
+	maxAge   time.Duration
+	rings    map[string]*sampleRing
+	mu       sync.RWMutex
+}
+
+// sampleRing is a fixed-size ring buffer of samples
+type sampleRing struct {
+	samples []TrackSample
+	start   int // Index of the oldest sample
+	count   int
+}
+
+// NewTrackHistory creates a track history keeping up to capacity samples per aircraft.
+// Aircraft without a sample newer than maxAge are dropped by Prune.
+func NewTrackHistory(capacity int, maxAge time.Duration) *TrackHistory {
+	if capacity <= 0 {
+		capacity = DefaultTrackHistorySamples
+	}
+	if maxAge <= 0 {
+		maxAge = DefaultTrackHistoryMaxAge
+	}
+
+	return &TrackHistory{
+		capacity: capacity,
+		maxAge:   maxAge,
+		rings:    make(map[string]*sampleRing),
+	}
+}
+
+// Add records a sample for an aircraft. Samples less than half a second newer than the
+// latest sample are ignored, so polling faster than the aircraft reports adds no duplicates.
+func (h *TrackHistory) Add(hex string, sample TrackSample) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	ring, exists := h.rings[hex]
+	if !exists {
+		ring = &sampleRing{samples: make([]TrackSample, h.capacity)}
+		h.rings[hex] = ring
+	}
+
+	if ring.count > 0 && sample.Timestamp.Sub(ring.latest().Timestamp) < minSampleInterval {
+		return
+	}
+
+	ring.push(sample)
+}
+
+// Samples returns a copy of an aircraft's samples, oldest first
+func (h *TrackHistory) Samples(hex string) []TrackSample {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	ring, exists := h.rings[hex]
+	if !exists {
+		return nil
+	}
+	return ring.slice(0)
+}
+
+// SamplesSince returns a copy of an aircraft's samples taken at or after since, oldest first
+func (h *TrackHistory) SamplesSince(hex string, since time.Time) []TrackSample {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	ring, exists := h.rings[hex]
+	if !exists {
+		return nil
+	}
+
+	// Samples are in time order, so skip the ones that are too old
+	skip := 0
+	for skip < ring.count && ring.at(skip).Timestamp.Before(since) {
+		skip++
+	}
+	return ring.slice(skip)
+}
+
+// Latest returns an aircraft's most recent sample
+func (h *TrackHistory) Latest(hex string) (TrackSample, bool) {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	ring, exists := h.rings[hex]
+	if !exists || ring.count == 0 {
+		return TrackSample{}, false
+	}
+	return ring.latest(), true
+}
+
+// Remove drops an aircraft's history
+func (h *TrackHistory) Remove(hex string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	delete(h.rings, hex)
+}
+
+// Prune drops the history of aircraft that have not reported within the max age
+func (h *TrackHistory) Prune(now time.Time) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	cutoff := now.Add(-h.maxAge)
+	for hex, ring := range h.rings {
+		if ring.count == 0 || ring.latest().Timestamp.Before(cutoff) {
+			delete(h.rings, hex)
+		}
+	}
+}
+
+// Len returns the number of aircraft with history
+func (h *TrackHistory) Len() int {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return len(h.rings)
+}
+
+// TurnRate estimates an aircraft's rate of turn in degrees per second over the window
+// ending at its latest sample. Positive values are right turns. It reports false if
+// there are not enough airborne samples in the window.
+func (h *TrackHistory) TurnRate(hex string, window time.Duration) (float64, bool) {
+	turn, elapsed, ok := h.turn(hex, window)
+	if !ok || elapsed <= 0 {
+		return 0, false
+	}
+	return turn / elapsed.Seconds(), true
+}
+
+// CumulativeTurn returns the total signed change of track in degrees over the window
+// ending at an aircraft's latest sample. Values beyond ±360 mean the aircraft has
+// completed an orbit, e.g. in a holding pattern.
+func (h *TrackHistory) CumulativeTurn(hex string, window time.Duration) (float64, bool) {
+	turn, _, ok := h.turn(hex, window)
+	return turn, ok
+}
+
+// SmoothedTrack returns an aircraft's track averaged over the window, which removes
+// jitter in the reported track while still following turns
+func (h *TrackHistory) SmoothedTrack(hex string, window time.Duration) (float64, bool) {
+	samples := h.windowSamples(hex, window)
+	if len(samples) == 0 {
+		return 0, false
+	}
+
+	// Average the unit vectors so tracks either side of north average correctly
+	var sumSin, sumCos float64
+	for _, sample := range samples {
+		rad := sample.Track * math.Pi / 180
+		sumSin += math.Sin(rad)
+		sumCos += math.Cos(rad)
+	}
+
+	track := math.Atan2(sumSin, sumCos) * 180 / math.Pi
+	if track < 0 {
+		track += 360
+	}
+	return track, true
+}
+
+// turn sums the signed track changes of the airborne samples in the window
+func (h *TrackHistory) turn(hex string, window time.Duration) (float64, time.Duration, bool) {
+	samples := h.windowSamples(hex, window)
+
+	var airborne []TrackSample
+	for _, sample := range samples {
+		if !sample.OnGround {
+			airborne = append(airborne, sample)
+		}
+	}
+	if len(airborne) < 2 {
+		return 0, 0, false
+	}
+
+	total := 0.0
+	for i := 1; i < len(airborne); i++ {
+		total += trackDelta(airborne[i-1].Track, airborne[i].Track)
+	}
+
+	elapsed := airborne[len(airborne)-1].Timestamp.Sub(airborne[0].Timestamp)
+	return total, elapsed, true
+}
+
+// windowSamples returns the samples in the window ending at the aircraft's latest sample
+func (h *TrackHistory) windowSamples(hex string, window time.Duration) []TrackSample {
+	latest, ok := h.Latest(hex)
+	if !ok {
+		return nil
+	}
+	return h.SamplesSince(hex, latest.Timestamp.Add(-window))
+}
+
+// trackDelta returns the signed shortest change from one track to another, in (-180, 180]
+func trackDelta(from, to float64) float64 {
+	delta := math.Mod(to-from, 360)
+	if delta > 180 {
+		delta -= 360
+	} else if delta <= -180 {
+		delta += 360
+	}
+	return delta
+}
+
+// push appends a sample, overwriting the oldest when full
+func (r *sampleRing) push(sample TrackSample) {
+	if r.count < len(r.samples) {
+		r.samples[(r.start+r.count)%len(r.samples)] = sample
+		r.count++
+		return
+	}
+	r.samples[r.start] = sample
+	r.start = (r.start + 1) % len(r.samples)
+}
+
+// at returns the i-th oldest sample
+func (r *sampleRing) at(i int) TrackSample {
+	return r.samples[(r.start+i)%len(r.samples)]
+}
+
+// latest returns the newest sample; the ring must not be empty
+func (r *sampleRing) latest() TrackSample {
+	return r.at(r.count - 1)
+}
+
+// slice copies the samples from the i-th oldest onwards
+func (r *sampleRing) slice(from int) []TrackSample {
+	if from >= r.count {
+		return nil
+	}
+	result := make([]TrackSample, 0, r.count-from)
+	for i := from; i < r.count; i++ {
+		result = append(result, r.at(i))
+	}
+	return result
+}
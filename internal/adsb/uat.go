@@ -0,0 +1,164 @@
+package adsb
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/yegors/co-atc/pkg/logger"
+)
+
+// uatSourceType tags aircraft received on 978 MHz UAT
+const uatSourceType = "uat"
+
+// uatTarget is an aircraft in dump978's aircraft.json, which names the address type addrtype
+type uatTarget struct {
+	ExternalADSBTarget
+	AddrType string `json:"addrtype"`
+}
+
+// uatResponse is the aircraft.json of dump978 (skyaware978)
+type uatResponse struct {
+	Now      float64     `json:"now"`
+	Messages int         `json:"messages"`
+	Aircraft []uatTarget `json:"aircraft"`
+}
+
+// UATStats reports how the UAT source is being fetched
+type UATStats struct {
+	URL                 string     `json:"url"`
+	Aircraft            int        `json:"aircraft"` // In the last response
+	Merged              int        `json:"merged"`   // Added to the last poll cycle; the others had a position from the main source
+	LastFetch           *time.Time `json:"last_fetch,omitempty"`
+	LastError           string     `json:"last_error,omitempty"`
+	ConsecutiveFailures int        `json:"consecutive_failures"`
+}
+
+// uatSource is a dump978 aircraft.json merged into every poll cycle
+type uatSource struct {
+	url   string
+	mu    sync.Mutex
+	stats UATStats
+}
+
+// SetUATSource merges the aircraft of a dump978 aircraft.json into every poll cycle
+func (c *Client) SetUATSource(url string) {
+	c.uat = &uatSource{url: url, stats: UATStats{URL: url}}
+}
+
+// UATStats returns how the UAT source is being fetched, or false if there's none
+func (c *Client) UATStats() (UATStats, bool) {
+	if c == nil || c.uat == nil {
+		return UATStats{}, false
+	}
+	c.uat.mu.Lock()
+	defer c.uat.mu.Unlock()
+	return c.uat.stats, true
+}
+
+// withUATData returns the poll cycle's data with the UAT aircraft merged in. The main
+// source's data is left untouched, as it may be a cached response. If the UAT source
+// fails, the cycle goes on with the main source alone.
+func (c *Client) withUATData(ctx context.Context, data *RawAircraftData) *RawAircraftData {
+	targets, err := c.fetchUATData(ctx)
+
+	c.uat.mu.Lock()
+	defer c.uat.mu.Unlock()
+	if err != nil {
+		c.uat.stats.ConsecutiveFailures++
+		c.uat.stats.LastError = err.Error()
+		c.uat.stats.Merged = 0
+		if c.uat.stats.ConsecutiveFailures == 1 {
+			c.logger.Warn("Failed to fetch UAT data, continuing without it", logger.String("url", c.uat.url), logger.Error(err))
+		}
+		return data
+	}
+	if c.uat.stats.ConsecutiveFailures > 0 {
+		c.logger.Info("UAT source recovered", logger.Int("failures", c.uat.stats.ConsecutiveFailures))
+	}
+
+	merged, count := mergeUAT(data, targets)
+	now := time.Now().UTC()
+	c.uat.stats.Aircraft = len(targets)
+	c.uat.stats.Merged = count
+	c.uat.stats.LastFetch = &now
+	c.uat.stats.LastError = ""
+	c.uat.stats.ConsecutiveFailures = 0
+	return merged
+}
+
+// fetchUATData fetches the aircraft of dump978's aircraft.json, tagged as UAT
+func (c *Client) fetchUATData(ctx context.Context) ([]ADSBTarget, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.uat.url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to execute request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status code: %d", resp.StatusCode)
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response body: %w", err)
+	}
+
+	var response uatResponse
+	if err := json.Unmarshal(body, &response); err != nil {
+		return nil, fmt.Errorf("failed to parse JSON: %w", err)
+	}
+
+	targets := make([]ADSBTarget, 0, len(response.Aircraft))
+	for _, aircraft := range response.Aircraft {
+		target := aircraft.Convert()
+		if target.Type == "" {
+			target.Type = aircraft.AddrType
+		}
+		target.SourceType = uatSourceType
+		targets = append(targets, target)
+	}
+
+	c.logger.Debug("Successfully fetched UAT data", logger.Int("aircraft_count", len(targets)))
+	return targets, nil
+}
+
+// mergeUAT returns a copy of a poll cycle's data with the UAT aircraft added, and how many
+// were added. Aircraft the main source has a position for are kept from it, since UAT only
+// sees 1090 MHz aircraft through ADS-R and TIS-B rebroadcasts.
+func mergeUAT(data *RawAircraftData, uat []ADSBTarget) (*RawAircraftData, int) {
+	merged := *data
+	merged.Aircraft = make([]ADSBTarget, 0, len(data.Aircraft)+len(uat))
+	merged.Aircraft = append(merged.Aircraft, data.Aircraft...)
+
+	index := make(map[string]int, len(merged.Aircraft))
+	for i, target := range merged.Aircraft {
+		index[target.Hex] = i
+	}
+
+	count := 0
+	for _, target := range uat {
+		i, found := index[target.Hex]
+		if !found {
+			index[target.Hex] = len(merged.Aircraft)
+			merged.Aircraft = append(merged.Aircraft, target)
+			count++
+			continue
+		}
+		if existing := merged.Aircraft[i]; existing.Lat == 0 && existing.Lon == 0 {
+			merged.Aircraft[i] = target
+			count++
+		}
+	}
+	return &merged, count
+}
@@ -0,0 +1,443 @@
+package adsb
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/yegors/co-atc/internal/websocket"
+	"github.com/yegors/co-atc/pkg/logger"
+)
+
+// Watchlist events
+const (
+	WatchEventAppeared = "appeared" // First seen, or seen again after the signal was lost
+	WatchEventDeparted = "departed" // Took off
+	WatchEventLanded   = "landed"   // Touched down
+)
+
+// WatchEvents lists the events a watchlist can select
+var WatchEvents = []string{WatchEventAppeared, WatchEventDeparted, WatchEventLanded}
+
+var (
+	// ErrWatchlistNotFound is returned when a watchlist ID does not exist
+	ErrWatchlistNotFound = errors.New("watchlist not found")
+	// ErrInvalidWatchlist is returned when a watchlist fails validation
+	ErrInvalidWatchlist = errors.New("invalid watchlist")
+)
+
+// Watchlist is a set of aircraft a user wants to know about. An aircraft is on the list
+// when it matches any of the criteria.
+type Watchlist struct {
+	ID               int64     `json:"id"`
+	Name             string    `json:"name"`
+	HexCodes         []string  `json:"hex_codes"`         // ICAO 24-bit addresses, lower case
+	Registrations    []string  `json:"registrations"`     // Upper case, e.g. "C-FTJP"
+	CallsignPrefixes []string  `json:"callsign_prefixes"` // Upper case, e.g. "ACA" or "WJA1"
+	AircraftTypes    []string  `json:"aircraft_types"`    // ICAO type designators, upper case, e.g. "A388"
+	Events           []string  `json:"events"`            // Events alerted (empty = all)
+	Notify           bool      `json:"notify"`            // Send watchlist alerts to push and notification channels
+	CreatedAt        time.Time `json:"created_at"`
+	UpdatedAt        time.Time `json:"updated_at"`
+}
+
+// WatchlistStore persists watchlists
+type WatchlistStore interface {
+	ListWatchlists() ([]*Watchlist, error)
+	CreateWatchlist(watchlist *Watchlist) error
+	UpdateWatchlist(watchlist *Watchlist) (bool, error)
+	DeleteWatchlist(id int64) (bool, error)
+}
+
+// watchlistState holds the watchlists and the watched aircraft currently seen
+type watchlistState struct {
+	store  WatchlistStore
+	lists  []*Watchlist
+	seen   map[string]time.Time // Hex -> last poll cycle the aircraft was seen in (nil until the first cycle)
+	mu     sync.RWMutex
+	seenMu sync.Mutex
+}
+
+// SetWatchlistStore sets the storage of watchlists and loads them. Must be called before Start.
+func (s *Service) SetWatchlistStore(store WatchlistStore) error {
+	lists, err := store.ListWatchlists()
+	if err != nil {
+		return err
+	}
+
+	s.watchlists.mu.Lock()
+	s.watchlists.store = store
+	s.watchlists.lists = lists
+	s.watchlists.mu.Unlock()
+
+	s.logger.Info("Loaded watchlists", logger.Int("count", len(lists)))
+	return nil
+}
+
+// GetWatchlists returns every watchlist, by ID
+func (s *Service) GetWatchlists() []*Watchlist {
+	s.watchlists.mu.RLock()
+	defer s.watchlists.mu.RUnlock()
+
+	lists := make([]*Watchlist, len(s.watchlists.lists))
+	copy(lists, s.watchlists.lists)
+	return lists
+}
+
+// GetWatchlist returns a watchlist by ID
+func (s *Service) GetWatchlist(id int64) (*Watchlist, error) {
+	s.watchlists.mu.RLock()
+	defer s.watchlists.mu.RUnlock()
+
+	for _, list := range s.watchlists.lists {
+		if list.ID == id {
+			return list, nil
+		}
+	}
+	return nil, ErrWatchlistNotFound
+}
+
+// CreateWatchlist validates and stores a new watchlist
+func (s *Service) CreateWatchlist(watchlist *Watchlist) (*Watchlist, error) {
+	if err := normalizeWatchlist(watchlist); err != nil {
+		return nil, err
+	}
+	now := time.Now().UTC()
+	watchlist.CreatedAt = now
+	watchlist.UpdatedAt = now
+
+	s.watchlists.mu.Lock()
+	defer s.watchlists.mu.Unlock()
+
+	if s.watchlists.store == nil {
+		return nil, errors.New("watchlist storage not configured")
+	}
+	if err := s.watchlists.store.CreateWatchlist(watchlist); err != nil {
+		return nil, err
+	}
+	s.watchlists.lists = append(s.watchlists.lists, watchlist)
+
+	s.logger.Info("Watchlist created", logger.Int64("id", watchlist.ID), logger.String("name", watchlist.Name))
+	return watchlist, nil
+}
+
+// UpdateWatchlist replaces the criteria, events and notification setting of a watchlist
+func (s *Service) UpdateWatchlist(id int64, watchlist *Watchlist) (*Watchlist, error) {
+	if err := normalizeWatchlist(watchlist); err != nil {
+		return nil, err
+	}
+
+	s.watchlists.mu.Lock()
+	defer s.watchlists.mu.Unlock()
+
+	index := -1
+	for i, list := range s.watchlists.lists {
+		if list.ID == id {
+			index = i
+			break
+		}
+	}
+	if index < 0 || s.watchlists.store == nil {
+		return nil, ErrWatchlistNotFound
+	}
+
+	watchlist.ID = id
+	watchlist.CreatedAt = s.watchlists.lists[index].CreatedAt
+	watchlist.UpdatedAt = time.Now().UTC()
+	found, err := s.watchlists.store.UpdateWatchlist(watchlist)
+	if err != nil {
+		return nil, err
+	}
+	if !found {
+		return nil, ErrWatchlistNotFound
+	}
+	s.watchlists.lists[index] = watchlist
+
+	s.logger.Info("Watchlist updated", logger.Int64("id", id), logger.String("name", watchlist.Name))
+	return watchlist, nil
+}
+
+// DeleteWatchlist removes a watchlist
+func (s *Service) DeleteWatchlist(id int64) error {
+	s.watchlists.mu.Lock()
+	defer s.watchlists.mu.Unlock()
+
+	if s.watchlists.store == nil {
+		return ErrWatchlistNotFound
+	}
+	found, err := s.watchlists.store.DeleteWatchlist(id)
+	if err != nil {
+		return err
+	}
+	if !found {
+		return ErrWatchlistNotFound
+	}
+
+	lists := s.watchlists.lists[:0]
+	for _, list := range s.watchlists.lists {
+		if list.ID != id {
+			lists = append(lists, list)
+		}
+	}
+	s.watchlists.lists = lists
+
+	s.logger.Info("Watchlist deleted", logger.Int64("id", id))
+	return nil
+}
+
+// normalizeWatchlist validates a watchlist and brings its criteria to canonical case
+func normalizeWatchlist(watchlist *Watchlist) error {
+	watchlist.Name = strings.TrimSpace(watchlist.Name)
+	if watchlist.Name == "" {
+		return fmt.Errorf("%w: name is required", ErrInvalidWatchlist)
+	}
+
+	watchlist.HexCodes = normalizeCriteria(watchlist.HexCodes, strings.ToLower)
+	watchlist.Registrations = normalizeCriteria(watchlist.Registrations, strings.ToUpper)
+	watchlist.CallsignPrefixes = normalizeCriteria(watchlist.CallsignPrefixes, strings.ToUpper)
+	watchlist.AircraftTypes = normalizeCriteria(watchlist.AircraftTypes, strings.ToUpper)
+	if len(watchlist.HexCodes)+len(watchlist.Registrations)+len(watchlist.CallsignPrefixes)+len(watchlist.AircraftTypes) == 0 {
+		return fmt.Errorf("%w: at least one hex code, registration, callsign prefix or aircraft type is required", ErrInvalidWatchlist)
+	}
+
+	watchlist.Events = normalizeCriteria(watchlist.Events, strings.ToLower)
+	for _, event := range watchlist.Events {
+		found := false
+		for _, known := range WatchEvents {
+			if event == known {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return fmt.Errorf("%w: unknown event %q", ErrInvalidWatchlist, event)
+		}
+	}
+	return nil
+}
+
+// normalizeCriteria trims, recases and deduplicates a list of criteria
+func normalizeCriteria(values []string, recase func(string) string) []string {
+	seen := make(map[string]bool)
+	normalized := make([]string, 0, len(values))
+	for _, value := range values {
+		value = recase(strings.TrimSpace(value))
+		if value == "" || seen[value] {
+			continue
+		}
+		seen[value] = true
+		normalized = append(normalized, value)
+	}
+	sort.Strings(normalized)
+	return normalized
+}
+
+// matches reports whether an aircraft is on the watchlist
+func (w *Watchlist) matches(a *Aircraft) bool {
+	hex := strings.ToLower(a.Hex)
+	for _, code := range w.HexCodes {
+		if hex == code {
+			return true
+		}
+	}
+
+	callsign := strings.ToUpper(strings.TrimSpace(a.Flight))
+	if callsign != "" {
+		for _, prefix := range w.CallsignPrefixes {
+			if strings.HasPrefix(callsign, prefix) {
+				return true
+			}
+		}
+	}
+
+	if a.ADSB == nil {
+		return false
+	}
+	if registration := normalizeRegistration(a.ADSB.Registration); registration != "" {
+		for _, watched := range w.Registrations {
+			if registration == normalizeRegistration(watched) {
+				return true
+			}
+		}
+	}
+	if aircraftType := strings.ToUpper(strings.TrimSpace(a.ADSB.AircraftType)); aircraftType != "" {
+		for _, watched := range w.AircraftTypes {
+			if aircraftType == watched {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// alerts reports whether the watchlist alerts an event
+func (w *Watchlist) alerts(event string) bool {
+	if len(w.Events) == 0 {
+		return true
+	}
+	for _, e := range w.Events {
+		if e == event {
+			return true
+		}
+	}
+	return false
+}
+
+// normalizeRegistration compares registrations with or without the dash ("C-FTJP" = "CFTJP")
+func normalizeRegistration(registration string) string {
+	return strings.ReplaceAll(strings.ToUpper(strings.TrimSpace(registration)), "-", "")
+}
+
+// matchingWatchlists returns the watchlists an aircraft is on
+func (s *Service) matchingWatchlists(a *Aircraft) []*Watchlist {
+	s.watchlists.mu.RLock()
+	defer s.watchlists.mu.RUnlock()
+
+	var matched []*Watchlist
+	for _, list := range s.watchlists.lists {
+		if list.matches(a) {
+			matched = append(matched, list)
+		}
+	}
+	return matched
+}
+
+// tagWatchlists sets the IDs of the watchlists each aircraft is on
+func (s *Service) tagWatchlists(aircraft []*Aircraft) {
+	s.watchlists.mu.RLock()
+	empty := len(s.watchlists.lists) == 0
+	s.watchlists.mu.RUnlock()
+	if empty {
+		return
+	}
+
+	for _, a := range aircraft {
+		if a == nil {
+			continue
+		}
+		a.Watchlists = nil
+		for _, list := range s.matchingWatchlists(a) {
+			a.Watchlists = append(a.Watchlists, list.ID)
+		}
+	}
+}
+
+// checkWatchlists raises watchlist events for the aircraft of a poll cycle: watched aircraft
+// seen for the first time since their signal was lost, and takeoffs and touchdowns. Every
+// aircraft is remembered, so aircraft already around when the server starts or a watchlist
+// is created don't count as appearing.
+func (s *Service) checkWatchlists(aircraft []*Aircraft, phaseChanges []PhaseChangeInsert) {
+	now := time.Now()
+	s.watchlists.seenMu.Lock()
+	defer s.watchlists.seenMu.Unlock()
+
+	primed := s.watchlists.seen != nil
+	if !primed {
+		s.watchlists.seen = make(map[string]time.Time)
+	}
+
+	transitions := make(map[string]string)
+	for _, change := range phaseChanges {
+		switch change.Phase {
+		case "T/O":
+			transitions[change.Hex] = WatchEventDeparted
+		case "T/D":
+			transitions[change.Hex] = WatchEventLanded
+		}
+	}
+
+	for _, a := range aircraft {
+		lastSeen, seen := s.watchlists.seen[a.Hex]
+		s.watchlists.seen[a.Hex] = now
+		appeared := primed && (!seen || now.Sub(lastSeen) > s.signalLostTimeout)
+		event, transitioned := transitions[a.Hex]
+		if !appeared && !transitioned {
+			continue
+		}
+
+		lists := s.matchingWatchlists(a)
+		if len(lists) == 0 {
+			continue
+		}
+		if appeared {
+			s.sendWatchlistAlert(a, lists, WatchEventAppeared)
+		}
+		if transitioned {
+			s.sendWatchlistAlert(a, lists, event)
+		}
+	}
+
+	for hex, lastSeen := range s.watchlists.seen {
+		if now.Sub(lastSeen) > s.signalLostTimeout {
+			delete(s.watchlists.seen, hex)
+		}
+	}
+}
+
+// sendWatchlistAlert broadcasts a watchlist event and sends it to the alert notifier once,
+// naming every watchlist that alerts it
+func (s *Service) sendWatchlistAlert(a *Aircraft, lists []*Watchlist, event string) {
+	var ids []int64
+	var names []string
+	notify := false
+	for _, list := range lists {
+		if !list.alerts(event) {
+			continue
+		}
+		ids = append(ids, list.ID)
+		names = append(names, list.Name)
+		notify = notify || list.Notify
+	}
+	if len(ids) == 0 {
+		return
+	}
+
+	callsign := strings.TrimSpace(a.Flight)
+	if callsign == "" {
+		callsign = strings.ToUpper(a.Hex)
+	}
+	data := map[string]interface{}{
+		"event":           event,
+		"hex":             a.Hex,
+		"flight":          callsign,
+		"watchlist_ids":   ids,
+		"watchlist_names": names,
+		"timestamp":       time.Now().UTC(),
+	}
+	if a.ADSB != nil {
+		data["registration"] = a.ADSB.Registration
+		data["aircraft_type"] = a.ADSB.AircraftType
+		data["lat"] = a.ADSB.Lat
+		data["lon"] = a.ADSB.Lon
+		data["alt"] = a.ADSB.AltBaro
+	}
+
+	s.logger.Info("Watched aircraft event",
+		logger.String("hex", a.Hex),
+		logger.String("flight", callsign),
+		logger.String("event", event),
+		logger.String("watchlists", strings.Join(names, ", ")))
+
+	if s.wsServer != nil {
+		s.wsServer.Broadcast(&websocket.Message{
+			Type: "watchlist_alert",
+			Data: data,
+		})
+	}
+
+	if notify && s.alertNotifier != nil && !isReplayed(a) {
+		body := fmt.Sprintf("%s %s (%s)", callsign, event, strings.Join(names, ", "))
+		if a.ADSB != nil && a.ADSB.AircraftType != "" {
+			body = fmt.Sprintf("%s, %s, %s (%s)", callsign, a.ADSB.AircraftType, event, strings.Join(names, ", "))
+		}
+		s.alertNotifier.NotifyAlert(
+			"watchlist",
+			fmt.Sprintf("Watched aircraft %s %s", callsign, event),
+			body,
+			data,
+		)
+	}
+}
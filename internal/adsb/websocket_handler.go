@@ -50,6 +50,7 @@ func (h *WebSocketHandler) handleBulkRequest(client *websocket.Client, data map[
 		h.logger.Error("Failed to get bulk aircraft data", logger.Error(err))
 		return err
 	}
+	response = h.visibleTo(client, response)
 
 	// Send response back to client
 	message := &websocket.Message{
@@ -138,6 +139,7 @@ func (h *WebSocketHandler) handleFilterUpdate(client *websocket.Client, data map
 		h.logger.Error("Failed to get filtered aircraft data", logger.Error(err))
 		return err
 	}
+	response = h.visibleTo(client, response)
 
 	// Send filtered aircraft data back to client
 	message := &websocket.Message{
@@ -153,6 +155,31 @@ func (h *WebSocketHandler) handleFilterUpdate(client *websocket.Client, data map
 	return h.sendToClient(client, message)
 }
 
+// visibleTo leaves the aircraft hidden from a client out of a bulk response, and out of its counts
+func (h *WebSocketHandler) visibleTo(client *websocket.Client, response *AircraftBulkResponse) *AircraftBulkResponse {
+	aircraft := make([]*Aircraft, 0, len(response.Aircraft))
+	for _, a := range response.Aircraft {
+		if !client.Hides(a.Military, a.Blocked) {
+			aircraft = append(aircraft, a)
+		}
+	}
+	if len(aircraft) == len(response.Aircraft) {
+		return response
+	}
+
+	groundActive, groundTotal, airActive, airTotal := h.service.calculateCounts(aircraft)
+	return &AircraftBulkResponse{
+		Aircraft: aircraft,
+		Count:    len(aircraft),
+		Counts: AircraftCounts{
+			GroundActive: groundActive,
+			GroundTotal:  groundTotal,
+			AirActive:    airActive,
+			AirTotal:     airTotal,
+		},
+	}
+}
+
 // sendToClient sends a message to a specific client
 func (h *WebSocketHandler) sendToClient(client *websocket.Client, message *websocket.Message) error {
 	messageData, err := json.Marshal(message)
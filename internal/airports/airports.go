@@ -0,0 +1,58 @@
+// Package airports reads the airport database: names, cities and positions of airports by
+// ICAO and IATA code.
+package airports
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// DefaultPath is the airport database used unless [board] airports_db_path is set
+const DefaultPath = "assets/airports.json"
+
+// Airport is an entry of the airport database
+type Airport struct {
+	ICAO          string  `json:"icao"`
+	IATA          string  `json:"iata"`
+	Name          string  `json:"name"`
+	City          string  `json:"city"`
+	Country       string  `json:"country"`
+	Latitude      float64 `json:"lat"`
+	Longitude     float64 `json:"lon"`
+	ElevationFeet float64 `json:"elevation"`
+}
+
+// Load reads the airport database
+func Load(path string) ([]Airport, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read airport database: %w", err)
+	}
+
+	var airports []Airport
+	if err := json.Unmarshal(data, &airports); err != nil {
+		return nil, fmt.Errorf("failed to parse airport database: %w", err)
+	}
+	return airports, nil
+}
+
+// ByCode keys airports by ICAO and IATA code
+func ByCode(airports []Airport) map[string]Airport {
+	byCode := make(map[string]Airport, 2*len(airports))
+	for _, airport := range airports {
+		if airport.ICAO != "" {
+			byCode[strings.ToUpper(airport.ICAO)] = airport
+		}
+	}
+	// IATA codes only fill in, so they never hide an ICAO code
+	for _, airport := range airports {
+		if iata := strings.ToUpper(airport.IATA); iata != "" {
+			if _, exists := byCode[iata]; !exists {
+				byCode[iata] = airport
+			}
+		}
+	}
+	return byCode
+}
@@ -0,0 +1,193 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/yegors/co-atc/internal/adsb"
+)
+
+// boundingBox is an area between two longitudes and latitudes. West is greater than east
+// when the box crosses the antimeridian.
+type boundingBox struct {
+	West, South, East, North float64
+}
+
+// contains reports whether a position is in the box
+func (b boundingBox) contains(lat, lon float64) bool {
+	if lat < b.South || lat > b.North {
+		return false
+	}
+	if b.West <= b.East {
+		return lon >= b.West && lon <= b.East
+	}
+	return lon >= b.West || lon <= b.East
+}
+
+// aircraftAreaFilter narrows /aircraft to an area and kinds of aircraft. Zero values match
+// everything.
+type aircraftAreaFilter struct {
+	BBox          *boundingBox
+	MaxDistanceNM float64  // From the station
+	Phases        []string // Current phase is one of these
+	OnGround      *bool
+	Military      *bool // Military aircraft only, or none
+	Blocked       *bool // Blocked aircraft only, or none
+	Emergency     bool  // Only aircraft squawking an emergency code
+}
+
+// active reports whether the filter excludes any aircraft
+func (f aircraftAreaFilter) active() bool {
+	return f.BBox != nil || f.MaxDistanceNM > 0 || len(f.Phases) > 0 || f.OnGround != nil ||
+		f.Military != nil || f.Blocked != nil || f.Emergency
+}
+
+// parseAircraftAreaFilter reads the bbox, max_distance_nm, phase, on_ground, military, blocked
+// and emergency parameters
+func parseAircraftAreaFilter(r *http.Request) (aircraftAreaFilter, error) {
+	query := r.URL.Query()
+	var filter aircraftAreaFilter
+
+	if value := query.Get("bbox"); value != "" {
+		bbox, err := parseBoundingBox(value)
+		if err != nil {
+			return filter, err
+		}
+		filter.BBox = &bbox
+	}
+	if value := query.Get("max_distance_nm"); value != "" {
+		distance, err := strconv.ParseFloat(value, 64)
+		if err != nil || distance <= 0 {
+			return filter, fmt.Errorf("max_distance_nm must be a positive number")
+		}
+		filter.MaxDistanceNM = distance
+	}
+	if value := query.Get("phase"); value != "" {
+		for _, phase := range strings.Split(value, ",") {
+			if phase = strings.TrimSpace(phase); phase != "" {
+				filter.Phases = append(filter.Phases, strings.ToUpper(phase))
+			}
+		}
+	}
+	for _, flag := range []struct {
+		name  string
+		value **bool
+	}{{"on_ground", &filter.OnGround}, {"military", &filter.Military}, {"blocked", &filter.Blocked}} {
+		if value := query.Get(flag.name); value != "" {
+			enabled, err := strconv.ParseBool(value)
+			if err != nil {
+				return filter, fmt.Errorf("%s must be true or false", flag.name)
+			}
+			*flag.value = &enabled
+		}
+	}
+	if value := query.Get("emergency"); value != "" {
+		enabled, err := strconv.ParseBool(value)
+		if err != nil {
+			return filter, fmt.Errorf("emergency must be true or false")
+		}
+		filter.Emergency = enabled
+	}
+	return filter, nil
+}
+
+// parseBoundingBox reads a box as "west,south,east,north" in degrees
+func parseBoundingBox(value string) (boundingBox, error) {
+	parts := strings.Split(value, ",")
+	if len(parts) != 4 {
+		return boundingBox{}, fmt.Errorf("bbox must be west,south,east,north")
+	}
+	var coords [4]float64
+	for i, part := range parts {
+		coord, err := strconv.ParseFloat(strings.TrimSpace(part), 64)
+		if err != nil {
+			return boundingBox{}, fmt.Errorf("bbox must be west,south,east,north")
+		}
+		coords[i] = coord
+	}
+	bbox := boundingBox{West: coords[0], South: coords[1], East: coords[2], North: coords[3]}
+	if bbox.South < -90 || bbox.North > 90 || bbox.South > bbox.North {
+		return boundingBox{}, fmt.Errorf("bbox latitudes must be between -90 and 90, south first")
+	}
+	if bbox.West < -180 || bbox.West > 180 || bbox.East < -180 || bbox.East > 180 {
+		return boundingBox{}, fmt.Errorf("bbox longitudes must be between -180 and 180")
+	}
+	return bbox, nil
+}
+
+// filterAircraftByArea keeps the aircraft a filter selects. Aircraft without a position are
+// dropped by the bbox and distance filters.
+func (h *Handler) filterAircraftByArea(aircraft []*adsb.Aircraft, filter aircraftAreaFilter) []*adsb.Aircraft {
+	filtered := make([]*adsb.Aircraft, 0, len(aircraft))
+	for _, a := range aircraft {
+		hasPosition := a.ADSB != nil && (a.ADSB.Lat != 0 || a.ADSB.Lon != 0)
+
+		if filter.BBox != nil && (!hasPosition || !filter.BBox.contains(a.ADSB.Lat, a.ADSB.Lon)) {
+			continue
+		}
+		if filter.MaxDistanceNM > 0 {
+			if !hasPosition {
+				continue
+			}
+			distNM := adsb.MetersToNM(adsb.Haversine(a.ADSB.Lat, a.ADSB.Lon, h.config.Station.Latitude, h.config.Station.Longitude))
+			if distNM > filter.MaxDistanceNM {
+				continue
+			}
+		}
+		if len(filter.Phases) > 0 {
+			if a.Phase == nil || len(a.Phase.Current) == 0 || !slices.Contains(filter.Phases, strings.ToUpper(a.Phase.Current[0].Phase)) {
+				continue
+			}
+		}
+		if filter.OnGround != nil && a.OnGround != *filter.OnGround {
+			continue
+		}
+		if filter.Military != nil && a.Military != *filter.Military {
+			continue
+		}
+		if filter.Blocked != nil && a.Blocked != *filter.Blocked {
+			continue
+		}
+		if filter.Emergency && (a.ADSB == nil || !slices.Contains(h.config.FlightPhases.EmergencySquawkCodes, a.ADSB.Squawk)) {
+			continue
+		}
+		filtered = append(filtered, a)
+	}
+	return filtered
+}
+
+// hides reports whether an aircraft is left out of the responses to a request: with [privacy]
+// hide_military or hide_blocked, for requests without the admin token
+func (h *Handler) hides(r *http.Request, military, blocked bool) bool {
+	if isAdmin(r) {
+		return false
+	}
+	return (military && h.config.Privacy.HideMilitary) || (blocked && h.config.Privacy.HideBlocked)
+}
+
+// hidesHex reports whether the aircraft with a hex code, tracked or not, is left out of the
+// responses to a request
+func (h *Handler) hidesHex(r *http.Request, hex string) bool {
+	if !h.hides(r, true, true) {
+		return false
+	}
+	military, blocked := h.adsbService.ClassifyHex(hex)
+	return h.hides(r, military, blocked)
+}
+
+// visibleAircraft removes the aircraft hidden from a request
+func (h *Handler) visibleAircraft(r *http.Request, aircraft []*adsb.Aircraft) []*adsb.Aircraft {
+	if !h.hides(r, true, true) {
+		return aircraft
+	}
+	visible := make([]*adsb.Aircraft, 0, len(aircraft))
+	for _, a := range aircraft {
+		if !h.hides(r, a.Military, a.Blocked) {
+			visible = append(visible, a)
+		}
+	}
+	return visible
+}
@@ -2,16 +2,20 @@ package api
 
 import (
 	"context"
+	"encoding/base64"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
+	"strings"
 	"sync"
 	"time"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/gorilla/websocket"
 	"github.com/yegors/co-atc/internal/atcchat"
+	"github.com/yegors/co-atc/internal/usage"
 	"github.com/yegors/co-atc/pkg/logger"
 )
 
@@ -49,16 +53,18 @@ func NewSafeWebSocketConn(conn *websocket.Conn) *SafeWebSocketConn {
 
 // ATCChatHandlers contains handlers for ATC chat functionality
 type ATCChatHandlers struct {
-	service  *atcchat.Service
-	logger   *logger.Logger
-	upgrader websocket.Upgrader
+	service      *atcchat.Service
+	usageTracker *usage.Tracker
+	logger       *logger.Logger
+	upgrader     websocket.Upgrader
 }
 
 // NewATCChatHandlers creates new ATC chat handlers
-func NewATCChatHandlers(service *atcchat.Service, logger *logger.Logger) *ATCChatHandlers {
+func NewATCChatHandlers(service *atcchat.Service, usageTracker *usage.Tracker, logger *logger.Logger) *ATCChatHandlers {
 	return &ATCChatHandlers{
-		service: service,
-		logger:  logger.Named("atc-chat-handlers"),
+		service:      service,
+		usageTracker: usageTracker,
+		logger:       logger.Named("atc-chat-handlers"),
 		upgrader: websocket.Upgrader{
 			CheckOrigin: func(r *http.Request) bool {
 				// Allow all origins for now - in production, restrict this
@@ -72,8 +78,17 @@ func NewATCChatHandlers(service *atcchat.Service, logger *logger.Logger) *ATCCha
 func (h *ATCChatHandlers) CreateSession(w http.ResponseWriter, r *http.Request) {
 	h.logger.Info("Creating new ATC chat session")
 
-	session, err := h.service.CreateSession(r.Context())
+	req := decodeChatSessionRequest(r)
+	session, err := h.service.CreateSession(r.Context(), req.ClientID, req.Persona)
 	if err != nil {
+		if errors.Is(err, atcchat.ErrUnknownPersona) {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if errors.Is(err, atcchat.ErrTooManySessions) {
+			http.Error(w, err.Error(), http.StatusTooManyRequests)
+			return
+		}
 		h.logger.Error("Failed to create session", logger.Error(err))
 		http.Error(w, "Failed to create session", http.StatusInternalServerError)
 		return
@@ -149,25 +164,51 @@ func (h *ATCChatHandlers) UpdateSessionContext(w http.ResponseWriter, r *http.Re
 		logger.String("session_id", sessionID))
 
 	// Update session context with fresh airspace data
-	if err := h.service.UpdateSessionContextOnDemand(sessionID); err != nil {
+	turnID, err := h.service.UpdateSessionContextOnDemand(sessionID)
+	if err != nil {
 		h.logger.Error("Failed to update session context",
 			logger.String("session_id", sessionID),
+			logger.String("correlation_id", turnID),
 			logger.Error(err))
 		http.Error(w, fmt.Sprintf("Failed to update session context: %v", err), http.StatusInternalServerError)
 		return
 	}
 
 	h.logger.Info("Session context updated successfully",
-		logger.String("session_id", sessionID))
+		logger.String("session_id", sessionID),
+		logger.String("correlation_id", turnID))
 
 	// Return success response
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(map[string]interface{}{
-		"status":  "success",
-		"message": "Session context updated with fresh airspace data",
+		"status":         "success",
+		"message":        "Session context updated with fresh airspace data",
+		"correlation_id": turnID,
 	})
 }
 
+// chatSessionRequest is the optional body of a session creation request
+type chatSessionRequest struct {
+	ClientID string `json:"client_id"` // Browser identity, persistent across sessions
+	Persona  string `json:"persona"`   // Persona name (empty = default)
+}
+
+// decodeChatSessionRequest returns the browser identity and persona sent when creating a
+// chat session. The body is optional, so older clients without an ID still get a session.
+func decodeChatSessionRequest(r *http.Request) chatSessionRequest {
+	var req chatSessionRequest
+	if r.Body != nil {
+		json.NewDecoder(io.LimitReader(r.Body, 4096)).Decode(&req)
+	}
+
+	req.ClientID = strings.TrimSpace(req.ClientID)
+	if len(req.ClientID) > 128 {
+		req.ClientID = ""
+	}
+	req.Persona = strings.TrimSpace(req.Persona)
+	return req
+}
+
 // WebSocketMessage represents a message sent over the WebSocket connection
 type WebSocketMessage struct {
 	Type      string          `json:"type"`
@@ -257,8 +298,8 @@ func (h *ATCChatHandlers) bridgeRealtimeAudio(ctx context.Context, clientConn *w
 	// Use static prompt for initial connection - templated data will be sent via session.update
 	systemPrompt := "You are an experienced Air Traffic Controller assistant. Real-time airspace data will be provided via system updates."
 
-	// Get configured model from service
-	model := h.service.GetRealtimeModel()
+	// Get the session persona's model from service
+	model := h.service.SessionPersona(session.ID).RealtimeModel
 	if model == "" {
 		model = "gpt-4o-realtime-preview-2024-12-17" // fallback
 	}
@@ -293,20 +334,26 @@ func (h *ATCChatHandlers) bridgeRealtimeAudio(ctx context.Context, clientConn *w
 		return fmt.Errorf("failed to send OpenAI ready message to client: %w", err)
 	}
 
-	// Wrap with safe WebSocket connection for thread-safe writes
+	// Wrap with safe WebSocket connections for thread-safe writes; relay errors are
+	// written to the client while OpenAI events are being forwarded
 	openaiConn := NewSafeWebSocketConn(rawOpenaiConn)
+	safeClientConn := NewSafeWebSocketConn(clientConn)
+
+	// Record the connection's audio, if enabled
+	recording := h.service.StartRecording(session.ID)
+	defer recording.Close()
 
 	// Start bidirectional message forwarding
 	errChan := make(chan error, 3)
 
 	// Forward messages from client to OpenAI
 	go func() {
-		errChan <- h.forwardClientToOpenAI(ctx, clientConn, openaiConn, session)
+		errChan <- h.forwardClientToOpenAI(ctx, safeClientConn, openaiConn, session, recording)
 	}()
 
 	// Forward messages from OpenAI to client
 	go func() {
-		errChan <- h.forwardOpenAIToClient(ctx, openaiConn, clientConn, session, systemPrompt)
+		errChan <- h.forwardOpenAIToClient(ctx, openaiConn, safeClientConn, session, systemPrompt, recording)
 	}()
 
 	// Handle context updates from service
@@ -427,21 +474,25 @@ func (h *ATCChatHandlers) connectToOpenAI(ctx context.Context, session *atcchat.
 
 // sendSessionUpdate sends the session.update event with system prompt
 func (h *ATCChatHandlers) sendSessionUpdate(conn *SafeWebSocketConn, session *atcchat.ChatSession, systemPrompt string) error {
-	// Get config for session parameters
+	// Get config for session parameters; model, voice and response settings are the persona's
 	config := h.service.GetConfig()
+	persona := h.service.SessionPersona(session.ID)
 
 	sessionData := map[string]interface{}{
 		"modalities":                 []string{"text", "audio"},
 		"instructions":               systemPrompt,
-		"voice":                      config.Voice,
+		"voice":                      persona.Voice,
 		"input_audio_format":         config.InputAudioFormat,
 		"output_audio_format":        config.OutputAudioFormat,
-		"temperature":                config.Temperature,
+		"temperature":                persona.Temperature,
 		"speed":                      config.Speed,
-		"max_response_output_tokens": config.MaxResponseTokens,
+		"max_response_output_tokens": persona.MaxResponseTokens,
 		"tool_choice":                "auto",
 		"tools":                      []interface{}{},
 	}
+	if tools := h.service.Tools(); len(tools) > 0 {
+		sessionData["tools"] = tools
+	}
 
 	// Add turn detection only if not disabled
 	if config.TurnDetectionType != "" && config.TurnDetectionType != "none" {
@@ -477,8 +528,9 @@ func (h *ATCChatHandlers) sendSessionUpdate(conn *SafeWebSocketConn, session *at
 		logger.String("session_id", session.ID),
 		logger.Int("prompt_length", len(systemPrompt)),
 		logger.String("prompt_preview", systemPrompt[:min(200, len(systemPrompt))]),
-		logger.String("voice", config.Voice),
-		logger.Float64("temperature", config.Temperature),
+		logger.String("persona", persona.Name),
+		logger.String("voice", persona.Voice),
+		logger.Float64("temperature", persona.Temperature),
 		logger.Float64("speed", config.Speed))
 
 	// Log the full session update for debugging
@@ -526,8 +578,26 @@ func (h *ATCChatHandlers) handleContextUpdates(ctx context.Context, openaiConn *
 	}
 }
 
-// forwardClientToOpenAI forwards messages from client to OpenAI
-func (h *ATCChatHandlers) forwardClientToOpenAI(ctx context.Context, clientConn *websocket.Conn, openaiConn *SafeWebSocketConn, session *atcchat.ChatSession) error {
+// relayedClientEvents are the realtime events a browser may send through the relay. Session
+// configuration, including the instructions and tools, is only set by the server.
+var relayedClientEvents = map[string]bool{
+	"input_audio_buffer.append":  true,
+	"input_audio_buffer.commit":  true,
+	"input_audio_buffer.clear":   true,
+	"response.create":            true,
+	"response.cancel":            true,
+	"conversation.item.create":   true,
+	"conversation.item.truncate": true,
+}
+
+// serverOnlyResponseFields are stripped from a client's response.create, so a browser
+// can't override the session's instructions or tools for a response
+var serverOnlyResponseFields = []string{"instructions", "tools", "tool_choice"}
+
+// forwardClientToOpenAI relays messages from the client to OpenAI. Binary frames are raw
+// audio in the session's input format; text frames are realtime events, of which only
+// relayedClientEvents are passed on.
+func (h *ATCChatHandlers) forwardClientToOpenAI(ctx context.Context, clientConn *SafeWebSocketConn, openaiConn *SafeWebSocketConn, session *atcchat.ChatSession, recording *atcchat.SessionRecording) error {
 	for {
 		select {
 		case <-ctx.Done():
@@ -541,13 +611,48 @@ func (h *ATCChatHandlers) forwardClientToOpenAI(ctx context.Context, clientConn
 				return err
 			}
 
-			h.logger.Debug("Forwarding client message to OpenAI",
+			var event map[string]interface{}
+			if messageType == websocket.BinaryMessage {
+				event = map[string]interface{}{
+					"type":  "input_audio_buffer.append",
+					"audio": base64.StdEncoding.EncodeToString(message),
+				}
+			} else if err := json.Unmarshal(message, &event); err != nil {
+				h.rejectClientEvent(clientConn, session, "", "invalid JSON event")
+				continue
+			}
+
+			eventType, _ := event["type"].(string)
+			if !relayedClientEvents[eventType] {
+				h.rejectClientEvent(clientConn, session, eventType, "event type not allowed through the relay")
+				continue
+			}
+			h.service.Touch(session.ID)
+
+			switch eventType {
+			case "input_audio_buffer.append":
+				if audio, ok := event["audio"].(string); ok {
+					recording.UserAudio(audio)
+				}
+			case "response.create":
+				if response, ok := event["response"].(map[string]interface{}); ok {
+					for _, field := range serverOnlyResponseFields {
+						delete(response, field)
+					}
+				}
+			}
+
+			relayed, err := json.Marshal(event)
+			if err != nil {
+				return fmt.Errorf("failed to marshal client event: %w", err)
+			}
+
+			h.logger.Debug("Forwarding client event to OpenAI",
 				logger.String("session_id", session.ID),
-				logger.Int("message_type", messageType),
-				logger.Int("size", len(message)))
+				logger.String("event_type", eventType),
+				logger.Int("size", len(relayed)))
 
-			// Forward message to OpenAI
-			if err := openaiConn.WriteMessage(messageType, message); err != nil {
+			if err := openaiConn.WriteMessage(websocket.TextMessage, relayed); err != nil {
 				h.logger.Error("Failed to forward message to OpenAI", logger.Error(err))
 				return err
 			}
@@ -555,8 +660,23 @@ func (h *ATCChatHandlers) forwardClientToOpenAI(ctx context.Context, clientConn
 	}
 }
 
+// rejectClientEvent tells the client an event it sent was not relayed to OpenAI
+func (h *ATCChatHandlers) rejectClientEvent(clientConn *SafeWebSocketConn, session *atcchat.ChatSession, eventType, reason string) {
+	h.logger.Warn("Rejected client event",
+		logger.String("session_id", session.ID),
+		logger.String("event_type", eventType),
+		logger.String("reason", reason))
+
+	rejected, _ := json.Marshal(map[string]interface{}{
+		"type":       "relay_error",
+		"event_type": eventType,
+		"error":      reason,
+	})
+	clientConn.WriteMessage(websocket.TextMessage, rejected)
+}
+
 // forwardOpenAIToClient forwards messages from OpenAI to client
-func (h *ATCChatHandlers) forwardOpenAIToClient(ctx context.Context, openaiConn *SafeWebSocketConn, clientConn *websocket.Conn, session *atcchat.ChatSession, systemPrompt string) error {
+func (h *ATCChatHandlers) forwardOpenAIToClient(ctx context.Context, openaiConn *SafeWebSocketConn, clientConn *SafeWebSocketConn, session *atcchat.ChatSession, systemPrompt string, recording *atcchat.SessionRecording) error {
 	sessionUpdateSent := false
 	usingSessionBasedConnection := session.OpenAISessionID != "" && session.ClientSecret != ""
 
@@ -603,6 +723,7 @@ func (h *ATCChatHandlers) forwardOpenAIToClient(ctx context.Context, openaiConn
 									h.logger.Error("Failed to send session update after session.created", logger.Error(err))
 									return err
 								}
+								h.service.MarkContextUpdated(session.ID)
 								sessionUpdateSent = true
 							}
 
@@ -631,9 +752,43 @@ func (h *ATCChatHandlers) forwardOpenAIToClient(ctx context.Context, openaiConn
 								}
 							}
 
+						case "response.audio.delta":
+							if delta, ok := event["delta"].(string); ok {
+								recording.AssistantAudio(delta)
+							}
+
+						case "conversation.item.input_audio_transcription.completed":
+							// What the user asked, for the transcript and session summary
+							if transcript, ok := event["transcript"].(string); ok {
+								itemID, _ := event["item_id"].(string)
+								h.service.RecordUserTurn(session.ID, transcript, itemID)
+							}
+
+						case "response.audio_transcript.done":
+							if transcript, ok := event["transcript"].(string); ok {
+								itemID, _ := event["item_id"].(string)
+								h.service.RecordAssistantTurn(session.ID, transcript, itemID)
+							}
+
+						case "response.text.done":
+							if text, ok := event["text"].(string); ok {
+								itemID, _ := event["item_id"].(string)
+								h.service.RecordAssistantTurn(session.ID, text, itemID)
+							}
+
+						case "response.done":
+							h.logger.Debug("Chat turn response completed",
+								logger.String("session_id", session.ID),
+								logger.String("correlation_id", h.service.CurrentTurnID(session.ID)))
+							h.recordResponseUsage(session, event)
+							if err := h.answerToolCalls(openaiConn, session, event); err != nil {
+								return err
+							}
+
 						case "error":
 							h.logger.Error("Received error from OpenAI",
 								logger.String("session_id", session.ID),
+								logger.String("correlation_id", h.service.CurrentTurnID(session.ID)),
 								logger.Any("error", event))
 						}
 					}
@@ -653,3 +808,75 @@ func (h *ATCChatHandlers) forwardOpenAIToClient(ctx context.Context, openaiConn
 		}
 	}
 }
+
+// answerToolCalls runs the functions a finished response called, sends their outputs to
+// OpenAI and asks for a new response that uses them. Responses without function calls are
+// left alone.
+func (h *ATCChatHandlers) answerToolCalls(openaiConn *SafeWebSocketConn, session *atcchat.ChatSession, event map[string]interface{}) error {
+	response, ok := event["response"].(map[string]interface{})
+	if !ok {
+		return nil
+	}
+	output, _ := response["output"].([]interface{})
+
+	answered := 0
+	for _, item := range output {
+		call, ok := item.(map[string]interface{})
+		if !ok || call["type"] != "function_call" {
+			continue
+		}
+		toolCall := atcchat.ToolCall{}
+		toolCall.CallID, _ = call["call_id"].(string)
+		toolCall.Name, _ = call["name"].(string)
+		toolCall.Arguments, _ = call["arguments"].(string)
+
+		result, err := json.Marshal(map[string]interface{}{
+			"type": "conversation.item.create",
+			"item": map[string]interface{}{
+				"type":    "function_call_output",
+				"call_id": toolCall.CallID,
+				"output":  h.service.CallTool(session.ID, toolCall),
+			},
+		})
+		if err != nil {
+			return fmt.Errorf("failed to marshal function output: %w", err)
+		}
+		if err := openaiConn.WriteMessage(websocket.TextMessage, result); err != nil {
+			return fmt.Errorf("failed to send function output: %w", err)
+		}
+		answered++
+	}
+	if answered == 0 {
+		return nil
+	}
+
+	next, _ := json.Marshal(map[string]interface{}{
+		"type": "response.create",
+		"response": map[string]interface{}{
+			"modalities": []string{"text", "audio"},
+		},
+	})
+	if err := openaiConn.WriteMessage(websocket.TextMessage, next); err != nil {
+		return fmt.Errorf("failed to request response to function output: %w", err)
+	}
+	return nil
+}
+
+// recordResponseUsage accounts for the tokens a realtime response.done event reports
+func (h *ATCChatHandlers) recordResponseUsage(session *atcchat.ChatSession, event map[string]interface{}) {
+	response, ok := event["response"].(map[string]interface{})
+	if !ok {
+		return
+	}
+	tokens, ok := response["usage"].(map[string]interface{})
+	if !ok {
+		return
+	}
+	inputTokens, _ := tokens["input_tokens"].(float64)
+	outputTokens, _ := tokens["output_tokens"].(float64)
+	h.usageTracker.Record(usage.SubsystemATCChat, h.service.SessionPersona(session.ID).RealtimeModel, usage.Usage{
+		Requests:     1,
+		InputTokens:  int64(inputTokens),
+		OutputTokens: int64(outputTokens),
+	})
+}
@@ -0,0 +1,86 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/yegors/co-atc/pkg/logger"
+)
+
+// GetATIS returns the airport's current ATIS broadcasts
+func (h *Handler) GetATIS(w http.ResponseWriter, r *http.Request) {
+	if h.atisService == nil {
+		http.Error(w, "ATIS not enabled", http.StatusServiceUnavailable)
+		return
+	}
+
+	current := h.atisService.Current()
+	WriteJSON(w, http.StatusOK, map[string]interface{}{
+		"timestamp": time.Now().UTC(),
+		"airport":   h.config.Station.AirportCode,
+		"count":     len(current),
+		"atis":      current,
+	})
+}
+
+// GetATISHistory returns the information letters received in the last hours (default 24),
+// newest first
+func (h *Handler) GetATISHistory(w http.ResponseWriter, r *http.Request) {
+	if h.atisService == nil {
+		http.Error(w, "ATIS not enabled", http.StatusServiceUnavailable)
+		return
+	}
+
+	hours := 24
+	if hoursStr := r.URL.Query().Get("hours"); hoursStr != "" {
+		parsedHours, err := strconv.Atoi(hoursStr)
+		if err != nil || parsedHours <= 0 {
+			http.Error(w, "Invalid hours", http.StatusBadRequest)
+			return
+		}
+		hours = parsedHours
+	}
+	limit := 100
+	if limitStr := r.URL.Query().Get("limit"); limitStr != "" {
+		if parsedLimit, err := strconv.Atoi(limitStr); err == nil && parsedLimit > 0 {
+			limit = parsedLimit
+		}
+	}
+
+	history, err := h.atisService.History(time.Now().UTC().Add(-time.Duration(hours)*time.Hour), limit)
+	if err != nil {
+		h.logger.Error("Failed to get ATIS history", logger.Error(err))
+		http.Error(w, "Failed to get ATIS history", http.StatusInternalServerError)
+		return
+	}
+
+	WriteJSON(w, http.StatusOK, map[string]interface{}{
+		"timestamp": time.Now().UTC(),
+		"airport":   h.config.Station.AirportCode,
+		"hours":     hours,
+		"count":     len(history),
+		"history":   history,
+	})
+}
+
+// GetATISAudio serves the spoken audio of the current synthesized ATIS
+func (h *Handler) GetATISAudio(w http.ResponseWriter, r *http.Request) {
+	if h.atisService == nil {
+		http.Error(w, "ATIS not enabled", http.StatusServiceUnavailable)
+		return
+	}
+
+	audio, contentType, ok := h.atisService.Audio()
+	if !ok {
+		http.Error(w, "No ATIS audio available", http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Content-Length", strconv.Itoa(len(audio)))
+	w.Header().Set("Cache-Control", "no-store")
+	if _, err := w.Write(audio); err != nil {
+		h.logger.Debug("Failed to write ATIS audio", logger.Error(err))
+	}
+}
@@ -0,0 +1,35 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/yegors/co-atc/internal/board"
+	"github.com/yegors/co-atc/pkg/logger"
+)
+
+// GetArrivalsBoard returns the airport's arrivals board
+func (h *Handler) GetArrivalsBoard(w http.ResponseWriter, r *http.Request) {
+	h.writeBoard(w, board.Arrivals)
+}
+
+// GetDeparturesBoard returns the airport's departures board
+func (h *Handler) GetDeparturesBoard(w http.ResponseWriter, r *http.Request) {
+	h.writeBoard(w, board.Departures)
+}
+
+// writeBoard writes the arrivals or departures board
+func (h *Handler) writeBoard(w http.ResponseWriter, boardType string) {
+	if h.boardService == nil {
+		http.Error(w, "Board not enabled", http.StatusServiceUnavailable)
+		return
+	}
+
+	result, err := h.boardService.Board(boardType)
+	if err != nil {
+		h.logger.Error("Failed to get board", logger.String("board", boardType), logger.Error(err))
+		http.Error(w, "Failed to get board", http.StatusInternalServerError)
+		return
+	}
+
+	WriteJSON(w, http.StatusOK, result)
+}
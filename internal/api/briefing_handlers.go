@@ -0,0 +1,64 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/yegors/co-atc/pkg/logger"
+)
+
+// defaultBriefingMaxAge is how old a briefing can be before a request generates a new one
+const defaultBriefingMaxAge = time.Minute
+
+// GetBriefing returns a spoken-style airspace briefing. A briefing younger than max_age
+// seconds (default 60) is reused; fresh=true always generates a new one.
+func (h *Handler) GetBriefing(w http.ResponseWriter, r *http.Request) {
+	if h.briefingService == nil {
+		http.Error(w, "Briefings not enabled", http.StatusServiceUnavailable)
+		return
+	}
+
+	maxAge := defaultBriefingMaxAge
+	if maxAgeStr := r.URL.Query().Get("max_age"); maxAgeStr != "" {
+		seconds, err := strconv.Atoi(maxAgeStr)
+		if err != nil || seconds < 0 {
+			http.Error(w, "Invalid max_age", http.StatusBadRequest)
+			return
+		}
+		maxAge = time.Duration(seconds) * time.Second
+	}
+	if r.URL.Query().Get("fresh") == "true" {
+		maxAge = 0
+	}
+
+	briefing, err := h.briefingService.Latest(r.Context(), maxAge)
+	if err != nil {
+		h.logger.Error("Failed to generate briefing", logger.Error(err))
+		http.Error(w, "Failed to generate briefing", http.StatusInternalServerError)
+		return
+	}
+
+	WriteJSON(w, http.StatusOK, briefing)
+}
+
+// GetBriefingAudio serves the spoken audio of the latest briefing
+func (h *Handler) GetBriefingAudio(w http.ResponseWriter, r *http.Request) {
+	if h.briefingService == nil {
+		http.Error(w, "Briefings not enabled", http.StatusServiceUnavailable)
+		return
+	}
+
+	audio, contentType, ok := h.briefingService.Audio()
+	if !ok {
+		http.Error(w, "No briefing audio available", http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Content-Length", strconv.Itoa(len(audio)))
+	w.Header().Set("Cache-Control", "no-store")
+	if _, err := w.Write(audio); err != nil {
+		h.logger.Debug("Failed to write briefing audio", logger.Error(err))
+	}
+}
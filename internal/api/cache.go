@@ -0,0 +1,206 @@
+package api
+
+import (
+	"bytes"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/yegors/co-atc/internal/adsb"
+	"github.com/yegors/co-atc/internal/config"
+	"github.com/yegors/co-atc/pkg/logger"
+)
+
+// Cache tags, invalidated when the data behind the cached endpoints changes
+const (
+	cacheTagAircraft    = "aircraft"
+	cacheTagStation     = "station"
+	cacheTagWeather     = "wx"
+	cacheTagFrequencies = "frequencies"
+	cacheTagConfig      = "config"
+)
+
+// maxCacheEntries is the number of entries above which expired entries are swept on insert
+const maxCacheEntries = 256
+
+// ResponseCache caches successful GET responses of expensive read endpoints for a short
+// time. Entries are tagged by the data they depend on so services can invalidate them as
+// soon as that data changes, and concurrent misses for the same URL share one computation.
+type ResponseCache struct {
+	enabled bool
+	entries map[string]*cacheEntry
+	mu      sync.Mutex
+	logger  *logger.Logger
+}
+
+// cacheEntry is a cached response, or a response being computed while ready is open
+type cacheEntry struct {
+	tag     string
+	status  int
+	header  http.Header
+	body    []byte
+	expires time.Time
+	ready   chan struct{}
+	stored  bool // Set before ready is closed if the response can be served from the cache
+}
+
+// NewResponseCache creates a new response cache. A disabled cache passes every request through.
+func NewResponseCache(enabled bool, logger *logger.Logger) *ResponseCache {
+	return &ResponseCache{
+		enabled: enabled,
+		entries: make(map[string]*cacheEntry),
+		logger:  logger.Named("response-cache"),
+	}
+}
+
+// Invalidate drops every cached response with one of the tags
+func (c *ResponseCache) Invalidate(tags ...string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	dropped := 0
+	for key, entry := range c.entries {
+		for _, tag := range tags {
+			if entry.tag == tag {
+				delete(c.entries, key)
+				dropped++
+				break
+			}
+		}
+	}
+
+	if dropped > 0 {
+		c.logger.Debug("Invalidated cached responses",
+			logger.Any("tags", tags),
+			logger.Int("count", dropped))
+	}
+}
+
+// Cached returns middleware that caches GET responses under the tag for up to ttl
+func (c *ResponseCache) Cached(tag string, ttl time.Duration) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if !c.enabled || r.Method != http.MethodGet {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			// Admin and public callers get different aircraft, so never share their responses
+			key := tag + " " + callerClass(r) + " " + r.URL.RequestURI()
+
+			c.mu.Lock()
+			entry, ok := c.entries[key]
+			if ok && entry.stored && time.Now().After(entry.expires) {
+				delete(c.entries, key)
+				ok = false
+			}
+			if ok {
+				c.mu.Unlock()
+
+				// Another request is computing this response; wait for it
+				select {
+				case <-entry.ready:
+				case <-r.Context().Done():
+					return
+				}
+				if entry.stored {
+					entry.write(w, "HIT")
+					return
+				}
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			entry = &cacheEntry{tag: tag, ready: make(chan struct{})}
+			c.entries[key] = entry
+			if len(c.entries) > maxCacheEntries {
+				c.sweep()
+			}
+			c.mu.Unlock()
+
+			recorder := &responseRecorder{header: make(http.Header), status: http.StatusOK}
+			next.ServeHTTP(recorder, r)
+
+			entry.status = recorder.status
+			entry.header = recorder.header
+			entry.body = recorder.body.Bytes()
+			entry.expires = time.Now().Add(ttl)
+
+			c.mu.Lock()
+			// Only keep successful responses that weren't invalidated while being computed
+			if entry.status == http.StatusOK && c.entries[key] == entry {
+				entry.stored = true
+			} else if c.entries[key] == entry {
+				delete(c.entries, key)
+			}
+			c.mu.Unlock()
+			close(entry.ready)
+
+			entry.write(w, "MISS")
+		})
+	}
+}
+
+// registerCacheInvalidation drops cached responses as soon as the data behind them changes
+func (h *Handler) registerCacheInvalidation() {
+	h.adsbService.OnUpdate(func([]*adsb.Aircraft) {
+		h.cache.Invalidate(cacheTagAircraft)
+	})
+	if h.weatherService != nil {
+		h.weatherService.OnRefresh(func() {
+			h.cache.Invalidate(cacheTagWeather)
+		})
+	}
+	if h.configReloader != nil {
+		h.configReloader.OnChange(func(config.RuntimeSettings) {
+			h.cache.Invalidate(cacheTagConfig, cacheTagStation, cacheTagAircraft)
+		})
+	}
+}
+
+// sweep drops expired entries. Must be called with the lock held.
+func (c *ResponseCache) sweep() {
+	now := time.Now()
+	for key, entry := range c.entries {
+		if entry.stored && now.After(entry.expires) {
+			delete(c.entries, key)
+		}
+	}
+}
+
+// write sends the cached response
+func (e *cacheEntry) write(w http.ResponseWriter, cacheStatus string) {
+	for name, values := range e.header {
+		w.Header()[name] = values
+	}
+	w.Header().Set("X-Cache", cacheStatus)
+	w.WriteHeader(e.status)
+	_, _ = w.Write(e.body)
+}
+
+// responseRecorder captures a handler's response so it can be cached
+type responseRecorder struct {
+	header      http.Header
+	status      int
+	wroteHeader bool
+	body        bytes.Buffer
+}
+
+// Header implements http.ResponseWriter
+func (r *responseRecorder) Header() http.Header {
+	return r.header
+}
+
+// WriteHeader implements http.ResponseWriter
+func (r *responseRecorder) WriteHeader(status int) {
+	if !r.wroteHeader {
+		r.status = status
+		r.wroteHeader = true
+	}
+}
+
+// Write implements http.ResponseWriter
+func (r *responseRecorder) Write(b []byte) (int, error) {
+	r.wroteHeader = true
+	return r.body.Write(b)
+}
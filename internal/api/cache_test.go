@@ -0,0 +1,66 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/yegors/co-atc/pkg/logger"
+	"go.uber.org/zap"
+)
+
+func TestCachedKeepsAdminAndPublicResponsesApart(t *testing.T) {
+	log := &logger.Logger{Logger: zap.NewNop()}
+	cache := NewResponseCache(true, log)
+	middleware := NewMiddleware(log)
+
+	calls := 0
+	handler := middleware.Caller("secret")(cache.Cached(cacheTagAircraft, time.Minute)(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			calls++
+			_, _ = w.Write([]byte(callerClass(r)))
+		})))
+
+	get := func(token string) string {
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/aircraft", nil)
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec.Body.String()
+	}
+
+	if body := get("secret"); body != "admin" {
+		t.Fatalf("admin request got %q", body)
+	}
+	if body := get(""); body != "public" {
+		t.Fatalf("public request got %q after an admin request", body)
+	}
+	if body := get("wrong"); body != "public" {
+		t.Fatalf("request with a wrong token got %q", body)
+	}
+	if body := get("secret"); body != "admin" {
+		t.Fatalf("second admin request got %q", body)
+	}
+	if calls != 2 {
+		t.Errorf("handler ran %d times, want once per caller class", calls)
+	}
+}
+
+func TestCallerWithoutTokenIsNeverAdmin(t *testing.T) {
+	middleware := NewMiddleware(&logger.Logger{Logger: zap.NewNop()})
+
+	var admin bool
+	handler := middleware.Caller("")(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		admin = isAdmin(r)
+	}))
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/aircraft", nil)
+	req.Header.Set("Authorization", "Bearer ")
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+	if admin {
+		t.Error("request was marked admin with no admin token configured")
+	}
+}
@@ -0,0 +1,77 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/yegors/co-atc/internal/events"
+	"github.com/yegors/co-atc/internal/storage/sqlite"
+	"github.com/yegors/co-atc/pkg/logger"
+)
+
+// GetEvents returns the event timeline of every alert, most recent first
+func (h *Handler) GetEvents(w http.ResponseWriter, r *http.Request) {
+	if h.eventsService == nil {
+		http.Error(w, "Event timeline not available", http.StatusServiceUnavailable)
+		return
+	}
+
+	query := r.URL.Query()
+	limit, offset := parsePaginationParams(r)
+	filter := sqlite.EventFilter{
+		Hex:      query.Get("hex"),
+		Callsign: query.Get("callsign"),
+		Limit:    limit,
+		Offset:   offset,
+	}
+	if types := query.Get("type"); types != "" {
+		filter.Types = strings.Split(types, ",")
+	}
+	if minimum := query.Get("min_severity"); minimum != "" {
+		severities, err := events.SeveritiesFrom(minimum)
+		if err != nil {
+			http.Error(w, "invalid min_severity (use info, warning or critical)", http.StatusBadRequest)
+			return
+		}
+		filter.Severities = severities
+	}
+	if id := query.Get("transcription_id"); id != "" {
+		parsed, err := strconv.ParseInt(id, 10, 64)
+		if err != nil || parsed <= 0 {
+			http.Error(w, "invalid transcription_id", http.StatusBadRequest)
+			return
+		}
+		filter.TranscriptionID = parsed
+	}
+	for _, param := range []struct {
+		name string
+		time *time.Time
+	}{
+		{"start_time", &filter.Since},
+		{"end_time", &filter.Until},
+	} {
+		if value := query.Get(param.name); value != "" {
+			parsed, err := time.Parse(time.RFC3339, value)
+			if err != nil {
+				http.Error(w, "invalid "+param.name+" format (use RFC3339)", http.StatusBadRequest)
+				return
+			}
+			*param.time = parsed
+		}
+	}
+
+	records, err := h.eventsService.Events(filter)
+	if err != nil {
+		h.logger.Error("Failed to retrieve events", logger.Error(err))
+		http.Error(w, "Failed to retrieve events", http.StatusInternalServerError)
+		return
+	}
+
+	WriteJSON(w, http.StatusOK, map[string]interface{}{
+		"timestamp": time.Now().UTC(),
+		"count":     len(records),
+		"events":    records,
+	})
+}
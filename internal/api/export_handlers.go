@@ -0,0 +1,258 @@
+package api
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/yegors/co-atc/internal/storage/sqlite"
+	"github.com/yegors/co-atc/pkg/logger"
+)
+
+// exportPageSize is the number of rows read from the database at a time while exporting
+const exportPageSize = 1000
+
+// Export formats
+const (
+	exportFormatCSV   = "csv"
+	exportFormatJSONL = "jsonl"
+)
+
+// transcriptionCSVHeader is the header row of transcription CSV exports
+var transcriptionCSVHeader = []string{"id", "frequency_id", "created_at", "speaker_type", "callsign", "aircraft_hex", "content", "content_processed", "is_complete", "is_processed", "correlation_id", "audio_start", "audio_end", "confidence"}
+
+// clearanceCSVHeader is the header row of clearance CSV exports
+var clearanceCSVHeader = []string{"id", "transcription_id", "callsign", "aircraft_hex", "clearance_type", "clearance_text", "runway", "altitude", "heading", "timestamp", "status", "created_at", "correlation_id"}
+
+// CreateBackup snapshots the databases into the backup directory
+func (h *Handler) CreateBackup(w http.ResponseWriter, r *http.Request) {
+	if h.backupStorage == nil {
+		http.Error(w, "Backups are not available", http.StatusServiceUnavailable)
+		return
+	}
+
+	start := time.Now()
+	files, err := h.backupStorage.Backup()
+	if err != nil {
+		h.logger.Error("Failed to back up databases", logger.Error(err))
+		http.Error(w, "Failed to back up databases", http.StatusInternalServerError)
+		return
+	}
+
+	WriteJSON(w, http.StatusOK, map[string]interface{}{
+		"timestamp":   time.Now().UTC(),
+		"duration_ms": time.Since(start).Milliseconds(),
+		"files":       files,
+	})
+}
+
+// ExportTranscriptions streams the transcriptions of a time range as CSV or JSON Lines
+func (h *Handler) ExportTranscriptions(w http.ResponseWriter, r *http.Request) {
+	startTime, endTime, format, err := parseExportParams(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	// Read the first page before committing to a response, so a database error can still be reported
+	page, err := h.transcriptionStorage.GetTranscriptionsForExport(startTime, endTime, 0, exportPageSize)
+	if err != nil {
+		h.logger.Error("Failed to export transcriptions", logger.Error(err))
+		http.Error(w, "Failed to export transcriptions", http.StatusInternalServerError)
+		return
+	}
+
+	writeExportHeaders(w, "transcriptions", format, startTime, endTime)
+	var writeRow func(t *sqlite.TranscriptionRecord) error
+	var csvWriter *csv.Writer
+	if format == exportFormatCSV {
+		csvWriter = csv.NewWriter(w)
+		csvWriter.Write(transcriptionCSVHeader)
+		writeRow = func(t *sqlite.TranscriptionRecord) error {
+			return csvWriter.Write([]string{
+				strconv.FormatInt(t.ID, 10),
+				t.FrequencyID,
+				formatExportTime(&t.CreatedAt),
+				t.SpeakerType,
+				t.Callsign,
+				t.AircraftHex,
+				t.Content,
+				t.ContentProcessed,
+				strconv.FormatBool(t.IsComplete),
+				strconv.FormatBool(t.IsProcessed),
+				t.CorrelationID,
+				formatExportTime(t.AudioStart),
+				formatExportTime(t.AudioEnd),
+				formatExportFloat(t.Confidence),
+			})
+		}
+	} else {
+		encoder := json.NewEncoder(w)
+		writeRow = func(t *sqlite.TranscriptionRecord) error {
+			return encoder.Encode(t)
+		}
+	}
+
+	count := 0
+	for len(page) > 0 {
+		for _, t := range page {
+			if err := writeRow(t); err != nil {
+				h.logger.Warn("Transcription export interrupted", logger.Error(err), logger.Int("rows", count))
+				return
+			}
+			count++
+		}
+		if csvWriter != nil {
+			csvWriter.Flush()
+		}
+		if len(page) < exportPageSize {
+			break
+		}
+
+		page, err = h.transcriptionStorage.GetTranscriptionsForExport(startTime, endTime, page[len(page)-1].ID, exportPageSize)
+		if err != nil {
+			// The response has started, so the export can only be cut short
+			h.logger.Error("Failed to export transcriptions", logger.Error(err), logger.Int("rows", count))
+			return
+		}
+	}
+	if csvWriter != nil {
+		csvWriter.Flush()
+	}
+
+	h.logger.Debug("Exported transcriptions", logger.Int("rows", count), logger.String("format", format))
+}
+
+// ExportClearances streams the clearances of a time range as CSV or JSON Lines
+func (h *Handler) ExportClearances(w http.ResponseWriter, r *http.Request) {
+	startTime, endTime, format, err := parseExportParams(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	page, err := h.clearanceStorage.GetClearancesForExport(startTime, endTime, 0, exportPageSize)
+	if err != nil {
+		h.logger.Error("Failed to export clearances", logger.Error(err))
+		http.Error(w, "Failed to export clearances", http.StatusInternalServerError)
+		return
+	}
+
+	writeExportHeaders(w, "clearances", format, startTime, endTime)
+	var writeRow func(c *sqlite.ClearanceRecord) error
+	var csvWriter *csv.Writer
+	if format == exportFormatCSV {
+		csvWriter = csv.NewWriter(w)
+		csvWriter.Write(clearanceCSVHeader)
+		writeRow = func(c *sqlite.ClearanceRecord) error {
+			return csvWriter.Write([]string{
+				strconv.FormatInt(c.ID, 10),
+				strconv.FormatInt(c.TranscriptionID, 10),
+				c.Callsign,
+				c.AircraftHex,
+				c.ClearanceType,
+				c.ClearanceText,
+				c.Runway,
+				formatExportInt(c.Altitude),
+				formatExportInt(c.Heading),
+				formatExportTime(&c.Timestamp),
+				c.Status,
+				formatExportTime(&c.CreatedAt),
+				c.CorrelationID,
+			})
+		}
+	} else {
+		encoder := json.NewEncoder(w)
+		writeRow = func(c *sqlite.ClearanceRecord) error {
+			return encoder.Encode(c)
+		}
+	}
+
+	count := 0
+	for len(page) > 0 {
+		for _, c := range page {
+			if err := writeRow(c); err != nil {
+				h.logger.Warn("Clearance export interrupted", logger.Error(err), logger.Int("rows", count))
+				return
+			}
+			count++
+		}
+		if csvWriter != nil {
+			csvWriter.Flush()
+		}
+		if len(page) < exportPageSize {
+			break
+		}
+
+		page, err = h.clearanceStorage.GetClearancesForExport(startTime, endTime, page[len(page)-1].ID, exportPageSize)
+		if err != nil {
+			h.logger.Error("Failed to export clearances", logger.Error(err), logger.Int("rows", count))
+			return
+		}
+	}
+	if csvWriter != nil {
+		csvWriter.Flush()
+	}
+
+	h.logger.Debug("Exported clearances", logger.Int("rows", count), logger.String("format", format))
+}
+
+// parseExportParams parses the time range and format of an export request
+func parseExportParams(r *http.Request) (time.Time, time.Time, string, error) {
+	startTime, endTime, err := parseTimeRangeParams(r)
+	if err != nil {
+		return time.Time{}, time.Time{}, "", err
+	}
+
+	format := r.URL.Query().Get("format")
+	switch format {
+	case "":
+		format = exportFormatJSONL
+	case exportFormatCSV, exportFormatJSONL:
+	default:
+		return time.Time{}, time.Time{}, "", fmt.Errorf("invalid format %q (use csv or jsonl)", format)
+	}
+
+	return startTime, endTime, format, nil
+}
+
+// writeExportHeaders sets the content type of an export and names it as a download
+func writeExportHeaders(w http.ResponseWriter, name, format string, startTime, endTime time.Time) {
+	contentType := "application/x-ndjson"
+	if format == exportFormatCSV {
+		contentType = "text/csv; charset=utf-8"
+	}
+	filename := fmt.Sprintf("%s-%s-%s.%s", name,
+		startTime.UTC().Format("20060102T150405Z"), endTime.UTC().Format("20060102T150405Z"), format)
+
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+	w.WriteHeader(http.StatusOK)
+}
+
+// formatExportTime formats an optional time for a CSV cell
+func formatExportTime(t *time.Time) string {
+	if t == nil || t.IsZero() {
+		return ""
+	}
+	return t.UTC().Format(time.RFC3339Nano)
+}
+
+// formatExportFloat formats an optional number for a CSV cell
+func formatExportFloat(f *float64) string {
+	if f == nil {
+		return ""
+	}
+	return strconv.FormatFloat(*f, 'f', -1, 64)
+}
+
+// formatExportInt formats an optional number, zero when unset, for a CSV cell
+func formatExportInt(n int) string {
+	if n == 0 {
+		return ""
+	}
+	return strconv.Itoa(n)
+}
@@ -0,0 +1,18 @@
+package api
+
+import (
+	"net/http"
+)
+
+// GetFeederStatus returns the feed of every configured aggregator
+func (h *Handler) GetFeederStatus(w http.ResponseWriter, r *http.Request) {
+	if h.feederService == nil {
+		http.Error(w, "Feeding not enabled", http.StatusServiceUnavailable)
+		return
+	}
+
+	WriteJSON(w, http.StatusOK, map[string]interface{}{
+		"uuid":        h.config.Feeder.UUID,
+		"aggregators": h.feederService.Status(),
+	})
+}
@@ -3,11 +3,11 @@ package api
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"math"
 	"net/http"
-	"os"
 	"sort"
 	"strconv"
 	"strings"
@@ -15,11 +15,31 @@ import (
 
 	"github.com/go-chi/chi/v5"
 	"github.com/yegors/co-atc/internal/adsb"
+	"github.com/yegors/co-atc/internal/approach"
 	"github.com/yegors/co-atc/internal/atcchat"
+	"github.com/yegors/co-atc/internal/atis"
+	"github.com/yegors/co-atc/internal/audio"
+	"github.com/yegors/co-atc/internal/board"
+	"github.com/yegors/co-atc/internal/briefing"
 	"github.com/yegors/co-atc/internal/config"
+	"github.com/yegors/co-atc/internal/deviation"
+	"github.com/yegors/co-atc/internal/events"
+	"github.com/yegors/co-atc/internal/feeder"
 	"github.com/yegors/co-atc/internal/frequencies"
+	"github.com/yegors/co-atc/internal/geomag"
+	"github.com/yegors/co-atc/internal/movements"
+	"github.com/yegors/co-atc/internal/noise"
+	"github.com/yegors/co-atc/internal/notify"
+	"github.com/yegors/co-atc/internal/occupancy"
+	"github.com/yegors/co-atc/internal/push"
+	"github.com/yegors/co-atc/internal/records"
+	"github.com/yegors/co-atc/internal/sequence"
 	"github.com/yegors/co-atc/internal/simulation"
+	"github.com/yegors/co-atc/internal/stats"
 	"github.com/yegors/co-atc/internal/storage/sqlite"
+	"github.com/yegors/co-atc/internal/templating"
+	"github.com/yegors/co-atc/internal/terrain"
+	"github.com/yegors/co-atc/internal/usage"
 	"github.com/yegors/co-atc/internal/weather"
 	"github.com/yegors/co-atc/internal/websocket"
 	"github.com/yegors/co-atc/pkg/logger"
@@ -32,27 +52,73 @@ type Handler struct {
 	weatherService       *weather.Service
 	atcChatService       *atcchat.Service
 	simulationService    *simulation.Service
+	pushService          *push.Service
+	notifyService        *notify.Service
+	recordsService       *records.Service
+	statsService         *stats.Service
+	deviationService     *deviation.Service
+	briefingService      *briefing.Service
+	atisService          *atis.Service
+	templateService      *templating.Service
 	config               *config.Config
+	configReloader       *config.Reloader
 	logger               *logger.Logger
 	wsServer             *websocket.Server
 	transcriptionStorage *sqlite.TranscriptionStorage
 	clearanceStorage     *sqlite.ClearanceStorage
+	backupStorage        *sqlite.BackupStorage
+	usageTracker         *usage.Tracker
+	eventsService        *events.Service
+	terrainService       *terrain.Service
+	approachService      *approach.Service
+	noiseService         *noise.Service
+	feederService        *feeder.Service
+	movementsService     *movements.Service
+	boardService         *board.Service
+	sequenceService      *sequence.Service
+	occupancyService     *occupancy.Service
+	cache                *ResponseCache
 }
 
 // NewHandler creates a new API handler
-func NewHandler(adsbService *adsb.Service, frequenciesService *frequencies.Service, weatherService *weather.Service, atcChatService *atcchat.Service, simulationService *simulation.Service, config *config.Config, logger *logger.Logger, wsServer *websocket.Server, transcriptionStorage *sqlite.TranscriptionStorage, clearanceStorage *sqlite.ClearanceStorage) *Handler {
-	return &Handler{
+func NewHandler(adsbService *adsb.Service, frequenciesService *frequencies.Service, weatherService *weather.Service, atcChatService *atcchat.Service, simulationService *simulation.Service, pushService *push.Service, notifyService *notify.Service, recordsService *records.Service, statsService *stats.Service, deviationService *deviation.Service, briefingService *briefing.Service, atisService *atis.Service, templateService *templating.Service, config *config.Config, configReloader *config.Reloader, logger *logger.Logger, wsServer *websocket.Server, transcriptionStorage *sqlite.TranscriptionStorage, clearanceStorage *sqlite.ClearanceStorage, backupStorage *sqlite.BackupStorage, usageTracker *usage.Tracker, eventsService *events.Service, terrainService *terrain.Service, approachService *approach.Service, noiseService *noise.Service, feederService *feeder.Service, movementsService *movements.Service, boardService *board.Service, sequenceService *sequence.Service, occupancyService *occupancy.Service) *Handler {
+	h := &Handler{
 		adsbService:          adsbService,
 		frequenciesService:   frequenciesService,
 		weatherService:       weatherService,
 		atcChatService:       atcChatService,
 		simulationService:    simulationService,
+		pushService:          pushService,
+		notifyService:        notifyService,
+		recordsService:       recordsService,
+		statsService:         statsService,
+		deviationService:     deviationService,
+		briefingService:      briefingService,
+		atisService:          atisService,
+		templateService:      templateService,
 		config:               config,
+		configReloader:       configReloader,
 		logger:               logger.Named("api-handler"),
 		wsServer:             wsServer,
 		transcriptionStorage: transcriptionStorage,
 		clearanceStorage:     clearanceStorage,
-	}
+		backupStorage:        backupStorage,
+		usageTracker:         usageTracker,
+		eventsService:        eventsService,
+		terrainService:       terrainService,
+		approachService:      approachService,
+		noiseService:         noiseService,
+		feederService:        feederService,
+		movementsService:     movementsService,
+		boardService:         boardService,
+		sequenceService:      sequenceService,
+		occupancyService:     occupancyService,
+		cache:                NewResponseCache(!config.Server.DisableResponseCache, logger),
+	}
+
+	h.registerCacheInvalidation()
+
+	return h
 }
 
 // GetAllAircraft returns all aircraft
@@ -64,6 +130,11 @@ func (h *Handler) GetAllAircraft(w http.ResponseWriter, r *http.Request) {
 	minAltitude, maxAltitude, callsign, status, lastSeenMinutes,
 		tookOffAfter, tookOffBefore, landedAfter, landedBefore, distanceNM,
 		refLat, refLon, refHex, refFlight, excludeOtherAirportsGrounded := parseAircraftFilters(r)
+	areaFilter, err := parseAircraftAreaFilter(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
 
 	// Get aircraft data
 	dataFetchStart := time.Now()
@@ -81,11 +152,18 @@ func (h *Handler) GetAllAircraft(w http.ResponseWriter, r *http.Request) {
 		aircraft = h.adsbService.GetAllAircraft()
 	}
 
+	aircraft = h.visibleAircraft(r, aircraft)
+
 	dataFetchDuration := time.Since(dataFetchStart)
 	h.logger.Debug("Aircraft data fetch completed",
 		logger.Duration("duration", dataFetchDuration),
 		logger.Int("aircraft_count", len(aircraft)))
 
+	// Narrow to an area and kinds of aircraft if requested
+	if areaFilter.active() {
+		aircraft = h.filterAircraftByArea(aircraft, areaFilter)
+	}
+
 	// Filter by callsign if provided
 	if callsign != "" {
 		filtered := make([]*adsb.Aircraft, 0)
@@ -127,7 +205,7 @@ func (h *Handler) GetAllAircraft(w http.ResponseWriter, r *http.Request) {
 			err = nil
 		} else if refHex != "" {
 			// Use aircraft hex code
-			refAircraft, err = h.getRefAircraft(refHex)
+			refAircraft, err = h.getRefAircraft(r, refHex)
 			if err == nil && refAircraft != nil && refAircraft.ADSB != nil {
 				refLatitude = refAircraft.ADSB.Lat
 				refLongitude = refAircraft.ADSB.Lon
@@ -140,7 +218,7 @@ func (h *Handler) GetAllAircraft(w http.ResponseWriter, r *http.Request) {
 			refType = "hex"
 		} else if refFlight != "" {
 			// Use flight number
-			refLatitude, refLongitude, err = h.getFlightCoordinates(refFlight)
+			refLatitude, refLongitude, err = h.getFlightCoordinates(r, refFlight)
 			refType = "flight"
 		} else {
 			// No valid reference provided
@@ -310,7 +388,7 @@ func (h *Handler) GetAllAircraft(w http.ResponseWriter, r *http.Request) {
 
 	// Populate clearances for each aircraft
 	for _, aircraft := range aircraft {
-		clearances, err := h.clearanceStorage.GetClearancesByCallsign(aircraft.Flight, 10) // Last 10 clearances
+		clearances, err := h.clearanceStorage.GetClearancesByAircraft(aircraft.Hex, adsb.NormalizeCallsign(aircraft.Flight), 10) // Last 10 clearances
 		if err != nil {
 			h.logger.Error("Failed to get clearances for aircraft",
 				logger.String("callsign", aircraft.Flight),
@@ -355,7 +433,7 @@ func (h *Handler) GetAircraftByHex(w http.ResponseWriter, r *http.Request) {
 
 	// Get aircraft data
 	aircraft, found := h.adsbService.GetAircraftByHex(hex)
-	if !found {
+	if !found || h.hides(r, aircraft.Military, aircraft.Blocked) {
 		http.Error(w, "Aircraft not found", http.StatusNotFound)
 		return
 	}
@@ -374,6 +452,106 @@ func (h *Handler) GetAircraftByHex(w http.ResponseWriter, r *http.Request) {
 	WriteJSON(w, http.StatusOK, aircraft)
 }
 
+// GetStationRecords returns the station's all-time records and rarest aircraft types
+func (h *Handler) GetStationRecords(w http.ResponseWriter, r *http.Request) {
+	records, err := h.recordsService.GetRecords()
+	if err != nil {
+		h.logger.Error("Failed to get station records", logger.Error(err))
+		http.Error(w, "Failed to get station records", http.StatusInternalServerError)
+		return
+	}
+
+	WriteJSON(w, http.StatusOK, records)
+}
+
+// GetDeviations returns the possible deviations from altitude and heading clearances
+// detected since startup, most recent first
+func (h *Handler) GetDeviations(w http.ResponseWriter, r *http.Request) {
+	if h.deviationService == nil {
+		http.Error(w, "Deviation monitoring not enabled", http.StatusServiceUnavailable)
+		return
+	}
+
+	limit := 100
+	if limitStr := r.URL.Query().Get("limit"); limitStr != "" {
+		if parsedLimit, err := strconv.Atoi(limitStr); err == nil && parsedLimit > 0 {
+			limit = parsedLimit
+		}
+	}
+
+	events := h.deviationService.Events(limit)
+	WriteJSON(w, http.StatusOK, map[string]interface{}{
+		"timestamp":  time.Now().UTC(),
+		"count":      len(events),
+		"deviations": events,
+	})
+}
+
+// GetUnstableApproaches returns the unstable approaches flagged since startup, most recent first
+func (h *Handler) GetUnstableApproaches(w http.ResponseWriter, r *http.Request) {
+	if h.approachService == nil {
+		http.Error(w, "Approach monitoring not enabled", http.StatusServiceUnavailable)
+		return
+	}
+
+	limit := 100
+	if limitStr := r.URL.Query().Get("limit"); limitStr != "" {
+		if parsedLimit, err := strconv.Atoi(limitStr); err == nil && parsedLimit > 0 {
+			limit = parsedLimit
+		}
+	}
+
+	approaches := h.approachService.Events(limit)
+	WriteJSON(w, http.StatusOK, map[string]interface{}{
+		"timestamp":  time.Now().UTC(),
+		"count":      len(approaches),
+		"approaches": approaches,
+	})
+}
+
+// GetTerrainAlerts returns the terrain and obstacle alerts raised since startup, most
+// recent first, and the terrain data loaded
+func (h *Handler) GetTerrainAlerts(w http.ResponseWriter, r *http.Request) {
+	if h.terrainService == nil {
+		http.Error(w, "Terrain warnings not enabled", http.StatusServiceUnavailable)
+		return
+	}
+
+	limit := 100
+	if limitStr := r.URL.Query().Get("limit"); limitStr != "" {
+		if parsedLimit, err := strconv.Atoi(limitStr); err == nil && parsedLimit > 0 {
+			limit = parsedLimit
+		}
+	}
+
+	alerts := h.terrainService.Events(limit)
+	WriteJSON(w, http.StatusOK, map[string]interface{}{
+		"timestamp": time.Now().UTC(),
+		"count":     len(alerts),
+		"alerts":    alerts,
+		"terrain":   h.terrainService.Status(),
+	})
+}
+
+// GetCallsigns returns the callsign, hex and registration of every active aircraft
+func (h *Handler) GetCallsigns(w http.ResponseWriter, r *http.Request) {
+	entries := h.adsbService.Callsigns().Entries()
+	if h.hides(r, true, true) {
+		visible := make([]adsb.CallsignEntry, 0, len(entries))
+		for _, entry := range entries {
+			if !h.hidesHex(r, entry.Hex) {
+				visible = append(visible, entry)
+			}
+		}
+		entries = visible
+	}
+	WriteJSON(w, http.StatusOK, map[string]interface{}{
+		"timestamp": time.Now().UTC(),
+		"count":     len(entries),
+		"callsigns": entries,
+	})
+}
+
 // GetAircraftTracks returns both history and future tracks for an aircraft
 func (h *Handler) GetAircraftTracks(w http.ResponseWriter, r *http.Request) {
 	// Get hex ID from URL
@@ -394,7 +572,7 @@ func (h *Handler) GetAircraftTracks(w http.ResponseWriter, r *http.Request) {
 
 	// Get aircraft data for basic info
 	aircraft, found := h.adsbService.GetAircraftByHex(hex)
-	if !found {
+	if !found || h.hides(r, aircraft.Military, aircraft.Blocked) {
 		http.Error(w, "Aircraft not found", http.StatusNotFound)
 		return
 	}
@@ -466,6 +644,115 @@ func (h *Handler) GetAircraftTracks(w http.ResponseWriter, r *http.Request) {
 	WriteJSON(w, http.StatusOK, response)
 }
 
+// GetAircraftProfile returns the altitude and speed of an aircraft's stored positions against
+// their distance to a runway threshold, for approach and departure charts
+func (h *Handler) GetAircraftProfile(w http.ResponseWriter, r *http.Request) {
+	hex := chi.URLParam(r, "id")
+	if hex == "" {
+		http.Error(w, "Missing aircraft ID", http.StatusBadRequest)
+		return
+	}
+
+	limit := 1000
+	if limitStr := r.URL.Query().Get("limit"); limitStr != "" {
+		if parsedLimit, err := strconv.Atoi(limitStr); err == nil && parsedLimit > 0 {
+			limit = parsedLimit
+		}
+	}
+
+	if h.hidesHex(r, hex) {
+		http.Error(w, "No stored positions for aircraft", http.StatusNotFound)
+		return
+	}
+
+	profile, err := h.adsbService.VerticalProfile(hex, r.URL.Query().Get("runway"), limit)
+	if err != nil {
+		if status := runwayErrorStatus(err); status != http.StatusInternalServerError {
+			http.Error(w, err.Error(), status)
+			return
+		}
+		h.logger.Error("Failed to build vertical profile", logger.Error(err), logger.String("hex", hex))
+		http.Error(w, "Failed to build vertical profile", http.StatusInternalServerError)
+		return
+	}
+	if profile == nil {
+		http.Error(w, "No stored positions for aircraft", http.StatusNotFound)
+		return
+	}
+
+	WriteJSON(w, http.StatusOK, profile)
+}
+
+// GetAircraftCommunications returns the transcriptions and clearances linked to an aircraft,
+// newest first. Aircraft no longer tracked keep their history; pass ?callsign= to also
+// include records from before the callsign was linked to the aircraft's hex.
+func (h *Handler) GetAircraftCommunications(w http.ResponseWriter, r *http.Request) {
+	hex := strings.ToLower(chi.URLParam(r, "id"))
+	if hex == "" {
+		http.Error(w, "Missing aircraft ID", http.StatusBadRequest)
+		return
+	}
+
+	limit := 100
+	if limitStr := r.URL.Query().Get("limit"); limitStr != "" {
+		if parsedLimit, err := strconv.Atoi(limitStr); err == nil && parsedLimit > 0 {
+			limit = min(parsedLimit, 1000)
+		}
+	}
+
+	if h.hidesHex(r, hex) {
+		http.Error(w, "Aircraft not found", http.StatusNotFound)
+		return
+	}
+
+	// Records whose callsign wasn't linked to any aircraft are matched by callsign
+	callsign := adsb.NormalizeCallsign(r.URL.Query().Get("callsign"))
+	if callsign == "" {
+		if aircraft, found := h.adsbService.GetAircraftByHex(hex); found {
+			callsign = adsb.NormalizeCallsign(aircraft.Flight)
+		} else if entry, ok := h.adsbService.Callsigns().Lookup(hex); ok {
+			callsign = adsb.NormalizeCallsign(entry.Callsign)
+		}
+	}
+
+	transcriptions, err := h.transcriptionStorage.GetTranscriptionsByAircraft(hex, callsign, limit)
+	if err != nil {
+		h.logger.Error("Failed to retrieve transcriptions by aircraft",
+			logger.String("hex", hex),
+			logger.Error(err))
+		http.Error(w, "Failed to retrieve transcriptions", http.StatusInternalServerError)
+		return
+	}
+
+	clearances, err := h.clearanceStorage.GetClearancesByAircraft(hex, callsign, limit)
+	if err != nil {
+		h.logger.Error("Failed to retrieve clearances by aircraft",
+			logger.String("hex", hex),
+			logger.Error(err))
+		http.Error(w, "Failed to retrieve clearances", http.StatusInternalServerError)
+		return
+	}
+
+	WriteJSON(w, http.StatusOK, map[string]interface{}{
+		"timestamp":      time.Now().UTC(),
+		"hex":            hex,
+		"callsign":       callsign,
+		"transcriptions": transcriptions,
+		"clearances":     clearances,
+	})
+}
+
+// GetReceiverStats returns the raw source's connection, decoding counters, coverage by
+// bearing and the reception of each aircraft, to help position the antenna
+func (h *Handler) GetReceiverStats(w http.ResponseWriter, r *http.Request) {
+	stats, ok := h.adsbService.ReceiverStats()
+	if !ok {
+		http.Error(w, "Receiver statistics need source_type = \"raw\"", http.StatusServiceUnavailable)
+		return
+	}
+	WriteJSON(w, http.StatusOK, stats)
+}
+
 // GetHealth returns the health status of the API
 func (h *Handler) GetHealth(w http.ResponseWriter, r *http.Request) {
 	lastFetch, status := h.adsbService.GetStatus()
@@ -476,16 +763,55 @@ func (h *Handler) GetHealth(w http.ResponseWriter, r *http.Request) {
 		"aircraft_count": len(h.adsbService.GetAllAircraft()),
 	}
 
+	if budget := h.adsbService.BudgetStats(); budget.Enabled {
+		response["budget"] = budget
+	}
+
+	if polling, ok := h.adsbService.ExternalPollStats(); ok {
+		response["external_api"] = polling
+	}
+
+	if uat, ok := h.adsbService.UATStats(); ok {
+		response["uat"] = uat
+	}
+
+	if h.feederService != nil {
+		response["feeder"] = h.feederService.Status()
+	}
+
+	if workers, ok := h.frequenciesService.TranscriptionWorkers(); ok {
+		response["transcription_workers"] = workers
+	}
+
+	if gating, reports := h.frequenciesService.SilenceGating(); gating {
+		saved := 0.0
+		for _, report := range reports {
+			saved += report.EstimatedMinutesSaved
+		}
+		response["transcription"] = map[string]interface{}{
+			"silence_gating":          true,
+			"estimated_minutes_saved": saved,
+			"frequencies":             reports,
+		}
+	}
+
+	if stats, ok := h.frequenciesService.PostProcessingStats(); ok {
+		response["post_processing"] = stats
+	}
+
 	WriteJSON(w, http.StatusOK, response)
 }
 
 // GetConfig returns the public configuration
 func (h *Handler) GetConfig(w http.ResponseWriter, r *http.Request) {
+	// Runtime settings may have changed since startup
+	settings := h.configReloader.Settings()
+
 	// Create a sanitized config with only public values
 	publicConfig := map[string]interface{}{
 		"adsb": map[string]interface{}{
-			"fetch_interval_seconds":     h.config.ADSB.FetchIntervalSecs,
-			"websocket_aircraft_updates": h.config.ADSB.WebSocketAircraftUpdates,
+			"fetch_interval_seconds":     settings.ADSB.FetchIntervalSecs,
+			"websocket_aircraft_updates": settings.ADSB.WebSocketAircraftUpdates,
 		},
 		"storage": map[string]interface{}{
 			"sqlite_base_path":     h.config.Storage.SQLiteBasePath,
@@ -504,6 +830,32 @@ func (h *Handler) GetConfig(w http.ResponseWriter, r *http.Request) {
 	WriteJSON(w, http.StatusOK, publicConfig)
 }
 
+// PatchConfig updates runtime settings (flight phase thresholds, polling intervals and
+// WebSocket options) without restarting audio streams or dropping WebSocket clients
+func (h *Handler) PatchConfig(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, "Failed to read request body", http.StatusBadRequest)
+		return
+	}
+
+	settings, err := h.configReloader.Patch(body)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	response, err := settings.Map()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	h.logger.Info("Updated runtime settings via API")
+
+	WriteJSON(w, http.StatusOK, response)
+}
+
 // GetStationConfig returns the station configuration (latitude, longitude, elevation)
 func (h *Handler) GetStationConfig(w http.ResponseWriter, r *http.Request) {
 	// Get effective coordinates (override if set, otherwise config)
@@ -515,6 +867,7 @@ func (h *Handler) GetStationConfig(w http.ResponseWriter, r *http.Request) {
 		ElevationFeet int         `json:"elevation_feet"`
 		AirportCode   string      `json:"airport_code"`
 		Runways       interface{} `json:"runways,omitempty"`
+		RunwayStatus  interface{} `json:"runway_status,omitempty"` // Closures and forced configuration
 		FetchErrors   []string    `json:"fetch_errors,omitempty"`
 		// Weather configuration flags
 		FetchMETAR  bool `json:"fetch_metar"`
@@ -522,15 +875,20 @@ func (h *Handler) GetStationConfig(w http.ResponseWriter, r *http.Request) {
 		FetchNOTAMs bool `json:"fetch_notams"`
 		// Station override information
 		OverrideActive bool `json:"override_active"`
+		// Magnetic declination at the station today (degrees, positive east)
+		MagneticDeclination float64 `json:"magnetic_declination"`
+		MagneticModel       string  `json:"magnetic_model"`
 	}{
-		Latitude:       effectiveLat,
-		Longitude:      effectiveLon,
-		ElevationFeet:  h.config.Station.ElevationFeet,
-		AirportCode:    h.config.Station.AirportCode,
-		FetchMETAR:     h.config.Weather.FetchMETAR,
-		FetchTAF:       h.config.Weather.FetchTAF,
-		FetchNOTAMs:    h.config.Weather.FetchNOTAMs,
-		OverrideActive: effectiveLat != h.config.Station.Latitude || effectiveLon != h.config.Station.Longitude,
+		Latitude:            effectiveLat,
+		Longitude:           effectiveLon,
+		ElevationFeet:       h.config.Station.ElevationFeet,
+		AirportCode:         h.config.Station.AirportCode,
+		FetchMETAR:          h.config.Weather.FetchMETAR,
+		FetchTAF:            h.config.Weather.FetchTAF,
+		FetchNOTAMs:         h.config.Weather.FetchNOTAMs,
+		OverrideActive:      effectiveLat != h.config.Station.Latitude || effectiveLon != h.config.Station.Longitude,
+		MagneticDeclination: math.Round(h.adsbService.Declination()*10) / 10,
+		MagneticModel:       geomag.Model,
 	}
 
 	// Track if we have any data fetch failures
@@ -541,6 +899,7 @@ func (h *Handler) GetStationConfig(w http.ResponseWriter, r *http.Request) {
 		runwayData, err := h.fetchRunwayData(h.config.Station.RunwaysDBPath)
 		if err == nil {
 			stationCfg.Runways = runwayData
+			stationCfg.RunwayStatus = h.adsbService.GetRunwayState()
 		} else {
 			h.logger.Error("Failed to fetch runway data",
 				logger.String("path", h.config.Station.RunwaysDBPath),
@@ -595,6 +954,7 @@ func (h *Handler) SetStationOverride(w http.ResponseWriter, r *http.Request) {
 
 		// Set override coordinates
 		h.adsbService.SetStationOverride(lat, lon)
+		h.cache.Invalidate(cacheTagStation, cacheTagAircraft)
 		h.logger.Info("Station override coordinates set via API",
 			logger.Float64("latitude", lat),
 			logger.Float64("longitude", lon))
@@ -614,6 +974,7 @@ func (h *Handler) SetStationOverride(w http.ResponseWriter, r *http.Request) {
 	} else {
 		// Clear override coordinates
 		h.adsbService.ClearStationOverride()
+		h.cache.Invalidate(cacheTagStation, cacheTagAircraft)
 		h.logger.Info("Station override coordinates cleared via API")
 
 		response := struct {
@@ -650,24 +1011,106 @@ func (h *Handler) GetWeatherData(w http.ResponseWriter, r *http.Request) {
 	WriteJSON(w, http.StatusOK, weatherData)
 }
 
-// fetchRunwayData loads runway data from the specified file and calculates extended centerlines
-func (h *Handler) fetchRunwayData(filePath string) (interface{}, error) {
-	// Read the runway data file
-	data, err := os.ReadFile(filePath)
+// GetWindsAloft returns the GFS winds aloft forecast for the station and surrounding area
+func (h *Handler) GetWindsAloft(w http.ResponseWriter, r *http.Request) {
+	if h.weatherService == nil || !h.config.Weather.FetchWindsAloft {
+		http.Error(w, "Winds aloft not enabled", http.StatusServiceUnavailable)
+		return
+	}
+
+	winds := h.weatherService.GetWindsAloft()
+	if winds == nil {
+		http.Error(w, "Winds aloft not available yet", http.StatusNotFound)
+		return
+	}
+
+	WriteJSON(w, http.StatusOK, winds)
+}
+
+// GetWeatherHazards returns the SIGMETs, AIRMETs and PIREPs near the station as a GeoJSON
+// feature collection
+func (h *Handler) GetWeatherHazards(w http.ResponseWriter, r *http.Request) {
+	if h.weatherService == nil || !h.config.Weather.FetchHazards {
+		http.Error(w, "Weather hazards not enabled", http.StatusServiceUnavailable)
+		return
+	}
+
+	hazards := h.weatherService.GetHazards()
+	if hazards == nil {
+		http.Error(w, "Weather hazards not available yet", http.StatusNotFound)
+		return
+	}
+
+	WriteJSON(w, http.StatusOK, hazards)
+}
+
+// weatherHistoryHours parses the hours query parameter of the weather history endpoints:
+// default 24, at most the history retention. It writes an error response when invalid.
+func (h *Handler) weatherHistoryHours(w http.ResponseWriter, r *http.Request) (int, bool) {
+	if h.weatherService == nil || !h.weatherService.HasHistory() {
+		http.Error(w, "Weather history not enabled", http.StatusServiceUnavailable)
+		return 0, false
+	}
+
+	hours := 24
+	maxHours := h.config.Weather.HistoryRetentionDays * 24
+	if hoursStr := r.URL.Query().Get("hours"); hoursStr != "" {
+		parsedHours, err := strconv.Atoi(hoursStr)
+		if err != nil || parsedHours <= 0 || parsedHours > maxHours {
+			http.Error(w, fmt.Sprintf("Invalid hours (1-%d)", maxHours), http.StatusBadRequest)
+			return 0, false
+		}
+		hours = parsedHours
+	}
+	return hours, true
+}
+
+// GetWeatherHistory returns the stored METARs of the last hours, oldest first, for graphs
+func (h *Handler) GetWeatherHistory(w http.ResponseWriter, r *http.Request) {
+	hours, ok := h.weatherHistoryHours(w, r)
+	if !ok {
+		return
+	}
+
+	observations, err := h.weatherService.History(hours)
 	if err != nil {
-		return nil, fmt.Errorf("failed to read runway data file: %w", err)
+		h.logger.Error("Failed to get weather history", logger.Error(err))
+		http.Error(w, "Failed to get weather history", http.StatusInternalServerError)
+		return
 	}
 
-	// Parse the JSON data
-	var runwayData struct {
-		Airport          string `json:"airport"`
-		RunwayThresholds map[string]map[string]struct {
-			Latitude  float64 `json:"latitude"`
-			Longitude float64 `json:"longitude"`
-		} `json:"runway_thresholds"`
+	WriteJSON(w, http.StatusOK, map[string]interface{}{
+		"timestamp":    time.Now().UTC(),
+		"airport":      h.config.Station.AirportCode,
+		"hours":        hours,
+		"count":        len(observations),
+		"observations": observations,
+	})
+}
+
+// GetWeatherTrends returns how the wind, pressure and visibility changed over the last hours
+func (h *Handler) GetWeatherTrends(w http.ResponseWriter, r *http.Request) {
+	hours, ok := h.weatherHistoryHours(w, r)
+	if !ok {
+		return
+	}
+
+	trends, err := h.weatherService.Trends(hours)
+	if err != nil {
+		h.logger.Error("Failed to get weather trends", logger.Error(err))
+		http.Error(w, "Failed to get weather trends", http.StatusInternalServerError)
+		return
 	}
-	if err := json.Unmarshal(data, &runwayData); err != nil {
-		return nil, fmt.Errorf("failed to parse runway data: %w", err)
+
+	WriteJSON(w, http.StatusOK, trends)
+}
+
+// fetchRunwayData loads runway data from the specified file and calculates extended centerlines
+func (h *Handler) fetchRunwayData(filePath string) (interface{}, error) {
+	// Read the runways.json or OurAirports runways.csv file
+	runwayData, err := adsb.LoadRunwayData(filePath, h.config.Station.AirportCode)
+	if err != nil {
+		return nil, err
 	}
 
 	// Define the point structure with distance field
@@ -679,12 +1122,9 @@ func (h *Handler) fetchRunwayData(filePath string) (interface{}, error) {
 
 	// Create the response structure with extended centerlines
 	response := struct {
-		Airport          string `json:"airport"`
-		RunwayThresholds map[string]map[string]struct {
-			Latitude  float64 `json:"latitude"`
-			Longitude float64 `json:"longitude"`
-		} `json:"runway_thresholds"`
-		RunwayExtensions map[string]map[string][]Point `json:"runway_extensions"`
+		Airport          string                                         `json:"airport"`
+		RunwayThresholds map[string]map[string]adsb.RunwayThresholdData `json:"runway_thresholds"`
+		RunwayExtensions map[string]map[string][]Point                  `json:"runway_extensions"`
 	}{
 		Airport:          runwayData.Airport,
 		RunwayThresholds: runwayData.RunwayThresholds,
@@ -698,10 +1138,7 @@ func (h *Handler) fetchRunwayData(filePath string) (interface{}, error) {
 		// Process each end of the runway
 		for endID, threshold := range thresholds {
 			// Find the opposite end
-			var oppositeThreshold struct {
-				Latitude  float64 `json:"latitude"`
-				Longitude float64 `json:"longitude"`
-			}
+			var oppositeThreshold adsb.RunwayThresholdData
 			for otherEndID, otherThreshold := range thresholds {
 				if otherEndID != endID {
 					oppositeThreshold = otherThreshold
@@ -718,12 +1155,16 @@ func (h *Handler) fetchRunwayData(filePath string) (interface{}, error) {
 			// Calculate the opposite bearing (for the extension)
 			oppositeBearing := math.Mod(bearing+180, 360)
 
+			// Distances are measured from the landing threshold, past the end of the runway
+			// when it's displaced
+			thresholdLat, thresholdLon := adsb.LandingThreshold(threshold, oppositeThreshold)
+
 			// Create points for the extended centerline (10 nm from threshold)
 			extensionPoints := []Point{
 				// Start with the threshold point
 				{
-					Latitude:  threshold.Latitude,
-					Longitude: threshold.Longitude,
+					Latitude:  thresholdLat,
+					Longitude: thresholdLon,
 					Distance:  0.0,
 				},
 			}
@@ -736,8 +1177,8 @@ func (h *Handler) fetchRunwayData(filePath string) (interface{}, error) {
 
 			// Add points at 1 nm intervals up to the configured length
 			for distance := 1.0; distance <= extensionLengthNM; distance += 1.0 {
-				lat, lon := calculateDestinationPoint(
-					threshold.Latitude, threshold.Longitude,
+				lat, lon := adsb.DestinationPoint(
+					thresholdLat, thresholdLon,
 					oppositeBearing, distance,
 				)
 				extensionPoints = append(extensionPoints, Point{
@@ -772,31 +1213,6 @@ func calculateBearing(lat1, lon1, lat2, lon2 float64) float64 {
 	return math.Mod(math.Mod(bearing, 360)+360, 360)
 }
 
-// calculateDestinationPoint calculates a destination point given a starting point, bearing, and distance
-func calculateDestinationPoint(lat, lon, bearing, distanceNM float64) (float64, float64) {
-	// Convert to radians
-	lat = lat * math.Pi / 180
-	lon = lon * math.Pi / 180
-	bearing = bearing * math.Pi / 180
-
-	// Earth radius in nautical miles
-	earthRadius := 3440.065 // 6371 km / 1.852 km/nm
-
-	// Calculate destination point
-	distRatio := distanceNM / earthRadius
-	lat2 := math.Asin(math.Sin(lat)*math.Cos(distRatio) + math.Cos(lat)*math.Sin(distRatio)*math.Cos(bearing))
-	lon2 := lon + math.Atan2(
-		math.Sin(bearing)*math.Sin(distRatio)*math.Cos(lat),
-		math.Cos(distRatio)-math.Sin(lat)*math.Sin(lat2),
-	)
-
-	// Convert back to degrees
-	lat2 = lat2 * 180 / math.Pi
-	lon2 = lon2 * 180 / math.Pi
-
-	return lat2, lon2
-}
-
 // fetchMetarData fetches METAR data from the Windy API with retry logic
 func (h *Handler) fetchMetarData(airportCode string) (interface{}, error) {
 	url := fmt.Sprintf("https://node.windy.com/airports/metar/%s", airportCode)
@@ -1073,48 +1489,304 @@ func (h *Handler) GetFrequencyByID(w http.ResponseWriter, r *http.Request) {
 	WriteJSON(w, http.StatusOK, frequency)
 }
 
-// StreamAudio streams audio for a frequency
-func (h *Handler) StreamAudio(w http.ResponseWriter, r *http.Request) {
-	// Get frequency ID from URL
+// CreateFrequency adds a new frequency at runtime
+func (h *Handler) CreateFrequency(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		ID              string  `json:"id"`
+		Airport         string  `json:"airport"`
+		Name            string  `json:"name"`
+		FrequencyMHz    float64 `json:"frequency_mhz"`
+		URL             string  `json:"url"`
+		Order           int     `json:"order"`
+		TranscribeAudio bool    `json:"transcribe_audio"`
+		AudioDelayMs    int     `json:"audio_delay_ms"`
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+
+	frequency, err := h.frequenciesService.AddFrequency(config.FrequencyConfig{
+		ID:              req.ID,
+		Airport:         req.Airport,
+		Name:            req.Name,
+		FrequencyMHz:    req.FrequencyMHz,
+		URL:             req.URL,
+		Order:           req.Order,
+		TranscribeAudio: req.TranscribeAudio,
+		AudioDelayMs:    req.AudioDelayMs,
+	})
+	if err != nil {
+		http.Error(w, err.Error(), frequencyErrorStatus(err))
+		return
+	}
+
+	h.cache.Invalidate(cacheTagFrequencies)
+	h.logger.Info("Created frequency via API",
+		logger.String("id", frequency.ID),
+		logger.String("name", frequency.Name))
+
+	WriteJSON(w, http.StatusCreated, frequency)
+}
+
+// UpdateFrequency edits, reorders or toggles transcription on a frequency
+func (h *Handler) UpdateFrequency(w http.ResponseWriter, r *http.Request) {
 	id := chi.URLParam(r, "id")
 	if id == "" {
 		http.Error(w, "Missing frequency ID", http.StatusBadRequest)
 		return
 	}
 
-	// Get client ID from query parameter
-	clientID := r.URL.Query().Get("id")
-	if clientID == "" {
-		// Generate a random client ID if not provided
-		clientID = fmt.Sprintf("client-%d", time.Now().UnixNano())
+	var req frequencies.FrequencyUpdate
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
 	}
 
-	clientRemoteAddr := r.RemoteAddr
+	frequency, err := h.frequenciesService.UpdateFrequency(id, req)
+	if err != nil {
+		http.Error(w, err.Error(), frequencyErrorStatus(err))
+		return
+	}
 
-	// Set binary streaming headers
-	w.Header().Set("Content-Type", "audio/wav")
-	w.Header().Set("Cache-Control", "no-cache, no-store")
-	w.Header().Set("Transfer-Encoding", "chunked")
-	w.Header().Set("Access-Control-Allow-Origin", "*")
-	w.Header().Set("Connection", "keep-alive")                // Add keep-alive
-	w.Header().Set("Keep-Alive", "timeout=86400, max=604800") // Add keep-alive timeout
+	h.cache.Invalidate(cacheTagFrequencies)
+	h.logger.Info("Updated frequency via API", logger.String("id", id))
 
-	// For HEAD requests, just return the headers
-	if r.Method == "HEAD" {
+	WriteJSON(w, http.StatusOK, frequency)
+}
+
+// DeleteFrequency removes a frequency at runtime
+func (h *Handler) DeleteFrequency(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	if id == "" {
+		http.Error(w, "Missing frequency ID", http.StatusBadRequest)
 		return
 	}
 
-	// Use the request's context directly - it will be canceled when the client disconnects
-	ctx := r.Context()
+	if err := h.frequenciesService.RemoveFrequency(id); err != nil {
+		http.Error(w, err.Error(), frequencyErrorStatus(err))
+		return
+	}
 
-	h.logger.Debug("Client requesting audio stream",
-		logger.String("id", id),
-		logger.String("client_id", clientID),
-		logger.String("remote_addr", clientRemoteAddr))
+	h.cache.Invalidate(cacheTagFrequencies)
+	h.logger.Info("Deleted frequency via API", logger.String("id", id))
 
-	// Get audio stream with client ID
-	stream, contentType, err := h.frequenciesService.GetAudioStream(ctx, id, clientID)
-	if err != nil {
+	WriteJSON(w, http.StatusOK, map[string]interface{}{
+		"status": "success",
+	})
+}
+
+// GetListeners returns the clients connected to audio streams, optionally of one frequency
+func (h *Handler) GetListeners(w http.ResponseWriter, r *http.Request) {
+	h.writeListeners(w, r.URL.Query().Get("frequency"))
+}
+
+// GetFrequencyListeners returns the clients connected to a frequency's audio stream
+func (h *Handler) GetFrequencyListeners(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	if id == "" {
+		http.Error(w, "Missing frequency ID", http.StatusBadRequest)
+		return
+	}
+	h.writeListeners(w, id)
+}
+
+// writeListeners writes the listeners of a frequency, or of all frequencies if id is empty
+func (h *Handler) writeListeners(w http.ResponseWriter, id string) {
+	listeners, err := h.frequenciesService.Listeners(id)
+	if err != nil {
+		http.Error(w, err.Error(), frequencyErrorStatus(err))
+		return
+	}
+
+	WriteJSON(w, http.StatusOK, map[string]interface{}{
+		"count":     len(listeners),
+		"listeners": listeners,
+	})
+}
+
+// DisconnectListener closes a client's audio stream
+func (h *Handler) DisconnectListener(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	clientID := chi.URLParam(r, "clientId")
+	if id == "" || clientID == "" {
+		http.Error(w, "Missing frequency or client ID", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.frequenciesService.DisconnectListener(id, clientID); err != nil {
+		http.Error(w, err.Error(), frequencyErrorStatus(err))
+		return
+	}
+
+	h.logger.Info("Disconnected listener via API",
+		logger.String("id", id),
+		logger.String("client_id", clientID))
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// frequencyErrorStatus maps frequency service errors to HTTP status codes
+func frequencyErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, frequencies.ErrFrequencyNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, frequencies.ErrFrequencyExists):
+		return http.StatusConflict
+	case errors.Is(err, frequencies.ErrInvalidFrequency):
+		return http.StatusBadRequest
+	case errors.Is(err, frequencies.ErrRecordingNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, frequencies.ErrListenerNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, frequencies.ErrStereoPairNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, frequencies.ErrRecordingDisabled):
+		return http.StatusServiceUnavailable
+	case errors.Is(err, audio.ErrHLSSegmentNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, audio.ErrHLSUnavailable):
+		return http.StatusServiceUnavailable
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// GetFrequencyRecordings returns the recorded segments of a frequency within a time range.
+// The range defaults to the last hour.
+func (h *Handler) GetFrequencyRecordings(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	if id == "" {
+		http.Error(w, "Missing frequency ID", http.StatusBadRequest)
+		return
+	}
+
+	to := time.Now().UTC()
+	if toStr := r.URL.Query().Get("to"); toStr != "" {
+		t, err := time.Parse(time.RFC3339, toStr)
+		if err != nil {
+			http.Error(w, "Invalid 'to' parameter, expected RFC3339", http.StatusBadRequest)
+			return
+		}
+		to = t
+	}
+
+	from := to.Add(-1 * time.Hour)
+	if fromStr := r.URL.Query().Get("from"); fromStr != "" {
+		t, err := time.Parse(time.RFC3339, fromStr)
+		if err != nil {
+			http.Error(w, "Invalid 'from' parameter, expected RFC3339", http.StatusBadRequest)
+			return
+		}
+		from = t
+	}
+
+	if !from.Before(to) {
+		http.Error(w, "'from' must be before 'to'", http.StatusBadRequest)
+		return
+	}
+
+	segments, err := h.frequenciesService.GetRecordings(id, from, to)
+	if err != nil {
+		http.Error(w, err.Error(), frequencyErrorStatus(err))
+		return
+	}
+
+	type recordingResponse struct {
+		*sqlite.RecordingSegment
+		URL string `json:"url"`
+	}
+	recordings := make([]recordingResponse, 0, len(segments))
+	for _, segment := range segments {
+		recordings = append(recordings, recordingResponse{
+			RecordingSegment: segment,
+			URL:              fmt.Sprintf("/api/v1/recordings/%d", segment.ID),
+		})
+	}
+
+	WriteJSON(w, http.StatusOK, map[string]interface{}{
+		"frequency_id": id,
+		"from":         from.UTC(),
+		"to":           to.UTC(),
+		"count":        len(recordings),
+		"recordings":   recordings,
+	})
+}
+
+// GetRecording serves the audio file of a recorded segment
+func (h *Handler) GetRecording(w http.ResponseWriter, r *http.Request) {
+	segmentID, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid recording ID", http.StatusBadRequest)
+		return
+	}
+
+	segment, err := h.frequenciesService.GetRecordingSegment(segmentID)
+	if err != nil {
+		http.Error(w, err.Error(), frequencyErrorStatus(err))
+		return
+	}
+
+	// ServeFile handles range requests so players can seek within the segment
+	w.Header().Set("Content-Type", audio.SegmentContentType(segment.Format))
+	http.ServeFile(w, r, segment.Path)
+}
+
+// StreamAudio streams audio for a frequency
+func (h *Handler) StreamAudio(w http.ResponseWriter, r *http.Request) {
+	// Get frequency ID from URL
+	id := chi.URLParam(r, "id")
+	if id == "" {
+		http.Error(w, "Missing frequency ID", http.StatusBadRequest)
+		return
+	}
+
+	// Get client ID from query parameter
+	clientID := r.URL.Query().Get("id")
+	if clientID == "" {
+		// Generate a random client ID if not provided
+		clientID = fmt.Sprintf("client-%d", time.Now().UnixNano())
+	}
+
+	clientRemoteAddr := r.RemoteAddr
+
+	format, ok := parseStreamFormat(r)
+	if !ok {
+		http.Error(w, "Invalid format (use wav, webm or ogg)", http.StatusBadRequest)
+		return
+	}
+	setStreamHeaders(w, format)
+
+	// For HEAD requests, just return the headers
+	if r.Method == "HEAD" {
+		return
+	}
+
+	// Use the request's context directly - it will be canceled when the client disconnects
+	ctx := r.Context()
+
+	h.logger.Debug("Client requesting audio stream",
+		logger.String("id", id),
+		logger.String("client_id", clientID),
+		logger.String("remote_addr", clientRemoteAddr))
+
+	info := frequencies.ListenerInfo{
+		RemoteAddr: clientRemoteAddr,
+		UserAgent:  r.UserAgent(),
+		Format:     "wav",
+	}
+
+	// Get audio stream with client ID
+	var stream io.ReadCloser
+	var contentType string
+	var err error
+	if format == "" {
+		stream, contentType, err = h.frequenciesService.GetAudioStream(ctx, id, clientID, info)
+	} else {
+		info.Format = format
+		stream, contentType, err = h.frequenciesService.GetEncodedAudioStream(ctx, id, clientID, format, info)
+	}
+	if err != nil {
 		// Check if the error is due to client already being connected
 		if strings.Contains(err.Error(), "client already connected") {
 			h.logger.Debug("Client already connected, returning success",
@@ -1146,6 +1818,131 @@ func (h *Handler) StreamAudio(w http.ResponseWriter, r *http.Request) {
 		logger.String("content_type", contentType),
 	)
 
+	h.copyAudioStream(ctx, w, stream, id, clientID, clientRemoteAddr)
+}
+
+// StreamScanner streams whichever of the requested frequencies has a transmission
+func (h *Handler) StreamScanner(w http.ResponseWriter, r *http.Request) {
+	var ids []string
+	for _, id := range strings.Split(r.URL.Query().Get("frequencies"), ",") {
+		if id = strings.TrimSpace(id); id != "" {
+			ids = append(ids, id)
+		}
+	}
+
+	h.serveMixedStream(w, r, frequencies.MixScanner, func(ctx context.Context, clientID, format string, info frequencies.ListenerInfo) (io.ReadCloser, string, error) {
+		return h.frequenciesService.GetScannerStream(ctx, ids, clientID, format, info)
+	})
+}
+
+// StreamStereo streams a configured frequency pair, or the left and right frequencies of
+// the query, one in each channel
+func (h *Handler) StreamStereo(w http.ResponseWriter, r *http.Request) {
+	pairID := chi.URLParam(r, "pair")
+	left, right := r.URL.Query().Get("left"), r.URL.Query().Get("right")
+	if pairID == "" && (left == "" || right == "") {
+		http.Error(w, "Missing left or right frequency ID", http.StatusBadRequest)
+		return
+	}
+
+	h.serveMixedStream(w, r, frequencies.MixStereo, func(ctx context.Context, clientID, format string, info frequencies.ListenerInfo) (io.ReadCloser, string, error) {
+		if pairID != "" {
+			return h.frequenciesService.GetStereoStreamByPair(ctx, pairID, clientID, format, info)
+		}
+		return h.frequenciesService.GetStereoStream(ctx, left, right, clientID, format, info)
+	})
+}
+
+// GetStereoPairs returns the configured stereo frequency pairs
+func (h *Handler) GetStereoPairs(w http.ResponseWriter, r *http.Request) {
+	pairs := h.frequenciesService.StereoPairs()
+	WriteJSON(w, http.StatusOK, map[string]interface{}{
+		"count": len(pairs),
+		"pairs": pairs,
+	})
+}
+
+// serveMixedStream streams audio mixed from several frequencies to the client, opened with
+// the client ID, format and listener details of the request
+func (h *Handler) serveMixedStream(w http.ResponseWriter, r *http.Request, mix string, open func(ctx context.Context, clientID, format string, info frequencies.ListenerInfo) (io.ReadCloser, string, error)) {
+	clientID := r.URL.Query().Get("id")
+	if clientID == "" {
+		clientID = fmt.Sprintf("%s-%d", mix, time.Now().UnixNano())
+	}
+
+	format, ok := parseStreamFormat(r)
+	if !ok {
+		http.Error(w, "Invalid format (use wav, webm or ogg)", http.StatusBadRequest)
+		return
+	}
+
+	info := frequencies.ListenerInfo{
+		RemoteAddr: r.RemoteAddr,
+		UserAgent:  r.UserAgent(),
+		Format:     "wav",
+	}
+	if format != "" {
+		info.Format = format
+	}
+
+	setStreamHeaders(w, format)
+	if r.Method == "HEAD" {
+		return
+	}
+
+	ctx := r.Context()
+	stream, _, err := open(ctx, clientID, format, info)
+	if err != nil {
+		if status := frequencyErrorStatus(err); status != http.StatusInternalServerError {
+			http.Error(w, err.Error(), status)
+			return
+		}
+		if strings.Contains(err.Error(), "client already connected") {
+			w.Header().Set("X-Already-Connected", "true")
+			w.WriteHeader(http.StatusOK)
+			return
+		}
+		h.logger.Error("Failed to get mixed audio stream",
+			logger.String("mix", mix),
+			logger.String("client_id", clientID),
+			logger.String("remote_addr", r.RemoteAddr),
+			logger.Error(err))
+		http.Error(w, "Stream unavailable", http.StatusServiceUnavailable)
+		return
+	}
+	defer stream.Close()
+
+	h.copyAudioStream(ctx, w, stream, mix, clientID, r.RemoteAddr)
+}
+
+// parseStreamFormat returns the listener stream format of a request: "" for WAV, which
+// passes the decoded audio through, or webm/ogg, which transcode it to Opus for the listener
+func parseStreamFormat(r *http.Request) (string, bool) {
+	switch format := r.URL.Query().Get("format"); format {
+	case "", "wav":
+		return "", true
+	case "opus":
+		return audio.StreamFormatWebM, true
+	case audio.StreamFormatWebM, audio.StreamFormatOgg:
+		return format, true
+	default:
+		return "", false
+	}
+}
+
+// setStreamHeaders sets the binary streaming headers of a listener stream
+func setStreamHeaders(w http.ResponseWriter, format string) {
+	w.Header().Set("Content-Type", audio.StreamContentType(format))
+	w.Header().Set("Cache-Control", "no-cache, no-store")
+	w.Header().Set("Transfer-Encoding", "chunked")
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+	w.Header().Set("Connection", "keep-alive")                // Add keep-alive
+	w.Header().Set("Keep-Alive", "timeout=86400, max=604800") // Add keep-alive timeout
+}
+
+// copyAudioStream writes a listener stream to the client until the client disconnects or
+// the stream ends
+func (h *Handler) copyAudioStream(ctx context.Context, w http.ResponseWriter, stream io.Reader, id, clientID, clientRemoteAddr string) {
 	// Connection monitoring setup
 	connectionStartTime := time.Now()
 
@@ -1248,6 +2045,41 @@ func (h *Handler) StreamAudio(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+// GetStreamHLSFile serves a frequency's live HLS playlist or one of its segments. Segment
+// URIs in the playlist are relative, so both live under /stream/{id}/.
+func (h *Handler) GetStreamHLSFile(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	file := chi.URLParam(r, "file")
+
+	if file == audio.HLSPlaylistName {
+		path, err := h.frequenciesService.GetHLSPlaylist(r.Context(), id)
+		if err != nil {
+			h.logger.Warn("Failed to get HLS playlist", logger.String("id", id), logger.Error(err))
+			http.Error(w, err.Error(), frequencyErrorStatus(err))
+			return
+		}
+
+		// The playlist changes with every segment
+		w.Header().Set("Content-Type", "application/vnd.apple.mpegurl")
+		w.Header().Set("Cache-Control", "no-cache, no-store")
+		w.Header().Set("Access-Control-Allow-Origin", "*")
+		http.ServeFile(w, r, path)
+		return
+	}
+
+	path, err := h.frequenciesService.GetHLSSegment(id, file)
+	if err != nil {
+		http.Error(w, err.Error(), frequencyErrorStatus(err))
+		return
+	}
+
+	// Segments never change once they're in the playlist
+	w.Header().Set("Content-Type", "video/mp2t")
+	w.Header().Set("Cache-Control", "public, max-age=60")
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+	http.ServeFile(w, r, path)
+}
+
 // parseAircraftFilters parses aircraft filter parameters from the request
 func parseAircraftFilters(r *http.Request) (float64, float64, string, []string, int, *time.Time, *time.Time, *time.Time, *time.Time, float64, float64, float64, string, string, bool) {
 	minAltitude := 0.0
@@ -1375,10 +2207,10 @@ func (h *Handler) getHexCoordinates(hexCode string) (float64, float64, error) {
 }
 
 // getRefAircraft gets the reference aircraft by hex code
-func (h *Handler) getRefAircraft(hexCode string) (*adsb.Aircraft, error) {
+func (h *Handler) getRefAircraft(r *http.Request, hexCode string) (*adsb.Aircraft, error) {
 	// Look up aircraft by hex code
 	aircraft, found := h.adsbService.GetAircraftByHex(hexCode)
-	if !found {
+	if !found || h.hides(r, aircraft.Military, aircraft.Blocked) {
 		return nil, fmt.Errorf("aircraft with hex %s not found", hexCode)
 	}
 
@@ -1390,26 +2222,22 @@ func (h *Handler) getRefAircraft(hexCode string) (*adsb.Aircraft, error) {
 }
 
 // getFlightCoordinates gets coordinates from a flight number or tail number
-func (h *Handler) getFlightCoordinates(flight string) (float64, float64, error) {
-	// Look up aircraft by flight number
-	// First, get all aircraft
-	allAircraft := h.adsbService.GetAllAircraft()
-
-	// Find the one with matching flight number
-	for _, a := range allAircraft {
-		if strings.EqualFold(strings.TrimSpace(a.Flight), strings.TrimSpace(flight)) {
-			if a.ADSB == nil {
-				return 0, 0, fmt.Errorf("aircraft with flight %s has no ADSB data", flight)
-			}
+func (h *Handler) getFlightCoordinates(r *http.Request, flight string) (float64, float64, error) {
+	// Look up aircraft by flight number or registration
+	a, found := h.adsbService.GetAircraftByCallsign(flight)
+	if !found || a == nil || h.hides(r, a.Military, a.Blocked) {
+		return 0, 0, fmt.Errorf("aircraft with flight %s not found", flight)
+	}
 
-			if a.ADSB.Lat == 0 && a.ADSB.Lon == 0 {
-				return 0, 0, fmt.Errorf("aircraft with flight %s has no position data", flight)
-			}
+	if a.ADSB == nil {
+		return 0, 0, fmt.Errorf("aircraft with flight %s has no ADSB data", flight)
+	}
 
-			return a.ADSB.Lat, a.ADSB.Lon, nil
-		}
+	if a.ADSB.Lat == 0 && a.ADSB.Lon == 0 {
+		return 0, 0, fmt.Errorf("aircraft with flight %s has no position data", flight)
 	}
-	return 0, 0, fmt.Errorf("aircraft with flight %s not found", flight)
+
+	return a.ADSB.Lat, a.ADSB.Lon, nil
 }
 
 // WriteJSON writes a JSON response
@@ -1442,8 +2270,18 @@ func (h *Handler) CreateATCChatSession(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	session, err := h.atcChatService.CreateSession(r.Context())
+	req := decodeChatSessionRequest(r)
+	session, err := h.atcChatService.CreateSession(r.Context(), req.ClientID, req.Persona)
 	if err != nil {
+		if errors.Is(err, atcchat.ErrUnknownPersona) {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if errors.Is(err, atcchat.ErrTooManySessions) {
+			http.Error(w, err.Error(), http.StatusTooManyRequests)
+			return
+		}
+
 		// Check if this is a missing API key error - handle gracefully
 		if strings.Contains(err.Error(), "OpenAI API key is required") {
 			h.logger.Warn("ATC Chat session creation failed - API key not configured")
@@ -1514,7 +2352,7 @@ func (h *Handler) HandleATCChatWebSocket(w http.ResponseWriter, r *http.Request)
 	}
 
 	// Create ATC chat handlers and delegate to them
-	atcChatHandlers := NewATCChatHandlers(h.atcChatService, h.logger)
+	atcChatHandlers := NewATCChatHandlers(h.atcChatService, h.usageTracker, h.logger)
 
 	// Update the URL parameter to match what the ATC chat handler expects
 	rctx := chi.NewRouteContext()
@@ -1554,18 +2392,47 @@ func (h *Handler) GetATCChatSessionStatus(w http.ResponseWriter, r *http.Request
 	}
 }
 
-// GetATCChatSessions returns all active ATC chat sessions
+// GetATCChatSessions returns stored ATC chat sessions, newest first, marking those still active
 func (h *Handler) GetATCChatSessions(w http.ResponseWriter, r *http.Request) {
 	if h.atcChatService == nil {
 		http.Error(w, "ATC Chat service not available", http.StatusServiceUnavailable)
 		return
 	}
 
-	sessions := h.atcChatService.ListActiveSessions()
+	limit := 50
+	if limitStr := r.URL.Query().Get("limit"); limitStr != "" {
+		if parsedLimit, err := strconv.Atoi(limitStr); err == nil && parsedLimit > 0 {
+			limit = min(parsedLimit, 500)
+		}
+	}
+	offset := 0
+	if offsetStr := r.URL.Query().Get("offset"); offsetStr != "" {
+		if parsedOffset, err := strconv.Atoi(offsetStr); err == nil && parsedOffset > 0 {
+			offset = parsedOffset
+		}
+	}
+
+	// Stored sessions, including those of previous runs of the server
+	sessions, err := h.atcChatService.ListSessions(r.URL.Query().Get("client_id"), limit, offset)
+	if err != nil {
+		h.logger.Error("Failed to list ATC chat sessions", logger.Error(err))
+		http.Error(w, "Failed to list sessions", http.StatusInternalServerError)
+		return
+	}
+
+	active := 0
+	for _, session := range sessions {
+		if session.Active {
+			active++
+		}
+	}
 
 	response := map[string]interface{}{
 		"sessions": sessions,
 		"count":    len(sessions),
+		"active":   active,
+		"limit":    limit,
+		"offset":   offset,
 		"status":   "success",
 	}
 
@@ -1577,6 +2444,34 @@ func (h *Handler) GetATCChatSessions(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+// GetATCChatSessionHistory returns a chat session with its full transcript
+func (h *Handler) GetATCChatSessionHistory(w http.ResponseWriter, r *http.Request) {
+	if h.atcChatService == nil {
+		http.Error(w, "ATC Chat service not available", http.StatusServiceUnavailable)
+		return
+	}
+
+	sessionID := chi.URLParam(r, "sessionId")
+	history, err := h.atcChatService.GetSessionHistory(sessionID)
+	if err != nil {
+		h.logger.Error("Failed to retrieve ATC chat session history",
+			logger.String("session_id", sessionID),
+			logger.Error(err))
+		http.Error(w, "Failed to retrieve session history", http.StatusInternalServerError)
+		return
+	}
+	if history == nil {
+		http.Error(w, "Session not found", http.StatusNotFound)
+		return
+	}
+
+	WriteJSON(w, http.StatusOK, map[string]interface{}{
+		"session":  history.Session,
+		"messages": history.Messages,
+		"count":    len(history.Messages),
+	})
+}
+
 // GetATCChatAirspaceStatus returns current airspace status for ATC chat
 func (h *Handler) GetATCChatAirspaceStatus(w http.ResponseWriter, r *http.Request) {
 	if h.atcChatService == nil {
@@ -1594,6 +2489,63 @@ func (h *Handler) GetATCChatAirspaceStatus(w http.ResponseWriter, r *http.Reques
 	}
 }
 
+// GetATCChatPersonas returns the personas a chat session can be created with
+func (h *Handler) GetATCChatPersonas(w http.ResponseWriter, r *http.Request) {
+	if h.atcChatService == nil {
+		http.Error(w, "ATC Chat service not available", http.StatusServiceUnavailable)
+		return
+	}
+
+	personas, defaultPersona := h.atcChatService.Personas()
+	WriteJSON(w, http.StatusOK, map[string]interface{}{
+		"personas": personas,
+		"default":  defaultPersona,
+		"count":    len(personas),
+	})
+}
+
+// GetATCChatMemory returns the remembered previous session of a chat client
+func (h *Handler) GetATCChatMemory(w http.ResponseWriter, r *http.Request) {
+	if h.atcChatService == nil {
+		http.Error(w, "ATC Chat service not available", http.StatusServiceUnavailable)
+		return
+	}
+
+	clientID := chi.URLParam(r, "clientId")
+	summary, err := h.atcChatService.GetPreviousSession(clientID)
+	if err != nil {
+		h.logger.Error("Failed to retrieve chat session summary", logger.Error(err))
+		http.Error(w, "Failed to retrieve chat session summary", http.StatusInternalServerError)
+		return
+	}
+
+	WriteJSON(w, http.StatusOK, map[string]interface{}{
+		"enabled":          h.atcChatService.GetConfig().SessionMemory,
+		"client_id":        clientID,
+		"previous_session": summary,
+	})
+}
+
+// DeleteATCChatMemory forgets everything remembered about a chat client's sessions
+func (h *Handler) DeleteATCChatMemory(w http.ResponseWriter, r *http.Request) {
+	if h.atcChatService == nil {
+		http.Error(w, "ATC Chat service not available", http.StatusServiceUnavailable)
+		return
+	}
+
+	clientID := chi.URLParam(r, "clientId")
+	if err := h.atcChatService.ForgetClient(clientID); err != nil {
+		h.logger.Error("Failed to delete chat session summaries", logger.Error(err))
+		http.Error(w, "Failed to delete chat session summaries", http.StatusInternalServerError)
+		return
+	}
+
+	WriteJSON(w, http.StatusOK, map[string]interface{}{
+		"status":    "success",
+		"client_id": clientID,
+	})
+}
+
 // UpdateATCChatSessionContext updates the session context with fresh airspace data
 func (h *Handler) UpdateATCChatSessionContext(w http.ResponseWriter, r *http.Request) {
 	if h.atcChatService == nil {
@@ -1621,25 +2573,29 @@ func (h *Handler) UpdateATCChatSessionContext(w http.ResponseWriter, r *http.Req
 	}
 
 	// Update session context with fresh airspace data
-	if err := h.atcChatService.UpdateSessionContextOnDemand(sessionID); err != nil {
+	turnID, err := h.atcChatService.UpdateSessionContextOnDemand(sessionID)
+	if err != nil {
 		h.logger.Error("Failed to update session context",
 			logger.String("session_id", sessionID),
+			logger.String("correlation_id", turnID),
 			logger.Error(err))
 		http.Error(w, fmt.Sprintf("Failed to update session context: %v", err), http.StatusInternalServerError)
 		return
 	}
 
 	h.logger.Info("Session context updated successfully",
-		logger.String("session_id", sessionID))
+		logger.String("session_id", sessionID),
+		logger.String("correlation_id", turnID))
 
 	// Return success response with the actual instructions sent to AI and individual variables
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(map[string]interface{}{
-		"status":        "success",
-		"message":       "Session context updated with fresh airspace data",
-		"instructions":  promptWithVars.Prompt,
-		"prompt_length": len(promptWithVars.Prompt),
-		"variables":     promptWithVars.Variables,
+		"status":         "success",
+		"message":        "Session context updated with fresh airspace data",
+		"instructions":   promptWithVars.Prompt,
+		"prompt_length":  len(promptWithVars.Prompt),
+		"variables":      promptWithVars.Variables,
+		"correlation_id": turnID,
 	})
 }
 
@@ -1654,6 +2610,8 @@ func (h *Handler) convertClearancesToAPIFormat(clearances []*sqlite.ClearanceRec
 			Type:            c.ClearanceType,
 			Text:            c.ClearanceText,
 			Runway:          c.Runway,
+			Altitude:        c.Altitude,
+			Heading:         c.Heading,
 			Timestamp:       c.Timestamp,
 			Status:          c.Status,
 			TimeSinceIssued: h.formatTimeSince(now.Sub(c.Timestamp)),
@@ -1685,6 +2643,10 @@ func (h *Handler) CreateSimulatedAircraft(w http.ResponseWriter, r *http.Request
 		Heading      float64 `json:"heading"`
 		Speed        float64 `json:"speed"`
 		VerticalRate float64 `json:"vertical_rate"`
+		AircraftType string  `json:"aircraft_type"`     // Optional ICAO type designator for the fuel profile
+		Endurance    float64 `json:"endurance_minutes"` // Optional starting fuel as time at cruise burn
+
+		Autopilot *simulation.AutopilotSettings `json:"autopilot"` // Optional autopilot to engage right away
 	}
 
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
@@ -1718,15 +2680,31 @@ func (h *Handler) CreateSimulatedAircraft(w http.ResponseWriter, r *http.Request
 		return
 	}
 
+	if req.Endurance < 0 || req.Endurance > 1440 {
+		http.Error(w, "Invalid endurance (0-1440 minutes)", http.StatusBadRequest)
+		return
+	}
+
 	aircraft, err := h.simulationService.CreateAircraft(
 		req.Lat, req.Lon, req.Altitude,
 		req.Heading, req.Speed, req.VerticalRate,
+		strings.ToUpper(strings.TrimSpace(req.AircraftType)), req.Endurance,
 	)
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
 
+	if req.Autopilot != nil {
+		aircraft, err = h.simulationService.SetAutopilot(aircraft.Hex, *req.Autopilot)
+		if err != nil {
+			// Don't leave an aircraft flying without the autopilot it was meant to have
+			h.simulationService.RemoveAircraft(aircraft.Hex)
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+
 	h.logger.Info("Created simulated aircraft via API",
 		logger.String("hex", aircraft.Hex),
 		logger.String("flight", aircraft.Flight))
@@ -1791,6 +2769,121 @@ func (h *Handler) UpdateSimulationControls(w http.ResponseWriter, r *http.Reques
 	})
 }
 
+// SetSimulatedFuel sets the fuel of a simulated aircraft, either as an endurance or as a
+// training scenario such as minimum fuel
+func (h *Handler) SetSimulatedFuel(w http.ResponseWriter, r *http.Request) {
+	hex := chi.URLParam(r, "hex")
+	if hex == "" {
+		http.Error(w, "Missing hex parameter", http.StatusBadRequest)
+		return
+	}
+
+	var req struct {
+		Scenario  string   `json:"scenario"`
+		Endurance *float64 `json:"endurance_minutes"`
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+
+	if (req.Scenario == "") == (req.Endurance == nil) {
+		http.Error(w, "Set either scenario or endurance_minutes", http.StatusBadRequest)
+		return
+	}
+
+	if !h.simulationService.IsSimulated(hex) {
+		http.Error(w, fmt.Sprintf("simulated aircraft with hex %s not found", hex), http.StatusNotFound)
+		return
+	}
+
+	var aircraft *simulation.SimulatedAircraft
+	var err error
+	if req.Scenario != "" {
+		aircraft, err = h.simulationService.ApplyFuelScenario(hex, req.Scenario)
+	} else {
+		if *req.Endurance > 1440 {
+			http.Error(w, "Invalid endurance (0-1440 minutes)", http.StatusBadRequest)
+			return
+		}
+		aircraft, err = h.simulationService.SetFuel(hex, *req.Endurance)
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	h.logger.Info("Set simulated fuel via API",
+		logger.String("hex", hex),
+		logger.String("scenario", req.Scenario),
+		logger.String("fuel_state", aircraft.FuelState))
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"status":   "success",
+		"aircraft": aircraft,
+	})
+}
+
+// SetSimulatedAutopilot engages the autopilot of a simulated aircraft: fly a heading or a
+// route, climb or descend to an altitude, intercept a localizer, and land and vacate
+func (h *Handler) SetSimulatedAutopilot(w http.ResponseWriter, r *http.Request) {
+	hex := chi.URLParam(r, "hex")
+	if hex == "" {
+		http.Error(w, "Missing hex parameter", http.StatusBadRequest)
+		return
+	}
+
+	var req simulation.AutopilotSettings
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+
+	if !h.simulationService.IsSimulated(hex) {
+		http.Error(w, fmt.Sprintf("simulated aircraft with hex %s not found", hex), http.StatusNotFound)
+		return
+	}
+
+	aircraft, err := h.simulationService.SetAutopilot(hex, req)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	h.logger.Info("Engaged simulated autopilot via API",
+		logger.String("hex", hex),
+		logger.String("mode", aircraft.Autopilot.Mode))
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"status":   "success",
+		"aircraft": aircraft,
+	})
+}
+
+// DisengageSimulatedAutopilot hands a simulated aircraft back to manual control
+func (h *Handler) DisengageSimulatedAutopilot(w http.ResponseWriter, r *http.Request) {
+	hex := chi.URLParam(r, "hex")
+	if hex == "" {
+		http.Error(w, "Missing hex parameter", http.StatusBadRequest)
+		return
+	}
+
+	aircraft, err := h.simulationService.DisengageAutopilot(hex)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"status":   "success",
+		"aircraft": aircraft,
+	})
+}
+
 // RemoveSimulatedAircraft removes a simulated aircraft
 func (h *Handler) RemoveSimulatedAircraft(w http.ResponseWriter, r *http.Request) {
 	hex := chi.URLParam(r, "hex")
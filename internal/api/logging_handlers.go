@@ -0,0 +1,158 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/yegors/co-atc/pkg/logger"
+	"go.uber.org/zap/zapcore"
+)
+
+// defaultLogEntries is how many of the latest log entries are returned without a limit
+const defaultLogEntries = 500
+
+// LoggingLevels are the log level and the overrides by logger name
+type LoggingLevels struct {
+	Level  string            `json:"level"`
+	Levels map[string]string `json:"levels"`
+}
+
+// loggingLevels describes the current levels
+func loggingLevels(levels *logger.Levels) LoggingLevels {
+	return LoggingLevels{Level: levels.Level(), Levels: levels.Overrides()}
+}
+
+// GetLogging returns the log level and the overrides by logger name
+func (h *Handler) GetLogging(w http.ResponseWriter, r *http.Request) {
+	levels := h.logger.Levels()
+	if levels == nil {
+		http.Error(w, "Log levels are not available", http.StatusServiceUnavailable)
+		return
+	}
+	WriteJSON(w, http.StatusOK, loggingLevels(levels))
+}
+
+// UpdateLogging changes the log level and overrides by logger name until the server restarts.
+// An empty override level returns the loggers it covers to the default level.
+func (h *Handler) UpdateLogging(w http.ResponseWriter, r *http.Request) {
+	levels := h.logger.Levels()
+	if levels == nil {
+		http.Error(w, "Log levels are not available", http.StatusServiceUnavailable)
+		return
+	}
+
+	var req LoggingLevels
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	// Check every level before changing any
+	check, err := logger.NewLevels(levels.Level(), nil)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if req.Level != "" {
+		if err := check.SetLevel(req.Level); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+	for name, level := range req.Levels {
+		if level == "" {
+			continue
+		}
+		if err := check.SetOverride(name, level); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+
+	if req.Level != "" {
+		levels.SetLevel(req.Level)
+	}
+	for name, level := range req.Levels {
+		name = strings.TrimSpace(name)
+		if level == "" {
+			levels.ClearOverride(name)
+		} else {
+			levels.SetOverride(name, level)
+		}
+	}
+
+	h.logger.Info("Changed log levels", logger.String("levels", levels.String()))
+	WriteJSON(w, http.StatusOK, loggingLevels(levels))
+}
+
+// GetLogs returns the latest log entries kept in memory, filtered by level, logger, time and
+// text
+func (h *Handler) GetLogs(w http.ResponseWriter, r *http.Request) {
+	buffer := h.logger.Buffer()
+	if buffer == nil {
+		http.Error(w, "Log buffer is disabled", http.StatusServiceUnavailable)
+		return
+	}
+
+	filter, err := parseLogFilter(r, buffer.Size())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	entries := buffer.Entries(filter)
+	if entries == nil {
+		entries = []logger.Entry{}
+	}
+	WriteJSON(w, http.StatusOK, map[string]interface{}{
+		"entries":     entries,
+		"count":       len(entries),
+		"buffer_size": buffer.Size(),
+		"logged":      buffer.Logged(),
+	})
+}
+
+// parseLogFilter reads the level, module, start_time, end_time, q and limit parameters
+func parseLogFilter(r *http.Request, bufferSize int) (logger.EntryFilter, error) {
+	query := r.URL.Query()
+	filter := logger.EntryFilter{
+		Level:  zapcore.DebugLevel,
+		Logger: strings.TrimSpace(query.Get("module")),
+		Text:   strings.TrimSpace(query.Get("q")),
+		Limit:  defaultLogEntries,
+	}
+
+	if level := query.Get("level"); level != "" {
+		parsed, err := zapcore.ParseLevel(level)
+		if err != nil || parsed > zapcore.ErrorLevel {
+			return filter, fmt.Errorf("invalid level %q (use debug, info, warn or error)", level)
+		}
+		filter.Level = parsed
+	}
+	if value := query.Get("start_time"); value != "" {
+		since, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			return filter, fmt.Errorf("invalid start_time format (use RFC3339)")
+		}
+		filter.Since = since
+	}
+	if value := query.Get("end_time"); value != "" {
+		until, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			return filter, fmt.Errorf("invalid end_time format (use RFC3339)")
+		}
+		filter.Until = until
+	}
+	if value := query.Get("limit"); value != "" {
+		limit, err := strconv.Atoi(value)
+		if err != nil || limit < 1 || limit > bufferSize {
+			return filter, fmt.Errorf("limit must be between 1 and %d", bufferSize)
+		}
+		filter.Limit = limit
+	}
+	return filter, nil
+}
@@ -1,10 +1,16 @@
 package api
 
 import (
+	"bytes"
+	"context"
+	"crypto/subtle"
 	"net/http"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/go-chi/chi/v5/middleware"
+	"github.com/yegors/co-atc/internal/units"
 	"github.com/yegors/co-atc/pkg/logger"
 )
 
@@ -64,7 +70,7 @@ func (m *Middleware) CORS(allowedOrigins []string) func(http.Handler) http.Handl
 			// Set CORS headers
 			if allowed {
 				w.Header().Set("Access-Control-Allow-Origin", origin)
-				w.Header().Set("Access-Control-Allow-Methods", "GET, POST, DELETE, OPTIONS")
+				w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, PATCH, DELETE, OPTIONS")
 				w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
 				w.Header().Set("Access-Control-Allow-Credentials", "true")
 			}
@@ -80,6 +86,148 @@ func (m *Middleware) CORS(allowedOrigins []string) func(http.Handler) http.Handl
 	}
 }
 
+// RequireAdminToken is a middleware that only allows requests carrying the admin token
+// as a bearer token. If no token is configured, admin endpoints are disabled.
+func (m *Middleware) RequireAdminToken(token string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if token == "" {
+				http.Error(w, "Admin endpoints are disabled (server.admin_token is not set)", http.StatusForbidden)
+				return
+			}
+
+			provided := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+			if subtle.ConstantTimeCompare([]byte(provided), []byte(token)) != 1 {
+				m.logger.Warn("Rejected unauthorized admin request",
+					logger.String("method", r.Method),
+					logger.String("path", r.URL.Path),
+					logger.String("remote_addr", r.RemoteAddr))
+				http.Error(w, "Unauthorized", http.StatusUnauthorized)
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// callerKey is the context key of whether a request carries the admin token
+type callerKey struct{}
+
+// Caller is a middleware that marks requests carrying the admin token as a bearer token as
+// admin requests, without rejecting the others. Admin requests get the aircraft hidden by
+// [privacy] hide_military and hide_blocked.
+func (m *Middleware) Caller(token string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			provided := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+			admin := token != "" && subtle.ConstantTimeCompare([]byte(provided), []byte(token)) == 1
+			w.Header().Add("Vary", "Authorization")
+			next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), callerKey{}, admin)))
+		})
+	}
+}
+
+// isAdmin reports whether a request was marked as carrying the admin token by Caller
+func isAdmin(r *http.Request) bool {
+	admin, _ := r.Context().Value(callerKey{}).(bool)
+	return admin
+}
+
+// callerClass returns the class of a request's caller, "admin" or "public", for responses
+// that differ between them
+func callerClass(r *http.Request) string {
+	if isAdmin(r) {
+		return "admin"
+	}
+	return "public"
+}
+
+// Units is a middleware that converts JSON responses to the unit system a request selects
+// with the units query parameter or the X-Units header. Other responses, and WebSocket
+// upgrades (the WebSocket server converts its own messages), pass through.
+func (m *Middleware) Units(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		system, err := units.FromRequest(r)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		w.Header().Add("Vary", units.Header)
+		if system == units.Aviation || strings.EqualFold(r.Header.Get("Upgrade"), "websocket") {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		uw := &unitsWriter{ResponseWriter: w, status: http.StatusOK}
+		next.ServeHTTP(uw, r)
+		if !uw.buffering {
+			return
+		}
+
+		body, err := system.ConvertJSON(uw.body.Bytes())
+		if err != nil {
+			// Not a single JSON document; send it as the handler wrote it
+			m.logger.Warn("Failed to convert response units",
+				logger.String("path", r.URL.Path),
+				logger.Error(err))
+			body = uw.body.Bytes()
+		} else {
+			w.Header().Set(units.Header, string(system))
+		}
+		w.Header().Set("Content-Length", strconv.Itoa(len(body)))
+		w.WriteHeader(uw.status)
+		_, _ = w.Write(body)
+	})
+}
+
+// unitsWriter holds back JSON responses so their units can be converted, and passes
+// everything else, including streams, straight through
+type unitsWriter struct {
+	http.ResponseWriter
+	status      int
+	wroteHeader bool
+	buffering   bool
+	body        bytes.Buffer
+}
+
+// WriteHeader implements http.ResponseWriter
+func (w *unitsWriter) WriteHeader(status int) {
+	if w.wroteHeader {
+		return
+	}
+	w.wroteHeader = true
+	w.status = status
+
+	contentType := w.Header().Get("Content-Type")
+	if strings.HasPrefix(contentType, "application/json") && status != http.StatusNoContent {
+		w.buffering = true
+		return
+	}
+	w.ResponseWriter.WriteHeader(status)
+}
+
+// Write implements http.ResponseWriter
+func (w *unitsWriter) Write(b []byte) (int, error) {
+	if !w.wroteHeader {
+		w.WriteHeader(http.StatusOK)
+	}
+	if w.buffering {
+		return w.body.Write(b)
+	}
+	return w.ResponseWriter.Write(b)
+}
+
+// Flush implements http.Flusher for responses that aren't held back
+func (w *unitsWriter) Flush() {
+	if w.buffering {
+		return
+	}
+	if flusher, ok := w.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
 // RequestID is a middleware that adds a request ID to the context
 func (m *Middleware) RequestID(next http.Handler) http.Handler {
 	return middleware.RequestID(next)
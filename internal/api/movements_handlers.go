@@ -0,0 +1,71 @@
+package api
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/yegors/co-atc/pkg/logger"
+)
+
+// GetMovements returns the departures and arrivals of the airport during a time range, most
+// recent first
+func (h *Handler) GetMovements(w http.ResponseWriter, r *http.Request) {
+	if h.movementsService == nil {
+		http.Error(w, "Movement log not enabled", http.StatusServiceUnavailable)
+		return
+	}
+
+	query, err := parseStatsQuery(r, 24*time.Hour)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	limit, err := parseStatsLimit(r, 100)
+	if err != nil || limit <= 0 {
+		http.Error(w, "limit must be a positive number", http.StatusBadRequest)
+		return
+	}
+
+	log, err := h.movementsService.Movements(query.Start, query.End, limit)
+	if err != nil {
+		h.logger.Error("Failed to get movements", logger.Error(err))
+		http.Error(w, "Failed to get movements", http.StatusInternalServerError)
+		return
+	}
+
+	WriteJSON(w, http.StatusOK, log)
+}
+
+// GetFlightSessions returns the flight sessions seen during a time range, most recently
+// started first, optionally of one aircraft
+func (h *Handler) GetFlightSessions(w http.ResponseWriter, r *http.Request) {
+	if h.movementsService == nil {
+		http.Error(w, "Movement log not enabled", http.StatusServiceUnavailable)
+		return
+	}
+
+	query, err := parseStatsQuery(r, 24*time.Hour)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	limit, err := parseStatsLimit(r, 100)
+	if err != nil || limit <= 0 {
+		http.Error(w, "limit must be a positive number", http.StatusBadRequest)
+		return
+	}
+
+	sessions, err := h.movementsService.Sessions(query.Start, query.End, r.URL.Query().Get("hex"), limit)
+	if err != nil {
+		h.logger.Error("Failed to get flight sessions", logger.Error(err))
+		http.Error(w, "Failed to get flight sessions", http.StatusInternalServerError)
+		return
+	}
+
+	WriteJSON(w, http.StatusOK, map[string]interface{}{
+		"start":    query.Start,
+		"end":      query.End,
+		"count":    len(sessions),
+		"sessions": sessions,
+	})
+}
@@ -0,0 +1,68 @@
+package api
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/yegors/co-atc/pkg/logger"
+)
+
+// GetNoiseViolations returns the noise violations started in a time range, most recent first
+func (h *Handler) GetNoiseViolations(w http.ResponseWriter, r *http.Request) {
+	if h.noiseService == nil {
+		http.Error(w, "Noise monitoring not enabled", http.StatusServiceUnavailable)
+		return
+	}
+
+	query, err := parseStatsQuery(r, 24*time.Hour)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	limit, err := parseStatsLimit(r, 100)
+	if err != nil || limit <= 0 {
+		http.Error(w, "limit must be a positive number", http.StatusBadRequest)
+		return
+	}
+
+	violations, err := h.noiseService.Violations(query.Start, query.End, limit)
+	if err != nil {
+		h.logger.Error("Failed to get noise violations", logger.Error(err))
+		http.Error(w, "Failed to get noise violations", http.StatusInternalServerError)
+		return
+	}
+
+	WriteJSON(w, http.StatusOK, map[string]interface{}{
+		"start":      query.Start,
+		"end":        query.End,
+		"count":      len(violations),
+		"violations": violations,
+	})
+}
+
+// GetNoiseReport summarizes the noise violations started in a time range per operator
+func (h *Handler) GetNoiseReport(w http.ResponseWriter, r *http.Request) {
+	if h.noiseService == nil {
+		http.Error(w, "Noise monitoring not enabled", http.StatusServiceUnavailable)
+		return
+	}
+
+	query, err := parseStatsQuery(r, 30*24*time.Hour)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if !query.Start.Before(query.End) {
+		http.Error(w, "start_time must be before end_time", http.StatusBadRequest)
+		return
+	}
+
+	report, err := h.noiseService.Report(query.Start, query.End)
+	if err != nil {
+		h.logger.Error("Failed to build noise report", logger.Error(err))
+		http.Error(w, "Failed to build noise report", http.StatusInternalServerError)
+		return
+	}
+
+	WriteJSON(w, http.StatusOK, report)
+}
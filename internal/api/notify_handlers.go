@@ -0,0 +1,43 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/yegors/co-atc/internal/notify"
+)
+
+// GetNotifyChannels returns the notification channels with their delivery counters
+func (h *Handler) GetNotifyChannels(w http.ResponseWriter, r *http.Request) {
+	if h.notifyService == nil {
+		http.Error(w, "Notifications not enabled", http.StatusServiceUnavailable)
+		return
+	}
+
+	WriteJSON(w, http.StatusOK, map[string]interface{}{
+		"event_types": notify.EventTypes,
+		"channels":    h.notifyService.Channels(),
+	})
+}
+
+// SendNotifyTest delivers a test event to a notification channel
+func (h *Handler) SendNotifyTest(w http.ResponseWriter, r *http.Request) {
+	if h.notifyService == nil {
+		http.Error(w, "Notifications not enabled", http.StatusServiceUnavailable)
+		return
+	}
+
+	if err := h.notifyService.SendTest(chi.URLParam(r, "name")); err != nil {
+		if errors.Is(err, notify.ErrChannelNotFound) {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		http.Error(w, "Delivery failed: "+err.Error(), http.StatusBadGateway)
+		return
+	}
+
+	WriteJSON(w, http.StatusOK, map[string]interface{}{
+		"success": true,
+	})
+}
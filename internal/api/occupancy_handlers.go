@@ -0,0 +1,36 @@
+package api
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/yegors/co-atc/pkg/logger"
+)
+
+// GetRunwayOccupancyStats summarizes the runway occupancy times of the landings and takeoffs
+// started in a time range, in total and per runway, optionally of one runway direction
+func (h *Handler) GetRunwayOccupancyStats(w http.ResponseWriter, r *http.Request) {
+	if h.occupancyService == nil {
+		http.Error(w, "Runway occupancy measurement not enabled", http.StatusServiceUnavailable)
+		return
+	}
+
+	query, err := parseStatsQuery(r, 7*24*time.Hour)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if !query.Start.Before(query.End) {
+		http.Error(w, "start_time must be before end_time", http.StatusBadRequest)
+		return
+	}
+
+	report, err := h.occupancyService.Report(query.Start, query.End, r.URL.Query().Get("runway"))
+	if err != nil {
+		h.logger.Error("Failed to get runway occupancy statistics", logger.Error(err))
+		http.Error(w, "Failed to get runway occupancy statistics", http.StatusInternalServerError)
+		return
+	}
+
+	WriteJSON(w, http.StatusOK, report)
+}
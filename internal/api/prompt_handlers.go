@@ -0,0 +1,122 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/yegors/co-atc/internal/templating"
+	"github.com/yegors/co-atc/pkg/logger"
+)
+
+// promptErrorStatus maps prompt management errors to HTTP status codes
+func promptErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, templating.ErrPromptsUnavailable):
+		return http.StatusServiceUnavailable
+	case errors.Is(err, templating.ErrUnknownPrompt), errors.Is(err, templating.ErrUnknownVersion):
+		return http.StatusNotFound
+	case errors.Is(err, templating.ErrInvalidPrompt):
+		return http.StatusBadRequest
+	}
+	return http.StatusInternalServerError
+}
+
+// writePromptError writes the error of a prompt request, logging unexpected ones
+func (h *Handler) writePromptError(w http.ResponseWriter, err error) {
+	code := promptErrorStatus(err)
+	if code == http.StatusInternalServerError {
+		h.logger.Error("Prompt request failed", logger.Error(err))
+	}
+	http.Error(w, err.Error(), code)
+}
+
+// GetPrompts lists the prompt templates in use
+func (h *Handler) GetPrompts(w http.ResponseWriter, r *http.Request) {
+	prompts, err := h.templateService.PromptTemplates()
+	if err != nil {
+		h.writePromptError(w, err)
+		return
+	}
+	WriteJSON(w, http.StatusOK, map[string]interface{}{"prompts": prompts})
+}
+
+// GetPrompt returns a prompt template with the content in use
+func (h *Handler) GetPrompt(w http.ResponseWriter, r *http.Request) {
+	prompt, err := h.templateService.PromptTemplate(chi.URLParam(r, "name"))
+	if err != nil {
+		h.writePromptError(w, err)
+		return
+	}
+	WriteJSON(w, http.StatusOK, prompt)
+}
+
+// ValidatePrompt checks prompt template content without storing it
+func (h *Handler) ValidatePrompt(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Content string `json:"content"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+
+	validation, err := h.templateService.ValidatePrompt(chi.URLParam(r, "name"), req.Content)
+	if err != nil {
+		h.writePromptError(w, err)
+		return
+	}
+	WriteJSON(w, http.StatusOK, validation)
+}
+
+// UpdatePrompt stores a new version of a prompt template and uses it
+func (h *Handler) UpdatePrompt(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Content string `json:"content"`
+		Comment string `json:"comment"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+
+	prompt, err := h.templateService.UpdatePrompt(chi.URLParam(r, "name"), req.Content, req.Comment)
+	if err != nil {
+		h.writePromptError(w, err)
+		return
+	}
+	WriteJSON(w, http.StatusOK, prompt)
+}
+
+// GetPromptVersions lists the stored versions of a prompt template
+func (h *Handler) GetPromptVersions(w http.ResponseWriter, r *http.Request) {
+	versions, err := h.templateService.PromptVersions(chi.URLParam(r, "name"))
+	if err != nil {
+		h.writePromptError(w, err)
+		return
+	}
+	WriteJSON(w, http.StatusOK, map[string]interface{}{"versions": versions})
+}
+
+// RollbackPrompt uses a stored version of a prompt template, or its file with version 0
+func (h *Handler) RollbackPrompt(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Version *int `json:"version"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+	if req.Version == nil || *req.Version < 0 {
+		http.Error(w, "version is required (0 for the file)", http.StatusBadRequest)
+		return
+	}
+
+	prompt, err := h.templateService.RollbackPrompt(chi.URLParam(r, "name"), *req.Version)
+	if err != nil {
+		h.writePromptError(w, err)
+		return
+	}
+	WriteJSON(w, http.StatusOK, prompt)
+}
@@ -0,0 +1,188 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/yegors/co-atc/internal/push"
+	"github.com/yegors/co-atc/pkg/logger"
+)
+
+// GetPushPublicKey returns the VAPID public key browsers need to subscribe
+func (h *Handler) GetPushPublicKey(w http.ResponseWriter, r *http.Request) {
+	if h.pushService == nil {
+		http.Error(w, "Push notifications not enabled", http.StatusServiceUnavailable)
+		return
+	}
+
+	WriteJSON(w, http.StatusOK, map[string]interface{}{
+		"public_key":  h.pushService.PublicKey(),
+		"alert_types": push.AlertTypes,
+	})
+}
+
+// CreatePushSubscription registers a browser push subscription. The body is the
+// browser's PushSubscription JSON with an optional list of alert types.
+func (h *Handler) CreatePushSubscription(w http.ResponseWriter, r *http.Request) {
+	if h.pushService == nil {
+		http.Error(w, "Push notifications not enabled", http.StatusServiceUnavailable)
+		return
+	}
+
+	var req struct {
+		Endpoint string `json:"endpoint"`
+		Keys     struct {
+			P256dh string `json:"p256dh"`
+			Auth   string `json:"auth"`
+		} `json:"keys"`
+		AlertTypes []string `json:"alert_types"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	subscription, err := h.pushService.Subscribe(req.Endpoint, req.Keys.P256dh, req.Keys.Auth, req.AlertTypes, r.UserAgent())
+	if err != nil {
+		http.Error(w, err.Error(), pushErrorStatus(err))
+		return
+	}
+
+	WriteJSON(w, http.StatusCreated, subscription)
+}
+
+// GetPushSubscriptions returns all push subscriptions
+func (h *Handler) GetPushSubscriptions(w http.ResponseWriter, r *http.Request) {
+	if h.pushService == nil {
+		http.Error(w, "Push notifications not enabled", http.StatusServiceUnavailable)
+		return
+	}
+
+	subscriptions, err := h.pushService.GetSubscriptions()
+	if err != nil {
+		h.logger.Error("Failed to retrieve push subscriptions", logger.Error(err))
+		http.Error(w, "Failed to retrieve push subscriptions", http.StatusInternalServerError)
+		return
+	}
+
+	WriteJSON(w, http.StatusOK, map[string]interface{}{
+		"count":         len(subscriptions),
+		"subscriptions": subscriptions,
+	})
+}
+
+// GetPushSubscription returns a single push subscription
+func (h *Handler) GetPushSubscription(w http.ResponseWriter, r *http.Request) {
+	if h.pushService == nil {
+		http.Error(w, "Push notifications not enabled", http.StatusServiceUnavailable)
+		return
+	}
+
+	subscription, err := h.pushService.GetSubscription(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, err.Error(), pushErrorStatus(err))
+		return
+	}
+
+	WriteJSON(w, http.StatusOK, subscription)
+}
+
+// UpdatePushSubscription changes the alert types of a push subscription
+func (h *Handler) UpdatePushSubscription(w http.ResponseWriter, r *http.Request) {
+	if h.pushService == nil {
+		http.Error(w, "Push notifications not enabled", http.StatusServiceUnavailable)
+		return
+	}
+
+	var req struct {
+		AlertTypes []string `json:"alert_types"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	subscription, err := h.pushService.UpdateSubscription(chi.URLParam(r, "id"), req.AlertTypes)
+	if err != nil {
+		http.Error(w, err.Error(), pushErrorStatus(err))
+		return
+	}
+
+	WriteJSON(w, http.StatusOK, subscription)
+}
+
+// DeletePushSubscription removes a push subscription
+func (h *Handler) DeletePushSubscription(w http.ResponseWriter, r *http.Request) {
+	if h.pushService == nil {
+		http.Error(w, "Push notifications not enabled", http.StatusServiceUnavailable)
+		return
+	}
+
+	if err := h.pushService.Unsubscribe(chi.URLParam(r, "id")); err != nil {
+		http.Error(w, err.Error(), pushErrorStatus(err))
+		return
+	}
+
+	WriteJSON(w, http.StatusOK, map[string]interface{}{
+		"status": "success",
+	})
+}
+
+// GetPushDeliveries returns the most recent delivery attempts of a push subscription
+func (h *Handler) GetPushDeliveries(w http.ResponseWriter, r *http.Request) {
+	if h.pushService == nil {
+		http.Error(w, "Push notifications not enabled", http.StatusServiceUnavailable)
+		return
+	}
+
+	limit := 50
+	if limitStr := r.URL.Query().Get("limit"); limitStr != "" {
+		if l, err := strconv.Atoi(limitStr); err == nil && l > 0 && l <= 500 {
+			limit = l
+		}
+	}
+
+	id := chi.URLParam(r, "id")
+	deliveries, err := h.pushService.GetDeliveries(id, limit)
+	if err != nil {
+		http.Error(w, err.Error(), pushErrorStatus(err))
+		return
+	}
+
+	WriteJSON(w, http.StatusOK, map[string]interface{}{
+		"subscription_id": id,
+		"count":           len(deliveries),
+		"deliveries":      deliveries,
+	})
+}
+
+// SendPushTest sends a test notification to a push subscription
+func (h *Handler) SendPushTest(w http.ResponseWriter, r *http.Request) {
+	if h.pushService == nil {
+		http.Error(w, "Push notifications not enabled", http.StatusServiceUnavailable)
+		return
+	}
+
+	delivery, err := h.pushService.SendTest(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, err.Error(), pushErrorStatus(err))
+		return
+	}
+
+	WriteJSON(w, http.StatusOK, delivery)
+}
+
+// pushErrorStatus maps push service errors to HTTP status codes
+func pushErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, push.ErrSubscriptionNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, push.ErrInvalidSubscription):
+		return http.StatusBadRequest
+	default:
+		return http.StatusInternalServerError
+	}
+}
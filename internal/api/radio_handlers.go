@@ -0,0 +1,65 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/yegors/co-atc/internal/simulation"
+	"github.com/yegors/co-atc/pkg/logger"
+)
+
+// radioErrorStatus maps radio simulation errors to HTTP status codes
+func radioErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, simulation.ErrRadioUnavailable):
+		return http.StatusServiceUnavailable
+	case errors.Is(err, simulation.ErrInvalidRadioCall):
+		return http.StatusBadRequest
+	}
+	return http.StatusInternalServerError
+}
+
+// GetRadioCalls returns the simulated radio calls waiting to be transmitted
+func (h *Handler) GetRadioCalls(w http.ResponseWriter, r *http.Request) {
+	calls, err := h.simulationService.PendingRadioCalls()
+	if err != nil {
+		http.Error(w, err.Error(), radioErrorStatus(err))
+		return
+	}
+	WriteJSON(w, http.StatusOK, map[string]interface{}{"calls": calls})
+}
+
+// TransmitRadioCalls schedules a script of simulated radio calls. Calls without a frequency
+// use the frequency of the script.
+func (h *Handler) TransmitRadioCalls(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		FrequencyID string                 `json:"frequency_id"`
+		Calls       []simulation.RadioCall `json:"calls"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+	for i := range req.Calls {
+		if req.Calls[i].FrequencyID == "" {
+			req.Calls[i].FrequencyID = req.FrequencyID
+		}
+	}
+
+	calls, err := h.simulationService.TransmitRadio(req.Calls)
+	if err != nil {
+		code := radioErrorStatus(err)
+		if code == http.StatusInternalServerError {
+			h.logger.Error("Failed to schedule radio calls", logger.Error(err))
+		}
+		http.Error(w, err.Error(), code)
+		return
+	}
+	WriteJSON(w, http.StatusAccepted, map[string]interface{}{"calls": calls})
+}
+
+// CancelRadioCalls drops the simulated radio calls waiting to be transmitted
+func (h *Handler) CancelRadioCalls(w http.ResponseWriter, r *http.Request) {
+	WriteJSON(w, http.StatusOK, map[string]interface{}{"cancelled": h.simulationService.CancelRadioCalls()})
+}
@@ -0,0 +1,122 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/yegors/co-atc/internal/simulation"
+	"github.com/yegors/co-atc/pkg/logger"
+)
+
+// replayErrorStatus maps replay errors to HTTP status codes
+func replayErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, simulation.ErrReplayUnavailable):
+		return http.StatusServiceUnavailable
+	case errors.Is(err, simulation.ErrNoReplay):
+		return http.StatusNotFound
+	case errors.Is(err, simulation.ErrInvalidReplay):
+		return http.StatusBadRequest
+	case errors.Is(err, simulation.ErrReplayState):
+		return http.StatusConflict
+	}
+	return http.StatusInternalServerError
+}
+
+// writeReplayResult writes the replay status after a change, or the error that prevented it
+func (h *Handler) writeReplayResult(w http.ResponseWriter, status *simulation.ReplayStatus, err error) {
+	if err != nil {
+		code := replayErrorStatus(err)
+		if code == http.StatusInternalServerError {
+			h.logger.Error("Failed to update replay", logger.Error(err))
+		}
+		http.Error(w, err.Error(), code)
+		return
+	}
+	WriteJSON(w, http.StatusOK, status)
+}
+
+// GetReplay returns the state of the replay
+func (h *Handler) GetReplay(w http.ResponseWriter, r *http.Request) {
+	WriteJSON(w, http.StatusOK, h.simulationService.ReplayStatus())
+}
+
+// StartReplay loads a past time window and starts replaying it
+func (h *Handler) StartReplay(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Start time.Time `json:"start"`
+		End   time.Time `json:"end"`
+		Speed float64   `json:"speed"` // Default 1
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+	if req.Start.IsZero() || req.End.IsZero() {
+		http.Error(w, "start and end are required", http.StatusBadRequest)
+		return
+	}
+	if req.Speed == 0 {
+		req.Speed = 1
+	}
+
+	status, err := h.simulationService.StartReplay(req.Start, req.End, req.Speed)
+	if err == nil {
+		h.logger.Info("Started replay via API",
+			logger.Time("start", req.Start),
+			logger.Time("end", req.End),
+			logger.Float64("speed", req.Speed))
+		h.cache.Invalidate(cacheTagAircraft)
+	}
+	h.writeReplayResult(w, status, err)
+}
+
+// UpdateReplay changes the speed of the replay and/or moves its clock
+func (h *Handler) UpdateReplay(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Speed    *float64   `json:"speed"`
+		Position *time.Time `json:"position"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+	if req.Speed == nil && req.Position == nil {
+		http.Error(w, "Set speed and/or position", http.StatusBadRequest)
+		return
+	}
+
+	var status *simulation.ReplayStatus
+	var err error
+	if req.Speed != nil {
+		status, err = h.simulationService.SetReplaySpeed(*req.Speed)
+	}
+	if err == nil && req.Position != nil {
+		status, err = h.simulationService.SeekReplay(*req.Position)
+	}
+	h.writeReplayResult(w, status, err)
+}
+
+// PauseReplay stops the replay clock
+func (h *Handler) PauseReplay(w http.ResponseWriter, r *http.Request) {
+	status, err := h.simulationService.PauseReplay()
+	h.writeReplayResult(w, status, err)
+}
+
+// ResumeReplay restarts the replay clock
+func (h *Handler) ResumeReplay(w http.ResponseWriter, r *http.Request) {
+	status, err := h.simulationService.ResumeReplay()
+	h.writeReplayResult(w, status, err)
+}
+
+// StopReplay unloads the replay
+func (h *Handler) StopReplay(w http.ResponseWriter, r *http.Request) {
+	if !h.simulationService.StopReplay() {
+		http.Error(w, simulation.ErrNoReplay.Error(), http.StatusNotFound)
+		return
+	}
+	h.cache.Invalidate(cacheTagAircraft)
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -2,14 +2,33 @@ package api
 
 import (
 	"net/http"
+	"time"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/yegors/co-atc/internal/adsb"
+	"github.com/yegors/co-atc/internal/approach"
 	"github.com/yegors/co-atc/internal/atcchat"
+	"github.com/yegors/co-atc/internal/atis"
+	"github.com/yegors/co-atc/internal/board"
+	"github.com/yegors/co-atc/internal/briefing"
 	"github.com/yegors/co-atc/internal/config"
+	"github.com/yegors/co-atc/internal/deviation"
+	"github.com/yegors/co-atc/internal/events"
+	"github.com/yegors/co-atc/internal/feeder"
 	"github.com/yegors/co-atc/internal/frequencies"
+	"github.com/yegors/co-atc/internal/movements"
+	"github.com/yegors/co-atc/internal/noise"
+	"github.com/yegors/co-atc/internal/notify"
+	"github.com/yegors/co-atc/internal/occupancy"
+	"github.com/yegors/co-atc/internal/push"
+	"github.com/yegors/co-atc/internal/records"
+	"github.com/yegors/co-atc/internal/sequence"
 	"github.com/yegors/co-atc/internal/simulation"
+	"github.com/yegors/co-atc/internal/stats"
 	"github.com/yegors/co-atc/internal/storage/sqlite"
+	"github.com/yegors/co-atc/internal/templating"
+	"github.com/yegors/co-atc/internal/terrain"
+	"github.com/yegors/co-atc/internal/usage"
 	"github.com/yegors/co-atc/internal/weather"
 	"github.com/yegors/co-atc/internal/websocket"
 	"github.com/yegors/co-atc/pkg/logger"
@@ -24,9 +43,9 @@ type Router struct {
 }
 
 // NewRouter creates a new API router
-func NewRouter(adsbService *adsb.Service, frequenciesService *frequencies.Service, weatherService *weather.Service, atcChatService *atcchat.Service, simulationService *simulation.Service, config *config.Config, logger *logger.Logger, wsServer *websocket.Server, transcriptionStorage *sqlite.TranscriptionStorage, clearanceStorage *sqlite.ClearanceStorage) *Router {
+func NewRouter(adsbService *adsb.Service, frequenciesService *frequencies.Service, weatherService *weather.Service, atcChatService *atcchat.Service, simulationService *simulation.Service, pushService *push.Service, notifyService *notify.Service, recordsService *records.Service, statsService *stats.Service, deviationService *deviation.Service, briefingService *briefing.Service, atisService *atis.Service, templateService *templating.Service, config *config.Config, configReloader *config.Reloader, logger *logger.Logger, wsServer *websocket.Server, transcriptionStorage *sqlite.TranscriptionStorage, clearanceStorage *sqlite.ClearanceStorage, backupStorage *sqlite.BackupStorage, usageTracker *usage.Tracker, eventsService *events.Service, terrainService *terrain.Service, approachService *approach.Service, noiseService *noise.Service, feederService *feeder.Service, movementsService *movements.Service, boardService *board.Service, sequenceService *sequence.Service, occupancyService *occupancy.Service) *Router {
 	return &Router{
-		handler:    NewHandler(adsbService, frequenciesService, weatherService, atcChatService, simulationService, config, logger, wsServer, transcriptionStorage, clearanceStorage),
+		handler:    NewHandler(adsbService, frequenciesService, weatherService, atcChatService, simulationService, pushService, notifyService, recordsService, statsService, deviationService, briefingService, atisService, templateService, config, configReloader, logger, wsServer, transcriptionStorage, clearanceStorage, backupStorage, usageTracker, eventsService, terrainService, approachService, noiseService, feederService, movementsService, boardService, sequenceService, occupancyService),
 		middleware: NewMiddleware(logger),
 		config:     config,
 		logger:     logger.Named("api-router"),
@@ -42,64 +61,267 @@ func (r *Router) Routes() http.Handler {
 	router.Use(r.middleware.Logger)
 	router.Use(r.middleware.Recoverer)
 	router.Use(r.middleware.CORS(r.config.Server.CORSAllowedOrigins))
+	router.Use(r.middleware.Units)
+	router.Use(r.middleware.Caller(r.config.Server.AdminToken))
+
+	// Short-lived caches for read endpoints many dashboards poll at once. Entries are also
+	// dropped as soon as the data behind them changes, so the TTLs only bound staleness
+	// when no change notification arrives (e.g. runway overrides expiring).
+	cache := r.handler.cache
+	cacheAircraft := cache.Cached(cacheTagAircraft, 5*time.Second)
+	cacheStation := cache.Cached(cacheTagStation, 30*time.Second)
 
 	// API routes
 	router.Route("/api/v1", func(router chi.Router) {
 		// Aircraft routes
-		router.Get("/aircraft", r.handler.GetAllAircraft)
+		router.With(cacheAircraft).Get("/aircraft", r.handler.GetAllAircraft)
 		router.Get("/aircraft/{id}", r.handler.GetAircraftByHex)
 		router.Get("/aircraft/{id}/tracks", r.handler.GetAircraftTracks)
+		router.Get("/aircraft/{id}/profile", r.handler.GetAircraftProfile)
+		router.Get("/aircraft/{id}/communications", r.handler.GetAircraftCommunications)
+		router.With(cacheAircraft).Get("/callsigns", r.handler.GetCallsigns)
 
 		// Frequency routes
-		router.Get("/frequencies", r.handler.GetAllFrequencies)
+		router.With(cache.Cached(cacheTagFrequencies, 5*time.Second)).Get("/frequencies", r.handler.GetAllFrequencies)
 		router.Get("/frequencies/{id}", r.handler.GetFrequencyByID)
+		router.Post("/frequencies", r.handler.CreateFrequency)
+		router.Put("/frequencies/{id}", r.handler.UpdateFrequency)
+		router.Delete("/frequencies/{id}", r.handler.DeleteFrequency)
+		router.Get("/frequencies/{id}/recordings", r.handler.GetFrequencyRecordings)
+
+		// Audio stream listeners
+		router.With(r.middleware.RequireAdminToken(r.config.Server.AdminToken)).Get("/listeners", r.handler.GetListeners)
+		router.With(r.middleware.RequireAdminToken(r.config.Server.AdminToken)).Get("/frequencies/{id}/listeners", r.handler.GetFrequencyListeners)
+		router.With(r.middleware.RequireAdminToken(r.config.Server.AdminToken)).Delete("/frequencies/{id}/listeners/{clientId}", r.handler.DisconnectListener)
+
+		// Recording routes
+		router.Get("/recordings/{id}", r.handler.GetRecording)
 
 		// Audio stream route
 		router.Get("/stream/{id}", r.handler.StreamAudio)
 		router.Head("/stream/{id}", r.handler.StreamAudio) // Add support for HEAD requests
+		router.Get("/stream/{id}/{file}", r.handler.GetStreamHLSFile)
+		router.Get("/scanner/stream", r.handler.StreamScanner)
+		router.Head("/scanner/stream", r.handler.StreamScanner)
+		router.Get("/stereo/pairs", r.handler.GetStereoPairs)
+		router.Get("/stereo/stream", r.handler.StreamStereo)
+		router.Head("/stereo/stream", r.handler.StreamStereo)
+		router.Get("/stereo/{pair}/stream", r.handler.StreamStereo)
+		router.Head("/stereo/{pair}/stream", r.handler.StreamStereo)
 
 		// WebSocket route
 		router.Get("/ws", r.handler.HandleWebSocket)
+		router.With(r.middleware.RequireAdminToken(r.config.Server.AdminToken)).Get("/admin/ws", r.handler.HandleAdminWebSocket)
 
 		// Transcription routes
 		router.Get("/transcriptions", r.handler.GetAllTranscriptions)
 		router.Get("/transcriptions/frequency/{id}", r.handler.GetTranscriptionsByFrequency)
 		router.Get("/transcriptions/time-range", r.handler.GetTranscriptionsByTimeRange)
+		router.Get("/transcriptions/search", r.handler.SearchTranscriptions)
+		router.Get("/transcriptions/export", r.handler.ExportTranscriptions)
 		router.Get("/transcriptions/speaker/{type}", r.handler.GetTranscriptionsBySpeaker)
 		router.Get("/transcriptions/callsign/{callsign}", r.handler.GetTranscriptionsByCallsign)
+		router.Get("/transcriptions/correlation/{id}", r.handler.GetTranscriptionsByCorrelationID)
+		router.Get("/transcriptions/{id}/recording", r.handler.GetTranscriptionRecording)
+		router.Get("/transcriptions/{id}/audio", r.handler.GetTranscriptionAudio)
+
+		// Clearance routes
+		router.Get("/clearances/export", r.handler.ExportClearances)
+		router.Get("/clearances/deviations", r.handler.GetDeviations)
+		router.Get("/terrain/alerts", r.handler.GetTerrainAlerts)
+		router.Get("/approaches/unstable", r.handler.GetUnstableApproaches)
+		router.Get("/approaches/sequence", r.handler.GetArrivalSequence)
+
+		// Noise-abatement violations
+		router.Get("/noise/violations", r.handler.GetNoiseViolations)
+		router.Get("/noise/report", r.handler.GetNoiseReport)
+
+		// Flight sessions and the airport's movement log
+		router.Get("/movements", r.handler.GetMovements)
+		router.Get("/movements/sessions", r.handler.GetFlightSessions)
+
+		// Arrivals and departures boards
+		router.Get("/board/arrivals", r.handler.GetArrivalsBoard)
+		router.Get("/board/departures", r.handler.GetDeparturesBoard)
+
+		// Feeding to community aggregators
+		router.Get("/feeder/status", r.handler.GetFeederStatus)
+
+		// Event timeline of every alert
+		router.Get("/events", r.handler.GetEvents)
+
+		// Spoken airspace briefings
+		router.Get("/briefing", r.handler.GetBriefing)
+		router.Get("/briefing/audio", r.handler.GetBriefingAudio)
+
+		// Digital or synthesized ATIS
+		router.Get("/atis", r.handler.GetATIS)
+		router.Get("/atis/history", r.handler.GetATISHistory)
+		router.Get("/atis/audio", r.handler.GetATISAudio)
+
+		// Station records
+		router.With(cacheAircraft).Get("/stats/records", r.handler.GetStationRecords)
+
+		// Traffic statistics
+		router.With(cacheAircraft).Get("/stats/movements", r.handler.GetMovementStats)
+		router.With(cacheAircraft).Get("/stats/busiest-hours", r.handler.GetBusiestHours)
+		router.With(cacheAircraft).Get("/stats/top", r.handler.GetTopTraffic)
+		router.With(cacheAircraft).Get("/stats/runway-occupancy", r.handler.GetRunwayOccupancyStats)
 
 		// Health check
 		router.Get("/health", r.handler.GetHealth)
 
+		// Raw receiver decode health
+		router.Get("/receiver/stats", r.handler.GetReceiverStats)
+
 		// Configuration
-		router.Get("/config", r.handler.GetConfig)
+		router.With(cache.Cached(cacheTagConfig, time.Minute)).Get("/config", r.handler.GetConfig)
+		router.With(r.middleware.RequireAdminToken(r.config.Server.AdminToken)).Patch("/config", r.handler.PatchConfig)
+
+		// Database backup
+		router.With(r.middleware.RequireAdminToken(r.config.Server.AdminToken)).Post("/admin/backup", r.handler.CreateBackup)
+
+		// Log levels
+		router.With(r.middleware.RequireAdminToken(r.config.Server.AdminToken)).Get("/admin/logging", r.handler.GetLogging)
+		router.With(r.middleware.RequireAdminToken(r.config.Server.AdminToken)).Put("/admin/logging", r.handler.UpdateLogging)
+		router.With(r.middleware.RequireAdminToken(r.config.Server.AdminToken)).Get("/admin/logs", r.handler.GetLogs)
 
 		// Station Configuration
-		router.Get("/station", r.handler.GetStationConfig)    // New route for station config
-		router.Post("/station", r.handler.SetStationOverride) // New route for station override
+		router.With(cacheStation).Get("/station", r.handler.GetStationConfig) // New route for station config
+		router.Post("/station", r.handler.SetStationOverride)                 // New route for station override
+
+		// Runway overrides
+		router.With(cacheStation).Get("/runways/status", r.handler.GetRunwayStatus)
+		router.With(cacheStation).Get("/runways/winds", r.handler.GetRunwayWinds)
+		router.Post("/runways/{runway}/close", r.handler.CloseRunway)
+		router.Post("/runways/{runway}/open", r.handler.OpenRunway)
+		router.Put("/runways/configuration", r.handler.SetRunwayConfiguration)
+		router.Delete("/runways/configuration", r.handler.ClearRunwayConfiguration)
+
+		// Prompt templates
+		router.Get("/prompts", r.handler.GetPrompts)
+		router.Get("/prompts/{name}", r.handler.GetPrompt)
+		router.Put("/prompts/{name}", r.handler.UpdatePrompt)
+		router.Post("/prompts/{name}/validate", r.handler.ValidatePrompt)
+		router.Get("/prompts/{name}/versions", r.handler.GetPromptVersions)
+		router.Post("/prompts/{name}/rollback", r.handler.RollbackPrompt)
+
+		// Aircraft watchlists
+		router.Get("/watchlists", r.handler.GetWatchlists)
+		router.Post("/watchlists", r.handler.CreateWatchlist)
+		router.Get("/watchlists/{id}", r.handler.GetWatchlist)
+		router.Put("/watchlists/{id}", r.handler.UpdateWatchlist)
+		router.Delete("/watchlists/{id}", r.handler.DeleteWatchlist)
 
 		// Weather Data
-		router.Get("/wx", r.handler.GetWeatherData) // New route for weather data
+		router.With(cache.Cached(cacheTagWeather, time.Minute)).Get("/wx", r.handler.GetWeatherData) // New route for weather data
+		router.With(cache.Cached(cacheTagWeather, time.Minute)).Get("/wx/winds", r.handler.GetWindsAloft)
+		router.With(cache.Cached(cacheTagWeather, time.Minute)).Get("/wx/hazards", r.handler.GetWeatherHazards)
+		router.With(cache.Cached(cacheTagWeather, time.Minute)).Get("/wx/history", r.handler.GetWeatherHistory)
+		router.With(cache.Cached(cacheTagWeather, time.Minute)).Get("/wx/trends", r.handler.GetWeatherTrends)
 
 		// ATC Chat routes
 		router.Post("/atc-chat/session", r.handler.CreateATCChatSession)
 		router.Delete("/atc-chat/session/{sessionId}", r.handler.EndATCChatSession)
 		router.Get("/atc-chat/session/{sessionId}/status", r.handler.GetATCChatSessionStatus)
+		router.Get("/atc-chat/session/{sessionId}/history", r.handler.GetATCChatSessionHistory)
 		router.Post("/atc-chat/session/{sessionId}/update-context", r.handler.UpdateATCChatSessionContext)
 		router.Get("/atc-chat/sessions", r.handler.GetATCChatSessions)
-		router.Get("/atc-chat/airspace-status", r.handler.GetATCChatAirspaceStatus)
+		router.Get("/atc-chat/personas", r.handler.GetATCChatPersonas)
+		router.With(cacheAircraft).Get("/atc-chat/airspace-status", r.handler.GetATCChatAirspaceStatus)
+		router.Get("/atc-chat/memory/{clientId}", r.handler.GetATCChatMemory)
+		router.Delete("/atc-chat/memory/{clientId}", r.handler.DeleteATCChatMemory)
 		router.Get("/atc-chat/ws/{sessionId}", r.handler.HandleATCChatWebSocket)
 
 		// Simulation routes
 		router.Post("/simulation/aircraft", r.handler.CreateSimulatedAircraft)
 		router.Put("/simulation/aircraft/{hex}/controls", r.handler.UpdateSimulationControls)
+		router.Put("/simulation/aircraft/{hex}/fuel", r.handler.SetSimulatedFuel)
+		router.Put("/simulation/aircraft/{hex}/autopilot", r.handler.SetSimulatedAutopilot)
+		router.Delete("/simulation/aircraft/{hex}/autopilot", r.handler.DisengageSimulatedAutopilot)
 		router.Delete("/simulation/aircraft/{hex}", r.handler.RemoveSimulatedAircraft)
 		router.Get("/simulation/aircraft", r.handler.GetSimulatedAircraft)
+		router.Get("/simulation/replay", r.handler.GetReplay)
+		router.Post("/simulation/replay", r.handler.StartReplay)
+		router.Put("/simulation/replay", r.handler.UpdateReplay)
+		router.Post("/simulation/replay/pause", r.handler.PauseReplay)
+		router.Post("/simulation/replay/resume", r.handler.ResumeReplay)
+		router.Delete("/simulation/replay", r.handler.StopReplay)
+		router.Get("/simulation/traffic", r.handler.GetTraffic)
+		router.Put("/simulation/traffic", r.handler.UpdateTraffic)
+		router.Get("/simulation/radio", r.handler.GetRadioCalls)
+		router.Post("/simulation/radio", r.handler.TransmitRadioCalls)
+		router.Delete("/simulation/radio", r.handler.CancelRadioCalls)
+
+		// Push notification routes
+		router.Get("/push/vapid-public-key", r.handler.GetPushPublicKey)
+		router.Post("/push/subscriptions", r.handler.CreatePushSubscription)
+		router.With(r.middleware.RequireAdminToken(r.config.Server.AdminToken)).Get("/push/subscriptions", r.handler.GetPushSubscriptions)
+		router.Get("/push/subscriptions/{id}", r.handler.GetPushSubscription)
+		router.Put("/push/subscriptions/{id}", r.handler.UpdatePushSubscription)
+		router.Delete("/push/subscriptions/{id}", r.handler.DeletePushSubscription)
+		router.Get("/push/subscriptions/{id}/deliveries", r.handler.GetPushDeliveries)
+		router.Post("/push/subscriptions/{id}/test", r.handler.SendPushTest)
+
+		// Notification channels
+		router.With(r.middleware.RequireAdminToken(r.config.Server.AdminToken)).Get("/notify/channels", r.handler.GetNotifyChannels)
+		router.With(r.middleware.RequireAdminToken(r.config.Server.AdminToken)).Post("/notify/channels/{name}/test", r.handler.SendNotifyTest)
+
+		// API usage and cost
+		router.Get("/usage", r.handler.GetUsage)
 	})
 
+	// Prometheus metrics
+	router.Get("/metrics", r.handler.GetMetrics)
+
 	// Serve static files from the configured directory
 	staticHandler := NewStaticFileHandler(r.config.Server.StaticFilesDir, r.logger)
 	router.Handle("/*", staticHandler)
 
 	return router
 }
+
+// PublicRoutes returns the routes of the read-only public view: aircraft, weather, station
+// information and delayed transcriptions. Control endpoints, audio and the WebSocket, which
+// carries live transcriptions, are left out.
+func (r *Router) PublicRoutes() http.Handler {
+	router := chi.NewRouter()
+
+	router.Use(r.middleware.RequestID)
+	router.Use(r.middleware.Logger)
+	router.Use(r.middleware.Recoverer)
+	router.Use(r.middleware.CORS(r.config.Server.CORSAllowedOrigins))
+	router.Use(r.middleware.Units)
+	router.Use(r.middleware.Caller(r.config.Server.AdminToken))
+
+	cache := r.handler.cache
+	cacheAircraft := cache.Cached(cacheTagAircraft, 5*time.Second)
+	cacheStation := cache.Cached(cacheTagStation, 30*time.Second)
+
+	router.Route("/api/v1", func(router chi.Router) {
+		// Aircraft routes
+		router.With(cacheAircraft).Get("/aircraft", r.handler.GetAllAircraft)
+		router.Get("/aircraft/{id}", r.handler.GetAircraftByHex)
+		router.Get("/aircraft/{id}/tracks", r.handler.GetAircraftTracks)
+		router.With(cacheAircraft).Get("/callsigns", r.handler.GetCallsigns)
+
+		// Station and weather
+		router.With(cacheStation).Get("/station", r.handler.GetStationConfig)
+		router.With(cacheStation).Get("/runways/status", r.handler.GetRunwayStatus)
+		router.With(cacheStation).Get("/runways/winds", r.handler.GetRunwayWinds)
+		router.With(cache.Cached(cacheTagWeather, time.Minute)).Get("/wx", r.handler.GetWeatherData)
+		router.With(cache.Cached(cacheTagWeather, time.Minute)).Get("/wx/winds", r.handler.GetWindsAloft)
+		router.With(cache.Cached(cacheTagWeather, time.Minute)).Get("/wx/hazards", r.handler.GetWeatherHazards)
+		router.With(cache.Cached(cacheTagWeather, time.Minute)).Get("/wx/history", r.handler.GetWeatherHistory)
+		router.With(cache.Cached(cacheTagWeather, time.Minute)).Get("/wx/trends", r.handler.GetWeatherTrends)
+
+		// Transcriptions, published after a delay
+		router.Get("/transcriptions", r.handler.GetDelayedTranscriptions)
+	})
+
+	if dir := r.config.Server.Public.StaticFilesDir; dir != "" {
+		router.Handle("/*", NewStaticFileHandler(dir, r.logger))
+	}
+
+	return router
+}
@@ -0,0 +1,175 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/yegors/co-atc/internal/adsb"
+	"github.com/yegors/co-atc/internal/weather"
+)
+
+// runwayOverrideUntil works out when an override ends from an RFC3339 time or a duration
+// in minutes. Neither means the override lasts until it is removed.
+func runwayOverrideUntil(until string, durationMinutes int) (*time.Time, error) {
+	if until != "" {
+		t, err := time.Parse(time.RFC3339, until)
+		if err != nil {
+			return nil, errors.New("invalid until (use RFC3339)")
+		}
+		return &t, nil
+	}
+	if durationMinutes < 0 {
+		return nil, errors.New("invalid duration_minutes")
+	}
+	if durationMinutes > 0 {
+		t := time.Now().UTC().Add(time.Duration(durationMinutes) * time.Minute)
+		return &t, nil
+	}
+	return nil, nil
+}
+
+// runwayErrorStatus maps runway override errors to HTTP status codes
+func runwayErrorStatus(err error) int {
+	if errors.Is(err, adsb.ErrUnknownRunway) {
+		return http.StatusBadRequest
+	}
+	return http.StatusInternalServerError
+}
+
+// GetRunwayStatus returns the runway closures, forced configuration and the status of each threshold
+func (h *Handler) GetRunwayStatus(w http.ResponseWriter, r *http.Request) {
+	WriteJSON(w, http.StatusOK, h.adsbService.GetRunwayState())
+}
+
+// GetRunwayWinds returns the head, cross and tailwind components of the METAR wind on each
+// runway threshold, flagging thresholds over the configured crosswind and tailwind limits
+func (h *Handler) GetRunwayWinds(w http.ResponseWriter, r *http.Request) {
+	if h.weatherService == nil || !h.config.Weather.FetchMETAR {
+		http.Error(w, "METAR not enabled", http.StatusServiceUnavailable)
+		return
+	}
+
+	data := h.weatherService.GetWeatherData()
+	if data == nil || data.Parsed == nil || data.Parsed.METAR == nil || data.Parsed.METAR.Wind == nil {
+		http.Error(w, "No METAR wind available", http.StatusNotFound)
+		return
+	}
+	metar := data.Parsed.METAR
+
+	type runwayWind struct {
+		Threshold  string `json:"threshold"`
+		Runway     string `json:"runway"`
+		Closed     bool   `json:"closed"`
+		Arrivals   bool   `json:"arrivals"`
+		Departures bool   `json:"departures"`
+		weather.RunwayWind
+	}
+
+	limits := weather.RunwayWindLimits{
+		CrosswindKt: h.config.Weather.CrosswindLimitKt,
+		TailwindKt:  h.config.Weather.TailwindLimitKt,
+	}
+	headings := h.adsbService.RunwayHeadings()
+	runways := make([]runwayWind, 0, len(headings))
+	for _, status := range h.adsbService.GetRunwayState().Runways {
+		heading, ok := headings[status.Threshold]
+		if !ok {
+			continue
+		}
+		runways = append(runways, runwayWind{
+			Threshold:  status.Threshold,
+			Runway:     status.Runway,
+			Closed:     status.Closed,
+			Arrivals:   status.Arrivals,
+			Departures: status.Departures,
+			RunwayWind: weather.ComputeRunwayWind(heading, metar.Wind, limits),
+		})
+	}
+
+	WriteJSON(w, http.StatusOK, map[string]interface{}{
+		"timestamp":   time.Now().UTC(),
+		"airport":     h.config.Station.AirportCode,
+		"observed_at": metar.ObservedAt,
+		"wind":        metar.Wind,
+		"limits":      limits,
+		"runways":     runways,
+	})
+}
+
+// CloseRunway marks a runway closed
+func (h *Handler) CloseRunway(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Reason          string `json:"reason"`
+		Until           string `json:"until"`
+		DurationMinutes int    `json:"duration_minutes"`
+	}
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "Invalid request body", http.StatusBadRequest)
+			return
+		}
+	}
+
+	until, err := runwayOverrideUntil(req.Until, req.DurationMinutes)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if _, err := h.adsbService.CloseRunway(chi.URLParam(r, "runway"), req.Reason, until); err != nil {
+		http.Error(w, err.Error(), runwayErrorStatus(err))
+		return
+	}
+	h.cache.Invalidate(cacheTagStation)
+
+	WriteJSON(w, http.StatusOK, h.adsbService.GetRunwayState())
+}
+
+// OpenRunway reopens a closed runway
+func (h *Handler) OpenRunway(w http.ResponseWriter, r *http.Request) {
+	if err := h.adsbService.OpenRunway(chi.URLParam(r, "runway")); err != nil {
+		http.Error(w, err.Error(), runwayErrorStatus(err))
+		return
+	}
+	h.cache.Invalidate(cacheTagStation)
+
+	WriteJSON(w, http.StatusOK, h.adsbService.GetRunwayState())
+}
+
+// SetRunwayConfiguration forces the runways in use for arrivals and departures
+func (h *Handler) SetRunwayConfiguration(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Arrivals        []string `json:"arrivals"`
+		Departures      []string `json:"departures"`
+		Until           string   `json:"until"`
+		DurationMinutes int      `json:"duration_minutes"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	until, err := runwayOverrideUntil(req.Until, req.DurationMinutes)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if _, err := h.adsbService.SetRunwayConfiguration(req.Arrivals, req.Departures, until); err != nil {
+		http.Error(w, err.Error(), runwayErrorStatus(err))
+		return
+	}
+	h.cache.Invalidate(cacheTagStation)
+
+	WriteJSON(w, http.StatusOK, h.adsbService.GetRunwayState())
+}
+
+// ClearRunwayConfiguration removes the forced runway configuration
+func (h *Handler) ClearRunwayConfiguration(w http.ResponseWriter, r *http.Request) {
+	h.adsbService.ClearRunwayConfiguration()
+	h.cache.Invalidate(cacheTagStation)
+	WriteJSON(w, http.StatusOK, h.adsbService.GetRunwayState())
+}
@@ -0,0 +1,16 @@
+package api
+
+import (
+	"net/http"
+)
+
+// GetArrivalSequence returns the arrivals to each runway in order of their estimated time to
+// the threshold, optionally of one runway
+func (h *Handler) GetArrivalSequence(w http.ResponseWriter, r *http.Request) {
+	if h.sequenceService == nil {
+		http.Error(w, "Arrival sequencing not enabled", http.StatusServiceUnavailable)
+		return
+	}
+
+	WriteJSON(w, http.StatusOK, h.sequenceService.Sequence(r.URL.Query().Get("runway")))
+}
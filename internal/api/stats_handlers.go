@@ -0,0 +1,134 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/yegors/co-atc/internal/stats"
+	"github.com/yegors/co-atc/pkg/logger"
+)
+
+// statsErrorStatus maps statistics errors to HTTP status codes
+func statsErrorStatus(err error) int {
+	if errors.Is(err, stats.ErrInvalidQuery) {
+		return http.StatusBadRequest
+	}
+	return http.StatusInternalServerError
+}
+
+// GetMovementStats returns arrivals, departures and overflights by hour or day
+func (h *Handler) GetMovementStats(w http.ResponseWriter, r *http.Request) {
+	interval := r.URL.Query().Get("interval")
+	if interval == "" {
+		interval = stats.IntervalHour
+	}
+	span := 24 * time.Hour
+	if interval == stats.IntervalDay {
+		span = 7 * 24 * time.Hour
+	}
+
+	query, err := parseStatsQuery(r, span)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	h.writeStats(w, "movement", func() (interface{}, error) {
+		return h.statsService.Movements(query, interval)
+	})
+}
+
+// GetBusiestHours returns the busiest hours of the day and clock hours
+func (h *Handler) GetBusiestHours(w http.ResponseWriter, r *http.Request) {
+	query, err := parseStatsQuery(r, 7*24*time.Hour)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	limit, err := parseStatsLimit(r, 5)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	h.writeStats(w, "busiest hours", func() (interface{}, error) {
+		return h.statsService.BusiestHours(query, limit)
+	})
+}
+
+// GetTopTraffic returns the operators and aircraft types with the most movements
+func (h *Handler) GetTopTraffic(w http.ResponseWriter, r *http.Request) {
+	query, err := parseStatsQuery(r, 7*24*time.Hour)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	limit, err := parseStatsLimit(r, 10)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	h.writeStats(w, "top traffic", func() (interface{}, error) {
+		return h.statsService.Top(query, limit)
+	})
+}
+
+// writeStats writes the statistics a function works out, or its error
+func (h *Handler) writeStats(w http.ResponseWriter, name string, get func() (interface{}, error)) {
+	result, err := get()
+	if err != nil {
+		status := statsErrorStatus(err)
+		if status == http.StatusInternalServerError {
+			h.logger.Error("Failed to get "+name+" statistics", logger.Error(err))
+			http.Error(w, "Failed to get "+name+" statistics", status)
+			return
+		}
+		http.Error(w, err.Error(), status)
+		return
+	}
+	WriteJSON(w, http.StatusOK, result)
+}
+
+// parseStatsQuery reads the start_time, end_time and tz parameters. The range ends now and
+// spans a default length unless given.
+func parseStatsQuery(r *http.Request, span time.Duration) (stats.Query, error) {
+	query := stats.Query{End: time.Now().UTC(), Location: time.UTC}
+
+	if value := r.URL.Query().Get("end_time"); value != "" {
+		end, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			return query, fmt.Errorf("invalid end_time format (use RFC3339)")
+		}
+		query.End = end
+	}
+	query.Start = query.End.Add(-span)
+	if value := r.URL.Query().Get("start_time"); value != "" {
+		start, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			return query, fmt.Errorf("invalid start_time format (use RFC3339)")
+		}
+		query.Start = start
+	}
+	if value := r.URL.Query().Get("tz"); value != "" {
+		location, err := time.LoadLocation(value)
+		if err != nil {
+			return query, fmt.Errorf("unknown time zone %q", value)
+		}
+		query.Location = location
+	}
+	return query, nil
+}
+
+// parseStatsLimit reads the limit parameter
+func parseStatsLimit(r *http.Request, defaultLimit int) (int, error) {
+	value := r.URL.Query().Get("limit")
+	if value == "" {
+		return defaultLimit, nil
+	}
+	limit, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, fmt.Errorf("limit must be a number")
+	}
+	return limit, nil
+}
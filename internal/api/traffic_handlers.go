@@ -0,0 +1,46 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/yegors/co-atc/internal/simulation"
+)
+
+// trafficErrorStatus maps traffic generator errors to HTTP status codes
+func trafficErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, simulation.ErrTrafficUnavailable):
+		return http.StatusServiceUnavailable
+	case errors.Is(err, simulation.ErrInvalidTraffic):
+		return http.StatusBadRequest
+	}
+	return http.StatusInternalServerError
+}
+
+// GetTraffic returns the state of the traffic generator
+func (h *Handler) GetTraffic(w http.ResponseWriter, r *http.Request) {
+	status, err := h.simulationService.TrafficStatus()
+	if err != nil {
+		http.Error(w, err.Error(), trafficErrorStatus(err))
+		return
+	}
+	WriteJSON(w, http.StatusOK, status)
+}
+
+// UpdateTraffic starts, stops or retunes the traffic generator
+func (h *Handler) UpdateTraffic(w http.ResponseWriter, r *http.Request) {
+	var req simulation.TrafficUpdate
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+
+	status, err := h.simulationService.UpdateTraffic(req)
+	if err != nil {
+		http.Error(w, err.Error(), trafficErrorStatus(err))
+		return
+	}
+	WriteJSON(w, http.StatusOK, status)
+}
@@ -1,15 +1,22 @@
 package api
 
 import (
+	"bytes"
 	"fmt"
 	"net/http"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/go-chi/chi/v5"
+	"github.com/yegors/co-atc/internal/storage/sqlite"
 	"github.com/yegors/co-atc/pkg/logger"
 )
 
+// transcriptionClipPadding is added either side of a transcription's audio so clipped
+// syllables at the VAD boundaries stay audible
+const transcriptionClipPadding = 250 * time.Millisecond
+
 // HandleWebSocket handles WebSocket connections
 func (h *Handler) HandleWebSocket(w http.ResponseWriter, r *http.Request) {
 	h.logger.Info("WebSocket connection request received")
@@ -18,13 +25,26 @@ func (h *Handler) HandleWebSocket(w http.ResponseWriter, r *http.Request) {
 	h.wsServer.HandleConnection(w, r)
 }
 
+// HandleAdminWebSocket handles WebSocket connections of admins, which are sent the aircraft
+// hidden from the public feed too
+func (h *Handler) HandleAdminWebSocket(w http.ResponseWriter, r *http.Request) {
+	h.logger.Info("Admin WebSocket connection request received")
+
+	h.wsServer.HandleAdminConnection(w, r)
+}
+
 // GetAllTranscriptions returns all transcriptions with pagination
 func (h *Handler) GetAllTranscriptions(w http.ResponseWriter, r *http.Request) {
 	// Parse pagination parameters
 	limit, offset := parsePaginationParams(r)
+	minConfidence, err := parseMinConfidence(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
 
 	// Get transcriptions from storage
-	transcriptions, err := h.transcriptionStorage.GetTranscriptions(limit, offset)
+	transcriptions, err := h.transcriptionStorage.GetTranscriptions(minConfidence, limit, offset)
 	if err != nil {
 		h.logger.Error("Failed to retrieve transcriptions", logger.Error(err))
 		http.Error(w, "Failed to retrieve transcriptions", http.StatusInternalServerError)
@@ -42,6 +62,47 @@ func (h *Handler) GetAllTranscriptions(w http.ResponseWriter, r *http.Request) {
 	WriteJSON(w, http.StatusOK, response)
 }
 
+// GetDelayedTranscriptions returns transcriptions for the public view. Only transcriptions
+// older than the configured delay are returned, optionally from start_time onwards.
+func (h *Handler) GetDelayedTranscriptions(w http.ResponseWriter, r *http.Request) {
+	delay := time.Duration(h.config.Server.Public.TranscriptionDelaySeconds) * time.Second
+	endTime := time.Now().Add(-delay)
+	startTime := endTime.Add(-24 * time.Hour)
+
+	if startTimeStr := r.URL.Query().Get("start_time"); startTimeStr != "" {
+		parsed, err := time.Parse(time.RFC3339, startTimeStr)
+		if err != nil {
+			http.Error(w, "invalid start_time format (use RFC3339)", http.StatusBadRequest)
+			return
+		}
+		startTime = parsed
+	}
+
+	limit, offset := parsePaginationParams(r)
+	minConfidence, err := parseMinConfidence(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	transcriptions, err := h.transcriptionStorage.GetTranscriptionsByTimeRange(startTime, endTime, minConfidence, limit, offset)
+	if err != nil {
+		h.logger.Error("Failed to retrieve delayed transcriptions", logger.Error(err))
+		http.Error(w, "Failed to retrieve transcriptions", http.StatusInternalServerError)
+		return
+	}
+
+	response := map[string]interface{}{
+		"timestamp":      time.Now(),
+		"delay_seconds":  int(delay.Seconds()),
+		"end_time":       endTime,
+		"count":          len(transcriptions),
+		"transcriptions": transcriptions,
+	}
+
+	WriteJSON(w, http.StatusOK, response)
+}
+
 // GetTranscriptionsByFrequency returns transcriptions for a specific frequency
 func (h *Handler) GetTranscriptionsByFrequency(w http.ResponseWriter, r *http.Request) {
 	// Get frequency ID from URL
@@ -53,9 +114,14 @@ func (h *Handler) GetTranscriptionsByFrequency(w http.ResponseWriter, r *http.Re
 
 	// Parse pagination parameters
 	limit, offset := parsePaginationParams(r)
+	minConfidence, err := parseMinConfidence(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
 
 	// Get transcriptions from storage
-	transcriptions, err := h.transcriptionStorage.GetTranscriptionsByFrequency(id, limit, offset)
+	transcriptions, err := h.transcriptionStorage.GetTranscriptionsByFrequency(id, minConfidence, limit, offset)
 	if err != nil {
 		h.logger.Error("Failed to retrieve transcriptions by frequency", logger.Error(err))
 		http.Error(w, "Failed to retrieve transcriptions", http.StatusInternalServerError)
@@ -85,9 +151,14 @@ func (h *Handler) GetTranscriptionsByTimeRange(w http.ResponseWriter, r *http.Re
 
 	// Parse pagination parameters
 	limit, offset := parsePaginationParams(r)
+	minConfidence, err := parseMinConfidence(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
 
 	// Get transcriptions from storage
-	transcriptions, err := h.transcriptionStorage.GetTranscriptionsByTimeRange(startTime, endTime, limit, offset)
+	transcriptions, err := h.transcriptionStorage.GetTranscriptionsByTimeRange(startTime, endTime, minConfidence, limit, offset)
 	if err != nil {
 		h.logger.Error("Failed to retrieve transcriptions by time range", logger.Error(err))
 		http.Error(w, "Failed to retrieve transcriptions", http.StatusInternalServerError)
@@ -107,6 +178,65 @@ func (h *Handler) GetTranscriptionsByTimeRange(w http.ResponseWriter, r *http.Re
 	WriteJSON(w, http.StatusOK, response)
 }
 
+// SearchTranscriptions returns transcriptions whose raw or processed content matches a
+// full-text query, optionally narrowed by frequency, callsign, speaker and time
+func (h *Handler) SearchTranscriptions(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	search := sqlite.TranscriptionSearch{
+		Query:       strings.TrimSpace(query.Get("q")),
+		FrequencyID: query.Get("frequency_id"),
+		SpeakerType: query.Get("speaker_type"),
+		SortByTime:  query.Get("sort") == "time",
+	}
+	if search.Query == "" {
+		http.Error(w, "Missing q parameter", http.StatusBadRequest)
+		return
+	}
+	if search.SpeakerType != "" && search.SpeakerType != "ATC" && search.SpeakerType != "PILOT" {
+		http.Error(w, "Invalid speaker_type (must be 'ATC' or 'PILOT')", http.StatusBadRequest)
+		return
+	}
+	if sort := query.Get("sort"); sort != "" && sort != "time" && sort != "relevance" {
+		http.Error(w, "Invalid sort (must be 'relevance' or 'time')", http.StatusBadRequest)
+		return
+	}
+	// Transcriptions are stored under the callsign of the aircraft they were linked to
+	if callsign := query.Get("callsign"); callsign != "" {
+		search.Callsign = h.adsbService.Callsigns().Canonical(callsign)
+	}
+
+	var err error
+	if search.StartTime, err = parseOptionalTime(r, "start_time"); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if search.EndTime, err = parseOptionalTime(r, "end_time"); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	search.Limit, search.Offset = parsePaginationParams(r)
+	if search.MinConfidence, err = parseMinConfidence(r); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	transcriptions, err := h.transcriptionStorage.SearchTranscriptions(search)
+	if err != nil {
+		h.logger.Error("Failed to search transcriptions", logger.Error(err), logger.String("query", search.Query))
+		http.Error(w, "Failed to search transcriptions", http.StatusInternalServerError)
+		return
+	}
+
+	response := map[string]interface{}{
+		"timestamp":      time.Now(),
+		"query":          search.Query,
+		"count":          len(transcriptions),
+		"transcriptions": transcriptions,
+	}
+
+	WriteJSON(w, http.StatusOK, response)
+}
+
 // GetTranscriptionsBySpeaker returns transcriptions by speaker type
 func (h *Handler) GetTranscriptionsBySpeaker(w http.ResponseWriter, r *http.Request) {
 	// Get speaker type from URL
@@ -124,9 +254,14 @@ func (h *Handler) GetTranscriptionsBySpeaker(w http.ResponseWriter, r *http.Requ
 
 	// Parse pagination parameters
 	limit, offset := parsePaginationParams(r)
+	minConfidence, err := parseMinConfidence(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
 
 	// Get transcriptions from storage
-	transcriptions, err := h.transcriptionStorage.GetTranscriptionsBySpeaker(speakerType, limit, offset)
+	transcriptions, err := h.transcriptionStorage.GetTranscriptionsBySpeaker(speakerType, minConfidence, limit, offset)
 	if err != nil {
 		h.logger.Error("Failed to retrieve transcriptions by speaker", logger.Error(err))
 		http.Error(w, "Failed to retrieve transcriptions", http.StatusInternalServerError)
@@ -156,9 +291,16 @@ func (h *Handler) GetTranscriptionsByCallsign(w http.ResponseWriter, r *http.Req
 
 	// Parse pagination parameters
 	limit, offset := parsePaginationParams(r)
+	minConfidence, err := parseMinConfidence(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
 
 	// Get transcriptions from storage
-	transcriptions, err := h.transcriptionStorage.GetTranscriptionsByCallsign(callsign, limit, offset)
+	// Transcriptions are stored under the callsign of the aircraft they were linked to
+	callsign = h.adsbService.Callsigns().Canonical(callsign)
+	transcriptions, err := h.transcriptionStorage.GetTranscriptionsByCallsign(callsign, minConfidence, limit, offset)
 	if err != nil {
 		h.logger.Error("Failed to retrieve transcriptions by callsign", logger.Error(err))
 		http.Error(w, "Failed to retrieve transcriptions", http.StatusInternalServerError)
@@ -177,6 +319,148 @@ func (h *Handler) GetTranscriptionsByCallsign(w http.ResponseWriter, r *http.Req
 	WriteJSON(w, http.StatusOK, response)
 }
 
+// GetTranscriptionsByCorrelationID returns the transcriptions and clearances that share a correlation ID,
+// allowing an extracted clearance to be traced back to the transmission it came from
+func (h *Handler) GetTranscriptionsByCorrelationID(w http.ResponseWriter, r *http.Request) {
+	// Get correlation ID from URL
+	correlationID := chi.URLParam(r, "id")
+	if correlationID == "" {
+		http.Error(w, "Missing correlation ID", http.StatusBadRequest)
+		return
+	}
+
+	// Get transcriptions from storage
+	transcriptions, err := h.transcriptionStorage.GetTranscriptionsByCorrelationID(correlationID)
+	if err != nil {
+		h.logger.Error("Failed to retrieve transcriptions by correlation ID", logger.Error(err))
+		http.Error(w, "Failed to retrieve transcriptions", http.StatusInternalServerError)
+		return
+	}
+
+	// Get clearances extracted from the same transmission
+	clearances, err := h.clearanceStorage.GetClearancesByCorrelationID(correlationID)
+	if err != nil {
+		h.logger.Error("Failed to retrieve clearances by correlation ID", logger.Error(err))
+		http.Error(w, "Failed to retrieve clearances", http.StatusInternalServerError)
+		return
+	}
+
+	// Create response
+	response := map[string]interface{}{
+		"timestamp":      time.Now(),
+		"correlation_id": correlationID,
+		"count":          len(transcriptions),
+		"transcriptions": transcriptions,
+		"clearances":     clearances,
+	}
+
+	// Write response
+	WriteJSON(w, http.StatusOK, response)
+}
+
+// GetTranscriptionRecording returns where a transcription's audio is in the recorded segments
+func (h *Handler) GetTranscriptionRecording(w http.ResponseWriter, r *http.Request) {
+	record, ok := h.transcriptionWithAudio(w, r)
+	if !ok {
+		return
+	}
+
+	from, to := transcriptionClipRange(record)
+	slices, err := h.frequenciesService.LocateRecording(record.FrequencyID, from, to)
+	if err != nil {
+		http.Error(w, err.Error(), frequencyErrorStatus(err))
+		return
+	}
+
+	segments := make([]map[string]interface{}, 0, len(slices))
+	for _, slice := range slices {
+		segment := map[string]interface{}{
+			"segment_id":    slice.SegmentID,
+			"in_progress":   slice.InProgress,
+			"format":        slice.Format,
+			"offset_ms":     slice.OffsetMs,
+			"end_offset_ms": slice.EndOffsetMs,
+			"byte_offset":   slice.ByteOffset,
+			"byte_end":      slice.ByteEnd,
+		}
+		// The segment still being recorded has no file URL until it is indexed
+		if !slice.InProgress {
+			segment["url"] = fmt.Sprintf("/api/v1/recordings/%d", slice.SegmentID)
+		}
+		segments = append(segments, segment)
+	}
+
+	WriteJSON(w, http.StatusOK, map[string]interface{}{
+		"transcription_id": record.ID,
+		"frequency_id":     record.FrequencyID,
+		"audio_start":      record.AudioStart,
+		"audio_end":        record.AudioEnd,
+		"clip_url":         fmt.Sprintf("/api/v1/transcriptions/%d/audio", record.ID),
+		"segments":         segments,
+	})
+}
+
+// GetTranscriptionAudio returns the recorded audio clip a transcription was made from
+func (h *Handler) GetTranscriptionAudio(w http.ResponseWriter, r *http.Request) {
+	record, ok := h.transcriptionWithAudio(w, r)
+	if !ok {
+		return
+	}
+
+	// Clips are a few seconds long, so buffer them to report extraction errors properly
+	var clip bytes.Buffer
+	from, to := transcriptionClipRange(record)
+	contentType, err := h.frequenciesService.WriteRecordingClip(r.Context(), record.FrequencyID, from, to, &clip)
+	if err != nil {
+		h.logger.Error("Failed to extract transcription audio",
+			logger.Int64("transcription_id", record.ID),
+			logger.Error(err))
+		http.Error(w, err.Error(), frequencyErrorStatus(err))
+		return
+	}
+
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Content-Length", strconv.Itoa(clip.Len()))
+	w.Header().Set("Cache-Control", "public, max-age=3600")
+	w.WriteHeader(http.StatusOK)
+	w.Write(clip.Bytes())
+}
+
+// transcriptionWithAudio loads the transcription in the URL, writing an error response
+// if it does not exist or has no audio times
+func (h *Handler) transcriptionWithAudio(w http.ResponseWriter, r *http.Request) (*sqlite.TranscriptionRecord, bool) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid transcription ID", http.StatusBadRequest)
+		return nil, false
+	}
+
+	record, err := h.transcriptionStorage.GetTranscriptionByID(id)
+	if err != nil {
+		h.logger.Error("Failed to retrieve transcription", logger.Error(err))
+		http.Error(w, "Failed to retrieve transcription", http.StatusInternalServerError)
+		return nil, false
+	}
+	if record == nil {
+		http.Error(w, "Transcription not found", http.StatusNotFound)
+		return nil, false
+	}
+	if record.AudioStart == nil || record.AudioEnd == nil {
+		http.Error(w, "Transcription has no audio times", http.StatusNotFound)
+		return nil, false
+	}
+
+	return record, true
+}
+
+// transcriptionClipRange returns the padded audio range of a transcription. Recordings are
+// timed by when their audio arrived, so the delay the transcript times were moved back by is
+// added again.
+func transcriptionClipRange(record *sqlite.TranscriptionRecord) (time.Time, time.Time) {
+	delay := time.Duration(record.AudioDelayMs) * time.Millisecond
+	return record.AudioStart.Add(delay - transcriptionClipPadding), record.AudioEnd.Add(delay + transcriptionClipPadding)
+}
+
 // Helper functions
 func parsePaginationParams(r *http.Request) (int, int) {
 	limit := 100 // Default limit
@@ -197,6 +481,32 @@ func parsePaginationParams(r *http.Request) (int, int) {
 	return limit, offset
 }
 
+// parseMinConfidence parses the optional min_confidence filter (0-1, default 0)
+func parseMinConfidence(r *http.Request) (float64, error) {
+	value := r.URL.Query().Get("min_confidence")
+	if value == "" {
+		return 0, nil
+	}
+	minConfidence, err := strconv.ParseFloat(value, 64)
+	if err != nil || minConfidence < 0 || minConfidence > 1 {
+		return 0, fmt.Errorf("invalid min_confidence (must be between 0 and 1)")
+	}
+	return minConfidence, nil
+}
+
+// parseOptionalTime parses an optional RFC3339 time parameter (nil if not given)
+func parseOptionalTime(r *http.Request, name string) (*time.Time, error) {
+	value := r.URL.Query().Get(name)
+	if value == "" {
+		return nil, nil
+	}
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s format (use RFC3339)", name)
+	}
+	return &t, nil
+}
+
 func parseTimeRangeParams(r *http.Request) (time.Time, time.Time, error) {
 	startTimeStr := r.URL.Query().Get("start_time")
 	endTimeStr := r.URL.Query().Get("end_time")
@@ -0,0 +1,78 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/yegors/co-atc/internal/audio"
+	"github.com/yegors/co-atc/pkg/logger"
+)
+
+// defaultUsageDays is how many days of usage are returned when no range is given
+const defaultUsageDays = 30
+
+// GetUsage returns the daily API usage and estimated cost of a range of days (UTC)
+func (h *Handler) GetUsage(w http.ResponseWriter, r *http.Request) {
+	if h.usageTracker == nil {
+		http.Error(w, "Usage tracking is not available", http.StatusServiceUnavailable)
+		return
+	}
+
+	fromDay, toDay, err := parseUsageRange(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	summary, err := h.usageTracker.GetSummary(fromDay, toDay)
+	if err != nil {
+		h.logger.Error("Failed to get usage", logger.Error(err))
+		http.Error(w, "Failed to get usage", http.StatusInternalServerError)
+		return
+	}
+
+	WriteJSON(w, http.StatusOK, summary)
+}
+
+// GetMetrics serves API usage and cost metrics and audio pipeline restarts in the Prometheus
+// text format
+func (h *Handler) GetMetrics(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	if h.usageTracker != nil {
+		if err := h.usageTracker.WritePrometheus(w); err != nil {
+			h.logger.Debug("Failed to write metrics", logger.Error(err))
+			return
+		}
+	}
+	if err := audio.WritePrometheus(w, h.frequenciesService.AudioPipelines()); err != nil {
+		h.logger.Debug("Failed to write metrics", logger.Error(err))
+	}
+}
+
+// parseUsageRange parses the from and to days (YYYY-MM-DD) of a usage request. To
+// defaults to today and from to the defaultUsageDays days ending on it.
+func parseUsageRange(r *http.Request) (string, string, error) {
+	to := time.Now().UTC()
+	if value := r.URL.Query().Get("to"); value != "" {
+		parsed, err := time.Parse("2006-01-02", value)
+		if err != nil {
+			return "", "", fmt.Errorf("invalid to day, expected YYYY-MM-DD: %s", value)
+		}
+		to = parsed
+	}
+
+	from := to.AddDate(0, 0, -(defaultUsageDays - 1))
+	if value := r.URL.Query().Get("from"); value != "" {
+		parsed, err := time.Parse("2006-01-02", value)
+		if err != nil {
+			return "", "", fmt.Errorf("invalid from day, expected YYYY-MM-DD: %s", value)
+		}
+		from = parsed
+	}
+
+	if from.After(to) {
+		return "", "", fmt.Errorf("from must not be after to")
+	}
+	return from.Format("2006-01-02"), to.Format("2006-01-02"), nil
+}
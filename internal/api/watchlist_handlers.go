@@ -0,0 +1,153 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/yegors/co-atc/internal/adsb"
+	"github.com/yegors/co-atc/pkg/logger"
+)
+
+// watchlistRequest is the body of watchlist create and update requests
+type watchlistRequest struct {
+	Name             string   `json:"name"`
+	HexCodes         []string `json:"hex_codes"`
+	Registrations    []string `json:"registrations"`
+	CallsignPrefixes []string `json:"callsign_prefixes"`
+	AircraftTypes    []string `json:"aircraft_types"`
+	Events           []string `json:"events"`
+	Notify           *bool    `json:"notify"` // Default true
+}
+
+// watchlist converts the request to a watchlist
+func (req watchlistRequest) watchlist() *adsb.Watchlist {
+	notify := true
+	if req.Notify != nil {
+		notify = *req.Notify
+	}
+	return &adsb.Watchlist{
+		Name:             req.Name,
+		HexCodes:         req.HexCodes,
+		Registrations:    req.Registrations,
+		CallsignPrefixes: req.CallsignPrefixes,
+		AircraftTypes:    req.AircraftTypes,
+		Events:           req.Events,
+		Notify:           notify,
+	}
+}
+
+// watchlistErrorStatus maps watchlist errors to HTTP status codes
+func watchlistErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, adsb.ErrWatchlistNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, adsb.ErrInvalidWatchlist):
+		return http.StatusBadRequest
+	}
+	return http.StatusInternalServerError
+}
+
+// watchlistID parses the watchlist ID of the URL
+func watchlistID(r *http.Request) (int64, bool) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	return id, err == nil
+}
+
+// GetWatchlists returns every watchlist
+func (h *Handler) GetWatchlists(w http.ResponseWriter, r *http.Request) {
+	watchlists := h.adsbService.GetWatchlists()
+	WriteJSON(w, http.StatusOK, map[string]interface{}{
+		"count":      len(watchlists),
+		"events":     adsb.WatchEvents,
+		"watchlists": watchlists,
+	})
+}
+
+// GetWatchlist returns a watchlist
+func (h *Handler) GetWatchlist(w http.ResponseWriter, r *http.Request) {
+	id, ok := watchlistID(r)
+	if !ok {
+		http.Error(w, "Invalid watchlist ID", http.StatusBadRequest)
+		return
+	}
+
+	watchlist, err := h.adsbService.GetWatchlist(id)
+	if err != nil {
+		http.Error(w, err.Error(), watchlistErrorStatus(err))
+		return
+	}
+	WriteJSON(w, http.StatusOK, watchlist)
+}
+
+// CreateWatchlist creates a watchlist
+func (h *Handler) CreateWatchlist(w http.ResponseWriter, r *http.Request) {
+	var req watchlistRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	watchlist, err := h.adsbService.CreateWatchlist(req.watchlist())
+	if err != nil {
+		if watchlistErrorStatus(err) == http.StatusInternalServerError {
+			h.logger.Error("Failed to create watchlist", logger.Error(err))
+		}
+		http.Error(w, err.Error(), watchlistErrorStatus(err))
+		return
+	}
+	h.cache.Invalidate(cacheTagAircraft)
+
+	WriteJSON(w, http.StatusCreated, watchlist)
+}
+
+// UpdateWatchlist replaces a watchlist
+func (h *Handler) UpdateWatchlist(w http.ResponseWriter, r *http.Request) {
+	id, ok := watchlistID(r)
+	if !ok {
+		http.Error(w, "Invalid watchlist ID", http.StatusBadRequest)
+		return
+	}
+
+	var req watchlistRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	watchlist, err := h.adsbService.UpdateWatchlist(id, req.watchlist())
+	if err != nil {
+		if watchlistErrorStatus(err) == http.StatusInternalServerError {
+			h.logger.Error("Failed to update watchlist", logger.Error(err))
+		}
+		http.Error(w, err.Error(), watchlistErrorStatus(err))
+		return
+	}
+	h.cache.Invalidate(cacheTagAircraft)
+
+	WriteJSON(w, http.StatusOK, watchlist)
+}
+
+// DeleteWatchlist deletes a watchlist
+func (h *Handler) DeleteWatchlist(w http.ResponseWriter, r *http.Request) {
+	id, ok := watchlistID(r)
+	if !ok {
+		http.Error(w, "Invalid watchlist ID", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.adsbService.DeleteWatchlist(id); err != nil {
+		if watchlistErrorStatus(err) == http.StatusInternalServerError {
+			h.logger.Error("Failed to delete watchlist", logger.Error(err))
+		}
+		http.Error(w, err.Error(), watchlistErrorStatus(err))
+		return
+	}
+	h.cache.Invalidate(cacheTagAircraft)
+
+	WriteJSON(w, http.StatusOK, map[string]interface{}{
+		"success": true,
+	})
+}
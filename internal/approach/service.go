@@ -0,0 +1,355 @@
+// Package approach monitors aircraft on final approach against stabilized approach criteria:
+// descent rate, speed trend and deviation from the glidepath.
+package approach
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/yegors/co-atc/internal/adsb"
+	"github.com/yegors/co-atc/internal/config"
+	"github.com/yegors/co-atc/internal/websocket"
+	"github.com/yegors/co-atc/pkg/logger"
+)
+
+const (
+	// minHeight is the height below which approaches aren't evaluated, as aircraft flare
+	minHeight = 100.0
+	// confirmCycles is how many poll cycles in a row an approach must be unstable to be flagged,
+	// so a single noisy sample doesn't raise one
+	confirmCycles = 2
+	// approachTimeout is how long an approach is remembered after the aircraft was last on it
+	approachTimeout = 2 * time.Minute
+	// maxEvents is how many recent unstable approaches are kept for review
+	maxEvents = 200
+)
+
+// Event is an unstable approach
+type Event struct {
+	Hex                   string    `json:"hex"`
+	Callsign              string    `json:"callsign"`
+	Runway                string    `json:"runway"` // Threshold ID, e.g. "24R"
+	DistanceNM            float64   `json:"distance_nm"`
+	HeightFeet            float64   `json:"height_ft"` // Above the field, corrected to the QNH the aircraft sends
+	DescentRateFPM        float64   `json:"descent_rate_fpm"`
+	SpeedKt               float64   `json:"speed_kt"`                  // Indicated airspeed, or ground speed if not sent
+	SpeedChangeKt         *float64  `json:"speed_change_kt,omitempty"` // Over speed_window_seconds, once known
+	GlidepathDeviationDeg float64   `json:"glidepath_deviation_deg"`   // Positive above the glidepath
+	GlidepathDeviationFt  float64   `json:"glidepath_deviation_ft"`
+	Reasons               []string  `json:"reasons"`
+	DetectedAt            time.Time `json:"detected_at"`
+}
+
+// AlertNotifier receives alerts that should reach users outside the web UI
+type AlertNotifier interface {
+	NotifyAlert(alertType, title, body string, data map[string]interface{})
+}
+
+// sample is what monitoring needs from an aircraft on approach in a poll cycle
+type sample struct {
+	hex         string
+	flight      string
+	guidance    adsb.ApproachGuidance
+	descentRate float64 // ft/min, positive descending
+	speed       float64
+	replay      bool
+}
+
+// speedPoint is the speed of an aircraft at a time
+type speedPoint struct {
+	at    time.Time
+	speed float64
+}
+
+// approachState is an aircraft's approach to a runway
+type approachState struct {
+	runway   string
+	speeds   []speedPoint // Oldest first, covering speed_window_seconds
+	unstable int          // Poll cycles in a row the approach was unstable
+	flagged  bool
+	lastSeen time.Time
+}
+
+// Service follows aircraft on final approach and flags approaches that aren't stabilized by
+// gate_height_feet above the field
+type Service struct {
+	config      config.ApproachesConfig
+	adsbService *adsb.Service
+	wsServer    *websocket.Server
+	notifier    AlertNotifier
+	logger      *logger.Logger
+
+	cycles chan []sample
+
+	// Approaches in progress, by hex. Only used by the worker goroutine.
+	approaches map[string]*approachState
+
+	events   []Event // Most recent last
+	eventsMu sync.RWMutex
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// NewService creates a new approach stability monitoring service
+func NewService(cfg config.ApproachesConfig, adsbService *adsb.Service, wsServer *websocket.Server, logger *logger.Logger) *Service {
+	return &Service{
+		config:      cfg,
+		adsbService: adsbService,
+		wsServer:    wsServer,
+		logger:      logger.Named("approach"),
+		cycles:      make(chan []sample, 4),
+		approaches:  make(map[string]*approachState),
+	}
+}
+
+// SetAlertNotifier sets the notifier that receives unstable approaches. Must be called before Start.
+func (s *Service) SetAlertNotifier(notifier AlertNotifier) {
+	s.notifier = notifier
+}
+
+// Start starts following aircraft on final approach
+func (s *Service) Start(ctx context.Context) {
+	s.ctx, s.cancel = context.WithCancel(ctx)
+	s.wg.Add(1)
+	go s.run()
+
+	s.adsbService.OnUpdate(s.handleUpdate)
+
+	s.logger.Info("Approach stability monitoring started",
+		logger.Int("gate_height_feet", s.config.GateHeightFeet),
+		logger.Float64("glidepath_deg", s.config.GlidepathDeg),
+		logger.Int("max_descent_rate_fpm", s.config.MaxDescentRateFPM))
+}
+
+// Stop stops following aircraft
+func (s *Service) Stop() {
+	if s.cancel != nil {
+		s.cancel()
+	}
+	s.wg.Wait()
+}
+
+// Events returns the unstable approaches flagged since startup, most recent first
+func (s *Service) Events(limit int) []Event {
+	s.eventsMu.RLock()
+	defer s.eventsMu.RUnlock()
+
+	events := make([]Event, 0, min(limit, len(s.events)))
+	for i := len(s.events) - 1; i >= 0 && len(events) < limit; i-- {
+		events = append(events, s.events[i])
+	}
+	return events
+}
+
+// Recent returns the unstable approaches flagged within context_minutes, most recent first,
+// for the ATC chat context
+func (s *Service) Recent() []Event {
+	since := time.Now().UTC().Add(-time.Duration(s.config.ContextMinutes) * time.Minute)
+
+	s.eventsMu.RLock()
+	defer s.eventsMu.RUnlock()
+
+	events := make([]Event, 0)
+	for i := len(s.events) - 1; i >= 0 && s.events[i].DetectedAt.After(since); i-- {
+		events = append(events, s.events[i])
+	}
+	return events
+}
+
+// handleUpdate hands the aircraft in the approach phase of a poll cycle that are lined up
+// with a runway to the worker without blocking polling
+func (s *Service) handleUpdate(aircraft []*adsb.Aircraft) {
+	samples := make([]sample, 0)
+	for _, a := range aircraft {
+		if a.ADSB == nil || a.Guidance == nil || a.OnGround || a.Status != "active" {
+			continue
+		}
+		if a.Phase == nil || len(a.Phase.Current) == 0 || a.Phase.Current[0].Phase != "APP" {
+			continue
+		}
+		verticalRate := a.ADSB.BaroRate
+		if verticalRate == 0 {
+			verticalRate = a.ADSB.GeomRate
+		}
+		speed := a.ADSB.IAS
+		if speed <= 0 {
+			speed = a.ADSB.GS
+		}
+
+		samples = append(samples, sample{
+			hex:         strings.ToLower(a.Hex),
+			flight:      strings.TrimSpace(a.Flight),
+			guidance:    *a.Guidance,
+			descentRate: -verticalRate,
+			speed:       speed,
+			replay:      a.ADSB.Type == adsb.TargetTypeReplay,
+		})
+	}
+
+	select {
+	case s.cycles <- samples:
+	default:
+		s.logger.Debug("Approach worker busy, skipping poll cycle")
+	}
+}
+
+// run evaluates poll cycles until the service stops
+func (s *Service) run() {
+	defer s.wg.Done()
+
+	for {
+		select {
+		case <-s.ctx.Done():
+			return
+		case samples := <-s.cycles:
+			s.evaluate(samples, time.Now().UTC())
+		}
+	}
+}
+
+// evaluate follows each aircraft's approach and flags it once if it's unstable below the gate
+func (s *Service) evaluate(samples []sample, now time.Time) {
+	window := time.Duration(s.config.SpeedWindowSeconds) * time.Second
+
+	for _, smp := range samples {
+		state, exists := s.approaches[smp.hex]
+		if !exists || state.runway != smp.guidance.Runway {
+			// A new approach, or a switch to another runway
+			state = &approachState{runway: smp.guidance.Runway}
+			s.approaches[smp.hex] = state
+		}
+		state.lastSeen = now
+		state.speeds = append(state.speeds, speedPoint{at: now, speed: smp.speed})
+		for len(state.speeds) > 2 && now.Sub(state.speeds[1].at) >= window {
+			state.speeds = state.speeds[1:]
+		}
+
+		height := smp.guidance.HeightFeet
+		if state.flagged || height > float64(s.config.GateHeightFeet) || height < minHeight {
+			state.unstable = 0
+			continue
+		}
+
+		event := s.assess(smp, state.speeds, window)
+		if len(event.Reasons) == 0 {
+			state.unstable = 0
+			continue
+		}
+		state.unstable++
+		if state.unstable >= confirmCycles {
+			state.flagged = true
+			event.DetectedAt = now
+			s.raise(event, smp.replay)
+		}
+	}
+
+	for hex, state := range s.approaches {
+		if now.Sub(state.lastSeen) > approachTimeout {
+			delete(s.approaches, hex)
+		}
+	}
+}
+
+// assess measures an aircraft against the stabilized approach criteria. The reasons of the
+// event are the criteria it doesn't meet.
+func (s *Service) assess(smp sample, speeds []speedPoint, window time.Duration) Event {
+	guidance := smp.guidance
+	event := Event{
+		Hex:                   smp.hex,
+		Callsign:              smp.flight,
+		Runway:                guidance.Runway,
+		DistanceNM:            guidance.DistanceNM,
+		HeightFeet:            guidance.HeightFeet,
+		DescentRateFPM:        smp.descentRate,
+		SpeedKt:               smp.speed,
+		GlidepathDeviationDeg: guidance.VerticalDeviationDeg,
+		GlidepathDeviationFt:  guidance.VerticalDeviationFt,
+		Reasons:               make([]string, 0),
+	}
+
+	if smp.descentRate > float64(s.config.MaxDescentRateFPM) {
+		event.Reasons = append(event.Reasons, fmt.Sprintf("descent rate %.0f ft/min", smp.descentRate))
+	}
+	if math.Abs(guidance.VerticalDeviationDeg) > s.config.GlidepathToleranceDeg {
+		position := "high"
+		if guidance.VerticalDeviationDeg < 0 {
+			position = "low"
+		}
+		event.Reasons = append(event.Reasons, fmt.Sprintf("%.0f ft %s on the glidepath", math.Abs(event.GlidepathDeviationFt), position))
+	}
+
+	// The speed trend is known once the samples cover the window
+	if len(speeds) > 1 && speeds[len(speeds)-1].at.Sub(speeds[0].at) >= window {
+		change := smp.speed - speeds[0].speed
+		event.SpeedChangeKt = &change
+		if math.Abs(change) > float64(s.config.MaxSpeedChangeKt) {
+			trend := "increased"
+			if change < 0 {
+				trend = "dropped"
+			}
+			event.Reasons = append(event.Reasons, fmt.Sprintf("speed %s %.0f kt in %ds", trend, math.Abs(change), s.config.SpeedWindowSeconds))
+		}
+	}
+	return event
+}
+
+// raise records an unstable approach and tells the UI and notifier about it
+func (s *Service) raise(event Event, replay bool) {
+	s.eventsMu.Lock()
+	s.events = append(s.events, event)
+	if len(s.events) > maxEvents {
+		s.events = s.events[len(s.events)-maxEvents:]
+	}
+	s.eventsMu.Unlock()
+
+	s.logger.Warn("Unstable approach",
+		logger.String("hex", event.Hex),
+		logger.String("callsign", event.Callsign),
+		logger.String("runway", event.Runway),
+		logger.Float64("height_ft", event.HeightFeet),
+		logger.String("reasons", strings.Join(event.Reasons, ", ")))
+
+	data := map[string]interface{}{
+		"hex":                     event.Hex,
+		"callsign":                event.Callsign,
+		"runway":                  event.Runway,
+		"distance_nm":             event.DistanceNM,
+		"height_ft":               event.HeightFeet,
+		"descent_rate_fpm":        event.DescentRateFPM,
+		"speed_kt":                event.SpeedKt,
+		"glidepath_deviation_deg": event.GlidepathDeviationDeg,
+		"glidepath_deviation_ft":  event.GlidepathDeviationFt,
+		"reasons":                 event.Reasons,
+		"detected_at":             event.DetectedAt,
+	}
+	if event.SpeedChangeKt != nil {
+		data["speed_change_kt"] = *event.SpeedChangeKt
+	}
+
+	if s.wsServer != nil {
+		s.wsServer.Broadcast(&websocket.Message{
+			Type: "unstable_approach_alert",
+			Data: data,
+		})
+	}
+
+	if s.notifier != nil && !replay {
+		callsign := event.Callsign
+		if callsign == "" {
+			callsign = event.Hex
+		}
+		s.notifier.NotifyAlert(
+			"unstable_approach",
+			fmt.Sprintf("Unstable approach: %s", callsign),
+			fmt.Sprintf("%s on final runway %s at %.0f ft, %.1f NM: %s",
+				callsign, event.Runway, event.HeightFeet, event.DistanceNM, strings.Join(event.Reasons, ", ")),
+			data,
+		)
+	}
+}
@@ -0,0 +1,181 @@
+package atcchat
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/yegors/co-atc/internal/storage/sqlite"
+	"github.com/yegors/co-atc/pkg/logger"
+)
+
+// Reasons a stored session ended
+const (
+	endReasonEnded    = "ended"
+	endReasonExpired  = "expired"
+	endReasonIdle     = "idle"
+	endReasonShutdown = "shutdown"
+	endReasonRestart  = "restart"
+)
+
+// maxHistoryMessages caps the turns returned for a session's history
+const maxHistoryMessages = 1000
+
+// SessionHistory is a stored session with its transcript
+type SessionHistory struct {
+	Session  *StoredSession              `json:"session"`
+	Messages []*sqlite.ChatMessageRecord `json:"messages"`
+}
+
+// StoredSession is a stored session and whether it is still running
+type StoredSession struct {
+	*sqlite.ChatSessionRecord
+	Active bool `json:"active"`
+}
+
+// historyEnabled reports whether sessions and transcripts are stored
+func (s *Service) historyEnabled() bool {
+	return s.historyStorage != nil
+}
+
+// closeStaleHistory ends sessions left open by a previous run of the server
+func (s *Service) closeStaleHistory() {
+	if !s.historyEnabled() {
+		return
+	}
+
+	ended, err := s.historyStorage.EndOpenSessions(time.Now(), endReasonRestart)
+	if err != nil {
+		s.logger.Error("Failed to close chat sessions from previous run", logger.Error(err))
+	} else if ended > 0 {
+		s.logger.Info("Closed chat sessions from previous run", logger.Int64("sessions", ended))
+	}
+}
+
+// saveSessionHistory stores a new session
+func (s *Service) saveSessionHistory(session *ChatSession) {
+	if !s.historyEnabled() {
+		return
+	}
+
+	record := &sqlite.ChatSessionRecord{
+		ID:              session.ID,
+		ClientID:        session.ClientID,
+		OpenAISessionID: session.OpenAISessionID,
+		Model:           s.SessionPersona(session.ID).RealtimeModel,
+		Persona:         session.Persona,
+		CreatedAt:       session.CreatedAt,
+		ExpiresAt:       session.ExpiresAt,
+	}
+	if err := s.historyStorage.SaveSession(record); err != nil {
+		s.logger.Error("Failed to store chat session",
+			logger.String("session_id", session.ID),
+			logger.Error(err))
+	}
+}
+
+// endSessionHistory marks a stored session as ended
+func (s *Service) endSessionHistory(sessionID, reason string) {
+	if !s.historyEnabled() {
+		return
+	}
+
+	if err := s.historyStorage.EndSession(sessionID, time.Now(), reason); err != nil {
+		s.logger.Error("Failed to mark chat session as ended",
+			logger.String("session_id", sessionID),
+			logger.Error(err))
+	}
+}
+
+// storeTurn adds a turn to a session's stored transcript, tagged with the current chat turn
+func (s *Service) storeTurn(sessionID, role, text, itemID string) {
+	text = strings.TrimSpace(text)
+	if !s.historyEnabled() || text == "" {
+		return
+	}
+
+	message := &sqlite.ChatMessageRecord{
+		SessionID: sessionID,
+		Role:      role,
+		Content:   text,
+		TurnID:    s.CurrentTurnID(sessionID),
+		ItemID:    itemID,
+		CreatedAt: time.Now().UTC(),
+	}
+	if err := s.historyStorage.AddMessage(message); err != nil {
+		s.logger.Error("Failed to store chat message",
+			logger.String("session_id", sessionID),
+			logger.String("role", role),
+			logger.Error(err))
+	}
+}
+
+// GetSessionHistory returns a stored session with its transcript, or nil if it doesn't exist
+func (s *Service) GetSessionHistory(sessionID string) (*SessionHistory, error) {
+	if !s.historyEnabled() {
+		return nil, fmt.Errorf("chat history is not available")
+	}
+
+	record, err := s.historyStorage.GetSession(sessionID)
+	if err != nil || record == nil {
+		return nil, err
+	}
+
+	messages, err := s.historyStorage.GetMessages(sessionID, maxHistoryMessages)
+	if err != nil {
+		return nil, err
+	}
+
+	return &SessionHistory{
+		Session:  s.storedSession(record),
+		Messages: messages,
+	}, nil
+}
+
+// ListSessions returns stored sessions, newest first, including those of previous runs.
+// An empty client ID lists the sessions of all clients.
+func (s *Service) ListSessions(clientID string, limit, offset int) ([]*StoredSession, error) {
+	if !s.historyEnabled() {
+		return nil, fmt.Errorf("chat history is not available")
+	}
+
+	records, err := s.historyStorage.ListSessions(clientID, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+
+	sessions := make([]*StoredSession, 0, len(records))
+	for _, record := range records {
+		sessions = append(sessions, s.storedSession(record))
+	}
+	return sessions, nil
+}
+
+// storedSession marks a stored session active if it is still running
+func (s *Service) storedSession(record *sqlite.ChatSessionRecord) *StoredSession {
+	s.sessionsMu.RLock()
+	session, running := s.sessions[record.ID]
+	s.sessionsMu.RUnlock()
+
+	return &StoredSession{
+		ChatSessionRecord: record,
+		Active:            running && record.EndedAt == nil && s.realtimeClient.ValidateSession(session),
+	}
+}
+
+// pruneSessionHistory removes sessions older than the retention period
+func (s *Service) pruneSessionHistory() {
+	if !s.historyEnabled() {
+		return
+	}
+
+	cutoff := time.Now().AddDate(0, 0, -s.config.HistoryRetentionDays)
+	s.pruneRecordings(cutoff)
+
+	deleted, err := s.historyStorage.DeleteSessionsBefore(cutoff)
+	if err != nil {
+		s.logger.Error("Failed to prune chat history", logger.Error(err))
+	} else if deleted > 0 {
+		s.logger.Debug("Pruned old chat sessions", logger.Int64("deleted", deleted))
+	}
+}
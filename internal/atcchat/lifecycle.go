@@ -0,0 +1,169 @@
+package atcchat
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/yegors/co-atc/internal/websocket"
+	"github.com/yegors/co-atc/pkg/logger"
+)
+
+// ErrTooManySessions is returned when a session is requested while max_sessions are running
+var ErrTooManySessions = errors.New("too many active ATC chat sessions")
+
+const (
+	// lifecycleInterval is how often sessions are checked for expiry and idleness
+	lifecycleInterval = 15 * time.Second
+
+	// refreshBeforeExpiry is how long before its OpenAI credentials expire a session is refreshed
+	refreshBeforeExpiry = 30 * time.Second
+)
+
+// Session status changes broadcast to WebSocket clients
+const (
+	sessionStatusCreated   = "created"
+	sessionStatusRefreshed = "refreshed"
+	sessionStatusEnded     = "ended"
+)
+
+// sessionLifecycleTask refreshes sessions nearing expiry, ends idle sessions and removes
+// expired ones
+func (s *Service) sessionLifecycleTask() {
+	defer s.wg.Done()
+
+	ticker := time.NewTicker(lifecycleInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-s.ctx.Done():
+			return
+		case <-ticker.C:
+			s.endIdleSessions()
+			s.refreshExpiringSessions()
+			s.cleanupExpiredSessions()
+		}
+	}
+}
+
+// Touch records user activity in a session, keeping it from being ended as idle
+func (s *Service) Touch(sessionID string) {
+	now := time.Now().UTC()
+	s.sessionsMu.Lock()
+	defer s.sessionsMu.Unlock()
+
+	if session, exists := s.sessions[sessionID]; exists {
+		session.LastActivity = now
+	}
+}
+
+// endIdleSessions ends sessions without user activity for longer than the idle timeout.
+// Context refreshes aren't activity, so a forgotten browser tab doesn't keep a session open.
+func (s *Service) endIdleSessions() {
+	idleTimeout := time.Duration(s.config.IdleTimeoutMinutes) * time.Minute
+	cutoff := time.Now().UTC().Add(-idleTimeout)
+
+	s.sessionsMu.RLock()
+	var idle []string
+	for sessionID, session := range s.sessions {
+		if session.LastActivity.Before(cutoff) {
+			idle = append(idle, sessionID)
+		}
+	}
+	s.sessionsMu.RUnlock()
+
+	for _, sessionID := range idle {
+		s.logger.Info("Ending idle ATC chat session",
+			logger.String("session_id", sessionID),
+			logger.Int("idle_timeout_minutes", s.config.IdleTimeoutMinutes))
+		if err := s.endSession(s.ctx, sessionID, endReasonIdle); err != nil {
+			s.logger.Debug("Idle session already ended",
+				logger.String("session_id", sessionID),
+				logger.Error(err))
+		}
+	}
+}
+
+// refreshExpiringSessions replaces the OpenAI session of sessions whose credentials expire
+// within refreshBeforeExpiry. The chat session keeps its ID, so the browser and the stored
+// history aren't affected; a relay connected on the old credentials stays connected.
+func (s *Service) refreshExpiringSessions() {
+	s.sessionsMu.RLock()
+	var expiring []*ChatSession
+	for _, session := range s.sessions {
+		if session.Active && s.realtimeClient.IsSessionExpiringSoon(session, refreshBeforeExpiry) {
+			expiring = append(expiring, session)
+		}
+	}
+	s.sessionsMu.RUnlock()
+
+	for _, session := range expiring {
+		if err := s.refreshSession(session); err != nil {
+			s.logger.Error("Failed to refresh ATC chat session",
+				logger.String("session_id", session.ID),
+				logger.Error(err))
+		}
+	}
+}
+
+// refreshSession creates a replacement OpenAI session with the session's persona and
+// current instructions, and moves the session onto it
+func (s *Service) refreshSession(session *ChatSession) error {
+	persona := s.SessionPersona(session.ID)
+	systemPrompt, err := s.GenerateSystemPrompt(session.ID)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(s.ctx, 30*time.Second)
+	defer cancel()
+
+	replacement, err := s.realtimeClient.RefreshSession(ctx, session, systemPrompt, s.personaSessionConfig(persona))
+	if err != nil {
+		return err
+	}
+
+	s.sessionsMu.Lock()
+	if _, exists := s.sessions[session.ID]; !exists {
+		s.sessionsMu.Unlock()
+		return fmt.Errorf("session ended during refresh: %s", session.ID)
+	}
+	session.OpenAISessionID = replacement.OpenAISessionID
+	session.ClientSecret = replacement.ClientSecret
+	session.ExpiresAt = replacement.ExpiresAt
+	s.sessionsMu.Unlock()
+
+	s.logger.Info("Refreshed ATC chat session",
+		logger.String("session_id", session.ID),
+		logger.String("openai_session_id", replacement.OpenAISessionID),
+		logger.Time("expires_at", replacement.ExpiresAt))
+
+	s.broadcastSessionStatus(session, sessionStatusRefreshed, "")
+	return nil
+}
+
+// broadcastSessionStatus tells WebSocket clients that a session was created, refreshed or ended
+func (s *Service) broadcastSessionStatus(session *ChatSession, status, reason string) {
+	if s.wsServer == nil {
+		return
+	}
+
+	data := map[string]interface{}{
+		"session_id":      session.ID,
+		"status":          status,
+		"persona":         session.Persona,
+		"expires_at":      session.ExpiresAt,
+		"active_sessions": s.GetSessionCount(),
+		"timestamp":       time.Now().UTC(),
+	}
+	if reason != "" {
+		data["reason"] = reason
+	}
+
+	s.wsServer.Broadcast(&websocket.Message{
+		Type: "atc_chat_session",
+		Data: data,
+	})
+}
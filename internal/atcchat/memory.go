@@ -0,0 +1,378 @@
+package atcchat
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/yegors/co-atc/internal/adsb"
+	"github.com/yegors/co-atc/internal/storage/sqlite"
+	"github.com/yegors/co-atc/pkg/logger"
+)
+
+// Limits on what is remembered about a session
+const (
+	maxRememberedQuestions = 3
+	maxRememberedTopics    = 5
+	maxRememberedAircraft  = 8
+	maxQuestionLength      = 120
+)
+
+// sessionMemory collects what is said in a session, to summarise it when the session ends
+type sessionMemory struct {
+	clientID  string
+	startedAt time.Time
+	previous  *sqlite.ChatSessionSummary // Summary of the client's previous session, if any
+	questions []string                   // User turns, oldest first
+	turns     int
+	topics    map[string]int // Topic -> turn of first mention
+	aircraft  map[string]int // Callsign -> turn of first mention
+}
+
+// chatTopics maps a topic to the words that indicate it. Matching is on lowercase text.
+var chatTopics = []struct {
+	name     string
+	keywords []string
+}{
+	{"weather", []string{"weather", "wind", "metar", "visibility", "ceiling", "temperature", "altimeter", "gust", "rain", "snow", "fog"}},
+	{"runways", []string{"runway", "in use", "active runway"}},
+	{"arrivals", []string{"arrival", "arriving", "approach", "landing", "final", "inbound", "ils"}},
+	{"departures", []string{"departure", "departing", "takeoff", "take off", "outbound", "climb"}},
+	{"ground movements", []string{"taxi", "gate", "pushback", "push back", "holding short", "apron"}},
+	{"traffic", []string{"traffic", "separation", "conflict", "busy", "how many"}},
+	{"radio communications", []string{"frequency", "tower said", "controller said", "transmission", "readback", "clearance"}},
+	{"holding", []string{"holding", "hold pattern", "orbit"}},
+	{"emergencies", []string{"emergency", "mayday", "pan pan", "7700", "7600", "7500"}},
+}
+
+// icaoCallsignPattern matches callsigns written the way ADS-B reports them, e.g. ACA123
+var icaoCallsignPattern = regexp.MustCompile(`\b([A-Z]{3})[0-9]{1,4}[A-Z]{0,2}\b`)
+
+// notAirlineCodes are three letter prefixes of written aviation terms that look like callsigns
+var notAirlineCodes = map[string]bool{
+	"RWY": true, "ILS": true, "VOR": true, "NDB": true, "DME": true, "GPS": true,
+	"RNP": true, "RNV": true, "QNH": true, "ATC": true, "TWR": true, "APP": true,
+}
+
+// nonAlphanumeric is stripped from text and callsigns before matching
+var nonAlphanumeric = regexp.MustCompile(`[^a-z0-9]+`)
+
+// memoryEnabled reports whether sessions are summarised and remembered
+func (s *Service) memoryEnabled() bool {
+	return s.config.SessionMemory && s.summaryStorage != nil
+}
+
+// startSessionMemory starts collecting a session's conversation and loads the client's
+// previous session summary for its instructions
+func (s *Service) startSessionMemory(sessionID, clientID string) {
+	if !s.memoryEnabled() || clientID == "" {
+		return
+	}
+
+	memory := &sessionMemory{
+		clientID:  clientID,
+		startedAt: time.Now().UTC(),
+		topics:    make(map[string]int),
+		aircraft:  make(map[string]int),
+	}
+
+	previous, err := s.summaryStorage.GetLatestSummary(clientID)
+	if err != nil {
+		s.logger.Error("Failed to load previous chat session summary",
+			logger.String("client_id", clientID),
+			logger.Error(err))
+	} else if previous != nil && time.Since(previous.EndedAt) <= s.memoryMaxAge() {
+		memory.previous = previous
+	}
+
+	s.memoriesMu.Lock()
+	s.memories[sessionID] = memory
+	s.memoriesMu.Unlock()
+}
+
+// RecordUserTurn adds what the user said to the session's transcript and memory. The item
+// ID is the OpenAI conversation item holding the audio. The aircraft lookup runs in the
+// background so the realtime audio isn't held up.
+func (s *Service) RecordUserTurn(sessionID, text, itemID string) {
+	s.storeTurn(sessionID, "user", text, itemID)
+	go s.recordTurn(sessionID, text, true)
+}
+
+// RecordAssistantTurn adds the assistant's answer to the session's transcript and memory.
+// Answers contribute aircraft and topics but are not remembered as questions.
+func (s *Service) RecordAssistantTurn(sessionID, text, itemID string) {
+	s.storeTurn(sessionID, "assistant", text, itemID)
+	go s.recordTurn(sessionID, text, false)
+}
+
+// recordTurn extracts topics and aircraft from a turn of the conversation
+func (s *Service) recordTurn(sessionID, text string, fromUser bool) {
+	text = strings.TrimSpace(text)
+	if text == "" {
+		return
+	}
+
+	s.memoriesMu.Lock()
+	_, exists := s.memories[sessionID]
+	s.memoriesMu.Unlock()
+	if !exists {
+		return
+	}
+
+	// Look up the aircraft in the area outside the lock, it renders the full template context
+	callsigns := s.referencedCallsigns(text)
+
+	s.memoriesMu.Lock()
+	defer s.memoriesMu.Unlock()
+
+	memory, exists := s.memories[sessionID]
+	if !exists {
+		return
+	}
+
+	if fromUser {
+		memory.turns++
+		question := text
+		if len(question) > maxQuestionLength {
+			question = strings.TrimSpace(question[:maxQuestionLength]) + "…"
+		}
+		memory.questions = append(memory.questions, question)
+		if len(memory.questions) > maxRememberedQuestions {
+			memory.questions = memory.questions[len(memory.questions)-maxRememberedQuestions:]
+		}
+	}
+
+	lower := strings.ToLower(text)
+	for _, topic := range chatTopics {
+		if _, seen := memory.topics[topic.name]; seen {
+			continue
+		}
+		for _, keyword := range topic.keywords {
+			if strings.Contains(lower, keyword) {
+				memory.topics[topic.name] = memory.turns
+				break
+			}
+		}
+	}
+
+	for _, callsign := range callsigns {
+		if _, seen := memory.aircraft[callsign]; !seen {
+			memory.aircraft[callsign] = memory.turns
+		}
+	}
+}
+
+// referencedCallsigns returns the callsigns mentioned in text, either as written by ADS-B
+// (ACA123) or spoken with the airline name (Air Canada 123) for aircraft currently in the area
+func (s *Service) referencedCallsigns(text string) []string {
+	found := make(map[string]bool)
+	for _, match := range icaoCallsignPattern.FindAllStringSubmatch(text, -1) {
+		if !notAirlineCodes[match[1]] {
+			found[s.templatingService.CanonicalCallsign(match[0])] = true
+		}
+	}
+
+	context, err := s.templatingService.GetTemplateContext(ATCChatFormattingOptions())
+	if err == nil && context != nil {
+		normalized := nonAlphanumeric.ReplaceAllString(strings.ToLower(text), "")
+		for _, aircraft := range context.Aircraft {
+			flight := adsb.NormalizeCallsign(aircraft.Flight)
+			if len(flight) < 4 || found[flight] {
+				continue
+			}
+			if strings.Contains(normalized, strings.ToLower(flight)) {
+				found[flight] = true
+				continue
+			}
+
+			// Spoken form: airline name followed by the flight number
+			if aircraft.Airline != "" && len(flight) > 3 {
+				spoken := nonAlphanumeric.ReplaceAllString(strings.ToLower(aircraft.Airline+flight[3:]), "")
+				if strings.Contains(normalized, spoken) {
+					found[flight] = true
+				}
+			}
+		}
+	}
+
+	callsigns := make([]string, 0, len(found))
+	for callsign := range found {
+		callsigns = append(callsigns, callsign)
+	}
+	return callsigns
+}
+
+// finishSessionMemory summarises a session and stores the summary for the client's next session.
+// Sessions in which the user never spoke are not stored, so they don't replace a useful summary.
+func (s *Service) finishSessionMemory(sessionID string) {
+	s.memoriesMu.Lock()
+	memory, exists := s.memories[sessionID]
+	delete(s.memories, sessionID)
+	s.memoriesMu.Unlock()
+
+	if !exists || memory.turns == 0 {
+		return
+	}
+
+	summary := &sqlite.ChatSessionSummary{
+		ClientID:  memory.clientID,
+		SessionID: sessionID,
+		StartedAt: memory.startedAt,
+		EndedAt:   time.Now().UTC(),
+		Turns:     memory.turns,
+		Topics:    firstMentioned(memory.topics, maxRememberedTopics),
+		Aircraft:  firstMentioned(memory.aircraft, maxRememberedAircraft),
+		Questions: memory.questions,
+	}
+
+	if err := s.summaryStorage.SaveSummary(summary); err != nil {
+		s.logger.Error("Failed to save chat session summary",
+			logger.String("session_id", sessionID),
+			logger.Error(err))
+		return
+	}
+
+	s.logger.Info("Saved chat session summary",
+		logger.String("session_id", sessionID),
+		logger.String("client_id", memory.clientID),
+		logger.Int("turns", summary.Turns),
+		logger.Int("topics", len(summary.Topics)),
+		logger.Int("aircraft", len(summary.Aircraft)))
+}
+
+// withPreviousSession appends the summary of the client's previous session to a prompt
+func (s *Service) withPreviousSession(sessionID, prompt string) string {
+	s.memoriesMu.Lock()
+	memory, exists := s.memories[sessionID]
+	var previous *sqlite.ChatSessionSummary
+	if exists {
+		previous = memory.previous
+	}
+	s.memoriesMu.Unlock()
+
+	if previous == nil {
+		return prompt
+	}
+
+	return prompt + "\n\n" + FormatPreviousSession(previous, time.Now())
+}
+
+// FormatPreviousSession renders a session summary as a section of the system prompt
+func FormatPreviousSession(summary *sqlite.ChatSessionSummary, now time.Time) string {
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "PREVIOUS SESSION WITH THIS USER (ended %s ago, %d questions):\n",
+		formatAge(now.Sub(summary.EndedAt)), summary.Turns)
+	if len(summary.Topics) > 0 {
+		fmt.Fprintf(&b, "- Topics discussed: %s\n", strings.Join(summary.Topics, ", "))
+	}
+	if len(summary.Aircraft) > 0 {
+		fmt.Fprintf(&b, "- Aircraft referenced: %s\n", strings.Join(summary.Aircraft, ", "))
+	}
+	if len(summary.Questions) > 0 {
+		b.WriteString("- Last questions:\n")
+		for _, question := range summary.Questions {
+			fmt.Fprintf(&b, "  - %q\n", question)
+		}
+	}
+	b.WriteString("Use this only for continuity, e.g. when the user follows up on an earlier question. " +
+		"The current airspace data takes precedence, and aircraft from the previous session may have left the area.")
+
+	return b.String()
+}
+
+// GetPreviousSession returns the most recent remembered session of a client, or nil
+func (s *Service) GetPreviousSession(clientID string) (*sqlite.ChatSessionSummary, error) {
+	if !s.memoryEnabled() {
+		return nil, nil
+	}
+
+	summary, err := s.summaryStorage.GetLatestSummary(clientID)
+	if err != nil || summary == nil || time.Since(summary.EndedAt) > s.memoryMaxAge() {
+		return nil, err
+	}
+	return summary, nil
+}
+
+// ForgetClient deletes everything remembered about a client's sessions, including their
+// transcripts and recordings
+func (s *Service) ForgetClient(clientID string) error {
+	if s.historyEnabled() && clientID != "" {
+		sessions, err := s.historyStorage.ListSessions(clientID, -1, 0)
+		if err != nil {
+			return err
+		}
+		for _, session := range sessions {
+			s.deleteRecordings(session.ID)
+		}
+		if err := s.historyStorage.DeleteClient(clientID); err != nil {
+			return err
+		}
+	}
+
+	if !s.memoryEnabled() {
+		return nil
+	}
+
+	// Sessions in progress start over without the previous summary
+	s.memoriesMu.Lock()
+	for _, memory := range s.memories {
+		if memory.clientID == clientID {
+			memory.previous = nil
+		}
+	}
+	s.memoriesMu.Unlock()
+
+	return s.summaryStorage.DeleteSummaries(clientID)
+}
+
+// pruneSessionMemory removes summaries too old to be used
+func (s *Service) pruneSessionMemory() {
+	if !s.memoryEnabled() {
+		return
+	}
+
+	deleted, err := s.summaryStorage.DeleteSummariesBefore(time.Now().Add(-s.memoryMaxAge()))
+	if err != nil {
+		s.logger.Error("Failed to prune chat session summaries", logger.Error(err))
+	} else if deleted > 0 {
+		s.logger.Debug("Pruned old chat session summaries", logger.Int64("deleted", deleted))
+	}
+}
+
+// memoryMaxAge returns how long a session summary is used for
+func (s *Service) memoryMaxAge() time.Duration {
+	return time.Duration(s.config.SessionMemoryMaxAgeHours) * time.Hour
+}
+
+// firstMentioned returns up to limit keys ordered by the turn they were first mentioned in
+func firstMentioned(mentions map[string]int, limit int) []string {
+	keys := make([]string, 0, len(mentions))
+	for key := range mentions {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if mentions[keys[i]] != mentions[keys[j]] {
+			return mentions[keys[i]] < mentions[keys[j]]
+		}
+		return keys[i] < keys[j]
+	})
+	if len(keys) > limit {
+		keys = keys[:limit]
+	}
+	return keys
+}
+
+// formatAge formats a duration as a rough age, e.g. "5 minutes" or "2 days"
+func formatAge(d time.Duration) string {
+	switch {
+	case d < time.Hour:
+		return fmt.Sprintf("%d minutes", int(d.Minutes()))
+	case d < 48*time.Hour:
+		return fmt.Sprintf("%d hours", int(d.Hours()))
+	default:
+		return fmt.Sprintf("%d days", int(d.Hours()/24))
+	}
+}
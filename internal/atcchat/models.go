@@ -11,11 +11,16 @@ import (
 type ChatSession struct {
 	ID              string    `json:"id"`
 	OpenAISessionID string    `json:"openai_session_id"`
-	ClientSecret    string    `json:"client_secret"`
+	ClientSecret    string    `json:"-"` // Ephemeral OpenAI key, only used by the server-side relay
 	CreatedAt       time.Time `json:"created_at"`
 	ExpiresAt       time.Time `json:"expires_at"`
 	Active          bool      `json:"active"`
 	LastActivity    time.Time `json:"last_activity"`
+	CurrentTurnID   string    `json:"current_turn_id,omitempty"` // Correlation ID of the latest chat turn
+	ClientID        string    `json:"client_id,omitempty"`       // Browser identity, persistent across sessions
+	Persona         string    `json:"persona,omitempty"`         // Persona the session was created with (empty = [atc_chat] settings)
+
+	LastContextUpdate time.Time `json:"last_context_update"` // When airspace data was last pushed to the session
 }
 
 // ChatMessage represents a message in the chat session
@@ -117,4 +122,6 @@ type SessionStatus struct {
 	LastActivity time.Time `json:"last_activity"`
 	ExpiresAt    time.Time `json:"expires_at"`
 	Error        string    `json:"error,omitempty"`
+
+	LastContextUpdate time.Time `json:"last_context_update"` // When airspace data was last pushed to the session
 }
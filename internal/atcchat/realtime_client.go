@@ -74,33 +74,39 @@ type SessionResponse struct {
 
 // CreateSession creates a new realtime session with OpenAI
 func (rc *RealtimeClient) CreateSession(ctx context.Context, systemPrompt string) (*ChatSession, error) {
+	return rc.CreateSessionWithConfig(ctx, systemPrompt, rc.config)
+}
+
+// CreateSessionWithConfig creates a new realtime session with OpenAI using the given
+// session settings instead of the client's, e.g. a persona's model and voice
+func (rc *RealtimeClient) CreateSessionWithConfig(ctx context.Context, systemPrompt string, config SessionConfig) (*ChatSession, error) {
 	// Check if OpenAI API key is provided - fail fast if missing
 	if rc.apiKey == "" {
 		return nil, fmt.Errorf("OpenAI API key is required for ATC Chat sessions")
 	}
 
 	rc.logger.Info("Creating new OpenAI realtime session",
-		logger.String("model", rc.config.Model),
-		logger.String("voice", rc.config.Voice))
+		logger.String("model", config.Model),
+		logger.String("voice", config.Voice))
 
 	// Create the session request with required parameters
 	sessionReq := SessionRequest{
-		Model:             rc.config.Model,
+		Model:             config.Model,
 		Instructions:      systemPrompt,
-		Voice:             rc.config.Voice,
+		Voice:             config.Voice,
 		Modalities:        []string{"text", "audio"},
-		InputAudioFormat:  rc.config.InputAudioFormat,
-		OutputAudioFormat: rc.config.OutputAudioFormat,
+		InputAudioFormat:  config.InputAudioFormat,
+		OutputAudioFormat: config.OutputAudioFormat,
 	}
 
 	// Add optional parameters if configured
-	if rc.config.MaxResponseTokens > 0 {
-		sessionReq.MaxResponseTokens = rc.config.MaxResponseTokens
+	if config.MaxResponseTokens > 0 {
+		sessionReq.MaxResponseTokens = config.MaxResponseTokens
 	}
 
 	// OpenAI realtime API requires temperature >= 0.6
-	if rc.config.Temperature >= 0.6 {
-		sessionReq.Temperature = rc.config.Temperature
+	if config.Temperature >= 0.6 {
+		sessionReq.Temperature = config.Temperature
 	} else {
 		// Use default temperature of 0.8 if not configured or below minimum
 		sessionReq.Temperature = 0.8
@@ -112,17 +118,17 @@ func (rc *RealtimeClient) CreateSession(ctx context.Context, systemPrompt string
 	// Add turn detection based on configuration
 	// If TurnDetectionType is empty or "none", omit turn_detection entirely (turn off)
 	// Otherwise, configure with the specified type
-	if rc.config.TurnDetectionType != "" && rc.config.TurnDetectionType != "none" {
+	if config.TurnDetectionType != "" && config.TurnDetectionType != "none" {
 		turnDetection := &TurnDetectionConfig{
-			Type: rc.config.TurnDetectionType,
+			Type: config.TurnDetectionType,
 		}
 
-		if rc.config.VADThreshold > 0 {
-			turnDetection.Threshold = &rc.config.VADThreshold
+		if config.VADThreshold > 0 {
+			turnDetection.Threshold = &config.VADThreshold
 		}
 
-		if rc.config.SilenceDurationMs > 0 {
-			turnDetection.SilenceDurationMs = &rc.config.SilenceDurationMs
+		if config.SilenceDurationMs > 0 {
+			turnDetection.SilenceDurationMs = &config.SilenceDurationMs
 		}
 
 		sessionReq.TurnDetection = turnDetection
@@ -227,20 +233,6 @@ func (rc *RealtimeClient) CreateSession(ctx context.Context, systemPrompt string
 	return chatSession, nil
 }
 
-// UpdateSessionInstructions updates the system instructions for an existing session
-func (rc *RealtimeClient) UpdateSessionInstructions(ctx context.Context, sessionID string, instructions string) error {
-	rc.logger.Debug("Updating session instructions",
-		logger.String("session_id", sessionID))
-
-	// Note: This would need to be implemented when the realtime API supports instruction updates
-	// For now, we'll log that this functionality is not yet available
-
-	rc.logger.Warn("Session instruction updates not yet implemented in realtime API",
-		logger.String("session_id", sessionID))
-
-	return nil
-}
-
 // EndSession terminates a realtime session
 func (rc *RealtimeClient) EndSession(ctx context.Context, sessionID string) error {
 	rc.logger.Info("Ending realtime session",
@@ -279,13 +271,13 @@ func (rc *RealtimeClient) ValidateSession(session *ChatSession) bool {
 	return true
 }
 
-// RefreshSession creates a new session to replace an expiring one
-func (rc *RealtimeClient) RefreshSession(ctx context.Context, oldSession *ChatSession, systemPrompt string) (*ChatSession, error) {
+// RefreshSession creates a new session with the given settings to replace an expiring one
+func (rc *RealtimeClient) RefreshSession(ctx context.Context, oldSession *ChatSession, systemPrompt string, config SessionConfig) (*ChatSession, error) {
 	rc.logger.Info("Refreshing realtime session",
 		logger.String("old_session_id", oldSession.ID))
 
 	// Create a new session
-	newSession, err := rc.CreateSession(ctx, systemPrompt)
+	newSession, err := rc.CreateSessionWithConfig(ctx, systemPrompt, config)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create replacement session: %w", err)
 	}
@@ -320,6 +312,8 @@ func (rc *RealtimeClient) GetSessionStatus(session *ChatSession) SessionStatus {
 		Connected:    rc.ValidateSession(session),
 		LastActivity: session.LastActivity,
 		ExpiresAt:    session.ExpiresAt,
+
+		LastContextUpdate: session.LastContextUpdate,
 	}
 
 	if !status.Connected {
@@ -0,0 +1,218 @@
+package atcchat
+
+import (
+	"encoding/base64"
+	"encoding/binary"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/yegors/co-atc/pkg/logger"
+)
+
+// wavHeaderSize is the size of a canonical PCM WAV header
+const wavHeaderSize = 44
+
+// SessionRecording records both sides of a relayed chat session to WAV files. A nil
+// recording records nothing, so callers don't need to check whether recording is enabled.
+type SessionRecording struct {
+	user      *wavWriter
+	assistant *wavWriter
+	logger    *logger.Logger
+}
+
+// StartRecording starts recording a relayed connection of a session, or returns nil if
+// recording is disabled. Each connection gets its own files, so a reconnect doesn't
+// overwrite what was already recorded.
+func (s *Service) StartRecording(sessionID string) *SessionRecording {
+	if !s.config.RecordAudio {
+		return nil
+	}
+	if s.config.InputAudioFormat != "pcm16" || s.config.OutputAudioFormat != "pcm16" {
+		s.logger.Warn("Chat audio recording needs pcm16 audio, not recording",
+			logger.String("session_id", sessionID),
+			logger.String("input_audio_format", s.config.InputAudioFormat),
+			logger.String("output_audio_format", s.config.OutputAudioFormat))
+		return nil
+	}
+
+	dir := s.recordingDir(sessionID)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		s.logger.Error("Failed to create chat recording directory",
+			logger.String("dir", dir),
+			logger.Error(err))
+		return nil
+	}
+
+	prefix := time.Now().UTC().Format("20060102T150405Z")
+	user, err := newWAVWriter(filepath.Join(dir, prefix+"-user.wav"), s.config.SampleRate, s.config.Channels)
+	if err != nil {
+		s.logger.Error("Failed to create chat recording", logger.Error(err))
+		return nil
+	}
+	assistant, err := newWAVWriter(filepath.Join(dir, prefix+"-assistant.wav"), s.config.SampleRate, s.config.Channels)
+	if err != nil {
+		user.Close()
+		s.logger.Error("Failed to create chat recording", logger.Error(err))
+		return nil
+	}
+
+	s.logger.Info("Recording chat session audio",
+		logger.String("session_id", sessionID),
+		logger.String("dir", dir))
+
+	return &SessionRecording{
+		user:      user,
+		assistant: assistant,
+		logger:    s.logger,
+	}
+}
+
+// recordingDir returns the directory a session's recordings are written to
+func (s *Service) recordingDir(sessionID string) string {
+	return filepath.Join(s.config.RecordingsDir, filepath.Base(sessionID))
+}
+
+// deleteRecordings removes a session's recordings
+func (s *Service) deleteRecordings(sessionID string) {
+	if s.config.RecordingsDir == "" || sessionID == "" {
+		return
+	}
+	if err := os.RemoveAll(s.recordingDir(sessionID)); err != nil {
+		s.logger.Error("Failed to delete chat recordings",
+			logger.String("session_id", sessionID),
+			logger.Error(err))
+	}
+}
+
+// pruneRecordings removes the recordings of sessions last written to before the cutoff
+func (s *Service) pruneRecordings(cutoff time.Time) {
+	if s.config.RecordingsDir == "" {
+		return
+	}
+
+	entries, err := os.ReadDir(s.config.RecordingsDir)
+	if err != nil {
+		return // Nothing recorded yet
+	}
+	for _, entry := range entries {
+		info, err := entry.Info()
+		if err != nil || !entry.IsDir() || !info.ModTime().Before(cutoff) {
+			continue
+		}
+		s.deleteRecordings(entry.Name())
+	}
+}
+
+// UserAudio records base64 encoded audio the user sent
+func (r *SessionRecording) UserAudio(encoded string) {
+	if r != nil {
+		r.write(r.user, encoded)
+	}
+}
+
+// AssistantAudio records a base64 encoded audio delta of the assistant's answer
+func (r *SessionRecording) AssistantAudio(encoded string) {
+	if r != nil {
+		r.write(r.assistant, encoded)
+	}
+}
+
+// write decodes audio and appends it to a recording
+func (r *SessionRecording) write(w *wavWriter, encoded string) {
+	audio, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		r.logger.Debug("Skipping undecodable chat audio", logger.Error(err))
+		return
+	}
+	if err := w.Write(audio); err != nil {
+		r.logger.Error("Failed to write chat recording", logger.Error(err))
+	}
+}
+
+// Close finishes both recordings
+func (r *SessionRecording) Close() {
+	if r == nil {
+		return
+	}
+	for _, w := range []*wavWriter{r.user, r.assistant} {
+		if err := w.Close(); err != nil {
+			r.logger.Error("Failed to finish chat recording", logger.Error(err))
+		}
+	}
+}
+
+// wavWriter writes PCM16 audio to a WAV file, filling in the lengths on Close
+type wavWriter struct {
+	mu         sync.Mutex
+	file       *os.File
+	dataBytes  uint32
+	sampleRate int
+	channels   int
+}
+
+// newWAVWriter creates a WAV file and writes its header
+func newWAVWriter(path string, sampleRate, channels int) (*wavWriter, error) {
+	file, err := os.Create(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create %s: %w", path, err)
+	}
+
+	w := &wavWriter{file: file, sampleRate: sampleRate, channels: max(channels, 1)}
+	if _, err := file.Write(w.header()); err != nil {
+		file.Close()
+		return nil, fmt.Errorf("failed to write WAV header: %w", err)
+	}
+	return w, nil
+}
+
+// Write appends audio
+func (w *wavWriter) Write(audio []byte) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.file == nil {
+		return nil
+	}
+	n, err := w.file.Write(audio)
+	w.dataBytes += uint32(n)
+	return err
+}
+
+// Close writes the final header and closes the file
+func (w *wavWriter) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.file == nil {
+		return nil
+	}
+	_, err := w.file.WriteAt(w.header(), 0)
+	if closeErr := w.file.Close(); err == nil {
+		err = closeErr
+	}
+	w.file = nil
+	return err
+}
+
+// header returns the WAV header for the audio written so far
+func (w *wavWriter) header() []byte {
+	blockAlign := w.channels * 2
+	header := make([]byte, wavHeaderSize)
+	copy(header[0:], "RIFF")
+	binary.LittleEndian.PutUint32(header[4:], 36+w.dataBytes)
+	copy(header[8:], "WAVE")
+	copy(header[12:], "fmt ")
+	binary.LittleEndian.PutUint32(header[16:], 16)
+	binary.LittleEndian.PutUint16(header[20:], 1) // PCM
+	binary.LittleEndian.PutUint16(header[22:], uint16(w.channels))
+	binary.LittleEndian.PutUint32(header[24:], uint32(w.sampleRate))
+	binary.LittleEndian.PutUint32(header[28:], uint32(w.sampleRate*blockAlign))
+	binary.LittleEndian.PutUint16(header[32:], uint16(blockAlign))
+	binary.LittleEndian.PutUint16(header[34:], 16)
+	copy(header[36:], "data")
+	binary.LittleEndian.PutUint32(header[40:], w.dataBytes)
+	return header
+}
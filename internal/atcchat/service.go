@@ -3,12 +3,15 @@ package atcchat
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"sync"
 	"time"
 
 	"github.com/yegors/co-atc/internal/config"
+	"github.com/yegors/co-atc/internal/storage/sqlite"
 	"github.com/yegors/co-atc/internal/templating"
+	"github.com/yegors/co-atc/internal/websocket"
 	"github.com/yegors/co-atc/pkg/logger"
 )
 
@@ -16,6 +19,8 @@ import (
 type TemplatingService interface {
 	RenderATCChatTemplate(templatePath string) (string, error)
 	GetTemplateContext(opts FormattingOptions) (*TemplateContext, error)
+	CanonicalCallsign(callsign string) string
+	AircraftHex(callsign string) string
 }
 
 // Import templating types
@@ -27,12 +32,24 @@ func ATCChatFormattingOptions() FormattingOptions {
 	return templating.ATCChatFormattingOptions()
 }
 
+// ErrUnknownPersona is returned when a session is requested with a persona that isn't configured
+var ErrUnknownPersona = errors.New("unknown ATC chat persona")
+
 // Service manages ATC chat sessions and interactions
 type Service struct {
-	realtimeClient    *RealtimeClient
-	templatingService TemplatingService
-	config            *config.ATCChatConfig
-	logger            *logger.Logger
+	realtimeClient       *RealtimeClient
+	sessionConfig        SessionConfig
+	templatingService    TemplatingService
+	summaryStorage       *sqlite.ChatSummaryStorage
+	historyStorage       *sqlite.ChatHistoryStorage
+	transcriptionStorage *sqlite.TranscriptionStorage
+	wsServer             *websocket.Server
+	config               *config.ATCChatConfig
+	logger               *logger.Logger
+
+	// Conversation of each session, summarised for the client's next session
+	memories   map[string]*sessionMemory
+	memoriesMu sync.Mutex
 
 	// Session management
 	sessions   map[string]*ChatSession
@@ -51,6 +68,10 @@ type Service struct {
 // NewService creates a new ATC chat service
 func NewService(
 	templatingService TemplatingService,
+	summaryStorage *sqlite.ChatSummaryStorage,
+	historyStorage *sqlite.ChatHistoryStorage,
+	transcriptionStorage *sqlite.TranscriptionStorage,
+	wsServer *websocket.Server,
 	config *config.Config,
 	logger *logger.Logger,
 ) (*Service, error) {
@@ -83,15 +104,24 @@ func NewService(
 	ctx, cancel := context.WithCancel(context.Background())
 
 	service := &Service{
-		realtimeClient:    realtimeClient,
-		templatingService: templatingService,
-		config:            &config.ATCChat,
-		logger:            logger.Named("atc-chat-service"),
-		sessions:          make(map[string]*ChatSession),
-		wsConnections:     make(map[string]chan string),
-		ctx:               ctx,
-		cancel:            cancel,
-	}
+		realtimeClient:       realtimeClient,
+		sessionConfig:        sessionConfig,
+		templatingService:    templatingService,
+		summaryStorage:       summaryStorage,
+		historyStorage:       historyStorage,
+		transcriptionStorage: transcriptionStorage,
+		wsServer:             wsServer,
+		config:               &config.ATCChat,
+		logger:               logger.Named("atc-chat-service"),
+		sessions:             make(map[string]*ChatSession),
+		memories:             make(map[string]*sessionMemory),
+		wsConnections:        make(map[string]chan string),
+		ctx:                  ctx,
+		cancel:               cancel,
+	}
+
+	// Sessions still open in the history belong to a previous run of the server
+	service.closeStaleHistory()
 
 	// Start background tasks
 	service.startBackgroundTasks()
@@ -99,12 +129,25 @@ func NewService(
 	return service, nil
 }
 
-// CreateSession creates a new chat session
-func (s *Service) CreateSession(ctx context.Context) (*ChatSession, error) {
-	s.logger.Info("Creating new ATC chat session")
+// CreateSession creates a new chat session. The client ID identifies the browser across
+// sessions; if session memory is enabled, the summary of its previous session is added
+// to the new session's instructions. It may be empty. The persona selects the session's
+// prompt template, voice and model; an empty persona uses the default one.
+func (s *Service) CreateSession(ctx context.Context, clientID, personaName string) (*ChatSession, error) {
+	persona, ok := s.config.Persona(personaName)
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownPersona, personaName)
+	}
+
+	if s.config.MaxSessions > 0 && s.GetSessionCount() >= s.config.MaxSessions {
+		return nil, fmt.Errorf("%w: %d", ErrTooManySessions, s.config.MaxSessions)
+	}
+
+	s.logger.Info("Creating new ATC chat session",
+		logger.String("persona", persona.Name))
 
-	// Use templating service to render the ATC chat template
-	staticPrompt, err := s.templatingService.RenderATCChatTemplate(s.config.SystemPromptPath)
+	// Use templating service to render the persona's chat template
+	staticPrompt, err := s.templatingService.RenderATCChatTemplate(persona.SystemPromptPath)
 	if err != nil {
 		return nil, fmt.Errorf("failed to render ATC chat template: %w", err)
 	}
@@ -113,16 +156,22 @@ func (s *Service) CreateSession(ctx context.Context) (*ChatSession, error) {
 	s.logger.Info("Creating OpenAI session via REST API with static instructions",
 		logger.Int("prompt_length", len(staticPrompt)))
 
-	session, err := s.realtimeClient.CreateSession(ctx, staticPrompt)
+	session, err := s.realtimeClient.CreateSessionWithConfig(ctx, staticPrompt, s.personaSessionConfig(persona))
 	if err != nil {
 		return nil, fmt.Errorf("failed to create OpenAI session: %w", err)
 	}
 
 	// Store session
+	session.ClientID = clientID
+	session.Persona = persona.Name
 	s.sessionsMu.Lock()
 	s.sessions[session.ID] = session
 	s.sessionsMu.Unlock()
 
+	s.startSessionMemory(session.ID, clientID)
+	s.saveSessionHistory(session)
+	s.broadcastSessionStatus(session, sessionStatusCreated, "")
+
 	s.logger.Info("Successfully created ATC chat session with OpenAI session",
 		logger.String("session_id", session.ID),
 		logger.String("openai_session_id", session.OpenAISessionID),
@@ -131,6 +180,45 @@ func (s *Service) CreateSession(ctx context.Context) (*ChatSession, error) {
 	return session, nil
 }
 
+// personaSessionConfig returns the realtime session settings of a persona
+func (s *Service) personaSessionConfig(persona config.ChatPersonaConfig) SessionConfig {
+	sessionConfig := s.sessionConfig
+	sessionConfig.Model = persona.RealtimeModel
+	sessionConfig.Voice = persona.Voice
+	sessionConfig.Temperature = persona.Temperature
+	sessionConfig.MaxResponseTokens = persona.MaxResponseTokens
+	return sessionConfig
+}
+
+// SessionPersona returns the persona of a session, with unset fields filled from the
+// [atc_chat] settings. A persona removed from the configuration since the session was
+// created falls back to the default one.
+func (s *Service) SessionPersona(sessionID string) config.ChatPersonaConfig {
+	s.sessionsMu.RLock()
+	var name string
+	if session, exists := s.sessions[sessionID]; exists {
+		name = session.Persona
+	}
+	s.sessionsMu.RUnlock()
+
+	if persona, ok := s.config.Persona(name); ok {
+		return persona
+	}
+	persona, _ := s.config.Persona("")
+	return persona
+}
+
+// Personas returns the configured personas with unset fields filled from the [atc_chat]
+// settings, and the name of the default one
+func (s *Service) Personas() ([]config.ChatPersonaConfig, string) {
+	personas := make([]config.ChatPersonaConfig, 0, len(s.config.Personas))
+	for _, p := range s.config.Personas {
+		persona, _ := s.config.Persona(p.Name)
+		personas = append(personas, persona)
+	}
+	return personas, s.config.DefaultPersona
+}
+
 // GetSession retrieves a session by ID
 func (s *Service) GetSession(sessionID string) (*ChatSession, error) {
 	s.sessionsMu.RLock()
@@ -151,8 +239,14 @@ func (s *Service) GetSession(sessionID string) (*ChatSession, error) {
 
 // EndSession terminates a chat session
 func (s *Service) EndSession(ctx context.Context, sessionID string) error {
+	return s.endSession(ctx, sessionID, endReasonEnded)
+}
+
+// endSession terminates a chat session, recording why it ended
+func (s *Service) endSession(ctx context.Context, sessionID, reason string) error {
 	s.logger.Info("Ending ATC chat session",
-		logger.String("session_id", sessionID))
+		logger.String("session_id", sessionID),
+		logger.String("reason", reason))
 
 	s.sessionsMu.Lock()
 	session, exists := s.sessions[sessionID]
@@ -168,6 +262,9 @@ func (s *Service) EndSession(ctx context.Context, sessionID string) error {
 	// Unregister WebSocket connection to stop receiving updates
 	s.UnregisterWebSocketConnection(sessionID)
 
+	s.finishSessionMemory(sessionID)
+	s.endSessionHistory(sessionID, reason)
+
 	// End OpenAI session
 	if err := s.realtimeClient.EndSession(ctx, session.OpenAISessionID); err != nil {
 		s.logger.Error("Failed to end OpenAI session",
@@ -178,6 +275,7 @@ func (s *Service) EndSession(ctx context.Context, sessionID string) error {
 
 	// Mark session as inactive
 	session.Active = false
+	s.broadcastSessionStatus(session, sessionStatusEnded, reason)
 
 	s.logger.Info("Successfully ended ATC chat session",
 		logger.String("session_id", sessionID),
@@ -222,31 +320,64 @@ func (s *Service) ListActiveSessions() []*ChatSession {
 
 // UpdateSessionContext updates the system prompt for a session with fresh airspace data
 func (s *Service) UpdateSessionContext(ctx context.Context, sessionID string) error {
-	session, err := s.GetSession(sessionID)
-	if err != nil {
+	if _, err := s.GetSession(sessionID); err != nil {
 		return err
 	}
 
-	// Render updated system prompt using shared templating service
-	systemPrompt, err := s.templatingService.RenderATCChatTemplate(s.config.SystemPromptPath)
+	systemPrompt, err := s.GenerateSystemPrompt(sessionID)
 	if err != nil {
-		return fmt.Errorf("failed to render system prompt: %w", err)
+		return err
 	}
 
-	// Update session instructions
-	if err := s.realtimeClient.UpdateSessionInstructions(ctx, session.OpenAISessionID, systemPrompt); err != nil {
+	if err := s.pushInstructions(sessionID, systemPrompt, ""); err != nil {
 		return fmt.Errorf("failed to update session instructions: %w", err)
 	}
 
-	// Update last activity
-	session.LastActivity = time.Now().UTC()
-
 	s.logger.Debug("Updated session context",
 		logger.String("session_id", sessionID))
 
 	return nil
 }
 
+// pushInstructions sends new instructions to a session through its relay connection. The
+// event ID, if set, lets an error OpenAI reports for the update be matched to it.
+// Instructions can only be changed over the realtime connection, so a session without a
+// connected relay can't be updated.
+func (s *Service) pushInstructions(sessionID, instructions, eventID string) error {
+	sessionUpdate := map[string]interface{}{
+		"type": "session.update",
+		"session": map[string]interface{}{
+			"instructions": instructions,
+		},
+	}
+	if eventID != "" {
+		sessionUpdate["event_id"] = eventID
+	}
+
+	updateData, err := json.Marshal(sessionUpdate)
+	if err != nil {
+		return fmt.Errorf("failed to marshal session update: %w", err)
+	}
+
+	if !s.SendSessionUpdate(sessionID, string(updateData)) {
+		return fmt.Errorf("session %s has no relay connection", sessionID)
+	}
+
+	s.MarkContextUpdated(sessionID)
+	return nil
+}
+
+// MarkContextUpdated records that fresh airspace data was sent to a session
+func (s *Service) MarkContextUpdated(sessionID string) {
+	now := time.Now().UTC()
+	s.sessionsMu.Lock()
+	defer s.sessionsMu.Unlock()
+
+	if session, exists := s.sessions[sessionID]; exists {
+		session.LastContextUpdate = now
+	}
+}
+
 // GetAirspaceStatus returns current airspace status
 func (s *Service) GetAirspaceStatus() map[string]interface{} {
 	s.sessionsMu.RLock()
@@ -264,6 +395,9 @@ func (s *Service) startBackgroundTasks() {
 	s.wg.Add(1)
 	go s.sessionCleanupTask()
 
+	s.wg.Add(1)
+	go s.sessionLifecycleTask()
+
 	// Start automatic system prompt refresh task if enabled
 	if s.config.RefreshSystemPromptSecs > 0 {
 		s.wg.Add(1)
@@ -271,7 +405,7 @@ func (s *Service) startBackgroundTasks() {
 	}
 }
 
-// sessionCleanupTask periodically cleans up expired sessions
+// sessionCleanupTask periodically prunes stored session data
 func (s *Service) sessionCleanupTask() {
 	defer s.wg.Done()
 
@@ -283,16 +417,19 @@ func (s *Service) sessionCleanupTask() {
 		case <-s.ctx.Done():
 			return
 		case <-ticker.C:
-			s.cleanupExpiredSessions()
+			s.pruneSessionMemory()
+			s.pruneSessionHistory()
 		}
 	}
 }
 
-// systemPromptRefreshTask periodically sends system prompt updates to all active sessions
+// systemPromptRefreshTask periodically re-renders the airspace template and pushes it to
+// every session with a relay connection, so long conversations reflect current traffic
 func (s *Service) systemPromptRefreshTask() {
 	defer s.wg.Done()
 
-	ticker := time.NewTicker(time.Duration(s.config.RefreshSystemPromptSecs) * time.Second)
+	interval := time.Duration(s.config.RefreshSystemPromptSecs) * time.Second
+	ticker := time.NewTicker(interval)
 	defer ticker.Stop()
 
 	s.logger.Info("Started automatic system prompt refresh task",
@@ -304,145 +441,143 @@ func (s *Service) systemPromptRefreshTask() {
 			s.logger.Info("System prompt refresh task shutting down")
 			return
 		case <-ticker.C:
-			s.refreshAllActiveSessions()
+			s.refreshAllActiveSessions(interval)
 		}
 	}
 }
 
-// refreshAllActiveSessions sends system prompt updates to all active sessions
-func (s *Service) refreshAllActiveSessions() {
+// refreshAllActiveSessions sends fresh instructions to active sessions whose context is
+// older than the refresh interval. Sessions updated in between, by a push-to-talk or an
+// update-context request, are skipped until their context is due again.
+func (s *Service) refreshAllActiveSessions(interval time.Duration) {
 	activeSessions := s.ListActiveSessions()
-
 	if len(activeSessions) == 0 {
-		s.logger.Debug("No active sessions to refresh")
 		return
 	}
 
-	s.logger.Info("Refreshing system prompt for all active sessions",
-		logger.Int("session_count", len(activeSessions)))
-
+	// Allow for ticker jitter, so a session updated by the previous tick is due on this one
+	due := time.Now().UTC().Add(-interval + interval/10)
+	refreshed := 0
 	for _, session := range activeSessions {
+		s.sessionsMu.RLock()
+		lastUpdate := session.LastContextUpdate
+		s.sessionsMu.RUnlock()
+		if lastUpdate.After(due) {
+			continue
+		}
+
 		if err := s.sendSystemPromptUpdate(session.ID); err != nil {
 			s.logger.Error("Failed to send system prompt update to session",
 				logger.String("session_id", session.ID),
 				logger.Error(err))
+			continue
 		}
+		refreshed++
 	}
-}
 
-// sendSystemPromptUpdate sends a system prompt update to a specific session with detailed logging
-func (s *Service) sendSystemPromptUpdate(sessionID string) error {
-	// Check if session still exists before processing
-	_, err := s.GetSession(sessionID)
-	if err != nil {
-		s.logger.Debug("Skipping system prompt update for non-existent session",
-			logger.String("session_id", sessionID),
-			logger.Error(err))
-		return nil // Don't treat this as an error, just skip
+	if refreshed > 0 {
+		s.logger.Debug("Refreshed system prompt of active sessions",
+			logger.Int("refreshed", refreshed),
+			logger.Int("active_sessions", len(activeSessions)))
 	}
+}
 
-	// Generate fresh system prompt with current airspace data and get variables
-	promptWithVars, err := s.GenerateSystemPromptWithVariables(sessionID)
+// sendSystemPromptUpdate pushes freshly rendered instructions to a session
+func (s *Service) sendSystemPromptUpdate(sessionID string) error {
+	systemPrompt, err := s.GenerateSystemPrompt(sessionID)
 	if err != nil {
-		return fmt.Errorf("failed to generate system prompt with variables: %w", err)
-	}
-
-	// Log all the templated variables at info level with proper formatting
-	fmt.Printf("\n=== System prompt refresh - templated variables (Session: %s) ===\n", sessionID)
-	fmt.Printf("\nAircraft Data:\n%v\n", promptWithVars.Variables["Aircraft"])
-	fmt.Printf("\nWeather Data:\n%v\n", promptWithVars.Variables["Weather"])
-	fmt.Printf("\nRunway Data:\n%v\n", promptWithVars.Variables["Runways"])
-	fmt.Printf("\nTranscription History:\n%v\n", promptWithVars.Variables["TranscriptionHistory"])
-	fmt.Printf("\nAirport Data:\n%v\n", promptWithVars.Variables["Airport"])
-	fmt.Printf("=== End templated variables ===\n\n")
-
-	// Create session.update message
-	sessionUpdate := map[string]interface{}{
-		"type": "session.update",
-		"session": map[string]interface{}{
-			"instructions": promptWithVars.Prompt,
-		},
+		return err
 	}
 
-	// Convert to JSON
-	updateData, err := json.Marshal(sessionUpdate)
-	if err != nil {
-		return fmt.Errorf("failed to marshal session update: %w", err)
+	if err := s.pushInstructions(sessionID, systemPrompt, ""); err != nil {
+		return err
 	}
 
-	// Send update through WebSocket channel
-	s.SendSessionUpdate(sessionID, string(updateData))
-
-	s.logger.Info("Successfully sent automatic system prompt update",
+	s.logger.Debug("Sent automatic system prompt update",
 		logger.String("session_id", sessionID),
-		logger.Int("prompt_length", len(promptWithVars.Prompt)))
+		logger.Int("prompt_length", len(systemPrompt)))
 
 	return nil
 }
 
 // UpdateSessionContextOnDemand updates the context for a specific session with fresh airspace data
-// This is called when the user starts speaking (push-to-talk) to ensure latest data
-func (s *Service) UpdateSessionContextOnDemand(sessionID string) error {
-	s.logger.Debug("Updating session context on-demand for user interaction",
+// This is called when the user starts speaking (push-to-talk) to ensure latest data.
+// Each call starts a new chat turn; the turn's correlation ID is returned.
+func (s *Service) UpdateSessionContextOnDemand(sessionID string) (string, error) {
+	turnID := logger.NewCorrelationID("turn")
+	turnLogger := s.logger.WithCorrelationID(turnID)
+
+	s.sessionsMu.Lock()
+	if session, exists := s.sessions[sessionID]; exists {
+		session.CurrentTurnID = turnID
+		session.LastActivity = time.Now().UTC()
+	}
+	s.sessionsMu.Unlock()
+
+	turnLogger.Debug("Updating session context on-demand for user interaction",
 		logger.String("session_id", sessionID))
 
 	// Generate fresh system prompt with current airspace data
 	systemPrompt, err := s.GenerateSystemPrompt(sessionID)
 	if err != nil {
-		s.logger.Error("Failed to generate system prompt for on-demand update",
+		turnLogger.Error("Failed to generate system prompt for on-demand update",
 			logger.String("session_id", sessionID),
 			logger.Error(err))
-		return fmt.Errorf("failed to generate system prompt: %w", err)
+		return turnID, fmt.Errorf("failed to generate system prompt: %w", err)
 	}
 
-	// Create session.update message
-	sessionUpdate := map[string]interface{}{
-		"type": "session.update",
-		"session": map[string]interface{}{
-			"instructions": systemPrompt,
-		},
-	}
-
-	// Convert to JSON
-	updateData, err := json.Marshal(sessionUpdate)
-	if err != nil {
-		s.logger.Error("Failed to marshal session update for on-demand update",
+	// The turn ID doubles as the client event ID, so any error OpenAI reports for this
+	// update can be matched to the turn
+	if err := s.pushInstructions(sessionID, systemPrompt, turnID); err != nil {
+		turnLogger.Error("Failed to send on-demand context update",
 			logger.String("session_id", sessionID),
 			logger.Error(err))
-		return fmt.Errorf("failed to marshal session update: %w", err)
+		return turnID, err
 	}
 
-	// Send update through WebSocket channel
-	s.SendSessionUpdate(sessionID, string(updateData))
-
-	s.logger.Info("Successfully sent on-demand context update",
+	turnLogger.Info("Successfully sent on-demand context update",
 		logger.String("session_id", sessionID))
 
-	return nil
+	return turnID, nil
+}
+
+// CurrentTurnID returns the correlation ID of the latest chat turn for a session
+func (s *Service) CurrentTurnID(sessionID string) string {
+	s.sessionsMu.RLock()
+	defer s.sessionsMu.RUnlock()
+
+	if session, exists := s.sessions[sessionID]; exists {
+		return session.CurrentTurnID
+	}
+	return ""
 }
 
 // cleanupExpiredSessions removes expired or invalid sessions
 func (s *Service) cleanupExpiredSessions() {
 	s.sessionsMu.Lock()
-	defer s.sessionsMu.Unlock()
-
-	var expiredSessions []string
+	var expiredSessions []*ChatSession
 	for sessionID, session := range s.sessions {
 		if !s.realtimeClient.ValidateSession(session) {
-			expiredSessions = append(expiredSessions, sessionID)
+			expiredSessions = append(expiredSessions, session)
+			delete(s.sessions, sessionID)
 		}
 	}
+	remaining := len(s.sessions)
+	s.sessionsMu.Unlock()
 
-	for _, sessionID := range expiredSessions {
-		delete(s.sessions, sessionID)
+	for _, session := range expiredSessions {
+		s.UnregisterWebSocketConnection(session.ID)
+		s.finishSessionMemory(session.ID)
+		s.endSessionHistory(session.ID, endReasonExpired)
+		s.broadcastSessionStatus(session, sessionStatusEnded, endReasonExpired)
 		s.logger.Debug("Cleaned up expired session",
-			logger.String("session_id", sessionID))
+			logger.String("session_id", session.ID))
 	}
 
 	if len(expiredSessions) > 0 {
 		s.logger.Info("Cleaned up expired sessions",
 			logger.Int("expired_count", len(expiredSessions)),
-			logger.Int("remaining_sessions", len(s.sessions)))
+			logger.Int("remaining_sessions", remaining))
 	}
 }
 
@@ -476,7 +611,7 @@ func (s *Service) Shutdown(ctx context.Context) error {
 	s.sessionsMu.Unlock()
 
 	for _, sessionID := range sessionIDs {
-		if err := s.EndSession(ctx, sessionID); err != nil {
+		if err := s.endSession(ctx, sessionID, endReasonShutdown); err != nil {
 			s.logger.Error("Failed to end session during shutdown",
 				logger.String("session_id", sessionID),
 				logger.Error(err))
@@ -500,11 +635,12 @@ func (s *Service) GenerateSystemPrompt(sessionID string) (string, error) {
 		logger.String("session_id", sessionID))
 
 	// Generate prompt using shared templating service
-	prompt, err := s.templatingService.RenderATCChatTemplate(s.config.SystemPromptPath)
+	prompt, err := s.templatingService.RenderATCChatTemplate(s.SessionPersona(sessionID).SystemPromptPath)
 	if err != nil {
 		s.logger.Error("Failed to generate prompt from template", logger.Error(err))
 		return "", fmt.Errorf("failed to generate prompt: %w", err)
 	}
+	prompt = s.withPreviousSession(sessionID, prompt)
 
 	s.logger.Info("Generated system prompt for ATC chat",
 		logger.String("session_id", sessionID),
@@ -529,11 +665,12 @@ func (s *Service) GenerateSystemPromptWithVariables(sessionID string) (*PromptWi
 		logger.String("session_id", sessionID))
 
 	// Generate prompt using shared templating service
-	prompt, err := s.templatingService.RenderATCChatTemplate(s.config.SystemPromptPath)
+	prompt, err := s.templatingService.RenderATCChatTemplate(s.SessionPersona(sessionID).SystemPromptPath)
 	if err != nil {
 		s.logger.Error("Failed to generate prompt from template", logger.Error(err))
 		return nil, fmt.Errorf("failed to generate prompt: %w", err)
 	}
+	prompt = s.withPreviousSession(sessionID, prompt)
 
 	// Get the actual template context to return real variable data
 	context, err := s.templatingService.GetTemplateContext(ATCChatFormattingOptions())
@@ -555,7 +692,7 @@ func (s *Service) GenerateSystemPromptWithVariables(sessionID string) (*PromptWi
 
 	// Format the actual template variables for display
 	variables := map[string]interface{}{
-		"Aircraft":             templating.FormatAircraftData(context.Aircraft, context.Airport),
+		"Aircraft":             templating.FormatAircraftData(context.Aircraft, context.Airport, context.Annotations),
 		"Weather":              templating.FormatWeatherData(context.Weather),
 		"Runways":              templating.FormatRunwayData(context.Runways),
 		"TranscriptionHistory": templating.FormatTranscriptionHistory(context.TranscriptionHistory),
@@ -624,20 +761,25 @@ func (s *Service) hasActiveWebSocketConnection(sessionID string) bool {
 	return exists
 }
 
-// SendSessionUpdate sends a session update to a specific session's WebSocket connection
-func (s *Service) SendSessionUpdate(sessionID string, updateMessage string) {
+// SendSessionUpdate sends a session update to a specific session's WebSocket connection.
+// It reports whether the update was queued for the relay.
+func (s *Service) SendSessionUpdate(sessionID string, updateMessage string) bool {
 	s.wsConnectionsMu.RLock()
+	defer s.wsConnectionsMu.RUnlock()
+
 	updateChan, exists := s.wsConnections[sessionID]
-	s.wsConnectionsMu.RUnlock()
+	if !exists {
+		return false
+	}
 
-	if exists {
-		select {
-		case updateChan <- updateMessage:
-			s.logger.Debug("Sent session update to WebSocket",
-				logger.String("session_id", sessionID))
-		default:
-			s.logger.Warn("Failed to send session update - channel full",
-				logger.String("session_id", sessionID))
-		}
+	select {
+	case updateChan <- updateMessage:
+		s.logger.Debug("Sent session update to WebSocket",
+			logger.String("session_id", sessionID))
+		return true
+	default:
+		s.logger.Warn("Failed to send session update - channel full",
+			logger.String("session_id", sessionID))
+		return false
 	}
 }
@@ -0,0 +1,286 @@
+package atcchat
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/yegors/co-atc/internal/adsb"
+	"github.com/yegors/co-atc/internal/storage/sqlite"
+	"github.com/yegors/co-atc/internal/templating"
+	"github.com/yegors/co-atc/pkg/logger"
+)
+
+// Limits on the data tools return
+const (
+	defaultToolSearchMinutes = 60
+	maxToolSearchMinutes     = 24 * 60
+	defaultToolSearchResults = 10
+	maxToolSearchResults     = 25
+	maxToolAircraft          = 1000
+	maxToolHazards           = 20
+)
+
+// ToolCall is a function call the assistant made in a response
+type ToolCall struct {
+	CallID    string
+	Name      string
+	Arguments string // JSON object
+}
+
+// chatTools describes the functions the assistant can call to look up live data, in the
+// realtime API's session tool format
+var chatTools = []map[string]interface{}{
+	{
+		"type":        "function",
+		"name":        "get_aircraft_details",
+		"description": "Get the current position, altitude, speed, phase and recent clearances of an aircraft in the area. Use it before answering about a specific aircraft, as the aircraft list in the instructions may be out of date.",
+		"parameters": map[string]interface{}{
+			"type": "object",
+			"properties": map[string]interface{}{
+				"callsign": map[string]interface{}{
+					"type":        "string",
+					"description": "Callsign (ACA123), spoken callsign (Air Canada 123), registration or hex code",
+				},
+			},
+			"required": []string{"callsign"},
+		},
+	},
+	{
+		"type":        "function",
+		"name":        "get_weather",
+		"description": "Get the latest METAR and TAF for the airport",
+		"parameters": map[string]interface{}{
+			"type":       "object",
+			"properties": map[string]interface{}{},
+		},
+	},
+	{
+		"type":        "function",
+		"name":        "get_runway_status",
+		"description": "Get the airport's runways, which are in use for arrivals and departures and which are closed",
+		"parameters": map[string]interface{}{
+			"type":       "object",
+			"properties": map[string]interface{}{},
+		},
+	},
+	{
+		"type":        "function",
+		"name":        "search_transcriptions",
+		"description": "Search recent radio transmissions on the monitored frequencies, newest first",
+		"parameters": map[string]interface{}{
+			"type": "object",
+			"properties": map[string]interface{}{
+				"query": map[string]interface{}{
+					"type":        "string",
+					"description": "Words to search for, e.g. 'cleared to land' or 'runway 23'",
+				},
+				"callsign": map[string]interface{}{
+					"type":        "string",
+					"description": "Only transmissions by or to this aircraft",
+				},
+				"minutes": map[string]interface{}{
+					"type":        "integer",
+					"description": fmt.Sprintf("How far back to search in minutes (default %d, max %d)", defaultToolSearchMinutes, maxToolSearchMinutes),
+				},
+				"limit": map[string]interface{}{
+					"type":        "integer",
+					"description": fmt.Sprintf("Maximum transmissions to return (default %d, max %d)", defaultToolSearchResults, maxToolSearchResults),
+				},
+			},
+			"required": []string{"query"},
+		},
+	},
+}
+
+// Tools returns the functions to expose to realtime sessions, or nil if tools are disabled
+func (s *Service) Tools() []map[string]interface{} {
+	if s.config.DisableTools {
+		return nil
+	}
+	return chatTools
+}
+
+// CallTool runs a function the assistant called and returns its output as JSON. Errors are
+// returned as output too, so the assistant can tell the user what went wrong.
+func (s *Service) CallTool(sessionID string, call ToolCall) string {
+	started := time.Now()
+	result, err := s.callTool(call)
+	if err != nil {
+		result = map[string]interface{}{"error": err.Error()}
+	}
+
+	output, marshalErr := json.Marshal(result)
+	if marshalErr != nil {
+		output = []byte(`{"error":"failed to encode result"}`)
+	}
+
+	s.logger.Info("Chat tool called",
+		logger.String("session_id", sessionID),
+		logger.String("correlation_id", s.CurrentTurnID(sessionID)),
+		logger.String("tool", call.Name),
+		logger.String("arguments", call.Arguments),
+		logger.Int("output_length", len(output)),
+		logger.Duration("duration", time.Since(started)),
+		logger.Bool("failed", err != nil))
+
+	return string(output)
+}
+
+// callTool dispatches a function call
+func (s *Service) callTool(call ToolCall) (interface{}, error) {
+	var args struct {
+		Callsign string `json:"callsign"`
+		Query    string `json:"query"`
+		Minutes  int    `json:"minutes"`
+		Limit    int    `json:"limit"`
+	}
+	if strings.TrimSpace(call.Arguments) != "" {
+		if err := json.Unmarshal([]byte(call.Arguments), &args); err != nil {
+			return nil, fmt.Errorf("invalid arguments: %v", err)
+		}
+	}
+
+	switch call.Name {
+	case "get_aircraft_details":
+		return s.toolAircraftDetails(args.Callsign)
+	case "get_weather":
+		return s.toolWeather()
+	case "get_runway_status":
+		return s.toolRunwayStatus()
+	case "search_transcriptions":
+		return s.toolSearchTranscriptions(args.Query, args.Callsign, args.Minutes, args.Limit)
+	default:
+		return nil, fmt.Errorf("unknown function %q", call.Name)
+	}
+}
+
+// toolContext returns the current airspace data. Unlike the instructions, it isn't cut
+// down to the nearest max_context_aircraft, so any tracked aircraft can be looked up.
+func (s *Service) toolContext() (*TemplateContext, error) {
+	opts := ATCChatFormattingOptions()
+	opts.IncludeTranscriptionHistory = false
+	opts.MaxAircraft = maxToolAircraft
+	context, err := s.templatingService.GetTemplateContext(opts)
+	if err != nil {
+		return nil, fmt.Errorf("airspace data is not available: %v", err)
+	}
+	return context, nil
+}
+
+// toolAircraftDetails describes the aircraft a callsign refers to
+func (s *Service) toolAircraftDetails(callsign string) (interface{}, error) {
+	if strings.TrimSpace(callsign) == "" {
+		return nil, fmt.Errorf("callsign is required")
+	}
+	context, err := s.toolContext()
+	if err != nil {
+		return nil, err
+	}
+
+	hex := s.templatingService.AircraftHex(callsign)
+	canonical := s.templatingService.CanonicalCallsign(callsign)
+	for _, ac := range context.Aircraft {
+		if (hex != "" && strings.EqualFold(ac.Hex, hex)) || adsb.NormalizeCallsign(ac.Flight) == canonical {
+			return map[string]interface{}{
+				"hex":        ac.Hex,
+				"callsign":   strings.TrimSpace(ac.Flight),
+				"details":    templating.FormatAircraft(ac, context.Airport, context.Annotations[ac.Hex]),
+				"clearances": ac.Clearances,
+			}, nil
+		}
+	}
+
+	return map[string]interface{}{
+		"found":   false,
+		"message": fmt.Sprintf("No aircraft matching %q is being tracked", callsign),
+	}, nil
+}
+
+// toolWeather returns the latest weather
+func (s *Service) toolWeather() (interface{}, error) {
+	context, err := s.toolContext()
+	if err != nil {
+		return nil, err
+	}
+	if context.Weather == nil {
+		return nil, fmt.Errorf("weather data is not available")
+	}
+
+	return map[string]interface{}{
+		"summary":      templating.FormatWeatherData(context.Weather),
+		"metar":        context.Weather.METAR,
+		"taf":          context.Weather.TAF,
+		"parsed":       context.Weather.Parsed,
+		"winds_aloft":  context.Weather.WindsAloft.StationLevels(),
+		"hazards":      context.Weather.Hazards.Nearby(maxToolHazards),
+		"last_updated": context.Weather.LastUpdated,
+	}, nil
+}
+
+// toolRunwayStatus returns the runways and their status
+func (s *Service) toolRunwayStatus() (interface{}, error) {
+	context, err := s.toolContext()
+	if err != nil {
+		return nil, err
+	}
+
+	return map[string]interface{}{
+		"summary": templating.FormatRunwayData(context.Runways),
+		"runways": context.Runways,
+	}, nil
+}
+
+// toolSearchTranscriptions searches recent transmissions
+func (s *Service) toolSearchTranscriptions(query, callsign string, minutes, limit int) (interface{}, error) {
+	if s.transcriptionStorage == nil {
+		return nil, fmt.Errorf("transcriptions are not available")
+	}
+	if strings.TrimSpace(query) == "" {
+		return nil, fmt.Errorf("query is required")
+	}
+	if minutes <= 0 {
+		minutes = defaultToolSearchMinutes
+	}
+	if limit <= 0 {
+		limit = defaultToolSearchResults
+	}
+
+	since := time.Now().Add(-time.Duration(min(minutes, maxToolSearchMinutes)) * time.Minute)
+	search := sqlite.TranscriptionSearch{
+		Query:      query,
+		StartTime:  &since,
+		SortByTime: true,
+		Limit:      min(limit, maxToolSearchResults),
+	}
+	if callsign != "" {
+		search.Callsign = s.templatingService.CanonicalCallsign(callsign)
+	}
+
+	records, err := s.transcriptionStorage.SearchTranscriptions(search)
+	if err != nil {
+		return nil, err
+	}
+
+	transmissions := make([]map[string]interface{}, 0, len(records))
+	for _, record := range records {
+		content := record.ContentProcessed
+		if content == "" {
+			content = record.Content
+		}
+		transmissions = append(transmissions, map[string]interface{}{
+			"time":      record.CreatedAt.UTC().Format(time.RFC3339),
+			"ago":       formatAge(time.Since(record.CreatedAt)),
+			"frequency": record.FrequencyID,
+			"speaker":   record.SpeakerType,
+			"callsign":  record.Callsign,
+			"content":   content,
+		})
+	}
+
+	return map[string]interface{}{
+		"count":         len(transmissions),
+		"transmissions": transmissions,
+	}, nil
+}
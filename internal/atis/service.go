@@ -0,0 +1,302 @@
+package atis
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/yegors/co-atc/internal/config"
+	"github.com/yegors/co-atc/internal/storage/sqlite"
+	"github.com/yegors/co-atc/internal/websocket"
+	"github.com/yegors/co-atc/pkg/logger"
+)
+
+// ATIS types reported by the D-ATIS API. Airports with separate arrival and departure
+// broadcasts report both; the others report a combined one.
+const (
+	TypeCombined  = "combined"
+	TypeArrival   = "arr"
+	TypeDeparture = "dep"
+
+	// TypeSynthesized is the ATIS generated from the METAR, runways and NOTAMs
+	TypeSynthesized = "synthesized"
+)
+
+// informationPattern finds the information letter in ATIS text, for entries without a code
+var informationPattern = regexp.MustCompile(`\bINFO(?:RMATION)?\s+([A-Z])\b`)
+
+// ATIS is an ATIS broadcast of the airport
+type ATIS struct {
+	Airport    string    `json:"airport"`
+	Type       string    `json:"type"` // "combined", "arr" or "dep"
+	Letter     string    `json:"letter"`
+	Text       string    `json:"text"`
+	ReceivedAt time.Time `json:"received_at"` // When the letter was first seen
+}
+
+// datisEntry is an entry of a D-ATIS API response
+type datisEntry struct {
+	Airport string `json:"airport"`
+	Type    string `json:"type"`
+	Code    string `json:"code"`
+	Text    string `json:"datis"`
+}
+
+// Service follows the airport's ATIS, polled from its D-ATIS or synthesized, keeps the history
+// of its information letters and tells WebSocket clients when a new one is issued
+type Service struct {
+	config      config.ATISConfig
+	airportCode string
+	storage     *sqlite.ATISStorage
+	wsServer    *websocket.Server
+	httpClient  *http.Client
+	logger      *logger.Logger
+
+	synthesizer *synthesizer // nil = polling the D-ATIS
+
+	mu      sync.RWMutex
+	current map[string]*ATIS // By type
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// NewService creates a new ATIS service
+func NewService(cfg config.ATISConfig, airportCode string, storage *sqlite.ATISStorage, wsServer *websocket.Server, logger *logger.Logger) *Service {
+	return &Service{
+		config:      cfg,
+		airportCode: strings.ToUpper(airportCode),
+		storage:     storage,
+		wsServer:    wsServer,
+		httpClient:  &http.Client{Timeout: 15 * time.Second},
+		logger:      logger.Named("atis"),
+		current:     make(map[string]*ATIS),
+	}
+}
+
+// Start restores the latest stored information letters and starts polling
+func (s *Service) Start(ctx context.Context) error {
+	s.ctx, s.cancel = context.WithCancel(ctx)
+
+	// Letters seen before a restart aren't new
+	records, err := s.storage.GetLatestATIS(s.airportCode)
+	if err != nil {
+		return err
+	}
+	for _, record := range records {
+		s.current[record.Type] = &ATIS{
+			Airport:    record.Airport,
+			Type:       record.Type,
+			Letter:     record.Letter,
+			Text:       record.Text,
+			ReceivedAt: record.ReceivedAt,
+		}
+	}
+
+	if s.config.Source == config.ATISSourceSynthesized && s.synthesizer == nil {
+		return fmt.Errorf("synthesized ATIS has no data source")
+	}
+
+	s.wg.Add(1)
+	go s.run()
+
+	s.logger.Info("ATIS service started",
+		logger.String("airport", s.airportCode),
+		logger.String("source", s.config.Source),
+		logger.String("api_base_url", s.config.APIBaseURL),
+		logger.Int("poll_interval_seconds", s.config.PollIntervalSeconds))
+	return nil
+}
+
+// Stop stops polling
+func (s *Service) Stop() {
+	if s.cancel != nil {
+		s.cancel()
+	}
+	s.wg.Wait()
+}
+
+// run polls the D-ATIS, or synthesizes the ATIS, every poll interval
+func (s *Service) run() {
+	defer s.wg.Done()
+
+	ticker := time.NewTicker(time.Duration(s.config.PollIntervalSeconds) * time.Second)
+	defer ticker.Stop()
+
+	for {
+		if s.synthesizer != nil {
+			if err := s.synthesize(); err != nil {
+				s.logger.Warn("Failed to synthesize ATIS",
+					logger.String("airport", s.airportCode),
+					logger.Error(err))
+			}
+		} else if err := s.poll(); err != nil {
+			s.logger.Warn("Failed to poll D-ATIS",
+				logger.String("airport", s.airportCode),
+				logger.Error(err))
+		}
+
+		select {
+		case <-s.ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Current returns the current ATIS broadcasts of the airport, ordered by type
+func (s *Service) Current() []ATIS {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	current := make([]ATIS, 0, len(s.current))
+	for _, atis := range s.current {
+		current = append(current, *atis)
+	}
+	sort.Slice(current, func(i, j int) bool {
+		return current[i].Type < current[j].Type
+	})
+	return current
+}
+
+// History returns up to limit information letters received since a time, newest first
+func (s *Service) History(since time.Time, limit int) ([]*sqlite.ATISRecord, error) {
+	return s.storage.ListATIS(s.airportCode, since, limit)
+}
+
+// poll fetches the D-ATIS and records information letters that changed
+func (s *Service) poll() error {
+	entries, err := s.fetch()
+	if err != nil {
+		return err
+	}
+
+	now := time.Now().UTC()
+	for _, entry := range entries {
+		atisType := strings.ToLower(entry.Type)
+		if atisType == "" {
+			atisType = TypeCombined
+		}
+		text := strings.TrimSpace(entry.Text)
+		letter := strings.ToUpper(strings.TrimSpace(entry.Code))
+		if letter == "" {
+			if match := informationPattern.FindStringSubmatch(text); match != nil {
+				letter = match[1]
+			}
+		}
+		if letter == "" || text == "" {
+			continue
+		}
+
+		s.record(atisType, letter, text, now)
+	}
+
+	return nil
+}
+
+// record makes an information letter current, storing and announcing it if it's new, and
+// reports whether it was
+func (s *Service) record(atisType, letter, text string, now time.Time) bool {
+	s.mu.Lock()
+	previous := s.current[atisType]
+	if previous != nil && previous.Letter == letter {
+		// Same information, amended or reformatted; keep it current without announcing it
+		previous.Text = text
+		s.mu.Unlock()
+		return false
+	}
+	atis := &ATIS{
+		Airport:    s.airportCode,
+		Type:       atisType,
+		Letter:     letter,
+		Text:       text,
+		ReceivedAt: now,
+	}
+	s.current[atisType] = atis
+	s.mu.Unlock()
+
+	if err := s.storage.AddATIS(&sqlite.ATISRecord{
+		Airport:    atis.Airport,
+		Type:       atis.Type,
+		Letter:     atis.Letter,
+		Text:       atis.Text,
+		ReceivedAt: atis.ReceivedAt,
+	}); err != nil {
+		s.logger.Error("Failed to store ATIS", logger.Error(err))
+	}
+
+	previousLetter := ""
+	if previous != nil {
+		previousLetter = previous.Letter
+	}
+	s.logger.Info("New ATIS information",
+		logger.String("airport", s.airportCode),
+		logger.String("type", atisType),
+		logger.String("letter", letter),
+		logger.String("previous_letter", previousLetter))
+	s.broadcast(atis, previousLetter)
+	return true
+}
+
+// fetch fetches the airport's D-ATIS entries
+func (s *Service) fetch() ([]datisEntry, error) {
+	req, err := http.NewRequestWithContext(s.ctx, http.MethodGet, s.config.APIBaseURL+"/"+s.airportCode, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("D-ATIS API returned status %d: %s", resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+
+	// Airports without a D-ATIS get an object with an error instead of a list
+	var entries []datisEntry
+	if err := json.Unmarshal(body, &entries); err != nil {
+		var failure struct {
+			Error string `json:"error"`
+		}
+		if json.Unmarshal(body, &failure) == nil && failure.Error != "" {
+			return nil, fmt.Errorf("D-ATIS API error: %s", failure.Error)
+		}
+		return nil, fmt.Errorf("failed to parse response: %w", err)
+	}
+	return entries, nil
+}
+
+// broadcast tells WebSocket clients about a new information letter
+func (s *Service) broadcast(atis *ATIS, previousLetter string) {
+	if s.wsServer == nil {
+		return
+	}
+
+	s.wsServer.Broadcast(&websocket.Message{
+		Type: "atis_update",
+		Data: map[string]interface{}{
+			"airport":         atis.Airport,
+			"type":            atis.Type,
+			"letter":          atis.Letter,
+			"previous_letter": previousLetter,
+			"text":            atis.Text,
+			"received_at":     atis.ReceivedAt,
+		},
+	})
+}
@@ -0,0 +1,135 @@
+package atis
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/yegors/co-atc/pkg/logger"
+)
+
+// Conditions are what a synthesized ATIS reports, spelled out the way they're said on the
+// radio so text-to-speech reads them right. Empty fields are unknown.
+type Conditions struct {
+	Airport     string   // Airport code
+	Letter      string   // Information letter ("B")
+	Time        string   // Observation time of the METAR ("one four three zero zulu")
+	Wind        string   // "wind two four zero at one five, gusting two five"
+	Visibility  string   // "visibility one zero" or "CAVOK"
+	Weather     string   // Present weather ("light rain, mist")
+	Sky         string   // "few clouds at three thousand, ceiling two five thousand broken"
+	Temperature string   // "temperature one five, dewpoint minus two"
+	Altimeter   string   // "altimeter two nine nine two" or "QNH one zero one three"
+	Runways     string   // Runways in use ("landing runway two three, departing runway two four right")
+	Closures    string   // Closed runways
+	NOTAMs      []string // Highlights of the airport's NOTAMs
+}
+
+// DataSource provides and renders the conditions of a synthesized ATIS
+type DataSource interface {
+	GetATISConditions(maxNOTAMs int) (*Conditions, error)
+	RenderATISTemplate(templatePath string, conditions *Conditions) (string, error)
+}
+
+// Speaker reads text out with text-to-speech
+type Speaker interface {
+	Synthesize(ctx context.Context, text string) ([]byte, error)
+	ContentType() string
+}
+
+// synthesizer generates the ATIS of an airport without a D-ATIS
+type synthesizer struct {
+	source  DataSource
+	speaker Speaker // nil = text only
+
+	mu          sync.RWMutex
+	audio       []byte
+	audioLetter string // Information letter the audio is of
+}
+
+// SetSynthesizer makes the service synthesize the ATIS instead of polling the D-ATIS, reading
+// each information letter out with a speaker if it isn't nil. It must be called before Start.
+func (s *Service) SetSynthesizer(source DataSource, speaker Speaker) {
+	s.synthesizer = &synthesizer{source: source, speaker: speaker}
+}
+
+// Audio returns the spoken audio of the current synthesized ATIS and its content type
+func (s *Service) Audio() ([]byte, string, bool) {
+	if s.synthesizer == nil || s.synthesizer.speaker == nil {
+		return nil, "", false
+	}
+
+	s.synthesizer.mu.RLock()
+	defer s.synthesizer.mu.RUnlock()
+	if len(s.synthesizer.audio) == 0 {
+		return nil, "", false
+	}
+	return s.synthesizer.audio, s.synthesizer.speaker.ContentType(), true
+}
+
+// synthesize renders the ATIS from current conditions. Like a real ATIS, the information
+// letter advances when what it reports changes: a new METAR, runway change or NOTAM.
+func (s *Service) synthesize() error {
+	conditions, err := s.synthesizer.source.GetATISConditions(s.config.NOTAMHighlights)
+	if err != nil {
+		return fmt.Errorf("failed to get ATIS conditions: %w", err)
+	}
+
+	s.mu.RLock()
+	current := s.current[TypeSynthesized]
+	s.mu.RUnlock()
+
+	conditions.Letter = "A"
+	if current != nil {
+		conditions.Letter = current.Letter
+	}
+	text, err := s.synthesizer.source.RenderATISTemplate(s.config.TemplatePath, conditions)
+	if err != nil {
+		return fmt.Errorf("failed to render ATIS: %w", err)
+	}
+	if current != nil && text != current.Text {
+		conditions.Letter = nextLetter(current.Letter)
+		if text, err = s.synthesizer.source.RenderATISTemplate(s.config.TemplatePath, conditions); err != nil {
+			return fmt.Errorf("failed to render ATIS: %w", err)
+		}
+	}
+
+	s.record(TypeSynthesized, conditions.Letter, text, time.Now().UTC())
+	s.speak(conditions.Letter, text)
+	return nil
+}
+
+// speak reads an information letter out, unless it already was
+func (s *Service) speak(letter, text string) {
+	if s.synthesizer.speaker == nil {
+		return
+	}
+
+	s.synthesizer.mu.RLock()
+	spoken := s.synthesizer.audioLetter == letter && len(s.synthesizer.audio) > 0
+	s.synthesizer.mu.RUnlock()
+	if spoken {
+		return
+	}
+
+	audio, err := s.synthesizer.speaker.Synthesize(s.ctx, text)
+	if err != nil {
+		// The text is still current; speaking it is tried again on the next cycle
+		s.logger.Error("Failed to speak ATIS", logger.String("letter", letter), logger.Error(err))
+		return
+	}
+
+	s.synthesizer.mu.Lock()
+	s.synthesizer.audio = audio
+	s.synthesizer.audioLetter = letter
+	s.synthesizer.mu.Unlock()
+}
+
+// nextLetter returns the information letter after another, from Zulu back to Alpha
+func nextLetter(letter string) string {
+	if len(letter) != 1 || letter[0] < 'A' || letter[0] >= 'Z' {
+		return "A"
+	}
+	return string(letter[0] + 1)
+}
@@ -0,0 +1,490 @@
+package audio
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/yegors/co-atc/internal/storage/sqlite"
+	"github.com/yegors/co-atc/pkg/logger"
+)
+
+// ArchiverConfig contains configuration for the audio archiver
+type ArchiverConfig struct {
+	FFmpegPath      string
+	OutputDir       string        // Root directory for recordings
+	Format          string        // "mp3" or "opus"
+	Bitrate         string        // Encoder bitrate (e.g., "32k")
+	SegmentDuration time.Duration // Length of each recorded segment
+	ReconnectDelay  time.Duration // Wait before re-attaching to a stream that went quiet
+}
+
+// Archiver continuously records frequencies to segmented audio files and
+// indexes the segments so past transmissions can be played back
+type Archiver struct {
+	config    ArchiverConfig
+	storage   *sqlite.RecordingStorage
+	recorders map[string]*recorder
+	mu        sync.Mutex
+	ctx       context.Context
+	cancel    context.CancelFunc
+	wg        sync.WaitGroup
+	logger    *logger.Logger
+}
+
+// recorder records a single frequency
+type recorder struct {
+	cancel  context.CancelFunc
+	done    chan struct{}
+	current *sqlite.RecordingSegment // Segment being written, not yet indexed (nil between segments)
+	mu      sync.Mutex               // Protects current
+}
+
+// SegmentSlice is the part of a recorded segment covering a time range
+type SegmentSlice struct {
+	SegmentID   int64  `json:"segment_id"` // 0 for the segment still being recorded
+	InProgress  bool   `json:"in_progress"`
+	Format      string `json:"format"`
+	OffsetMs    int64  `json:"offset_ms"`     // Start of the range within the segment
+	EndOffsetMs int64  `json:"end_offset_ms"` // End of the range within the segment
+	ByteOffset  int64  `json:"byte_offset"`   // Estimated from the segment's average bitrate
+	ByteEnd     int64  `json:"byte_end"`
+	path        string
+}
+
+// segmentWriter encodes PCM audio into a single segment file
+type segmentWriter struct {
+	segment        *sqlite.RecordingSegment
+	cmd            *exec.Cmd
+	stdin          io.WriteCloser
+	bytesWritten   int64
+	bytesPerSecond int64
+}
+
+// NewArchiver creates a new audio archiver
+func NewArchiver(ctx context.Context, config ArchiverConfig, storage *sqlite.RecordingStorage, logger *logger.Logger) *Archiver {
+	archiverCtx, cancel := context.WithCancel(ctx)
+
+	if config.SegmentDuration <= 0 {
+		config.SegmentDuration = 10 * time.Minute
+	}
+	if config.ReconnectDelay <= 0 {
+		config.ReconnectDelay = 5 * time.Second
+	}
+
+	return &Archiver{
+		config:    config,
+		storage:   storage,
+		recorders: make(map[string]*recorder),
+		ctx:       archiverCtx,
+		cancel:    cancel,
+		logger:    logger.Named("archiver"),
+	}
+}
+
+// Stop stops all recorders, finishing their current segments
+func (a *Archiver) Stop() {
+	a.cancel()
+
+	a.mu.Lock()
+	for id, rec := range a.recorders {
+		rec.cancel()
+		delete(a.recorders, id)
+	}
+	a.mu.Unlock()
+
+	a.wg.Wait()
+	a.logger.Info("Archiver stopped")
+}
+
+// AddFrequency starts recording a frequency from its audio processor. A frequency that
+// is already being recorded is restarted with the new processor.
+func (a *Archiver) AddFrequency(frequencyID string, processor *CentralAudioProcessor) {
+	a.RemoveFrequency(frequencyID)
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if a.ctx.Err() != nil {
+		return
+	}
+
+	ctx, cancel := context.WithCancel(a.ctx)
+	rec := &recorder{
+		cancel: cancel,
+		done:   make(chan struct{}),
+	}
+	a.recorders[frequencyID] = rec
+
+	a.wg.Add(1)
+	go func() {
+		defer a.wg.Done()
+		defer close(rec.done)
+		a.record(ctx, rec, frequencyID, processor)
+	}()
+
+	a.logger.Info("Started recording frequency", String("id", frequencyID))
+}
+
+// RemoveFrequency stops recording a frequency and waits for its current segment to be saved
+func (a *Archiver) RemoveFrequency(frequencyID string) {
+	a.mu.Lock()
+	rec, exists := a.recorders[frequencyID]
+	delete(a.recorders, frequencyID)
+	a.mu.Unlock()
+
+	if !exists {
+		return
+	}
+
+	rec.cancel()
+	<-rec.done
+
+	a.logger.Info("Stopped recording frequency", String("id", frequencyID))
+}
+
+// GetSegments returns the indexed segments of a frequency that overlap the time range
+func (a *Archiver) GetSegments(frequencyID string, from, to time.Time) ([]*sqlite.RecordingSegment, error) {
+	return a.storage.GetSegments(frequencyID, from, to)
+}
+
+// GetSegment returns a single indexed segment, or nil if it does not exist
+func (a *Archiver) GetSegment(id int64) (*sqlite.RecordingSegment, error) {
+	return a.storage.GetSegmentByID(id)
+}
+
+// Locate returns the parts of the recorded segments that cover a time range, oldest first.
+// The segment still being recorded is included, so recent transmissions can be located
+// before their segment is indexed.
+func (a *Archiver) Locate(frequencyID string, from, to time.Time) ([]SegmentSlice, error) {
+	segments, err := a.storage.GetSegments(frequencyID, from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	a.mu.Lock()
+	rec := a.recorders[frequencyID]
+	a.mu.Unlock()
+	if rec != nil {
+		if current := rec.currentSegment(); current != nil && current.StartTime.Before(to) && current.EndTime.After(from) {
+			segments = append(segments, current)
+		}
+	}
+
+	var slices []SegmentSlice
+	for _, segment := range segments {
+		start := from
+		if start.Before(segment.StartTime) {
+			start = segment.StartTime
+		}
+		end := to
+		if end.After(segment.EndTime) {
+			end = segment.EndTime
+		}
+
+		slice := SegmentSlice{
+			SegmentID:   segment.ID,
+			InProgress:  segment.ID == 0,
+			Format:      segment.Format,
+			OffsetMs:    start.Sub(segment.StartTime).Milliseconds(),
+			EndOffsetMs: end.Sub(segment.StartTime).Milliseconds(),
+			path:        segment.Path,
+		}
+		if segment.DurationMs > 0 && segment.SizeBytes > 0 {
+			slice.ByteOffset = segment.SizeBytes * slice.OffsetMs / segment.DurationMs
+			slice.ByteEnd = segment.SizeBytes * slice.EndOffsetMs / segment.DurationMs
+		}
+		slices = append(slices, slice)
+	}
+
+	return slices, nil
+}
+
+// WriteClip writes the recorded audio of a time range to w, in the archive format.
+// Ranges spanning several segments are joined into one clip.
+func (a *Archiver) WriteClip(ctx context.Context, slices []SegmentSlice, w io.Writer) error {
+	if len(slices) == 0 {
+		return fmt.Errorf("no recorded audio in range")
+	}
+
+	// The concat demuxer cuts and joins the segments in one pass
+	list, err := os.CreateTemp("", "co-atc-clip-*.txt")
+	if err != nil {
+		return fmt.Errorf("failed to create clip list: %w", err)
+	}
+	defer os.Remove(list.Name())
+
+	for _, slice := range slices {
+		fmt.Fprintf(list, "file '%s'\n", strings.ReplaceAll(slice.path, "'", `'\''`))
+		fmt.Fprintf(list, "inpoint %.3f\n", float64(slice.OffsetMs)/1000)
+		fmt.Fprintf(list, "outpoint %.3f\n", float64(slice.EndOffsetMs)/1000)
+	}
+	if err := list.Close(); err != nil {
+		return fmt.Errorf("failed to write clip list: %w", err)
+	}
+
+	args := []string{
+		"-loglevel", "error",
+		"-f", "concat",
+		"-safe", "0",
+		"-i", list.Name(),
+	}
+	if slices[0].Format == "opus" {
+		args = append(args, "-c:a", "libopus", "-b:a", a.config.Bitrate, "-f", "ogg")
+	} else {
+		args = append(args, "-c:a", "libmp3lame", "-b:a", a.config.Bitrate, "-f", "mp3")
+	}
+	args = append(args, "pipe:1")
+
+	var stderr strings.Builder
+	cmd := exec.CommandContext(ctx, a.config.FFmpegPath, args...)
+	cmd.Stdout = w
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("failed to extract clip: %w: %s", err, strings.TrimSpace(stderr.String()))
+	}
+
+	return nil
+}
+
+// SegmentContentType returns the HTTP content type of a segment format
+func SegmentContentType(format string) string {
+	if format == "opus" {
+		return "audio/ogg"
+	}
+	return "audio/mpeg"
+}
+
+// record reads the frequency's PCM stream and writes it to rotating segments until ctx is canceled
+func (a *Archiver) record(ctx context.Context, rec *recorder, frequencyID string, processor *CentralAudioProcessor) {
+	readerID := "archive-" + frequencyID
+	log := a.logger.With(String("id", frequencyID))
+
+	// Removing the reader wakes up a blocked Read when recording is stopped
+	go func() {
+		<-ctx.Done()
+		processor.RemoveReader(readerID)
+	}()
+
+	buffer := make([]byte, 8192)
+	var writer *segmentWriter
+
+	for ctx.Err() == nil {
+		reader, err := processor.CreateRawReader(readerID)
+		if err != nil {
+			log.Error("Failed to attach archiver to audio stream", Error(err))
+		} else {
+			for ctx.Err() == nil {
+				n, readErr := reader.Read(buffer)
+				if n > 0 {
+					if writer == nil {
+						writer, err = a.startSegment(frequencyID, processor.SampleRate(), processor.Channels())
+						if err != nil {
+							log.Error("Failed to start recording segment", Error(err))
+						}
+					}
+					if writer != nil {
+						if _, err := writer.stdin.Write(buffer[:n]); err != nil {
+							log.Error("Failed to write recording segment", Error(err))
+							a.finishSegment(rec, writer)
+							writer = nil
+						} else {
+							writer.bytesWritten += int64(n)
+							rec.setCurrent(writer)
+						}
+					}
+					if writer != nil && writer.duration() >= a.config.SegmentDuration {
+						a.finishSegment(rec, writer)
+						writer = nil
+					}
+				}
+				if readErr != nil {
+					break
+				}
+			}
+			reader.Close()
+		}
+
+		// The stream went quiet or recording is stopping; close the segment so the
+		// index reflects the gap instead of stretching a segment across it
+		if writer != nil {
+			a.finishSegment(rec, writer)
+			writer = nil
+		}
+
+		select {
+		case <-ctx.Done():
+		case <-time.After(a.config.ReconnectDelay):
+		}
+	}
+}
+
+// startSegment starts an encoder writing a new segment file
+func (a *Archiver) startSegment(frequencyID string, sampleRate, channels int) (*segmentWriter, error) {
+	start := time.Now().UTC()
+
+	format := a.config.Format
+	if format != "opus" {
+		format = "mp3"
+	}
+
+	dir := filepath.Join(a.config.OutputDir, safePathComponent(frequencyID), start.Format("2006-01-02"))
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create recording directory: %w", err)
+	}
+	path := filepath.Join(dir, fmt.Sprintf("%s-%s.%s", safePathComponent(frequencyID), start.Format("20060102T150405Z"), format))
+
+	args := []string{
+		"-loglevel", "error",
+		"-f", "s16le",
+		"-ar", fmt.Sprintf("%d", sampleRate),
+		"-ac", fmt.Sprintf("%d", channels),
+		"-i", "pipe:0",
+	}
+	if format == "opus" {
+		args = append(args, "-c:a", "libopus", "-b:a", a.config.Bitrate, "-f", "ogg")
+	} else {
+		args = append(args, "-c:a", "libmp3lame", "-b:a", a.config.Bitrate, "-f", "mp3")
+	}
+	args = append(args, "-y", path)
+
+	// Not bound to a context: the encoder must be allowed to finish the file on shutdown
+	cmd := exec.Command(a.config.FFmpegPath, args...)
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, fmt.Errorf("failed to create stdin pipe: %w", err)
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("failed to start encoder: %w", err)
+	}
+
+	return &segmentWriter{
+		segment: &sqlite.RecordingSegment{
+			FrequencyID: frequencyID,
+			Path:        path,
+			Format:      format,
+			StartTime:   start,
+		},
+		cmd:            cmd,
+		stdin:          stdin,
+		bytesPerSecond: int64(sampleRate * channels * 2), // 16-bit PCM
+	}, nil
+}
+
+// finishSegment closes the encoder and indexes the segment
+func (a *Archiver) finishSegment(rec *recorder, writer *segmentWriter) {
+	segment := writer.segment
+	log := a.logger.With(String("id", segment.FrequencyID), String("path", segment.Path))
+
+	// Clips of the segment are served from the index once it is finished
+	defer rec.setCurrent(nil)
+
+	writer.stdin.Close()
+	if err := writer.cmd.Wait(); err != nil {
+		log.Error("Recording encoder exited with error", Error(err))
+	}
+
+	info, err := os.Stat(segment.Path)
+	if err != nil || info.Size() == 0 || writer.bytesWritten == 0 {
+		os.Remove(segment.Path)
+		return
+	}
+
+	// The end time is based on the audio written, so offsets into the file line up with wall-clock time
+	segment.SizeBytes = info.Size()
+	segment.DurationMs = writer.duration().Milliseconds()
+	segment.EndTime = segment.StartTime.Add(writer.duration())
+
+	id, err := a.storage.InsertSegment(segment)
+	if err != nil {
+		log.Error("Failed to index recording segment", Error(err))
+		return
+	}
+
+	log.Debug("Recording segment saved",
+		logger.Int64("segment_id", id),
+		logger.Int64("duration_ms", segment.DurationMs),
+		logger.Int64("size_bytes", segment.SizeBytes))
+}
+
+// setCurrent publishes the segment being written so it can be located before it is indexed
+func (r *recorder) setCurrent(writer *segmentWriter) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if writer == nil {
+		r.current = nil
+		return
+	}
+
+	segment := *writer.segment
+	segment.DurationMs = writer.duration().Milliseconds()
+	segment.EndTime = segment.StartTime.Add(writer.duration())
+	r.current = &segment
+}
+
+// currentSegment returns a copy of the segment being written, or nil
+func (r *recorder) currentSegment() *sqlite.RecordingSegment {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.current == nil {
+		return nil
+	}
+	segment := *r.current
+	return &segment
+}
+
+// duration returns the length of audio written to the segment
+func (w *segmentWriter) duration() time.Duration {
+	if w.bytesPerSecond == 0 {
+		return 0
+	}
+	return time.Duration(w.bytesWritten * int64(time.Second) / w.bytesPerSecond)
+}
+
+// PruneSegments deletes the segment files and index entries of segments that ended
+// before the cutoff, and returns how many were deleted
+func (a *Archiver) PruneSegments(cutoff time.Time) (int, error) {
+	segments, err := a.storage.GetSegmentsEndedBefore(cutoff)
+	if err != nil {
+		return 0, fmt.Errorf("failed to query expired recording segments: %w", err)
+	}
+
+	deleted := 0
+	for _, segment := range segments {
+		if err := os.Remove(segment.Path); err != nil && !os.IsNotExist(err) {
+			a.logger.Error("Failed to delete recording segment",
+				String("path", segment.Path),
+				Error(err))
+			continue
+		}
+		// Remove the day directory once it is empty; fails harmlessly otherwise
+		os.Remove(filepath.Dir(segment.Path))
+
+		if err := a.storage.DeleteSegment(segment.ID); err != nil {
+			a.logger.Error("Failed to delete recording segment from index", Error(err))
+			continue
+		}
+		deleted++
+	}
+	return deleted, nil
+}
+
+// safePathComponent replaces characters that are not safe in a file name
+func safePathComponent(s string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '-', r == '_':
+			return r
+		default:
+			return '_'
+		}
+	}, s)
+}
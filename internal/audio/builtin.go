@@ -0,0 +1,208 @@
+package audio
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/yegors/co-atc/pkg/logger"
+)
+
+// Audio decoders of the central audio processor
+const (
+	DecoderFFmpeg  = "ffmpeg"  // ffmpeg converts every source
+	DecoderBuiltin = "builtin" // Sources Go can read are converted in-process, the rest by ffmpeg
+)
+
+// builtinConnectTimeout bounds stream requests of the built-in decoder when ffmpeg_timeout_secs is 0
+const builtinConnectTimeout = 15 * time.Second
+
+// newBuiltinHTTPClient creates the client for streams read by the built-in decoder. Only
+// the response headers are bounded, as the body is a stream without end.
+func newBuiltinHTTPClient(timeoutSecs int) *http.Client {
+	timeout := builtinConnectTimeout
+	if timeoutSecs > 0 {
+		timeout = time.Duration(timeoutSecs) * time.Second
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.ResponseHeaderTimeout = timeout
+	return &http.Client{Transport: transport}
+}
+
+// startBuiltin converts the source's audio in Go in place of ffmpeg: SDR output, PCM and IQ
+// pipes, and WAV, L16 or MP3 HTTP streams, LiveATC's among them. There is no built-in AAC
+// decoder, so AAC streams still need ffmpeg. It returns false, without an error, for sources
+// that need ffmpeg and remembers so they go straight to ffmpeg when restarted.
+func (p *CentralAudioProcessor) startBuiltin() (bool, error) {
+	if p.format != "s16le" {
+		p.fallBackToFFmpeg(String("output_format", p.format))
+		return false, nil
+	}
+
+	format, rate, channels := p.source.Format, p.source.SampleRate, p.source.Channels
+	var src io.ReadCloser
+	switch {
+	case p.source.Type == SourceSDR:
+		stdout, err := p.startSDR()
+		if err != nil {
+			return false, err
+		}
+		src, format, channels = stdout, "s16le", 1
+
+	case p.source.IsIQ():
+		demodulator, err := NewAMDemodulator(p.source.Format, p.source.SampleRate, p.sampleRate)
+		if err != nil {
+			return false, err
+		}
+		reader, writer := io.Pipe()
+		go p.readPipe(demodulator, writer)
+		src, format, rate, channels = reader, "s16le", demodulator.OutputRate(), 1
+
+	case p.source.Type == SourcePipe:
+		if _, ok := pcmFormats[format]; !ok {
+			p.fallBackToFFmpeg(String("format", format))
+			return false, nil
+		}
+		reader, writer := io.Pipe()
+		go p.readPipe(nil, writer)
+		src = reader
+
+	case p.source.Protocol == StreamHTTP:
+		var err error
+		if src, format, rate, channels, err = p.openBuiltinStream(); err != nil {
+			if errors.Is(err, ErrUnsupportedAudio) {
+				p.fallBackToFFmpeg(Error(err))
+				return false, nil
+			}
+			return false, err
+		}
+
+	default:
+		p.fallBackToFFmpeg(String("protocol", string(p.source.Protocol)))
+		return false, nil
+	}
+
+	converter, err := NewPCMConverter(src, format, rate, channels, p.sampleRate, p.channels)
+	if err != nil {
+		src.Close()
+		return false, err
+	}
+
+	p.logger.Info("Converting audio with the built-in decoder",
+		String("format", format),
+		Int("sample_rate", rate),
+		Int("channels", channels))
+
+	p.ffmpegCmd = nil
+	p.builtin = converter
+	p.ffmpegStdout = converter
+	p.pipeline.Decoder = DecoderBuiltin
+	go p.processFFmpegOutput(converter)
+	return true, nil
+}
+
+// fallBackToFFmpeg hands a source the built-in decoder can't read to ffmpeg, with a warning
+// since ffmpeg may not be installed where the built-in decoder was chosen
+func (p *CentralAudioProcessor) fallBackToFFmpeg(reason logger.Field) {
+	p.logger.Warn("Built-in decoder can't read this source, falling back to ffmpeg",
+		reason,
+		String("ffmpeg_path", p.ffmpegPath))
+	p.needsFFmpeg = true
+}
+
+// openBuiltinStream requests an HTTP stream and returns its body positioned at the samples,
+// with their PCM format, sample rate and channels. MP3 streams are returned decoded. Streams
+// that aren't L16, WAV or MP3, AAC among them, return ErrUnsupportedAudio.
+func (p *CentralAudioProcessor) openBuiltinStream() (io.ReadCloser, string, int, int, error) {
+	req, err := http.NewRequestWithContext(p.ctx, http.MethodGet, p.source.URL, nil)
+	if err != nil {
+		return nil, "", 0, 0, fmt.Errorf("failed to create request: %w", err)
+	}
+	resp, err := p.httpClient.Do(req)
+	if err != nil {
+		return nil, "", 0, 0, fmt.Errorf("failed to request stream: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		return nil, "", 0, 0, fmt.Errorf("unexpected status code: %d", resp.StatusCode)
+	}
+
+	contentType := resp.Header.Get("Content-Type")
+	mediaType, params, _ := mime.ParseMediaType(contentType)
+
+	// Raw big-endian PCM as in RFC 2586, e.g. audio/L16;rate=16000;channels=1
+	if strings.EqualFold(mediaType, "audio/L16") {
+		rate, err := strconv.Atoi(params["rate"])
+		if err != nil || rate <= 0 {
+			resp.Body.Close()
+			return nil, "", 0, 0, fmt.Errorf("L16 stream without a valid rate: %q", contentType)
+		}
+		channels := 1
+		if value := params["channels"]; value != "" {
+			if channels, err = strconv.Atoi(value); err != nil || channels <= 0 {
+				resp.Body.Close()
+				return nil, "", 0, 0, fmt.Errorf("L16 stream with invalid channels: %q", contentType)
+			}
+		}
+		return resp.Body, "s16be", rate, channels, nil
+	}
+
+	// WAV is recognised by its header, as servers label it inconsistently
+	reader := bufio.NewReader(resp.Body)
+	magic, err := reader.Peek(4)
+	if err != nil {
+		resp.Body.Close()
+		return nil, "", 0, 0, fmt.Errorf("failed to read stream: %w", err)
+	}
+	switch {
+	case isAACStream(mediaType, magic):
+		resp.Body.Close()
+		return nil, "", 0, 0, fmt.Errorf("%w: AAC stream (%s)", ErrUnsupportedAudio, contentType)
+
+	case isMP3Stream(mediaType, magic):
+		decoder, err := NewMP3Decoder(bufferedBody{reader, resp.Body})
+		if err != nil {
+			resp.Body.Close()
+			return nil, "", 0, 0, err
+		}
+		p.logger.Info("Decoding MP3 stream",
+			Int("bitrate_kbps", decoder.Bitrate()))
+		return decoder, "f32le", decoder.SampleRate(), decoder.Channels(), nil
+
+	case string(magic) != "RIFF":
+		resp.Body.Close()
+		return nil, "", 0, 0, fmt.Errorf("%w: %s stream", ErrUnsupportedAudio, contentType)
+	}
+	format, rate, channels, err := readWAVHeader(reader)
+	if err != nil {
+		resp.Body.Close()
+		return nil, "", 0, 0, err
+	}
+	return bufferedBody{reader, resp.Body}, format, rate, channels, nil
+}
+
+// isMP3Stream reports whether a stream is MPEG audio, by its media type or its first bytes: an
+// ID3v2 tag or a frame header
+func isMP3Stream(mediaType string, magic []byte) bool {
+	switch strings.ToLower(mediaType) {
+	case "audio/mpeg", "audio/mp3", "audio/mpeg3", "audio/x-mpeg", "audio/x-mp3":
+		return true
+	}
+	return string(magic[:3]) == "ID3" || magic[0] == 0xFF && magic[1]&0xE0 == 0xE0
+}
+
+// isAACStream reports whether a stream is AAC, by its media type or an ADTS frame header,
+// which has the MPEG sync with layer 0
+func isAACStream(mediaType string, magic []byte) bool {
+	switch strings.ToLower(mediaType) {
+	case "audio/aac", "audio/aacp", "audio/x-aac", "audio/mp4", "audio/x-m4a":
+		return true
+	}
+	return magic[0] == 0xFF && magic[1]&0xF6 == 0xF0
+}
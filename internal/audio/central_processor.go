@@ -4,9 +4,12 @@ import (
 	"context"
 	"fmt"
 	"io"
+	"net/http"
+	"os"
 	"os/exec"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/yegors/co-atc/pkg/logger"
@@ -19,6 +22,29 @@ var (
 	Error  = logger.Error
 )
 
+// DefaultStallTimeout is how long ffmpeg may go without output before it's restarted
+const DefaultStallTimeout = 20 * time.Second
+
+// Reasons the ffmpeg pipeline is restarted
+const (
+	RestartExit  = "exit"  // ffmpeg's output ended or it exited
+	RestartStall = "stall" // No output for the stall timeout
+	RestartStart = "start" // A previous start failed
+)
+
+// PipelineStats shows how a processor's ffmpeg pipeline has been kept running
+type PipelineStats struct {
+	Decoder        string           `json:"decoder"`         // Decoder of the running pipeline: ffmpeg or builtin
+	StallDetection bool             `json:"stall_detection"` // Off for sources that may legitimately go quiet
+	Running        bool             `json:"running"`         // Whether the last start succeeded
+	Restarts       map[string]int   `json:"restarts"`        // By reason
+	StartFailures  int              `json:"start_failures"`
+	LastRestartAt  *time.Time       `json:"last_restart_at,omitempty"`
+	LastOutputAt   *time.Time       `json:"last_output_at,omitempty"`
+	Latency        *LatencyEstimate `json:"latency,omitempty"` // Estimated lag of a network stream behind the air
+	Buffer         MultiReaderStats `json:"buffer"`
+}
+
 // CentralAudioProcessor manages a single ffmpeg process for a frequency
 // that can be shared between browser streaming and transcription
 type CentralAudioProcessor struct {
@@ -31,7 +57,18 @@ type CentralAudioProcessor struct {
 	ffmpegReconnectDelaySecs int // FFmpeg reconnect delay in seconds
 	ffmpegCmd                *exec.Cmd
 	ffmpegStdout             io.ReadCloser
+	decoder                  string        // DecoderFFmpeg or DecoderBuiltin
+	builtin                  io.ReadCloser // Output of the built-in decoder while it replaces ffmpeg
+	needsFFmpeg              bool          // The built-in decoder found it can't read the source
+	httpClient               *http.Client  // Stream requests of the built-in decoder
+	source                   *Source
+	frequencyMHz             float64
+	rtlFMPath                string
+	soapyFMPath              string
+	sdrCmd                   *exec.Cmd // SDR tool feeding ffmpeg, for SDR sources
+	iqFile                   *os.File  // Pipe read in Go, for IQ pipe sources and the built-in decoder
 	multiReader              *MultiReader
+	levels                   *LevelMeter
 	ctx                      context.Context
 	cancel                   context.CancelFunc
 	logger                   *logger.Logger
@@ -40,10 +77,14 @@ type CentralAudioProcessor struct {
 	lastError                error
 	lastActivity             time.Time
 	reconnectTimer           *time.Timer
-	monitorTicker            *time.Ticker
 	reconnectDelay           time.Duration
 	format                   string
 	contentType              string
+	stallTimeout             time.Duration // 0 = no stall detection
+	lastOutput               atomic.Int64  // Unix nanoseconds of ffmpeg's last output
+	startedAt                time.Time     // Last start of the pipeline
+	latency                  *latencyMeter // Estimates how far a network stream lags the air (nil for local sources)
+	pipeline                 PipelineStats
 }
 
 // CentralProcessorConfig contains configuration for the central audio processor
@@ -53,8 +94,16 @@ type CentralProcessorConfig struct {
 	Channels                 int
 	Format                   string
 	ReconnectDelay           time.Duration
-	FFmpegTimeoutSecs        int // FFmpeg connection timeout in seconds (0 = no timeout)
-	FFmpegReconnectDelaySecs int // FFmpeg reconnect delay in seconds
+	FFmpegTimeoutSecs        int               // FFmpeg connection timeout in seconds (0 = no timeout)
+	FFmpegReconnectDelaySecs int               // FFmpeg reconnect delay in seconds
+	FrequencyMHz             float64           // Frequency an SDR source is tuned to
+	RTLFMPath                string            // Path to rtl_fm, for sdr://rtl_fm sources
+	SoapyFMPath              string            // Path to rx_fm, for sdr://soapy sources
+	StreamProtocol           StreamProtocol    // Protocol of a network stream, detected from the URL if empty
+	Squelch                  SquelchConfig     // Level thresholds for detecting transmissions
+	StallTimeout             time.Duration     // Restart ffmpeg after this long without output (0 = default, < 0 = never)
+	Decoder                  string            // DecoderBuiltin to convert audio in Go where possible (default: DecoderFFmpeg)
+	Buffer                   MultiReaderConfig // Buffer shared by the readers; its frame size is set from Channels
 }
 
 // NewCentralAudioProcessor creates a new central audio processor
@@ -65,10 +114,49 @@ func NewCentralAudioProcessor(
 	config CentralProcessorConfig,
 	logger *logger.Logger,
 ) (*CentralAudioProcessor, error) {
+	source, err := ParseSource(audioURL)
+	if err != nil {
+		return nil, err
+	}
+
+	if source.Type == SourceStream && config.StreamProtocol != "" {
+		source.Protocol = config.StreamProtocol
+	}
+
+	// Squelched SDRs and local pipes stay silent while nothing is received, so only
+	// sources that always produce audio can be told apart from a hung pipeline
+	stallTimeout := config.StallTimeout
+	if stallTimeout == 0 {
+		stallTimeout = DefaultStallTimeout
+	}
+	if stallTimeout < 0 || !(source.Type == SourceStream || (source.Type == SourceSDR && source.Squelch == 0)) {
+		stallTimeout = 0
+	}
+
+	decoder := config.Decoder
+	if decoder == "" {
+		decoder = DecoderFFmpeg
+	}
+	if decoder != DecoderFFmpeg && decoder != DecoderBuiltin {
+		return nil, fmt.Errorf("unknown audio decoder %q (use %s or %s)", decoder, DecoderFFmpeg, DecoderBuiltin)
+	}
+	var httpClient *http.Client
+	if decoder == DecoderBuiltin {
+		httpClient = newBuiltinHTTPClient(config.FFmpegTimeoutSecs)
+	}
+
 	procCtx, procCancel := context.WithCancel(ctx)
 
 	// Create multi-reader for sharing the stream
-	multiReader := NewMultiReader(procCtx, logger.Named("multi-reader"))
+	readerConfig := config.Buffer
+	readerConfig.FrameSize = config.Channels * 2 // s16le
+	multiReader := NewMultiReader(procCtx, readerConfig, logger.Named("multi-reader").With(String("id", id)))
+
+	// Local sources are live; only network streams arrive with the server's buffering
+	var latency *latencyMeter
+	if source.Type == SourceStream {
+		latency = newLatencyMeter(config.SampleRate, config.Channels)
+	}
 
 	return &CentralAudioProcessor{
 		id:                       id,
@@ -78,7 +166,12 @@ func NewCentralAudioProcessor(
 		channels:                 config.Channels,
 		ffmpegTimeoutSecs:        config.FFmpegTimeoutSecs,
 		ffmpegReconnectDelaySecs: config.FFmpegReconnectDelaySecs,
+		source:                   source,
+		frequencyMHz:             config.FrequencyMHz,
+		rtlFMPath:                config.RTLFMPath,
+		soapyFMPath:              config.SoapyFMPath,
 		multiReader:              multiReader,
+		levels:                   NewLevelMeter(config.Squelch, config.SampleRate, config.Channels),
 		ctx:                      procCtx,
 		cancel:                   procCancel,
 		logger:                   logger.Named("central-audio-processor").With(String("id", id)),
@@ -87,6 +180,14 @@ func NewCentralAudioProcessor(
 		contentType:              "audio/wav", // We'll be serving WAV format
 		format:                   config.Format,
 		reconnectDelay:           config.ReconnectDelay,
+		stallTimeout:             stallTimeout,
+		decoder:                  decoder,
+		httpClient:               httpClient,
+		latency:                  latency,
+		pipeline: PipelineStats{
+			StallDetection: stallTimeout > 0,
+			Restarts:       make(map[string]int),
+		},
 	}, nil
 }
 
@@ -105,9 +206,12 @@ func (p *CentralAudioProcessor) Start() error {
 		Int("channels", p.channels))
 
 	// Start the ffmpeg process
+	p.startedAt = time.Now()
+	p.startLatency(p.startedAt)
 	if err := p.startFFmpeg(); err != nil {
 		return fmt.Errorf("failed to start ffmpeg: %w", err)
 	}
+	p.pipeline.Running = true
 
 	// Start monitoring the ffmpeg process
 	p.startMonitoring()
@@ -127,13 +231,7 @@ func (p *CentralAudioProcessor) Stop() error {
 
 	p.logger.Info("Stopping central audio processor")
 
-	// Stop monitoring
-	if p.monitorTicker != nil {
-		p.monitorTicker.Stop()
-		p.monitorTicker = nil
-	}
-
-	// Cancel context to stop all operations
+	// Cancel context to stop all operations, monitoring included
 	p.cancel()
 
 	// Stop ffmpeg
@@ -150,34 +248,62 @@ func (p *CentralAudioProcessor) Stop() error {
 func (p *CentralAudioProcessor) startFFmpeg() error {
 	p.logger.Debug("Starting ffmpeg process",
 		String("path", p.ffmpegPath),
-		String("url", p.audioURL))
+		String("url", p.audioURL),
+		String("protocol", string(p.source.Protocol)))
+
+	if p.decoder == DecoderBuiltin && !p.needsFFmpeg {
+		started, err := p.startBuiltin()
+		if started || err != nil {
+			return err
+		}
+	}
+	p.pipeline.Decoder = DecoderFFmpeg
 
 	// Create FFmpeg command with different options based on stream type
 	var args []string
 
-	// Check if this is an SRT stream
-	if strings.HasPrefix(p.audioURL, "srt://") {
+	// Local sources are raw audio, so ffmpeg only needs to convert it
+	if p.source.Type != SourceStream {
+		return p.startLocalSource()
+	}
+
+	args = []string{
+		"-loglevel", "error", // Minimal logging
+		"-fflags", "nobuffer", // Disable input buffering
+		"-flags", "low_delay", // Enable low delay mode
+	}
+
+	switch p.source.Protocol {
+	case StreamSRT:
 		// SRT stream configuration - optimized for low latency
-		args = []string{
-			"-loglevel", "error", // Minimal logging
-			"-fflags", "nobuffer", // Disable input buffering
-			"-flags", "low_delay", // Enable low delay mode
-			"-i", p.audioURL, // Input SRT URL
-			"-f", p.format, // Output format (should be s16le for raw PCM)
-			"-acodec", "pcm_s16le", // Audio codec
-			"-ac", fmt.Sprintf("%d", p.channels), // Channels
-			"-ar", fmt.Sprintf("%d", p.sampleRate), // Sample rate
-			"-flush_packets", "1", // Flush packets immediately
-			"pipe:1", // Output to stdout
+
+	case StreamRTSP:
+		// RTSP has no reconnect option; when the session drops ffmpeg exits and the
+		// monitor restarts it. TCP interleaving survives NAT and lossy links better than UDP.
+		args = append(args, "-rtsp_transport", p.source.RTSPTransport)
+		if p.ffmpegTimeoutSecs > 0 {
+			args = append(args, "-timeout", fmt.Sprintf("%d", p.ffmpegTimeoutSecs*1000000))
 		}
-	} else {
-		// HTTP stream configuration - optimized for low latency with reconnection
-		args = []string{
-			"-loglevel", "error", // Minimal logging
-			"-fflags", "nobuffer", // Disable input buffering
-			"-flags", "low_delay", // Enable low delay mode
+
+	case StreamHLS:
+		// Start near the live edge rather than at the oldest segment of the playlist, and
+		// keep reloading a playlist that stops advancing instead of giving up. Segment
+		// requests are retried by the HTTP reconnect options; reconnect_at_eof is left
+		// out because it would re-request every segment once it has been read.
+		if p.ffmpegTimeoutSecs > 0 {
+			args = append(args, "-rw_timeout", fmt.Sprintf("%d", p.ffmpegTimeoutSecs*1000000))
 		}
+		args = append(args,
+			"-live_start_index", fmt.Sprintf("%d", -hlsLiveStartSegments),
+			"-max_reload", fmt.Sprintf("%d", hlsMaxReload),
+			"-http_persistent", "1",
+			"-reconnect", "1",
+			"-reconnect_streamed", "1",
+			"-reconnect_delay_max", fmt.Sprintf("%d", p.ffmpegReconnectDelaySecs),
+		)
 
+	default:
+		// HTTP stream configuration - optimized for low latency with reconnection
 		// Add timeout if configured (convert seconds to microseconds)
 		if p.ffmpegTimeoutSecs > 0 {
 			timeoutMicros := p.ffmpegTimeoutSecs * 1000000
@@ -190,19 +316,28 @@ func (p *CentralAudioProcessor) startFFmpeg() error {
 			"-reconnect_at_eof", "1", // Reconnect at end of file
 			"-reconnect_streamed", "1", // Reconnect for streamed inputs
 			"-reconnect_delay_max", fmt.Sprintf("%d", p.ffmpegReconnectDelaySecs), // Configurable reconnect delay
-			"-i", p.audioURL, // Input URL
-			"-f", p.format, // Output format (should be s16le for raw PCM)
-			"-acodec", "pcm_s16le", // Audio codec
-			"-ac", fmt.Sprintf("%d", p.channels), // Channels
-			"-ar", fmt.Sprintf("%d", p.sampleRate), // Sample rate
-			"-flush_packets", "1", // Flush packets immediately
-			"pipe:1", // Output to stdout
 		)
 	}
 
+	args = append(args,
+		"-i", p.source.URL, // Input URL
+		"-vn",          // RTSP cameras and HLS renditions may carry video
+		"-f", p.format, // Output format (should be s16le for raw PCM)
+		"-acodec", "pcm_s16le", // Audio codec
+		"-ac", fmt.Sprintf("%d", p.channels), // Channels
+		"-ar", fmt.Sprintf("%d", p.sampleRate), // Sample rate
+		"-flush_packets", "1", // Flush packets immediately
+		"pipe:1", // Output to stdout
+	)
+
 	// Create ffmpeg command with enhanced arguments
 	p.ffmpegCmd = exec.CommandContext(p.ctx, p.ffmpegPath, args...)
 
+	return p.runFFmpeg()
+}
+
+// runFFmpeg starts the prepared ffmpeg command and copies its output to the readers
+func (p *CentralAudioProcessor) runFFmpeg() error {
 	// Get stdout pipe
 	var err error
 	p.ffmpegStdout, err = p.ffmpegCmd.StdoutPipe()
@@ -216,11 +351,142 @@ func (p *CentralAudioProcessor) startFFmpeg() error {
 	}
 
 	// Start copying data from ffmpeg to multi-reader
-	go p.processFFmpegOutput()
+	go p.processFFmpegOutput(p.ffmpegStdout)
 
 	return nil
 }
 
+// startLocalSource starts ffmpeg on a local SDR or pipe source. SDR tools and IQ
+// demodulation feed ffmpeg's stdin, so they are restarted together with ffmpeg.
+func (p *CentralAudioProcessor) startLocalSource() error {
+	inputRate, inputChannels, inputFormat, input := p.source.SampleRate, p.source.Channels, p.source.Format, "pipe:0"
+
+	var sdrOut io.ReadCloser
+	var stdin io.WriteCloser
+	var demodulator *AMDemodulator
+	var err error
+	switch {
+	case p.source.Type == SourceSDR:
+		inputChannels, inputFormat = 1, "s16le"
+
+		if sdrOut, err = p.startSDR(); err != nil {
+			return err
+		}
+
+		// ffmpeg reads the SDR's stdout directly; when either exits the other sees EOF
+		// or a broken pipe and the usual restart brings both back
+		p.ffmpegCmd = exec.CommandContext(p.ctx, p.ffmpegPath, p.localFFmpegArgs(inputFormat, inputRate, inputChannels, input)...)
+		p.ffmpegCmd.Stdin = sdrOut
+
+	case p.source.IsIQ():
+		if demodulator, err = NewAMDemodulator(p.source.Format, p.source.SampleRate, p.sampleRate); err != nil {
+			return err
+		}
+		inputRate, inputChannels, inputFormat = demodulator.OutputRate(), 1, "s16le"
+
+		p.ffmpegCmd = exec.CommandContext(p.ctx, p.ffmpegPath, p.localFFmpegArgs(inputFormat, inputRate, inputChannels, input)...)
+		if stdin, err = p.ffmpegCmd.StdinPipe(); err != nil {
+			return fmt.Errorf("failed to create stdin pipe: %w", err)
+		}
+
+	default:
+		// ffmpeg reads PCM pipes itself
+		p.ffmpegCmd = exec.CommandContext(p.ctx, p.ffmpegPath, p.localFFmpegArgs(inputFormat, inputRate, inputChannels, p.source.Path)...)
+	}
+
+	err = p.runFFmpeg()
+
+	// ffmpeg has its own copy of the SDR pipe now
+	if sdrOut != nil {
+		sdrOut.Close()
+	}
+	if err != nil {
+		return err
+	}
+
+	if demodulator != nil {
+		go p.readPipe(demodulator, stdin)
+	}
+	return nil
+}
+
+// startSDR starts the SDR tool and returns its mono s16le output
+func (p *CentralAudioProcessor) startSDR() (io.ReadCloser, error) {
+	sdrPath := p.rtlFMPath
+	if p.source.Driver == SDRDriverSoapy {
+		sdrPath = p.soapyFMPath
+	}
+	args := p.source.sdrArgs(p.frequencyMHz)
+	p.logger.Info("Starting SDR", String("path", sdrPath), String("args", strings.Join(args, " ")))
+
+	p.sdrCmd = exec.CommandContext(p.ctx, sdrPath, args...)
+	sdrOut, err := p.sdrCmd.StdoutPipe()
+	if err != nil {
+		p.sdrCmd = nil
+		return nil, fmt.Errorf("failed to create SDR pipe: %w", err)
+	}
+	if err := p.sdrCmd.Start(); err != nil {
+		p.sdrCmd = nil
+		return nil, fmt.Errorf("failed to start %s: %w", sdrPath, err)
+	}
+	return sdrOut, nil
+}
+
+// localFFmpegArgs returns the ffmpeg arguments to convert raw audio to the output format
+func (p *CentralAudioProcessor) localFFmpegArgs(inputFormat string, inputRate, inputChannels int, input string) []string {
+	return []string{
+		"-loglevel", "error",
+		"-f", inputFormat,
+		"-ar", fmt.Sprintf("%d", inputRate),
+		"-ac", fmt.Sprintf("%d", inputChannels),
+		"-i", input,
+		"-f", p.format,
+		"-acodec", "pcm_s16le",
+		"-ac", fmt.Sprintf("%d", p.channels),
+		"-ar", fmt.Sprintf("%d", p.sampleRate),
+		"-flush_packets", "1",
+		"pipe:1",
+	}
+}
+
+// readPipe reads the pipe and writes its samples, AM-demodulated if a demodulator is given, to
+// ffmpeg or the built-in decoder until either side closes. Opening a named pipe blocks until a
+// writer connects.
+func (p *CentralAudioProcessor) readPipe(demodulator *AMDemodulator, stdin io.WriteCloser) {
+	defer stdin.Close()
+
+	file, err := os.Open(p.source.Path)
+	if err != nil {
+		p.logger.Error("Failed to open pipe", String("path", p.source.Path), Error(err))
+		return
+	}
+	defer file.Close()
+
+	p.mu.Lock()
+	p.iqFile = file
+	p.mu.Unlock()
+
+	buffer := make([]byte, 64*1024)
+	for {
+		n, err := file.Read(buffer)
+		if n > 0 {
+			data := buffer[:n]
+			if demodulator != nil {
+				data = demodulator.Process(data)
+			}
+			if _, writeErr := stdin.Write(data); writeErr != nil {
+				return
+			}
+		}
+		if err != nil {
+			if err != io.EOF && p.ctx.Err() == nil {
+				p.logger.Error("Error reading pipe", Error(err))
+			}
+			return
+		}
+	}
+}
+
 // stopFFmpeg stops the ffmpeg process
 func (p *CentralAudioProcessor) stopFFmpeg() {
 	if p.ffmpegCmd != nil && p.ffmpegCmd.Process != nil {
@@ -235,6 +501,22 @@ func (p *CentralAudioProcessor) stopFFmpeg() {
 		_ = p.ffmpegCmd.Wait()
 	}
 
+	if p.builtin != nil {
+		_ = p.builtin.Close()
+		p.builtin = nil
+	}
+
+	// The source feeding ffmpeg goes with it
+	if p.sdrCmd != nil && p.sdrCmd.Process != nil {
+		_ = p.sdrCmd.Process.Kill()
+		_ = p.sdrCmd.Wait()
+	}
+	p.sdrCmd = nil
+	if p.iqFile != nil {
+		p.iqFile.Close()
+		p.iqFile = nil
+	}
+
 	if p.reconnectTimer != nil {
 		p.reconnectTimer.Stop()
 		p.reconnectTimer = nil
@@ -242,7 +524,7 @@ func (p *CentralAudioProcessor) stopFFmpeg() {
 }
 
 // processFFmpegOutput processes the output from ffmpeg
-func (p *CentralAudioProcessor) processFFmpegOutput() {
+func (p *CentralAudioProcessor) processFFmpegOutput(stdout io.ReadCloser) {
 	p.logger.Info("Starting to process ffmpeg output")
 
 	// Create buffer for reading
@@ -258,8 +540,16 @@ func (p *CentralAudioProcessor) processFFmpegOutput() {
 			return
 		default:
 			// Read from ffmpeg
-			n, err := p.ffmpegStdout.Read(buffer)
+			n, err := stdout.Read(buffer)
 			if err != nil {
+				// A pipeline that was already replaced, e.g. after a stall, needs no restart
+				p.mu.Lock()
+				replaced := p.ffmpegStdout != stdout
+				p.mu.Unlock()
+				if replaced {
+					return
+				}
+
 				if err == io.EOF {
 					p.logger.Warn("FFmpeg output ended unexpectedly",
 						Int("total_bytes_processed", bytesProcessed),
@@ -271,6 +561,9 @@ func (p *CentralAudioProcessor) processFFmpegOutput() {
 					p.lastError = err
 				}
 
+				// A broken stream ends any transmission in progress
+				p.levels.Reset()
+
 				// Attempt to restart ffmpeg after a delay
 				p.mu.Lock()
 				if p.isRunning && p.reconnectTimer == nil {
@@ -284,12 +577,7 @@ func (p *CentralAudioProcessor) processFFmpegOutput() {
 						p.reconnectTimer = nil
 						if p.isRunning {
 							p.logger.Info("Executing scheduled ffmpeg restart")
-							p.stopFFmpeg()
-							if err := p.startFFmpeg(); err != nil {
-								p.logger.Error("Failed to restart ffmpeg", Error(err))
-							} else {
-								p.logger.Info("FFmpeg restarted successfully")
-							}
+							p.restartFFmpeg(RestartExit)
 						}
 					})
 				}
@@ -301,6 +589,10 @@ func (p *CentralAudioProcessor) processFFmpegOutput() {
 				bytesProcessed += n
 				// Update last activity time
 				p.lastActivity = time.Now()
+				p.lastOutput.Store(p.lastActivity.UnixNano())
+				if p.latency != nil {
+					p.latency.observe(p.lastActivity, n)
+				}
 
 				// Log progress every 30 seconds
 				if time.Since(lastLogTime) > 30*time.Second {
@@ -317,6 +609,8 @@ func (p *CentralAudioProcessor) processFFmpegOutput() {
 						Int("bytes_processed_before_error", bytesProcessed))
 					return
 				}
+
+				p.levels.Write(buffer[:n])
 			}
 		}
 	}
@@ -324,26 +618,29 @@ func (p *CentralAudioProcessor) processFFmpegOutput() {
 
 // startMonitoring starts monitoring the ffmpeg process
 func (p *CentralAudioProcessor) startMonitoring() {
-	p.monitorTicker = time.NewTicker(5 * time.Second)
-
+	ctx := p.ctx
 	go func() {
+		ticker := time.NewTicker(5 * time.Second)
+		defer ticker.Stop()
 		for {
 			select {
-			case <-p.ctx.Done():
+			case <-ctx.Done():
 				return
-			case <-p.monitorTicker.C:
+			case <-ticker.C:
 				p.mu.Lock()
-				if p.isRunning && p.ffmpegCmd != nil && p.ffmpegCmd.ProcessState != nil {
-					// Process has exited
-					p.logger.Warn("FFmpeg process has exited unexpectedly")
-
-					// Only restart if we're still running
-					if p.isRunning && p.reconnectTimer == nil {
-						p.logger.Info("Restarting ffmpeg after unexpected exit")
-						p.stopFFmpeg()
-						if err := p.startFFmpeg(); err != nil {
-							p.logger.Error("Failed to restart ffmpeg", Error(err))
-						}
+				// A restart scheduled after a read error is already on its way
+				if p.isRunning && p.reconnectTimer == nil {
+					switch {
+					case !p.pipeline.Running:
+						p.logger.Info("Retrying ffmpeg after failed start")
+						p.restartFFmpeg(RestartStart)
+					case p.ffmpegCmd != nil && p.ffmpegCmd.ProcessState != nil:
+						p.logger.Warn("FFmpeg process has exited unexpectedly, restarting")
+						p.restartFFmpeg(RestartExit)
+					case p.stalled(time.Now()):
+						p.logger.Warn("FFmpeg produced no output, killing and restarting",
+							String("stall_timeout", p.stallTimeout.String()))
+						p.restartFFmpeg(RestartStall)
 					}
 				}
 				p.mu.Unlock()
@@ -352,15 +649,89 @@ func (p *CentralAudioProcessor) startMonitoring() {
 	}()
 }
 
+// stalled reports whether the pipeline has gone without output for the stall timeout since
+// its last start. The caller holds p.mu.
+func (p *CentralAudioProcessor) stalled(now time.Time) bool {
+	if p.stallTimeout <= 0 {
+		return false
+	}
+	since := p.startedAt
+	if last := time.Unix(0, p.lastOutput.Load()); last.After(since) {
+		since = last
+	}
+	return now.Sub(since) >= p.stallTimeout
+}
+
+// restartFFmpeg kills the pipeline and starts it again, counting the restart by reason.
+// The caller holds p.mu.
+func (p *CentralAudioProcessor) restartFFmpeg(reason string) {
+	p.stopFFmpeg()
+	p.levels.Reset()
+
+	now := time.Now()
+	p.startedAt = now
+	p.startLatency(now)
+	p.pipeline.Restarts[reason]++
+	p.pipeline.LastRestartAt = &now
+
+	if err := p.startFFmpeg(); err != nil {
+		p.logger.Error("Failed to restart ffmpeg", String("reason", reason), Error(err))
+		p.pipeline.Running = false
+		p.pipeline.StartFailures++
+		p.lastError = err
+		return
+	}
+	p.logger.Info("FFmpeg restarted successfully", String("reason", reason))
+	p.pipeline.Running = true
+	p.lastError = nil
+}
+
+// PipelineStats returns how the ffmpeg pipeline has been kept running
+func (p *CentralAudioProcessor) PipelineStats() PipelineStats {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	stats := p.pipeline
+	stats.Restarts = make(map[string]int, len(p.pipeline.Restarts))
+	for reason, count := range p.pipeline.Restarts {
+		stats.Restarts[reason] = count
+	}
+	if last := p.lastOutput.Load(); last > 0 {
+		at := time.Unix(0, last)
+		stats.LastOutputAt = &at
+	}
+	stats.Buffer = p.multiReader.Stats()
+	stats.Latency = p.Latency()
+	return stats
+}
+
+// startLatency begins measuring the latency of a pipeline started at now
+func (p *CentralAudioProcessor) startLatency(now time.Time) {
+	if p.latency != nil {
+		p.latency.start(now)
+	}
+}
+
+// Latency returns the estimated lag of a network stream behind the air, or nil for a local
+// source or before the stream's first audio
+func (p *CentralAudioProcessor) Latency() *LatencyEstimate {
+	if p.latency == nil {
+		return nil
+	}
+	return p.latency.get()
+}
+
 // CreateReader creates a new reader for the audio stream
 func (p *CentralAudioProcessor) CreateReader(id string) (io.ReadCloser, error) {
 	p.mu.Lock()
 	defer p.mu.Unlock()
 
 	if !p.isRunning {
+		p.startedAt = time.Now()
 		if err := p.startFFmpeg(); err != nil {
 			return nil, fmt.Errorf("failed to start processor: %w", err)
 		}
+		p.pipeline.Running = true
 		p.isRunning = true
 	}
 
@@ -369,6 +740,58 @@ func (p *CentralAudioProcessor) CreateReader(id string) (io.ReadCloser, error) {
 	return NewWAVReader(reader, p.sampleRate, p.channels), nil
 }
 
+// CreateRawReader creates a new reader for the raw PCM stream, without a WAV header
+func (p *CentralAudioProcessor) CreateRawReader(id string) (io.ReadCloser, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if !p.isRunning {
+		p.startedAt = time.Now()
+		if err := p.startFFmpeg(); err != nil {
+			return nil, fmt.Errorf("failed to start processor: %w", err)
+		}
+		p.pipeline.Running = true
+		p.isRunning = true
+	}
+
+	return p.multiReader.CreateReader(id), nil
+}
+
+// SampleRate returns the sample rate of the PCM stream
+func (p *CentralAudioProcessor) SampleRate() int {
+	return p.sampleRate
+}
+
+// Channels returns the number of channels in the PCM stream
+func (p *CentralAudioProcessor) Channels() int {
+	return p.channels
+}
+
+// Level returns the latest audio level and squelch state
+func (p *CentralAudioProcessor) Level() AudioLevel {
+	return p.levels.Level()
+}
+
+// SquelchOpen reports whether a transmission is in progress
+func (p *CentralAudioProcessor) SquelchOpen() bool {
+	return p.levels.SquelchOpen()
+}
+
+// OnSquelch registers a function that is called when a transmission starts or ends
+func (p *CentralAudioProcessor) OnSquelch(fn func(SquelchEvent)) {
+	p.levels.OnSquelch(fn)
+}
+
+// ReaderLag returns how far a reader is behind live audio, or false if there's no such reader
+func (p *CentralAudioProcessor) ReaderLag(id string) (time.Duration, bool) {
+	lag, ok := p.multiReader.Lag(id)
+	if !ok {
+		return 0, false
+	}
+	bytesPerSecond := int64(p.sampleRate * p.channels * 2) // s16le
+	return time.Duration(lag * int64(time.Second) / bytesPerSecond), true
+}
+
 // RemoveReader removes a reader
 func (p *CentralAudioProcessor) RemoveReader(id string) {
 	p.multiReader.RemoveReader(id)
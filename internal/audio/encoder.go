@@ -0,0 +1,122 @@
+package audio
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os/exec"
+	"sync"
+)
+
+// Listener stream formats besides the default WAV
+const (
+	StreamFormatWebM = "webm" // Opus in WebM, for Chrome/Firefox MediaSource and <audio>
+	StreamFormatOgg  = "ogg"  // Opus in Ogg, for Firefox and native players
+)
+
+// opusPacketsPerPage is how many Opus frames are muxed into each WebM cluster or Ogg page.
+// Containers hold packets back until a cluster/page is complete, so this bounds the
+// latency the muxer adds on top of the frame size.
+const opusPacketsPerPage = 5
+
+// EncoderConfig contains settings for transcoding a listener stream
+type EncoderConfig struct {
+	FFmpegPath string
+	Format     string // StreamFormatWebM or StreamFormatOgg
+	Bitrate    string // Opus bitrate, e.g. "24k"
+	FrameMs    int    // Opus frame duration in milliseconds (5, 10, 20, 40 or 60)
+}
+
+// StreamContentType returns the content type of a listener stream format
+func StreamContentType(format string) string {
+	switch format {
+	case StreamFormatWebM:
+		return "audio/webm; codecs=opus"
+	case StreamFormatOgg:
+		return "audio/ogg; codecs=opus"
+	default:
+		return "audio/wav"
+	}
+}
+
+// StreamEncoder transcodes a WAV listener stream to Opus with its own ffmpeg process.
+// Reading returns the encoded stream; closing stops ffmpeg and closes the source.
+type StreamEncoder struct {
+	cmd    *exec.Cmd
+	stdout io.ReadCloser
+	source io.ReadCloser
+	once   sync.Once
+}
+
+// NewStreamEncoder starts an encoder reading the WAV stream from source
+func NewStreamEncoder(ctx context.Context, config EncoderConfig, source io.ReadCloser) (*StreamEncoder, error) {
+	if config.Format != StreamFormatWebM && config.Format != StreamFormatOgg {
+		return nil, fmt.Errorf("unsupported stream format %q", config.Format)
+	}
+
+	// Flush each container page after a handful of frames instead of ffmpeg's defaults
+	// (one second for Ogg, a few seconds for WebM)
+	pageMs := config.FrameMs * opusPacketsPerPage
+
+	args := []string{
+		"-loglevel", "error",
+		"-fflags", "nobuffer",
+		"-flags", "low_delay",
+		"-probesize", "32",
+		"-analyzeduration", "0",
+		"-f", "wav",
+		"-i", "pipe:0",
+		"-c:a", "libopus",
+		"-b:a", config.Bitrate,
+		"-application", "lowdelay",
+		"-frame_duration", fmt.Sprintf("%d", config.FrameMs),
+	}
+	if config.Format == StreamFormatWebM {
+		args = append(args, "-f", "webm", "-live", "1", "-cluster_time_limit", fmt.Sprintf("%d", pageMs))
+	} else {
+		args = append(args, "-f", "ogg", "-page_duration", fmt.Sprintf("%d", pageMs*1000))
+	}
+	args = append(args, "-flush_packets", "1", "pipe:1")
+
+	cmd := exec.CommandContext(ctx, config.FFmpegPath, args...)
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, fmt.Errorf("failed to create stdin pipe: %w", err)
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, fmt.Errorf("failed to create stdout pipe: %w", err)
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("failed to start encoder: %w", err)
+	}
+
+	// Feed the listener stream to ffmpeg until either side closes
+	go func() {
+		_, _ = io.Copy(stdin, source)
+		stdin.Close()
+	}()
+
+	return &StreamEncoder{
+		cmd:    cmd,
+		stdout: stdout,
+		source: source,
+	}, nil
+}
+
+// Read implements the io.Reader interface
+func (e *StreamEncoder) Read(p []byte) (int, error) {
+	return e.stdout.Read(p)
+}
+
+// Close stops the encoder and closes the source stream
+func (e *StreamEncoder) Close() error {
+	e.once.Do(func() {
+		e.source.Close()
+		if e.cmd.Process != nil {
+			_ = e.cmd.Process.Kill()
+		}
+		_ = e.cmd.Wait()
+	})
+	return nil
+}
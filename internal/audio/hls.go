@@ -0,0 +1,271 @@
+package audio
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"sync"
+	"time"
+
+	"github.com/yegors/co-atc/pkg/logger"
+)
+
+// HLSPlaylistName is the file name of each frequency's live playlist
+const HLSPlaylistName = "playlist.m3u8"
+
+// ErrHLSUnavailable is returned when the packager can't produce a playlist in time
+var ErrHLSUnavailable = errors.New("HLS stream not available")
+
+// ErrHLSSegmentNotFound is returned for segment names that aren't (or are no longer) in the playlist
+var ErrHLSSegmentNotFound = errors.New("HLS segment not found")
+
+// hlsSegmentName matches the segment files written by ffmpeg
+var hlsSegmentName = regexp.MustCompile(`^seg[0-9]+\.ts$`)
+
+// HLSConfig contains settings for the HLS packager
+type HLSConfig struct {
+	FFmpegPath       string
+	OutputDir        string        // Each packaged frequency gets a subdirectory, removed when it stops
+	SegmentDuration  time.Duration // Target duration of each segment
+	PlaylistSegments int           // Segments listed in the live playlist
+	Bitrate          string        // AAC bitrate
+	IdleTimeout      time.Duration // Packaging stops when nobody fetched the playlist for this long
+	ReconnectDelay   time.Duration // Delay before re-attaching to a stream that stopped
+}
+
+// HLSPackager segments frequencies into AAC HLS streams for players that handle HLS better
+// than a long-lived HTTP response, like iOS Safari and mobile background playback. A frequency
+// is only packaged while someone is fetching its playlist.
+type HLSPackager struct {
+	config  HLSConfig
+	logger  *logger.Logger
+	ctx     context.Context
+	cancel  context.CancelFunc
+	streams map[string]*hlsStream
+	mu      sync.Mutex
+	wg      sync.WaitGroup
+}
+
+// hlsStream is the packaging of one frequency
+type hlsStream struct {
+	dir         string
+	cancel      context.CancelFunc
+	lastRequest time.Time
+}
+
+// NewHLSPackager creates a new HLS packager
+func NewHLSPackager(ctx context.Context, config HLSConfig, logger *logger.Logger) *HLSPackager {
+	packagerCtx, cancel := context.WithCancel(ctx)
+	p := &HLSPackager{
+		config:  config,
+		logger:  logger.Named("hls"),
+		ctx:     packagerCtx,
+		cancel:  cancel,
+		streams: make(map[string]*hlsStream),
+	}
+
+	p.wg.Add(1)
+	go p.idleLoop()
+
+	return p
+}
+
+// Stop stops packaging every frequency and removes the segments
+func (p *HLSPackager) Stop() {
+	p.cancel()
+	p.wg.Wait()
+}
+
+// Playlist returns the path of a frequency's live playlist, starting the packager if needed
+// and waiting until the first segments are written
+func (p *HLSPackager) Playlist(ctx context.Context, frequencyID string, processor *CentralAudioProcessor) (string, error) {
+	stream := p.touch(frequencyID, processor)
+	path := filepath.Join(stream.dir, HLSPlaylistName)
+
+	wait := time.NewTimer(p.config.SegmentDuration*3 + 5*time.Second)
+	defer wait.Stop()
+	poll := time.NewTicker(200 * time.Millisecond)
+	defer poll.Stop()
+
+	for {
+		if _, err := os.Stat(path); err == nil {
+			return path, nil
+		}
+		select {
+		case <-ctx.Done():
+			return "", ctx.Err()
+		case <-wait.C:
+			return "", ErrHLSUnavailable
+		case <-poll.C:
+		}
+	}
+}
+
+// Segment returns the path of a segment of a frequency that is being packaged
+func (p *HLSPackager) Segment(frequencyID, name string) (string, error) {
+	if !hlsSegmentName.MatchString(name) {
+		return "", ErrHLSSegmentNotFound
+	}
+
+	p.mu.Lock()
+	stream, ok := p.streams[frequencyID]
+	if ok {
+		stream.lastRequest = time.Now()
+	}
+	p.mu.Unlock()
+	if !ok {
+		return "", ErrHLSSegmentNotFound
+	}
+
+	path := filepath.Join(stream.dir, name)
+	if _, err := os.Stat(path); err != nil {
+		return "", ErrHLSSegmentNotFound
+	}
+	return path, nil
+}
+
+// RemoveFrequency stops packaging a frequency
+func (p *HLSPackager) RemoveFrequency(frequencyID string) {
+	p.mu.Lock()
+	stream, ok := p.streams[frequencyID]
+	delete(p.streams, frequencyID)
+	p.mu.Unlock()
+
+	if ok {
+		stream.cancel()
+	}
+}
+
+// touch records a playlist request, starting the frequency's packaging if it isn't running
+func (p *HLSPackager) touch(frequencyID string, processor *CentralAudioProcessor) *hlsStream {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if stream, ok := p.streams[frequencyID]; ok {
+		stream.lastRequest = time.Now()
+		return stream
+	}
+
+	ctx, cancel := context.WithCancel(p.ctx)
+	stream := &hlsStream{
+		// A fresh directory per run, so a stopping run can't delete a new run's files
+		dir:         filepath.Join(p.config.OutputDir, fmt.Sprintf("%s-%d", safePathComponent(frequencyID), time.Now().UnixNano())),
+		cancel:      cancel,
+		lastRequest: time.Now(),
+	}
+	p.streams[frequencyID] = stream
+
+	p.logger.Info("Starting HLS packaging", String("id", frequencyID))
+
+	p.wg.Add(1)
+	go func() {
+		defer p.wg.Done()
+		p.run(ctx, frequencyID, stream.dir, processor)
+	}()
+
+	return stream
+}
+
+// idleLoop stops packaging frequencies nobody is listening to
+func (p *HLSPackager) idleLoop() {
+	defer p.wg.Done()
+
+	ticker := time.NewTicker(10 * time.Second)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-p.ctx.Done():
+			return
+		case <-ticker.C:
+			p.mu.Lock()
+			for id, stream := range p.streams {
+				if time.Since(stream.lastRequest) > p.config.IdleTimeout {
+					p.logger.Info("Stopping idle HLS packaging", String("id", id))
+					stream.cancel()
+					delete(p.streams, id)
+				}
+			}
+			p.mu.Unlock()
+		}
+	}
+}
+
+// run feeds a frequency's audio to an ffmpeg HLS muxer until the context is canceled.
+// When the stream breaks, the muxer is restarted and appends to the existing playlist
+// with a discontinuity, so players keep going.
+func (p *HLSPackager) run(ctx context.Context, frequencyID, dir string, processor *CentralAudioProcessor) {
+	readerID := "hls-" + filepath.Base(dir)
+	log := p.logger.With(String("id", frequencyID))
+
+	defer os.RemoveAll(dir)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		log.Error("Failed to create HLS directory", Error(err))
+		return
+	}
+
+	// Removing the reader wakes up a blocked Read when packaging is stopped
+	go func() {
+		<-ctx.Done()
+		processor.RemoveReader(readerID)
+	}()
+
+	for ctx.Err() == nil {
+		reader, err := processor.CreateRawReader(readerID)
+		if err != nil {
+			log.Error("Failed to attach HLS packager to audio stream", Error(err))
+		} else {
+			if err := p.segment(ctx, dir, reader, processor.SampleRate(), processor.Channels()); err != nil && ctx.Err() == nil {
+				log.Warn("HLS muxer stopped", Error(err))
+			}
+			reader.Close()
+		}
+
+		select {
+		case <-ctx.Done():
+		case <-time.After(p.config.ReconnectDelay):
+		}
+	}
+}
+
+// segment runs one ffmpeg HLS muxer on the raw PCM reader until either side stops
+func (p *HLSPackager) segment(ctx context.Context, dir string, reader io.Reader, sampleRate, channels int) error {
+	args := []string{
+		"-loglevel", "error",
+		"-f", "s16le",
+		"-ar", fmt.Sprintf("%d", sampleRate),
+		"-ac", fmt.Sprintf("%d", channels),
+		"-i", "pipe:0",
+		"-c:a", "aac",
+		"-b:a", p.config.Bitrate,
+		"-f", "hls",
+		"-hls_time", fmt.Sprintf("%g", p.config.SegmentDuration.Seconds()),
+		"-hls_list_size", fmt.Sprintf("%d", p.config.PlaylistSegments),
+		"-hls_flags", "delete_segments+append_list+omit_endlist+discont_start",
+		"-hls_segment_filename", filepath.Join(dir, "seg%05d.ts"),
+		filepath.Join(dir, HLSPlaylistName),
+	}
+
+	cmd := exec.CommandContext(ctx, p.config.FFmpegPath, args...)
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return fmt.Errorf("failed to create stdin pipe: %w", err)
+	}
+	if err := cmd.Start(); err != nil {
+		return fmt.Errorf("failed to start HLS muxer: %w", err)
+	}
+
+	_, copyErr := io.Copy(stdin, reader)
+	stdin.Close()
+	waitErr := cmd.Wait()
+
+	if copyErr != nil {
+		return copyErr
+	}
+	return waitErr
+}
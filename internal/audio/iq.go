@@ -0,0 +1,113 @@
+package audio
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+)
+
+// amOutputLevel is the full-scale fraction of 100% modulation, leaving headroom for overmodulation
+const amOutputLevel = 0.8
+
+// isIQFormat reports whether a pipe format carries complex samples
+func isIQFormat(format string) bool {
+	return format == "cu8" || format == "cs16"
+}
+
+// AMDemodulator turns interleaved IQ samples centred on an AM carrier into mono s16le audio.
+// Each output sample averages a block of IQ samples, which both decimates and low-pass
+// filters to roughly the audio rate, then takes the envelope. Normalising the envelope by
+// the carrier level makes the audio level independent of signal strength.
+type AMDemodulator struct {
+	format     string
+	sampleSize int // Bytes per IQ pair
+	decimation int
+	outputRate int
+
+	sumI, sumQ float64
+	count      int
+	carrier    float64 // Running average of the envelope
+	leftover   []byte  // Partial IQ pair from the previous call
+}
+
+// NewAMDemodulator creates a demodulator for IQ samples at inputRate, producing audio
+// as close to audioRate as an integer decimation allows
+func NewAMDemodulator(format string, inputRate, audioRate int) (*AMDemodulator, error) {
+	sampleSize := 0
+	switch format {
+	case "cu8":
+		sampleSize = 2
+	case "cs16":
+		sampleSize = 4
+	default:
+		return nil, fmt.Errorf("unsupported IQ format %q (use cu8 or cs16)", format)
+	}
+	if inputRate < audioRate {
+		return nil, fmt.Errorf("IQ sample rate %d is below the audio rate %d", inputRate, audioRate)
+	}
+
+	decimation := inputRate / audioRate
+	return &AMDemodulator{
+		format:     format,
+		sampleSize: sampleSize,
+		decimation: decimation,
+		outputRate: inputRate / decimation,
+	}, nil
+}
+
+// OutputRate returns the sample rate of the demodulated audio
+func (d *AMDemodulator) OutputRate() int {
+	return d.outputRate
+}
+
+// Process demodulates a block of IQ data and returns the audio produced so far.
+// Blocks don't need to be aligned to IQ pairs.
+func (d *AMDemodulator) Process(data []byte) []byte {
+	if len(d.leftover) > 0 {
+		data = append(d.leftover, data...)
+		d.leftover = nil
+	}
+
+	pairs := len(data) / d.sampleSize
+	if rest := data[pairs*d.sampleSize:]; len(rest) > 0 {
+		d.leftover = append([]byte(nil), rest...)
+	}
+
+	out := make([]byte, 0, (pairs/d.decimation+1)*2)
+	for n := 0; n < pairs; n++ {
+		i, q := d.sample(data[n*d.sampleSize:])
+		d.sumI += i
+		d.sumQ += q
+		d.count++
+		if d.count < d.decimation {
+			continue
+		}
+
+		envelope := math.Hypot(d.sumI, d.sumQ) / float64(d.count)
+		d.sumI, d.sumQ, d.count = 0, 0, 0
+
+		// Track the carrier over about a second so speech doesn't pump the level
+		if d.carrier == 0 {
+			d.carrier = envelope
+		} else {
+			d.carrier += (envelope - d.carrier) / float64(d.outputRate)
+		}
+
+		value := 0.0
+		if d.carrier > 1e-6 {
+			value = (envelope/d.carrier - 1) * amOutputLevel
+		}
+		value = math.Max(-1, math.Min(1, value))
+		out = binary.LittleEndian.AppendUint16(out, uint16(int16(value*math.MaxInt16)))
+	}
+
+	return out
+}
+
+// sample decodes one IQ pair to the range [-1, 1]
+func (d *AMDemodulator) sample(b []byte) (float64, float64) {
+	if d.format == "cu8" {
+		return (float64(b[0]) - 127.5) / 127.5, (float64(b[1]) - 127.5) / 127.5
+	}
+	return float64(int16(binary.LittleEndian.Uint16(b))) / 32768, float64(int16(binary.LittleEndian.Uint16(b[2:]))) / 32768
+}
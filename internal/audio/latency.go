@@ -0,0 +1,97 @@
+package audio
+
+import (
+	"sync"
+	"time"
+)
+
+// latencyWindow is how long after a pipeline's first audio its buffer depth is measured.
+// Stream servers send their buffered audio in a burst on connect, well within it.
+const latencyWindow = 15 * time.Second
+
+// LatencyEstimate is how far a stream's audio is estimated to lag the air, measured when its
+// pipeline last connected
+type LatencyEstimate struct {
+	HandshakeMs   int64     `json:"handshake_ms"`    // From starting the pipeline to its first audio
+	BufferDepthMs int64     `json:"buffer_depth_ms"` // Audio the server had buffered, received faster than real time
+	TotalMs       int64     `json:"total_ms"`
+	Measuring     bool      `json:"measuring"` // Still within the window after connecting
+	MeasuredAt    time.Time `json:"measured_at"`
+}
+
+// Delay returns the estimated lag
+func (e LatencyEstimate) Delay() time.Duration {
+	return time.Duration(e.TotalMs) * time.Millisecond
+}
+
+// latencyMeter estimates a stream's latency from how its audio arrives after each connect:
+// the time to the first audio, plus how far the audio received gets ahead of the time
+// elapsed since connecting, which is the backlog the server sent from its buffer
+type latencyMeter struct {
+	bytesPerSecond float64
+	startedAt      time.Time
+	firstAudio     time.Time
+	received       int64 // Bytes since the pipeline started
+	handshake      time.Duration
+	depth          time.Duration
+	estimate       *LatencyEstimate // Last estimate, kept through a reconnect until a new one starts
+	mu             sync.Mutex
+}
+
+// newLatencyMeter creates a meter of s16le audio with the given sample rate and channels
+func newLatencyMeter(sampleRate, channels int) *latencyMeter {
+	return &latencyMeter{bytesPerSecond: float64(sampleRate * channels * 2)}
+}
+
+// start begins a new measurement for a pipeline (re)started at now
+func (m *latencyMeter) start(now time.Time) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.startedAt = now
+	m.firstAudio = time.Time{}
+	m.received = 0
+	m.handshake = 0
+	m.depth = 0
+}
+
+// observe records n bytes of audio produced at now
+func (m *latencyMeter) observe(now time.Time, n int) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.startedAt.IsZero() {
+		return
+	}
+	if m.firstAudio.IsZero() {
+		m.firstAudio = now
+		m.handshake = now.Sub(m.startedAt)
+	}
+	m.received += int64(n)
+	if now.Sub(m.firstAudio) > latencyWindow {
+		if m.estimate != nil {
+			m.estimate.Measuring = false
+		}
+		return
+	}
+
+	audio := time.Duration(float64(m.received) / m.bytesPerSecond * float64(time.Second))
+	m.depth = max(m.depth, audio-now.Sub(m.startedAt))
+	m.estimate = &LatencyEstimate{
+		HandshakeMs:   m.handshake.Milliseconds(),
+		BufferDepthMs: m.depth.Milliseconds(),
+		TotalMs:       (m.handshake + m.depth).Milliseconds(),
+		Measuring:     true,
+		MeasuredAt:    now,
+	}
+}
+
+// get returns the latest estimate, or nil before the first audio
+func (m *latencyMeter) get() *LatencyEstimate {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.estimate == nil {
+		return nil
+	}
+	estimate := *m.estimate
+	return &estimate
+}
@@ -0,0 +1,209 @@
+package audio
+
+import (
+	"encoding/binary"
+	"math"
+	"sync"
+	"time"
+)
+
+// Level metering constants
+const (
+	levelWindow         = 50 * time.Millisecond // Audio measured per level update
+	minLevelDB          = -96.0                 // Level reported for digital silence
+	squelchHysteresisDB = 3.0                   // The squelch closes this far below the open threshold
+)
+
+// SquelchConfig contains the thresholds for detecting transmissions
+type SquelchConfig struct {
+	ThresholdDB float64       // RMS level in dBFS that opens the squelch
+	HangTime    time.Duration // How long the level must stay low before the squelch closes
+}
+
+// AudioLevel is the latest measured level of a frequency
+type AudioLevel struct {
+	RMSDB       float64   `json:"rms_db"`
+	PeakDB      float64   `json:"peak_db"`
+	SquelchOpen bool      `json:"squelch_open"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+// SquelchEvent reports a transmission starting (Open) or ending
+type SquelchEvent struct {
+	Open      bool
+	StartedAt time.Time     // When the transmission started
+	EndedAt   time.Time     // When the transmission ended (zero while open)
+	Duration  time.Duration // Length of the transmission (zero while open)
+	PeakDB    float64       // Highest peak level of the transmission so far
+}
+
+// LevelMeter measures RMS and peak levels of 16-bit PCM audio and detects transmissions
+// with a level squelch, so the UI can show which frequency is active and consumers can
+// skip silence
+type LevelMeter struct {
+	config        SquelchConfig
+	windowSamples int
+	carry         []byte // Odd trailing byte of the previous write
+
+	sumSquares float64
+	peak       int
+	samples    int
+
+	level     AudioLevel
+	open      bool
+	openedAt  time.Time
+	lastLoud  time.Time
+	openPeak  float64
+	listeners []func(SquelchEvent)
+	mu        sync.Mutex
+}
+
+// NewLevelMeter creates a level meter for PCM audio with the given format
+func NewLevelMeter(config SquelchConfig, sampleRate, channels int) *LevelMeter {
+	windowSamples := int(float64(sampleRate*channels) * levelWindow.Seconds())
+	if windowSamples <= 0 {
+		windowSamples = 1
+	}
+	return &LevelMeter{
+		config:        config,
+		windowSamples: windowSamples,
+		level:         AudioLevel{RMSDB: minLevelDB, PeakDB: minLevelDB},
+	}
+}
+
+// OnSquelch registers a function that is called when a transmission starts or ends
+func (m *LevelMeter) OnSquelch(fn func(SquelchEvent)) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.listeners = append(m.listeners, fn)
+}
+
+// Level returns the latest measured level
+func (m *LevelMeter) Level() AudioLevel {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.level
+}
+
+// SquelchOpen reports whether a transmission is in progress
+func (m *LevelMeter) SquelchOpen() bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.open
+}
+
+// Write measures a chunk of little-endian 16-bit PCM audio
+func (m *LevelMeter) Write(pcm []byte) {
+	m.mu.Lock()
+
+	if len(m.carry) > 0 {
+		pcm = append(m.carry, pcm...)
+		m.carry = nil
+	}
+	if len(pcm)%2 != 0 {
+		m.carry = []byte{pcm[len(pcm)-1]}
+		pcm = pcm[:len(pcm)-1]
+	}
+
+	var events []SquelchEvent
+	for i := 0; i < len(pcm); i += 2 {
+		sample := int(int16(binary.LittleEndian.Uint16(pcm[i:])))
+		if sample < 0 {
+			sample = -sample
+		}
+		m.sumSquares += float64(sample * sample)
+		if sample > m.peak {
+			m.peak = sample
+		}
+		m.samples++
+
+		if m.samples >= m.windowSamples {
+			if event, ok := m.finishWindow(time.Now()); ok {
+				events = append(events, event)
+			}
+		}
+	}
+
+	listeners := m.listeners
+	m.mu.Unlock()
+
+	for _, event := range events {
+		for _, fn := range listeners {
+			fn(event)
+		}
+	}
+}
+
+// Reset closes an open squelch and clears the level, e.g. when the stream breaks
+func (m *LevelMeter) Reset() {
+	m.mu.Lock()
+
+	var events []SquelchEvent
+	if m.open {
+		events = append(events, m.closeSquelch(time.Now()))
+	}
+	m.carry = nil
+	m.sumSquares, m.peak, m.samples = 0, 0, 0
+	m.level = AudioLevel{RMSDB: minLevelDB, PeakDB: minLevelDB, UpdatedAt: time.Now()}
+
+	listeners := m.listeners
+	m.mu.Unlock()
+
+	for _, event := range events {
+		for _, fn := range listeners {
+			fn(event)
+		}
+	}
+}
+
+// finishWindow updates the level and squelch state from the measured window.
+// Must be called with the lock held.
+func (m *LevelMeter) finishWindow(now time.Time) (SquelchEvent, bool) {
+	rms := math.Sqrt(m.sumSquares / float64(m.samples))
+	m.level = AudioLevel{
+		RMSDB:     toDBFS(rms),
+		PeakDB:    toDBFS(float64(m.peak)),
+		UpdatedAt: now,
+	}
+	m.sumSquares, m.peak, m.samples = 0, 0, 0
+
+	var event SquelchEvent
+	changed := false
+	switch {
+	case !m.open && m.level.RMSDB >= m.config.ThresholdDB:
+		m.open = true
+		m.openedAt = now.Add(-levelWindow)
+		m.lastLoud = now
+		m.openPeak = m.level.PeakDB
+		event = SquelchEvent{Open: true, StartedAt: m.openedAt, PeakDB: m.openPeak}
+		changed = true
+	case m.open && m.level.RMSDB >= m.config.ThresholdDB-squelchHysteresisDB:
+		m.lastLoud = now
+		m.openPeak = math.Max(m.openPeak, m.level.PeakDB)
+	case m.open && now.Sub(m.lastLoud) >= m.config.HangTime:
+		event = m.closeSquelch(m.lastLoud)
+		changed = true
+	}
+
+	m.level.SquelchOpen = m.open
+	return event, changed
+}
+
+// closeSquelch ends the current transmission. Must be called with the lock held.
+func (m *LevelMeter) closeSquelch(endedAt time.Time) SquelchEvent {
+	m.open = false
+	return SquelchEvent{
+		StartedAt: m.openedAt,
+		EndedAt:   endedAt,
+		Duration:  endedAt.Sub(m.openedAt),
+		PeakDB:    m.openPeak,
+	}
+}
+
+// toDBFS converts a 16-bit sample amplitude to dBFS
+func toDBFS(amplitude float64) float64 {
+	if amplitude <= 0 {
+		return minLevelDB
+	}
+	return math.Max(minLevelDB, 20*math.Log10(amplitude/32768))
+}
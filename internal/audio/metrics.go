@@ -0,0 +1,74 @@
+package audio
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+)
+
+// WritePrometheus writes the pipeline restarts, buffer overruns and output age of each
+// frequency in the Prometheus text exposition format
+func WritePrometheus(w io.Writer, pipelines map[string]PipelineStats) error {
+	ids := make([]string, 0, len(pipelines))
+	for id := range pipelines {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "# HELP co_atc_audio_pipeline_restarts_total FFmpeg pipeline restarts since startup, by reason.\n# TYPE co_atc_audio_pipeline_restarts_total counter\n")
+	for _, id := range ids {
+		for _, reason := range []string{RestartExit, RestartStall, RestartStart} {
+			fmt.Fprintf(&b, "co_atc_audio_pipeline_restarts_total{frequency_id=%q,reason=%q} %d\n", id, reason, pipelines[id].Restarts[reason])
+		}
+	}
+
+	fmt.Fprintf(&b, "# HELP co_atc_audio_pipeline_start_failures_total FFmpeg pipeline restarts that failed to start since startup.\n# TYPE co_atc_audio_pipeline_start_failures_total counter\n")
+	for _, id := range ids {
+		fmt.Fprintf(&b, "co_atc_audio_pipeline_start_failures_total{frequency_id=%q} %d\n", id, pipelines[id].StartFailures)
+	}
+
+	fmt.Fprintf(&b, "# HELP co_atc_audio_pipeline_up Whether the FFmpeg pipeline is running.\n# TYPE co_atc_audio_pipeline_up gauge\n")
+	for _, id := range ids {
+		up := 0
+		if pipelines[id].Running {
+			up = 1
+		}
+		fmt.Fprintf(&b, "co_atc_audio_pipeline_up{frequency_id=%q} %d\n", id, up)
+	}
+
+	fmt.Fprintf(&b, "# HELP co_atc_audio_reader_overruns_total Times a reader fell a whole buffer behind and skipped to live audio.\n# TYPE co_atc_audio_reader_overruns_total counter\n")
+	for _, id := range ids {
+		fmt.Fprintf(&b, "co_atc_audio_reader_overruns_total{frequency_id=%q} %d\n", id, pipelines[id].Buffer.Overruns)
+	}
+
+	fmt.Fprintf(&b, "# HELP co_atc_audio_reader_skipped_bytes_total Audio bytes readers lost to overruns.\n# TYPE co_atc_audio_reader_skipped_bytes_total counter\n")
+	for _, id := range ids {
+		fmt.Fprintf(&b, "co_atc_audio_reader_skipped_bytes_total{frequency_id=%q} %d\n", id, pipelines[id].Buffer.BytesSkipped)
+	}
+
+	fmt.Fprintf(&b, "# HELP co_atc_audio_buffer_bytes Size of the buffer shared by a frequency's readers.\n# TYPE co_atc_audio_buffer_bytes gauge\n")
+	for _, id := range ids {
+		fmt.Fprintf(&b, "co_atc_audio_buffer_bytes{frequency_id=%q} %d\n", id, pipelines[id].Buffer.BufferSize)
+	}
+
+	fmt.Fprintf(&b, "# HELP co_atc_audio_pipeline_output_age_seconds Seconds since the FFmpeg pipeline last produced audio.\n# TYPE co_atc_audio_pipeline_output_age_seconds gauge\n")
+	now := time.Now()
+	for _, id := range ids {
+		if last := pipelines[id].LastOutputAt; last != nil {
+			fmt.Fprintf(&b, "co_atc_audio_pipeline_output_age_seconds{frequency_id=%q} %v\n", id, now.Sub(*last).Seconds())
+		}
+	}
+
+	fmt.Fprintf(&b, "# HELP co_atc_audio_latency_seconds Estimated lag of a network stream's audio behind the air.\n# TYPE co_atc_audio_latency_seconds gauge\n")
+	for _, id := range ids {
+		if latency := pipelines[id].Latency; latency != nil {
+			fmt.Fprintf(&b, "co_atc_audio_latency_seconds{frequency_id=%q} %v\n", id, latency.Delay().Seconds())
+		}
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
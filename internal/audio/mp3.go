@@ -0,0 +1,1024 @@
+package audio
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+)
+
+// MP3 decoding settings
+const (
+	mp3MaxSyncSearch = 64 * 1024 // Bytes skipped looking for a frame before giving up
+	mp3ReservoirSize = 4 * 1024  // Main data kept for frames that start in earlier frames
+)
+
+// mp3Header is the header of an MPEG audio frame
+type mp3Header struct {
+	layer        int  // 1-3
+	lsf          bool // MPEG-2 or 2.5 low sampling frequencies: one granule and MPEG-2 scale factors
+	crc          bool
+	bitrate      int // kbit/s
+	rateIndex    int // Into mp3SampleRates and the band tables
+	mode         int // 0 stereo, 1 joint stereo, 2 dual channel, 3 mono
+	modeExt      int // Joint stereo: 2 = M/S, 1 = intensity
+	channels     int
+	frameSize    int // Bytes, header included
+	sideInfoSize int
+}
+
+// parseMP3Header parses a frame header, reporting false if the bytes aren't one
+func parseMP3Header(b []byte) (mp3Header, bool) {
+	var h mp3Header
+	if len(b) < 4 {
+		return h, false
+	}
+	word := binary.BigEndian.Uint32(b)
+	version := int(word>>19) & 3
+	layerBits := int(word>>17) & 3
+	bitrateIndex := int(word>>12) & 15
+	rateBits := int(word>>10) & 3
+	if word>>21 != 0x7FF || version == 1 || layerBits == 0 || bitrateIndex == 0 || bitrateIndex == 15 || rateBits == 3 {
+		return h, false
+	}
+
+	h.layer = 4 - layerBits
+	h.lsf = version != 3
+	h.crc = word>>16&1 == 0
+	h.mode = int(word>>6) & 3
+	h.modeExt = int(word>>4) & 3
+	h.channels = 2
+	if h.mode == 3 {
+		h.channels = 1
+	}
+	switch version {
+	case 3:
+		h.rateIndex = rateBits
+	case 2:
+		h.rateIndex = 3 + rateBits
+	default:
+		h.rateIndex = 6 + rateBits
+	}
+
+	// Only layer III frames are decoded, so other layers keep their header size
+	padding := int(word>>9) & 1
+	h.frameSize = 4
+	if h.layer == 3 {
+		table, perFrame := 0, 144000
+		if h.lsf {
+			table, perFrame = 1, 72000
+		}
+		h.bitrate = mp3Bitrates[table][bitrateIndex]
+		h.frameSize = perFrame*h.bitrate/mp3SampleRates[h.rateIndex] + padding
+		switch {
+		case !h.lsf && h.channels == 1:
+			h.sideInfoSize = 17
+		case !h.lsf:
+			h.sideInfoSize = 32
+		case h.channels == 1:
+			h.sideInfoSize = 9
+		default:
+			h.sideInfoSize = 17
+		}
+	}
+	return h, true
+}
+
+// sameStream reports whether two headers belong to the same stream
+func (h mp3Header) sameStream(other mp3Header) bool {
+	return h.layer == other.layer && h.rateIndex == other.rateIndex && h.channels == other.channels
+}
+
+// granules returns the granules of 576 samples in a frame
+func (h mp3Header) granules() int {
+	if h.lsf {
+		return 1
+	}
+	return 2
+}
+
+// mp3Granule is the side information of one channel of a granule
+type mp3Granule struct {
+	part23Length     int // Bits of scale factors and Huffman data
+	bigValues        int
+	globalGain       int
+	scalefacCompress int
+	windowSwitching  bool
+	blockType        int
+	mixedBlock       bool
+	tableSelect      [3]int
+	subblockGain     [3]int
+	region0Count     int
+	region1Count     int
+	preflag          bool
+	scalefacScale    int
+	count1Table      int
+}
+
+// shortBlocks reports whether the granule is coded in short blocks, in part when mixed
+func (g *mp3Granule) shortBlocks() bool {
+	return g.windowSwitching && g.blockType == 2
+}
+
+// mp3SideInfo is the side information of a frame
+type mp3SideInfo struct {
+	mainDataBegin int // Bytes before the frame's main data at which its main data starts
+	scfsi         [2][4]bool
+	granules      [2][2]mp3Granule // By granule, then channel
+}
+
+// mp3Bits reads bits most significant first, returning zeros past the end of the data
+type mp3Bits struct {
+	data []byte
+	pos  int // In bits
+}
+
+func (r *mp3Bits) bit() int {
+	pos := r.pos
+	r.pos++
+	if pos>>3 >= len(r.data) {
+		return 0
+	}
+	return int(r.data[pos>>3]>>(7-pos&7)) & 1
+}
+
+func (r *mp3Bits) read(n int) int {
+	v := 0
+	for ; n > 0; n-- {
+		v = v<<1 | r.bit()
+	}
+	return v
+}
+
+// MP3Decoder decodes an MPEG-1, 2 or 2.5 layer III stream, as served by Icecast and LiveATC,
+// to interleaved f32le samples. The sample rate and channels of the first frame are kept for
+// the stream; frames in another format are skipped.
+type MP3Decoder struct {
+	src    io.ReadCloser
+	reader *bufio.Reader
+	format mp3Header // Of the first frame
+
+	pending   []byte // Frame read when the stream was opened, decoded first
+	reservoir []byte // Main data of earlier frames
+
+	scalefacL   [2][22]int
+	scalefacS   [2][13][3]int
+	maxScalefac [2][22]int      // MPEG-2 intensity stereo: the illegal position of each long band
+	maxShort    [2][13]int      // and short band
+	isScale     int             // MPEG-2 intensity_scale of the right channel
+	samples     [2][576]float64 // Spectrum, then time samples by subband
+	overlap     [2][576]float64 // Second half of the previous granule's IMDCT output
+	synthesis   [2]mp3Synthesis
+
+	out []byte
+	err error
+}
+
+// NewMP3Decoder creates a decoder of an MP3 stream, reading up to its first frame for the
+// sample rate and channels. Streams of other MPEG layers or with no frame near the start
+// return ErrUnsupportedAudio.
+func NewMP3Decoder(src io.ReadCloser) (*MP3Decoder, error) {
+	d := &MP3Decoder{src: src, reader: bufio.NewReaderSize(src, 8*1024)}
+	header, frame, err := d.nextFrame()
+	if err != nil {
+		return nil, err
+	}
+	if header.layer != 3 {
+		return nil, fmt.Errorf("%w: MPEG audio layer %d", ErrUnsupportedAudio, header.layer)
+	}
+	d.format, d.pending = header, frame
+	return d, nil
+}
+
+// SampleRate returns the stream's sample rate
+func (d *MP3Decoder) SampleRate() int {
+	return mp3SampleRates[d.format.rateIndex]
+}
+
+// Channels returns the stream's channels
+func (d *MP3Decoder) Channels() int {
+	return d.format.channels
+}
+
+// Bitrate returns the bitrate of the stream's first frame in kbit/s
+func (d *MP3Decoder) Bitrate() int {
+	return d.format.bitrate
+}
+
+// Read returns decoded f32le samples
+func (d *MP3Decoder) Read(p []byte) (int, error) {
+	for len(d.out) == 0 {
+		if d.err != nil {
+			return 0, d.err
+		}
+		frame := d.pending
+		d.pending = nil
+		if frame == nil {
+			var header mp3Header
+			header, frame, d.err = d.nextFrame()
+			if d.err != nil {
+				continue
+			}
+			if !header.sameStream(d.format) {
+				continue
+			}
+		}
+		d.out = d.decodeFrame(frame, d.out[:0])
+	}
+
+	n := copy(p, d.out)
+	d.out = d.out[n:]
+	return n, nil
+}
+
+// Close closes the source
+func (d *MP3Decoder) Close() error {
+	return d.src.Close()
+}
+
+// nextFrame reads the next frame, skipping ID3v2 tags and anything between frames. Until the
+// stream's format is known, a frame counts as found when another frame header follows it.
+func (d *MP3Decoder) nextFrame() (mp3Header, []byte, error) {
+	skipped := 0
+	for {
+		head, err := d.reader.Peek(10)
+		if err != nil {
+			return mp3Header{}, nil, err
+		}
+		if string(head[:3]) == "ID3" {
+			size := int(head[6]&0x7F)<<21 | int(head[7]&0x7F)<<14 | int(head[8]&0x7F)<<7 | int(head[9]&0x7F)
+			if head[5]&0x10 != 0 { // Footer
+				size += 10
+			}
+			if _, err := d.reader.Discard(10 + size); err != nil {
+				return mp3Header{}, nil, err
+			}
+			continue
+		}
+
+		header, ok := parseMP3Header(head)
+		if ok && d.format.layer != 0 && !header.sameStream(d.format) {
+			ok = false
+		}
+		if ok && d.format.layer == 0 {
+			next, err := d.reader.Peek(header.frameSize + 4)
+			if err != nil && !errors.Is(err, io.EOF) {
+				return mp3Header{}, nil, err
+			}
+			if err == nil {
+				nextHeader, found := parseMP3Header(next[header.frameSize:])
+				ok = found && nextHeader.sameStream(header)
+			}
+		}
+		if !ok {
+			if skipped++; skipped > mp3MaxSyncSearch {
+				return mp3Header{}, nil, fmt.Errorf("%w: no MP3 frame found", ErrUnsupportedAudio)
+			}
+			if _, err := d.reader.Discard(1); err != nil {
+				return mp3Header{}, nil, err
+			}
+			continue
+		}
+
+		frame := make([]byte, header.frameSize)
+		if _, err := io.ReadFull(d.reader, frame); err != nil {
+			return mp3Header{}, nil, err
+		}
+		return header, frame, nil
+	}
+}
+
+// decodeFrame decodes a layer III frame and appends its samples to out
+func (d *MP3Decoder) decodeFrame(frame []byte, out []byte) []byte {
+	header, _ := parseMP3Header(frame)
+	start := 4
+	if header.crc {
+		start += 2
+	}
+	if len(frame) < start+header.sideInfoSize {
+		return out
+	}
+	side := d.readSideInfo(header, frame[start:start+header.sideInfoSize])
+
+	// Main data starts in the reservoir of earlier frames' main data. The first frames of a
+	// stream joined part way through refer to frames never received and are skipped.
+	mainStart := len(d.reservoir) - side.mainDataBegin
+	d.reservoir = append(d.reservoir, frame[start+header.sideInfoSize:]...)
+	defer func() {
+		if len(d.reservoir) > mp3ReservoirSize {
+			d.reservoir = append(d.reservoir[:0], d.reservoir[len(d.reservoir)-mp3ReservoirSize:]...)
+		}
+	}()
+	if mainStart < 0 {
+		return out
+	}
+
+	bits := &mp3Bits{data: d.reservoir[mainStart:]}
+	var pcm [2][576]float32
+	for gr := 0; gr < header.granules(); gr++ {
+		for ch := 0; ch < header.channels; ch++ {
+			g := &side.granules[gr][ch]
+			end := bits.pos + g.part23Length
+			if header.lsf {
+				d.readLSFScalefactors(bits, header, g, ch)
+			} else {
+				d.readScalefactors(bits, &side, gr, ch)
+			}
+			var values [576]int
+			readHuffman(bits, header, g, end, &values)
+			bits.pos = end
+			d.requantize(header, g, ch, &values)
+		}
+		if header.mode == 1 {
+			d.jointStereo(header, &side.granules[gr][1])
+		}
+		for ch := 0; ch < header.channels; ch++ {
+			d.hybridSynthesis(header, &side.granules[gr][ch], ch)
+			for slot := 0; slot < 18; slot++ {
+				d.synthesis[ch].synthesize(d.samples[ch][slot*32:slot*32+32], pcm[ch][slot*32:slot*32+32])
+			}
+		}
+		for i := 0; i < 576; i++ {
+			for ch := 0; ch < header.channels; ch++ {
+				out = binary.LittleEndian.AppendUint32(out, math.Float32bits(pcm[ch][i]))
+			}
+		}
+	}
+	return out
+}
+
+// readSideInfo parses the side information following the header
+func (d *MP3Decoder) readSideInfo(h mp3Header, data []byte) mp3SideInfo {
+	var side mp3SideInfo
+	bits := &mp3Bits{data: data}
+	if h.lsf {
+		side.mainDataBegin = bits.read(8)
+		bits.read(h.channels) // Private bits
+	} else {
+		side.mainDataBegin = bits.read(9)
+		if h.channels == 1 { // Private bits
+			bits.read(5)
+		} else {
+			bits.read(3)
+		}
+		for ch := 0; ch < h.channels; ch++ {
+			for band := 0; band < 4; band++ {
+				side.scfsi[ch][band] = bits.bit() == 1
+			}
+		}
+	}
+
+	for gr := 0; gr < h.granules(); gr++ {
+		for ch := 0; ch < h.channels; ch++ {
+			g := &side.granules[gr][ch]
+			g.part23Length = bits.read(12)
+			g.bigValues = min(bits.read(9), 288)
+			g.globalGain = bits.read(8)
+			if h.lsf {
+				g.scalefacCompress = bits.read(9)
+			} else {
+				g.scalefacCompress = bits.read(4)
+			}
+			g.windowSwitching = bits.bit() == 1
+			if g.windowSwitching {
+				g.blockType = bits.read(2)
+				g.mixedBlock = bits.bit() == 1
+				for i := 0; i < 2; i++ {
+					g.tableSelect[i] = bits.read(5)
+				}
+				for i := 0; i < 3; i++ {
+					g.subblockGain[i] = bits.read(3)
+				}
+				g.region0Count = 7
+				if g.blockType == 2 && !g.mixedBlock {
+					g.region0Count = 8
+				}
+				g.region1Count = 20 - g.region0Count
+			} else {
+				for i := 0; i < 3; i++ {
+					g.tableSelect[i] = bits.read(5)
+				}
+				g.region0Count = bits.read(4)
+				g.region1Count = bits.read(3)
+			}
+			if !h.lsf {
+				g.preflag = bits.bit() == 1
+			}
+			g.scalefacScale = bits.bit()
+			g.count1Table = bits.bit()
+		}
+	}
+	return side
+}
+
+// readScalefactors reads the MPEG-1 scale factors of a channel. Long block scale factors of
+// the second granule may be shared with the first, as scfsi says.
+func (d *MP3Decoder) readScalefactors(bits *mp3Bits, side *mp3SideInfo, gr, ch int) {
+	g := &side.granules[gr][ch]
+	slen := mp3ScalefacBits[g.scalefacCompress]
+	long, short := &d.scalefacL[ch], &d.scalefacS[ch]
+
+	if g.shortBlocks() {
+		first := 0
+		if g.mixedBlock {
+			for sfb := 0; sfb < 8; sfb++ {
+				long[sfb] = bits.read(slen[0])
+			}
+			first = 3
+		}
+		for sfb := first; sfb < 12; sfb++ {
+			n := slen[0]
+			if sfb >= 6 {
+				n = slen[1]
+			}
+			for w := 0; w < 3; w++ {
+				short[sfb][w] = bits.read(n)
+			}
+		}
+		short[12] = [3]int{}
+		return
+	}
+
+	groups := [5]int{0, 6, 11, 16, 21}
+	for group := 0; group < 4; group++ {
+		if gr == 1 && side.scfsi[ch][group] {
+			continue
+		}
+		n := slen[0]
+		if group >= 2 {
+			n = slen[1]
+		}
+		for sfb := groups[group]; sfb < groups[group+1]; sfb++ {
+			long[sfb] = bits.read(n)
+		}
+	}
+	long[21] = 0
+}
+
+// readLSFScalefactors reads the MPEG-2 scale factors of a channel. With intensity stereo the
+// right channel's are intensity positions, coded differently.
+func (d *MP3Decoder) readLSFScalefactors(bits *mp3Bits, h mp3Header, g *mp3Granule, ch int) {
+	var slen [4]int
+	var table int
+	sfc := g.scalefacCompress
+	if h.mode == 1 && h.modeExt&1 != 0 && ch == 1 {
+		d.isScale = sfc & 1
+		sfc >>= 1
+		switch {
+		case sfc < 180:
+			slen, table = [4]int{sfc / 36, sfc % 36 / 6, sfc % 36 % 6, 0}, 3
+		case sfc < 244:
+			sfc -= 180
+			slen, table = [4]int{sfc % 64 >> 4, sfc % 16 >> 2, sfc % 4, 0}, 4
+		default:
+			sfc -= 244
+			slen, table = [4]int{sfc / 3, sfc % 3, 0, 0}, 5
+		}
+	} else {
+		switch {
+		case sfc < 400:
+			slen, table = [4]int{(sfc >> 4) / 5, (sfc >> 4) % 5, sfc & 15 >> 2, sfc & 3}, 0
+		case sfc < 500:
+			sfc -= 400
+			slen, table = [4]int{(sfc >> 2) / 5, (sfc >> 2) % 5, sfc & 3, 0}, 1
+		default:
+			sfc -= 500
+			slen, table = [4]int{sfc / 3, sfc % 3, 0, 0}, 2
+			g.preflag = true
+		}
+	}
+
+	blocks := 0
+	if g.shortBlocks() {
+		blocks = 1
+		if g.mixedBlock {
+			blocks = 2
+		}
+	}
+	var values, maxima [39]int
+	n := 0
+	for group, count := range mp3LSFBandCounts[table][blocks] {
+		for i := 0; i < count; i++ {
+			values[n], maxima[n] = bits.read(slen[group]), 1<<slen[group]-1
+			n++
+		}
+	}
+
+	long, short := &d.scalefacL[ch], &d.scalefacS[ch]
+	n = 0
+	if !g.shortBlocks() {
+		for sfb := 0; sfb < 21; sfb++ {
+			long[sfb], d.maxScalefac[ch][sfb] = values[n], maxima[n]
+			n++
+		}
+		long[21], d.maxScalefac[ch][21] = 0, d.maxScalefac[ch][20]
+		return
+	}
+	first := 0
+	if g.mixedBlock {
+		for sfb := 0; sfb < 6; sfb++ {
+			long[sfb], d.maxScalefac[ch][sfb] = values[n], maxima[n]
+			n++
+		}
+		first = 3
+	}
+	for sfb := first; sfb < 12; sfb++ {
+		for w := 0; w < 3; w++ {
+			short[sfb][w] = values[n]
+			n++
+		}
+		d.maxShort[ch][sfb] = maxima[n-1]
+	}
+	short[12], d.maxShort[ch][12] = [3]int{}, d.maxShort[ch][11]
+}
+
+// readHuffman decodes a channel's quantized spectrum up to the bit position end: pairs of big
+// values in three regions with their own tables, then quadruples of values up to 1.
+func readHuffman(bits *mp3Bits, h mp3Header, g *mp3Granule, end int, values *[576]int) {
+	long := &mp3LongBands[h.rateIndex]
+	var region1, region2 int
+	switch {
+	case g.shortBlocks() && !g.mixedBlock:
+		region1, region2 = 3*mp3ShortBands[h.rateIndex][3], 576
+	case g.windowSwitching:
+		region1, region2 = long[8], 576
+	default:
+		region1 = long[min(g.region0Count+1, 22)]
+		region2 = long[min(g.region0Count+g.region1Count+2, 22)]
+	}
+
+	i := 0
+	for ; i < g.bigValues*2; i += 2 {
+		table := g.tableSelect[2]
+		if i < region1 {
+			table = g.tableSelect[0]
+		} else if i < region2 {
+			table = g.tableSelect[1]
+		}
+		values[i], values[i+1] = readPair(bits, table)
+	}
+
+	for i+4 <= 576 && bits.pos < end {
+		quad := 15 - bits.read(4)
+		if g.count1Table == 0 {
+			bits.pos -= 4
+			quad = mp3QuadTreeA.decode(bits)
+		}
+		for j := 0; j < 4; j++ {
+			v := quad >> (3 - j) & 1
+			if v != 0 && bits.bit() == 1 {
+				v = -v
+			}
+			values[i+j] = v
+		}
+		// A quadruple running past the end is padding, not data
+		if bits.pos > end {
+			values[i], values[i+1], values[i+2], values[i+3] = 0, 0, 0, 0
+			break
+		}
+		i += 4
+	}
+}
+
+// readPair decodes a pair of big values with their linbits and signs
+func readPair(bits *mp3Bits, table int) (int, int) {
+	tree := mp3PairTrees[table]
+	if tree == nil {
+		return 0, 0
+	}
+	value := tree.decode(bits)
+	size := mp3PairTables[table].size
+	x, y := value/size, value%size
+	linbits := mp3Linbits[table]
+	if linbits > 0 && x == 15 {
+		x += bits.read(linbits)
+	}
+	if x != 0 && bits.bit() == 1 {
+		x = -x
+	}
+	if linbits > 0 && y == 15 {
+		y += bits.read(linbits)
+	}
+	if y != 0 && bits.bit() == 1 {
+		y = -y
+	}
+	return x, y
+}
+
+// mp3Tree is a Huffman decoding tree: pairs of child nodes, negative for the leaf of value -n-1
+type mp3Tree []int32
+
+// newMP3Tree builds the decoding tree of a Huffman table
+func newMP3Tree(table *mp3HuffmanTable) mp3Tree {
+	tree := mp3Tree{0, 0}
+	for value, code := range table.codes {
+		node := 0
+		for bit := int(table.lengths[value]) - 1; bit >= 0; bit-- {
+			branch := node + int(code>>bit&1)
+			if bit == 0 {
+				tree[branch] = int32(-value - 1)
+				break
+			}
+			if tree[branch] <= 0 {
+				tree[branch] = int32(len(tree))
+				tree = append(tree, 0, 0)
+			}
+			node = int(tree[branch])
+		}
+	}
+	return tree
+}
+
+// decode reads one value. Codes are complete, so every bit sequence ends at a leaf.
+func (t mp3Tree) decode(bits *mp3Bits) int {
+	node := 0
+	for {
+		next := t[node+bits.bit()]
+		if next < 0 {
+			return int(-next - 1)
+		}
+		node = int(next)
+	}
+}
+
+// Decoding trees of the Huffman tables
+var (
+	mp3PairTrees [32]mp3Tree
+	mp3QuadTreeA = newMP3Tree(&mp3QuadTableA)
+)
+
+func init() {
+	for i, table := range mp3PairTables {
+		if table != nil {
+			mp3PairTrees[i] = newMP3Tree(table)
+		}
+	}
+}
+
+// mp3Pow43 is |x|^(4/3) of the quantized values, up to 15 with 13 linbits
+var mp3Pow43 = func() []float64 {
+	table := make([]float64, 8207)
+	for i := range table {
+		table[i] = math.Pow(float64(i), 4.0/3)
+	}
+	return table
+}()
+
+// requantize scales a channel's quantized values to the spectrum by the global gain, scale
+// factors and subblock gains
+func (d *MP3Decoder) requantize(h mp3Header, g *mp3Granule, ch int, values *[576]int) {
+	xr := &d.samples[ch]
+	gain := float64(g.globalGain-210) / 4
+	multiplier := 0.5 * float64(1+g.scalefacScale)
+	scale := func(v int, exponent float64) float64 {
+		switch {
+		case v == 0:
+			return 0
+		case v < 0:
+			return -mp3Pow43[min(-v, 8206)] * math.Exp2(exponent)
+		default:
+			return mp3Pow43[min(v, 8206)] * math.Exp2(exponent)
+		}
+	}
+
+	longEnd := 576
+	if g.shortBlocks() {
+		longEnd = 0
+		if g.mixedBlock {
+			longEnd = 36
+		}
+	}
+	long := &mp3LongBands[h.rateIndex]
+	for sfb := 0; long[sfb] < longEnd; sfb++ {
+		sf := d.scalefacL[ch][sfb]
+		if g.preflag {
+			sf += mp3Pretab[sfb]
+		}
+		exponent := gain - multiplier*float64(sf)
+		for i := long[sfb]; i < min(long[sfb+1], longEnd); i++ {
+			xr[i] = scale(values[i], exponent)
+		}
+	}
+	if longEnd == 576 {
+		return
+	}
+
+	short := &mp3ShortBands[h.rateIndex]
+	for sfb := 0; sfb < 13; sfb++ {
+		if 3*short[sfb] < longEnd {
+			continue
+		}
+		width := short[sfb+1] - short[sfb]
+		for w := 0; w < 3; w++ {
+			exponent := gain - 2*float64(g.subblockGain[w]) - multiplier*float64(d.scalefacS[ch][sfb][w])
+			for i := 3*short[sfb] + w*width; i < 3*short[sfb]+(w+1)*width; i++ {
+				xr[i] = scale(values[i], exponent)
+			}
+		}
+	}
+}
+
+// mp3IntensityRatios are the left and right factors of MPEG-1 intensity positions 0-6
+var mp3IntensityRatios = func() [7][2]float64 {
+	var ratios [7][2]float64
+	for pos := range ratios {
+		if pos == 6 {
+			ratios[pos] = [2]float64{1, 0}
+			continue
+		}
+		ratio := math.Tan(float64(pos) * math.Pi / 12)
+		ratios[pos] = [2]float64{ratio / (1 + ratio), 1 / (1 + ratio)}
+	}
+	return ratios
+}()
+
+// jointStereo undoes intensity and M/S stereo coding of a granule. Intensity stereo codes the
+// bands above the right channel's last nonzero value as the left channel and a position;
+// M/S stereo codes the rest as sum and difference.
+func (d *MP3Decoder) jointStereo(h mp3Header, right *mp3Granule) {
+	left, rightXR := &d.samples[0], &d.samples[1]
+	var intensity [576]bool
+
+	if h.modeExt&1 != 0 {
+		// apply sets a range of lines from an intensity position, unless it's the band's illegal one
+		apply := func(from, to, pos, illegal int) {
+			if pos == illegal {
+				return
+			}
+			var kl, kr float64
+			if h.lsf {
+				base := math.Exp2(-0.25 * float64(1+d.isScale))
+				kl, kr = 1, 1
+				if pos%2 == 1 {
+					kl = math.Pow(base, float64(pos+1)/2)
+				} else if pos > 0 {
+					kr = math.Pow(base, float64(pos)/2)
+				}
+			} else {
+				if pos > 6 {
+					return
+				}
+				kl, kr = mp3IntensityRatios[pos][0], mp3IntensityRatios[pos][1]
+			}
+			for i := from; i < to; i++ {
+				rightXR[i] = left[i] * kr
+				left[i] *= kl
+				intensity[i] = true
+			}
+		}
+		illegalLong := func(sfb int) int {
+			if h.lsf {
+				return d.maxScalefac[1][sfb]
+			}
+			return 7
+		}
+		illegalShort := func(sfb int) int {
+			if h.lsf {
+				return d.maxShort[1][sfb]
+			}
+			return 7
+		}
+
+		long, short := &mp3LongBands[h.rateIndex], &mp3ShortBands[h.rateIndex]
+		longEnd, shortStart := 576, 13
+		if right.shortBlocks() {
+			longEnd, shortStart = 0, 0
+			if right.mixedBlock {
+				longEnd, shortStart = 36, 3
+			}
+		}
+
+		// Short bands, window by window above the last nonzero band of each
+		shortZero := true
+		for w := 0; w < 3 && shortStart < 13; w++ {
+			first := shortStart
+			for sfb := 12; sfb >= shortStart; sfb-- {
+				width := short[sfb+1] - short[sfb]
+				base := 3*short[sfb] + w*width
+				if nonzero(rightXR[base : base+width]) {
+					first, shortZero = sfb+1, false
+					break
+				}
+			}
+			for sfb := first; sfb < 13; sfb++ {
+				width := short[sfb+1] - short[sfb]
+				base := 3*short[sfb] + w*width
+				pos := d.scalefacS[1][min(sfb, 11)][w]
+				apply(base, base+width, pos, illegalShort(min(sfb, 11)))
+			}
+		}
+
+		// Long bands, only when nothing above them is coded in the right channel
+		if longEnd > 0 && shortZero {
+			first := 0
+			for sfb := 21; sfb >= 0; sfb-- {
+				if long[sfb] < longEnd && nonzero(rightXR[long[sfb]:min(long[sfb+1], longEnd)]) {
+					first = sfb + 1
+					break
+				}
+			}
+			for sfb := first; sfb < 22 && long[sfb] < longEnd; sfb++ {
+				pos := d.scalefacL[1][min(sfb, 20)]
+				apply(long[sfb], min(long[sfb+1], longEnd), pos, illegalLong(min(sfb, 20)))
+			}
+		}
+	}
+
+	if h.modeExt&2 != 0 {
+		for i := range left {
+			if !intensity[i] {
+				m, s := left[i], rightXR[i]
+				left[i], rightXR[i] = (m+s)*math.Sqrt2/2, (m-s)*math.Sqrt2/2
+			}
+		}
+	}
+}
+
+// nonzero reports whether any sample is nonzero
+func nonzero(samples []float64) bool {
+	for _, s := range samples {
+		if s != 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// Alias reduction butterflies
+var mp3AliasCS, mp3AliasCA = func() ([8]float64, [8]float64) {
+	coefficients := [8]float64{-0.6, -0.535, -0.33, -0.185, -0.095, -0.041, -0.0142, -0.0037}
+	var cs, ca [8]float64
+	for i, c := range coefficients {
+		norm := math.Sqrt(1 + c*c)
+		cs[i], ca[i] = 1/norm, c/norm
+	}
+	return cs, ca
+}()
+
+// IMDCT cosines and windows of the four block types
+var (
+	mp3IMDCTLong  [36][18]float64
+	mp3IMDCTShort [12][6]float64
+	mp3Windows    [4][36]float64 // Long, start, short (one 12 sample window), stop
+)
+
+func init() {
+	for i := 0; i < 36; i++ {
+		for k := 0; k < 18; k++ {
+			mp3IMDCTLong[i][k] = math.Cos(math.Pi / 72 * float64((2*i+1+18)*(2*k+1)))
+		}
+	}
+	for i := 0; i < 12; i++ {
+		for k := 0; k < 6; k++ {
+			mp3IMDCTShort[i][k] = math.Cos(math.Pi / 24 * float64((2*i+1+6)*(2*k+1)))
+		}
+	}
+
+	for i := 0; i < 36; i++ {
+		mp3Windows[0][i] = math.Sin(math.Pi / 36 * (float64(i) + 0.5))
+	}
+	for i := 0; i < 18; i++ {
+		mp3Windows[1][i] = mp3Windows[0][i]
+		mp3Windows[3][i+18] = mp3Windows[0][i+18]
+	}
+	for i := 18; i < 24; i++ {
+		mp3Windows[1][i] = 1
+		mp3Windows[3][i-6] = 1
+	}
+	for i := 24; i < 30; i++ {
+		mp3Windows[1][i] = math.Sin(math.Pi / 12 * (float64(i-18) + 0.5))
+		mp3Windows[3][i-18] = math.Sin(math.Pi / 12 * (float64(i-24) + 0.5))
+	}
+	for i := 0; i < 12; i++ {
+		mp3Windows[2][i] = math.Sin(math.Pi / 12 * (float64(i) + 0.5))
+	}
+}
+
+// hybridSynthesis turns a channel's spectrum into 18 time samples of each of the 32
+// subbands, stored slot by slot in samples: short blocks are reordered, aliasing reduced,
+// and each subband inverse transformed and overlapped with the previous granule.
+func (d *MP3Decoder) hybridSynthesis(h mp3Header, g *mp3Granule, ch int) {
+	xr := &d.samples[ch]
+
+	if g.shortBlocks() {
+		// Short blocks are coded band by band and window by window; the transform takes
+		// each subband's windows interleaved
+		short := &mp3ShortBands[h.rateIndex]
+		first := 0
+		if g.mixedBlock {
+			first = 3
+		}
+		var reordered [576]float64
+		for sfb := first; sfb < 13; sfb++ {
+			width := short[sfb+1] - short[sfb]
+			for w := 0; w < 3; w++ {
+				for j := 0; j < width; j++ {
+					reordered[3*(short[sfb]+j)+w] = xr[3*short[sfb]+w*width+j]
+				}
+			}
+		}
+		copy(xr[3*short[first]:], reordered[3*short[first]:])
+	}
+
+	aliased := 32
+	if g.shortBlocks() {
+		aliased = 0
+		if g.mixedBlock {
+			aliased = 2
+		}
+	}
+	for sb := 1; sb < aliased; sb++ {
+		for i := 0; i < 8; i++ {
+			lower, upper := xr[18*sb-1-i], xr[18*sb+i]
+			xr[18*sb-1-i] = lower*mp3AliasCS[i] - upper*mp3AliasCA[i]
+			xr[18*sb+i] = upper*mp3AliasCS[i] + lower*mp3AliasCA[i]
+		}
+	}
+
+	var slots [576]float64
+	for sb := 0; sb < 32; sb++ {
+		blockType := 0
+		if g.windowSwitching && !(g.mixedBlock && sb < 2) {
+			blockType = g.blockType
+		}
+		in := xr[18*sb : 18*sb+18]
+		var out [36]float64
+		if blockType == 2 {
+			for w := 0; w < 3; w++ {
+				for i := 0; i < 12; i++ {
+					sum := 0.0
+					for k := 0; k < 6; k++ {
+						sum += in[3*k+w] * mp3IMDCTShort[i][k]
+					}
+					out[6+6*w+i] += sum * mp3Windows[2][i]
+				}
+			}
+		} else {
+			for i := 0; i < 36; i++ {
+				sum := 0.0
+				for k := 0; k < 18; k++ {
+					sum += in[k] * mp3IMDCTLong[i][k]
+				}
+				out[i] = sum * mp3Windows[blockType][i]
+			}
+		}
+
+		overlap := d.overlap[ch][18*sb : 18*sb+18]
+		for i := 0; i < 18; i++ {
+			sample := out[i] + overlap[i]
+			overlap[i] = out[i+18]
+			// Odd subbands are frequency inverted
+			if sb%2 == 1 && i%2 == 1 {
+				sample = -sample
+			}
+			slots[32*i+sb] = sample
+		}
+	}
+	*xr = slots
+}
+
+// Polyphase synthesis matrix and window
+var (
+	mp3SynthesisMatrix [64][32]float64
+	mp3Window          [512]float64
+)
+
+func init() {
+	for i := 0; i < 64; i++ {
+		for k := 0; k < 32; k++ {
+			mp3SynthesisMatrix[i][k] = math.Cos(float64((16+i)*(2*k+1)) * math.Pi / 64)
+		}
+	}
+	copy(mp3Window[:], mp3SynthesisWindow[:])
+	for i := 257; i < 512; i++ {
+		mp3Window[i] = -mp3SynthesisWindow[512-i]
+	}
+}
+
+// mp3Synthesis is the polyphase filterbank merging a channel's 32 subbands
+type mp3Synthesis struct {
+	v      [1024]float64
+	offset int
+}
+
+// synthesize turns one sample of each subband into 32 output samples
+func (s *mp3Synthesis) synthesize(subbands []float64, out []float32) {
+	s.offset = (s.offset - 64) & 1023
+	for i := 0; i < 64; i++ {
+		sum := 0.0
+		for k, sample := range subbands {
+			sum += mp3SynthesisMatrix[i][k] * sample
+		}
+		s.v[(s.offset+i)&1023] = sum
+	}
+	for j := 0; j < 32; j++ {
+		sum := 0.0
+		for i := 0; i < 8; i++ {
+			sum += s.v[(s.offset+128*i+j)&1023] * mp3Window[64*i+j]
+			sum += s.v[(s.offset+128*i+96+j)&1023] * mp3Window[64*i+32+j]
+		}
+		out[j] = float32(sum)
+	}
+}
@@ -0,0 +1,156 @@
+package audio
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io"
+	"math"
+	"os"
+	"testing"
+)
+
+// decodeMP3 decodes a whole MP3 stream to samples
+func decodeMP3(t *testing.T, src io.ReadCloser) (*MP3Decoder, []float64) {
+	t.Helper()
+	decoder, err := NewMP3Decoder(src)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := io.ReadAll(decoder)
+	if err != nil {
+		t.Fatal(err)
+	}
+	samples := make([]float64, len(data)/4)
+	for i := range samples {
+		samples[i] = float64(math.Float32frombits(binary.LittleEndian.Uint32(data[4*i:])))
+	}
+	return decoder, samples
+}
+
+func TestMP3DecoderReadsSounds(t *testing.T) {
+	for _, name := range []string{"airbus_retard.mp3", "airplane-ding-dong.mp3"} {
+		file, err := os.Open("../../www/sounds/" + name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		decoder, samples := decodeMP3(t, file)
+		file.Close()
+
+		if decoder.SampleRate() != 44100 || decoder.Channels() != 2 {
+			t.Errorf("%s: got %d Hz, %d channels", name, decoder.SampleRate(), decoder.Channels())
+		}
+		if len(samples) == 0 || len(samples)%(2*1152) != 0 {
+			t.Errorf("%s: got %d samples, not whole frames", name, len(samples))
+		}
+		// Decoded sounds are smooth: a misdecoded frame clicks
+		var energy, steps float64
+		for i, s := range samples {
+			if math.IsNaN(s) || math.Abs(s) > 1 {
+				t.Fatalf("%s: sample %d is %v", name, i, s)
+			}
+			energy += s * s
+			if i >= 2 {
+				steps += (s - samples[i-2]) * (s - samples[i-2])
+			}
+		}
+		if energy == 0 || steps/energy > 0.1 {
+			t.Errorf("%s: energy %.1f, step energy ratio %.3f", name, energy, steps/energy)
+		}
+	}
+}
+
+// mp3BitWriter writes bits most significant first
+type mp3BitWriter struct {
+	data []byte
+	bits int
+}
+
+func (w *mp3BitWriter) write(value, n int) {
+	for i := n - 1; i >= 0; i-- {
+		if w.bits%8 == 0 {
+			w.data = append(w.data, 0)
+		}
+		w.data[len(w.data)-1] |= byte(value>>i&1) << (7 - w.bits%8)
+		w.bits++
+	}
+}
+
+// lsfToneFrame builds an MPEG-2 22.05 kHz mono frame, as LiveATC serves, whose only nonzero
+// spectral line is line, which must be even
+func lsfToneFrame(line int) []byte {
+	w := &mp3BitWriter{}
+	// Sync, MPEG-2, layer III, no CRC, 64 kbit/s, 22050 Hz, no padding, mono
+	w.write(0x7FF, 11)
+	w.write(2, 2)
+	w.write(1, 2)
+	w.write(1, 1)
+	w.write(8, 4)
+	w.write(0, 4) // 22050 Hz, no padding, private bit
+	w.write(3, 2) // Mono
+	w.write(0, 6)
+
+	// Side info: pairs of table 1 up to the line, no scale factors, unit gain
+	pairs := line/2 + 1
+	w.write(0, 8)        // main_data_begin
+	w.write(0, 1)        // Private bit
+	w.write(pairs+2, 12) // part2_3_length: a 1 bit code per zero pair, 2 bits and a sign for the line
+	w.write(pairs, 9)    // big_values
+	w.write(210, 8)      // global_gain
+	w.write(0, 9)        // scalefac_compress
+	w.write(0, 1)        // No window switching
+	for region := 0; region < 3; region++ {
+		w.write(1, 5) // table_select
+	}
+	w.write(15, 4) // region0_count
+	w.write(7, 3)  // region1_count
+	w.write(0, 2)  // scalefac_scale, count1table_select
+
+	for i := 1; i < pairs; i++ {
+		w.write(1, 1) // 0, 0
+	}
+	w.write(1, 2) // 1, 0
+	w.write(0, 1) // Positive
+
+	frame := make([]byte, 72000*64/22050)
+	copy(frame, w.data)
+	return frame
+}
+
+func TestMP3DecoderPlacesLSFLines(t *testing.T) {
+	const line = 40
+	var stream []byte
+	for i := 0; i < 40; i++ {
+		stream = append(stream, lsfToneFrame(line)...)
+	}
+	decoder, samples := decodeMP3(t, io.NopCloser(bytes.NewReader(stream)))
+	if decoder.SampleRate() != 22050 || decoder.Channels() != 1 || len(samples) != 40*576 {
+		t.Fatalf("got %d Hz, %d channels, %d samples", decoder.SampleRate(), decoder.Channels(), len(samples))
+	}
+
+	// A steady line sounds at its lower edge, line * 22050 / 1152 Hz
+	samples = samples[4*576:]
+	strongest, strongestPower := 0.0, 0.0
+	for freq := 500.0; freq < 1000; freq++ {
+		var re, im float64
+		for i, s := range samples {
+			phase := 2 * math.Pi * freq * float64(i) / 22050
+			re += s * math.Cos(phase)
+			im += s * math.Sin(phase)
+		}
+		if power := re*re + im*im; power > strongestPower {
+			strongest, strongestPower = freq, power
+		}
+	}
+	if want := float64(line) * 22050 / 1152; math.Abs(strongest-want) > 3 {
+		t.Errorf("strongest frequency %.0f Hz, want %.0f Hz", strongest, want)
+	}
+}
+
+func TestMP3DecoderRejectsOtherAudio(t *testing.T) {
+	adts := bytes.Repeat([]byte{0xFF, 0xF1, 0x50, 0x80, 0x02, 0x1F, 0xFC}, 20000)
+	_, err := NewMP3Decoder(io.NopCloser(bytes.NewReader(adts)))
+	if !errors.Is(err, ErrUnsupportedAudio) {
+		t.Fatalf("expected ErrUnsupportedAudio for AAC, got %v", err)
+	}
+}
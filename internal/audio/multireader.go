@@ -9,43 +9,91 @@ import (
 	"github.com/yegors/co-atc/pkg/logger"
 )
 
-// MultiReader implements a reader that can be consumed by multiple goroutines
+// MultiReader buffer defaults
+const (
+	DefaultReaderBufferSize    = 64 * 1024   // About 1.3 seconds at 24kHz mono
+	DefaultReaderMaxBufferSize = 1024 * 1024 // Largest the buffer grows to with OverrunGrow
+)
+
+// What a reader that falls a whole buffer behind the writer gets
+const (
+	OverrunSkip = "skip" // Skips to live audio, leaving a gap
+	OverrunGrow = "grow" // The buffer grows to keep the audio, up to its maximum, then skips
+)
+
+// readerWaitTimeout is how long a read waits for data before returning EOF, so the caller
+// reconnects
+const readerWaitTimeout = 30 * time.Second
+
+// MultiReaderConfig sizes a multi-reader's buffer
+type MultiReaderConfig struct {
+	BufferSize    int    // Bytes (default: DefaultReaderBufferSize)
+	MaxBufferSize int    // Bytes the buffer may grow to with OverrunGrow (default: DefaultReaderMaxBufferSize)
+	Overrun       string // OverrunSkip (default) or OverrunGrow
+	FrameSize     int    // Bytes per sample frame, so gaps never split one (default: 1)
+}
+
+// MultiReaderStats counts buffer overruns since the multi-reader was created
+type MultiReaderStats struct {
+	BufferSize   int   `json:"buffer_size"`
+	Readers      int   `json:"readers"`
+	Overruns     int64 `json:"overruns"`      // Times a reader fell a whole buffer behind and skipped to live
+	BytesSkipped int64 `json:"bytes_skipped"` // Audio lost to overruns
+	Grows        int   `json:"grows"`
+}
+
+// MultiReader implements a reader that can be consumed by multiple goroutines. Writes go to
+// a circular buffer and each reader keeps its own position in the stream. Positions count
+// bytes since the start, so a reader that has fallen more than a buffer behind is detected
+// instead of silently reading overwritten audio.
 type MultiReader struct {
-	buffer     []byte // Circular buffer for audio data
-	bufferSize int    // Size of the circular buffer
-	writeIndex int    // Current write position in the buffer
-	readers    map[string]*readerState
-	mu         sync.RWMutex // Mutex for thread safety
-	ctx        context.Context
-	cancel     context.CancelFunc
-	logger     *logger.Logger
-	closed     bool
+	config   MultiReaderConfig
+	buffer   []byte // Circular buffer for audio data
+	written  int64  // Bytes written since the start; the buffer holds the last len(buffer)
+	readers  map[string]*readerState
+	notify   chan struct{} // Closed and replaced on every write
+	mu       sync.Mutex    // Mutex for thread safety
+	ctx      context.Context
+	cancel   context.CancelFunc
+	logger   *logger.Logger
+	closed   bool
+	stats    MultiReaderStats
+	maxLimit bool // The buffer reached its maximum size; logged once
 }
 
 // readerState tracks the state of each reader
 type readerState struct {
-	readIndex int        // Current read position in the circular buffer
-	readCond  *sync.Cond // Condition variable for signaling new data
-	closed    bool
+	position int64         // Stream position of the next byte to read
+	done     chan struct{} // Closed when the reader is removed
+	overruns int64
 }
 
 // NewMultiReader creates a new multi-reader
-func NewMultiReader(ctx context.Context, logger *logger.Logger) *MultiReader {
-	bufferSize := 1024 * 64 // 64KB buffer for low latency (about 1.3 seconds at 24kHz mono)
+func NewMultiReader(ctx context.Context, config MultiReaderConfig, logger *logger.Logger) *MultiReader {
+	if config.BufferSize <= 0 {
+		config.BufferSize = DefaultReaderBufferSize
+	}
+	if config.MaxBufferSize <= 0 {
+		config.MaxBufferSize = DefaultReaderMaxBufferSize
+	}
+	config.MaxBufferSize = max(config.MaxBufferSize, config.BufferSize)
+	if config.Overrun == "" {
+		config.Overrun = OverrunSkip
+	}
+	if config.FrameSize <= 0 {
+		config.FrameSize = 1
+	}
 	readerCtx, readerCancel := context.WithCancel(ctx)
 
-	mr := &MultiReader{
-		buffer:     make([]byte, bufferSize),
-		bufferSize: bufferSize,
-		writeIndex: 0,
-		readers:    make(map[string]*readerState),
-		ctx:        readerCtx,
-		cancel:     readerCancel,
-		logger:     logger,
-		closed:     false,
+	return &MultiReader{
+		config:  config,
+		buffer:  make([]byte, config.BufferSize),
+		readers: make(map[string]*readerState),
+		notify:  make(chan struct{}),
+		ctx:     readerCtx,
+		cancel:  readerCancel,
+		logger:  logger,
 	}
-
-	return mr
 }
 
 // Write writes data to the buffer and notifies all readers
@@ -56,22 +104,71 @@ func (mr *MultiReader) Write(p []byte) (n int, err error) {
 	if mr.closed {
 		return 0, io.ErrClosedPipe
 	}
-
-	// Copy data to the circular buffer
 	n = len(p)
-	for i := 0; i < n; i++ {
-		mr.buffer[mr.writeIndex] = p[i]
-		mr.writeIndex = (mr.writeIndex + 1) % mr.bufferSize
+
+	if mr.config.Overrun == OverrunGrow {
+		mr.growFor(len(p))
 	}
 
-	// Notify all readers that new data is available
+	// Only the last len(buffer) bytes of a large write can be kept
+	if len(p) > len(mr.buffer) {
+		mr.written += int64(len(p) - len(mr.buffer))
+		p = p[len(p)-len(mr.buffer):]
+	}
+	for len(p) > 0 {
+		offset := int(mr.written % int64(len(mr.buffer)))
+		copied := copy(mr.buffer[offset:], p)
+		mr.written += int64(copied)
+		p = p[copied:]
+	}
+
+	// Wake every waiting reader
+	close(mr.notify)
+	mr.notify = make(chan struct{})
+
+	return n, nil
+}
+
+// growFor grows the buffer so the slowest reader keeps its audio through a write of n bytes,
+// doubling its size up to the maximum. The caller holds mr.mu.
+func (mr *MultiReader) growFor(n int) {
+	var lag int64
 	for _, reader := range mr.readers {
-		if !reader.closed && reader.readCond != nil {
-			reader.readCond.Signal()
+		lag = max(lag, mr.written-reader.position)
+	}
+	needed := lag + int64(n)
+	if needed <= int64(len(mr.buffer)) {
+		return
+	}
+
+	size := len(mr.buffer)
+	for int64(size) < needed && size < mr.config.MaxBufferSize {
+		size = min(size*2, mr.config.MaxBufferSize)
+	}
+	if size == len(mr.buffer) {
+		if !mr.maxLimit {
+			mr.maxLimit = true
+			mr.logger.Warn("Audio buffer is at its maximum size, slow readers will skip audio",
+				logger.Int("max_buffer_size", mr.config.MaxBufferSize))
 		}
+		return
 	}
 
-	return n, nil
+	// Lay out the buffered bytes at their positions in the larger buffer
+	buffer := make([]byte, size)
+	kept := min(mr.written, int64(len(mr.buffer)))
+	for pos := mr.written - kept; pos < mr.written; {
+		from := int(pos % int64(len(mr.buffer)))
+		to := int(pos % int64(size))
+		copied := copy(buffer[to:], mr.buffer[from:min(len(mr.buffer), from+int(mr.written-pos))])
+		pos += int64(copied)
+	}
+
+	mr.logger.Info("Grew audio buffer for a slow reader",
+		logger.Int("from", len(mr.buffer)),
+		logger.Int("to", size))
+	mr.buffer = buffer
+	mr.stats.Grows++
 }
 
 // CreateReader creates a new reader for the multi-reader
@@ -79,26 +176,15 @@ func (mr *MultiReader) CreateReader(id string) io.ReadCloser {
 	mr.mu.Lock()
 	defer mr.mu.Unlock()
 
-	// Check if reader already exists
-	if reader, exists := mr.readers[id]; exists {
-		if !reader.closed {
-			return newMultiReaderClient(mr, id)
+	// A reader that already exists keeps its position
+	if _, exists := mr.readers[id]; !exists {
+		mr.readers[id] = &readerState{
+			position: mr.livePosition(), // Start reading from the current write position
+			done:     make(chan struct{}),
 		}
-		// If it exists but is closed, remove it and create a new one
-		delete(mr.readers, id)
+		mr.logger.Debug("Created new reader", logger.String("reader_id", id))
 	}
 
-	// Create a new reader state
-	readerMutex := &sync.Mutex{}
-	reader := &readerState{
-		readIndex: mr.writeIndex,             // Start reading from current write position
-		readCond:  sync.NewCond(readerMutex), // Condition variable for signaling
-		closed:    false,
-	}
-
-	mr.readers[id] = reader
-	mr.logger.Debug("Created new reader", logger.String("reader_id", id))
-
 	return newMultiReaderClient(mr, id)
 }
 
@@ -108,15 +194,7 @@ func (mr *MultiReader) RemoveReader(id string) {
 	defer mr.mu.Unlock()
 
 	if reader, exists := mr.readers[id]; exists {
-		// Mark as closed
-		reader.closed = true
-
-		// Signal the reader in case it's waiting
-		if reader.readCond != nil {
-			reader.readCond.Signal()
-		}
-
-		// Remove from map
+		close(reader.done)
 		delete(mr.readers, id)
 		mr.logger.Debug("Removed reader", logger.String("reader_id", id))
 	}
@@ -134,25 +212,80 @@ func (mr *MultiReader) Close() error {
 	mr.closed = true
 	mr.cancel()
 
-	// Close all readers
 	for id, reader := range mr.readers {
-		// Mark as closed
-		reader.closed = true
-
-		// Signal the reader in case it's waiting
-		if reader.readCond != nil {
-			reader.readCond.Signal()
-		}
-
+		close(reader.done)
 		mr.logger.Debug("Closed reader during shutdown", logger.String("reader_id", id))
 	}
-
-	// Clear readers map
 	mr.readers = make(map[string]*readerState)
 
 	return nil
 }
 
+// Stats returns the buffer size and overruns
+func (mr *MultiReader) Stats() MultiReaderStats {
+	mr.mu.Lock()
+	defer mr.mu.Unlock()
+
+	stats := mr.stats
+	stats.BufferSize = len(mr.buffer)
+	stats.Readers = len(mr.readers)
+	return stats
+}
+
+// Lag returns how many bytes a reader is behind the writer, or false if there's no such reader
+func (mr *MultiReader) Lag(id string) (int64, bool) {
+	mr.mu.Lock()
+	defer mr.mu.Unlock()
+
+	reader, exists := mr.readers[id]
+	if !exists {
+		return 0, false
+	}
+	return mr.written - reader.position, true
+}
+
+// livePosition returns the write position, rounded down to the start of a sample frame.
+// The caller holds mr.mu.
+func (mr *MultiReader) livePosition() int64 {
+	return mr.written - mr.written%int64(mr.config.FrameSize)
+}
+
+// read copies buffered data for a reader into p. A reader that has fallen more than a whole
+// buffer behind lost audio to the writer, so it skips to live audio and the gap is logged.
+// With nothing to read it returns 0 and the channel closed by the next write. The caller
+// holds mr.mu.
+func (mr *MultiReader) read(id string, reader *readerState, p []byte) (int, <-chan struct{}) {
+	if mr.written-reader.position > int64(len(mr.buffer)) {
+		live := mr.livePosition()
+		skipped := live - reader.position
+		reader.position = live
+		reader.overruns++
+		mr.stats.Overruns++
+		mr.stats.BytesSkipped += skipped
+
+		mr.logger.Warn("Reader fell behind the audio buffer, skipped ahead leaving a gap",
+			logger.String("reader_id", id),
+			logger.Int("bytes_skipped", int(skipped)),
+			logger.Int("buffer_size", len(mr.buffer)),
+			logger.Int("reader_overruns", int(reader.overruns)))
+	}
+
+	available := mr.written - reader.position
+	if available <= 0 {
+		return 0, mr.notify
+	}
+
+	n := 0
+	for n < len(p) && reader.position < mr.written {
+		offset := int(reader.position % int64(len(mr.buffer)))
+		end := min(len(mr.buffer), offset+int(mr.written-reader.position))
+		copied := copy(p[n:], mr.buffer[offset:end])
+		n += copied
+		reader.position += int64(copied)
+	}
+	return n, nil
+}
+
 // multiReaderClient is a ReadCloser that reads from a MultiReader
 type multiReaderClient struct {
 	mr *MultiReader
@@ -168,103 +301,44 @@ func newMultiReaderClient(mr *MultiReader, id string) io.ReadCloser {
 	}
 }
 
-// Read reads data from the multi-reader
+// Read reads data from the multi-reader, waiting for new data if there is none
 func (mrc *multiReaderClient) Read(p []byte) (n int, err error) {
 	// Lock to prevent concurrent reads from the same client
 	mrc.mu.Lock()
 	defer mrc.mu.Unlock()
 
-	// Get reader state
-	mrc.mr.mu.RLock()
-	reader, exists := mrc.mr.readers[mrc.id]
-	if !exists || reader.closed || mrc.mr.closed {
-		mrc.mr.mu.RUnlock()
-		return 0, io.EOF
+	if len(p) == 0 {
+		return 0, nil
 	}
 
-	// Get current read position and buffer size
-	readIndex := reader.readIndex
-	writeIndex := mrc.mr.writeIndex
-	bufferSize := mrc.mr.bufferSize
-	readCond := reader.readCond
-	mrc.mr.mu.RUnlock()
+	timeout := time.NewTimer(readerWaitTimeout)
+	defer timeout.Stop()
 
-	// If there's no data available, wait for it
-	if readIndex == writeIndex {
-		// Wait for data with a timeout
-		waitChan := make(chan struct{})
-
-		go func() {
-			readCond.L.Lock()
-			defer readCond.L.Unlock()
-
-			// Wait for signal or timeout
-			readCond.Wait()
-			close(waitChan)
-		}()
+	for {
+		mrc.mr.mu.Lock()
+		reader, exists := mrc.mr.readers[mrc.id]
+		if !exists || mrc.mr.closed {
+			mrc.mr.mu.Unlock()
+			return 0, io.EOF
+		}
+		n, wait := mrc.mr.read(mrc.id, reader, p)
+		mrc.mr.mu.Unlock()
+		if n > 0 {
+			return n, nil
+		}
 
-		// Wait for either data or context cancellation
 		select {
-		case <-waitChan:
-			// Data is available, continue
-		case <-mrc.mr.ctx.Done():
+		case <-wait:
+			// Data is available, read it
+		case <-reader.done:
 			return 0, io.EOF
-		case <-time.After(30 * time.Second):
-			// Longer timeout, and return EOF to signal connection should be reestablished
+		case <-mrc.mr.ctx.Done():
 			return 0, io.EOF
-		}
-
-		// Re-check state after waiting
-		mrc.mr.mu.RLock()
-		reader, exists = mrc.mr.readers[mrc.id]
-		if !exists || reader.closed || mrc.mr.closed {
-			mrc.mr.mu.RUnlock()
+		case <-timeout.C:
+			// Return EOF to signal the connection should be reestablished
 			return 0, io.EOF
 		}
-		readIndex = reader.readIndex
-		writeIndex = mrc.mr.writeIndex
-		mrc.mr.mu.RUnlock()
-	}
-
-	// Calculate how much data is available
-	var available int
-	if writeIndex > readIndex {
-		available = writeIndex - readIndex
-	} else {
-		available = bufferSize - readIndex + writeIndex
 	}
-
-	// Limit to buffer size
-	if available > len(p) {
-		available = len(p)
-	}
-
-	// Copy data from circular buffer to output buffer
-	copied := 0
-	for copied < available {
-		// Calculate contiguous chunk size
-		chunkSize := available - copied
-		if readIndex+chunkSize > bufferSize {
-			chunkSize = bufferSize - readIndex
-		}
-
-		// Lock for reading from buffer
-		mrc.mr.mu.RLock()
-		copy(p[copied:copied+chunkSize], mrc.mr.buffer[readIndex:readIndex+chunkSize])
-		mrc.mr.mu.RUnlock()
-
-		copied += chunkSize
-		readIndex = (readIndex + chunkSize) % bufferSize
-	}
-
-	// Update read position
-	mrc.mr.mu.Lock()
-	if reader, exists := mrc.mr.readers[mrc.id]; exists && !reader.closed {
-		reader.readIndex = readIndex
-	}
-	mrc.mr.mu.Unlock()
-
-	return copied, nil
 }
 
 // Close closes the reader
@@ -0,0 +1,185 @@
+package audio
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+)
+
+// ErrUnsupportedAudio is returned when the built-in decoder can't read a source's audio
+// and ffmpeg is needed
+var ErrUnsupportedAudio = errors.New("audio format not supported by the built-in decoder")
+
+// pcmFormat decodes one sample of a raw PCM format to [-1, 1]
+type pcmFormat struct {
+	size   int
+	decode func(b []byte) float64
+}
+
+// pcmFormats are the raw PCM formats the built-in decoder reads, by ffmpeg's names
+var pcmFormats = map[string]pcmFormat{
+	"u8":    {1, func(b []byte) float64 { return (float64(b[0]) - 128) / 128 }},
+	"s16le": {2, func(b []byte) float64 { return float64(int16(binary.LittleEndian.Uint16(b))) / 32768 }},
+	"s16be": {2, func(b []byte) float64 { return float64(int16(binary.BigEndian.Uint16(b))) / 32768 }},
+	"s24le": {3, decodeS24LE},
+	"s32le": {4, func(b []byte) float64 { return float64(int32(binary.LittleEndian.Uint32(b))) / 2147483648 }},
+	"f32le": {4, func(b []byte) float64 { return float64(math.Float32frombits(binary.LittleEndian.Uint32(b))) }},
+}
+
+// decodeS24LE decodes a little-endian 24-bit sample, shifted into the top of an int32 for its sign
+func decodeS24LE(b []byte) float64 {
+	return float64(int32(uint32(b[0])<<8|uint32(b[1])<<16|uint32(b[2])<<24)) / 2147483648
+}
+
+// PCMConverter reads raw PCM from a source and converts it to s16le at another sample rate
+// and channel count, without ffmpeg
+type PCMConverter struct {
+	src       io.ReadCloser
+	format    pcmFormat
+	resampler *Resampler
+	buffer    []byte
+	leftover  []byte // Partial sample from the previous read
+	out       []byte // Converted audio not yet read
+	err       error  // Error to return once out is drained
+}
+
+// NewPCMConverter creates a converter of raw PCM in a format (u8, s16le, s16be, s24le, s32le
+// or f32le) at inRate with inChannels to s16le at outRate with outChannels
+func NewPCMConverter(src io.ReadCloser, format string, inRate, inChannels, outRate, outChannels int) (*PCMConverter, error) {
+	pcm, ok := pcmFormats[format]
+	if !ok {
+		return nil, fmt.Errorf("%w: PCM format %q", ErrUnsupportedAudio, format)
+	}
+	if inRate <= 0 || inChannels <= 0 || outRate <= 0 || outChannels <= 0 {
+		return nil, fmt.Errorf("invalid sample rate or channels")
+	}
+	return &PCMConverter{
+		src:       src,
+		format:    pcm,
+		resampler: NewResampler(inRate, inChannels, outRate, outChannels),
+		buffer:    make([]byte, 16*1024),
+	}, nil
+}
+
+// Read returns converted s16le audio
+func (c *PCMConverter) Read(p []byte) (int, error) {
+	for len(c.out) == 0 {
+		if c.err != nil {
+			return 0, c.err
+		}
+		n, err := c.src.Read(c.buffer)
+		if n > 0 {
+			c.out = c.convert(c.buffer[:n])
+		}
+		c.err = err
+	}
+
+	n := copy(p, c.out)
+	c.out = c.out[n:]
+	return n, nil
+}
+
+// Close closes the source
+func (c *PCMConverter) Close() error {
+	return c.src.Close()
+}
+
+// convert decodes, resamples and encodes a block of input
+func (c *PCMConverter) convert(data []byte) []byte {
+	if len(c.leftover) > 0 {
+		data = append(c.leftover, data...)
+		c.leftover = nil
+	}
+	count := len(data) / c.format.size
+	if rest := data[count*c.format.size:]; len(rest) > 0 {
+		c.leftover = append([]byte(nil), rest...)
+	}
+
+	samples := make([]float64, count)
+	for i := range samples {
+		samples[i] = c.format.decode(data[i*c.format.size:])
+	}
+
+	resampled := c.resampler.Process(samples)
+	out := make([]byte, len(resampled)*2)
+	for i, sample := range resampled {
+		sample = math.Max(-1, math.Min(1, sample))
+		binary.LittleEndian.PutUint16(out[i*2:], uint16(int16(math.Round(sample*32767))))
+	}
+	return out
+}
+
+// bufferedBody reads through a buffer and closes the underlying body
+type bufferedBody struct {
+	*bufio.Reader
+	io.Closer
+}
+
+// readWAVHeader reads a WAV header up to the start of the sample data and returns the
+// PCM format, sample rate and channels. Streamed WAV often has no valid data size, so
+// the data chunk is read to the end of the stream.
+func readWAVHeader(r *bufio.Reader) (string, int, int, error) {
+	var riff [12]byte
+	if _, err := io.ReadFull(r, riff[:]); err != nil {
+		return "", 0, 0, err
+	}
+	if string(riff[0:4]) != "RIFF" || string(riff[8:12]) != "WAVE" {
+		return "", 0, 0, fmt.Errorf("%w: not a WAV stream", ErrUnsupportedAudio)
+	}
+
+	format, rate, channels := "", 0, 0
+	for {
+		var chunk [8]byte
+		if _, err := io.ReadFull(r, chunk[:]); err != nil {
+			return "", 0, 0, err
+		}
+		id, size := string(chunk[0:4]), binary.LittleEndian.Uint32(chunk[4:8])
+
+		switch id {
+		case "fmt ":
+			if size < 16 {
+				return "", 0, 0, fmt.Errorf("invalid WAV fmt chunk")
+			}
+			fmtChunk := make([]byte, size+size%2)
+			if _, err := io.ReadFull(r, fmtChunk); err != nil {
+				return "", 0, 0, err
+			}
+			audioFormat := binary.LittleEndian.Uint16(fmtChunk[0:2])
+			channels = int(binary.LittleEndian.Uint16(fmtChunk[2:4]))
+			rate = int(binary.LittleEndian.Uint32(fmtChunk[4:8]))
+			bits := binary.LittleEndian.Uint16(fmtChunk[14:16])
+			if audioFormat == 0xFFFE && size >= 26 { // WAVE_FORMAT_EXTENSIBLE keeps the format in its sub-format GUID
+				audioFormat = binary.LittleEndian.Uint16(fmtChunk[24:26])
+			}
+
+			switch {
+			case audioFormat == 1 && bits == 8:
+				format = "u8"
+			case audioFormat == 1 && bits == 16:
+				format = "s16le"
+			case audioFormat == 1 && bits == 24:
+				format = "s24le"
+			case audioFormat == 1 && bits == 32:
+				format = "s32le"
+			case audioFormat == 3 && bits == 32:
+				format = "f32le"
+			default:
+				return "", 0, 0, fmt.Errorf("%w: WAV format %d with %d bits", ErrUnsupportedAudio, audioFormat, bits)
+			}
+
+		case "data":
+			if format == "" || rate <= 0 || channels <= 0 {
+				return "", 0, 0, fmt.Errorf("invalid WAV header")
+			}
+			return format, rate, channels, nil
+
+		default:
+			if _, err := r.Discard(int(size + size%2)); err != nil {
+				return "", 0, 0, err
+			}
+		}
+	}
+}
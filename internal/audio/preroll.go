@@ -0,0 +1,69 @@
+package audio
+
+import (
+	"sync"
+	"time"
+)
+
+// PreRollChunk is a chunk of audio with the time it was captured
+type PreRollChunk struct {
+	Data       []byte
+	CapturedAt time.Time // When the end of the chunk was received
+}
+
+// PreRollBuffer keeps the most recent audio chunks of a stream, up to a maximum duration,
+// so audio that was sent to a consumer can be sent again after the consumer restarts.
+// It is safe for concurrent use.
+type PreRollBuffer struct {
+	maxAge time.Duration
+	chunks []PreRollChunk
+	mu     sync.Mutex
+}
+
+// NewPreRollBuffer creates a pre-roll buffer holding up to maxAge of audio
+func NewPreRollBuffer(maxAge time.Duration) *PreRollBuffer {
+	return &PreRollBuffer{
+		maxAge: maxAge,
+	}
+}
+
+// Add appends a chunk and drops the chunks that have aged out. The data is copied.
+func (b *PreRollBuffer) Add(data []byte, capturedAt time.Time) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.chunks = append(b.chunks, PreRollChunk{
+		Data:       append([]byte(nil), data...),
+		CapturedAt: capturedAt,
+	})
+
+	cutoff := capturedAt.Add(-b.maxAge)
+	drop := 0
+	for drop < len(b.chunks) && b.chunks[drop].CapturedAt.Before(cutoff) {
+		drop++
+	}
+	if drop > 0 {
+		// Copy down rather than reslice so the backing array doesn't grow forever
+		b.chunks = append(b.chunks[:0], b.chunks[drop:]...)
+	}
+}
+
+// Since returns the chunks that end after since, oldest first
+func (b *PreRollBuffer) Since(since time.Time) []PreRollChunk {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for i, chunk := range b.chunks {
+		if chunk.CapturedAt.After(since) {
+			return append([]PreRollChunk(nil), b.chunks[i:]...)
+		}
+	}
+	return nil
+}
+
+// Reset drops all buffered chunks
+func (b *PreRollBuffer) Reset() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.chunks = nil
+}
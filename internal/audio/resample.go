@@ -0,0 +1,160 @@
+package audio
+
+import (
+	"math"
+)
+
+// Resampler settings
+const (
+	resampleZeroCrossings = 16   // Zero crossings of the sinc kernel on each side, at the cutoff
+	resampleKernelPhases  = 256  // Kernel table entries per input sample
+	resampleCutoff        = 0.95 // Passband as a fraction of the lower Nyquist frequency
+)
+
+// Resampler converts interleaved samples between sample rates and channel counts with a
+// windowed-sinc interpolator, low-pass filtering below the output's Nyquist frequency when
+// downsampling. Samples are kept between calls, so a stream can be fed in blocks of any size.
+type Resampler struct {
+	inChannels  int
+	outChannels int
+	channels    int     // Channels resampled: outChannels if the counts match, mono otherwise
+	step        float64 // Input samples per output sample
+	cutoff      float64 // Cutoff as a fraction of the input Nyquist frequency
+	halfTaps    int     // Kernel half-width in input samples
+	kernel      []float64
+
+	history [][]float64 // Input samples not yet consumed, per channel
+	pos     float64     // Position of the next output sample in history
+}
+
+// NewResampler creates a resampler from inRate with inChannels to outRate with outChannels.
+// Channels are averaged down to mono or mono is copied to every channel when the counts differ.
+func NewResampler(inRate, inChannels, outRate, outChannels int) *Resampler {
+	r := &Resampler{
+		inChannels:  inChannels,
+		outChannels: outChannels,
+		channels:    1,
+		step:        float64(inRate) / float64(outRate),
+	}
+	if inChannels == outChannels {
+		r.channels = outChannels
+	}
+	r.history = make([][]float64, r.channels)
+
+	if inRate == outRate {
+		return r
+	}
+
+	r.cutoff = resampleCutoff
+	if outRate < inRate {
+		r.cutoff *= float64(outRate) / float64(inRate)
+	}
+	r.halfTaps = int(math.Ceil(resampleZeroCrossings / r.cutoff))
+
+	// Blackman-windowed sinc, sampled finely enough to interpolate between entries
+	r.kernel = make([]float64, r.halfTaps*resampleKernelPhases+2)
+	for i := range r.kernel {
+		x := float64(i) / resampleKernelPhases
+		if x >= float64(r.halfTaps) {
+			continue
+		}
+		u := x / float64(r.halfTaps)
+		window := 0.42 + 0.5*math.Cos(math.Pi*u) + 0.08*math.Cos(2*math.Pi*u)
+		r.kernel[i] = r.cutoff * sinc(r.cutoff*x) * window
+	}
+
+	// Start with silence before the first sample so output begins with it
+	for c := range r.history {
+		r.history[c] = make([]float64, r.halfTaps)
+	}
+	r.pos = float64(r.halfTaps)
+	return r
+}
+
+// Process resamples interleaved samples in [-1, 1] and returns the interleaved output
+// produced so far. Trailing partial frames are ignored.
+func (r *Resampler) Process(in []float64) []float64 {
+	frames := len(in) / r.inChannels
+	for c := range r.history {
+		for f := 0; f < frames; f++ {
+			r.history[c] = append(r.history[c], r.inputSample(in[f*r.inChannels:], c))
+		}
+	}
+
+	if r.kernel == nil {
+		out := r.interleave(r.history, len(r.history[0]))
+		for c := range r.history {
+			r.history[c] = r.history[c][:0]
+		}
+		return out
+	}
+
+	available := len(r.history[0])
+	outputs := make([][]float64, r.channels)
+	for int(r.pos)+r.halfTaps < available {
+		base := int(r.pos)
+		frac := r.pos - float64(base)
+		for c, history := range r.history {
+			sum := 0.0
+			for k := -r.halfTaps + 1; k <= r.halfTaps; k++ {
+				sum += history[base+k] * r.kernelAt(float64(k)-frac)
+			}
+			outputs[c] = append(outputs[c], sum)
+		}
+		r.pos += r.step
+	}
+
+	// Keep the samples the next outputs still need
+	if drop := int(r.pos) - r.halfTaps + 1; drop > 0 {
+		drop = min(drop, available)
+		for c := range r.history {
+			r.history[c] = append(r.history[c][:0], r.history[c][drop:]...)
+		}
+		r.pos -= float64(drop)
+	}
+
+	return r.interleave(outputs, len(outputs[0]))
+}
+
+// inputSample returns channel c of an input frame, averaging the frame down to mono when
+// the channel counts differ
+func (r *Resampler) inputSample(frame []float64, c int) float64 {
+	if r.channels == r.inChannels {
+		return frame[c]
+	}
+	sum := 0.0
+	for i := 0; i < r.inChannels; i++ {
+		sum += frame[i]
+	}
+	return sum / float64(r.inChannels)
+}
+
+// interleave interleaves the resampled channels into output frames
+func (r *Resampler) interleave(channels [][]float64, frames int) []float64 {
+	out := make([]float64, 0, frames*r.outChannels)
+	for f := 0; f < frames; f++ {
+		for c := 0; c < r.outChannels; c++ {
+			out = append(out, channels[c%r.channels][f])
+		}
+	}
+	return out
+}
+
+// kernelAt interpolates the kernel at an offset in input samples
+func (r *Resampler) kernelAt(x float64) float64 {
+	x = math.Abs(x) * resampleKernelPhases
+	i := int(x)
+	if i+1 >= len(r.kernel) {
+		return 0
+	}
+	frac := x - float64(i)
+	return r.kernel[i] + (r.kernel[i+1]-r.kernel[i])*frac
+}
+
+// sinc is the normalised sinc function
+func sinc(x float64) float64 {
+	if x == 0 {
+		return 1
+	}
+	return math.Sin(math.Pi*x) / (math.Pi * x)
+}
@@ -0,0 +1,260 @@
+package audio
+
+import (
+	"context"
+	"encoding/binary"
+	"io"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/yegors/co-atc/pkg/logger"
+)
+
+// DefaultScannerHold is how long a scanner stays on a frequency after its transmission ends
+const DefaultScannerHold = 2 * time.Second
+
+// Mixing of scanner and stereo streams
+const (
+	mixFrame       = 20 * time.Millisecond  // Audio mixed per step
+	mixMaxLag      = time.Second            // Audio of a playing frequency kept before the oldest is dropped
+	mixMaxCatchUp  = 5                      // Frames produced at once after the reader fell behind
+	scannerPreRoll = 300 * time.Millisecond // Audio kept from before a squelch opens, covering its detection delay
+)
+
+// ScannerInput is a frequency played by a scanner
+type ScannerInput struct {
+	ID     string
+	Reader io.ReadCloser // s16le PCM in the scanner's format
+	Active func() bool   // Reports whether a transmission is in progress
+	GainDB float64
+}
+
+// ScannerConfig contains the settings of a scanner
+type ScannerConfig struct {
+	SampleRate int
+	Channels   int
+	Hold       time.Duration // How long to stay on a frequency after its transmission ends, for the reply (default: DefaultScannerHold)
+}
+
+// Scanner plays whichever of its frequencies has a transmission, like a radio scanner. Inputs
+// are in priority order: the scanner stays on a frequency until its transmission ends and
+// the hold time passes, unless a frequency of higher priority starts transmitting. Reading
+// returns s16le PCM in real time, with silence while no frequency is active.
+type Scanner struct {
+	config      ScannerConfig
+	inputs      []*scannerInput
+	sampleBytes int // Bytes per sample frame
+	frameBytes  int
+	preRoll     int // Bytes
+	maxLag      int // Bytes
+
+	current   int // Index of the playing input, -1 for none
+	heldUntil time.Time
+	mu        sync.Mutex
+
+	out    *io.PipeReader
+	ctx    context.Context
+	cancel context.CancelFunc
+	once   sync.Once
+	logger *logger.Logger
+}
+
+// scannerInput is an input with the audio read from it and not yet played
+type scannerInput struct {
+	ScannerInput
+	gain  float64
+	queue []byte
+}
+
+// NewScanner creates a scanner of the inputs, highest priority first, and starts reading
+// them. Closing the scanner closes the inputs; the scanner ends when any input ends.
+func NewScanner(ctx context.Context, config ScannerConfig, inputs []ScannerInput, logger *logger.Logger) *Scanner {
+	if config.Hold <= 0 {
+		config.Hold = DefaultScannerHold
+	}
+	sampleBytes := config.Channels * 2
+	bytesFor := func(d time.Duration) int {
+		return int(d.Seconds()*float64(config.SampleRate)) * sampleBytes
+	}
+
+	scannerCtx, cancel := context.WithCancel(ctx)
+	reader, writer := io.Pipe()
+	s := &Scanner{
+		config:      config,
+		sampleBytes: sampleBytes,
+		frameBytes:  bytesFor(mixFrame),
+		preRoll:     bytesFor(scannerPreRoll),
+		maxLag:      bytesFor(mixMaxLag),
+		current:     -1,
+		out:         reader,
+		ctx:         scannerCtx,
+		cancel:      cancel,
+		logger:      logger,
+	}
+	for _, input := range inputs {
+		s.inputs = append(s.inputs, &scannerInput{
+			ScannerInput: input,
+			gain:         math.Pow(10, input.GainDB/20),
+		})
+	}
+
+	for i := range s.inputs {
+		go s.readInput(i)
+	}
+	go s.run(writer)
+	return s
+}
+
+// Read returns the scanner's audio
+func (s *Scanner) Read(p []byte) (int, error) {
+	return s.out.Read(p)
+}
+
+// Close stops the scanner and closes its inputs
+func (s *Scanner) Close() error {
+	s.once.Do(func() {
+		s.cancel()
+		for _, input := range s.inputs {
+			input.Reader.Close()
+		}
+		s.out.Close()
+	})
+	return nil
+}
+
+// Playing returns the ID of the frequency being played, or "" if none is
+func (s *Scanner) Playing() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.current < 0 {
+		return ""
+	}
+	return s.inputs[s.current].ID
+}
+
+// readInput queues an input's audio. Only the pre-roll is kept while the input isn't playing.
+func (s *Scanner) readInput(i int) {
+	input := s.inputs[i]
+	buf := make([]byte, 4096)
+	for {
+		n, err := input.Reader.Read(buf)
+		if n > 0 {
+			s.mu.Lock()
+			input.queue = append(input.queue, buf[:n]...)
+			limit := s.preRoll
+			if i == s.current {
+				limit = s.maxLag
+			}
+			input.queue = trimQueue(input.queue, limit, s.sampleBytes)
+			s.mu.Unlock()
+		}
+		if err != nil {
+			if s.ctx.Err() == nil {
+				s.logger.Info("Scanner input ended", String("id", input.ID), Error(err))
+			}
+			s.cancel()
+			return
+		}
+	}
+}
+
+// run writes a frame of audio every frame interval until the scanner stops
+func (s *Scanner) run(w *io.PipeWriter) {
+	writeFrames(s.ctx, w, s.mix)
+}
+
+// writeFrames writes a frame of mixed audio every frame interval until the context ends or
+// the reader goes away, catching up a few frames after the reader fell behind
+func writeFrames(ctx context.Context, w *io.PipeWriter, frame func(now time.Time) []byte) {
+	defer w.Close()
+
+	ticker := time.NewTicker(mixFrame)
+	defer ticker.Stop()
+
+	start := time.Now()
+	var written int64
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			due := int64(now.Sub(start) / mixFrame)
+			written = max(written, due-mixMaxCatchUp)
+			for ; written < due; written++ {
+				if _, err := w.Write(frame(now)); err != nil {
+					return
+				}
+			}
+		}
+	}
+}
+
+// trimQueue drops the oldest whole sample frames of queued audio beyond limit bytes
+func trimQueue(queue []byte, limit, sampleBytes int) []byte {
+	excess := len(queue) - limit
+	if excess <= 0 {
+		return queue
+	}
+	excess += (sampleBytes - excess%sampleBytes) % sampleBytes
+	return append(queue[:0], queue[min(excess, len(queue)):]...)
+}
+
+// scaleSamples applies a linear gain to s16le samples, clipping at full scale
+func scaleSamples(pcm []byte, gain float64) {
+	for i := 0; i+1 < len(pcm); i += 2 {
+		sample := float64(int16(binary.LittleEndian.Uint16(pcm[i:]))) * gain
+		sample = math.Max(-32768, math.Min(32767, math.Round(sample)))
+		binary.LittleEndian.PutUint16(pcm[i:], uint16(int16(sample)))
+	}
+}
+
+// mix returns the next frame of the playing input with its gain applied, padded with silence
+func (s *Scanner) mix(now time.Time) []byte {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.selectInput(now)
+
+	frame := make([]byte, s.frameBytes)
+	if s.current < 0 {
+		return frame
+	}
+	input := s.inputs[s.current]
+	n := min(len(input.queue), s.frameBytes)
+	n -= n % s.sampleBytes
+	copy(frame, input.queue[:n])
+	input.queue = append(input.queue[:0], input.queue[n:]...)
+
+	if input.gain != 1 {
+		scaleSamples(frame[:n], input.gain)
+	}
+	return frame
+}
+
+// selectInput picks the input to play: the current one until its transmission ends and the
+// hold time passes, unless an input of higher priority becomes active. The caller holds s.mu.
+func (s *Scanner) selectInput(now time.Time) {
+	if s.current >= 0 && s.inputs[s.current].Active() {
+		s.heldUntil = now.Add(s.config.Hold)
+	}
+	held := s.current >= 0 && now.Before(s.heldUntil)
+
+	candidates := len(s.inputs)
+	if held {
+		candidates = s.current
+	}
+	for i := 0; i < candidates; i++ {
+		if s.inputs[i].Active() {
+			if i != s.current {
+				s.logger.Debug("Scanner switched frequency", String("id", s.inputs[i].ID))
+			}
+			s.current = i
+			s.heldUntil = now.Add(s.config.Hold)
+			return
+		}
+	}
+	if !held {
+		s.current = -1
+	}
+}
@@ -0,0 +1,267 @@
+package audio
+
+import (
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// SourceType identifies where a frequency's audio comes from
+type SourceType string
+
+const (
+	// SourceStream is a network stream (HTTP, HLS, SRT, ...) read by ffmpeg
+	SourceStream SourceType = "stream"
+	// SourceSDR is a local SDR tuned and demodulated by rtl_fm or SoapySDR's rx_fm
+	SourceSDR SourceType = "sdr"
+	// SourcePipe is raw PCM or IQ samples read from a named pipe or file
+	SourcePipe SourceType = "pipe"
+)
+
+// StreamProtocol is the protocol of a network stream, which decides how ffmpeg reads it
+type StreamProtocol string
+
+const (
+	StreamHTTP StreamProtocol = "http" // Continuous HTTP/Icecast stream
+	StreamHLS  StreamProtocol = "hls"  // HTTP Live Streaming playlist (m3u8)
+	StreamRTSP StreamProtocol = "rtsp"
+	StreamSRT  StreamProtocol = "srt"
+)
+
+// HLS input settings
+const (
+	hlsLiveStartSegments = 3    // Segments behind the live edge to start from
+	hlsMaxReload         = 1000 // Playlist reloads without a new segment before ffmpeg gives up
+)
+
+// SDR drivers
+const (
+	SDRDriverRTLFM = "rtl_fm"
+	SDRDriverSoapy = "soapy"
+)
+
+// Source defaults, matching the rate the transcription service expects
+const (
+	defaultSDRRate  = 24000
+	defaultSDRGain  = "auto"
+	defaultPipeRate = 24000
+)
+
+// Source describes the input of a frequency. Local sources are written as URLs so they
+// can be configured, stored and edited like stream URLs:
+//
+//	sdr://rtl_fm?device=0&gain=40&ppm=1&squelch=0&sample_rate=24000
+//	sdr://soapy?device=driver=airspy&gain=20
+//	pipe:///run/co-atc/cyyz_twr.pcm?format=s16le&sample_rate=16000&channels=1
+//	pipe:///run/co-atc/cyyz_twr.iq?format=cu8&sample_rate=2400000
+//
+// The SDR is tuned to the frequency's frequency_mhz unless freq_mhz is given.
+//
+// Network streams are used as-is, except that an hls+ or rtsp+udp prefix can force
+// the protocol when it can't be told from the URL:
+//
+//	hls+https://example.com/live/feed?token=abc
+//	rtsp+udp://192.168.1.20:8554/airband
+type Source struct {
+	Type SourceType
+	URL  string // Stream URL
+
+	// Stream settings
+	Protocol      StreamProtocol
+	RTSPTransport string // tcp or udp
+
+	// SDR settings
+	Driver     string
+	Device     string  // Device index (rtl_fm) or SoapySDR device arguments (soapy)
+	Gain       string  // Tuner gain in dB, or "auto"
+	PPM        int     // Frequency correction in parts per million
+	Squelch    int     // Squelch level, 0 = open
+	FreqMHz    float64 // Overrides the frequency's frequency_mhz
+	Modulation string  // Demodulation mode, "am" for airband
+
+	// Pipe settings
+	Path     string
+	Format   string // PCM format (s16le, s32le, f32le, ...) or IQ format (cu8, cs16)
+	Channels int
+
+	SampleRate int // Output rate of the SDR tool, or sample rate of the pipe
+}
+
+// ParseSource parses a frequency URL into a source description. Anything that isn't an
+// sdr:// or pipe:// URL is a stream for ffmpeg.
+func ParseSource(rawURL string) (*Source, error) {
+	switch {
+	case strings.HasPrefix(rawURL, "sdr://"):
+		return parseSDRSource(rawURL)
+	case strings.HasPrefix(rawURL, "pipe://"):
+		return parsePipeSource(rawURL)
+	default:
+		return parseStreamSource(rawURL)
+	}
+}
+
+// parseStreamSource works out the protocol of a network stream URL
+func parseStreamSource(rawURL string) (*Source, error) {
+	source := &Source{Type: SourceStream, URL: rawURL, Protocol: StreamHTTP, RTSPTransport: "tcp"}
+
+	switch {
+	case strings.HasPrefix(rawURL, "hls+"):
+		source.URL = strings.TrimPrefix(rawURL, "hls+")
+		source.Protocol = StreamHLS
+	case strings.HasPrefix(rawURL, "rtsp+udp://"):
+		source.URL = "rtsp://" + strings.TrimPrefix(rawURL, "rtsp+udp://")
+		source.Protocol = StreamRTSP
+		source.RTSPTransport = "udp"
+	case strings.HasPrefix(rawURL, "rtsp://"), strings.HasPrefix(rawURL, "rtsps://"):
+		source.Protocol = StreamRTSP
+	case strings.HasPrefix(rawURL, "srt://"):
+		source.Protocol = StreamSRT
+	default:
+		if IsHLSURL(rawURL) {
+			source.Protocol = StreamHLS
+		}
+	}
+
+	if source.Protocol == StreamHLS && !strings.HasPrefix(source.URL, "http://") && !strings.HasPrefix(source.URL, "https://") {
+		return nil, fmt.Errorf("HLS sources must be http:// or https:// URLs")
+	}
+
+	return source, nil
+}
+
+// IsHLSURL reports whether a URL points at an HLS playlist by its extension
+func IsHLSURL(rawURL string) bool {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return false
+	}
+	return strings.HasSuffix(strings.ToLower(u.Path), ".m3u8")
+}
+
+// IsIQ reports whether a pipe source carries IQ samples that must be demodulated
+func (s *Source) IsIQ() bool {
+	return s.Type == SourcePipe && isIQFormat(s.Format)
+}
+
+// parseSDRSource parses an sdr:// URL
+func parseSDRSource(rawURL string) (*Source, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid SDR URL: %w", err)
+	}
+
+	source := &Source{
+		Type:       SourceSDR,
+		Driver:     u.Host,
+		Gain:       defaultSDRGain,
+		Modulation: "am",
+		SampleRate: defaultSDRRate,
+	}
+	if source.Driver != SDRDriverRTLFM && source.Driver != SDRDriverSoapy {
+		return nil, fmt.Errorf("unknown SDR driver %q (use %s or %s)", source.Driver, SDRDriverRTLFM, SDRDriverSoapy)
+	}
+
+	query := u.Query()
+	source.Device = query.Get("device")
+	if gain := query.Get("gain"); gain != "" {
+		if gain != "auto" {
+			if _, err := strconv.ParseFloat(gain, 64); err != nil {
+				return nil, fmt.Errorf("invalid SDR gain %q", gain)
+			}
+		}
+		source.Gain = gain
+	}
+	if modulation := query.Get("modulation"); modulation != "" {
+		source.Modulation = modulation
+	}
+	if source.PPM, err = queryInt(query, "ppm", 0); err != nil {
+		return nil, err
+	}
+	if source.Squelch, err = queryInt(query, "squelch", 0); err != nil {
+		return nil, err
+	}
+	if source.SampleRate, err = queryInt(query, "sample_rate", defaultSDRRate); err != nil {
+		return nil, err
+	}
+	if source.SampleRate <= 0 {
+		return nil, fmt.Errorf("invalid SDR sample_rate: %d", source.SampleRate)
+	}
+	if freq := query.Get("freq_mhz"); freq != "" {
+		if source.FreqMHz, err = strconv.ParseFloat(freq, 64); err != nil || source.FreqMHz <= 0 {
+			return nil, fmt.Errorf("invalid SDR freq_mhz %q", freq)
+		}
+	}
+
+	return source, nil
+}
+
+// parsePipeSource parses a pipe:// URL
+func parsePipeSource(rawURL string) (*Source, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid pipe URL: %w", err)
+	}
+	if u.Path == "" {
+		return nil, fmt.Errorf("pipe URL needs an absolute path, e.g. pipe:///run/co-atc/audio.pcm")
+	}
+
+	query := u.Query()
+	source := &Source{
+		Type:   SourcePipe,
+		Path:   u.Path,
+		Format: query.Get("format"),
+	}
+	if source.Format == "" {
+		source.Format = "s16le"
+	}
+	if source.SampleRate, err = queryInt(query, "sample_rate", defaultPipeRate); err != nil {
+		return nil, err
+	}
+	if source.Channels, err = queryInt(query, "channels", 1); err != nil {
+		return nil, err
+	}
+	if source.SampleRate <= 0 || source.Channels <= 0 {
+		return nil, fmt.Errorf("invalid pipe sample_rate or channels")
+	}
+
+	return source, nil
+}
+
+// queryInt reads an integer query parameter
+func queryInt(query url.Values, key string, defaultValue int) (int, error) {
+	value := query.Get(key)
+	if value == "" {
+		return defaultValue, nil
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, fmt.Errorf("invalid %s %q", key, value)
+	}
+	return n, nil
+}
+
+// sdrArgs returns the rtl_fm / rx_fm arguments to tune and demodulate the frequency.
+// Both tools share rtl_fm's command line and write mono s16le at the sample rate.
+func (s *Source) sdrArgs(frequencyMHz float64) []string {
+	if s.FreqMHz > 0 {
+		frequencyMHz = s.FreqMHz
+	}
+
+	args := []string{
+		"-f", strconv.FormatInt(int64(frequencyMHz*1e6+0.5), 10),
+		"-M", s.Modulation,
+		"-s", strconv.Itoa(s.SampleRate),
+		"-p", strconv.Itoa(s.PPM),
+		"-l", strconv.Itoa(s.Squelch),
+		"-E", "dc", // AM carriers leave a DC offset after demodulation
+	}
+	if s.Gain != "auto" {
+		args = append(args, "-g", s.Gain)
+	}
+	if s.Device != "" {
+		args = append(args, "-d", s.Device)
+	}
+
+	return append(args, "-")
+}
@@ -0,0 +1,144 @@
+package audio
+
+import (
+	"context"
+	"encoding/binary"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/yegors/co-atc/pkg/logger"
+)
+
+// stereoJitter is the audio a side buffers before it plays, and again after it ran dry, so
+// the bursts its source is read in don't leave gaps
+const stereoJitter = 250 * time.Millisecond
+
+// StereoMixer plays one frequency in the left channel and another in the right, so a single
+// player can monitor both. Reading returns 16-bit stereo PCM in real time; a side without
+// audio is silent.
+type StereoMixer struct {
+	sides       [2]*stereoSide
+	channels    int // Channels of the inputs, mixed down to mono for their side
+	sampleBytes int // Bytes per input sample frame
+	frameBytes  int // Bytes of input per frame
+	jitter      int // Bytes
+	maxLag      int // Bytes
+	mu          sync.Mutex
+
+	out    *io.PipeReader
+	ctx    context.Context
+	cancel context.CancelFunc
+	once   sync.Once
+	logger *logger.Logger
+}
+
+// stereoSide is the input of one channel with its audio not yet played
+type stereoSide struct {
+	id        string
+	reader    io.ReadCloser
+	queue     []byte
+	buffering bool // Waiting for the jitter buffer to fill
+}
+
+// NewStereoMixer creates a mixer of two inputs of s16le PCM with the given sample rate and
+// channels, and starts reading them. Its output has the same sample rate and two channels.
+// Closing the mixer closes the inputs; the mixer ends when either input ends.
+func NewStereoMixer(ctx context.Context, sampleRate, channels int, leftID string, left io.ReadCloser, rightID string, right io.ReadCloser, logger *logger.Logger) *StereoMixer {
+	sampleBytes := channels * 2
+	bytesFor := func(d time.Duration) int {
+		return int(d.Seconds()*float64(sampleRate)) * sampleBytes
+	}
+
+	mixerCtx, cancel := context.WithCancel(ctx)
+	reader, writer := io.Pipe()
+	m := &StereoMixer{
+		sides: [2]*stereoSide{
+			{id: leftID, reader: left, buffering: true},
+			{id: rightID, reader: right, buffering: true},
+		},
+		channels:    channels,
+		sampleBytes: sampleBytes,
+		frameBytes:  bytesFor(mixFrame),
+		jitter:      bytesFor(stereoJitter),
+		maxLag:      bytesFor(mixMaxLag),
+		out:         reader,
+		ctx:         mixerCtx,
+		cancel:      cancel,
+		logger:      logger,
+	}
+
+	for _, side := range m.sides {
+		go m.readSide(side)
+	}
+	go writeFrames(m.ctx, writer, m.mix)
+	return m
+}
+
+// Read returns the mixed stereo audio
+func (m *StereoMixer) Read(p []byte) (int, error) {
+	return m.out.Read(p)
+}
+
+// Close stops the mixer and closes its inputs
+func (m *StereoMixer) Close() error {
+	m.once.Do(func() {
+		m.cancel()
+		for _, side := range m.sides {
+			side.reader.Close()
+		}
+		m.out.Close()
+	})
+	return nil
+}
+
+// readSide queues the audio of one side
+func (m *StereoMixer) readSide(side *stereoSide) {
+	buf := make([]byte, 4096)
+	for {
+		n, err := side.reader.Read(buf)
+		if n > 0 {
+			m.mu.Lock()
+			side.queue = trimQueue(append(side.queue, buf[:n]...), m.maxLag, m.sampleBytes)
+			if side.buffering && len(side.queue) >= m.jitter {
+				side.buffering = false
+			}
+			m.mu.Unlock()
+		}
+		if err != nil {
+			if m.ctx.Err() == nil {
+				m.logger.Info("Stereo input ended", String("id", side.id), Error(err))
+			}
+			m.cancel()
+			return
+		}
+	}
+}
+
+// mix returns the next frame with each side mixed down to mono in its channel
+func (m *StereoMixer) mix(time.Time) []byte {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	frames := m.frameBytes / m.sampleBytes
+	out := make([]byte, frames*4)
+	for c, side := range m.sides {
+		if side.buffering {
+			continue
+		}
+		n := min(len(side.queue), m.frameBytes)
+		n -= n % m.sampleBytes
+		for f := 0; f < n/m.sampleBytes; f++ {
+			sum := 0
+			for ch := 0; ch < m.channels; ch++ {
+				sum += int(int16(binary.LittleEndian.Uint16(side.queue[f*m.sampleBytes+ch*2:])))
+			}
+			binary.LittleEndian.PutUint16(out[f*4+c*2:], uint16(int16(sum/m.channels)))
+		}
+		side.queue = append(side.queue[:0], side.queue[n:]...)
+		if len(side.queue) == 0 {
+			side.buffering = true
+		}
+	}
+	return out
+}
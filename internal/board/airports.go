@@ -0,0 +1,37 @@
+package board
+
+import "strings"
+
+// Airport is the origin or destination of a flight on a board
+type Airport struct {
+	ICAO    string `json:"icao,omitempty"`
+	IATA    string `json:"iata,omitempty"`
+	Name    string `json:"name,omitempty"`
+	City    string `json:"city,omitempty"`
+	Country string `json:"country,omitempty"`
+}
+
+// describeAirport fills in an airport's name, city and country from the database
+func (s *Service) describeAirport(airport Airport) *Airport {
+	if airport.ICAO == "" && airport.IATA == "" {
+		return nil
+	}
+	known, ok := s.airports[strings.ToUpper(airport.ICAO)]
+	if !ok {
+		known, ok = s.airports[strings.ToUpper(airport.IATA)]
+	}
+	if ok {
+		if airport.ICAO == "" {
+			airport.ICAO = known.ICAO
+		}
+		if airport.IATA == "" {
+			airport.IATA = known.IATA
+		}
+		if airport.Name == "" {
+			airport.Name = known.Name
+		}
+		airport.City = known.City
+		airport.Country = known.Country
+	}
+	return &airport
+}
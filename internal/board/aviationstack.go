@@ -0,0 +1,136 @@
+package board
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// aviationstackPageSize is the most flights aviationstack returns per request on its free plan
+const aviationstackPageSize = 100
+
+// aviationstackResponse is a response of aviationstack's flights endpoint
+type aviationstackResponse struct {
+	Error *struct {
+		Code    string `json:"code"`
+		Message string `json:"message"`
+	} `json:"error"`
+	Data []aviationstackFlight `json:"data"`
+}
+
+// aviationstackFlight is a flight in an aviationstack response
+type aviationstackFlight struct {
+	FlightStatus string                `json:"flight_status"` // "scheduled", "active", "landed", "cancelled", "incident" or "diverted"
+	Departure    aviationstackEndpoint `json:"departure"`
+	Arrival      aviationstackEndpoint `json:"arrival"`
+	Airline      struct {
+		Name string `json:"name"`
+	} `json:"airline"`
+	Flight struct {
+		IATA       string          `json:"iata"`
+		ICAO       string          `json:"icao"`
+		Codeshared json.RawMessage `json:"codeshared"` // The operating flight, for codeshare listings
+	} `json:"flight"`
+}
+
+// aviationstackEndpoint is the departure or arrival of an aviationstack flight
+type aviationstackEndpoint struct {
+	Airport   string `json:"airport"`
+	Timezone  string `json:"timezone"`
+	IATA      string `json:"iata"`
+	ICAO      string `json:"icao"`
+	Delay     *int   `json:"delay"` // Minutes
+	Scheduled string `json:"scheduled"`
+	Estimated string `json:"estimated"`
+	Actual    string `json:"actual"`
+}
+
+// fetchAviationstack fetches the airport's arrivals or departures from aviationstack
+func (s *Service) fetchAviationstack(ctx context.Context, direction string) ([]scheduledFlight, error) {
+	query := url.Values{}
+	query.Set("access_key", s.config.APIKey)
+	query.Set("limit", fmt.Sprint(aviationstackPageSize))
+	if direction == Arrivals {
+		query.Set("arr_icao", s.airportCode)
+	} else {
+		query.Set("dep_icao", s.airportCode)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, s.config.APIBaseURL+"/flights?"+query.Encode(), nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to execute request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response body: %w", err)
+	}
+
+	var response aviationstackResponse
+	if err := json.Unmarshal(body, &response); err != nil {
+		if resp.StatusCode != http.StatusOK {
+			return nil, fmt.Errorf("unexpected status code: %d", resp.StatusCode)
+		}
+		return nil, fmt.Errorf("failed to parse JSON: %w", err)
+	}
+	if response.Error != nil {
+		return nil, fmt.Errorf("aviationstack error %s: %s", response.Error.Code, response.Error.Message)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status code: %d", resp.StatusCode)
+	}
+
+	flights := make([]scheduledFlight, 0, len(response.Data))
+	for _, f := range response.Data {
+		// Codeshare listings repeat the operating flight under another number
+		if codeshared := strings.TrimSpace(string(f.Flight.Codeshared)); codeshared != "" && codeshared != "null" {
+			continue
+		}
+
+		here, other := f.Arrival, f.Departure
+		if direction == Departures {
+			here, other = f.Departure, f.Arrival
+		}
+		flights = append(flights, scheduledFlight{
+			Callsign:     strings.ToUpper(f.Flight.ICAO),
+			FlightNumber: strings.ToUpper(f.Flight.IATA),
+			Airline:      f.Airline.Name,
+			Status:       f.FlightStatus,
+			Other:        Airport{ICAO: other.ICAO, IATA: other.IATA, Name: other.Airport},
+			Scheduled:    parseAviationstackTime(here.Scheduled, here.Timezone),
+			Estimated:    parseAviationstackTime(here.Estimated, here.Timezone),
+			Actual:       parseAviationstackTime(here.Actual, here.Timezone),
+			Delay:        here.Delay,
+		})
+	}
+	return flights, nil
+}
+
+// parseAviationstackTime parses an aviationstack time. aviationstack sends the airport's local
+// time marked +00:00, so the wall clock is read in the airport's time zone.
+func parseAviationstackTime(value, timezone string) *time.Time {
+	if value == "" {
+		return nil
+	}
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return nil
+	}
+	if location, err := time.LoadLocation(timezone); err == nil {
+		t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), t.Second(), 0, location)
+	}
+	t = t.UTC()
+	return &t
+}
@@ -0,0 +1,397 @@
+// Package board builds the airport's arrivals and departures boards from the movement log,
+// enriched with scheduled times, origins and destinations from a schedule provider.
+package board
+
+import (
+	"context"
+	"math"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/yegors/co-atc/internal/airports"
+	"github.com/yegors/co-atc/internal/config"
+	"github.com/yegors/co-atc/internal/movements"
+	"github.com/yegors/co-atc/pkg/logger"
+)
+
+// Boards
+const (
+	Arrivals   = "arrivals"
+	Departures = "departures"
+)
+
+// Flight statuses on a board
+const (
+	StatusScheduled = "scheduled"
+	StatusEnRoute   = "en_route" // Arrivals the provider reports airborne
+	StatusDelayed   = "delayed"
+	StatusLanded    = "landed"
+	StatusDeparted  = "departed"
+	StatusCancelled = "cancelled"
+	StatusDiverted  = "diverted"
+)
+
+// matchWindow is how far a movement may be from a flight's expected time to be that flight
+const matchWindow = 6 * time.Hour
+
+// scheduledFlight is a flight of the airport's schedule
+type scheduledFlight struct {
+	Callsign     string // ICAO flight number, e.g. "ACA123"
+	FlightNumber string // IATA flight number, e.g. "AC123"
+	Airline      string
+	Status       string  // As the provider reports it
+	Other        Airport // Origin of arrivals, destination of departures
+	Scheduled    *time.Time
+	Estimated    *time.Time
+	Actual       *time.Time
+	Delay        *int // Minutes, as the provider reports it
+}
+
+// Flight is a flight on a board
+type Flight struct {
+	Callsign     string     `json:"callsign,omitempty"`
+	FlightNumber string     `json:"flight_number,omitempty"`
+	Airline      string     `json:"airline,omitempty"`
+	Hex          string     `json:"hex,omitempty"`
+	AircraftType string     `json:"aircraft_type,omitempty"`
+	Origin       *Airport   `json:"origin,omitempty"`      // Arrivals
+	Destination  *Airport   `json:"destination,omitempty"` // Departures
+	Scheduled    *time.Time `json:"scheduled,omitempty"`
+	Estimated    *time.Time `json:"estimated,omitempty"`
+	Actual       *time.Time `json:"actual,omitempty"`        // Touchdown or takeoff
+	DelayMinutes *int       `json:"delay_minutes,omitempty"` // Left out for unscheduled flights
+	Runway       string     `json:"runway,omitempty"`
+	SessionID    int64      `json:"session_id,omitempty"` // Flight session of the movement
+	Status       string     `json:"status"`
+}
+
+// ScheduleStatus reports how the schedule is being fetched
+type ScheduleStatus struct {
+	Provider   string     `json:"provider"`
+	Arrivals   int        `json:"arrivals"`   // Flights in the last fetch
+	Departures int        `json:"departures"` // Flights in the last fetch
+	LastFetch  *time.Time `json:"last_fetch,omitempty"`
+	LastError  string     `json:"last_error,omitempty"`
+}
+
+// Board is the airport's arrivals or departures
+type Board struct {
+	Airport  string          `json:"airport"`
+	Type     string          `json:"type"` // "arrivals" or "departures"
+	Start    time.Time       `json:"start"`
+	End      time.Time       `json:"end"`
+	Schedule *ScheduleStatus `json:"schedule,omitempty"` // Left out without a schedule provider
+	Flights  []Flight        `json:"flights"`            // By actual, estimated or scheduled time
+}
+
+// Service builds the boards and keeps the schedule up to date
+type Service struct {
+	config           config.BoardConfig
+	airportCode      string
+	movementsService *movements.Service
+	httpClient       *http.Client
+	logger           *logger.Logger
+
+	airports map[string]airports.Airport // By ICAO and IATA code
+
+	mu       sync.RWMutex
+	schedule map[string][]scheduledFlight // By board
+	status   ScheduleStatus
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// NewService creates a new board service
+func NewService(cfg config.BoardConfig, airportCode string, movementsService *movements.Service, logger *logger.Logger) *Service {
+	return &Service{
+		config:           cfg,
+		airportCode:      strings.ToUpper(airportCode),
+		movementsService: movementsService,
+		httpClient:       &http.Client{Timeout: 15 * time.Second},
+		logger:           logger.Named("board"),
+		airports:         make(map[string]airports.Airport),
+		schedule:         make(map[string][]scheduledFlight),
+		status:           ScheduleStatus{Provider: cfg.ScheduleProvider},
+	}
+}
+
+// Start loads the airport database and starts fetching the schedule
+func (s *Service) Start(ctx context.Context) error {
+	// Without city names the boards still work
+	if entries, err := airports.Load(s.config.AirportsDBPath); err != nil {
+		s.logger.Warn("Failed to load airport database", logger.String("path", s.config.AirportsDBPath), logger.Error(err))
+	} else {
+		s.airports = airports.ByCode(entries)
+	}
+
+	s.ctx, s.cancel = context.WithCancel(ctx)
+	if s.config.ScheduleProvider != "" {
+		s.wg.Add(1)
+		go s.run()
+	}
+
+	s.logger.Info("Board service started",
+		logger.String("airport", s.airportCode),
+		logger.String("schedule_provider", s.config.ScheduleProvider),
+		logger.Int("refresh_minutes", s.config.RefreshMinutes))
+	return nil
+}
+
+// Stop stops fetching the schedule
+func (s *Service) Stop() {
+	if s.cancel != nil {
+		s.cancel()
+	}
+	s.wg.Wait()
+}
+
+// Board returns the arrivals or departures board, from past_hours ago to ahead_hours ahead
+func (s *Service) Board(boardType string) (*Board, error) {
+	now := time.Now().UTC()
+	board := &Board{
+		Airport: s.airportCode,
+		Type:    boardType,
+		Start:   now.Add(-time.Duration(s.config.PastHours) * time.Hour),
+		End:     now.Add(time.Duration(s.config.AheadHours) * time.Hour),
+		Flights: make([]Flight, 0),
+	}
+
+	log, err := s.movementsService.Movements(board.Start, board.End, 0)
+	if err != nil {
+		return nil, err
+	}
+	moves := log.Arrivals
+	if boardType == Departures {
+		moves = log.Departures
+	}
+
+	s.mu.RLock()
+	schedule := s.schedule[boardType]
+	if s.config.ScheduleProvider != "" {
+		status := s.status
+		board.Schedule = &status
+	}
+	s.mu.RUnlock()
+
+	matched := make(map[int]bool)
+	for _, f := range schedule {
+		expected := expectedTime(f)
+		if expected == nil {
+			continue
+		}
+		i := matchMovement(f, *expected, moves, matched)
+		var move *movements.Movement
+		if i >= 0 {
+			matched[i] = true
+			move = &moves[i]
+		}
+
+		flight := s.scheduledEntry(boardType, f, move, now)
+		if at := boardTime(flight); at.Before(board.Start) || !at.Before(board.End) {
+			continue
+		}
+		board.Flights = append(board.Flights, flight)
+	}
+
+	// Movements of flights the schedule doesn't have, or of every flight without a provider
+	for i, move := range moves {
+		if matched[i] {
+			continue
+		}
+		at := move.Time
+		status := StatusLanded
+		if boardType == Departures {
+			status = StatusDeparted
+		}
+		board.Flights = append(board.Flights, Flight{
+			Callsign:     move.Callsign,
+			Airline:      move.Operator,
+			Hex:          move.Hex,
+			AircraftType: move.AircraftType,
+			Actual:       &at,
+			Runway:       move.Runway,
+			SessionID:    move.SessionID,
+			Status:       status,
+		})
+	}
+
+	sort.SliceStable(board.Flights, func(i, j int) bool {
+		return boardTime(board.Flights[i]).Before(boardTime(board.Flights[j]))
+	})
+	return board, nil
+}
+
+// scheduledEntry is the board entry of a scheduled flight and its movement, if it moved
+func (s *Service) scheduledEntry(boardType string, f scheduledFlight, move *movements.Movement, now time.Time) Flight {
+	flight := Flight{
+		Callsign:     f.Callsign,
+		FlightNumber: f.FlightNumber,
+		Airline:      f.Airline,
+		Scheduled:    f.Scheduled,
+		Estimated:    f.Estimated,
+		Actual:       f.Actual,
+	}
+	if boardType == Arrivals {
+		flight.Origin = s.describeAirport(f.Other)
+	} else {
+		flight.Destination = s.describeAirport(f.Other)
+	}
+	if move != nil {
+		at := move.Time
+		flight.Actual = &at
+		flight.Hex = move.Hex
+		flight.AircraftType = move.AircraftType
+		flight.Runway = move.Runway
+		flight.SessionID = move.SessionID
+	}
+
+	flight.DelayMinutes = estimateDelay(flight.Scheduled, flight.Estimated, flight.Actual, f.Delay, now)
+	flight.Status = s.flightStatus(boardType, f.Status, flight)
+	return flight
+}
+
+// flightStatus works out the status of a scheduled flight on a board
+func (s *Service) flightStatus(boardType, reported string, flight Flight) string {
+	switch {
+	case reported == "cancelled":
+		return StatusCancelled
+	case reported == "diverted":
+		return StatusDiverted
+	case flight.Actual != nil && boardType == Arrivals:
+		return StatusLanded
+	case flight.Actual != nil:
+		return StatusDeparted
+	case flight.DelayMinutes != nil && *flight.DelayMinutes >= s.config.DelayThresholdMinutes:
+		return StatusDelayed
+	case reported == "active" && boardType == Arrivals:
+		return StatusEnRoute
+	case reported == "active":
+		return StatusDeparted
+	}
+	return StatusScheduled
+}
+
+// estimateDelay returns how late a flight is in minutes: at its actual time, else its
+// estimated time or the delay the provider reports, else, for a flight that's due and hasn't
+// moved, at least how long it's been due. Unscheduled flights have no delay.
+func estimateDelay(scheduled, estimated, actual *time.Time, reported *int, now time.Time) *int {
+	if scheduled == nil {
+		return nil
+	}
+
+	var late time.Duration
+	switch {
+	case actual != nil:
+		late = actual.Sub(*scheduled)
+	case estimated != nil:
+		late = estimated.Sub(*scheduled)
+	case reported != nil:
+		late = time.Duration(*reported) * time.Minute
+	case now.After(*scheduled):
+		late = now.Sub(*scheduled)
+	}
+	minutes := int(math.Round(late.Minutes()))
+	return &minutes
+}
+
+// matchMovement returns the index of the movement of a scheduled flight: the unmatched one
+// with its callsign or flight number closest to its expected time, or -1 if there's none
+func matchMovement(f scheduledFlight, expected time.Time, moves []movements.Movement, matched map[int]bool) int {
+	best, bestGap := -1, matchWindow
+	for i, move := range moves {
+		if matched[i] || move.Callsign == "" {
+			continue
+		}
+		callsign := strings.ToUpper(move.Callsign)
+		if callsign != f.Callsign && callsign != f.FlightNumber {
+			continue
+		}
+		gap := move.Time.Sub(expected)
+		if gap < 0 {
+			gap = -gap
+		}
+		if gap <= bestGap {
+			best, bestGap = i, gap
+		}
+	}
+	return best
+}
+
+// expectedTime is when a scheduled flight is expected to move: its actual time if the provider
+// has one, else its estimated or scheduled time
+func expectedTime(f scheduledFlight) *time.Time {
+	switch {
+	case f.Actual != nil:
+		return f.Actual
+	case f.Estimated != nil:
+		return f.Estimated
+	}
+	return f.Scheduled
+}
+
+// boardTime is the time a flight is listed at: actual, estimated or scheduled
+func boardTime(f Flight) time.Time {
+	switch {
+	case f.Actual != nil:
+		return *f.Actual
+	case f.Estimated != nil:
+		return *f.Estimated
+	case f.Scheduled != nil:
+		return *f.Scheduled
+	}
+	return time.Time{}
+}
+
+// run fetches the schedule every refresh interval until the service stops
+func (s *Service) run() {
+	defer s.wg.Done()
+
+	ticker := time.NewTicker(time.Duration(s.config.RefreshMinutes) * time.Minute)
+	defer ticker.Stop()
+
+	for {
+		s.refresh()
+
+		select {
+		case <-s.ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// refresh fetches the arrivals and departures schedules. The previous schedule is kept if a
+// fetch fails.
+func (s *Service) refresh() {
+	for _, boardType := range []string{Arrivals, Departures} {
+		flights, err := s.fetchAviationstack(s.ctx, boardType)
+		if s.ctx.Err() != nil {
+			return
+		}
+
+		s.mu.Lock()
+		if err != nil {
+			s.status.LastError = err.Error()
+			s.mu.Unlock()
+			s.logger.Warn("Failed to fetch schedule", logger.String("board", boardType), logger.Error(err))
+			continue
+		}
+		now := time.Now().UTC()
+		s.schedule[boardType] = flights
+		s.status.LastFetch = &now
+		s.status.LastError = ""
+		if boardType == Arrivals {
+			s.status.Arrivals = len(flights)
+		} else {
+			s.status.Departures = len(flights)
+		}
+		s.mu.Unlock()
+
+		s.logger.Debug("Fetched schedule", logger.String("board", boardType), logger.Int("flights", len(flights)))
+	}
+}
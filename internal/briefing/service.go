@@ -0,0 +1,217 @@
+package briefing
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/yegors/co-atc/internal/config"
+	"github.com/yegors/co-atc/internal/templating"
+	"github.com/yegors/co-atc/internal/usage"
+	"github.com/yegors/co-atc/internal/websocket"
+	"github.com/yegors/co-atc/pkg/logger"
+)
+
+// DataSource renders briefings from live airspace data
+type DataSource interface {
+	GetBriefingData(information string) (*templating.BriefingData, error)
+	RenderBriefingTemplate(templatePath string, data *templating.BriefingData) (string, error)
+}
+
+// Briefing is a generated airspace briefing
+type Briefing struct {
+	Information   string    `json:"information"`
+	Text          string    `json:"text"`
+	AircraftCount int       `json:"aircraft_count"`
+	HasAudio      bool      `json:"has_audio"`
+	AudioURL      string    `json:"audio_url,omitempty"`
+	GeneratedAt   time.Time `json:"generated_at"`
+}
+
+// Service generates spoken airspace briefings and broadcasts them on a schedule
+type Service struct {
+	config     config.BriefingConfig
+	dataSource DataSource
+	wsServer   *websocket.Server
+	speaker    *Speaker
+	logger     *logger.Logger
+
+	// generateMu serializes generation, so concurrent requests don't each pay for speech
+	generateMu sync.Mutex
+
+	mu          sync.RWMutex
+	latest      *Briefing
+	audio       []byte
+	letter      int    // Index of the current information letter
+	conditions  string // Weather and runway conditions of the current information letter
+	initialized bool
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// NewService creates a new briefing service
+func NewService(cfg config.BriefingConfig, dataSource DataSource, wsServer *websocket.Server, usageTracker *usage.Tracker, logger *logger.Logger) *Service {
+	return &Service{
+		config:     cfg,
+		dataSource: dataSource,
+		wsServer:   wsServer,
+		speaker:    NewSpeaker(cfg, usage.SubsystemBriefing, usageTracker),
+		logger:     logger.Named("briefing"),
+	}
+}
+
+// Start starts broadcasting briefings every interval_minutes, if set
+func (s *Service) Start(ctx context.Context) {
+	s.ctx, s.cancel = context.WithCancel(ctx)
+
+	if s.config.IntervalMinutes > 0 {
+		s.wg.Add(1)
+		go s.run(time.Duration(s.config.IntervalMinutes) * time.Minute)
+	}
+
+	s.logger.Info("Briefing service started",
+		logger.Int("interval_minutes", s.config.IntervalMinutes),
+		logger.Bool("speech", s.config.OpenAIAPIKey != ""))
+}
+
+// Stop stops broadcasting briefings
+func (s *Service) Stop() {
+	if s.cancel != nil {
+		s.cancel()
+	}
+	s.wg.Wait()
+}
+
+// run generates and broadcasts a briefing every interval
+func (s *Service) run(interval time.Duration) {
+	defer s.wg.Done()
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-s.ctx.Done():
+			return
+		case <-ticker.C:
+			briefing, err := s.Generate(s.ctx)
+			if err != nil {
+				s.logger.Error("Failed to generate scheduled briefing", logger.Error(err))
+				continue
+			}
+			s.broadcast(briefing)
+		}
+	}
+}
+
+// Latest returns the latest briefing if it's younger than maxAge, or generates a new one
+func (s *Service) Latest(ctx context.Context, maxAge time.Duration) (*Briefing, error) {
+	s.mu.RLock()
+	latest := s.latest
+	s.mu.RUnlock()
+
+	if latest != nil && time.Since(latest.GeneratedAt) < maxAge {
+		return latest, nil
+	}
+	return s.Generate(ctx)
+}
+
+// Generate renders a briefing from current data and, if an API key is configured, speaks it
+func (s *Service) Generate(ctx context.Context) (*Briefing, error) {
+	s.generateMu.Lock()
+	defer s.generateMu.Unlock()
+
+	// The information letter depends on the conditions, so it's set once the data is read
+	data, err := s.dataSource.GetBriefingData("")
+	if err != nil {
+		return nil, fmt.Errorf("failed to get briefing data: %w", err)
+	}
+	data.Information = s.informationLetter(data)
+
+	text, err := s.dataSource.RenderBriefingTemplate(s.config.TemplatePath, data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to render briefing: %w", err)
+	}
+
+	briefing := &Briefing{
+		Information:   data.Information,
+		Text:          text,
+		AircraftCount: data.AircraftCount,
+		GeneratedAt:   time.Now().UTC(),
+	}
+
+	var audio []byte
+	if s.config.OpenAIAPIKey != "" {
+		audio, err = s.speaker.Synthesize(ctx, text)
+		if err != nil {
+			// The text is still worth serving without audio
+			s.logger.Error("Failed to synthesize briefing", logger.Error(err))
+		} else {
+			briefing.HasAudio = true
+			briefing.AudioURL = "/api/v1/briefing/audio"
+		}
+	}
+
+	s.mu.Lock()
+	s.latest = briefing
+	s.audio = audio
+	s.mu.Unlock()
+
+	s.logger.Debug("Generated briefing",
+		logger.String("information", briefing.Information),
+		logger.Int("length", len(text)),
+		logger.Bool("audio", briefing.HasAudio))
+
+	return briefing, nil
+}
+
+// Audio returns the audio of the latest briefing and its content type
+func (s *Service) Audio() ([]byte, string, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if len(s.audio) == 0 {
+		return nil, "", false
+	}
+	return s.audio, s.speaker.ContentType(), true
+}
+
+// informationLetter returns the information letter for the briefing data. Like ATIS, the
+// letter advances when the weather or runways change, not on every briefing.
+func (s *Service) informationLetter(data *templating.BriefingData) string {
+	conditions := strings.Join([]string{data.Wind, data.Altimeter, data.Runways, data.Closures}, "|")
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.initialized && conditions != s.conditions {
+		s.letter++
+	}
+	s.conditions = conditions
+	s.initialized = true
+
+	return templating.InformationLetter(s.letter)
+}
+
+// broadcast sends a briefing to WebSocket clients
+func (s *Service) broadcast(briefing *Briefing) {
+	if s.wsServer == nil {
+		return
+	}
+
+	s.wsServer.Broadcast(&websocket.Message{
+		Type: "briefing",
+		Data: map[string]interface{}{
+			"information":    briefing.Information,
+			"text":           briefing.Text,
+			"aircraft_count": briefing.AircraftCount,
+			"has_audio":      briefing.HasAudio,
+			"audio_url":      briefing.AudioURL,
+			"generated_at":   briefing.GeneratedAt,
+		},
+	})
+}
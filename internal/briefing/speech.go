@@ -0,0 +1,107 @@
+package briefing
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/yegors/co-atc/internal/config"
+	"github.com/yegors/co-atc/internal/usage"
+)
+
+const (
+	// speechURL is OpenAI's text-to-speech endpoint
+	speechURL = "https://api.openai.com/v1/audio/speech"
+
+	// wordsPerMinute is the speaking rate used to estimate how much audio a text is
+	wordsPerMinute = 150
+)
+
+// audioContentTypes are the content types of the audio formats text-to-speech returns
+var audioContentTypes = map[string]string{
+	"mp3":  "audio/mpeg",
+	"opus": "audio/ogg",
+	"aac":  "audio/aac",
+	"wav":  "audio/wav",
+}
+
+// Speaker reads text out with OpenAI's text-to-speech API, using the voice settings of the
+// briefing configuration
+type Speaker struct {
+	config       config.BriefingConfig
+	subsystem    string // Usage subsystem the speech is recorded under
+	usageTracker *usage.Tracker
+	httpClient   *http.Client
+}
+
+// NewSpeaker creates a speaker whose usage is recorded under a subsystem
+func NewSpeaker(cfg config.BriefingConfig, subsystem string, usageTracker *usage.Tracker) *Speaker {
+	return &Speaker{
+		config:       cfg,
+		subsystem:    subsystem,
+		usageTracker: usageTracker,
+		httpClient:   &http.Client{Timeout: 60 * time.Second},
+	}
+}
+
+// ContentType returns the content type of the audio the speaker returns
+func (s *Speaker) ContentType() string {
+	return audioContentTypes[s.config.AudioFormat]
+}
+
+// Synthesize turns text into speech
+func (s *Speaker) Synthesize(ctx context.Context, text string) ([]byte, error) {
+	body := struct {
+		Model          string `json:"model"`
+		Input          string `json:"input"`
+		Voice          string `json:"voice"`
+		Instructions   string `json:"instructions,omitempty"`
+		ResponseFormat string `json:"response_format"`
+	}{
+		Model:          s.config.TTSModel,
+		Input:          text,
+		Voice:          s.config.Voice,
+		Instructions:   s.config.Instructions,
+		ResponseFormat: s.config.AudioFormat,
+	}
+
+	jsonData, err := json.Marshal(body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", speechURL, bytes.NewBuffer(jsonData))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+s.config.OpenAIAPIKey)
+
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to execute request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	audio, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response body: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("text-to-speech request failed with status %d: %s", resp.StatusCode, string(audio))
+	}
+
+	// The speech endpoint doesn't report usage; estimate the audio length from the word count
+	words := len(strings.Fields(text))
+	s.usageTracker.Record(s.subsystem, s.config.TTSModel, usage.Usage{
+		Requests:     1,
+		AudioSeconds: float64(words) / wordsPerMinute * 60,
+	})
+
+	return audio, nil
+}
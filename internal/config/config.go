@@ -1,8 +1,14 @@
 package config
 
 import (
+	"encoding/hex"
 	"fmt"
+	"net"
 	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/BurntSushi/toml"
 )
@@ -13,6 +19,7 @@ type Config struct {
 	Server         ServerConfig         `toml:"server"`          // HTTP server settings
 	ADSB           ADSBConfig           `toml:"adsb"`            // Aircraft tracking data source settings
 	Frequencies    FrequenciesConfig    `toml:"frequencies"`     // Radio frequency monitoring settings
+	Recording      RecordingConfig      `toml:"recording"`       // Audio recording and archival settings
 	Logging        LoggingConfig        `toml:"logging"`         // Application logging settings
 	Storage        StorageConfig        `toml:"storage"`         // Data persistence settings
 	Station        StationConfig        `toml:"station"`         // Physical location settings
@@ -22,24 +29,57 @@ type Config struct {
 	Weather        WeatherConfig        `toml:"wx"`              // Weather data fetching and caching settings
 	ATCChat        ATCChatConfig        `toml:"atc_chat"`        // ATC Chat voice assistant settings
 	Templating     TemplatingConfig     `toml:"templating"`      // Shared templating system settings
+	Push           PushConfig           `toml:"push"`            // Web Push notification settings
+	Usage          UsageConfig          `toml:"usage"`           // API usage and cost accounting settings
+	Deviations     DeviationsConfig     `toml:"deviations"`      // Altitude and heading clearance compliance monitoring
+	Terrain        TerrainConfig        `toml:"terrain"`         // Terrain and obstacle proximity warnings
+	Approaches     ApproachesConfig     `toml:"approaches"`      // Stabilized approach monitoring
+	Sequencing     SequencingConfig     `toml:"sequencing"`      // Arrival sequence and estimated times to touchdown
+	Intents        IntentsConfig        `toml:"intents"`         // Aircraft intent inference from clearances and tracks
+	Noise          NoiseConfig          `toml:"noise"`           // Noise-abatement zones and curfew monitoring
+	Movements      MovementsConfig      `toml:"movements"`       // Flight sessions and the airport's movement log
+	Occupancy      OccupancyConfig      `toml:"occupancy"`       // Runway occupancy times of landings and takeoffs
+	Board          BoardConfig          `toml:"board"`           // Arrivals and departures boards with schedule enrichment
+	Privacy        PrivacyConfig        `toml:"privacy"`         // Military and blocked aircraft identification
+	Feeder         FeederConfig         `toml:"feeder"`          // Feeding received aircraft to community aggregators
+	Briefing       BriefingConfig       `toml:"briefing"`        // Spoken airspace briefings
+	ATIS           ATISConfig           `toml:"atis"`            // Digital ATIS polling
+	Notify         NotifyConfig         `toml:"notify"`          // Alert delivery to webhooks, chat services and MQTT
+	MQTT           MQTTConfig           `toml:"mqtt"`            // Aircraft, transcription and alert publishing to an MQTT broker
+
+	TrafficGenerator TrafficGeneratorConfig `toml:"traffic_generator"` // Simulated background arrivals and departures
 }
 
 // ServerConfig contains HTTP server configuration settings
 type ServerConfig struct {
-	Port               int      `toml:"port"`                  // Primary HTTP port for the server
-	Host               string   `toml:"host"`                  // Host address to bind to (e.g., 127.0.0.1 for localhost only, 0.0.0.0 for all interfaces)
-	CORSAllowedOrigins []string `toml:"cors_allowed_origins"`  // List of origins allowed for CORS requests (use ["*"] for all origins)
-	ReadTimeoutSecs    int      `toml:"read_timeout_seconds"`  // Maximum duration for reading the entire request (0 = no timeout)
-	WriteTimeoutSecs   int      `toml:"write_timeout_seconds"` // Maximum duration for writing the response (0 = no timeout, recommended for streaming)
-	IdleTimeoutSecs    int      `toml:"idle_timeout_seconds"`  // Maximum duration to wait for the next request when keep-alives are enabled
-	AdditionalPorts    []int    `toml:"additional_ports"`      // Additional HTTP ports to listen on (useful for multiple interfaces)
-	StaticFilesDir     string   `toml:"static_files_dir"`      // Directory to serve static files from (e.g., "www")
+	Port                 int      `toml:"port"`                   // Primary HTTP port for the server
+	Host                 string   `toml:"host"`                   // Host address to bind to (e.g., 127.0.0.1 for localhost only, 0.0.0.0 for all interfaces)
+	CORSAllowedOrigins   []string `toml:"cors_allowed_origins"`   // List of origins allowed for CORS requests (use ["*"] for all origins)
+	ReadTimeoutSecs      int      `toml:"read_timeout_seconds"`   // Maximum duration for reading the entire request (0 = no timeout)
+	WriteTimeoutSecs     int      `toml:"write_timeout_seconds"`  // Maximum duration for writing the response (0 = no timeout, recommended for streaming)
+	IdleTimeoutSecs      int      `toml:"idle_timeout_seconds"`   // Maximum duration to wait for the next request when keep-alives are enabled
+	AdditionalPorts      []int    `toml:"additional_ports"`       // Additional HTTP ports to listen on (useful for multiple interfaces)
+	StaticFilesDir       string   `toml:"static_files_dir"`       // Directory to serve static files from (e.g., "www")
+	AdminToken           string   `toml:"admin_token"`            // Bearer token required for admin endpoints such as PATCH /api/v1/config (empty = admin endpoints disabled)
+	DisableResponseCache bool     `toml:"disable_response_cache"` // Disable the short-lived cache of read endpoints polled by dashboards (/aircraft, /station, /wx, ...)
+
+	Public PublicServerConfig `toml:"public"` // Read-only public view on a separate port
+}
+
+// PublicServerConfig contains settings for the read-only public view. It serves aircraft,
+// weather and delayed transcriptions only: no control endpoints, audio or WebSocket.
+type PublicServerConfig struct {
+	Enabled                   bool   `toml:"enabled"`                     // Serve the public view
+	Port                      int    `toml:"port"`                        // Port of the public view (must differ from the console ports)
+	Host                      string `toml:"host"`                        // Host address to bind to (default: server host)
+	TranscriptionDelaySeconds int    `toml:"transcription_delay_seconds"` // Transcriptions are only published this long after they were made (default: 300)
+	StaticFilesDir            string `toml:"static_files_dir"`            // Directory of a public web page to serve (empty = API only)
 }
 
 // ADSBConfig contains ADS-B aircraft tracking data source configuration
 type ADSBConfig struct {
 	// Source selection
-	SourceType string `toml:"source_type"` // Data source type: "local" (e.g., dump1090) or "external" (e.g., ADS-B Exchange API)
+	SourceType string `toml:"source_type"` // Data source type: "local" (e.g., dump1090), "external" (e.g., ADS-B Exchange API), "raw" (Beast/AVR) or "none"
 
 	// Legacy field - deprecated, use LocalSourceURL instead
 	SourceURL string `toml:"source_url"` // DEPRECATED: Legacy URL field for backward compatibility
@@ -53,24 +93,77 @@ type ADSBConfig struct {
 	APIKey            string `toml:"api_key"`             // API key for authentication with external service
 	SearchRadiusNM    int    `toml:"search_radius_nm"`    // Search radius in nautical miles for external API queries
 
+	// External API polling, to stay within rate limits
+	ExternalPollIntervalSecs   int `toml:"external_poll_interval_seconds"` // Minimum time between API requests (default: fetch_interval_seconds)
+	ExternalIdleIntervalSecs   int `toml:"external_idle_interval_seconds"` // Slowest polling while responses have no aircraft (default: 60)
+	ExternalMaxBackoffSecs     int `toml:"external_max_backoff_seconds"`   // Longest wait after failed requests, unless Retry-After asks for longer (default: 600)
+	ExternalDailyRequestBudget int `toml:"external_daily_request_budget"`  // Most API requests per day, spread over the day (0 = unlimited)
+
+	// Raw receiver output settings (used when source_type = "raw")
+	RawSourceAddress string `toml:"raw_source_address"` // host:port of the receiver's raw output (e.g., 127.0.0.1:30005)
+	RawFormat        string `toml:"raw_format"`         // "beast" (default, port 30005) or "avr" (port 30002)
+
+	// UAT 978 MHz receiver, merged with any source type
+	UATSourceURL string `toml:"uat_source_url"` // URL of dump978's aircraft.json (e.g., http://192.168.1.10/skyaware978/data/aircraft.json; empty = none)
+
 	// Common settings for both source types
 	FetchIntervalSecs        int    `toml:"fetch_interval_seconds"`      // How often to fetch new aircraft data (in seconds)
 	SignalLostTimeoutSecs    int    `toml:"signal_lost_timeout_seconds"` // Time after which aircraft is marked as signal_lost (in seconds, default: 60)
 	AirlineDBPath            string `toml:"airline_db_path"`             // Path to airline database JSON file for aircraft operator lookups
 	WebSocketAircraftUpdates bool   `toml:"websocket_aircraft_updates"`  // Enable WebSocket aircraft streaming (hybrid mode)
+	EstimateFuel             bool   `toml:"estimate_fuel"`               // Estimate fuel burn of real aircraft from type profiles
+
+	// Budget mode for constrained hosts (e.g. Raspberry Pi)
+	BudgetMode                bool `toml:"budget_mode"`                  // Cap per-cycle processing work
+	BudgetMaxPredictions      int  `toml:"budget_max_predictions"`       // Aircraft nearest the station that get future positions (default: 25)
+	BudgetPositionSampleEvery int  `toml:"budget_position_sample_every"` // Store an aircraft's position every N poll cycles (default: 3)
 }
 
 // LoggingConfig contains application logging configuration
 type LoggingConfig struct {
-	Level  string `toml:"level"`  // Log level: "debug", "info", "warn", or "error"
-	Format string `toml:"format"` // Log format: "json" (structured) or "console" (human-readable)
+	Level  string            `toml:"level"`  // Log level: "debug", "info", "warn", or "error"
+	Format string            `toml:"format"` // Log format: "json" (structured) or "console" (human-readable)
+	Levels map[string]string `toml:"levels"` // Level overrides by logger name, e.g. adsb = "debug"
+	File   LoggingFileConfig `toml:"file"`   // Rotating log file, written besides the console
+	// Latest log entries kept in memory for GET /api/v1/admin/logs (default: 5000, -1 = none)
+	BufferSize int `toml:"buffer_size"`
+}
+
+// LoggingFileConfig contains log file settings
+type LoggingFileConfig struct {
+	Path       string `toml:"path"`         // Log file path, empty = console only
+	Format     string `toml:"format"`       // "json" (default) or "console"
+	MaxSizeMB  int    `toml:"max_size_mb"`  // Size the file is rotated at (default: 100)
+	MaxBackups int    `toml:"max_backups"`  // Rotated files kept (default: 5, 0 with max_age_days set = no count limit)
+	MaxAgeDays int    `toml:"max_age_days"` // Days rotated files are kept (0 = no limit)
+	Compress   bool   `toml:"compress"`     // Gzip rotated files
 }
 
 // StorageConfig contains data persistence configuration
 type StorageConfig struct {
-	Type              string `toml:"type"`                 // Storage backend type (currently only "sqlite" is supported)
-	SQLiteBasePath    string `toml:"sqlite_base_path"`     // Base path for SQLite database files (actual filename will be generated as co-atc-YYYY-MM-DD.db)
-	MaxPositionsInAPI int    `toml:"max_positions_in_api"` // Maximum number of positions to return in the /aircraft API response
+	Type              string          `toml:"type"`                 // Storage backend type (currently only "sqlite" is supported)
+	SQLiteBasePath    string          `toml:"sqlite_base_path"`     // Base path for SQLite database files (actual filename will be generated as co-atc-YYYY-MM-DD.db)
+	MaxPositionsInAPI int             `toml:"max_positions_in_api"` // Maximum number of positions to return in the /aircraft API response
+	Retention         RetentionConfig `toml:"retention"`            // How long stored data is kept
+	BackupDir         string          `toml:"backup_dir"`           // Directory database backups are written to (default: <sqlite_base_path>/backups)
+
+	// Write tuning for busy airports
+	WriteQueueSize         int `toml:"write_queue_size"`            // Aircraft writes buffered ahead of the database (default: 2048, -1 = write synchronously)
+	WriteBatchSize         int `toml:"write_batch_size"`            // Most queued aircraft writes committed in one transaction (default: 500)
+	WALAutocheckpointPages int `toml:"wal_autocheckpoint_pages"`    // WAL pages written before SQLite checkpoints automatically (default: 4000)
+	CheckpointIntervalSecs int `toml:"checkpoint_interval_seconds"` // How often the WAL is checkpointed and truncated (default: 300, -1 = only automatically)
+}
+
+// RetentionConfig contains how long stored data is kept before it is pruned.
+// Recorded audio is pruned with the recording retention_hours window.
+type RetentionConfig struct {
+	IntervalMinutes   int    `toml:"interval_minutes"`   // Time between pruning passes (default: 60)
+	TranscriptionDays int    `toml:"transcription_days"` // Days transcriptions are kept (0 = keep forever)
+	ClearanceDays     int    `toml:"clearance_days"`     // Days clearances are kept (0 = keep forever)
+	TrackDays         int    `toml:"track_days"`         // Days positions, phase changes and aircraft no longer seen are kept (0 = keep forever)
+	EventDays         int    `toml:"event_days"`         // Days events on the alert timeline are kept (0 = keep forever)
+	Export            bool   `toml:"export"`             // Archive pruned rows to gzip-compressed JSON Lines files before deleting them
+	ExportDir         string `toml:"export_dir"`         // Directory for archives (default: <sqlite_base_path>/archive)
 }
 
 // StationConfig contains physical location configuration for the monitoring station
@@ -79,23 +172,28 @@ type StationConfig struct {
 	Longitude               float64 `toml:"longitude"`                  // Longitude of the station in decimal degrees (-180 to 180)
 	ElevationFeet           int     `toml:"elevation_feet"`             // Elevation of the station above sea level in feet
 	AirportCode             string  `toml:"airport_code"`               // ICAO code of the airport (e.g., "CYYZ")
-	RunwaysDBPath           string  `toml:"runways_db_path"`            // Path to runway database JSON file
+	RunwaysDBPath           string  `toml:"runways_db_path"`            // Path to runway database: runways.json, or an OurAirports runways.csv read for airport_code
 	RunwayExtensionLengthNM float64 `toml:"runway_extension_length_nm"` // Length of runway extensions in nautical miles
 	AirportRangeNM          float64 `toml:"airport_range_nm"`           // Range in nautical miles to consider aircraft as being at this airport (default: 5.0)
+	AutoDetect              bool    `toml:"auto_detect"`                // Fill in unset coordinates from the local receiver, and an unset airport code and elevation from the nearest airport
 }
 
 // TranscriptionConfig contains settings for audio transcription services
 type TranscriptionConfig struct {
+	Provider string `toml:"provider"` // Speech-to-text provider: "openai" (default) or "deepgram"
+
 	// OpenAI API settings
-	OpenAIAPIKey string `toml:"openai_api_key"` // OpenAI API key for transcription service
-	Model        string `toml:"model"`          // OpenAI model to use (e.g., "gpt-4o-transcribe")
-	Language     string `toml:"language"`       // Primary language for transcription (e.g., "en" for English)
-	PromptPath   string `toml:"prompt_path"`    // Path to the system prompt file for transcription
+	OpenAIAPIKey  string `toml:"openai_api_key"`  // OpenAI API key for transcription service
+	OpenAIBaseURL string `toml:"openai_base_url"` // OpenAI API base URL for transcription and post-processing (default: https://api.openai.com/v1)
+	Model         string `toml:"model"`           // OpenAI model to use (e.g., "gpt-4o-transcribe")
+	Language      string `toml:"language"`        // Primary language for transcription (e.g., "en" for English)
+	PromptPath    string `toml:"prompt_path"`     // Path to the system prompt file for transcription
 
 	// Audio processing settings
 	NoiseReduction string `toml:"noise_reduction"` // Noise reduction mode: "near_field", "far_field", or "none"
 	ChunkMs        int    `toml:"chunk_ms"`        // Size of audio chunks for processing in milliseconds
 	BufferSizeKB   int    `toml:"buffer_size_kb"`  // Audio buffer size in kilobytes
+	PreRollMs      int    `toml:"pre_roll_ms"`     // Recent audio replayed into a new session after a reconnect (0 = default, negative = off)
 
 	// FFmpeg conversion settings
 	FFmpegPath       string `toml:"ffmpeg_path"`        // Path to FFmpeg executable
@@ -107,30 +205,83 @@ type TranscriptionConfig struct {
 	ReconnectIntervalSec int `toml:"reconnect_interval_sec"` // Seconds to wait before reconnecting after failure
 	MaxRetries           int `toml:"max_retries"`            // Maximum number of connection retry attempts
 
+	// Frequency workers
+	MaxConcurrentSessions   int `toml:"max_concurrent_sessions"`    // Most frequencies transcribed at once, one realtime session each (0 = no limit)
+	WorkerRestartSeconds    int `toml:"worker_restart_seconds"`     // Wait before restarting a failed frequency worker, doubled per failure in a row (default: 5)
+	WorkerMaxRestartSeconds int `toml:"worker_max_restart_seconds"` // Longest wait before restarting a failed frequency worker (default: 300)
+
 	// Voice activity detection (VAD) settings
 	TurnDetectionType string  `toml:"turn_detection_type"` // Method for detecting speech turns (e.g., "server_vad")
 	PrefixPaddingMs   int     `toml:"prefix_padding_ms"`   // Milliseconds of audio to include before detected speech
 	SilenceDurationMs int     `toml:"silence_duration_ms"` // Milliseconds of silence to consider end of speech
 	VADThreshold      float64 `toml:"vad_threshold"`       // Threshold for voice activity detection (0.0-1.0)
 
+	// Silence gating settings
+	SilenceGating      bool    `toml:"silence_gating"`       // Only stream audio to OpenAI while a transmission is detected locally
+	SilenceThresholdDB float64 `toml:"silence_threshold_db"` // RMS level in dBFS that counts as a transmission (default: -45)
+	SilenceHangMs      int     `toml:"silence_hang_ms"`      // Audio streamed after a transmission ends (default: silence_duration_ms + 500)
+	SilencePaddingMs   int     `toml:"silence_padding_ms"`   // Audio streamed before a transmission starts (default: prefix_padding_ms)
+
 	// API retry settings
 	RetryMaxAttempts      int `toml:"retry_max_attempts"`       // Maximum number of API call retry attempts
 	RetryInitialBackoffMs int `toml:"retry_initial_backoff_ms"` // Initial backoff time in milliseconds
 	RetryMaxBackoffMs     int `toml:"retry_max_backoff_ms"`     // Maximum backoff time in milliseconds
+	ConnectsPerMinute     int `toml:"connects_per_minute"`      // New OpenAI sessions per minute across all frequencies (0 = no limit)
 
 	// HTTP timeout settings
 	TimeoutSeconds int `toml:"timeout_seconds"` // HTTP timeout for OpenAI API requests in seconds
+
+	// Vocabulary biasing settings
+	VocabularyBiasing        bool     `toml:"vocabulary_biasing"`         // Bias transcription toward the callsigns, runways and fixes currently in use
+	VocabularyMaxTerms       int      `toml:"vocabulary_max_terms"`       // Maximum number of terms passed to the provider (default: 60)
+	VocabularyRefreshSeconds int      `toml:"vocabulary_refresh_seconds"` // How often the terms are rebuilt from the airspace (default: 60)
+	LocalVocabulary          []string `toml:"local_vocabulary"`           // Local fixes, SIDs, STARs and landmarks always included in the terms
+
+	// Splitting transcripts that span several transmissions
+	SplitTurns bool `toml:"split_turns"` // Store one transcription per transmission (squelch) or speaker (diarization) within a transcript
+
+	Deepgram DeepgramConfig `toml:"deepgram"` // Deepgram settings, used when provider is "deepgram"
+}
+
+// DeepgramConfig contains settings for Deepgram live transcription
+type DeepgramConfig struct {
+	APIKey                string `toml:"api_key"`                  // Deepgram API key
+	Model                 string `toml:"model"`                    // Deepgram model (default: "nova-2")
+	EndpointingMs         int    `toml:"endpointing_ms"`           // Silence that ends an utterance (default: 500)
+	UtteranceEndMs        int    `toml:"utterance_end_ms"`         // Gap between words that ends an utterance (default: 1000)
+	RetryMaxAttempts      int    `toml:"retry_max_attempts"`       // Consecutive failed reconnects before giving up (default: 5)
+	RetryInitialBackoffMs int    `toml:"retry_initial_backoff_ms"` // Wait before the first reconnect (default: 2000)
+	RetryMaxBackoffMs     int    `toml:"retry_max_backoff_ms"`     // Maximum wait between reconnects (default: 60000)
+	ConnectsPerMinute     int    `toml:"connects_per_minute"`      // New streams per minute across all frequencies (0 = no limit)
+	Diarize               bool   `toml:"diarize"`                  // Label the speaker of each word, so split_turns can split at speaker changes
 }
 
 // PostProcessingConfig contains settings for post-processing of transcriptions
 type PostProcessingConfig struct {
-	Enabled               bool   `toml:"enabled"`                // Enable or disable post-processing
-	Model                 string `toml:"model"`                  // OpenAI model to use for post-processing
-	IntervalSeconds       int    `toml:"interval_seconds"`       // How often to run the post-processing (in seconds)
-	BatchSize             int    `toml:"batch_size"`             // Maximum number of transcriptions to process in each batch
-	ContextTranscriptions int    `toml:"context_transcriptions"` // Number of previous processed transcriptions to include for context
-	SystemPromptPath      string `toml:"system_prompt_path"`     // Path to the system prompt file
-	TimeoutSeconds        int    `toml:"timeout_seconds"`        // HTTP timeout for OpenAI API requests in seconds
+	Enabled               bool      `toml:"enabled"`                 // Enable or disable post-processing
+	Model                 string    `toml:"model"`                   // Model to use for post-processing
+	IntervalSeconds       int       `toml:"interval_seconds"`        // How often to run the post-processing (in seconds)
+	BatchSize             int       `toml:"batch_size"`              // Maximum number of transcriptions to process in each batch
+	ContextTranscriptions int       `toml:"context_transcriptions"`  // Number of previous processed transcriptions to include for context
+	SystemPromptPath      string    `toml:"system_prompt_path"`      // Path to the system prompt file
+	TimeoutSeconds        int       `toml:"timeout_seconds"`         // HTTP timeout for LLM requests in seconds
+	RepairAttempts        int       `toml:"repair_attempts"`         // Times invalid results are sent back to the model to fix (default: 1, -1 = none)
+	MaxConcurrentBatches  int       `toml:"max_concurrent_batches"`  // Batches sent to the LLM at once, each for a different frequency (default: 2)
+	BackoffInitialSeconds int       `toml:"backoff_initial_seconds"` // Pause after the LLM rate-limits or is overloaded, doubled on each repeat (default: 5)
+	BackoffMaxSeconds     int       `toml:"backoff_max_seconds"`     // Longest pause after repeated rate limits (default: 300)
+	LLM                   LLMConfig `toml:"llm"`                     // Provider the model is served by
+}
+
+// LLMConfig selects where a task's language model is served from, so tasks can run on
+// OpenAI, Anthropic or an on-prem server with an OpenAI-compatible API
+type LLMConfig struct {
+	Provider string `toml:"provider"` // "openai" (default), "openai-compatible" (llama.cpp, Ollama, vLLM) or "anthropic"
+	BaseURL  string `toml:"base_url"` // API base URL (default: the provider's API; for openai, the transcription openai_base_url)
+	APIKey   string `toml:"api_key"`  // API key (default for openai: the transcription openai_api_key; optional for openai-compatible)
+
+	// Replies are constrained to a JSON schema (a strict response_format, or a forced tool
+	// call on anthropic). Disable it for servers that reject response_format.
+	DisableStructuredOutput bool `toml:"disable_structured_output"`
 }
 
 // FrequenciesConfig contains settings for radio frequency monitoring
@@ -143,6 +294,345 @@ type FrequenciesConfig struct {
 	// FFmpeg timeout configuration
 	FFmpegTimeoutSecs        int `toml:"ffmpeg_timeout_secs"`         // FFmpeg connection timeout in seconds (0 = no timeout, default: 30)
 	FFmpegReconnectDelaySecs int `toml:"ffmpeg_reconnect_delay_secs"` // FFmpeg reconnect delay in seconds (default: 2)
+	FFmpegStallTimeoutSecs   int `toml:"ffmpeg_stall_timeout_secs"`   // Restart ffmpeg after this long without output (0 = default: 20, < 0 = never)
+
+	// AudioDecoder converts frequency audio with "ffmpeg" (default) or "builtin", which reads
+	// SDRs, pipes and WAV/L16 streams in Go and leaves MP3/AAC streams to ffmpeg
+	AudioDecoder string `toml:"audio_decoder"`
+
+	// AudioDelayMode is what a frequency's audio_delay_ms is applied to: "timestamps" (default)
+	// moves transcript times back to when they were spoken, "adsb" holds back WebSocket aircraft
+	// updates by the largest delay so the map matches what is heard
+	AudioDelayMode string `toml:"audio_delay_mode"`
+
+	// EstimateLatency adds each network stream's measured latency (time to its first audio
+	// plus the backlog its server sent on connect) to the frequency's audio_delay_ms
+	EstimateLatency bool `toml:"estimate_latency"`
+
+	// Buffer shared by a frequency's listeners and transcription
+	ReaderBufferKB    int    `toml:"reader_buffer_kb"`     // Buffer size in KB (default: 64)
+	ReaderMaxBufferKB int    `toml:"reader_max_buffer_kb"` // Largest the buffer grows to with reader_overrun = "grow" (default: 1024)
+	ReaderOverrun     string `toml:"reader_overrun"`       // What a reader that falls a whole buffer behind gets: "skip" to live (default) or "grow"
+
+	// Local SDR tools, for sdr:// frequency URLs
+	RTLFMPath   string `toml:"rtl_fm_path"`   // Path to rtl_fm (default: "rtl_fm")
+	SoapyFMPath string `toml:"soapy_fm_path"` // Path to SoapySDR's rx_fm from rx_tools (default: "rx_fm")
+
+	// Opus listener streams (/stream/{id}?format=webm|ogg)
+	OpusBitrate string `toml:"opus_bitrate"`  // Opus bitrate per listener (default: "24k")
+	OpusFrameMs int    `toml:"opus_frame_ms"` // Opus frame duration in ms: 5, 10, 20, 40 or 60 (default: 20)
+
+	// HLS listener streams (/stream/{id}/playlist.m3u8)
+	HLSDir              string `toml:"hls_dir"`               // Directory for live segments (default: <temp dir>/co-atc-hls)
+	HLSSegmentSecs      int    `toml:"hls_segment_secs"`      // Target segment duration in seconds (default: 2)
+	HLSPlaylistSegments int    `toml:"hls_playlist_segments"` // Segments in the live playlist (default: 6)
+	HLSBitrate          string `toml:"hls_bitrate"`           // AAC bitrate (default: "64k")
+	HLSIdleTimeoutSecs  int    `toml:"hls_idle_timeout_secs"` // Stop packaging a frequency after this long without requests (default: 60)
+
+	// Level squelch for detecting transmissions
+	SquelchThresholdDB float64 `toml:"squelch_threshold_db"` // RMS level in dBFS that marks a transmission (default: -45)
+	SquelchHangMs      int     `toml:"squelch_hang_ms"`      // Quiet time before a transmission is considered ended (default: 700)
+
+	// Scanner stream (/scanner/stream), playing whichever frequency has a transmission
+	ScannerFrequencies []string           `toml:"scanner_frequencies"` // Frequencies scanned, highest priority first (default: all, in display order)
+	ScannerHoldMs      int                `toml:"scanner_hold_ms"`     // How long the scanner stays on a frequency after its transmission ends (default: 2000)
+	ScannerGainDB      map[string]float64 `toml:"scanner_gain_db"`     // Gain in dB by frequency ID (default: 0)
+
+	// Stereo streams (/stereo/{pair}/stream) of two frequencies, one in each channel
+	StereoPairs []StereoPairConfig `toml:"stereo_pairs"`
+}
+
+// StereoPairConfig pairs two frequencies into one stereo stream
+type StereoPairConfig struct {
+	ID    string `toml:"id"`    // Unique identifier, used in the stream URL
+	Name  string `toml:"name"`  // Human-readable name (e.g., "Tower / Ground")
+	Left  string `toml:"left"`  // Frequency ID played in the left channel
+	Right string `toml:"right"` // Frequency ID played in the right channel
+}
+
+// RecordingConfig contains settings for recording frequencies to disk
+type RecordingConfig struct {
+	Enabled        bool   `toml:"enabled"`         // Continuously record every frequency
+	OutputDir      string `toml:"output_dir"`      // Directory for recorded segments (default: <sqlite_base_path>/recordings)
+	Format         string `toml:"format"`          // Segment format: "mp3" or "opus" (default: mp3)
+	Bitrate        string `toml:"bitrate"`         // Encoder bitrate (default: 32k)
+	SegmentMinutes int    `toml:"segment_minutes"` // Length of each segment file in minutes (default: 10)
+	RetentionHours int    `toml:"retention_hours"` // How long segments are kept in hours (0 = keep forever)
+}
+
+// PushConfig contains Web Push (VAPID) notification settings
+type PushConfig struct {
+	Enabled         bool   `toml:"enabled"`           // Enable browser push notifications
+	Subject         string `toml:"subject"`           // Contact for push services, "mailto:" or "https:" URL
+	VAPIDPublicKey  string `toml:"vapid_public_key"`  // Base64url VAPID public key (empty = generate and store in co-atc.db)
+	VAPIDPrivateKey string `toml:"vapid_private_key"` // Base64url VAPID private key (empty = generate and store in co-atc.db)
+	TTLSeconds      int    `toml:"ttl_seconds"`       // How long push services keep undelivered notifications (default: 3600)
+}
+
+// UsageConfig contains settings for accounting of tokens, audio minutes and estimated cost
+// of the transcription, post-processing and ATC chat APIs
+type UsageConfig struct {
+	FlushIntervalSeconds  int                   `toml:"flush_interval_seconds"`  // How often usage is added to the daily aggregates in co-atc.db (default: 60)
+	DailyBudgetUSD        float64               `toml:"daily_budget_usd"`        // Alert when the day's estimated cost (UTC) nears this (0 = no daily budget)
+	MonthlyBudgetUSD      float64               `toml:"monthly_budget_usd"`      // Alert when the month's estimated cost (UTC) nears this (0 = no monthly budget)
+	AlertThresholdPercent float64               `toml:"alert_threshold_percent"` // Percentage of a budget that raises the first alert; a second is raised at 100% (default: 80)
+	Prices                map[string]UsagePrice `toml:"prices"`                  // Prices by model, overriding or extending the built-in price list
+}
+
+// UsagePrice is the price of a model in US dollars
+type UsagePrice struct {
+	InputPerMillion  float64 `toml:"input_per_million"`  // Per million input tokens
+	OutputPerMillion float64 `toml:"output_per_million"` // Per million output tokens
+	AudioPerMinute   float64 `toml:"audio_per_minute"`   // Per minute of streamed audio
+}
+
+// DeviationsConfig contains settings for monitoring whether aircraft follow the altitude and
+// heading clearances extracted by post-processing
+type DeviationsConfig struct {
+	Enabled               bool `toml:"enabled"`                 // Watch altitude and heading clearances and raise possible deviations
+	ResponseWindowSeconds int  `toml:"response_window_seconds"` // How long an aircraft has to start climbing, descending or turning toward its clearance (default: 60)
+	MonitorMinutes        int  `toml:"monitor_minutes"`         // How long a clearance is watched after it is issued, unless a newer one replaces it (default: 10)
+	AltitudeToleranceFeet int  `toml:"altitude_tolerance_feet"` // Distance from the assigned altitude still counted as holding it (default: 300)
+	HeadingToleranceDeg   int  `toml:"heading_tolerance_deg"`   // Difference from the assigned heading still counted as flying it (default: 15)
+}
+
+// TerrainConfig contains settings for minimum safe altitude warnings (MSAW): descending
+// aircraft whose predicted path comes too close to terrain or obstacles, away from the
+// final approach paths of the station's runways
+type TerrainConfig struct {
+	Enabled              bool    `toml:"enabled"`                 // Load terrain and obstacles and raise proximity alerts
+	SRTMDir              string  `toml:"srtm_dir"`                // Directory of SRTM .hgt tiles named like N43W080.hgt (1 or 3 arc-second)
+	ObstaclesPath        string  `toml:"obstacles_path"`          // CSV of obstacles with latitude, longitude and amsl_ft columns (empty = terrain only)
+	RadiusNM             int     `toml:"radius_nm"`               // Area around the station loaded and monitored (default: 40)
+	MinClearanceFeet     int     `toml:"min_clearance_feet"`      // Predicted height above terrain or obstacles that raises an alert (default: 500)
+	ObstacleRadiusNM     float64 `toml:"obstacle_radius_nm"`      // Horizontal distance within which an obstacle counts (default: 0.5)
+	LookaheadSeconds     int     `toml:"lookahead_seconds"`       // How far ahead the path is predicted (default: 60)
+	MinDescentRateFPM    int     `toml:"min_descent_rate_fpm"`    // Descent rate from which aircraft are checked (default: 300)
+	FinalApproachNM      float64 `toml:"final_approach_nm"`       // Length of the final approach paths left out (default: 10)
+	FinalApproachWidthNM float64 `toml:"final_approach_width_nm"` // Distance either side of the extended centerline left out (default: 1)
+	RealertMinutes       int     `toml:"realert_minutes"`         // Time without a conflict before an aircraft can be alerted again (default: 2)
+}
+
+// ApproachesConfig contains the glidepaths of the station's runways, used for the approach
+// guidance of aircraft lined up with them, and settings for monitoring whether aircraft on
+// final approach meet stabilized approach criteria
+type ApproachesConfig struct {
+	Enabled               bool               `toml:"enabled"`                 // Evaluate aircraft on final approach and flag unstable approaches
+	GlidepathDeg          float64            `toml:"glidepath_deg"`           // Glidepath angle deviations are measured from (default: 3)
+	RunwayGlidepaths      map[string]float64 `toml:"runway_glidepaths"`       // Glidepath angles of runways that differ, by threshold ID
+	GateHeightFeet        int                `toml:"gate_height_feet"`        // Height above the threshold from which the approach must be stable (default: 1000)
+	MaxDistanceNM         float64            `toml:"max_distance_nm"`         // Distance from the threshold within which approaches are followed (default: 10)
+	MaxDescentRateFPM     int                `toml:"max_descent_rate_fpm"`    // Highest stable descent rate (default: 1000)
+	GlidepathToleranceDeg float64            `toml:"glidepath_tolerance_deg"` // Largest stable deviation above or below the glidepath (default: 0.7)
+	MaxSpeedChangeKt      int                `toml:"max_speed_change_kt"`     // Largest stable speed change over speed_window_seconds (default: 15)
+	SpeedWindowSeconds    int                `toml:"speed_window_seconds"`    // Period the speed trend is measured over (default: 30)
+	ContextMinutes        int                `toml:"context_minutes"`         // How long unstable approaches stay in the ATC chat context (default: 15)
+}
+
+// SequencingConfig contains settings for sequencing arrivals by their estimated time to the
+// runway threshold
+type SequencingConfig struct {
+	Enabled            bool    `toml:"enabled"`              // Sequence arrivals and estimate their times to touchdown
+	MaxDistanceNM      float64 `toml:"max_distance_nm"`      // Flying distance to the threshold within which arrivals are sequenced (default: 40)
+	FinalSpeedKt       int     `toml:"final_speed_kt"`       // Ground speed decelerating arrivals are assumed to slow to (default: 140)
+	SpeedWindowSeconds int     `toml:"speed_window_seconds"` // Period the ground speed trend is measured over (default: 60)
+}
+
+// IntentsConfig contains settings for inferring what aircraft have been cleared to do and are
+// doing from the clearances extracted from transcriptions and their tracks
+type IntentsConfig struct {
+	Enabled          bool `toml:"enabled"`           // Infer and publish an intent per aircraft
+	ClearanceMinutes int  `toml:"clearance_minutes"` // How long a clearance shapes an aircraft's intent, unless a newer one replaces it (default: 15)
+}
+
+// MovementsConfig contains settings for stitching the track segments of each aircraft into
+// flight sessions and logging the airport's arrivals and departures
+type MovementsConfig struct {
+	Enabled           bool `toml:"enabled"`             // Record flight sessions and movements
+	SessionGapMinutes int  `toml:"session_gap_minutes"` // How long an aircraft may go unseen before its session ends (default: 10)
+	RetentionDays     int  `toml:"retention_days"`      // How long sessions are kept (default: 90)
+}
+
+// OccupancyConfig contains settings for measuring how long landings and takeoffs occupy the
+// runway, from the threshold crossing or line-up to the aircraft leaving the runway outline
+type OccupancyConfig struct {
+	Enabled         bool                   `toml:"enabled"`           // Measure and record runway occupancy times
+	RunwayWidthFeet float64                `toml:"runway_width_feet"` // Width of the outline drawn between the thresholds of each runway (default: 200)
+	RetentionDays   int                    `toml:"retention_days"`    // How long occupancy times are kept (default: 90)
+	Polygons        map[string][][]float64 `toml:"polygons"`          // Surveyed [latitude, longitude] outlines by runway, e.g. "05-23", instead of the drawn ones
+}
+
+// BoardConfig contains settings for the arrivals and departures boards: the movement log,
+// optionally enriched with the airport's schedule from a provider
+type BoardConfig struct {
+	ScheduleProvider      string `toml:"schedule_provider"`       // "aviationstack", or "" for the movement log alone
+	APIKey                string `toml:"api_key"`                 // Access key of the schedule provider
+	APIBaseURL            string `toml:"api_base_url"`            // Schedule provider API (default: http://api.aviationstack.com/v1)
+	RefreshMinutes        int    `toml:"refresh_minutes"`         // How often the schedule is fetched (default: 60)
+	PastHours             int    `toml:"past_hours"`              // How far back the boards go (default: 3)
+	AheadHours            int    `toml:"ahead_hours"`             // How far ahead the boards go (default: 12)
+	DelayThresholdMinutes int    `toml:"delay_threshold_minutes"` // Delay from which a flight shows as delayed (default: 15)
+	AirportsDBPath        string `toml:"airports_db_path"`        // Airport database for city names (default: assets/airports.json)
+}
+
+// Board schedule providers
+const (
+	ScheduleProviderAviationstack = "aviationstack"
+)
+
+// NoiseConfig contains settings for monitoring noise-sensitive zones: aircraft overflying a
+// zone below its altitude during curfew hours are logged and alerted
+type NoiseConfig struct {
+	Enabled         bool              `toml:"enabled"`           // Watch the zones and record violations
+	MaxAltitudeFeet int               `toml:"max_altitude_feet"` // Altitude (MSL) zones must be overflown above (default: 3000)
+	CurfewStart     string            `toml:"curfew_start"`      // Start of the curfew, "HH:MM" local time (empty with curfew_end = zones restricted at all times)
+	CurfewEnd       string            `toml:"curfew_end"`        // End of the curfew, "HH:MM" local time; may be on the next day
+	Timezone        string            `toml:"timezone"`          // IANA time zone of the curfew hours (default: the server's)
+	RetentionDays   int               `toml:"retention_days"`    // How long violations are kept (default: 90)
+	Zones           []NoiseZoneConfig `toml:"zones"`             // Noise-sensitive zones, as [[noise.zones]] tables
+}
+
+// NoiseZoneConfig is a noise-sensitive zone: a circle around a point, or a polygon
+type NoiseZoneConfig struct {
+	Name            string      `toml:"name"`
+	Latitude        float64     `toml:"latitude"`          // Center of a circular zone
+	Longitude       float64     `toml:"longitude"`         // Center of a circular zone
+	RadiusNM        float64     `toml:"radius_nm"`         // Radius of a circular zone
+	Polygon         [][]float64 `toml:"polygon"`           // [latitude, longitude] vertices of a polygon zone, instead of a circle
+	MaxAltitudeFeet int         `toml:"max_altitude_feet"` // Overrides [noise] max_altitude_feet
+	CurfewStart     string      `toml:"curfew_start"`      // Overrides the [noise] curfew, with curfew_end
+	CurfewEnd       string      `toml:"curfew_end"`
+}
+
+// PrivacyConfig contains settings for identifying blocked aircraft, whose owners asked not to
+// be tracked publicly, and for keeping them and military aircraft off the WebSocket feed.
+// Aircraft with the LADD or PIA database flag are blocked besides those listed here.
+type PrivacyConfig struct {
+	BlockedHexCodes      []string `toml:"blocked_hex_codes"`     // ICAO addresses of blocked aircraft
+	BlockedRegistrations []string `toml:"blocked_registrations"` // Registrations of blocked aircraft, e.g. "N123AB"
+	BlockedListPath      string   `toml:"blocked_list_path"`     // File of blocked hex codes or registrations, one per line (e.g. an exported LADD list)
+	HideMilitary         bool     `toml:"hide_military"`         // Leave military aircraft out of /api/v1/ws (still sent on /api/v1/admin/ws)
+	HideBlocked          bool     `toml:"hide_blocked"`          // Leave blocked aircraft out of /api/v1/ws (still sent on /api/v1/admin/ws)
+}
+
+// FeederConfig contains settings for feeding locally received aircraft to community
+// aggregators, without running separate feeder containers
+type FeederConfig struct {
+	Enabled     bool                     `toml:"enabled"`     // Feed the enabled aggregators
+	UUID        string                   `toml:"uuid"`        // Feeder UUID, sent as the Beast receiver ID so aggregators can tell feeders apart (empty = none)
+	Aggregators []FeederAggregatorConfig `toml:"aggregators"` // Aggregators, as [[feeder.aggregators]] tables
+}
+
+// FeederAggregatorConfig is an aggregator to feed
+type FeederAggregatorConfig struct {
+	Name     string `toml:"name"`     // "adsb.fi", "adsbexchange" and "airplanes.live" have a default address
+	Enabled  bool   `toml:"enabled"`  // Feed this aggregator
+	Address  string `toml:"address"`  // host:port of the aggregator's feed input
+	Protocol string `toml:"protocol"` // "beast" (default; needs source_type = "raw") or "json" (aircraft of each poll cycle, one per line)
+}
+
+// DefaultAggregatorAddresses are the Beast feed inputs of known aggregators
+var DefaultAggregatorAddresses = map[string]string{
+	"adsb.fi":        "feed.adsb.fi:30004",
+	"adsbexchange":   "feed1.adsbexchange.com:30004",
+	"airplanes.live": "feed.airplanes.live:30004",
+}
+
+// BriefingConfig contains settings for spoken airspace briefings: a short ATIS-style summary
+// of weather, runways and traffic rendered from a template and read out by text-to-speech
+type BriefingConfig struct {
+	Enabled         bool   `toml:"enabled"`          // Serve briefings at /api/v1/briefing
+	TemplatePath    string `toml:"template_path"`    // Briefing template (default: assets/briefing_template.txt)
+	IntervalMinutes int    `toml:"interval_minutes"` // Broadcast a briefing to WebSocket clients this often (0 = on request only)
+	OpenAIAPIKey    string `toml:"openai_api_key"`   // OpenAI API key for text-to-speech (empty = text only)
+	TTSModel        string `toml:"tts_model"`        // Text-to-speech model (default: gpt-4o-mini-tts)
+	Voice           string `toml:"voice"`            // Text-to-speech voice (default: alloy)
+	Instructions    string `toml:"instructions"`     // How the voice should speak, for models that take instructions
+	AudioFormat     string `toml:"audio_format"`     // "mp3" (default), "opus", "aac" or "wav"
+}
+
+// ATISConfig contains settings for the airport's ATIS: polled from its digital ATIS (D-ATIS),
+// or synthesized from the METAR, runways in use and NOTAMs for airports without one
+type ATISConfig struct {
+	Enabled             bool   `toml:"enabled"`               // Follow the ATIS and broadcast new information letters
+	Source              string `toml:"source"`                // "datis" (default) or "synthesized"
+	APIBaseURL          string `toml:"api_base_url"`          // D-ATIS API, queried as {api_base_url}/{ICAO} (default: https://datis.clowd.io/api)
+	PollIntervalSeconds int    `toml:"poll_interval_seconds"` // How often the D-ATIS is polled or the synthesized ATIS regenerated (default: 120)
+	TemplatePath        string `toml:"template_path"`         // Synthesized ATIS template (default: assets/atis_template.txt)
+	NOTAMHighlights     int    `toml:"notam_highlights"`      // Most NOTAMs a synthesized ATIS reads out (default: 3, -1 = none)
+}
+
+// ATIS sources
+const (
+	ATISSourceDATIS       = "datis"
+	ATISSourceSynthesized = "synthesized"
+)
+
+// Notification channel types
+const (
+	NotifyChannelWebhook  = "webhook"
+	NotifyChannelDiscord  = "discord"
+	NotifyChannelSlack    = "slack"
+	NotifyChannelTelegram = "telegram"
+	NotifyChannelMQTT     = "mqtt"
+)
+
+// NotifyConfig contains settings for delivering alerts outside the web UI
+type NotifyConfig struct {
+	Enabled  bool                  `toml:"enabled"`  // Deliver alerts to the configured channels
+	Channels []NotifyChannelConfig `toml:"channels"` // Destinations, as [[notify.channels]] tables
+}
+
+// NotifyChannelConfig contains the settings for one alert destination
+type NotifyChannelConfig struct {
+	Name           string            `toml:"name"`            // Unique name, used in logs and the API
+	Type           string            `toml:"type"`            // "webhook", "discord", "slack", "telegram" or "mqtt"
+	URL            string            `toml:"url"`             // Webhook URL, or the MQTT broker (tcp://host:1883, mqtts://host:8883 for TLS)
+	Events         []string          `toml:"events"`          // Alert types delivered (empty = all)
+	Template       string            `toml:"template"`        // Go text/template for the payload (webhook, mqtt) or message (discord, slack, telegram)
+	Headers        map[string]string `toml:"headers"`         // Extra HTTP headers (webhook)
+	BotToken       string            `toml:"bot_token"`       // Bot API token (telegram)
+	ChatID         string            `toml:"chat_id"`         // Chat to post to (telegram)
+	Topic          string            `toml:"topic"`           // Topic template (mqtt, default: co-atc/alerts/{{.Type}})
+	Username       string            `toml:"username"`        // Broker username (mqtt)
+	Password       string            `toml:"password"`        // Broker password (mqtt)
+	ClientID       string            `toml:"client_id"`       // Client ID (mqtt, default: co-atc-{name})
+	Retain         bool              `toml:"retain"`          // Publish retained messages (mqtt)
+	TimeoutSeconds int               `toml:"timeout_seconds"` // Delivery timeout (default: 10)
+}
+
+// MQTTConfig contains settings for publishing to an MQTT broker, e.g. for Home Assistant
+type MQTTConfig struct {
+	Enabled                 bool   `toml:"enabled"`                   // Publish to the broker
+	BrokerURL               string `toml:"broker_url"`                // tcp://host:1883 or mqtt://host:1883, mqtts://host:8883 for TLS
+	ClientID                string `toml:"client_id"`                 // Client ID (default: co-atc)
+	Username                string `toml:"username"`                  // Broker username (empty = anonymous)
+	Password                string `toml:"password"`                  // Broker password
+	TopicPrefix             string `toml:"topic_prefix"`              // Prefix of every topic (default: co-atc)
+	KeepAliveSeconds        int    `toml:"keepalive_seconds"`         // Keep-alive interval (default: 60)
+	PublishAircraft         bool   `toml:"publish_aircraft"`          // Publish the aircraft summary to {prefix}/aircraft
+	AircraftIntervalSeconds int    `toml:"aircraft_interval_seconds"` // Shortest time between aircraft summaries (default: 10)
+	PerAircraftTopics       bool   `toml:"per_aircraft_topics"`       // Also publish each aircraft to {prefix}/aircraft/{hex}
+	PublishTranscriptions   bool   `toml:"publish_transcriptions"`    // Publish completed transcriptions to {prefix}/transcriptions
+	PublishEvents           bool   `toml:"publish_events"`            // Publish phase changes, runway status, clearances, ... to {prefix}/events/{type}
+	PublishAlerts           bool   `toml:"publish_alerts"`            // Publish alerts to {prefix}/alerts
+	HomeAssistantDiscovery  bool   `toml:"home_assistant_discovery"`  // Announce sensors through Home Assistant MQTT discovery
+	DiscoveryPrefix         string `toml:"discovery_prefix"`          // Home Assistant discovery prefix (default: homeassistant)
+}
+
+// TrafficGeneratorConfig contains settings for generated background traffic: simulated
+// arrivals and departures on the runways in use, for demos and load tests
+type TrafficGeneratorConfig struct {
+	Enabled             bool     `toml:"enabled"`               // Generate traffic from startup (it can also be started from the API)
+	ArrivalsPerHour     float64  `toml:"arrivals_per_hour"`     // Average arrival rate (default: 12)
+	DeparturesPerHour   float64  `toml:"departures_per_hour"`   // Average departure rate (default: 12)
+	MaxAircraft         int      `toml:"max_aircraft"`          // Most generated aircraft at once (default: 20)
+	ArrivalRunways      []string `toml:"arrival_runways"`       // Thresholds for arrivals when no runway configuration is forced (empty = the first threshold of each runway)
+	DepartureRunways    []string `toml:"departure_runways"`     // Thresholds for departures when no runway configuration is forced (empty = as arrivals)
+	SpawnDistanceNM     float64  `toml:"spawn_distance_nm"`     // Distance from the runway arrivals appear at and departures leave at (default: 30)
+	ArrivalAltitudeFt   int      `toml:"arrival_altitude_ft"`   // Height above the airport arrivals appear at (default: 6000)
+	DepartureAltitudeFt int      `toml:"departure_altitude_ft"` // Height above the airport departures climb to (default: 8000)
+	RadioFrequencyID    string   `toml:"radio_frequency_id"`    // Frequency generated traffic and its controller talk on (empty = silent)
 }
 
 // FrequencyConfig contains configuration for a single monitored radio frequency
@@ -151,9 +641,26 @@ type FrequencyConfig struct {
 	Airport         string  `toml:"airport"`          // ICAO code of the airport (e.g., "CYYZ" for Toronto Pearson)
 	Name            string  `toml:"name"`             // Human-readable name (e.g., "CYYZ Tower")
 	FrequencyMHz    float64 `toml:"frequency_mhz"`    // Actual radio frequency in MHz (e.g., 118.7)
-	URL             string  `toml:"url"`              // URL to the audio stream
+	URL             string  `toml:"url"`              // URL to the audio stream, or an sdr:// or pipe:// local source
 	Order           int     `toml:"order"`            // Display order in the UI (lower numbers first)
 	TranscribeAudio bool    `toml:"transcribe_audio"` // Whether to transcribe audio for this frequency
+	AudioDelayMs    int     `toml:"audio_delay_ms"`   // How far the audio lags the air, e.g. LiveATC feeds (0 = live)
+
+	PostProcessing FrequencyPostProcessingConfig `toml:"post_processing"` // Post-processing overrides for this frequency
+}
+
+// FrequencyPostProcessingConfig overrides post-processing for one frequency, since tower,
+// ground and approach controllers issue different instructions in different phraseology.
+// Unset fields use the [post_processing] settings.
+type FrequencyPostProcessingConfig struct {
+	SystemPromptPath string   `toml:"system_prompt_path"` // Prompt template for this frequency
+	Model            string   `toml:"model"`              // Model for this frequency, on the [post_processing.llm] provider
+	ClearanceTypes   []string `toml:"clearance_types"`    // Clearance types stored: "takeoff", "landing", "approach", "taxi", "altitude", "heading" (unset = all, [] = none)
+}
+
+// IsSet reports whether any post-processing setting is overridden
+func (p FrequencyPostProcessingConfig) IsSet() bool {
+	return p.SystemPromptPath != "" || p.Model != "" || p.ClearanceTypes != nil
 }
 
 // FlightPhasesConfig contains settings for flight phase detection
@@ -238,6 +745,12 @@ func Load(path string) (*Config, error) {
 
 // LoadWithFallback loads the configuration by checking multiple locations in order of preference
 func LoadWithFallback(preferredPath string) (*Config, error) {
+	config, _, err := LoadWithFallbackPath(preferredPath)
+	return config, err
+}
+
+// LoadWithFallbackPath is like LoadWithFallback but also returns the path the configuration was loaded from
+func LoadWithFallbackPath(preferredPath string) (*Config, string, error) {
 	// List of paths to check in order of preference
 	searchPaths := []string{
 		preferredPath,         // User-specified path (if provided)
@@ -264,27 +777,64 @@ func LoadWithFallback(preferredPath string) (*Config, error) {
 				lastErr = fmt.Errorf("failed to load config from %s: %w", path, err)
 				continue
 			}
-			return config, nil
+			return config, path, nil
 		}
 		lastErr = fmt.Errorf("config file not found: %s", path)
 	}
 
-	return nil, fmt.Errorf("config file not found in any of the expected locations: %v. Last error: %w", uniquePaths, lastErr)
+	return nil, "", fmt.Errorf("config file not found in any of the expected locations: %v. Last error: %w", uniquePaths, lastErr)
 }
 
-// Validate validates the configuration
+// Validate sets defaults and validates the configuration. Every section is checked, and the
+// problems found are returned together in a ValidationError: the first of each section, then
+// every problem between sections.
 func (c *Config) Validate() error {
-	// Validate frequencies config
-	if err := c.ValidateFrequencies(); err != nil {
-		return err
-	}
-
-	// Validate post-processing config
-	if c.PostProcessing.Enabled && c.PostProcessing.ContextTranscriptions < 0 {
-		return fmt.Errorf("invalid context_transcriptions value: %d (must be >= 0)", c.PostProcessing.ContextTranscriptions)
-	}
+	problems := &ValidationError{}
+	for _, validate := range []func() error{
+		c.ValidateFrequencies,
+		c.ValidatePostProcessing,
+		c.ValidateServer,
+		c.ValidateADSB,
+		c.ValidateLogging,
+		c.ValidateStorage,
+		c.ValidateRetention,
+		c.ValidateStation,
+		c.ValidateFlightPhases,
+		c.ValidateRecording,
+		c.ValidatePush,
+		c.ValidateTranscription,
+		c.ValidateUsage,
+		c.ValidateDeviations,
+		c.ValidateTerrain,
+		c.ValidateApproaches,
+		c.ValidateSequencing,
+		c.ValidateIntents,
+		c.ValidateNoise,
+		c.ValidateMovements,
+		c.ValidateOccupancy,
+		c.ValidateBoard,
+		c.ValidatePrivacy,
+		c.ValidateFeeder,
+		c.ValidateBriefing,
+		c.ValidateTemplating,
+		c.ValidateATIS,
+		c.ValidateNotify,
+		c.ValidateMQTT,
+		c.ValidateTrafficGenerator,
+		c.ValidateATCChat,
+		c.ValidateATCChatPersonas,
+		c.ValidateWeather,
+		c.ValidateOpenAIKeys,
+		// Settings that depend on other sections, once they have their defaults
+		c.ValidateDependencies,
+	} {
+		problems.add(validate())
+	}
+	return problems.err()
+}
 
-	// Validate server config
+// ValidateServer validates the server configuration
+func (c *Config) ValidateServer() error {
 	if c.Server.Port <= 0 || c.Server.Port > 65535 {
 		return fmt.Errorf("invalid server port: %d", c.Server.Port)
 	}
@@ -301,6 +851,25 @@ func (c *Config) Validate() error {
 		portsSeen[p] = true
 	}
 
+	// Validate the public view
+	if c.Server.Public.Enabled {
+		if c.Server.Public.Port <= 0 || c.Server.Public.Port > 65535 {
+			return fmt.Errorf("invalid public server port: %d", c.Server.Public.Port)
+		}
+		if portsSeen[c.Server.Public.Port] {
+			return fmt.Errorf("public server port %d is already used by the console", c.Server.Public.Port)
+		}
+		if c.Server.Public.Host == "" {
+			c.Server.Public.Host = c.Server.Host
+		}
+		if c.Server.Public.TranscriptionDelaySeconds == 0 {
+			c.Server.Public.TranscriptionDelaySeconds = 300
+		}
+		if c.Server.Public.TranscriptionDelaySeconds < 0 {
+			return fmt.Errorf("public transcription_delay_seconds must be 0 or greater: %d", c.Server.Public.TranscriptionDelaySeconds)
+		}
+	}
+
 	// Set default static files directory if not specified
 	if c.Server.StaticFilesDir == "" {
 		c.Server.StaticFilesDir = "www"
@@ -311,13 +880,17 @@ func (c *Config) Validate() error {
 		return fmt.Errorf("static files directory does not exist: %s", c.Server.StaticFilesDir)
 	}
 
-	// Validate ADSB config
+	return nil
+}
+
+// ValidateADSB validates the ADS-B source configuration
+func (c *Config) ValidateADSB() error {
 	if c.ADSB.SourceType == "" {
 		c.ADSB.SourceType = "local" // Default to local if not specified
 	}
 
-	if c.ADSB.SourceType != "local" && c.ADSB.SourceType != "external" {
-		return fmt.Errorf("invalid ADSB source type: %s (must be 'local' or 'external')", c.ADSB.SourceType)
+	if c.ADSB.SourceType != "local" && c.ADSB.SourceType != "external" && c.ADSB.SourceType != "raw" && c.ADSB.SourceType != "none" {
+		return fmt.Errorf("invalid ADSB source type: %s (must be 'local', 'external', 'raw' or 'none')", c.ADSB.SourceType)
 	}
 
 	// Handle legacy configuration
@@ -334,26 +907,53 @@ func (c *Config) Validate() error {
 		if c.ADSB.ExternalSourceURL == "" {
 			return fmt.Errorf("external_source_url is required when source_type is external")
 		}
-		if c.ADSB.APIHost == "" {
-			return fmt.Errorf("api_host is required when source_type is external")
-		}
-		if c.ADSB.APIKey == "" {
-			return fmt.Errorf("api_key is required when source_type is external")
-		}
 		if c.ADSB.SearchRadiusNM <= 0 {
 			return fmt.Errorf("search_radius_nm must be positive when source_type is external")
 		}
+		if c.ADSB.ExternalPollIntervalSecs < 0 || c.ADSB.ExternalIdleIntervalSecs < 0 || c.ADSB.ExternalMaxBackoffSecs < 0 {
+			return fmt.Errorf("external polling intervals must not be negative")
+		}
+		if c.ADSB.ExternalDailyRequestBudget < 0 {
+			return fmt.Errorf("external_daily_request_budget must not be negative")
+		}
+	}
+
+	if c.ADSB.SourceType == "raw" {
+		if c.ADSB.RawSourceAddress == "" {
+			return fmt.Errorf("raw_source_address is required when source_type is raw")
+		}
+		if c.ADSB.RawFormat == "" {
+			c.ADSB.RawFormat = "beast"
+		}
+		if c.ADSB.RawFormat != "beast" && c.ADSB.RawFormat != "avr" {
+			return fmt.Errorf("invalid raw_format: %s (must be 'beast' or 'avr')", c.ADSB.RawFormat)
+		}
+	}
+
+	if c.ADSB.UATSourceURL != "" && !strings.HasPrefix(c.ADSB.UATSourceURL, "http://") && !strings.HasPrefix(c.ADSB.UATSourceURL, "https://") {
+		return fmt.Errorf("uat_source_url must be an http or https URL: %s", c.ADSB.UATSourceURL)
 	}
 
 	if c.ADSB.FetchIntervalSecs <= 0 {
 		return fmt.Errorf("invalid fetch interval: %d", c.ADSB.FetchIntervalSecs)
 	}
-	// Set default value for MaxPositionsInAPI if not specified
-	if c.Storage.MaxPositionsInAPI <= 0 {
-		c.Storage.MaxPositionsInAPI = 60 // Default to 60 positions if not specified
+	if c.ADSB.BudgetMode {
+		if c.ADSB.BudgetMaxPredictions == 0 {
+			c.ADSB.BudgetMaxPredictions = 25
+		}
+		if c.ADSB.BudgetPositionSampleEvery == 0 {
+			c.ADSB.BudgetPositionSampleEvery = 3
+		}
+		if c.ADSB.BudgetMaxPredictions < 0 || c.ADSB.BudgetPositionSampleEvery < 0 {
+			return fmt.Errorf("budget_max_predictions and budget_position_sample_every must be positive")
+		}
 	}
 
-	// Validate logging config
+	return nil
+}
+
+// ValidateLogging validates the logging configuration
+func (c *Config) ValidateLogging() error {
 	switch c.Logging.Level {
 	case "debug", "info", "warn", "error":
 		// Valid log level
@@ -368,6 +968,50 @@ func (c *Config) Validate() error {
 		return fmt.Errorf("invalid log format: %s", c.Logging.Format)
 	}
 
+	for name, level := range c.Logging.Levels {
+		switch level {
+		case "debug", "info", "warn", "error":
+			// Valid log level
+		default:
+			return fmt.Errorf("invalid log level for logger %s: %s", name, level)
+		}
+	}
+
+	switch {
+	case c.Logging.BufferSize == 0:
+		c.Logging.BufferSize = 5000
+	case c.Logging.BufferSize < -1 || c.Logging.BufferSize > 100000:
+		return fmt.Errorf("logging buffer_size must be between 1 and 100000, or -1 to disable")
+	}
+
+	if file := &c.Logging.File; file.Path != "" {
+		if file.Format == "" {
+			file.Format = "json"
+		}
+		if file.Format != "json" && file.Format != "console" {
+			return fmt.Errorf("invalid log file format: %s", file.Format)
+		}
+		if file.MaxSizeMB <= 0 {
+			file.MaxSizeMB = 100
+		}
+		if file.MaxBackups < 0 || file.MaxAgeDays < 0 {
+			return fmt.Errorf("log file max_backups and max_age_days can't be negative")
+		}
+		if file.MaxBackups == 0 && file.MaxAgeDays == 0 {
+			file.MaxBackups = 5
+		}
+	}
+
+	return nil
+}
+
+// ValidateStorage validates the storage configuration
+func (c *Config) ValidateStorage() error {
+	// Set default value for MaxPositionsInAPI if not specified
+	if c.Storage.MaxPositionsInAPI <= 0 {
+		c.Storage.MaxPositionsInAPI = 60 // Default to 60 positions if not specified
+	}
+
 	// Validate storage config
 	if c.Storage.Type != "sqlite" {
 		return fmt.Errorf("invalid storage type: %s (only 'sqlite' is supported)", c.Storage.Type)
@@ -377,24 +1021,53 @@ func (c *Config) Validate() error {
 		return fmt.Errorf("sqlite_base_path is required when storage type is sqlite")
 	}
 
-	// Validate Station config
-	if err := c.ValidateStation(); err != nil {
-		return err
+	if c.Storage.BackupDir == "" {
+		c.Storage.BackupDir = filepath.Join(c.Storage.SQLiteBasePath, "backups")
+	}
+
+	// Default write tuning
+	if c.Storage.WriteQueueSize == 0 {
+		c.Storage.WriteQueueSize = 2048
+	}
+	if c.Storage.WriteBatchSize <= 0 {
+		c.Storage.WriteBatchSize = 500
+	}
+	if c.Storage.WALAutocheckpointPages <= 0 {
+		c.Storage.WALAutocheckpointPages = 4000
+	}
+	if c.Storage.CheckpointIntervalSecs == 0 {
+		c.Storage.CheckpointIntervalSecs = 300
+	}
+
+	return nil
+}
+
+// ValidateATCChat validates the ATC chat configuration
+func (c *Config) ValidateATCChat() error {
+	// Default ATC chat session memory to a week
+	if c.ATCChat.SessionMemoryMaxAgeHours <= 0 {
+		c.ATCChat.SessionMemoryMaxAgeHours = 168
 	}
 
-	// Validate Flight Phases config
-	if err := c.ValidateFlightPhases(); err != nil {
-		return err
+	// Pushing context more often than this only adds load, OpenAI reads it once per response
+	if c.ATCChat.RefreshSystemPromptSecs > 0 && c.ATCChat.RefreshSystemPromptSecs < minChatRefreshSecs {
+		c.ATCChat.RefreshSystemPromptSecs = minChatRefreshSecs
 	}
 
-	// Validate Weather config
-	if err := c.ValidateWeather(); err != nil {
-		return err
+	// Default ATC chat idle timeout to 15 minutes
+	if c.ATCChat.IdleTimeoutMinutes <= 0 {
+		c.ATCChat.IdleTimeoutMinutes = 15
+	}
+	if c.ATCChat.MaxSessions < 0 {
+		return fmt.Errorf("atc_chat max_sessions must not be negative: %d", c.ATCChat.MaxSessions)
 	}
 
-	// Validate OpenAI API keys for enabled features
-	if err := c.ValidateOpenAIKeys(); err != nil {
-		return err
+	// Default ATC chat transcripts to a month
+	if c.ATCChat.HistoryRetentionDays <= 0 {
+		c.ATCChat.HistoryRetentionDays = 30
+	}
+	if c.ATCChat.RecordingsDir == "" {
+		c.ATCChat.RecordingsDir = filepath.Join(c.Storage.SQLiteBasePath, "atc-chat")
 	}
 
 	return nil
@@ -457,48 +1130,161 @@ func (c *Config) ValidateFrequencies() error {
 	if c.Frequencies.FFmpegReconnectDelaySecs == 0 {
 		c.Frequencies.FFmpegReconnectDelaySecs = 2 // Default to 2 seconds
 	}
+	if c.Frequencies.ReaderBufferKB < 0 || c.Frequencies.ReaderMaxBufferKB < 0 {
+		return fmt.Errorf("invalid reader_buffer_kb or reader_max_buffer_kb (must be >= 0)")
+	}
+	switch c.Frequencies.ReaderOverrun {
+	case "":
+		c.Frequencies.ReaderOverrun = "skip"
+	case "skip", "grow":
+	default:
+		return fmt.Errorf("invalid reader_overrun: %q (must be skip or grow)", c.Frequencies.ReaderOverrun)
+	}
+	switch c.Frequencies.AudioDecoder {
+	case "":
+		c.Frequencies.AudioDecoder = "ffmpeg"
+	case "ffmpeg", "builtin":
+	default:
+		return fmt.Errorf("invalid audio_decoder: %q (must be ffmpeg or builtin)", c.Frequencies.AudioDecoder)
+	}
+	switch c.Frequencies.AudioDelayMode {
+	case "":
+		c.Frequencies.AudioDelayMode = "timestamps"
+	case "timestamps", "adsb":
+	default:
+		return fmt.Errorf("invalid audio_delay_mode: %q (must be timestamps or adsb)", c.Frequencies.AudioDelayMode)
+	}
+	if c.Frequencies.RTLFMPath == "" {
+		c.Frequencies.RTLFMPath = "rtl_fm"
+	}
+	if c.Frequencies.SoapyFMPath == "" {
+		c.Frequencies.SoapyFMPath = "rx_fm"
+	}
+	if c.Frequencies.OpusBitrate == "" {
+		c.Frequencies.OpusBitrate = "24k"
+	}
+	if c.Frequencies.OpusFrameMs == 0 {
+		c.Frequencies.OpusFrameMs = 20
+	}
+	switch c.Frequencies.OpusFrameMs {
+	case 5, 10, 20, 40, 60:
+	default:
+		return fmt.Errorf("invalid opus_frame_ms: %d (must be 5, 10, 20, 40 or 60)", c.Frequencies.OpusFrameMs)
+	}
+	if c.Frequencies.HLSDir == "" {
+		c.Frequencies.HLSDir = filepath.Join(os.TempDir(), "co-atc-hls")
+	}
+	if c.Frequencies.HLSSegmentSecs <= 0 {
+		c.Frequencies.HLSSegmentSecs = 2
+	}
+	if c.Frequencies.HLSPlaylistSegments <= 0 {
+		c.Frequencies.HLSPlaylistSegments = 6
+	}
+	if c.Frequencies.HLSBitrate == "" {
+		c.Frequencies.HLSBitrate = "64k"
+	}
+	if c.Frequencies.HLSIdleTimeoutSecs <= 0 {
+		c.Frequencies.HLSIdleTimeoutSecs = 60
+	}
+	if c.Frequencies.SquelchThresholdDB == 0 {
+		c.Frequencies.SquelchThresholdDB = -45
+	}
+	if c.Frequencies.SquelchThresholdDB > 0 || c.Frequencies.SquelchThresholdDB < -96 {
+		return fmt.Errorf("invalid squelch_threshold_db: %g (must be between -96 and 0)", c.Frequencies.SquelchThresholdDB)
+	}
+	if c.Frequencies.SquelchHangMs <= 0 {
+		c.Frequencies.SquelchHangMs = 700
+	}
+	if c.Frequencies.ScannerHoldMs <= 0 {
+		c.Frequencies.ScannerHoldMs = 2000
+	}
+	for id, gain := range c.Frequencies.ScannerGainDB {
+		if gain < -40 || gain > 40 {
+			return fmt.Errorf("invalid scanner_gain_db for %s: %g (must be between -40 and 40)", id, gain)
+		}
+	}
+	pairIDs := make(map[string]bool)
+	for i, pair := range c.Frequencies.StereoPairs {
+		if pair.ID == "" || pair.Left == "" || pair.Right == "" {
+			return fmt.Errorf("stereo pair #%d: id, left and right are required", i+1)
+		}
+		if pair.Left == pair.Right {
+			return fmt.Errorf("stereo pair %s: left and right must be different frequencies", pair.ID)
+		}
+		if pairIDs[pair.ID] {
+			return fmt.Errorf("stereo pair #%d: duplicate ID: %s", i+1, pair.ID)
+		}
+		pairIDs[pair.ID] = true
+	}
 
 	// Validate frequency sources
 	idMap := make(map[string]bool)
 	orderMap := make(map[int]string) // Track orders to check for duplicates
 	for i, freq := range c.Frequencies.Sources {
-		// Validate ID
-		if freq.ID == "" {
-			return fmt.Errorf("frequency #%d: ID is required", i+1)
+		if err := freq.Validate(); err != nil {
+			return fmt.Errorf("frequency #%d: %w", i+1, err)
 		}
+
+		// Validate ID uniqueness
 		if idMap[freq.ID] {
 			return fmt.Errorf("frequency #%d: duplicate ID: %s", i+1, freq.ID)
 		}
 		idMap[freq.ID] = true
 
-		// Validate airport
-		if freq.Airport == "" {
-			return fmt.Errorf("frequency #%d: airport is required", i+1)
+		// Validate order uniqueness
+		if existingID, exists := orderMap[freq.Order]; exists {
+			return fmt.Errorf("frequency #%d: duplicate order value %d (already used by %s)", i+1, freq.Order, existingID)
 		}
+		orderMap[freq.Order] = freq.ID
+	}
 
-		// Validate name
-		if freq.Name == "" {
-			return fmt.Errorf("frequency #%d: name is required", i+1)
-		}
+	return nil
+}
 
-		// Validate frequency
-		if freq.FrequencyMHz <= 0 {
-			return fmt.Errorf("frequency #%d: invalid frequency: %f", i+1, freq.FrequencyMHz)
-		}
+// Validate validates a single frequency configuration
+func (f FrequencyConfig) Validate() error {
+	// Validate ID
+	if f.ID == "" {
+		return fmt.Errorf("ID is required")
+	}
 
-		// Validate URL
-		if freq.URL == "" {
-			return fmt.Errorf("frequency #%d: URL is required", i+1)
-		}
+	// Validate airport
+	if f.Airport == "" {
+		return fmt.Errorf("airport is required")
+	}
 
-		// Validate order
-		if freq.Order <= 0 {
-			return fmt.Errorf("frequency #%d: order must be a positive integer", i+1)
-		}
-		if existingID, exists := orderMap[freq.Order]; exists {
-			return fmt.Errorf("frequency #%d: duplicate order value %d (already used by %s)", i+1, freq.Order, existingID)
+	// Validate name
+	if f.Name == "" {
+		return fmt.Errorf("name is required")
+	}
+
+	// Validate frequency
+	if f.FrequencyMHz <= 0 {
+		return fmt.Errorf("invalid frequency: %f", f.FrequencyMHz)
+	}
+
+	// Validate URL
+	if f.URL == "" {
+		return fmt.Errorf("URL is required")
+	}
+
+	// Validate order
+	if f.Order <= 0 {
+		return fmt.Errorf("order must be a positive integer")
+	}
+
+	// Validate audio delay
+	if f.AudioDelayMs < 0 || f.AudioDelayMs > 300000 {
+		return fmt.Errorf("invalid audio_delay_ms: %d (must be between 0 and 300000)", f.AudioDelayMs)
+	}
+
+	// Validate post-processing overrides
+	for _, clearanceType := range f.PostProcessing.ClearanceTypes {
+		switch clearanceType {
+		case "takeoff", "landing", "approach", "taxi", "altitude", "heading":
+		default:
+			return fmt.Errorf("invalid post_processing clearance type: %s (must be takeoff, landing, approach, taxi, altitude or heading)", clearanceType)
 		}
-		orderMap[freq.Order] = freq.ID
 	}
 
 	return nil
@@ -597,80 +1383,996 @@ func (c *Config) ValidateFlightPhases() error {
 	return nil
 }
 
-// ValidateOpenAIKeys validates OpenAI API keys for enabled features
-func (c *Config) ValidateOpenAIKeys() error {
-	// Check transcription API key - transcription is always available if configured
-	if c.Transcription.OpenAIAPIKey == "" {
-		fmt.Printf("WARN: No OpenAI API key provided for transcription - transcription features will be disabled\n")
+// ValidateRecording validates the recording configuration
+func (c *Config) ValidateRecording() error {
+	if !c.Recording.Enabled {
+		return nil // Skip validation if recording is disabled
 	}
 
-	// Check ATC chat API key if ATC chat is enabled
-	if c.ATCChat.Enabled && c.ATCChat.OpenAIAPIKey == "" {
-		fmt.Printf("WARN: ATC Chat is enabled but no OpenAI API key provided - ATC chat features will be disabled\n")
+	// Set default values if not specified
+	if c.Recording.OutputDir == "" {
+		c.Recording.OutputDir = filepath.Join(c.Storage.SQLiteBasePath, "recordings")
+	}
+	if c.Recording.Format == "" {
+		c.Recording.Format = "mp3"
+	}
+	if c.Recording.Bitrate == "" {
+		c.Recording.Bitrate = "32k"
+	}
+	if c.Recording.SegmentMinutes == 0 {
+		c.Recording.SegmentMinutes = 10
 	}
 
-	// Check post-processing API key if post-processing is enabled
-	if c.PostProcessing.Enabled {
-		// Post-processing uses the same API key as transcription
-		if c.Transcription.OpenAIAPIKey == "" {
-			fmt.Printf("WARN: Post-processing is enabled but no OpenAI API key provided in transcription config - post-processing features will be disabled\n")
-		}
+	if c.Recording.Format != "mp3" && c.Recording.Format != "opus" {
+		return fmt.Errorf("invalid recording format: %s (must be 'mp3' or 'opus')", c.Recording.Format)
+	}
+	if c.Recording.SegmentMinutes < 0 {
+		return fmt.Errorf("recording segment_minutes must be positive: %d", c.Recording.SegmentMinutes)
+	}
+	if c.Recording.RetentionHours < 0 {
+		return fmt.Errorf("recording retention_hours must be 0 or greater: %d", c.Recording.RetentionHours)
 	}
 
 	return nil
 }
 
-// ValidateWeather validates the weather configuration
-func (c *Config) ValidateWeather() error {
-	// Validate refresh interval
-	if c.Weather.RefreshIntervalMinutes <= 0 {
-		return fmt.Errorf("weather refresh_interval_minutes must be greater than 0: %d", c.Weather.RefreshIntervalMinutes)
-	}
+// ValidateRetention validates the data retention configuration
+func (c *Config) ValidateRetention() error {
+	r := &c.Storage.Retention
 
-	// Validate request timeout
-	if c.Weather.RequestTimeoutSeconds <= 0 {
-		return fmt.Errorf("weather request_timeout_seconds must be greater than 0: %d", c.Weather.RequestTimeoutSeconds)
+	if r.IntervalMinutes == 0 {
+		r.IntervalMinutes = 60
 	}
-
-	// Validate max retries
-	if c.Weather.MaxRetries < 0 {
-		return fmt.Errorf("weather max_retries must be 0 or greater: %d", c.Weather.MaxRetries)
+	if r.Export && r.ExportDir == "" {
+		r.ExportDir = filepath.Join(c.Storage.SQLiteBasePath, "archive")
 	}
 
-	// Validate cache expiry
-	if c.Weather.CacheExpiryMinutes <= 0 {
-		return fmt.Errorf("weather cache_expiry_minutes must be greater than 0: %d", c.Weather.CacheExpiryMinutes)
+	if r.IntervalMinutes < 0 {
+		return fmt.Errorf("retention interval_minutes must be positive: %d", r.IntervalMinutes)
 	}
-
-	// Validate API base URL
-	if c.Weather.APIBaseURL == "" {
-		return fmt.Errorf("weather api_base_url cannot be empty")
+	if r.TranscriptionDays < 0 || r.ClearanceDays < 0 || r.TrackDays < 0 || r.EventDays < 0 {
+		return fmt.Errorf("retention windows must be 0 or greater")
 	}
 
-	// At least one weather type must be enabled
-	if !c.Weather.FetchMETAR && !c.Weather.FetchTAF && !c.Weather.FetchNOTAMs {
-		return fmt.Errorf("at least one weather type must be enabled (fetch_metar, fetch_taf, or fetch_notams)")
+	return nil
+}
+
+// ValidatePush validates the Web Push configuration
+func (c *Config) ValidatePush() error {
+	if !c.Push.Enabled {
+		return nil // Skip validation if push is disabled
 	}
 
-	// Validate that airport code is set if weather fetching is enabled
-	if (c.Weather.FetchMETAR || c.Weather.FetchTAF || c.Weather.FetchNOTAMs) && c.Station.AirportCode == "" {
-		return fmt.Errorf("station airport_code is required when weather fetching is enabled")
+	if !strings.HasPrefix(c.Push.Subject, "mailto:") && !strings.HasPrefix(c.Push.Subject, "https:") {
+		return fmt.Errorf("push subject must be a mailto: or https: URL: %q", c.Push.Subject)
+	}
+	if (c.Push.VAPIDPublicKey == "") != (c.Push.VAPIDPrivateKey == "") {
+		return fmt.Errorf("push vapid_public_key and vapid_private_key must be set together")
+	}
+	if c.Push.TTLSeconds == 0 {
+		c.Push.TTLSeconds = 3600
+	}
+	if c.Push.TTLSeconds < 0 {
+		return fmt.Errorf("push ttl_seconds must be positive: %d", c.Push.TTLSeconds)
 	}
 
 	return nil
 }
 
-// WeatherConfig contains weather data fetching and caching configuration
-type WeatherConfig struct {
-	RefreshIntervalMinutes int    `toml:"refresh_interval_minutes"` // Weather data refresh interval in minutes
-	APIBaseURL             string `toml:"api_base_url"`             // Base URL for weather API (e.g., https://node.windy.com/airports)
-	RequestTimeoutSeconds  int    `toml:"request_timeout_seconds"`  // HTTP request timeout in seconds
-	MaxRetries             int    `toml:"max_retries"`              // Maximum number of retry attempts for failed requests
-	FetchMETAR             bool   `toml:"fetch_metar"`              // Whether to fetch METAR data
-	FetchTAF               bool   `toml:"fetch_taf"`                // Whether to fetch TAF data
-	FetchNOTAMs            bool   `toml:"fetch_notams"`             // Whether to fetch NOTAM data
-	CacheExpiryMinutes     int    `toml:"cache_expiry_minutes"`     // How long to keep cached data if refresh fails
-}
+// ValidateUsage validates the usage accounting configuration
+func (c *Config) ValidateUsage() error {
+	if c.Usage.FlushIntervalSeconds <= 0 {
+		c.Usage.FlushIntervalSeconds = 60
+	}
+	if c.Usage.DailyBudgetUSD < 0 || c.Usage.MonthlyBudgetUSD < 0 {
+		return fmt.Errorf("usage budgets must not be negative")
+	}
+	if c.Usage.AlertThresholdPercent == 0 {
+		c.Usage.AlertThresholdPercent = 80
+	}
+	if c.Usage.AlertThresholdPercent < 0 || c.Usage.AlertThresholdPercent > 100 {
+		return fmt.Errorf("usage alert_threshold_percent must be between 0 and 100: %v", c.Usage.AlertThresholdPercent)
+	}
+	for model, price := range c.Usage.Prices {
+		if price.InputPerMillion < 0 || price.OutputPerMillion < 0 || price.AudioPerMinute < 0 {
+			return fmt.Errorf("usage price of %s must not be negative", model)
+		}
+	}
+
+	return nil
+}
+
+// ValidateTemplating validates the templating configuration
+func (c *Config) ValidateTemplating() error {
+	t := &c.Templating
+	if t.ReloadIntervalSecs <= 0 {
+		t.ReloadIntervalSecs = 2
+	}
+
+	if t.Profiles == nil {
+		t.Profiles = make(map[string]FormattingProfileConfig)
+	}
+	for name, profile := range builtinFormattingProfiles {
+		if _, exists := t.Profiles[name]; !exists {
+			t.Profiles[name] = profile
+		}
+	}
+	for name, profile := range t.Profiles {
+		if profile.Format == "" {
+			profile.Format = FormatText
+		}
+		if profile.Format != FormatText && profile.Format != FormatTable && profile.Format != FormatJSON {
+			return fmt.Errorf("templating profile %s: format must be text, table or json: %s", name, profile.Format)
+		}
+		if profile.MaxAircraft < 0 || profile.TokenBudget < 0 {
+			return fmt.Errorf("templating profile %s: max_aircraft and token_budget can't be negative", name)
+		}
+		t.Profiles[name] = profile
+	}
+
+	if t.ATCChat.Profile == "" {
+		t.ATCChat.Profile = "detailed"
+	}
+	if t.PostProcessing.Profile == "" {
+		t.PostProcessing.Profile = "detailed"
+	}
+	for _, name := range []string{t.ATCChat.Profile, t.PostProcessing.Profile} {
+		if _, exists := t.Profiles[name]; !exists {
+			return fmt.Errorf("unknown templating profile: %s", name)
+		}
+	}
+	return nil
+}
+
+// ValidateBriefing validates the spoken briefing configuration
+func (c *Config) ValidateBriefing() error {
+	if c.Briefing.TemplatePath == "" {
+		c.Briefing.TemplatePath = "assets/briefing_template.txt"
+	}
+	if c.Briefing.TTSModel == "" {
+		c.Briefing.TTSModel = "gpt-4o-mini-tts"
+	}
+	if c.Briefing.Voice == "" {
+		c.Briefing.Voice = "alloy"
+	}
+	if c.Briefing.AudioFormat == "" {
+		c.Briefing.AudioFormat = "mp3"
+	}
+	switch c.Briefing.AudioFormat {
+	case "mp3", "opus", "aac", "wav":
+	default:
+		return fmt.Errorf("briefing audio_format must be mp3, opus, aac or wav: %s", c.Briefing.AudioFormat)
+	}
+	if c.Briefing.IntervalMinutes < 0 {
+		return fmt.Errorf("briefing interval_minutes must not be negative: %d", c.Briefing.IntervalMinutes)
+	}
+
+	return nil
+}
+
+// ValidateATIS validates the D-ATIS configuration
+func (c *Config) ValidateATIS() error {
+	if c.ATIS.APIBaseURL == "" {
+		c.ATIS.APIBaseURL = "https://datis.clowd.io/api"
+	}
+	c.ATIS.APIBaseURL = strings.TrimRight(c.ATIS.APIBaseURL, "/")
+	if c.ATIS.PollIntervalSeconds <= 0 {
+		c.ATIS.PollIntervalSeconds = 120
+	}
+	if c.ATIS.Enabled && c.ATIS.PollIntervalSeconds < 30 {
+		return fmt.Errorf("atis poll_interval_seconds must be at least 30: %d", c.ATIS.PollIntervalSeconds)
+	}
+	if c.ATIS.Source == "" {
+		c.ATIS.Source = ATISSourceDATIS
+	}
+	if c.ATIS.Source != ATISSourceDATIS && c.ATIS.Source != ATISSourceSynthesized {
+		return fmt.Errorf("atis source must be datis or synthesized: %s", c.ATIS.Source)
+	}
+	if c.ATIS.TemplatePath == "" {
+		c.ATIS.TemplatePath = "assets/atis_template.txt"
+	}
+	if c.ATIS.NOTAMHighlights == 0 {
+		c.ATIS.NOTAMHighlights = 3
+	}
+
+	return nil
+}
+
+// ValidateNotify validates the notification channels
+func (c *Config) ValidateNotify() error {
+	if !c.Notify.Enabled {
+		return nil // Skip validation if notifications are disabled
+	}
+
+	names := make(map[string]bool)
+	for i := range c.Notify.Channels {
+		channel := &c.Notify.Channels[i]
+		if channel.Name == "" {
+			return fmt.Errorf("notify channel %d has no name", i+1)
+		}
+		if names[channel.Name] {
+			return fmt.Errorf("duplicate notify channel name: %s", channel.Name)
+		}
+		names[channel.Name] = true
+
+		channel.Type = strings.ToLower(channel.Type)
+		switch channel.Type {
+		case NotifyChannelWebhook, NotifyChannelDiscord, NotifyChannelSlack:
+			if !strings.HasPrefix(channel.URL, "http://") && !strings.HasPrefix(channel.URL, "https://") {
+				return fmt.Errorf("notify channel %s needs an http(s) url", channel.Name)
+			}
+		case NotifyChannelTelegram:
+			if channel.BotToken == "" || channel.ChatID == "" {
+				return fmt.Errorf("notify channel %s needs bot_token and chat_id", channel.Name)
+			}
+		case NotifyChannelMQTT:
+			if !strings.HasPrefix(channel.URL, "tcp://") && !strings.HasPrefix(channel.URL, "mqtt://") && !strings.HasPrefix(channel.URL, "mqtts://") {
+				return fmt.Errorf("notify channel %s needs a tcp://, mqtt:// or mqtts:// broker url", channel.Name)
+			}
+			if channel.Topic == "" {
+				channel.Topic = "co-atc/alerts/{{.Type}}"
+			}
+			if channel.ClientID == "" {
+				channel.ClientID = "co-atc-" + channel.Name
+			}
+		default:
+			return fmt.Errorf("invalid notify channel type for %s: %q", channel.Name, channel.Type)
+		}
+
+		if channel.TimeoutSeconds <= 0 {
+			channel.TimeoutSeconds = 10
+		}
+	}
+
+	return nil
+}
+
+// ValidateMQTT validates the MQTT publishing configuration
+func (c *Config) ValidateMQTT() error {
+	if !c.MQTT.Enabled {
+		return nil // Skip validation if MQTT is disabled
+	}
+
+	if !strings.HasPrefix(c.MQTT.BrokerURL, "tcp://") && !strings.HasPrefix(c.MQTT.BrokerURL, "mqtt://") && !strings.HasPrefix(c.MQTT.BrokerURL, "mqtts://") {
+		return fmt.Errorf("mqtt needs a tcp://, mqtt:// or mqtts:// broker_url")
+	}
+	if c.MQTT.ClientID == "" {
+		c.MQTT.ClientID = "co-atc"
+	}
+	c.MQTT.TopicPrefix = strings.Trim(c.MQTT.TopicPrefix, "/")
+	if c.MQTT.TopicPrefix == "" {
+		c.MQTT.TopicPrefix = "co-atc"
+	}
+	if strings.ContainsAny(c.MQTT.TopicPrefix, "+#") {
+		return fmt.Errorf("mqtt topic_prefix must not contain wildcards")
+	}
+	if c.MQTT.KeepAliveSeconds <= 0 {
+		c.MQTT.KeepAliveSeconds = 60
+	}
+	if c.MQTT.AircraftIntervalSeconds <= 0 {
+		c.MQTT.AircraftIntervalSeconds = 10
+	}
+	c.MQTT.DiscoveryPrefix = strings.Trim(c.MQTT.DiscoveryPrefix, "/")
+	if c.MQTT.DiscoveryPrefix == "" {
+		c.MQTT.DiscoveryPrefix = "homeassistant"
+	}
+
+	return nil
+}
+
+// ValidateTrafficGenerator validates the traffic generator configuration. The generator can
+// be started from the API, so the defaults are set even when it's disabled.
+func (c *Config) ValidateTrafficGenerator() error {
+	t := &c.TrafficGenerator
+	if t.ArrivalsPerHour == 0 {
+		t.ArrivalsPerHour = 12
+	}
+	if t.DeparturesPerHour == 0 {
+		t.DeparturesPerHour = 12
+	}
+	if t.ArrivalsPerHour < 0 || t.ArrivalsPerHour > 120 || t.DeparturesPerHour < 0 || t.DeparturesPerHour > 120 {
+		return fmt.Errorf("traffic_generator rates must be between 0 and 120 per hour")
+	}
+	if t.MaxAircraft <= 0 {
+		t.MaxAircraft = 20
+	}
+	if t.MaxAircraft > 100 {
+		return fmt.Errorf("traffic_generator max_aircraft must be at most 100: %d", t.MaxAircraft)
+	}
+	if t.SpawnDistanceNM <= 0 {
+		t.SpawnDistanceNM = 30
+	}
+	if t.SpawnDistanceNM < 15 || t.SpawnDistanceNM > 100 {
+		return fmt.Errorf("traffic_generator spawn_distance_nm must be between 15 and 100: %g", t.SpawnDistanceNM)
+	}
+	if t.ArrivalAltitudeFt <= 0 {
+		t.ArrivalAltitudeFt = 6000
+	}
+	if t.DepartureAltitudeFt <= 0 {
+		t.DepartureAltitudeFt = 8000
+	}
+	for i, runway := range t.ArrivalRunways {
+		t.ArrivalRunways[i] = strings.ToUpper(strings.TrimSpace(runway))
+	}
+	for i, runway := range t.DepartureRunways {
+		t.DepartureRunways[i] = strings.ToUpper(strings.TrimSpace(runway))
+	}
+	if t.RadioFrequencyID != "" {
+		known := false
+		for _, freq := range c.Frequencies.Sources {
+			known = known || freq.ID == t.RadioFrequencyID
+		}
+		if !known {
+			return fmt.Errorf("traffic_generator radio_frequency_id is not a configured frequency: %s", t.RadioFrequencyID)
+		}
+	}
+
+	return nil
+}
+
+// ValidateTerrain validates the terrain and obstacle proximity warning configuration
+func (c *Config) ValidateTerrain() error {
+	if c.Terrain.RadiusNM <= 0 {
+		c.Terrain.RadiusNM = 40
+	}
+	if c.Terrain.MinClearanceFeet <= 0 {
+		c.Terrain.MinClearanceFeet = 500
+	}
+	if c.Terrain.ObstacleRadiusNM <= 0 {
+		c.Terrain.ObstacleRadiusNM = 0.5
+	}
+	if c.Terrain.LookaheadSeconds <= 0 {
+		c.Terrain.LookaheadSeconds = 60
+	}
+	if c.Terrain.MinDescentRateFPM <= 0 {
+		c.Terrain.MinDescentRateFPM = 300
+	}
+	if c.Terrain.FinalApproachNM <= 0 {
+		c.Terrain.FinalApproachNM = 10
+	}
+	if c.Terrain.FinalApproachWidthNM <= 0 {
+		c.Terrain.FinalApproachWidthNM = 1
+	}
+	if c.Terrain.RealertMinutes <= 0 {
+		c.Terrain.RealertMinutes = 2
+	}
+	if !c.Terrain.Enabled {
+		return nil
+	}
+
+	if c.Terrain.SRTMDir == "" {
+		return fmt.Errorf("terrain srtm_dir is required when terrain warnings are enabled")
+	}
+	if info, err := os.Stat(c.Terrain.SRTMDir); err != nil || !info.IsDir() {
+		return fmt.Errorf("terrain srtm_dir is not a directory: %s", c.Terrain.SRTMDir)
+	}
+	if c.Terrain.ObstaclesPath != "" {
+		if _, err := os.Stat(c.Terrain.ObstaclesPath); err != nil {
+			return fmt.Errorf("terrain obstacles_path not found: %s", c.Terrain.ObstaclesPath)
+		}
+	}
+	if c.Terrain.RadiusNM > 200 {
+		return fmt.Errorf("terrain radius_nm must be at most 200: %d", c.Terrain.RadiusNM)
+	}
+
+	return nil
+}
+
+// ValidateApproaches validates the stabilized approach monitoring configuration
+func (c *Config) ValidateApproaches() error {
+	if c.Approaches.GlidepathDeg <= 0 {
+		c.Approaches.GlidepathDeg = 3
+	}
+	if c.Approaches.GateHeightFeet <= 0 {
+		c.Approaches.GateHeightFeet = 1000
+	}
+	if c.Approaches.MaxDistanceNM <= 0 {
+		c.Approaches.MaxDistanceNM = 10
+	}
+	if c.Approaches.MaxDescentRateFPM <= 0 {
+		c.Approaches.MaxDescentRateFPM = 1000
+	}
+	if c.Approaches.GlidepathToleranceDeg <= 0 {
+		c.Approaches.GlidepathToleranceDeg = 0.7
+	}
+	if c.Approaches.MaxSpeedChangeKt <= 0 {
+		c.Approaches.MaxSpeedChangeKt = 15
+	}
+	if c.Approaches.SpeedWindowSeconds <= 0 {
+		c.Approaches.SpeedWindowSeconds = 30
+	}
+	if c.Approaches.ContextMinutes <= 0 {
+		c.Approaches.ContextMinutes = 15
+	}
+	if c.Approaches.GlidepathDeg > 6 {
+		return fmt.Errorf("approaches glidepath_deg must be at most 6: %g", c.Approaches.GlidepathDeg)
+	}
+	for runway, angle := range c.Approaches.RunwayGlidepaths {
+		if angle <= 0 || angle > 6 {
+			return fmt.Errorf("approaches runway_glidepaths angle of %s must be between 0 and 6: %g", runway, angle)
+		}
+		if c.Approaches.GlidepathToleranceDeg >= angle {
+			return fmt.Errorf("approaches glidepath_tolerance_deg must be less than the glidepath of %s", runway)
+		}
+	}
+	if c.Approaches.GlidepathToleranceDeg >= c.Approaches.GlidepathDeg {
+		return fmt.Errorf("approaches glidepath_tolerance_deg must be less than glidepath_deg")
+	}
+
+	return nil
+}
+
+// ValidateSequencing validates the arrival sequencing configuration
+func (c *Config) ValidateSequencing() error {
+	if c.Sequencing.MaxDistanceNM <= 0 {
+		c.Sequencing.MaxDistanceNM = 40
+	}
+	if c.Sequencing.FinalSpeedKt <= 0 {
+		c.Sequencing.FinalSpeedKt = 140
+	}
+	if c.Sequencing.SpeedWindowSeconds <= 0 {
+		c.Sequencing.SpeedWindowSeconds = 60
+	}
+
+	return nil
+}
+
+// ValidateDeviations validates the deviation monitoring configuration
+func (c *Config) ValidateDeviations() error {
+	if c.Deviations.ResponseWindowSeconds <= 0 {
+		c.Deviations.ResponseWindowSeconds = 60
+	}
+	if c.Deviations.MonitorMinutes <= 0 {
+		c.Deviations.MonitorMinutes = 10
+	}
+	if c.Deviations.AltitudeToleranceFeet <= 0 {
+		c.Deviations.AltitudeToleranceFeet = 300
+	}
+	if c.Deviations.HeadingToleranceDeg <= 0 {
+		c.Deviations.HeadingToleranceDeg = 15
+	}
+	if c.Deviations.HeadingToleranceDeg >= 90 {
+		return fmt.Errorf("deviations heading_tolerance_deg must be less than 90: %d", c.Deviations.HeadingToleranceDeg)
+	}
+	if c.Deviations.ResponseWindowSeconds >= c.Deviations.MonitorMinutes*60 {
+		return fmt.Errorf("deviations response_window_seconds must be shorter than monitor_minutes")
+	}
+
+	return nil
+}
+
+// ValidateIntents sets defaults for intent inference
+func (c *Config) ValidateIntents() error {
+	if c.Intents.ClearanceMinutes <= 0 {
+		c.Intents.ClearanceMinutes = 15
+	}
+
+	return nil
+}
+
+// ValidateMovements sets defaults for flight session recording
+func (c *Config) ValidateMovements() error {
+	if c.Movements.SessionGapMinutes <= 0 {
+		c.Movements.SessionGapMinutes = 10
+	}
+	if c.Movements.RetentionDays <= 0 {
+		c.Movements.RetentionDays = 90
+	}
+
+	return nil
+}
+
+// ValidateOccupancy validates the runway outlines of occupancy measurement
+func (c *Config) ValidateOccupancy() error {
+	if c.Occupancy.RunwayWidthFeet <= 0 {
+		c.Occupancy.RunwayWidthFeet = 200
+	}
+	if c.Occupancy.RetentionDays <= 0 {
+		c.Occupancy.RetentionDays = 90
+	}
+
+	for runway, polygon := range c.Occupancy.Polygons {
+		if len(polygon) < 3 {
+			return fmt.Errorf("occupancy polygon %s needs at least 3 vertices", runway)
+		}
+		for _, vertex := range polygon {
+			if len(vertex) != 2 || vertex[0] < -90 || vertex[0] > 90 || vertex[1] < -180 || vertex[1] > 180 {
+				return fmt.Errorf("occupancy polygon %s vertices must be [latitude, longitude]", runway)
+			}
+		}
+	}
+
+	return nil
+}
+
+// ValidateBoard validates the schedule provider of the arrivals and departures boards
+func (c *Config) ValidateBoard() error {
+	if c.Board.RefreshMinutes <= 0 {
+		c.Board.RefreshMinutes = 60
+	}
+	if c.Board.PastHours <= 0 {
+		c.Board.PastHours = 3
+	}
+	if c.Board.AheadHours <= 0 {
+		c.Board.AheadHours = 12
+	}
+	if c.Board.DelayThresholdMinutes <= 0 {
+		c.Board.DelayThresholdMinutes = 15
+	}
+	if c.Board.AirportsDBPath == "" {
+		c.Board.AirportsDBPath = "assets/airports.json"
+	}
+
+	switch c.Board.ScheduleProvider {
+	case "":
+		return nil
+	case ScheduleProviderAviationstack:
+		if c.Board.APIBaseURL == "" {
+			c.Board.APIBaseURL = "http://api.aviationstack.com/v1"
+		}
+	default:
+		return fmt.Errorf("board schedule_provider must be aviationstack or empty: %s", c.Board.ScheduleProvider)
+	}
+	c.Board.APIBaseURL = strings.TrimRight(c.Board.APIBaseURL, "/")
+
+	if !c.Movements.Enabled {
+		return fmt.Errorf("board schedule_provider needs [movements] enabled = true")
+	}
+	if c.Board.APIKey == "" {
+		return fmt.Errorf("board schedule_provider %s needs an api_key", c.Board.ScheduleProvider)
+	}
+	if c.Station.AirportCode == "" {
+		return fmt.Errorf("station airport_code is required for the board schedule")
+	}
+	if c.Board.RefreshMinutes < 5 {
+		return fmt.Errorf("board refresh_minutes must be at least 5: %d", c.Board.RefreshMinutes)
+	}
+
+	return nil
+}
+
+// ValidateNoise validates the noise-sensitive zones and curfew hours
+func (c *Config) ValidateNoise() error {
+	if c.Noise.MaxAltitudeFeet <= 0 {
+		c.Noise.MaxAltitudeFeet = 3000
+	}
+	if c.Noise.RetentionDays <= 0 {
+		c.Noise.RetentionDays = 90
+	}
+	if !c.Noise.Enabled {
+		return nil
+	}
+
+	if c.Noise.Timezone != "" {
+		if _, err := time.LoadLocation(c.Noise.Timezone); err != nil {
+			return fmt.Errorf("invalid noise timezone %q: %w", c.Noise.Timezone, err)
+		}
+	}
+	if err := validateCurfew(c.Noise.CurfewStart, c.Noise.CurfewEnd); err != nil {
+		return fmt.Errorf("noise %w", err)
+	}
+	if len(c.Noise.Zones) == 0 {
+		return fmt.Errorf("noise monitoring needs at least one [[noise.zones]] zone")
+	}
+
+	names := make(map[string]bool)
+	for i, zone := range c.Noise.Zones {
+		if zone.Name == "" {
+			return fmt.Errorf("noise zone %d has no name", i+1)
+		}
+		if names[zone.Name] {
+			return fmt.Errorf("duplicate noise zone name: %s", zone.Name)
+		}
+		names[zone.Name] = true
+
+		if len(zone.Polygon) > 0 {
+			if len(zone.Polygon) < 3 {
+				return fmt.Errorf("noise zone %s polygon needs at least 3 vertices", zone.Name)
+			}
+			for _, vertex := range zone.Polygon {
+				if len(vertex) != 2 || vertex[0] < -90 || vertex[0] > 90 || vertex[1] < -180 || vertex[1] > 180 {
+					return fmt.Errorf("noise zone %s polygon vertices must be [latitude, longitude]", zone.Name)
+				}
+			}
+		} else if zone.RadiusNM <= 0 {
+			return fmt.Errorf("noise zone %s needs a radius_nm or a polygon", zone.Name)
+		}
+		if zone.MaxAltitudeFeet < 0 {
+			return fmt.Errorf("noise zone %s max_altitude_feet must not be negative: %d", zone.Name, zone.MaxAltitudeFeet)
+		}
+		if err := validateCurfew(zone.CurfewStart, zone.CurfewEnd); err != nil {
+			return fmt.Errorf("noise zone %s %w", zone.Name, err)
+		}
+	}
+
+	return nil
+}
+
+// ValidatePrivacy validates the blocked aircraft and brings their hex codes and registrations
+// to canonical case
+func (c *Config) ValidatePrivacy() error {
+	for i, hex := range c.Privacy.BlockedHexCodes {
+		hex = strings.ToLower(strings.TrimSpace(hex))
+		if _, err := strconv.ParseUint(hex, 16, 32); err != nil || len(hex) != 6 {
+			return fmt.Errorf("invalid blocked hex code %q (must be 6 hex digits)", c.Privacy.BlockedHexCodes[i])
+		}
+		c.Privacy.BlockedHexCodes[i] = hex
+	}
+	for i, registration := range c.Privacy.BlockedRegistrations {
+		registration = strings.ToUpper(strings.TrimSpace(registration))
+		if registration == "" {
+			return fmt.Errorf("blocked registrations must not be empty")
+		}
+		c.Privacy.BlockedRegistrations[i] = registration
+	}
+	if c.Privacy.BlockedListPath != "" {
+		if _, err := os.Stat(c.Privacy.BlockedListPath); err != nil {
+			return fmt.Errorf("blocked list file not found: %s", c.Privacy.BlockedListPath)
+		}
+	}
+
+	return nil
+}
+
+// ValidateFeeder validates the aggregators to feed and fills in the addresses of known ones
+func (c *Config) ValidateFeeder() error {
+	if !c.Feeder.Enabled {
+		return nil
+	}
+
+	if c.Feeder.UUID != "" {
+		id := strings.ReplaceAll(c.Feeder.UUID, "-", "")
+		if _, err := hex.DecodeString(id); err != nil || len(id) < 16 {
+			return fmt.Errorf("invalid feeder uuid %q (must be at least 16 hex digits)", c.Feeder.UUID)
+		}
+	}
+	if len(c.Feeder.Aggregators) == 0 {
+		return fmt.Errorf("feeding needs at least one [[feeder.aggregators]] aggregator")
+	}
+
+	names := make(map[string]bool)
+	for i := range c.Feeder.Aggregators {
+		aggregator := &c.Feeder.Aggregators[i]
+		if aggregator.Name == "" {
+			return fmt.Errorf("feeder aggregator %d has no name", i+1)
+		}
+		if names[aggregator.Name] {
+			return fmt.Errorf("duplicate feeder aggregator name: %s", aggregator.Name)
+		}
+		names[aggregator.Name] = true
+
+		if aggregator.Protocol == "" {
+			aggregator.Protocol = "beast"
+		}
+		switch aggregator.Protocol {
+		case "beast":
+			if aggregator.Address == "" {
+				aggregator.Address = DefaultAggregatorAddresses[aggregator.Name]
+			}
+			if aggregator.Enabled && c.ADSB.SourceType != "raw" {
+				return fmt.Errorf("feeder aggregator %s: the beast protocol needs adsb source_type = \"raw\"", aggregator.Name)
+			}
+		case "json":
+			if aggregator.Enabled && c.ADSB.SourceType != "local" && c.ADSB.SourceType != "raw" && c.ADSB.UATSourceURL == "" {
+				return fmt.Errorf("feeder aggregator %s: the json protocol needs a local, raw or UAT source", aggregator.Name)
+			}
+		default:
+			return fmt.Errorf("feeder aggregator %s: invalid protocol %q (must be 'beast' or 'json')", aggregator.Name, aggregator.Protocol)
+		}
+		if _, _, err := net.SplitHostPort(aggregator.Address); err != nil {
+			return fmt.Errorf("feeder aggregator %s: address must be host:port: %q", aggregator.Name, aggregator.Address)
+		}
+	}
+
+	return nil
+}
+
+// validateCurfew checks that curfew hours are both set as "HH:MM" and differ, or both empty
+func validateCurfew(start, end string) error {
+	if start == "" && end == "" {
+		return nil
+	}
+	startTime, err := time.Parse("15:04", start)
+	if err != nil {
+		return fmt.Errorf("curfew_start must be HH:MM: %q", start)
+	}
+	endTime, err := time.Parse("15:04", end)
+	if err != nil {
+		return fmt.Errorf("curfew_end must be HH:MM: %q", end)
+	}
+	if startTime.Equal(endTime) {
+		return fmt.Errorf("curfew_start and curfew_end must differ")
+	}
+	return nil
+}
+
+// ValidateATCChatPersonas validates the ATC chat personas
+func (c *Config) ValidateATCChatPersonas() error {
+	names := make(map[string]bool)
+	for _, persona := range c.ATCChat.Personas {
+		if persona.Name == "" {
+			return fmt.Errorf("atc_chat persona name must not be empty")
+		}
+		if names[persona.Name] {
+			return fmt.Errorf("duplicate atc_chat persona: %s", persona.Name)
+		}
+		names[persona.Name] = true
+
+		if persona.Temperature != 0 && persona.Temperature < 0.6 {
+			return fmt.Errorf("atc_chat persona %s temperature must be at least 0.6: %v", persona.Name, persona.Temperature)
+		}
+		if persona.MaxResponseTokens < 0 {
+			return fmt.Errorf("atc_chat persona %s max_response_tokens must not be negative", persona.Name)
+		}
+	}
+
+	if c.ATCChat.DefaultPersona != "" && !names[c.ATCChat.DefaultPersona] {
+		return fmt.Errorf("atc_chat default_persona %s is not a configured persona", c.ATCChat.DefaultPersona)
+	}
+
+	return nil
+}
+
+// ValidateTranscription validates the transcription configuration
+func (c *Config) ValidateTranscription() error {
+	switch c.Transcription.Provider {
+	case "":
+		c.Transcription.Provider = "openai"
+	case "openai", "deepgram":
+	default:
+		return fmt.Errorf("invalid transcription provider: %s (must be openai or deepgram)", c.Transcription.Provider)
+	}
+	if c.Transcription.ConnectsPerMinute < 0 || c.Transcription.Deepgram.ConnectsPerMinute < 0 {
+		return fmt.Errorf("transcription connects_per_minute must be 0 or greater")
+	}
+	if c.Transcription.MaxConcurrentSessions < 0 {
+		return fmt.Errorf("transcription max_concurrent_sessions must be 0 or greater: %d", c.Transcription.MaxConcurrentSessions)
+	}
+	if c.Transcription.WorkerRestartSeconds < 0 || c.Transcription.WorkerMaxRestartSeconds < 0 {
+		return fmt.Errorf("transcription worker restart times must be 0 or greater")
+	}
+	if c.Transcription.Provider == "deepgram" && c.Transcription.Deepgram.Model == "" {
+		c.Transcription.Deepgram.Model = "nova-2"
+	}
+	if c.Transcription.VocabularyMaxTerms == 0 {
+		c.Transcription.VocabularyMaxTerms = 60
+	}
+	if c.Transcription.VocabularyMaxTerms < 0 {
+		return fmt.Errorf("transcription vocabulary_max_terms must be positive: %d", c.Transcription.VocabularyMaxTerms)
+	}
+	if c.Transcription.VocabularyRefreshSeconds == 0 {
+		c.Transcription.VocabularyRefreshSeconds = 60
+	}
+	if c.Transcription.VocabularyRefreshSeconds < 0 {
+		return fmt.Errorf("transcription vocabulary_refresh_seconds must be positive: %d", c.Transcription.VocabularyRefreshSeconds)
+	}
+
+	if !c.Transcription.SilenceGating {
+		return nil // Skip validation if silence gating is disabled
+	}
+
+	if c.Transcription.SilenceThresholdDB == 0 {
+		c.Transcription.SilenceThresholdDB = -45
+	}
+	if c.Transcription.SilenceThresholdDB > 0 || c.Transcription.SilenceThresholdDB < -96 {
+		return fmt.Errorf("invalid silence_threshold_db: %g (must be between -96 and 0)", c.Transcription.SilenceThresholdDB)
+	}
+	// The server VAD only ends a turn after silence_duration_ms of silence, so the tail
+	// streamed after a transmission must be longer than that
+	if c.Transcription.SilenceHangMs == 0 {
+		c.Transcription.SilenceHangMs = c.Transcription.SilenceDurationMs + 500
+	}
+	if c.Transcription.SilenceHangMs <= c.Transcription.SilenceDurationMs {
+		return fmt.Errorf("silence_hang_ms (%d) must be longer than silence_duration_ms (%d)",
+			c.Transcription.SilenceHangMs, c.Transcription.SilenceDurationMs)
+	}
+	if c.Transcription.SilencePaddingMs == 0 {
+		c.Transcription.SilencePaddingMs = c.Transcription.PrefixPaddingMs
+	}
+	if c.Transcription.SilencePaddingMs < 0 {
+		return fmt.Errorf("transcription silence_padding_ms must be 0 or greater: %d", c.Transcription.SilencePaddingMs)
+	}
+
+	return nil
+}
+
+// ValidatePostProcessing validates the post-processing configuration
+func (c *Config) ValidatePostProcessing() error {
+	p := &c.PostProcessing
+	if !p.Enabled {
+		return nil
+	}
+
+	if p.ContextTranscriptions < 0 {
+		return fmt.Errorf("invalid context_transcriptions value: %d (must be >= 0)", p.ContextTranscriptions)
+	}
+	if p.RepairAttempts == 0 {
+		p.RepairAttempts = 1
+	}
+	if p.MaxConcurrentBatches <= 0 {
+		p.MaxConcurrentBatches = 2
+	}
+	if p.BackoffInitialSeconds <= 0 {
+		p.BackoffInitialSeconds = 5
+	}
+	if p.BackoffMaxSeconds <= 0 {
+		p.BackoffMaxSeconds = 300
+	}
+	if p.BackoffMaxSeconds < p.BackoffInitialSeconds {
+		return fmt.Errorf("post_processing backoff_max_seconds (%d) must be at least backoff_initial_seconds (%d)", p.BackoffMaxSeconds, p.BackoffInitialSeconds)
+	}
+
+	switch p.LLM.Provider {
+	case "":
+		p.LLM.Provider = "openai"
+		fallthrough
+	case "openai":
+		// OpenAI post-processing shares the transcription account unless told otherwise
+		if p.LLM.APIKey == "" {
+			p.LLM.APIKey = c.Transcription.OpenAIAPIKey
+		}
+		if p.LLM.BaseURL == "" {
+			p.LLM.BaseURL = c.Transcription.OpenAIBaseURL
+		}
+	case "openai-compatible":
+		if p.LLM.BaseURL == "" {
+			return fmt.Errorf("post_processing llm base_url is required for the openai-compatible provider")
+		}
+	case "anthropic":
+	default:
+		return fmt.Errorf("invalid post_processing llm provider: %s (must be openai, openai-compatible or anthropic)", p.LLM.Provider)
+	}
+
+	return nil
+}
+
+// ValidateOpenAIKeys validates OpenAI API keys for enabled features
+func (c *Config) ValidateOpenAIKeys() error {
+	// Check transcription API key - transcription is always available if configured
+	if c.Transcription.Provider == "deepgram" {
+		if c.Transcription.Deepgram.APIKey == "" {
+			fmt.Printf("WARN: No Deepgram API key provided for transcription - transcription features will be disabled\n")
+		}
+	} else if c.Transcription.OpenAIAPIKey == "" {
+		fmt.Printf("WARN: No OpenAI API key provided for transcription - transcription features will be disabled\n")
+	}
+
+	// Check ATC chat API key if ATC chat is enabled
+	if c.ATCChat.Enabled && c.ATCChat.OpenAIAPIKey == "" {
+		fmt.Printf("WARN: ATC Chat is enabled but no OpenAI API key provided - ATC chat features will be disabled\n")
+	}
+
+	// Check post-processing API key if post-processing is enabled
+	if c.PostProcessing.Enabled && c.PostProcessing.LLM.APIKey == "" && c.PostProcessing.LLM.Provider != "openai-compatible" {
+		fmt.Printf("WARN: Post-processing is enabled but no %s API key provided - post-processing features will be disabled\n", c.PostProcessing.LLM.Provider)
+	}
+
+	return nil
+}
+
+// ValidateWeather validates the weather configuration
+func (c *Config) ValidateWeather() error {
+	// Validate refresh interval
+	if c.Weather.RefreshIntervalMinutes <= 0 {
+		return fmt.Errorf("weather refresh_interval_minutes must be greater than 0: %d", c.Weather.RefreshIntervalMinutes)
+	}
+
+	// Validate request timeout
+	if c.Weather.RequestTimeoutSeconds <= 0 {
+		return fmt.Errorf("weather request_timeout_seconds must be greater than 0: %d", c.Weather.RequestTimeoutSeconds)
+	}
+
+	// Validate max retries
+	if c.Weather.MaxRetries < 0 {
+		return fmt.Errorf("weather max_retries must be 0 or greater: %d", c.Weather.MaxRetries)
+	}
+
+	// Validate cache expiry
+	if c.Weather.CacheExpiryMinutes <= 0 {
+		return fmt.Errorf("weather cache_expiry_minutes must be greater than 0: %d", c.Weather.CacheExpiryMinutes)
+	}
+
+	// Validate providers
+	if len(c.Weather.Providers) == 0 {
+		c.Weather.Providers = []string{"windy"}
+	}
+	seen := make(map[string]bool)
+	for _, provider := range c.Weather.Providers {
+		if seen[provider] {
+			return fmt.Errorf("weather provider listed twice: %s", provider)
+		}
+		seen[provider] = true
+
+		switch provider {
+		case "windy":
+			if c.Weather.APIBaseURL == "" {
+				return fmt.Errorf("weather api_base_url cannot be empty")
+			}
+		case "aviationweather":
+		case "checkwx":
+			if c.Weather.CheckWXAPIKey == "" {
+				return fmt.Errorf("weather checkwx_api_key is required for the checkwx provider")
+			}
+		default:
+			return fmt.Errorf("unknown weather provider: %s (must be windy, aviationweather or checkwx)", provider)
+		}
+	}
+
+	// Validate winds aloft
+	if c.Weather.WindsAloftRadiusNM < 0 {
+		return fmt.Errorf("weather winds_aloft_radius_nm must not be negative: %d", c.Weather.WindsAloftRadiusNM)
+	}
+	if c.Weather.WindsAloftRadiusNM == 0 {
+		c.Weather.WindsAloftRadiusNM = 50
+	}
+
+	// Validate hazards
+	if c.Weather.HazardsRadiusNM < 0 {
+		return fmt.Errorf("weather hazards_radius_nm must not be negative: %d", c.Weather.HazardsRadiusNM)
+	}
+	if c.Weather.HazardsRadiusNM == 0 {
+		c.Weather.HazardsRadiusNM = 150
+	}
+
+	// Validate METAR history
+	if c.Weather.HistoryRetentionDays < 0 {
+		return fmt.Errorf("weather history_retention_days must not be negative: %d", c.Weather.HistoryRetentionDays)
+	}
+	if c.Weather.HistoryRetentionDays == 0 {
+		c.Weather.HistoryRetentionDays = 7
+	}
+
+	// Validate runway wind limits
+	if c.Weather.CrosswindLimitKt < 0 {
+		return fmt.Errorf("weather crosswind_limit_kt must not be negative: %d", c.Weather.CrosswindLimitKt)
+	}
+	if c.Weather.CrosswindLimitKt == 0 {
+		c.Weather.CrosswindLimitKt = 20
+	}
+	if c.Weather.TailwindLimitKt < -1 {
+		return fmt.Errorf("weather tailwind_limit_kt must be -1 or more: %d", c.Weather.TailwindLimitKt)
+	}
+	if c.Weather.TailwindLimitKt == 0 {
+		c.Weather.TailwindLimitKt = 5
+	}
+
+	// At least one weather type must be enabled
+	if !c.Weather.FetchMETAR && !c.Weather.FetchTAF && !c.Weather.FetchNOTAMs {
+		return fmt.Errorf("at least one weather type must be enabled (fetch_metar, fetch_taf, or fetch_notams)")
+	}
+
+	// Validate that airport code is set if weather fetching is enabled
+	if (c.Weather.FetchMETAR || c.Weather.FetchTAF || c.Weather.FetchNOTAMs) && c.Station.AirportCode == "" {
+		return fmt.Errorf("station airport_code is required when weather fetching is enabled")
+	}
+
+	return nil
+}
+
+// WeatherConfig contains weather data fetching and caching configuration
+type WeatherConfig struct {
+	RefreshIntervalMinutes int      `toml:"refresh_interval_minutes"` // Weather data refresh interval in minutes
+	Providers              []string `toml:"providers"`                // Weather providers in failover order: "windy", "aviationweather", "checkwx" (default: ["windy"])
+	APIBaseURL             string   `toml:"api_base_url"`             // Base URL for the windy provider (e.g., https://node.windy.com/airports)
+	CheckWXAPIKey          string   `toml:"checkwx_api_key"`          // API key for the checkwx provider
+	RequestTimeoutSeconds  int      `toml:"request_timeout_seconds"`  // HTTP request timeout in seconds
+	MaxRetries             int      `toml:"max_retries"`              // Maximum number of retry attempts for failed requests
+	FetchMETAR             bool     `toml:"fetch_metar"`              // Whether to fetch METAR data
+	FetchTAF               bool     `toml:"fetch_taf"`                // Whether to fetch TAF data
+	FetchNOTAMs            bool     `toml:"fetch_notams"`             // Whether to fetch NOTAM data
+	FetchWindsAloft        bool     `toml:"fetch_winds_aloft"`        // Whether to fetch GFS winds aloft for the station and surrounding area
+	WindsAloftRadiusNM     int      `toml:"winds_aloft_radius_nm"`    // Distance of the surrounding winds aloft points from the station (default: 50)
+	FetchHazards           bool     `toml:"fetch_hazards"`            // Whether to fetch SIGMETs, AIRMETs and PIREPs near the station from aviationweather.gov
+	HazardsRadiusNM        int      `toml:"hazards_radius_nm"`        // Distance from the station within which hazards are kept (default: 150)
+	StoreHistory           bool     `toml:"store_history"`            // Whether to store every new METAR for weather trends
+	HistoryRetentionDays   int      `toml:"history_retention_days"`   // How long stored METARs are kept (default: 7)
+	CrosswindLimitKt       int      `toml:"crosswind_limit_kt"`       // Runways whose crosswind, gusts included, exceeds this are flagged (default: 20)
+	TailwindLimitKt        int      `toml:"tailwind_limit_kt"`        // Runways whose tailwind, gusts included, exceeds this are flagged (default: 5, -1 flags any tailwind)
+	CacheExpiryMinutes     int      `toml:"cache_expiry_minutes"`     // How long to keep cached data if refresh fails
+}
+
+// minChatRefreshSecs is the shortest automatic ATC chat context refresh interval
+const minChatRefreshSecs = 5
 
 // ATCChatConfig contains ATC Chat voice assistant configuration
 type ATCChatConfig struct {
@@ -703,6 +2405,79 @@ type ATCChatConfig struct {
 	// System prompt configuration
 	SystemPromptPath        string `toml:"system_prompt_path"`    // Path to system prompt template file
 	RefreshSystemPromptSecs int    `toml:"refresh_system_prompt"` // Automatic system prompt refresh interval in seconds (0 = disabled)
+
+	// Session lifecycle
+	MaxSessions        int `toml:"max_sessions"`         // Maximum concurrent sessions (0 = unlimited)
+	IdleTimeoutMinutes int `toml:"idle_timeout_minutes"` // Sessions without user activity for this long are ended (default: 15)
+
+	// Session memory
+	SessionMemory            bool `toml:"session_memory"`               // Summarise each session and include the summary in the same client's next session
+	SessionMemoryMaxAgeHours int  `toml:"session_memory_max_age_hours"` // How long a summary is used and kept (default: 168)
+
+	// Session history
+	HistoryRetentionDays int `toml:"history_retention_days"` // How long session transcripts are kept (default: 30)
+
+	// Function calling
+	DisableTools bool `toml:"disable_tools"` // Don't let the assistant call functions for live aircraft, weather, runway and transcription data
+
+	// Relay recording
+	RecordAudio   bool   `toml:"record_audio"`   // Record the audio relayed in each session to WAV files
+	RecordingsDir string `toml:"recordings_dir"` // Directory for session recordings (default: <sqlite_base_path>/atc-chat)
+
+	// Personas
+	DefaultPersona string              `toml:"default_persona"` // Persona of sessions created without one (empty = the settings above)
+	Personas       []ChatPersonaConfig `toml:"personas"`        // Personas a session can be created with
+}
+
+// ChatPersonaConfig is a role the ATC chat assistant can take for a session, such as a
+// student pilot tutor or a plane-spotter guide. Unset fields use the [atc_chat] settings.
+type ChatPersonaConfig struct {
+	Name              string  `toml:"name" json:"name"`                               // Identifier used in the session request
+	Description       string  `toml:"description" json:"description,omitempty"`       // Shown to users picking a persona
+	SystemPromptPath  string  `toml:"system_prompt_path" json:"-"`                    // Prompt template for this persona
+	Voice             string  `toml:"voice" json:"voice"`                             // Voice for audio responses
+	RealtimeModel     string  `toml:"realtime_model" json:"realtime_model"`           // OpenAI realtime model
+	Temperature       float64 `toml:"temperature" json:"temperature"`                 // Response randomness
+	MaxResponseTokens int     `toml:"max_response_tokens" json:"max_response_tokens"` // Maximum tokens in response
+}
+
+// Persona returns the named persona with unset fields filled from the [atc_chat] settings.
+// An empty name selects the default persona, or the [atc_chat] settings if there is none.
+func (c *ATCChatConfig) Persona(name string) (ChatPersonaConfig, bool) {
+	if name == "" {
+		name = c.DefaultPersona
+	}
+
+	persona := ChatPersonaConfig{}
+	if name != "" {
+		found := false
+		for _, p := range c.Personas {
+			if p.Name == name {
+				persona, found = p, true
+				break
+			}
+		}
+		if !found {
+			return ChatPersonaConfig{}, false
+		}
+	}
+
+	if persona.SystemPromptPath == "" {
+		persona.SystemPromptPath = c.SystemPromptPath
+	}
+	if persona.Voice == "" {
+		persona.Voice = c.Voice
+	}
+	if persona.RealtimeModel == "" {
+		persona.RealtimeModel = c.RealtimeModel
+	}
+	if persona.Temperature == 0 {
+		persona.Temperature = c.Temperature
+	}
+	if persona.MaxResponseTokens == 0 {
+		persona.MaxResponseTokens = c.MaxResponseTokens
+	}
+	return persona, true
 }
 
 // TemplatingConfig contains shared templating system configuration
@@ -711,24 +2486,54 @@ type TemplatingConfig struct {
 	Enabled bool `toml:"enabled"` // Enable or disable templating system
 
 	// Template cache settings
-	TemplateCacheSize int  `toml:"template_cache_size"` // Maximum number of templates to cache
-	ReloadTemplates   bool `toml:"reload_templates"`    // Whether to reload templates from disk (development mode)
+	TemplateCacheSize  int  `toml:"template_cache_size"`     // Maximum number of templates to cache
+	ReloadTemplates    bool `toml:"reload_templates"`        // Reload templates and partials when their files change
+	ReloadIntervalSecs int  `toml:"reload_interval_seconds"` // How often template files are checked for changes (default: 2)
 
 	// ATC Chat template settings
 	ATCChat TemplatingATCChatConfig `toml:"atc_chat"`
 
 	// Post-processing template settings
 	PostProcessing TemplatingPostProcessingConfig `toml:"post_processing"`
+
+	// Aircraft formatting profiles by name, besides the built-in ones
+	Profiles map[string]FormattingProfileConfig `toml:"profiles"`
 }
 
 // TemplatingATCChatConfig contains ATC chat specific templating settings
 type TemplatingATCChatConfig struct {
 	TemplatePath string `toml:"template_path"` // Path to ATC chat template file
 	MaxAircraft  int    `toml:"max_aircraft"`  // Maximum aircraft to include in template
+	Profile      string `toml:"profile"`       // Aircraft formatting profile (default: "detailed")
 }
 
 // TemplatingPostProcessingConfig contains post-processing specific templating settings
 type TemplatingPostProcessingConfig struct {
 	TemplatePath          string `toml:"template_path"`          // Path to post-processing template file
 	ContextTranscriptions int    `toml:"context_transcriptions"` // Number of context transcriptions to include
+	Profile               string `toml:"profile"`                // Aircraft formatting profile (default: "detailed")
+}
+
+// FormattingProfileConfig sets how the aircraft in a prompt are formatted, trading detail
+// for tokens
+type FormattingProfileConfig struct {
+	Format      string `toml:"format"`       // "text" (a detailed line per aircraft), "table" (compact columns) or "json"
+	MaxAircraft int    `toml:"max_aircraft"` // Most aircraft listed (0 = the consumer's default)
+	TokenBudget int    `toml:"token_budget"` // Approximate tokens the aircraft may take; the rest are summarized (0 = no limit)
+}
+
+// Formats of aircraft formatting profiles
+const (
+	FormatText  = "text"
+	FormatTable = "table"
+	FormatJSON  = "json"
+)
+
+// builtinFormattingProfiles are available without configuration; a configured profile of
+// the same name replaces one
+var builtinFormattingProfiles = map[string]FormattingProfileConfig{
+	"detailed": {Format: FormatText},
+	"compact":  {Format: FormatTable},
+	"json":     {Format: FormatJSON},
+	"budget":   {Format: FormatTable, TokenBudget: 1500},
 }
@@ -0,0 +1,170 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// ValidationError lists every problem found in a configuration, so they can be fixed at once
+// rather than one startup at a time
+type ValidationError struct {
+	Problems []string
+}
+
+// Error lists the problems, one per line when there are several
+func (e *ValidationError) Error() string {
+	if len(e.Problems) == 1 {
+		return e.Problems[0]
+	}
+	return fmt.Sprintf("%d problems:\n  - %s", len(e.Problems), strings.Join(e.Problems, "\n  - "))
+}
+
+// add appends the problems of an error, those of a ValidationError one by one
+func (e *ValidationError) add(err error) {
+	var validationErr *ValidationError
+	switch {
+	case err == nil:
+	case errors.As(err, &validationErr):
+		e.Problems = append(e.Problems, validationErr.Problems...)
+	default:
+		e.Problems = append(e.Problems, err.Error())
+	}
+}
+
+// err returns the error, or nil when there are no problems
+func (e *ValidationError) err() error {
+	if len(e.Problems) == 0 {
+		return nil
+	}
+	return e
+}
+
+// transcribes reports whether any frequency is transcribed with the provider's API key set
+func (c *Config) transcribes() bool {
+	if c.Transcription.Provider == "deepgram" {
+		if c.Transcription.Deepgram.APIKey == "" {
+			return false
+		}
+	} else if c.Transcription.OpenAIAPIKey == "" {
+		return false
+	}
+	for _, freq := range c.Frequencies.Sources {
+		if freq.TranscribeAudio {
+			return true
+		}
+	}
+	return false
+}
+
+// aacFrequencies returns the IDs of the frequencies whose stream URL names an AAC file, which
+// the builtin decoder hands to FFmpeg
+func (c *Config) aacFrequencies() []string {
+	var ids []string
+	for _, freq := range c.Frequencies.Sources {
+		path := freq.URL
+		if u, err := url.Parse(freq.URL); err == nil {
+			path = u.Path
+		}
+		switch strings.ToLower(filepath.Ext(path)) {
+		case ".aac", ".aacp", ".adts", ".m4a":
+			ids = append(ids, freq.ID)
+		}
+	}
+	return ids
+}
+
+// ValidateDependencies checks the settings that only work together with other settings, such
+// as the external ADS-B source and its API key, the audio pipeline and FFmpeg, and the
+// runway features and the station. Every problem is reported at once, before a subsystem
+// fails on first use. Run after the sections have their defaults.
+func (c *Config) ValidateDependencies() error {
+	problems := &ValidationError{}
+	add := func(format string, args ...interface{}) {
+		problems.Problems = append(problems.Problems, fmt.Sprintf(format, args...))
+	}
+
+	// The external source is queried around the station with its own credentials
+	if c.ADSB.SourceType == "external" {
+		if c.ADSB.APIHost == "" {
+			add("[adsb] api_host is required when source_type is external")
+		}
+		if c.ADSB.APIKey == "" {
+			add("[adsb] api_key is required when source_type is external")
+		}
+	}
+
+	// FFmpeg decodes frequency audio for transcription unless the builtin decoder is used, and
+	// encodes recordings. Missing transcription keys only disable transcription, with a
+	// warning from ValidateOpenAIKeys, so transcription only needs FFmpeg when it has a key.
+	ffmpegDecodes := c.Frequencies.AudioDecoder == "ffmpeg"
+	var ffmpegUsers []string
+	if ffmpegDecodes && c.transcribes() {
+		ffmpegUsers = append(ffmpegUsers, "transcription (audio_decoder = \"ffmpeg\")")
+	}
+	if c.Recording.Enabled {
+		ffmpegUsers = append(ffmpegUsers, "[recording]")
+	}
+	ffmpegFound := c.Transcription.FFmpegPath != ""
+	if ffmpegFound {
+		_, err := exec.LookPath(c.Transcription.FFmpegPath)
+		ffmpegFound = err == nil
+	}
+	switch {
+	case len(ffmpegUsers) > 0 && c.Transcription.FFmpegPath == "":
+		add("[transcription] ffmpeg_path is required for %s", strings.Join(ffmpegUsers, " and "))
+	case len(ffmpegUsers) > 0 && !ffmpegFound:
+		add("FFmpeg not found at %q, needed for %s: install FFmpeg or set [transcription] ffmpeg_path", c.Transcription.FFmpegPath, strings.Join(ffmpegUsers, " and "))
+	case ffmpegDecodes && len(c.Frequencies.Sources) > 0 && !ffmpegFound:
+		// Frequencies that are only listened to don't stop the rest from starting
+		fmt.Printf("WARN: FFmpeg not found at %q - frequency audio won't play; install FFmpeg, set [transcription] ffmpeg_path or audio_decoder = \"builtin\"\n", c.Transcription.FFmpegPath)
+	}
+	// The builtin decoder reads MP3 but not AAC, which it hands to FFmpeg
+	if aac := c.aacFrequencies(); !ffmpegDecodes && len(aac) > 0 && !ffmpegFound {
+		add("AAC streams aren't decoded with audio_decoder = \"builtin\" and FFmpeg isn't found at %q, for frequencies %s: install FFmpeg or use MP3 streams", c.Transcription.FFmpegPath, strings.Join(aac, ", "))
+	}
+
+	// Runway and flight phase features place aircraft relative to the station and its runways
+	var stationUsers, runwayUsers []string
+	if c.ADSB.SourceType == "external" {
+		stationUsers = append(stationUsers, "the external ADS-B source")
+	}
+	if c.FlightPhases.Enabled {
+		stationUsers = append(stationUsers, "[flight_phases]")
+	}
+	for _, feature := range []struct {
+		name    string
+		enabled bool
+	}{
+		{"[approaches]", c.Approaches.Enabled},
+		{"[sequencing]", c.Sequencing.Enabled},
+		{"[movements]", c.Movements.Enabled},
+		{"[occupancy]", c.Occupancy.Enabled},
+	} {
+		if feature.enabled {
+			stationUsers = append(stationUsers, feature.name)
+			runwayUsers = append(runwayUsers, feature.name)
+		}
+	}
+	if len(stationUsers) > 0 && c.Station.Latitude == 0 && c.Station.Longitude == 0 {
+		add("[station] latitude and longitude are required for %s", strings.Join(stationUsers, ", "))
+	}
+	if len(runwayUsers) > 0 && c.Station.RunwaysDBPath == "" {
+		add("[station] runways_db_path is required for %s", strings.Join(runwayUsers, ", "))
+	}
+	if c.Station.RunwaysDBPath != "" {
+		if _, err := os.Stat(c.Station.RunwaysDBPath); err != nil {
+			add("[station] runways_db_path %s can't be read: %v", c.Station.RunwaysDBPath, err)
+		}
+		// An OurAirports runways.csv lists every airport's runways
+		if strings.EqualFold(filepath.Ext(c.Station.RunwaysDBPath), ".csv") && c.Station.AirportCode == "" {
+			add("[station] airport_code is required to read runways from an OurAirports runways.csv")
+		}
+	}
+
+	return problems.err()
+}
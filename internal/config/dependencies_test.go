@@ -0,0 +1,101 @@
+package config
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+// exampleConfig loads the example config with a static files directory that exists
+func exampleConfig(t *testing.T) *Config {
+	t.Helper()
+	cfg, err := Load("../../configs/config.toml.example")
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg.Server.StaticFilesDir = t.TempDir()
+	cfg.Transcription.FFmpegPath = "/nonexistent/ffmpeg"
+	return cfg
+}
+
+// problems returns the problems Validate reports
+func problems(t *testing.T, cfg *Config) []string {
+	t.Helper()
+	err := cfg.Validate()
+	if err == nil {
+		return nil
+	}
+	var validationErr *ValidationError
+	if !errors.As(err, &validationErr) {
+		t.Fatalf("expected a ValidationError, got %T: %v", err, err)
+	}
+	return validationErr.Problems
+}
+
+// mentions reports whether any problem contains a text
+func mentions(problems []string, text string) bool {
+	for _, problem := range problems {
+		if strings.Contains(problem, text) {
+			return true
+		}
+	}
+	return false
+}
+
+func TestValidateReportsEveryProblem(t *testing.T) {
+	cfg := exampleConfig(t)
+	cfg.Server.Port = 0
+	cfg.Storage.Type = "postgres"
+	cfg.Occupancy.Enabled = true
+	cfg.Station.Latitude, cfg.Station.Longitude = 0, 0
+
+	got := problems(t, cfg)
+	for _, want := range []string{"invalid server port", "invalid storage type", "latitude and longitude are required"} {
+		if !mentions(got, want) {
+			t.Errorf("expected a problem containing %q, got %q", want, got)
+		}
+	}
+}
+
+func TestFFmpegNotRequiredForListeningOnly(t *testing.T) {
+	cfg := exampleConfig(t)
+	cfg.Frequencies.AudioDecoder = "ffmpeg"
+	cfg.Recording.Enabled = false
+	cfg.Transcription.OpenAIAPIKey = ""
+
+	if got := problems(t, cfg); mentions(got, "FFmpeg") || mentions(got, "ffmpeg_path") {
+		t.Fatalf("FFmpeg required without transcription or recording: %q", got)
+	}
+}
+
+func TestFFmpegRequiredForRecordingAndTranscription(t *testing.T) {
+	recording := exampleConfig(t)
+	recording.Recording.Enabled = true
+	if got := problems(t, recording); !mentions(got, "FFmpeg not found") {
+		t.Errorf("expected FFmpeg to be required for recording, got %q", got)
+	}
+
+	transcription := exampleConfig(t)
+	transcription.Frequencies.AudioDecoder = "ffmpeg"
+	transcription.Transcription.Provider = "openai"
+	transcription.Transcription.OpenAIAPIKey = "sk-test"
+	transcription.Frequencies.Sources[0].TranscribeAudio = true
+	if got := problems(t, transcription); !mentions(got, "FFmpeg not found") {
+		t.Errorf("expected FFmpeg to be required for transcription, got %q", got)
+	}
+}
+
+func TestBuiltinDecoderRejectsAACWithoutFFmpeg(t *testing.T) {
+	cfg := exampleConfig(t)
+	cfg.Frequencies.AudioDecoder = "builtin"
+	cfg.Recording.Enabled = false
+	cfg.Frequencies.Sources[0].URL = "https://example.com/tower.mp3"
+	if got := problems(t, cfg); mentions(got, "AAC") {
+		t.Fatalf("MP3 stream rejected with the builtin decoder: %q", got)
+	}
+
+	cfg.Frequencies.Sources[0].URL = "https://example.com/tower.aac?token=1"
+	if got := problems(t, cfg); !mentions(got, "AAC streams aren't decoded") || !mentions(got, cfg.Frequencies.Sources[0].ID) {
+		t.Fatalf("expected the AAC stream to be rejected, got %q", got)
+	}
+}
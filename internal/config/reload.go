@@ -0,0 +1,292 @@
+package config
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/signal"
+	"reflect"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/BurntSushi/toml"
+	"github.com/yegors/co-atc/pkg/logger"
+)
+
+// RuntimeSettings contains the settings that can be changed while the server is running,
+// without restarting audio streams or dropping WebSocket clients. Keys mirror the config file.
+type RuntimeSettings struct {
+	ADSB         RuntimeADSBSettings    `toml:"adsb"`
+	FlightPhases FlightPhasesConfig     `toml:"flight_phases"`
+	Weather      RuntimeWeatherSettings `toml:"wx"`
+}
+
+// RuntimeADSBSettings contains the ADS-B settings that can be changed at runtime
+type RuntimeADSBSettings struct {
+	FetchIntervalSecs        int  `toml:"fetch_interval_seconds"`
+	SignalLostTimeoutSecs    int  `toml:"signal_lost_timeout_seconds"`
+	WebSocketAircraftUpdates bool `toml:"websocket_aircraft_updates"`
+}
+
+// RuntimeWeatherSettings contains the weather settings that can be changed at runtime
+type RuntimeWeatherSettings struct {
+	RefreshIntervalMinutes int `toml:"refresh_interval_minutes"`
+}
+
+// RuntimeSettings returns the runtime-changeable subset of the configuration
+func (c *Config) RuntimeSettings() RuntimeSettings {
+	return RuntimeSettings{
+		ADSB: RuntimeADSBSettings{
+			FetchIntervalSecs:        c.ADSB.FetchIntervalSecs,
+			SignalLostTimeoutSecs:    c.ADSB.SignalLostTimeoutSecs,
+			WebSocketAircraftUpdates: c.ADSB.WebSocketAircraftUpdates,
+		},
+		FlightPhases: c.FlightPhases,
+		Weather: RuntimeWeatherSettings{
+			RefreshIntervalMinutes: c.Weather.RefreshIntervalMinutes,
+		},
+	}
+}
+
+// SetRuntimeSettings copies runtime settings into the configuration
+func (c *Config) SetRuntimeSettings(settings RuntimeSettings) {
+	c.ADSB.FetchIntervalSecs = settings.ADSB.FetchIntervalSecs
+	c.ADSB.SignalLostTimeoutSecs = settings.ADSB.SignalLostTimeoutSecs
+	c.ADSB.WebSocketAircraftUpdates = settings.ADSB.WebSocketAircraftUpdates
+	c.FlightPhases = settings.FlightPhases
+	c.Weather.RefreshIntervalMinutes = settings.Weather.RefreshIntervalMinutes
+}
+
+// ValidateRuntime validates only the runtime-changeable settings
+func (c *Config) ValidateRuntime() error {
+	if c.ADSB.FetchIntervalSecs <= 0 {
+		return fmt.Errorf("invalid fetch interval: %d", c.ADSB.FetchIntervalSecs)
+	}
+	if c.ADSB.SignalLostTimeoutSecs < 0 {
+		return fmt.Errorf("invalid signal lost timeout: %d", c.ADSB.SignalLostTimeoutSecs)
+	}
+	if c.Weather.RefreshIntervalMinutes <= 0 {
+		return fmt.Errorf("weather refresh_interval_minutes must be greater than 0: %d", c.Weather.RefreshIntervalMinutes)
+	}
+
+	return c.ValidateFlightPhases()
+}
+
+// Map returns the settings keyed the same way as the config file, for API responses
+func (s RuntimeSettings) Map() (map[string]interface{}, error) {
+	var buf bytes.Buffer
+	if err := toml.NewEncoder(&buf).Encode(s); err != nil {
+		return nil, fmt.Errorf("failed to encode runtime settings: %w", err)
+	}
+
+	result := make(map[string]interface{})
+	if _, err := toml.Decode(buf.String(), &result); err != nil {
+		return nil, fmt.Errorf("failed to decode runtime settings: %w", err)
+	}
+
+	return result, nil
+}
+
+// Reloader keeps the runtime settings in sync with the config file and with changes
+// made through the API, and notifies subscribers when they change
+type Reloader struct {
+	path      string
+	config    Config // Last applied configuration
+	modTime   time.Time
+	listeners []func(RuntimeSettings)
+	mu        sync.RWMutex
+	logger    *logger.Logger
+}
+
+// NewReloader creates a reloader for the configuration loaded from path
+func NewReloader(path string, config *Config, logger *logger.Logger) *Reloader {
+	r := &Reloader{
+		path:   path,
+		config: *config,
+		logger: logger.Named("config"),
+	}
+
+	if info, err := os.Stat(path); err == nil {
+		r.modTime = info.ModTime()
+	}
+
+	return r
+}
+
+// Settings returns the current runtime settings
+func (r *Reloader) Settings() RuntimeSettings {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.config.RuntimeSettings()
+}
+
+// OnChange registers a function that is called with the new settings after every change
+func (r *Reloader) OnChange(fn func(RuntimeSettings)) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.listeners = append(r.listeners, fn)
+}
+
+// Reload re-reads the config file and applies its runtime settings. Changes to any
+// other setting are reported but only take effect after a restart.
+func (r *Reloader) Reload() (RuntimeSettings, error) {
+	loaded, err := Load(r.path)
+	if err != nil {
+		return RuntimeSettings{}, err
+	}
+	r.mu.RLock()
+	unchanged := r.config
+	r.mu.RUnlock()
+	// The station is only detected at startup; keep what was detected then
+	if loaded.Station.AutoDetect {
+		loaded.Station = unchanged.Station
+	}
+	if err := loaded.Validate(); err != nil {
+		return RuntimeSettings{}, fmt.Errorf("invalid configuration: %w", err)
+	}
+	unchanged.SetRuntimeSettings(loaded.RuntimeSettings())
+	if !reflect.DeepEqual(unchanged, *loaded) {
+		r.logger.Warn("Config file contains changes that require a restart; only runtime settings were applied",
+			logger.String("path", r.path))
+	}
+
+	settings := loaded.RuntimeSettings()
+	r.apply(settings)
+
+	r.logger.Info("Configuration reloaded", logger.String("path", r.path))
+	return settings, nil
+}
+
+// Patch applies a partial update of runtime settings. The patch is a JSON object using
+// the same section and key names as the config file, e.g. {"adsb": {"fetch_interval_seconds": 2}}.
+// Patched settings are not written to the config file and are replaced by the next reload.
+func (r *Reloader) Patch(patch []byte) (RuntimeSettings, error) {
+	decoder := json.NewDecoder(bytes.NewReader(patch))
+	decoder.UseNumber()
+
+	var values map[string]interface{}
+	if err := decoder.Decode(&values); err != nil {
+		return RuntimeSettings{}, fmt.Errorf("invalid JSON: %w", err)
+	}
+
+	// Round-trip through TOML so the patch is decoded with the config file's key names and types
+	var buf bytes.Buffer
+	if err := toml.NewEncoder(&buf).Encode(fromJSONNumbers(values)); err != nil {
+		return RuntimeSettings{}, fmt.Errorf("invalid settings: %w", err)
+	}
+
+	r.mu.RLock()
+	next := r.config
+	r.mu.RUnlock()
+
+	settings := next.RuntimeSettings()
+	meta, err := toml.Decode(buf.String(), &settings)
+	if err != nil {
+		return RuntimeSettings{}, fmt.Errorf("invalid settings: %w", err)
+	}
+	if undecoded := meta.Undecoded(); len(undecoded) > 0 {
+		keys := make([]string, len(undecoded))
+		for i, key := range undecoded {
+			keys[i] = key.String()
+		}
+		return RuntimeSettings{}, fmt.Errorf("settings cannot be changed at runtime: %s", strings.Join(keys, ", "))
+	}
+
+	next.SetRuntimeSettings(settings)
+	if err := next.ValidateRuntime(); err != nil {
+		return RuntimeSettings{}, err
+	}
+
+	// Validation may fill in defaults
+	settings = next.RuntimeSettings()
+	r.apply(settings)
+
+	r.logger.Info("Runtime settings updated via API")
+	return settings, nil
+}
+
+// Watch reloads the configuration on SIGHUP and whenever the config file is modified
+func (r *Reloader) Watch(ctx context.Context, pollInterval time.Duration) {
+	sighup := make(chan os.Signal, 1)
+	signal.Notify(sighup, syscall.SIGHUP)
+	defer signal.Stop(sighup)
+
+	ticker := time.NewTicker(pollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-sighup:
+			r.logger.Info("Received SIGHUP, reloading configuration")
+			if _, err := r.Reload(); err != nil {
+				r.logger.Error("Failed to reload configuration", logger.Error(err))
+			}
+		case <-ticker.C:
+			info, err := os.Stat(r.path)
+			if err != nil {
+				continue
+			}
+
+			r.mu.RLock()
+			modified := !info.ModTime().Equal(r.modTime)
+			r.mu.RUnlock()
+			if !modified {
+				continue
+			}
+
+			r.mu.Lock()
+			r.modTime = info.ModTime()
+			r.mu.Unlock()
+
+			r.logger.Info("Config file changed, reloading configuration")
+			if _, err := r.Reload(); err != nil {
+				r.logger.Error("Failed to reload configuration", logger.Error(err))
+			}
+		}
+	}
+}
+
+// apply stores the settings and notifies listeners
+func (r *Reloader) apply(settings RuntimeSettings) {
+	r.mu.Lock()
+	r.config.SetRuntimeSettings(settings)
+	listeners := make([]func(RuntimeSettings), len(r.listeners))
+	copy(listeners, r.listeners)
+	r.mu.Unlock()
+
+	for _, fn := range listeners {
+		fn(settings)
+	}
+}
+
+// fromJSONNumbers converts json.Number values to int64 or float64 so they encode as TOML numbers
+func fromJSONNumbers(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, item := range v {
+			v[key] = fromJSONNumbers(item)
+		}
+		return v
+	case []interface{}:
+		for i, item := range v {
+			v[i] = fromJSONNumbers(item)
+		}
+		return v
+	case json.Number:
+		if i, err := v.Int64(); err == nil {
+			return i
+		}
+		if f, err := v.Float64(); err == nil {
+			return f
+		}
+		return v.String()
+	default:
+		return value
+	}
+}
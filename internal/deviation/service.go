@@ -0,0 +1,393 @@
+package deviation
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/yegors/co-atc/internal/adsb"
+	"github.com/yegors/co-atc/internal/config"
+	"github.com/yegors/co-atc/internal/storage/sqlite"
+	"github.com/yegors/co-atc/internal/websocket"
+	"github.com/yegors/co-atc/pkg/logger"
+)
+
+// Clearance types that are monitored
+const (
+	TypeAltitude = "altitude"
+	TypeHeading  = "heading"
+)
+
+// Clearance statuses set by monitoring
+const (
+	StatusComplied  = "complied"
+	StatusDeviation = "deviation"
+)
+
+const (
+	// clearancePollInterval is how often newly extracted clearances are picked up
+	clearancePollInterval = 5 * time.Second
+	// maxEvents is how many recent deviations are kept for review
+	maxEvents = 200
+)
+
+// Event is a possible deviation from an altitude or heading clearance
+type Event struct {
+	ClearanceID     int64     `json:"clearance_id"`
+	TranscriptionID int64     `json:"transcription_id"` // Transcription the clearance was extracted from
+	Hex             string    `json:"hex"`
+	Callsign        string    `json:"callsign"`
+	ClearanceType   string    `json:"clearance_type"` // "altitude" or "heading"
+	ClearanceText   string    `json:"clearance_text"`
+	Assigned        int       `json:"assigned"`           // Assigned altitude (ft) or magnetic heading
+	Observed        float64   `json:"observed"`           // Altitude or heading when the deviation was detected
+	Selected        *float64  `json:"selected,omitempty"` // Altitude or heading selected on the autopilot, if transmitted
+	Reason          string    `json:"reason"`
+	IssuedAt        time.Time `json:"issued_at"`
+	DetectedAt      time.Time `json:"detected_at"`
+	CorrelationID   string    `json:"correlation_id,omitempty"` // Correlation ID of the transmission the clearance came from
+}
+
+// AlertNotifier receives deviations that should reach users outside the web UI
+type AlertNotifier interface {
+	NotifyAlert(alertType, title, body string, data map[string]interface{})
+}
+
+// watch is a clearance being monitored
+type watch struct {
+	clearance *sqlite.ClearanceRecord
+	hex       string
+	deadline  time.Time // End of the response window
+	expires   time.Time // End of monitoring
+	started   bool      // Seen at least once since the clearance
+	initial   float64   // Difference from the assignment when first seen
+	captured  bool      // Reached the assignment
+}
+
+// sample is what monitoring needs from an aircraft in a poll cycle
+type sample struct {
+	altitude    float64
+	heading     float64
+	hasHeading  bool
+	selectedAlt float64
+	selectedHdg float64
+	onGround    bool
+	flight      string
+}
+
+// Service watches whether aircraft follow the altitude and heading clearances extracted from
+// transcriptions, and raises possible deviations for review
+type Service struct {
+	config           config.DeviationsConfig
+	adsbService      *adsb.Service
+	clearanceStorage *sqlite.ClearanceStorage
+	wsServer         *websocket.Server
+	notifier         AlertNotifier
+	logger           *logger.Logger
+
+	samples chan map[string]sample
+
+	// Monitoring state, only used by the worker goroutine
+	watches       map[string]*watch // By hex and clearance type
+	lastClearance int64             // Highest clearance ID picked up
+
+	events   []Event // Most recent last
+	eventsMu sync.RWMutex
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// NewService creates a new deviation monitoring service
+func NewService(cfg config.DeviationsConfig, adsbService *adsb.Service, clearanceStorage *sqlite.ClearanceStorage, wsServer *websocket.Server, logger *logger.Logger) *Service {
+	return &Service{
+		config:           cfg,
+		adsbService:      adsbService,
+		clearanceStorage: clearanceStorage,
+		wsServer:         wsServer,
+		logger:           logger.Named("deviation"),
+		samples:          make(chan map[string]sample, 4),
+		watches:          make(map[string]*watch),
+	}
+}
+
+// SetAlertNotifier sets the notifier that receives deviations. Must be called before Start.
+func (s *Service) SetAlertNotifier(notifier AlertNotifier) {
+	s.notifier = notifier
+}
+
+// Start starts watching clearances issued from now on and within the last monitoring period
+func (s *Service) Start(ctx context.Context) {
+	s.ctx, s.cancel = context.WithCancel(ctx)
+
+	s.wg.Add(1)
+	go s.run()
+
+	s.adsbService.OnUpdate(s.handleUpdate)
+
+	s.logger.Info("Deviation monitoring started",
+		logger.Int("response_window_seconds", s.config.ResponseWindowSeconds),
+		logger.Int("monitor_minutes", s.config.MonitorMinutes),
+		logger.Int("altitude_tolerance_feet", s.config.AltitudeToleranceFeet),
+		logger.Int("heading_tolerance_deg", s.config.HeadingToleranceDeg))
+}
+
+// Stop stops monitoring
+func (s *Service) Stop() {
+	if s.cancel != nil {
+		s.cancel()
+	}
+	s.wg.Wait()
+}
+
+// Events returns the possible deviations detected since startup, most recent first
+func (s *Service) Events(limit int) []Event {
+	s.eventsMu.RLock()
+	defer s.eventsMu.RUnlock()
+
+	events := make([]Event, 0, min(limit, len(s.events)))
+	for i := len(s.events) - 1; i >= 0 && len(events) < limit; i-- {
+		events = append(events, s.events[i])
+	}
+	return events
+}
+
+// handleUpdate hands the aircraft of a poll cycle to the worker without blocking polling
+func (s *Service) handleUpdate(aircraft []*adsb.Aircraft) {
+	samples := make(map[string]sample, len(aircraft))
+	declination := s.adsbService.Declination()
+	for _, a := range aircraft {
+		if a.ADSB == nil {
+			continue
+		}
+		smp := sample{
+			altitude:    a.ADSB.AltBaro,
+			selectedAlt: a.ADSB.NavAltitudeMCP,
+			selectedHdg: a.ADSB.NavHeading,
+			onGround:    a.OnGround,
+			flight:      strings.TrimSpace(a.Flight),
+		}
+		// Clearances assign magnetic headings; a true heading or track is converted when no
+		// magnetic heading is sent
+		if heading := adsb.MagneticHeading(a.ADSB, declination); heading != 0 {
+			smp.heading, smp.hasHeading = heading, true
+		}
+		samples[strings.ToLower(a.Hex)] = smp
+	}
+
+	select {
+	case s.samples <- samples:
+	default:
+		s.logger.Debug("Deviation worker busy, skipping poll cycle")
+	}
+}
+
+// run picks up new clearances and checks poll cycles until the service stops
+func (s *Service) run() {
+	defer s.wg.Done()
+
+	ticker := time.NewTicker(clearancePollInterval)
+	defer ticker.Stop()
+
+	s.loadClearances(time.Now().UTC())
+	for {
+		select {
+		case <-s.ctx.Done():
+			return
+		case <-ticker.C:
+			s.loadClearances(time.Now().UTC())
+		case samples := <-s.samples:
+			s.check(samples, time.Now().UTC())
+		}
+	}
+}
+
+// loadClearances starts watching the altitude and heading clearances extracted since the
+// last load. A newer clearance of the same type replaces the one being watched.
+func (s *Service) loadClearances(now time.Time) {
+	monitor := time.Duration(s.config.MonitorMinutes) * time.Minute
+	clearances, err := s.clearanceStorage.GetIssuedClearances([]string{TypeAltitude, TypeHeading}, now.Add(-monitor), s.lastClearance)
+	if err != nil {
+		s.logger.Error("Failed to load clearances", logger.Error(err))
+		return
+	}
+
+	for _, clearance := range clearances {
+		s.lastClearance = max(s.lastClearance, clearance.ID)
+
+		hex := clearance.AircraftHex
+		if hex == "" {
+			// Extracted before the aircraft was tracked, or under another form of its callsign
+			entry, ok := s.adsbService.Callsigns().Correlate(clearance.Callsign)
+			if !ok {
+				continue
+			}
+			hex = entry.Hex
+		}
+		if (clearance.ClearanceType == TypeAltitude && clearance.Altitude <= 0) ||
+			(clearance.ClearanceType == TypeHeading && clearance.Heading <= 0) {
+			continue
+		}
+
+		s.watches[watchKey(hex, clearance.ClearanceType)] = &watch{
+			clearance: clearance,
+			hex:       hex,
+			deadline:  clearance.Timestamp.Add(time.Duration(s.config.ResponseWindowSeconds) * time.Second),
+			expires:   clearance.Timestamp.Add(monitor),
+		}
+	}
+}
+
+// check compares the aircraft of a poll cycle with the clearances being watched
+func (s *Service) check(samples map[string]sample, now time.Time) {
+	for key, w := range s.watches {
+		if now.After(w.expires) {
+			delete(s.watches, key)
+			continue
+		}
+		smp, ok := samples[w.hex]
+		if !ok || smp.onGround {
+			continue
+		}
+
+		var observed, selected, diff, tolerance float64
+		var hasSelected bool
+		noun := "altitude"
+		switch w.clearance.ClearanceType {
+		case TypeAltitude:
+			observed, selected, hasSelected = smp.altitude, smp.selectedAlt, smp.selectedAlt != 0
+			diff = math.Abs(observed - float64(w.clearance.Altitude))
+			tolerance = float64(s.config.AltitudeToleranceFeet)
+		case TypeHeading:
+			if !smp.hasHeading {
+				continue
+			}
+			observed, selected, hasSelected = smp.heading, smp.selectedHdg, smp.selectedHdg != 0
+			diff = adsb.HeadingDifference(observed, float64(w.clearance.Heading))
+			tolerance = float64(s.config.HeadingToleranceDeg)
+			noun = "heading"
+		}
+
+		if !w.started {
+			w.started = true
+			w.initial = diff
+		}
+
+		var reason string
+		switch {
+		case diff <= tolerance:
+			if !w.captured {
+				w.captured = true
+				s.setStatus(w.clearance, StatusComplied)
+			}
+			continue
+		case w.captured:
+			reason = fmt.Sprintf("left the assigned %s", noun)
+		case diff > w.initial+tolerance:
+			reason = fmt.Sprintf("moving away from the assigned %s", noun)
+		case now.After(w.deadline) && w.initial-diff < tolerance:
+			reason = fmt.Sprintf("not moving toward the assigned %s after %ds", noun, s.config.ResponseWindowSeconds)
+		default:
+			continue
+		}
+
+		delete(s.watches, key)
+		event := Event{
+			ClearanceID:     w.clearance.ID,
+			TranscriptionID: w.clearance.TranscriptionID,
+			Hex:             w.hex,
+			Callsign:        w.clearance.Callsign,
+			ClearanceType:   w.clearance.ClearanceType,
+			ClearanceText:   w.clearance.ClearanceText,
+			Assigned:        w.clearance.Altitude,
+			Observed:        math.Round(observed),
+			Reason:          reason,
+			IssuedAt:        w.clearance.Timestamp,
+			DetectedAt:      now,
+			CorrelationID:   w.clearance.CorrelationID,
+		}
+		if w.clearance.ClearanceType == TypeHeading {
+			event.Assigned = w.clearance.Heading
+		}
+		if event.Callsign == "" {
+			event.Callsign = smp.flight
+		}
+		if hasSelected {
+			event.Selected = &selected
+		}
+		s.raise(event)
+	}
+}
+
+// raise records a possible deviation and tells the UI and notifier about it
+func (s *Service) raise(event Event) {
+	s.setStatus(&sqlite.ClearanceRecord{ID: event.ClearanceID}, StatusDeviation)
+
+	s.eventsMu.Lock()
+	s.events = append(s.events, event)
+	if len(s.events) > maxEvents {
+		s.events = s.events[len(s.events)-maxEvents:]
+	}
+	s.eventsMu.Unlock()
+
+	s.logger.WithCorrelationID(event.CorrelationID).Warn("Possible deviation from clearance",
+		logger.String("hex", event.Hex),
+		logger.String("callsign", event.Callsign),
+		logger.String("type", event.ClearanceType),
+		logger.Int("assigned", event.Assigned),
+		logger.Float64("observed", event.Observed),
+		logger.String("reason", event.Reason))
+
+	data := map[string]interface{}{
+		"clearance_id":     event.ClearanceID,
+		"transcription_id": event.TranscriptionID,
+		"hex":              event.Hex,
+		"callsign":         event.Callsign,
+		"clearance_type":   event.ClearanceType,
+		"clearance_text":   event.ClearanceText,
+		"assigned":         event.Assigned,
+		"observed":         event.Observed,
+		"reason":           event.Reason,
+		"issued_at":        event.IssuedAt,
+		"detected_at":      event.DetectedAt,
+		"correlation_id":   event.CorrelationID,
+	}
+	if event.Selected != nil {
+		data["selected"] = *event.Selected
+	}
+
+	if s.wsServer != nil {
+		s.wsServer.Broadcast(&websocket.Message{
+			Type: "deviation_alert",
+			Data: data,
+		})
+	}
+
+	if s.notifier != nil {
+		s.notifier.NotifyAlert(
+			"deviation",
+			fmt.Sprintf("Possible deviation: %s", event.Callsign),
+			fmt.Sprintf("%s %s (cleared: %s)", event.Callsign, event.Reason, event.ClearanceText),
+			data,
+		)
+	}
+}
+
+// setStatus stores the status monitoring found a clearance in
+func (s *Service) setStatus(clearance *sqlite.ClearanceRecord, status string) {
+	if err := s.clearanceStorage.UpdateClearanceStatus(clearance.ID, status); err != nil {
+		s.logger.Error("Failed to update clearance status",
+			logger.Int64("clearance_id", clearance.ID),
+			logger.String("status", status),
+			logger.Error(err))
+	}
+}
+
+// watchKey identifies the clearance of a type being watched for an aircraft
+func watchKey(hex, clearanceType string) string {
+	return hex + "/" + clearanceType
+}
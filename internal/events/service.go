@@ -0,0 +1,162 @@
+package events
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/yegors/co-atc/internal/notify"
+	"github.com/yegors/co-atc/internal/storage/sqlite"
+	"github.com/yegors/co-atc/internal/websocket"
+	"github.com/yegors/co-atc/pkg/logger"
+)
+
+// Severities of events, lowest first
+const (
+	SeverityInfo     = "info"
+	SeverityWarning  = "warning"
+	SeverityCritical = "critical"
+)
+
+// Severities lists the severities, lowest first
+var Severities = []string{SeverityInfo, SeverityWarning, SeverityCritical}
+
+// ErrInvalidSeverity is returned for a severity that isn't one of Severities
+var ErrInvalidSeverity = errors.New("invalid severity")
+
+// MessageTypeEvent is the WebSocket message sent for every event recorded
+const MessageTypeEvent = "event"
+
+// typeSeverity is the severity of each alert type; other types are info
+var typeSeverity = map[string]string{
+	notify.EventEmergency: SeverityCritical,
+	notify.EventConflict:  SeverityCritical,
+	notify.EventRunway:    SeverityCritical,
+	notify.EventGoAround:  SeverityWarning,
+	notify.EventDeviation: SeverityWarning,
+	notify.EventTerrain:   SeverityCritical,
+	notify.EventUnstable:  SeverityWarning,
+	notify.EventNoise:     SeverityInfo,
+	notify.EventWatchlist: SeverityInfo,
+}
+
+// Service records the alerts raised by every service as events, giving a single timeline
+// of emergencies, conflicts, runway incursions, go-arounds, deviations, terrain warnings,
+// unstable approaches, noise violations and watchlist hits
+type Service struct {
+	storage  *sqlite.EventStorage
+	wsServer *websocket.Server
+	logger   *logger.Logger
+}
+
+// NewService creates a new event service
+func NewService(storage *sqlite.EventStorage, wsServer *websocket.Server, logger *logger.Logger) *Service {
+	return &Service{
+		storage:  storage,
+		wsServer: wsServer,
+		logger:   logger.Named("events"),
+	}
+}
+
+// NotifyAlert records an alert as an event and sends it to WebSocket clients; used as one
+// of the alert notifiers. The aircraft and transcriptions involved are taken from the
+// alert's data, and a "severity" in the data overrides the type's severity.
+func (s *Service) NotifyAlert(alertType, title, body string, data map[string]interface{}) {
+	if alertType == notify.EventTest {
+		return
+	}
+
+	event := &sqlite.EventRecord{
+		Type:             alertType,
+		Severity:         severityOf(alertType, data),
+		Title:            title,
+		Body:             body,
+		Aircraft:         make([]string, 0),
+		Callsigns:        make([]string, 0),
+		TranscriptionIDs: make([]int64, 0),
+		Data:             data,
+		CreatedAt:        time.Now().UTC(),
+	}
+	for _, key := range []string{"hex", "other_hex"} {
+		if hex, ok := data[key].(string); ok && hex != "" {
+			event.Aircraft = appendUnique(event.Aircraft, strings.ToLower(hex))
+		}
+	}
+	for _, key := range []string{"callsign", "flight", "other_callsign"} {
+		if callsign, ok := data[key].(string); ok && strings.TrimSpace(callsign) != "" {
+			event.Callsigns = appendUnique(event.Callsigns, strings.ToUpper(strings.TrimSpace(callsign)))
+		}
+	}
+	if id := toInt64(data["transcription_id"]); id > 0 {
+		event.TranscriptionIDs = append(event.TranscriptionIDs, id)
+	}
+
+	if err := s.storage.StoreEvent(event); err != nil {
+		s.logger.Error("Failed to store event",
+			logger.String("type", alertType),
+			logger.Error(err))
+		return
+	}
+
+	if s.wsServer != nil {
+		s.wsServer.Broadcast(&websocket.Message{
+			Type: MessageTypeEvent,
+			Data: map[string]interface{}{
+				"event": event,
+			},
+		})
+	}
+}
+
+// Events returns the recorded events matching a filter, most recent first
+func (s *Service) Events(filter sqlite.EventFilter) ([]*sqlite.EventRecord, error) {
+	return s.storage.GetEvents(filter)
+}
+
+// SeveritiesFrom returns the severities at or above a minimum severity
+func SeveritiesFrom(minimum string) ([]string, error) {
+	for i, severity := range Severities {
+		if severity == minimum {
+			return Severities[i:], nil
+		}
+	}
+	return nil, ErrInvalidSeverity
+}
+
+// severityOf returns the severity of an alert: the one in its data if valid, else its type's
+func severityOf(alertType string, data map[string]interface{}) string {
+	if severity, ok := data["severity"].(string); ok {
+		for _, known := range Severities {
+			if severity == known {
+				return severity
+			}
+		}
+	}
+	if severity, ok := typeSeverity[alertType]; ok {
+		return severity
+	}
+	return SeverityInfo
+}
+
+// appendUnique appends an item to a list that doesn't contain it yet
+func appendUnique(list []string, item string) []string {
+	for _, existing := range list {
+		if existing == item {
+			return list
+		}
+	}
+	return append(list, item)
+}
+
+// toInt64 converts a numeric alert value to an int64, or 0 if it isn't one
+func toInt64(value interface{}) int64 {
+	switch v := value.(type) {
+	case int64:
+		return v
+	case int:
+		return int64(v)
+	case float64:
+		return int64(v)
+	}
+	return 0
+}
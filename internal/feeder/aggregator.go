@@ -0,0 +1,163 @@
+package feeder
+
+import (
+	"context"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/yegors/co-atc/internal/config"
+	"github.com/yegors/co-atc/pkg/logger"
+)
+
+const (
+	queueSize        = 4096 // Chunks waiting to be sent to an aggregator
+	dialTimeout      = 10 * time.Second
+	writeTimeout     = 10 * time.Second
+	minReconnectWait = 2 * time.Second
+	maxReconnectWait = 2 * time.Minute
+)
+
+// AggregatorStatus reports the feed of one aggregator
+type AggregatorStatus struct {
+	Name           string     `json:"name"`
+	Address        string     `json:"address"`
+	Protocol       string     `json:"protocol"`
+	Enabled        bool       `json:"enabled"`
+	Connected      bool       `json:"connected"`
+	ConnectedSince *time.Time `json:"connected_since,omitempty"`
+	Reconnects     int        `json:"reconnects"`
+	LastError      string     `json:"last_error,omitempty"`
+	LastErrorAt    *time.Time `json:"last_error_at,omitempty"`
+	Sent           int64      `json:"sent"`    // Beast frames or aircraft positions sent
+	Dropped        int64      `json:"dropped"` // Not sent while disconnected or falling behind
+	BytesSent      int64      `json:"bytes_sent"`
+	LastSentAt     *time.Time `json:"last_sent_at,omitempty"`
+}
+
+// chunk is data for an aggregator and how many frames or positions it holds
+type chunk struct {
+	data  []byte
+	count int
+}
+
+// aggregator keeps a connection to an aggregator's feed input and sends it what's queued
+type aggregator struct {
+	cfg    config.FeederAggregatorConfig
+	queue  chan chunk
+	logger *logger.Logger
+
+	mu     sync.Mutex
+	status AggregatorStatus
+}
+
+// newAggregator creates the feed of an aggregator. Nothing connects until run.
+func newAggregator(cfg config.FeederAggregatorConfig, log *logger.Logger) *aggregator {
+	return &aggregator{
+		cfg:    cfg,
+		queue:  make(chan chunk, queueSize),
+		logger: log.With(logger.String("aggregator", cfg.Name)),
+		status: AggregatorStatus{
+			Name:     cfg.Name,
+			Address:  cfg.Address,
+			Protocol: cfg.Protocol,
+			Enabled:  cfg.Enabled,
+		},
+	}
+}
+
+// send queues data for the aggregator without blocking, dropping it if the queue is full
+func (a *aggregator) send(c chunk) {
+	select {
+	case a.queue <- c:
+	default:
+		a.mu.Lock()
+		a.status.Dropped += int64(c.count)
+		a.mu.Unlock()
+	}
+}
+
+// run keeps a connection open and sends the queue over it until the context ends
+func (a *aggregator) run(ctx context.Context) {
+	wait := minReconnectWait
+	for {
+		dialer := net.Dialer{Timeout: dialTimeout}
+		conn, err := dialer.DialContext(ctx, "tcp", a.cfg.Address)
+		if err == nil {
+			wait = minReconnectWait
+			err = a.serve(ctx, conn)
+		}
+		if ctx.Err() != nil {
+			return
+		}
+
+		now := time.Now().UTC()
+		a.mu.Lock()
+		a.status.LastError = err.Error()
+		a.status.LastErrorAt = &now
+		a.status.Reconnects++
+		a.mu.Unlock()
+		a.logger.Warn("Aggregator feed failed",
+			logger.String("address", a.cfg.Address),
+			logger.Duration("retry_in", wait),
+			logger.Error(err))
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(wait):
+		}
+		wait = min(wait*2, maxReconnectWait)
+	}
+}
+
+// serve sends the queue over a connection until a write fails or the context ends. What
+// was queued while disconnected is stale and dropped first.
+func (a *aggregator) serve(ctx context.Context, conn net.Conn) error {
+	defer conn.Close()
+
+	now := time.Now().UTC()
+	a.mu.Lock()
+	for len(a.queue) > 0 {
+		a.status.Dropped += int64((<-a.queue).count)
+	}
+	a.status.Connected = true
+	a.status.ConnectedSince = &now
+	a.mu.Unlock()
+	a.logger.Info("Connected to aggregator", logger.String("address", a.cfg.Address))
+
+	defer func() {
+		a.mu.Lock()
+		a.status.Connected = false
+		a.status.ConnectedSince = nil
+		a.mu.Unlock()
+	}()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case c := <-a.queue:
+			conn.SetWriteDeadline(time.Now().Add(writeTimeout))
+			if _, err := conn.Write(c.data); err != nil {
+				a.mu.Lock()
+				a.status.Dropped += int64(c.count)
+				a.mu.Unlock()
+				return err
+			}
+			sentAt := time.Now().UTC()
+			a.mu.Lock()
+			a.status.Sent += int64(c.count)
+			a.status.BytesSent += int64(len(c.data))
+			a.status.LastSentAt = &sentAt
+			a.mu.Unlock()
+		}
+	}
+}
+
+// currentStatus returns a copy of the aggregator's status
+func (a *aggregator) currentStatus() AggregatorStatus {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.status
+}
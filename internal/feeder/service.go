@@ -0,0 +1,158 @@
+package feeder
+
+import (
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/yegors/co-atc/internal/adsb"
+	"github.com/yegors/co-atc/internal/config"
+	"github.com/yegors/co-atc/internal/modes"
+	"github.com/yegors/co-atc/pkg/logger"
+)
+
+// beastReceiverID is the Beast frame type carrying the feeder's receiver ID
+const beastReceiverID = 0xE3
+
+// locallyReceived are the ADS-B source types fed to aggregators. Aircraft from the external
+// API came from an aggregator in the first place; simulated and replayed ones have none.
+var locallyReceived = map[string]bool{"local": true, "raw": true, "uat": true}
+
+// jsonPosition is an aircraft of a poll cycle as sent with the json protocol
+type jsonPosition struct {
+	Now float64 `json:"now"`
+	*adsb.ADSBTarget
+}
+
+// Service feeds locally received aircraft to community aggregators: Beast frames from the
+// raw receiver as they arrive, or each poll cycle's aircraft as JSON lines
+type Service struct {
+	cfg         config.FeederConfig
+	adsbService *adsb.Service
+	raw         *adsb.RawReceiver // Source of Beast frames; nil unless source_type = "raw"
+	logger      *logger.Logger
+
+	aggregators []*aggregator
+	beast       []*aggregator
+	json        []*aggregator
+	receiverID  []byte // Beast receiver ID frame sent before every frame; nil without a uuid
+
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// NewService creates a feeder. raw is the receiver of the "raw" source, or nil for the others.
+func NewService(cfg config.FeederConfig, adsbService *adsb.Service, raw *adsb.RawReceiver, logger *logger.Logger) *Service {
+	s := &Service{
+		cfg:         cfg,
+		adsbService: adsbService,
+		raw:         raw,
+		logger:      logger.Named("feeder"),
+	}
+
+	for _, aggregatorCfg := range cfg.Aggregators {
+		a := newAggregator(aggregatorCfg, s.logger)
+		s.aggregators = append(s.aggregators, a)
+		if !aggregatorCfg.Enabled {
+			continue
+		}
+		if aggregatorCfg.Protocol == "json" {
+			s.json = append(s.json, a)
+		} else {
+			s.beast = append(s.beast, a)
+		}
+	}
+
+	if id := strings.ReplaceAll(cfg.UUID, "-", ""); len(id) >= 16 {
+		if b, err := hex.DecodeString(id[:16]); err == nil {
+			s.receiverID = modes.AppendBeastEscaped([]byte{0x1A, beastReceiverID}, b)
+		}
+	}
+	return s
+}
+
+// Start connects to the enabled aggregators and starts feeding them. Must be called before
+// the raw receiver starts.
+func (s *Service) Start(ctx context.Context) {
+	ctx, s.cancel = context.WithCancel(ctx)
+
+	for _, a := range s.aggregators {
+		if !a.cfg.Enabled {
+			continue
+		}
+		s.wg.Add(1)
+		go func(a *aggregator) {
+			defer s.wg.Done()
+			a.run(ctx)
+		}(a)
+	}
+
+	if len(s.beast) > 0 && s.raw != nil {
+		s.raw.OnFrame(s.handleFrame)
+	}
+	if len(s.json) > 0 {
+		s.adsbService.OnUpdate(s.handleUpdate)
+	}
+
+	s.logger.Info("Feeder started",
+		logger.Int("beast_aggregators", len(s.beast)),
+		logger.Int("json_aggregators", len(s.json)))
+}
+
+// Stop disconnects from the aggregators
+func (s *Service) Stop() {
+	if s.cancel != nil {
+		s.cancel()
+	}
+	s.wg.Wait()
+	s.logger.Info("Feeder stopped")
+}
+
+// Status returns the feed of every configured aggregator, in configuration order
+func (s *Service) Status() []AggregatorStatus {
+	statuses := make([]AggregatorStatus, 0, len(s.aggregators))
+	for _, a := range s.aggregators {
+		statuses = append(statuses, a.currentStatus())
+	}
+	return statuses
+}
+
+// handleFrame queues a frame accepted by the raw receiver for the Beast aggregators
+func (s *Service) handleFrame(frame modes.Frame) {
+	data := make([]byte, 0, len(s.receiverID)+2*(9+len(frame.Data)))
+	data = append(data, s.receiverID...)
+	data = append(data, modes.EncodeBeast(frame)...)
+	for _, a := range s.beast {
+		a.send(chunk{data: data, count: 1})
+	}
+}
+
+// handleUpdate queues the locally received aircraft of a poll cycle for the JSON aggregators,
+// one JSON object per line
+func (s *Service) handleUpdate(aircraft []*adsb.Aircraft) {
+	now := float64(time.Now().UnixMilli()) / 1000
+	var data []byte
+	count := 0
+	for _, a := range aircraft {
+		if a == nil || a.ADSB == nil || a.IsSimulated || !locallyReceived[a.ADSB.SourceType] {
+			continue
+		}
+		line, err := json.Marshal(jsonPosition{Now: now, ADSBTarget: a.ADSB})
+		if err != nil {
+			s.logger.Debug("Failed to encode aircraft for feeding", logger.String("hex", a.Hex), logger.Error(err))
+			continue
+		}
+		data = append(append(data, line...), '\n')
+		count++
+	}
+	if count == 0 {
+		return
+	}
+
+	for _, a := range s.json {
+		a.send(chunk{data: data, count: count})
+	}
+}
@@ -10,6 +10,7 @@ import (
 	"strings"
 	"time"
 
+	"github.com/yegors/co-atc/internal/audio"
 	"github.com/yegors/co-atc/pkg/logger"
 )
 
@@ -134,6 +135,66 @@ func (c *Client) StreamAudio(ctx context.Context, opts StreamOptions) (io.ReadCl
 	return readCloser, resp.Header, nil
 }
 
+// hlsProbeTimeout bounds how long DetectStreamProtocol waits for a stream to answer
+const hlsProbeTimeout = 5 * time.Second
+
+// hlsContentTypes are the content types HLS playlists are served with
+var hlsContentTypes = []string{
+	"application/vnd.apple.mpegurl",
+	"application/x-mpegurl",
+	"audio/mpegurl",
+	"audio/x-mpegurl",
+}
+
+// DetectStreamProtocol works out how ffmpeg should read a stream URL. HTTP URLs that
+// don't end in .m3u8 are fetched to see whether they serve an HLS playlist, since many
+// feed providers hand out extensionless or tokenized playlist URLs. Detection failures
+// fall back to a plain HTTP stream.
+func (c *Client) DetectStreamProtocol(ctx context.Context, rawURL string) audio.StreamProtocol {
+	source, err := audio.ParseSource(rawURL)
+	if err != nil || source.Type != audio.SourceStream {
+		return ""
+	}
+	if source.Protocol != audio.StreamHTTP {
+		return source.Protocol
+	}
+	if !strings.HasPrefix(rawURL, "http://") && !strings.HasPrefix(rawURL, "https://") {
+		return source.Protocol
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, hlsProbeTimeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, rawURL, nil)
+	if err != nil {
+		return audio.StreamHTTP
+	}
+	req.Header.Set("Accept", "*/*")
+	req.Header.Set("User-Agent", "Co-ATC/1.0")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		c.logger.Debug("Failed to probe audio stream, assuming HTTP stream",
+			logger.String("url", rawURL),
+			logger.Error(err),
+		)
+		return audio.StreamHTTP
+	}
+	defer resp.Body.Close()
+
+	if c.ExtractMetadata(resp.Header).Format == "hls" {
+		return audio.StreamHLS
+	}
+
+	// Some servers send playlists as text/plain or application/octet-stream
+	head, _ := bufio.NewReaderSize(resp.Body, 16).Peek(len("#EXTM3U"))
+	if string(head) == "#EXTM3U" {
+		return audio.StreamHLS
+	}
+
+	return audio.StreamHTTP
+}
+
 // bufferedReadCloser combines a buffered reader with a closer
 type bufferedReadCloser struct {
 	Reader *bufio.Reader
@@ -168,7 +229,15 @@ func (c *Client) ExtractMetadata(headers http.Header) StreamMetadata {
 	}
 
 	// Set format based on content type
-	switch metadata.ContentType {
+	contentType := strings.ToLower(strings.TrimSpace(strings.Split(metadata.ContentType, ";")[0]))
+	for _, hlsType := range hlsContentTypes {
+		if contentType == hlsType {
+			metadata.Format = "hls"
+			return metadata
+		}
+	}
+
+	switch contentType {
 	case "audio/mpeg":
 		metadata.Format = "mp3"
 	case "audio/aac":
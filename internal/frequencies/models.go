@@ -2,25 +2,122 @@ package frequencies
 
 import (
 	"context"
+	"errors"
 	"io"
 	"time"
+
+	"github.com/yegors/co-atc/internal/audio"
+	cfg "github.com/yegors/co-atc/internal/config"
+)
+
+var (
+	// ErrFrequencyNotFound is returned when a frequency ID is not configured
+	ErrFrequencyNotFound = errors.New("frequency not found")
+	// ErrFrequencyExists is returned when adding a frequency whose ID is already in use
+	ErrFrequencyExists = errors.New("frequency already exists")
+	// ErrInvalidFrequency is returned when a frequency fails validation
+	ErrInvalidFrequency = errors.New("invalid frequency")
+	// ErrRecordingDisabled is returned when recordings are requested but recording is not enabled
+	ErrRecordingDisabled = errors.New("recording is disabled")
+	// ErrRecordingNotFound is returned when a recording segment does not exist
+	ErrRecordingNotFound = errors.New("recording not found")
+	// ErrListenerNotFound is returned when a client isn't listening to a frequency
+	ErrListenerNotFound = errors.New("listener not found")
+	// ErrStereoPairNotFound is returned when a stereo pair ID is not configured
+	ErrStereoPairNotFound = errors.New("stereo pair not found")
+)
+
+// ListenerInfo describes who opened an audio stream
+type ListenerInfo struct {
+	RemoteAddr string
+	UserAgent  string
+	Format     string // wav, webm or ogg
+	Mix        string // Mixed stream the frequency is part of: "scanner" or "stereo" (empty for its own stream)
+}
+
+// Mixed streams a listener can read a frequency through
+const (
+	MixScanner = "scanner"
+	MixStereo  = "stereo"
 )
 
+// Listener is a client connected to a frequency's audio stream
+type Listener struct {
+	ClientID    string    `json:"client_id"`
+	FrequencyID string    `json:"frequency_id"`
+	RemoteAddr  string    `json:"remote_addr"`
+	UserAgent   string    `json:"user_agent,omitempty"`
+	Format      string    `json:"format"`
+	Mix         string    `json:"mix,omitempty"` // Listening through a mixed stream: "scanner" or "stereo"
+	ConnectedAt time.Time `json:"connected_at"`
+	BytesServed int64     `json:"bytes_served"` // PCM audio read by the listener, before any Opus encoding
+	LagSeconds  float64   `json:"lag_seconds"`  // How far the listener is behind live audio
+}
+
+// StereoPair is a configured pair of frequencies streamed in stereo
+type StereoPair struct {
+	ID        string `json:"id"`
+	Name      string `json:"name"`
+	Left      string `json:"left"`
+	Right     string `json:"right"`
+	StreamURL string `json:"stream_url"` // Relative URL of the stereo stream
+}
+
 // Frequency represents a monitored ATC frequency
 type Frequency struct {
-	ID              string    `json:"id"`
-	Airport         string    `json:"airport"`
-	Name            string    `json:"name"`
-	FrequencyMHz    float64   `json:"frequency_mhz"`
-	URL             string    `json:"url"`
-	Status          string    `json:"status"` // "active", "connecting", "error"
-	LastError       string    `json:"last_error,omitempty"`
-	Bitrate         int       `json:"bitrate,omitempty"`
-	Format          string    `json:"format,omitempty"`
-	StreamURL       string    `json:"stream_url"` // Relative URL to stream from our server
-	LastActive      time.Time `json:"last_active,omitempty"`
-	Order           int       `json:"order"`            // Order for display/sorting
-	TranscribeAudio bool      `json:"transcribe_audio"` // Whether to transcribe audio for this frequency
+	ID              string                 `json:"id"`
+	Airport         string                 `json:"airport"`
+	Name            string                 `json:"name"`
+	FrequencyMHz    float64                `json:"frequency_mhz"`
+	URL             string                 `json:"url"`
+	Status          string                 `json:"status"` // "active", "connecting", "error"
+	LastError       string                 `json:"last_error,omitempty"`
+	Bitrate         int                    `json:"bitrate,omitempty"`
+	Format          string                 `json:"format,omitempty"`
+	StreamURL       string                 `json:"stream_url"` // Relative URL to stream from our server
+	HLSURL          string                 `json:"hls_url"`    // Relative URL of the live HLS playlist
+	LastActive      time.Time              `json:"last_active,omitempty"`
+	Order           int                    `json:"order"`             // Order for display/sorting
+	TranscribeAudio bool                   `json:"transcribe_audio"`  // Whether to transcribe audio for this frequency
+	AudioDelayMs    int                    `json:"audio_delay_ms"`    // How far the audio lags the air
+	Level           *audio.AudioLevel      `json:"level,omitempty"`   // Current audio level, while the frequency is being processed
+	Latency         *audio.LatencyEstimate `json:"latency,omitempty"` // Estimated lag of a network stream behind the air
+}
+
+// FrequencyUpdate is a partial update to a frequency. Nil fields are left unchanged.
+type FrequencyUpdate struct {
+	Airport         *string  `json:"airport,omitempty"`
+	Name            *string  `json:"name,omitempty"`
+	FrequencyMHz    *float64 `json:"frequency_mhz,omitempty"`
+	URL             *string  `json:"url,omitempty"`
+	Order           *int     `json:"order,omitempty"`
+	TranscribeAudio *bool    `json:"transcribe_audio,omitempty"`
+	AudioDelayMs    *int     `json:"audio_delay_ms,omitempty"`
+}
+
+// apply copies the set fields of the update onto a frequency config
+func (u FrequencyUpdate) apply(fc *cfg.FrequencyConfig) {
+	if u.Airport != nil {
+		fc.Airport = *u.Airport
+	}
+	if u.Name != nil {
+		fc.Name = *u.Name
+	}
+	if u.FrequencyMHz != nil {
+		fc.FrequencyMHz = *u.FrequencyMHz
+	}
+	if u.URL != nil {
+		fc.URL = *u.URL
+	}
+	if u.Order != nil {
+		fc.Order = *u.Order
+	}
+	if u.TranscribeAudio != nil {
+		fc.TranscribeAudio = *u.TranscribeAudio
+	}
+	if u.AudioDelayMs != nil {
+		fc.AudioDelayMs = *u.AudioDelayMs
+	}
 }
 
 // Stream represents the resources for a single active client's connection to an audio feed.
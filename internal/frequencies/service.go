@@ -5,16 +5,20 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"math"
 	"os"
 	"sort"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/yegors/co-atc/internal/audio"
 	cfg "github.com/yegors/co-atc/internal/config"
+	"github.com/yegors/co-atc/internal/llm"
 	"github.com/yegors/co-atc/internal/storage/sqlite"
 	"github.com/yegors/co-atc/internal/transcription"
+	"github.com/yegors/co-atc/internal/usage"
 	"github.com/yegors/co-atc/internal/websocket"
 	"github.com/yegors/co-atc/pkg/logger"
 )
@@ -50,6 +54,7 @@ func NewStreamProcessor(
 	ctx context.Context,
 	id string,
 	audioURL string,
+	frequencyMHz float64,
 	client *Client,
 	config *cfg.Config,
 	logger *logger.Logger,
@@ -65,6 +70,21 @@ func NewStreamProcessor(
 		ReconnectDelay:           time.Duration(config.Frequencies.ReconnectIntervalSecs) * time.Second,
 		FFmpegTimeoutSecs:        config.Frequencies.FFmpegTimeoutSecs,
 		FFmpegReconnectDelaySecs: config.Frequencies.FFmpegReconnectDelaySecs,
+		FrequencyMHz:             frequencyMHz,
+		RTLFMPath:                config.Frequencies.RTLFMPath,
+		SoapyFMPath:              config.Frequencies.SoapyFMPath,
+		StreamProtocol:           client.DetectStreamProtocol(ctx, audioURL),
+		Squelch: audio.SquelchConfig{
+			ThresholdDB: config.Frequencies.SquelchThresholdDB,
+			HangTime:    time.Duration(config.Frequencies.SquelchHangMs) * time.Millisecond,
+		},
+		StallTimeout: time.Duration(config.Frequencies.FFmpegStallTimeoutSecs) * time.Second,
+		Decoder:      config.Frequencies.AudioDecoder,
+		Buffer: audio.MultiReaderConfig{
+			BufferSize:    config.Frequencies.ReaderBufferKB * 1024,
+			MaxBufferSize: config.Frequencies.ReaderMaxBufferKB * 1024,
+			Overrun:       config.Frequencies.ReaderOverrun,
+		},
 	}
 
 	audioProcessor, err := audio.NewCentralAudioProcessor(
@@ -75,6 +95,7 @@ func NewStreamProcessor(
 		logger.Named("audio"),
 	)
 	if err != nil {
+		procCancel()
 		return nil, fmt.Errorf("failed to create audio processor: %w", err)
 	}
 
@@ -260,7 +281,17 @@ func (sp *StreamProcessor) Stop() {
 }
 
 // AddClient adds a new client to the stream processor.
-func (sp *StreamProcessor) AddClient(clientID string) *ClientStreamReader {
+func (sp *StreamProcessor) AddClient(clientID string, info ListenerInfo) *ClientStreamReader {
+	return sp.addClient(clientID, info, false)
+}
+
+// AddRawClient adds a client that reads PCM without a WAV header, for mixing
+func (sp *StreamProcessor) AddRawClient(clientID string, info ListenerInfo) *ClientStreamReader {
+	return sp.addClient(clientID, info, true)
+}
+
+// addClient adds a client reading the frequency's audio with or without a WAV header
+func (sp *StreamProcessor) addClient(clientID string, info ListenerInfo, raw bool) *ClientStreamReader {
 	sp.clientsMu.Lock()
 	defer sp.clientsMu.Unlock()
 
@@ -293,7 +324,11 @@ func (sp *StreamProcessor) AddClient(clientID string) *ClientStreamReader {
 	sp.logger.Info("Adding new client (or replacing closed one)", String("clientID", clientID))
 
 	// Create a reader from the audio processor
-	audioReader, err := sp.audioProcessor.CreateReader(clientID)
+	createReader := sp.audioProcessor.CreateReader
+	if raw {
+		createReader = sp.audioProcessor.CreateRawReader
+	}
+	audioReader, err := createReader(clientID)
 	if err != nil {
 		sp.logger.Error("Failed to create audio reader", Error(err), String("clientID", clientID))
 		// Return a dummy reader that will return EOF
@@ -308,6 +343,8 @@ func (sp *StreamProcessor) AddClient(clientID string) *ClientStreamReader {
 			ctx:          context.Background(),
 			cancel:       func() {},
 			closed:       true,
+			info:         info,
+			connectedAt:  time.Now(),
 		}
 	}
 
@@ -332,6 +369,8 @@ func (sp *StreamProcessor) AddClient(clientID string) *ClientStreamReader {
 		lastActivity: time.Now(),
 		ctx:          ctx,
 		cancel:       cancel,
+		info:         info,
+		connectedAt:  time.Now(),
 	}
 
 	// Store the client and track activity time
@@ -378,6 +417,39 @@ func (sp *StreamProcessor) RemoveClient(clientID string) {
 	}
 }
 
+// Listeners returns the connected clients, oldest first
+func (sp *StreamProcessor) Listeners() []Listener {
+	sp.clientsMu.RLock()
+	defer sp.clientsMu.RUnlock()
+
+	listeners := make([]Listener, 0, len(sp.clients))
+	for clientID, reader := range sp.clients {
+		reader.mu.Lock()
+		closed := reader.closed
+		reader.mu.Unlock()
+		if closed {
+			continue
+		}
+
+		lag, _ := sp.audioProcessor.ReaderLag(clientID)
+		listeners = append(listeners, Listener{
+			ClientID:    clientID,
+			FrequencyID: sp.id,
+			RemoteAddr:  reader.info.RemoteAddr,
+			UserAgent:   reader.info.UserAgent,
+			Format:      reader.info.Format,
+			Mix:         reader.info.Mix,
+			ConnectedAt: reader.connectedAt,
+			BytesServed: reader.bytesServed.Load(),
+			LagSeconds:  lag.Seconds(),
+		})
+	}
+	sort.Slice(listeners, func(i, j int) bool {
+		return listeners[i].ConnectedAt.Before(listeners[j].ConnectedAt)
+	})
+	return listeners
+}
+
 // GetClientCount returns the number of connected clients.
 func (sp *StreamProcessor) GetClientCount() int {
 	sp.clientsMu.RLock()
@@ -460,6 +532,9 @@ func (sp *StreamProcessor) updateClientActivity(clientID string) {
 type Service struct {
 	client               *Client
 	frequenciesConfig    map[string]*cfg.FrequencyConfig
+	freqMu               sync.RWMutex // Protects frequenciesConfig
+	changeMu             sync.Mutex   // Serializes runtime frequency changes
+	frequencyStorage     *sqlite.FrequencyStorage
 	bufferSize           int
 	config               *cfg.Config
 	logger               *logger.Logger
@@ -470,6 +545,9 @@ type Service struct {
 	streamPortIndex      int   // For round-robin port selection
 	allServerPorts       []int // Combined list of primary and additional ports
 	transcriptionManager *transcription.TranscriptionManager
+	archiver             *audio.Archiver // nil when recording is disabled
+	hlsPackager          *audio.HLSPackager
+	wsServer             *websocket.Server
 }
 
 // NewService creates a new frequencies service.
@@ -480,7 +558,10 @@ func NewService(
 	transcriptionStorage *sqlite.TranscriptionStorage,
 	aircraftStorage *sqlite.AircraftStorage,
 	clearanceStorage *sqlite.ClearanceStorage,
+	frequencyStorage *sqlite.FrequencyStorage,
+	recordingStorage *sqlite.RecordingStorage,
 	templateRenderer transcription.TemplateRenderer,
+	usageTracker *usage.Tracker,
 ) *Service {
 	// EXPERIMENT: Reduce buffer size to see impact on perceived lag from "live"
 	bufferSize := 4 * 1024 // 4KB buffer, approx 2 seconds at 16kbps
@@ -494,11 +575,42 @@ func NewService(
 		freqsConfig[src.ID] = &src
 	}
 
+	// Apply frequencies added, edited or removed at runtime on top of the config file
+	if frequencyStorage != nil {
+		records, err := frequencyStorage.GetFrequencies()
+		if err != nil {
+			logger.Error("Failed to load stored frequencies, using config file only", Error(err))
+		}
+		for _, record := range records {
+			if record.Deleted {
+				delete(freqsConfig, record.ID)
+				continue
+			}
+			stored := &cfg.FrequencyConfig{
+				ID:              record.ID,
+				Airport:         record.Airport,
+				Name:            record.Name,
+				FrequencyMHz:    record.FrequencyMHz,
+				URL:             record.URL,
+				Order:           record.Order,
+				TranscribeAudio: record.TranscribeAudio,
+				AudioDelayMs:    record.AudioDelayMs,
+			}
+			// Post-processing overrides only come from the config file
+			if fileConfig, ok := freqsConfig[record.ID]; ok {
+				stored.PostProcessing = fileConfig.PostProcessing
+			}
+			freqsConfig[record.ID] = stored
+		}
+	}
+
 	ctx, cancel := context.WithCancel(context.Background())
 
 	// Create transcription manager
 	transcriptionConfig := transcription.Config{
+		Provider:              config.Transcription.Provider,
 		OpenAIAPIKey:          config.Transcription.OpenAIAPIKey,
+		OpenAIBaseURL:         config.Transcription.OpenAIBaseURL,
 		Model:                 config.Transcription.Model,
 		Language:              config.Transcription.Language,
 		NoiseReduction:        config.Transcription.NoiseReduction,
@@ -519,6 +631,30 @@ func NewService(
 		RetryMaxBackoffMs:     config.Transcription.RetryMaxBackoffMs,
 		PromptPath:            config.Transcription.PromptPath,
 		TimeoutSeconds:        config.Transcription.TimeoutSeconds,
+		PreRollMs:             config.Transcription.PreRollMs,
+		SilenceGating:         config.Transcription.SilenceGating,
+		SilenceThresholdDB:    config.Transcription.SilenceThresholdDB,
+		SilenceHangMs:         config.Transcription.SilenceHangMs,
+		SilencePaddingMs:      config.Transcription.SilencePaddingMs,
+		ConnectsPerMinute:     config.Transcription.ConnectsPerMinute,
+		MaxConcurrentSessions: config.Transcription.MaxConcurrentSessions,
+		WorkerRestartSec:      config.Transcription.WorkerRestartSeconds,
+		WorkerMaxRestartSec:   config.Transcription.WorkerMaxRestartSeconds,
+		VocabularyBiasing:     config.Transcription.VocabularyBiasing,
+		VocabularyMaxTerms:    config.Transcription.VocabularyMaxTerms,
+		VocabularyRefreshSec:  config.Transcription.VocabularyRefreshSeconds,
+		SplitTurns:            config.Transcription.SplitTurns,
+		Deepgram: transcription.DeepgramConfig{
+			APIKey:                config.Transcription.Deepgram.APIKey,
+			Model:                 config.Transcription.Deepgram.Model,
+			EndpointingMs:         config.Transcription.Deepgram.EndpointingMs,
+			UtteranceEndMs:        config.Transcription.Deepgram.UtteranceEndMs,
+			RetryMaxAttempts:      config.Transcription.Deepgram.RetryMaxAttempts,
+			RetryInitialBackoffMs: config.Transcription.Deepgram.RetryInitialBackoffMs,
+			RetryMaxBackoffMs:     config.Transcription.Deepgram.RetryMaxBackoffMs,
+			ConnectsPerMinute:     config.Transcription.Deepgram.ConnectsPerMinute,
+			Diarize:               config.Transcription.Deepgram.Diarize,
+		},
 	}
 
 	// Load the prompt from file
@@ -536,18 +672,38 @@ func NewService(
 	}
 
 	postProcessingConfig := transcription.PostProcessingConfig{
-		Enabled:               config.PostProcessing.Enabled,
-		Model:                 config.PostProcessing.Model,
+		Enabled: config.PostProcessing.Enabled,
+		LLM: llm.Config{
+			Provider:       config.PostProcessing.LLM.Provider,
+			BaseURL:        config.PostProcessing.LLM.BaseURL,
+			APIKey:         config.PostProcessing.LLM.APIKey,
+			Model:          config.PostProcessing.Model,
+			TimeoutSeconds: config.PostProcessing.TimeoutSeconds,
+			NoSchema:       config.PostProcessing.LLM.DisableStructuredOutput,
+		},
 		IntervalSeconds:       config.PostProcessing.IntervalSeconds,
 		BatchSize:             config.PostProcessing.BatchSize,
 		ContextTranscriptions: config.PostProcessing.ContextTranscriptions,
 		SystemPromptPath:      config.PostProcessing.SystemPromptPath,
-		TimeoutSeconds:        config.PostProcessing.TimeoutSeconds,
+		RepairAttempts:        config.PostProcessing.RepairAttempts,
+		MaxConcurrentBatches:  config.PostProcessing.MaxConcurrentBatches,
+		BackoffInitial:        time.Duration(config.PostProcessing.BackoffInitialSeconds) * time.Second,
+		BackoffMax:            time.Duration(config.PostProcessing.BackoffMaxSeconds) * time.Second,
+		Frequencies:           make(map[string]transcription.FrequencyPostProcessing),
+	}
+	for _, freq := range freqsConfig {
+		if freq.PostProcessing.IsSet() {
+			postProcessingConfig.Frequencies[freq.ID] = transcription.FrequencyPostProcessing{
+				SystemPromptPath: freq.PostProcessing.SystemPromptPath,
+				Model:            freq.PostProcessing.Model,
+				ClearanceTypes:   freq.PostProcessing.ClearanceTypes,
+			}
+		}
 	}
 
 	// Convert frequency configs to the format expected by TranscriptionManager
 	var frequencyConfigs []transcription.FrequencyConfig
-	for _, freq := range config.Frequencies.Sources {
+	for _, freq := range freqsConfig {
 		frequencyConfigs = append(frequencyConfigs, transcription.FrequencyConfig{
 			ID:   freq.ID,
 			Name: freq.Name,
@@ -564,6 +720,7 @@ func NewService(
 		transcriptionConfig,
 		postProcessingConfig,
 		templateRenderer,
+		usageTracker,
 		frequencyConfigs,
 	)
 
@@ -580,9 +737,34 @@ func NewService(
 		}
 	}
 
-	return &Service{
+	// Create the archiver if recording is enabled
+	var archiver *audio.Archiver
+	if config.Recording.Enabled && recordingStorage != nil {
+		archiver = audio.NewArchiver(ctx, audio.ArchiverConfig{
+			FFmpegPath:      config.Transcription.FFmpegPath,
+			OutputDir:       config.Recording.OutputDir,
+			Format:          config.Recording.Format,
+			Bitrate:         config.Recording.Bitrate,
+			SegmentDuration: time.Duration(config.Recording.SegmentMinutes) * time.Minute,
+			ReconnectDelay:  time.Duration(config.Frequencies.ReconnectIntervalSecs) * time.Second,
+		}, recordingStorage, logger)
+	}
+
+	// HLS packaging is started on demand for each frequency
+	hlsPackager := audio.NewHLSPackager(ctx, audio.HLSConfig{
+		FFmpegPath:       config.Transcription.FFmpegPath,
+		OutputDir:        config.Frequencies.HLSDir,
+		SegmentDuration:  time.Duration(config.Frequencies.HLSSegmentSecs) * time.Second,
+		PlaylistSegments: config.Frequencies.HLSPlaylistSegments,
+		Bitrate:          config.Frequencies.HLSBitrate,
+		IdleTimeout:      time.Duration(config.Frequencies.HLSIdleTimeoutSecs) * time.Second,
+		ReconnectDelay:   time.Duration(config.Frequencies.ReconnectIntervalSecs) * time.Second,
+	}, logger)
+
+	s := &Service{
 		client:               NewClient(0, logger),
 		frequenciesConfig:    freqsConfig,
+		frequencyStorage:     frequencyStorage,
 		bufferSize:           bufferSize,
 		config:               config,
 		logger:               logger.Named("freq-service"),
@@ -593,7 +775,12 @@ func NewService(
 		streamPortIndex:      0, // Initialize for round-robin
 		allServerPorts:       allPorts,
 		transcriptionManager: transcriptionManager,
+		archiver:             archiver,
+		hlsPackager:          hlsPackager,
+		wsServer:             wsServer,
 	}
+	transcriptionManager.SetAudioDelay(s.TimestampDelay)
+	return s
 }
 
 // Start initializes connections to all configured frequencies.
@@ -601,70 +788,18 @@ func (s *Service) Start(ctx context.Context) error {
 	s.logger.Info("Starting frequencies service with persistent connections")
 
 	// Start a stream processor for each configured frequency
-	for id, freqConfig := range s.frequenciesConfig {
-		s.logger.Info("Starting stream processor for frequency",
-			String("id", id),
-			String("name", freqConfig.Name),
-			String("url", freqConfig.URL))
-
-		processor, err := NewStreamProcessor(
-			s.ctx,
-			id,
-			freqConfig.URL,
-			s.client,
-			s.config,
-			s.logger,
-		)
+	s.freqMu.RLock()
+	freqConfigs := make([]cfg.FrequencyConfig, 0, len(s.frequenciesConfig))
+	for _, freqConfig := range s.frequenciesConfig {
+		freqConfigs = append(freqConfigs, *freqConfig)
+	}
+	s.freqMu.RUnlock()
 
-		if err != nil {
-			s.logger.Error("Failed to create stream processor",
-				String("id", id),
+	for _, freqConfig := range freqConfigs {
+		if err := s.startFrequency(freqConfig); err != nil {
+			s.logger.Error("Failed to start frequency",
+				String("id", freqConfig.ID),
 				Error(err))
-			continue
-		}
-
-		err = processor.Start()
-		if err != nil {
-			s.logger.Error("Failed to start stream processor",
-				String("id", id),
-				Error(err))
-			continue
-		}
-
-		s.streamsMu.Lock()
-		s.activeStreams[id] = processor
-		s.streamsMu.Unlock()
-
-		// Start transcription with external audio if enabled
-		frequency := &Frequency{
-			ID:              id,
-			Name:            freqConfig.Name,
-			URL:             freqConfig.URL,
-			TranscribeAudio: freqConfig.TranscribeAudio,
-		}
-
-		if frequency.TranscribeAudio {
-			s.logger.Info("Starting transcription with external audio for frequency",
-				String("id", id),
-				String("name", freqConfig.Name),
-				Bool("transcribe_audio", freqConfig.TranscribeAudio))
-
-			if err := s.transcriptionManager.StartTranscriptionWithExternalAudio(
-				s.ctx,
-				frequency.ID,
-				frequency.Name,
-				frequency.TranscribeAudio,
-				processor.audioProcessor,
-			); err != nil {
-				s.logger.Error("Failed to start transcription with external audio for frequency",
-					String("id", id),
-					Error(err))
-			}
-		} else {
-			s.logger.Info("Transcription not enabled for frequency",
-				String("id", id),
-				String("name", freqConfig.Name),
-				Bool("transcribe_audio", freqConfig.TranscribeAudio))
 		}
 	}
 
@@ -684,6 +819,94 @@ func (s *Service) Start(ctx context.Context) error {
 	return nil
 }
 
+// startFrequency starts the stream processor for a frequency and, if enabled, its transcription
+func (s *Service) startFrequency(freqConfig cfg.FrequencyConfig) error {
+	s.logger.Info("Starting stream processor for frequency",
+		String("id", freqConfig.ID),
+		String("name", freqConfig.Name),
+		String("url", freqConfig.URL))
+
+	processor, err := NewStreamProcessor(
+		s.ctx,
+		freqConfig.ID,
+		freqConfig.URL,
+		freqConfig.FrequencyMHz,
+		s.client,
+		s.config,
+		s.logger,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to create stream processor: %w", err)
+	}
+
+	s.broadcastSquelch(freqConfig.ID, processor)
+
+	if err := processor.Start(); err != nil {
+		return fmt.Errorf("failed to start stream processor: %w", err)
+	}
+
+	s.streamsMu.Lock()
+	s.activeStreams[freqConfig.ID] = processor
+	s.streamsMu.Unlock()
+
+	if s.archiver != nil {
+		s.archiver.AddFrequency(freqConfig.ID, processor.audioProcessor)
+	}
+
+	s.startTranscription(freqConfig, processor)
+
+	return nil
+}
+
+// startTranscription starts transcription with external audio if enabled for the frequency
+func (s *Service) startTranscription(freqConfig cfg.FrequencyConfig, processor *StreamProcessor) {
+	if !freqConfig.TranscribeAudio {
+		s.logger.Info("Transcription not enabled for frequency",
+			String("id", freqConfig.ID),
+			String("name", freqConfig.Name),
+			Bool("transcribe_audio", freqConfig.TranscribeAudio))
+		return
+	}
+
+	s.logger.Info("Starting transcription with external audio for frequency",
+		String("id", freqConfig.ID),
+		String("name", freqConfig.Name),
+		Bool("transcribe_audio", freqConfig.TranscribeAudio))
+
+	if err := s.transcriptionManager.StartTranscriptionWithExternalAudio(
+		s.ctx,
+		freqConfig.ID,
+		freqConfig.Name,
+		freqConfig.TranscribeAudio,
+		processor.audioProcessor,
+	); err != nil {
+		s.logger.Error("Failed to start transcription with external audio for frequency",
+			String("id", freqConfig.ID),
+			Error(err))
+	}
+}
+
+// stopFrequency stops transcription and the stream processor for a frequency.
+// Clients listening to the frequency are disconnected.
+func (s *Service) stopFrequency(id string) {
+	s.transcriptionManager.StopTranscription(id)
+
+	if s.archiver != nil {
+		s.archiver.RemoveFrequency(id)
+	}
+	s.hlsPackager.RemoveFrequency(id)
+
+	s.streamsMu.Lock()
+	processor, exists := s.activeStreams[id]
+	delete(s.activeStreams, id)
+	s.streamsMu.Unlock()
+
+	if exists && processor != nil {
+		s.logger.Info("Stopping stream processor", String("id", id))
+		processor.Stop()
+	}
+}
+
 // Stop stops all stream processors and cleans up resources.
 func (s *Service) Stop() {
 	s.logger.Info("Frequencies service stopping")
@@ -691,6 +914,12 @@ func (s *Service) Stop() {
 	// Stop all transcriptions
 	s.transcriptionManager.StopAllTranscriptions()
 
+	// Finish the current recording segments while the streams are still open
+	if s.archiver != nil {
+		s.archiver.Stop()
+	}
+	s.hlsPackager.Stop()
+
 	// Cancel the main context to signal all stream processors to stop
 	s.cancel()
 
@@ -747,6 +976,9 @@ type ClientStreamReader struct {
 	cancel        context.CancelFunc // Function to cancel the context
 	closed        bool               // Flag to track if the reader is closed
 	mu            sync.Mutex         // Mutex to protect the closed flag
+	info          ListenerInfo
+	connectedAt   time.Time
+	bytesServed   atomic.Int64
 }
 
 // Close cleans up resources for this specific client stream.
@@ -759,6 +991,11 @@ func (csr *ClientStreamReader) Close() error {
 			return // Already closed by another goroutine
 		}
 		csr.closed = true
+		// Drop the client's position in the shared buffer, which wakes a blocked read. This
+		// happens under the lock so a client reconnecting with the same ID gets a new one.
+		if csr.processor != nil {
+			csr.processor.audioProcessor.RemoveReader(csr.clientID)
+		}
 		csr.mu.Unlock()
 
 		csr.logger.Info("Closing client stream reader, cancelling context and cleaning up local resources",
@@ -822,6 +1059,7 @@ func (csr *ClientStreamReader) Read(p []byte) (n int, err error) {
 	// csr.ReadCloser is NonClosingReader, which wraps the shared CircularBuffer.
 	// CircularBuffer.Read will block until data is available or the buffer itself is closed.
 	n, err = csr.ReadCloser.Read(p)
+	csr.bytesServed.Add(int64(n))
 
 	// Handle the result of the read operation
 	if err != nil {
@@ -851,15 +1089,27 @@ func (csr *ClientStreamReader) Read(p []byte) (n int, err error) {
 
 // GetAudioStream returns a reader for a frequency's audio stream.
 // It accepts a client ID to track individual client connections.
-func (s *Service) GetAudioStream(ctx context.Context, id string, clientID string) (io.ReadCloser, string, error) {
+func (s *Service) GetAudioStream(ctx context.Context, id string, clientID string, info ListenerInfo) (io.ReadCloser, string, error) {
+	reader, processor, err := s.addListener(ctx, id, clientID, info, false)
+	if err != nil {
+		return nil, "", err
+	}
+	return reader, processor.contentType, nil
+}
+
+// addListener connects a client to a frequency's audio, starting the frequency's stream
+// processor if needed. Raw listeners read PCM without a WAV header.
+func (s *Service) addListener(ctx context.Context, id string, clientID string, info ListenerInfo, raw bool) (*ClientStreamReader, *StreamProcessor, error) {
 	// Create a context with timeout to prevent hanging
 	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
 	defer cancel()
 
 	// Check if the frequency exists
+	s.freqMu.RLock()
 	freqConfig, ok := s.frequenciesConfig[id]
+	s.freqMu.RUnlock()
 	if !ok {
-		return nil, "", fmt.Errorf("frequency configuration not found: %s", id)
+		return nil, nil, fmt.Errorf("frequency configuration not found: %s", id)
 	}
 
 	s.logger.Info("Client requesting audio stream",
@@ -875,7 +1125,7 @@ func (s *Service) GetAudioStream(ctx context.Context, id string, clientID string
 		s.logger.Info("Client already connected to this frequency, rejecting duplicate request",
 			String("id", id),
 			String("clientID", clientID))
-		return nil, "", fmt.Errorf("client already connected to this frequency")
+		return nil, nil, fmt.Errorf("client already connected to this frequency")
 	}
 
 	// Check if we've reached the maximum number of active clients
@@ -892,7 +1142,7 @@ func (s *Service) GetAudioStream(ctx context.Context, id string, clientID string
 			String("id", id),
 			String("clientID", clientID),
 			Int("total_clients", totalClients))
-		return nil, "", fmt.Errorf("too many concurrent clients (max 100)")
+		return nil, nil, fmt.Errorf("too many concurrent clients (max 100)")
 	}
 
 	// Check if we already have a processor for this frequency
@@ -906,7 +1156,7 @@ func (s *Service) GetAudioStream(ctx context.Context, id string, clientID string
 			String("id", id),
 			String("clientID", clientID),
 			Int("client_count", processor.GetClientCount()))
-		return nil, "", fmt.Errorf("too many clients for this frequency (max 10)")
+		return nil, nil, fmt.Errorf("too many clients for this frequency (max 10)")
 	}
 
 	// We already have the processor from the check above, no need to get it again
@@ -923,6 +1173,7 @@ func (s *Service) GetAudioStream(ctx context.Context, id string, clientID string
 				s.ctx,
 				id,
 				freqConfig.URL,
+				freqConfig.FrequencyMHz,
 				s.client,
 				s.config,
 				s.logger,
@@ -931,17 +1182,22 @@ func (s *Service) GetAudioStream(ctx context.Context, id string, clientID string
 			if err != nil {
 				s.streamsMu.Unlock()
 				s.logger.Error("Failed to create stream processor", String("id", id), Error(err))
-				return nil, "", fmt.Errorf("failed to create stream processor: %w", err)
+				return nil, nil, fmt.Errorf("failed to create stream processor: %w", err)
 			}
+			s.broadcastSquelch(id, processor)
 
 			err = processor.Start()
 			if err != nil {
 				s.streamsMu.Unlock()
 				s.logger.Error("Failed to start stream processor", String("id", id), Error(err))
-				return nil, "", fmt.Errorf("failed to start stream processor: %w", err)
+				return nil, nil, fmt.Errorf("failed to start stream processor: %w", err)
 			}
 
 			s.activeStreams[id] = processor
+
+			if s.archiver != nil {
+				s.archiver.AddFrequency(id, processor.audioProcessor)
+			}
 		}
 		s.streamsMu.Unlock()
 	}
@@ -949,66 +1205,425 @@ func (s *Service) GetAudioStream(ctx context.Context, id string, clientID string
 	// Check if the context has been canceled
 	select {
 	case <-ctx.Done():
-		return nil, "", ctx.Err()
+		return nil, nil, ctx.Err()
 	default:
 		// Continue
 	}
 
 	// Add the client to the stream processor
-	clientReader := processor.AddClient(clientID)
+	var clientReader *ClientStreamReader
+	if raw {
+		clientReader = processor.AddRawClient(clientID, info)
+	} else {
+		clientReader = processor.AddClient(clientID, info)
+	}
 
 	s.logger.Debug("Client connected to audio stream",
 		String("id", id),
 		String("clientID", clientID),
 		String("contentType", processor.contentType))
 
-	return clientReader, processor.contentType, nil
+	return clientReader, processor, nil
+}
+
+// GetEncodedAudioStream returns a frequency's audio stream transcoded to Opus in the given
+// container (audio.StreamFormatWebM or audio.StreamFormatOgg) with the content type to serve it as
+func (s *Service) GetEncodedAudioStream(ctx context.Context, id string, clientID string, format string, info ListenerInfo) (io.ReadCloser, string, error) {
+	stream, _, err := s.GetAudioStream(ctx, id, clientID, info)
+	if err != nil {
+		return nil, "", err
+	}
+	return s.encodeStream(ctx, stream, format)
+}
+
+// encodeStream transcodes a WAV stream to Opus in the given container, or passes it through
+// if format is empty, and returns it with the content type to serve it as
+func (s *Service) encodeStream(ctx context.Context, stream io.ReadCloser, format string) (io.ReadCloser, string, error) {
+	if format == "" {
+		return stream, audio.StreamContentType(format), nil
+	}
+
+	encoder, err := audio.NewStreamEncoder(ctx, audio.EncoderConfig{
+		FFmpegPath: s.config.Transcription.FFmpegPath,
+		Format:     format,
+		Bitrate:    s.config.Frequencies.OpusBitrate,
+		FrameMs:    s.config.Frequencies.OpusFrameMs,
+	}, stream)
+	if err != nil {
+		stream.Close()
+		return nil, "", fmt.Errorf("failed to start %s encoder: %w", format, err)
+	}
+
+	return encoder, audio.StreamContentType(format), nil
+}
+
+// GetScannerStream returns a stream that plays whichever of the frequencies has a
+// transmission, highest priority first, in the given format ("" for WAV). Without
+// frequencies it scans scanner_frequencies, or every frequency in display order.
+func (s *Service) GetScannerStream(ctx context.Context, ids []string, clientID string, format string, info ListenerInfo) (io.ReadCloser, string, error) {
+	if len(ids) == 0 {
+		for _, id := range s.config.Frequencies.ScannerFrequencies {
+			if _, ok := s.GetFrequencyByID(id); ok {
+				ids = append(ids, id)
+			}
+		}
+	}
+	if len(ids) == 0 {
+		for _, f := range s.GetAllFrequencies() {
+			ids = append(ids, f.ID)
+		}
+	}
+	if len(ids) == 0 {
+		return nil, "", fmt.Errorf("%w: no frequencies to scan", ErrFrequencyNotFound)
+	}
+	for _, id := range ids {
+		if _, ok := s.GetFrequencyByID(id); !ok {
+			return nil, "", fmt.Errorf("%w: %s", ErrFrequencyNotFound, id)
+		}
+	}
+
+	info.Mix = MixScanner
+	var inputs []audio.ScannerInput
+	var processor *StreamProcessor
+	closeInputs := func() {
+		for _, input := range inputs {
+			input.Reader.Close()
+		}
+	}
+	for _, id := range ids {
+		var reader *ClientStreamReader
+		var err error
+		reader, processor, err = s.addListener(ctx, id, clientID, info, true)
+		if err != nil {
+			closeInputs()
+			return nil, "", err
+		}
+		inputs = append(inputs, audio.ScannerInput{
+			ID:     id,
+			Reader: reader,
+			Active: processor.audioProcessor.SquelchOpen,
+			GainDB: s.config.Frequencies.ScannerGainDB[id],
+		})
+	}
+
+	sampleRate, channels := processor.audioProcessor.SampleRate(), processor.audioProcessor.Channels()
+	scanner := audio.NewScanner(s.ctx, audio.ScannerConfig{
+		SampleRate: sampleRate,
+		Channels:   channels,
+		Hold:       time.Duration(s.config.Frequencies.ScannerHoldMs) * time.Millisecond,
+	}, inputs, s.logger.Named("scanner").With(String("clientID", clientID)))
+
+	s.logger.Info("Client connected to scanner",
+		String("clientID", clientID),
+		String("frequencies", strings.Join(ids, ",")))
+
+	return s.encodeStream(ctx, audio.NewWAVReader(scanner, sampleRate, channels), format)
+}
+
+// GetStereoStream returns a stream with one frequency in the left channel and another in
+// the right, in the given format ("" for WAV)
+func (s *Service) GetStereoStream(ctx context.Context, left, right string, clientID string, format string, info ListenerInfo) (io.ReadCloser, string, error) {
+	for _, id := range []string{left, right} {
+		if _, ok := s.GetFrequencyByID(id); !ok {
+			return nil, "", fmt.Errorf("%w: %s", ErrFrequencyNotFound, id)
+		}
+	}
+	if left == right {
+		return nil, "", fmt.Errorf("%w: the left and right frequencies must differ", ErrInvalidFrequency)
+	}
+
+	info.Mix = MixStereo
+	leftReader, processor, err := s.addListener(ctx, left, clientID, info, true)
+	if err != nil {
+		return nil, "", err
+	}
+	rightReader, _, err := s.addListener(ctx, right, clientID, info, true)
+	if err != nil {
+		leftReader.Close()
+		return nil, "", err
+	}
+
+	sampleRate, channels := processor.audioProcessor.SampleRate(), processor.audioProcessor.Channels()
+	mixer := audio.NewStereoMixer(s.ctx, sampleRate, channels, left, leftReader, right, rightReader,
+		s.logger.Named("stereo").With(String("clientID", clientID)))
+
+	s.logger.Info("Client connected to stereo stream",
+		String("clientID", clientID),
+		String("left", left),
+		String("right", right))
+
+	return s.encodeStream(ctx, audio.NewWAVReader(mixer, sampleRate, 2), format)
+}
+
+// GetStereoStreamByPair returns the stereo stream of a configured frequency pair
+func (s *Service) GetStereoStreamByPair(ctx context.Context, pairID string, clientID string, format string, info ListenerInfo) (io.ReadCloser, string, error) {
+	for _, pair := range s.config.Frequencies.StereoPairs {
+		if pair.ID == pairID {
+			return s.GetStereoStream(ctx, pair.Left, pair.Right, clientID, format, info)
+		}
+	}
+	return nil, "", fmt.Errorf("%w: %s", ErrStereoPairNotFound, pairID)
+}
+
+// StereoPairs returns the configured frequency pairs
+func (s *Service) StereoPairs() []StereoPair {
+	pairs := make([]StereoPair, 0, len(s.config.Frequencies.StereoPairs))
+	for _, pair := range s.config.Frequencies.StereoPairs {
+		pairs = append(pairs, StereoPair{
+			ID:        pair.ID,
+			Name:      pair.Name,
+			Left:      pair.Left,
+			Right:     pair.Right,
+			StreamURL: fmt.Sprintf("/api/v1/stereo/%s/stream", pair.ID),
+		})
+	}
+	return pairs
+}
+
+// Listeners returns the clients connected to a frequency's audio stream, or to every
+// frequency's if id is empty
+func (s *Service) Listeners(id string) ([]Listener, error) {
+	if id != "" {
+		if _, ok := s.GetFrequencyByID(id); !ok {
+			return nil, fmt.Errorf("%w: %s", ErrFrequencyNotFound, id)
+		}
+	}
+
+	s.streamsMu.RLock()
+	defer s.streamsMu.RUnlock()
+
+	listeners := []Listener{}
+	for frequencyID, processor := range s.activeStreams {
+		if id == "" || frequencyID == id {
+			listeners = append(listeners, processor.Listeners()...)
+		}
+	}
+	sort.SliceStable(listeners, func(i, j int) bool {
+		return listeners[i].ConnectedAt.Before(listeners[j].ConnectedAt)
+	})
+	return listeners, nil
+}
+
+// DisconnectListener closes a client's audio stream
+func (s *Service) DisconnectListener(id, clientID string) error {
+	if _, ok := s.GetFrequencyByID(id); !ok {
+		return fmt.Errorf("%w: %s", ErrFrequencyNotFound, id)
+	}
+
+	s.streamsMu.RLock()
+	processor, ok := s.activeStreams[id]
+	s.streamsMu.RUnlock()
+	if !ok || !processor.IsClientConnected(clientID) {
+		return fmt.Errorf("%w: %s", ErrListenerNotFound, clientID)
+	}
+
+	s.logger.Info("Disconnecting listener", String("id", id), String("clientID", clientID))
+	processor.RemoveClient(clientID)
+	return nil
+}
+
+// GetHLSPlaylist returns the path of a frequency's live HLS playlist, starting HLS packaging
+// of the frequency if nobody is listening to it over HLS yet
+func (s *Service) GetHLSPlaylist(ctx context.Context, id string) (string, error) {
+	s.streamsMu.RLock()
+	processor, ok := s.activeStreams[id]
+	s.streamsMu.RUnlock()
+	if !ok {
+		return "", fmt.Errorf("%w: %s", ErrFrequencyNotFound, id)
+	}
+
+	return s.hlsPackager.Playlist(ctx, id, processor.audioProcessor)
+}
+
+// GetHLSSegment returns the path of a segment in a frequency's live HLS playlist
+func (s *Service) GetHLSSegment(id, name string) (string, error) {
+	return s.hlsPackager.Segment(id, name)
+}
+
+// SilenceGating reports whether silence gating is enabled and how much audio it has held
+// back from transcription on each frequency
+func (s *Service) SilenceGating() (bool, []transcription.GateReport) {
+	return s.transcriptionManager.SilenceGatingEnabled(), s.transcriptionManager.GateReports()
+}
+
+// TranscriptionWorkers returns the state of each frequency's transcription worker, or false
+// if transcription isn't configured
+func (s *Service) TranscriptionWorkers() (transcription.WorkerReport, bool) {
+	return s.transcriptionManager.Workers()
+}
+
+// AudioPipelines returns how each active frequency's ffmpeg pipeline has been kept running
+func (s *Service) AudioPipelines() map[string]audio.PipelineStats {
+	s.streamsMu.RLock()
+	defer s.streamsMu.RUnlock()
+
+	pipelines := make(map[string]audio.PipelineStats, len(s.activeStreams))
+	for id, processor := range s.activeStreams {
+		pipelines[id] = processor.audioProcessor.PipelineStats()
+	}
+	return pipelines
+}
+
+// PostProcessingStats reports how post-processing is keeping up, or false if it isn't running
+func (s *Service) PostProcessingStats() (transcription.PostProcessingStats, bool) {
+	return s.transcriptionManager.PostProcessingStats()
 }
 
 // GetAllFrequencies and GetFrequencyByID now only report on configured frequencies,
 // as "active" status is per-client and not centrally tracked in the same way.
 // We can indicate a general "available" status based on config existence.
 func (s *Service) GetAllFrequencies() []*Frequency { // frequencies.Frequency from models.go
-	// No RLock needed as s.frequenciesConfig is read-only after NewService
+	s.freqMu.RLock()
 	var result []*Frequency
-	for _, fc := range s.frequenciesConfig { // Changed id to _ as it was unused
-		result = append(result, &Frequency{
-			ID:              fc.ID,
-			Airport:         fc.Airport,
-			Name:            fc.Name,
-			FrequencyMHz:    fc.FrequencyMHz,
-			URL:             fc.URL,
-			StreamURL:       s.buildStreamURL(fc.ID),
-			Status:          "available",        // All configured frequencies are considered available for connection
-			Order:           fc.Order,           // Include order in the response
-			TranscribeAudio: fc.TranscribeAudio, // Include transcribe_audio flag from config
-		})
+	for _, fc := range s.frequenciesConfig {
+		result = append(result, s.toFrequency(fc))
+	}
+	s.freqMu.RUnlock()
+
+	for _, f := range result {
+		s.addLevel(f)
 	}
 
 	// Sort frequencies by order instead of name
 	sort.Slice(result, func(i, j int) bool {
-		return result[i].Order < result[j].Order
+		if result[i].Order != result[j].Order {
+			return result[i].Order < result[j].Order
+		}
+		return result[i].ID < result[j].ID
 	})
 
 	return result
 }
 
 func (s *Service) GetFrequencyByID(id string) (*Frequency, bool) {
+	s.freqMu.RLock()
 	fc, ok := s.frequenciesConfig[id]
 	if !ok {
+		s.freqMu.RUnlock()
 		return nil, false
 	}
+	frequency := s.toFrequency(fc)
+	s.freqMu.RUnlock()
+
+	s.addLevel(frequency)
+	return frequency, true
+}
+
+// AudioDelay returns how far a frequency's audio lags the air: its configured delay, plus
+// its stream's estimated latency with estimate_latency. Zero for an unknown frequency.
+func (s *Service) AudioDelay(id string) time.Duration {
+	s.freqMu.RLock()
+	fc, ok := s.frequenciesConfig[id]
+	var delay time.Duration
+	if ok {
+		delay = time.Duration(fc.AudioDelayMs) * time.Millisecond
+	}
+	s.freqMu.RUnlock()
+	if !ok {
+		return 0
+	}
+	return delay + s.estimatedLatency(id)
+}
+
+// estimatedLatency returns the measured latency of a frequency's stream, or zero if
+// estimate_latency is off or nothing has been measured
+func (s *Service) estimatedLatency(id string) time.Duration {
+	if !s.config.Frequencies.EstimateLatency {
+		return 0
+	}
+	s.streamsMu.RLock()
+	processor, ok := s.activeStreams[id]
+	s.streamsMu.RUnlock()
+	if !ok {
+		return 0
+	}
+	if latency := processor.audioProcessor.Latency(); latency != nil {
+		return latency.Delay()
+	}
+	return 0
+}
+
+// TimestampDelay returns the delay a frequency's transcript times are moved back by, which is
+// its audio delay when audio_delay_mode is "timestamps" and zero otherwise
+func (s *Service) TimestampDelay(id string) time.Duration {
+	if s.config.Frequencies.AudioDelayMode == "adsb" {
+		return 0
+	}
+	return s.AudioDelay(id)
+}
+
+// MaxAudioDelay returns the largest audio delay of all frequencies, the delay aircraft updates
+// are held back by when audio_delay_mode is "adsb"
+func (s *Service) MaxAudioDelay() time.Duration {
+	s.freqMu.RLock()
+	ids := make([]string, 0, len(s.frequenciesConfig))
+	for id := range s.frequenciesConfig {
+		ids = append(ids, id)
+	}
+	s.freqMu.RUnlock()
+
+	var delay time.Duration
+	for _, id := range ids {
+		delay = max(delay, s.AudioDelay(id))
+	}
+	return delay
+}
+
+// addLevel fills in the audio level and estimated latency of a frequency that is being processed
+func (s *Service) addLevel(f *Frequency) {
+	s.streamsMu.RLock()
+	processor, ok := s.activeStreams[f.ID]
+	s.streamsMu.RUnlock()
+
+	if ok {
+		level := processor.audioProcessor.Level()
+		f.Level = &level
+		f.Latency = processor.audioProcessor.Latency()
+	}
+}
+
+// broadcastSquelch sends transmission_started and transmission_ended events for a
+// frequency to WebSocket clients
+func (s *Service) broadcastSquelch(id string, processor *StreamProcessor) {
+	if s.wsServer == nil {
+		return
+	}
+
+	processor.audioProcessor.OnSquelch(func(event audio.SquelchEvent) {
+		data := map[string]interface{}{
+			"frequency_id": id,
+			"started_at":   event.StartedAt.UTC(),
+			"peak_db":      math.Round(event.PeakDB*10) / 10,
+		}
+		messageType := "transmission_started"
+		if !event.Open {
+			messageType = "transmission_ended"
+			data["ended_at"] = event.EndedAt.UTC()
+			data["duration_ms"] = event.Duration.Milliseconds()
+		}
+
+		s.wsServer.Broadcast(&websocket.Message{
+			Type: messageType,
+			Data: data,
+		})
+	})
+}
+
+// toFrequency converts a frequency config into its API representation
+func (s *Service) toFrequency(fc *cfg.FrequencyConfig) *Frequency {
 	return &Frequency{
 		ID:              fc.ID,
 		Airport:         fc.Airport,
 		Name:            fc.Name,
-		Order:           fc.Order,
 		FrequencyMHz:    fc.FrequencyMHz,
 		URL:             fc.URL,
 		StreamURL:       s.buildStreamURL(fc.ID),
-		Status:          "available",
+		HLSURL:          fmt.Sprintf("/api/v1/stream/%s/%s", fc.ID, audio.HLSPlaylistName),
+		Status:          "available",        // All configured frequencies are considered available for connection
+		Order:           fc.Order,           // Include order in the response
 		TranscribeAudio: fc.TranscribeAudio, // Include transcribe_audio flag from config
-	}, true
+		AudioDelayMs:    fc.AudioDelayMs,
+	}
 }
 
 func (s *Service) buildStreamURL(frequencyID string) string {
@@ -1034,6 +1649,239 @@ func (s *Service) buildStreamURL(frequencyID string) string {
 	return fmt.Sprintf("http://%s:%d/api/v1/stream/%s", host, port, frequencyID)
 }
 
-// AddFrequency and RemoveFrequency could be implemented to modify s.frequenciesConfig
-// if dynamic updates to available frequencies are needed. For now, assuming static config.
-// They would require s.mu to protect s.frequenciesConfig if made concurrent-safe.
+// AddFrequency adds a new frequency at runtime, persists it and starts its stream processor
+func (s *Service) AddFrequency(freqConfig cfg.FrequencyConfig) (*Frequency, error) {
+	if err := validateFrequency(freqConfig); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidFrequency, err)
+	}
+
+	s.changeMu.Lock()
+	defer s.changeMu.Unlock()
+
+	s.freqMu.RLock()
+	_, exists := s.frequenciesConfig[freqConfig.ID]
+	s.freqMu.RUnlock()
+	if exists {
+		return nil, fmt.Errorf("%w: %s", ErrFrequencyExists, freqConfig.ID)
+	}
+
+	if err := s.persistFrequency(freqConfig); err != nil {
+		return nil, err
+	}
+
+	s.freqMu.Lock()
+	s.frequenciesConfig[freqConfig.ID] = &freqConfig
+	s.freqMu.Unlock()
+
+	s.transcriptionManager.SetFrequencyName(freqConfig.ID, freqConfig.Name)
+
+	// A failed start is not fatal: the stream processor is created on demand
+	// when the first client connects
+	if err := s.startFrequency(freqConfig); err != nil {
+		s.logger.Error("Failed to start added frequency",
+			String("id", freqConfig.ID),
+			Error(err))
+	}
+
+	s.logger.Info("Added frequency",
+		String("id", freqConfig.ID),
+		String("name", freqConfig.Name))
+
+	return s.toFrequency(&freqConfig), nil
+}
+
+// UpdateFrequency applies a partial update to a frequency. Changing the URL restarts
+// the stream processor; toggling transcribe_audio starts or stops transcription.
+func (s *Service) UpdateFrequency(id string, update FrequencyUpdate) (*Frequency, error) {
+	s.changeMu.Lock()
+	defer s.changeMu.Unlock()
+
+	s.freqMu.RLock()
+	current, exists := s.frequenciesConfig[id]
+	var previous cfg.FrequencyConfig
+	if exists {
+		previous = *current
+	}
+	s.freqMu.RUnlock()
+	if !exists {
+		return nil, fmt.Errorf("%w: %s", ErrFrequencyNotFound, id)
+	}
+
+	updated := previous
+	update.apply(&updated)
+	if err := validateFrequency(updated); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidFrequency, err)
+	}
+
+	if err := s.persistFrequency(updated); err != nil {
+		return nil, err
+	}
+
+	s.freqMu.Lock()
+	s.frequenciesConfig[id] = &updated
+	s.freqMu.Unlock()
+
+	s.transcriptionManager.SetFrequencyName(id, updated.Name)
+
+	switch {
+	case updated.URL != previous.URL:
+		s.stopFrequency(id)
+		if err := s.startFrequency(updated); err != nil {
+			s.logger.Error("Failed to restart frequency",
+				String("id", id),
+				Error(err))
+		}
+	case updated.TranscribeAudio && !previous.TranscribeAudio:
+		s.streamsMu.RLock()
+		processor, running := s.activeStreams[id]
+		s.streamsMu.RUnlock()
+		if running {
+			s.startTranscription(updated, processor)
+		} else if err := s.startFrequency(updated); err != nil {
+			s.logger.Error("Failed to start frequency",
+				String("id", id),
+				Error(err))
+		}
+	case !updated.TranscribeAudio && previous.TranscribeAudio:
+		s.transcriptionManager.StopTranscription(id)
+	}
+
+	s.logger.Info("Updated frequency",
+		String("id", id),
+		String("name", updated.Name))
+
+	return s.toFrequency(&updated), nil
+}
+
+// RemoveFrequency stops a frequency and removes it from the available frequencies
+func (s *Service) RemoveFrequency(id string) error {
+	s.changeMu.Lock()
+	defer s.changeMu.Unlock()
+
+	s.freqMu.RLock()
+	_, exists := s.frequenciesConfig[id]
+	s.freqMu.RUnlock()
+	if !exists {
+		return fmt.Errorf("%w: %s", ErrFrequencyNotFound, id)
+	}
+
+	if s.frequencyStorage != nil {
+		if err := s.frequencyStorage.MarkFrequencyDeleted(id); err != nil {
+			return err
+		}
+	}
+
+	s.freqMu.Lock()
+	delete(s.frequenciesConfig, id)
+	s.freqMu.Unlock()
+
+	s.stopFrequency(id)
+	s.transcriptionManager.RemoveFrequencyName(id)
+
+	s.logger.Info("Removed frequency", String("id", id))
+
+	return nil
+}
+
+// GetRecordings returns the recorded segments of a frequency that overlap the time range
+func (s *Service) GetRecordings(id string, from, to time.Time) ([]*sqlite.RecordingSegment, error) {
+	if s.archiver == nil {
+		return nil, ErrRecordingDisabled
+	}
+
+	s.freqMu.RLock()
+	_, exists := s.frequenciesConfig[id]
+	s.freqMu.RUnlock()
+	if !exists {
+		return nil, fmt.Errorf("%w: %s", ErrFrequencyNotFound, id)
+	}
+
+	return s.archiver.GetSegments(id, from, to)
+}
+
+// GetRecordingSegment returns a single recorded segment
+func (s *Service) GetRecordingSegment(segmentID int64) (*sqlite.RecordingSegment, error) {
+	if s.archiver == nil {
+		return nil, ErrRecordingDisabled
+	}
+
+	segment, err := s.archiver.GetSegment(segmentID)
+	if err != nil {
+		return nil, err
+	}
+	if segment == nil {
+		return nil, fmt.Errorf("%w: %d", ErrRecordingNotFound, segmentID)
+	}
+
+	return segment, nil
+}
+
+// PruneRecordings deletes recorded segments that ended before the cutoff and returns how
+// many were deleted. Nothing is deleted when recording is disabled.
+func (s *Service) PruneRecordings(cutoff time.Time) (int, error) {
+	if s.archiver == nil {
+		return 0, nil
+	}
+	return s.archiver.PruneSegments(cutoff)
+}
+
+// LocateRecording returns the parts of a frequency's recorded segments that cover a time range.
+// The frequency does not have to be configured any more, so older transmissions stay playable.
+func (s *Service) LocateRecording(id string, from, to time.Time) ([]audio.SegmentSlice, error) {
+	if s.archiver == nil {
+		return nil, ErrRecordingDisabled
+	}
+
+	slices, err := s.archiver.Locate(id, from, to)
+	if err != nil {
+		return nil, err
+	}
+	if len(slices) == 0 {
+		return nil, fmt.Errorf("%w: no audio for %s between %s and %s", ErrRecordingNotFound, id, from.Format(time.RFC3339), to.Format(time.RFC3339))
+	}
+
+	return slices, nil
+}
+
+// WriteRecordingClip writes a frequency's recorded audio for a time range to w and
+// returns its content type
+func (s *Service) WriteRecordingClip(ctx context.Context, id string, from, to time.Time, w io.Writer) (string, error) {
+	slices, err := s.LocateRecording(id, from, to)
+	if err != nil {
+		return "", err
+	}
+
+	if err := s.archiver.WriteClip(ctx, slices, w); err != nil {
+		return "", err
+	}
+
+	return audio.SegmentContentType(slices[0].Format), nil
+}
+
+// validateFrequency checks a frequency's settings, including the SDR or pipe options in its URL
+func validateFrequency(freqConfig cfg.FrequencyConfig) error {
+	if err := freqConfig.Validate(); err != nil {
+		return err
+	}
+	_, err := audio.ParseSource(freqConfig.URL)
+	return err
+}
+
+// persistFrequency stores a runtime frequency change so it survives a restart
+func (s *Service) persistFrequency(freqConfig cfg.FrequencyConfig) error {
+	if s.frequencyStorage == nil {
+		return nil
+	}
+
+	return s.frequencyStorage.UpsertFrequency(&sqlite.FrequencyRecord{
+		ID:              freqConfig.ID,
+		Airport:         freqConfig.Airport,
+		Name:            freqConfig.Name,
+		FrequencyMHz:    freqConfig.FrequencyMHz,
+		URL:             freqConfig.URL,
+		Order:           freqConfig.Order,
+		TranscribeAudio: freqConfig.TranscribeAudio,
+		AudioDelayMs:    freqConfig.AudioDelayMs,
+		UpdatedAt:       time.Now().UTC(),
+	})
+}
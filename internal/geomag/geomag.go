@@ -0,0 +1,126 @@
+// Package geomag computes the magnetic declination (variation) of a location and date with
+// the World Magnetic Model, to convert between true and magnetic headings.
+package geomag
+
+import (
+	"math"
+	"time"
+)
+
+const (
+	wgs84A          = 6378.137          // WGS-84 semi-major axis (km)
+	wgs84F          = 1 / 298.257223563 // WGS-84 flattening
+	referenceRadius = 6371.2            // Geomagnetic reference radius (km)
+	feetToKm        = 0.0003048
+)
+
+// schmidt are the Schmidt semi-normalization factors of each degree and order
+var schmidt = schmidtFactors()
+
+// Declination returns the magnetic declination in degrees at a location, altitude (feet
+// above the ellipsoid) and time: positive when magnetic north is east of true north.
+// Times after the model's validity are extrapolated, losing accuracy each year.
+func Declination(lat, lon, altitudeFt float64, t time.Time) float64 {
+	// Geodetic to geocentric spherical coordinates
+	e2 := wgs84F * (2 - wgs84F)
+	phi := lat * math.Pi / 180
+	lambda := lon * math.Pi / 180
+	height := altitudeFt * feetToKm
+	rc := wgs84A / math.Sqrt(1-e2*math.Sin(phi)*math.Sin(phi))
+	p := (rc + height) * math.Cos(phi)
+	z := (rc*(1-e2) + height) * math.Sin(phi)
+	r := math.Hypot(p, z)
+	phiC := math.Asin(z / r)
+
+	// Associated Legendre functions of the geocentric colatitude and their derivatives
+	cosTheta := math.Sin(phiC)
+	sinTheta := math.Max(math.Cos(phiC), 1e-10) // Keeps the east component finite at the poles
+	var pnm, dpnm [maxDegree + 1][maxDegree + 1]float64
+	pnm[0][0] = 1
+	for n := 1; n <= maxDegree; n++ {
+		for m := 0; m <= n; m++ {
+			switch {
+			case n == m:
+				pnm[n][m] = sinTheta * pnm[n-1][m-1]
+				dpnm[n][m] = sinTheta*dpnm[n-1][m-1] + cosTheta*pnm[n-1][m-1]
+			case n == 1 || m > n-2:
+				pnm[n][m] = cosTheta * pnm[n-1][m]
+				dpnm[n][m] = cosTheta*dpnm[n-1][m] - sinTheta*pnm[n-1][m]
+			default:
+				k := float64((n-1)*(n-1)-m*m) / float64((2*n-1)*(2*n-3))
+				pnm[n][m] = cosTheta*pnm[n-1][m] - k*pnm[n-2][m]
+				dpnm[n][m] = cosTheta*dpnm[n-1][m] - sinTheta*pnm[n-1][m] - k*dpnm[n-2][m]
+			}
+		}
+	}
+
+	// Field components in geocentric north, east and down
+	dt := decimalYear(t) - modelEpoch
+	var north, east, down float64
+	for _, c := range coefficients {
+		g := c.g + c.gDot*dt
+		h := c.h + c.hDot*dt
+		scale := math.Pow(referenceRadius/r, float64(c.n+2)) * schmidt[c.n][c.m]
+		cosM := math.Cos(float64(c.m) * lambda)
+		sinM := math.Sin(float64(c.m) * lambda)
+		north += scale * (g*cosM + h*sinM) * dpnm[c.n][c.m]
+		east += scale * float64(c.m) * (g*sinM - h*cosM) * pnm[c.n][c.m] / sinTheta
+		down -= scale * float64(c.n+1) * (g*cosM + h*sinM) * pnm[c.n][c.m]
+	}
+
+	// Rotate north back to the geodetic frame; east is the same in both
+	psi := phiC - phi
+	north = north*math.Cos(psi) - down*math.Sin(psi)
+
+	return math.Atan2(east, north) * 180 / math.Pi
+}
+
+// ToMagnetic converts a true heading to magnetic with a declination
+func ToMagnetic(trueHeading, declination float64) float64 {
+	return normalize(trueHeading - declination)
+}
+
+// ToTrue converts a magnetic heading to true with a declination
+func ToTrue(magneticHeading, declination float64) float64 {
+	return normalize(magneticHeading + declination)
+}
+
+// Expired reports whether a time is past the model's validity, when it should be replaced
+func Expired(t time.Time) bool {
+	return decimalYear(t) >= modelExpiry
+}
+
+// normalize returns a heading in [0, 360)
+func normalize(heading float64) float64 {
+	heading = math.Mod(heading, 360)
+	if heading < 0 {
+		heading += 360
+	}
+	return heading
+}
+
+// decimalYear returns a time as a year with its fraction, e.g. 2025.5 in early July
+func decimalYear(t time.Time) float64 {
+	t = t.UTC()
+	start := time.Date(t.Year(), 1, 1, 0, 0, 0, 0, time.UTC)
+	end := start.AddDate(1, 0, 0)
+	return float64(t.Year()) + float64(t.Sub(start))/float64(end.Sub(start))
+}
+
+// schmidtFactors returns the factors turning Gauss-normalized Legendre functions into
+// Schmidt semi-normalized ones
+func schmidtFactors() [maxDegree + 1][maxDegree + 1]float64 {
+	var s [maxDegree + 1][maxDegree + 1]float64
+	s[0][0] = 1
+	for n := 1; n <= maxDegree; n++ {
+		s[n][0] = s[n-1][0] * float64(2*n-1) / float64(n)
+		for m := 1; m <= n; m++ {
+			j := 1.0
+			if m == 1 {
+				j = 2
+			}
+			s[n][m] = s[n][m-1] * math.Sqrt(float64(n-m+1)*j/float64(n+m))
+		}
+	}
+	return s
+}
@@ -0,0 +1,119 @@
+package harness
+
+import (
+	"encoding/json"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"time"
+
+	"github.com/yegors/co-atc/internal/adsb"
+	"github.com/yegors/co-atc/pkg/logger"
+)
+
+// ADSBServer is a fake ADS-B receiver serving aircraft.json like dump1090/tar1090
+type ADSBServer struct {
+	server   *httptest.Server
+	aircraft []adsb.ADSBTarget
+	requests int
+	mu       sync.Mutex
+	logger   *logger.Logger
+}
+
+// NewADSBServer starts a fake receiver with no aircraft
+func NewADSBServer(logger *logger.Logger) *ADSBServer {
+	s := &ADSBServer{
+		logger: logger.Named("harness-adsb"),
+	}
+	s.server = httptest.NewServer(http.HandlerFunc(s.handleAircraft))
+	return s
+}
+
+// URL returns the URL of aircraft.json
+func (s *ADSBServer) URL() string {
+	return s.server.URL + "/data/aircraft.json"
+}
+
+// SetAircraft replaces the aircraft the receiver reports
+func (s *ADSBServer) SetAircraft(aircraft []adsb.ADSBTarget) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.aircraft = append([]adsb.ADSBTarget(nil), aircraft...)
+}
+
+// Update changes one aircraft in place, adding it if the receiver doesn't report it yet
+func (s *ADSBServer) Update(hex string, update func(target *adsb.ADSBTarget)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for i := range s.aircraft {
+		if s.aircraft[i].Hex == hex {
+			update(&s.aircraft[i])
+			return
+		}
+	}
+	target := adsb.ADSBTarget{Hex: hex}
+	update(&target)
+	s.aircraft = append(s.aircraft, target)
+}
+
+// Remove stops reporting an aircraft, as if it went out of range
+func (s *ADSBServer) Remove(hex string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for i := range s.aircraft {
+		if s.aircraft[i].Hex == hex {
+			s.aircraft = append(s.aircraft[:i], s.aircraft[i+1:]...)
+			return
+		}
+	}
+}
+
+// Advance moves every aircraft along its track at its ground speed and vertical rate, so
+// successive polls show movement the flight phase logic can follow
+func (s *ADSBServer) Advance(elapsed time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	hours := elapsed.Hours()
+	for i := range s.aircraft {
+		a := &s.aircraft[i]
+		distanceNM := a.GS * hours
+		track := a.Track * math.Pi / 180
+		a.Lat += distanceNM * math.Cos(track) / 60
+		a.Lon += distanceNM * math.Sin(track) / (60 * math.Cos(a.Lat*math.Pi/180))
+		a.AltBaro = math.Max(0, a.AltBaro+a.BaroRate*elapsed.Minutes())
+		a.AltGeom = a.AltBaro
+	}
+}
+
+// Requests returns how many times aircraft.json has been fetched
+func (s *ADSBServer) Requests() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.requests
+}
+
+// Close stops the receiver
+func (s *ADSBServer) Close() {
+	s.server.Close()
+}
+
+// handleAircraft serves the current aircraft
+func (s *ADSBServer) handleAircraft(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	s.requests++
+	data := adsb.RawAircraftData{
+		Now:      float64(time.Now().UnixMilli()) / 1000,
+		Messages: s.requests,
+		Aircraft: append([]adsb.ADSBTarget(nil), s.aircraft...),
+	}
+	s.mu.Unlock()
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(data); err != nil {
+		s.logger.Error("Failed to encode aircraft", logger.Error(err))
+	}
+}
@@ -0,0 +1,173 @@
+package harness
+
+import (
+	"encoding/binary"
+	"io"
+	"math"
+	"math/rand"
+	"net/http"
+	"net/http/httptest"
+	"time"
+
+	"github.com/yegors/co-atc/pkg/logger"
+)
+
+// Segment is a stretch of synthetic radio audio: a tone standing in for a transmission,
+// or background noise between transmissions when ToneHz is 0
+type Segment struct {
+	Duration time.Duration
+	ToneHz   float64
+	LevelDB  float64 // Level in dBFS (default: -12 for tones, -60 for noise)
+}
+
+// Transmissions returns a script of count transmissions of the given length, separated
+// by gaps of background noise
+func Transmissions(count int, length, gap time.Duration) []Segment {
+	var script []Segment
+	for i := 0; i < count; i++ {
+		script = append(script,
+			Segment{Duration: gap},
+			Segment{Duration: length, ToneHz: 440 + float64(i%4)*110},
+		)
+	}
+	return append(script, Segment{Duration: gap})
+}
+
+// AudioGenerator produces mono PCM16 audio from a script, looping it forever
+type AudioGenerator struct {
+	sampleRate int
+	script     []Segment
+	segment    int // Segment being generated
+	sample     int // Samples generated in the segment
+	noise      *rand.Rand
+}
+
+// NewAudioGenerator creates a generator for the script at the sample rate
+func NewAudioGenerator(sampleRate int, script []Segment) *AudioGenerator {
+	if len(script) == 0 {
+		script = []Segment{{Duration: time.Second}}
+	}
+	return &AudioGenerator{
+		sampleRate: sampleRate,
+		script:     script,
+		noise:      rand.New(rand.NewSource(1)),
+	}
+}
+
+// Read fills p with whole PCM16 samples
+func (g *AudioGenerator) Read(p []byte) (int, error) {
+	n := 0
+	for ; n+2 <= len(p); n += 2 {
+		segment := g.script[g.segment]
+		length := int(segment.Duration.Seconds() * float64(g.sampleRate))
+		if g.sample >= length {
+			g.segment = (g.segment + 1) % len(g.script)
+			g.sample = 0
+			continue
+		}
+
+		var value float64
+		if segment.ToneHz > 0 {
+			level := segment.LevelDB
+			if level == 0 {
+				level = -12
+			}
+			t := float64(g.sample) / float64(g.sampleRate)
+			value = math.Pow(10, level/20) * math.Sin(2*math.Pi*segment.ToneHz*t)
+		} else {
+			level := segment.LevelDB
+			if level == 0 {
+				level = -60
+			}
+			value = math.Pow(10, level/20) * (g.noise.Float64()*2 - 1)
+		}
+
+		binary.LittleEndian.PutUint16(p[n:], uint16(int16(value*math.MaxInt16)))
+		g.sample++
+	}
+	return n, nil
+}
+
+// AudioServer is a fake radio stream serving the generated audio as an endless WAV
+// file, paced in real time like a live stream
+type AudioServer struct {
+	server     *httptest.Server
+	sampleRate int
+	script     []Segment
+	logger     *logger.Logger
+}
+
+// NewAudioServer starts a stream playing the script in a loop
+func NewAudioServer(sampleRate int, script []Segment, logger *logger.Logger) *AudioServer {
+	s := &AudioServer{
+		sampleRate: sampleRate,
+		script:     script,
+		logger:     logger.Named("harness-audio"),
+	}
+	s.server = httptest.NewServer(http.HandlerFunc(s.handleStream))
+	return s
+}
+
+// URL returns the URL of the stream
+func (s *AudioServer) URL() string {
+	return s.server.URL + "/stream.wav"
+}
+
+// Close stops the stream
+func (s *AudioServer) Close() {
+	s.server.CloseClientConnections()
+	s.server.Close()
+}
+
+// handleStream streams audio to a listener until it disconnects. Every listener hears the
+// script from the start.
+func (s *AudioServer) handleStream(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "audio/wav")
+	if _, err := w.Write(wavHeader(s.sampleRate)); err != nil {
+		return
+	}
+
+	generator := NewAudioGenerator(s.sampleRate, s.script)
+	const chunk = 100 * time.Millisecond
+	buffer := make([]byte, int(chunk.Seconds()*float64(s.sampleRate))*2)
+	ticker := time.NewTicker(chunk)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-ticker.C:
+			if _, err := io.ReadFull(generator, buffer); err != nil {
+				return
+			}
+			if _, err := w.Write(buffer); err != nil {
+				s.logger.Debug("Listener disconnected", logger.Error(err))
+				return
+			}
+			if flusher, ok := w.(http.Flusher); ok {
+				flusher.Flush()
+			}
+		}
+	}
+}
+
+// wavHeader returns the header of a mono PCM16 WAV file of unknown length
+func wavHeader(sampleRate int) []byte {
+	const unknownLength = 0xFFFFFFFF
+	header := make([]byte, 44)
+	copy(header[0:], "RIFF")
+	binary.LittleEndian.PutUint32(header[4:], unknownLength)
+	copy(header[8:], "WAVE")
+	copy(header[12:], "fmt ")
+	binary.LittleEndian.PutUint32(header[16:], 16)
+	binary.LittleEndian.PutUint16(header[20:], 1) // PCM
+	binary.LittleEndian.PutUint16(header[22:], 1) // Mono
+	binary.LittleEndian.PutUint32(header[24:], uint32(sampleRate))
+	binary.LittleEndian.PutUint32(header[28:], uint32(sampleRate*2))
+	binary.LittleEndian.PutUint16(header[32:], 2)
+	binary.LittleEndian.PutUint16(header[34:], 16)
+	copy(header[36:], "data")
+	binary.LittleEndian.PutUint32(header[40:], unknownLength)
+	return header
+}
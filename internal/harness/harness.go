@@ -0,0 +1,69 @@
+// Package harness provides fake versions of the services co-atc depends on — an ADS-B
+// receiver, a radio audio stream and OpenAI — so the ADS-B and transcription pipelines can
+// be run end to end without hardware or API keys.
+package harness
+
+import (
+	"github.com/yegors/co-atc/internal/config"
+	"github.com/yegors/co-atc/pkg/logger"
+)
+
+// FrequencyID is the ID of the frequency the harness configures for its audio stream
+const FrequencyID = "harness"
+
+// Harness runs the fake ADS-B receiver, audio stream and OpenAI API together
+type Harness struct {
+	ADSB   *ADSBServer
+	Audio  *AudioServer
+	OpenAI *OpenAIServer
+	logger *logger.Logger
+}
+
+// New starts the fake services. The audio stream plays script in a loop.
+func New(sampleRate int, script []Segment, logger *logger.Logger) *Harness {
+	return &Harness{
+		ADSB:   NewADSBServer(logger),
+		Audio:  NewAudioServer(sampleRate, script, logger),
+		OpenAI: NewOpenAIServer(sampleRate, logger),
+		logger: logger.Named("harness"),
+	}
+}
+
+// Configure points a configuration at the fake services: ADS-B from the fake receiver,
+// one transcribed frequency playing the synthetic audio, and transcription and
+// post-processing through the fake OpenAI API
+func (h *Harness) Configure(cfg *config.Config) {
+	cfg.ADSB.SourceType = "local"
+	cfg.ADSB.LocalSourceURL = h.ADSB.URL()
+
+	// The stream is WAV, which the built-in decoder reads without ffmpeg
+	cfg.Frequencies.AudioDecoder = "builtin"
+	cfg.Frequencies.Sources = []config.FrequencyConfig{
+		{
+			ID:              FrequencyID,
+			Airport:         cfg.Station.AirportCode,
+			Name:            "Harness",
+			FrequencyMHz:    118.7,
+			URL:             h.Audio.URL(),
+			TranscribeAudio: true,
+		},
+	}
+
+	cfg.Transcription.Provider = "openai"
+	cfg.Transcription.OpenAIAPIKey = FakeAPIKey
+	cfg.Transcription.OpenAIBaseURL = h.OpenAI.URL()
+	cfg.PostProcessing.Enabled = true
+	cfg.PostProcessing.LLM = config.LLMConfig{Provider: "openai", BaseURL: h.OpenAI.URL(), APIKey: FakeAPIKey}
+
+	h.logger.Info("Configured fake services",
+		logger.String("adsb_url", h.ADSB.URL()),
+		logger.String("audio_url", h.Audio.URL()),
+		logger.String("openai_url", h.OpenAI.URL()))
+}
+
+// Close stops the fake services
+func (h *Harness) Close() {
+	h.ADSB.Close()
+	h.Audio.Close()
+	h.OpenAI.Close()
+}
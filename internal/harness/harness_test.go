@@ -0,0 +1,254 @@
+package harness
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	gorillaws "github.com/gorilla/websocket"
+	"github.com/yegors/co-atc/internal/adsb"
+	"github.com/yegors/co-atc/internal/config"
+	"github.com/yegors/co-atc/internal/frequencies"
+	"github.com/yegors/co-atc/internal/storage/sqlite"
+	"github.com/yegors/co-atc/internal/templating"
+	"github.com/yegors/co-atc/internal/websocket"
+	"github.com/yegors/co-atc/pkg/logger"
+	"go.uber.org/zap"
+)
+
+// sampleRate is the rate of the synthetic audio, the transcription default
+const sampleRate = 24000
+
+// testLogger returns a logger that discards everything
+func testLogger() *logger.Logger {
+	return &logger.Logger{Logger: zap.NewNop()}
+}
+
+// testConfig loads the example config pointed at the harness, with storage in a temporary
+// directory and asset paths resolved from the repository root
+func testConfig(t *testing.T, h *Harness) *config.Config {
+	t.Helper()
+	cfg, err := config.Load("../../configs/config.toml.example")
+	if err != nil {
+		t.Fatal(err)
+	}
+	h.Configure(cfg)
+
+	cfg.Storage.SQLiteBasePath = t.TempDir()
+	cfg.Station.RunwaysDBPath = "../../" + cfg.Station.RunwaysDBPath
+	cfg.Transcription.PromptPath = "../../" + cfg.Transcription.PromptPath
+	cfg.PostProcessing.SystemPromptPath = "../../" + cfg.PostProcessing.SystemPromptPath
+	cfg.PostProcessing.IntervalSeconds = 1
+	return cfg
+}
+
+// openStorage opens the daily database in the config's storage directory
+func openStorage(t *testing.T, cfg *config.Config, log *logger.Logger) *sqlite.AircraftStorage {
+	t.Helper()
+	storage, err := sqlite.NewAircraftStorage(filepath.Join(cfg.Storage.SQLiteBasePath, "co-atc-test.db"), cfg.Storage.MaxPositionsInAPI, sqlite.WriteOptions{}, log)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { storage.Close() })
+	return storage
+}
+
+// startADSB starts an ADS-B service polling the fake receiver
+func startADSB(t *testing.T, cfg *config.Config, storage adsb.Storage, wsServer *websocket.Server, log *logger.Logger) *adsb.Service {
+	t.Helper()
+	client := adsb.NewClient(cfg.ADSB.SourceType, cfg.ADSB.LocalSourceURL, cfg.ADSB.ExternalSourceURL, cfg.ADSB.APIHost, cfg.ADSB.APIKey,
+		cfg.Station.Latitude, cfg.Station.Longitude, float64(cfg.ADSB.SearchRadiusNM), 5*time.Second, log)
+	service := adsb.NewService(client, storage, 200*time.Millisecond, cfg.Storage.MaxPositionsInAPI, "",
+		log, cfg.Station, cfg.ADSB, cfg.FlightPhases, wsServer, nil)
+	if err := service.Start(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(service.Stop)
+	return service
+}
+
+// wsClient is a WebSocket client of a running server
+type wsClient struct {
+	conn *gorillaws.Conn
+}
+
+// connect starts a WebSocket server and connects a client to it
+func connect(t *testing.T, server *websocket.Server) *wsClient {
+	t.Helper()
+	go server.Run()
+
+	httpServer := httptest.NewServer(http.HandlerFunc(server.HandleConnection))
+	t.Cleanup(httpServer.Close)
+
+	conn, _, err := gorillaws.DefaultDialer.Dial("ws"+strings.TrimPrefix(httpServer.URL, "http"), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return &wsClient{conn: conn}
+}
+
+// await reads messages until one matches, failing the test if none does in time
+func (c *wsClient) await(t *testing.T, timeout time.Duration, description string, match func(message websocket.Message) bool) websocket.Message {
+	t.Helper()
+	deadline := time.Now().Add(timeout)
+	c.conn.SetReadDeadline(deadline)
+	for {
+		var message websocket.Message
+		if err := c.conn.ReadJSON(&message); err != nil {
+			t.Fatalf("no %s within %s: %v", description, timeout, err)
+		}
+		if match(message) {
+			return message
+		}
+	}
+}
+
+// batchAircraft returns the aircraft added or updated by an aircraft_batch message, by hex
+func batchAircraft(t *testing.T, message websocket.Message) map[string]adsb.Aircraft {
+	t.Helper()
+	aircraft := make(map[string]adsb.Aircraft)
+	if message.Type != websocket.MessageTypeAircraftBatch {
+		return aircraft
+	}
+	for _, key := range []string{"adds", "updates"} {
+		data, err := json.Marshal(message.Data[key])
+		if err != nil {
+			t.Fatal(err)
+		}
+		var list []adsb.Aircraft
+		if err := json.Unmarshal(data, &list); err != nil {
+			t.Fatal(err)
+		}
+		for _, a := range list {
+			aircraft[a.Hex] = a
+		}
+	}
+	return aircraft
+}
+
+// currentPhase returns an aircraft's current flight phase
+func currentPhase(a adsb.Aircraft) string {
+	if a.Phase == nil || len(a.Phase.Current) == 0 {
+		return ""
+	}
+	return a.Phase.Current[0].Phase
+}
+
+func TestADSBPollPhaseBroadcast(t *testing.T) {
+	log := testLogger()
+	h := New(sampleRate, nil, log)
+	defer h.Close()
+	cfg := testConfig(t, h)
+	cfg.ADSB.WebSocketAircraftUpdates = true
+
+	lat, lon := cfg.Station.Latitude, cfg.Station.Longitude
+	h.ADSB.SetAircraft([]adsb.ADSBTarget{
+		{Hex: "c01234", Flight: "ACA123  ", Lat: lat + 0.2, Lon: lon, AltBaro: 35000, AltGeom: 35000, GS: 450, Track: 90},
+		{Hex: "c05678", Flight: "WJA456  ", Lat: lat + 0.005, Lon: lon + 0.005, GS: 15, Track: 180},
+	})
+
+	wsServer := websocket.NewServer(log)
+	client := connect(t, wsServer)
+
+	startADSB(t, cfg, openStorage(t, cfg, log), wsServer, log)
+
+	// Both aircraft are added with the phase their first poll shows
+	phases := make(map[string]string)
+	client.await(t, 10*time.Second, "batch adding both aircraft", func(message websocket.Message) bool {
+		for hex, a := range batchAircraft(t, message) {
+			phases[hex] = currentPhase(a)
+		}
+		return phases["c01234"] != "" && phases["c05678"] != ""
+	})
+	if phases["c01234"] != "CRZ" {
+		t.Errorf("aircraft at FL350 has phase %q, want CRZ", phases["c01234"])
+	}
+	if phases["c05678"] != "TAX" {
+		t.Errorf("aircraft moving at 15 kt on the ground has phase %q, want TAX", phases["c05678"])
+	}
+
+	// Lifting off is broadcast as a phase change. Phase changes are stored to the second, so
+	// the aircraft taxis into the next second first.
+	time.Sleep(time.Second)
+	h.ADSB.Update("c05678", func(target *adsb.ADSBTarget) {
+		target.AltBaro, target.AltGeom, target.GS, target.BaroRate = 800, 800, 160, 2000
+	})
+	client.await(t, 10*time.Second, "batch with the takeoff", func(message websocket.Message) bool {
+		a, ok := batchAircraft(t, message)["c05678"]
+		return ok && currentPhase(a) == "T/O"
+	})
+	if h.ADSB.Requests() < 2 {
+		t.Errorf("receiver was polled %d times", h.ADSB.Requests())
+	}
+}
+
+func TestAudioTranscriptionClearance(t *testing.T) {
+	log := testLogger()
+	h := New(sampleRate, Transmissions(1, 2*time.Second, time.Second), log)
+	defer h.Close()
+	h.OpenAI.SetExchanges([]Exchange{{
+		Transcript:  "Air Canada 123, runway 24R, cleared to land",
+		SpeakerType: "ATC",
+		Callsign:    "ACA123",
+		Clearances: []sqlite.ExtractedClearance{
+			{Callsign: "ACA123", Type: "landing", Text: "runway 24R, cleared to land", Runway: "24R"},
+		},
+	}})
+	cfg := testConfig(t, h)
+	h.ADSB.SetAircraft([]adsb.ADSBTarget{
+		{Hex: "c01234", Flight: "ACA123  ", Lat: cfg.Station.Latitude + 0.1, Lon: cfg.Station.Longitude, AltBaro: 3000, AltGeom: 3000, GS: 160, Track: 240, BaroRate: -700},
+	})
+
+	wsServer := websocket.NewServer(log)
+	client := connect(t, wsServer)
+
+	// Post-processing resolves callsigns against the tracked aircraft through the templating service
+	storage := openStorage(t, cfg, log)
+	transcriptionStorage := sqlite.NewTranscriptionStorage(storage.GetDB(), log)
+	clearanceStorage := sqlite.NewClearanceStorage(storage.GetDB(), log)
+	adsbService := startADSB(t, cfg, storage, wsServer, log)
+	templateService := templating.NewService(adsbService, nil, transcriptionStorage, nil, cfg, log)
+	service := frequencies.NewService(cfg, log, wsServer, transcriptionStorage, storage, clearanceStorage, nil, nil, templateService, nil)
+	if err := service.Start(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	defer service.Stop()
+
+	// The transmission is transcribed when its tone ends
+	transcribed := client.await(t, 15*time.Second, "completed transcription", func(message websocket.Message) bool {
+		complete, _ := message.Data["is_complete"].(bool)
+		_, stored := message.Data["id"]
+		return message.Type == "transcription" && complete && stored
+	})
+	if text := transcribed.Data["text"]; text != "Air Canada 123, runway 24R, cleared to land" {
+		t.Errorf("transcription text %q", text)
+	}
+	if frequencyID := transcribed.Data["frequency_id"]; frequencyID != FrequencyID {
+		t.Errorf("transcription of frequency %q, want %q", frequencyID, FrequencyID)
+	}
+
+	// Post-processing attributes it and extracts the clearance
+	processed := client.await(t, 15*time.Second, "processed transcription", func(message websocket.Message) bool {
+		return message.Type == "transcription_update" && message.Data["id"] == transcribed.Data["id"]
+	})
+	if processed.Data["speaker_type"] != "ATC" || processed.Data["callsign"] != "ACA123" {
+		t.Errorf("processed as speaker %v, callsign %v", processed.Data["speaker_type"], processed.Data["callsign"])
+	}
+	clearance := client.await(t, 15*time.Second, "clearance", func(message websocket.Message) bool {
+		return message.Type == "clearance_issued"
+	})
+	if clearance.Data["callsign"] != "ACA123" || clearance.Data["clearance_type"] != "landing" || clearance.Data["runway"] != "24R" ||
+		clearance.Data["aircraft_hex"] != "c01234" {
+		t.Errorf("clearance %v", clearance.Data)
+	}
+
+	if h.OpenAI.Sessions() == 0 || h.OpenAI.Completions() == 0 {
+		t.Errorf("fake OpenAI API got %d sessions and %d completions", h.OpenAI.Sessions(), h.OpenAI.Completions())
+	}
+}
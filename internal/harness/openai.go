@@ -0,0 +1,345 @@
+package harness
+
+import (
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/yegors/co-atc/internal/storage/sqlite"
+	"github.com/yegors/co-atc/internal/transcription"
+	"github.com/yegors/co-atc/pkg/logger"
+)
+
+// FakeAPIKey is the API key the fake OpenAI API expects
+const FakeAPIKey = "sk-harness-fake-key"
+
+// fakeVAD settings: how loud audio must be to count as speech, and how long it must be
+// quiet before a turn ends
+const (
+	fakeVADThresholdDB = -30.0
+	fakeVADSilence     = 500 * time.Millisecond
+)
+
+// Exchange is a scripted transmission: what the fake transcriber hears and what the fake
+// post-processor extracts from it
+type Exchange struct {
+	Transcript  string
+	SpeakerType string // Default: "PILOT"
+	Callsign    string
+	Clearances  []sqlite.ExtractedClearance
+}
+
+// OpenAIServer is a fake of the OpenAI endpoints co-atc uses: transcription sessions with
+// a level-based VAD, and chat completions for post-processing. Transmissions get their
+// transcripts from a script of exchanges, in order and looping.
+type OpenAIServer struct {
+	server     *httptest.Server
+	sampleRate int
+	upgrader   websocket.Upgrader
+	logger     *logger.Logger
+
+	mu          sync.Mutex
+	exchanges   []Exchange
+	next        int      // Next exchange to transcribe
+	sessions    int      // Sessions created
+	completions int      // Chat completion requests
+	prompts     []string // Transcription prompts, in the order sessions set them
+}
+
+// NewOpenAIServer starts a fake OpenAI API for audio at the sample rate
+func NewOpenAIServer(sampleRate int, logger *logger.Logger) *OpenAIServer {
+	s := &OpenAIServer{
+		sampleRate: sampleRate,
+		logger:     logger.Named("harness-openai"),
+		exchanges:  []Exchange{{Transcript: "Toronto tower, test transmission"}},
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/v1/realtime/transcription_sessions", s.handleCreateSession)
+	mux.HandleFunc("/v1/realtime", s.handleRealtime)
+	mux.HandleFunc("/v1/chat/completions", s.handleChatCompletions)
+	s.server = httptest.NewServer(mux)
+	return s
+}
+
+// URL returns the base URL of the API
+func (s *OpenAIServer) URL() string {
+	return s.server.URL + "/v1"
+}
+
+// SetExchanges replaces the script of transmissions
+func (s *OpenAIServer) SetExchanges(exchanges []Exchange) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if len(exchanges) > 0 {
+		s.exchanges = append([]Exchange(nil), exchanges...)
+		s.next = 0
+	}
+}
+
+// Sessions returns how many transcription sessions have been created
+func (s *OpenAIServer) Sessions() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.sessions
+}
+
+// Completions returns how many post-processing requests have been made
+func (s *OpenAIServer) Completions() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.completions
+}
+
+// Prompts returns the transcription prompts sessions were created or updated with
+func (s *OpenAIServer) Prompts() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]string(nil), s.prompts...)
+}
+
+// Close stops the API
+func (s *OpenAIServer) Close() {
+	s.server.CloseClientConnections()
+	s.server.Close()
+}
+
+// authorized checks the bearer token of a request
+func (s *OpenAIServer) authorized(w http.ResponseWriter, r *http.Request, token string) bool {
+	if r.Header.Get("Authorization") != "Bearer "+token {
+		http.Error(w, `{"error":{"message":"invalid api key"}}`, http.StatusUnauthorized)
+		return false
+	}
+	return true
+}
+
+// handleCreateSession creates a transcription session
+func (s *OpenAIServer) handleCreateSession(w http.ResponseWriter, r *http.Request) {
+	if !s.authorized(w, r, FakeAPIKey) {
+		return
+	}
+
+	var request struct {
+		InputAudioTranscription struct {
+			Prompt string `json:"prompt"`
+		} `json:"input_audio_transcription"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	s.mu.Lock()
+	s.sessions++
+	id := fmt.Sprintf("sess_harness_%d", s.sessions)
+	s.prompts = append(s.prompts, request.InputAudioTranscription.Prompt)
+	s.mu.Unlock()
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"id":         id,
+		"session_id": id,
+		"client_secret": map[string]interface{}{
+			"value":      "ek_" + id,
+			"expires_at": time.Now().Add(time.Minute).Unix(),
+		},
+	})
+}
+
+// handleRealtime runs a transcription session: a turn starts when the audio gets loud
+// and ends after fakeVADSilence of quiet, when the next scripted transcript is completed
+func (s *OpenAIServer) handleRealtime(w http.ResponseWriter, r *http.Request) {
+	sessionID := r.URL.Query().Get("session_id")
+	if !s.authorized(w, r, "ek_"+sessionID) {
+		return
+	}
+
+	conn, err := s.upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		s.logger.Error("Failed to upgrade realtime connection", logger.Error(err))
+		return
+	}
+	defer conn.Close()
+
+	var (
+		received    time.Duration // Audio received in the session
+		speaking    bool
+		speechStart time.Duration
+		lastSpeech  time.Duration
+		items       int
+	)
+	send := func(event map[string]interface{}) error {
+		return conn.WriteJSON(event)
+	}
+
+	for {
+		var message struct {
+			Type    string `json:"type"`
+			Audio   string `json:"audio"`
+			Session struct {
+				InputAudioTranscription struct {
+					Prompt string `json:"prompt"`
+				} `json:"input_audio_transcription"`
+			} `json:"session"`
+		}
+		if err := conn.ReadJSON(&message); err != nil {
+			return
+		}
+
+		switch message.Type {
+		case "transcription_session.update":
+			s.mu.Lock()
+			s.prompts = append(s.prompts, message.Session.InputAudioTranscription.Prompt)
+			s.mu.Unlock()
+
+		case "input_audio_buffer.append":
+			pcm, err := base64.StdEncoding.DecodeString(message.Audio)
+			if err != nil {
+				send(map[string]interface{}{"type": "error", "error": map[string]string{"code": "invalid_audio", "message": err.Error()}})
+				continue
+			}
+			start := received
+			received += time.Duration(len(pcm)/2) * time.Second / time.Duration(s.sampleRate)
+			loud := levelDB(pcm) > fakeVADThresholdDB
+
+			itemID := fmt.Sprintf("item_%s_%d", sessionID, items)
+			switch {
+			case loud && !speaking:
+				speaking = true
+				speechStart = start
+				lastSpeech = received
+				err = send(map[string]interface{}{
+					"type":           "input_audio_buffer.speech_started",
+					"item_id":        itemID,
+					"audio_start_ms": speechStart.Milliseconds(),
+				})
+			case loud:
+				lastSpeech = received
+			case speaking && received-lastSpeech >= fakeVADSilence:
+				speaking = false
+				items++
+				err = s.completeTurn(send, itemID, lastSpeech)
+			}
+			if err != nil {
+				return
+			}
+		}
+	}
+}
+
+// completeTurn ends a turn and transcribes it with the next scripted exchange
+func (s *OpenAIServer) completeTurn(send func(map[string]interface{}) error, itemID string, end time.Duration) error {
+	s.mu.Lock()
+	exchange := s.exchanges[s.next%len(s.exchanges)]
+	s.next++
+	s.mu.Unlock()
+
+	events := []map[string]interface{}{
+		{"type": "input_audio_buffer.speech_stopped", "item_id": itemID, "audio_end_ms": end.Milliseconds()},
+		{"type": "conversation.item.input_audio_transcription.delta", "item_id": itemID, "delta": exchange.Transcript},
+		{
+			"type":       "conversation.item.input_audio_transcription.completed",
+			"item_id":    itemID,
+			"transcript": exchange.Transcript,
+			"logprobs":   []map[string]float64{{"logprob": -0.05}},
+		},
+	}
+	for _, event := range events {
+		if err := send(event); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// handleChatCompletions post-processes a batch of transcriptions. Each transcription is
+// matched to the scripted exchange with its transcript, which supplies the speaker,
+// callsign and clearances.
+func (s *OpenAIServer) handleChatCompletions(w http.ResponseWriter, r *http.Request) {
+	if !s.authorized(w, r, FakeAPIKey) {
+		return
+	}
+
+	var request struct {
+		Messages []struct {
+			Role    string `json:"role"`
+			Content string `json:"content"`
+		} `json:"messages"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil || len(request.Messages) == 0 {
+		http.Error(w, "invalid request", http.StatusBadRequest)
+		return
+	}
+
+	// The transmissions log is the JSON array at the end of the user message
+	input := request.Messages[len(request.Messages)-1].Content
+	var batch []transcription.TranscriptionBatch
+	if start := strings.Index(input, "["); start >= 0 {
+		if err := json.Unmarshal([]byte(input[start:]), &batch); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+
+	s.mu.Lock()
+	s.completions++
+	var results []transcription.TranscriptionBatch
+	for _, item := range batch {
+		if item.ContentProcessed != "" {
+			continue // Context from earlier batches
+		}
+		result := transcription.TranscriptionBatch{
+			ID:               item.ID,
+			Content:          item.Content,
+			ContentProcessed: item.Content,
+			SpeakerType:      "PILOT",
+			Clearances:       []sqlite.ExtractedClearance{},
+			Timestamp:        item.Timestamp,
+		}
+		for _, exchange := range s.exchanges {
+			if exchange.Transcript != item.Content {
+				continue
+			}
+			if exchange.SpeakerType != "" {
+				result.SpeakerType = exchange.SpeakerType
+			}
+			result.Callsign = exchange.Callsign
+			if exchange.Clearances != nil {
+				result.Clearances = exchange.Clearances
+			}
+			break
+		}
+		results = append(results, result)
+	}
+	s.mu.Unlock()
+
+	content, _ := json.Marshal(results)
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"choices": []map[string]interface{}{
+			{"message": map[string]string{"role": "assistant", "content": string(content)}},
+		},
+	})
+}
+
+// levelDB returns the RMS level of PCM16 audio in dBFS
+func levelDB(pcm []byte) float64 {
+	samples := len(pcm) / 2
+	if samples == 0 {
+		return math.Inf(-1)
+	}
+	sum := 0.0
+	for i := 0; i < samples; i++ {
+		v := float64(int16(binary.LittleEndian.Uint16(pcm[i*2:]))) / math.MaxInt16
+		sum += v * v
+	}
+	return 10 * math.Log10(sum/float64(samples)+1e-12)
+}
@@ -0,0 +1,467 @@
+// Package intent infers what aircraft have been cleared to do and are doing from the
+// clearances extracted from transcriptions and their tracks.
+package intent
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/yegors/co-atc/internal/adsb"
+	"github.com/yegors/co-atc/internal/config"
+	"github.com/yegors/co-atc/internal/storage/sqlite"
+	"github.com/yegors/co-atc/pkg/logger"
+)
+
+const (
+	// clearancePollInterval is how often newly extracted clearances are picked up
+	clearancePollInterval = 5 * time.Second
+	// taxiSpeed is the ground speed above which an aircraft on the ground is moving, in knots
+	taxiSpeed = 3.0
+	// rollSpeed is the ground speed above which an aircraft on the runway is taking off or
+	// rolling out, in knots
+	rollSpeed = 40.0
+	// levelTolerance is the distance from an assigned altitude counted as level at it, in feet
+	levelTolerance = 300.0
+	// verticalRate is the vertical rate above which an aircraft is climbing or descending, in ft/min
+	verticalRate = 300.0
+	// transitionAltitude is the altitude from which assigned altitudes are flight levels, in feet
+	transitionAltitude = 18000
+)
+
+var (
+	// approachTypes finds the type of approach in a clearance
+	approachTypes = regexp.MustCompile(`(?i)\b(ILS|RNAV|RNP|GPS|VOR|LOC|localizer|NDB|visual)\b`)
+	// taxiRoute finds the taxiways of a taxi clearance, up to the next instruction
+	taxiRoute = regexp.MustCompile(`(?i)\bvia\s+(.+?)(?:\s*[,.;]|\s+(?:and\s+)?(?:hold|cross|then|contact|monitor)\b|$)`)
+	// taxiDestination finds where an aircraft taxis to when it's not a runway
+	taxiDestination = regexp.MustCompile(`(?i)\bto\s+(?:the\s+)?(gate|apron|ramp|stand|terminal|hangar|FBO)\b`)
+)
+
+// sample is what inference needs from an aircraft in a poll cycle
+type sample struct {
+	hex          string
+	onGround     bool
+	groundSpeed  float64
+	altitude     float64 // MSL, corrected to the QNH the aircraft sends
+	verticalRate float64
+	phase        string
+	guidance     *adsb.ApproachGuidance
+}
+
+// clearances are the latest clearances of an aircraft that shape its intent
+type clearances struct {
+	runway   *sqlite.ClearanceRecord // Latest takeoff, landing, approach or taxi clearance
+	altitude *sqlite.ClearanceRecord
+	heading  *sqlite.ClearanceRecord
+}
+
+// Service infers an intent per aircraft from its recent clearances and track, and gives it
+// to the ADS-B service to publish with the aircraft
+type Service struct {
+	config           config.IntentsConfig
+	adsbService      *adsb.Service
+	clearanceStorage *sqlite.ClearanceStorage
+	logger           *logger.Logger
+
+	cycles chan []sample
+
+	// Inference state, only used by the worker goroutine
+	clearances    map[string]*clearances // By hex
+	lastClearance int64                  // Highest clearance ID picked up
+	lastSamples   []sample               // Latest poll cycle, to infer again when clearances arrive
+
+	intents   map[string]*adsb.Intent // By hex
+	intentsMu sync.RWMutex
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// NewService creates a new intent inference service
+func NewService(cfg config.IntentsConfig, adsbService *adsb.Service, clearanceStorage *sqlite.ClearanceStorage, logger *logger.Logger) *Service {
+	return &Service{
+		config:           cfg,
+		adsbService:      adsbService,
+		clearanceStorage: clearanceStorage,
+		logger:           logger.Named("intent"),
+		cycles:           make(chan []sample, 4),
+		clearances:       make(map[string]*clearances),
+		intents:          make(map[string]*adsb.Intent),
+	}
+}
+
+// Start starts inferring intents. Must be called before the ADS-B service starts.
+func (s *Service) Start(ctx context.Context) {
+	s.ctx, s.cancel = context.WithCancel(ctx)
+	s.wg.Add(1)
+	go s.run()
+
+	s.adsbService.SetIntentSource(s)
+	s.adsbService.OnUpdate(s.handleUpdate)
+
+	s.logger.Info("Intent inference started",
+		logger.Int("clearance_minutes", s.config.ClearanceMinutes))
+}
+
+// Stop stops inferring intents
+func (s *Service) Stop() {
+	if s.cancel != nil {
+		s.cancel()
+	}
+	s.wg.Wait()
+}
+
+// Intent returns the intent inferred for an aircraft, if any
+func (s *Service) Intent(hex string) (*adsb.Intent, bool) {
+	s.intentsMu.RLock()
+	defer s.intentsMu.RUnlock()
+
+	intent, ok := s.intents[hex]
+	return intent, ok
+}
+
+// handleUpdate hands the aircraft of a poll cycle to the worker without blocking polling
+func (s *Service) handleUpdate(aircraft []*adsb.Aircraft) {
+	samples := make([]sample, 0, len(aircraft))
+	for _, a := range aircraft {
+		if a.ADSB == nil || a.Status != "active" {
+			continue
+		}
+		rate := a.ADSB.BaroRate
+		if rate == 0 {
+			rate = a.ADSB.GeomRate
+		}
+		smp := sample{
+			hex:          strings.ToLower(a.Hex),
+			onGround:     a.OnGround,
+			groundSpeed:  a.ADSB.GS,
+			altitude:     adsb.AltitudeMSL(a.ADSB),
+			verticalRate: rate,
+		}
+		if a.Phase != nil && len(a.Phase.Current) > 0 {
+			smp.phase = a.Phase.Current[0].Phase
+		}
+		if a.Guidance != nil {
+			guidance := *a.Guidance
+			smp.guidance = &guidance
+		}
+		samples = append(samples, smp)
+	}
+
+	select {
+	case s.cycles <- samples:
+	default:
+		s.logger.Debug("Intent worker busy, skipping poll cycle")
+	}
+}
+
+// run picks up new clearances and infers the intents of poll cycles until the service stops
+func (s *Service) run() {
+	defer s.wg.Done()
+
+	ticker := time.NewTicker(clearancePollInterval)
+	defer ticker.Stop()
+
+	s.loadClearances(time.Now().UTC())
+	for {
+		select {
+		case <-s.ctx.Done():
+			return
+		case <-ticker.C:
+			if s.loadClearances(time.Now().UTC()) {
+				s.inferAll(s.lastSamples, time.Now().UTC())
+			}
+		case samples := <-s.cycles:
+			s.lastSamples = samples
+			s.inferAll(samples, time.Now().UTC())
+		}
+	}
+}
+
+// loadClearances keeps the latest clearances of each aircraft extracted since the last load,
+// and reports whether there were any
+func (s *Service) loadClearances(now time.Time) bool {
+	window := time.Duration(s.config.ClearanceMinutes) * time.Minute
+	records, err := s.clearanceStorage.GetClearancesSince(now.Add(-window), s.lastClearance)
+	if err != nil {
+		s.logger.Error("Failed to load clearances", logger.Error(err))
+		return false
+	}
+
+	for _, record := range records {
+		s.lastClearance = max(s.lastClearance, record.ID)
+
+		hex := strings.ToLower(record.AircraftHex)
+		if hex == "" {
+			// Extracted before the aircraft was tracked, or under another form of its callsign
+			entry, ok := s.adsbService.Callsigns().Correlate(record.Callsign)
+			if !ok {
+				continue
+			}
+			hex = strings.ToLower(entry.Hex)
+		}
+
+		latest, ok := s.clearances[hex]
+		if !ok {
+			latest = &clearances{}
+			s.clearances[hex] = latest
+		}
+		switch record.ClearanceType {
+		case "takeoff", "landing", "approach", "taxi":
+			latest.runway = newer(latest.runway, record)
+		case "altitude":
+			if record.Altitude > 0 {
+				latest.altitude = newer(latest.altitude, record)
+			}
+		case "heading":
+			if record.Heading > 0 {
+				latest.heading = newer(latest.heading, record)
+			}
+		}
+	}
+	return len(records) > 0
+}
+
+// inferAll infers the intent of each aircraft of a poll cycle and drops clearances that no
+// longer shape one
+func (s *Service) inferAll(samples []sample, now time.Time) {
+	expiry := now.Add(-time.Duration(s.config.ClearanceMinutes) * time.Minute)
+	for hex, latest := range s.clearances {
+		latest.runway = current(latest.runway, expiry)
+		latest.altitude = current(latest.altitude, expiry)
+		latest.heading = current(latest.heading, expiry)
+		if latest.runway == nil && latest.altitude == nil && latest.heading == nil {
+			delete(s.clearances, hex)
+		}
+	}
+
+	s.intentsMu.RLock()
+	previous := s.intents
+	s.intentsMu.RUnlock()
+
+	intents := make(map[string]*adsb.Intent, len(samples))
+	for _, smp := range samples {
+		intent := infer(smp, s.clearances[smp.hex])
+		if intent == nil {
+			continue
+		}
+		intent.UpdatedAt = now
+		if last, ok := previous[smp.hex]; ok && last.Summary == intent.Summary {
+			intent.UpdatedAt = last.UpdatedAt
+		}
+		intents[smp.hex] = intent
+	}
+
+	s.intentsMu.Lock()
+	s.intents = intents
+	s.intentsMu.Unlock()
+}
+
+// infer combines an aircraft's clearances with its track into an intent, or returns nil if
+// neither says anything
+func infer(smp sample, latest *clearances) *adsb.Intent {
+	intent := &adsb.Intent{}
+	parts := make([]string, 0, 3)
+	add := func(part, action string, record *sqlite.ClearanceRecord) {
+		parts = append(parts, part)
+		if intent.Action == "" {
+			intent.Action = action
+		}
+		if record != nil {
+			intent.ClearanceIDs = append(intent.ClearanceIDs, record.ID)
+		}
+	}
+
+	var runwayClearance *sqlite.ClearanceRecord
+	if latest != nil {
+		runwayClearance = latest.runway
+	}
+	if part, runway, ok := describeRunwayClearance(smp, runwayClearance); ok {
+		add(part, runwayClearance.ClearanceType, runwayClearance)
+		intent.Runway = runway
+	} else {
+		runwayClearance = nil
+		if smp.guidance != nil && smp.phase == "APP" {
+			add(fmt.Sprintf("%s final %s", formatFinal(smp.guidance.DistanceNM), smp.guidance.Runway), "final", nil)
+			intent.Runway = smp.guidance.Runway
+		}
+	}
+
+	// Altitude and heading assignments count in the air, or with a takeoff clearance, unless
+	// a later runway clearance replaced them
+	if latest != nil && (!smp.onGround || (runwayClearance != nil && runwayClearance.ClearanceType == "takeoff")) {
+		if record := latest.altitude; record != nil && !issuedBefore(record, runwayClearance) {
+			part, action := describeAltitude(smp, record.Altitude)
+			add(part, action, record)
+		}
+		if record := latest.heading; record != nil && !issuedBefore(record, runwayClearance) {
+			add(fmt.Sprintf("heading %03d", record.Heading), "heading", record)
+		}
+	}
+
+	if len(parts) == 0 {
+		return nil
+	}
+	intent.Summary = strings.Join(parts, ", ")
+	return intent
+}
+
+// describeRunwayClearance describes a takeoff, landing, approach or taxi clearance as the
+// aircraft follows it, and reports whether it still applies to what the aircraft is doing
+func describeRunwayClearance(smp sample, record *sqlite.ClearanceRecord) (string, string, bool) {
+	if record == nil {
+		return "", "", false
+	}
+	runway := strings.ToUpper(strings.TrimSpace(record.Runway))
+
+	switch record.ClearanceType {
+	case "taxi":
+		if !smp.onGround {
+			return "", "", false
+		}
+		verb := "cleared to taxi"
+		if smp.groundSpeed >= taxiSpeed {
+			verb = "taxiing"
+		}
+		part := verb
+		if runway != "" {
+			part += " to " + runway
+		} else if match := taxiDestination.FindStringSubmatch(record.ClearanceText); match != nil {
+			part += " to the " + strings.ToLower(match[1])
+		}
+		if match := taxiRoute.FindStringSubmatch(record.ClearanceText); match != nil {
+			part += " via " + formatRoute(match[1])
+		}
+		return part, runway, true
+
+	case "takeoff":
+		if !smp.onGround {
+			if smp.phase != "T/O" && smp.phase != "DEP" {
+				return "", "", false
+			}
+			return strings.TrimSpace("departing " + runway), runway, true
+		}
+		if smp.groundSpeed >= rollSpeed {
+			return strings.TrimSpace("taking off " + runway), runway, true
+		}
+		return strings.TrimSpace("cleared for takeoff " + runway), runway, true
+
+	case "landing":
+		if smp.onGround {
+			if smp.groundSpeed >= rollSpeed {
+				return strings.TrimSpace("rolling out " + runway), runway, true
+			}
+			return strings.TrimSpace("landed " + runway), runway, true
+		}
+		if runway == "" && smp.guidance != nil {
+			runway = smp.guidance.Runway
+		}
+		return strings.TrimSpace("cleared to land "+runway) + finalSuffix(smp, runway), runway, true
+
+	case "approach":
+		if smp.onGround {
+			return "", "", false
+		}
+		if runway == "" && smp.guidance != nil {
+			runway = smp.guidance.Runway
+		}
+		name := "approach"
+		if match := approachTypes.FindStringSubmatch(record.ClearanceText); match != nil {
+			name = strings.ToUpper(match[1])
+			switch name {
+			case "LOCALIZER":
+				name = "LOC"
+			case "VISUAL":
+				name = "visual approach"
+			}
+		}
+		return strings.TrimSpace("cleared "+name+" "+runway) + finalSuffix(smp, runway), runway, true
+	}
+	return "", "", false
+}
+
+// describeAltitude describes how an aircraft is following an assigned altitude
+func describeAltitude(smp sample, assigned int) (string, string) {
+	target := formatAltitude(assigned)
+	diff := float64(assigned) - smp.altitude
+	switch {
+	case math.Abs(diff) <= levelTolerance:
+		return "maintaining " + target, "level"
+	case diff > 0 && smp.verticalRate >= verticalRate:
+		return "climbing to " + target, "climb"
+	case diff < 0 && smp.verticalRate <= -verticalRate:
+		return "descending to " + target, "descent"
+	case diff > 0:
+		return "cleared to climb to " + target, "climb"
+	default:
+		return "cleared to descend to " + target, "descent"
+	}
+}
+
+// finalSuffix returns the distance on final of an aircraft lined up with a runway, or ""
+func finalSuffix(smp sample, runway string) string {
+	if smp.guidance == nil || !sameRunway(smp.guidance.Runway, runway) {
+		return ""
+	}
+	return fmt.Sprintf(", %s final", formatFinal(smp.guidance.DistanceNM))
+}
+
+// formatFinal formats a distance on final, to the nearest NM except close in
+func formatFinal(distanceNM float64) string {
+	if distanceNM < 2 {
+		return fmt.Sprintf("%.1f NM", distanceNM)
+	}
+	return fmt.Sprintf("%.0f NM", distanceNM)
+}
+
+// formatAltitude formats an assigned altitude in feet, or as a flight level from the transition altitude
+func formatAltitude(feet int) string {
+	if feet >= transitionAltitude {
+		return fmt.Sprintf("FL%03d", feet/100)
+	}
+	return fmt.Sprintf("%d ft", feet)
+}
+
+// formatRoute capitalizes the taxiways of a route: "bravo and alpha" is "Bravo and Alpha"
+func formatRoute(route string) string {
+	words := strings.Fields(route)
+	for i, word := range words {
+		if word == "and" {
+			continue
+		}
+		words[i] = strings.ToUpper(word[:1]) + strings.ToLower(word[1:])
+	}
+	return strings.Join(words, " ")
+}
+
+// sameRunway compares runway IDs, ignoring leading zeros and case: "5" is "05"
+func sameRunway(a, b string) bool {
+	return strings.TrimLeft(strings.ToUpper(a), "0") == strings.TrimLeft(strings.ToUpper(b), "0")
+}
+
+// newer returns the later of two clearances
+func newer(a, b *sqlite.ClearanceRecord) *sqlite.ClearanceRecord {
+	if a == nil || b.Timestamp.After(a.Timestamp) || (b.Timestamp.Equal(a.Timestamp) && b.ID > a.ID) {
+		return b
+	}
+	return a
+}
+
+// current returns a clearance if it was issued after expiry, or nil
+func current(record *sqlite.ClearanceRecord, expiry time.Time) *sqlite.ClearanceRecord {
+	if record == nil || record.Timestamp.Before(expiry) {
+		return nil
+	}
+	return record
+}
+
+// issuedBefore reports whether a clearance was issued before another one, if there is one
+func issuedBefore(record, other *sqlite.ClearanceRecord) bool {
+	return other != nil && record.Timestamp.Before(other.Timestamp)
+}
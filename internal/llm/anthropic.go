@@ -0,0 +1,168 @@
+package llm
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/yegors/co-atc/pkg/logger"
+)
+
+const (
+	defaultAnthropicBaseURL = "https://api.anthropic.com/v1"
+	anthropicAPIVersion     = "2023-06-01"
+
+	// anthropicDefaultMaxTokens is used when a request doesn't set a limit, which the
+	// Messages API requires
+	anthropicDefaultMaxTokens = 4096
+)
+
+// anthropicProvider completes prompts through Anthropic's Messages API
+type anthropicProvider struct {
+	config     Config
+	httpClient *http.Client
+	logger     *logger.Logger
+}
+
+// newAnthropicProvider creates a Messages API provider
+func newAnthropicProvider(config Config, logger *logger.Logger) *anthropicProvider {
+	config.BaseURL = strings.TrimSuffix(config.BaseURL, "/")
+	return &anthropicProvider{
+		config:     config,
+		httpClient: &http.Client{Timeout: config.timeout()},
+		logger:     logger.Named("llm-anthropic"),
+	}
+}
+
+// Name returns the provider name
+func (p *anthropicProvider) Name() string {
+	return ProviderAnthropic
+}
+
+// Model returns the model prompts are completed with
+func (p *anthropicProvider) Model() string {
+	return p.config.Model
+}
+
+// Complete sends a prompt to /messages and returns the text of the reply
+func (p *anthropicProvider) Complete(ctx context.Context, request Request) (Reply, error) {
+	type message struct {
+		Role    string `json:"role"`
+		Content string `json:"content"`
+	}
+	type tool struct {
+		Name        string                 `json:"name"`
+		Description string                 `json:"description,omitempty"`
+		InputSchema map[string]interface{} `json:"input_schema"`
+	}
+	type toolChoice struct {
+		Type string `json:"type"`
+		Name string `json:"name"`
+	}
+	body := struct {
+		Model       string      `json:"model"`
+		System      string      `json:"system,omitempty"`
+		Messages    []message   `json:"messages"`
+		MaxTokens   int         `json:"max_tokens"`
+		Temperature float64     `json:"temperature"`
+		Tools       []tool      `json:"tools,omitempty"`
+		ToolChoice  *toolChoice `json:"tool_choice,omitempty"`
+	}{
+		Model:       p.config.Model,
+		System:      request.System,
+		Messages:    []message{{Role: "user", Content: request.User}},
+		MaxTokens:   request.MaxTokens,
+		Temperature: request.Temperature,
+	}
+	if body.MaxTokens <= 0 {
+		body.MaxTokens = anthropicDefaultMaxTokens
+	}
+	// A schema is enforced by making the model call a tool that takes the reply as its input
+	useTool := request.Schema != nil && !p.config.NoSchema
+	if useTool {
+		body.Tools = []tool{{
+			Name:        request.Schema.Name,
+			Description: request.Schema.Description,
+			InputSchema: request.Schema.Definition,
+		}}
+		body.ToolChoice = &toolChoice{Type: "tool", Name: request.Schema.Name}
+	}
+
+	jsonData, err := json.Marshal(body)
+	if err != nil {
+		return Reply{}, fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", p.config.BaseURL+"/messages", bytes.NewBuffer(jsonData))
+	if err != nil {
+		return Reply{}, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("x-api-key", p.config.APIKey)
+	req.Header.Set("anthropic-version", anthropicAPIVersion)
+
+	resp, err := p.httpClient.Do(req)
+	if err != nil {
+		return Reply{}, fmt.Errorf("failed to execute request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	bodyBytes, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return Reply{}, fmt.Errorf("failed to read response body: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return Reply{}, newStatusError(resp, bodyBytes)
+	}
+
+	var result struct {
+		Content []struct {
+			Type  string          `json:"type"`
+			Text  string          `json:"text"`
+			Name  string          `json:"name"`
+			Input json.RawMessage `json:"input"`
+		} `json:"content"`
+		StopReason string `json:"stop_reason"`
+		Usage      struct {
+			InputTokens  int64 `json:"input_tokens"`
+			OutputTokens int64 `json:"output_tokens"`
+		} `json:"usage"`
+	}
+	if err := json.Unmarshal(bodyBytes, &result); err != nil {
+		return Reply{}, fmt.Errorf("failed to parse response: %w", err)
+	}
+
+	var text strings.Builder
+	for _, block := range result.Content {
+		if useTool {
+			if block.Type == "tool_use" && block.Name == request.Schema.Name {
+				text.Write(block.Input)
+				break
+			}
+		} else if block.Type == "text" {
+			text.WriteString(block.Text)
+		}
+	}
+	if text.Len() == 0 {
+		return Reply{}, fmt.Errorf("no text in response (stop reason: %s)", result.StopReason)
+	}
+	if result.StopReason == "max_tokens" {
+		p.logger.Warn("Reply was cut off at the token limit", logger.Int("max_tokens", body.MaxTokens))
+	}
+
+	p.logger.Debug("Completed prompt",
+		logger.String("model", p.config.Model),
+		logger.Int("response_length", text.Len()),
+		logger.Int64("input_tokens", result.Usage.InputTokens),
+		logger.Int64("output_tokens", result.Usage.OutputTokens))
+
+	return Reply{
+		Text:         text.String(),
+		InputTokens:  result.Usage.InputTokens,
+		OutputTokens: result.Usage.OutputTokens,
+	}, nil
+}
@@ -0,0 +1,144 @@
+// Package llm runs prompts on a language model, hosted or on-prem, behind one interface
+package llm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/yegors/co-atc/pkg/logger"
+)
+
+// LLM providers
+const (
+	ProviderOpenAI           = "openai"            // OpenAI's API
+	ProviderOpenAICompatible = "openai-compatible" // Servers with an OpenAI-style chat completions API: llama.cpp, Ollama, vLLM
+	ProviderAnthropic        = "anthropic"         // Anthropic's Messages API
+)
+
+// Config selects the model a task runs on
+type Config struct {
+	Provider       string // One of the provider constants (default: openai)
+	BaseURL        string // API base URL (default: the provider's public API)
+	APIKey         string // Optional for openai-compatible servers
+	Model          string
+	TimeoutSeconds int  // HTTP timeout per request (default: 120)
+	NoSchema       bool // Don't constrain replies to a request's schema, for servers that can't
+}
+
+// Request is a single-turn prompt
+type Request struct {
+	System      string
+	User        string
+	MaxTokens   int
+	Temperature float64
+	Schema      *Schema // Constrains the reply to JSON matching a schema, if set
+}
+
+// Schema is a JSON schema a reply must match. OpenAI-style servers enforce it as a strict
+// response format, which needs an object at the top level with every property required
+// and no additional properties. Anthropic enforces it as the input of a forced tool call.
+type Schema struct {
+	Name        string // Letters, digits, underscores and dashes
+	Description string
+	Definition  map[string]interface{}
+}
+
+// Reply is a model's reply to a prompt, with the tokens the prompt and reply used as
+// reported by the server. Servers that don't report usage leave the counts at zero.
+type Reply struct {
+	Text         string
+	InputTokens  int64
+	OutputTokens int64
+}
+
+// Provider completes prompts with a language model
+type Provider interface {
+	Name() string
+	Model() string
+	// Complete returns the model's reply to a prompt
+	Complete(ctx context.Context, request Request) (Reply, error)
+}
+
+// NewProvider creates the provider selected in the configuration
+func NewProvider(config Config, logger *logger.Logger) (Provider, error) {
+	if config.Model == "" {
+		return nil, fmt.Errorf("model is required")
+	}
+
+	switch config.Provider {
+	case "", ProviderOpenAI:
+		if config.APIKey == "" {
+			return nil, fmt.Errorf("API key is required for the openai LLM provider")
+		}
+		if config.BaseURL == "" {
+			config.BaseURL = defaultOpenAIBaseURL
+		}
+		return newOpenAIProvider(ProviderOpenAI, config, logger), nil
+	case ProviderOpenAICompatible:
+		if config.BaseURL == "" {
+			return nil, fmt.Errorf("base URL is required for the openai-compatible LLM provider")
+		}
+		return newOpenAIProvider(ProviderOpenAICompatible, config, logger), nil
+	case ProviderAnthropic:
+		if config.APIKey == "" {
+			return nil, fmt.Errorf("API key is required for the anthropic LLM provider")
+		}
+		if config.BaseURL == "" {
+			config.BaseURL = defaultAnthropicBaseURL
+		}
+		return newAnthropicProvider(config, logger), nil
+	default:
+		return nil, fmt.Errorf("unknown LLM provider: %s", config.Provider)
+	}
+}
+
+// timeout returns the configured request timeout, or the default of 2 minutes
+func (c Config) timeout() time.Duration {
+	if c.TimeoutSeconds <= 0 {
+		return 120 * time.Second
+	}
+	return time.Duration(c.TimeoutSeconds) * time.Second
+}
+
+// StatusError is a reply from the server with a status other than 200
+type StatusError struct {
+	StatusCode int
+	Body       string
+	RetryAfter time.Duration // How long the server asked to wait, from Retry-After (0 = not given)
+}
+
+// Error returns the status and body of the reply
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("unexpected status code: %d, response: %s", e.StatusCode, e.Body)
+}
+
+// newStatusError creates the error of a reply with an unexpected status
+func newStatusError(resp *http.Response, body []byte) *StatusError {
+	err := &StatusError{StatusCode: resp.StatusCode, Body: string(body)}
+	if value := resp.Header.Get("Retry-After"); value != "" {
+		if seconds, parseErr := strconv.Atoi(value); parseErr == nil {
+			err.RetryAfter = time.Duration(seconds) * time.Second
+		} else if at, parseErr := http.ParseTime(value); parseErr == nil {
+			err.RetryAfter = time.Until(at)
+		}
+	}
+	return err
+}
+
+// Retryable reports whether an error is a rate limit or server overload that the same
+// request may get past later, and how long the server asked to wait (0 if it didn't say)
+func Retryable(err error) (time.Duration, bool) {
+	var statusErr *StatusError
+	if !errors.As(err, &statusErr) {
+		return 0, false
+	}
+	// 529 is Anthropic's "overloaded"
+	if statusErr.StatusCode == http.StatusTooManyRequests || statusErr.StatusCode >= 500 {
+		return max(statusErr.RetryAfter, 0), true
+	}
+	return 0, false
+}
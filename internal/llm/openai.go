@@ -0,0 +1,156 @@
+package llm
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/yegors/co-atc/pkg/logger"
+)
+
+// defaultOpenAIBaseURL is the base URL of OpenAI's API
+const defaultOpenAIBaseURL = "https://api.openai.com/v1"
+
+// openAIProvider completes prompts through an OpenAI-style /chat/completions endpoint,
+// which OpenAI and local servers such as llama.cpp, Ollama and vLLM all serve
+type openAIProvider struct {
+	name       string
+	config     Config
+	httpClient *http.Client
+	logger     *logger.Logger
+}
+
+// newOpenAIProvider creates a chat completions provider
+func newOpenAIProvider(name string, config Config, logger *logger.Logger) *openAIProvider {
+	config.BaseURL = strings.TrimSuffix(config.BaseURL, "/")
+	return &openAIProvider{
+		name:       name,
+		config:     config,
+		httpClient: &http.Client{Timeout: config.timeout()},
+		logger:     logger.Named("llm-" + name),
+	}
+}
+
+// Name returns the provider name
+func (p *openAIProvider) Name() string {
+	return p.name
+}
+
+// Model returns the model prompts are completed with
+func (p *openAIProvider) Model() string {
+	return p.config.Model
+}
+
+// Complete sends a prompt to /chat/completions and returns the first choice
+func (p *openAIProvider) Complete(ctx context.Context, request Request) (Reply, error) {
+	type message struct {
+		Role    string `json:"role"`
+		Content string `json:"content"`
+	}
+	type jsonSchema struct {
+		Name        string                 `json:"name"`
+		Description string                 `json:"description,omitempty"`
+		Schema      map[string]interface{} `json:"schema"`
+		Strict      bool                   `json:"strict"`
+	}
+	type responseFormat struct {
+		Type       string      `json:"type"`
+		JSONSchema *jsonSchema `json:"json_schema,omitempty"`
+	}
+	body := struct {
+		Model          string          `json:"model"`
+		Messages       []message       `json:"messages"`
+		MaxTokens      int             `json:"max_tokens,omitempty"`
+		Temperature    float64         `json:"temperature"`
+		ResponseFormat *responseFormat `json:"response_format,omitempty"`
+	}{
+		Model: p.config.Model,
+		Messages: []message{
+			{Role: "system", Content: request.System},
+			{Role: "user", Content: request.User},
+		},
+		MaxTokens:   request.MaxTokens,
+		Temperature: request.Temperature,
+	}
+	if request.Schema != nil && !p.config.NoSchema {
+		body.ResponseFormat = &responseFormat{
+			Type: "json_schema",
+			JSONSchema: &jsonSchema{
+				Name:        request.Schema.Name,
+				Description: request.Schema.Description,
+				Schema:      request.Schema.Definition,
+				Strict:      true,
+			},
+		}
+	}
+
+	jsonData, err := json.Marshal(body)
+	if err != nil {
+		return Reply{}, fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", p.config.BaseURL+"/chat/completions", bytes.NewBuffer(jsonData))
+	if err != nil {
+		return Reply{}, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if p.config.APIKey != "" {
+		req.Header.Set("Authorization", "Bearer "+p.config.APIKey)
+	}
+
+	resp, err := p.httpClient.Do(req)
+	if err != nil {
+		return Reply{}, fmt.Errorf("failed to execute request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	bodyBytes, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return Reply{}, fmt.Errorf("failed to read response body: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return Reply{}, newStatusError(resp, bodyBytes)
+	}
+
+	var result struct {
+		Choices []struct {
+			Message struct {
+				Content string `json:"content"`
+				Refusal string `json:"refusal"`
+			} `json:"message"`
+			FinishReason string `json:"finish_reason"`
+		} `json:"choices"`
+		Usage struct {
+			PromptTokens     int64 `json:"prompt_tokens"`
+			CompletionTokens int64 `json:"completion_tokens"`
+		} `json:"usage"`
+	}
+	if err := json.Unmarshal(bodyBytes, &result); err != nil {
+		return Reply{}, fmt.Errorf("failed to parse response: %w", err)
+	}
+	if len(result.Choices) == 0 {
+		return Reply{}, fmt.Errorf("no choices in response")
+	}
+	if refusal := result.Choices[0].Message.Refusal; refusal != "" {
+		return Reply{}, fmt.Errorf("model refused the request: %s", refusal)
+	}
+	if result.Choices[0].FinishReason == "length" {
+		p.logger.Warn("Reply was cut off at the token limit", logger.Int("max_tokens", request.MaxTokens))
+	}
+
+	p.logger.Debug("Completed prompt",
+		logger.String("model", p.config.Model),
+		logger.Int("response_length", len(result.Choices[0].Message.Content)),
+		logger.Int64("input_tokens", result.Usage.PromptTokens),
+		logger.Int64("output_tokens", result.Usage.CompletionTokens))
+
+	return Reply{
+		Text:         result.Choices[0].Message.Content,
+		InputTokens:  result.Usage.PromptTokens,
+		OutputTokens: result.Usage.CompletionTokens,
+	}, nil
+}
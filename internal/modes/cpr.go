@@ -0,0 +1,115 @@
+package modes
+
+import "math"
+
+// cprMax is the number of steps a CPR latitude or longitude divides its zone into (2^17)
+const cprMax = 131072.0
+
+// CPRPosition is a compact position report: a 17-bit latitude and longitude within an even
+// or odd zone
+type CPRPosition struct {
+	Lat     uint32
+	Lon     uint32
+	Odd     bool
+	Surface bool // Surface positions divide a quarter of the globe into zones
+}
+
+// zoneSpan returns the degrees of latitude or longitude a position's zones cover in total
+func (p CPRPosition) zoneSpan() float64 {
+	if p.Surface {
+		return 90
+	}
+	return 360
+}
+
+// DecodeGlobal works out an airborne position from an even and an odd report sent within
+// about 10 seconds of each other, at the newer report. It fails if the aircraft crossed a
+// longitude zone boundary between them.
+func DecodeGlobal(even, odd CPRPosition, oddNewer bool) (lat, lon float64, ok bool) {
+	const dLatEven, dLatOdd = 360.0 / 60, 360.0 / 59
+
+	latEven, latOdd := float64(even.Lat)/cprMax, float64(odd.Lat)/cprMax
+	lonEven, lonOdd := float64(even.Lon)/cprMax, float64(odd.Lon)/cprMax
+
+	j := math.Floor(59*latEven - 60*latOdd + 0.5)
+	rlatEven := dLatEven * (positiveMod(j, 60) + latEven)
+	rlatOdd := dLatOdd * (positiveMod(j, 59) + latOdd)
+	if rlatEven >= 270 {
+		rlatEven -= 360
+	}
+	if rlatOdd >= 270 {
+		rlatOdd -= 360
+	}
+	if rlatEven < -90 || rlatEven > 90 || rlatOdd < -90 || rlatOdd > 90 {
+		return 0, 0, false
+	}
+	if nl(rlatEven) != nl(rlatOdd) {
+		return 0, 0, false
+	}
+
+	zones := nl(rlatEven)
+	m := math.Floor(lonEven*float64(zones-1) - lonOdd*float64(zones) + 0.5)
+	if oddNewer {
+		ni := max(zones-1, 1)
+		lat = rlatOdd
+		lon = 360 / float64(ni) * (positiveMod(m, float64(ni)) + lonOdd)
+	} else {
+		ni := max(zones, 1)
+		lat = rlatEven
+		lon = 360 / float64(ni) * (positiveMod(m, float64(ni)) + lonEven)
+	}
+	if lon >= 180 {
+		lon -= 360
+	}
+	return lat, lon, true
+}
+
+// DecodeLocal works out a position from one report and a reference position. The result is
+// only right if the reference is within half a zone: about 180 NM for airborne and 45 NM for
+// surface positions.
+func DecodeLocal(p CPRPosition, refLat, refLon float64) (lat, lon float64) {
+	zones := 60.0
+	if p.Odd {
+		zones = 59
+	}
+	dLat := p.zoneSpan() / zones
+	cprLat, cprLon := float64(p.Lat)/cprMax, float64(p.Lon)/cprMax
+
+	j := math.Floor(refLat/dLat) + math.Floor(0.5+positiveMod(refLat, dLat)/dLat-cprLat)
+	lat = dLat * (j + cprLat)
+
+	ni := nl(lat)
+	if p.Odd {
+		ni--
+	}
+	dLon := p.zoneSpan() / float64(max(ni, 1))
+	m := math.Floor(refLon/dLon) + math.Floor(0.5+positiveMod(refLon, dLon)/dLon-cprLon)
+	lon = dLon * (m + cprLon)
+	return lat, lon
+}
+
+// nl returns the number of longitude zones at a latitude
+func nl(lat float64) int {
+	lat = math.Abs(lat)
+	switch {
+	case lat == 0:
+		return 59
+	case lat == 87:
+		return 2
+	case lat > 87:
+		return 1
+	}
+	const nz = 15
+	a := 1 - math.Cos(math.Pi/(2*nz))
+	b := math.Pow(math.Cos(math.Pi/180*lat), 2)
+	return int(math.Floor(2 * math.Pi / math.Acos(1-a/b)))
+}
+
+// positiveMod returns x modulo y, between 0 and y
+func positiveMod(x, y float64) float64 {
+	r := math.Mod(x, y)
+	if r < 0 {
+		r += y
+	}
+	return r
+}
@@ -0,0 +1,39 @@
+package modes
+
+// crcPolynomial is the Mode S CRC-24 generator polynomial, without its leading bit
+const crcPolynomial = 0xFFF409
+
+// crcTable holds the CRC-24 of each byte value
+var crcTable = func() [256]uint32 {
+	var table [256]uint32
+	for i := range table {
+		crc := uint32(i) << 16
+		for bit := 0; bit < 8; bit++ {
+			if crc&0x800000 != 0 {
+				crc = (crc << 1) ^ crcPolynomial
+			} else {
+				crc <<= 1
+			}
+		}
+		table[i] = crc & 0xFFFFFF
+	}
+	return table
+}()
+
+// Checksum returns the Mode S CRC-24 of data
+func Checksum(data []byte) uint32 {
+	var crc uint32
+	for _, b := range data {
+		crc = ((crc << 8) ^ crcTable[byte(crc>>16)^b]) & 0xFFFFFF
+	}
+	return crc
+}
+
+// residual returns the checksum of a message's data XORed with its last 24 bits. It's zero
+// for an intact message with a parity field, and the sender's address for one whose parity
+// is overlaid with it.
+func residual(msg []byte) uint32 {
+	n := len(msg)
+	parity := uint32(msg[n-3])<<16 | uint32(msg[n-2])<<8 | uint32(msg[n-1])
+	return Checksum(msg[:n-3]) ^ parity
+}
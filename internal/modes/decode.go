@@ -0,0 +1,432 @@
+package modes
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+)
+
+var (
+	// ErrBadLength is returned for a message that isn't 56 or 112 bits long, or whose length
+	// doesn't match its downlink format
+	ErrBadLength = errors.New("bad message length")
+	// ErrBadCRC is returned for a message with a parity field that doesn't match its data
+	ErrBadCRC = errors.New("bad CRC")
+	// ErrUnsupported is returned for downlink formats that aren't decoded
+	ErrUnsupported = errors.New("unsupported downlink format")
+)
+
+// Message kinds
+const (
+	KindShortACAS         = "short_acas"         // DF0
+	KindAltitudeReply     = "altitude_reply"     // DF4
+	KindIdentityReply     = "identity_reply"     // DF5
+	KindAllCallReply      = "all_call_reply"     // DF11
+	KindLongACAS          = "long_acas"          // DF16
+	KindCommBAltitude     = "comm_b_altitude"    // DF20
+	KindCommBIdentity     = "comm_b_identity"    // DF21
+	KindIdentification    = "identification"     // ADS-B type codes 1-4
+	KindSurfacePosition   = "surface_position"   // ADS-B type codes 5-8
+	KindAirbornePosition  = "airborne_position"  // ADS-B type codes 9-18 and 20-22
+	KindAirborneVelocity  = "airborne_velocity"  // ADS-B type code 19
+	KindAircraftStatus    = "aircraft_status"    // ADS-B type code 28
+	KindOperationalStatus = "operational_status" // ADS-B type code 31
+	KindOtherSquitter     = "other_squitter"     // Other ADS-B type codes
+)
+
+// Message is a decoded Mode S downlink message. Fields the message doesn't carry are nil or
+// empty.
+type Message struct {
+	DF       int
+	Address  uint32 // ICAO address of the sender
+	NonICAO  bool   // DF18 with an anonymous or ground-assigned address
+	Parity   bool   // The address was recovered from the parity, so it's only right if the sender is known
+	TypeCode int    // ADS-B type code (DF17/18)
+	Kind     string
+
+	Callsign     string
+	Category     string // Emitter category ("A3")
+	Squawk       string
+	Altitude     *int  // Barometric altitude (ft)
+	AltitudeGNSS *int  // Geometric altitude (ft)
+	OnGround     *bool // From the surveillance status, capability or position type
+	Position     *CPRPosition
+
+	GroundSpeed  *float64 // kt
+	Track        *float64 // True degrees
+	Heading      *float64 // Magnetic degrees
+	IAS          *float64 // kt
+	TAS          *float64 // kt
+	VerticalRate *int     // ft/min
+	VerticalGNSS bool     // The vertical rate is geometric rather than barometric
+}
+
+// Hex returns the sender's address as readsb shows it: six hex digits, prefixed with "~" for
+// non-ICAO addresses
+func (m *Message) Hex() string {
+	if m.NonICAO {
+		return fmt.Sprintf("~%06x", m.Address)
+	}
+	return fmt.Sprintf("%06x", m.Address)
+}
+
+// callsignChars maps the 6-bit characters of an identification message
+const callsignChars = "#ABCDEFGHIJKLMNOPQRSTUVWXYZ##### ###############0123456789######"
+
+// Decode decodes a 56 or 112 bit Mode S message. Messages with a parity field (DF11, DF17 and
+// DF18) are checked; for the others the address is recovered from the parity and Parity set.
+func Decode(msg []byte) (*Message, error) {
+	if len(msg) != 7 && len(msg) != 14 {
+		return nil, ErrBadLength
+	}
+	m := &Message{DF: int(msg[0] >> 3)}
+	if m.DF >= 24 {
+		m.DF = 24 // Comm-D uses the first two bits only
+	}
+	long := m.DF >= 16
+	if long != (len(msg) == 14) {
+		return nil, ErrBadLength
+	}
+
+	switch m.DF {
+	case 0, 4, 5, 16, 20, 21:
+		m.Address = residual(msg)
+		m.Parity = true
+	case 11:
+		// The interrogator identifier may be overlaid on the low 7 bits
+		if residual(msg)&^0x7F != 0 {
+			return nil, ErrBadCRC
+		}
+		m.Address = bits(msg, 9, 32)
+	case 17, 18:
+		if residual(msg) != 0 {
+			return nil, ErrBadCRC
+		}
+		m.Address = bits(msg, 9, 32)
+	default:
+		return nil, ErrUnsupported
+	}
+
+	switch m.DF {
+	case 0:
+		m.Kind = KindShortACAS
+		m.OnGround = boolPtr(bits(msg, 6, 6) == 1)
+		m.Altitude = decodeAC13(bits(msg, 20, 32))
+	case 16:
+		m.Kind = KindLongACAS
+		m.OnGround = boolPtr(bits(msg, 6, 6) == 1)
+		m.Altitude = decodeAC13(bits(msg, 20, 32))
+	case 4, 20:
+		m.Kind = KindAltitudeReply
+		if m.DF == 20 {
+			m.Kind = KindCommBAltitude
+		}
+		m.OnGround = flightStatusGround(bits(msg, 6, 8))
+		m.Altitude = decodeAC13(bits(msg, 20, 32))
+	case 5, 21:
+		m.Kind = KindIdentityReply
+		if m.DF == 21 {
+			m.Kind = KindCommBIdentity
+		}
+		m.OnGround = flightStatusGround(bits(msg, 6, 8))
+		m.Squawk = decodeSquawk(bits(msg, 20, 32))
+	case 11:
+		m.Kind = KindAllCallReply
+		m.OnGround = capabilityGround(bits(msg, 6, 8))
+	case 17:
+		m.OnGround = capabilityGround(bits(msg, 6, 8))
+		decodeExtendedSquitter(msg, m)
+	case 18:
+		// Control field: 0 = ADS-B from a non-transponder device, 1 = ADS-B with an anonymous
+		// address, 6 = rebroadcast ADS-B. Others are TIS-B and ADS-R formats not decoded here.
+		switch bits(msg, 6, 8) {
+		case 0:
+		case 1, 6:
+			m.NonICAO = bits(msg, 6, 8) == 1
+		default:
+			return nil, ErrUnsupported
+		}
+		decodeExtendedSquitter(msg, m)
+	}
+	return m, nil
+}
+
+// decodeExtendedSquitter decodes the ADS-B message field of a DF17 or DF18 message
+func decodeExtendedSquitter(msg []byte, m *Message) {
+	m.TypeCode = int(bits(msg, 33, 37))
+	switch tc := m.TypeCode; {
+	case tc >= 1 && tc <= 4:
+		m.Kind = KindIdentification
+		m.Category = fmt.Sprintf("%c%d", 'A'+4-tc, bits(msg, 38, 40))
+		var callsign strings.Builder
+		for bit := 41; bit <= 83; bit += 6 {
+			callsign.WriteByte(callsignChars[bits(msg, bit, bit+5)])
+		}
+		m.Callsign = strings.TrimRight(strings.ReplaceAll(callsign.String(), "#", ""), " ")
+
+	case tc >= 5 && tc <= 8:
+		m.Kind = KindSurfacePosition
+		m.OnGround = boolPtr(true)
+		m.GroundSpeed = decodeMovement(bits(msg, 38, 44))
+		if bits(msg, 45, 45) == 1 {
+			track := float64(bits(msg, 46, 52)) * 360 / 128
+			m.Track = &track
+		}
+		m.Position = &CPRPosition{Lat: bits(msg, 55, 71), Lon: bits(msg, 72, 88), Odd: bits(msg, 54, 54) == 1, Surface: true}
+
+	case (tc >= 9 && tc <= 18) || (tc >= 20 && tc <= 22):
+		m.Kind = KindAirbornePosition
+		m.OnGround = boolPtr(false)
+		altitude := decodeAC12(bits(msg, 41, 52))
+		if tc <= 18 {
+			m.Altitude = altitude
+		} else {
+			m.AltitudeGNSS = altitude
+		}
+		if lat, lon := bits(msg, 55, 71), bits(msg, 72, 88); lat != 0 || lon != 0 {
+			m.Position = &CPRPosition{Lat: lat, Lon: lon, Odd: bits(msg, 54, 54) == 1}
+		}
+
+	case tc == 19:
+		m.Kind = KindAirborneVelocity
+		decodeVelocity(msg, m)
+
+	case tc == 28:
+		m.Kind = KindAircraftStatus
+		if bits(msg, 38, 40) == 1 {
+			m.Squawk = decodeSquawk(bits(msg, 44, 56))
+		}
+
+	case tc == 31:
+		m.Kind = KindOperationalStatus
+
+	default:
+		m.Kind = KindOtherSquitter
+	}
+}
+
+// decodeVelocity decodes an airborne velocity message: ground speed and track (subtypes 1
+// and 2) or heading and airspeed (subtypes 3 and 4), and the vertical rate
+func decodeVelocity(msg []byte, m *Message) {
+	subtype := bits(msg, 38, 40)
+	scale := 1.0
+	if subtype == 2 || subtype == 4 {
+		scale = 4 // Supersonic
+	}
+
+	switch subtype {
+	case 1, 2:
+		ewRaw, nsRaw := bits(msg, 47, 56), bits(msg, 58, 67)
+		if ewRaw != 0 && nsRaw != 0 {
+			ew := float64(ewRaw-1) * scale
+			if bits(msg, 46, 46) == 1 {
+				ew = -ew
+			}
+			ns := float64(nsRaw-1) * scale
+			if bits(msg, 57, 57) == 1 {
+				ns = -ns
+			}
+			speed := math.Hypot(ew, ns)
+			track := math.Mod(math.Atan2(ew, ns)*180/math.Pi+360, 360)
+			m.GroundSpeed = &speed
+			m.Track = &track
+		}
+	case 3, 4:
+		if bits(msg, 46, 46) == 1 {
+			heading := float64(bits(msg, 47, 56)) * 360 / 1024
+			m.Heading = &heading
+		}
+		if raw := bits(msg, 58, 67); raw != 0 {
+			speed := float64(raw-1) * scale
+			if bits(msg, 57, 57) == 1 {
+				m.TAS = &speed
+			} else {
+				m.IAS = &speed
+			}
+		}
+	default:
+		return
+	}
+
+	if raw := bits(msg, 70, 78); raw != 0 {
+		rate := int(raw-1) * 64
+		if bits(msg, 69, 69) == 1 {
+			rate = -rate
+		}
+		m.VerticalRate = &rate
+		m.VerticalGNSS = bits(msg, 68, 68) == 0
+	}
+}
+
+// decodeMovement returns the ground speed of a surface position's movement field (kt)
+func decodeMovement(movement uint32) *float64 {
+	var speed float64
+	switch {
+	case movement == 1:
+		speed = 0
+	case movement >= 2 && movement <= 8:
+		speed = 0.125 + float64(movement-2)*0.125
+	case movement >= 9 && movement <= 12:
+		speed = 1 + float64(movement-9)*0.25
+	case movement >= 13 && movement <= 38:
+		speed = 2 + float64(movement-13)*0.5
+	case movement >= 39 && movement <= 93:
+		speed = 15 + float64(movement-39)
+	case movement >= 94 && movement <= 108:
+		speed = 70 + float64(movement-94)*2
+	case movement >= 109 && movement <= 123:
+		speed = 100 + float64(movement-109)*5
+	case movement == 124:
+		speed = 175
+	default:
+		return nil // Not available or reserved
+	}
+	return &speed
+}
+
+// decodeAC13 decodes the 13-bit altitude code of surveillance replies (ft). Metric
+// altitudes aren't decoded.
+func decodeAC13(field uint32) *int {
+	if field == 0 || field&0x40 != 0 {
+		return nil
+	}
+	if field&0x10 != 0 {
+		// 25 ft increments: the 11 bits left after removing M and Q
+		n := int((field&0x1F80)>>2 | (field&0x20)>>1 | field&0x0F)
+		return intPtr(n*25 - 1000)
+	}
+	return gillhamAltitude(field)
+}
+
+// decodeAC12 decodes the 12-bit altitude of an airborne position (ft)
+func decodeAC12(field uint32) *int {
+	if field == 0 {
+		return nil
+	}
+	if field&0x10 != 0 {
+		n := int((field&0xFE0)>>1 | field&0x0F)
+		return intPtr(n*25 - 1000)
+	}
+	// Insert the M bit to get a 13-bit Gillham code
+	return gillhamAltitude((field&0xFC0)<<1 | field&0x3F)
+}
+
+// gillhamAltitude decodes a 13-bit Gray-coded altitude in 100 ft increments
+func gillhamAltitude(field uint32) *int {
+	code := identityCode(field)
+	if code&0x8889 != 0 || code&0xF0 == 0 {
+		return nil // D1 set, or no C bits
+	}
+
+	oneHundreds := 0
+	if code&0x10 != 0 {
+		oneHundreds ^= 7 // C1
+	}
+	if code&0x20 != 0 {
+		oneHundreds ^= 3 // C2
+	}
+	if code&0x40 != 0 {
+		oneHundreds ^= 1 // C4
+	}
+	if oneHundreds&5 == 5 {
+		oneHundreds ^= 2
+	}
+	if oneHundreds > 5 {
+		return nil
+	}
+
+	fiveHundreds := 0
+	for _, b := range []struct {
+		mask uint32
+		gray int
+	}{
+		{0x0002, 0xFF}, {0x0004, 0x7F}, // D2, D4
+		{0x1000, 0x3F}, {0x2000, 0x1F}, {0x4000, 0x0F}, // A1, A2, A4
+		{0x0100, 0x07}, {0x0200, 0x03}, {0x0400, 0x01}, // B1, B2, B4
+	} {
+		if code&b.mask != 0 {
+			fiveHundreds ^= b.gray
+		}
+	}
+	if fiveHundreds&1 != 0 {
+		oneHundreds = 6 - oneHundreds
+	}
+
+	hundreds := fiveHundreds*5 + oneHundreds - 13
+	if hundreds < -12 {
+		return nil
+	}
+	return intPtr(hundreds * 100)
+}
+
+// identityCode rearranges a 13-bit identity or Gillham altitude field into the octal digits
+// A, B, C and D, one per hex digit (0xABCD)
+func identityCode(field uint32) uint32 {
+	var code uint32
+	for _, b := range []struct{ from, to uint32 }{
+		{0x1000, 0x0010}, // C1
+		{0x0800, 0x1000}, // A1
+		{0x0400, 0x0020}, // C2
+		{0x0200, 0x2000}, // A2
+		{0x0100, 0x0040}, // C4
+		{0x0080, 0x4000}, // A4
+		{0x0020, 0x0100}, // B1
+		{0x0010, 0x0001}, // D1
+		{0x0008, 0x0200}, // B2
+		{0x0004, 0x0002}, // D2
+		{0x0002, 0x0400}, // B4
+		{0x0001, 0x0004}, // D4
+	} {
+		if field&b.from != 0 {
+			code |= b.to
+		}
+	}
+	return code
+}
+
+// decodeSquawk decodes a 13-bit identity field into a four digit squawk
+func decodeSquawk(field uint32) string {
+	return fmt.Sprintf("%04x", identityCode(field))
+}
+
+// flightStatusGround returns whether the flight status of a surveillance reply says the
+// aircraft is on the ground, or nil if it doesn't say
+func flightStatusGround(fs uint32) *bool {
+	switch fs {
+	case 0, 2:
+		return boolPtr(false)
+	case 1, 3:
+		return boolPtr(true)
+	}
+	return nil
+}
+
+// capabilityGround returns whether the capability of an all-call reply or extended squitter
+// says the aircraft is on the ground, or nil if it doesn't say
+func capabilityGround(ca uint32) *bool {
+	switch ca {
+	case 4:
+		return boolPtr(true)
+	case 5:
+		return boolPtr(false)
+	}
+	return nil
+}
+
+// bits returns the bits of a message from first to last, numbered from 1 at the most
+// significant bit of the first byte
+func bits(msg []byte, first, last int) uint32 {
+	var value uint32
+	for bit := first; bit <= last; bit++ {
+		value <<= 1
+		if msg[(bit-1)/8]&(0x80>>((bit-1)%8)) != 0 {
+			value |= 1
+		}
+	}
+	return value
+}
+
+func boolPtr(b bool) *bool { return &b }
+
+func intPtr(i int) *int { return &i }
@@ -0,0 +1,239 @@
+package modes
+
+import (
+	"bufio"
+	"encoding/hex"
+	"errors"
+	"io"
+	"math"
+	"strings"
+)
+
+// Raw output formats of receivers such as readsb and dump1090
+const (
+	FormatBeast = "beast" // Binary, usually on port 30005
+	FormatAVR   = "avr"   // Hex text, usually on port 30002
+)
+
+// beastEscape starts every Beast frame and is doubled inside one
+const beastEscape = 0x1A
+
+// ErrUnknownFormat is returned for a raw format other than beast or avr
+var ErrUnknownFormat = errors.New("unknown raw format")
+
+// Frame is a message as received: a 2 byte Mode A/C reply or a 7 or 14 byte Mode S message
+type Frame struct {
+	Data      []byte
+	Signal    float64 // Signal level from 0 to 1, zero when the format doesn't carry it
+	Timestamp uint64  // Receiver clock (12 MHz), zero when the format doesn't carry it
+}
+
+// RSSI returns the signal level in dBFS, or 0 if it isn't known
+func (f Frame) RSSI() float64 {
+	if f.Signal <= 0 {
+		return 0
+	}
+	return 10 * math.Log10(f.Signal*f.Signal)
+}
+
+// FrameReader reads frames from a receiver's raw output
+type FrameReader interface {
+	ReadFrame() (Frame, error)
+}
+
+// NewFrameReader creates a reader of a raw format
+func NewFrameReader(format string, r io.Reader) (FrameReader, error) {
+	switch format {
+	case FormatBeast:
+		return &beastReader{r: bufio.NewReader(r)}, nil
+	case FormatAVR:
+		return &avrReader{r: bufio.NewReader(r)}, nil
+	}
+	return nil, ErrUnknownFormat
+}
+
+// beastReader reads Beast binary frames: an escape byte, a type byte ('1' Mode A/C, '2'
+// short and '3' long Mode S), a 6 byte timestamp, a signal byte and the message, with escape
+// bytes doubled
+type beastReader struct {
+	r       *bufio.Reader
+	pending int // Type byte of a frame that cut the previous one short, or 0
+}
+
+// ReadFrame reads the next Mode A/C or Mode S frame, skipping status frames and anything
+// between frames
+func (b *beastReader) ReadFrame() (Frame, error) {
+	for {
+		frameType, err := b.nextFrameType()
+		if err != nil {
+			return Frame{}, err
+		}
+		length := 0
+		switch frameType {
+		case '1':
+			length = 2
+		case '2':
+			length = 7
+		case '3':
+			length = 14
+		default:
+			continue // Status or unknown frame; resynchronise on the next escape
+		}
+
+		buf := make([]byte, 7+length)
+		complete := true
+		for i := range buf {
+			c, err := b.r.ReadByte()
+			if err != nil {
+				return Frame{}, err
+			}
+			if c == beastEscape {
+				next, err := b.r.ReadByte()
+				if err != nil {
+					return Frame{}, err
+				}
+				if next != beastEscape {
+					// An unescaped escape starts a new frame
+					b.pending = int(next)
+					complete = false
+					break
+				}
+			}
+			buf[i] = c
+		}
+		if !complete {
+			continue
+		}
+
+		var timestamp uint64
+		for _, c := range buf[:6] {
+			timestamp = timestamp<<8 | uint64(c)
+		}
+		return Frame{Data: buf[7:], Signal: float64(buf[6]) / 255, Timestamp: timestamp}, nil
+	}
+}
+
+// EncodeBeast encodes a frame as a Beast binary frame, as the Beast reader reads them. The
+// signal level is left at zero when the frame doesn't carry one.
+func EncodeBeast(frame Frame) []byte {
+	var frameType byte
+	switch len(frame.Data) {
+	case 2:
+		frameType = '1'
+	case 7:
+		frameType = '2'
+	default:
+		frameType = '3'
+	}
+
+	body := make([]byte, 0, 7+len(frame.Data))
+	for shift := 40; shift >= 0; shift -= 8 {
+		body = append(body, byte(frame.Timestamp>>shift))
+	}
+	body = append(body, byte(math.Round(math.Min(math.Max(frame.Signal, 0), 1)*255)))
+	body = append(body, frame.Data...)
+	return AppendBeastEscaped([]byte{beastEscape, frameType}, body)
+}
+
+// AppendBeastEscaped appends bytes to a Beast frame, doubling escape bytes
+func AppendBeastEscaped(frame, data []byte) []byte {
+	for _, c := range data {
+		frame = append(frame, c)
+		if c == beastEscape {
+			frame = append(frame, beastEscape)
+		}
+	}
+	return frame
+}
+
+// nextFrameType skips to the next escape byte and returns the type byte after it
+func (b *beastReader) nextFrameType() (byte, error) {
+	if b.pending > 0 {
+		frameType := byte(b.pending)
+		b.pending = 0
+		return frameType, nil
+	}
+	for {
+		c, err := b.r.ReadByte()
+		if err != nil {
+			return 0, err
+		}
+		if c != beastEscape {
+			continue
+		}
+		frameType, err := b.r.ReadByte()
+		if err != nil {
+			return 0, err
+		}
+		if frameType != beastEscape {
+			return frameType, nil
+		}
+	}
+}
+
+// avrReader reads AVR text frames, one per line: "*<hex>;", "@<12 hex timestamp><hex>;" or
+// "<<12 hex timestamp><2 hex signal><hex>;"
+type avrReader struct {
+	r *bufio.Reader
+}
+
+// ReadFrame reads the next frame, skipping lines that aren't frames
+func (a *avrReader) ReadFrame() (Frame, error) {
+	for {
+		line, err := a.r.ReadString('\n')
+		if err != nil && (line == "" || err != io.EOF) {
+			return Frame{}, err
+		}
+		if frame, ok := parseAVR(strings.TrimSpace(line)); ok {
+			return frame, nil
+		}
+		if err != nil {
+			return Frame{}, err
+		}
+	}
+}
+
+// parseAVR parses one AVR line
+func parseAVR(line string) (Frame, bool) {
+	if len(line) < 2 || !strings.HasSuffix(line, ";") {
+		return Frame{}, false
+	}
+	body := line[1 : len(line)-1]
+
+	var frame Frame
+	switch line[0] {
+	case '*':
+	case '@', '<':
+		header := 12
+		if line[0] == '<' {
+			header = 14
+		}
+		if len(body) < header {
+			return Frame{}, false
+		}
+		stamp, err := hex.DecodeString(body[:12])
+		if err != nil {
+			return Frame{}, false
+		}
+		for _, c := range stamp {
+			frame.Timestamp = frame.Timestamp<<8 | uint64(c)
+		}
+		if line[0] == '<' {
+			signal, err := hex.DecodeString(body[12:14])
+			if err != nil {
+				return Frame{}, false
+			}
+			frame.Signal = float64(signal[0]) / 255
+		}
+		body = body[header:]
+	default:
+		return Frame{}, false
+	}
+
+	data, err := hex.DecodeString(body)
+	if err != nil || (len(data) != 2 && len(data) != 7 && len(data) != 14) {
+		return Frame{}, false
+	}
+	frame.Data = data
+	return frame, true
+}
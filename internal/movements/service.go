@@ -0,0 +1,455 @@
+// Package movements stitches the track segments of each aircraft into flight sessions, from
+// first seen to last seen, and logs the departures and arrivals of the airport.
+package movements
+
+import (
+	"context"
+	"math"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/yegors/co-atc/internal/adsb"
+	"github.com/yegors/co-atc/internal/config"
+	"github.com/yegors/co-atc/internal/storage/sqlite"
+	"github.com/yegors/co-atc/pkg/logger"
+)
+
+const (
+	// flushInterval is how often the last seen time of active sessions is stored
+	flushInterval = time.Minute
+	// pruneInterval is how often sessions older than retention_days are deleted
+	pruneInterval = time.Hour
+	// runwayAlignment is how far the course may be from the runway heading at takeoff or touchdown
+	runwayAlignment = 30.0
+	// runwayHalfWidthNM is how far from the centerline an aircraft may be to use a runway
+	runwayHalfWidthNM = 0.25
+)
+
+// Along-track range from the threshold an aircraft is matched to a runway in, in NM: after
+// takeoff it may be past the far end; at touchdown it's over the runway, or still on final
+// when the signal was lost first
+const (
+	takeoffBeforeNM = 0.5
+	takeoffPastNM   = 2.0
+	landingBeforeNM = 1.0
+	landingPastNM   = 0.5
+	lostBeforeNM    = 5.0
+)
+
+// sample is what session tracking needs from an aircraft in a poll cycle
+type sample struct {
+	hex          string
+	flight       string
+	registration string
+	aircraftType string
+	operator     string
+	lat, lon     float64
+	course       float64 // True
+	hasPosition  bool
+	tookOff      *time.Time // Latest takeoff detected by flight phases
+	landed       *time.Time // Latest landing detected by flight phases
+}
+
+// session is an aircraft's active flight session
+type session struct {
+	record  *sqlite.FlightSessionRecord
+	tookOff *time.Time // Latest takeoff already known, so only newer ones are departures
+	landed  *time.Time // Latest landing already known
+	last    sample     // Last sample with a position
+	changed bool       // Changed since last stored
+}
+
+// Movement is a departure or arrival of the airport
+type Movement struct {
+	SessionID    int64     `json:"session_id"`
+	Hex          string    `json:"hex"`
+	Callsign     string    `json:"callsign,omitempty"`
+	Registration string    `json:"registration,omitempty"`
+	AircraftType string    `json:"aircraft_type,omitempty"`
+	Operator     string    `json:"operator,omitempty"`
+	Runway       string    `json:"runway,omitempty"` // Threshold ID, empty if unknown
+	Time         time.Time `json:"time"`             // Takeoff or touchdown
+}
+
+// Log is the departures and arrivals of the airport during a time range
+type Log struct {
+	Start      time.Time  `json:"start"`
+	End        time.Time  `json:"end"`
+	Arrivals   []Movement `json:"arrivals"`   // Most recent first
+	Departures []Movement `json:"departures"` // Most recent first
+}
+
+// Service stitches poll cycles into flight sessions and records the airport's movements
+type Service struct {
+	config         config.MovementsConfig
+	airportRangeNM float64
+	adsbService    *adsb.Service
+	storage        *sqlite.FlightSessionStorage
+	logger         *logger.Logger
+
+	cycles chan []sample
+
+	// Active sessions by hex. Only used by the worker goroutine.
+	sessions map[string]*session
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// NewService creates a new flight session service. Takeoffs and landings within airportRangeNM
+// of the station are movements of the airport even if no runway matches.
+func NewService(cfg config.MovementsConfig, airportRangeNM float64, adsbService *adsb.Service, storage *sqlite.FlightSessionStorage, logger *logger.Logger) *Service {
+	return &Service{
+		config:         cfg,
+		airportRangeNM: airportRangeNM,
+		adsbService:    adsbService,
+		storage:        storage,
+		logger:         logger.Named("movements"),
+		cycles:         make(chan []sample, 4),
+		sessions:       make(map[string]*session),
+	}
+}
+
+// Start starts recording flight sessions
+func (s *Service) Start(ctx context.Context) error {
+	// Sessions left active by a shutdown can't be continued
+	if ended, err := s.storage.EndActiveSessions(); err != nil {
+		return err
+	} else if ended > 0 {
+		s.logger.Info("Ended flight sessions left active", logger.Int64("count", ended))
+	}
+
+	s.ctx, s.cancel = context.WithCancel(ctx)
+	s.wg.Add(1)
+	go s.run()
+
+	s.adsbService.OnUpdate(s.handleUpdate)
+
+	s.logger.Info("Flight session recording started",
+		logger.Int("session_gap_minutes", s.config.SessionGapMinutes),
+		logger.Int("retention_days", s.config.RetentionDays))
+	return nil
+}
+
+// Stop stops recording and ends the active sessions
+func (s *Service) Stop() {
+	if s.cancel != nil {
+		s.cancel()
+	}
+	s.wg.Wait()
+}
+
+// Sessions returns the sessions seen during a time range, most recently started first,
+// optionally of one aircraft
+func (s *Service) Sessions(start, end time.Time, hex string, limit int) ([]*sqlite.FlightSessionRecord, error) {
+	return s.storage.ListSessions(start, end, strings.ToLower(hex), limit)
+}
+
+// Movements returns the departures and arrivals of the airport during a time range, up to
+// limit of each
+func (s *Service) Movements(start, end time.Time, limit int) (*Log, error) {
+	departures, err := s.storage.ListDepartures(start, end, limit)
+	if err != nil {
+		return nil, err
+	}
+	arrivals, err := s.storage.ListArrivals(start, end, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	log := &Log{
+		Start:      start,
+		End:        end,
+		Arrivals:   make([]Movement, 0, len(arrivals)),
+		Departures: make([]Movement, 0, len(departures)),
+	}
+	for _, record := range arrivals {
+		log.Arrivals = append(log.Arrivals, movement(record, record.ArrivalRunway, *record.ArrivedAt))
+	}
+	for _, record := range departures {
+		log.Departures = append(log.Departures, movement(record, record.DepartureRunway, *record.DepartedAt))
+	}
+	return log, nil
+}
+
+// movement is the departure or arrival of a session
+func movement(record *sqlite.FlightSessionRecord, runway string, at time.Time) Movement {
+	return Movement{
+		SessionID:    record.ID,
+		Hex:          record.Hex,
+		Callsign:     record.Callsign,
+		Registration: record.Registration,
+		AircraftType: record.AircraftType,
+		Operator:     record.Operator,
+		Runway:       runway,
+		Time:         at,
+	}
+}
+
+// handleUpdate hands the live aircraft of a poll cycle to the worker without blocking polling
+func (s *Service) handleUpdate(aircraft []*adsb.Aircraft) {
+	declination := s.adsbService.Declination()
+	samples := make([]sample, 0, len(aircraft))
+	for _, a := range aircraft {
+		if a.ADSB == nil || a.IsSimulated || a.ADSB.Type == adsb.TargetTypeReplay {
+			continue
+		}
+		samples = append(samples, sample{
+			hex:          strings.ToLower(a.Hex),
+			flight:       strings.TrimSpace(a.Flight),
+			registration: a.ADSB.Registration,
+			aircraftType: a.ADSB.AircraftType,
+			operator:     a.Airline,
+			lat:          a.ADSB.Lat,
+			lon:          a.ADSB.Lon,
+			course:       adsb.TrueCourse(a.ADSB, declination),
+			hasPosition:  a.ADSB.Lat != 0 || a.ADSB.Lon != 0,
+			tookOff:      a.DateTookoff,
+			landed:       a.DateLanded,
+		})
+	}
+
+	select {
+	case s.cycles <- samples:
+	default:
+		s.logger.Debug("Movements worker busy, skipping poll cycle")
+	}
+}
+
+// run tracks poll cycles, stores active sessions and prunes old ones until the service stops
+func (s *Service) run() {
+	defer s.wg.Done()
+
+	flush := time.NewTicker(flushInterval)
+	defer flush.Stop()
+	prune := time.NewTicker(pruneInterval)
+	defer prune.Stop()
+
+	s.prune()
+	for {
+		select {
+		case <-s.ctx.Done():
+			for _, ss := range s.sessions {
+				s.end(ss)
+			}
+			return
+		case <-flush.C:
+			s.flush()
+		case <-prune.C:
+			s.prune()
+		case samples := <-s.cycles:
+			s.track(samples, time.Now().UTC())
+		}
+	}
+}
+
+// track continues the session of each aircraft seen, starting one for aircraft unseen for
+// longer than the session gap, records takeoffs and landings, and ends the sessions of
+// aircraft gone for longer than the gap
+func (s *Service) track(samples []sample, now time.Time) {
+	gap := time.Duration(s.config.SessionGapMinutes) * time.Minute
+
+	for _, smp := range samples {
+		ss, ok := s.sessions[smp.hex]
+		if ok && now.Sub(ss.record.LastSeen) > gap {
+			s.end(ss)
+			ok = false
+		}
+		if !ok {
+			s.sessions[smp.hex] = s.start(smp, now)
+			continue
+		}
+
+		tookOff := isNewer(smp.tookOff, ss.tookOff)
+		landed := isNewer(smp.landed, ss.landed)
+
+		// A takeoff after landing here is the next flight; the ground time stays with this one
+		if tookOff && ss.record.ArrivedAt != nil {
+			previous := ss.tookOff
+			s.end(ss)
+			ss = s.start(smp, now)
+			ss.tookOff = previous
+			s.sessions[smp.hex] = ss
+		}
+
+		if tookOff {
+			s.depart(ss, smp, *smp.tookOff)
+		}
+		if landed {
+			s.arrive(ss, smp, *smp.landed, landingBeforeNM, landingPastNM)
+		}
+		ss.tookOff, ss.landed = smp.tookOff, smp.landed
+		s.update(ss, smp, now)
+	}
+
+	for _, ss := range s.sessions {
+		if now.Sub(ss.record.LastSeen) > gap {
+			s.end(ss)
+		}
+	}
+}
+
+// start stores a new session for an aircraft
+func (s *Service) start(smp sample, now time.Time) *session {
+	ss := &session{
+		record: &sqlite.FlightSessionRecord{
+			Hex:          smp.hex,
+			Callsign:     smp.flight,
+			Registration: smp.registration,
+			AircraftType: smp.aircraftType,
+			Operator:     smp.operator,
+			FirstSeen:    now,
+			LastSeen:     now,
+			Active:       true,
+		},
+		tookOff: smp.tookOff,
+		landed:  smp.landed,
+	}
+	if smp.hasPosition {
+		ss.last = smp
+	}
+	if err := s.storage.AddSession(ss.record); err != nil {
+		s.logger.Error("Failed to store flight session", logger.String("hex", smp.hex), logger.Error(err))
+	}
+	return ss
+}
+
+// update records the latest details of a session's aircraft
+func (s *Service) update(ss *session, smp sample, now time.Time) {
+	record := ss.record
+	record.LastSeen = now
+	if smp.flight != "" && smp.flight != record.Callsign {
+		record.Callsign, record.Operator = smp.flight, smp.operator
+	}
+	if smp.registration != "" {
+		record.Registration = smp.registration
+	}
+	if smp.aircraftType != "" {
+		record.AircraftType = smp.aircraftType
+	}
+	if smp.hasPosition {
+		ss.last = smp
+	}
+	ss.changed = true
+}
+
+// depart records a takeoff from the airport. Takeoffs elsewhere, away from the runways and
+// the airport, are left out.
+func (s *Service) depart(ss *session, smp sample, at time.Time) {
+	runway := ""
+	if smp.hasPosition {
+		runway = runwayAt(s.adsbService.RunwayEnds(), smp.lat, smp.lon, smp.course, takeoffBeforeNM, takeoffPastNM)
+	}
+	if runway == "" && !s.atAirport(smp) {
+		return
+	}
+
+	ss.record.DepartedAt = &at
+	ss.record.DepartureRunway = runway
+	s.store(ss)
+	s.logger.Info("Departure",
+		logger.String("hex", ss.record.Hex),
+		logger.String("callsign", ss.record.Callsign),
+		logger.String("runway", runway))
+}
+
+// arrive records a landing at the airport, matching the runway from the aircraft's position
+// within the along-track range. Landings elsewhere are left out.
+func (s *Service) arrive(ss *session, smp sample, at time.Time, beforeNM, pastNM float64) {
+	runway := ""
+	if smp.hasPosition {
+		runway = runwayAt(s.adsbService.RunwayEnds(), smp.lat, smp.lon, smp.course, beforeNM, pastNM)
+	}
+	if runway == "" && !s.atAirport(smp) {
+		return
+	}
+
+	ss.record.ArrivedAt = &at
+	ss.record.ArrivalRunway = runway
+	s.store(ss)
+	s.logger.Info("Arrival",
+		logger.String("hex", ss.record.Hex),
+		logger.String("callsign", ss.record.Callsign),
+		logger.String("runway", runway))
+}
+
+// end stores a session as ended and stops following it. An aircraft the flight phases marked
+// as landed after its signal was lost near the airport arrives at the runway it was lined up
+// with when last seen.
+func (s *Service) end(ss *session) {
+	delete(s.sessions, ss.record.Hex)
+
+	if ss.record.ArrivedAt == nil {
+		if a, found := s.adsbService.GetAircraftByHex(ss.record.Hex); found && isNewer(a.DateLanded, ss.landed) {
+			s.arrive(ss, ss.last, *a.DateLanded, lostBeforeNM, landingPastNM)
+		}
+	}
+
+	ss.record.Active = false
+	s.store(ss)
+}
+
+// flush stores the sessions that changed since they were last stored
+func (s *Service) flush() {
+	for _, ss := range s.sessions {
+		if ss.changed {
+			s.store(ss)
+		}
+	}
+}
+
+// store writes a session to storage
+func (s *Service) store(ss *session) {
+	ss.changed = false
+	if ss.record.ID == 0 {
+		return
+	}
+	if err := s.storage.UpdateSession(ss.record); err != nil {
+		s.logger.Error("Failed to update flight session", logger.String("hex", ss.record.Hex), logger.Error(err))
+	}
+}
+
+// prune deletes sessions older than retention_days
+func (s *Service) prune() {
+	cutoff := time.Now().UTC().AddDate(0, 0, -s.config.RetentionDays)
+	if deleted, err := s.storage.DeleteSessionsBefore(cutoff); err != nil {
+		s.logger.Error("Failed to prune flight sessions", logger.Error(err))
+	} else if deleted > 0 {
+		s.logger.Debug("Pruned flight sessions", logger.Int64("deleted", deleted))
+	}
+}
+
+// atAirport reports whether an aircraft is within the airport range of the station
+func (s *Service) atAirport(smp sample) bool {
+	if !smp.hasPosition {
+		return false
+	}
+	lat, lon := s.adsbService.GetEffectiveStationCoords()
+	return adsb.MetersToNM(adsb.Haversine(lat, lon, smp.lat, smp.lon)) <= s.airportRangeNM
+}
+
+// runwayAt returns the threshold whose runway an aircraft is lined up with and over, from
+// beforeNM short of the threshold to pastNM beyond the far end, or "" if there's none
+func runwayAt(ends []adsb.RunwayEnd, lat, lon, course, beforeNM, pastNM float64) string {
+	best, bestOffset := "", runwayHalfWidthNM
+	for _, end := range ends {
+		if adsb.HeadingDifference(course, end.Heading) > runwayAlignment {
+			continue
+		}
+		// Measured along the runway, the opposite of the approach path
+		approach, right := adsb.RunwayPosition(end, lat, lon)
+		along, offset := -approach, math.Abs(right)
+		if along < -beforeNM || along > end.LandingLengthNM+pastNM || offset > bestOffset {
+			continue
+		}
+		best, bestOffset = end.ID, offset
+	}
+	return best
+}
+
+// isNewer reports whether a takeoff or landing time is later than the one already known
+func isNewer(t, known *time.Time) bool {
+	return t != nil && (known == nil || t.After(*known))
+}
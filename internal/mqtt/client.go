@@ -0,0 +1,244 @@
+// Package mqtt is a minimal MQTT 3.1.1 client that publishes QoS 0 messages, and the
+// Publisher that feeds aircraft, transcriptions, events and alerts to a broker. It covers
+// what co-atc needs to feed brokers such as Mosquitto or Home Assistant's, without
+// subscriptions or delivery guarantees.
+package mqtt
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"sync"
+	"time"
+)
+
+// MQTT control packet types, shifted into the first header byte
+const (
+	packetConnect    = 0x10
+	packetConnAck    = 0x20
+	packetPublish    = 0x30
+	packetPingReq    = 0xC0
+	packetDisconnect = 0xE0
+)
+
+// maxRemainingLength is the largest packet body MQTT can encode
+const maxRemainingLength = 268435455
+
+// writeTimeout bounds a write, so a stalled broker shows up as an error instead of a hang
+const writeTimeout = 10 * time.Second
+
+// connectReturnCodes explains the CONNACK return codes
+var connectReturnCodes = map[byte]string{
+	1: "unacceptable protocol version",
+	2: "client identifier rejected",
+	3: "server unavailable",
+	4: "bad username or password",
+	5: "not authorized",
+}
+
+// Options are the connection settings
+type Options struct {
+	BrokerURL string        // tcp://host:1883 or mqtt://host:1883, mqtts://host:8883 for TLS
+	ClientID  string        // Unique per connection; brokers drop the older of two connections with the same ID
+	Username  string        // Empty = anonymous
+	Password  string        // Sent only with a username
+	KeepAlive time.Duration // Longest silence before the broker drops the connection (0 = never)
+
+	// Last will, published by the broker when the connection drops without DISCONNECT
+	WillTopic   string // Empty = no will
+	WillPayload []byte
+	WillRetain  bool
+}
+
+// Conn is a connection to a broker
+type Conn struct {
+	conn net.Conn
+	mu   sync.Mutex    // Serializes writes
+	done chan struct{} // Closed when the connection is lost or closed
+}
+
+// Dial connects to a broker and sends CONNECT, waiting for the broker's CONNACK
+func Dial(ctx context.Context, opts Options) (*Conn, error) {
+	broker, err := url.Parse(opts.BrokerURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid broker url: %w", err)
+	}
+
+	secure := false
+	port := "1883"
+	switch broker.Scheme {
+	case "tcp", "mqtt":
+	case "mqtts", "ssl":
+		secure = true
+		port = "8883"
+	default:
+		return nil, fmt.Errorf("unsupported broker scheme: %q", broker.Scheme)
+	}
+	if broker.Port() != "" {
+		port = broker.Port()
+	}
+	address := net.JoinHostPort(broker.Hostname(), port)
+
+	var conn net.Conn
+	if secure {
+		dialer := &tls.Dialer{Config: &tls.Config{ServerName: broker.Hostname()}}
+		conn, err = dialer.DialContext(ctx, "tcp", address)
+	} else {
+		var dialer net.Dialer
+		conn, err = dialer.DialContext(ctx, "tcp", address)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to broker: %w", err)
+	}
+
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+	if err := handshake(conn, opts); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	conn.SetDeadline(time.Time{})
+
+	c := &Conn{conn: conn, done: make(chan struct{})}
+	go c.readLoop()
+	return c, nil
+}
+
+// handshake sends CONNECT and reads CONNACK
+func handshake(conn net.Conn, opts Options) error {
+	flags := byte(0x02) // Clean session
+	payload := encodeString(opts.ClientID)
+	if opts.WillTopic != "" {
+		flags |= 0x04
+		if opts.WillRetain {
+			flags |= 0x20
+		}
+		payload = append(payload, encodeString(opts.WillTopic)...)
+		payload = append(payload, byte(len(opts.WillPayload)>>8), byte(len(opts.WillPayload)))
+		payload = append(payload, opts.WillPayload...)
+	}
+	if opts.Username != "" {
+		flags |= 0x80
+		payload = append(payload, encodeString(opts.Username)...)
+		if opts.Password != "" {
+			flags |= 0x40
+			payload = append(payload, encodeString(opts.Password)...)
+		}
+	}
+
+	keepAlive := int(opts.KeepAlive / time.Second)
+	if keepAlive > 0xFFFF {
+		keepAlive = 0xFFFF
+	}
+	body := append(encodeString("MQTT"), 4, flags, byte(keepAlive>>8), byte(keepAlive))
+	body = append(body, payload...)
+
+	if err := writePacket(conn, packetConnect, body); err != nil {
+		return fmt.Errorf("failed to send connect: %w", err)
+	}
+
+	ack := make([]byte, 4) // Header, remaining length (2), session present flag, return code
+	if _, err := io.ReadFull(conn, ack); err != nil {
+		return fmt.Errorf("failed to read connack: %w", err)
+	}
+	if ack[0]&0xF0 != packetConnAck {
+		return fmt.Errorf("unexpected packet from broker: 0x%02x", ack[0])
+	}
+	if code := ack[3]; code != 0 {
+		reason, ok := connectReturnCodes[code]
+		if !ok {
+			reason = fmt.Sprintf("return code %d", code)
+		}
+		return fmt.Errorf("broker refused connection: %s", reason)
+	}
+	return nil
+}
+
+// Publish sends a QoS 0 message. Retained messages are kept by the broker and sent to
+// clients that subscribe later.
+func (c *Conn) Publish(topic string, payload []byte, retain bool) error {
+	if topic == "" {
+		return errors.New("empty topic")
+	}
+	header := byte(packetPublish)
+	if retain {
+		header |= 0x01
+	}
+	body := append(encodeString(topic), payload...)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.write(header, body)
+}
+
+// Ping sends PINGREQ, keeping an idle connection alive. The response isn't read; a dead
+// connection shows up as a write error on a later packet.
+func (c *Conn) Ping() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.write(packetPingReq, nil)
+}
+
+// Done returns a channel that is closed when the connection is lost or closed
+func (c *Conn) Done() <-chan struct{} {
+	return c.done
+}
+
+// Close sends DISCONNECT and closes the connection
+func (c *Conn) Close() error {
+	c.mu.Lock()
+	c.write(packetDisconnect, nil)
+	c.mu.Unlock()
+	return c.conn.Close()
+}
+
+// write writes a packet within writeTimeout. Callers hold mu.
+func (c *Conn) write(header byte, body []byte) error {
+	c.conn.SetWriteDeadline(time.Now().Add(writeTimeout))
+	return writePacket(c.conn, header, body)
+}
+
+// readLoop discards what the broker sends (only PINGRESP, since nothing is subscribed)
+// and closes done when the connection ends
+func (c *Conn) readLoop() {
+	defer close(c.done)
+	io.Copy(io.Discard, c.conn)
+}
+
+// writePacket writes a packet: header byte, remaining length and body
+func writePacket(w io.Writer, header byte, body []byte) error {
+	if len(body) > maxRemainingLength {
+		return fmt.Errorf("packet too large: %d bytes", len(body))
+	}
+
+	packet := make([]byte, 0, len(body)+5)
+	packet = append(packet, header)
+	length := len(body)
+	for {
+		digit := byte(length % 128)
+		length /= 128
+		if length > 0 {
+			digit |= 0x80
+		}
+		packet = append(packet, digit)
+		if length == 0 {
+			break
+		}
+	}
+	packet = append(packet, body...)
+
+	_, err := w.Write(packet)
+	return err
+}
+
+// encodeString encodes a length-prefixed UTF-8 string
+func encodeString(s string) []byte {
+	b := make([]byte, 0, len(s)+2)
+	b = append(b, byte(len(s)>>8), byte(len(s)))
+	return append(b, s...)
+}
@@ -0,0 +1,418 @@
+package mqtt
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/yegors/co-atc/internal/adsb"
+	"github.com/yegors/co-atc/internal/config"
+	"github.com/yegors/co-atc/internal/websocket"
+	"github.com/yegors/co-atc/pkg/logger"
+)
+
+const (
+	queueSize         = 1000             // Messages waiting for the broker before new ones are dropped
+	minReconnectDelay = 5 * time.Second  // First wait after a lost connection
+	maxReconnectDelay = 60 * time.Second // Longest wait between connection attempts
+	connectTimeout    = 15 * time.Second // Longest a connection attempt may take
+)
+
+// Payloads of the availability topic
+const (
+	availabilityOnline  = "online"
+	availabilityOffline = "offline"
+)
+
+// skippedEvents are WebSocket messages not published as events: aircraft data has its own
+// topics, and status updates are too frequent to be useful downstream
+var skippedEvents = map[string]bool{
+	websocket.MessageTypeAircraftAdded:        true,
+	websocket.MessageTypeAircraftUpdate:       true,
+	websocket.MessageTypeAircraftRemoved:      true,
+	websocket.MessageTypeAircraftBatch:        true,
+	websocket.MessageTypeAircraftBulkResponse: true,
+	"status_update":                           true,
+	"transcription":                           true, // Published to the transcriptions topic
+	"event":                                   true, // Recorded alerts, published to the alerts topic
+}
+
+// unsafeIDChars are the characters Home Assistant doesn't accept in discovery IDs
+var unsafeIDChars = regexp.MustCompile(`[^a-zA-Z0-9_-]`)
+
+// message is a queued publish
+type message struct {
+	topic   string
+	payload []byte
+	retain  bool
+}
+
+// Publisher keeps a connection to a broker and publishes aircraft summaries,
+// transcriptions, events and alerts to it. Topics are under the configured prefix:
+//
+//	{prefix}/availability     online/offline (retained, also the last will)
+//	{prefix}/aircraft         aircraft counts and positions (retained)
+//	{prefix}/aircraft/{hex}   each aircraft, if per_aircraft_topics is set
+//	{prefix}/transcriptions   completed transcriptions
+//	{prefix}/events/{type}    phase changes, runway status, clearances, ...
+//	{prefix}/alerts           emergency, runway, watchlist and other alerts
+type Publisher struct {
+	cfg     config.MQTTConfig
+	airport string
+	queue   chan message
+	ctx     context.Context
+	cancel  context.CancelFunc
+	wg      sync.WaitGroup
+	logger  *logger.Logger
+
+	mu             sync.Mutex
+	lastAircraftAt time.Time // When the last aircraft summary was queued
+}
+
+// NewPublisher creates a publisher. Nothing is sent until Start.
+func NewPublisher(cfg config.MQTTConfig, airportCode string, logger *logger.Logger) *Publisher {
+	return &Publisher{
+		cfg:     cfg,
+		airport: airportCode,
+		queue:   make(chan message, queueSize),
+		logger:  logger.Named("mqtt"),
+	}
+}
+
+// Start connects to the broker in the background, reconnecting whenever the connection is lost
+func (p *Publisher) Start(ctx context.Context) {
+	p.ctx, p.cancel = context.WithCancel(ctx)
+
+	p.wg.Add(1)
+	go func() {
+		defer p.wg.Done()
+		p.run()
+	}()
+
+	p.logger.Info("MQTT publisher started",
+		logger.String("broker", p.cfg.BrokerURL),
+		logger.String("topic_prefix", p.cfg.TopicPrefix))
+}
+
+// Stop marks the publisher offline and disconnects. Messages still queued are dropped.
+func (p *Publisher) Stop() {
+	if p.cancel != nil {
+		p.cancel()
+	}
+	p.wg.Wait()
+	p.logger.Info("MQTT publisher stopped")
+}
+
+// HandleAircraft publishes the aircraft of a poll cycle, at most once per aircraft
+// interval. Registered with the ADS-B service's OnUpdate.
+func (p *Publisher) HandleAircraft(aircraft []*adsb.Aircraft) {
+	if !p.cfg.PublishAircraft {
+		return
+	}
+
+	now := time.Now().UTC()
+	p.mu.Lock()
+	if now.Sub(p.lastAircraftAt) < time.Duration(p.cfg.AircraftIntervalSeconds)*time.Second {
+		p.mu.Unlock()
+		return
+	}
+	p.lastAircraftAt = now
+	p.mu.Unlock()
+
+	summaries := make([]map[string]interface{}, 0, len(aircraft))
+	airborne, ground := 0, 0
+	for _, a := range aircraft {
+		if a.ADSB != nil && a.ADSB.Type == adsb.TargetTypeReplay {
+			continue
+		}
+		if a.OnGround {
+			ground++
+		} else {
+			airborne++
+		}
+		summary := aircraftSummary(a)
+		summaries = append(summaries, summary)
+
+		if p.cfg.PerAircraftTopics {
+			p.publishJSON(p.topic("aircraft/"+strings.ToLower(a.Hex)), summary, false)
+		}
+	}
+
+	p.publishJSON(p.topic("aircraft"), map[string]interface{}{
+		"timestamp": now,
+		"airport":   p.airport,
+		"total":     len(summaries),
+		"airborne":  airborne,
+		"ground":    ground,
+		"aircraft":  summaries,
+	}, true)
+}
+
+// HandleBroadcast publishes completed transcriptions and events broadcast to the web UI.
+// Registered with the WebSocket server's OnBroadcast.
+func (p *Publisher) HandleBroadcast(msg *websocket.Message) {
+	if replay, _ := msg.Data["replay"].(bool); replay {
+		return // Replayed history isn't news downstream
+	}
+	if msg.Type == "transcription" {
+		// Partial transcriptions stream word by word; only finished ones are published
+		if complete, _ := msg.Data["is_complete"].(bool); !complete || !p.cfg.PublishTranscriptions {
+			return
+		}
+		p.publishJSON(p.topic("transcriptions"), map[string]interface{}{
+			"id":           msg.Data["id"],
+			"frequency_id": msg.Data["frequency_id"],
+			"text":         msg.Data["text"],
+			"timestamp":    msg.Data["timestamp"],
+			"airport":      p.airport,
+		}, false)
+		return
+	}
+
+	if !p.cfg.PublishEvents || skippedEvents[msg.Type] {
+		return
+	}
+	p.publishJSON(p.topic("events/"+msg.Type), map[string]interface{}{
+		"type":      msg.Type,
+		"airport":   p.airport,
+		"timestamp": time.Now().UTC(),
+		"data":      msg.Data,
+	}, false)
+}
+
+// NotifyAlert publishes an alert; used as one of the alert notifiers
+func (p *Publisher) NotifyAlert(alertType, title, body string, data map[string]interface{}) {
+	if !p.cfg.PublishAlerts {
+		return
+	}
+	p.publishJSON(p.topic("alerts"), map[string]interface{}{
+		"type":      alertType,
+		"title":     title,
+		"body":      body,
+		"airport":   p.airport,
+		"timestamp": time.Now().UTC(),
+		"data":      data,
+	}, false)
+}
+
+// publishJSON queues a value as JSON. It never blocks; messages are dropped while the
+// queue is full, e.g. while the broker is unreachable.
+func (p *Publisher) publishJSON(topic string, v interface{}, retain bool) {
+	payload, err := json.Marshal(v)
+	if err != nil {
+		p.logger.Error("Failed to encode MQTT payload", logger.String("topic", topic), logger.Error(err))
+		return
+	}
+	select {
+	case p.queue <- message{topic: topic, payload: payload, retain: retain}:
+	default:
+		p.logger.Debug("MQTT queue full, dropping message", logger.String("topic", topic))
+	}
+}
+
+// topic returns a topic under the prefix
+func (p *Publisher) topic(suffix string) string {
+	return p.cfg.TopicPrefix + "/" + suffix
+}
+
+// run keeps a connection open and publishes queued messages until the publisher stops
+func (p *Publisher) run() {
+	delay := minReconnectDelay
+	for {
+		conn, err := p.connect()
+		if err != nil {
+			p.logger.Warn("Failed to connect to MQTT broker",
+				logger.String("broker", p.cfg.BrokerURL),
+				logger.Duration("retry_in", delay),
+				logger.Error(err))
+			select {
+			case <-p.ctx.Done():
+				return
+			case <-time.After(delay):
+			}
+			delay *= 2
+			if delay > maxReconnectDelay {
+				delay = maxReconnectDelay
+			}
+			continue
+		}
+
+		delay = minReconnectDelay
+		p.logger.Info("Connected to MQTT broker", logger.String("broker", p.cfg.BrokerURL))
+
+		err = p.serve(conn)
+		if err == nil {
+			return // Stopped
+		}
+		p.logger.Warn("Lost connection to MQTT broker", logger.Error(err))
+	}
+}
+
+// connect dials the broker, announces the publisher online and publishes the discovery configs
+func (p *Publisher) connect() (*Conn, error) {
+	ctx, cancel := context.WithTimeout(p.ctx, connectTimeout)
+	defer cancel()
+
+	availability := p.topic("availability")
+	conn, err := Dial(ctx, Options{
+		BrokerURL:   p.cfg.BrokerURL,
+		ClientID:    p.cfg.ClientID,
+		Username:    p.cfg.Username,
+		Password:    p.cfg.Password,
+		KeepAlive:   time.Duration(p.cfg.KeepAliveSeconds) * time.Second,
+		WillTopic:   availability,
+		WillPayload: []byte(availabilityOffline),
+		WillRetain:  true,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if err := conn.Publish(availability, []byte(availabilityOnline), true); err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("failed to publish availability: %w", err)
+	}
+	if p.cfg.HomeAssistantDiscovery {
+		for _, msg := range p.discoveryMessages() {
+			if err := conn.Publish(msg.topic, msg.payload, msg.retain); err != nil {
+				conn.Close()
+				return nil, fmt.Errorf("failed to publish discovery config: %w", err)
+			}
+		}
+	}
+	return conn, nil
+}
+
+// serve publishes queued messages and keeps the connection alive. It returns nil when the
+// publisher stops, or the error that ended the connection.
+func (p *Publisher) serve(conn *Conn) error {
+	keepAlive := time.NewTicker(time.Duration(p.cfg.KeepAliveSeconds) * time.Second / 2)
+	defer keepAlive.Stop()
+
+	for {
+		select {
+		case <-p.ctx.Done():
+			// Going offline on purpose; the will is only sent for unexpected disconnects
+			conn.Publish(p.topic("availability"), []byte(availabilityOffline), true)
+			conn.Close()
+			return nil
+		case <-conn.Done():
+			conn.Close()
+			return fmt.Errorf("connection closed by broker")
+		case <-keepAlive.C:
+			if err := conn.Ping(); err != nil {
+				conn.Close()
+				return err
+			}
+		case msg := <-p.queue:
+			if err := conn.Publish(msg.topic, msg.payload, msg.retain); err != nil {
+				conn.Close()
+				return err
+			}
+		}
+	}
+}
+
+// discoveryMessages returns the Home Assistant discovery configs of the published sensors,
+// so they show up as one device without any YAML
+func (p *Publisher) discoveryMessages() []message {
+	nodeID := unsafeIDChars.ReplaceAllString(p.cfg.ClientID, "_")
+	device := map[string]interface{}{
+		"identifiers":  []string{nodeID},
+		"name":         strings.TrimSpace("Co-ATC " + p.airport),
+		"manufacturer": "Co-ATC",
+		"model":        "Co-ATC",
+	}
+
+	type sensor struct {
+		id            string
+		name          string
+		stateTopic    string
+		valueTemplate string
+		icon          string
+		counter       bool // Aircraft count, with a unit and statistics
+		attributes    bool // The whole payload is also the sensor's attributes
+	}
+	var sensors []sensor
+	if p.cfg.PublishAircraft {
+		sensors = append(sensors,
+			sensor{id: "aircraft_total", name: "Aircraft", stateTopic: p.topic("aircraft"), valueTemplate: "{{ value_json.total }}", icon: "mdi:airplane", counter: true},
+			sensor{id: "aircraft_airborne", name: "Aircraft airborne", stateTopic: p.topic("aircraft"), valueTemplate: "{{ value_json.airborne }}", icon: "mdi:airplane-takeoff", counter: true},
+			sensor{id: "aircraft_ground", name: "Aircraft on ground", stateTopic: p.topic("aircraft"), valueTemplate: "{{ value_json.ground }}", icon: "mdi:airport", counter: true},
+		)
+	}
+	if p.cfg.PublishTranscriptions {
+		// States are limited to 255 characters; the full text is in the attributes
+		sensors = append(sensors, sensor{id: "last_transcription", name: "Last transmission", stateTopic: p.topic("transcriptions"),
+			valueTemplate: "{{ value_json.text[:255] }}", icon: "mdi:radio-tower", attributes: true})
+	}
+	if p.cfg.PublishAlerts {
+		sensors = append(sensors, sensor{id: "last_alert", name: "Last alert", stateTopic: p.topic("alerts"),
+			valueTemplate: "{{ value_json.title[:255] }}", icon: "mdi:alert", attributes: true})
+	}
+
+	messages := make([]message, 0, len(sensors))
+	for _, s := range sensors {
+		cfg := map[string]interface{}{
+			"name":               s.name,
+			"unique_id":          nodeID + "_" + s.id,
+			"object_id":          nodeID + "_" + s.id,
+			"state_topic":        s.stateTopic,
+			"value_template":     s.valueTemplate,
+			"icon":               s.icon,
+			"availability_topic": p.topic("availability"),
+			"device":             device,
+		}
+		if s.counter {
+			cfg["unit_of_measurement"] = "aircraft"
+			cfg["state_class"] = "measurement"
+		}
+		if s.attributes {
+			cfg["json_attributes_topic"] = s.stateTopic
+		}
+
+		payload, err := json.Marshal(cfg)
+		if err != nil {
+			continue
+		}
+		messages = append(messages, message{
+			topic:   fmt.Sprintf("%s/sensor/%s/%s/config", p.cfg.DiscoveryPrefix, nodeID, s.id),
+			payload: payload,
+			retain:  true,
+		})
+	}
+	return messages
+}
+
+// aircraftSummary returns the fields of an aircraft worth publishing
+func aircraftSummary(a *adsb.Aircraft) map[string]interface{} {
+	summary := map[string]interface{}{
+		"hex":       strings.ToLower(a.Hex),
+		"flight":    strings.TrimSpace(a.Flight),
+		"on_ground": a.OnGround,
+	}
+	if a.Airline != "" {
+		summary["airline"] = a.Airline
+	}
+	if a.Distance != nil {
+		summary["distance_nm"] = *a.Distance
+	}
+	if a.Phase != nil && len(a.Phase.Current) > 0 {
+		summary["phase"] = a.Phase.Current[0].Phase
+	}
+	if a.ADSB != nil {
+		summary["registration"] = a.ADSB.Registration
+		summary["type"] = a.ADSB.AircraftType
+		summary["lat"] = a.ADSB.Lat
+		summary["lon"] = a.ADSB.Lon
+		summary["altitude"] = a.ADSB.AltBaro
+		summary["ground_speed"] = a.ADSB.GS
+		summary["track"] = a.ADSB.Track
+		summary["squawk"] = a.ADSB.Squawk
+	}
+	return summary
+}
@@ -0,0 +1,379 @@
+// Package noise monitors noise-sensitive zones: aircraft overflying a zone below its altitude
+// during curfew hours are recorded as violations, alerted and reported per operator.
+package noise
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/yegors/co-atc/internal/adsb"
+	"github.com/yegors/co-atc/internal/config"
+	"github.com/yegors/co-atc/internal/storage/sqlite"
+	"github.com/yegors/co-atc/internal/websocket"
+	"github.com/yegors/co-atc/pkg/logger"
+)
+
+const (
+	// overflightTimeout is how long an aircraft in violation may go unseen before its
+	// violation is ended
+	overflightTimeout = time.Minute
+	// pruneInterval is how often violations older than retention_days are deleted
+	pruneInterval = time.Hour
+	// unknownOperator groups the violations of private flights and unknown operators in reports
+	unknownOperator = "Unknown"
+)
+
+// AlertNotifier receives alerts that should reach users outside the web UI
+type AlertNotifier interface {
+	NotifyAlert(alertType, title, body string, data map[string]interface{})
+}
+
+// sample is what monitoring needs from an airborne aircraft in a poll cycle
+type sample struct {
+	hex          string
+	flight       string
+	registration string
+	aircraftType string
+	operator     string
+	lat, lon     float64
+	altitude     float64 // MSL, corrected to the QNH the aircraft sends
+	recorded     bool    // Real traffic: violations are stored and notified
+}
+
+// overflight is an aircraft in violation over a zone
+type overflight struct {
+	record   *sqlite.NoiseViolationRecord // ID 0 when not stored
+	lastSeen time.Time
+}
+
+// OperatorSummary is the violations of one operator in a report
+type OperatorSummary struct {
+	Operator         string         `json:"operator"` // Airline name, or "Unknown" for private flights
+	Violations       int            `json:"violations"`
+	Aircraft         int            `json:"aircraft"` // Distinct aircraft
+	LowestAltitudeFt float64        `json:"lowest_altitude_ft"`
+	Zones            map[string]int `json:"zones"` // Violations by zone
+}
+
+// Report summarizes the violations started in a time range
+type Report struct {
+	Start      time.Time         `json:"start"`
+	End        time.Time         `json:"end"`
+	Violations int               `json:"violations"`
+	Zones      map[string]int    `json:"zones"`     // Violations by zone
+	Operators  []OperatorSummary `json:"operators"` // Most violations first
+}
+
+// Service watches noise-sensitive zones for aircraft overflying them too low during
+// restricted times
+type Service struct {
+	config      config.NoiseConfig
+	adsbService *adsb.Service
+	storage     *sqlite.NoiseStorage
+	wsServer    *websocket.Server
+	notifier    AlertNotifier
+	logger      *logger.Logger
+
+	zones    []*zone
+	location *time.Location
+
+	cycles chan []sample
+
+	// Overflights in violation, by hex and zone name. Only used by the worker goroutine.
+	overflights map[string]*overflight
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// NewService creates a new noise monitoring service
+func NewService(cfg config.NoiseConfig, adsbService *adsb.Service, storage *sqlite.NoiseStorage, wsServer *websocket.Server, logger *logger.Logger) *Service {
+	return &Service{
+		config:      cfg,
+		adsbService: adsbService,
+		storage:     storage,
+		wsServer:    wsServer,
+		logger:      logger.Named("noise"),
+		cycles:      make(chan []sample, 4),
+		overflights: make(map[string]*overflight),
+	}
+}
+
+// SetAlertNotifier sets the notifier that receives violations. Must be called before Start.
+func (s *Service) SetAlertNotifier(notifier AlertNotifier) {
+	s.notifier = notifier
+}
+
+// Start builds the zones and starts watching them
+func (s *Service) Start(ctx context.Context) error {
+	zones, err := newZones(s.config)
+	if err != nil {
+		return fmt.Errorf("failed to build noise zones: %w", err)
+	}
+	s.zones = zones
+
+	s.location = time.Local
+	if s.config.Timezone != "" {
+		if s.location, err = time.LoadLocation(s.config.Timezone); err != nil {
+			return fmt.Errorf("failed to load noise timezone: %w", err)
+		}
+	}
+
+	// Violations left open by a shutdown can't be followed any more
+	if ended, err := s.storage.EndOpenViolations(); err != nil {
+		return err
+	} else if ended > 0 {
+		s.logger.Info("Ended noise violations left open", logger.Int64("count", ended))
+	}
+
+	s.ctx, s.cancel = context.WithCancel(ctx)
+	s.wg.Add(1)
+	go s.run()
+
+	s.adsbService.OnUpdate(s.handleUpdate)
+
+	s.logger.Info("Noise monitoring started",
+		logger.Int("zones", len(s.zones)),
+		logger.Int("max_altitude_feet", s.config.MaxAltitudeFeet),
+		logger.String("curfew", s.curfewDescription()),
+		logger.String("timezone", s.location.String()))
+	return nil
+}
+
+// Stop stops watching the zones and ends the violations in progress
+func (s *Service) Stop() {
+	if s.cancel != nil {
+		s.cancel()
+	}
+	s.wg.Wait()
+}
+
+// Violations returns the violations started in a time range, most recent first
+func (s *Service) Violations(start, end time.Time, limit int) ([]*sqlite.NoiseViolationRecord, error) {
+	return s.storage.ListViolations(start, end, limit)
+}
+
+// Report summarizes the violations started in a time range per operator
+func (s *Service) Report(start, end time.Time) (*Report, error) {
+	violations, err := s.storage.ListViolations(start, end, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &Report{
+		Start:      start,
+		End:        end,
+		Violations: len(violations),
+		Zones:      make(map[string]int),
+		Operators:  make([]OperatorSummary, 0),
+	}
+	operators := make(map[string]*OperatorSummary)
+	aircraft := make(map[string]map[string]bool)
+	for _, v := range violations {
+		report.Zones[v.Zone]++
+
+		name := v.Operator
+		if name == "" {
+			name = unknownOperator
+		}
+		summary, ok := operators[name]
+		if !ok {
+			summary = &OperatorSummary{Operator: name, LowestAltitudeFt: v.LowestAltitudeFt, Zones: make(map[string]int)}
+			operators[name] = summary
+			aircraft[name] = make(map[string]bool)
+		}
+		summary.Violations++
+		summary.Zones[v.Zone]++
+		summary.LowestAltitudeFt = min(summary.LowestAltitudeFt, v.LowestAltitudeFt)
+		aircraft[name][v.Hex] = true
+	}
+	for name, summary := range operators {
+		summary.Aircraft = len(aircraft[name])
+		report.Operators = append(report.Operators, *summary)
+	}
+	sort.Slice(report.Operators, func(i, j int) bool {
+		if report.Operators[i].Violations != report.Operators[j].Violations {
+			return report.Operators[i].Violations > report.Operators[j].Violations
+		}
+		return report.Operators[i].Operator < report.Operators[j].Operator
+	})
+	return report, nil
+}
+
+// handleUpdate hands the airborne aircraft of a poll cycle to the worker without blocking polling
+func (s *Service) handleUpdate(aircraft []*adsb.Aircraft) {
+	samples := make([]sample, 0)
+	for _, a := range aircraft {
+		if a.ADSB == nil || a.OnGround || a.Status != "active" || (a.ADSB.Lat == 0 && a.ADSB.Lon == 0) {
+			continue
+		}
+		samples = append(samples, sample{
+			hex:          strings.ToLower(a.Hex),
+			flight:       strings.TrimSpace(a.Flight),
+			registration: a.ADSB.Registration,
+			aircraftType: a.ADSB.AircraftType,
+			operator:     a.Airline,
+			lat:          a.ADSB.Lat,
+			lon:          a.ADSB.Lon,
+			altitude:     adsb.AltitudeMSL(a.ADSB),
+			recorded:     !a.IsSimulated && a.ADSB.Type != adsb.TargetTypeReplay,
+		})
+	}
+
+	select {
+	case s.cycles <- samples:
+	default:
+		s.logger.Debug("Noise worker busy, skipping poll cycle")
+	}
+}
+
+// run checks poll cycles and prunes old violations until the service stops
+func (s *Service) run() {
+	defer s.wg.Done()
+
+	ticker := time.NewTicker(pruneInterval)
+	defer ticker.Stop()
+
+	s.prune()
+	for {
+		select {
+		case <-s.ctx.Done():
+			for key, o := range s.overflights {
+				s.end(key, o)
+			}
+			return
+		case <-ticker.C:
+			s.prune()
+		case samples := <-s.cycles:
+			s.check(samples, time.Now().UTC())
+		}
+	}
+}
+
+// check starts a violation for each aircraft newly below the altitude of a restricted zone
+// it's over, and ends those of aircraft that left, climbed or whose curfew ended
+func (s *Service) check(samples []sample, now time.Time) {
+	local := now.In(s.location)
+	for _, smp := range samples {
+		for _, z := range s.zones {
+			key := smp.hex + "|" + z.name
+			o, inViolation := s.overflights[key]
+			violating := z.restricted(local) && smp.altitude < float64(z.maxAltitude) && z.contains(smp.lat, smp.lon)
+
+			switch {
+			case violating && !inViolation:
+				s.overflights[key] = s.start(z, smp, now)
+			case violating:
+				o.lastSeen = now
+				o.record.LowestAltitudeFt = min(o.record.LowestAltitudeFt, smp.altitude)
+			case inViolation:
+				o.lastSeen = now
+				s.end(key, o)
+			}
+		}
+	}
+
+	for key, o := range s.overflights {
+		if now.Sub(o.lastSeen) > overflightTimeout {
+			s.end(key, o)
+		}
+	}
+}
+
+// start records a violation and tells the UI and notifier about it
+func (s *Service) start(z *zone, smp sample, now time.Time) *overflight {
+	record := &sqlite.NoiseViolationRecord{
+		Zone:             z.name,
+		Hex:              smp.hex,
+		Callsign:         smp.flight,
+		Registration:     smp.registration,
+		AircraftType:     smp.aircraftType,
+		Operator:         smp.operator,
+		AltitudeFeet:     smp.altitude,
+		LowestAltitudeFt: smp.altitude,
+		MaxAltitudeFeet:  z.maxAltitude,
+		StartedAt:        now,
+	}
+	if smp.recorded {
+		if err := s.storage.AddViolation(record); err != nil {
+			s.logger.Error("Failed to store noise violation", logger.Error(err))
+		}
+	}
+
+	s.logger.Warn("Noise violation",
+		logger.String("zone", z.name),
+		logger.String("hex", smp.hex),
+		logger.String("callsign", smp.flight),
+		logger.String("operator", smp.operator),
+		logger.Float64("altitude_ft", smp.altitude),
+		logger.Int("max_altitude_ft", z.maxAltitude))
+
+	data := map[string]interface{}{
+		"id":              record.ID,
+		"zone":            record.Zone,
+		"hex":             record.Hex,
+		"callsign":        record.Callsign,
+		"registration":    record.Registration,
+		"aircraft_type":   record.AircraftType,
+		"operator":        record.Operator,
+		"altitude_ft":     record.AltitudeFeet,
+		"max_altitude_ft": record.MaxAltitudeFeet,
+		"started_at":      record.StartedAt,
+	}
+
+	if s.wsServer != nil {
+		s.wsServer.Broadcast(&websocket.Message{
+			Type: "noise_alert",
+			Data: data,
+		})
+	}
+
+	if s.notifier != nil && smp.recorded {
+		callsign := smp.flight
+		if callsign == "" {
+			callsign = smp.hex
+		}
+		s.notifier.NotifyAlert(
+			"noise",
+			fmt.Sprintf("Noise violation: %s", callsign),
+			fmt.Sprintf("%s over %s at %.0f ft, below %d ft during restricted hours", callsign, z.name, smp.altitude, z.maxAltitude),
+			data,
+		)
+	}
+
+	return &overflight{record: record, lastSeen: now}
+}
+
+// end records the lowest altitude and end of a violation and stops following it
+func (s *Service) end(key string, o *overflight) {
+	delete(s.overflights, key)
+	if o.record.ID == 0 {
+		return
+	}
+	if err := s.storage.EndViolation(o.record.ID, o.record.LowestAltitudeFt, o.lastSeen); err != nil {
+		s.logger.Error("Failed to end noise violation", logger.Error(err))
+	}
+}
+
+// prune deletes violations older than retention_days
+func (s *Service) prune() {
+	cutoff := time.Now().UTC().AddDate(0, 0, -s.config.RetentionDays)
+	if deleted, err := s.storage.DeleteViolationsBefore(cutoff); err != nil {
+		s.logger.Error("Failed to prune noise violations", logger.Error(err))
+	} else if deleted > 0 {
+		s.logger.Debug("Pruned noise violations", logger.Int64("deleted", deleted))
+	}
+}
+
+// curfewDescription describes the global curfew hours for logging
+func (s *Service) curfewDescription() string {
+	if s.config.CurfewStart == "" {
+		return "always"
+	}
+	return s.config.CurfewStart + "-" + s.config.CurfewEnd
+}
@@ -0,0 +1,112 @@
+package noise
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/yegors/co-atc/internal/adsb"
+	"github.com/yegors/co-atc/internal/config"
+)
+
+// curfew is a daily restricted period, in minutes since local midnight. It spans midnight
+// when it ends before it starts.
+type curfew struct {
+	start, end int
+}
+
+// zone is a noise-sensitive zone
+type zone struct {
+	name        string
+	lat, lon    float64
+	radiusNM    float64      // Circular zone; 0 for a polygon
+	polygon     [][2]float64 // [latitude, longitude] vertices
+	maxAltitude int          // Feet MSL
+	curfew      *curfew      // Nil when restricted at all times
+}
+
+// parseCurfew parses curfew hours, or returns nil when both are empty
+func parseCurfew(start, end string) (*curfew, error) {
+	if start == "" && end == "" {
+		return nil, nil
+	}
+	startTime, err := time.Parse("15:04", start)
+	if err != nil {
+		return nil, fmt.Errorf("invalid curfew_start %q: %w", start, err)
+	}
+	endTime, err := time.Parse("15:04", end)
+	if err != nil {
+		return nil, fmt.Errorf("invalid curfew_end %q: %w", end, err)
+	}
+	return &curfew{
+		start: startTime.Hour()*60 + startTime.Minute(),
+		end:   endTime.Hour()*60 + endTime.Minute(),
+	}, nil
+}
+
+// active reports whether a local time is within the curfew
+func (c *curfew) active(t time.Time) bool {
+	minute := t.Hour()*60 + t.Minute()
+	if c.start < c.end {
+		return minute >= c.start && minute < c.end
+	}
+	return minute >= c.start || minute < c.end
+}
+
+// newZones builds the zones of the configuration. Zones without their own altitude or
+// curfew take the global ones.
+func newZones(cfg config.NoiseConfig) ([]*zone, error) {
+	global, err := parseCurfew(cfg.CurfewStart, cfg.CurfewEnd)
+	if err != nil {
+		return nil, err
+	}
+
+	zones := make([]*zone, 0, len(cfg.Zones))
+	for _, zc := range cfg.Zones {
+		z := &zone{
+			name:        zc.Name,
+			lat:         zc.Latitude,
+			lon:         zc.Longitude,
+			radiusNM:    zc.RadiusNM,
+			maxAltitude: cfg.MaxAltitudeFeet,
+			curfew:      global,
+		}
+		if len(zc.Polygon) > 0 {
+			z.radiusNM = 0
+			for _, vertex := range zc.Polygon {
+				z.polygon = append(z.polygon, [2]float64{vertex[0], vertex[1]})
+			}
+		}
+		if zc.MaxAltitudeFeet > 0 {
+			z.maxAltitude = zc.MaxAltitudeFeet
+		}
+		if zc.CurfewStart != "" || zc.CurfewEnd != "" {
+			if z.curfew, err = parseCurfew(zc.CurfewStart, zc.CurfewEnd); err != nil {
+				return nil, fmt.Errorf("zone %s: %w", zc.Name, err)
+			}
+		}
+		zones = append(zones, z)
+	}
+	return zones, nil
+}
+
+// restricted reports whether the zone is restricted at a local time
+func (z *zone) restricted(local time.Time) bool {
+	return z.curfew == nil || z.curfew.active(local)
+}
+
+// contains reports whether a position is inside the zone
+func (z *zone) contains(lat, lon float64) bool {
+	if z.radiusNM > 0 {
+		return adsb.MetersToNM(adsb.Haversine(z.lat, z.lon, lat, lon)) <= z.radiusNM
+	}
+
+	// Ray casting: a point is inside if a ray from it crosses the edges an odd number of times
+	inside := false
+	for i, j := 0, len(z.polygon)-1; i < len(z.polygon); j, i = i, i+1 {
+		a, b := z.polygon[i], z.polygon[j]
+		if (a[0] > lat) != (b[0] > lat) && lon < (b[1]-a[1])*(lat-a[0])/(b[0]-a[0])+a[1] {
+			inside = !inside
+		}
+	}
+	return inside
+}
@@ -0,0 +1,263 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"text/template"
+	"time"
+
+	"github.com/yegors/co-atc/internal/config"
+	"github.com/yegors/co-atc/internal/mqtt"
+)
+
+// telegramAPIBaseURL is the Telegram Bot API
+const telegramAPIBaseURL = "https://api.telegram.org"
+
+// Default message templates of the chat channels
+const (
+	defaultDiscordTemplate  = "**{{.Title}}**\n{{.Body}}"
+	defaultSlackTemplate    = "*{{.Title}}*\n{{.Body}}"
+	defaultTelegramTemplate = "{{.Title}}\n{{.Body}}"
+)
+
+// Longest messages the chat services accept
+const (
+	discordMaxLength  = 2000
+	slackMaxLength    = 40000
+	telegramMaxLength = 4096
+)
+
+// templateFuncs are the functions available in payload and topic templates
+var templateFuncs = template.FuncMap{
+	"json": func(v interface{}) (string, error) {
+		b, err := json.Marshal(v)
+		return string(b), err
+	},
+	"upper": strings.ToUpper,
+	"lower": strings.ToLower,
+}
+
+// channel is a configured destination with its queue and delivery counters
+type channel struct {
+	cfg      config.NotifyChannelConfig
+	template *template.Template // Nil = the channel type's default payload
+	topic    *template.Template // MQTT only
+	client   *http.Client
+	queue    chan Event
+
+	mu          sync.Mutex
+	delivered   int
+	failed      int
+	dropped     int
+	lastSentAt  *time.Time
+	lastError   string
+	lastErrorAt *time.Time
+}
+
+// newChannel parses a channel's templates
+func newChannel(cfg config.NotifyChannelConfig) (*channel, error) {
+	ch := &channel{
+		cfg:    cfg,
+		client: &http.Client{Timeout: time.Duration(cfg.TimeoutSeconds) * time.Second},
+		queue:  make(chan Event, queueSize),
+	}
+
+	text := cfg.Template
+	if text == "" {
+		switch cfg.Type {
+		case config.NotifyChannelDiscord:
+			text = defaultDiscordTemplate
+		case config.NotifyChannelSlack:
+			text = defaultSlackTemplate
+		case config.NotifyChannelTelegram:
+			text = defaultTelegramTemplate
+		}
+	}
+	if text != "" {
+		tmpl, err := template.New(cfg.Name).Funcs(templateFuncs).Parse(text)
+		if err != nil {
+			return nil, fmt.Errorf("invalid template: %w", err)
+		}
+		ch.template = tmpl
+	}
+
+	if cfg.Type == config.NotifyChannelMQTT {
+		tmpl, err := template.New(cfg.Name + "-topic").Funcs(templateFuncs).Parse(cfg.Topic)
+		if err != nil {
+			return nil, fmt.Errorf("invalid topic: %w", err)
+		}
+		ch.topic = tmpl
+	}
+
+	return ch, nil
+}
+
+// accepts reports whether the channel delivers an event type
+func (ch *channel) accepts(eventType string) bool {
+	return len(ch.cfg.Events) == 0 || contains(ch.cfg.Events, eventType)
+}
+
+// send delivers an event once
+func (ch *channel) send(ctx context.Context, event Event) error {
+	payload, err := ch.render(event)
+	if err != nil {
+		return err
+	}
+
+	switch ch.cfg.Type {
+	case config.NotifyChannelWebhook:
+		contentType := "application/json"
+		if ch.template != nil && !json.Valid(payload) {
+			contentType = "text/plain; charset=utf-8"
+		}
+		return ch.post(ctx, ch.cfg.URL, contentType, payload)
+	case config.NotifyChannelDiscord:
+		return ch.postJSON(ctx, ch.cfg.URL, map[string]string{"content": truncate(string(payload), discordMaxLength)})
+	case config.NotifyChannelSlack:
+		return ch.postJSON(ctx, ch.cfg.URL, map[string]string{"text": truncate(string(payload), slackMaxLength)})
+	case config.NotifyChannelTelegram:
+		url := fmt.Sprintf("%s/bot%s/sendMessage", telegramAPIBaseURL, ch.cfg.BotToken)
+		err := ch.postJSON(ctx, url, map[string]string{"chat_id": ch.cfg.ChatID, "text": truncate(string(payload), telegramMaxLength)})
+		if err != nil {
+			// The token is part of the URL; keep it out of logs and the API
+			return errors.New(strings.ReplaceAll(err.Error(), ch.cfg.BotToken, "<bot_token>"))
+		}
+		return nil
+	case config.NotifyChannelMQTT:
+		var topic bytes.Buffer
+		if err := ch.topic.Execute(&topic, event); err != nil {
+			return fmt.Errorf("failed to render topic: %w", err)
+		}
+		return ch.publish(ctx, topic.String(), payload)
+	}
+	return fmt.Errorf("unsupported channel type: %s", ch.cfg.Type)
+}
+
+// render builds the payload or message of an event: the channel's template or, without
+// one, the event as JSON
+func (ch *channel) render(event Event) ([]byte, error) {
+	if ch.template == nil {
+		return json.Marshal(event)
+	}
+	var buf bytes.Buffer
+	if err := ch.template.Execute(&buf, event); err != nil {
+		return nil, fmt.Errorf("failed to render template: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// postJSON posts a value as JSON
+func (ch *channel) postJSON(ctx context.Context, url string, v interface{}) error {
+	body, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	return ch.post(ctx, url, "application/json", body)
+}
+
+// post sends a payload, with the channel's extra headers, and checks the response status
+func (ch *channel) post(ctx context.Context, url, contentType string, body []byte) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", contentType)
+	req.Header.Set("User-Agent", "co-atc")
+	for name, value := range ch.cfg.Headers {
+		req.Header.Set(name, value)
+	}
+
+	resp, err := ch.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		detail, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("status %d: %s", resp.StatusCode, strings.TrimSpace(string(detail)))
+	}
+	io.Copy(io.Discard, resp.Body)
+	return nil
+}
+
+// publish connects to the channel's MQTT broker, publishes one message and disconnects.
+// Alerts are rare enough that a connection per alert is simpler than keeping one alive.
+func (ch *channel) publish(ctx context.Context, topic string, payload []byte) error {
+	ctx, cancel := context.WithTimeout(ctx, time.Duration(ch.cfg.TimeoutSeconds)*time.Second)
+	defer cancel()
+
+	conn, err := mqtt.Dial(ctx, mqtt.Options{
+		BrokerURL: ch.cfg.URL,
+		ClientID:  ch.cfg.ClientID,
+		Username:  ch.cfg.Username,
+		Password:  ch.cfg.Password,
+	})
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	return conn.Publish(topic, payload, ch.cfg.Retain)
+}
+
+// record counts a delivery
+func (ch *channel) record(err error) {
+	ch.mu.Lock()
+	defer ch.mu.Unlock()
+
+	now := time.Now().UTC()
+	if err != nil {
+		ch.failed++
+		ch.lastError = err.Error()
+		ch.lastErrorAt = &now
+		return
+	}
+	ch.delivered++
+	ch.lastSentAt = &now
+}
+
+// recordDropped counts an event dropped because the queue was full
+func (ch *channel) recordDropped() {
+	ch.mu.Lock()
+	ch.dropped++
+	ch.mu.Unlock()
+}
+
+// status returns the channel's configuration and counters
+func (ch *channel) status() ChannelStatus {
+	ch.mu.Lock()
+	defer ch.mu.Unlock()
+
+	events := ch.cfg.Events
+	if events == nil {
+		events = []string{}
+	}
+	return ChannelStatus{
+		Name:        ch.cfg.Name,
+		Type:        ch.cfg.Type,
+		Events:      events,
+		Delivered:   ch.delivered,
+		Failed:      ch.failed,
+		Dropped:     ch.dropped,
+		LastSentAt:  ch.lastSentAt,
+		LastError:   ch.lastError,
+		LastErrorAt: ch.lastErrorAt,
+	}
+}
+
+// truncate shortens a message to a maximum number of characters
+func truncate(s string, limit int) string {
+	runes := []rune(s)
+	if len(runes) <= limit {
+		return s
+	}
+	return string(runes[:limit-1]) + "…"
+}
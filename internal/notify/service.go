@@ -0,0 +1,258 @@
+package notify
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/yegors/co-atc/internal/config"
+	"github.com/yegors/co-atc/pkg/logger"
+)
+
+// Event types channels can filter on. They match the push alert types, so one alert
+// reaches browsers and channels alike.
+const (
+	EventEmergency = "emergency"         // Aircraft squawking an emergency code
+	EventConflict  = "conflict"          // Aircraft losing separation
+	EventRunway    = "runway"            // Runway incursion: aircraft using a closed runway or one outside the forced configuration
+	EventGoAround  = "go_around"         // Aircraft going around or executing a missed approach
+	EventWatchlist = "watchlist"         // Watched aircraft appeared, departed or landed
+	EventDeviation = "deviation"         // Aircraft possibly not following an altitude or heading clearance
+	EventTerrain   = "terrain"           // Descending aircraft predicted too close to terrain or an obstacle
+	EventUnstable  = "unstable_approach" // Aircraft on final approach not meeting stabilized approach criteria
+	EventNoise     = "noise"             // Aircraft overflying a noise-sensitive zone too low during restricted times
+	EventTest      = "test"              // Test event sent on request; always delivered
+)
+
+// EventTypes lists the event types a channel can select
+var EventTypes = []string{EventEmergency, EventConflict, EventRunway, EventGoAround, EventWatchlist, EventDeviation, EventTerrain, EventUnstable, EventNoise}
+
+// ErrChannelNotFound is returned when a channel name does not exist
+var ErrChannelNotFound = errors.New("channel not found")
+
+const (
+	queueSize     = 50 // Events waiting per channel before new ones are dropped
+	deliveryTries = 3  // Attempts per event before it is given up
+)
+
+// Event is an alert delivered to the channels. It is also the data of payload templates.
+type Event struct {
+	Type      string                 `json:"type"`
+	Title     string                 `json:"title"`
+	Body      string                 `json:"body"`
+	Airport   string                 `json:"airport"`
+	Timestamp time.Time              `json:"timestamp"`
+	Data      map[string]interface{} `json:"data,omitempty"`
+}
+
+// ChannelStatus is a channel's configuration and delivery counters
+type ChannelStatus struct {
+	Name        string     `json:"name"`
+	Type        string     `json:"type"`
+	Events      []string   `json:"events"` // Empty = all
+	Delivered   int        `json:"delivered"`
+	Failed      int        `json:"failed"`
+	Dropped     int        `json:"dropped"` // Events dropped because the channel's queue was full
+	LastSentAt  *time.Time `json:"last_sent_at,omitempty"`
+	LastError   string     `json:"last_error,omitempty"`
+	LastErrorAt *time.Time `json:"last_error_at,omitempty"`
+}
+
+// Service delivers alerts to webhooks, Discord, Slack, Telegram and MQTT. Each channel
+// has its own queue, so a slow or failing destination doesn't hold up the others.
+type Service struct {
+	airport  string
+	channels []*channel
+	ctx      context.Context
+	cancel   context.CancelFunc
+	wg       sync.WaitGroup
+	logger   *logger.Logger
+}
+
+// NewService creates a notification service from the channel configuration. Templates
+// are parsed here, so a broken template fails at startup rather than on the first alert.
+func NewService(cfg config.NotifyConfig, airportCode string, logger *logger.Logger) (*Service, error) {
+	s := &Service{
+		airport: airportCode,
+		logger:  logger.Named("notify"),
+	}
+
+	for _, channelCfg := range cfg.Channels {
+		for _, eventType := range channelCfg.Events {
+			if !contains(EventTypes, eventType) {
+				return nil, fmt.Errorf("notify channel %s: unknown event type %q", channelCfg.Name, eventType)
+			}
+		}
+		ch, err := newChannel(channelCfg)
+		if err != nil {
+			return nil, fmt.Errorf("notify channel %s: %w", channelCfg.Name, err)
+		}
+		s.channels = append(s.channels, ch)
+	}
+
+	return s, nil
+}
+
+// Start starts a delivery worker per channel
+func (s *Service) Start(ctx context.Context) {
+	s.ctx, s.cancel = context.WithCancel(ctx)
+
+	for _, ch := range s.channels {
+		s.wg.Add(1)
+		go func(ch *channel) {
+			defer s.wg.Done()
+			s.deliveryLoop(ch)
+		}(ch)
+	}
+
+	s.logger.Info("Notification service started", logger.Int("channels", len(s.channels)))
+}
+
+// Stop stops delivering alerts. Events still queued are dropped.
+func (s *Service) Stop() {
+	if s.cancel != nil {
+		s.cancel()
+	}
+	s.wg.Wait()
+	s.logger.Info("Notification service stopped")
+}
+
+// Notify queues an event for every channel that selected its type. It never blocks;
+// events are dropped for channels whose queue is full.
+func (s *Service) Notify(event Event) {
+	if event.Timestamp.IsZero() {
+		event.Timestamp = time.Now().UTC()
+	}
+	if event.Airport == "" {
+		event.Airport = s.airport
+	}
+
+	for _, ch := range s.channels {
+		if !ch.accepts(event.Type) {
+			continue
+		}
+		select {
+		case ch.queue <- event:
+		default:
+			ch.recordDropped()
+			s.logger.Warn("Notification queue full, dropping event",
+				logger.String("channel", ch.cfg.Name),
+				logger.String("type", event.Type))
+		}
+	}
+}
+
+// NotifyAlert queues an event built from its parts; used by services that raise alerts
+func (s *Service) NotifyAlert(alertType, title, body string, data map[string]interface{}) {
+	s.Notify(Event{
+		Type:  alertType,
+		Title: title,
+		Body:  body,
+		Data:  data,
+	})
+}
+
+// Channels returns the status of every channel, by name
+func (s *Service) Channels() []ChannelStatus {
+	statuses := make([]ChannelStatus, 0, len(s.channels))
+	for _, ch := range s.channels {
+		statuses = append(statuses, ch.status())
+	}
+	sort.Slice(statuses, func(i, j int) bool { return statuses[i].Name < statuses[j].Name })
+	return statuses
+}
+
+// SendTest delivers a test event to a channel right away and returns the delivery error
+func (s *Service) SendTest(name string) error {
+	for _, ch := range s.channels {
+		if ch.cfg.Name != name {
+			continue
+		}
+		event := Event{
+			Type:      EventTest,
+			Title:     "Co-ATC test notification",
+			Body:      fmt.Sprintf("Notifications from %s reach the %s channel", s.airport, name),
+			Airport:   s.airport,
+			Timestamp: time.Now().UTC(),
+		}
+		ctx := s.ctx
+		if ctx == nil {
+			ctx = context.Background()
+		}
+		err := ch.send(ctx, event)
+		ch.record(err)
+		return err
+	}
+	return fmt.Errorf("%w: %s", ErrChannelNotFound, name)
+}
+
+// deliveryLoop delivers a channel's queued events, retrying failed deliveries
+func (s *Service) deliveryLoop(ch *channel) {
+	for {
+		select {
+		case <-s.ctx.Done():
+			return
+		case event := <-ch.queue:
+			s.deliver(ch, event)
+		}
+	}
+}
+
+// deliver sends an event to a channel, trying again after a growing delay on failure
+func (s *Service) deliver(ch *channel, event Event) {
+	var err error
+	for attempt := 1; attempt <= deliveryTries; attempt++ {
+		if err = ch.send(s.ctx, event); err == nil {
+			break
+		}
+		if attempt == deliveryTries {
+			break
+		}
+		select {
+		case <-s.ctx.Done():
+			return
+		case <-time.After(time.Duration(attempt*attempt) * 2 * time.Second):
+		}
+	}
+	ch.record(err)
+
+	if err != nil {
+		s.logger.Error("Failed to deliver notification",
+			logger.String("channel", ch.cfg.Name),
+			logger.String("type", event.Type),
+			logger.Error(err))
+		return
+	}
+	s.logger.Debug("Notification delivered",
+		logger.String("channel", ch.cfg.Name),
+		logger.String("type", event.Type),
+		logger.String("title", event.Title))
+}
+
+// AlertNotifier receives alerts raised by the ADS-B, deviation and other services
+type AlertNotifier interface {
+	NotifyAlert(alertType, title, body string, data map[string]interface{})
+}
+
+// Fanout passes each alert on to several notifiers, e.g. push and this service
+type Fanout []AlertNotifier
+
+// NotifyAlert passes the alert on to every notifier
+func (f Fanout) NotifyAlert(alertType, title, body string, data map[string]interface{}) {
+	for _, notifier := range f {
+		notifier.NotifyAlert(alertType, title, body, data)
+	}
+}
+
+// contains reports whether a slice contains a string
+func contains(slice []string, item string) bool {
+	for _, s := range slice {
+		if s == item {
+			return true
+		}
+	}
+	return false
+}
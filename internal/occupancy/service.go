@@ -0,0 +1,439 @@
+// Package occupancy measures how long landings and takeoffs occupy the runway: arrivals from
+// crossing the threshold to turning off the runway, departures from entering the runway to
+// leaving it airborne. Times are recorded per runway for operational efficiency statistics.
+package occupancy
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/yegors/co-atc/internal/adsb"
+	"github.com/yegors/co-atc/internal/config"
+	"github.com/yegors/co-atc/internal/storage/sqlite"
+	"github.com/yegors/co-atc/pkg/logger"
+)
+
+const (
+	// maxHeightFt is the height above the field under which an aircraft over the runway
+	// outline occupies the runway
+	maxHeightFt = 500
+	// occupantTimeout is how long an aircraft on the runway may go unseen before it's
+	// forgotten without a measurement
+	occupantTimeout = 30 * time.Second
+	// pruneInterval is how often occupancy times older than retention_days are deleted
+	pruneInterval = time.Hour
+)
+
+// sample is what measurement needs from an aircraft in a poll cycle
+type sample struct {
+	hex          string
+	flight       string
+	aircraftType string
+	lat, lon     float64
+	course       float64 // True
+	height       float64 // Above the field
+	onGround     bool
+}
+
+// runway is the outline of a runway and its two thresholds
+type runway struct {
+	pair    string
+	ends    []adsb.RunwayEnd
+	polygon [][2]float64 // [latitude, longitude] vertices
+}
+
+// occupant is an aircraft on a runway
+type occupant struct {
+	runway          *runway
+	threshold       string // Direction of the operation, once known
+	enteredAt       time.Time
+	enteredAirborne bool // Crossed the threshold to land, rather than entered from a taxiway
+	touchedDown     bool
+	liftedOff       bool
+	lastSeen        time.Time
+}
+
+// Summary is the occupancy times of a set of operations
+type Summary struct {
+	Operations     int     `json:"operations"`
+	AverageSeconds float64 `json:"average_seconds"`
+	MedianSeconds  float64 `json:"median_seconds"`
+	P90Seconds     float64 `json:"p90_seconds"`
+	MinSeconds     float64 `json:"min_seconds"`
+	MaxSeconds     float64 `json:"max_seconds"`
+}
+
+// RunwaySummary is the occupancy times of the arrivals and departures of one runway direction
+type RunwaySummary struct {
+	Runway     string  `json:"runway"` // Threshold ID, e.g. "24R"
+	Arrivals   Summary `json:"arrivals"`
+	Departures Summary `json:"departures"`
+}
+
+// Report summarizes the occupancy times of the operations started in a time range
+type Report struct {
+	Start      time.Time       `json:"start"`
+	End        time.Time       `json:"end"`
+	Arrivals   Summary         `json:"arrivals"`
+	Departures Summary         `json:"departures"`
+	Runways    []RunwaySummary `json:"runways"` // By threshold ID
+}
+
+// Service measures the runway occupancy times of landings and takeoffs
+type Service struct {
+	config        config.OccupancyConfig
+	elevationFeet float64 // Of the field
+	adsbService   *adsb.Service
+	storage       *sqlite.RunwayOccupancyStorage
+	logger        *logger.Logger
+
+	runways []*runway
+
+	cycles chan []sample
+
+	// Aircraft on a runway, by hex. Only used by the worker goroutine.
+	occupants map[string]*occupant
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// NewService creates a new runway occupancy service
+func NewService(cfg config.OccupancyConfig, elevationFeet int, adsbService *adsb.Service, storage *sqlite.RunwayOccupancyStorage, logger *logger.Logger) *Service {
+	return &Service{
+		config:        cfg,
+		elevationFeet: float64(elevationFeet),
+		adsbService:   adsbService,
+		storage:       storage,
+		logger:        logger.Named("occupancy"),
+		cycles:        make(chan []sample, 4),
+		occupants:     make(map[string]*occupant),
+	}
+}
+
+// Start builds the runway outlines and starts measuring
+func (s *Service) Start(ctx context.Context) error {
+	s.runways = s.buildRunways()
+	if len(s.runways) == 0 {
+		return fmt.Errorf("no runways with both thresholds known to measure occupancy on")
+	}
+
+	s.ctx, s.cancel = context.WithCancel(ctx)
+	s.wg.Add(1)
+	go s.run()
+
+	s.adsbService.OnUpdate(s.handleUpdate)
+
+	s.logger.Info("Runway occupancy measurement started",
+		logger.Int("runways", len(s.runways)),
+		logger.Int("surveyed", len(s.config.Polygons)))
+	return nil
+}
+
+// Stop stops measuring. Aircraft still on a runway aren't recorded.
+func (s *Service) Stop() {
+	if s.cancel != nil {
+		s.cancel()
+	}
+	s.wg.Wait()
+}
+
+// Report summarizes the occupancy times of the operations started in a time range, in total
+// and per runway direction. A threshold ID limits the report to that runway direction.
+func (s *Service) Report(start, end time.Time, threshold string) (*Report, error) {
+	records, err := s.storage.ListOccupancy(start, end)
+	if err != nil {
+		return nil, err
+	}
+
+	threshold = strings.ToUpper(strings.TrimSpace(threshold))
+	var arrivals, departures []float64
+	byRunway := make(map[string]map[string][]float64) // Seconds by threshold and operation
+	for _, record := range records {
+		if threshold != "" && record.Runway != threshold {
+			continue
+		}
+		if byRunway[record.Runway] == nil {
+			byRunway[record.Runway] = make(map[string][]float64)
+		}
+		byRunway[record.Runway][record.Operation] = append(byRunway[record.Runway][record.Operation], record.Seconds)
+		if record.Operation == sqlite.OccupancyArrival {
+			arrivals = append(arrivals, record.Seconds)
+		} else {
+			departures = append(departures, record.Seconds)
+		}
+	}
+
+	report := &Report{
+		Start:      start,
+		End:        end,
+		Arrivals:   summarize(arrivals),
+		Departures: summarize(departures),
+		Runways:    make([]RunwaySummary, 0, len(byRunway)),
+	}
+	for id, operations := range byRunway {
+		report.Runways = append(report.Runways, RunwaySummary{
+			Runway:     id,
+			Arrivals:   summarize(operations[sqlite.OccupancyArrival]),
+			Departures: summarize(operations[sqlite.OccupancyDeparture]),
+		})
+	}
+	sort.Slice(report.Runways, func(i, j int) bool { return report.Runways[i].Runway < report.Runways[j].Runway })
+	return report, nil
+}
+
+// summarize works out the statistics of a set of occupancy times
+func summarize(seconds []float64) Summary {
+	if len(seconds) == 0 {
+		return Summary{}
+	}
+	sort.Float64s(seconds)
+
+	total := 0.0
+	for _, value := range seconds {
+		total += value
+	}
+	median := seconds[len(seconds)/2]
+	if len(seconds)%2 == 0 {
+		median = (seconds[len(seconds)/2-1] + median) / 2
+	}
+	return Summary{
+		Operations:     len(seconds),
+		AverageSeconds: math.Round(total/float64(len(seconds))*10) / 10,
+		MedianSeconds:  median,
+		P90Seconds:     seconds[int(math.Ceil(0.9*float64(len(seconds))))-1], // Nearest rank
+		MinSeconds:     seconds[0],
+		MaxSeconds:     seconds[len(seconds)-1],
+	}
+}
+
+// buildRunways builds the outline of every runway with both thresholds known: the surveyed
+// polygon if configured, else a rectangle of runway_width_feet between the thresholds
+func (s *Service) buildRunways() []*runway {
+	byPair := make(map[string]*runway)
+	pairs := make([]string, 0)
+	for _, end := range s.adsbService.RunwayEnds() {
+		if byPair[end.Runway] == nil {
+			byPair[end.Runway] = &runway{pair: end.Runway}
+			pairs = append(pairs, end.Runway)
+		}
+		byPair[end.Runway].ends = append(byPair[end.Runway].ends, end)
+	}
+
+	halfWidth := s.config.RunwayWidthFeet / adsb.FEET_PER_METER / 2
+	runways := make([]*runway, 0, len(pairs))
+	for _, pair := range pairs {
+		r := byPair[pair]
+		if polygon, ok := s.config.Polygons[pair]; ok {
+			for _, vertex := range polygon {
+				r.polygon = append(r.polygon, [2]float64{vertex[0], vertex[1]})
+			}
+		} else {
+			from, to := r.ends[0], r.ends[1]
+			r.polygon = [][2]float64{
+				offset(from.Latitude, from.Longitude, from.Heading-90, halfWidth),
+				offset(to.Latitude, to.Longitude, from.Heading-90, halfWidth),
+				offset(to.Latitude, to.Longitude, from.Heading+90, halfWidth),
+				offset(from.Latitude, from.Longitude, from.Heading+90, halfWidth),
+			}
+		}
+		runways = append(runways, r)
+	}
+	for pair := range s.config.Polygons {
+		if byPair[pair] == nil {
+			s.logger.Warn("Occupancy polygon for an unknown runway", logger.String("runway", pair))
+		}
+	}
+	return runways
+}
+
+// offset returns the position a distance in meters from a point along a true bearing. Flat
+// earth, which is exact enough across an airport.
+func offset(lat, lon, bearing, meters float64) [2]float64 {
+	const earthRadius = 6371000
+	rad := bearing * math.Pi / 180
+	dLat := meters * math.Cos(rad) / earthRadius
+	dLon := meters * math.Sin(rad) / (earthRadius * math.Cos(lat*math.Pi/180))
+	return [2]float64{lat + dLat*180/math.Pi, lon + dLon*180/math.Pi}
+}
+
+// contains reports whether a position is inside the runway outline
+func (r *runway) contains(lat, lon float64) bool {
+	// Ray casting: a point is inside if a ray from it crosses the edges an odd number of times
+	inside := false
+	for i, j := 0, len(r.polygon)-1; i < len(r.polygon); j, i = i, i+1 {
+		a, b := r.polygon[i], r.polygon[j]
+		if (a[0] > lat) != (b[0] > lat) && lon < (b[1]-a[1])*(lat-a[0])/(b[0]-a[0])+a[1] {
+			inside = !inside
+		}
+	}
+	return inside
+}
+
+// direction returns the threshold an aircraft moving along a course is using
+func (r *runway) direction(course float64) string {
+	best := r.ends[0]
+	for _, end := range r.ends[1:] {
+		if adsb.HeadingDifference(course, end.Heading) < adsb.HeadingDifference(course, best.Heading) {
+			best = end
+		}
+	}
+	return best.ID
+}
+
+// handleUpdate hands the real aircraft of a poll cycle to the worker without blocking polling
+func (s *Service) handleUpdate(aircraft []*adsb.Aircraft) {
+	declination := s.adsbService.Declination()
+	samples := make([]sample, 0)
+	for _, a := range aircraft {
+		if a.ADSB == nil || a.Status != "active" || a.IsSimulated || a.ADSB.Type == adsb.TargetTypeReplay ||
+			(a.ADSB.Lat == 0 && a.ADSB.Lon == 0) {
+			continue
+		}
+		samples = append(samples, sample{
+			hex:          strings.ToLower(a.Hex),
+			flight:       strings.TrimSpace(a.Flight),
+			aircraftType: a.ADSB.AircraftType,
+			lat:          a.ADSB.Lat,
+			lon:          a.ADSB.Lon,
+			course:       adsb.TrueCourse(a.ADSB, declination),
+			height:       adsb.AltitudeMSL(a.ADSB) - s.elevationFeet,
+			onGround:     a.OnGround,
+		})
+	}
+
+	select {
+	case s.cycles <- samples:
+	default:
+		s.logger.Debug("Occupancy worker busy, skipping poll cycle")
+	}
+}
+
+// run checks poll cycles and prunes old occupancy times until the service stops
+func (s *Service) run() {
+	defer s.wg.Done()
+
+	ticker := time.NewTicker(pruneInterval)
+	defer ticker.Stop()
+
+	s.prune()
+	for {
+		select {
+		case <-s.ctx.Done():
+			return
+		case <-ticker.C:
+			s.prune()
+		case samples := <-s.cycles:
+			s.check(samples, time.Now().UTC())
+		}
+	}
+}
+
+// check follows aircraft onto, along and off the runways, and records the occupancy of each
+// landing or takeoff once the aircraft has left the runway
+func (s *Service) check(samples []sample, now time.Time) {
+	for _, smp := range samples {
+		low := smp.onGround || smp.height <= maxHeightFt
+		o, onRunway := s.occupants[smp.hex]
+
+		if !onRunway {
+			if !low {
+				continue
+			}
+			if r := s.runwayAt(smp); r != nil {
+				o = &occupant{runway: r, enteredAt: now, enteredAirborne: !smp.onGround, lastSeen: now}
+				if o.enteredAirborne {
+					o.threshold = r.direction(smp.course)
+				}
+				s.occupants[smp.hex] = o
+			}
+			continue
+		}
+
+		if low && o.runway.contains(smp.lat, smp.lon) {
+			o.lastSeen = now
+			switch {
+			case o.enteredAirborne && smp.onGround:
+				o.touchedDown = true
+			case !o.enteredAirborne && !smp.onGround && !o.liftedOff:
+				o.liftedOff = true
+				o.threshold = o.runway.direction(smp.course)
+			}
+			continue
+		}
+
+		// Off the runway: a landing that turned off on the ground, or a takeoff that left
+		// airborne. Go-arounds, touch-and-goes, low passes and crossings aren't measured.
+		delete(s.occupants, smp.hex)
+		switch {
+		case o.enteredAirborne && o.touchedDown && smp.onGround:
+			s.record(smp, o, sqlite.OccupancyArrival, now)
+		case !o.enteredAirborne && o.liftedOff && !smp.onGround:
+			s.record(smp, o, sqlite.OccupancyDeparture, now)
+		}
+	}
+
+	for hex, o := range s.occupants {
+		if now.Sub(o.lastSeen) > occupantTimeout {
+			delete(s.occupants, hex)
+		}
+	}
+}
+
+// runwayAt returns the runway an aircraft is on, preferring the one it's aligned with where
+// runways cross
+func (s *Service) runwayAt(smp sample) *runway {
+	var best *runway
+	bestDiff := math.Inf(1)
+	for _, r := range s.runways {
+		if !r.contains(smp.lat, smp.lon) {
+			continue
+		}
+		diff := math.Min(adsb.HeadingDifference(smp.course, r.ends[0].Heading), adsb.HeadingDifference(smp.course, r.ends[1].Heading))
+		if diff < bestDiff {
+			best, bestDiff = r, diff
+		}
+	}
+	return best
+}
+
+// record stores the occupancy of a landing or takeoff
+func (s *Service) record(smp sample, o *occupant, operation string, now time.Time) {
+	record := &sqlite.RunwayOccupancyRecord{
+		Runway:       o.threshold,
+		Operation:    operation,
+		Hex:          smp.hex,
+		Callsign:     smp.flight,
+		AircraftType: smp.aircraftType,
+		EnteredAt:    o.enteredAt,
+		ExitedAt:     now,
+		Seconds:      math.Round(now.Sub(o.enteredAt).Seconds()*10) / 10,
+	}
+	if err := s.storage.AddOccupancy(record); err != nil {
+		s.logger.Error("Failed to store runway occupancy", logger.Error(err))
+		return
+	}
+
+	s.logger.Debug("Runway occupancy",
+		logger.String("runway", record.Runway),
+		logger.String("operation", operation),
+		logger.String("hex", smp.hex),
+		logger.String("callsign", smp.flight),
+		logger.Float64("seconds", record.Seconds))
+}
+
+// prune deletes occupancy times older than retention_days
+func (s *Service) prune() {
+	cutoff := time.Now().UTC().AddDate(0, 0, -s.config.RetentionDays)
+	if deleted, err := s.storage.DeleteOccupancyBefore(cutoff); err != nil {
+		s.logger.Error("Failed to prune runway occupancy", logger.Error(err))
+	} else if deleted > 0 {
+		s.logger.Debug("Pruned runway occupancy", logger.Int64("deleted", deleted))
+	}
+}
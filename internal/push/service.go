@@ -0,0 +1,484 @@
+package push
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/yegors/co-atc/internal/config"
+	"github.com/yegors/co-atc/internal/storage/sqlite"
+	"github.com/yegors/co-atc/pkg/logger"
+)
+
+// Alert types browsers can subscribe to
+const (
+	AlertEmergency = "emergency"         // Aircraft squawking an emergency code
+	AlertWatchlist = "watchlist"         // Watched aircraft appeared, departed or landed
+	AlertRunway    = "runway"            // Aircraft using a closed runway or one outside the forced configuration
+	AlertDeviation = "deviation"         // Aircraft possibly not following an altitude or heading clearance
+	AlertTerrain   = "terrain"           // Descending aircraft predicted too close to terrain or an obstacle
+	AlertUnstable  = "unstable_approach" // Aircraft on final approach not meeting stabilized approach criteria
+	AlertNoise     = "noise"             // Aircraft overflying a noise-sensitive zone too low during restricted times
+	AlertTest      = "test"              // Test notification sent on request; always delivered
+)
+
+// AlertTypes lists the alert types a subscription can select
+var AlertTypes = []string{AlertEmergency, AlertWatchlist, AlertRunway, AlertDeviation, AlertTerrain, AlertUnstable, AlertNoise}
+
+var (
+	// ErrSubscriptionNotFound is returned when a subscription ID does not exist
+	ErrSubscriptionNotFound = errors.New("subscription not found")
+	// ErrInvalidSubscription is returned when a subscription fails validation
+	ErrInvalidSubscription = errors.New("invalid subscription")
+)
+
+// deliveryRetention is how long delivery history is kept
+const deliveryRetention = 30 * 24 * time.Hour
+
+// Alert is a notification delivered to subscribed browsers
+type Alert struct {
+	Type      string                 `json:"type"`
+	Title     string                 `json:"title"`
+	Body      string                 `json:"body"`
+	Tag       string                 `json:"tag,omitempty"` // Notifications with the same tag replace each other
+	URL       string                 `json:"url,omitempty"` // Page opened when the notification is clicked
+	Timestamp time.Time              `json:"timestamp"`
+	Data      map[string]interface{} `json:"data,omitempty"`
+}
+
+// Service manages Web Push subscriptions and delivers alerts to them
+type Service struct {
+	config  config.PushConfig
+	storage *sqlite.PushStorage
+	keys    *vapidKeys
+	client  *http.Client
+	queue   chan Alert
+	ctx     context.Context
+	cancel  context.CancelFunc
+	wg      sync.WaitGroup
+	logger  *logger.Logger
+}
+
+// NewService creates a new push service. The VAPID key pair comes from the config or,
+// if not configured, is loaded from storage and generated on first use.
+func NewService(pushConfig config.PushConfig, storage *sqlite.PushStorage, logger *logger.Logger) (*Service, error) {
+	log := logger.Named("push")
+
+	publicKey, privateKey := pushConfig.VAPIDPublicKey, pushConfig.VAPIDPrivateKey
+	if privateKey == "" {
+		var err error
+		publicKey, privateKey, err = storage.GetVAPIDKeys()
+		if err != nil {
+			return nil, err
+		}
+		if privateKey == "" {
+			publicKey, privateKey, err = generateVAPIDKeys()
+			if err != nil {
+				return nil, err
+			}
+			if err := storage.SaveVAPIDKeys(publicKey, privateKey); err != nil {
+				return nil, err
+			}
+			log.Info("Generated new VAPID key pair")
+		}
+	}
+
+	keys, err := parseVAPIDKeys(publicKey, privateKey)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Service{
+		config:  pushConfig,
+		storage: storage,
+		keys:    keys,
+		client:  &http.Client{Timeout: 10 * time.Second},
+		queue:   make(chan Alert, 100),
+		logger:  log,
+	}, nil
+}
+
+// Start starts delivering queued alerts
+func (s *Service) Start(ctx context.Context) {
+	s.ctx, s.cancel = context.WithCancel(ctx)
+
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		s.deliveryLoop()
+	}()
+
+	s.logger.Info("Push service started", logger.String("public_key", s.keys.publicKey))
+}
+
+// Stop stops delivering alerts. Alerts still queued are dropped.
+func (s *Service) Stop() {
+	if s.cancel != nil {
+		s.cancel()
+	}
+	s.wg.Wait()
+	s.logger.Info("Push service stopped")
+}
+
+// PublicKey returns the VAPID public key browsers need to subscribe
+func (s *Service) PublicKey() string {
+	return s.keys.publicKey
+}
+
+// Subscribe registers a browser push subscription. Subscribing again with the same
+// endpoint updates the existing subscription. No alert types means all of them.
+func (s *Service) Subscribe(endpoint, p256dh, auth string, alertTypes []string, userAgent string) (*sqlite.PushSubscription, error) {
+	if err := validateEndpoint(endpoint); err != nil {
+		return nil, err
+	}
+	if key, err := decodeBase64URL(p256dh); err != nil || len(key) != 65 {
+		return nil, fmt.Errorf("%w: keys.p256dh must be an uncompressed P-256 public key", ErrInvalidSubscription)
+	}
+	if secret, err := decodeBase64URL(auth); err != nil || len(secret) != 16 {
+		return nil, fmt.Errorf("%w: keys.auth must be a 16-byte secret", ErrInvalidSubscription)
+	}
+
+	alertTypes, err := normalizeAlertTypes(alertTypes)
+	if err != nil {
+		return nil, err
+	}
+
+	id, err := newSubscriptionID()
+	if err != nil {
+		return nil, err
+	}
+
+	id, err = s.storage.UpsertSubscription(&sqlite.PushSubscription{
+		ID:         id,
+		Endpoint:   endpoint,
+		P256dh:     p256dh,
+		Auth:       auth,
+		AlertTypes: alertTypes,
+		UserAgent:  userAgent,
+		CreatedAt:  time.Now().UTC(),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s.logger.Info("Push subscription registered",
+		logger.String("id", id),
+		logger.Any("alert_types", alertTypes))
+
+	return s.GetSubscription(id)
+}
+
+// UpdateSubscription changes the alert types of a subscription
+func (s *Service) UpdateSubscription(id string, alertTypes []string) (*sqlite.PushSubscription, error) {
+	alertTypes, err := normalizeAlertTypes(alertTypes)
+	if err != nil {
+		return nil, err
+	}
+
+	found, err := s.storage.UpdateAlertTypes(id, alertTypes)
+	if err != nil {
+		return nil, err
+	}
+	if !found {
+		return nil, fmt.Errorf("%w: %s", ErrSubscriptionNotFound, id)
+	}
+
+	return s.GetSubscription(id)
+}
+
+// Unsubscribe removes a subscription
+func (s *Service) Unsubscribe(id string) error {
+	found, err := s.storage.DeleteSubscription(id)
+	if err != nil {
+		return err
+	}
+	if !found {
+		return fmt.Errorf("%w: %s", ErrSubscriptionNotFound, id)
+	}
+
+	s.logger.Info("Push subscription removed", logger.String("id", id))
+	return nil
+}
+
+// GetSubscription returns a single subscription
+func (s *Service) GetSubscription(id string) (*sqlite.PushSubscription, error) {
+	sub, err := s.storage.GetSubscription(id)
+	if err != nil {
+		return nil, err
+	}
+	if sub == nil {
+		return nil, fmt.Errorf("%w: %s", ErrSubscriptionNotFound, id)
+	}
+	return sub, nil
+}
+
+// GetSubscriptions returns all subscriptions
+func (s *Service) GetSubscriptions() ([]*sqlite.PushSubscription, error) {
+	return s.storage.GetSubscriptions()
+}
+
+// GetDeliveries returns the most recent delivery attempts of a subscription
+func (s *Service) GetDeliveries(id string, limit int) ([]*sqlite.PushDelivery, error) {
+	if _, err := s.GetSubscription(id); err != nil {
+		return nil, err
+	}
+	return s.storage.GetDeliveries(id, limit)
+}
+
+// SendTest sends a test notification to a single subscription and returns the delivery result
+func (s *Service) SendTest(id string) (*sqlite.PushDelivery, error) {
+	sub, err := s.GetSubscription(id)
+	if err != nil {
+		return nil, err
+	}
+
+	alert := Alert{
+		Type:      AlertTest,
+		Title:     "Co-ATC test notification",
+		Body:      "Push notifications are working.",
+		Tag:       "co-atc-test",
+		URL:       "/",
+		Timestamp: time.Now().UTC(),
+	}
+
+	return s.deliver(sub, alert), nil
+}
+
+// Notify queues an alert for delivery to every subscription that selected its type.
+// It never blocks; alerts are dropped if the queue is full.
+func (s *Service) Notify(alert Alert) {
+	if alert.Timestamp.IsZero() {
+		alert.Timestamp = time.Now().UTC()
+	}
+
+	select {
+	case s.queue <- alert:
+	default:
+		s.logger.Warn("Push queue full, dropping alert",
+			logger.String("type", alert.Type),
+			logger.String("title", alert.Title))
+	}
+}
+
+// NotifyAlert queues an alert built from its parts; used by services that raise alerts
+func (s *Service) NotifyAlert(alertType, title, body string, data map[string]interface{}) {
+	s.Notify(Alert{
+		Type:  alertType,
+		Title: title,
+		Body:  body,
+		URL:   "/",
+		Data:  data,
+	})
+}
+
+// deliveryLoop delivers queued alerts and periodically prunes delivery history
+func (s *Service) deliveryLoop() {
+	pruneTicker := time.NewTicker(24 * time.Hour)
+	defer pruneTicker.Stop()
+
+	s.pruneDeliveries()
+
+	for {
+		select {
+		case <-s.ctx.Done():
+			return
+		case alert := <-s.queue:
+			s.broadcast(alert)
+		case <-pruneTicker.C:
+			s.pruneDeliveries()
+		}
+	}
+}
+
+// broadcast delivers an alert to every subscription that selected its type
+func (s *Service) broadcast(alert Alert) {
+	subscriptions, err := s.storage.GetSubscriptions()
+	if err != nil {
+		s.logger.Error("Failed to load push subscriptions", logger.Error(err))
+		return
+	}
+
+	delivered := 0
+	for _, sub := range subscriptions {
+		if s.ctx.Err() != nil {
+			return
+		}
+		if !contains(sub.AlertTypes, alert.Type) {
+			continue
+		}
+		if s.deliver(sub, alert).Status == "delivered" {
+			delivered++
+		}
+	}
+
+	s.logger.Info("Push alert delivered",
+		logger.String("type", alert.Type),
+		logger.String("title", alert.Title),
+		logger.Int("delivered", delivered))
+}
+
+// deliver sends an alert to a subscription and records the outcome. Subscriptions the
+// push service reports as gone are removed.
+func (s *Service) deliver(sub *sqlite.PushSubscription, alert Alert) *sqlite.PushDelivery {
+	delivery := &sqlite.PushDelivery{
+		SubscriptionID: sub.ID,
+		AlertType:      alert.Type,
+		Title:          alert.Title,
+		CreatedAt:      time.Now().UTC(),
+	}
+
+	statusCode, err := s.send(sub, alert)
+	delivery.StatusCode = statusCode
+
+	switch {
+	case err == nil:
+		delivery.Status = "delivered"
+	case statusCode == http.StatusNotFound || statusCode == http.StatusGone:
+		// The browser unsubscribed or the subscription expired
+		delivery.Status = "expired"
+		delivery.Error = err.Error()
+	default:
+		delivery.Status = "failed"
+		delivery.Error = err.Error()
+	}
+
+	if delivery.Status == "expired" {
+		if _, err := s.storage.DeleteSubscription(sub.ID); err != nil {
+			s.logger.Error("Failed to remove expired push subscription", logger.String("id", sub.ID), logger.Error(err))
+		} else {
+			s.logger.Info("Removed expired push subscription", logger.String("id", sub.ID))
+		}
+		return delivery
+	}
+
+	if delivery.Status == "failed" {
+		s.logger.Warn("Push delivery failed",
+			logger.String("id", sub.ID),
+			logger.Int("status_code", statusCode),
+			logger.Error(err))
+	}
+
+	if err := s.storage.RecordDelivery(delivery); err != nil {
+		s.logger.Error("Failed to record push delivery", logger.Error(err))
+	}
+
+	return delivery
+}
+
+// send encrypts the alert and posts it to the subscription's push service
+func (s *Service) send(sub *sqlite.PushSubscription, alert Alert) (int, error) {
+	payload, err := json.Marshal(alert)
+	if err != nil {
+		return 0, fmt.Errorf("failed to encode alert: %w", err)
+	}
+
+	body, err := encryptPayload(payload, sub.P256dh, sub.Auth)
+	if err != nil {
+		return 0, err
+	}
+
+	authorization, err := s.keys.authorization(sub.Endpoint, s.config.Subject)
+	if err != nil {
+		return 0, err
+	}
+
+	ctx := s.ctx
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, sub.Endpoint, bytes.NewReader(body))
+	if err != nil {
+		return 0, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Authorization", authorization)
+	req.Header.Set("Content-Encoding", "aes128gcm")
+	req.Header.Set("Content-Type", "application/octet-stream")
+	req.Header.Set("TTL", strconv.Itoa(s.config.TTLSeconds))
+	if alert.Type == AlertEmergency {
+		req.Header.Set("Urgency", "high")
+	} else {
+		req.Header.Set("Urgency", "normal")
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return 0, fmt.Errorf("push request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		message, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return resp.StatusCode, fmt.Errorf("push service returned %d: %s", resp.StatusCode, bytes.TrimSpace(message))
+	}
+
+	return resp.StatusCode, nil
+}
+
+// pruneDeliveries deletes delivery history past the retention period
+func (s *Service) pruneDeliveries() {
+	deleted, err := s.storage.DeleteDeliveriesBefore(time.Now().Add(-deliveryRetention))
+	if err != nil {
+		s.logger.Error("Failed to prune push delivery history", logger.Error(err))
+		return
+	}
+	if deleted > 0 {
+		s.logger.Debug("Pruned push delivery history", logger.Int64("count", deleted))
+	}
+}
+
+// validateEndpoint checks that a push endpoint is an absolute HTTPS URL
+func validateEndpoint(endpoint string) error {
+	u, err := url.Parse(endpoint)
+	if err != nil || u.Scheme != "https" || u.Host == "" {
+		return fmt.Errorf("%w: endpoint must be an https URL", ErrInvalidSubscription)
+	}
+	return nil
+}
+
+// normalizeAlertTypes validates alert types, defaulting to all of them
+func normalizeAlertTypes(alertTypes []string) ([]string, error) {
+	if len(alertTypes) == 0 {
+		return append([]string{}, AlertTypes...), nil
+	}
+
+	var result []string
+	for _, alertType := range alertTypes {
+		if !contains(AlertTypes, alertType) {
+			return nil, fmt.Errorf("%w: unknown alert type %q", ErrInvalidSubscription, alertType)
+		}
+		if !contains(result, alertType) {
+			result = append(result, alertType)
+		}
+	}
+	return result, nil
+}
+
+// newSubscriptionID returns a random subscription ID. IDs are unguessable because
+// they are the only credential needed to manage a subscription.
+func newSubscriptionID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate subscription ID: %w", err)
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// contains checks if a string slice contains a specific string
+func contains(slice []string, item string) bool {
+	for _, s := range slice {
+		if s == item {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,115 @@
+package push
+
+import (
+	"crypto/ecdh"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"net/url"
+	"time"
+)
+
+// vapidTokenLifetime is how long a VAPID JWT is valid; push services reject more than 24 hours
+const vapidTokenLifetime = 12 * time.Hour
+
+// vapidKeys is the application server key pair used to sign push requests (RFC 8292)
+type vapidKeys struct {
+	privateKey *ecdsa.PrivateKey
+	publicKey  string // Uncompressed P-256 point, base64url encoded
+}
+
+// generateVAPIDKeys generates a new key pair, returned base64url encoded
+func generateVAPIDKeys() (publicKey, privateKey string, err error) {
+	key, err := ecdh.P256().GenerateKey(rand.Reader)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to generate VAPID key: %w", err)
+	}
+
+	return base64.RawURLEncoding.EncodeToString(key.PublicKey().Bytes()),
+		base64.RawURLEncoding.EncodeToString(key.Bytes()),
+		nil
+}
+
+// parseVAPIDKeys parses a base64url encoded key pair and checks that the keys belong together
+func parseVAPIDKeys(publicKey, privateKey string) (*vapidKeys, error) {
+	privateBytes, err := decodeBase64URL(privateKey)
+	if err != nil {
+		return nil, fmt.Errorf("invalid VAPID private key: %w", err)
+	}
+
+	ecdhKey, err := ecdh.P256().NewPrivateKey(privateBytes)
+	if err != nil {
+		return nil, fmt.Errorf("invalid VAPID private key: %w", err)
+	}
+
+	publicBytes := ecdhKey.PublicKey().Bytes()
+	if publicKey != "" {
+		configured, err := decodeBase64URL(publicKey)
+		if err != nil {
+			return nil, fmt.Errorf("invalid VAPID public key: %w", err)
+		}
+		if string(configured) != string(publicBytes) {
+			return nil, fmt.Errorf("VAPID public key does not match the private key")
+		}
+	}
+
+	// Uncompressed point: 0x04 || X || Y
+	return &vapidKeys{
+		privateKey: &ecdsa.PrivateKey{
+			PublicKey: ecdsa.PublicKey{
+				Curve: elliptic.P256(),
+				X:     new(big.Int).SetBytes(publicBytes[1:33]),
+				Y:     new(big.Int).SetBytes(publicBytes[33:65]),
+			},
+			D: new(big.Int).SetBytes(privateBytes),
+		},
+		publicKey: base64.RawURLEncoding.EncodeToString(publicBytes),
+	}, nil
+}
+
+// authorization returns the Authorization header value for a push request to endpoint
+func (k *vapidKeys) authorization(endpoint, subject string) (string, error) {
+	endpointURL, err := url.Parse(endpoint)
+	if err != nil {
+		return "", fmt.Errorf("invalid push endpoint: %w", err)
+	}
+
+	header, _ := json.Marshal(map[string]string{"typ": "JWT", "alg": "ES256"})
+	claims, err := json.Marshal(map[string]interface{}{
+		"aud": endpointURL.Scheme + "://" + endpointURL.Host,
+		"exp": time.Now().Add(vapidTokenLifetime).Unix(),
+		"sub": subject,
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to encode VAPID claims: %w", err)
+	}
+
+	unsigned := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(claims)
+	digest := sha256.Sum256([]byte(unsigned))
+
+	r, s, err := ecdsa.Sign(rand.Reader, k.privateKey, digest[:])
+	if err != nil {
+		return "", fmt.Errorf("failed to sign VAPID token: %w", err)
+	}
+
+	// ES256 signatures are the fixed-width concatenation of r and s
+	signature := make([]byte, 64)
+	r.FillBytes(signature[:32])
+	s.FillBytes(signature[32:])
+
+	token := unsigned + "." + base64.RawURLEncoding.EncodeToString(signature)
+	return fmt.Sprintf("vapid t=%s, k=%s", token, k.publicKey), nil
+}
+
+// decodeBase64URL decodes base64url with or without padding, as browsers and key generators differ
+func decodeBase64URL(s string) ([]byte, error) {
+	if b, err := base64.RawURLEncoding.DecodeString(s); err == nil {
+		return b, nil
+	}
+	return base64.URLEncoding.DecodeString(s)
+}
@@ -0,0 +1,321 @@
+package records
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/yegors/co-atc/internal/adsb"
+	"github.com/yegors/co-atc/internal/storage/sqlite"
+	"github.com/yegors/co-atc/pkg/logger"
+)
+
+// Record names
+const (
+	RecordFastestGroundSpeed = "fastest_ground_speed" // Highest ground speed of an airborne aircraft
+	RecordBusiestHour        = "busiest_hour"         // Most distinct aircraft seen in one clock hour
+	RecordLongestCoverage    = "longest_coverage"     // Longest time a flight was tracked airborne without a gap
+	RecordHighestAltitude    = "highest_altitude"     // Highest barometric altitude
+)
+
+const (
+	// coverageGap is how long an aircraft can go unseen before its coverage is considered broken
+	coverageGap = 2 * time.Minute
+	// maxPlausibleGroundSpeed filters out corrupt ADS-B speeds (knots)
+	maxPlausibleGroundSpeed = 1000.0
+	// maxPlausibleAltitude filters out corrupt ADS-B altitudes (feet)
+	maxPlausibleAltitude = 65000.0
+	// rarestTypesLimit is how many of the rarest aircraft types are reported
+	rarestTypesLimit = 10
+)
+
+// Records is the response of the records endpoint
+type Records struct {
+	Station     string                 `json:"station"`
+	Records     []sqlite.StationRecord `json:"records"`
+	RarestTypes []sqlite.TypeSighting  `json:"rarest_types"`
+	TypesSeen   int                    `json:"types_seen"`
+	CurrentHour int                    `json:"current_hour_aircraft"` // Distinct aircraft seen so far this hour
+	Timestamp   time.Time              `json:"timestamp"`
+}
+
+// sample is what the tracker needs from an aircraft in a poll cycle
+type sample struct {
+	hex          string
+	flight       string
+	aircraftType string
+	groundSpeed  float64
+	altitude     float64
+	onGround     bool
+}
+
+// coverage is the unbroken airborne tracking of one aircraft
+type coverage struct {
+	flight   string
+	acType   string
+	start    time.Time
+	lastSeen time.Time
+}
+
+// Service tracks fun all-time records of a station, like the fastest aircraft or busiest hour
+type Service struct {
+	adsbService *adsb.Service
+	storage     *sqlite.RecordStorage
+	station     string
+	logger      *logger.Logger
+
+	samples chan []sample
+
+	// Tracking state, only used by the worker goroutine
+	lastSeen     map[string]time.Time // When each aircraft was last seen, for counting sightings
+	coverage     map[string]*coverage
+	hourStart    time.Time
+	hourAircraft map[string]bool
+
+	// Current records, to avoid a database write unless a record is beaten
+	best        map[string]sqlite.StationRecord
+	currentHour int // Distinct aircraft seen so far this hour
+	bestMu      sync.RWMutex
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// NewService creates a new records service for the station
+func NewService(adsbService *adsb.Service, storage *sqlite.RecordStorage, station string, logger *logger.Logger) *Service {
+	return &Service{
+		adsbService:  adsbService,
+		storage:      storage,
+		station:      strings.ToUpper(station),
+		logger:       logger.Named("records"),
+		samples:      make(chan []sample, 4),
+		lastSeen:     make(map[string]time.Time),
+		coverage:     make(map[string]*coverage),
+		hourAircraft: make(map[string]bool),
+		best:         make(map[string]sqlite.StationRecord),
+	}
+}
+
+// Start loads the current records and starts tracking new poll cycles
+func (s *Service) Start(ctx context.Context) error {
+	records, err := s.storage.GetRecords(s.station)
+	if err != nil {
+		return fmt.Errorf("failed to load station records: %w", err)
+	}
+	for _, record := range records {
+		s.best[record.Record] = record
+	}
+
+	s.ctx, s.cancel = context.WithCancel(ctx)
+
+	s.wg.Add(1)
+	go s.run()
+
+	s.adsbService.OnUpdate(s.handleUpdate)
+
+	s.logger.Info("Records tracking started",
+		logger.String("station", s.station),
+		logger.Int("records", len(records)))
+	return nil
+}
+
+// Stop stops tracking
+func (s *Service) Stop() {
+	if s.cancel != nil {
+		s.cancel()
+	}
+	s.wg.Wait()
+}
+
+// GetRecords returns the station's records and rarest aircraft types
+func (s *Service) GetRecords() (*Records, error) {
+	records, err := s.storage.GetRecords(s.station)
+	if err != nil {
+		return nil, err
+	}
+	rarest, err := s.storage.GetRarestTypes(s.station, rarestTypesLimit)
+	if err != nil {
+		return nil, err
+	}
+	typesSeen, err := s.storage.CountTypes(s.station)
+	if err != nil {
+		return nil, err
+	}
+
+	if records == nil {
+		records = []sqlite.StationRecord{}
+	}
+	if rarest == nil {
+		rarest = []sqlite.TypeSighting{}
+	}
+
+	s.bestMu.RLock()
+	currentHour := s.currentHour
+	s.bestMu.RUnlock()
+
+	return &Records{
+		Station:     s.station,
+		Records:     records,
+		RarestTypes: rarest,
+		TypesSeen:   typesSeen,
+		CurrentHour: currentHour,
+		Timestamp:   time.Now().UTC(),
+	}, nil
+}
+
+// handleUpdate hands the aircraft of a poll cycle to the worker without blocking polling
+func (s *Service) handleUpdate(aircraft []*adsb.Aircraft) {
+	samples := make([]sample, 0, len(aircraft))
+	for _, a := range aircraft {
+		if a.ADSB == nil || a.IsSimulated {
+			continue
+		}
+		samples = append(samples, sample{
+			hex:          strings.ToLower(a.Hex),
+			flight:       strings.TrimSpace(a.Flight),
+			aircraftType: strings.ToUpper(strings.TrimSpace(a.ADSB.AircraftType)),
+			groundSpeed:  a.ADSB.GS,
+			altitude:     a.ADSB.AltBaro,
+			onGround:     a.OnGround,
+		})
+	}
+
+	select {
+	case s.samples <- samples:
+	default:
+		s.logger.Debug("Records worker busy, skipping poll cycle")
+	}
+}
+
+// run processes poll cycles until the service stops
+func (s *Service) run() {
+	defer s.wg.Done()
+
+	for {
+		select {
+		case <-s.ctx.Done():
+			return
+		case samples := <-s.samples:
+			s.process(samples, time.Now().UTC())
+		}
+	}
+}
+
+// process updates the tracking state and records with one poll cycle
+func (s *Service) process(samples []sample, now time.Time) {
+	// Busiest hour: distinct aircraft per clock hour
+	hour := now.Truncate(time.Hour)
+	if !hour.Equal(s.hourStart) {
+		s.hourStart = hour
+		s.hourAircraft = make(map[string]bool)
+	}
+
+	var newSightings []sqlite.TypeSighting
+	for _, smp := range samples {
+		s.hourAircraft[smp.hex] = true
+
+		// An aircraft appearing, or reappearing after a gap, is a new sighting of its type
+		if last, ok := s.lastSeen[smp.hex]; (!ok || now.Sub(last) > coverageGap) && smp.aircraftType != "" {
+			newSightings = append(newSightings, sqlite.TypeSighting{
+				AircraftType: smp.aircraftType,
+				LastSeen:     now,
+				LastHex:      smp.hex,
+				LastFlight:   smp.flight,
+			})
+		}
+		s.lastSeen[smp.hex] = now
+
+		if smp.onGround {
+			// Parked aircraft would otherwise hold the coverage record
+			delete(s.coverage, smp.hex)
+			continue
+		}
+
+		cov, ok := s.coverage[smp.hex]
+		if !ok || now.Sub(cov.lastSeen) > coverageGap {
+			cov = &coverage{start: now}
+			s.coverage[smp.hex] = cov
+		}
+		cov.lastSeen = now
+		if smp.flight != "" {
+			cov.flight = smp.flight
+		}
+		if smp.aircraftType != "" {
+			cov.acType = smp.aircraftType
+		}
+
+		if smp.groundSpeed > 0 && smp.groundSpeed <= maxPlausibleGroundSpeed {
+			s.beat(RecordFastestGroundSpeed, smp.groundSpeed, "kts", smp, "", now)
+		}
+		if smp.altitude > 0 && smp.altitude <= maxPlausibleAltitude {
+			s.beat(RecordHighestAltitude, smp.altitude, "ft", smp, "", now)
+		}
+		// Whole minutes, so a record-holding flight isn't saved on every poll cycle
+		if minutes := int(cov.lastSeen.Sub(cov.start).Minutes()); minutes > 0 {
+			s.beat(RecordLongestCoverage, float64(minutes), "min", sample{
+				hex: smp.hex, flight: cov.flight, aircraftType: cov.acType,
+			}, "since "+cov.start.Format(time.RFC3339), now)
+		}
+	}
+
+	count := len(s.hourAircraft)
+	s.beat(RecordBusiestHour, float64(count), "aircraft", sample{}, hour.Format(time.RFC3339), now)
+
+	s.bestMu.Lock()
+	s.currentHour = count
+	s.bestMu.Unlock()
+
+	// Forget aircraft that have been gone for a while
+	for hex, last := range s.lastSeen {
+		if now.Sub(last) > coverageGap {
+			delete(s.lastSeen, hex)
+			delete(s.coverage, hex)
+		}
+	}
+
+	if err := s.storage.AddTypeSightings(s.station, newSightings); err != nil {
+		s.logger.Error("Failed to record aircraft type sightings", logger.Error(err))
+	}
+}
+
+// beat stores a new record if the value is higher than the current one
+func (s *Service) beat(name string, value float64, unit string, smp sample, detail string, now time.Time) {
+	s.bestMu.RLock()
+	current, ok := s.best[name]
+	s.bestMu.RUnlock()
+	if ok && value <= current.Value {
+		return
+	}
+
+	record := sqlite.StationRecord{
+		Station:      s.station,
+		Record:       name,
+		Value:        value,
+		Unit:         unit,
+		Hex:          smp.hex,
+		Flight:       smp.flight,
+		AircraftType: smp.aircraftType,
+		Detail:       detail,
+		SetAt:        now,
+	}
+	if err := s.storage.SaveRecord(record); err != nil {
+		s.logger.Error("Failed to save record", logger.String("record", name), logger.Error(err))
+		return
+	}
+
+	s.bestMu.Lock()
+	s.best[name] = record
+	s.bestMu.Unlock()
+
+	// Coverage and the busy hour creep up every cycle; only log records worth a mention
+	if name == RecordFastestGroundSpeed || name == RecordHighestAltitude {
+		s.logger.Info("New station record",
+			logger.String("record", name),
+			logger.Float64("value", value),
+			logger.String("flight", smp.flight),
+			logger.String("hex", smp.hex))
+	}
+}
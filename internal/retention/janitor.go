@@ -0,0 +1,239 @@
+package retention
+
+import (
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/yegors/co-atc/internal/storage/sqlite"
+	"github.com/yegors/co-atc/pkg/logger"
+)
+
+// Config contains how long each kind of data is kept. A window of 0 keeps the data forever.
+type Config struct {
+	Interval       time.Duration // Time between pruning passes
+	Transcriptions time.Duration
+	Clearances     time.Duration
+	Tracks         time.Duration // Positions, phase changes and aircraft no longer seen
+	Events         time.Duration // Events on the alert timeline
+	Audio          time.Duration // Recorded audio segments
+	Export         bool          // Archive pruned rows before deleting them
+	ExportDir      string        // Directory archives are written to
+}
+
+// Enabled reports whether any kind of data has a retention window
+func (c Config) Enabled() bool {
+	return c.Transcriptions > 0 || c.Clearances > 0 || c.Tracks > 0 || c.Events > 0 || c.Audio > 0
+}
+
+// archives reports whether pruned rows are archived
+func (c Config) archives() bool {
+	return c.Export && c.ExportDir != ""
+}
+
+// RowPruner deletes the rows of a table older than a cutoff, passing them to export first
+type RowPruner interface {
+	Prune(table sqlite.PrunableTable, cutoff time.Time, export sqlite.ExportFunc) (int64, error)
+}
+
+// RecordingPruner deletes recorded audio that ended before a cutoff
+type RecordingPruner interface {
+	PruneRecordings(cutoff time.Time) (int, error)
+}
+
+// Janitor periodically deletes data that is past its retention window, so the database
+// and recordings directory do not grow without bound. Pruned rows can be archived to
+// gzip-compressed JSON Lines files first.
+type Janitor struct {
+	config     Config
+	storage    RowPruner
+	recordings RecordingPruner
+	logger     *logger.Logger
+
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// NewJanitor creates a new janitor
+func NewJanitor(config Config, storage RowPruner, recordings RecordingPruner, logger *logger.Logger) *Janitor {
+	if config.Interval <= 0 {
+		config.Interval = time.Hour
+	}
+
+	return &Janitor{
+		config:     config,
+		storage:    storage,
+		recordings: recordings,
+		logger:     logger.Named("retention"),
+	}
+}
+
+// Start runs a pruning pass now and then at every interval
+func (j *Janitor) Start(ctx context.Context) error {
+	if !j.config.Enabled() {
+		j.logger.Info("Data retention disabled, data is kept forever")
+		return nil
+	}
+
+	if j.config.archives() {
+		if err := os.MkdirAll(j.config.ExportDir, 0755); err != nil {
+			return fmt.Errorf("failed to create retention export directory: %w", err)
+		}
+	}
+
+	ctx, j.cancel = context.WithCancel(ctx)
+	j.wg.Add(1)
+	go func() {
+		defer j.wg.Done()
+		j.run(ctx)
+	}()
+
+	exportDir := ""
+	if j.config.archives() {
+		exportDir = j.config.ExportDir
+	}
+	j.logger.Info("Started data retention",
+		logger.Duration("interval", j.config.Interval),
+		logger.String("export_dir", exportDir))
+	return nil
+}
+
+// Stop stops pruning, waiting for a pass in progress to finish
+func (j *Janitor) Stop() {
+	if j.cancel != nil {
+		j.cancel()
+	}
+	j.wg.Wait()
+}
+
+// run prunes at every interval until the context is cancelled
+func (j *Janitor) run(ctx context.Context) {
+	ticker := time.NewTicker(j.config.Interval)
+	defer ticker.Stop()
+
+	j.prune(time.Now())
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			j.prune(now)
+		}
+	}
+}
+
+// prune runs one pruning pass. A failure is logged and retried on the next pass.
+func (j *Janitor) prune(now time.Time) {
+	tables := []struct {
+		table  sqlite.PrunableTable
+		window time.Duration
+	}{
+		// Clearances go before the transcriptions they were extracted from
+		{sqlite.ClearancesTable, j.config.Clearances},
+		{sqlite.TranscriptionsTable, j.config.Transcriptions},
+		{sqlite.PhaseChangesTable, j.config.Tracks},
+		{sqlite.PositionsTable, j.config.Tracks},
+		{sqlite.AircraftTable, j.config.Tracks},
+		{sqlite.EventsTable, j.config.Events},
+	}
+
+	for _, t := range tables {
+		if t.window <= 0 {
+			continue
+		}
+		cutoff := now.Add(-t.window)
+
+		var archive *archiveFile
+		var export sqlite.ExportFunc
+		if j.config.archives() {
+			archive = &archiveFile{path: filepath.Join(j.config.ExportDir,
+				fmt.Sprintf("%s-%s.jsonl.gz", t.table.Name, now.UTC().Format("20060102T150405Z")))}
+			export = archive.write
+		}
+
+		deleted, err := j.storage.Prune(t.table, cutoff, export)
+		if archive != nil {
+			if closeErr := archive.close(); closeErr != nil && err == nil {
+				err = closeErr
+			}
+		}
+		if err != nil {
+			j.logger.Error("Failed to prune expired data",
+				logger.String("table", t.table.Name),
+				logger.Int64("deleted", deleted),
+				logger.Error(err))
+			continue
+		}
+		if deleted > 0 {
+			j.logger.Info("Pruned expired data",
+				logger.String("table", t.table.Name),
+				logger.Int64("deleted", deleted),
+				logger.String("cutoff", cutoff.UTC().Format(time.RFC3339)))
+		}
+	}
+
+	if j.config.Audio > 0 && j.recordings != nil {
+		cutoff := now.Add(-j.config.Audio)
+		deleted, err := j.recordings.PruneRecordings(cutoff)
+		if err != nil {
+			j.logger.Error("Failed to prune expired recordings", logger.Error(err))
+		} else if deleted > 0 {
+			j.logger.Info("Pruned expired recordings",
+				logger.Int("deleted", deleted),
+				logger.String("cutoff", cutoff.UTC().Format(time.RFC3339)))
+		}
+	}
+}
+
+// archiveFile is a gzip-compressed JSON Lines file that pruned rows are written to. The
+// file is only created once there is a row to write.
+type archiveFile struct {
+	path    string
+	file    *os.File
+	gz      *gzip.Writer
+	encoder *json.Encoder
+}
+
+// write appends rows to the archive, one JSON object per line
+func (a *archiveFile) write(rows []map[string]interface{}) error {
+	if a.file == nil {
+		file, err := os.OpenFile(a.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+		if err != nil {
+			return fmt.Errorf("failed to create archive: %w", err)
+		}
+		a.file = file
+		a.gz = gzip.NewWriter(file)
+		a.encoder = json.NewEncoder(a.gz)
+	}
+
+	for _, row := range rows {
+		if err := a.encoder.Encode(row); err != nil {
+			return fmt.Errorf("failed to write archive: %w", err)
+		}
+	}
+	// Make sure the rows are on disk before they are deleted
+	if err := a.gz.Flush(); err != nil {
+		return fmt.Errorf("failed to write archive: %w", err)
+	}
+	return a.file.Sync()
+}
+
+// close finishes the archive, if one was created
+func (a *archiveFile) close() error {
+	if a.file == nil {
+		return nil
+	}
+	err := a.gz.Close()
+	if closeErr := a.file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return fmt.Errorf("failed to finish archive: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,82 @@
+package retention
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/yegors/co-atc/internal/storage/sqlite"
+	"github.com/yegors/co-atc/pkg/logger"
+	"go.uber.org/zap"
+)
+
+// fakePruner prunes one row from every table, passing it to export when there is one
+type fakePruner struct {
+	tables []string
+}
+
+func (p *fakePruner) Prune(table sqlite.PrunableTable, cutoff time.Time, export sqlite.ExportFunc) (int64, error) {
+	p.tables = append(p.tables, table.Name)
+	if export != nil {
+		if err := export([]map[string]interface{}{{"id": 1}}); err != nil {
+			return 0, err
+		}
+	}
+	return 1, nil
+}
+
+func newTestJanitor(config Config, pruner RowPruner) *Janitor {
+	return NewJanitor(config, pruner, nil, &logger.Logger{Logger: zap.NewNop()})
+}
+
+func archives(t *testing.T, dir string) []string {
+	t.Helper()
+	matches, err := filepath.Glob(filepath.Join(dir, "*.jsonl.gz"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return matches
+}
+
+func TestPruneWithoutExportWritesNoArchives(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "archive")
+	pruner := &fakePruner{}
+	janitor := newTestJanitor(Config{Tracks: time.Hour, Export: false, ExportDir: dir}, pruner)
+
+	janitor.prune(time.Now())
+
+	if len(pruner.tables) == 0 {
+		t.Fatal("expected tables to be pruned")
+	}
+	if _, err := os.Stat(dir); !os.IsNotExist(err) {
+		t.Fatalf("export directory was created with export disabled: %v", err)
+	}
+}
+
+func TestStartWithoutExportCreatesNoDirectory(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "archive")
+	janitor := newTestJanitor(Config{Interval: time.Hour, Events: time.Hour, ExportDir: dir}, &fakePruner{})
+
+	if err := janitor.Start(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	janitor.Stop()
+
+	if _, err := os.Stat(dir); !os.IsNotExist(err) {
+		t.Fatalf("export directory was created with export disabled: %v", err)
+	}
+}
+
+func TestPruneWithExportWritesArchives(t *testing.T) {
+	dir := t.TempDir()
+	pruner := &fakePruner{}
+	janitor := newTestJanitor(Config{Tracks: time.Hour, Export: true, ExportDir: dir}, pruner)
+
+	janitor.prune(time.Now())
+
+	if got, want := len(archives(t, dir)), len(pruner.tables); got != want {
+		t.Fatalf("got %d archives, want one per pruned table (%d)", got, want)
+	}
+}
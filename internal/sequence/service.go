@@ -0,0 +1,468 @@
+// Package sequence sequences arrivals to each runway by their estimated time to the threshold,
+// from their flying distance and ground speed trend.
+package sequence
+
+import (
+	"context"
+	"math"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/yegors/co-atc/internal/adsb"
+	"github.com/yegors/co-atc/internal/config"
+	"github.com/yegors/co-atc/internal/websocket"
+	"github.com/yegors/co-atc/pkg/logger"
+)
+
+const (
+	// finalAlignment is how far the course may be from the runway heading to be on final
+	finalAlignment = 30.0
+	// finalCoverage is the angle either side of the extended centerline, seen from the
+	// threshold, that counts as on final
+	finalCoverage = 10.0
+	// minFinalNM is the shortest final arrivals not yet on it are expected to fly
+	minFinalNM = 3.0
+	// profileFtPerNM and profileMarginFt bound the height of arrivals by their flying distance,
+	// so overflights aren't sequenced
+	profileFtPerNM  = 400.0
+	profileMarginFt = 3000.0
+	// maxClimbFPM is the fastest climb an arrival may be in, e.g. on a go-around it keeps its place
+	maxClimbFPM = 300.0
+	// departureTimeout is how long after taking off an aircraft isn't an arrival
+	departureTimeout = 15 * time.Minute
+	// runwayInUseTimeout is how long a runway an arrival was on final for counts as in use
+	runwayInUseTimeout = 15 * time.Minute
+	// stateTimeout is how long an aircraft's speed samples are kept after it was last sequenced
+	stateTimeout = 2 * time.Minute
+	// broadcastInterval is how often the sequence is sent when its order doesn't change
+	broadcastInterval = 5 * time.Second
+)
+
+// Arrival is an aircraft in the arrival sequence of a runway
+type Arrival struct {
+	Number         int       `json:"number"` // Position in the runway's sequence, 1 = next to land
+	Hex            string    `json:"hex"`
+	Callsign       string    `json:"callsign,omitempty"`
+	AircraftType   string    `json:"aircraft_type,omitempty"`
+	Runway         string    `json:"runway"`      // Threshold ID, e.g. "24R"
+	OnFinal        bool      `json:"on_final"`    // Lined up with the runway
+	DistanceNM     float64   `json:"distance_nm"` // Flying distance to the threshold
+	AltitudeFt     float64   `json:"altitude_ft"`
+	GroundSpeedKt  float64   `json:"ground_speed_kt"`
+	SpeedTrendKt   *float64  `json:"speed_trend_kt,omitempty"` // Ground speed change per minute, once known
+	ETASeconds     int       `json:"eta_seconds"`
+	ETA            time.Time `json:"eta"`
+	SpacingNM      *float64  `json:"spacing_nm,omitempty"`      // Behind the previous arrival to the runway
+	SpacingSeconds *int      `json:"spacing_seconds,omitempty"` // Behind the previous arrival to the runway
+}
+
+// Sequence is the arrivals to every runway
+type Sequence struct {
+	UpdatedAt time.Time `json:"updated_at"`
+	Arrivals  []Arrival `json:"arrivals"` // By ETA
+}
+
+// sample is what sequencing needs from an arrival in a poll cycle
+type sample struct {
+	hex          string
+	flight       string
+	aircraftType string
+	lat          float64
+	lon          float64
+	course       float64
+	altitude     float64 // MSL
+	groundSpeed  float64
+}
+
+// speedPoint is the ground speed of an aircraft at a time
+type speedPoint struct {
+	at    time.Time
+	speed float64
+}
+
+// arrivalState is what's remembered of an arrival between poll cycles
+type arrivalState struct {
+	speeds   []speedPoint // Oldest first, covering speed_window_seconds
+	lastSeen time.Time
+}
+
+// Service sequences arrivals every poll cycle
+type Service struct {
+	config      config.SequencingConfig
+	adsbService *adsb.Service
+	wsServer    *websocket.Server
+	logger      *logger.Logger
+
+	cycles chan []sample
+
+	// Only used by the worker goroutine
+	arrivals      map[string]*arrivalState // By hex
+	runwaysInUse  map[string]time.Time     // Last time an arrival was on final, by threshold ID
+	lastOrder     string
+	lastBroadcast time.Time
+
+	sequence   Sequence
+	sequenceMu sync.RWMutex
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// NewService creates a new arrival sequencing service
+func NewService(cfg config.SequencingConfig, adsbService *adsb.Service, wsServer *websocket.Server, logger *logger.Logger) *Service {
+	return &Service{
+		config:       cfg,
+		adsbService:  adsbService,
+		wsServer:     wsServer,
+		logger:       logger.Named("sequence"),
+		cycles:       make(chan []sample, 4),
+		arrivals:     make(map[string]*arrivalState),
+		runwaysInUse: make(map[string]time.Time),
+		sequence:     Sequence{Arrivals: []Arrival{}},
+	}
+}
+
+// Start starts sequencing arrivals
+func (s *Service) Start(ctx context.Context) {
+	s.ctx, s.cancel = context.WithCancel(ctx)
+	s.wg.Add(1)
+	go s.run()
+
+	s.adsbService.OnUpdate(s.handleUpdate)
+
+	s.logger.Info("Arrival sequencing started",
+		logger.Float64("max_distance_nm", s.config.MaxDistanceNM),
+		logger.Int("final_speed_kt", s.config.FinalSpeedKt))
+}
+
+// Stop stops sequencing arrivals
+func (s *Service) Stop() {
+	if s.cancel != nil {
+		s.cancel()
+	}
+	s.wg.Wait()
+}
+
+// Sequence returns the latest arrival sequence, optionally of one runway
+func (s *Service) Sequence(runway string) Sequence {
+	s.sequenceMu.RLock()
+	defer s.sequenceMu.RUnlock()
+
+	sequence := Sequence{UpdatedAt: s.sequence.UpdatedAt, Arrivals: make([]Arrival, 0, len(s.sequence.Arrivals))}
+	for _, arrival := range s.sequence.Arrivals {
+		if runway == "" || strings.EqualFold(arrival.Runway, runway) {
+			sequence.Arrivals = append(sequence.Arrivals, arrival)
+		}
+	}
+	return sequence
+}
+
+// handleUpdate hands the airborne aircraft of a poll cycle that may be arriving to the worker
+// without blocking polling
+func (s *Service) handleUpdate(aircraft []*adsb.Aircraft) {
+	now := time.Now().UTC()
+	declination := s.adsbService.Declination()
+
+	samples := make([]sample, 0)
+	for _, a := range aircraft {
+		if a.ADSB == nil || a.OnGround || a.Status != "active" || a.ADSB.GS <= 0 {
+			continue
+		}
+		if a.ADSB.Lat == 0 && a.ADSB.Lon == 0 {
+			continue
+		}
+		if a.DateTookoff != nil && now.Sub(*a.DateTookoff) < departureTimeout {
+			continue
+		}
+		climbRate := a.ADSB.BaroRate
+		if climbRate == 0 {
+			climbRate = a.ADSB.GeomRate
+		}
+		if climbRate > maxClimbFPM {
+			continue
+		}
+
+		altitude := adsb.AltitudeMSL(a.ADSB)
+		samples = append(samples, sample{
+			hex:          strings.ToLower(a.Hex),
+			flight:       strings.TrimSpace(a.Flight),
+			aircraftType: a.ADSB.AircraftType,
+			lat:          a.ADSB.Lat,
+			lon:          a.ADSB.Lon,
+			course:       adsb.TrueCourse(a.ADSB, declination),
+			altitude:     altitude,
+			groundSpeed:  a.ADSB.GS,
+		})
+	}
+
+	select {
+	case s.cycles <- samples:
+	default:
+		s.logger.Debug("Sequencing worker busy, skipping poll cycle")
+	}
+}
+
+// run sequences poll cycles until the service stops
+func (s *Service) run() {
+	defer s.wg.Done()
+
+	for {
+		select {
+		case <-s.ctx.Done():
+			return
+		case samples := <-s.cycles:
+			s.update(samples, time.Now().UTC())
+		}
+	}
+}
+
+// update sequences the arrivals of a poll cycle and sends the sequence when it changes
+func (s *Service) update(samples []sample, now time.Time) {
+	ends := s.adsbService.RunwayEnds()
+	if len(ends) == 0 {
+		return
+	}
+
+	arrivals := make([]Arrival, 0)
+	pending := make([]sample, 0)
+
+	// Aircraft on final fly to their runway; the runways they use are in use for the others
+	for _, smp := range samples {
+		end, distance, ok := s.finalTo(ends, smp)
+		if !ok {
+			pending = append(pending, smp)
+			continue
+		}
+		s.runwaysInUse[end.ID] = now
+		arrivals = append(arrivals, s.arrival(smp, end.ID, distance, true, now))
+	}
+
+	candidates := s.arrivalRunways(ends, now)
+	for _, smp := range pending {
+		end, distance, ok := s.inboundTo(candidates, smp)
+		if !ok {
+			continue
+		}
+		arrivals = append(arrivals, s.arrival(smp, end.ID, distance, false, now))
+	}
+
+	sortArrivals(arrivals)
+
+	for hex, state := range s.arrivals {
+		if now.Sub(state.lastSeen) > stateTimeout {
+			delete(s.arrivals, hex)
+		}
+	}
+	for runway, lastUsed := range s.runwaysInUse {
+		if now.Sub(lastUsed) > runwayInUseTimeout {
+			delete(s.runwaysInUse, runway)
+		}
+	}
+
+	s.sequenceMu.Lock()
+	s.sequence = Sequence{UpdatedAt: now, Arrivals: arrivals}
+	s.sequenceMu.Unlock()
+
+	s.broadcast(arrivals, now)
+}
+
+// finalTo returns the runway an aircraft is lined up with and its distance to the threshold
+// along the extended centerline
+func (s *Service) finalTo(ends []adsb.RunwayEnd, smp sample) (adsb.RunwayEnd, float64, bool) {
+	var best adsb.RunwayEnd
+	bestAlong := math.Inf(1)
+	for _, end := range ends {
+		if adsb.HeadingDifference(smp.course, end.Heading) > finalAlignment {
+			continue
+		}
+		along, right := adsb.RunwayPosition(end, smp.lat, smp.lon)
+		if along <= 0 || along > s.config.MaxDistanceNM {
+			continue
+		}
+		if math.Abs(math.Atan2(right, along))*180/math.Pi > finalCoverage {
+			continue
+		}
+		if smp.altitude-end.ElevationFeet > along*profileFtPerNM+profileMarginFt {
+			continue
+		}
+		if along < bestAlong {
+			best, bestAlong = end, along
+		}
+	}
+	return best, bestAlong, !math.IsInf(bestAlong, 1)
+}
+
+// inboundTo returns the runway an aircraft not yet on final is expected to land on and its
+// flying distance to the threshold: a 45° intercept to the extended centerline, joining it no
+// closer than minFinalNM. The runway is the one of the candidates with the shortest distance.
+func (s *Service) inboundTo(candidates []adsb.RunwayEnd, smp sample) (adsb.RunwayEnd, float64, bool) {
+	var best adsb.RunwayEnd
+	bestDistance := math.Inf(1)
+	for _, end := range candidates {
+		distance := flyingDistance(end, smp.lat, smp.lon)
+		if distance > s.config.MaxDistanceNM || smp.altitude-end.ElevationFeet > distance*profileFtPerNM+profileMarginFt {
+			continue
+		}
+		// Inbound: flying toward the threshold rather than away
+		bearing := adsb.CalculateBearing(smp.lat, smp.lon, end.ThresholdLatitude, end.ThresholdLongitude)
+		if adsb.HeadingDifference(smp.course, bearing) > 90 {
+			continue
+		}
+		if distance < bestDistance {
+			best, bestDistance = end, distance
+		}
+	}
+	return best, bestDistance, !math.IsInf(bestDistance, 1)
+}
+
+// arrivalRunways returns the runways arrivals not yet on final are expected to land on: the
+// runways forced in use for arrivals, else those arrivals were recently on final for, else every
+// runway open for arrivals
+func (s *Service) arrivalRunways(ends []adsb.RunwayEnd, now time.Time) []adsb.RunwayEnd {
+	forced := make([]adsb.RunwayEnd, 0)
+	recent := make([]adsb.RunwayEnd, 0)
+	open := make([]adsb.RunwayEnd, 0)
+	for _, end := range ends {
+		status := s.adsbService.GetRunwayStatus(end.ID)
+		if status != nil && !status.Arrivals {
+			continue
+		}
+		open = append(open, end)
+		if status != nil && status.Source == "override" {
+			forced = append(forced, end)
+		}
+		if lastUsed, ok := s.runwaysInUse[end.ID]; ok && now.Sub(lastUsed) <= runwayInUseTimeout {
+			recent = append(recent, end)
+		}
+	}
+
+	switch {
+	case len(forced) > 0:
+		return forced
+	case len(recent) > 0:
+		return recent
+	}
+	return open
+}
+
+// arrival estimates an aircraft's time to the threshold from its ground speed trend
+func (s *Service) arrival(smp sample, runway string, distance float64, onFinal bool, now time.Time) Arrival {
+	state, exists := s.arrivals[smp.hex]
+	if !exists {
+		state = &arrivalState{}
+		s.arrivals[smp.hex] = state
+	}
+	state.lastSeen = now
+	window := time.Duration(s.config.SpeedWindowSeconds) * time.Second
+	state.speeds = append(state.speeds, speedPoint{at: now, speed: smp.groundSpeed})
+	for len(state.speeds) > 2 && now.Sub(state.speeds[1].at) >= window {
+		state.speeds = state.speeds[1:]
+	}
+
+	// The trend is known once the samples cover most of the window
+	var trend *float64
+	deceleration := 0.0 // kt/s
+	if first := state.speeds[0]; now.Sub(first.at) >= window/2 {
+		perSecond := (smp.groundSpeed - first.speed) / now.Sub(first.at).Seconds()
+		perMinute := math.Round(perSecond*60*10) / 10
+		trend = &perMinute
+		if perSecond < 0 {
+			deceleration = -perSecond
+		}
+	}
+
+	eta := timeToGo(distance, smp.groundSpeed, deceleration, float64(s.config.FinalSpeedKt))
+	return Arrival{
+		Hex:           smp.hex,
+		Callsign:      smp.flight,
+		AircraftType:  smp.aircraftType,
+		Runway:        runway,
+		OnFinal:       onFinal,
+		DistanceNM:    math.Round(distance*10) / 10,
+		AltitudeFt:    math.Round(smp.altitude),
+		GroundSpeedKt: math.Round(smp.groundSpeed),
+		SpeedTrendKt:  trend,
+		ETASeconds:    int(math.Round(eta.Seconds())),
+		ETA:           now.Add(eta).Truncate(time.Second),
+	}
+}
+
+// broadcast sends the sequence when its order changed, and otherwise every broadcastInterval
+func (s *Service) broadcast(arrivals []Arrival, now time.Time) {
+	if s.wsServer == nil {
+		return
+	}
+
+	order := make([]string, 0, len(arrivals))
+	for _, arrival := range arrivals {
+		order = append(order, arrival.Runway+"/"+arrival.Hex)
+	}
+	key := strings.Join(order, ",")
+	if key == s.lastOrder && now.Sub(s.lastBroadcast) < broadcastInterval {
+		return
+	}
+	s.lastOrder = key
+	s.lastBroadcast = now
+
+	s.wsServer.Broadcast(&websocket.Message{
+		Type: "arrival_sequence",
+		Data: map[string]interface{}{
+			"updated_at": now,
+			"arrivals":   arrivals,
+		},
+	})
+}
+
+// sortArrivals orders arrivals by ETA and numbers them and their spacing within each runway's
+// sequence
+func sortArrivals(arrivals []Arrival) {
+	sort.SliceStable(arrivals, func(i, j int) bool {
+		return arrivals[i].ETASeconds < arrivals[j].ETASeconds
+	})
+
+	previous := make(map[string]*Arrival)
+	for i := range arrivals {
+		arrival := &arrivals[i]
+		if ahead, ok := previous[arrival.Runway]; ok {
+			arrival.Number = ahead.Number + 1
+			spacingNM := math.Round((arrival.DistanceNM-ahead.DistanceNM)*10) / 10
+			spacingSeconds := arrival.ETASeconds - ahead.ETASeconds
+			arrival.SpacingNM = &spacingNM
+			arrival.SpacingSeconds = &spacingSeconds
+		} else {
+			arrival.Number = 1
+		}
+		previous[arrival.Runway] = arrival
+	}
+}
+
+// timeToGo is how long it takes to fly a distance from a ground speed, slowing down at a rate
+// in kt/s until the final speed is reached
+func timeToGo(distanceNM, speedKt, deceleration, finalSpeedKt float64) time.Duration {
+	if deceleration <= 0 || speedKt <= finalSpeedKt {
+		return time.Duration(distanceNM / speedKt * float64(time.Hour))
+	}
+
+	// Distance covered while slowing to the final speed
+	slowing := (speedKt - finalSpeedKt) / deceleration
+	slowingNM := (speedKt + finalSpeedKt) / 2 * slowing / 3600
+	if slowingNM >= distanceNM {
+		// Reaches the threshold while still slowing: d = v*t - a*t²/2
+		v, a := speedKt/3600, deceleration/3600
+		seconds := (v - math.Sqrt(v*v-2*a*distanceNM)) / a
+		return time.Duration(seconds * float64(time.Second))
+	}
+	seconds := slowing + (distanceNM-slowingNM)/finalSpeedKt*3600
+	return time.Duration(seconds * float64(time.Second))
+}
+
+// flyingDistance returns the distance to a threshold flying a 45° intercept to its extended
+// centerline, joining it no closer than minFinalNM
+func flyingDistance(end adsb.RunwayEnd, lat, lon float64) float64 {
+	along, right := adsb.RunwayPosition(end, lat, lon)
+	join := math.Max(along-math.Abs(right), minFinalNM)
+	return math.Hypot(along-join, right) + join
+}
@@ -0,0 +1,402 @@
+package simulation
+
+import (
+	"fmt"
+	"math"
+	"strings"
+
+	"github.com/yegors/co-atc/internal/adsb"
+)
+
+// Autopilot lateral modes
+const (
+	AutopilotModeTakeoff        = "takeoff"         // Accelerating along the runway to rotation speed
+	AutopilotModeHeading        = "heading"         // Fly a heading (the simulation has no wind, so also the track)
+	AutopilotModeRoute          = "route"           // Fly to each waypoint of the route in turn
+	AutopilotModeLocalizerArmed = "localizer_armed" // Fly a heading until the localizer is intercepted
+	AutopilotModeLocalizer      = "localizer"       // Track the localizer, and the glidepath when landing
+	AutopilotModeRollout        = "rollout"         // Landed, braking on the runway
+	AutopilotModeVacating       = "vacating"        // Turning off the runway at taxi speed
+	AutopilotModeVacated        = "vacated"         // Stopped clear of the runway
+)
+
+const (
+	autopilotStep            = 1.0    // Longest step (s) the autopilot flies between corrections
+	airTurnRate              = 3.0    // Standard rate turn (deg/s)
+	groundTurnRate           = 10.0   // Turn rate when taxiing (deg/s)
+	airAcceleration          = 1.5    // Speed change in the air (kts/s)
+	rolloutDeceleration      = 4.0    // Braking on the runway (kts/s)
+	takeoffAcceleration      = 3.0    // Acceleration on the takeoff roll (kts/s)
+	rotationSpeed            = 140.0  // Speed the aircraft lifts off at
+	defaultAutopilotRate     = 1500.0 // Climb or descent rate (fpm) when none is set
+	waypointPassNM           = 0.5    // Distance at which a waypoint counts as passed
+	localizerRangeNM         = 25.0   // Furthest the localizer can be intercepted from the threshold
+	localizerCaptureNM       = 1.5    // Cross-track distance at which the localizer is captured
+	localizerGainDegPerNM    = 30.0   // Intercept angle per NM off the localizer
+	localizerMaxInterceptDeg = 45.0   // Steepest intercept flown once established
+	maxInterceptAngle        = 120.0  // Steepest intercept the localizer is captured from
+	glidepathAngleDeg        = 3.0
+	thresholdCrossingFt      = 50.0 // Height of the glidepath over the threshold
+	glidepathGainFpmPerFt    = 6.0  // Descent correction per foot off the glidepath
+	glidepathCapturedFt      = 100.0
+	maxDescentRate           = 2500.0
+	approachSpeed            = 140.0 // Speed flown inside approachSlowdownNM when landing
+	approachSlowdownNM       = 8.0
+	taxiSpeed                = 15.0
+	vacateDistanceNM         = 0.15 // Distance taxied off the runway before stopping
+	feetPerNM                = 6076.12
+)
+
+// Waypoint is a point of an autopilot route, with an optional altitude and speed to fly
+// once it becomes the active waypoint
+type Waypoint struct {
+	Name     string   `json:"name,omitempty"`
+	Lat      float64  `json:"lat"`
+	Lon      float64  `json:"lon"`
+	Altitude *float64 `json:"altitude,omitempty"`
+	Speed    *float64 `json:"speed,omitempty"`
+}
+
+// AutopilotSettings are what an autopilot is engaged with. Targets left out hold the
+// aircraft's current heading, altitude or speed.
+type AutopilotSettings struct {
+	Heading      *float64   `json:"heading,omitempty"`       // Heading to fly, or to intercept the localizer on
+	Altitude     *float64   `json:"altitude,omitempty"`      // Altitude to climb or descend to
+	VerticalRate float64    `json:"vertical_rate,omitempty"` // Climb or descent rate (fpm), default 1500
+	Speed        *float64   `json:"speed,omitempty"`
+	Route        []Waypoint `json:"route,omitempty"`   // Waypoints to fly before the heading or the localizer
+	Runway       string     `json:"runway,omitempty"`  // Threshold whose localizer to intercept, e.g. "06L"
+	Land         bool       `json:"land,omitempty"`    // Follow the glidepath, land and vacate the runway
+	Takeoff      bool       `json:"takeoff,omitempty"` // Take off along the current heading first
+}
+
+// Autopilot is the autopilot of a simulated aircraft
+type Autopilot struct {
+	AutopilotSettings
+	Mode              string  `json:"mode"`                        // Lateral mode
+	ActiveWaypoint    int     `json:"active_waypoint"`             // Index into the route
+	GlidepathCaptured bool    `json:"glidepath_captured"`          // Established on the glidepath
+	DistanceToGoNM    float64 `json:"distance_to_go_nm,omitempty"` // To the active waypoint or the threshold
+
+	runway        *adsb.RunwayEnd
+	vacatedNM     float64 // Distance taxied off the runway
+	vacateHeading float64
+}
+
+// airport is the runway data and elevation the autopilot lands with
+type airport struct {
+	runways       []adsb.RunwayEnd
+	elevationFeet float64
+}
+
+// SetAirport sets the runways simulated aircraft can intercept and land on, and the field
+// elevation they land at
+func (s *Service) SetAirport(runways []adsb.RunwayEnd, elevationFeet float64) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.airport = airport{runways: runways, elevationFeet: elevationFeet}
+}
+
+// SetAutopilot engages the autopilot of a simulated aircraft, replacing any settings it was
+// flying with
+func (s *Service) SetAutopilot(hex string, settings AutopilotSettings) (*SimulatedAircraft, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	aircraft, exists := s.aircraft[hex]
+	if !exists {
+		return nil, fmt.Errorf("simulated aircraft with hex %s not found", hex)
+	}
+
+	autopilot, err := s.newAutopilot(aircraft, settings)
+	if err != nil {
+		return nil, err
+	}
+	aircraft.Autopilot = autopilot
+
+	s.logger.Info(fmt.Sprintf("Engaged simulated autopilot hex=%s mode=%s runway=%s land=%t waypoints=%d",
+		hex, autopilot.Mode, autopilot.Runway, autopilot.Land, len(autopilot.Route)))
+	return aircraft, nil
+}
+
+// DisengageAutopilot hands a simulated aircraft back to manual control. It keeps its
+// current heading, speed and vertical rate.
+func (s *Service) DisengageAutopilot(hex string) (*SimulatedAircraft, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	aircraft, exists := s.aircraft[hex]
+	if !exists {
+		return nil, fmt.Errorf("simulated aircraft with hex %s not found", hex)
+	}
+	aircraft.Autopilot = nil
+
+	s.logger.Info(fmt.Sprintf("Disengaged simulated autopilot hex=%s", hex))
+	return aircraft, nil
+}
+
+// newAutopilot checks autopilot settings and fills in the targets left out. Must be called
+// with the lock held.
+func (s *Service) newAutopilot(aircraft *SimulatedAircraft, settings AutopilotSettings) (*Autopilot, error) {
+	if settings.Heading != nil && (*settings.Heading < 0 || *settings.Heading >= 360) {
+		return nil, fmt.Errorf("invalid heading (0-359 degrees): %g", *settings.Heading)
+	}
+	if settings.Altitude != nil && (*settings.Altitude < 0 || *settings.Altitude > 60000) {
+		return nil, fmt.Errorf("invalid altitude (0-60000 ft): %g", *settings.Altitude)
+	}
+	if settings.VerticalRate < 0 || settings.VerticalRate > 3000 {
+		return nil, fmt.Errorf("invalid vertical rate (0-3000 fpm): %g", settings.VerticalRate)
+	}
+	if settings.Speed != nil && (*settings.Speed < 0 || *settings.Speed > 500) {
+		return nil, fmt.Errorf("invalid speed (0-500 knots): %g", *settings.Speed)
+	}
+	for i, waypoint := range settings.Route {
+		if waypoint.Lat < -90 || waypoint.Lat > 90 || waypoint.Lon < -180 || waypoint.Lon > 180 {
+			return nil, fmt.Errorf("invalid coordinates for waypoint %d", i+1)
+		}
+		if waypoint.Altitude != nil && (*waypoint.Altitude < 0 || *waypoint.Altitude > 60000) {
+			return nil, fmt.Errorf("invalid altitude for waypoint %d (0-60000 ft)", i+1)
+		}
+		if waypoint.Speed != nil && (*waypoint.Speed < 0 || *waypoint.Speed > 500) {
+			return nil, fmt.Errorf("invalid speed for waypoint %d (0-500 knots)", i+1)
+		}
+	}
+	if settings.Land && settings.Runway == "" {
+		return nil, fmt.Errorf("landing needs a runway")
+	}
+
+	autopilot := &Autopilot{AutopilotSettings: settings}
+	if settings.Runway != "" {
+		for i := range s.airport.runways {
+			if strings.EqualFold(s.airport.runways[i].ID, settings.Runway) {
+				end := s.airport.runways[i]
+				autopilot.runway = &end
+				autopilot.Runway = end.ID
+			}
+		}
+		if autopilot.runway == nil {
+			return nil, fmt.Errorf("%w: %s", adsb.ErrUnknownRunway, settings.Runway)
+		}
+	}
+
+	// Hold whatever wasn't set; a route without a heading flies on from its last waypoint
+	if autopilot.Heading == nil && len(autopilot.Route) == 0 {
+		heading := aircraft.TargetHeading
+		autopilot.Heading = &heading
+	}
+	if autopilot.Altitude == nil {
+		altitude := math.Round(aircraft.CurrentAltitude)
+		autopilot.Altitude = &altitude
+	}
+	if autopilot.VerticalRate == 0 {
+		autopilot.VerticalRate = defaultAutopilotRate
+	}
+	if autopilot.Speed == nil {
+		speed := aircraft.TargetSpeed
+		autopilot.Speed = &speed
+	}
+
+	if len(autopilot.Route) > 0 {
+		autopilot.activateWaypoint(0)
+	}
+	autopilot.Mode = autopilot.flightMode()
+	if autopilot.Takeoff {
+		autopilot.Mode = AutopilotModeTakeoff
+	}
+	return autopilot, nil
+}
+
+// flightMode returns the lateral mode to fly the settings with once airborne
+func (a *Autopilot) flightMode() string {
+	switch {
+	case len(a.Route) > 0:
+		return AutopilotModeRoute
+	case a.runway != nil:
+		return AutopilotModeLocalizerArmed
+	default:
+		return AutopilotModeHeading
+	}
+}
+
+// activateWaypoint makes a waypoint of the route the active one and takes on its altitude
+// and speed
+func (a *Autopilot) activateWaypoint(i int) {
+	a.ActiveWaypoint = i
+	waypoint := a.Route[i]
+	if waypoint.Altitude != nil {
+		altitude := *waypoint.Altitude
+		a.Altitude = &altitude
+	}
+	if waypoint.Speed != nil {
+		speed := *waypoint.Speed
+		a.Speed = &speed
+	}
+}
+
+// flyAutopilot sets the controls of an aircraft for the next deltaTime seconds: it turns
+// toward the heading of the lateral mode, climbs or descends toward the target altitude or
+// the glidepath, and speeds up or slows down toward the target speed. Must be called with
+// the lock held.
+func (s *Service) flyAutopilot(aircraft *SimulatedAircraft, deltaTime float64) {
+	a := aircraft.Autopilot
+	heading := aircraft.TargetHeading
+	if a.Heading != nil {
+		heading = *a.Heading
+	}
+	speed := *a.Speed
+	turnRate := airTurnRate
+	acceleration := airAcceleration
+	glidepath := false
+
+	switch a.Mode {
+	case AutopilotModeTakeoff:
+		heading = aircraft.TargetHeading
+		speed = rotationSpeed
+		acceleration = takeoffAcceleration
+		if aircraft.TargetSpeed >= rotationSpeed {
+			a.Mode = a.flightMode()
+			s.logger.Info(fmt.Sprintf("Simulated aircraft took off hex=%s flight=%s", aircraft.Hex, aircraft.Flight))
+		}
+
+	case AutopilotModeRoute:
+		waypoint := a.Route[a.ActiveWaypoint]
+		a.DistanceToGoNM = adsb.MetersToNM(adsb.Haversine(aircraft.CurrentLat, aircraft.CurrentLon, waypoint.Lat, waypoint.Lon))
+		heading = adsb.CalculateBearing(aircraft.CurrentLat, aircraft.CurrentLon, waypoint.Lat, waypoint.Lon)
+		if a.DistanceToGoNM <= waypointPassNM {
+			if a.ActiveWaypoint+1 < len(a.Route) {
+				a.activateWaypoint(a.ActiveWaypoint + 1)
+			} else {
+				// End of the route; fly the heading, or on to the localizer
+				if a.Heading == nil {
+					a.Heading = &heading
+				}
+				a.DistanceToGoNM = 0
+				a.Mode = AutopilotModeHeading
+				if a.runway != nil {
+					a.Mode = AutopilotModeLocalizerArmed
+				}
+			}
+		}
+
+	case AutopilotModeLocalizerArmed, AutopilotModeLocalizer:
+		along, right := adsb.RunwayPosition(*a.runway, aircraft.CurrentLat, aircraft.CurrentLon)
+		a.DistanceToGoNM = math.Max(0, along)
+		intercept := adsb.HeadingDifference(aircraft.TargetHeading, a.runway.Heading)
+		// Capture early enough to turn onto the localizer at standard rate
+		captureNM := math.Max(localizerCaptureNM, turnRadiusNM(aircraft.TargetSpeed)*(1-math.Cos(intercept*math.Pi/180)))
+		if a.Mode == AutopilotModeLocalizerArmed && along > 0 && along <= localizerRangeNM &&
+			math.Abs(right) <= captureNM && intercept <= maxInterceptAngle {
+			a.Mode = AutopilotModeLocalizer
+			s.logger.Info(fmt.Sprintf("Simulated aircraft established on localizer hex=%s flight=%s runway=%s",
+				aircraft.Hex, aircraft.Flight, a.runway.ID))
+		}
+		if a.Mode != AutopilotModeLocalizer {
+			break
+		}
+
+		// Turn toward the centerline, left when right of it
+		heading = normalizeHeading(a.runway.Heading - math.Max(-localizerMaxInterceptDeg,
+			math.Min(localizerMaxInterceptDeg, right*localizerGainDegPerNM)))
+		if !a.Land {
+			if along < 0 {
+				// Past the threshold without landing; fly the runway heading
+				runwayHeading := a.runway.Heading
+				a.Heading = &runwayHeading
+				a.DistanceToGoNM = 0
+				a.Mode = AutopilotModeHeading
+			}
+			break
+		}
+
+		glidepath = true
+		if along < approachSlowdownNM {
+			speed = math.Min(speed, approachSpeed)
+		}
+		if along <= 0 || aircraft.CurrentAltitude <= a.runway.ElevationFeet {
+			aircraft.CurrentAltitude = a.runway.ElevationFeet
+			aircraft.TargetVerticalRate = 0
+			a.Mode = AutopilotModeRollout
+			s.logger.Info(fmt.Sprintf("Simulated aircraft landed hex=%s flight=%s runway=%s",
+				aircraft.Hex, aircraft.Flight, a.runway.ID))
+			break
+		}
+		glidepathAltitude := a.runway.ElevationFeet + thresholdCrossingFt +
+			along*feetPerNM*math.Tan(glidepathAngleDeg*math.Pi/180)
+		nominalRate := -aircraft.TargetSpeed * feetPerNM / 60 * math.Tan(glidepathAngleDeg*math.Pi/180)
+		// Level below the glidepath until it comes down to the aircraft, then follow it
+		aircraft.TargetVerticalRate = math.Max(-maxDescentRate,
+			math.Min(0, nominalRate+(glidepathAltitude-aircraft.CurrentAltitude)*glidepathGainFpmPerFt))
+		a.GlidepathCaptured = math.Abs(aircraft.CurrentAltitude-glidepathAltitude) < glidepathCapturedFt
+
+	case AutopilotModeRollout:
+		heading = a.runway.Heading
+		speed = taxiSpeed
+		acceleration = rolloutDeceleration
+		if aircraft.TargetSpeed <= taxiSpeed {
+			a.vacateHeading = normalizeHeading(a.runway.Heading + 90)
+			a.Mode = AutopilotModeVacating
+		}
+
+	case AutopilotModeVacating:
+		heading = a.vacateHeading
+		speed = taxiSpeed
+		turnRate = groundTurnRate
+		a.vacatedNM += aircraft.TargetSpeed * deltaTime / 3600
+		if a.vacatedNM >= vacateDistanceNM {
+			a.Mode = AutopilotModeVacated
+			s.logger.Info(fmt.Sprintf("Simulated aircraft vacated runway hex=%s flight=%s runway=%s",
+				aircraft.Hex, aircraft.Flight, a.runway.ID))
+		}
+
+	case AutopilotModeVacated:
+		heading = aircraft.TargetHeading
+		speed = 0
+		acceleration = rolloutDeceleration
+	}
+
+	switch {
+	case a.Mode == AutopilotModeTakeoff:
+		aircraft.TargetVerticalRate = 0
+	case a.Mode == AutopilotModeRollout || a.Mode == AutopilotModeVacating || a.Mode == AutopilotModeVacated:
+		aircraft.CurrentAltitude = s.airport.elevationFeet
+		if a.runway != nil {
+			aircraft.CurrentAltitude = a.runway.ElevationFeet
+		}
+		aircraft.TargetVerticalRate = 0
+	case !glidepath:
+		aircraft.TargetVerticalRate = altitudeRate(aircraft.CurrentAltitude, *a.Altitude, a.VerticalRate, deltaTime)
+	}
+
+	// Turn the shorter way at the turn rate
+	turn := adsb.TurnAngle(aircraft.TargetHeading, heading)
+	maxTurn := turnRate * deltaTime
+	aircraft.TargetHeading = normalizeHeading(aircraft.TargetHeading + math.Max(-maxTurn, math.Min(maxTurn, turn)))
+
+	change := speed - aircraft.TargetSpeed
+	maxChange := acceleration * deltaTime
+	aircraft.TargetSpeed += math.Max(-maxChange, math.Min(maxChange, change))
+}
+
+// altitudeRate returns the vertical rate that climbs or descends toward a target altitude at
+// rate, leveling off exactly at the target
+func altitudeRate(altitude, target, rate, deltaTime float64) float64 {
+	remaining := target - altitude
+	if math.Abs(remaining) <= rate*deltaTime/60 {
+		return remaining * 60 / deltaTime
+	}
+	return math.Copysign(rate, remaining)
+}
+
+// turnRadiusNM returns the radius of a standard rate turn at a speed
+func turnRadiusNM(speed float64) float64 {
+	return speed / (20 * math.Pi)
+}
+
+// normalizeHeading brings a heading into 0-360
+func normalizeHeading(heading float64) float64 {
+	heading = math.Mod(heading, 360)
+	if heading < 0 {
+		heading += 360
+	}
+	return heading
+}
@@ -0,0 +1,325 @@
+package simulation
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/yegors/co-atc/internal/adsb"
+	"github.com/yegors/co-atc/internal/storage/sqlite"
+	"github.com/yegors/co-atc/internal/websocket"
+	"github.com/yegors/co-atc/pkg/logger"
+)
+
+const (
+	MaxRadioCalls      = 50               // Most calls in one script
+	MaxRadioCallDelay  = time.Hour        // Latest a call can be scheduled
+	maxPendingCalls    = 500              // Most calls waiting to be transmitted
+	radioWordsPerSec   = 3.0              // Speaking rate used to time the words of a call
+	radioReadbackDelay = 4 * time.Second  // Gap between a call and its reply in generated exchanges
+	radioHandoffDelay  = 20 * time.Second // Time after takeoff departures are sent to departure
+)
+
+var (
+	ErrRadioUnavailable = errors.New("radio simulation is not available")
+	ErrInvalidRadioCall = errors.New("invalid radio call")
+)
+
+// RadioCall is a transmission injected into the transcription pipeline as if it had been
+// heard and transcribed on a frequency. The text can refer to the simulated aircraft given
+// by Hex with {callsign} (spoken, "Air Canada 123"), {flight}, {altitude}, {heading},
+// {speed} and {runway}; they're filled in with its state when the call is transmitted.
+type RadioCall struct {
+	FrequencyID  string  `json:"frequency_id"`
+	Text         string  `json:"text"`
+	Hex          string  `json:"hex,omitempty"`           // Simulated aircraft the call is about
+	DelaySeconds float64 `json:"delay_seconds,omitempty"` // Time after the script starts
+}
+
+// PendingRadioCall is a call waiting to be transmitted
+type PendingRadioCall struct {
+	ID int64 `json:"id"`
+	RadioCall
+	At time.Time `json:"at"` // When the call will be transmitted
+}
+
+// radioState is the radio simulation part of the service
+type radioState struct {
+	storage     *sqlite.TranscriptionStorage // nil = radio simulation not set up
+	frequencies map[string]bool              // Known frequency IDs
+	wsServer    *websocket.Server
+	pending     map[int64]*pendingCall
+	nextID      int64
+	mu          sync.Mutex
+}
+
+// pendingCall is a scheduled call and the timer that transmits it
+type pendingCall struct {
+	call  PendingRadioCall
+	timer *time.Timer
+}
+
+// SetRadio enables simulated radio calls on the given frequencies. Calls are stored in
+// transcriptions as unprocessed, so the post-processor, clearance extraction and ATC chat
+// handle them like transcriptions of received audio, and broadcast to wsServer.
+func (s *Service) SetRadio(transcriptions *sqlite.TranscriptionStorage, frequencyIDs []string, wsServer *websocket.Server) {
+	s.radio.mu.Lock()
+	defer s.radio.mu.Unlock()
+	s.radio.storage = transcriptions
+	s.radio.wsServer = wsServer
+	s.radio.frequencies = make(map[string]bool, len(frequencyIDs))
+	for _, id := range frequencyIDs {
+		s.radio.frequencies[id] = true
+	}
+	s.radio.pending = make(map[int64]*pendingCall)
+}
+
+// TransmitRadio schedules a script of radio calls, each at its delay from now. The whole
+// script is rejected if any call is invalid.
+func (s *Service) TransmitRadio(calls []RadioCall) ([]PendingRadioCall, error) {
+	if len(calls) == 0 || len(calls) > MaxRadioCalls {
+		return nil, fmt.Errorf("%w: a script has 1 to %d calls", ErrInvalidRadioCall, MaxRadioCalls)
+	}
+
+	s.radio.mu.Lock()
+	defer s.radio.mu.Unlock()
+	if s.radio.storage == nil {
+		return nil, ErrRadioUnavailable
+	}
+	if len(s.radio.pending)+len(calls) > maxPendingCalls {
+		return nil, fmt.Errorf("%w: at most %d calls can be pending", ErrInvalidRadioCall, maxPendingCalls)
+	}
+
+	for i, call := range calls {
+		if err := s.validateRadioCall(call); err != nil {
+			return nil, fmt.Errorf("call #%d: %w", i+1, err)
+		}
+	}
+
+	now := time.Now().UTC()
+	scheduled := make([]PendingRadioCall, 0, len(calls))
+	for _, call := range calls {
+		call.Text = strings.TrimSpace(call.Text)
+		scheduled = append(scheduled, s.scheduleRadioCall(call, now))
+	}
+
+	s.logger.Info(fmt.Sprintf("Scheduled %d simulated radio calls", len(scheduled)))
+	return scheduled, nil
+}
+
+// PendingRadioCalls returns the calls waiting to be transmitted, earliest first
+func (s *Service) PendingRadioCalls() ([]PendingRadioCall, error) {
+	s.radio.mu.Lock()
+	defer s.radio.mu.Unlock()
+	if s.radio.storage == nil {
+		return nil, ErrRadioUnavailable
+	}
+
+	calls := make([]PendingRadioCall, 0, len(s.radio.pending))
+	for _, pending := range s.radio.pending {
+		calls = append(calls, pending.call)
+	}
+	sort.Slice(calls, func(i, j int) bool {
+		if calls[i].At.Equal(calls[j].At) {
+			return calls[i].ID < calls[j].ID
+		}
+		return calls[i].At.Before(calls[j].At)
+	})
+	return calls, nil
+}
+
+// CancelRadioCalls drops the calls waiting to be transmitted and returns how many there were
+func (s *Service) CancelRadioCalls() int {
+	s.radio.mu.Lock()
+	defer s.radio.mu.Unlock()
+
+	count := len(s.radio.pending)
+	for id, pending := range s.radio.pending {
+		pending.timer.Stop()
+		delete(s.radio.pending, id)
+	}
+	if count > 0 {
+		s.logger.Info(fmt.Sprintf("Cancelled %d simulated radio calls", count))
+	}
+	return count
+}
+
+// validateRadioCall checks a call before it's scheduled. Must be called with the radio lock
+// held.
+func (s *Service) validateRadioCall(call RadioCall) error {
+	if !s.radio.frequencies[call.FrequencyID] {
+		return fmt.Errorf("%w: unknown frequency_id %q", ErrInvalidRadioCall, call.FrequencyID)
+	}
+	if strings.TrimSpace(call.Text) == "" {
+		return fmt.Errorf("%w: text is required", ErrInvalidRadioCall)
+	}
+	if call.DelaySeconds < 0 || call.DelaySeconds > MaxRadioCallDelay.Seconds() {
+		return fmt.Errorf("%w: delay_seconds must be between 0 and %.0f", ErrInvalidRadioCall, MaxRadioCallDelay.Seconds())
+	}
+	if call.Hex != "" {
+		s.mutex.RLock()
+		_, exists := s.aircraft[call.Hex]
+		s.mutex.RUnlock()
+		if !exists {
+			return fmt.Errorf("%w: %s is not a simulated aircraft", ErrInvalidRadioCall, call.Hex)
+		}
+	}
+	return nil
+}
+
+// scheduleRadioCall starts the timer of a call. Must be called with the radio lock held.
+func (s *Service) scheduleRadioCall(call RadioCall, now time.Time) PendingRadioCall {
+	s.radio.nextID++
+	delay := time.Duration(call.DelaySeconds * float64(time.Second))
+	pending := &pendingCall{call: PendingRadioCall{
+		ID:        s.radio.nextID,
+		RadioCall: call,
+		At:        now.Add(delay),
+	}}
+	id := pending.call.ID
+	pending.timer = time.AfterFunc(delay, func() {
+		s.radio.mu.Lock()
+		_, scheduled := s.radio.pending[id]
+		delete(s.radio.pending, id)
+		s.radio.mu.Unlock()
+		if scheduled {
+			s.transmitRadioCall(call)
+		}
+	})
+	s.radio.pending[id] = pending
+	return pending.call
+}
+
+// queueRadioCalls schedules generated calls, dropping them if the radio simulation isn't set
+// up or too many calls are pending
+func (s *Service) queueRadioCalls(calls []RadioCall) {
+	s.radio.mu.Lock()
+	defer s.radio.mu.Unlock()
+	if s.radio.storage == nil || len(s.radio.pending)+len(calls) > maxPendingCalls {
+		return
+	}
+
+	now := time.Now().UTC()
+	for _, call := range calls {
+		if s.radio.frequencies[call.FrequencyID] {
+			s.scheduleRadioCall(call, now)
+		}
+	}
+}
+
+// transmitRadioCall stores a call as a transcription that ends now and broadcasts it
+func (s *Service) transmitRadioCall(call RadioCall) {
+	text := call.Text
+	if call.Hex != "" {
+		s.mutex.RLock()
+		aircraft, exists := s.aircraft[call.Hex]
+		if exists {
+			text = fillRadioText(text, aircraft)
+		}
+		s.mutex.RUnlock()
+		if !exists {
+			s.logger.Warn(fmt.Sprintf("Dropped simulated radio call: aircraft %s is gone", call.Hex))
+			return
+		}
+	}
+
+	s.radio.mu.Lock()
+	storage, wsServer := s.radio.storage, s.radio.wsServer
+	s.radio.mu.Unlock()
+
+	record := radioTranscription(call.FrequencyID, text, time.Now().UTC())
+	id, err := storage.StoreTranscription(record)
+	if err != nil {
+		s.logger.Error("Failed to store simulated radio call", logger.Error(err))
+		return
+	}
+	record.ID = id
+
+	s.logger.Debug(fmt.Sprintf("Transmitted simulated radio call id=%d frequency=%s text=%q", id, call.FrequencyID, text))
+
+	if wsServer != nil {
+		wsServer.Broadcast(&websocket.Message{
+			Type: "transcription",
+			Data: map[string]interface{}{
+				"id":                record.ID,
+				"frequency_id":      record.FrequencyID,
+				"text":              record.Content,
+				"timestamp":         record.CreatedAt,
+				"is_complete":       true,
+				"is_processed":      false,
+				"content_processed": "",
+				"correlation_id":    record.CorrelationID,
+				"audio_start":       record.AudioStart,
+				"audio_end":         record.AudioEnd,
+				"words":             record.Words,
+				"simulated":         true,
+			},
+		})
+	}
+}
+
+// radioTranscription builds the transcription of a call that ended at end, with the audio
+// span and word timings of the call spoken at an even pace
+func radioTranscription(frequencyID, text string, end time.Time) *sqlite.TranscriptionRecord {
+	fields := strings.Fields(text)
+	duration := time.Duration(float64(len(fields)) / radioWordsPerSec * float64(time.Second))
+	start := end.Add(-duration)
+
+	words := make([]sqlite.TranscriptionWord, 0, len(fields))
+	wordDuration := time.Duration(0)
+	if len(fields) > 0 {
+		wordDuration = duration / time.Duration(len(fields))
+	}
+	for i, field := range fields {
+		wordStart := start.Add(time.Duration(i) * wordDuration)
+		words = append(words, sqlite.TranscriptionWord{
+			Word:  field,
+			Start: wordStart,
+			End:   wordStart.Add(wordDuration),
+		})
+	}
+
+	confidence := 1.0
+	return &sqlite.TranscriptionRecord{
+		FrequencyID:   frequencyID,
+		CreatedAt:     end,
+		Content:       text,
+		IsComplete:    true,
+		IsProcessed:   false,
+		CorrelationID: logger.NewCorrelationID("sim"),
+		AudioStart:    &start,
+		AudioEnd:      &end,
+		Confidence:    &confidence,
+		Words:         words,
+	}
+}
+
+// fillRadioText replaces the placeholders of a call with the state of an aircraft. Must be
+// called with the lock held.
+func fillRadioText(text string, aircraft *SimulatedAircraft) string {
+	runway := ""
+	if aircraft.Autopilot != nil {
+		runway = aircraft.Autopilot.Runway
+	}
+	return strings.NewReplacer(
+		"{callsign}", spokenCallsign(aircraft.Flight),
+		"{flight}", aircraft.Flight,
+		"{altitude}", fmt.Sprintf("%.0f", math.Round(aircraft.CurrentAltitude/100)*100),
+		"{heading}", fmt.Sprintf("%03.0f", normalizeHeading(math.Round(magneticHeading(aircraft)))),
+		"{speed}", fmt.Sprintf("%.0f", aircraft.TargetSpeed),
+		"{runway}", runway,
+	).Replace(text)
+}
+
+// spokenCallsign returns how a callsign is said on the radio, or the callsign itself if
+// the airline is unknown
+func spokenCallsign(flight string) string {
+	if spoken := adsb.Telephony(flight); spoken != "" {
+		return spoken
+	}
+	return flight
+}
@@ -0,0 +1,596 @@
+package simulation
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/yegors/co-atc/internal/adsb"
+	"github.com/yegors/co-atc/internal/storage/sqlite"
+	"github.com/yegors/co-atc/internal/websocket"
+)
+
+// Replay states
+const (
+	ReplayStateIdle     = "idle"     // No replay loaded
+	ReplayStatePlaying  = "playing"  // Replay clock running
+	ReplayStatePaused   = "paused"   // Replay clock stopped
+	ReplayStateFinished = "finished" // Replay clock reached the end of the window
+)
+
+const (
+	MaxReplayWindow = 2 * time.Hour // Longest time window that can be replayed
+	MinReplaySpeed  = 0.25          // Slowest replay speed (quarter time)
+	MaxReplaySpeed  = 32.0          // Fastest replay speed
+
+	maxReplayTrackPoints    = 200000 // Positions loaded for one replay
+	maxReplayTranscriptions = 5000   // Transcriptions loaded for one replay
+	replayTickInterval      = 500 * time.Millisecond
+	replayInterpolationGap  = 2 * time.Minute // Longest gap between positions that is interpolated
+	replayStationarySpeed   = 5.0             // Aircraft slower than this (kts) hold position over longer gaps
+	replayWeatherLookback   = 3 * time.Hour   // How far before the window the METAR in effect is looked for
+)
+
+var (
+	ErrReplayUnavailable = errors.New("replay is not available")
+	ErrNoReplay          = errors.New("no replay loaded")
+	ErrInvalidReplay     = errors.New("invalid replay")
+	ErrReplayState       = errors.New("replay can't do that now")
+)
+
+// replayTrack is the recorded track of one aircraft, under the hex it is replayed with
+type replayTrack struct {
+	hex         string
+	originalHex string
+	points      []sqlite.TrackPoint // Oldest first
+}
+
+// replay is a loaded time window and its replay clock. The clock advances with wall time
+// times the speed while playing.
+type replay struct {
+	start          time.Time
+	end            time.Time
+	speed          float64
+	state          string
+	anchorPosition time.Time // Replay time at anchorWall
+	anchorWall     time.Time
+
+	tracks         map[string]*replayTrack // By replay hex
+	transcriptions []*sqlite.TranscriptionRecord
+	weather        []*sqlite.WeatherObservationRecord
+	nextTranscript int    // Index of the next transcription to broadcast
+	weatherIndex   int    // Index of the METAR last broadcast (-1 = none yet)
+	broadcasted    int    // Transcriptions broadcast since the replay was loaded
+	cancel         func() // Stops the replay's event loop
+}
+
+// ReplayAircraft maps a replayed aircraft to the hex it was recorded under
+type ReplayAircraft struct {
+	Hex         string    `json:"hex"`          // Hex the aircraft is replayed with
+	OriginalHex string    `json:"original_hex"` // Hex it was recorded under
+	Flight      string    `json:"flight"`
+	FirstSeen   time.Time `json:"first_seen"`
+	LastSeen    time.Time `json:"last_seen"`
+}
+
+// ReplayStatus describes the loaded replay
+type ReplayStatus struct {
+	State                  string                           `json:"state"`
+	Start                  *time.Time                       `json:"start,omitempty"`
+	End                    *time.Time                       `json:"end,omitempty"`
+	Position               *time.Time                       `json:"position,omitempty"` // Replay clock
+	Speed                  float64                          `json:"speed,omitempty"`
+	Progress               float64                          `json:"progress"` // Share of the window replayed (0-1)
+	Aircraft               []ReplayAircraft                 `json:"aircraft"`
+	Transcriptions         int                              `json:"transcriptions"`          // Transcriptions in the window
+	TranscriptionsReplayed int                              `json:"transcriptions_replayed"` // Transcriptions broadcast so far
+	Weather                *sqlite.WeatherObservationRecord `json:"weather,omitempty"`       // METAR in effect at the replay clock
+}
+
+// replaySources are the stores a replay reads history from
+type replaySources struct {
+	aircraft       *sqlite.AircraftStorage
+	transcriptions *sqlite.TranscriptionStorage
+	weather        *sqlite.WeatherHistoryStorage
+	airport        string
+	wsServer       *websocket.Server
+}
+
+// replayState is the replay part of the service
+type replayState struct {
+	sources *replaySources
+	current *replay
+	mu      sync.Mutex
+}
+
+// SetReplaySources enables replays of the tracks, transcriptions and METARs in the given
+// stores. Replayed events are broadcast to wsServer.
+func (s *Service) SetReplaySources(aircraft *sqlite.AircraftStorage, transcriptions *sqlite.TranscriptionStorage,
+	weather *sqlite.WeatherHistoryStorage, airport string, wsServer *websocket.Server) {
+	s.replay.mu.Lock()
+	defer s.replay.mu.Unlock()
+	s.replay.sources = &replaySources{
+		aircraft:       aircraft,
+		transcriptions: transcriptions,
+		weather:        weather,
+		airport:        airport,
+		wsServer:       wsServer,
+	}
+}
+
+// StartReplay loads a past time window and starts replaying it at speed. Recorded aircraft
+// are fed into the ADS-B pipeline under new hex codes, next to live traffic, and recorded
+// transcriptions and METARs are broadcast as the replay clock passes them. A replay already
+// loaded is stopped first.
+func (s *Service) StartReplay(start, end time.Time, speed float64) (*ReplayStatus, error) {
+	if err := validateReplayWindow(start, end, speed); err != nil {
+		return nil, err
+	}
+
+	s.replay.mu.Lock()
+	sources := s.replay.sources
+	s.replay.mu.Unlock()
+	if sources == nil {
+		return nil, ErrReplayUnavailable
+	}
+
+	r, err := s.loadReplay(sources, start.UTC(), end.UTC())
+	if err != nil {
+		return nil, err
+	}
+	r.speed = speed
+
+	s.StopReplay()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	r.cancel = cancel
+	r.setPosition(r.start, ReplayStatePlaying)
+
+	s.replay.mu.Lock()
+	s.replay.current = r
+	s.replay.mu.Unlock()
+
+	go s.replayLoop(ctx, r)
+
+	s.logger.Info(fmt.Sprintf("Started replay start=%s end=%s speed=%.2f aircraft=%d transcriptions=%d",
+		r.start.Format(time.RFC3339), r.end.Format(time.RFC3339), speed, len(r.tracks), len(r.transcriptions)))
+
+	status := s.ReplayStatus()
+	s.broadcastReplayStatus(sources, status)
+	return &status, nil
+}
+
+// PauseReplay stops the replay clock; replayed aircraft hold their positions
+func (s *Service) PauseReplay() (*ReplayStatus, error) {
+	return s.updateReplay(func(r *replay, now time.Time) error {
+		if r.state != ReplayStatePlaying {
+			return fmt.Errorf("%w: replay is %s", ErrReplayState, r.state)
+		}
+		r.setPosition(r.positionAt(now), ReplayStatePaused)
+		return nil
+	})
+}
+
+// ResumeReplay restarts a paused replay, or a finished one from the start
+func (s *Service) ResumeReplay() (*ReplayStatus, error) {
+	return s.updateReplay(func(r *replay, now time.Time) error {
+		switch r.state {
+		case ReplayStatePaused:
+			r.setPosition(r.positionAt(now), ReplayStatePlaying)
+		case ReplayStateFinished:
+			r.seek(r.start)
+			r.setPosition(r.start, ReplayStatePlaying)
+		default:
+			return fmt.Errorf("%w: replay is %s", ErrReplayState, r.state)
+		}
+		return nil
+	})
+}
+
+// SetReplaySpeed changes the replay speed without moving the replay clock
+func (s *Service) SetReplaySpeed(speed float64) (*ReplayStatus, error) {
+	if speed < MinReplaySpeed || speed > MaxReplaySpeed {
+		return nil, fmt.Errorf("%w: speed must be between %g and %g", ErrInvalidReplay, MinReplaySpeed, MaxReplaySpeed)
+	}
+	return s.updateReplay(func(r *replay, now time.Time) error {
+		r.setPosition(r.positionAt(now), r.state)
+		r.speed = speed
+		return nil
+	})
+}
+
+// SeekReplay moves the replay clock within the window. Transcriptions before the new
+// position are not broadcast again.
+func (s *Service) SeekReplay(position time.Time) (*ReplayStatus, error) {
+	return s.updateReplay(func(r *replay, now time.Time) error {
+		if position.Before(r.start) || position.After(r.end) {
+			return fmt.Errorf("%w: position must be between %s and %s", ErrInvalidReplay, r.start.Format(time.RFC3339), r.end.Format(time.RFC3339))
+		}
+		state := r.state
+		if state == ReplayStateFinished {
+			state = ReplayStatePaused
+		}
+		r.seek(position.UTC())
+		r.setPosition(position.UTC(), state)
+		return nil
+	})
+}
+
+// StopReplay unloads the replay. Replayed aircraft stop reporting and age out like any
+// other aircraft.
+func (s *Service) StopReplay() bool {
+	s.replay.mu.Lock()
+	r := s.replay.current
+	s.replay.current = nil
+	sources := s.replay.sources
+	s.replay.mu.Unlock()
+
+	if r == nil {
+		return false
+	}
+	r.cancel()
+	s.logger.Info("Stopped replay")
+	s.broadcastReplayStatus(sources, ReplayStatus{State: ReplayStateIdle, Aircraft: []ReplayAircraft{}})
+	return true
+}
+
+// ReplayStatus returns the state of the replay
+func (s *Service) ReplayStatus() ReplayStatus {
+	s.replay.mu.Lock()
+	defer s.replay.mu.Unlock()
+
+	r := s.replay.current
+	if r == nil {
+		return ReplayStatus{State: ReplayStateIdle, Aircraft: []ReplayAircraft{}}
+	}
+
+	position := r.positionAt(time.Now())
+	start, end := r.start, r.end
+	status := ReplayStatus{
+		State:                  r.state,
+		Start:                  &start,
+		End:                    &end,
+		Position:               &position,
+		Speed:                  r.speed,
+		Progress:               float64(position.Sub(r.start)) / float64(r.end.Sub(r.start)),
+		Aircraft:               make([]ReplayAircraft, 0, len(r.tracks)),
+		Transcriptions:         len(r.transcriptions),
+		TranscriptionsReplayed: r.broadcasted,
+	}
+	for _, track := range r.tracks {
+		status.Aircraft = append(status.Aircraft, ReplayAircraft{
+			Hex:         track.hex,
+			OriginalHex: track.originalHex,
+			Flight:      track.points[0].Flight,
+			FirstSeen:   track.points[0].Timestamp,
+			LastSeen:    track.points[len(track.points)-1].Timestamp,
+		})
+	}
+	sort.Slice(status.Aircraft, func(i, j int) bool {
+		return status.Aircraft[i].FirstSeen.Before(status.Aircraft[j].FirstSeen)
+	})
+	if i := r.weatherAt(position); i >= 0 {
+		status.Weather = r.weather[i]
+	}
+	return status
+}
+
+// isReplayed reports whether a hex belongs to a replayed aircraft
+func (s *Service) isReplayed(hex string) bool {
+	s.replay.mu.Lock()
+	defer s.replay.mu.Unlock()
+	if s.replay.current == nil {
+		return false
+	}
+	_, exists := s.replay.current.tracks[hex]
+	return exists
+}
+
+// replayTargets returns the ADS-B data of the replayed aircraft at the replay clock
+func (s *Service) replayTargets() []adsb.ADSBTarget {
+	s.replay.mu.Lock()
+	defer s.replay.mu.Unlock()
+
+	r := s.replay.current
+	if r == nil {
+		return nil
+	}
+
+	position := r.positionAt(time.Now())
+	targets := make([]adsb.ADSBTarget, 0)
+	for _, track := range r.tracks {
+		if target, ok := track.targetAt(position); ok {
+			targets = append(targets, target)
+		}
+	}
+	return targets
+}
+
+// updateReplay applies a change to the loaded replay and broadcasts the new status
+func (s *Service) updateReplay(change func(r *replay, now time.Time) error) (*ReplayStatus, error) {
+	s.replay.mu.Lock()
+	r := s.replay.current
+	sources := s.replay.sources
+	if r == nil {
+		s.replay.mu.Unlock()
+		return nil, ErrNoReplay
+	}
+	err := change(r, time.Now())
+	s.replay.mu.Unlock()
+	if err != nil {
+		return nil, err
+	}
+
+	status := s.ReplayStatus()
+	s.broadcastReplayStatus(sources, status)
+	return &status, nil
+}
+
+// loadReplay reads the tracks, transcriptions and METARs of a window
+func (s *Service) loadReplay(sources *replaySources, start, end time.Time) (*replay, error) {
+	points, err := sources.aircraft.GetTrackPoints(start, end, maxReplayTrackPoints+1)
+	if err != nil {
+		return nil, err
+	}
+	if len(points) > maxReplayTrackPoints {
+		return nil, fmt.Errorf("%w: too many positions in the window (over %d), choose a shorter one", ErrInvalidReplay, maxReplayTrackPoints)
+	}
+
+	r := &replay{
+		start:        start,
+		end:          end,
+		state:        ReplayStatePaused,
+		tracks:       make(map[string]*replayTrack),
+		weatherIndex: -1,
+	}
+
+	byOriginal := make(map[string]*replayTrack)
+	s.mutex.Lock()
+	for _, point := range points {
+		track, exists := byOriginal[point.Hex]
+		if !exists {
+			hex := s.generateUniqueHex()
+			for r.tracks[hex] != nil {
+				hex = s.generateUniqueHex()
+			}
+			track = &replayTrack{hex: hex, originalHex: point.Hex}
+			byOriginal[point.Hex] = track
+			r.tracks[hex] = track
+		}
+		track.points = append(track.points, point)
+	}
+	s.mutex.Unlock()
+
+	if sources.transcriptions != nil {
+		var afterID int64
+		for len(r.transcriptions) < maxReplayTranscriptions {
+			page, err := sources.transcriptions.GetTranscriptionsForExport(start, end, afterID, 500)
+			if err != nil {
+				return nil, err
+			}
+			for _, record := range page {
+				if record.IsComplete {
+					r.transcriptions = append(r.transcriptions, record)
+				}
+			}
+			if len(page) < 500 {
+				break
+			}
+			afterID = page[len(page)-1].ID
+		}
+		sort.SliceStable(r.transcriptions, func(i, j int) bool {
+			return r.transcriptions[i].CreatedAt.Before(r.transcriptions[j].CreatedAt)
+		})
+	}
+
+	if sources.weather != nil {
+		observations, err := sources.weather.ListObservations(sources.airport, start.Add(-replayWeatherLookback))
+		if err != nil {
+			return nil, err
+		}
+		for _, observation := range observations {
+			if !observation.ObservedAt.After(end) {
+				r.weather = append(r.weather, observation)
+			}
+		}
+	}
+
+	return r, nil
+}
+
+// replayLoop broadcasts the transcriptions and METARs the replay clock passes, and ends the
+// replay at the end of its window
+func (s *Service) replayLoop(ctx context.Context, r *replay) {
+	ticker := time.NewTicker(replayTickInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		s.replay.mu.Lock()
+		sources := s.replay.sources
+		position := r.positionAt(time.Now())
+
+		var messages []*websocket.Message
+		for r.nextTranscript < len(r.transcriptions) && !r.transcriptions[r.nextTranscript].CreatedAt.After(position) {
+			messages = append(messages, replayTranscriptionMessage(r.transcriptions[r.nextTranscript]))
+			r.nextTranscript++
+			r.broadcasted++
+		}
+		if i := r.weatherAt(position); i != r.weatherIndex {
+			r.weatherIndex = i
+			if i >= 0 {
+				messages = append(messages, &websocket.Message{
+					Type: "replay_weather",
+					Data: map[string]interface{}{
+						"replay":      true,
+						"observation": r.weather[i],
+					},
+				})
+			}
+		}
+		finished := false
+		if r.state == ReplayStatePlaying && !position.Before(r.end) {
+			r.setPosition(r.end, ReplayStateFinished)
+			finished = true
+		}
+		s.replay.mu.Unlock()
+
+		if sources.wsServer != nil {
+			for _, message := range messages {
+				sources.wsServer.Broadcast(message)
+			}
+		}
+		if finished {
+			s.logger.Info("Replay finished")
+			s.broadcastReplayStatus(sources, s.ReplayStatus())
+		}
+	}
+}
+
+// broadcastReplayStatus tells web clients about a replay state change
+func (s *Service) broadcastReplayStatus(sources *replaySources, status ReplayStatus) {
+	if sources == nil || sources.wsServer == nil {
+		return
+	}
+	sources.wsServer.Broadcast(&websocket.Message{
+		Type: "replay_status",
+		Data: map[string]interface{}{
+			"replay": true,
+			"status": status,
+		},
+	})
+}
+
+// replayTranscriptionMessage builds the transcription message of a recorded transcription,
+// marked as replayed so clients and downstream feeds can tell it from live traffic
+func replayTranscriptionMessage(record *sqlite.TranscriptionRecord) *websocket.Message {
+	return &websocket.Message{
+		Type: "transcription",
+		Data: map[string]interface{}{
+			"id":                record.ID,
+			"frequency_id":      record.FrequencyID,
+			"text":              record.Content,
+			"timestamp":         record.CreatedAt,
+			"is_complete":       true,
+			"is_processed":      record.IsProcessed,
+			"content_processed": record.ContentProcessed,
+			"speaker_type":      record.SpeakerType,
+			"callsign":          record.Callsign,
+			"correlation_id":    record.CorrelationID,
+			"replay":            true,
+		},
+	}
+}
+
+// validateReplayWindow checks the window and speed of a new replay
+func validateReplayWindow(start, end time.Time, speed float64) error {
+	if !end.After(start) {
+		return fmt.Errorf("%w: end must be after start", ErrInvalidReplay)
+	}
+	if end.Sub(start) > MaxReplayWindow {
+		return fmt.Errorf("%w: window must be at most %s", ErrInvalidReplay, MaxReplayWindow)
+	}
+	if end.After(time.Now()) {
+		return fmt.Errorf("%w: end must be in the past", ErrInvalidReplay)
+	}
+	if speed < MinReplaySpeed || speed > MaxReplaySpeed {
+		return fmt.Errorf("%w: speed must be between %g and %g", ErrInvalidReplay, MinReplaySpeed, MaxReplaySpeed)
+	}
+	return nil
+}
+
+// positionAt returns the replay clock at a wall time
+func (r *replay) positionAt(now time.Time) time.Time {
+	position := r.anchorPosition
+	if r.state == ReplayStatePlaying {
+		position = position.Add(time.Duration(float64(now.Sub(r.anchorWall)) * r.speed))
+	}
+	if position.After(r.end) {
+		position = r.end
+	}
+	return position
+}
+
+// setPosition restarts the replay clock from a position in a state
+func (r *replay) setPosition(position time.Time, state string) {
+	r.anchorPosition = position
+	r.anchorWall = time.Now()
+	r.state = state
+}
+
+// seek moves the transcription and METAR cursors to a position
+func (r *replay) seek(position time.Time) {
+	r.nextTranscript = sort.Search(len(r.transcriptions), func(i int) bool {
+		return r.transcriptions[i].CreatedAt.After(position)
+	})
+	r.weatherIndex = -1 // Broadcast the METAR in effect again
+}
+
+// weatherAt returns the index of the METAR in effect at a position, or -1
+func (r *replay) weatherAt(position time.Time) int {
+	return sort.Search(len(r.weather), func(i int) bool {
+		return r.weather[i].ObservedAt.After(position)
+	}) - 1
+}
+
+// targetAt returns the aircraft's ADS-B data at a position, interpolated between the
+// recorded positions around it. Positions are only stored when they change, so aircraft
+// standing still hold their last position; moving aircraft are left out before their first
+// and after their last position, and within gaps too long to interpolate.
+func (t *replayTrack) targetAt(position time.Time) (adsb.ADSBTarget, bool) {
+	points := t.points
+	i := sort.Search(len(points), func(i int) bool { return points[i].Timestamp.After(position) })
+	if i == 0 {
+		return adsb.ADSBTarget{}, false
+	}
+	prev := points[i-1]
+
+	lat, lon, alt := prev.Lat, prev.Lon, prev.AltBaro
+	if i < len(points) {
+		next := points[i]
+		gap := next.Timestamp.Sub(prev.Timestamp)
+		if gap > replayInterpolationGap {
+			if prev.GS >= replayStationarySpeed {
+				return adsb.ADSBTarget{}, false
+			}
+		} else if gap > 0 {
+			f := float64(position.Sub(prev.Timestamp)) / float64(gap)
+			lat += (next.Lat - prev.Lat) * f
+			lon += (next.Lon - prev.Lon) * f
+			alt += (next.AltBaro - prev.AltBaro) * f
+		}
+	} else if position.After(prev.Timestamp) && (prev.GS >= replayStationarySpeed || position.After(prev.LastSeen)) {
+		// Past the last position; only aircraft that stood still until last seen stay
+		return adsb.ADSBTarget{}, false
+	}
+
+	return adsb.ADSBTarget{
+		Hex:          t.hex,
+		Type:         adsb.TargetTypeReplay,
+		Flight:       prev.Flight,
+		Registration: prev.Registration,
+		AircraftType: prev.AircraftType,
+		Category:     prev.Category,
+		Squawk:       prev.Squawk,
+		Lat:          lat,
+		Lon:          lon,
+		AltBaro:      alt,
+		AltGeom:      alt,
+		GS:           prev.GS,
+		TAS:          prev.TAS,
+		Track:        prev.Track,
+		TrueHeading:  prev.Track,
+		BaroRate:     prev.BaroRate,
+		Seen:         0,
+		Messages:     100,
+		RSSI:         -20,
+	}, true
+}
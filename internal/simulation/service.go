@@ -8,32 +8,56 @@ import (
 	"time"
 
 	"github.com/yegors/co-atc/internal/adsb"
+	"github.com/yegors/co-atc/internal/geomag"
 	"github.com/yegors/co-atc/pkg/logger"
 )
 
 const (
 	MaxSimulatedAircraft = 10 // Hardcoded maximum number of simulated aircraft
+
+	// DefaultEnduranceMinutes is the fuel a simulated aircraft starts with, as time at cruise burn
+	DefaultEnduranceMinutes = 120.0
+)
+
+// Fuel scenarios for training
+const (
+	FuelScenarioNormal    = "normal"         // Refuel to the default endurance
+	FuelScenarioMinimum   = "minimum_fuel"   // Leave 10 minutes above the final reserve
+	FuelScenarioEmergency = "emergency_fuel" // Leave 5 minutes less than the final reserve
 )
 
 // SimulatedAircraft represents a single simulated aircraft with its current state
 type SimulatedAircraft struct {
-	Hex                string    `json:"hex"`
-	Flight             string    `json:"flight"`
-	AircraftType       string    `json:"aircraft_type"`
-	CurrentLat         float64   `json:"current_lat"`
-	CurrentLon         float64   `json:"current_lon"`
-	CurrentAltitude    float64   `json:"current_altitude"`
-	TargetHeading      float64   `json:"target_heading"`
-	TargetSpeed        float64   `json:"target_speed"`
-	TargetVerticalRate float64   `json:"target_vertical_rate"`
-	LastUpdate         time.Time `json:"last_update"`
-	CreatedAt          time.Time `json:"created_at"`
+	Hex                string     `json:"hex"`
+	Flight             string     `json:"flight"`
+	AircraftType       string     `json:"aircraft_type"`
+	CurrentLat         float64    `json:"current_lat"`
+	CurrentLon         float64    `json:"current_lon"`
+	CurrentAltitude    float64    `json:"current_altitude"`
+	TargetHeading      float64    `json:"target_heading"`
+	TargetSpeed        float64    `json:"target_speed"`
+	TargetVerticalRate float64    `json:"target_vertical_rate"`
+	FuelRemainingKg    float64    `json:"fuel_remaining_kg"`
+	FuelBurnedKg       float64    `json:"fuel_burned_kg"`
+	FuelBurnKgPerHour  float64    `json:"fuel_burn_kg_per_hour"`
+	EnduranceMinutes   float64    `json:"endurance_minutes"`   // Time until the tanks are dry at the current burn
+	FuelState          string     `json:"fuel_state"`          // "normal", "minimum_fuel" or "emergency_fuel"
+	Autopilot          *Autopilot `json:"autopilot,omitempty"` // nil = manual control
+	Generated          string     `json:"generated,omitempty"` // "arrival" or "departure" for generated traffic
+	LastUpdate         time.Time  `json:"last_update"`
+	CreatedAt          time.Time  `json:"created_at"`
+
+	fuelProfile adsb.FuelProfile
 }
 
 // Service manages simulated aircraft
 type Service struct {
 	aircraft map[string]*SimulatedAircraft
 	mutex    sync.RWMutex
+	airport  airport      // Runways and elevation for autopilot approaches
+	replay   replayState  // Replay of recorded history
+	traffic  trafficState // Generated background traffic
+	radio    radioState   // Simulated radio calls
 	logger   *logger.Logger
 }
 
@@ -45,24 +69,43 @@ func NewService(logger *logger.Logger) *Service {
 	}
 }
 
-// CreateAircraft creates a new simulated aircraft
-func (s *Service) CreateAircraft(lat, lon, altitude, heading, speed, verticalRate float64) (*SimulatedAircraft, error) {
+// CreateAircraft creates a new simulated aircraft. An empty aircraft type creates a generic
+// "SIM" aircraft; an endurance of zero fuels it for DefaultEnduranceMinutes.
+func (s *Service) CreateAircraft(lat, lon, altitude, heading, speed, verticalRate float64, aircraftType string, enduranceMinutes float64) (*SimulatedAircraft, error) {
 	s.mutex.Lock()
 	defer s.mutex.Unlock()
 
-	// Check if we've reached the maximum
-	if len(s.aircraft) >= MaxSimulatedAircraft {
+	// Check if we've reached the maximum; generated traffic has its own limit
+	if s.countAircraft("") >= MaxSimulatedAircraft {
 		return nil, fmt.Errorf("maximum number of simulated aircraft (%d) reached", MaxSimulatedAircraft)
 	}
 
-	// Generate unique identifiers
+	aircraft := s.addAircraft(lat, lon, altitude, heading, speed, verticalRate, aircraftType, enduranceMinutes, s.generateFlightNumber())
+	s.logger.Info(fmt.Sprintf("Created simulated aircraft hex=%s flight=%s lat=%.6f lon=%.6f", aircraft.Hex, aircraft.Flight, lat, lon))
+
+	return aircraft, nil
+}
+
+// addAircraft creates a simulated aircraft under a new hex code. Must be called with the
+// lock held.
+func (s *Service) addAircraft(lat, lon, altitude, heading, speed, verticalRate float64, aircraftType string, enduranceMinutes float64, flight string) *SimulatedAircraft {
 	hex := s.generateUniqueHex()
-	flight := s.generateFlightNumber()
+
+	if aircraftType == "" {
+		aircraftType = "SIM"
+	}
+	profile, ok := adsb.FuelProfileFor(aircraftType)
+	if !ok {
+		profile = adsb.DefaultFuelProfile(aircraftType)
+	}
+	if enduranceMinutes <= 0 {
+		enduranceMinutes = DefaultEnduranceMinutes
+	}
 
 	aircraft := &SimulatedAircraft{
 		Hex:                hex,
 		Flight:             flight,
-		AircraftType:       "SIM",
+		AircraftType:       profile.AircraftType,
 		CurrentLat:         lat,
 		CurrentLon:         lon,
 		CurrentAltitude:    altitude,
@@ -71,15 +114,29 @@ func (s *Service) CreateAircraft(lat, lon, altitude, heading, speed, verticalRat
 		TargetVerticalRate: verticalRate,
 		LastUpdate:         time.Now().UTC(),
 		CreatedAt:          time.Now().UTC(),
+		FuelRemainingKg:    profile.FuelForEndurance(time.Duration(enduranceMinutes * float64(time.Minute))),
+		fuelProfile:        profile,
 	}
+	s.updateFuelState(aircraft)
 
 	s.aircraft[hex] = aircraft
-	s.logger.Info(fmt.Sprintf("Created simulated aircraft hex=%s flight=%s lat=%.6f lon=%.6f", hex, flight, lat, lon))
+	return aircraft
+}
 
-	return aircraft, nil
+// countAircraft counts the simulated aircraft of a kind of generated traffic ("" for
+// aircraft created through the API). Must be called with the lock held.
+func (s *Service) countAircraft(generated string) int {
+	count := 0
+	for _, aircraft := range s.aircraft {
+		if aircraft.Generated == generated {
+			count++
+		}
+	}
+	return count
 }
 
-// UpdateControls updates the control parameters for a simulated aircraft
+// UpdateControls updates the control parameters for a simulated aircraft, disengaging its
+// autopilot
 func (s *Service) UpdateControls(hex string, heading, speed, verticalRate float64) error {
 	s.mutex.Lock()
 	defer s.mutex.Unlock()
@@ -92,11 +149,81 @@ func (s *Service) UpdateControls(hex string, heading, speed, verticalRate float6
 	aircraft.TargetHeading = heading
 	aircraft.TargetSpeed = speed
 	aircraft.TargetVerticalRate = verticalRate
+	aircraft.Autopilot = nil
 
 	s.logger.Debug(fmt.Sprintf("Updated simulation controls hex=%s heading=%.1f speed=%.1f vs=%.0f", hex, heading, speed, verticalRate))
 	return nil
 }
 
+// SetFuel sets the fuel of a simulated aircraft to last the given time at cruise burn
+func (s *Service) SetFuel(hex string, enduranceMinutes float64) (*SimulatedAircraft, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	aircraft, exists := s.aircraft[hex]
+	if !exists {
+		return nil, fmt.Errorf("simulated aircraft with hex %s not found", hex)
+	}
+	if enduranceMinutes < 0 {
+		return nil, fmt.Errorf("endurance must be 0 or greater: %g", enduranceMinutes)
+	}
+
+	aircraft.FuelRemainingKg = aircraft.fuelProfile.FuelForEndurance(time.Duration(enduranceMinutes * float64(time.Minute)))
+	s.updateFuelState(aircraft)
+
+	s.logger.Info(fmt.Sprintf("Set simulated fuel hex=%s endurance=%.0fmin state=%s", hex, enduranceMinutes, aircraft.FuelState))
+	return aircraft, nil
+}
+
+// ApplyFuelScenario sets the fuel of a simulated aircraft for a training scenario
+func (s *Service) ApplyFuelScenario(hex, scenario string) (*SimulatedAircraft, error) {
+	s.mutex.RLock()
+	aircraft, exists := s.aircraft[hex]
+	var reserve float64
+	if exists {
+		reserve = aircraft.fuelProfile.ReserveMinutes
+	}
+	s.mutex.RUnlock()
+	if !exists {
+		return nil, fmt.Errorf("simulated aircraft with hex %s not found", hex)
+	}
+
+	switch scenario {
+	case FuelScenarioNormal:
+		return s.SetFuel(hex, DefaultEnduranceMinutes)
+	case FuelScenarioMinimum:
+		return s.SetFuel(hex, reserve+10)
+	case FuelScenarioEmergency:
+		return s.SetFuel(hex, math.Max(0, reserve-5))
+	default:
+		return nil, fmt.Errorf("unknown fuel scenario %q (must be %q, %q or %q)",
+			scenario, FuelScenarioNormal, FuelScenarioMinimum, FuelScenarioEmergency)
+	}
+}
+
+// FuelEstimate returns the fuel state of a simulated aircraft
+func (s *Service) FuelEstimate(hex string) (*adsb.FuelEstimate, bool) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	aircraft, exists := s.aircraft[hex]
+	if !exists {
+		return nil, false
+	}
+
+	remaining := aircraft.FuelRemainingKg
+	endurance := aircraft.EnduranceMinutes
+	return &adsb.FuelEstimate{
+		Source:            "simulated",
+		AircraftType:      aircraft.AircraftType,
+		BurnRateKgPerHour: aircraft.FuelBurnKgPerHour,
+		BurnedKg:          aircraft.FuelBurnedKg,
+		RemainingKg:       &remaining,
+		EnduranceMinutes:  &endurance,
+		State:             aircraft.FuelState,
+	}, true
+}
+
 // RemoveAircraft removes a simulated aircraft
 func (s *Service) RemoveAircraft(hex string) error {
 	s.mutex.Lock()
@@ -140,15 +267,33 @@ func (s *Service) UpdatePositions() {
 	now := time.Now().UTC()
 	for _, aircraft := range s.aircraft {
 		deltaTime := now.Sub(aircraft.LastUpdate).Seconds()
-		if deltaTime > 0 {
+		if deltaTime <= 0 {
+			continue
+		}
+		if aircraft.Autopilot == nil {
 			s.updateAircraftPosition(aircraft, deltaTime)
-			aircraft.LastUpdate = now
+		} else {
+			// Fly in short steps so turns and level-offs follow the autopilot
+			for deltaTime > 0 {
+				step := math.Min(deltaTime, autopilotStep)
+				s.flyAutopilot(aircraft, step)
+				s.updateAircraftPosition(aircraft, step)
+				deltaTime -= step
+			}
 		}
+		aircraft.LastUpdate = now
 	}
 }
 
-// GenerateADSBData generates ADSB data for all simulated aircraft
+// GenerateADSBData generates ADSB data for all simulated aircraft, and for the aircraft of
+// the replay at the replay clock
 func (s *Service) GenerateADSBData() []adsb.ADSBTarget {
+	targets := s.generateSimulatedTargets()
+	return append(targets, s.replayTargets()...)
+}
+
+// generateSimulatedTargets generates ADSB data for the simulated aircraft
+func (s *Service) generateSimulatedTargets() []adsb.ADSBTarget {
 	s.mutex.RLock()
 	defer s.mutex.RUnlock()
 
@@ -156,7 +301,7 @@ func (s *Service) GenerateADSBData() []adsb.ADSBTarget {
 	for _, aircraft := range s.aircraft {
 		target := adsb.ADSBTarget{
 			Hex:          aircraft.Hex,
-			Type:         "sim", // Mark as simulated
+			Type:         adsb.TargetTypeSimulated, // Mark as simulated
 			Flight:       aircraft.Flight,
 			AircraftType: aircraft.AircraftType,
 			Lat:          aircraft.CurrentLat,
@@ -166,7 +311,7 @@ func (s *Service) GenerateADSBData() []adsb.ADSBTarget {
 			TAS:          aircraft.TargetSpeed,
 			GS:           aircraft.TargetSpeed, // Simplified: assume no wind
 			Track:        aircraft.TargetHeading,
-			MagHeading:   aircraft.TargetHeading,
+			MagHeading:   magneticHeading(aircraft),
 			TrueHeading:  aircraft.TargetHeading,
 			BaroRate:     aircraft.TargetVerticalRate,
 			GeomRate:     aircraft.TargetVerticalRate,
@@ -180,6 +325,12 @@ func (s *Service) GenerateADSBData() []adsb.ADSBTarget {
 	return targets
 }
 
+// magneticHeading returns the magnetic heading of a simulated aircraft, which flies true headings
+func magneticHeading(aircraft *SimulatedAircraft) float64 {
+	declination := geomag.Declination(aircraft.CurrentLat, aircraft.CurrentLon, aircraft.CurrentAltitude, time.Now())
+	return geomag.ToMagnetic(aircraft.TargetHeading, declination)
+}
+
 // updateAircraftPosition updates a single aircraft's position using dead reckoning
 func (s *Service) updateAircraftPosition(aircraft *SimulatedAircraft, deltaTime float64) {
 	// Convert heading to radians (0° = North, clockwise)
@@ -209,6 +360,36 @@ func (s *Service) updateAircraftPosition(aircraft *SimulatedAircraft, deltaTime
 		aircraft.CurrentAltitude = 0
 		aircraft.TargetVerticalRate = 0 // Stop descent at ground level
 	}
+
+	// Burn fuel for the phase of flight
+	burned := math.Min(aircraft.FuelRemainingKg, aircraft.FuelBurnKgPerHour*deltaTime/3600)
+	aircraft.FuelRemainingKg -= burned
+	aircraft.FuelBurnedKg += burned
+
+	previousState := aircraft.FuelState
+	s.updateFuelState(aircraft)
+	if aircraft.FuelState != previousState {
+		s.logger.Info(fmt.Sprintf("Simulated aircraft fuel state changed hex=%s flight=%s state=%s endurance=%.0fmin",
+			aircraft.Hex, aircraft.Flight, aircraft.FuelState, aircraft.EnduranceMinutes))
+	}
+}
+
+// updateFuelState recalculates the burn rate, endurance and fuel state of an aircraft
+func (s *Service) updateFuelState(aircraft *SimulatedAircraft) {
+	onGround := aircraft.CurrentAltitude <= math.Max(0, s.airport.elevationFeet)
+	aircraft.FuelBurnKgPerHour = aircraft.fuelProfile.BurnRate(aircraft.CurrentAltitude, aircraft.TargetVerticalRate, onGround)
+
+	aircraft.EnduranceMinutes = 0
+	if aircraft.FuelBurnKgPerHour > 0 {
+		aircraft.EnduranceMinutes = aircraft.FuelRemainingKg / aircraft.FuelBurnKgPerHour * 60
+	}
+
+	// Classify at cruise burn, so the state doesn't flap between climb and descent
+	cruiseEndurance := time.Duration(0)
+	if aircraft.fuelProfile.CruiseBurnKgHour > 0 {
+		cruiseEndurance = time.Duration(aircraft.FuelRemainingKg / aircraft.fuelProfile.CruiseBurnKgHour * float64(time.Hour))
+	}
+	aircraft.FuelState = aircraft.fuelProfile.FuelState(cruiseEndurance)
 }
 
 // generateUniqueHex generates a unique 6-character hex code
@@ -227,11 +408,11 @@ func (s *Service) generateFlightNumber() string {
 	return fmt.Sprintf("SIM%03d", rand.Intn(999)+1)
 }
 
-// IsSimulated checks if a hex code belongs to a simulated aircraft
+// IsSimulated checks if a hex code belongs to a simulated or replayed aircraft
 func (s *Service) IsSimulated(hex string) bool {
 	s.mutex.RLock()
-	defer s.mutex.RUnlock()
-
 	_, exists := s.aircraft[hex]
-	return exists
+	s.mutex.RUnlock()
+
+	return exists || s.isReplayed(hex)
 }
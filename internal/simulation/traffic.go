@@ -0,0 +1,568 @@
+package simulation
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/yegors/co-atc/internal/adsb"
+	"github.com/yegors/co-atc/internal/config"
+)
+
+// Kinds of generated traffic
+const (
+	GeneratedArrival   = "arrival"
+	GeneratedDeparture = "departure"
+)
+
+const (
+	trafficTickInterval     = time.Second
+	arrivalFixNM            = 12.0  // Distance of the fix on the extended centerline arrivals fly to
+	arrivalFixHeightFt      = 3000  // Height above the airport arrivals cross the fix at, below the glidepath
+	arrivalFixSpeed         = 190.0 // Speed arrivals cross the fix at
+	arrivalSpeed            = 250.0
+	departureSpeed          = 250.0
+	departureStraightNM     = 4.0              // Distance departures climb on the runway heading before turning
+	arrivalSeparationNM     = 5.0              // Arrivals wait while generated traffic is this close to their spawn point
+	shortFinalNM            = 3.0              // Departures wait while an arrival is this close to the runway
+	trafficRetry            = 30 * time.Second // Wait before trying again when an aircraft can't be generated yet
+	vacatedRemoveAfter      = time.Minute      // Time arrivals stay stopped off the runway before they're removed
+	maxGeneratedAircraftAge = 90 * time.Minute
+)
+
+var (
+	ErrTrafficUnavailable = errors.New("traffic generator is not available")
+	ErrInvalidTraffic     = errors.New("invalid traffic settings")
+)
+
+// trafficFleet is the airlines and types generated traffic is drawn from
+var trafficFleet = []struct {
+	airline string
+	types   []string
+}{
+	{"ACA", []string{"A320", "A321", "B38M", "A333"}},
+	{"JZA", []string{"DH8D", "CRJ9", "E75L"}},
+	{"WJA", []string{"B738", "B38M"}},
+	{"POE", []string{"DH8D", "E195"}},
+	{"TSC", []string{"A321", "A333"}},
+	{"DAL", []string{"A320", "B739", "E75L"}},
+	{"UAL", []string{"B738", "A320", "E75L"}},
+	{"AAL", []string{"A321", "B738"}},
+}
+
+// RunwayStateSource reports the runway closures and forced configuration generated traffic
+// follows
+type RunwayStateSource interface {
+	GetRunwayState() *adsb.RunwayState
+}
+
+// TrafficSettings are the settings of the traffic generator that can be changed at runtime
+type TrafficSettings struct {
+	Enabled           bool    `json:"enabled"`
+	ArrivalsPerHour   float64 `json:"arrivals_per_hour"`
+	DeparturesPerHour float64 `json:"departures_per_hour"`
+	MaxAircraft       int     `json:"max_aircraft"`
+}
+
+// TrafficUpdate changes some of the traffic settings; fields left nil are kept
+type TrafficUpdate struct {
+	Enabled           *bool    `json:"enabled"`
+	ArrivalsPerHour   *float64 `json:"arrivals_per_hour"`
+	DeparturesPerHour *float64 `json:"departures_per_hour"`
+	MaxAircraft       *int     `json:"max_aircraft"`
+}
+
+// TrafficStatus describes the traffic generator and the traffic it's flying
+type TrafficStatus struct {
+	TrafficSettings
+	Arrivals            int      `json:"arrivals"`             // Generated arrivals flying now
+	Departures          int      `json:"departures"`           // Generated departures flying now
+	ArrivalsGenerated   int      `json:"arrivals_generated"`   // Since startup
+	DeparturesGenerated int      `json:"departures_generated"` // Since startup
+	ArrivalRunways      []string `json:"arrival_runways"`      // Thresholds arrivals are generated for
+	DepartureRunways    []string `json:"departure_runways"`    // Thresholds departures are generated from
+}
+
+// generatedFlight is what the generator keeps about an aircraft it generated
+type generatedFlight struct {
+	runway    adsb.RunwayEnd
+	vacatedAt time.Time
+	lastMode  string // Autopilot mode radio calls were last made for
+}
+
+// trafficState is the traffic generator part of the service
+type trafficState struct {
+	cfg                 *config.TrafficGeneratorConfig // nil = generator not set up
+	settings            TrafficSettings
+	runways             RunwayStateSource
+	nextArrival         time.Time
+	nextDeparture       time.Time
+	arrivalsGenerated   int
+	departuresGenerated int
+	flights             map[string]*generatedFlight // By hex
+	mu                  sync.Mutex
+}
+
+// SetTrafficGenerator sets up generated background traffic on the runways in use, as given
+// by the runway configuration of runways
+func (s *Service) SetTrafficGenerator(cfg config.TrafficGeneratorConfig, runways RunwayStateSource) {
+	s.traffic.mu.Lock()
+	defer s.traffic.mu.Unlock()
+	s.traffic.cfg = &cfg
+	s.traffic.runways = runways
+	s.traffic.flights = make(map[string]*generatedFlight)
+	s.traffic.settings = TrafficSettings{
+		Enabled:           cfg.Enabled,
+		ArrivalsPerHour:   cfg.ArrivalsPerHour,
+		DeparturesPerHour: cfg.DeparturesPerHour,
+		MaxAircraft:       cfg.MaxAircraft,
+	}
+}
+
+// StartTraffic starts the traffic generator loop. It generates nothing until enabled.
+func (s *Service) StartTraffic(ctx context.Context) {
+	s.traffic.mu.Lock()
+	ready := s.traffic.cfg != nil
+	enabled := s.traffic.settings.Enabled
+	s.traffic.mu.Unlock()
+	if !ready {
+		return
+	}
+
+	if enabled {
+		s.logger.Info("Traffic generator enabled")
+	}
+
+	go func() {
+		ticker := time.NewTicker(trafficTickInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case now := <-ticker.C:
+				s.generateTraffic(now)
+			}
+		}
+	}()
+}
+
+// UpdateTraffic starts, stops or retunes the traffic generator. Stopping it leaves the
+// generated aircraft to finish their flights.
+func (s *Service) UpdateTraffic(update TrafficUpdate) (*TrafficStatus, error) {
+	s.traffic.mu.Lock()
+	if s.traffic.cfg == nil {
+		s.traffic.mu.Unlock()
+		return nil, ErrTrafficUnavailable
+	}
+
+	settings := s.traffic.settings
+	if update.Enabled != nil {
+		settings.Enabled = *update.Enabled
+	}
+	if update.ArrivalsPerHour != nil {
+		settings.ArrivalsPerHour = *update.ArrivalsPerHour
+	}
+	if update.DeparturesPerHour != nil {
+		settings.DeparturesPerHour = *update.DeparturesPerHour
+	}
+	if update.MaxAircraft != nil {
+		settings.MaxAircraft = *update.MaxAircraft
+	}
+	if settings.ArrivalsPerHour < 0 || settings.ArrivalsPerHour > 120 || settings.DeparturesPerHour < 0 || settings.DeparturesPerHour > 120 {
+		s.traffic.mu.Unlock()
+		return nil, fmt.Errorf("%w: rates must be between 0 and 120 per hour", ErrInvalidTraffic)
+	}
+	if settings.MaxAircraft < 1 || settings.MaxAircraft > 100 {
+		s.traffic.mu.Unlock()
+		return nil, fmt.Errorf("%w: max_aircraft must be between 1 and 100", ErrInvalidTraffic)
+	}
+
+	if settings != s.traffic.settings {
+		// Draw new times for the new rates
+		s.traffic.nextArrival = time.Time{}
+		s.traffic.nextDeparture = time.Time{}
+	}
+	s.traffic.settings = settings
+	s.traffic.mu.Unlock()
+
+	s.logger.Info(fmt.Sprintf("Updated traffic generator enabled=%t arrivals=%.1f/h departures=%.1f/h max=%d",
+		settings.Enabled, settings.ArrivalsPerHour, settings.DeparturesPerHour, settings.MaxAircraft))
+
+	status, err := s.TrafficStatus()
+	return &status, err
+}
+
+// TrafficStatus returns the state of the traffic generator
+func (s *Service) TrafficStatus() (TrafficStatus, error) {
+	s.traffic.mu.Lock()
+	defer s.traffic.mu.Unlock()
+	if s.traffic.cfg == nil {
+		return TrafficStatus{}, ErrTrafficUnavailable
+	}
+
+	arrivals, departures := s.trafficRunways()
+	status := TrafficStatus{
+		TrafficSettings:     s.traffic.settings,
+		ArrivalsGenerated:   s.traffic.arrivalsGenerated,
+		DeparturesGenerated: s.traffic.departuresGenerated,
+		ArrivalRunways:      runwayIDs(arrivals),
+		DepartureRunways:    runwayIDs(departures),
+	}
+
+	s.mutex.RLock()
+	status.Arrivals = s.countAircraft(GeneratedArrival)
+	status.Departures = s.countAircraft(GeneratedDeparture)
+	s.mutex.RUnlock()
+
+	return status, nil
+}
+
+// generateTraffic removes generated aircraft that are done and generates the arrivals and
+// departures that are due
+func (s *Service) generateTraffic(now time.Time) {
+	s.traffic.mu.Lock()
+	defer s.traffic.mu.Unlock()
+
+	s.removeFinishedTraffic(now)
+	if frequencyID := s.traffic.cfg.RadioFrequencyID; frequencyID != "" {
+		s.queueRadioCalls(s.trafficRadioCalls(frequencyID))
+	}
+
+	settings := s.traffic.settings
+	if !settings.Enabled {
+		return
+	}
+	arrivals, departures := s.trafficRunways()
+
+	if s.traffic.nextArrival.IsZero() {
+		s.traffic.nextArrival = nextTrafficTime(now, settings.ArrivalsPerHour)
+	}
+	if s.traffic.nextDeparture.IsZero() {
+		s.traffic.nextDeparture = nextTrafficTime(now, settings.DeparturesPerHour)
+	}
+
+	if settings.ArrivalsPerHour > 0 && !now.Before(s.traffic.nextArrival) {
+		if len(arrivals) > 0 && s.generatedCount() < settings.MaxAircraft && s.generateArrival(arrivals[rand.Intn(len(arrivals))]) {
+			s.traffic.nextArrival = nextTrafficTime(now, settings.ArrivalsPerHour)
+		} else {
+			s.traffic.nextArrival = now.Add(trafficRetry)
+		}
+	}
+	if settings.DeparturesPerHour > 0 && !now.Before(s.traffic.nextDeparture) {
+		if len(departures) > 0 && s.generatedCount() < settings.MaxAircraft && s.generateDeparture(departures[rand.Intn(len(departures))]) {
+			s.traffic.nextDeparture = nextTrafficTime(now, settings.DeparturesPerHour)
+		} else {
+			s.traffic.nextDeparture = now.Add(trafficRetry)
+		}
+	}
+}
+
+// generateArrival spawns an arrival for a runway: it appears at the spawn distance within 60°
+// of the final approach course, flies to a fix on the extended centerline, intercepts the
+// localizer, lands and vacates. It reports false if the spawn point isn't clear. Must be
+// called with the traffic lock held.
+func (s *Service) generateArrival(runway adsb.RunwayEnd) bool {
+	cfg := s.traffic.cfg
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	bearing := normalizeHeading(runway.Heading + 180 + rand.Float64()*120 - 60)
+	lat, lon := adsb.DestinationPoint(runway.Latitude, runway.Longitude, bearing, cfg.SpawnDistanceNM)
+	for hex := range s.traffic.flights {
+		if aircraft, exists := s.aircraft[hex]; exists &&
+			adsb.MetersToNM(adsb.Haversine(lat, lon, aircraft.CurrentLat, aircraft.CurrentLon)) < arrivalSeparationNM {
+			return false
+		}
+	}
+
+	fixLat, fixLon := adsb.DestinationPoint(runway.Latitude, runway.Longitude, runway.Heading+180, arrivalFixNM)
+	fixAltitude := s.airport.elevationFeet + arrivalFixHeightFt
+	fixSpeed := arrivalFixSpeed
+	interceptHeading := runway.Heading
+	aircraftType, callsign := s.generateTrafficFlight()
+
+	aircraft := s.addAircraft(lat, lon, s.airport.elevationFeet+float64(cfg.ArrivalAltitudeFt),
+		adsb.CalculateBearing(lat, lon, fixLat, fixLon), arrivalSpeed, 0, aircraftType, 0, callsign)
+	aircraft.Generated = GeneratedArrival
+	autopilot, err := s.newAutopilot(aircraft, AutopilotSettings{
+		Heading: &interceptHeading,
+		Route: []Waypoint{{
+			Name:     "F" + runway.ID,
+			Lat:      fixLat,
+			Lon:      fixLon,
+			Altitude: &fixAltitude,
+			Speed:    &fixSpeed,
+		}},
+		Runway: runway.ID,
+		Land:   true,
+	})
+	if err != nil {
+		delete(s.aircraft, aircraft.Hex)
+		s.logger.Warn(fmt.Sprintf("Failed to generate arrival runway=%s: %v", runway.ID, err))
+		return false
+	}
+	aircraft.Autopilot = autopilot
+
+	s.traffic.flights[aircraft.Hex] = &generatedFlight{runway: runway}
+	s.traffic.arrivalsGenerated++
+	s.logger.Info(fmt.Sprintf("Generated arrival hex=%s flight=%s type=%s runway=%s", aircraft.Hex, callsign, aircraftType, runway.ID))
+	return true
+}
+
+// generateDeparture spawns a departure on a runway threshold: it takes off, climbs on the
+// runway heading, turns toward a random exit and is removed at the spawn distance. It
+// reports false while the runway is busy. Must be called with the traffic lock held.
+func (s *Service) generateDeparture(runway adsb.RunwayEnd) bool {
+	cfg := s.traffic.cfg
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	// One aircraft on the runway at a time, and none on short final
+	for hex, flight := range s.traffic.flights {
+		aircraft, exists := s.aircraft[hex]
+		if !exists || aircraft.Autopilot == nil || flight.runway.Runway != runway.Runway {
+			continue
+		}
+		switch aircraft.Autopilot.Mode {
+		case AutopilotModeTakeoff, AutopilotModeRollout, AutopilotModeVacating:
+			return false
+		case AutopilotModeLocalizer:
+			if aircraft.Autopilot.DistanceToGoNM < shortFinalNM {
+				return false
+			}
+		}
+	}
+
+	climbLat, climbLon := adsb.DestinationPoint(runway.Latitude, runway.Longitude, runway.Heading, runway.LengthNM+departureStraightNM)
+	exitLat, exitLon := adsb.DestinationPoint(runway.Latitude, runway.Longitude,
+		runway.Heading+rand.Float64()*180-90, cfg.SpawnDistanceNM+10)
+	altitude := s.airport.elevationFeet + float64(cfg.DepartureAltitudeFt)
+	speed := departureSpeed
+	aircraftType, callsign := s.generateTrafficFlight()
+
+	aircraft := s.addAircraft(runway.Latitude, runway.Longitude, s.airport.elevationFeet, runway.Heading, 0, 0,
+		aircraftType, 0, callsign)
+	aircraft.Generated = GeneratedDeparture
+	autopilot, err := s.newAutopilot(aircraft, AutopilotSettings{
+		Altitude: &altitude,
+		Speed:    &speed,
+		Route: []Waypoint{
+			{Name: "CLIMB", Lat: climbLat, Lon: climbLon},
+			{Name: "EXIT", Lat: exitLat, Lon: exitLon},
+		},
+		Takeoff: true,
+	})
+	if err != nil {
+		delete(s.aircraft, aircraft.Hex)
+		s.logger.Warn(fmt.Sprintf("Failed to generate departure runway=%s: %v", runway.ID, err))
+		return false
+	}
+	aircraft.Autopilot = autopilot
+
+	s.traffic.flights[aircraft.Hex] = &generatedFlight{runway: runway}
+	s.traffic.departuresGenerated++
+	s.logger.Info(fmt.Sprintf("Generated departure hex=%s flight=%s type=%s runway=%s", aircraft.Hex, callsign, aircraftType, runway.ID))
+	return true
+}
+
+// removeFinishedTraffic removes arrivals that have vacated the runway, departures that have
+// left, and generated aircraft flying for too long. Must be called with the traffic lock held.
+func (s *Service) removeFinishedTraffic(now time.Time) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	for hex, flight := range s.traffic.flights {
+		aircraft, exists := s.aircraft[hex]
+		if !exists {
+			delete(s.traffic.flights, hex) // Removed through the API
+			continue
+		}
+
+		done := now.Sub(aircraft.CreatedAt) > maxGeneratedAircraftAge
+		switch aircraft.Generated {
+		case GeneratedArrival:
+			if aircraft.Autopilot != nil && aircraft.Autopilot.Mode == AutopilotModeVacated {
+				if flight.vacatedAt.IsZero() {
+					flight.vacatedAt = now
+				}
+				done = done || now.Sub(flight.vacatedAt) >= vacatedRemoveAfter
+			}
+		case GeneratedDeparture:
+			distance := adsb.MetersToNM(adsb.Haversine(flight.runway.Latitude, flight.runway.Longitude, aircraft.CurrentLat, aircraft.CurrentLon))
+			done = done || distance > s.traffic.cfg.SpawnDistanceNM
+		}
+		if done {
+			delete(s.aircraft, hex)
+			delete(s.traffic.flights, hex)
+			s.logger.Debug(fmt.Sprintf("Removed generated %s hex=%s flight=%s", aircraft.Generated, hex, aircraft.Flight))
+		}
+	}
+}
+
+// trafficRadioCalls returns the exchanges generated traffic has with the tower as flights
+// reach the points that need a clearance or a frequency change: departures are cleared for
+// takeoff and sent to departure, arrivals check in on final, are cleared to land and are sent
+// to ground once off the runway. Must be called with the traffic lock held.
+func (s *Service) trafficRadioCalls(frequencyID string) []RadioCall {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	var calls []RadioCall
+	exchange := func(hex string, delay time.Duration, texts ...string) {
+		for i, text := range texts {
+			calls = append(calls, RadioCall{
+				FrequencyID:  frequencyID,
+				Text:         text,
+				Hex:          hex,
+				DelaySeconds: (delay + time.Duration(i)*radioReadbackDelay).Seconds(),
+			})
+		}
+	}
+
+	for hex, flight := range s.traffic.flights {
+		aircraft, exists := s.aircraft[hex]
+		if !exists || aircraft.Autopilot == nil || aircraft.Autopilot.Mode == flight.lastMode {
+			continue
+		}
+		mode, lastMode := aircraft.Autopilot.Mode, flight.lastMode
+		flight.lastMode = mode
+
+		callsign := spokenCallsign(aircraft.Flight)
+		runway := flight.runway.ID
+		switch {
+		case aircraft.Generated == GeneratedDeparture && mode == AutopilotModeTakeoff && lastMode == "":
+			exchange(hex, 0,
+				fmt.Sprintf("%s, runway %s, cleared for takeoff.", callsign, runway),
+				fmt.Sprintf("Cleared for takeoff runway %s, %s.", runway, callsign))
+		case aircraft.Generated == GeneratedDeparture && lastMode == AutopilotModeTakeoff:
+			exchange(hex, radioHandoffDelay,
+				fmt.Sprintf("%s, contact departure, good day.", callsign),
+				fmt.Sprintf("Contact departure, %s, good day.", callsign))
+		case aircraft.Generated == GeneratedArrival && mode == AutopilotModeLocalizer:
+			exchange(hex, 0,
+				fmt.Sprintf("Tower, %s, established on the localizer runway %s.", callsign, runway),
+				fmt.Sprintf("%s, tower, runway %s, cleared to land.", callsign, runway),
+				fmt.Sprintf("Cleared to land runway %s, %s.", runway, callsign))
+		case aircraft.Generated == GeneratedArrival && mode == AutopilotModeVacated:
+			exchange(hex, 0,
+				fmt.Sprintf("%s, contact ground.", callsign),
+				fmt.Sprintf("Contact ground, %s.", callsign))
+		}
+	}
+	return calls
+}
+
+// trafficRunways returns the open thresholds arrivals and departures are generated for: those
+// of a forced runway configuration, or else the configured ones, or else the first threshold
+// of each runway. Must be called with the traffic lock held.
+func (s *Service) trafficRunways() (arrivals, departures []adsb.RunwayEnd) {
+	var state *adsb.RunwayState
+	if s.traffic.runways != nil {
+		state = s.traffic.runways.GetRunwayState()
+	}
+
+	s.mutex.RLock()
+	ends := s.airport.runways
+	s.mutex.RUnlock()
+
+	statuses := make(map[string]adsb.RunwayStatus)
+	if state != nil {
+		for _, status := range state.Runways {
+			statuses[status.Threshold] = status
+		}
+	}
+	forced := state != nil && state.Configuration != nil
+
+	cfg := s.traffic.cfg
+	departureList := cfg.DepartureRunways
+	if len(departureList) == 0 {
+		departureList = cfg.ArrivalRunways
+	}
+
+	inUse := func(end adsb.RunwayEnd, configured []string, departure bool) bool {
+		status, known := statuses[end.ID]
+		if known && status.Closed {
+			return false
+		}
+		if forced {
+			return known && ((departure && status.Departures) || (!departure && status.Arrivals))
+		}
+		if len(configured) > 0 {
+			return containsRunway(configured, end.ID)
+		}
+		return strings.HasPrefix(end.Runway, end.ID+"-")
+	}
+
+	for _, end := range ends {
+		if inUse(end, cfg.ArrivalRunways, false) {
+			arrivals = append(arrivals, end)
+		}
+		if inUse(end, departureList, true) {
+			departures = append(departures, end)
+		}
+	}
+	return arrivals, departures
+}
+
+// generatedCount counts the generated aircraft flying. Must be called with the traffic lock
+// held.
+func (s *Service) generatedCount() int {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	return s.countAircraft(GeneratedArrival) + s.countAircraft(GeneratedDeparture)
+}
+
+// generateTrafficFlight picks an aircraft type and an airline callsign not already flying.
+// Must be called with the lock held.
+func (s *Service) generateTrafficFlight() (aircraftType, callsign string) {
+	for {
+		operator := trafficFleet[rand.Intn(len(trafficFleet))]
+		aircraftType = operator.types[rand.Intn(len(operator.types))]
+		callsign = fmt.Sprintf("%s%d", operator.airline, rand.Intn(9900)+100)
+
+		taken := false
+		for _, aircraft := range s.aircraft {
+			if aircraft.Flight == callsign {
+				taken = true
+				break
+			}
+		}
+		if !taken {
+			return aircraftType, callsign
+		}
+	}
+}
+
+// nextTrafficTime draws the time of the next aircraft of a Poisson process at rate per hour
+func nextTrafficTime(now time.Time, perHour float64) time.Time {
+	if perHour <= 0 {
+		return time.Time{}
+	}
+	return now.Add(time.Duration(rand.ExpFloat64() * float64(time.Hour) / perHour))
+}
+
+// runwayIDs returns the threshold IDs of runway ends, sorted
+func runwayIDs(ends []adsb.RunwayEnd) []string {
+	ids := make([]string, 0, len(ends))
+	for _, end := range ends {
+		ids = append(ids, end.ID)
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+// containsRunway reports whether a list holds a threshold ID
+func containsRunway(list []string, id string) bool {
+	for _, item := range list {
+		if strings.EqualFold(item, id) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,162 @@
+// Package station fills in the station from the local ADS-B receiver: its coordinates from
+// the receiver's metadata, and its airport and elevation from the nearest airport.
+package station
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/yegors/co-atc/internal/adsb"
+	"github.com/yegors/co-atc/internal/airports"
+	"github.com/yegors/co-atc/internal/config"
+)
+
+const (
+	// requestTimeout bounds the request for the receiver's metadata
+	requestTimeout = 10 * time.Second
+	// servedAirportMarginNM is how much further an airport with scheduled service may be than
+	// the nearest strip or heliport and still be picked
+	servedAirportMarginNM = 10.0
+)
+
+// Detection is what auto-detection filled in
+type Detection struct {
+	ReceiverURL string // receiver.json the coordinates came from; empty when configured
+	Latitude    float64
+	Longitude   float64
+	Airport     *airports.Airport // Nearest airport, when it set the airport code or elevation
+	DistanceNM  float64           // From the station to the airport
+}
+
+// receiverMetadata is the part of tar1090, readsb and dump1090 receiver.json used here
+type receiverMetadata struct {
+	Lat *float64 `json:"lat"`
+	Lon *float64 `json:"lon"`
+}
+
+// Detect fills in the station settings left unset: the coordinates from receiver.json next
+// to the local source's aircraft.json, and the airport code and elevation from the nearest
+// airport in the airport database. Configured settings are kept.
+func Detect(ctx context.Context, cfg *config.Config) (*Detection, error) {
+	st := &cfg.Station
+	detection := &Detection{}
+
+	if st.Latitude == 0 && st.Longitude == 0 {
+		sourceURL := cfg.ADSB.LocalSourceURL
+		if sourceURL == "" {
+			sourceURL = cfg.ADSB.SourceURL
+		}
+		if (cfg.ADSB.SourceType != "" && cfg.ADSB.SourceType != "local") || sourceURL == "" {
+			return nil, fmt.Errorf("station coordinates can only be detected from a local source with local_source_url set")
+		}
+
+		detection.ReceiverURL = receiverURL(sourceURL)
+		lat, lon, err := fetchReceiverLocation(ctx, detection.ReceiverURL)
+		if err != nil {
+			return nil, err
+		}
+		st.Latitude, st.Longitude = lat, lon
+	}
+	detection.Latitude, detection.Longitude = st.Latitude, st.Longitude
+
+	if st.AirportCode != "" && st.ElevationFeet != 0 {
+		return detection, nil
+	}
+
+	// Detection runs before validation fills in the [board] default
+	path := cfg.Board.AirportsDBPath
+	if path == "" {
+		path = airports.DefaultPath
+	}
+	entries, err := airports.Load(path)
+	if err != nil {
+		return nil, err
+	}
+	airport, distance := nearestAirport(entries, st.Latitude, st.Longitude)
+	if airport == nil {
+		return nil, fmt.Errorf("no airports in %s", path)
+	}
+	detection.Airport, detection.DistanceNM = airport, distance
+	if st.AirportCode == "" {
+		st.AirportCode = airport.ICAO
+	}
+	if st.ElevationFeet == 0 {
+		st.ElevationFeet = int(math.Round(airport.ElevationFeet))
+	}
+	return detection, nil
+}
+
+// receiverURL returns the URL of receiver.json next to a source's aircraft.json
+func receiverURL(sourceURL string) string {
+	base := sourceURL
+	if query := strings.IndexByte(base, '?'); query >= 0 {
+		base = base[:query]
+	}
+	if slash := strings.LastIndexByte(base, '/'); slash >= 0 && strings.HasSuffix(base, ".json") {
+		base = base[:slash]
+	}
+	return strings.TrimRight(base, "/") + "/receiver.json"
+}
+
+// fetchReceiverLocation reads the receiver's location from its receiver.json
+func fetchReceiverLocation(ctx context.Context, url string) (float64, float64, error) {
+	ctx, cancel := context.WithTimeout(ctx, requestTimeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to create receiver request: %w", err)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to fetch %s: %w", url, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return 0, 0, fmt.Errorf("failed to fetch %s: status %d", url, resp.StatusCode)
+	}
+
+	var metadata receiverMetadata
+	if err := json.NewDecoder(resp.Body).Decode(&metadata); err != nil {
+		return 0, 0, fmt.Errorf("failed to parse %s: %w", url, err)
+	}
+	if metadata.Lat == nil || metadata.Lon == nil || (*metadata.Lat == 0 && *metadata.Lon == 0) {
+		return 0, 0, fmt.Errorf("receiver at %s doesn't report its location; set it in the decoder or set the station coordinates", url)
+	}
+	if *metadata.Lat < -90 || *metadata.Lat > 90 || *metadata.Lon < -180 || *metadata.Lon > 180 {
+		return 0, 0, fmt.Errorf("receiver at %s reports an invalid location: %f, %f", url, *metadata.Lat, *metadata.Lon)
+	}
+	return *metadata.Lat, *metadata.Lon, nil
+}
+
+// nearestAirport returns the nearest airport with an ICAO code to a position and its distance.
+// Airports with an IATA code, which have scheduled service, are preferred over the strips and
+// heliports around them.
+func nearestAirport(entries []airports.Airport, lat, lon float64) (*airports.Airport, float64) {
+	var nearest, nearestServed *airports.Airport
+	nearestDistance, servedDistance := math.Inf(1), math.Inf(1)
+	for i := range entries {
+		airport := &entries[i]
+		if airport.ICAO == "" {
+			continue
+		}
+		distance := adsb.MetersToNM(adsb.Haversine(lat, lon, airport.Latitude, airport.Longitude))
+		if distance < nearestDistance {
+			nearest, nearestDistance = airport, distance
+		}
+		if airport.IATA != "" && distance < servedDistance {
+			nearestServed, servedDistance = airport, distance
+		}
+	}
+	// A receiver is rarely on the airport itself, so a served airport a few miles further
+	// away is the one it watches
+	if nearestServed != nil && servedDistance <= nearestDistance+servedAirportMarginNM {
+		return nearestServed, servedDistance
+	}
+	return nearest, nearestDistance
+}
@@ -0,0 +1,296 @@
+package stats
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/yegors/co-atc/internal/storage/sqlite"
+	"github.com/yegors/co-atc/pkg/logger"
+)
+
+// Intervals movements are counted in
+const (
+	IntervalHour = "hour"
+	IntervalDay  = "day"
+)
+
+const (
+	// maxHourBuckets bounds the range of hourly counts (31 days)
+	maxHourBuckets = 31 * 24
+	// maxDayBuckets bounds the range of daily counts
+	maxDayBuckets = 366
+)
+
+// ErrInvalidQuery is returned for a statistics query with an invalid range, interval or limit
+var ErrInvalidQuery = errors.New("invalid statistics query")
+
+// Query is the time range statistics cover, half-open, and the time zone hours and days are
+// counted in
+type Query struct {
+	Start    time.Time
+	End      time.Time
+	Location *time.Location
+}
+
+// MovementCounts counts movements by kind
+type MovementCounts struct {
+	Arrivals    int `json:"arrivals"`
+	Departures  int `json:"departures"`
+	Overflights int `json:"overflights"`
+	Total       int `json:"total"`
+}
+
+// add counts a movement
+func (c *MovementCounts) add(m sqlite.Movement) {
+	switch m.Kind {
+	case sqlite.MovementArrival:
+		c.Arrivals++
+	case sqlite.MovementDeparture:
+		c.Departures++
+	case sqlite.MovementOverflight:
+		c.Overflights++
+	}
+	c.Total++
+}
+
+// MovementBucket is the movements of one hour or day
+type MovementBucket struct {
+	Start time.Time `json:"start"`
+	MovementCounts
+}
+
+// MovementStats is the movements of a time range, in total and by hour or day
+type MovementStats struct {
+	Start    time.Time        `json:"start"`
+	End      time.Time        `json:"end"`
+	Interval string           `json:"interval"`
+	Timezone string           `json:"timezone"`
+	Totals   MovementCounts   `json:"totals"`
+	Buckets  []MovementBucket `json:"buckets"` // Every interval in the range, oldest first
+}
+
+// HourOfDay is the movements in one hour of the day across a time range
+type HourOfDay struct {
+	Hour      int     `json:"hour"` // 0-23
+	Movements int     `json:"movements"`
+	PerDay    float64 `json:"per_day"` // Average over the days of the range
+}
+
+// BusiestHours is the busiest hours of the day and the busiest clock hours of a time range
+type BusiestHours struct {
+	Start      time.Time        `json:"start"`
+	End        time.Time        `json:"end"`
+	Timezone   string           `json:"timezone"`
+	HoursOfDay []HourOfDay      `json:"hours_of_day"` // Busiest first
+	Peaks      []MovementBucket `json:"peaks"`        // Busiest clock hours, busiest first
+}
+
+// TopEntry is the movements of one operator or aircraft type
+type TopEntry struct {
+	Name string `json:"name"`
+	MovementCounts
+}
+
+// TopTraffic is the operators and aircraft types with the most movements in a time range
+type TopTraffic struct {
+	Start     time.Time  `json:"start"`
+	End       time.Time  `json:"end"`
+	Operators []TopEntry `json:"operators"`
+	Types     []TopEntry `json:"types"`
+}
+
+// Service derives traffic statistics from the stored phase changes and tracks
+type Service struct {
+	storage *sqlite.StatsStorage
+	logger  *logger.Logger
+}
+
+// NewService creates a new statistics service
+func NewService(storage *sqlite.StatsStorage, logger *logger.Logger) *Service {
+	return &Service{
+		storage: storage,
+		logger:  logger.Named("stats"),
+	}
+}
+
+// Movements counts the movements of a time range, in total and by hour or day
+func (s *Service) Movements(q Query, interval string) (*MovementStats, error) {
+	if err := q.validate(); err != nil {
+		return nil, err
+	}
+
+	var start func(time.Time) time.Time
+	var next func(time.Time) time.Time
+	var maxBuckets int
+	switch interval {
+	case IntervalHour:
+		start, next, maxBuckets = q.hourStart, func(t time.Time) time.Time { return t.Add(time.Hour) }, maxHourBuckets
+	case IntervalDay:
+		start, next, maxBuckets = q.dayStart, func(t time.Time) time.Time { return t.AddDate(0, 0, 1) }, maxDayBuckets
+	default:
+		return nil, fmt.Errorf("%w: interval must be %s or %s", ErrInvalidQuery, IntervalHour, IntervalDay)
+	}
+
+	stats := &MovementStats{
+		Start:    q.Start,
+		End:      q.End,
+		Interval: interval,
+		Timezone: q.Location.String(),
+		Buckets:  make([]MovementBucket, 0),
+	}
+	index := make(map[int64]int) // Bucket by start, in Unix seconds
+	for t := start(q.Start); t.Before(q.End); t = next(t) {
+		if len(stats.Buckets) == maxBuckets {
+			return nil, fmt.Errorf("%w: at most %d %ss can be counted at once", ErrInvalidQuery, maxBuckets, interval)
+		}
+		index[t.Unix()] = len(stats.Buckets)
+		stats.Buckets = append(stats.Buckets, MovementBucket{Start: t})
+	}
+
+	movements, err := s.storage.GetMovements(q.Start, q.End)
+	if err != nil {
+		return nil, err
+	}
+	for _, m := range movements {
+		stats.Totals.add(m)
+		if i, ok := index[start(m.Time).Unix()]; ok {
+			stats.Buckets[i].add(m)
+		}
+	}
+	return stats, nil
+}
+
+// BusiestHours finds the busiest hours of the day and clock hours of a time range
+func (s *Service) BusiestHours(q Query, limit int) (*BusiestHours, error) {
+	if err := q.validate(); err != nil {
+		return nil, err
+	}
+	if limit < 1 || limit > 24 {
+		return nil, fmt.Errorf("%w: limit must be between 1 and 24", ErrInvalidQuery)
+	}
+
+	movements, err := s.storage.GetMovements(q.Start, q.End)
+	if err != nil {
+		return nil, err
+	}
+
+	hours := make([]HourOfDay, 24)
+	for hour := range hours {
+		hours[hour].Hour = hour
+	}
+	clockHours := make(map[int64]*MovementBucket)
+	for _, m := range movements {
+		hours[m.Time.In(q.Location).Hour()].Movements++
+		start := q.hourStart(m.Time)
+		if clockHours[start.Unix()] == nil {
+			clockHours[start.Unix()] = &MovementBucket{Start: start}
+		}
+		clockHours[start.Unix()].add(m)
+	}
+
+	days := q.End.Sub(q.Start).Hours() / 24
+	for i := range hours {
+		hours[i].PerDay = float64(hours[i].Movements) / days
+	}
+	sort.SliceStable(hours, func(i, j int) bool { return hours[i].Movements > hours[j].Movements })
+
+	peaks := make([]MovementBucket, 0, len(clockHours))
+	for _, bucket := range clockHours {
+		peaks = append(peaks, *bucket)
+	}
+	sort.Slice(peaks, func(i, j int) bool {
+		if peaks[i].Total != peaks[j].Total {
+			return peaks[i].Total > peaks[j].Total
+		}
+		return peaks[i].Start.Before(peaks[j].Start)
+	})
+
+	return &BusiestHours{
+		Start:      q.Start,
+		End:        q.End,
+		Timezone:   q.Location.String(),
+		HoursOfDay: hours[:limit],
+		Peaks:      peaks[:min(limit, len(peaks))],
+	}, nil
+}
+
+// Top finds the operators and aircraft types with the most movements in a time range.
+// Movements without a known operator or type aren't ranked.
+func (s *Service) Top(q Query, limit int) (*TopTraffic, error) {
+	if err := q.validate(); err != nil {
+		return nil, err
+	}
+	if limit < 1 || limit > 100 {
+		return nil, fmt.Errorf("%w: limit must be between 1 and 100", ErrInvalidQuery)
+	}
+
+	movements, err := s.storage.GetMovements(q.Start, q.End)
+	if err != nil {
+		return nil, err
+	}
+
+	operators := make(map[string]*TopEntry)
+	types := make(map[string]*TopEntry)
+	for _, m := range movements {
+		countBy(operators, m.Operator, m)
+		countBy(types, m.AircraftType, m)
+	}
+
+	return &TopTraffic{
+		Start:     q.Start,
+		End:       q.End,
+		Operators: rank(operators, limit),
+		Types:     rank(types, limit),
+	}, nil
+}
+
+// countBy counts a movement under a name, unless the name is empty
+func countBy(entries map[string]*TopEntry, name string, m sqlite.Movement) {
+	if name == "" {
+		return
+	}
+	if entries[name] == nil {
+		entries[name] = &TopEntry{Name: name}
+	}
+	entries[name].add(m)
+}
+
+// rank returns the entries with the most movements, most first, up to limit
+func rank(entries map[string]*TopEntry, limit int) []TopEntry {
+	ranked := make([]TopEntry, 0, len(entries))
+	for _, entry := range entries {
+		ranked = append(ranked, *entry)
+	}
+	sort.Slice(ranked, func(i, j int) bool {
+		if ranked[i].Total != ranked[j].Total {
+			return ranked[i].Total > ranked[j].Total
+		}
+		return ranked[i].Name < ranked[j].Name
+	})
+	return ranked[:min(limit, len(ranked))]
+}
+
+// validate checks the range of a query and defaults its time zone to UTC
+func (q *Query) validate() error {
+	if q.Location == nil {
+		q.Location = time.UTC
+	}
+	if !q.End.After(q.Start) {
+		return fmt.Errorf("%w: end must be after start", ErrInvalidQuery)
+	}
+	return nil
+}
+
+// hourStart returns the start of the clock hour a time is in, in the query's time zone
+func (q Query) hourStart(t time.Time) time.Time {
+	t = t.In(q.Location)
+	return time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), 0, 0, 0, q.Location)
+}
+
+// dayStart returns the start of the day a time is in, in the query's time zone
+func (q Query) dayStart(t time.Time) time.Time {
+	t = t.In(q.Location)
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, q.Location)
+}
@@ -5,11 +5,12 @@ import (
 	"encoding/json"
 	"fmt"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/yegors/co-atc/internal/adsb"
 	"github.com/yegors/co-atc/pkg/logger"
-	_ "modernc.org/sqlite"
 )
 
 // AircraftRecord represents an aircraft record for context
@@ -24,42 +25,30 @@ type AircraftStorage struct {
 	db                *sql.DB
 	logger            *logger.Logger
 	maxPositionsInAPI int
+	liveTargets       map[string]*adsb.ADSBTarget // Latest ADS-B data of aircraft whose last position wasn't stored
+	liveTargetsMu     sync.RWMutex
+	stmts             *statementCache
+
+	// Write queue
+	options      WriteOptions
+	writes       chan aircraftWrite // nil when writes are synchronous
+	writesClosed bool
+	writesMu     sync.RWMutex // Guards sending on writes against closing it
+	writerDone   chan struct{}
+
+	queueFullWarned atomic.Int64 // When a full queue was last logged (Unix nanoseconds)
 }
 
 // NewAircraftStorage creates a new SQLite-based aircraft storage
-func NewAircraftStorage(dbPath string, maxPositionsInAPI int, log *logger.Logger) (*AircraftStorage, error) {
+func NewAircraftStorage(dbPath string, maxPositionsInAPI int, options WriteOptions, log *logger.Logger) (*AircraftStorage, error) {
 	storageLogger := log.Named("sqlite")
 
 	storageLogger.Info("Initializing SQLite storage",
 		logger.String("path", dbPath))
 
-	// Open the database
-	db, err := sql.Open("sqlite", dbPath)
+	// Open the database, creating or upgrading its tables
+	db, err := OpenMigratedDatabase(dbPath, DailySchema, storageLogger)
 	if err != nil {
-		return nil, fmt.Errorf("failed to open database: %w", err)
-	}
-
-	// Set connection pool limits
-	db.SetMaxOpenConns(1) // SQLite only supports one writer at a time
-	db.SetMaxIdleConns(1)
-
-	// Set pragmas for better performance and concurrency
-	if _, err := db.Exec("PRAGMA journal_mode=WAL"); err != nil {
-		return nil, fmt.Errorf("failed to set journal mode: %w", err)
-	}
-	if _, err := db.Exec("PRAGMA synchronous=NORMAL"); err != nil {
-		return nil, fmt.Errorf("failed to set synchronous mode: %w", err)
-	}
-	if _, err := db.Exec("PRAGMA busy_timeout=5000"); err != nil {
-		return nil, fmt.Errorf("failed to set busy timeout: %w", err)
-	}
-	if _, err := db.Exec("PRAGMA cache_size=10000"); err != nil {
-		return nil, fmt.Errorf("failed to set cache size: %w", err)
-	}
-
-	// Create tables if they don't exist
-	if err := initDatabase(db, storageLogger); err != nil {
-		db.Close()
 		return nil, err
 	}
 
@@ -67,13 +56,23 @@ func NewAircraftStorage(dbPath string, maxPositionsInAPI int, log *logger.Logger
 		db:                db,
 		logger:            storageLogger,
 		maxPositionsInAPI: maxPositionsInAPI,
+		liveTargets:       make(map[string]*adsb.ADSBTarget),
+		stmts:             newStatementCache(db),
+		options:           options,
+	}
+
+	if err := storage.startWriter(); err != nil {
+		db.Close()
+		return nil, err
 	}
 
 	return storage, nil
 }
 
-// Close closes the database connection
+// Close writes any queued aircraft and closes the database connection
 func (s *AircraftStorage) Close() error {
+	s.stopWriter()
+	s.stmts.close()
 	if s.db != nil {
 		return s.db.Close()
 	}
@@ -85,154 +84,6 @@ func (s *AircraftStorage) GetDB() *sql.DB {
 	return s.db
 }
 
-// initDatabase initializes the database schema
-func initDatabase(db *sql.DB, log *logger.Logger) error {
-	log.Info("Initializing database schema")
-
-	// Create aircraft table with essential fields
-	_, err := db.Exec(`
-		CREATE TABLE IF NOT EXISTS aircraft (
-			hex TEXT PRIMARY KEY,
-			flight TEXT,
-			airline TEXT,
-			status TEXT,
-			last_seen TIMESTAMP,
-			on_ground INTEGER DEFAULT 0,
-			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
-			updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
-		)
-	`)
-	if err != nil {
-		return fmt.Errorf("failed to create aircraft table: %w", err)
-	}
-
-	// Create adsb_targets table with all possible fields from both local and external APIs
-	_, err = db.Exec(`
-		CREATE TABLE IF NOT EXISTS adsb_targets (
-			id INTEGER PRIMARY KEY AUTOINCREMENT,
-			aircraft_hex TEXT,
-			hex TEXT,
-			type TEXT,
-			flight TEXT,
-			registration TEXT,      -- External API specific field (r)
-			aircraft_type TEXT,     -- External API specific field (t)
-			alt_baro REAL,
-			alt_geom REAL,
-			gs REAL,
-			ias REAL,
-			tas REAL,
-			mach REAL,
-			wd REAL,
-			ws REAL,
-			oat REAL,
-			tat REAL,
-			track REAL,
-			track_rate REAL,
-			roll REAL,
-			mag_heading REAL,
-			true_heading REAL,
-			baro_rate REAL,
-			geom_rate REAL,
-			squawk TEXT,
-			emergency TEXT,
-			category TEXT,
-			nav_qnh REAL,
-			nav_altitude_mcp REAL,
-			nav_altitude_fms REAL,
-			nav_heading REAL,
-			nav_modes TEXT,
-			lat REAL,
-			lon REAL,
-			nic INTEGER,
-			rc INTEGER,
-			seen_pos REAL,
-			r_dst REAL,
-			r_dir REAL,
-			version INTEGER,
-			nic_baro INTEGER,
-			nac_p INTEGER,
-			nac_v INTEGER,
-			sil INTEGER,
-			sil_type TEXT,
-			gva INTEGER,
-			sda INTEGER,
-			alert INTEGER,
-			spi INTEGER,
-			mlat TEXT,
-			tisb TEXT,
-			messages INTEGER,
-			seen REAL,
-			rssi REAL,
-			timestamp TIMESTAMP,
-			raw_data TEXT,
-			source_type TEXT,       -- Indicates whether data came from "local" or "external" source
-			FOREIGN KEY (aircraft_hex) REFERENCES aircraft(hex) ON DELETE CASCADE,
-			UNIQUE(aircraft_hex, lat, lon, alt_baro, gs, tas, track)
-		)
-	`)
-	if err != nil {
-		return fmt.Errorf("failed to create adsb_targets table: %w", err)
-	}
-
-	// Create phase_changes table for tracking flight phase transitions
-	_, err = db.Exec(`
-		CREATE TABLE IF NOT EXISTS phase_changes (
-			id INTEGER PRIMARY KEY AUTOINCREMENT,
-			hex TEXT NOT NULL,
-			flight TEXT,
-			phase TEXT NOT NULL,
-			timestamp TIMESTAMP NOT NULL,
-			adsb_id INTEGER,
-			FOREIGN KEY (adsb_id) REFERENCES adsb_targets(id),
-			FOREIGN KEY (hex) REFERENCES aircraft(hex) ON DELETE CASCADE
-		)
-	`)
-	if err != nil {
-		return fmt.Errorf("failed to create phase_changes table: %w", err)
-	}
-
-	// Create indexes for efficient querying
-	_, err = db.Exec(`CREATE INDEX IF NOT EXISTS idx_adsb_targets_aircraft_hex ON adsb_targets(aircraft_hex)`)
-	if err != nil {
-		return fmt.Errorf("failed to create index on adsb_targets.aircraft_hex: %w", err)
-	}
-
-	_, err = db.Exec(`CREATE INDEX IF NOT EXISTS idx_adsb_targets_timestamp ON adsb_targets(timestamp)`)
-	if err != nil {
-		return fmt.Errorf("failed to create index on adsb_targets.timestamp: %w", err)
-	}
-
-	// Critical composite index for efficient latest record queries
-	_, err = db.Exec(`CREATE INDEX IF NOT EXISTS idx_adsb_targets_hex_timestamp ON adsb_targets(aircraft_hex, timestamp DESC)`)
-	if err != nil {
-		return fmt.Errorf("failed to create index on adsb_targets.aircraft_hex_timestamp: %w", err)
-	}
-
-	_, err = db.Exec(`CREATE INDEX IF NOT EXISTS idx_aircraft_status ON aircraft(status)`)
-	if err != nil {
-		return fmt.Errorf("failed to create index on aircraft.status: %w", err)
-	}
-
-	_, err = db.Exec(`CREATE INDEX IF NOT EXISTS idx_aircraft_last_seen ON aircraft(last_seen)`)
-	if err != nil {
-		return fmt.Errorf("failed to create index on aircraft.last_seen: %w", err)
-	}
-
-	// Create indexes for phase_changes table
-	_, err = db.Exec(`CREATE INDEX IF NOT EXISTS idx_phase_changes_hex_timestamp ON phase_changes(hex, timestamp)`)
-	if err != nil {
-		return fmt.Errorf("failed to create index on phase_changes.hex_timestamp: %w", err)
-	}
-
-	_, err = db.Exec(`CREATE INDEX IF NOT EXISTS idx_phase_changes_phase_timestamp ON phase_changes(phase, timestamp)`)
-	if err != nil {
-		return fmt.Errorf("failed to create index on phase_changes.phase_timestamp: %w", err)
-	}
-
-	log.Info("Database schema initialized successfully")
-	return nil
-}
-
 // GetAll returns all aircraft
 func (s *AircraftStorage) GetAll() []*adsb.Aircraft {
 	aircraft, err := s.getAllAircraft()
@@ -404,6 +255,14 @@ func (s *AircraftStorage) getAllAircraft() ([]*adsb.Aircraft, error) {
 
 // getLatestADSBData returns the latest ADSB data for an aircraft
 func (s *AircraftStorage) getLatestADSBData(hex string) (*adsb.ADSBTarget, error) {
+	s.liveTargetsMu.RLock()
+	live, ok := s.liveTargets[hex]
+	s.liveTargetsMu.RUnlock()
+	if ok {
+		target := *live
+		return &target, nil
+	}
+
 	row := s.db.QueryRow(`
 		SELECT raw_data, source_type, registration, aircraft_type FROM adsb_targets
 		WHERE aircraft_hex = ?
@@ -813,6 +672,70 @@ func (s *AircraftStorage) GetPositionHistoryWithLimit(hex string, limit int) ([]
 	return positions, nil
 }
 
+// TrackPoint is a stored position of an aircraft, with the fields needed to replay it
+type TrackPoint struct {
+	Hex          string
+	Flight       string
+	Registration string
+	AircraftType string
+	Category     string
+	Squawk       string
+	Lat          float64
+	Lon          float64
+	AltBaro      float64
+	GS           float64
+	TAS          float64
+	Track        float64
+	BaroRate     float64
+	Timestamp    time.Time
+	LastSeen     time.Time // When the aircraft was last seen at all, which can be after its last stored position
+}
+
+// GetTrackPoints returns the positions stored in a time range, oldest first, up to limit.
+// Positions of replayed aircraft are left out, so a replay never replays itself.
+func (s *AircraftStorage) GetTrackPoints(startTime, endTime time.Time, limit int) ([]TrackPoint, error) {
+	rows, err := s.db.Query(`
+		SELECT t.aircraft_hex, COALESCE(t.flight, ''), COALESCE(t.registration, ''), COALESCE(t.aircraft_type, ''),
+			COALESCE(t.category, ''), COALESCE(t.squawk, ''), t.lat, t.lon, t.alt_baro, t.gs, t.tas, t.track, t.baro_rate,
+			t.timestamp, COALESCE(a.last_seen, t.timestamp)
+		FROM adsb_targets t
+		LEFT JOIN aircraft a ON a.hex = t.aircraft_hex
+		WHERE t.timestamp BETWEEN ? AND ? AND COALESCE(t.type, '') != ?
+		ORDER BY t.timestamp, t.id
+		LIMIT ?
+	`, startTime.UTC().Format(time.RFC3339), endTime.UTC().Format(time.RFC3339), adsb.TargetTypeReplay, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query track points: %w", err)
+	}
+	defer rows.Close()
+
+	points := make([]TrackPoint, 0)
+	for rows.Next() {
+		var p TrackPoint
+		var lat, lon, altBaro, gs, tas, track, baroRate sql.NullFloat64
+		var timestamp, lastSeen string
+		if err := rows.Scan(&p.Hex, &p.Flight, &p.Registration, &p.AircraftType, &p.Category, &p.Squawk,
+			&lat, &lon, &altBaro, &gs, &tas, &track, &baroRate, &timestamp, &lastSeen); err != nil {
+			return nil, fmt.Errorf("failed to scan track point: %w", err)
+		}
+		if !lat.Valid || !lon.Valid {
+			continue
+		}
+		p.Timestamp, err = time.Parse(time.RFC3339, timestamp)
+		if err != nil {
+			continue
+		}
+		if p.LastSeen, err = time.Parse(time.RFC3339, lastSeen); err != nil {
+			p.LastSeen = p.Timestamp
+		}
+		p.Lat, p.Lon = lat.Float64, lon.Float64
+		p.AltBaro, p.GS, p.TAS = altBaro.Float64, gs.Float64, tas.Float64
+		p.Track, p.BaroRate = track.Float64, baroRate.Float64
+		points = append(points, p)
+	}
+	return points, rows.Err()
+}
+
 // GetByHex returns an aircraft by its hex ID
 func (s *AircraftStorage) GetByHex(hex string) (*adsb.Aircraft, bool) {
 
@@ -903,6 +826,7 @@ func (s *AircraftStorage) GetByHex(hex string) (*adsb.Aircraft, bool) {
 				magHeading, // magnetic heading
 				speed,
 				verticalRate,
+				a.ADSB.TAS != 0 && a.ADSB.TrueHeading != 0,
 			)
 		} else {
 			// Initialize empty future slice
@@ -939,220 +863,119 @@ func (s *AircraftStorage) GetByHex(hex string) (*adsb.Aircraft, bool) {
 
 // Upsert updates or inserts an aircraft
 func (s *AircraftStorage) Upsert(aircraft *adsb.Aircraft) {
-	// Ensure all timestamps are in UTC
-	aircraft.LastSeen = aircraft.LastSeen.UTC()
-
-	// Try to begin a transaction with retries
-	var tx *sql.Tx
-	var err error
-
-	// Retry up to 3 times with exponential backoff
-	for i := 0; i < 3; i++ {
-		tx, err = s.db.Begin()
-		if err == nil {
-			break
-		}
+	s.queueWrite(aircraft, true)
+}
 
-		s.logger.Warn("Failed to begin transaction, retrying...",
-			logger.Error(err),
-			logger.String("hex", aircraft.Hex),
-			logger.Int("attempt", i+1))
+// UpsertWithoutPosition updates or inserts an aircraft without storing its ADS-B target,
+// for poll cycles whose positions are sampled out in budget mode
+func (s *AircraftStorage) UpsertWithoutPosition(aircraft *adsb.Aircraft) {
+	s.queueWrite(aircraft, false)
+}
 
-		// Exponential backoff: 100ms, 200ms, 400ms
-		time.Sleep(time.Duration(100*(1<<i)) * time.Millisecond)
-	}
+// upsertAircraftSQL inserts an aircraft or updates the one with the same hex, keeping
+// its created_at
+const upsertAircraftSQL = `
+	INSERT INTO aircraft (
+		hex, flight, airline, status, last_seen,
+		on_ground, created_at, updated_at
+	) VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+	ON CONFLICT(hex) DO UPDATE SET
+		flight = excluded.flight,
+		airline = excluded.airline,
+		status = excluded.status,
+		last_seen = excluded.last_seen,
+		on_ground = excluded.on_ground,
+		updated_at = excluded.updated_at
+`
+
+// insertTargetSQL stores an ADS-B target. Targets repeating a stored position/state are
+// ignored by the table's UNIQUE constraint.
+const insertTargetSQL = `
+	INSERT OR IGNORE INTO adsb_targets (
+		aircraft_hex, hex, type, flight, registration, aircraft_type, alt_baro, alt_geom, gs, ias, tas, mach, wd, ws, oat, tat,
+		track, track_rate, roll, mag_heading, true_heading, baro_rate, geom_rate, squawk, emergency,
+		category, nav_qnh, nav_altitude_mcp, nav_altitude_fms, nav_heading, nav_modes, lat, lon,
+		nic, rc, seen_pos, r_dst, r_dir, version, nic_baro, nac_p, nac_v, sil, sil_type, gva, sda,
+		alert, spi, mlat, tisb, messages, seen, rssi, timestamp, raw_data, source_type
+	) VALUES (
+		?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?,
+		?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?
+	)
+`
+
+// aircraftStatements are the prepared statements aircraft are written with. They are
+// prepared before a write transaction begins: the database has a single connection, which
+// the transaction holds until it ends.
+type aircraftStatements struct {
+	upsert       *sql.Stmt
+	insertTarget *sql.Stmt
+}
 
+// prepareWrites returns the prepared statements for writing aircraft
+func (s *AircraftStorage) prepareWrites() (*aircraftStatements, error) {
+	upsert, err := s.stmts.prepare(upsertAircraftSQL)
 	if err != nil {
-		s.logger.Error("Failed to begin transaction after retries", logger.Error(err), logger.String("hex", aircraft.Hex))
-		return
-	}
-	if err != nil {
-		s.logger.Error("Failed to begin transaction", logger.Error(err))
-		return
-	}
-	defer func() {
-		if err != nil {
-			if rollbackErr := tx.Rollback(); rollbackErr != nil {
-				s.logger.Error("Failed to rollback transaction", logger.Error(rollbackErr))
-			} else {
-				s.logger.Error("Transaction rolled back", logger.Error(err))
-			}
-		}
-	}()
-
-	// Check if aircraft already exists and get current status
-	var exists bool
-	var currentStatus string
-	err = tx.QueryRow("SELECT 1, status FROM aircraft WHERE hex = ?", aircraft.Hex).Scan(&exists, &currentStatus)
-	if err != nil && err != sql.ErrNoRows {
-		s.logger.Error("Failed to check if aircraft exists", logger.Error(err), logger.String("hex", aircraft.Hex))
-		return
-	}
-
-	// Set status to active for new data
-	if aircraft.Status == "" {
-		aircraft.Status = "active"
-	}
-
-	if err == sql.ErrNoRows {
-		// Insert new aircraft with UTC timestamps
-		now := time.Now().UTC().Format(time.RFC3339)
-		_, err = tx.Exec(`
-			INSERT INTO aircraft (
-				hex, flight, airline, status, last_seen,
-				on_ground, created_at, updated_at
-			) VALUES (?, ?, ?, ?, ?, ?, ?, ?)
-		`,
-			aircraft.Hex, aircraft.Flight, aircraft.Airline, aircraft.Status,
-			aircraft.LastSeen.Format(time.RFC3339),
-			boolToInt(aircraft.OnGround),
-			now, now,
-		)
-		if err != nil {
-			s.logger.Error("Failed to insert aircraft", logger.Error(err), logger.String("hex", aircraft.Hex))
-			return
-		}
-	} else {
-		// Update existing aircraft with UTC timestamp for updated_at
-		now := time.Now().UTC().Format(time.RFC3339)
-		_, err = tx.Exec(`
-			UPDATE aircraft SET
-				flight = ?, airline = ?, status = ?, last_seen = ?, on_ground = ?, updated_at = ?
-			WHERE hex = ?
-		`,
-			aircraft.Flight, aircraft.Airline, aircraft.Status, aircraft.LastSeen.Format(time.RFC3339),
-			boolToInt(aircraft.OnGround), now, aircraft.Hex,
-		)
-		if err != nil {
-			s.logger.Error("Failed to update aircraft", logger.Error(err), logger.String("hex", aircraft.Hex))
-			return
-		}
+		return nil, fmt.Errorf("failed to prepare aircraft upsert: %w", err)
 	}
-
-	// Check if this is a unique ADSB target
-	isUnique, err := s.isUniqueADSBTarget(tx, aircraft)
+	insertTarget, err := s.stmts.prepare(insertTargetSQL)
 	if err != nil {
-		s.logger.Error("Failed to check for unique ADSB target", logger.Error(err), logger.String("hex", aircraft.Hex))
-		return
-	}
-
-	if isUnique && aircraft.ADSB != nil {
-		// Convert ADSB data to JSON
-		rawData, err := json.Marshal(aircraft.ADSB)
-		if err != nil {
-			s.logger.Error("Failed to marshal ADSB data", logger.Error(err), logger.String("hex", aircraft.Hex))
-			return
-		}
-
-		// Get source type and registration/aircraft type directly from the ADSB data
-		sourceType := "local"
-		registration := ""
-		aircraftType := ""
-
-		if aircraft.ADSB != nil {
-			// Use the fields directly from the ADSBTarget struct
-			if aircraft.ADSB.SourceType != "" {
-				sourceType = aircraft.ADSB.SourceType
-			}
-
-			if aircraft.ADSB.Registration != "" {
-				registration = aircraft.ADSB.Registration
-			}
-
-			if aircraft.ADSB.AircraftType != "" {
-				aircraftType = aircraft.ADSB.AircraftType
-			}
-
-			//s.logger.Debug("ADSB data source info",
-			//	logger.String("hex", aircraft.Hex),
-			//	logger.String("source_type", sourceType),
-			//	logger.String("registration", registration),
-			//	logger.String("aircraft_type", aircraftType))
-		}
-
-		// Insert the ADSB target
-		_, err = tx.Exec(`
-			INSERT INTO adsb_targets (
-				aircraft_hex, hex, type, flight, registration, aircraft_type, alt_baro, alt_geom, gs, ias, tas, mach, wd, ws, oat, tat,
-				track, track_rate, roll, mag_heading, true_heading, baro_rate, geom_rate, squawk, emergency,
-				category, nav_qnh, nav_altitude_mcp, nav_altitude_fms, nav_heading, nav_modes, lat, lon,
-				nic, rc, seen_pos, r_dst, r_dir, version, nic_baro, nac_p, nac_v, sil, sil_type, gva, sda,
-				alert, spi, mlat, tisb, messages, seen, rssi, timestamp, raw_data, source_type
-			) VALUES (
-				?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?,
-				?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?
-			)
-		`,
-			aircraft.Hex, aircraft.ADSB.Hex, aircraft.ADSB.Type, aircraft.ADSB.Flight,
-			registration, aircraftType, // Registration and AircraftType (populated for external API)
-			aircraft.ADSB.AltBaro, aircraft.ADSB.AltGeom, aircraft.ADSB.GS, aircraft.ADSB.IAS,
-			aircraft.ADSB.TAS, aircraft.ADSB.Mach, aircraft.ADSB.WD, aircraft.ADSB.WS,
-			aircraft.ADSB.OAT, aircraft.ADSB.TAT, aircraft.ADSB.Track, aircraft.ADSB.TrackRate,
-			aircraft.ADSB.Roll, aircraft.ADSB.MagHeading, aircraft.ADSB.TrueHeading,
-			aircraft.ADSB.BaroRate, aircraft.ADSB.GeomRate, aircraft.ADSB.Squawk,
-			"", aircraft.ADSB.Category, aircraft.ADSB.NavQNH,
-			aircraft.ADSB.NavAltitudeMCP, aircraft.ADSB.NavAltitudeFMS, aircraft.ADSB.NavHeading,
-			"", aircraft.ADSB.Lat, aircraft.ADSB.Lon,
-			aircraft.ADSB.NIC, aircraft.ADSB.RC, aircraft.ADSB.SeenPos, aircraft.ADSB.RDst,
-			aircraft.ADSB.RDir, aircraft.ADSB.Version, aircraft.ADSB.NICBaro, aircraft.ADSB.NACP,
-			aircraft.ADSB.NACV, aircraft.ADSB.SIL, aircraft.ADSB.SILType, aircraft.ADSB.GVA,
-			aircraft.ADSB.SDA, aircraft.ADSB.Alert, aircraft.ADSB.SPI,
-			"", "", // MLAT and TISB as strings (we'll store them as empty strings for now)
-			aircraft.ADSB.Messages, aircraft.ADSB.Seen, aircraft.ADSB.RSSI,
-			aircraft.LastSeen.Format(time.RFC3339), string(rawData), sourceType,
-		)
-		if err != nil {
-			s.logger.Error("Failed to insert ADSB target", logger.Error(err), logger.String("hex", aircraft.Hex))
-			return
-		}
-	}
-
-	// Commit transaction with retries
-	for i := 0; i < 3; i++ {
-		err = tx.Commit()
-		if err == nil {
-			break
-		}
-
-		s.logger.Warn("Failed to commit transaction, retrying...",
-			logger.Error(err),
-			logger.String("hex", aircraft.Hex),
-			logger.Int("attempt", i+1))
-
-		// Exponential backoff: 100ms, 200ms, 400ms
-		time.Sleep(time.Duration(100*(1<<i)) * time.Millisecond)
-	}
-
-	if err != nil {
-		s.logger.Error("Failed to commit transaction after retries", logger.Error(err), logger.String("hex", aircraft.Hex))
+		return nil, fmt.Errorf("failed to prepare ADSB target insert: %w", err)
 	}
+	return &aircraftStatements{upsert: upsert, insertTarget: insertTarget}, nil
 }
 
-// isUniqueADSBTarget checks if the ADSB target represents a unique position/state
-func (s *AircraftStorage) isUniqueADSBTarget(tx *sql.Tx, aircraft *adsb.Aircraft) (bool, error) {
-	if aircraft.ADSB == nil {
-		return false, nil
+// writeAircraft writes an aircraft, and its ADS-B target if storePosition is set, within
+// a transaction
+func (s *AircraftStorage) writeAircraft(tx *sql.Tx, stmts *aircraftStatements, aircraft *adsb.Aircraft, storePosition bool) error {
+	now := time.Now().UTC().Format(time.RFC3339)
+	if _, err := tx.Stmt(stmts.upsert).Exec(
+		aircraft.Hex, aircraft.Flight, aircraft.Airline, aircraft.Status,
+		aircraft.LastSeen.Format(time.RFC3339),
+		boolToInt(aircraft.OnGround),
+		now, now,
+	); err != nil {
+		return fmt.Errorf("failed to upsert aircraft: %w", err)
 	}
 
-	var count int
-	err := tx.QueryRow(`
-		SELECT COUNT(*) FROM adsb_targets
-		WHERE aircraft_hex = ? AND lat = ? AND lon = ? AND alt_baro = ? AND gs = ? AND tas = ? AND track = ?
-	`, aircraft.Hex, aircraft.ADSB.Lat, aircraft.ADSB.Lon, aircraft.ADSB.AltBaro, aircraft.ADSB.GS, aircraft.ADSB.TAS, aircraft.ADSB.Track).Scan(&count)
+	if !storePosition || aircraft.ADSB == nil {
+		return nil
+	}
 
+	// Convert ADSB data to JSON
+	rawData, err := json.Marshal(aircraft.ADSB)
 	if err != nil {
-		s.logger.Error("Error checking for unique ADSB target",
-			logger.Error(err),
-			logger.String("hex", aircraft.Hex))
-		return false, err
+		return fmt.Errorf("failed to marshal ADSB data: %w", err)
+	}
+
+	// Source type, registration and aircraft type (populated for external API)
+	sourceType := "local"
+	if aircraft.ADSB.SourceType != "" {
+		sourceType = aircraft.ADSB.SourceType
+	}
+
+	if _, err := tx.Stmt(stmts.insertTarget).Exec(
+		aircraft.Hex, aircraft.ADSB.Hex, aircraft.ADSB.Type, aircraft.ADSB.Flight,
+		aircraft.ADSB.Registration, aircraft.ADSB.AircraftType,
+		aircraft.ADSB.AltBaro, aircraft.ADSB.AltGeom, aircraft.ADSB.GS, aircraft.ADSB.IAS,
+		aircraft.ADSB.TAS, aircraft.ADSB.Mach, aircraft.ADSB.WD, aircraft.ADSB.WS,
+		aircraft.ADSB.OAT, aircraft.ADSB.TAT, aircraft.ADSB.Track, aircraft.ADSB.TrackRate,
+		aircraft.ADSB.Roll, aircraft.ADSB.MagHeading, aircraft.ADSB.TrueHeading,
+		aircraft.ADSB.BaroRate, aircraft.ADSB.GeomRate, aircraft.ADSB.Squawk,
+		"", aircraft.ADSB.Category, aircraft.ADSB.NavQNH,
+		aircraft.ADSB.NavAltitudeMCP, aircraft.ADSB.NavAltitudeFMS, aircraft.ADSB.NavHeading,
+		"", aircraft.ADSB.Lat, aircraft.ADSB.Lon,
+		aircraft.ADSB.NIC, aircraft.ADSB.RC, aircraft.ADSB.SeenPos, aircraft.ADSB.RDst,
+		aircraft.ADSB.RDir, aircraft.ADSB.Version, aircraft.ADSB.NICBaro, aircraft.ADSB.NACP,
+		aircraft.ADSB.NACV, aircraft.ADSB.SIL, aircraft.ADSB.SILType, aircraft.ADSB.GVA,
+		aircraft.ADSB.SDA, aircraft.ADSB.Alert, aircraft.ADSB.SPI,
+		"", "", // MLAT and TISB as strings (we'll store them as empty strings for now)
+		aircraft.ADSB.Messages, aircraft.ADSB.Seen, aircraft.ADSB.RSSI,
+		aircraft.LastSeen.Format(time.RFC3339), string(rawData), sourceType,
+	); err != nil {
+		return fmt.Errorf("failed to insert ADSB target: %w", err)
 	}
 
-	//s.logger.Debug("Checked for unique ADSB target",
-	//	logger.String("hex", aircraft.Hex),
-	//	logger.Int("count", count),
-	//	logger.Bool("is_unique", count == 0))
-
-	return count == 0, nil
+	return nil
 }
 
 // Count returns the number of aircraft in the database
@@ -1573,6 +1396,8 @@ func (s *AircraftStorage) populatePhaseData(aircraft *adsb.Aircraft) error {
 
 // GetLatestADSBTargetID returns the ID of the latest ADSB target record for an aircraft
 func (s *AircraftStorage) GetLatestADSBTargetID(hex string) (*int, error) {
+	// The target may still be queued
+	s.Flush()
 
 	row := s.db.QueryRow(`
 		SELECT id
@@ -1661,6 +1486,9 @@ func (s *AircraftStorage) GetLatestADSBTargetIDsBatch(hexCodes []string) (map[st
 		return make(map[string]*int), nil
 	}
 
+	// Targets may still be queued
+	s.Flush()
+
 	// Create placeholders for the IN clause
 	placeholders := make([]string, len(hexCodes))
 	args := make([]interface{}, len(hexCodes))
@@ -1721,8 +1549,8 @@ func (s *AircraftStorage) InsertPhaseChangesBatch(changes []adsb.PhaseChangeInse
 
 	// Prepare the insert statement
 	stmt, err := tx.Prepare(`
-		INSERT INTO phase_changes (hex, flight, phase, timestamp, adsb_id)
-		VALUES (?, ?, ?, ?, ?)
+		INSERT INTO phase_changes (hex, flight, phase, timestamp, adsb_id, correlation_id)
+		VALUES (?, ?, ?, ?, ?, ?)
 	`)
 	if err != nil {
 		return fmt.Errorf("failed to prepare phase change insert statement: %w", err)
@@ -1737,6 +1565,7 @@ func (s *AircraftStorage) InsertPhaseChangesBatch(changes []adsb.PhaseChangeInse
 			change.Phase,
 			change.Timestamp.Format(time.RFC3339),
 			change.ADSBId,
+			change.CorrelationID,
 		)
 		if err != nil {
 			return fmt.Errorf("failed to insert phase change for %s: %w", change.Hex, err)
@@ -0,0 +1,96 @@
+package sqlite
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/yegors/co-atc/pkg/logger"
+)
+
+// ATISRecord is a stored ATIS information letter
+type ATISRecord struct {
+	ID         int64     `json:"id"`
+	Airport    string    `json:"airport"`
+	Type       string    `json:"type"` // "combined", "arr" or "dep"
+	Letter     string    `json:"letter"`
+	Text       string    `json:"text"`
+	ReceivedAt time.Time `json:"received_at"` // When the letter was first seen
+}
+
+// ATISStorage handles storage of the ATIS history
+type ATISStorage struct {
+	db     *sql.DB
+	logger *logger.Logger
+}
+
+// NewATISStorage creates a new SQLite ATIS storage
+func NewATISStorage(db *sql.DB, logger *logger.Logger) *ATISStorage {
+	return &ATISStorage{
+		db:     db,
+		logger: logger.Named("sqlite-atis"),
+	}
+}
+
+// AddATIS stores a new information letter
+func (s *ATISStorage) AddATIS(record *ATISRecord) error {
+	result, err := s.db.Exec(
+		`INSERT INTO atis_history (airport, type, letter, text, received_at) VALUES (?, ?, ?, ?, ?)`,
+		record.Airport, record.Type, record.Letter, record.Text, record.ReceivedAt.UTC().Format(time.RFC3339),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to insert ATIS: %w", err)
+	}
+	record.ID, _ = result.LastInsertId()
+	return nil
+}
+
+// GetLatestATIS returns the latest stored information of each ATIS type of an airport
+func (s *ATISStorage) GetLatestATIS(airport string) ([]*ATISRecord, error) {
+	rows, err := s.db.Query(
+		`SELECT id, airport, type, letter, text, received_at
+		FROM atis_history
+		WHERE id IN (SELECT MAX(id) FROM atis_history WHERE airport = ? GROUP BY type)
+		ORDER BY type`,
+		airport,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get latest ATIS: %w", err)
+	}
+	defer rows.Close()
+
+	return scanATIS(rows)
+}
+
+// ListATIS returns up to limit information letters of an airport received since a time, newest first
+func (s *ATISStorage) ListATIS(airport string, since time.Time, limit int) ([]*ATISRecord, error) {
+	rows, err := s.db.Query(
+		`SELECT id, airport, type, letter, text, received_at
+		FROM atis_history
+		WHERE airport = ? AND received_at >= ?
+		ORDER BY received_at DESC, id DESC
+		LIMIT ?`,
+		airport, since.UTC().Format(time.RFC3339), limit,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list ATIS: %w", err)
+	}
+	defer rows.Close()
+
+	return scanATIS(rows)
+}
+
+// scanATIS scans ATIS rows
+func scanATIS(rows *sql.Rows) ([]*ATISRecord, error) {
+	records := make([]*ATISRecord, 0)
+	for rows.Next() {
+		var record ATISRecord
+		var receivedAt string
+		if err := rows.Scan(&record.ID, &record.Airport, &record.Type, &record.Letter, &record.Text, &receivedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan ATIS: %w", err)
+		}
+		record.ReceivedAt, _ = time.Parse(time.RFC3339, receivedAt)
+		records = append(records, &record)
+	}
+	return records, rows.Err()
+}
@@ -0,0 +1,266 @@
+package sqlite
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/yegors/co-atc/pkg/logger"
+)
+
+// ChatSessionRecord is a stored ATC chat session. The session's client secret is never stored.
+type ChatSessionRecord struct {
+	ID              string     `json:"id"`
+	ClientID        string     `json:"client_id,omitempty"`
+	OpenAISessionID string     `json:"openai_session_id"`
+	Model           string     `json:"model,omitempty"`
+	Persona         string     `json:"persona,omitempty"`
+	CreatedAt       time.Time  `json:"created_at"`
+	ExpiresAt       time.Time  `json:"expires_at"`
+	EndedAt         *time.Time `json:"ended_at,omitempty"`
+	EndReason       string     `json:"end_reason,omitempty"` // "ended", "expired", "shutdown" or "restart"
+	Messages        int        `json:"messages"`
+	LastActivity    time.Time  `json:"last_activity"`
+}
+
+// ChatMessageRecord is a stored turn of an ATC chat conversation
+type ChatMessageRecord struct {
+	ID        int64     `json:"id"`
+	SessionID string    `json:"session_id"`
+	Role      string    `json:"role"` // "user" or "assistant"
+	Content   string    `json:"content"`
+	TurnID    string    `json:"turn_id,omitempty"` // Correlation ID of the chat turn
+	ItemID    string    `json:"item_id,omitempty"` // OpenAI conversation item holding the turn's audio
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// ChatHistoryStorage handles storage of ATC chat sessions and their transcripts
+type ChatHistoryStorage struct {
+	db     *sql.DB
+	logger *logger.Logger
+}
+
+// NewChatHistoryStorage creates a new SQLite chat history storage
+func NewChatHistoryStorage(db *sql.DB, logger *logger.Logger) *ChatHistoryStorage {
+	return &ChatHistoryStorage{
+		db:     db,
+		logger: logger.Named("sqlite-chat-history"),
+	}
+}
+
+// SaveSession stores a new session
+func (s *ChatHistoryStorage) SaveSession(session *ChatSessionRecord) error {
+	_, err := s.db.Exec(
+		`INSERT OR REPLACE INTO chat_sessions
+		(id, client_id, openai_session_id, model, persona, created_at, expires_at, end_reason, messages, last_activity)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		session.ID,
+		session.ClientID,
+		session.OpenAISessionID,
+		session.Model,
+		session.Persona,
+		session.CreatedAt.UTC().Format(time.RFC3339),
+		session.ExpiresAt.UTC().Format(time.RFC3339),
+		session.EndReason,
+		session.Messages,
+		session.CreatedAt.UTC().Format(time.RFC3339),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to insert chat session: %w", err)
+	}
+	return nil
+}
+
+// EndSession marks a session as ended. Sessions that already ended are left as they are.
+func (s *ChatHistoryStorage) EndSession(sessionID string, endedAt time.Time, reason string) error {
+	_, err := s.db.Exec(
+		`UPDATE chat_sessions SET ended_at = ?, end_reason = ? WHERE id = ? AND ended_at IS NULL`,
+		endedAt.UTC().Format(time.RFC3339), reason, sessionID,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to end chat session: %w", err)
+	}
+	return nil
+}
+
+// EndOpenSessions marks every session that hasn't ended as ended, for sessions left open
+// when the server stopped. It returns the number of sessions ended.
+func (s *ChatHistoryStorage) EndOpenSessions(endedAt time.Time, reason string) (int64, error) {
+	result, err := s.db.Exec(
+		`UPDATE chat_sessions SET ended_at = ?, end_reason = ? WHERE ended_at IS NULL`,
+		endedAt.UTC().Format(time.RFC3339), reason,
+	)
+	if err != nil {
+		return 0, fmt.Errorf("failed to end open chat sessions: %w", err)
+	}
+	return result.RowsAffected()
+}
+
+// AddMessage stores a turn of a session's conversation and updates the session's activity
+func (s *ChatHistoryStorage) AddMessage(message *ChatMessageRecord) error {
+	createdAt := message.CreatedAt.UTC().Format(time.RFC3339)
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	result, err := tx.Exec(
+		`INSERT INTO chat_messages (session_id, role, content, turn_id, item_id, created_at)
+		VALUES (?, ?, ?, ?, ?, ?)`,
+		message.SessionID, message.Role, message.Content, message.TurnID, message.ItemID, createdAt,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to insert chat message: %w", err)
+	}
+	message.ID, _ = result.LastInsertId()
+
+	if _, err := tx.Exec(
+		`UPDATE chat_sessions SET messages = messages + 1, last_activity = ? WHERE id = ?`,
+		createdAt, message.SessionID,
+	); err != nil {
+		return fmt.Errorf("failed to update chat session activity: %w", err)
+	}
+
+	return tx.Commit()
+}
+
+// GetSession returns a stored session, or nil if it doesn't exist
+func (s *ChatHistoryStorage) GetSession(sessionID string) (*ChatSessionRecord, error) {
+	rows, err := s.db.Query(chatSessionSelect+` WHERE id = ?`, sessionID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get chat session: %w", err)
+	}
+	defer rows.Close()
+
+	sessions, err := scanChatSessions(rows)
+	if err != nil || len(sessions) == 0 {
+		return nil, err
+	}
+	return sessions[0], nil
+}
+
+// ListSessions returns stored sessions, newest first. An empty client ID lists all clients' sessions.
+func (s *ChatHistoryStorage) ListSessions(clientID string, limit, offset int) ([]*ChatSessionRecord, error) {
+	query := chatSessionSelect
+	args := []interface{}{}
+	if clientID != "" {
+		query += ` WHERE client_id = ?`
+		args = append(args, clientID)
+	}
+	query += ` ORDER BY created_at DESC, id DESC LIMIT ? OFFSET ?`
+	args = append(args, limit, offset)
+
+	rows, err := s.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list chat sessions: %w", err)
+	}
+	defer rows.Close()
+
+	return scanChatSessions(rows)
+}
+
+// GetMessages returns up to limit turns of a session's conversation, oldest first
+func (s *ChatHistoryStorage) GetMessages(sessionID string, limit int) ([]*ChatMessageRecord, error) {
+	rows, err := s.db.Query(
+		`SELECT id, session_id, role, content, turn_id, item_id, created_at
+		FROM chat_messages
+		WHERE session_id = ?
+		ORDER BY id
+		LIMIT ?`,
+		sessionID, limit,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get chat messages: %w", err)
+	}
+	defer rows.Close()
+
+	messages := make([]*ChatMessageRecord, 0)
+	for rows.Next() {
+		var message ChatMessageRecord
+		var createdAt string
+		if err := rows.Scan(&message.ID, &message.SessionID, &message.Role, &message.Content,
+			&message.TurnID, &message.ItemID, &createdAt); err != nil {
+			return nil, fmt.Errorf("failed to scan chat message: %w", err)
+		}
+		message.CreatedAt, _ = time.Parse(time.RFC3339, createdAt)
+		messages = append(messages, &message)
+	}
+	return messages, rows.Err()
+}
+
+// DeleteClient removes all sessions and transcripts of a client
+func (s *ChatHistoryStorage) DeleteClient(clientID string) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(
+		`DELETE FROM chat_messages WHERE session_id IN (SELECT id FROM chat_sessions WHERE client_id = ?)`,
+		clientID,
+	); err != nil {
+		return fmt.Errorf("failed to delete chat messages: %w", err)
+	}
+	if _, err := tx.Exec(`DELETE FROM chat_sessions WHERE client_id = ?`, clientID); err != nil {
+		return fmt.Errorf("failed to delete chat sessions: %w", err)
+	}
+
+	return tx.Commit()
+}
+
+// DeleteSessionsBefore removes ended sessions created before the cutoff, with their transcripts
+func (s *ChatHistoryStorage) DeleteSessionsBefore(cutoff time.Time) (int64, error) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	cutoffStr := cutoff.UTC().Format(time.RFC3339)
+	if _, err := tx.Exec(
+		`DELETE FROM chat_messages WHERE session_id IN
+		(SELECT id FROM chat_sessions WHERE created_at < ? AND ended_at IS NOT NULL)`,
+		cutoffStr,
+	); err != nil {
+		return 0, fmt.Errorf("failed to delete old chat messages: %w", err)
+	}
+
+	result, err := tx.Exec(`DELETE FROM chat_sessions WHERE created_at < ? AND ended_at IS NOT NULL`, cutoffStr)
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete old chat sessions: %w", err)
+	}
+	deleted, _ := result.RowsAffected()
+
+	return deleted, tx.Commit()
+}
+
+// chatSessionSelect selects the columns scanChatSessions reads
+const chatSessionSelect = `SELECT id, client_id, openai_session_id, model, persona, created_at, expires_at,
+	ended_at, end_reason, messages, last_activity FROM chat_sessions`
+
+// scanChatSessions reads the rows of a chatSessionSelect query
+func scanChatSessions(rows *sql.Rows) ([]*ChatSessionRecord, error) {
+	sessions := make([]*ChatSessionRecord, 0)
+	for rows.Next() {
+		var session ChatSessionRecord
+		var createdAt, expiresAt, lastActivity string
+		var endedAt sql.NullString
+		if err := rows.Scan(&session.ID, &session.ClientID, &session.OpenAISessionID, &session.Model,
+			&session.Persona, &createdAt, &expiresAt, &endedAt, &session.EndReason, &session.Messages, &lastActivity); err != nil {
+			return nil, fmt.Errorf("failed to scan chat session: %w", err)
+		}
+		session.CreatedAt, _ = time.Parse(time.RFC3339, createdAt)
+		session.ExpiresAt, _ = time.Parse(time.RFC3339, expiresAt)
+		session.LastActivity, _ = time.Parse(time.RFC3339, lastActivity)
+		if endedAt.Valid {
+			if t, err := time.Parse(time.RFC3339, endedAt.String); err == nil {
+				session.EndedAt = &t
+			}
+		}
+		sessions = append(sessions, &session)
+	}
+	return sessions, rows.Err()
+}
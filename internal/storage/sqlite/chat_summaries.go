@@ -0,0 +1,113 @@
+package sqlite
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/yegors/co-atc/pkg/logger"
+)
+
+// ChatSessionSummary is a short record of an ATC chat session, kept per client so the
+// next session of the same client can pick up where the last one left off
+type ChatSessionSummary struct {
+	ID        int64     `json:"id"`
+	ClientID  string    `json:"client_id"`
+	SessionID string    `json:"session_id"`
+	StartedAt time.Time `json:"started_at"`
+	EndedAt   time.Time `json:"ended_at"`
+	Turns     int       `json:"turns"`
+	Topics    []string  `json:"topics"`
+	Aircraft  []string  `json:"aircraft"`  // Callsigns referenced in the session
+	Questions []string  `json:"questions"` // Last questions the user asked
+}
+
+// ChatSummaryStorage handles storage of ATC chat session summaries
+type ChatSummaryStorage struct {
+	db     *sql.DB
+	logger *logger.Logger
+}
+
+// NewChatSummaryStorage creates a new SQLite chat summary storage
+func NewChatSummaryStorage(db *sql.DB, logger *logger.Logger) *ChatSummaryStorage {
+	return &ChatSummaryStorage{
+		db:     db,
+		logger: logger.Named("sqlite-chat"),
+	}
+}
+
+// SaveSummary stores a session summary
+func (s *ChatSummaryStorage) SaveSummary(summary *ChatSessionSummary) error {
+	topics, _ := json.Marshal(summary.Topics)
+	aircraft, _ := json.Marshal(summary.Aircraft)
+	questions, _ := json.Marshal(summary.Questions)
+
+	result, err := s.db.Exec(
+		`INSERT INTO chat_session_summaries
+		(client_id, session_id, started_at, ended_at, turns, topics, aircraft, questions)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)`,
+		summary.ClientID,
+		summary.SessionID,
+		summary.StartedAt.UTC().Format(time.RFC3339),
+		summary.EndedAt.UTC().Format(time.RFC3339),
+		summary.Turns,
+		string(topics),
+		string(aircraft),
+		string(questions),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to insert chat summary: %w", err)
+	}
+
+	summary.ID, _ = result.LastInsertId()
+	return nil
+}
+
+// GetLatestSummary returns the most recent summary of a client, or nil if it has none
+func (s *ChatSummaryStorage) GetLatestSummary(clientID string) (*ChatSessionSummary, error) {
+	row := s.db.QueryRow(
+		`SELECT id, client_id, session_id, started_at, ended_at, turns, topics, aircraft, questions
+		FROM chat_session_summaries
+		WHERE client_id = ?
+		ORDER BY ended_at DESC, id DESC
+		LIMIT 1`,
+		clientID,
+	)
+
+	var summary ChatSessionSummary
+	var startedAt, endedAt, topics, aircraft, questions string
+	err := row.Scan(&summary.ID, &summary.ClientID, &summary.SessionID, &startedAt, &endedAt,
+		&summary.Turns, &topics, &aircraft, &questions)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get chat summary: %w", err)
+	}
+
+	summary.StartedAt, _ = time.Parse(time.RFC3339, startedAt)
+	summary.EndedAt, _ = time.Parse(time.RFC3339, endedAt)
+	json.Unmarshal([]byte(topics), &summary.Topics)
+	json.Unmarshal([]byte(aircraft), &summary.Aircraft)
+	json.Unmarshal([]byte(questions), &summary.Questions)
+
+	return &summary, nil
+}
+
+// DeleteSummaries removes all summaries of a client
+func (s *ChatSummaryStorage) DeleteSummaries(clientID string) error {
+	if _, err := s.db.Exec(`DELETE FROM chat_session_summaries WHERE client_id = ?`, clientID); err != nil {
+		return fmt.Errorf("failed to delete chat summaries: %w", err)
+	}
+	return nil
+}
+
+// DeleteSummariesBefore removes summaries of sessions that ended before the cutoff
+func (s *ChatSummaryStorage) DeleteSummariesBefore(cutoff time.Time) (int64, error) {
+	result, err := s.db.Exec(`DELETE FROM chat_session_summaries WHERE ended_at < ?`, cutoff.UTC().Format(time.RFC3339))
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete old chat summaries: %w", err)
+	}
+	return result.RowsAffected()
+}
@@ -7,18 +7,24 @@ type ClearanceRecord struct {
 	ID              int64     `json:"id"`
 	TranscriptionID int64     `json:"transcription_id"`
 	Callsign        string    `json:"callsign"`
-	ClearanceType   string    `json:"clearance_type"` // "takeoff" or "landing"
+	ClearanceType   string    `json:"clearance_type"` // "takeoff", "landing", "approach", "taxi", "altitude" or "heading"
 	ClearanceText   string    `json:"clearance_text"`
 	Runway          string    `json:"runway,omitempty"`
 	Timestamp       time.Time `json:"timestamp"`
 	Status          string    `json:"status"` // "issued", "complied", "deviation"
 	CreatedAt       time.Time `json:"created_at"`
+	CorrelationID   string    `json:"correlation_id,omitempty"` // Correlation ID of the source transmission
+	AircraftHex     string    `json:"aircraft_hex,omitempty"`   // Tracked aircraft the callsign was correlated with
+	Altitude        int       `json:"altitude,omitempty"`       // Assigned altitude in feet, for altitude clearances
+	Heading         int       `json:"heading,omitempty"`        // Assigned magnetic heading, for heading clearances
 }
 
 // ExtractedClearance represents clearance data from AI processing
 type ExtractedClearance struct {
 	Callsign string `json:"callsign"`
-	Type     string `json:"type"` // "takeoff" or "landing"
+	Type     string `json:"type"` // "takeoff", "landing", "approach", "taxi", "altitude" or "heading"
 	Text     string `json:"text"` // Full clearance text
 	Runway   string `json:"runway,omitempty"`
+	Altitude int    `json:"altitude,omitempty"` // Assigned altitude in feet (flight level 350 is 35000)
+	Heading  int    `json:"heading,omitempty"`  // Assigned magnetic heading in degrees
 }
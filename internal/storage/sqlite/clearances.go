@@ -3,6 +3,7 @@ package sqlite
 import (
 	"database/sql"
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/yegors/co-atc/pkg/logger"
@@ -11,71 +12,26 @@ import (
 // ClearanceStorage handles storage of clearance records
 type ClearanceStorage struct {
 	db     *sql.DB
+	stmts  *statementCache // Frequent writes
 	logger *logger.Logger
 }
 
 // NewClearanceStorage creates a new SQLite clearance storage
 func NewClearanceStorage(db *sql.DB, logger *logger.Logger) *ClearanceStorage {
-	storage := &ClearanceStorage{
+	return &ClearanceStorage{
 		db:     db,
+		stmts:  newStatementCache(db),
 		logger: logger.Named("sqlite-clearances"),
 	}
-
-	// Initialize database
-	if err := storage.initDB(); err != nil {
-		logger.Error("Failed to initialize clearance storage", Error(err))
-	}
-
-	return storage
-}
-
-// initDB initializes the database tables
-func (s *ClearanceStorage) initDB() error {
-	// Create clearances table
-	_, err := s.db.Exec(`
-		CREATE TABLE IF NOT EXISTS clearances (
-			id INTEGER PRIMARY KEY AUTOINCREMENT,
-			transcription_id INTEGER NOT NULL,
-			callsign TEXT NOT NULL,
-			clearance_type TEXT NOT NULL,
-			clearance_text TEXT NOT NULL,
-			runway TEXT,
-			timestamp TIMESTAMP NOT NULL,
-			status TEXT NOT NULL DEFAULT 'issued',
-			created_at TIMESTAMP NOT NULL,
-			FOREIGN KEY (transcription_id) REFERENCES transcriptions(id)
-		)
-	`)
-	if err != nil {
-		return fmt.Errorf("failed to create clearances table: %w", err)
-	}
-
-	// Create indexes for performance
-	indexes := []string{
-		`CREATE INDEX IF NOT EXISTS idx_clearances_callsign ON clearances(callsign)`,
-		`CREATE INDEX IF NOT EXISTS idx_clearances_timestamp ON clearances(timestamp)`,
-		`CREATE INDEX IF NOT EXISTS idx_clearances_type ON clearances(clearance_type)`,
-		`CREATE INDEX IF NOT EXISTS idx_clearances_status ON clearances(status)`,
-		`CREATE INDEX IF NOT EXISTS idx_clearances_transcription_id ON clearances(transcription_id)`,
-	}
-
-	for _, indexSQL := range indexes {
-		_, err = s.db.Exec(indexSQL)
-		if err != nil {
-			return fmt.Errorf("failed to create clearance index: %w", err)
-		}
-	}
-
-	return nil
 }
 
 // StoreClearance stores a clearance record
 func (s *ClearanceStorage) StoreClearance(record *ClearanceRecord) (int64, error) {
 	// Insert record
-	result, err := s.db.Exec(
+	result, err := s.stmts.exec(
 		`INSERT INTO clearances 
-		(transcription_id, callsign, clearance_type, clearance_text, runway, timestamp, status, created_at) 
-		VALUES (?, ?, ?, ?, ?, ?, ?, ?)`,
+		(transcription_id, callsign, clearance_type, clearance_text, runway, timestamp, status, created_at, correlation_id, aircraft_hex, altitude, heading) 
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
 		record.TranscriptionID,
 		record.Callsign,
 		record.ClearanceType,
@@ -84,6 +40,10 @@ func (s *ClearanceStorage) StoreClearance(record *ClearanceRecord) (int64, error
 		record.Timestamp.Format(time.RFC3339),
 		record.Status,
 		record.CreatedAt.Format(time.RFC3339),
+		record.CorrelationID,
+		nullIfEmpty(record.AircraftHex),
+		nullIfZero(record.Altitude),
+		nullIfZero(record.Heading),
 	)
 	if err != nil {
 		return 0, fmt.Errorf("failed to insert clearance: %w", err)
@@ -102,7 +62,7 @@ func (s *ClearanceStorage) StoreClearance(record *ClearanceRecord) (int64, error
 func (s *ClearanceStorage) GetClearancesByCallsign(callsign string, limit int) ([]*ClearanceRecord, error) {
 	// Query records
 	rows, err := s.db.Query(
-		`SELECT id, transcription_id, callsign, clearance_type, clearance_text, runway, timestamp, status, created_at 
+		`SELECT id, transcription_id, callsign, clearance_type, clearance_text, runway, timestamp, status, created_at, correlation_id, aircraft_hex, altitude, heading 
 		FROM clearances 
 		WHERE callsign = ? 
 		ORDER BY timestamp DESC 
@@ -117,11 +77,30 @@ func (s *ClearanceStorage) GetClearancesByCallsign(callsign string, limit int) (
 	return s.scanClearanceRows(rows)
 }
 
+// GetClearancesByAircraft returns an aircraft's clearances, newest first: those linked to
+// its hex, and those with its callsign that aren't linked to any aircraft
+func (s *ClearanceStorage) GetClearancesByAircraft(hex, callsign string, limit int) ([]*ClearanceRecord, error) {
+	rows, err := s.db.Query(
+		`SELECT id, transcription_id, callsign, clearance_type, clearance_text, runway, timestamp, status, created_at, correlation_id, aircraft_hex, altitude, heading
+		FROM clearances
+		WHERE aircraft_hex = ? OR (aircraft_hex IS NULL AND callsign != '' AND callsign = ?)
+		ORDER BY timestamp DESC
+		LIMIT ?`,
+		strings.ToLower(hex), callsign, limit,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query clearances by aircraft: %w", err)
+	}
+	defer rows.Close()
+
+	return s.scanClearanceRows(rows)
+}
+
 // GetClearancesByTimeRange returns clearances within a time range
 func (s *ClearanceStorage) GetClearancesByTimeRange(startTime, endTime time.Time) ([]*ClearanceRecord, error) {
 	// Query records
 	rows, err := s.db.Query(
-		`SELECT id, transcription_id, callsign, clearance_type, clearance_text, runway, timestamp, status, created_at 
+		`SELECT id, transcription_id, callsign, clearance_type, clearance_text, runway, timestamp, status, created_at, correlation_id, aircraft_hex, altitude, heading 
 		FROM clearances 
 		WHERE timestamp BETWEEN ? AND ? 
 		ORDER BY timestamp DESC`,
@@ -139,7 +118,7 @@ func (s *ClearanceStorage) GetClearancesByTimeRange(startTime, endTime time.Time
 func (s *ClearanceStorage) GetClearancesByType(clearanceType string, limit int) ([]*ClearanceRecord, error) {
 	// Query records
 	rows, err := s.db.Query(
-		`SELECT id, transcription_id, callsign, clearance_type, clearance_text, runway, timestamp, status, created_at 
+		`SELECT id, transcription_id, callsign, clearance_type, clearance_text, runway, timestamp, status, created_at, correlation_id, aircraft_hex, altitude, heading 
 		FROM clearances 
 		WHERE clearance_type = ? 
 		ORDER BY timestamp DESC 
@@ -154,7 +133,71 @@ func (s *ClearanceStorage) GetClearancesByType(clearanceType string, limit int)
 	return s.scanClearanceRows(rows)
 }
 
-// UpdateClearanceStatus updates the status of a clearance (for Phase 2 compliance monitoring)
+// GetClearancesForExport returns up to limit clearances issued in a time range with an
+// ID after afterID, oldest first
+func (s *ClearanceStorage) GetClearancesForExport(startTime, endTime time.Time, afterID int64, limit int) ([]*ClearanceRecord, error) {
+	rows, err := s.db.Query(
+		`SELECT id, transcription_id, callsign, clearance_type, clearance_text, runway, timestamp, status, created_at, correlation_id, aircraft_hex, altitude, heading
+		FROM clearances
+		WHERE timestamp BETWEEN ? AND ? AND id > ?
+		ORDER BY id
+		LIMIT ?`,
+		startTime.UTC().Format(time.RFC3339), endTime.UTC().Format(time.RFC3339), afterID, limit,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query clearances for export: %w", err)
+	}
+	defer rows.Close()
+
+	return s.scanClearanceRows(rows)
+}
+
+// GetIssuedClearances returns the clearances of the given types with an ID after afterID,
+// issued since a time and still in the "issued" status, oldest first
+func (s *ClearanceStorage) GetIssuedClearances(types []string, since time.Time, afterID int64) ([]*ClearanceRecord, error) {
+	if len(types) == 0 {
+		return nil, nil
+	}
+	args := make([]interface{}, 0, len(types)+2)
+	for _, clearanceType := range types {
+		args = append(args, clearanceType)
+	}
+	args = append(args, since.UTC().Format(time.RFC3339), afterID)
+
+	rows, err := s.db.Query(
+		`SELECT id, transcription_id, callsign, clearance_type, clearance_text, runway, timestamp, status, created_at, correlation_id, aircraft_hex, altitude, heading
+		FROM clearances
+		WHERE clearance_type IN (?`+strings.Repeat(", ?", len(types)-1)+`) AND timestamp >= ? AND id > ? AND status = 'issued'
+		ORDER BY id`,
+		args...,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query issued clearances: %w", err)
+	}
+	defer rows.Close()
+
+	return s.scanClearanceRows(rows)
+}
+
+// GetClearancesSince returns the clearances with an ID after afterID issued since a time,
+// whatever their status, oldest first
+func (s *ClearanceStorage) GetClearancesSince(since time.Time, afterID int64) ([]*ClearanceRecord, error) {
+	rows, err := s.db.Query(
+		`SELECT id, transcription_id, callsign, clearance_type, clearance_text, runway, timestamp, status, created_at, correlation_id, aircraft_hex, altitude, heading
+		FROM clearances
+		WHERE timestamp >= ? AND id > ?
+		ORDER BY id`,
+		since.UTC().Format(time.RFC3339), afterID,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query clearances since %s: %w", since.Format(time.RFC3339), err)
+	}
+	defer rows.Close()
+
+	return s.scanClearanceRows(rows)
+}
+
+// UpdateClearanceStatus updates the status of a clearance, as compliance monitoring finds it "complied" or a "deviation"
 func (s *ClearanceStorage) UpdateClearanceStatus(id int64, status string) error {
 	// Update record
 	_, err := s.db.Exec(
@@ -175,7 +218,7 @@ func (s *ClearanceStorage) UpdateClearanceStatus(id int64, status string) error
 func (s *ClearanceStorage) GetRecentClearances(limit int) ([]*ClearanceRecord, error) {
 	// Query records
 	rows, err := s.db.Query(
-		`SELECT id, transcription_id, callsign, clearance_type, clearance_text, runway, timestamp, status, created_at 
+		`SELECT id, transcription_id, callsign, clearance_type, clearance_text, runway, timestamp, status, created_at, correlation_id, aircraft_hex, altitude, heading 
 		FROM clearances 
 		ORDER BY timestamp DESC 
 		LIMIT ?`,
@@ -189,13 +232,32 @@ func (s *ClearanceStorage) GetRecentClearances(limit int) ([]*ClearanceRecord, e
 	return s.scanClearanceRows(rows)
 }
 
+// GetClearancesByCorrelationID returns clearances extracted from the transmission with the given correlation ID
+func (s *ClearanceStorage) GetClearancesByCorrelationID(correlationID string) ([]*ClearanceRecord, error) {
+	// Query records
+	rows, err := s.db.Query(
+		`SELECT id, transcription_id, callsign, clearance_type, clearance_text, runway, timestamp, status, created_at, correlation_id, aircraft_hex, altitude, heading 
+		FROM clearances 
+		WHERE correlation_id = ? 
+		ORDER BY timestamp ASC`,
+		correlationID,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query clearances by correlation ID: %w", err)
+	}
+	defer rows.Close()
+
+	return s.scanClearanceRows(rows)
+}
+
 // scanClearanceRows scans database rows into ClearanceRecord structs
 func (s *ClearanceStorage) scanClearanceRows(rows *sql.Rows) ([]*ClearanceRecord, error) {
 	var records []*ClearanceRecord
 	for rows.Next() {
 		var record ClearanceRecord
 		var timestamp, createdAt string
-		var runway sql.NullString
+		var runway, correlationID, aircraftHex sql.NullString
+		var altitude, heading sql.NullInt64
 
 		if err := rows.Scan(
 			&record.ID,
@@ -207,6 +269,10 @@ func (s *ClearanceStorage) scanClearanceRows(rows *sql.Rows) ([]*ClearanceRecord
 			&timestamp,
 			&record.Status,
 			&createdAt,
+			&correlationID,
+			&aircraftHex,
+			&altitude,
+			&heading,
 		); err != nil {
 			return nil, fmt.Errorf("failed to scan clearance: %w", err)
 		}
@@ -227,6 +293,14 @@ func (s *ClearanceStorage) scanClearanceRows(rows *sql.Rows) ([]*ClearanceRecord
 		if runway.Valid {
 			record.Runway = runway.String
 		}
+		if correlationID.Valid {
+			record.CorrelationID = correlationID.String
+		}
+		if aircraftHex.Valid {
+			record.AircraftHex = aircraftHex.String
+		}
+		record.Altitude = int(altitude.Int64)
+		record.Heading = int(heading.Int64)
 
 		records = append(records, &record)
 	}
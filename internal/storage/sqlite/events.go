@@ -0,0 +1,175 @@
+package sqlite
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/yegors/co-atc/pkg/logger"
+)
+
+// EventRecord is an alert raised by any service, kept for the event timeline
+type EventRecord struct {
+	ID               int64                  `json:"id"`
+	Type             string                 `json:"type"`     // Alert type, e.g. "emergency", "runway" or "deviation"
+	Severity         string                 `json:"severity"` // "info", "warning" or "critical"
+	Title            string                 `json:"title"`
+	Body             string                 `json:"body,omitempty"`
+	Aircraft         []string               `json:"aircraft"`          // Hex codes of the aircraft involved
+	Callsigns        []string               `json:"callsigns"`         // Callsigns of the aircraft involved
+	TranscriptionIDs []int64                `json:"transcription_ids"` // Transcriptions the event relates to
+	Data             map[string]interface{} `json:"data,omitempty"`    // Details of the alert, by type
+	CreatedAt        time.Time              `json:"created_at"`
+}
+
+// EventFilter selects events. Zero fields don't filter.
+type EventFilter struct {
+	Types           []string
+	Severities      []string
+	Hex             string
+	Callsign        string
+	TranscriptionID int64
+	Since           time.Time
+	Until           time.Time
+	Limit           int
+	Offset          int
+}
+
+// EventStorage handles storage of the event timeline
+type EventStorage struct {
+	db     *sql.DB
+	logger *logger.Logger
+}
+
+// NewEventStorage creates a new SQLite event storage
+func NewEventStorage(db *sql.DB, logger *logger.Logger) *EventStorage {
+	return &EventStorage{
+		db:     db,
+		logger: logger.Named("sqlite-events"),
+	}
+}
+
+// StoreEvent stores an event and sets its ID
+func (s *EventStorage) StoreEvent(event *EventRecord) error {
+	var data interface{}
+	if len(event.Data) > 0 {
+		encoded, err := json.Marshal(event.Data)
+		if err != nil {
+			return fmt.Errorf("failed to encode event data: %w", err)
+		}
+		data = string(encoded)
+	}
+
+	ids := make([]string, len(event.TranscriptionIDs))
+	for i, id := range event.TranscriptionIDs {
+		ids[i] = strconv.FormatInt(id, 10)
+	}
+
+	result, err := s.db.Exec(
+		`INSERT INTO events (type, severity, title, body, aircraft, callsigns, transcription_ids, data, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		event.Type,
+		event.Severity,
+		event.Title,
+		event.Body,
+		strings.Join(event.Aircraft, ","),
+		strings.Join(event.Callsigns, ","),
+		strings.Join(ids, ","),
+		data,
+		event.CreatedAt.UTC().Format(recordingTimeFormat),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to insert event: %w", err)
+	}
+	event.ID, err = result.LastInsertId()
+	return err
+}
+
+// GetEvents returns the events matching a filter, most recent first
+func (s *EventStorage) GetEvents(filter EventFilter) ([]*EventRecord, error) {
+	var conditions []string
+	var args []interface{}
+	if len(filter.Types) > 0 {
+		conditions = append(conditions, "type IN (?"+strings.Repeat(", ?", len(filter.Types)-1)+")")
+		for _, t := range filter.Types {
+			args = append(args, t)
+		}
+	}
+	if len(filter.Severities) > 0 {
+		conditions = append(conditions, "severity IN (?"+strings.Repeat(", ?", len(filter.Severities)-1)+")")
+		for _, severity := range filter.Severities {
+			args = append(args, severity)
+		}
+	}
+	// Lists are comma-separated, so an item is matched with commas around it
+	if filter.Hex != "" {
+		conditions = append(conditions, "instr(',' || aircraft || ',', ',' || ? || ',') > 0")
+		args = append(args, strings.ToLower(filter.Hex))
+	}
+	if filter.Callsign != "" {
+		conditions = append(conditions, "instr(',' || callsigns || ',', ',' || ? || ',') > 0")
+		args = append(args, strings.ToUpper(filter.Callsign))
+	}
+	if filter.TranscriptionID > 0 {
+		conditions = append(conditions, "instr(',' || transcription_ids || ',', ',' || ? || ',') > 0")
+		args = append(args, strconv.FormatInt(filter.TranscriptionID, 10))
+	}
+	if !filter.Since.IsZero() {
+		conditions = append(conditions, "created_at >= ?")
+		args = append(args, filter.Since.UTC().Format(recordingTimeFormat))
+	}
+	if !filter.Until.IsZero() {
+		conditions = append(conditions, "created_at <= ?")
+		args = append(args, filter.Until.UTC().Format(recordingTimeFormat))
+	}
+
+	where := ""
+	if len(conditions) > 0 {
+		where = "WHERE " + strings.Join(conditions, " AND ")
+	}
+	args = append(args, filter.Limit, filter.Offset)
+
+	rows, err := s.db.Query(
+		`SELECT id, type, severity, title, body, aircraft, callsigns, transcription_ids, data, created_at
+		FROM events
+		`+where+`
+		ORDER BY created_at DESC, id DESC
+		LIMIT ? OFFSET ?`,
+		args...,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query events: %w", err)
+	}
+	defer rows.Close()
+
+	events := make([]*EventRecord, 0)
+	for rows.Next() {
+		var event EventRecord
+		var aircraft, callsigns, transcriptionIDs, createdAt string
+		var data sql.NullString
+		if err := rows.Scan(&event.ID, &event.Type, &event.Severity, &event.Title, &event.Body,
+			&aircraft, &callsigns, &transcriptionIDs, &data, &createdAt); err != nil {
+			return nil, fmt.Errorf("failed to scan event: %w", err)
+		}
+
+		event.Aircraft = splitList(aircraft)
+		event.Callsigns = splitList(callsigns)
+		event.TranscriptionIDs = make([]int64, 0)
+		for _, id := range splitList(transcriptionIDs) {
+			if value, err := strconv.ParseInt(id, 10, 64); err == nil {
+				event.TranscriptionIDs = append(event.TranscriptionIDs, value)
+			}
+		}
+		if data.Valid && data.String != "" {
+			if err := json.Unmarshal([]byte(data.String), &event.Data); err != nil {
+				s.logger.Warn("Failed to parse event data", Error(err))
+			}
+		}
+		event.CreatedAt, _ = time.Parse(time.RFC3339, createdAt)
+		events = append(events, &event)
+	}
+	return events, rows.Err()
+}
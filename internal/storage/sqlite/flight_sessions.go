@@ -0,0 +1,211 @@
+package sqlite
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/yegors/co-atc/pkg/logger"
+)
+
+// FlightSessionRecord is one flight of an aircraft: its contiguous track from first seen to
+// last seen, with the runways it departed from and arrived on at the airport
+type FlightSessionRecord struct {
+	ID              int64      `json:"id"`
+	Hex             string     `json:"hex"`
+	Callsign        string     `json:"callsign,omitempty"`
+	Registration    string     `json:"registration,omitempty"`
+	AircraftType    string     `json:"aircraft_type,omitempty"`
+	Operator        string     `json:"operator,omitempty"` // Airline name from the callsign, empty for private flights
+	FirstSeen       time.Time  `json:"first_seen"`
+	LastSeen        time.Time  `json:"last_seen"`
+	DurationSeconds int64      `json:"duration_seconds"`
+	DepartureRunway string     `json:"departure_runway,omitempty"` // Threshold ID, empty if unknown
+	DepartedAt      *time.Time `json:"departed_at,omitempty"`      // Nil unless the aircraft took off from the airport
+	ArrivalRunway   string     `json:"arrival_runway,omitempty"`
+	ArrivedAt       *time.Time `json:"arrived_at,omitempty"` // Nil unless the aircraft landed at the airport
+	Active          bool       `json:"active"`               // Still being seen
+}
+
+// FlightSessionStorage handles storage of flight sessions
+type FlightSessionStorage struct {
+	db     *sql.DB
+	logger *logger.Logger
+}
+
+// NewFlightSessionStorage creates a new SQLite flight session storage
+func NewFlightSessionStorage(db *sql.DB, logger *logger.Logger) *FlightSessionStorage {
+	return &FlightSessionStorage{
+		db:     db,
+		logger: logger.Named("sqlite-sessions"),
+	}
+}
+
+// AddSession stores a session and sets its ID
+func (s *FlightSessionStorage) AddSession(record *FlightSessionRecord) error {
+	result, err := s.db.Exec(
+		`INSERT INTO flight_sessions
+		(hex, callsign, registration, aircraft_type, operator, first_seen, last_seen,
+		departure_runway, departed_at, arrival_runway, arrived_at, active)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		record.Hex,
+		record.Callsign,
+		record.Registration,
+		record.AircraftType,
+		record.Operator,
+		record.FirstSeen.UTC().Format(time.RFC3339),
+		record.LastSeen.UTC().Format(time.RFC3339),
+		record.DepartureRunway,
+		formatSessionTime(record.DepartedAt),
+		record.ArrivalRunway,
+		formatSessionTime(record.ArrivedAt),
+		record.Active,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to insert flight session: %w", err)
+	}
+	record.ID, err = result.LastInsertId()
+	return err
+}
+
+// UpdateSession stores what changed in a session since it was added
+func (s *FlightSessionStorage) UpdateSession(record *FlightSessionRecord) error {
+	_, err := s.db.Exec(
+		`UPDATE flight_sessions SET callsign = ?, registration = ?, aircraft_type = ?, operator = ?,
+		last_seen = ?, departure_runway = ?, departed_at = ?, arrival_runway = ?, arrived_at = ?, active = ?
+		WHERE id = ?`,
+		record.Callsign,
+		record.Registration,
+		record.AircraftType,
+		record.Operator,
+		record.LastSeen.UTC().Format(time.RFC3339),
+		record.DepartureRunway,
+		formatSessionTime(record.DepartedAt),
+		record.ArrivalRunway,
+		formatSessionTime(record.ArrivedAt),
+		record.Active,
+		record.ID,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to update flight session: %w", err)
+	}
+	return nil
+}
+
+// ListSessions returns the sessions seen during a time range, most recently started first,
+// optionally of one aircraft. A zero limit returns them all.
+func (s *FlightSessionStorage) ListSessions(start, end time.Time, hex string, limit int) ([]*FlightSessionRecord, error) {
+	if limit <= 0 {
+		limit = -1
+	}
+	rows, err := s.db.Query(
+		`SELECT `+flightSessionColumns+`
+		FROM flight_sessions
+		WHERE first_seen < ? AND last_seen >= ? AND (? = '' OR hex = ?)
+		ORDER BY first_seen DESC, id DESC
+		LIMIT ?`,
+		end.UTC().Format(time.RFC3339), start.UTC().Format(time.RFC3339), hex, hex, limit,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list flight sessions: %w", err)
+	}
+	defer rows.Close()
+	return scanFlightSessions(rows)
+}
+
+// ListDepartures returns the sessions that departed the airport during a time range, most
+// recent first. A zero limit returns them all.
+func (s *FlightSessionStorage) ListDepartures(start, end time.Time, limit int) ([]*FlightSessionRecord, error) {
+	return s.listMovements("departed_at", start, end, limit)
+}
+
+// ListArrivals returns the sessions that arrived at the airport during a time range, most
+// recent first. A zero limit returns them all.
+func (s *FlightSessionStorage) ListArrivals(start, end time.Time, limit int) ([]*FlightSessionRecord, error) {
+	return s.listMovements("arrived_at", start, end, limit)
+}
+
+// listMovements returns the sessions whose departed_at or arrived_at column falls in a time range
+func (s *FlightSessionStorage) listMovements(column string, start, end time.Time, limit int) ([]*FlightSessionRecord, error) {
+	if limit <= 0 {
+		limit = -1
+	}
+	rows, err := s.db.Query(
+		`SELECT `+flightSessionColumns+`
+		FROM flight_sessions
+		WHERE `+column+` >= ? AND `+column+` < ?
+		ORDER BY `+column+` DESC, id DESC
+		LIMIT ?`,
+		start.UTC().Format(time.RFC3339), end.UTC().Format(time.RFC3339), limit,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list movements: %w", err)
+	}
+	defer rows.Close()
+	return scanFlightSessions(rows)
+}
+
+// EndActiveSessions marks the sessions still active, left by a shutdown, as ended at their
+// last seen time. It returns how many were ended.
+func (s *FlightSessionStorage) EndActiveSessions() (int64, error) {
+	result, err := s.db.Exec(`UPDATE flight_sessions SET active = 0 WHERE active = 1`)
+	if err != nil {
+		return 0, fmt.Errorf("failed to end active flight sessions: %w", err)
+	}
+	return result.RowsAffected()
+}
+
+// DeleteSessionsBefore deletes sessions last seen before a time and returns how many were deleted
+func (s *FlightSessionStorage) DeleteSessionsBefore(cutoff time.Time) (int64, error) {
+	result, err := s.db.Exec(`DELETE FROM flight_sessions WHERE last_seen < ?`, cutoff.UTC().Format(time.RFC3339))
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete flight sessions: %w", err)
+	}
+	return result.RowsAffected()
+}
+
+// flightSessionColumns are the columns scanFlightSessions reads
+const flightSessionColumns = `id, hex, callsign, registration, aircraft_type, operator, first_seen, last_seen,
+	departure_runway, departed_at, arrival_runway, arrived_at, active`
+
+// scanFlightSessions reads the rows of a flight session query
+func scanFlightSessions(rows *sql.Rows) ([]*FlightSessionRecord, error) {
+	records := make([]*FlightSessionRecord, 0)
+	for rows.Next() {
+		var record FlightSessionRecord
+		var firstSeen, lastSeen string
+		var departedAt, arrivedAt sql.NullString
+		if err := rows.Scan(&record.ID, &record.Hex, &record.Callsign, &record.Registration, &record.AircraftType,
+			&record.Operator, &firstSeen, &lastSeen, &record.DepartureRunway, &departedAt,
+			&record.ArrivalRunway, &arrivedAt, &record.Active); err != nil {
+			return nil, fmt.Errorf("failed to scan flight session: %w", err)
+		}
+		record.FirstSeen, _ = time.Parse(time.RFC3339, firstSeen)
+		record.LastSeen, _ = time.Parse(time.RFC3339, lastSeen)
+		record.DurationSeconds = int64(record.LastSeen.Sub(record.FirstSeen).Seconds())
+		record.DepartedAt = parseSessionTime(departedAt)
+		record.ArrivedAt = parseSessionTime(arrivedAt)
+		records = append(records, &record)
+	}
+	return records, rows.Err()
+}
+
+// formatSessionTime formats an optional departure or arrival time
+func formatSessionTime(t *time.Time) interface{} {
+	if t == nil {
+		return nil
+	}
+	return t.UTC().Format(time.RFC3339)
+}
+
+// parseSessionTime parses an optional departure or arrival time
+func parseSessionTime(value sql.NullString) *time.Time {
+	if !value.Valid {
+		return nil
+	}
+	t, err := time.Parse(time.RFC3339, value.String)
+	if err != nil {
+		return nil
+	}
+	return &t
+}
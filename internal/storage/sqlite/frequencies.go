@@ -0,0 +1,139 @@
+package sqlite
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/yegors/co-atc/pkg/logger"
+)
+
+// FrequencyRecord represents a frequency managed at runtime through the API.
+// Records overlay the frequencies defined in the config file: a record with the
+// same ID replaces the configured frequency, and a deleted record hides it.
+type FrequencyRecord struct {
+	ID              string    `json:"id"`
+	Airport         string    `json:"airport"`
+	Name            string    `json:"name"`
+	FrequencyMHz    float64   `json:"frequency_mhz"`
+	URL             string    `json:"url"`
+	Order           int       `json:"order"`
+	TranscribeAudio bool      `json:"transcribe_audio"`
+	AudioDelayMs    int       `json:"audio_delay_ms"`
+	Deleted         bool      `json:"deleted"`
+	UpdatedAt       time.Time `json:"updated_at"`
+}
+
+// FrequencyStorage handles storage of runtime frequency changes
+type FrequencyStorage struct {
+	db     *sql.DB
+	logger *logger.Logger
+}
+
+// NewFrequencyStorage creates a new SQLite frequency storage
+func NewFrequencyStorage(db *sql.DB, logger *logger.Logger) *FrequencyStorage {
+	return &FrequencyStorage{
+		db:     db,
+		logger: logger.Named("sqlite-freqs"),
+	}
+}
+
+// UpsertFrequency stores a frequency, replacing any previous record with the same ID
+func (s *FrequencyStorage) UpsertFrequency(record *FrequencyRecord) error {
+	_, err := s.db.Exec(
+		`INSERT INTO frequencies
+		(id, airport, name, frequency_mhz, url, display_order, transcribe_audio, audio_delay_ms, deleted, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT(id) DO UPDATE SET
+			airport = excluded.airport,
+			name = excluded.name,
+			frequency_mhz = excluded.frequency_mhz,
+			url = excluded.url,
+			display_order = excluded.display_order,
+			transcribe_audio = excluded.transcribe_audio,
+			audio_delay_ms = excluded.audio_delay_ms,
+			deleted = excluded.deleted,
+			updated_at = excluded.updated_at`,
+		record.ID,
+		record.Airport,
+		record.Name,
+		record.FrequencyMHz,
+		record.URL,
+		record.Order,
+		record.TranscribeAudio,
+		record.AudioDelayMs,
+		record.Deleted,
+		record.UpdatedAt.Format(time.RFC3339),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to upsert frequency %s: %w", record.ID, err)
+	}
+
+	return nil
+}
+
+// MarkFrequencyDeleted records that a frequency was removed so it stays removed after a restart
+func (s *FrequencyStorage) MarkFrequencyDeleted(id string) error {
+	result, err := s.db.Exec(
+		`UPDATE frequencies SET deleted = 1, updated_at = ? WHERE id = ?`,
+		time.Now().UTC().Format(time.RFC3339),
+		id,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to delete frequency %s: %w", id, err)
+	}
+
+	// Frequencies that only exist in the config file need a tombstone row
+	if affected, _ := result.RowsAffected(); affected == 0 {
+		return s.UpsertFrequency(&FrequencyRecord{
+			ID:        id,
+			Deleted:   true,
+			UpdatedAt: time.Now().UTC(),
+		})
+	}
+
+	return nil
+}
+
+// GetFrequencies returns all stored frequency records, including deleted ones
+func (s *FrequencyStorage) GetFrequencies() ([]*FrequencyRecord, error) {
+	rows, err := s.db.Query(
+		`SELECT id, airport, name, frequency_mhz, url, display_order, transcribe_audio, audio_delay_ms, deleted, updated_at
+		FROM frequencies
+		ORDER BY display_order ASC`,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query frequencies: %w", err)
+	}
+	defer rows.Close()
+
+	var records []*FrequencyRecord
+	for rows.Next() {
+		var record FrequencyRecord
+		var updatedAt string
+
+		if err := rows.Scan(
+			&record.ID,
+			&record.Airport,
+			&record.Name,
+			&record.FrequencyMHz,
+			&record.URL,
+			&record.Order,
+			&record.TranscribeAudio,
+			&record.AudioDelayMs,
+			&record.Deleted,
+			&updatedAt,
+		); err != nil {
+			return nil, fmt.Errorf("failed to scan frequency: %w", err)
+		}
+
+		record.UpdatedAt, err = time.Parse(time.RFC3339, updatedAt)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse updated_at: %w", err)
+		}
+
+		records = append(records, &record)
+	}
+
+	return records, nil
+}
@@ -0,0 +1,259 @@
+package sqlite
+
+import (
+	"database/sql"
+	"embed"
+	"fmt"
+	"io/fs"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/yegors/co-atc/pkg/logger"
+)
+
+//go:embed migrations
+var migrationFiles embed.FS
+
+// Schema names a set of migrations for one kind of database
+type Schema string
+
+const (
+	DailySchema    Schema = "daily"    // Rotated daily database: tracks, transcriptions, clearances
+	SettingsSchema Schema = "settings" // Persistent co-atc.db: frequencies, recordings, push, records, usage
+)
+
+// migrationFileName matches migration files: <version>_<name>.<up|down>.sql
+var migrationFileName = regexp.MustCompile(`^(\d+)_([a-z0-9_]+)\.(up|down)\.sql$`)
+
+// migration is one versioned schema change
+type migration struct {
+	version int
+	name    string
+	up      string
+	down    string // Empty if the migration cannot be rolled back
+}
+
+// legacyColumns are the columns that builds before migrations added to existing tables
+// on startup. A database from such a build may lack some of them, so they are added
+// before the initial migration adopts its tables.
+var legacyColumns = map[Schema][]struct{ table, column, definition string }{
+	DailySchema: {
+		{"phase_changes", "correlation_id", "TEXT"},
+		{"transcriptions", "correlation_id", "TEXT"},
+		{"transcriptions", "audio_start_time", "TEXT"},
+		{"transcriptions", "audio_end_time", "TEXT"},
+		{"transcriptions", "confidence", "REAL"},
+		{"transcriptions", "words", "TEXT"},
+		{"clearances", "correlation_id", "TEXT"},
+	},
+}
+
+// loadMigrations reads a schema's embedded migrations, oldest first
+func loadMigrations(schema Schema) ([]migration, error) {
+	dir := path.Join("migrations", string(schema))
+	entries, err := fs.ReadDir(migrationFiles, dir)
+	if err != nil {
+		return nil, fmt.Errorf("unknown schema %q: %w", schema, err)
+	}
+
+	byVersion := make(map[int]*migration)
+	for _, entry := range entries {
+		match := migrationFileName.FindStringSubmatch(entry.Name())
+		if match == nil {
+			return nil, fmt.Errorf("invalid migration file name: %s", entry.Name())
+		}
+		version, _ := strconv.Atoi(match[1])
+		content, err := migrationFiles.ReadFile(path.Join(dir, entry.Name()))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read migration %s: %w", entry.Name(), err)
+		}
+
+		m := byVersion[version]
+		if m == nil {
+			m = &migration{version: version, name: match[2]}
+			byVersion[version] = m
+		} else if m.name != match[2] {
+			return nil, fmt.Errorf("migration %d has two names: %s and %s", version, m.name, match[2])
+		}
+		if match[3] == "up" {
+			m.up = string(content)
+		} else {
+			m.down = string(content)
+		}
+	}
+
+	migrations := make([]migration, 0, len(byVersion))
+	for _, m := range byVersion {
+		if m.up == "" {
+			return nil, fmt.Errorf("migration %d_%s has no up file", m.version, m.name)
+		}
+		migrations = append(migrations, *m)
+	}
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].version < migrations[j].version })
+	return migrations, nil
+}
+
+// SchemaVersion returns the version a database is migrated to (0 for a new database) and
+// the latest version of the schema this build knows
+func SchemaVersion(db *sql.DB, schema Schema) (current, latest int, err error) {
+	migrations, err := loadMigrations(schema)
+	if err != nil {
+		return 0, 0, err
+	}
+	if len(migrations) > 0 {
+		latest = migrations[len(migrations)-1].version
+	}
+
+	if err := createMigrationsTable(db); err != nil {
+		return 0, 0, err
+	}
+	current, err = appliedVersion(db)
+	return current, latest, err
+}
+
+// Migrate brings a database up to the latest version of its schema. Each migration runs
+// in its own transaction, so a failed one leaves the database at the previous version.
+// A database migrated by a newer build is refused rather than used with a schema this
+// build does not know.
+func Migrate(db *sql.DB, schema Schema, log *logger.Logger) error {
+	migrations, err := loadMigrations(schema)
+	if err != nil {
+		return err
+	}
+	if err := createMigrationsTable(db); err != nil {
+		return err
+	}
+	current, err := appliedVersion(db)
+	if err != nil {
+		return err
+	}
+
+	if len(migrations) > 0 && current > migrations[len(migrations)-1].version {
+		return fmt.Errorf("%s database is at schema version %d, newer than this build supports (%d)",
+			schema, current, migrations[len(migrations)-1].version)
+	}
+
+	if current == 0 {
+		if err := upgradeLegacySchema(db, schema); err != nil {
+			return err
+		}
+	}
+
+	for _, m := range migrations {
+		if m.version <= current {
+			continue
+		}
+		if err := applyMigration(db, m.up, func(tx *sql.Tx) error {
+			_, err := tx.Exec(`INSERT INTO schema_migrations (version, name, applied_at) VALUES (?, ?, ?)`,
+				m.version, m.name, time.Now().UTC().Format(time.RFC3339))
+			return err
+		}); err != nil {
+			return fmt.Errorf("failed to apply %s migration %d_%s: %w", schema, m.version, m.name, err)
+		}
+		log.Info("Applied database migration",
+			logger.String("schema", string(schema)),
+			logger.Int("version", m.version),
+			logger.String("name", m.name))
+	}
+
+	return nil
+}
+
+// MigrateDown rolls a database back to a version of its schema by running the down
+// migrations of every later version, newest first
+func MigrateDown(db *sql.DB, schema Schema, target int, log *logger.Logger) error {
+	migrations, err := loadMigrations(schema)
+	if err != nil {
+		return err
+	}
+	if err := createMigrationsTable(db); err != nil {
+		return err
+	}
+	current, err := appliedVersion(db)
+	if err != nil {
+		return err
+	}
+
+	for i := len(migrations) - 1; i >= 0; i-- {
+		m := migrations[i]
+		if m.version <= target || m.version > current {
+			continue
+		}
+		if m.down == "" {
+			return fmt.Errorf("%s migration %d_%s cannot be rolled back", schema, m.version, m.name)
+		}
+		if err := applyMigration(db, m.down, func(tx *sql.Tx) error {
+			_, err := tx.Exec(`DELETE FROM schema_migrations WHERE version = ?`, m.version)
+			return err
+		}); err != nil {
+			return fmt.Errorf("failed to roll back %s migration %d_%s: %w", schema, m.version, m.name, err)
+		}
+		log.Info("Rolled back database migration",
+			logger.String("schema", string(schema)),
+			logger.Int("version", m.version),
+			logger.String("name", m.name))
+	}
+
+	return nil
+}
+
+// createMigrationsTable creates the table recording which migrations have been applied
+func createMigrationsTable(db *sql.DB) error {
+	_, err := db.Exec(`
+		CREATE TABLE IF NOT EXISTS schema_migrations (
+			version INTEGER PRIMARY KEY,
+			name TEXT NOT NULL,
+			applied_at TIMESTAMP NOT NULL
+		)
+	`)
+	if err != nil {
+		return fmt.Errorf("failed to create schema_migrations table: %w", err)
+	}
+	return nil
+}
+
+// appliedVersion returns the highest applied migration version, or 0 if there is none
+func appliedVersion(db *sql.DB) (int, error) {
+	var version sql.NullInt64
+	if err := db.QueryRow(`SELECT MAX(version) FROM schema_migrations`).Scan(&version); err != nil {
+		return 0, fmt.Errorf("failed to read schema version: %w", err)
+	}
+	return int(version.Int64), nil
+}
+
+// applyMigration runs a migration's SQL and records the change in one transaction
+func applyMigration(db *sql.DB, statements string, record func(tx *sql.Tx) error) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(statements); err != nil {
+		return err
+	}
+	if err := record(tx); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// upgradeLegacySchema adds the columns a database created before migrations may lack
+func upgradeLegacySchema(db *sql.DB, schema Schema) error {
+	for _, c := range legacyColumns[schema] {
+		var exists int
+		if err := db.QueryRow(`SELECT COUNT(*) FROM sqlite_master WHERE type = 'table' AND name = ?`, c.table).Scan(&exists); err != nil {
+			return fmt.Errorf("failed to check for table %s: %w", c.table, err)
+		}
+		if exists == 0 {
+			continue
+		}
+		if err := ensureColumn(db, c.table, c.column, c.definition); err != nil {
+			return err
+		}
+	}
+	return nil
+}
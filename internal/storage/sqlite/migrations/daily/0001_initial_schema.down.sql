@@ -0,0 +1,9 @@
+DROP TABLE IF EXISTS clearances;
+DROP TRIGGER IF EXISTS transcriptions_fts_update;
+DROP TRIGGER IF EXISTS transcriptions_fts_delete;
+DROP TRIGGER IF EXISTS transcriptions_fts_insert;
+DROP TABLE IF EXISTS transcriptions_fts;
+DROP TABLE IF EXISTS transcriptions;
+DROP TABLE IF EXISTS phase_changes;
+DROP TABLE IF EXISTS adsb_targets;
+DROP TABLE IF EXISTS aircraft;
@@ -0,0 +1,166 @@
+-- Daily database: aircraft tracks, transcriptions and clearances.
+-- Tables use IF NOT EXISTS so databases created before migrations were introduced
+-- are adopted as they are.
+
+CREATE TABLE IF NOT EXISTS aircraft (
+	hex TEXT PRIMARY KEY,
+	flight TEXT,
+	airline TEXT,
+	status TEXT,
+	last_seen TIMESTAMP,
+	on_ground INTEGER DEFAULT 0,
+	created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+	updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+-- All possible fields from both local and external APIs
+CREATE TABLE IF NOT EXISTS adsb_targets (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	aircraft_hex TEXT,
+	hex TEXT,
+	type TEXT,
+	flight TEXT,
+	registration TEXT,      -- External API specific field (r)
+	aircraft_type TEXT,     -- External API specific field (t)
+	alt_baro REAL,
+	alt_geom REAL,
+	gs REAL,
+	ias REAL,
+	tas REAL,
+	mach REAL,
+	wd REAL,
+	ws REAL,
+	oat REAL,
+	tat REAL,
+	track REAL,
+	track_rate REAL,
+	roll REAL,
+	mag_heading REAL,
+	true_heading REAL,
+	baro_rate REAL,
+	geom_rate REAL,
+	squawk TEXT,
+	emergency TEXT,
+	category TEXT,
+	nav_qnh REAL,
+	nav_altitude_mcp REAL,
+	nav_altitude_fms REAL,
+	nav_heading REAL,
+	nav_modes TEXT,
+	lat REAL,
+	lon REAL,
+	nic INTEGER,
+	rc INTEGER,
+	seen_pos REAL,
+	r_dst REAL,
+	r_dir REAL,
+	version INTEGER,
+	nic_baro INTEGER,
+	nac_p INTEGER,
+	nac_v INTEGER,
+	sil INTEGER,
+	sil_type TEXT,
+	gva INTEGER,
+	sda INTEGER,
+	alert INTEGER,
+	spi INTEGER,
+	mlat TEXT,
+	tisb TEXT,
+	messages INTEGER,
+	seen REAL,
+	rssi REAL,
+	timestamp TIMESTAMP,
+	raw_data TEXT,
+	source_type TEXT,       -- Indicates whether data came from "local" or "external" source
+	FOREIGN KEY (aircraft_hex) REFERENCES aircraft(hex) ON DELETE CASCADE,
+	UNIQUE(aircraft_hex, lat, lon, alt_baro, gs, tas, track)
+);
+
+-- Flight phase transitions
+CREATE TABLE IF NOT EXISTS phase_changes (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	hex TEXT NOT NULL,
+	flight TEXT,
+	phase TEXT NOT NULL,
+	timestamp TIMESTAMP NOT NULL,
+	adsb_id INTEGER,
+	correlation_id TEXT,        -- Poll cycle that detected the change
+	FOREIGN KEY (adsb_id) REFERENCES adsb_targets(id),
+	FOREIGN KEY (hex) REFERENCES aircraft(hex) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_adsb_targets_aircraft_hex ON adsb_targets(aircraft_hex);
+CREATE INDEX IF NOT EXISTS idx_adsb_targets_timestamp ON adsb_targets(timestamp);
+-- Critical composite index for efficient latest record queries
+CREATE INDEX IF NOT EXISTS idx_adsb_targets_hex_timestamp ON adsb_targets(aircraft_hex, timestamp DESC);
+CREATE INDEX IF NOT EXISTS idx_aircraft_status ON aircraft(status);
+CREATE INDEX IF NOT EXISTS idx_aircraft_last_seen ON aircraft(last_seen);
+CREATE INDEX IF NOT EXISTS idx_phase_changes_hex_timestamp ON phase_changes(hex, timestamp);
+CREATE INDEX IF NOT EXISTS idx_phase_changes_phase_timestamp ON phase_changes(phase, timestamp);
+
+CREATE TABLE IF NOT EXISTS transcriptions (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	frequency_id TEXT NOT NULL,
+	created_at TIMESTAMP NOT NULL,
+	content TEXT NOT NULL,
+	is_complete BOOLEAN NOT NULL,
+	is_processed BOOLEAN NOT NULL,
+	content_processed TEXT,
+	speaker_type TEXT,
+	callsign TEXT,
+	correlation_id TEXT,
+	audio_start_time TEXT,
+	audio_end_time TEXT,
+	confidence REAL,
+	words TEXT
+);
+
+CREATE INDEX IF NOT EXISTS idx_frequency_id ON transcriptions(frequency_id);
+CREATE INDEX IF NOT EXISTS idx_created_at ON transcriptions(created_at);
+CREATE INDEX IF NOT EXISTS idx_speaker_type ON transcriptions(speaker_type);
+CREATE INDEX IF NOT EXISTS idx_callsign ON transcriptions(callsign);
+CREATE INDEX IF NOT EXISTS idx_transcriptions_correlation_id ON transcriptions(correlation_id);
+
+-- Full-text index over the raw and processed content, kept in step by triggers
+CREATE VIRTUAL TABLE IF NOT EXISTS transcriptions_fts USING fts5(
+	content, content_processed,
+	content = 'transcriptions', content_rowid = 'id',
+	tokenize = 'unicode61'
+);
+
+CREATE TRIGGER IF NOT EXISTS transcriptions_fts_insert AFTER INSERT ON transcriptions BEGIN
+	INSERT INTO transcriptions_fts(rowid, content, content_processed) VALUES (new.id, new.content, new.content_processed);
+END;
+
+CREATE TRIGGER IF NOT EXISTS transcriptions_fts_delete AFTER DELETE ON transcriptions BEGIN
+	INSERT INTO transcriptions_fts(transcriptions_fts, rowid, content, content_processed) VALUES ('delete', old.id, old.content, old.content_processed);
+END;
+
+CREATE TRIGGER IF NOT EXISTS transcriptions_fts_update AFTER UPDATE OF content, content_processed ON transcriptions BEGIN
+	INSERT INTO transcriptions_fts(transcriptions_fts, rowid, content, content_processed) VALUES ('delete', old.id, old.content, old.content_processed);
+	INSERT INTO transcriptions_fts(rowid, content, content_processed) VALUES (new.id, new.content, new.content_processed);
+END;
+
+-- Index transcriptions stored before the index existed
+INSERT INTO transcriptions_fts(transcriptions_fts) VALUES ('rebuild');
+
+CREATE TABLE IF NOT EXISTS clearances (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	transcription_id INTEGER NOT NULL,
+	callsign TEXT NOT NULL,
+	clearance_type TEXT NOT NULL,
+	clearance_text TEXT NOT NULL,
+	runway TEXT,
+	timestamp TIMESTAMP NOT NULL,
+	status TEXT NOT NULL DEFAULT 'issued',
+	created_at TIMESTAMP NOT NULL,
+	correlation_id TEXT,
+	FOREIGN KEY (transcription_id) REFERENCES transcriptions(id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_clearances_callsign ON clearances(callsign);
+CREATE INDEX IF NOT EXISTS idx_clearances_timestamp ON clearances(timestamp);
+CREATE INDEX IF NOT EXISTS idx_clearances_type ON clearances(clearance_type);
+CREATE INDEX IF NOT EXISTS idx_clearances_status ON clearances(status);
+CREATE INDEX IF NOT EXISTS idx_clearances_transcription_id ON clearances(transcription_id);
+CREATE INDEX IF NOT EXISTS idx_clearances_correlation_id ON clearances(correlation_id);
@@ -0,0 +1 @@
+CREATE INDEX IF NOT EXISTS idx_transcriptions_unprocessed ON transcriptions(created_at) WHERE is_complete = 1 AND is_processed = 0;
@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS idx_clearances_aircraft_hex;
+DROP INDEX IF EXISTS idx_transcriptions_aircraft_hex;
+
+ALTER TABLE clearances DROP COLUMN aircraft_hex;
+ALTER TABLE transcriptions DROP COLUMN aircraft_hex;
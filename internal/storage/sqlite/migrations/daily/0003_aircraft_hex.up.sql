@@ -0,0 +1,5 @@
+ALTER TABLE transcriptions ADD COLUMN aircraft_hex TEXT;
+ALTER TABLE clearances ADD COLUMN aircraft_hex TEXT;
+
+CREATE INDEX IF NOT EXISTS idx_transcriptions_aircraft_hex ON transcriptions(aircraft_hex);
+CREATE INDEX IF NOT EXISTS idx_clearances_aircraft_hex ON clearances(aircraft_hex);
@@ -0,0 +1,2 @@
+ALTER TABLE clearances DROP COLUMN heading;
+ALTER TABLE clearances DROP COLUMN altitude;
@@ -0,0 +1,2 @@
+ALTER TABLE clearances ADD COLUMN altitude INTEGER;
+ALTER TABLE clearances ADD COLUMN heading INTEGER;
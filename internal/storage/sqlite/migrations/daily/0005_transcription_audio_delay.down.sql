@@ -0,0 +1 @@
+ALTER TABLE transcriptions DROP COLUMN audio_delay_ms;
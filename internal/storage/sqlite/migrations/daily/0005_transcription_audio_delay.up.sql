@@ -0,0 +1 @@
+ALTER TABLE transcriptions ADD COLUMN audio_delay_ms INTEGER;
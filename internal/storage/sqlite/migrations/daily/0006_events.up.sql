@@ -0,0 +1,15 @@
+CREATE TABLE IF NOT EXISTS events (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	type TEXT NOT NULL,
+	severity TEXT NOT NULL,
+	title TEXT NOT NULL,
+	body TEXT NOT NULL DEFAULT '',
+	aircraft TEXT NOT NULL DEFAULT '',
+	callsigns TEXT NOT NULL DEFAULT '',
+	transcription_ids TEXT NOT NULL DEFAULT '',
+	data TEXT,
+	created_at TEXT NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_events_created_at ON events(created_at);
+CREATE INDEX IF NOT EXISTS idx_events_type ON events(type, created_at);
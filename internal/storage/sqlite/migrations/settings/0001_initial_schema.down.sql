@@ -0,0 +1,8 @@
+DROP TABLE IF EXISTS aircraft_type_sightings;
+DROP TABLE IF EXISTS station_records;
+DROP TABLE IF EXISTS chat_session_summaries;
+DROP TABLE IF EXISTS push_vapid_keys;
+DROP TABLE IF EXISTS push_deliveries;
+DROP TABLE IF EXISTS push_subscriptions;
+DROP TABLE IF EXISTS recording_segments;
+DROP TABLE IF EXISTS frequencies;
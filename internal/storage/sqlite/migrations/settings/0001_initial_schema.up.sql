@@ -0,0 +1,101 @@
+-- Settings database: state that survives across days and restarts.
+-- Tables use IF NOT EXISTS so databases created before migrations were introduced
+-- are adopted as they are.
+
+CREATE TABLE IF NOT EXISTS frequencies (
+	id TEXT PRIMARY KEY,
+	airport TEXT NOT NULL,
+	name TEXT NOT NULL,
+	frequency_mhz REAL NOT NULL,
+	url TEXT NOT NULL,
+	display_order INTEGER NOT NULL,
+	transcribe_audio BOOLEAN NOT NULL,
+	deleted BOOLEAN NOT NULL DEFAULT 0,
+	updated_at TIMESTAMP NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS recording_segments (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	frequency_id TEXT NOT NULL,
+	path TEXT NOT NULL,
+	format TEXT NOT NULL,
+	start_time TIMESTAMP NOT NULL,
+	end_time TIMESTAMP NOT NULL,
+	duration_ms INTEGER NOT NULL,
+	size_bytes INTEGER NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_recording_segments_frequency_time ON recording_segments(frequency_id, start_time);
+CREATE INDEX IF NOT EXISTS idx_recording_segments_end_time ON recording_segments(end_time);
+
+CREATE TABLE IF NOT EXISTS push_subscriptions (
+	id TEXT PRIMARY KEY,
+	endpoint TEXT NOT NULL UNIQUE,
+	p256dh TEXT NOT NULL,
+	auth TEXT NOT NULL,
+	alert_types TEXT NOT NULL,
+	user_agent TEXT NOT NULL DEFAULT '',
+	created_at TIMESTAMP NOT NULL,
+	last_delivery_at TIMESTAMP,
+	last_status TEXT NOT NULL DEFAULT '',
+	failure_count INTEGER NOT NULL DEFAULT 0
+);
+
+CREATE TABLE IF NOT EXISTS push_deliveries (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	subscription_id TEXT NOT NULL,
+	alert_type TEXT NOT NULL,
+	title TEXT NOT NULL,
+	status TEXT NOT NULL,
+	status_code INTEGER NOT NULL DEFAULT 0,
+	error TEXT NOT NULL DEFAULT '',
+	created_at TIMESTAMP NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_push_deliveries_subscription ON push_deliveries(subscription_id, created_at);
+
+-- Single-row table holding the generated VAPID key pair
+CREATE TABLE IF NOT EXISTS push_vapid_keys (
+	id INTEGER PRIMARY KEY CHECK (id = 1),
+	public_key TEXT NOT NULL,
+	private_key TEXT NOT NULL,
+	created_at TIMESTAMP NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS chat_session_summaries (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	client_id TEXT NOT NULL,
+	session_id TEXT NOT NULL,
+	started_at TIMESTAMP NOT NULL,
+	ended_at TIMESTAMP NOT NULL,
+	turns INTEGER NOT NULL,
+	topics TEXT NOT NULL,
+	aircraft TEXT NOT NULL,
+	questions TEXT NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_chat_session_summaries_client ON chat_session_summaries(client_id, ended_at);
+
+CREATE TABLE IF NOT EXISTS station_records (
+	station TEXT NOT NULL,
+	record TEXT NOT NULL,
+	value REAL NOT NULL,
+	unit TEXT NOT NULL,
+	hex TEXT,
+	flight TEXT,
+	aircraft_type TEXT,
+	detail TEXT,
+	set_at TIMESTAMP NOT NULL,
+	PRIMARY KEY (station, record)
+);
+
+CREATE TABLE IF NOT EXISTS aircraft_type_sightings (
+	station TEXT NOT NULL,
+	aircraft_type TEXT NOT NULL,
+	sightings INTEGER NOT NULL,
+	first_seen TIMESTAMP NOT NULL,
+	last_seen TIMESTAMP NOT NULL,
+	last_hex TEXT,
+	last_flight TEXT,
+	PRIMARY KEY (station, aircraft_type)
+);
@@ -0,0 +1,11 @@
+CREATE TABLE IF NOT EXISTS usage_daily (
+	day TEXT NOT NULL,
+	subsystem TEXT NOT NULL,
+	model TEXT NOT NULL,
+	requests INTEGER NOT NULL DEFAULT 0,
+	input_tokens INTEGER NOT NULL DEFAULT 0,
+	output_tokens INTEGER NOT NULL DEFAULT 0,
+	audio_seconds REAL NOT NULL DEFAULT 0,
+	cost_usd REAL NOT NULL DEFAULT 0,
+	PRIMARY KEY (day, subsystem, model)
+);
@@ -0,0 +1 @@
+ALTER TABLE chat_sessions ADD COLUMN persona TEXT NOT NULL DEFAULT '';
@@ -0,0 +1,10 @@
+CREATE TABLE IF NOT EXISTS atis_history (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	airport TEXT NOT NULL,
+	type TEXT NOT NULL,
+	letter TEXT NOT NULL,
+	text TEXT NOT NULL,
+	received_at TEXT NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_atis_history_airport ON atis_history(airport, received_at);
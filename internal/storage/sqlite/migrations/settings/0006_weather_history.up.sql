@@ -0,0 +1,20 @@
+CREATE TABLE IF NOT EXISTS weather_observations (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	airport TEXT NOT NULL,
+	observed_at TEXT NOT NULL,
+	raw TEXT NOT NULL,
+	provider TEXT NOT NULL DEFAULT '',
+	wind_direction_deg INTEGER,
+	wind_speed_kt INTEGER,
+	wind_gust_kt INTEGER,
+	visibility_sm REAL,
+	ceiling_ft INTEGER,
+	altimeter_inhg REAL,
+	qnh_hpa INTEGER,
+	temperature_c INTEGER,
+	dewpoint_c INTEGER,
+	flight_category TEXT NOT NULL DEFAULT '',
+	fetched_at TEXT NOT NULL
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_weather_observations_report ON weather_observations(airport, observed_at, raw);
@@ -0,0 +1,12 @@
+CREATE TABLE IF NOT EXISTS watchlists (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	name TEXT NOT NULL,
+	hex_codes TEXT NOT NULL DEFAULT '',
+	registrations TEXT NOT NULL DEFAULT '',
+	callsign_prefixes TEXT NOT NULL DEFAULT '',
+	aircraft_types TEXT NOT NULL DEFAULT '',
+	events TEXT NOT NULL DEFAULT '',
+	notify INTEGER NOT NULL DEFAULT 1,
+	created_at TEXT NOT NULL,
+	updated_at TEXT NOT NULL
+);
@@ -0,0 +1,10 @@
+CREATE TABLE IF NOT EXISTS prompt_versions (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	name TEXT NOT NULL,
+	version INTEGER NOT NULL,
+	content TEXT NOT NULL,
+	comment TEXT NOT NULL DEFAULT '',
+	active INTEGER NOT NULL DEFAULT 0,
+	created_at TEXT NOT NULL,
+	UNIQUE (name, version)
+);
@@ -0,0 +1 @@
+ALTER TABLE frequencies DROP COLUMN audio_delay_ms;
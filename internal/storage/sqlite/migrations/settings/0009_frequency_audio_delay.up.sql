@@ -0,0 +1 @@
+ALTER TABLE frequencies ADD COLUMN audio_delay_ms INTEGER NOT NULL DEFAULT 0;
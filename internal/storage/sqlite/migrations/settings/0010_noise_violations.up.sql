@@ -0,0 +1,16 @@
+CREATE TABLE IF NOT EXISTS noise_violations (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	zone TEXT NOT NULL,
+	hex TEXT NOT NULL,
+	callsign TEXT NOT NULL DEFAULT '',
+	registration TEXT NOT NULL DEFAULT '',
+	aircraft_type TEXT NOT NULL DEFAULT '',
+	operator TEXT NOT NULL DEFAULT '',
+	altitude_ft REAL NOT NULL,
+	lowest_altitude_ft REAL NOT NULL,
+	max_altitude_ft INTEGER NOT NULL,
+	started_at TEXT NOT NULL,
+	ended_at TEXT
+);
+
+CREATE INDEX IF NOT EXISTS idx_noise_violations_started_at ON noise_violations(started_at);
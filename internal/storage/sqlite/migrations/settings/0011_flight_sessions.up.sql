@@ -0,0 +1,20 @@
+CREATE TABLE IF NOT EXISTS flight_sessions (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	hex TEXT NOT NULL,
+	callsign TEXT NOT NULL DEFAULT '',
+	registration TEXT NOT NULL DEFAULT '',
+	aircraft_type TEXT NOT NULL DEFAULT '',
+	operator TEXT NOT NULL DEFAULT '',
+	first_seen TEXT NOT NULL,
+	last_seen TEXT NOT NULL,
+	departure_runway TEXT NOT NULL DEFAULT '',
+	departed_at TEXT,
+	arrival_runway TEXT NOT NULL DEFAULT '',
+	arrived_at TEXT,
+	active INTEGER NOT NULL DEFAULT 1
+);
+
+CREATE INDEX IF NOT EXISTS idx_flight_sessions_hex ON flight_sessions(hex);
+CREATE INDEX IF NOT EXISTS idx_flight_sessions_last_seen ON flight_sessions(last_seen);
+CREATE INDEX IF NOT EXISTS idx_flight_sessions_departed_at ON flight_sessions(departed_at);
+CREATE INDEX IF NOT EXISTS idx_flight_sessions_arrived_at ON flight_sessions(arrived_at);
@@ -0,0 +1,13 @@
+CREATE TABLE IF NOT EXISTS runway_occupancy (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	runway TEXT NOT NULL,
+	operation TEXT NOT NULL,
+	hex TEXT NOT NULL,
+	callsign TEXT NOT NULL DEFAULT '',
+	aircraft_type TEXT NOT NULL DEFAULT '',
+	entered_at TEXT NOT NULL,
+	exited_at TEXT NOT NULL,
+	seconds REAL NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_runway_occupancy_entered_at ON runway_occupancy(entered_at);
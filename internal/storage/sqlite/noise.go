@@ -0,0 +1,135 @@
+package sqlite
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/yegors/co-atc/pkg/logger"
+)
+
+// NoiseViolationRecord is an aircraft overflying a noise-sensitive zone below its altitude
+// during restricted times
+type NoiseViolationRecord struct {
+	ID               int64      `json:"id"`
+	Zone             string     `json:"zone"`
+	Hex              string     `json:"hex"`
+	Callsign         string     `json:"callsign,omitempty"`
+	Registration     string     `json:"registration,omitempty"`
+	AircraftType     string     `json:"aircraft_type,omitempty"`
+	Operator         string     `json:"operator,omitempty"` // Airline name from the callsign, empty for private flights
+	AltitudeFeet     float64    `json:"altitude_ft"`        // When the violation started
+	LowestAltitudeFt float64    `json:"lowest_altitude_ft"` // Over the whole overflight
+	MaxAltitudeFeet  int        `json:"max_altitude_ft"`    // Altitude the zone must be overflown above
+	StartedAt        time.Time  `json:"started_at"`
+	EndedAt          *time.Time `json:"ended_at,omitempty"` // Nil while the aircraft is still in violation
+}
+
+// NoiseStorage handles storage of noise-abatement violations
+type NoiseStorage struct {
+	db     *sql.DB
+	logger *logger.Logger
+}
+
+// NewNoiseStorage creates a new SQLite noise violation storage
+func NewNoiseStorage(db *sql.DB, logger *logger.Logger) *NoiseStorage {
+	return &NoiseStorage{
+		db:     db,
+		logger: logger.Named("sqlite-noise"),
+	}
+}
+
+// AddViolation stores a violation and sets its ID
+func (s *NoiseStorage) AddViolation(record *NoiseViolationRecord) error {
+	result, err := s.db.Exec(
+		`INSERT INTO noise_violations
+		(zone, hex, callsign, registration, aircraft_type, operator, altitude_ft, lowest_altitude_ft, max_altitude_ft, started_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		record.Zone,
+		record.Hex,
+		record.Callsign,
+		record.Registration,
+		record.AircraftType,
+		record.Operator,
+		record.AltitudeFeet,
+		record.LowestAltitudeFt,
+		record.MaxAltitudeFeet,
+		record.StartedAt.UTC().Format(time.RFC3339),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to insert noise violation: %w", err)
+	}
+	record.ID, err = result.LastInsertId()
+	return err
+}
+
+// EndViolation records the lowest altitude of a violation and when it ended
+func (s *NoiseStorage) EndViolation(id int64, lowestAltitudeFt float64, endedAt time.Time) error {
+	_, err := s.db.Exec(
+		`UPDATE noise_violations SET lowest_altitude_ft = ?, ended_at = ? WHERE id = ?`,
+		lowestAltitudeFt, endedAt.UTC().Format(time.RFC3339), id,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to end noise violation: %w", err)
+	}
+	return nil
+}
+
+// ListViolations returns the violations started in a time range, most recent first. A zero
+// limit returns them all.
+func (s *NoiseStorage) ListViolations(start, end time.Time, limit int) ([]*NoiseViolationRecord, error) {
+	if limit <= 0 {
+		limit = -1
+	}
+	rows, err := s.db.Query(
+		`SELECT id, zone, hex, callsign, registration, aircraft_type, operator, altitude_ft, lowest_altitude_ft, max_altitude_ft, started_at, ended_at
+		FROM noise_violations
+		WHERE started_at >= ? AND started_at < ?
+		ORDER BY started_at DESC, id DESC
+		LIMIT ?`,
+		start.UTC().Format(time.RFC3339), end.UTC().Format(time.RFC3339), limit,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list noise violations: %w", err)
+	}
+	defer rows.Close()
+
+	records := make([]*NoiseViolationRecord, 0)
+	for rows.Next() {
+		var record NoiseViolationRecord
+		var startedAt string
+		var endedAt sql.NullString
+		if err := rows.Scan(&record.ID, &record.Zone, &record.Hex, &record.Callsign, &record.Registration,
+			&record.AircraftType, &record.Operator, &record.AltitudeFeet, &record.LowestAltitudeFt,
+			&record.MaxAltitudeFeet, &startedAt, &endedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan noise violation: %w", err)
+		}
+		record.StartedAt, _ = time.Parse(time.RFC3339, startedAt)
+		if endedAt.Valid {
+			if t, err := time.Parse(time.RFC3339, endedAt.String); err == nil {
+				record.EndedAt = &t
+			}
+		}
+		records = append(records, &record)
+	}
+	return records, rows.Err()
+}
+
+// EndOpenViolations ends the violations still open, left by a shutdown, at the time they
+// started. It returns how many were ended.
+func (s *NoiseStorage) EndOpenViolations() (int64, error) {
+	result, err := s.db.Exec(`UPDATE noise_violations SET ended_at = started_at WHERE ended_at IS NULL`)
+	if err != nil {
+		return 0, fmt.Errorf("failed to end open noise violations: %w", err)
+	}
+	return result.RowsAffected()
+}
+
+// DeleteViolationsBefore deletes violations started before a time and returns how many were deleted
+func (s *NoiseStorage) DeleteViolationsBefore(cutoff time.Time) (int64, error) {
+	result, err := s.db.Exec(`DELETE FROM noise_violations WHERE started_at < ?`, cutoff.UTC().Format(time.RFC3339))
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete noise violations: %w", err)
+	}
+	return result.RowsAffected()
+}
@@ -0,0 +1,147 @@
+package sqlite
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/yegors/co-atc/pkg/logger"
+)
+
+// PromptVersion is a stored version of a prompt template. At most one version of a prompt
+// is active; it's used instead of the template file.
+type PromptVersion struct {
+	ID        int64     `json:"id"`
+	Name      string    `json:"name"`
+	Version   int       `json:"version"` // 1, 2, ... per prompt
+	Content   string    `json:"content"`
+	Comment   string    `json:"comment,omitempty"`
+	Active    bool      `json:"active"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// PromptStorage handles storage of prompt template versions
+type PromptStorage struct {
+	db     *sql.DB
+	logger *logger.Logger
+}
+
+// NewPromptStorage creates a new SQLite prompt version storage
+func NewPromptStorage(db *sql.DB, logger *logger.Logger) *PromptStorage {
+	return &PromptStorage{
+		db:     db,
+		logger: logger.Named("sqlite-prompts"),
+	}
+}
+
+const promptVersionSelect = `SELECT id, name, version, content, comment, active, created_at FROM prompt_versions`
+
+// ListVersions returns the versions of a prompt, newest first
+func (s *PromptStorage) ListVersions(name string) ([]*PromptVersion, error) {
+	rows, err := s.db.Query(promptVersionSelect+` WHERE name = ? ORDER BY version DESC`, name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query prompt versions: %w", err)
+	}
+	defer rows.Close()
+	return scanPromptVersions(rows)
+}
+
+// ActiveVersions returns the active version of every prompt that has one
+func (s *PromptStorage) ActiveVersions() ([]*PromptVersion, error) {
+	rows, err := s.db.Query(promptVersionSelect + ` WHERE active = 1 ORDER BY name`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query active prompt versions: %w", err)
+	}
+	defer rows.Close()
+	return scanPromptVersions(rows)
+}
+
+// GetVersion returns a version of a prompt, or nil if it doesn't exist
+func (s *PromptStorage) GetVersion(name string, version int) (*PromptVersion, error) {
+	rows, err := s.db.Query(promptVersionSelect+` WHERE name = ? AND version = ?`, name, version)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get prompt version: %w", err)
+	}
+	defer rows.Close()
+
+	versions, err := scanPromptVersions(rows)
+	if err != nil || len(versions) == 0 {
+		return nil, err
+	}
+	return versions[0], nil
+}
+
+// AddVersion stores the next version of a prompt and sets its ID and number. An active
+// version replaces the prompt's active version.
+func (s *PromptStorage) AddVersion(v *PromptVersion) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var latest int
+	if err := tx.QueryRow(`SELECT COALESCE(MAX(version), 0) FROM prompt_versions WHERE name = ?`, v.Name).Scan(&latest); err != nil {
+		return fmt.Errorf("failed to get latest prompt version: %w", err)
+	}
+	if v.Active {
+		if _, err := tx.Exec(`UPDATE prompt_versions SET active = 0 WHERE name = ?`, v.Name); err != nil {
+			return fmt.Errorf("failed to deactivate prompt versions: %w", err)
+		}
+	}
+
+	v.Version = latest + 1
+	result, err := tx.Exec(
+		`INSERT INTO prompt_versions (name, version, content, comment, active, created_at)
+		VALUES (?, ?, ?, ?, ?, ?)`,
+		v.Name, v.Version, v.Content, v.Comment, v.Active, v.CreatedAt.UTC().Format(time.RFC3339),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to insert prompt version: %w", err)
+	}
+	v.ID, _ = result.LastInsertId()
+
+	return tx.Commit()
+}
+
+// ActivateVersion makes a version the active one of its prompt, or deactivates every
+// version of the prompt if version is 0. It reports whether the version exists.
+func (s *PromptStorage) ActivateVersion(name string, version int) (bool, error) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return false, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`UPDATE prompt_versions SET active = 0 WHERE name = ?`, name); err != nil {
+		return false, fmt.Errorf("failed to deactivate prompt versions: %w", err)
+	}
+	if version != 0 {
+		result, err := tx.Exec(`UPDATE prompt_versions SET active = 1 WHERE name = ? AND version = ?`, name, version)
+		if err != nil {
+			return false, fmt.Errorf("failed to activate prompt version: %w", err)
+		}
+		if activated, _ := result.RowsAffected(); activated == 0 {
+			return false, nil
+		}
+	}
+
+	return true, tx.Commit()
+}
+
+// scanPromptVersions reads prompt version rows
+func scanPromptVersions(rows *sql.Rows) ([]*PromptVersion, error) {
+	versions := make([]*PromptVersion, 0)
+	for rows.Next() {
+		var v PromptVersion
+		var active int
+		var createdAt string
+		if err := rows.Scan(&v.ID, &v.Name, &v.Version, &v.Content, &v.Comment, &active, &createdAt); err != nil {
+			return nil, fmt.Errorf("failed to scan prompt version: %w", err)
+		}
+		v.Active = active != 0
+		v.CreatedAt, _ = time.Parse(time.RFC3339, createdAt)
+		versions = append(versions, &v)
+	}
+	return versions, rows.Err()
+}
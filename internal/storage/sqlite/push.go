@@ -0,0 +1,321 @@
+package sqlite
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/yegors/co-atc/pkg/logger"
+)
+
+// PushSubscription represents a browser subscribed to Web Push notifications
+type PushSubscription struct {
+	ID             string     `json:"id"`
+	Endpoint       string     `json:"endpoint"`
+	P256dh         string     `json:"-"` // Browser public key (base64url)
+	Auth           string     `json:"-"` // Browser auth secret (base64url)
+	AlertTypes     []string   `json:"alert_types"`
+	UserAgent      string     `json:"user_agent,omitempty"`
+	CreatedAt      time.Time  `json:"created_at"`
+	LastDeliveryAt *time.Time `json:"last_delivery_at,omitempty"`
+	LastStatus     string     `json:"last_status,omitempty"`
+	FailureCount   int        `json:"failure_count"`
+}
+
+// PushDelivery records a single attempt to deliver a notification to a subscription
+type PushDelivery struct {
+	ID             int64     `json:"id"`
+	SubscriptionID string    `json:"subscription_id"`
+	AlertType      string    `json:"alert_type"`
+	Title          string    `json:"title"`
+	Status         string    `json:"status"` // "delivered", "failed" or "expired"
+	StatusCode     int       `json:"status_code,omitempty"`
+	Error          string    `json:"error,omitempty"`
+	CreatedAt      time.Time `json:"created_at"`
+}
+
+// PushStorage handles storage of push subscriptions, delivery history and VAPID keys
+type PushStorage struct {
+	db     *sql.DB
+	logger *logger.Logger
+}
+
+// NewPushStorage creates a new SQLite push storage
+func NewPushStorage(db *sql.DB, logger *logger.Logger) *PushStorage {
+	return &PushStorage{
+		db:     db,
+		logger: logger.Named("sqlite-push"),
+	}
+}
+
+// GetVAPIDKeys returns the stored VAPID key pair, or empty strings if none has been generated
+func (s *PushStorage) GetVAPIDKeys() (publicKey, privateKey string, err error) {
+	err = s.db.QueryRow(`SELECT public_key, private_key FROM push_vapid_keys WHERE id = 1`).Scan(&publicKey, &privateKey)
+	if err == sql.ErrNoRows {
+		return "", "", nil
+	}
+	if err != nil {
+		return "", "", fmt.Errorf("failed to query VAPID keys: %w", err)
+	}
+	return publicKey, privateKey, nil
+}
+
+// SaveVAPIDKeys stores the VAPID key pair
+func (s *PushStorage) SaveVAPIDKeys(publicKey, privateKey string) error {
+	_, err := s.db.Exec(
+		`INSERT OR REPLACE INTO push_vapid_keys (id, public_key, private_key, created_at) VALUES (1, ?, ?, ?)`,
+		publicKey,
+		privateKey,
+		time.Now().UTC().Format(time.RFC3339),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to save VAPID keys: %w", err)
+	}
+	return nil
+}
+
+// UpsertSubscription inserts a subscription, or updates the keys and alert types
+// of an existing subscription with the same endpoint. The stored ID is returned.
+func (s *PushStorage) UpsertSubscription(sub *PushSubscription) (string, error) {
+	_, err := s.db.Exec(
+		`INSERT INTO push_subscriptions (id, endpoint, p256dh, auth, alert_types, user_agent, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT(endpoint) DO UPDATE SET
+			p256dh = excluded.p256dh,
+			auth = excluded.auth,
+			alert_types = excluded.alert_types,
+			user_agent = excluded.user_agent,
+			failure_count = 0`,
+		sub.ID,
+		sub.Endpoint,
+		sub.P256dh,
+		sub.Auth,
+		strings.Join(sub.AlertTypes, ","),
+		sub.UserAgent,
+		sub.CreatedAt.UTC().Format(time.RFC3339),
+	)
+	if err != nil {
+		return "", fmt.Errorf("failed to upsert push subscription: %w", err)
+	}
+
+	var id string
+	if err := s.db.QueryRow(`SELECT id FROM push_subscriptions WHERE endpoint = ?`, sub.Endpoint).Scan(&id); err != nil {
+		return "", fmt.Errorf("failed to query push subscription ID: %w", err)
+	}
+
+	return id, nil
+}
+
+// UpdateAlertTypes changes the alert types of a subscription. It reports whether the subscription exists.
+func (s *PushStorage) UpdateAlertTypes(id string, alertTypes []string) (bool, error) {
+	result, err := s.db.Exec(
+		`UPDATE push_subscriptions SET alert_types = ? WHERE id = ?`,
+		strings.Join(alertTypes, ","),
+		id,
+	)
+	if err != nil {
+		return false, fmt.Errorf("failed to update push subscription: %w", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	return rows > 0, nil
+}
+
+// DeleteSubscription removes a subscription and its delivery history. It reports whether the subscription existed.
+func (s *PushStorage) DeleteSubscription(id string) (bool, error) {
+	result, err := s.db.Exec(`DELETE FROM push_subscriptions WHERE id = ?`, id)
+	if err != nil {
+		return false, fmt.Errorf("failed to delete push subscription: %w", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if _, err := s.db.Exec(`DELETE FROM push_deliveries WHERE subscription_id = ?`, id); err != nil {
+		return false, fmt.Errorf("failed to delete push deliveries: %w", err)
+	}
+
+	return rows > 0, nil
+}
+
+// GetSubscription returns a single subscription, or nil if it does not exist
+func (s *PushStorage) GetSubscription(id string) (*PushSubscription, error) {
+	rows, err := s.db.Query(
+		`SELECT id, endpoint, p256dh, auth, alert_types, user_agent, created_at, last_delivery_at, last_status, failure_count
+		FROM push_subscriptions
+		WHERE id = ?`,
+		id,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query push subscription: %w", err)
+	}
+	defer rows.Close()
+
+	subscriptions, err := scanPushSubscriptions(rows)
+	if err != nil {
+		return nil, err
+	}
+	if len(subscriptions) == 0 {
+		return nil, nil
+	}
+
+	return subscriptions[0], nil
+}
+
+// GetSubscriptions returns all subscriptions, oldest first
+func (s *PushStorage) GetSubscriptions() ([]*PushSubscription, error) {
+	rows, err := s.db.Query(
+		`SELECT id, endpoint, p256dh, auth, alert_types, user_agent, created_at, last_delivery_at, last_status, failure_count
+		FROM push_subscriptions
+		ORDER BY created_at ASC`,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query push subscriptions: %w", err)
+	}
+	defer rows.Close()
+
+	return scanPushSubscriptions(rows)
+}
+
+// RecordDelivery stores a delivery attempt and updates the subscription's delivery status.
+// Failed deliveries increment the subscription's failure count; successful ones reset it.
+func (s *PushStorage) RecordDelivery(delivery *PushDelivery) error {
+	createdAt := delivery.CreatedAt.UTC().Format(time.RFC3339)
+
+	_, err := s.db.Exec(
+		`INSERT INTO push_deliveries (subscription_id, alert_type, title, status, status_code, error, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?)`,
+		delivery.SubscriptionID,
+		delivery.AlertType,
+		delivery.Title,
+		delivery.Status,
+		delivery.StatusCode,
+		delivery.Error,
+		createdAt,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to insert push delivery: %w", err)
+	}
+
+	failureUpdate := "failure_count = failure_count + 1"
+	if delivery.Status == "delivered" {
+		failureUpdate = "failure_count = 0"
+	}
+
+	_, err = s.db.Exec(
+		`UPDATE push_subscriptions SET last_delivery_at = ?, last_status = ?, `+failureUpdate+` WHERE id = ?`,
+		createdAt,
+		delivery.Status,
+		delivery.SubscriptionID,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to update push subscription status: %w", err)
+	}
+
+	return nil
+}
+
+// GetDeliveries returns the most recent delivery attempts of a subscription, newest first
+func (s *PushStorage) GetDeliveries(subscriptionID string, limit int) ([]*PushDelivery, error) {
+	rows, err := s.db.Query(
+		`SELECT id, subscription_id, alert_type, title, status, status_code, error, created_at
+		FROM push_deliveries
+		WHERE subscription_id = ?
+		ORDER BY id DESC
+		LIMIT ?`,
+		subscriptionID,
+		limit,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query push deliveries: %w", err)
+	}
+	defer rows.Close()
+
+	var deliveries []*PushDelivery
+	for rows.Next() {
+		var delivery PushDelivery
+		var createdAt string
+
+		if err := rows.Scan(
+			&delivery.ID,
+			&delivery.SubscriptionID,
+			&delivery.AlertType,
+			&delivery.Title,
+			&delivery.Status,
+			&delivery.StatusCode,
+			&delivery.Error,
+			&createdAt,
+		); err != nil {
+			return nil, fmt.Errorf("failed to scan push delivery: %w", err)
+		}
+
+		delivery.CreatedAt, _ = time.Parse(time.RFC3339, createdAt)
+		deliveries = append(deliveries, &delivery)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read push deliveries: %w", err)
+	}
+
+	return deliveries, nil
+}
+
+// DeleteDeliveriesBefore removes delivery history older than the cutoff
+func (s *PushStorage) DeleteDeliveriesBefore(cutoff time.Time) (int64, error) {
+	result, err := s.db.Exec(`DELETE FROM push_deliveries WHERE created_at < ?`, cutoff.UTC().Format(time.RFC3339))
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete old push deliveries: %w", err)
+	}
+
+	return result.RowsAffected()
+}
+
+// scanPushSubscriptions scans push subscription rows
+func scanPushSubscriptions(rows *sql.Rows) ([]*PushSubscription, error) {
+	var subscriptions []*PushSubscription
+	for rows.Next() {
+		var sub PushSubscription
+		var alertTypes, createdAt string
+		var lastDeliveryAt sql.NullString
+
+		if err := rows.Scan(
+			&sub.ID,
+			&sub.Endpoint,
+			&sub.P256dh,
+			&sub.Auth,
+			&alertTypes,
+			&sub.UserAgent,
+			&createdAt,
+			&lastDeliveryAt,
+			&sub.LastStatus,
+			&sub.FailureCount,
+		); err != nil {
+			return nil, fmt.Errorf("failed to scan push subscription: %w", err)
+		}
+
+		if alertTypes != "" {
+			sub.AlertTypes = strings.Split(alertTypes, ",")
+		}
+		sub.CreatedAt, _ = time.Parse(time.RFC3339, createdAt)
+		if lastDeliveryAt.Valid {
+			if t, err := time.Parse(time.RFC3339, lastDeliveryAt.String); err == nil {
+				sub.LastDeliveryAt = &t
+			}
+		}
+
+		subscriptions = append(subscriptions, &sub)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read push subscriptions: %w", err)
+	}
+
+	return subscriptions, nil
+}
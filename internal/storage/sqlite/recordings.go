@@ -0,0 +1,171 @@
+package sqlite
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/yegors/co-atc/pkg/logger"
+)
+
+// recordingTimeFormat stores segment times with fixed-width milliseconds so they
+// sort correctly as text and keep enough precision for seeking within a segment
+const recordingTimeFormat = "2006-01-02T15:04:05.000Z07:00"
+
+// RecordingSegment represents an archived audio segment of a frequency
+type RecordingSegment struct {
+	ID          int64     `json:"id"`
+	FrequencyID string    `json:"frequency_id"`
+	Path        string    `json:"-"`
+	Format      string    `json:"format"` // "mp3" or "opus"
+	StartTime   time.Time `json:"start_time"`
+	EndTime     time.Time `json:"end_time"`
+	DurationMs  int64     `json:"duration_ms"`
+	SizeBytes   int64     `json:"size_bytes"`
+}
+
+// RecordingStorage indexes archived audio segments
+type RecordingStorage struct {
+	db     *sql.DB
+	logger *logger.Logger
+}
+
+// NewRecordingStorage creates a new SQLite recording storage
+func NewRecordingStorage(db *sql.DB, logger *logger.Logger) *RecordingStorage {
+	return &RecordingStorage{
+		db:     db,
+		logger: logger.Named("sqlite-rec"),
+	}
+}
+
+// InsertSegment indexes a finished segment
+func (s *RecordingStorage) InsertSegment(segment *RecordingSegment) (int64, error) {
+	result, err := s.db.Exec(
+		`INSERT INTO recording_segments
+		(frequency_id, path, format, start_time, end_time, duration_ms, size_bytes)
+		VALUES (?, ?, ?, ?, ?, ?, ?)`,
+		segment.FrequencyID,
+		segment.Path,
+		segment.Format,
+		segment.StartTime.UTC().Format(recordingTimeFormat),
+		segment.EndTime.UTC().Format(recordingTimeFormat),
+		segment.DurationMs,
+		segment.SizeBytes,
+	)
+	if err != nil {
+		return 0, fmt.Errorf("failed to insert recording segment: %w", err)
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return 0, fmt.Errorf("failed to get last insert ID: %w", err)
+	}
+
+	return id, nil
+}
+
+// GetSegments returns the segments of a frequency that overlap the time range, oldest first
+func (s *RecordingStorage) GetSegments(frequencyID string, from, to time.Time) ([]*RecordingSegment, error) {
+	rows, err := s.db.Query(
+		`SELECT id, frequency_id, path, format, start_time, end_time, duration_ms, size_bytes
+		FROM recording_segments
+		WHERE frequency_id = ? AND start_time < ? AND end_time > ?
+		ORDER BY start_time ASC`,
+		frequencyID,
+		to.UTC().Format(recordingTimeFormat),
+		from.UTC().Format(recordingTimeFormat),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query recording segments: %w", err)
+	}
+	defer rows.Close()
+
+	return scanRecordingSegments(rows)
+}
+
+// GetSegmentByID returns a single segment
+func (s *RecordingStorage) GetSegmentByID(id int64) (*RecordingSegment, error) {
+	rows, err := s.db.Query(
+		`SELECT id, frequency_id, path, format, start_time, end_time, duration_ms, size_bytes
+		FROM recording_segments
+		WHERE id = ?`,
+		id,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query recording segment: %w", err)
+	}
+	defer rows.Close()
+
+	segments, err := scanRecordingSegments(rows)
+	if err != nil {
+		return nil, err
+	}
+	if len(segments) == 0 {
+		return nil, nil
+	}
+
+	return segments[0], nil
+}
+
+// GetSegmentsEndedBefore returns all segments that ended before the cutoff
+func (s *RecordingStorage) GetSegmentsEndedBefore(cutoff time.Time) ([]*RecordingSegment, error) {
+	rows, err := s.db.Query(
+		`SELECT id, frequency_id, path, format, start_time, end_time, duration_ms, size_bytes
+		FROM recording_segments
+		WHERE end_time < ?
+		ORDER BY end_time ASC`,
+		cutoff.UTC().Format(recordingTimeFormat),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query expired recording segments: %w", err)
+	}
+	defer rows.Close()
+
+	return scanRecordingSegments(rows)
+}
+
+// DeleteSegment removes a segment from the index
+func (s *RecordingStorage) DeleteSegment(id int64) error {
+	if _, err := s.db.Exec(`DELETE FROM recording_segments WHERE id = ?`, id); err != nil {
+		return fmt.Errorf("failed to delete recording segment %d: %w", id, err)
+	}
+	return nil
+}
+
+// scanRecordingSegments scans recording segment rows
+func scanRecordingSegments(rows *sql.Rows) ([]*RecordingSegment, error) {
+	var segments []*RecordingSegment
+	for rows.Next() {
+		var segment RecordingSegment
+		var startTime, endTime string
+
+		if err := rows.Scan(
+			&segment.ID,
+			&segment.FrequencyID,
+			&segment.Path,
+			&segment.Format,
+			&startTime,
+			&endTime,
+			&segment.DurationMs,
+			&segment.SizeBytes,
+		); err != nil {
+			return nil, fmt.Errorf("failed to scan recording segment: %w", err)
+		}
+
+		var err error
+		if segment.StartTime, err = time.Parse(time.RFC3339, startTime); err != nil {
+			return nil, fmt.Errorf("failed to parse start_time: %w", err)
+		}
+		if segment.EndTime, err = time.Parse(time.RFC3339, endTime); err != nil {
+			return nil, fmt.Errorf("failed to parse end_time: %w", err)
+		}
+
+		segments = append(segments, &segment)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read recording segments: %w", err)
+	}
+
+	return segments, nil
+}
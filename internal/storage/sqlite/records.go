@@ -0,0 +1,179 @@
+package sqlite
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/yegors/co-atc/pkg/logger"
+)
+
+// StationRecord is the best value ever seen at a station for one kind of record,
+// such as the fastest ground speed, with the aircraft that set it
+type StationRecord struct {
+	Station      string    `json:"station"`
+	Record       string    `json:"record"`
+	Value        float64   `json:"value"`
+	Unit         string    `json:"unit"`
+	Hex          string    `json:"hex,omitempty"`
+	Flight       string    `json:"flight,omitempty"`
+	AircraftType string    `json:"aircraft_type,omitempty"`
+	Detail       string    `json:"detail,omitempty"`
+	SetAt        time.Time `json:"set_at"`
+}
+
+// TypeSighting counts how often an aircraft type has been seen at a station
+type TypeSighting struct {
+	AircraftType string    `json:"aircraft_type"`
+	Sightings    int       `json:"sightings"`
+	FirstSeen    time.Time `json:"first_seen"`
+	LastSeen     time.Time `json:"last_seen"`
+	LastHex      string    `json:"last_hex,omitempty"`
+	LastFlight   string    `json:"last_flight,omitempty"`
+}
+
+// RecordStorage handles storage of station records and aircraft type sightings
+type RecordStorage struct {
+	db     *sql.DB
+	logger *logger.Logger
+}
+
+// NewRecordStorage creates a new SQLite record storage
+func NewRecordStorage(db *sql.DB, logger *logger.Logger) *RecordStorage {
+	return &RecordStorage{
+		db:     db,
+		logger: logger.Named("sqlite-records"),
+	}
+}
+
+// SaveRecord stores a record, replacing the previous holder
+func (s *RecordStorage) SaveRecord(record StationRecord) error {
+	_, err := s.db.Exec(
+		`INSERT OR REPLACE INTO station_records
+		(station, record, value, unit, hex, flight, aircraft_type, detail, set_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		record.Station,
+		record.Record,
+		record.Value,
+		record.Unit,
+		record.Hex,
+		record.Flight,
+		record.AircraftType,
+		record.Detail,
+		record.SetAt.UTC().Format(time.RFC3339),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to save station record: %w", err)
+	}
+	return nil
+}
+
+// GetRecords returns every record of a station
+func (s *RecordStorage) GetRecords(station string) ([]StationRecord, error) {
+	rows, err := s.db.Query(
+		`SELECT station, record, value, unit, hex, flight, aircraft_type, detail, set_at
+		FROM station_records
+		WHERE station = ?
+		ORDER BY record`,
+		station,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query station records: %w", err)
+	}
+	defer rows.Close()
+
+	var records []StationRecord
+	for rows.Next() {
+		var record StationRecord
+		var hex, flight, aircraftType, detail sql.NullString
+		var setAt string
+		if err := rows.Scan(&record.Station, &record.Record, &record.Value, &record.Unit,
+			&hex, &flight, &aircraftType, &detail, &setAt); err != nil {
+			return nil, fmt.Errorf("failed to scan station record: %w", err)
+		}
+		record.Hex = hex.String
+		record.Flight = flight.String
+		record.AircraftType = aircraftType.String
+		record.Detail = detail.String
+		record.SetAt, _ = time.Parse(time.RFC3339, setAt)
+		records = append(records, record)
+	}
+
+	return records, rows.Err()
+}
+
+// AddTypeSightings counts one sighting for each aircraft, keyed by aircraft type
+func (s *RecordStorage) AddTypeSightings(station string, sightings []TypeSighting) error {
+	if len(sightings) == 0 {
+		return nil
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	for _, sighting := range sightings {
+		seen := sighting.LastSeen.UTC().Format(time.RFC3339)
+		_, err := tx.Exec(
+			`INSERT INTO aircraft_type_sightings
+			(station, aircraft_type, sightings, first_seen, last_seen, last_hex, last_flight)
+			VALUES (?, ?, 1, ?, ?, ?, ?)
+			ON CONFLICT(station, aircraft_type) DO UPDATE SET
+				sightings = sightings + 1,
+				last_seen = excluded.last_seen,
+				last_hex = excluded.last_hex,
+				last_flight = excluded.last_flight`,
+			station, sighting.AircraftType, seen, seen, sighting.LastHex, sighting.LastFlight,
+		)
+		if err != nil {
+			return fmt.Errorf("failed to record type sighting: %w", err)
+		}
+	}
+
+	return tx.Commit()
+}
+
+// GetRarestTypes returns the least often seen aircraft types of a station, most recently seen first among equals
+func (s *RecordStorage) GetRarestTypes(station string, limit int) ([]TypeSighting, error) {
+	rows, err := s.db.Query(
+		`SELECT aircraft_type, sightings, first_seen, last_seen, last_hex, last_flight
+		FROM aircraft_type_sightings
+		WHERE station = ?
+		ORDER BY sightings ASC, last_seen DESC
+		LIMIT ?`,
+		station, limit,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query type sightings: %w", err)
+	}
+	defer rows.Close()
+
+	var sightings []TypeSighting
+	for rows.Next() {
+		var sighting TypeSighting
+		var firstSeen, lastSeen string
+		var lastHex, lastFlight sql.NullString
+		if err := rows.Scan(&sighting.AircraftType, &sighting.Sightings, &firstSeen, &lastSeen,
+			&lastHex, &lastFlight); err != nil {
+			return nil, fmt.Errorf("failed to scan type sighting: %w", err)
+		}
+		sighting.FirstSeen, _ = time.Parse(time.RFC3339, firstSeen)
+		sighting.LastSeen, _ = time.Parse(time.RFC3339, lastSeen)
+		sighting.LastHex = lastHex.String
+		sighting.LastFlight = lastFlight.String
+		sightings = append(sightings, sighting)
+	}
+
+	return sightings, rows.Err()
+}
+
+// CountTypes returns how many distinct aircraft types have been seen at a station
+func (s *RecordStorage) CountTypes(station string) (int, error) {
+	var count int
+	if err := s.db.QueryRow(`SELECT COUNT(*) FROM aircraft_type_sightings WHERE station = ?`, station).Scan(&count); err != nil {
+		return 0, fmt.Errorf("failed to count aircraft types: %w", err)
+	}
+	return count, nil
+}
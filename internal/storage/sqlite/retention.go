@@ -0,0 +1,161 @@
+package sqlite
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/yegors/co-atc/pkg/logger"
+)
+
+// pruneBatchSize is how many rows are exported and deleted at a time, so pruning a large
+// backlog does not hold the database's only connection for long
+const pruneBatchSize = 1000
+
+// PrunableTable is a table whose rows are pruned by the age in one of its time columns.
+// The column must hold RFC3339 UTC timestamps, like every time column co-atc writes.
+type PrunableTable struct {
+	Name       string
+	TimeColumn string
+}
+
+// Tables of the daily database that are pruned by age
+var (
+	TranscriptionsTable = PrunableTable{Name: "transcriptions", TimeColumn: "created_at"}
+	ClearancesTable     = PrunableTable{Name: "clearances", TimeColumn: "timestamp"}
+	PositionsTable      = PrunableTable{Name: "adsb_targets", TimeColumn: "timestamp"}
+	PhaseChangesTable   = PrunableTable{Name: "phase_changes", TimeColumn: "timestamp"}
+	AircraftTable       = PrunableTable{Name: "aircraft", TimeColumn: "last_seen"}
+	EventsTable         = PrunableTable{Name: "events", TimeColumn: "created_at"}
+)
+
+// ExportFunc receives a batch of rows, keyed by column name, before they are deleted
+type ExportFunc func(rows []map[string]interface{}) error
+
+// RetentionStorage deletes rows that are past their retention window
+type RetentionStorage struct {
+	db     *sql.DB
+	logger *logger.Logger
+}
+
+// NewRetentionStorage creates a new retention storage
+func NewRetentionStorage(db *sql.DB, logger *logger.Logger) *RetentionStorage {
+	return &RetentionStorage{
+		db:     db,
+		logger: logger.Named("sqlite-retention"),
+	}
+}
+
+// Prune deletes the rows of a table that are older than the cutoff, oldest first, and
+// returns how many were deleted. With an export function, each batch is exported before
+// it is deleted and pruning stops at the first batch that fails to export, so no row is
+// deleted without having been archived.
+func (s *RetentionStorage) Prune(table PrunableTable, cutoff time.Time, export ExportFunc) (int64, error) {
+	before := cutoff.UTC().Format(time.RFC3339)
+
+	var deleted int64
+	var lastRowID int64
+	for {
+		var (
+			count int64
+			err   error
+		)
+		if export != nil {
+			count, lastRowID, err = s.exportBatch(table, before, lastRowID, export)
+		} else {
+			count, err = s.deleteBatch(table, before)
+		}
+		deleted += count
+		if err != nil {
+			return deleted, err
+		}
+		if count < pruneBatchSize {
+			return deleted, nil
+		}
+	}
+}
+
+// deleteBatch deletes up to a batch of rows older than the cutoff
+func (s *RetentionStorage) deleteBatch(table PrunableTable, before string) (int64, error) {
+	result, err := s.db.Exec(
+		fmt.Sprintf(`DELETE FROM %[1]s WHERE rowid IN (SELECT rowid FROM %[1]s WHERE %[2]s < ? LIMIT ?)`, table.Name, table.TimeColumn),
+		before, pruneBatchSize,
+	)
+	if err != nil {
+		return 0, fmt.Errorf("failed to prune %s: %w", table.Name, err)
+	}
+	return result.RowsAffected()
+}
+
+// exportBatch exports the next batch of rows older than the cutoff after a rowid, then
+// deletes them. It returns how many rows were deleted and the last rowid of the batch.
+func (s *RetentionStorage) exportBatch(table PrunableTable, before string, afterRowID int64, export ExportFunc) (int64, int64, error) {
+	rows, err := s.db.Query(
+		fmt.Sprintf(`SELECT rowid AS pruned_rowid, * FROM %s WHERE %s < ? AND rowid > ? ORDER BY rowid LIMIT ?`, table.Name, table.TimeColumn),
+		before, afterRowID, pruneBatchSize,
+	)
+	if err != nil {
+		return 0, afterRowID, fmt.Errorf("failed to query expired %s: %w", table.Name, err)
+	}
+
+	batch, lastRowID, err := scanPrunedRows(rows)
+	rows.Close()
+	if err != nil {
+		return 0, afterRowID, fmt.Errorf("failed to read expired %s: %w", table.Name, err)
+	}
+	if len(batch) == 0 {
+		return 0, afterRowID, nil
+	}
+
+	if err := export(batch); err != nil {
+		return 0, afterRowID, fmt.Errorf("failed to export expired %s: %w", table.Name, err)
+	}
+
+	// The rows of the batch are exactly the expired rows in its rowid range
+	result, err := s.db.Exec(
+		fmt.Sprintf(`DELETE FROM %s WHERE rowid > ? AND rowid <= ? AND %s < ?`, table.Name, table.TimeColumn),
+		afterRowID, lastRowID, before,
+	)
+	if err != nil {
+		return 0, afterRowID, fmt.Errorf("failed to prune %s: %w", table.Name, err)
+	}
+	deleted, err := result.RowsAffected()
+	return deleted, lastRowID, err
+}
+
+// scanPrunedRows reads rows selected with their rowid first into maps keyed by column name
+func scanPrunedRows(rows *sql.Rows) ([]map[string]interface{}, int64, error) {
+	columns, err := rows.Columns()
+	if err != nil {
+		return nil, 0, err
+	}
+
+	var batch []map[string]interface{}
+	var lastRowID int64
+	for rows.Next() {
+		values := make([]interface{}, len(columns))
+		pointers := make([]interface{}, len(columns))
+		for i := range values {
+			pointers[i] = &values[i]
+		}
+		if err := rows.Scan(pointers...); err != nil {
+			return nil, 0, err
+		}
+
+		row := make(map[string]interface{}, len(columns)-1)
+		for i, column := range columns {
+			if i == 0 {
+				if rowID, ok := values[i].(int64); ok {
+					lastRowID = rowID
+				}
+				continue
+			}
+			if b, ok := values[i].([]byte); ok {
+				values[i] = string(b)
+			}
+			row[column] = values[i]
+		}
+		batch = append(batch, row)
+	}
+	return batch, lastRowID, rows.Err()
+}
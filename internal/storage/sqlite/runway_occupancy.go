@@ -0,0 +1,102 @@
+package sqlite
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/yegors/co-atc/pkg/logger"
+)
+
+// Runway operations occupancy is measured for
+const (
+	OccupancyArrival   = "arrival"
+	OccupancyDeparture = "departure"
+)
+
+// RunwayOccupancyRecord is how long an aircraft occupied a runway for a landing or takeoff
+type RunwayOccupancyRecord struct {
+	ID           int64     `json:"id"`
+	Runway       string    `json:"runway"`    // Threshold ID of the direction used, e.g. "24R"
+	Operation    string    `json:"operation"` // "arrival" or "departure"
+	Hex          string    `json:"hex"`
+	Callsign     string    `json:"callsign,omitempty"`
+	AircraftType string    `json:"aircraft_type,omitempty"`
+	EnteredAt    time.Time `json:"entered_at"` // Threshold crossing, or entering the runway to depart
+	ExitedAt     time.Time `json:"exited_at"`  // Leaving the runway after landing, or the runway area after takeoff
+	Seconds      float64   `json:"seconds"`
+}
+
+// RunwayOccupancyStorage handles storage of runway occupancy times
+type RunwayOccupancyStorage struct {
+	db     *sql.DB
+	logger *logger.Logger
+}
+
+// NewRunwayOccupancyStorage creates a new SQLite runway occupancy storage
+func NewRunwayOccupancyStorage(db *sql.DB, logger *logger.Logger) *RunwayOccupancyStorage {
+	return &RunwayOccupancyStorage{
+		db:     db,
+		logger: logger.Named("sqlite-occupancy"),
+	}
+}
+
+// AddOccupancy stores an occupancy and sets its ID
+func (s *RunwayOccupancyStorage) AddOccupancy(record *RunwayOccupancyRecord) error {
+	result, err := s.db.Exec(
+		`INSERT INTO runway_occupancy
+		(runway, operation, hex, callsign, aircraft_type, entered_at, exited_at, seconds)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)`,
+		record.Runway,
+		record.Operation,
+		record.Hex,
+		record.Callsign,
+		record.AircraftType,
+		record.EnteredAt.UTC().Format(time.RFC3339),
+		record.ExitedAt.UTC().Format(time.RFC3339),
+		record.Seconds,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to insert runway occupancy: %w", err)
+	}
+	record.ID, err = result.LastInsertId()
+	return err
+}
+
+// ListOccupancy returns the occupancies that started in a time range, oldest first
+func (s *RunwayOccupancyStorage) ListOccupancy(start, end time.Time) ([]*RunwayOccupancyRecord, error) {
+	rows, err := s.db.Query(
+		`SELECT id, runway, operation, hex, callsign, aircraft_type, entered_at, exited_at, seconds
+		FROM runway_occupancy
+		WHERE entered_at >= ? AND entered_at < ?
+		ORDER BY entered_at, id`,
+		start.UTC().Format(time.RFC3339), end.UTC().Format(time.RFC3339),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list runway occupancy: %w", err)
+	}
+	defer rows.Close()
+
+	records := make([]*RunwayOccupancyRecord, 0)
+	for rows.Next() {
+		var record RunwayOccupancyRecord
+		var enteredAt, exitedAt string
+		if err := rows.Scan(&record.ID, &record.Runway, &record.Operation, &record.Hex, &record.Callsign,
+			&record.AircraftType, &enteredAt, &exitedAt, &record.Seconds); err != nil {
+			return nil, fmt.Errorf("failed to scan runway occupancy: %w", err)
+		}
+		record.EnteredAt, _ = time.Parse(time.RFC3339, enteredAt)
+		record.ExitedAt, _ = time.Parse(time.RFC3339, exitedAt)
+		records = append(records, &record)
+	}
+	return records, rows.Err()
+}
+
+// DeleteOccupancyBefore deletes occupancies started before a time and returns how many were deleted
+func (s *RunwayOccupancyStorage) DeleteOccupancyBefore(cutoff time.Time) (int64, error) {
+	result, err := s.db.Exec(`DELETE FROM runway_occupancy WHERE entered_at < ?`, cutoff.UTC().Format(time.RFC3339))
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete runway occupancy: %w", err)
+	}
+	return result.RowsAffected()
+}
@@ -0,0 +1,93 @@
+package sqlite
+
+import (
+	"database/sql"
+	"fmt"
+
+	"github.com/yegors/co-atc/pkg/logger"
+	_ "modernc.org/sqlite"
+)
+
+// OpenDatabase opens a SQLite database with the connection limits and pragmas
+// used by all co-atc databases
+func OpenDatabase(dbPath string) (*sql.DB, error) {
+	db, err := sql.Open("sqlite", dbPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open database: %w", err)
+	}
+
+	// Set connection pool limits
+	db.SetMaxOpenConns(1) // SQLite only supports one writer at a time
+	db.SetMaxIdleConns(1)
+
+	// Set pragmas for better performance and concurrency
+	pragmas := []struct {
+		sql  string
+		name string
+	}{
+		{"PRAGMA journal_mode=WAL", "journal mode"},
+		{"PRAGMA synchronous=NORMAL", "synchronous mode"},
+		{"PRAGMA busy_timeout=5000", "busy timeout"},
+		{"PRAGMA cache_size=10000", "cache size"},
+	}
+	for _, pragma := range pragmas {
+		if _, err := db.Exec(pragma.sql); err != nil {
+			db.Close()
+			return nil, fmt.Errorf("failed to set %s: %w", pragma.name, err)
+		}
+	}
+
+	return db, nil
+}
+
+// OpenMigratedDatabase opens a SQLite database and migrates it to the latest version
+// of its schema
+func OpenMigratedDatabase(dbPath string, schema Schema, log *logger.Logger) (*sql.DB, error) {
+	db, err := OpenDatabase(dbPath)
+	if err != nil {
+		return nil, err
+	}
+	if err := Migrate(db, schema, log); err != nil {
+		db.Close()
+		return nil, err
+	}
+	return db, nil
+}
+
+// ensureColumn adds a column to an existing table if it is not already present.
+// Builds before schema migrations added new columns this way, so it is only used to
+// bring their databases up to the initial migration.
+func ensureColumn(db *sql.DB, table, column, definition string) error {
+	rows, err := db.Query(fmt.Sprintf("PRAGMA table_info(%s)", table))
+	if err != nil {
+		return fmt.Errorf("failed to read schema for %s: %w", table, err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var (
+			cid        int
+			name       string
+			colType    string
+			notNull    int
+			defaultVal sql.NullString
+			pk         int
+		)
+		if err := rows.Scan(&cid, &name, &colType, &notNull, &defaultVal, &pk); err != nil {
+			return fmt.Errorf("failed to scan schema for %s: %w", table, err)
+		}
+		if name == column {
+			return nil
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("failed to read schema for %s: %w", table, err)
+	}
+	rows.Close()
+
+	if _, err := db.Exec(fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s %s", table, column, definition)); err != nil {
+		return fmt.Errorf("failed to add column %s.%s: %w", table, column, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,58 @@
+package sqlite
+
+import (
+	"database/sql"
+	"sync"
+)
+
+// statementCache prepares each query once and reuses the statement, so frequent writes
+// skip parsing and planning their SQL every time
+type statementCache struct {
+	db    *sql.DB
+	mu    sync.Mutex
+	stmts map[string]*sql.Stmt
+}
+
+// newStatementCache creates an empty statement cache for a database
+func newStatementCache(db *sql.DB) *statementCache {
+	return &statementCache{
+		db:    db,
+		stmts: make(map[string]*sql.Stmt),
+	}
+}
+
+// prepare returns the prepared statement for a query, preparing it on first use
+func (c *statementCache) prepare(query string) (*sql.Stmt, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if stmt, ok := c.stmts[query]; ok {
+		return stmt, nil
+	}
+	stmt, err := c.db.Prepare(query)
+	if err != nil {
+		return nil, err
+	}
+	c.stmts[query] = stmt
+	return stmt, nil
+}
+
+// exec runs a query through its prepared statement
+func (c *statementCache) exec(query string, args ...interface{}) (sql.Result, error) {
+	stmt, err := c.prepare(query)
+	if err != nil {
+		return nil, err
+	}
+	return stmt.Exec(args...)
+}
+
+// close closes every prepared statement
+func (c *statementCache) close() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for query, stmt := range c.stmts {
+		stmt.Close()
+		delete(c.stmts, query)
+	}
+}
@@ -0,0 +1,133 @@
+package sqlite
+
+import (
+	"database/sql"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/yegors/co-atc/internal/adsb"
+	"github.com/yegors/co-atc/pkg/logger"
+)
+
+// Movement kinds
+const (
+	MovementArrival    = "arrival"    // Touched down
+	MovementDeparture  = "departure"  // Took off
+	MovementOverflight = "overflight" // Stayed airborne without approaching or using the airport
+)
+
+// Movement is an arrival, departure or overflight of an aircraft
+type Movement struct {
+	Hex          string
+	Flight       string
+	Operator     string // Airline name from the callsign, empty for private flights
+	AircraftType string
+	Kind         string
+	Time         time.Time // Touchdown, takeoff or first position
+}
+
+// StatsStorage reads traffic movements from the stored phase changes and tracks
+type StatsStorage struct {
+	db     *sql.DB
+	logger *logger.Logger
+}
+
+// NewStatsStorage creates a new SQLite statistics storage
+func NewStatsStorage(db *sql.DB, logger *logger.Logger) *StatsStorage {
+	return &StatsStorage{
+		db:     db,
+		logger: logger.Named("sqlite-stats"),
+	}
+}
+
+// latestTypeQuery selects the latest known type of the aircraft whose hex column fills %s
+const latestTypeQuery = `(SELECT aircraft_type FROM adsb_targets
+	WHERE aircraft_hex = %s AND COALESCE(aircraft_type, '') != ''
+	ORDER BY timestamp DESC LIMIT 1)`
+
+// GetMovements returns the movements in a time range, oldest first. Arrivals and departures
+// are touchdowns and takeoffs; overflights are aircraft never seen on the ground, taxiing,
+// taking off, landing or on approach, timed at their first position. Simulated and replayed
+// aircraft are left out.
+func (s *StatsStorage) GetMovements(startTime, endTime time.Time) ([]Movement, error) {
+	movements := make([]Movement, 0)
+
+	// Timestamps are stored with the offset of the time they were recorded in, so they are
+	// compared after parsing rather than as text
+	rows, err := s.db.Query(fmt.Sprintf(`
+		SELECT p.hex, COALESCE(p.flight, ''), COALESCE(a.airline, ''), COALESCE(%s, ''),
+			p.phase, p.timestamp
+		FROM phase_changes p
+		LEFT JOIN aircraft a ON a.hex = p.hex
+		LEFT JOIN adsb_targets t ON t.id = p.adsb_id
+		WHERE p.phase IN ('T/O', 'T/D') AND COALESCE(t.type, '') NOT IN (?, ?)
+	`, fmt.Sprintf(latestTypeQuery, "p.hex")), adsb.TargetTypeSimulated, adsb.TargetTypeReplay)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query runway movements: %w", err)
+	}
+	for rows.Next() {
+		var m Movement
+		var phase, timestamp string
+		if err := rows.Scan(&m.Hex, &m.Flight, &m.Operator, &m.AircraftType, &phase, &timestamp); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("failed to scan runway movement: %w", err)
+		}
+		if m.Time, err = time.Parse(time.RFC3339, timestamp); err != nil {
+			continue
+		}
+		m.Kind = MovementDeparture
+		if phase == "T/D" {
+			m.Kind = MovementArrival
+		}
+		movements = appendInRange(movements, m, startTime, endTime)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read runway movements: %w", err)
+	}
+
+	// Aircraft seen at only one position are usually garbled messages, not overflights
+	rows, err = s.db.Query(fmt.Sprintf(`
+		SELECT a.hex, COALESCE(a.flight, ''), COALESCE(a.airline, ''), COALESCE(%s, ''),
+			MIN(t.timestamp)
+		FROM aircraft a
+		JOIN adsb_targets t ON t.aircraft_hex = a.hex
+		WHERE a.on_ground = 0 AND COALESCE(t.type, '') NOT IN (?, ?)
+			AND NOT EXISTS (
+				SELECT 1 FROM phase_changes p
+				WHERE p.hex = a.hex AND p.phase IN ('TAX', 'T/O', 'T/D', 'APP')
+			)
+		GROUP BY a.hex
+		HAVING COUNT(t.id) > 1
+	`, fmt.Sprintf(latestTypeQuery, "a.hex")), adsb.TargetTypeSimulated, adsb.TargetTypeReplay)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query overflights: %w", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		m := Movement{Kind: MovementOverflight}
+		var timestamp string
+		if err := rows.Scan(&m.Hex, &m.Flight, &m.Operator, &m.AircraftType, &timestamp); err != nil {
+			return nil, fmt.Errorf("failed to scan overflight: %w", err)
+		}
+		if m.Time, err = time.Parse(time.RFC3339, timestamp); err != nil {
+			continue
+		}
+		movements = appendInRange(movements, m, startTime, endTime)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read overflights: %w", err)
+	}
+
+	sort.Slice(movements, func(i, j int) bool { return movements[i].Time.Before(movements[j].Time) })
+	return movements, nil
+}
+
+// appendInRange adds a movement if it's at or after the start and before the end
+func appendInRange(movements []Movement, m Movement, startTime, endTime time.Time) []Movement {
+	if m.Time.Before(startTime) || !m.Time.Before(endTime) {
+		return movements
+	}
+	return append(movements, m)
+}
@@ -0,0 +1,110 @@
+package sqlite
+
+import (
+	"fmt"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// TranscriptionSearch is a full-text search over transcriptions. Query is required; the
+// other fields narrow the results when set.
+type TranscriptionSearch struct {
+	Query         string
+	FrequencyID   string
+	Callsign      string
+	SpeakerType   string
+	StartTime     *time.Time
+	EndTime       *time.Time
+	MinConfidence float64
+	SortByTime    bool // Newest first instead of best match first
+	Limit         int
+	Offset        int
+}
+
+// SearchTranscriptions returns the transcriptions whose raw or processed content matches
+// the search's query, best match first unless sorted by time
+func (s *TranscriptionStorage) SearchTranscriptions(search TranscriptionSearch) ([]*TranscriptionRecord, error) {
+	match := ftsQuery(search.Query)
+	if match == "" {
+		return nil, fmt.Errorf("search query has no words")
+	}
+
+	conditions := []string{"transcriptions_fts MATCH ?", strings.ReplaceAll(confidenceFilter, "confidence", "t.confidence")}
+	args := []interface{}{match, search.MinConfidence}
+	if search.FrequencyID != "" {
+		conditions = append(conditions, "t.frequency_id = ?")
+		args = append(args, search.FrequencyID)
+	}
+	if search.Callsign != "" {
+		conditions = append(conditions, "t.callsign = ?")
+		args = append(args, search.Callsign)
+	}
+	if search.SpeakerType != "" {
+		conditions = append(conditions, "t.speaker_type = ?")
+		args = append(args, search.SpeakerType)
+	}
+	if search.StartTime != nil {
+		conditions = append(conditions, "t.created_at >= ?")
+		args = append(args, search.StartTime.UTC().Format(time.RFC3339))
+	}
+	if search.EndTime != nil {
+		conditions = append(conditions, "t.created_at <= ?")
+		args = append(args, search.EndTime.UTC().Format(time.RFC3339))
+	}
+
+	order := "transcriptions_fts.rank, t.created_at DESC"
+	if search.SortByTime {
+		order = "t.created_at DESC"
+	}
+	args = append(args, search.Limit, search.Offset)
+
+	rows, err := s.db.Query(
+		`SELECT t.id, t.frequency_id, t.created_at, t.content, t.is_complete, t.is_processed, t.content_processed, t.speaker_type, t.callsign, t.correlation_id, t.audio_start_time, t.audio_end_time, t.confidence, t.words, t.aircraft_hex, t.audio_delay_ms
+		FROM transcriptions_fts
+		JOIN transcriptions t ON t.id = transcriptions_fts.rowid
+		WHERE `+strings.Join(conditions, " AND ")+`
+		ORDER BY `+order+`
+		LIMIT ? OFFSET ?`,
+		args...,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to search transcriptions: %w", err)
+	}
+	defer rows.Close()
+
+	return s.scanTranscriptionRows(rows)
+}
+
+// ftsQuery turns a search as typed into an FTS5 query matching transcriptions that contain
+// every word. Quoted text must match as a phrase and a trailing * matches a prefix
+// ("clear*"); other FTS5 syntax is treated as plain text.
+func ftsQuery(query string) string {
+	var terms []string
+	for i, part := range strings.Split(query, `"`) {
+		if i%2 == 1 {
+			// Inside quotes
+			if words := ftsWords(part); len(words) > 0 {
+				terms = append(terms, `"`+strings.Join(words, " ")+`"`)
+			}
+			continue
+		}
+		for _, field := range strings.Fields(part) {
+			prefix := strings.HasSuffix(field, "*")
+			for _, word := range ftsWords(field) {
+				terms = append(terms, `"`+word+`"`)
+			}
+			if prefix && len(terms) > 0 {
+				terms[len(terms)-1] += "*"
+			}
+		}
+	}
+	return strings.Join(terms, " ")
+}
+
+// ftsWords splits text into the words the index's tokenizer sees
+func ftsWords(text string) []string {
+	return strings.FieldsFunc(text, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
@@ -2,7 +2,9 @@ package sqlite
 
 import (
 	"database/sql"
+	"encoding/json"
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/yegors/co-atc/pkg/logger"
@@ -16,89 +18,65 @@ var (
 
 // TranscriptionRecord represents a transcription record in the database
 type TranscriptionRecord struct {
-	ID               int64     `json:"id"`
-	FrequencyID      string    `json:"frequency_id"`
-	CreatedAt        time.Time `json:"created_at"`
-	Content          string    `json:"content"`
-	IsComplete       bool      `json:"is_complete"`
-	IsProcessed      bool      `json:"is_processed"`
-	ContentProcessed string    `json:"content_processed"`
-	SpeakerType      string    `json:"speaker_type,omitempty"` // "ATC" or "PILOT"
-	Callsign         string    `json:"callsign,omitempty"`     // Aircraft callsign if speaker is a pilot
+	ID               int64               `json:"id"`
+	FrequencyID      string              `json:"frequency_id"`
+	CreatedAt        time.Time           `json:"created_at"`
+	Content          string              `json:"content"`
+	IsComplete       bool                `json:"is_complete"`
+	IsProcessed      bool                `json:"is_processed"`
+	ContentProcessed string              `json:"content_processed"`
+	SpeakerType      string              `json:"speaker_type,omitempty"`   // "ATC" or "PILOT"
+	Callsign         string              `json:"callsign,omitempty"`       // Aircraft callsign if speaker is a pilot
+	CorrelationID    string              `json:"correlation_id,omitempty"` // Correlation ID of the transmission
+	AudioStart       *time.Time          `json:"audio_start,omitempty"`    // When the transmission started on the frequency
+	AudioEnd         *time.Time          `json:"audio_end,omitempty"`      // When the transmission ended on the frequency
+	Confidence       *float64            `json:"confidence,omitempty"`     // Confidence of the transcript (0-1), if the provider reports one
+	Words            []TranscriptionWord `json:"words,omitempty"`          // Word timings, if the provider reports them
+	AircraftHex      string              `json:"aircraft_hex,omitempty"`   // Tracked aircraft the callsign was correlated with
+	AudioDelayMs     int                 `json:"audio_delay_ms,omitempty"` // How far the times were moved back from when the audio arrived
 }
 
+// TranscriptionWord is a word of a transcript and when it was spoken on the frequency
+type TranscriptionWord struct {
+	Word       string    `json:"word"`
+	Start      time.Time `json:"start"`
+	End        time.Time `json:"end"`
+	Confidence float64   `json:"confidence"`
+	Speaker    *int      `json:"speaker,omitempty"` // Speaker label from diarization (nil if not diarized)
+}
+
+// confidenceFilter keeps transcriptions with at least the confidence bound to it. Transcriptions
+// without a confidence are always kept.
+const confidenceFilter = `(confidence IS NULL OR confidence >= ?)`
+
 // TranscriptionStorage handles storage of transcription records
 type TranscriptionStorage struct {
 	db     *sql.DB
+	stmts  *statementCache // Frequent writes
 	logger *logger.Logger
 }
 
 // NewTranscriptionStorage creates a new SQLite transcription storage
 func NewTranscriptionStorage(db *sql.DB, logger *logger.Logger) *TranscriptionStorage {
-	storage := &TranscriptionStorage{
+	return &TranscriptionStorage{
 		db:     db,
+		stmts:  newStatementCache(db),
 		logger: logger.Named("sqlite-tx"),
 	}
-
-	// Initialize database
-	if err := storage.initDB(); err != nil {
-		logger.Error("Failed to initialize transcription storage", Error(err))
-	}
-
-	return storage
 }
 
-// initDB initializes the database tables
-func (s *TranscriptionStorage) initDB() error {
-	// Create transcriptions table
-	_, err := s.db.Exec(`
-		CREATE TABLE IF NOT EXISTS transcriptions (
-			id INTEGER PRIMARY KEY AUTOINCREMENT,
-			frequency_id TEXT NOT NULL,
-			created_at TIMESTAMP NOT NULL,
-			content TEXT NOT NULL,
-			is_complete BOOLEAN NOT NULL,
-			is_processed BOOLEAN NOT NULL,
-			content_processed TEXT,
-			speaker_type TEXT,
-			callsign TEXT
-		)
-	`)
-	if err != nil {
-		return fmt.Errorf("failed to create transcriptions table: %w", err)
-	}
-
-	// Create indexes
-	_, err = s.db.Exec(`CREATE INDEX IF NOT EXISTS idx_frequency_id ON transcriptions(frequency_id)`)
-	if err != nil {
-		return fmt.Errorf("failed to create frequency_id index: %w", err)
-	}
-
-	_, err = s.db.Exec(`CREATE INDEX IF NOT EXISTS idx_created_at ON transcriptions(created_at)`)
-	if err != nil {
-		return fmt.Errorf("failed to create created_at index: %w", err)
-	}
-
-	_, err = s.db.Exec(`CREATE INDEX IF NOT EXISTS idx_speaker_type ON transcriptions(speaker_type)`)
-	if err != nil {
-		return fmt.Errorf("failed to create speaker_type index: %w", err)
-	}
-
-	_, err = s.db.Exec(`CREATE INDEX IF NOT EXISTS idx_callsign ON transcriptions(callsign)`)
+// StoreTranscription stores a transcription record
+func (s *TranscriptionStorage) StoreTranscription(record *TranscriptionRecord) (int64, error) {
+	words, err := formatWords(record.Words)
 	if err != nil {
-		return fmt.Errorf("failed to create callsign index: %w", err)
+		return 0, err
 	}
 
-	return nil
-}
-
-// StoreTranscription stores a transcription record
-func (s *TranscriptionStorage) StoreTranscription(record *TranscriptionRecord) (int64, error) {
 	// Insert record
-	result, err := s.db.Exec(
+	result, err := s.stmts.exec(
 		`INSERT INTO transcriptions 
-		(frequency_id, created_at, content, is_complete, is_processed, content_processed, speaker_type, callsign) 
-		VALUES (?, ?, ?, ?, ?, ?, ?, ?)`,
+		(frequency_id, created_at, content, is_complete, is_processed, content_processed, speaker_type, callsign, correlation_id, audio_start_time, audio_end_time, confidence, words, aircraft_hex, audio_delay_ms) 
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
 		record.FrequencyID,
 		record.CreatedAt.Format(time.RFC3339),
 		record.Content,
@@ -107,6 +85,13 @@ func (s *TranscriptionStorage) StoreTranscription(record *TranscriptionRecord) (
 		record.ContentProcessed,
 		record.SpeakerType,
 		record.Callsign,
+		record.CorrelationID,
+		formatAudioTime(record.AudioStart),
+		formatAudioTime(record.AudioEnd),
+		record.Confidence,
+		words,
+		nullIfEmpty(record.AircraftHex),
+		nullIfZero(record.AudioDelayMs),
 	)
 	if err != nil {
 		return 0, fmt.Errorf("failed to insert transcription: %w", err)
@@ -122,380 +107,188 @@ func (s *TranscriptionStorage) StoreTranscription(record *TranscriptionRecord) (
 }
 
 // GetTranscriptions returns all transcriptions with pagination
-func (s *TranscriptionStorage) GetTranscriptions(limit, offset int) ([]*TranscriptionRecord, error) {
+func (s *TranscriptionStorage) GetTranscriptions(minConfidence float64, limit, offset int) ([]*TranscriptionRecord, error) {
 	// Query records
 	rows, err := s.db.Query(
-		`SELECT id, frequency_id, created_at, content, is_complete, is_processed, content_processed, speaker_type, callsign 
+		`SELECT id, frequency_id, created_at, content, is_complete, is_processed, content_processed, speaker_type, callsign, correlation_id, audio_start_time, audio_end_time, confidence, words, aircraft_hex, audio_delay_ms 
 		FROM transcriptions 
+		WHERE `+confidenceFilter+`
 		ORDER BY created_at DESC 
 		LIMIT ? OFFSET ?`,
-		limit, offset,
+		minConfidence, limit, offset,
 	)
 	if err != nil {
 		return nil, fmt.Errorf("failed to query transcriptions: %w", err)
 	}
 	defer rows.Close()
 
-	// Parse records
-	var records []*TranscriptionRecord
-	for rows.Next() {
-		var record TranscriptionRecord
-		var createdAt string
-		var speakerType, callsign sql.NullString
-		var contentProcessed sql.NullString
-
-		if err := rows.Scan(
-			&record.ID,
-			&record.FrequencyID,
-			&createdAt,
-			&record.Content,
-			&record.IsComplete,
-			&record.IsProcessed,
-			&contentProcessed,
-			&speakerType,
-			&callsign,
-		); err != nil {
-			return nil, fmt.Errorf("failed to scan transcription: %w", err)
-		}
-
-		// Parse created_at
-		record.CreatedAt, err = time.Parse(time.RFC3339, createdAt)
-		if err != nil {
-			return nil, fmt.Errorf("failed to parse created_at: %w", err)
-		}
-
-		// Handle nullable fields
-		if contentProcessed.Valid {
-			record.ContentProcessed = contentProcessed.String
-		}
-		if speakerType.Valid {
-			record.SpeakerType = speakerType.String
-		}
-		if callsign.Valid {
-			record.Callsign = callsign.String
-		}
-
-		records = append(records, &record)
-	}
-
-	return records, nil
+	return s.scanTranscriptionRows(rows)
 }
 
 // GetTranscriptionsByFrequency returns transcriptions for a specific frequency
-func (s *TranscriptionStorage) GetTranscriptionsByFrequency(frequencyID string, limit, offset int) ([]*TranscriptionRecord, error) {
+func (s *TranscriptionStorage) GetTranscriptionsByFrequency(frequencyID string, minConfidence float64, limit, offset int) ([]*TranscriptionRecord, error) {
 	// Query records
 	rows, err := s.db.Query(
-		`SELECT id, frequency_id, created_at, content, is_complete, is_processed, content_processed, speaker_type, callsign 
+		`SELECT id, frequency_id, created_at, content, is_complete, is_processed, content_processed, speaker_type, callsign, correlation_id, audio_start_time, audio_end_time, confidence, words, aircraft_hex, audio_delay_ms 
 		FROM transcriptions 
-		WHERE frequency_id = ? 
+		WHERE frequency_id = ? AND `+confidenceFilter+`
 		ORDER BY created_at DESC 
 		LIMIT ? OFFSET ?`,
-		frequencyID, limit, offset,
+		frequencyID, minConfidence, limit, offset,
 	)
 	if err != nil {
 		return nil, fmt.Errorf("failed to query transcriptions by frequency: %w", err)
 	}
 	defer rows.Close()
 
-	// Parse records
-	var records []*TranscriptionRecord
-	for rows.Next() {
-		var record TranscriptionRecord
-		var createdAt string
-		var speakerType, callsign sql.NullString
-		var contentProcessed sql.NullString
-
-		if err := rows.Scan(
-			&record.ID,
-			&record.FrequencyID,
-			&createdAt,
-			&record.Content,
-			&record.IsComplete,
-			&record.IsProcessed,
-			&contentProcessed,
-			&speakerType,
-			&callsign,
-		); err != nil {
-			return nil, fmt.Errorf("failed to scan transcription: %w", err)
-		}
-
-		// Parse created_at
-		record.CreatedAt, err = time.Parse(time.RFC3339, createdAt)
-		if err != nil {
-			return nil, fmt.Errorf("failed to parse created_at: %w", err)
-		}
-
-		// Handle nullable fields
-		if contentProcessed.Valid {
-			record.ContentProcessed = contentProcessed.String
-		}
-		if speakerType.Valid {
-			record.SpeakerType = speakerType.String
-		}
-		if callsign.Valid {
-			record.Callsign = callsign.String
-		}
-
-		records = append(records, &record)
-	}
-
-	return records, nil
+	return s.scanTranscriptionRows(rows)
 }
 
 // GetTranscriptionsByTimeRange returns transcriptions within a time range
-func (s *TranscriptionStorage) GetTranscriptionsByTimeRange(startTime, endTime time.Time, limit, offset int) ([]*TranscriptionRecord, error) {
+func (s *TranscriptionStorage) GetTranscriptionsByTimeRange(startTime, endTime time.Time, minConfidence float64, limit, offset int) ([]*TranscriptionRecord, error) {
 	// Query records
 	rows, err := s.db.Query(
-		`SELECT id, frequency_id, created_at, content, is_complete, is_processed, content_processed, speaker_type, callsign 
+		`SELECT id, frequency_id, created_at, content, is_complete, is_processed, content_processed, speaker_type, callsign, correlation_id, audio_start_time, audio_end_time, confidence, words, aircraft_hex, audio_delay_ms 
 		FROM transcriptions 
-		WHERE created_at BETWEEN ? AND ? 
+		WHERE created_at BETWEEN ? AND ? AND `+confidenceFilter+`
 		ORDER BY created_at DESC 
 		LIMIT ? OFFSET ?`,
-		startTime.Format(time.RFC3339), endTime.Format(time.RFC3339), limit, offset,
+		startTime.Format(time.RFC3339), endTime.Format(time.RFC3339), minConfidence, limit, offset,
 	)
 	if err != nil {
 		return nil, fmt.Errorf("failed to query transcriptions by time range: %w", err)
 	}
 	defer rows.Close()
 
-	// Parse records
-	var records []*TranscriptionRecord
-	for rows.Next() {
-		var record TranscriptionRecord
-		var createdAt string
-		var speakerType, callsign sql.NullString
-		var contentProcessed sql.NullString
-
-		if err := rows.Scan(
-			&record.ID,
-			&record.FrequencyID,
-			&createdAt,
-			&record.Content,
-			&record.IsComplete,
-			&record.IsProcessed,
-			&contentProcessed,
-			&speakerType,
-			&callsign,
-		); err != nil {
-			return nil, fmt.Errorf("failed to scan transcription: %w", err)
-		}
-
-		// Parse created_at
-		record.CreatedAt, err = time.Parse(time.RFC3339, createdAt)
-		if err != nil {
-			return nil, fmt.Errorf("failed to parse created_at: %w", err)
-		}
-
-		// Handle nullable fields
-		if contentProcessed.Valid {
-			record.ContentProcessed = contentProcessed.String
-		}
-		if speakerType.Valid {
-			record.SpeakerType = speakerType.String
-		}
-		if callsign.Valid {
-			record.Callsign = callsign.String
-		}
+	return s.scanTranscriptionRows(rows)
+}
 
-		records = append(records, &record)
+// GetTranscriptionsForExport returns up to limit transcriptions created in a time range
+// with an ID after afterID, oldest first. Exports page through a range with it, so the
+// database is not held for the whole export.
+func (s *TranscriptionStorage) GetTranscriptionsForExport(startTime, endTime time.Time, afterID int64, limit int) ([]*TranscriptionRecord, error) {
+	rows, err := s.db.Query(
+		`SELECT id, frequency_id, created_at, content, is_complete, is_processed, content_processed, speaker_type, callsign, correlation_id, audio_start_time, audio_end_time, confidence, words, aircraft_hex, audio_delay_ms
+		FROM transcriptions
+		WHERE created_at BETWEEN ? AND ? AND id > ?
+		ORDER BY id
+		LIMIT ?`,
+		startTime.UTC().Format(time.RFC3339), endTime.UTC().Format(time.RFC3339), afterID, limit,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query transcriptions for export: %w", err)
 	}
+	defer rows.Close()
 
-	return records, nil
+	return s.scanTranscriptionRows(rows)
 }
 
 // GetTranscriptionsBySpeaker returns transcriptions by speaker type
-func (s *TranscriptionStorage) GetTranscriptionsBySpeaker(speakerType string, limit, offset int) ([]*TranscriptionRecord, error) {
+func (s *TranscriptionStorage) GetTranscriptionsBySpeaker(speakerType string, minConfidence float64, limit, offset int) ([]*TranscriptionRecord, error) {
 	// Query records
 	rows, err := s.db.Query(
-		`SELECT id, frequency_id, created_at, content, is_complete, is_processed, content_processed, speaker_type, callsign 
+		`SELECT id, frequency_id, created_at, content, is_complete, is_processed, content_processed, speaker_type, callsign, correlation_id, audio_start_time, audio_end_time, confidence, words, aircraft_hex, audio_delay_ms 
 		FROM transcriptions 
-		WHERE speaker_type = ? 
+		WHERE speaker_type = ? AND `+confidenceFilter+`
 		ORDER BY created_at DESC 
 		LIMIT ? OFFSET ?`,
-		speakerType, limit, offset,
+		speakerType, minConfidence, limit, offset,
 	)
 	if err != nil {
 		return nil, fmt.Errorf("failed to query transcriptions by speaker: %w", err)
 	}
 	defer rows.Close()
 
-	// Parse records
-	var records []*TranscriptionRecord
-	for rows.Next() {
-		var record TranscriptionRecord
-		var createdAt string
-		var speakerTypeDB, callsign sql.NullString
-		var contentProcessed sql.NullString
-
-		if err := rows.Scan(
-			&record.ID,
-			&record.FrequencyID,
-			&createdAt,
-			&record.Content,
-			&record.IsComplete,
-			&record.IsProcessed,
-			&contentProcessed,
-			&speakerTypeDB,
-			&callsign,
-		); err != nil {
-			return nil, fmt.Errorf("failed to scan transcription: %w", err)
-		}
-
-		// Parse created_at
-		record.CreatedAt, err = time.Parse(time.RFC3339, createdAt)
-		if err != nil {
-			return nil, fmt.Errorf("failed to parse created_at: %w", err)
-		}
-
-		// Handle nullable fields
-		if contentProcessed.Valid {
-			record.ContentProcessed = contentProcessed.String
-		}
-		if speakerTypeDB.Valid {
-			record.SpeakerType = speakerTypeDB.String
-		}
-		if callsign.Valid {
-			record.Callsign = callsign.String
-		}
-
-		records = append(records, &record)
-	}
-
-	return records, nil
+	return s.scanTranscriptionRows(rows)
 }
 
 // GetTranscriptionsByCallsign returns transcriptions by aircraft callsign
-func (s *TranscriptionStorage) GetTranscriptionsByCallsign(callsign string, limit, offset int) ([]*TranscriptionRecord, error) {
+func (s *TranscriptionStorage) GetTranscriptionsByCallsign(callsign string, minConfidence float64, limit, offset int) ([]*TranscriptionRecord, error) {
 	// Query records
 	rows, err := s.db.Query(
-		`SELECT id, frequency_id, created_at, content, is_complete, is_processed, content_processed, speaker_type, callsign 
+		`SELECT id, frequency_id, created_at, content, is_complete, is_processed, content_processed, speaker_type, callsign, correlation_id, audio_start_time, audio_end_time, confidence, words, aircraft_hex, audio_delay_ms 
 		FROM transcriptions 
-		WHERE callsign = ? 
+		WHERE callsign = ? AND `+confidenceFilter+`
 		ORDER BY created_at DESC 
 		LIMIT ? OFFSET ?`,
-		callsign, limit, offset,
+		callsign, minConfidence, limit, offset,
 	)
 	if err != nil {
 		return nil, fmt.Errorf("failed to query transcriptions by callsign: %w", err)
 	}
 	defer rows.Close()
 
-	// Parse records
-	var records []*TranscriptionRecord
-	for rows.Next() {
-		var record TranscriptionRecord
-		var createdAt string
-		var speakerType, callsignDB sql.NullString
-		var contentProcessed sql.NullString
-
-		if err := rows.Scan(
-			&record.ID,
-			&record.FrequencyID,
-			&createdAt,
-			&record.Content,
-			&record.IsComplete,
-			&record.IsProcessed,
-			&contentProcessed,
-			&speakerType,
-			&callsignDB,
-		); err != nil {
-			return nil, fmt.Errorf("failed to scan transcription: %w", err)
-		}
-
-		// Parse created_at
-		record.CreatedAt, err = time.Parse(time.RFC3339, createdAt)
-		if err != nil {
-			return nil, fmt.Errorf("failed to parse created_at: %w", err)
-		}
-
-		// Handle nullable fields
-		if contentProcessed.Valid {
-			record.ContentProcessed = contentProcessed.String
-		}
-		if speakerType.Valid {
-			record.SpeakerType = speakerType.String
-		}
-		if callsignDB.Valid {
-			record.Callsign = callsignDB.String
-		}
-
-		records = append(records, &record)
-	}
-
-	return records, nil
+	return s.scanTranscriptionRows(rows)
 }
 
-// GetUnprocessedTranscriptions retrieves a batch of unprocessed transcriptions
-func (s *TranscriptionStorage) GetUnprocessedTranscriptions(batchSize int) ([]*TranscriptionRecord, error) {
-	// Query records
+// GetTranscriptionsByAircraft returns an aircraft's transcriptions, newest first: those
+// linked to its hex, and those with its callsign that aren't linked to any aircraft
+func (s *TranscriptionStorage) GetTranscriptionsByAircraft(hex, callsign string, limit int) ([]*TranscriptionRecord, error) {
 	rows, err := s.db.Query(
-		`SELECT id, frequency_id, created_at, content, is_complete, is_processed, content_processed, speaker_type, callsign
+		`SELECT id, frequency_id, created_at, content, is_complete, is_processed, content_processed, speaker_type, callsign, correlation_id, audio_start_time, audio_end_time, confidence, words, aircraft_hex, audio_delay_ms
 		FROM transcriptions
-		WHERE is_complete = 1 AND is_processed = 0
-		ORDER BY created_at ASC
+		WHERE aircraft_hex = ? OR (aircraft_hex IS NULL AND callsign != '' AND callsign = ?)
+		ORDER BY created_at DESC
 		LIMIT ?`,
-		batchSize,
+		strings.ToLower(hex), callsign, limit,
 	)
 	if err != nil {
-		return nil, fmt.Errorf("failed to query unprocessed transcriptions: %w", err)
+		return nil, fmt.Errorf("failed to query transcriptions by aircraft: %w", err)
 	}
 	defer rows.Close()
 
-	// Parse records
-	var records []*TranscriptionRecord
-	for rows.Next() {
-		var record TranscriptionRecord
-		var createdAt string
-		var speakerType, callsign sql.NullString
-		var contentProcessed sql.NullString
+	return s.scanTranscriptionRows(rows)
+}
 
-		if err := rows.Scan(
-			&record.ID,
-			&record.FrequencyID,
-			&createdAt,
-			&record.Content,
-			&record.IsComplete,
-			&record.IsProcessed,
-			&contentProcessed,
-			&speakerType,
-			&callsign,
-		); err != nil {
-			return nil, fmt.Errorf("failed to scan transcription: %w", err)
+// GetUnprocessedTranscriptions retrieves up to limit unprocessed transcriptions, newest
+// first, leaving out the frequencies in excludeFrequencyIDs
+func (s *TranscriptionStorage) GetUnprocessedTranscriptions(limit int, excludeFrequencyIDs []string) ([]*TranscriptionRecord, error) {
+	query := `SELECT id, frequency_id, created_at, content, is_complete, is_processed, content_processed, speaker_type, callsign, correlation_id, audio_start_time, audio_end_time, confidence, words, aircraft_hex, audio_delay_ms
+		FROM transcriptions
+		WHERE is_complete = 1 AND is_processed = 0`
+	args := make([]interface{}, 0, len(excludeFrequencyIDs)+1)
+	if len(excludeFrequencyIDs) > 0 {
+		query += ` AND frequency_id NOT IN (?` + strings.Repeat(", ?", len(excludeFrequencyIDs)-1) + `)`
+		for _, id := range excludeFrequencyIDs {
+			args = append(args, id)
 		}
+	}
+	query += ` ORDER BY created_at DESC LIMIT ?`
+	args = append(args, limit)
 
-		// Parse created_at
-		record.CreatedAt, err = time.Parse(time.RFC3339, createdAt)
-		if err != nil {
-			return nil, fmt.Errorf("failed to parse created_at: %w", err)
-		}
+	rows, err := s.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query unprocessed transcriptions: %w", err)
+	}
+	defer rows.Close()
 
-		// Handle nullable fields
-		if contentProcessed.Valid {
-			record.ContentProcessed = contentProcessed.String
-		}
-		if speakerType.Valid {
-			record.SpeakerType = speakerType.String
-		}
-		if callsign.Valid {
-			record.Callsign = callsign.String
-		}
+	return s.scanTranscriptionRows(rows)
+}
 
-		records = append(records, &record)
+// CountUnprocessedTranscriptions returns how many transcriptions are waiting for post-processing
+func (s *TranscriptionStorage) CountUnprocessedTranscriptions() (int, error) {
+	var count int
+	err := s.db.QueryRow(
+		`SELECT COUNT(*) FROM transcriptions WHERE is_complete = 1 AND is_processed = 0`,
+	).Scan(&count)
+	if err != nil {
+		return 0, fmt.Errorf("failed to count unprocessed transcriptions: %w", err)
 	}
-
-	return records, nil
+	return count, nil
 }
 
-// UpdateProcessedTranscription updates a transcription with processed content
-func (s *TranscriptionStorage) UpdateProcessedTranscription(id int64, contentProcessed string, speakerType string, callsign string) error {
+// UpdateProcessedTranscription updates a transcription with processed content and the
+// hex of the aircraft its callsign was correlated with, if any
+func (s *TranscriptionStorage) UpdateProcessedTranscription(id int64, contentProcessed string, speakerType string, callsign string, aircraftHex string) error {
 	// Update record
-	_, err := s.db.Exec(
+	_, err := s.stmts.exec(
 		`UPDATE transcriptions
-		SET content_processed = ?, is_processed = 1, speaker_type = ?, callsign = ?
+		SET content_processed = ?, is_processed = 1, speaker_type = ?, callsign = ?, aircraft_hex = ?
 		WHERE id = ?`,
 		contentProcessed,
 		speakerType,
 		callsign,
+		nullIfEmpty(aircraftHex),
 		id,
 	)
 	if err != nil {
@@ -505,29 +298,80 @@ func (s *TranscriptionStorage) UpdateProcessedTranscription(id int64, contentPro
 	return nil
 }
 
-// GetLastProcessedTranscriptions retrieves the last N processed transcriptions for a given frequency
-func (s *TranscriptionStorage) GetLastProcessedTranscriptions(frequencyID string, limit int) ([]*TranscriptionRecord, error) {
+// GetLastProcessedTranscriptions retrieves the last N processed transcriptions for a given
+// frequency made before a time
+func (s *TranscriptionStorage) GetLastProcessedTranscriptions(frequencyID string, before time.Time, limit int) ([]*TranscriptionRecord, error) {
 	// Query records
 	rows, err := s.db.Query(
-		`SELECT id, frequency_id, created_at, content, is_complete, is_processed, content_processed, speaker_type, callsign
+		`SELECT id, frequency_id, created_at, content, is_complete, is_processed, content_processed, speaker_type, callsign, correlation_id, audio_start_time, audio_end_time, confidence, words, aircraft_hex, audio_delay_ms
 		FROM transcriptions
-		WHERE frequency_id = ? AND is_processed = 1
+		WHERE frequency_id = ? AND is_processed = 1 AND created_at < ?
 		ORDER BY created_at DESC
 		LIMIT ?`,
-		frequencyID, limit,
+		frequencyID, before.UTC().Format(time.RFC3339), limit,
 	)
 	if err != nil {
 		return nil, fmt.Errorf("failed to query last processed transcriptions: %w", err)
 	}
 	defer rows.Close()
 
-	// Parse records
+	return s.scanTranscriptionRows(rows)
+}
+
+// GetTranscriptionsByCorrelationID returns transcriptions carrying the given correlation ID
+func (s *TranscriptionStorage) GetTranscriptionsByCorrelationID(correlationID string) ([]*TranscriptionRecord, error) {
+	// Query records
+	rows, err := s.db.Query(
+		`SELECT id, frequency_id, created_at, content, is_complete, is_processed, content_processed, speaker_type, callsign, correlation_id, audio_start_time, audio_end_time, confidence, words, aircraft_hex, audio_delay_ms
+		FROM transcriptions
+		WHERE correlation_id = ?
+		ORDER BY created_at ASC`,
+		correlationID,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query transcriptions by correlation ID: %w", err)
+	}
+	defer rows.Close()
+
+	return s.scanTranscriptionRows(rows)
+}
+
+// GetTranscriptionByID returns a single transcription, or nil if it does not exist
+func (s *TranscriptionStorage) GetTranscriptionByID(id int64) (*TranscriptionRecord, error) {
+	rows, err := s.db.Query(
+		`SELECT id, frequency_id, created_at, content, is_complete, is_processed, content_processed, speaker_type, callsign, correlation_id, audio_start_time, audio_end_time, confidence, words, aircraft_hex, audio_delay_ms
+		FROM transcriptions
+		WHERE id = ?`,
+		id,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query transcription: %w", err)
+	}
+	defer rows.Close()
+
+	records, err := s.scanTranscriptionRows(rows)
+	if err != nil {
+		return nil, err
+	}
+	if len(records) == 0 {
+		return nil, nil
+	}
+
+	return records[0], nil
+}
+
+// scanTranscriptionRows scans database rows into TranscriptionRecord structs
+func (s *TranscriptionStorage) scanTranscriptionRows(rows *sql.Rows) ([]*TranscriptionRecord, error) {
 	var records []*TranscriptionRecord
 	for rows.Next() {
 		var record TranscriptionRecord
 		var createdAt string
 		var speakerType, callsign sql.NullString
-		var contentProcessed sql.NullString
+		var contentProcessed, correlationID sql.NullString
+		var audioStart, audioEnd sql.NullString
+		var confidence sql.NullFloat64
+		var words, aircraftHex sql.NullString
+		var audioDelay sql.NullInt64
 
 		if err := rows.Scan(
 			&record.ID,
@@ -539,11 +383,19 @@ func (s *TranscriptionStorage) GetLastProcessedTranscriptions(frequencyID string
 			&contentProcessed,
 			&speakerType,
 			&callsign,
+			&correlationID,
+			&audioStart,
+			&audioEnd,
+			&confidence,
+			&words,
+			&aircraftHex,
+			&audioDelay,
 		); err != nil {
 			return nil, fmt.Errorf("failed to scan transcription: %w", err)
 		}
 
 		// Parse created_at
+		var err error
 		record.CreatedAt, err = time.Parse(time.RFC3339, createdAt)
 		if err != nil {
 			return nil, fmt.Errorf("failed to parse created_at: %w", err)
@@ -559,9 +411,75 @@ func (s *TranscriptionStorage) GetLastProcessedTranscriptions(frequencyID string
 		if callsign.Valid {
 			record.Callsign = callsign.String
 		}
+		if correlationID.Valid {
+			record.CorrelationID = correlationID.String
+		}
+		record.AudioStart = parseAudioTime(audioStart)
+		record.AudioEnd = parseAudioTime(audioEnd)
+		if confidence.Valid {
+			value := confidence.Float64
+			record.Confidence = &value
+		}
+		if aircraftHex.Valid {
+			record.AircraftHex = aircraftHex.String
+		}
+		record.AudioDelayMs = int(audioDelay.Int64)
+		if words.Valid && words.String != "" {
+			if err := json.Unmarshal([]byte(words.String), &record.Words); err != nil {
+				s.logger.Warn("Failed to parse transcription word timings", Error(err))
+			}
+		}
 
 		records = append(records, &record)
 	}
 
 	return records, nil
 }
+
+// nullIfEmpty stores an empty optional string as NULL
+func nullIfEmpty(value string) interface{} {
+	if value == "" {
+		return nil
+	}
+	return value
+}
+
+// nullIfZero stores a zero integer as NULL
+func nullIfZero(value int) interface{} {
+	if value == 0 {
+		return nil
+	}
+	return value
+}
+
+// formatAudioTime formats an optional transmission time with millisecond precision
+func formatAudioTime(t *time.Time) interface{} {
+	if t == nil {
+		return nil
+	}
+	return t.UTC().Format(recordingTimeFormat)
+}
+
+// parseAudioTime parses an optional transmission time
+func parseAudioTime(value sql.NullString) *time.Time {
+	if !value.Valid || value.String == "" {
+		return nil
+	}
+	t, err := time.Parse(time.RFC3339, value.String)
+	if err != nil {
+		return nil
+	}
+	return &t
+}
+
+// formatWords encodes optional word timings
+func formatWords(words []TranscriptionWord) (interface{}, error) {
+	if len(words) == 0 {
+		return nil, nil
+	}
+	data, err := json.Marshal(words)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode word timings: %w", err)
+	}
+	return string(data), nil
+}
@@ -0,0 +1,101 @@
+package sqlite
+
+import (
+	"database/sql"
+	"fmt"
+
+	"github.com/yegors/co-atc/pkg/logger"
+)
+
+// UsageDay is the API usage of one model by one subsystem on one day (UTC)
+type UsageDay struct {
+	Day          string  `json:"day"` // YYYY-MM-DD
+	Subsystem    string  `json:"subsystem"`
+	Model        string  `json:"model"`
+	Requests     int64   `json:"requests"`
+	InputTokens  int64   `json:"input_tokens"`
+	OutputTokens int64   `json:"output_tokens"`
+	AudioSeconds float64 `json:"audio_seconds"`
+	CostUSD      float64 `json:"cost_usd"`
+}
+
+// UsageStorage handles storage of daily API usage aggregates
+type UsageStorage struct {
+	db     *sql.DB
+	logger *logger.Logger
+}
+
+// NewUsageStorage creates a new SQLite usage storage
+func NewUsageStorage(db *sql.DB, logger *logger.Logger) *UsageStorage {
+	return &UsageStorage{
+		db:     db,
+		logger: logger.Named("sqlite-usage"),
+	}
+}
+
+// AddUsage adds usage to the aggregates of its day, subsystem and model
+func (s *UsageStorage) AddUsage(usage []UsageDay) error {
+	if len(usage) == 0 {
+		return nil
+	}
+
+	stmt, err := s.db.Prepare(
+		`INSERT INTO usage_daily
+		(day, subsystem, model, requests, input_tokens, output_tokens, audio_seconds, cost_usd)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT (day, subsystem, model) DO UPDATE SET
+			requests = requests + excluded.requests,
+			input_tokens = input_tokens + excluded.input_tokens,
+			output_tokens = output_tokens + excluded.output_tokens,
+			audio_seconds = audio_seconds + excluded.audio_seconds,
+			cost_usd = cost_usd + excluded.cost_usd`,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to prepare usage statement: %w", err)
+	}
+	defer stmt.Close()
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	txStmt := tx.Stmt(stmt)
+	for _, u := range usage {
+		if _, err := txStmt.Exec(u.Day, u.Subsystem, u.Model, u.Requests, u.InputTokens, u.OutputTokens, u.AudioSeconds, u.CostUSD); err != nil {
+			return fmt.Errorf("failed to add usage: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit usage: %w", err)
+	}
+	return nil
+}
+
+// GetDailyUsage returns the usage aggregates of the days from fromDay to toDay inclusive
+// (YYYY-MM-DD), oldest first
+func (s *UsageStorage) GetDailyUsage(fromDay, toDay string) ([]UsageDay, error) {
+	rows, err := s.db.Query(
+		`SELECT day, subsystem, model, requests, input_tokens, output_tokens, audio_seconds, cost_usd
+		FROM usage_daily
+		WHERE day >= ? AND day <= ?
+		ORDER BY day, subsystem, model`,
+		fromDay, toDay,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query usage: %w", err)
+	}
+	defer rows.Close()
+
+	var usage []UsageDay
+	for rows.Next() {
+		var u UsageDay
+		if err := rows.Scan(&u.Day, &u.Subsystem, &u.Model, &u.Requests, &u.InputTokens, &u.OutputTokens, &u.AudioSeconds, &u.CostUSD); err != nil {
+			return nil, fmt.Errorf("failed to scan usage: %w", err)
+		}
+		usage = append(usage, u)
+	}
+	return usage, rows.Err()
+}
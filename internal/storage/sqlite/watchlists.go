@@ -0,0 +1,122 @@
+package sqlite
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/yegors/co-atc/internal/adsb"
+	"github.com/yegors/co-atc/pkg/logger"
+)
+
+// WatchlistStorage handles storage of aircraft watchlists
+type WatchlistStorage struct {
+	db     *sql.DB
+	logger *logger.Logger
+}
+
+// NewWatchlistStorage creates a new SQLite watchlist storage
+func NewWatchlistStorage(db *sql.DB, logger *logger.Logger) *WatchlistStorage {
+	return &WatchlistStorage{
+		db:     db,
+		logger: logger.Named("sqlite-watchlists"),
+	}
+}
+
+// ListWatchlists returns every watchlist, by ID
+func (s *WatchlistStorage) ListWatchlists() ([]*adsb.Watchlist, error) {
+	rows, err := s.db.Query(
+		`SELECT id, name, hex_codes, registrations, callsign_prefixes, aircraft_types, events, notify, created_at, updated_at
+		FROM watchlists
+		ORDER BY id`,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query watchlists: %w", err)
+	}
+	defer rows.Close()
+
+	watchlists := make([]*adsb.Watchlist, 0)
+	for rows.Next() {
+		var w adsb.Watchlist
+		var hexCodes, registrations, callsignPrefixes, aircraftTypes, events, createdAt, updatedAt string
+		var notify int
+		if err := rows.Scan(&w.ID, &w.Name, &hexCodes, &registrations, &callsignPrefixes, &aircraftTypes,
+			&events, &notify, &createdAt, &updatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan watchlist: %w", err)
+		}
+		w.HexCodes = splitList(hexCodes)
+		w.Registrations = splitList(registrations)
+		w.CallsignPrefixes = splitList(callsignPrefixes)
+		w.AircraftTypes = splitList(aircraftTypes)
+		w.Events = splitList(events)
+		w.Notify = notify != 0
+		w.CreatedAt, _ = time.Parse(time.RFC3339, createdAt)
+		w.UpdatedAt, _ = time.Parse(time.RFC3339, updatedAt)
+		watchlists = append(watchlists, &w)
+	}
+	return watchlists, rows.Err()
+}
+
+// CreateWatchlist stores a new watchlist and sets its ID
+func (s *WatchlistStorage) CreateWatchlist(w *adsb.Watchlist) error {
+	result, err := s.db.Exec(
+		`INSERT INTO watchlists (name, hex_codes, registrations, callsign_prefixes, aircraft_types, events, notify, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		w.Name,
+		strings.Join(w.HexCodes, ","),
+		strings.Join(w.Registrations, ","),
+		strings.Join(w.CallsignPrefixes, ","),
+		strings.Join(w.AircraftTypes, ","),
+		strings.Join(w.Events, ","),
+		w.Notify,
+		w.CreatedAt.UTC().Format(time.RFC3339),
+		w.UpdatedAt.UTC().Format(time.RFC3339),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to insert watchlist: %w", err)
+	}
+	w.ID, err = result.LastInsertId()
+	return err
+}
+
+// UpdateWatchlist replaces a watchlist. It reports whether the watchlist exists.
+func (s *WatchlistStorage) UpdateWatchlist(w *adsb.Watchlist) (bool, error) {
+	result, err := s.db.Exec(
+		`UPDATE watchlists SET name = ?, hex_codes = ?, registrations = ?, callsign_prefixes = ?, aircraft_types = ?,
+		events = ?, notify = ?, updated_at = ?
+		WHERE id = ?`,
+		w.Name,
+		strings.Join(w.HexCodes, ","),
+		strings.Join(w.Registrations, ","),
+		strings.Join(w.CallsignPrefixes, ","),
+		strings.Join(w.AircraftTypes, ","),
+		strings.Join(w.Events, ","),
+		w.Notify,
+		w.UpdatedAt.UTC().Format(time.RFC3339),
+		w.ID,
+	)
+	if err != nil {
+		return false, fmt.Errorf("failed to update watchlist: %w", err)
+	}
+	updated, err := result.RowsAffected()
+	return updated > 0, err
+}
+
+// DeleteWatchlist deletes a watchlist. It reports whether the watchlist existed.
+func (s *WatchlistStorage) DeleteWatchlist(id int64) (bool, error) {
+	result, err := s.db.Exec(`DELETE FROM watchlists WHERE id = ?`, id)
+	if err != nil {
+		return false, fmt.Errorf("failed to delete watchlist: %w", err)
+	}
+	deleted, err := result.RowsAffected()
+	return deleted > 0, err
+}
+
+// splitList splits a comma-separated column, returning an empty list for an empty column
+func splitList(value string) []string {
+	if value == "" {
+		return []string{}
+	}
+	return strings.Split(value, ",")
+}
@@ -0,0 +1,149 @@
+package sqlite
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/yegors/co-atc/pkg/logger"
+)
+
+// WeatherObservationRecord is a stored METAR with its decoded values. Values the METAR doesn't
+// report are nil.
+type WeatherObservationRecord struct {
+	ID               int64     `json:"id"`
+	Airport          string    `json:"airport"`
+	ObservedAt       time.Time `json:"observed_at"`
+	Raw              string    `json:"raw"`
+	Provider         string    `json:"provider,omitempty"`
+	WindDirectionDeg *int      `json:"wind_direction_deg,omitempty"` // Nil for calm or variable wind
+	WindSpeedKt      *int      `json:"wind_speed_kt,omitempty"`
+	WindGustKt       *int      `json:"wind_gust_kt,omitempty"`
+	VisibilitySM     *float64  `json:"visibility_sm,omitempty"`
+	CeilingFt        *int      `json:"ceiling_ft,omitempty"`
+	AltimeterInHg    *float64  `json:"altimeter_inhg,omitempty"`
+	QNHHPa           *int      `json:"qnh_hpa,omitempty"`
+	TemperatureC     *int      `json:"temperature_c,omitempty"`
+	DewpointC        *int      `json:"dewpoint_c,omitempty"`
+	FlightCategory   string    `json:"flight_category,omitempty"`
+	FetchedAt        time.Time `json:"fetched_at"` // When the METAR was first fetched
+}
+
+// WeatherHistoryStorage handles storage of METAR observations for weather trends
+type WeatherHistoryStorage struct {
+	db     *sql.DB
+	logger *logger.Logger
+}
+
+// NewWeatherHistoryStorage creates a new SQLite weather history storage
+func NewWeatherHistoryStorage(db *sql.DB, logger *logger.Logger) *WeatherHistoryStorage {
+	return &WeatherHistoryStorage{
+		db:     db,
+		logger: logger.Named("sqlite-weather-history"),
+	}
+}
+
+// AddObservation stores a METAR. A METAR already stored, fetched again before the next one
+// is issued, is ignored; it reports whether the METAR was new.
+func (s *WeatherHistoryStorage) AddObservation(record *WeatherObservationRecord) (bool, error) {
+	result, err := s.db.Exec(
+		`INSERT OR IGNORE INTO weather_observations
+		(airport, observed_at, raw, provider, wind_direction_deg, wind_speed_kt, wind_gust_kt, visibility_sm,
+		ceiling_ft, altimeter_inhg, qnh_hpa, temperature_c, dewpoint_c, flight_category, fetched_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		record.Airport,
+		record.ObservedAt.UTC().Format(time.RFC3339),
+		record.Raw,
+		record.Provider,
+		record.WindDirectionDeg,
+		record.WindSpeedKt,
+		record.WindGustKt,
+		record.VisibilitySM,
+		record.CeilingFt,
+		record.AltimeterInHg,
+		record.QNHHPa,
+		record.TemperatureC,
+		record.DewpointC,
+		record.FlightCategory,
+		record.FetchedAt.UTC().Format(time.RFC3339),
+	)
+	if err != nil {
+		return false, fmt.Errorf("failed to insert weather observation: %w", err)
+	}
+	added, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	if added > 0 {
+		record.ID, _ = result.LastInsertId()
+	}
+	return added > 0, nil
+}
+
+// ListObservations returns the METARs of an airport observed since a time, oldest first
+func (s *WeatherHistoryStorage) ListObservations(airport string, since time.Time) ([]*WeatherObservationRecord, error) {
+	rows, err := s.db.Query(
+		`SELECT id, airport, observed_at, raw, provider, wind_direction_deg, wind_speed_kt, wind_gust_kt,
+		visibility_sm, ceiling_ft, altimeter_inhg, qnh_hpa, temperature_c, dewpoint_c, flight_category, fetched_at
+		FROM weather_observations
+		WHERE airport = ? AND observed_at >= ?
+		ORDER BY observed_at, id`,
+		airport, since.UTC().Format(time.RFC3339),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list weather observations: %w", err)
+	}
+	defer rows.Close()
+
+	records := make([]*WeatherObservationRecord, 0)
+	for rows.Next() {
+		var record WeatherObservationRecord
+		var observedAt, fetchedAt string
+		var windDirection, windSpeed, windGust, ceiling, qnh, temperature, dewpoint sql.NullInt64
+		var visibility, altimeter sql.NullFloat64
+		if err := rows.Scan(&record.ID, &record.Airport, &observedAt, &record.Raw, &record.Provider,
+			&windDirection, &windSpeed, &windGust, &visibility, &ceiling, &altimeter, &qnh,
+			&temperature, &dewpoint, &record.FlightCategory, &fetchedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan weather observation: %w", err)
+		}
+		record.ObservedAt, _ = time.Parse(time.RFC3339, observedAt)
+		record.FetchedAt, _ = time.Parse(time.RFC3339, fetchedAt)
+		record.WindDirectionDeg = nullIntPtr(windDirection)
+		record.WindSpeedKt = nullIntPtr(windSpeed)
+		record.WindGustKt = nullIntPtr(windGust)
+		record.VisibilitySM = nullFloatPtr(visibility)
+		record.CeilingFt = nullIntPtr(ceiling)
+		record.AltimeterInHg = nullFloatPtr(altimeter)
+		record.QNHHPa = nullIntPtr(qnh)
+		record.TemperatureC = nullIntPtr(temperature)
+		record.DewpointC = nullIntPtr(dewpoint)
+		records = append(records, &record)
+	}
+	return records, rows.Err()
+}
+
+// DeleteObservationsBefore deletes METARs observed before a time and returns how many were deleted
+func (s *WeatherHistoryStorage) DeleteObservationsBefore(cutoff time.Time) (int64, error) {
+	result, err := s.db.Exec(`DELETE FROM weather_observations WHERE observed_at < ?`, cutoff.UTC().Format(time.RFC3339))
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete weather observations: %w", err)
+	}
+	return result.RowsAffected()
+}
+
+// nullIntPtr returns a nullable integer column as a pointer
+func nullIntPtr(value sql.NullInt64) *int {
+	if !value.Valid {
+		return nil
+	}
+	v := int(value.Int64)
+	return &v
+}
+
+// nullFloatPtr returns a nullable real column as a pointer
+func nullFloatPtr(value sql.NullFloat64) *float64 {
+	if !value.Valid {
+		return nil
+	}
+	return &value.Float64
+}
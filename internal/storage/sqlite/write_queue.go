@@ -0,0 +1,267 @@
+package sqlite
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/yegors/co-atc/internal/adsb"
+	"github.com/yegors/co-atc/pkg/logger"
+)
+
+// WriteOptions tunes how aircraft writes reach the daily database
+type WriteOptions struct {
+	QueueSize          int           // Aircraft writes buffered ahead of the database (0 = write synchronously)
+	BatchSize          int           // Most queued writes committed in one transaction
+	WALAutocheckpoint  int           // WAL pages written before SQLite checkpoints automatically
+	CheckpointInterval time.Duration // How often the WAL is checkpointed and truncated (0 = only automatically)
+}
+
+// aircraftWrite is a queued aircraft write, or a flush marker when flushed is set
+type aircraftWrite struct {
+	aircraft      adsb.Aircraft // Snapshot taken when the write was queued
+	storePosition bool
+	flushed       chan struct{}
+}
+
+// startWriter applies the WAL settings and starts the goroutine that drains the write queue
+func (s *AircraftStorage) startWriter() error {
+	if s.options.WALAutocheckpoint > 0 {
+		if _, err := s.db.Exec(fmt.Sprintf("PRAGMA wal_autocheckpoint=%d", s.options.WALAutocheckpoint)); err != nil {
+			return fmt.Errorf("failed to set WAL autocheckpoint: %w", err)
+		}
+	}
+	if s.options.BatchSize <= 0 {
+		s.options.BatchSize = 1
+	}
+	if s.options.QueueSize <= 0 {
+		return nil
+	}
+
+	s.writes = make(chan aircraftWrite, s.options.QueueSize)
+	s.writerDone = make(chan struct{})
+	go s.runWriter()
+
+	s.logger.Info("Started aircraft write queue",
+		logger.Int("queue_size", s.options.QueueSize),
+		logger.Int("batch_size", s.options.BatchSize))
+	return nil
+}
+
+// queueWrite queues an aircraft write, or writes it right away when there is no queue.
+// The aircraft is copied, so the caller may keep changing it.
+func (s *AircraftStorage) queueWrite(aircraft *adsb.Aircraft, storePosition bool) {
+	// Ensure all timestamps are in UTC and new data is active
+	aircraft.LastSeen = aircraft.LastSeen.UTC()
+	if aircraft.Status == "" {
+		aircraft.Status = "active"
+	}
+
+	write := aircraftWrite{aircraft: *aircraft, storePosition: storePosition}
+	if aircraft.ADSB != nil {
+		target := *aircraft.ADSB
+		write.aircraft.ADSB = &target
+	}
+
+	s.writesMu.RLock()
+	defer s.writesMu.RUnlock()
+	if s.writes == nil || s.writesClosed {
+		s.writeBatch([]aircraftWrite{write})
+		return
+	}
+
+	select {
+	case s.writes <- write:
+	default:
+		// Queue full: the database is behind, so wait rather than drop the write
+		now := time.Now().UnixNano()
+		if last := s.queueFullWarned.Load(); now-last > int64(time.Minute) && s.queueFullWarned.CompareAndSwap(last, now) {
+			s.logger.Warn("Aircraft write queue full, waiting for the database",
+				logger.Int("queue_size", cap(s.writes)))
+		}
+		s.writes <- write
+	}
+}
+
+// Flush waits until every aircraft write queued so far has been committed
+func (s *AircraftStorage) Flush() {
+	s.writesMu.RLock()
+	if s.writes == nil || s.writesClosed {
+		s.writesMu.RUnlock()
+		return
+	}
+	flushed := make(chan struct{})
+	s.writes <- aircraftWrite{flushed: flushed}
+	s.writesMu.RUnlock()
+
+	<-flushed
+}
+
+// stopWriter writes what is still queued and stops the writer
+func (s *AircraftStorage) stopWriter() {
+	s.writesMu.Lock()
+	if s.writes == nil || s.writesClosed {
+		s.writesMu.Unlock()
+		return
+	}
+	s.writesClosed = true
+	close(s.writes)
+	s.writesMu.Unlock()
+
+	<-s.writerDone
+}
+
+// runWriter commits queued writes in batches until the queue is closed. Whatever has
+// queued up while a batch was being committed goes into the next one, so batches grow
+// with the load instead of writes waiting on each other.
+func (s *AircraftStorage) runWriter() {
+	defer close(s.writerDone)
+
+	var checkpoints <-chan time.Time
+	if s.options.CheckpointInterval > 0 {
+		ticker := time.NewTicker(s.options.CheckpointInterval)
+		defer ticker.Stop()
+		checkpoints = ticker.C
+	}
+
+	for {
+		select {
+		case write, ok := <-s.writes:
+			if !ok {
+				return
+			}
+			batch := []aircraftWrite{write}
+		drain:
+			for len(batch) < s.options.BatchSize {
+				select {
+				case write, ok := <-s.writes:
+					if !ok {
+						break drain
+					}
+					batch = append(batch, write)
+				default:
+					break drain
+				}
+			}
+			s.writeBatch(batch)
+
+		case <-checkpoints:
+			s.checkpoint()
+		}
+	}
+}
+
+// writeBatch commits a batch of aircraft writes in one transaction, then releases any
+// flush markers in it
+func (s *AircraftStorage) writeBatch(batch []aircraftWrite) {
+	defer func() {
+		for _, write := range batch {
+			if write.flushed != nil {
+				close(write.flushed)
+			}
+		}
+	}()
+
+	stmts, err := s.prepareWrites()
+	if err != nil {
+		s.logger.Error("Failed to prepare aircraft writes", logger.Error(err))
+		return
+	}
+
+	tx, err := s.beginTx()
+	if err != nil {
+		s.logger.Error("Failed to begin transaction after retries",
+			logger.Error(err),
+			logger.Int("writes", len(batch)))
+		return
+	}
+
+	written := 0
+	for i := range batch {
+		write := &batch[i]
+		if write.flushed != nil {
+			continue
+		}
+		// A failed statement only undoes itself, so the rest of the batch is still written
+		if err := s.writeAircraft(tx, stmts, &write.aircraft, write.storePosition); err != nil {
+			s.logger.Error("Failed to write aircraft", logger.Error(err), logger.String("hex", write.aircraft.Hex))
+			continue
+		}
+		written++
+	}
+
+	if err := s.commitTx(tx); err != nil {
+		s.logger.Error("Failed to commit transaction after retries",
+			logger.Error(err),
+			logger.Int("writes", len(batch)))
+		tx.Rollback()
+		return
+	}
+
+	// Serve the latest data of aircraft whose position wasn't stored until the next one is
+	s.liveTargetsMu.Lock()
+	for i := range batch {
+		write := &batch[i]
+		if write.flushed != nil {
+			continue
+		}
+		if write.storePosition {
+			delete(s.liveTargets, write.aircraft.Hex)
+		} else if write.aircraft.ADSB != nil {
+			s.liveTargets[write.aircraft.Hex] = write.aircraft.ADSB
+		}
+	}
+	s.liveTargetsMu.Unlock()
+
+	if written > 1 {
+		s.logger.Debug("Committed aircraft write batch", logger.Int("writes", written))
+	}
+}
+
+// beginTx begins a transaction, retrying with exponential backoff: 100ms, 200ms, 400ms
+func (s *AircraftStorage) beginTx() (*sql.Tx, error) {
+	var tx *sql.Tx
+	var err error
+	for i := 0; i < 3; i++ {
+		tx, err = s.db.Begin()
+		if err == nil {
+			return tx, nil
+		}
+
+		s.logger.Warn("Failed to begin transaction, retrying...",
+			logger.Error(err),
+			logger.Int("attempt", i+1))
+		time.Sleep(time.Duration(100*(1<<i)) * time.Millisecond)
+	}
+	return nil, err
+}
+
+// commitTx commits a transaction, retrying with exponential backoff: 100ms, 200ms, 400ms
+func (s *AircraftStorage) commitTx(tx *sql.Tx) error {
+	var err error
+	for i := 0; i < 3; i++ {
+		err = tx.Commit()
+		if err == nil {
+			return nil
+		}
+
+		s.logger.Warn("Failed to commit transaction, retrying...",
+			logger.Error(err),
+			logger.Int("attempt", i+1))
+		time.Sleep(time.Duration(100*(1<<i)) * time.Millisecond)
+	}
+	return err
+}
+
+// checkpoint copies the WAL into the database and truncates it, so the WAL file does not
+// keep the size it grew to at the busiest moment
+func (s *AircraftStorage) checkpoint() {
+	var busy, logPages, checkpointed int
+	if err := s.db.QueryRow("PRAGMA wal_checkpoint(TRUNCATE)").Scan(&busy, &logPages, &checkpointed); err != nil {
+		s.logger.Warn("Failed to checkpoint WAL", logger.Error(err))
+		return
+	}
+	if busy != 0 {
+		s.logger.Debug("WAL checkpoint blocked by readers, will retry", logger.Int("pages", logPages))
+	}
+}
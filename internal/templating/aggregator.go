@@ -3,11 +3,15 @@ package templating
 import (
 	"fmt"
 	"math"
+	"sync"
 	"time"
 
 	"github.com/yegors/co-atc/internal/adsb"
+	"github.com/yegors/co-atc/internal/approach"
+	"github.com/yegors/co-atc/internal/atis"
 	"github.com/yegors/co-atc/internal/config"
 	"github.com/yegors/co-atc/internal/frequencies"
+	"github.com/yegors/co-atc/internal/sequence"
 	"github.com/yegors/co-atc/internal/storage/sqlite"
 	"github.com/yegors/co-atc/internal/weather"
 	"github.com/yegors/co-atc/pkg/logger"
@@ -19,8 +23,15 @@ type DataAggregator struct {
 	weatherService       *weather.Service
 	transcriptionStorage *sqlite.TranscriptionStorage
 	frequencyService     *frequencies.Service
+	atisService          *atis.Service
+	approachService      *approach.Service
+	sequenceService      *sequence.Service
 	config               *config.Config
 	logger               *logger.Logger
+
+	// Ground speeds of aircraft, by hex, to tell whether they're speeding up or slowing down
+	speedSamples map[string]*speedSample
+	speedMu      sync.Mutex
 }
 
 // NewDataAggregator creates a new data aggregator
@@ -42,6 +53,21 @@ func NewDataAggregator(
 	}
 }
 
+// SetATISService sets the service providing the current ATIS, if D-ATIS polling is enabled
+func (da *DataAggregator) SetATISService(atisService *atis.Service) {
+	da.atisService = atisService
+}
+
+// SetApproachService sets the service flagging unstable approaches, if approach monitoring is enabled
+func (da *DataAggregator) SetApproachService(approachService *approach.Service) {
+	da.approachService = approachService
+}
+
+// SetSequenceService sets the service sequencing arrivals, if arrival sequencing is enabled
+func (da *DataAggregator) SetSequenceService(sequenceService *sequence.Service) {
+	da.sequenceService = sequenceService
+}
+
 // GetTemplateContext aggregates all current airspace data for templating
 func (da *DataAggregator) GetTemplateContext(opts FormattingOptions) (*TemplateContext, error) {
 	// Override max aircraft with config value if available for ATC chat
@@ -49,6 +75,9 @@ func (da *DataAggregator) GetTemplateContext(opts FormattingOptions) (*TemplateC
 	if opts.IncludeTranscriptionHistory && da.config.ATCChat.MaxContextAircraft > 0 {
 		maxAircraft = da.config.ATCChat.MaxContextAircraft
 	}
+	if opts.Profile.MaxAircraft > 0 {
+		maxAircraft = opts.Profile.MaxAircraft
+	}
 
 	da.logger.Debug("Aggregating template context",
 		logger.Int("max_aircraft", maxAircraft),
@@ -70,6 +99,7 @@ func (da *DataAggregator) GetTemplateContext(opts FormattingOptions) (*TemplateC
 		aircraft = []*adsb.Aircraft{}
 	}
 	context.Aircraft = aircraft
+	context.Annotations = da.annotateAircraft(aircraft, context.Airport, context.Timestamp)
 
 	// Get weather data if requested
 	if opts.IncludeWeather {
@@ -79,6 +109,10 @@ func (da *DataAggregator) GetTemplateContext(opts FormattingOptions) (*TemplateC
 			// Continue with nil weather rather than failing completely
 		}
 		context.Weather = weatherData
+
+		if da.atisService != nil {
+			context.ATIS = da.atisService.Current()
+		}
 	}
 
 	// Get runway data if requested
@@ -101,6 +135,13 @@ func (da *DataAggregator) GetTemplateContext(opts FormattingOptions) (*TemplateC
 			communications = []TranscriptionSummary{}
 		}
 		context.TranscriptionHistory = communications
+
+		if da.approachService != nil {
+			context.UnstableApproaches = da.approachService.Recent()
+		}
+		if da.sequenceService != nil {
+			context.ArrivalSequence = da.sequenceService.Sequence("").Arrivals
+		}
 	}
 
 	da.logger.Debug("Template context aggregated",
@@ -162,7 +203,8 @@ func (da *DataAggregator) getAircraftData(maxAircraft int) ([]*adsb.Aircraft, er
 		logger.Int("active_aircraft", len(activeAircraft)),
 		logger.Int("filtered_aircraft", len(aircraft)))
 
-	// Limit the number of aircraft
+	// Limit the number of aircraft, keeping the most important and nearest
+	prioritizeAircraft(aircraft)
 	if len(aircraft) > maxAircraft {
 		aircraft = aircraft[:maxAircraft]
 	}
@@ -201,6 +243,27 @@ func (da *DataAggregator) getRunwayData() ([]RunwayInfo, error) {
 		{Name: "33L", LengthFt: 10700, Active: true, Operations: []string{"departure", "arrival"}},
 	}
 
+	// Apply manual closures and a forced configuration
+	for i := range runways {
+		status := da.adsbService.GetRunwayStatus(runways[i].Name)
+		if status == nil {
+			continue
+		}
+		runways[i].Closed = status.Closed
+		runways[i].Override = status.Closed || status.Source == "override"
+		runways[i].Operations = []string{}
+		if status.Departures {
+			runways[i].Operations = append(runways[i].Operations, "departure")
+		}
+		if status.Arrivals {
+			runways[i].Operations = append(runways[i].Operations, "arrival")
+		}
+		runways[i].Active = len(runways[i].Operations) > 0
+		if status.Closed {
+			runways[i].Remarks = status.ClosureReason
+		}
+	}
+
 	return runways, nil
 }
 
@@ -219,7 +282,7 @@ func (da *DataAggregator) getRecentCommunications() ([]TranscriptionSummary, err
 	since := time.Now().UTC().Add(-time.Duration(timeWindowSeconds) * time.Second)
 	endTime := time.Now().UTC()
 
-	transcriptions, err := da.transcriptionStorage.GetTranscriptionsByTimeRange(since, endTime, 100, 0)
+	transcriptions, err := da.transcriptionStorage.GetTranscriptionsByTimeRange(since, endTime, 0, 100, 0)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get recent transcriptions: %w", err)
 	}
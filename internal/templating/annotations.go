@@ -0,0 +1,121 @@
+package templating
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/yegors/co-atc/internal/adsb"
+)
+
+const (
+	levelRateFPM        = 300              // Vertical rates within this are level flight
+	speedTrendWindow    = 30 * time.Second // Shortest time ground speed samples are compared over
+	speedTrendMaxAge    = 3 * time.Minute  // Samples older than this are too old to compare to
+	steadySpeedKtPerMin = 10               // Ground speed changes within this are steady
+)
+
+// speedSample is the ground speed of an aircraft at a time, and the trend worked out from
+// the sample before it
+type speedSample struct {
+	gs    float64
+	at    time.Time
+	trend string
+}
+
+// annotateAircraft works out the position of each aircraft relative to the airport and its
+// trends, by hex
+func (da *DataAggregator) annotateAircraft(aircraft []*adsb.Aircraft, airport AirportInfo, now time.Time) map[string]*AircraftAnnotation {
+	da.speedMu.Lock()
+	defer da.speedMu.Unlock()
+	if da.speedSamples == nil {
+		da.speedSamples = make(map[string]*speedSample)
+	}
+
+	annotations := make(map[string]*AircraftAnnotation, len(aircraft))
+	for _, ac := range aircraft {
+		if ac.ADSB == nil {
+			continue
+		}
+		annotation := &AircraftAnnotation{}
+		if bearing, ok := bearingFromAirport(ac, airport); ok {
+			annotation.DistanceNM = math.Round(*ac.Distance*10) / 10
+			annotation.BearingDeg = math.Round(bearing)
+			annotation.Direction = compassPoint(int(annotation.BearingDeg))
+			if !ac.OnGround && ac.ADSB.Track != 0 {
+				toField := adsb.CalculateBearing(ac.ADSB.Lat, ac.ADSB.Lon, airport.Coordinates[0], airport.Coordinates[1])
+				annotation.ClockPosition = clockHour(ac.ADSB.Track, toField)
+			}
+		}
+		if !ac.OnGround {
+			annotation.VerticalTrend = verticalTrend(ac.ADSB.BaroRate)
+		}
+		annotation.SpeedTrend = da.speedTrend(ac.Hex, ac.ADSB.GS, now)
+		annotation.Summary = annotation.describe()
+		annotations[ac.Hex] = annotation
+	}
+
+	// Forget aircraft that haven't been listed for a while
+	for hex, sample := range da.speedSamples {
+		if now.Sub(sample.at) > speedTrendMaxAge {
+			delete(da.speedSamples, hex)
+		}
+	}
+	return annotations
+}
+
+// speedTrend compares the ground speed of an aircraft to a sample at least speedTrendWindow
+// old, and keeps the trend until the next comparison. Must be called with the speed lock held.
+func (da *DataAggregator) speedTrend(hex string, gs float64, now time.Time) string {
+	sample, ok := da.speedSamples[hex]
+	if !ok || now.Sub(sample.at) > speedTrendMaxAge {
+		da.speedSamples[hex] = &speedSample{gs: gs, at: now}
+		return ""
+	}
+	elapsed := now.Sub(sample.at)
+	if elapsed < speedTrendWindow {
+		return sample.trend
+	}
+
+	trend := "steady"
+	rate := (gs - sample.gs) / elapsed.Minutes()
+	switch {
+	case rate > steadySpeedKtPerMin:
+		trend = "accelerating"
+	case rate < -steadySpeedKtPerMin:
+		trend = "decelerating"
+	}
+	da.speedSamples[hex] = &speedSample{gs: gs, at: now, trend: trend}
+	return trend
+}
+
+// verticalTrend describes a vertical rate in feet per minute
+func verticalTrend(rateFPM float64) string {
+	switch {
+	case rateFPM > levelRateFPM:
+		return "climbing"
+	case rateFPM < -levelRateFPM:
+		return "descending"
+	}
+	return "level"
+}
+
+// describe summarizes an annotation ("5.2 NM NE of the field, field at 2 o'clock,
+// descending, decelerating")
+func (a *AircraftAnnotation) describe() string {
+	var parts []string
+	if a.Direction != "" {
+		parts = append(parts, fmt.Sprintf("%.1f NM %s of the field", a.DistanceNM, a.Direction))
+	}
+	if a.ClockPosition != 0 {
+		parts = append(parts, fmt.Sprintf("field at %d o'clock", a.ClockPosition))
+	}
+	if a.VerticalTrend != "" {
+		parts = append(parts, a.VerticalTrend)
+	}
+	if a.SpeedTrend != "" {
+		parts = append(parts, a.SpeedTrend)
+	}
+	return strings.Join(parts, ", ")
+}
@@ -0,0 +1,353 @@
+package templating
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/yegors/co-atc/internal/atis"
+	"github.com/yegors/co-atc/internal/weather"
+)
+
+// ATISData is the data of a synthesized ATIS template
+type ATISData struct {
+	atis.Conditions
+	Information string // Information letter in the spelling alphabet ("Bravo")
+}
+
+var (
+	// notamHighlightPattern matches NOTAMs worth reading out: closures and facilities
+	// out of service
+	notamHighlightPattern = regexp.MustCompile(`\b(CLSD|CLOSED|U/S|UNSERVICEABLE|OUT OF SERVICE|OTS|UNAVBL|NOT AVBL)\b`)
+
+	// notamItemEPattern finds the text (item E) of a NOTAM in ICAO format
+	notamItemEPattern = regexp.MustCompile(`(?s)\bE\)\s*(.*?)(?:\s+[FG]\)|$)`)
+
+	// notamRunwayPattern finds runways in NOTAM text ("RWY 06L/24R")
+	notamRunwayPattern = regexp.MustCompile(`\bRWY\s+(\d{2}[LRC]?)(?:/(\d{2}[LRC]?))?\b`)
+)
+
+// notamWords are the NOTAM abbreviations spoken as words
+var notamWords = map[string]string{
+	"RWY": "runway", "TWY": "taxiway", "APRON": "apron", "CLSD": "closed", "U/S": "unserviceable",
+	"OTS": "out of service", "UNAVBL": "unavailable", "AVBL": "available", "APCH": "approach",
+	"LGT": "lighting", "LGTS": "lights", "BTN": "between", "WIP": "work in progress",
+	"MAINT": "maintenance", "FM": "from", "EXC": "except", "ACFT": "aircraft",
+	"THR": "threshold", "DEP": "departure", "ARR": "arrival",
+}
+
+// notamAcronyms are the NOTAM words spoken letter by letter, left in capitals
+var notamAcronyms = map[string]bool{
+	"ILS": true, "LOC": true, "GP": true, "DME": true, "VOR": true, "NDB": true, "RNAV": true,
+	"GNSS": true, "PAPI": true, "VASIS": true, "ALS": true, "RVR": true, "ATIS": true,
+}
+
+// weatherIntensities, weatherDescriptors and weatherPhenomena are how present weather groups
+// are spoken
+var (
+	weatherIntensities = map[byte]string{'-': "light", '+': "heavy"}
+	weatherDescriptors = map[string]string{
+		"MI": "shallow", "BC": "patches of", "PR": "partial", "DR": "low drifting",
+		"BL": "blowing", "TS": "thunderstorm", "FZ": "freezing",
+	}
+	weatherPhenomena = map[string]string{
+		"DZ": "drizzle", "RA": "rain", "SN": "snow", "SG": "snow grains", "PL": "ice pellets",
+		"GR": "hail", "GS": "small hail", "UP": "unknown precipitation", "BR": "mist",
+		"FG": "fog", "FU": "smoke", "VA": "volcanic ash", "DU": "dust", "SA": "sand",
+		"HZ": "haze", "SQ": "squalls", "FC": "funnel cloud", "SS": "sandstorm",
+		"DS": "duststorm", "PO": "dust whirls",
+	}
+)
+
+// cloudCovers are how cloud layer covers are spoken
+var cloudCovers = map[string]string{
+	"FEW": "few clouds", "SCT": "scattered clouds", "BKN": "broken", "OVC": "overcast",
+}
+
+// GetATISConditions builds the spoken conditions of a synthesized ATIS from the latest METAR,
+// the runways in use and up to maxNOTAMs NOTAM highlights
+func (da *DataAggregator) GetATISConditions(maxNOTAMs int) (*atis.Conditions, error) {
+	conditions := &atis.Conditions{Airport: da.getAirportInfo().Code}
+
+	weatherData, err := da.getWeatherData()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get weather data: %w", err)
+	}
+	if weatherData != nil && weatherData.Parsed != nil && weatherData.Parsed.METAR != nil {
+		metar := weatherData.Parsed.METAR
+		if !metar.ObservedAt.IsZero() {
+			conditions.Time = spellOut(metar.ObservedAt.UTC().Format("1504")) + " zulu"
+		}
+		conditions.Wind = spokenWind(metar.Wind)
+		conditions.Visibility = spokenVisibility(metar)
+		conditions.Weather = spokenWeather(metar.Weather)
+		conditions.Sky = spokenSky(metar)
+		conditions.Temperature = spokenTemperature(metar)
+		conditions.Altimeter = spokenAltimeter(metar)
+	}
+	if weatherData != nil && maxNOTAMs > 0 {
+		conditions.NOTAMs = notamHighlights(weatherData.NOTAMs, maxNOTAMs)
+	}
+
+	if da.adsbService != nil {
+		runways, err := da.getRunwayData()
+		if err != nil {
+			return nil, fmt.Errorf("failed to get runway data: %w", err)
+		}
+		conditions.Runways, conditions.Closures = spokenRunways(runways)
+	}
+
+	return conditions, nil
+}
+
+// spokenVisibility returns the visibility of a METAR as spoken: in statute miles where the
+// altimeter is in inches, else in meters or kilometers
+func spokenVisibility(metar *weather.METAR) string {
+	if metar.CAVOK {
+		return "CAVOK"
+	}
+	if metar.VisibilitySM == nil {
+		return ""
+	}
+
+	sm := *metar.VisibilitySM
+	if metar.AltimeterUnit == "hPa" {
+		meters := sm * 1609.344
+		if meters >= 9999 {
+			return "visibility one zero kilometers or more"
+		}
+		if meters >= 5000 {
+			return "visibility " + spellOut(strconv.Itoa(int(meters/1000))) + " kilometers"
+		}
+		return "visibility " + spellOut(strconv.Itoa(int(math.Round(meters/100)*100))) + " meters"
+	}
+
+	whole := int(sm)
+	var parts []string
+	if whole > 0 {
+		parts = append(parts, spellOut(strconv.Itoa(whole)))
+	}
+	switch fraction := sm - float64(whole); {
+	case fraction >= 0.7:
+		parts = append(parts, "three quarters")
+	case fraction >= 0.45:
+		parts = append(parts, "one half")
+	case fraction >= 0.2:
+		parts = append(parts, "one quarter")
+	case whole == 0:
+		parts = append(parts, "less than one quarter")
+	}
+	return "visibility " + strings.Join(parts, " and ")
+}
+
+// spokenWeather returns present weather groups as spoken ("light rain showers, mist")
+func spokenWeather(groups []string) string {
+	var spoken []string
+	for _, group := range groups {
+		if group == "" {
+			continue
+		}
+		var words []string
+		if intensity, ok := weatherIntensities[group[0]]; ok {
+			words = append(words, intensity)
+			group = group[1:]
+		}
+		vicinity := strings.HasPrefix(group, "VC")
+		group = strings.TrimPrefix(group, "VC")
+
+		showers := false
+		for i := 0; i+2 <= len(group); i += 2 {
+			code := group[i : i+2]
+			if code == "SH" {
+				showers = true
+			} else if word, ok := weatherDescriptors[code]; ok {
+				words = append(words, word)
+			} else if word, ok := weatherPhenomena[code]; ok {
+				words = append(words, word)
+			}
+		}
+		if showers {
+			words = append(words, "showers")
+		}
+		if vicinity {
+			words = append(words, "in the vicinity")
+		}
+		if len(words) > 0 {
+			spoken = append(spoken, strings.Join(words, " "))
+		}
+	}
+	return strings.Join(spoken, ", ")
+}
+
+// spokenSky returns the cloud layers of a METAR as spoken, marking the ceiling
+func spokenSky(metar *weather.METAR) string {
+	if metar.CAVOK {
+		return ""
+	}
+
+	var layers []string
+	for _, layer := range metar.Clouds {
+		var spoken string
+		cover, ok := cloudCovers[layer.Cover]
+		switch {
+		case layer.Cover == "VV":
+			spoken = "vertical visibility " + spokenHeight(layer.BaseFt)
+		case !ok:
+			continue
+		case metar.CeilingFt != nil && layer.BaseFt == *metar.CeilingFt && (layer.Cover == "BKN" || layer.Cover == "OVC"):
+			spoken = "ceiling " + spokenHeight(layer.BaseFt) + " " + cover
+		default:
+			spoken = cover + " at " + spokenHeight(layer.BaseFt)
+		}
+		switch layer.Type {
+		case "CB":
+			spoken += " cumulonimbus"
+		case "TCU":
+			spoken += " towering cumulus"
+		}
+		layers = append(layers, spoken)
+	}
+	return strings.Join(layers, ", ")
+}
+
+// spokenHeight says a cloud base the way ATC does: thousands digit by digit, then hundreds
+// ("one two thousand", "two thousand five hundred")
+func spokenHeight(feet int) string {
+	thousands, hundreds := feet/1000, feet%1000/100
+	var parts []string
+	if thousands > 0 {
+		parts = append(parts, spellOut(strconv.Itoa(thousands))+" thousand")
+	}
+	if hundreds > 0 || thousands == 0 {
+		parts = append(parts, spellOut(strconv.Itoa(hundreds))+" hundred")
+	}
+	return strings.Join(parts, " ")
+}
+
+// spokenTemperature returns the temperature and dewpoint of a METAR as spoken
+func spokenTemperature(metar *weather.METAR) string {
+	if metar.TemperatureC == nil {
+		return ""
+	}
+	spoken := "temperature " + spokenCelsius(*metar.TemperatureC)
+	if metar.DewpointC != nil {
+		spoken += ", dewpoint " + spokenCelsius(*metar.DewpointC)
+	}
+	return spoken
+}
+
+// spokenCelsius says a temperature digit by digit ("minus two", "one five")
+func spokenCelsius(celsius int) string {
+	if celsius < 0 {
+		return "minus " + spellOut(strconv.Itoa(-celsius))
+	}
+	return spellOut(strconv.Itoa(celsius))
+}
+
+// notamHighlights returns up to max NOTAMs about closures and facilities out of service,
+// spoken, from the NOTAM data of the weather provider
+func notamHighlights(data interface{}, max int) []string {
+	var highlights []string
+	seen := make(map[string]bool)
+	for _, text := range notamTexts(data) {
+		if len(highlights) >= max {
+			break
+		}
+		if match := notamItemEPattern.FindStringSubmatch(text); match != nil {
+			text = match[1]
+		}
+		text = strings.Join(strings.Fields(strings.ToUpper(text)), " ")
+		if !notamHighlightPattern.MatchString(text) {
+			continue
+		}
+		spoken := spokenNOTAM(text)
+		if !seen[spoken] {
+			seen[spoken] = true
+			highlights = append(highlights, spoken)
+		}
+	}
+	return highlights
+}
+
+// notamTexts returns the strings in NOTAM data long enough to be NOTAM text, in a stable
+// order so the ATIS doesn't change with map iteration
+func notamTexts(data interface{}) []string {
+	switch v := data.(type) {
+	case string:
+		if len(v) >= 12 {
+			return []string{v}
+		}
+	case []interface{}:
+		var texts []string
+		for _, item := range v {
+			texts = append(texts, notamTexts(item)...)
+		}
+		return texts
+	case map[string]interface{}:
+		keys := make([]string, 0, len(v))
+		for key := range v {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		var texts []string
+		for _, key := range keys {
+			texts = append(texts, notamTexts(v[key])...)
+		}
+		return texts
+	}
+	return nil
+}
+
+// spokenNOTAM expands the common abbreviations and runways of NOTAM text for speech, ending
+// at its first sentence
+func spokenNOTAM(text string) string {
+	if end := strings.IndexAny(text, ".\n"); end > 0 {
+		text = text[:end]
+	}
+	text = notamRunwayPattern.ReplaceAllStringFunc(text, func(match string) string {
+		groups := notamRunwayPattern.FindStringSubmatch(match)
+		spoken := "runway " + spokenRunway(groups[1])
+		if groups[2] != "" {
+			spoken += " " + spokenRunway(groups[2])
+		}
+		return spoken
+	})
+
+	words := strings.Fields(text)
+	for i, word := range words {
+		switch {
+		case word == "DUE" && (i+1 == len(words) || words[i+1] != "TO"):
+			words[i] = "due to"
+		case notamWords[word] != "":
+			words[i] = notamWords[word]
+		case !notamAcronyms[word]:
+			words[i] = strings.ToLower(word)
+		}
+	}
+	return strings.Join(words, " ")
+}
+
+// RenderATIS renders a synthesized ATIS template with its conditions
+func (e *Engine) RenderATIS(templatePath string, conditions *atis.Conditions) (string, error) {
+	tmpl, err := e.getTemplate(templatePath)
+	if err != nil {
+		return "", fmt.Errorf("failed to get template: %w", err)
+	}
+
+	data := &ATISData{Conditions: *conditions}
+	if conditions.Letter != "" {
+		data.Information = InformationLetter(int(conditions.Letter[0] - 'A'))
+	}
+
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return "", fmt.Errorf("failed to execute template: %w", err)
+	}
+
+	// Templates lay sentences out on lines; spoken text reads better as one paragraph
+	return strings.Join(strings.Fields(buf.String()), " "), nil
+}
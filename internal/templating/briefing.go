@@ -0,0 +1,276 @@
+package templating
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/yegors/co-atc/internal/adsb"
+	"github.com/yegors/co-atc/internal/weather"
+)
+
+// BriefingData is the data of a spoken airspace briefing. Numbers, runways and callsigns
+// are spelled out the way they're said on the radio, so text-to-speech reads them right.
+type BriefingData struct {
+	Airport       string // Airport code
+	Information   string // ATIS-style information letter in the spelling alphabet ("Bravo")
+	Time          string // Observation time ("one four three zero zulu")
+	Wind          string // "wind two four zero at one five, gusting two five", empty if unknown
+	Altimeter     string // "altimeter two nine nine two" or "QNH one zero one three", empty if unknown
+	Runways       string // Runways in use ("landing runway two three, departing runway two four right")
+	Closures      string // Closed runways, empty if none
+	Arrivals      string // "three aircraft on final for runway two four right, two more on approach"
+	Departures    string // "two aircraft departing, four taxiing"
+	Emergencies   string // Aircraft squawking an emergency code, empty if none
+	AircraftCount int    // Aircraft tracked in the airspace
+	Timestamp     time.Time
+}
+
+// emergencySquawks are the transponder codes spoken in a briefing
+var emergencySquawks = map[string]string{
+	"7500": "squawking seven five zero zero",
+	"7600": "squawking seven six zero zero, radio failure",
+	"7700": "squawking seven seven zero zero, emergency",
+}
+
+// GetBriefingData builds the spoken data of an airspace briefing
+func (da *DataAggregator) GetBriefingData(information string) (*BriefingData, error) {
+	opts := DefaultFormattingOptions()
+	opts.MaxAircraft = math.MaxInt32
+	context, err := da.GetTemplateContext(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	now := context.Timestamp
+	data := &BriefingData{
+		Airport:       context.Airport.Code,
+		Information:   information,
+		Time:          spellOut(now.Format("1504")) + " zulu",
+		AircraftCount: len(context.Aircraft),
+		Timestamp:     now,
+	}
+
+	if context.Weather != nil && context.Weather.Parsed != nil && context.Weather.Parsed.METAR != nil {
+		metar := context.Weather.Parsed.METAR
+		data.Wind = spokenWind(metar.Wind)
+		data.Altimeter = spokenAltimeter(metar)
+	}
+
+	data.Runways, data.Closures = spokenRunways(context.Runways)
+	data.Arrivals, data.Departures, data.Emergencies = summarizeTraffic(context.Aircraft)
+
+	return data, nil
+}
+
+// spokenRunways describes the runways of a forced configuration in use and the closed ones
+func spokenRunways(runways []RunwayInfo) (inUse, closures string) {
+	var landing, departing, closed []string
+	for _, runway := range runways {
+		if runway.Closed {
+			closed = append(closed, "runway "+spokenRunway(runway.Name))
+			continue
+		}
+		if !runway.Override {
+			continue
+		}
+		for _, operation := range runway.Operations {
+			switch operation {
+			case "arrival":
+				landing = append(landing, spokenRunway(runway.Name))
+			case "departure":
+				departing = append(departing, spokenRunway(runway.Name))
+			}
+		}
+	}
+	var parts []string
+	if len(landing) > 0 {
+		parts = append(parts, "landing "+runwayList(landing))
+	}
+	if len(departing) > 0 {
+		parts = append(parts, "departing "+runwayList(departing))
+	}
+	inUse = strings.Join(parts, ", ")
+	if len(closed) > 0 {
+		closures = strings.Join(closed, ", ") + " closed"
+	}
+	return inUse, closures
+}
+
+// summarizeTraffic describes arriving and departing traffic and emergencies. Arrivals are
+// grouped by the runway of their latest landing or approach clearance.
+func summarizeTraffic(aircraft []*adsb.Aircraft) (arrivals, departures, emergencies string) {
+	finals := make(map[string]int)
+	approaching, departing, taxiing := 0, 0, 0
+	var emergencyList []string
+
+	for _, ac := range aircraft {
+		if ac.Status != "active" {
+			continue
+		}
+
+		if ac.ADSB != nil {
+			if squawk, ok := emergencySquawks[ac.ADSB.Squawk]; ok {
+				name := spokenCallsign(ac.Flight)
+				if name == "" {
+					name = "unidentified aircraft"
+				}
+				emergencyList = append(emergencyList, name+" "+squawk)
+			}
+		}
+
+		switch currentPhase(ac) {
+		case "APP":
+			if runway := clearedRunway(ac); runway != "" {
+				finals[runway]++
+			} else {
+				approaching++
+			}
+		case "T/O", "DEP":
+			departing++
+		case "TAX":
+			taxiing++
+		}
+	}
+
+	runways := make([]string, 0, len(finals))
+	for runway := range finals {
+		runways = append(runways, runway)
+	}
+	sort.Strings(runways)
+
+	var arrivalParts []string
+	for _, runway := range runways {
+		arrivalParts = append(arrivalParts, fmt.Sprintf("%s on final for runway %s",
+			aircraftCount(finals[runway]), spokenRunway(runway)))
+	}
+	if approaching > 0 {
+		if len(arrivalParts) > 0 {
+			arrivalParts = append(arrivalParts, fmt.Sprintf("%s more on approach", spokenNumber(approaching)))
+		} else {
+			arrivalParts = append(arrivalParts, aircraftCount(approaching)+" on approach")
+		}
+	}
+	arrivals = strings.Join(arrivalParts, ", ")
+
+	var departureParts []string
+	if departing > 0 {
+		departureParts = append(departureParts, aircraftCount(departing)+" departing")
+	}
+	if taxiing > 0 {
+		departureParts = append(departureParts, fmt.Sprintf("%s taxiing", spokenNumber(taxiing)))
+	}
+	departures = strings.Join(departureParts, ", ")
+
+	return arrivals, departures, strings.Join(emergencyList, ", ")
+}
+
+// currentPhase returns an aircraft's current flight phase code
+func currentPhase(ac *adsb.Aircraft) string {
+	if ac.Phase == nil || len(ac.Phase.Current) == 0 {
+		return ""
+	}
+	return ac.Phase.Current[0].Phase
+}
+
+// clearedRunway returns the runway of an aircraft's latest landing or approach clearance
+func clearedRunway(ac *adsb.Aircraft) string {
+	var latest *adsb.ClearanceData
+	for i := range ac.Clearances {
+		clearance := &ac.Clearances[i]
+		if clearance.Runway == "" || (clearance.Type != "landing" && clearance.Type != "approach") {
+			continue
+		}
+		if latest == nil || clearance.Timestamp.After(latest.Timestamp) {
+			latest = clearance
+		}
+	}
+	if latest == nil {
+		return ""
+	}
+	return latest.Runway
+}
+
+// spokenWind returns a decoded wind as spoken in a briefing
+func spokenWind(wind *weather.Wind) string {
+	if wind == nil {
+		return ""
+	}
+	if wind.SpeedKt == 0 {
+		return "wind calm"
+	}
+
+	direction := "variable"
+	if wind.DirectionDeg != nil {
+		direction = spellOut(fmt.Sprintf("%03d", *wind.DirectionDeg))
+	}
+	spoken := fmt.Sprintf("wind %s at %s", direction, spellOut(strconv.Itoa(wind.SpeedKt)))
+	if wind.GustKt > 0 {
+		spoken += ", gusting " + spellOut(strconv.Itoa(wind.GustKt))
+	}
+	return spoken
+}
+
+// spokenAltimeter returns the altimeter setting of a METAR as spoken in a briefing, in the
+// unit the METAR reports it in
+func spokenAltimeter(metar *weather.METAR) string {
+	if metar.AltimeterInHg == nil || metar.QNHHPa == nil {
+		return ""
+	}
+	if metar.AltimeterUnit == "hPa" {
+		return "QNH " + spellOut(strconv.Itoa(*metar.QNHHPa))
+	}
+	return "altimeter " + spellOut(fmt.Sprintf("%04d", int(math.Round(*metar.AltimeterInHg*100))))
+}
+
+// runwayList joins spoken runway designators ("runway two three" or "runways two three and two four right")
+func runwayList(runways []string) string {
+	if len(runways) == 1 {
+		return "runway " + runways[0]
+	}
+	return "runways " + strings.Join(runways[:len(runways)-1], ", ") + " and " + runways[len(runways)-1]
+}
+
+// aircraftCount says a number of aircraft ("one aircraft", "three aircraft")
+func aircraftCount(n int) string {
+	return spokenNumber(n) + " aircraft"
+}
+
+// spokenNumbers are the small counts a briefing says as words
+var spokenNumbers = []string{"no", "one", "two", "three", "four", "five", "six", "seven", "eight", "nine",
+	"ten", "eleven", "twelve", "thirteen", "fourteen", "fifteen", "sixteen", "seventeen", "eighteen", "nineteen"}
+
+// spokenNumber says a count as a word, or digit by digit if it's twenty or more
+func spokenNumber(n int) string {
+	if n >= 0 && n < len(spokenNumbers) {
+		return spokenNumbers[n]
+	}
+	return spellOut(strconv.Itoa(n))
+}
+
+// InformationLetter returns the spelling alphabet word for the nth information letter,
+// cycling from Alpha through Zulu
+func InformationLetter(n int) string {
+	letter := rune('A' + (n%26+26)%26)
+	return phoneticAlphabet[letter]
+}
+
+// RenderBriefing renders a briefing template with briefing data
+func (e *Engine) RenderBriefing(templatePath string, data *BriefingData) (string, error) {
+	tmpl, err := e.getTemplate(templatePath)
+	if err != nil {
+		return "", fmt.Errorf("failed to get template: %w", err)
+	}
+
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return "", fmt.Errorf("failed to execute template: %w", err)
+	}
+
+	// Templates lay sentences out on lines; spoken text reads better as one paragraph
+	return strings.Join(strings.Fields(buf.String()), " "), nil
+}
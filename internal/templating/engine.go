@@ -2,10 +2,12 @@ package templating
 
 import (
 	"bytes"
+	"context"
 	"fmt"
-	"io/ioutil"
+	"os"
 	"sync"
 	"text/template"
+	"time"
 
 	"github.com/yegors/co-atc/pkg/logger"
 )
@@ -13,16 +15,25 @@ import (
 // Engine handles template loading, caching, and rendering
 type Engine struct {
 	aggregator    *DataAggregator
-	templateCache map[string]*template.Template
+	templateCache map[string]*cachedTemplate
+	overrides     map[string]string // Content used instead of a template file, by path
 	cacheMutex    sync.RWMutex
 	logger        *logger.Logger
 }
 
+// cachedTemplate is a parsed template and the files it was parsed from
+type cachedTemplate struct {
+	tmpl      *template.Template
+	files     map[string]time.Time // Template and partial paths, with their modification times
+	reloadErr error                // Why the files last failed to reload, nil if they didn't
+}
+
 // NewEngine creates a new template engine
 func NewEngine(aggregator *DataAggregator, logger *logger.Logger) *Engine {
 	return &Engine{
 		aggregator:    aggregator,
-		templateCache: make(map[string]*template.Template),
+		templateCache: make(map[string]*cachedTemplate),
+		overrides:     make(map[string]string),
 		logger:        logger.Named("template-engine"),
 	}
 }
@@ -101,7 +112,7 @@ func (e *Engine) prepareTemplateData(context *TemplateContext, opts FormattingOp
 	}
 
 	// Format aircraft data
-	data.Aircraft = FormatAircraftData(context.Aircraft, context.Airport)
+	data.Aircraft = FormatAircraftWithProfile(context.Aircraft, context.Airport, context.Annotations, opts.Profile)
 
 	// Format weather data if available
 	if opts.IncludeWeather && context.Weather != nil {
@@ -109,6 +120,7 @@ func (e *Engine) prepareTemplateData(context *TemplateContext, opts FormattingOp
 	} else {
 		data.Weather = "Weather data not available."
 	}
+	data.ATIS = FormatATISData(context.ATIS)
 
 	// Format runway data if available
 	if opts.IncludeRunways {
@@ -120,6 +132,8 @@ func (e *Engine) prepareTemplateData(context *TemplateContext, opts FormattingOp
 	// Format transcription history if requested (only for ATC Chat)
 	if opts.IncludeTranscriptionHistory {
 		data.TranscriptionHistory = FormatTranscriptionHistory(context.TranscriptionHistory)
+		data.UnstableApproaches = FormatUnstableApproaches(context.UnstableApproaches)
+		data.ArrivalSequence = FormatArrivalSequence(context.ArrivalSequence)
 	} else {
 		data.TranscriptionHistory = ""
 	}
@@ -134,9 +148,9 @@ func (e *Engine) prepareTemplateData(context *TemplateContext, opts FormattingOp
 func (e *Engine) getTemplate(templatePath string) (*template.Template, error) {
 	// Check cache first (read lock)
 	e.cacheMutex.RLock()
-	if tmpl, exists := e.templateCache[templatePath]; exists {
+	if cached, exists := e.templateCache[templatePath]; exists {
 		e.cacheMutex.RUnlock()
-		return tmpl, nil
+		return cached.tmpl, nil
 	}
 	e.cacheMutex.RUnlock()
 
@@ -145,37 +159,113 @@ func (e *Engine) getTemplate(templatePath string) (*template.Template, error) {
 	defer e.cacheMutex.Unlock()
 
 	// Double-check in case another goroutine loaded it while we were waiting
-	if tmpl, exists := e.templateCache[templatePath]; exists {
-		return tmpl, nil
+	if cached, exists := e.templateCache[templatePath]; exists {
+		return cached.tmpl, nil
 	}
 
 	// Load template from file
-	tmpl, err := e.loadTemplate(templatePath)
+	cached, err := e.loadTemplate(templatePath)
 	if err != nil {
 		return nil, err
 	}
 
 	// Cache the template
-	e.templateCache[templatePath] = tmpl
+	e.templateCache[templatePath] = cached
 	e.logger.Debug("Template loaded and cached",
 		logger.String("template_path", templatePath))
 
-	return tmpl, nil
+	return cached.tmpl, nil
+}
+
+// loadTemplate loads a template, or its override, and the partials next to it from file.
+// Must be called with the cache lock held.
+func (e *Engine) loadTemplate(templatePath string) (*cachedTemplate, error) {
+	content, overridden := e.overrides[templatePath]
+	if !overridden {
+		file, err := os.ReadFile(templatePath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read template file '%s': %w", templatePath, err)
+		}
+		content = string(file)
+	}
+	return e.parseTemplate(templatePath, content)
+}
+
+// parseTemplate parses the content of a template with the partials next to its file
+func (e *Engine) parseTemplate(templatePath, content string) (*cachedTemplate, error) {
+	files, err := templateFiles(templatePath)
+	if err != nil {
+		return nil, err
+	}
+
+	partials := make(map[string]string)
+	for file := range files {
+		if file == templatePath {
+			continue
+		}
+		partial, err := os.ReadFile(file)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read partial '%s': %w", file, err)
+		}
+		partials[file] = string(partial)
+	}
+
+	tmpl, err := ParseTemplate(templatePath, content, partials)
+	if err != nil {
+		return nil, err
+	}
+	return &cachedTemplate{tmpl: tmpl, files: files}, nil
+}
+
+// SetOverride renders a template from content instead of its file, until the override is
+// cleared. Content that doesn't parse is rejected.
+func (e *Engine) SetOverride(templatePath, content string) error {
+	e.cacheMutex.Lock()
+	defer e.cacheMutex.Unlock()
+
+	cached, err := e.parseTemplate(templatePath, content)
+	if err != nil {
+		return err
+	}
+	e.overrides[templatePath] = content
+	e.templateCache[templatePath] = cached
+	e.logger.Info("Template overridden",
+		logger.String("template_path", templatePath))
+	return nil
+}
+
+// ClearOverride renders a template from its file again
+func (e *Engine) ClearOverride(templatePath string) {
+	e.cacheMutex.Lock()
+	defer e.cacheMutex.Unlock()
+
+	if _, overridden := e.overrides[templatePath]; !overridden {
+		return
+	}
+	delete(e.overrides, templatePath)
+	delete(e.templateCache, templatePath) // Loaded from file when next rendered
+	e.logger.Info("Template override cleared",
+		logger.String("template_path", templatePath))
 }
 
-// loadTemplate loads a template from file
-func (e *Engine) loadTemplate(templatePath string) (*template.Template, error) {
-	content, err := ioutil.ReadFile(templatePath)
+// RenderContent parses template content as if it were the template at templatePath, and
+// renders it with current airspace data without caching it
+func (e *Engine) RenderContent(templatePath, content string, opts FormattingOptions) (string, error) {
+	cached, err := e.parseTemplate(templatePath, content)
 	if err != nil {
-		return nil, fmt.Errorf("failed to read template file '%s': %w", templatePath, err)
+		return "", err
 	}
 
-	tmpl, err := template.New(templatePath).Parse(string(content))
+	context, err := e.aggregator.GetTemplateContext(opts)
 	if err != nil {
-		return nil, fmt.Errorf("failed to parse template file '%s': %w", templatePath, err)
+		return "", fmt.Errorf("failed to get template context: %w", err)
 	}
 
-	return tmpl, nil
+	var buf bytes.Buffer
+	if err := cached.tmpl.Execute(&buf, e.prepareTemplateData(context, opts)); err != nil {
+		return "", fmt.Errorf("failed to execute template: %w", err)
+	}
+	return buf.String(), nil
 }
 
 // ReloadTemplate forces a template to be reloaded from file
@@ -184,13 +274,13 @@ func (e *Engine) ReloadTemplate(templatePath string) error {
 	defer e.cacheMutex.Unlock()
 
 	// Load template from file
-	tmpl, err := e.loadTemplate(templatePath)
+	cached, err := e.loadTemplate(templatePath)
 	if err != nil {
 		return err
 	}
 
 	// Update cache
-	e.templateCache[templatePath] = tmpl
+	e.templateCache[templatePath] = cached
 	e.logger.Info("Template reloaded",
 		logger.String("template_path", templatePath))
 
@@ -206,12 +296,12 @@ func (e *Engine) ReloadAllTemplates() error {
 	reloadedCount := 0
 
 	for templatePath := range e.templateCache {
-		tmpl, err := e.loadTemplate(templatePath)
+		cached, err := e.loadTemplate(templatePath)
 		if err != nil {
 			errors = append(errors, fmt.Sprintf("%s: %v", templatePath, err))
 			continue
 		}
-		e.templateCache[templatePath] = tmpl
+		e.templateCache[templatePath] = cached
 		reloadedCount++
 	}
 
@@ -228,13 +318,70 @@ func (e *Engine) ReloadAllTemplates() error {
 	return nil
 }
 
+// Watch reloads cached templates whenever their files or the partials next to them change,
+// checking every pollInterval. A template that fails to reload keeps its last good version
+// until it's fixed.
+func (e *Engine) Watch(ctx context.Context, pollInterval time.Duration) {
+	ticker := time.NewTicker(pollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			e.reloadChanged()
+		}
+	}
+}
+
+// reloadChanged reloads the cached templates whose files changed
+func (e *Engine) reloadChanged() {
+	e.cacheMutex.Lock()
+	defer e.cacheMutex.Unlock()
+
+	for templatePath, cached := range e.templateCache {
+		files, err := templateFiles(templatePath)
+		if err != nil || sameFiles(files, cached.files) {
+			continue
+		}
+		cached.files = files
+
+		reloaded, err := e.loadTemplate(templatePath)
+		if err != nil {
+			cached.reloadErr = err
+			e.logger.Error("Template changed but failed to reload, keeping the previous version",
+				logger.String("template_path", templatePath),
+				logger.Error(err))
+			continue
+		}
+		e.templateCache[templatePath] = reloaded
+		e.logger.Info("Template changed, reloaded",
+			logger.String("template_path", templatePath))
+	}
+}
+
+// sameFiles reports whether two sets of template files have the same paths and
+// modification times
+func sameFiles(a, b map[string]time.Time) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for path, modTime := range a {
+		if other, ok := b[path]; !ok || !other.Equal(modTime) {
+			return false
+		}
+	}
+	return true
+}
+
 // ClearCache clears the template cache
 func (e *Engine) ClearCache() {
 	e.cacheMutex.Lock()
 	defer e.cacheMutex.Unlock()
 
 	templateCount := len(e.templateCache)
-	e.templateCache = make(map[string]*template.Template)
+	e.templateCache = make(map[string]*cachedTemplate)
 
 	e.logger.Info("Template cache cleared",
 		logger.Int("cleared_count", templateCount))
@@ -246,19 +393,32 @@ func (e *Engine) GetCacheStats() map[string]interface{} {
 	defer e.cacheMutex.RUnlock()
 
 	templates := make([]string, 0, len(e.templateCache))
-	for path := range e.templateCache {
+	reloadErrors := make(map[string]string)
+	for path, cached := range e.templateCache {
 		templates = append(templates, path)
+		if cached.reloadErr != nil {
+			reloadErrors[path] = cached.reloadErr.Error()
+		}
 	}
 
 	return map[string]interface{}{
 		"cached_template_count": len(e.templateCache),
 		"cached_templates":      templates,
+		"reload_errors":         reloadErrors,
 	}
 }
 
-// GetRawTemplate returns the raw template content without processing
+// GetRawTemplate returns the raw template content without processing, from its override
+// if it has one
 func (e *Engine) GetRawTemplate(templatePath string) (string, error) {
-	content, err := ioutil.ReadFile(templatePath)
+	e.cacheMutex.RLock()
+	override, overridden := e.overrides[templatePath]
+	e.cacheMutex.RUnlock()
+	if overridden {
+		return override, nil
+	}
+
+	content, err := os.ReadFile(templatePath)
 	if err != nil {
 		return "", fmt.Errorf("failed to read template file '%s': %w", templatePath, err)
 	}
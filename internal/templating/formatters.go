@@ -6,12 +6,15 @@ import (
 	"time"
 
 	"github.com/yegors/co-atc/internal/adsb"
+	"github.com/yegors/co-atc/internal/approach"
+	"github.com/yegors/co-atc/internal/atis"
+	"github.com/yegors/co-atc/internal/sequence"
 	"github.com/yegors/co-atc/internal/weather"
 )
 
-// FormatAircraftData formats aircraft data for template rendering
-// Uses the same format as ATC chat for consistency
-func FormatAircraftData(aircraft []*adsb.Aircraft, airport AirportInfo) string {
+// FormatAircraftData formats aircraft data for template rendering, with their annotations
+// by hex if given. Uses the same format as ATC chat for consistency
+func FormatAircraftData(aircraft []*adsb.Aircraft, airport AirportInfo, annotations map[string]*AircraftAnnotation) string {
 	if len(aircraft) == 0 {
 		return "No aircraft currently in the airspace."
 	}
@@ -36,7 +39,7 @@ func FormatAircraftData(aircraft []*adsb.Aircraft, airport AirportInfo) string {
 		builder.WriteString("No airborne aircraft\n")
 	} else {
 		for _, ac := range airborne {
-			builder.WriteString(formatAirborneAircraft(ac, airport))
+			builder.WriteString(formatAirborneAircraft(ac, airport, annotations[ac.Hex]))
 			builder.WriteString("\n")
 		}
 	}
@@ -49,7 +52,7 @@ func FormatAircraftData(aircraft []*adsb.Aircraft, airport AirportInfo) string {
 		builder.WriteString("No ground aircraft\n")
 	} else {
 		for _, ac := range onGround {
-			builder.WriteString(formatGroundAircraft(ac, airport))
+			builder.WriteString(formatGroundAircraft(ac, airport, annotations[ac.Hex]))
 			builder.WriteString("\n")
 		}
 	}
@@ -57,8 +60,17 @@ func FormatAircraftData(aircraft []*adsb.Aircraft, airport AirportInfo) string {
 	return builder.String()
 }
 
+// FormatAircraft formats a single aircraft for display, airborne or on the ground, with its
+// annotation if it has one
+func FormatAircraft(ac *adsb.Aircraft, airport AirportInfo, annotation *AircraftAnnotation) string {
+	if ac.OnGround {
+		return formatGroundAircraft(ac, airport, annotation)
+	}
+	return formatAirborneAircraft(ac, airport, annotation)
+}
+
 // formatAirborneAircraft formats a single airborne aircraft for display
-func formatAirborneAircraft(ac *adsb.Aircraft, airport AirportInfo) string {
+func formatAirborneAircraft(ac *adsb.Aircraft, airport AirportInfo, annotation *AircraftAnnotation) string {
 	var builder strings.Builder
 
 	// Basic info - callsign and operator
@@ -138,6 +150,7 @@ func formatAirborneAircraft(ac *adsb.Aircraft, airport AirportInfo) string {
 		}
 		builder.WriteString(fmt.Sprintf(" | Airport position: %.1f NM, heading %.0f° | BFS (airport->plane): %.0f°", *ac.Distance, bearingToStation, bearingFromStation))
 	}
+	builder.WriteString(formatAnnotation(annotation))
 
 	// Flight phase
 	if ac.Phase != nil && len(ac.Phase.Current) > 0 {
@@ -147,6 +160,11 @@ func formatAirborneAircraft(ac *adsb.Aircraft, airport AirportInfo) string {
 		builder.WriteString(fmt.Sprintf(" | Phase: %s (%s)", fullPhaseName, formatDuration(timeSince)))
 	}
 
+	// Intent inferred from clearances and track
+	if ac.Intent != nil {
+		builder.WriteString(fmt.Sprintf(" | Intent: %s", ac.Intent.Summary))
+	}
+
 	// Telemetry status
 	builder.WriteString(fmt.Sprintf(" | Telemetry: %s", ac.Status))
 	if !ac.LastSeen.IsZero() {
@@ -158,7 +176,7 @@ func formatAirborneAircraft(ac *adsb.Aircraft, airport AirportInfo) string {
 }
 
 // formatGroundAircraft formats a single ground aircraft for display
-func formatGroundAircraft(ac *adsb.Aircraft, airport AirportInfo) string {
+func formatGroundAircraft(ac *adsb.Aircraft, airport AirportInfo, annotation *AircraftAnnotation) string {
 	var builder strings.Builder
 
 	// Basic info - callsign and operator
@@ -229,6 +247,8 @@ func formatGroundAircraft(ac *adsb.Aircraft, airport AirportInfo) string {
 		}
 	}
 
+	builder.WriteString(formatAnnotation(annotation))
+
 	// Flight phase
 	if ac.Phase != nil && len(ac.Phase.Current) > 0 {
 		currentPhase := ac.Phase.Current[0]
@@ -237,6 +257,11 @@ func formatGroundAircraft(ac *adsb.Aircraft, airport AirportInfo) string {
 		builder.WriteString(fmt.Sprintf(" | Phase: %s (%s)", fullPhaseName, formatDuration(timeSince)))
 	}
 
+	// Intent inferred from clearances and track
+	if ac.Intent != nil {
+		builder.WriteString(fmt.Sprintf(" | Intent: %s", ac.Intent.Summary))
+	}
+
 	// Telemetry status
 	builder.WriteString(fmt.Sprintf(" | Telemetry: %s", ac.Status))
 	if !ac.LastSeen.IsZero() {
@@ -247,6 +272,14 @@ func formatGroundAircraft(ac *adsb.Aircraft, airport AirportInfo) string {
 	return builder.String()
 }
 
+// formatAnnotation formats the position and trends of an aircraft, if known
+func formatAnnotation(annotation *AircraftAnnotation) string {
+	if annotation == nil || annotation.Summary == "" {
+		return ""
+	}
+	return " | Position: " + annotation.Summary
+}
+
 // getFullPhaseName converts phase codes to full names
 func getFullPhaseName(phase string) string {
 	switch phase {
@@ -297,12 +330,51 @@ func FormatWeatherData(weather *weather.WeatherData) string {
 		}
 	}
 
-	// TAF summary (keep this but simplified)
-	if weather.TAF != nil {
-		if tafMap, ok := weather.TAF.(map[string]interface{}); ok {
-			if _, exists := tafMap["decoded"]; exists {
-				builder.WriteString("TAF Summary: Terminal forecast available\n")
+	// Decoded values, so the model doesn't have to read them out of the text
+	if weather.Parsed != nil && weather.Parsed.METAR != nil {
+		metar := weather.Parsed.METAR
+		// Only Windy sends a plain-language METAR; show the raw one from other providers
+		if builder.Len() == 0 {
+			builder.WriteString(fmt.Sprintf("Current METAR: %s\n", metar.Raw))
+		}
+		builder.WriteString(fmt.Sprintf("Observed: %s", FormatConditions(metar.Conditions)))
+		if metar.AltimeterInHg != nil && metar.QNHHPa != nil {
+			builder.WriteString(fmt.Sprintf(", altimeter %.2f inHg (QNH %d hPa)", *metar.AltimeterInHg, *metar.QNHHPa))
+		}
+		builder.WriteString("\n")
+	}
+
+	// TAF periods
+	if weather.Parsed != nil && weather.Parsed.TAF != nil {
+		taf := weather.Parsed.TAF
+		builder.WriteString(fmt.Sprintf("TAF valid %s to %s:\n", taf.ValidFrom.Format("02/1504Z"), taf.ValidTo.Format("02/1504Z")))
+		for _, period := range taf.Periods {
+			label := period.Type
+			if period.Type == "PROB" {
+				label = fmt.Sprintf("PROB%d", period.Probability)
 			}
+			builder.WriteString(fmt.Sprintf("• %s %s-%s: %s\n", label,
+				period.From.Format("02/1504Z"), period.To.Format("02/1504Z"), FormatConditions(period.Conditions)))
+		}
+	} else if weather.TAF != nil {
+		builder.WriteString("TAF Summary: Terminal forecast available\n")
+	}
+
+	// Winds aloft over the station, for the levels traffic in the area flies at
+	if levels := weather.WindsAloft.StationLevels(); len(levels) > 0 {
+		builder.WriteString("Winds aloft (GFS):")
+		for _, level := range levels {
+			builder.WriteString(fmt.Sprintf(" %d ft %03d°/%d kt %+.0f°C;", roundAltitude(level.AltitudeFt),
+				level.DirectionDeg, level.SpeedKt, level.TemperatureC))
+		}
+		builder.WriteString("\n")
+	}
+
+	// Nearby SIGMETs, AIRMETs and PIREPs
+	if hazards := weather.Hazards.Nearby(maxPromptHazards); len(hazards) > 0 {
+		builder.WriteString(fmt.Sprintf("Hazards within %d NM:\n", weather.Hazards.RadiusNM))
+		for _, hazard := range hazards {
+			builder.WriteString(fmt.Sprintf("• %s\n", FormatHazard(hazard.Properties)))
 		}
 	}
 
@@ -315,6 +387,161 @@ func FormatWeatherData(weather *weather.WeatherData) string {
 	return builder.String()
 }
 
+// maxPromptHazards is how many of the nearest weather hazards are listed in prompts
+const maxPromptHazards = 8
+
+// FormatHazard formats a SIGMET, AIRMET or PIREP as a short summary
+// ("SIGMET CONVECTIVE, 10000-45000 ft, 35 NM NW, until 1655Z")
+func FormatHazard(hazard weather.HazardProperties) string {
+	summary := hazard.Kind + " " + hazard.Hazard
+	if hazard.Severity != "" {
+		summary += " " + hazard.Severity
+	}
+	if hazard.AircraftType != "" {
+		summary += " from " + hazard.AircraftType
+	}
+
+	parts := []string{summary}
+	switch {
+	case hazard.BaseFt != nil && hazard.TopFt != nil:
+		parts = append(parts, fmt.Sprintf("%d-%d ft", *hazard.BaseFt, *hazard.TopFt))
+	case hazard.TopFt != nil && hazard.Kind == weather.HazardKindPIREP:
+		parts = append(parts, fmt.Sprintf("at %d ft", *hazard.TopFt))
+	case hazard.TopFt != nil:
+		parts = append(parts, fmt.Sprintf("up to %d ft", *hazard.TopFt))
+	}
+	if hazard.DistanceNM == 0 {
+		parts = append(parts, "over the station")
+	} else {
+		parts = append(parts, fmt.Sprintf("%.0f NM %s", hazard.DistanceNM, compassPoint(hazard.BearingDeg)))
+	}
+	switch {
+	case hazard.ValidTo != nil:
+		parts = append(parts, "until "+hazard.ValidTo.Format("1504Z"))
+	case hazard.ValidFrom != nil:
+		parts = append(parts, fmt.Sprintf("reported %s ago", formatDuration(time.Since(*hazard.ValidFrom))))
+	}
+	return strings.Join(parts, ", ")
+}
+
+// compassPoint returns the eight-point compass direction of a bearing
+func compassPoint(bearing int) string {
+	points := []string{"N", "NE", "E", "SE", "S", "SW", "W", "NW"}
+	return points[((bearing+22)%360+360)%360/45]
+}
+
+// FormatConditions formats decoded weather conditions as a short summary
+// ("IFR, wind 240° at 15 kt gusting 25, visibility 1.5 SM, -RA BR, ceiling 800 ft")
+func FormatConditions(conditions weather.Conditions) string {
+	var parts []string
+	if conditions.FlightCategory != "" {
+		parts = append(parts, conditions.FlightCategory)
+	}
+	if wind := conditions.Wind; wind != nil {
+		switch {
+		case wind.SpeedKt == 0:
+			parts = append(parts, "wind calm")
+		case wind.DirectionDeg == nil:
+			parts = append(parts, fmt.Sprintf("wind variable at %d kt", wind.SpeedKt))
+		default:
+			parts = append(parts, fmt.Sprintf("wind %03d° at %d kt", *wind.DirectionDeg, wind.SpeedKt))
+		}
+		if wind.GustKt > 0 {
+			parts[len(parts)-1] += fmt.Sprintf(" gusting %d", wind.GustKt)
+		}
+	}
+	if conditions.CAVOK {
+		parts = append(parts, "CAVOK")
+	} else if conditions.VisibilitySM != nil {
+		parts = append(parts, fmt.Sprintf("visibility %g SM", *conditions.VisibilitySM))
+	}
+	if len(conditions.Weather) > 0 {
+		parts = append(parts, strings.Join(conditions.Weather, " "))
+	}
+	if conditions.CeilingFt != nil {
+		parts = append(parts, fmt.Sprintf("ceiling %d ft", *conditions.CeilingFt))
+	}
+	if len(parts) == 0 {
+		return "no change reported"
+	}
+	return strings.Join(parts, ", ")
+}
+
+// atisTypeNames are the names of ATIS types in formatted data
+var atisTypeNames = map[string]string{
+	atis.TypeCombined:    "ATIS",
+	atis.TypeArrival:     "Arrival ATIS",
+	atis.TypeDeparture:   "Departure ATIS",
+	atis.TypeSynthesized: "Synthesized ATIS",
+}
+
+// FormatATISData formats the current ATIS broadcasts for template rendering
+func FormatATISData(broadcasts []atis.ATIS) string {
+	if len(broadcasts) == 0 {
+		return "ATIS not available."
+	}
+
+	var builder strings.Builder
+	for _, broadcast := range broadcasts {
+		name, ok := atisTypeNames[broadcast.Type]
+		if !ok {
+			name = "ATIS"
+		}
+		builder.WriteString(fmt.Sprintf("• %s information %s (issued %s ago): %s\n",
+			name, broadcast.Letter, formatDuration(time.Since(broadcast.ReceivedAt)), broadcast.Text))
+	}
+
+	return builder.String()
+}
+
+// FormatUnstableApproaches formats recent unstable approaches for template rendering
+func FormatUnstableApproaches(events []approach.Event) string {
+	if len(events) == 0 {
+		return "No unstable approaches recently."
+	}
+
+	var builder strings.Builder
+	for _, event := range events {
+		callsign := event.Callsign
+		if callsign == "" {
+			callsign = event.Hex
+		}
+		builder.WriteString(fmt.Sprintf("• %s, runway %s, %.1f NM final at %.0f ft (%s ago): %s\n",
+			callsign, event.Runway, event.DistanceNM, event.HeightFeet,
+			formatDuration(time.Since(event.DetectedAt)), strings.Join(event.Reasons, ", ")))
+	}
+
+	return builder.String()
+}
+
+// FormatArrivalSequence formats the arrival sequence of each runway for template rendering
+func FormatArrivalSequence(arrivals []sequence.Arrival) string {
+	if len(arrivals) == 0 {
+		return "No arrivals in sequence."
+	}
+
+	var builder strings.Builder
+	for _, arrival := range arrivals {
+		callsign := arrival.Callsign
+		if callsign == "" {
+			callsign = arrival.Hex
+		}
+		position := "inbound"
+		if arrival.OnFinal {
+			position = "on final"
+		}
+		builder.WriteString(fmt.Sprintf("• Runway %s #%d %s, %.1f NM %s at %.0f ft, %.0f kt, touchdown in %s",
+			arrival.Runway, arrival.Number, callsign, arrival.DistanceNM, position,
+			arrival.AltitudeFt, arrival.GroundSpeedKt, formatDuration(time.Duration(arrival.ETASeconds)*time.Second)))
+		if arrival.SpacingNM != nil {
+			builder.WriteString(fmt.Sprintf(", %.1f NM behind #%d", *arrival.SpacingNM, arrival.Number-1))
+		}
+		builder.WriteString("\n")
+	}
+
+	return builder.String()
+}
+
 // FormatRunwayData formats runway data for template rendering
 func FormatRunwayData(runways []RunwayInfo) string {
 	if len(runways) == 0 {
@@ -328,6 +555,17 @@ func FormatRunwayData(runways []RunwayInfo) string {
 		if runway.LengthFt > 0 {
 			builder.WriteString(fmt.Sprintf(" (%d ft)", runway.LengthFt))
 		}
+		switch {
+		case runway.Closed:
+			builder.WriteString(" - CLOSED")
+			if runway.Remarks != "" {
+				builder.WriteString(fmt.Sprintf(" (%s)", runway.Remarks))
+			}
+		case runway.Override && !runway.Active:
+			builder.WriteString(" - not in use")
+		case runway.Override:
+			builder.WriteString(fmt.Sprintf(" - in use for %ss", strings.Join(runway.Operations, "s and ")))
+		}
 		builder.WriteString("\n")
 	}
 
@@ -387,3 +625,8 @@ func formatDuration(d time.Duration) string {
 		return fmt.Sprintf("%dh%dm", hours, minutes)
 	}
 }
+
+// roundAltitude rounds a pressure level's height to the nearest 100 ft
+func roundAltitude(altitudeFt int) int {
+	return (altitudeFt + 50) / 100 * 100
+}
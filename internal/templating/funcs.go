@@ -0,0 +1,185 @@
+package templating
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+	"strconv"
+	"strings"
+	"text/template"
+)
+
+// transitionAltitudeFt is the altitude at and above which the altitude function says a
+// flight level
+const transitionAltitudeFt = 18000
+
+// templateFuncs are the helper functions available to templates, besides include, which
+// is bound to each template set when it's parsed
+func templateFuncs() template.FuncMap {
+	return template.FuncMap{
+		// Units
+		"feet":        formatFeet,
+		"meters":      formatMeters,
+		"flightLevel": formatFlightLevel,
+		"altitude":    formatAltitude,
+		"knots":       formatKnots,
+		"nm":          formatNM,
+		"heading":     formatHeading,
+
+		// Positions
+		"clockPosition": clockPosition,
+
+		// Radio phraseology
+		"phonetic":       phonetic,
+		"spokenRunway":   spokenRunway,
+		"spokenCallsign": spokenCallsign,
+
+		// Text
+		"upper": strings.ToUpper,
+		"lower": strings.ToLower,
+		"trim":  strings.TrimSpace,
+		"join":  strings.Join,
+	}
+}
+
+// number converts a template argument to a float64. Nil pointers are an error, so a
+// missing value doesn't render as zero.
+func number(value interface{}) (float64, error) {
+	v := reflect.ValueOf(value)
+	for v.Kind() == reflect.Pointer {
+		if v.IsNil() {
+			return 0, fmt.Errorf("value is nil")
+		}
+		v = v.Elem()
+	}
+
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(v.Int()), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(v.Uint()), nil
+	case reflect.Float32, reflect.Float64:
+		return v.Float(), nil
+	case reflect.String:
+		f, err := strconv.ParseFloat(strings.TrimSpace(v.String()), 64)
+		if err != nil {
+			return 0, fmt.Errorf("%q is not a number", v.String())
+		}
+		return f, nil
+	}
+	return 0, fmt.Errorf("%v is not a number", value)
+}
+
+// groupThousands formats a whole number with comma thousands separators ("12,500")
+func groupThousands(n int) string {
+	digits := strconv.Itoa(n)
+	sign := ""
+	if n < 0 {
+		sign, digits = "-", digits[1:]
+	}
+	for i := len(digits) - 3; i > 0; i -= 3 {
+		digits = digits[:i] + "," + digits[i:]
+	}
+	return sign + digits
+}
+
+// formatFeet formats an altitude or length in feet ("3,500 ft")
+func formatFeet(value interface{}) (string, error) {
+	feet, err := number(value)
+	if err != nil {
+		return "", err
+	}
+	return groupThousands(int(math.Round(feet))) + " ft", nil
+}
+
+// formatMeters formats a value in feet in meters ("1,067 m")
+func formatMeters(value interface{}) (string, error) {
+	feet, err := number(value)
+	if err != nil {
+		return "", err
+	}
+	return groupThousands(int(math.Round(feet*0.3048))) + " m", nil
+}
+
+// formatFlightLevel formats an altitude in feet as a flight level ("FL350")
+func formatFlightLevel(value interface{}) (string, error) {
+	feet, err := number(value)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("FL%03d", int(math.Round(feet/100))), nil
+}
+
+// formatAltitude formats an altitude in feet as a flight level at and above the transition
+// altitude, and in feet below it
+func formatAltitude(value interface{}) (string, error) {
+	feet, err := number(value)
+	if err != nil {
+		return "", err
+	}
+	if feet >= transitionAltitudeFt {
+		return formatFlightLevel(feet)
+	}
+	return formatFeet(feet)
+}
+
+// formatKnots formats a speed in knots ("250 kt")
+func formatKnots(value interface{}) (string, error) {
+	knots, err := number(value)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%d kt", int(math.Round(knots))), nil
+}
+
+// formatNM formats a distance in nautical miles to a tenth ("12.3 NM")
+func formatNM(value interface{}) (string, error) {
+	nm, err := number(value)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%.1f NM", nm), nil
+}
+
+// formatHeading formats a heading or bearing in degrees as three digits ("057°")
+func formatHeading(value interface{}) (string, error) {
+	degrees, err := number(value)
+	if err != nil {
+		return "", err
+	}
+	heading := int(math.Round(math.Mod(math.Mod(degrees, 360)+360, 360)))
+	if heading == 0 {
+		heading = 360
+	}
+	return fmt.Sprintf("%03d°", heading), nil
+}
+
+// clockPosition says where a bearing is relative to a track, as traffic is called on the
+// radio: 12 o'clock is straight ahead, 3 o'clock to the right
+func clockPosition(track, bearing interface{}) (string, error) {
+	t, err := number(track)
+	if err != nil {
+		return "", fmt.Errorf("track: %w", err)
+	}
+	b, err := number(bearing)
+	if err != nil {
+		return "", fmt.Errorf("bearing: %w", err)
+	}
+	return fmt.Sprintf("%d o'clock", clockHour(t, b)), nil
+}
+
+// clockHour returns the clock position of a bearing relative to a track, 1 to 12
+func clockHour(track, bearing float64) int {
+	relative := math.Mod(math.Mod(bearing-track, 360)+360, 360)
+	hour := int(math.Round(relative/30)) % 12
+	if hour == 0 {
+		hour = 12
+	}
+	return hour
+}
+
+// phonetic spells letters in the spelling alphabet and digits as words ("C-GABC" is
+// "Charlie Golf Alpha Bravo Charlie")
+func phonetic(s string) string {
+	return spellOut(s)
+}
@@ -4,25 +4,47 @@ import (
 	"time"
 
 	"github.com/yegors/co-atc/internal/adsb"
+	"github.com/yegors/co-atc/internal/approach"
+	"github.com/yegors/co-atc/internal/atis"
+	"github.com/yegors/co-atc/internal/sequence"
 	"github.com/yegors/co-atc/internal/weather"
 )
 
 // TemplateContext represents the raw data context for template rendering
 type TemplateContext struct {
-	Aircraft             []*adsb.Aircraft       `json:"aircraft"`
-	Weather              *weather.WeatherData   `json:"weather"`
-	Runways              []RunwayInfo           `json:"runways"`
-	TranscriptionHistory []TranscriptionSummary `json:"transcription_history"`
-	Airport              AirportInfo            `json:"airport"`
-	Timestamp            time.Time              `json:"timestamp"`
+	Aircraft             []*adsb.Aircraft               `json:"aircraft"`
+	Annotations          map[string]*AircraftAnnotation `json:"annotations"` // By hex
+	Weather              *weather.WeatherData           `json:"weather"`
+	ATIS                 []atis.ATIS                    `json:"atis,omitempty"`
+	Runways              []RunwayInfo                   `json:"runways"`
+	TranscriptionHistory []TranscriptionSummary         `json:"transcription_history"`
+	UnstableApproaches   []approach.Event               `json:"unstable_approaches,omitempty"` // Only for ATC Chat
+	ArrivalSequence      []sequence.Arrival             `json:"arrival_sequence,omitempty"`    // Only for ATC Chat
+	Airport              AirportInfo                    `json:"airport"`
+	Timestamp            time.Time                      `json:"timestamp"`
+}
+
+// AircraftAnnotation is the position of an aircraft relative to the airport and its trends,
+// worked out so prompts don't leave the geometry to the model
+type AircraftAnnotation struct {
+	DistanceNM    float64 `json:"distance_nm,omitempty"`
+	BearingDeg    float64 `json:"bearing_deg,omitempty"`    // From the airport to the aircraft
+	Direction     string  `json:"direction,omitempty"`      // Of the aircraft from the airport: "NE"
+	ClockPosition int     `json:"clock_position,omitempty"` // Of the airport from the aircraft's track, 12 = ahead; 0 if unknown
+	VerticalTrend string  `json:"vertical_trend,omitempty"` // "climbing", "descending" or "level"; empty on the ground
+	SpeedTrend    string  `json:"speed_trend,omitempty"`    // "accelerating", "decelerating" or "steady"; empty until known
+	Summary       string  `json:"summary"`                  // "5.2 NM NE of the field, field at 2 o'clock, descending"
 }
 
 // TemplateData represents the formatted data for template rendering
 type TemplateData struct {
 	Aircraft             string    `json:"aircraft"`
 	Weather              string    `json:"weather"`
+	ATIS                 string    `json:"atis"`
 	Runways              string    `json:"runways"`
 	TranscriptionHistory string    `json:"transcription_history"` // Only populated for ATC Chat
+	UnstableApproaches   string    `json:"unstable_approaches"`   // Only populated for ATC Chat
+	ArrivalSequence      string    `json:"arrival_sequence"`      // Only populated for ATC Chat
 	Airport              string    `json:"airport"`
 	Time                 string    `json:"time"`
 	Timestamp            time.Time `json:"timestamp"`
@@ -35,6 +57,17 @@ type FormattingOptions struct {
 	IncludeRunways              bool   `json:"include_runways"`
 	IncludeTranscriptionHistory bool   `json:"include_transcription_history"` // Only for ATC Chat
 	TimeFormat                  string `json:"time_format"`
+
+	// How aircraft are formatted; the zero value is the detailed text format
+	Profile FormattingProfile `json:"profile"`
+}
+
+// FormattingProfile sets how aircraft are formatted for a consumer, trading detail for tokens
+type FormattingProfile struct {
+	Name        string `json:"name"`
+	Format      string `json:"format"`       // "text", "table" or "json"
+	MaxAircraft int    `json:"max_aircraft"` // Overrides the consumer's limit if set
+	TokenBudget int    `json:"token_budget"` // Approximate tokens the aircraft may take, 0 = no limit
 }
 
 // AirportInfo represents airport information for templating
@@ -52,6 +85,9 @@ type RunwayInfo struct {
 	LengthFt   int      `json:"length_ft"`
 	Active     bool     `json:"active"`
 	Operations []string `json:"operations"`
+	Closed     bool     `json:"closed,omitempty"`
+	Remarks    string   `json:"remarks,omitempty"`  // Closure reason
+	Override   bool     `json:"override,omitempty"` // Status set manually rather than by default
 }
 
 // TranscriptionSummary represents recent radio communications for templating
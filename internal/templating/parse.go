@@ -0,0 +1,153 @@
+package templating
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/template"
+	"text/template/parse"
+	"time"
+)
+
+// PartialPrefix starts the file names of partials. A template can use the partials in its
+// directory by name, without the prefix and extension: _traffic_rules.txt is
+// {{template "traffic_rules" .}}, or {{include "traffic_rules" .}} to pipe its output.
+const PartialPrefix = "_"
+
+// ParseTemplate parses a template and its partials (by path) strictly: functions and
+// partials must exist, and missing map keys fail rendering instead of printing "<no value>".
+// Errors name the file and line.
+func ParseTemplate(name, content string, partials map[string]string) (*template.Template, error) {
+	root := template.New(name).Option("missingkey=error").Funcs(templateFuncs())
+	root.Funcs(template.FuncMap{
+		"include": func(partial string, data interface{}) (string, error) {
+			var buf bytes.Buffer
+			if err := root.ExecuteTemplate(&buf, partial, data); err != nil {
+				return "", err
+			}
+			return buf.String(), nil
+		},
+	})
+
+	paths := make([]string, 0, len(partials))
+	for path := range partials {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	for _, path := range paths {
+		if _, err := root.New(partialName(path)).Parse(partials[path]); err != nil {
+			return nil, fmt.Errorf("failed to parse partial '%s': %w", path, err)
+		}
+	}
+
+	if _, err := root.Parse(content); err != nil {
+		return nil, fmt.Errorf("failed to parse template file '%s': %w", name, err)
+	}
+	if err := checkReferences(root); err != nil {
+		return nil, fmt.Errorf("invalid template file '%s': %w", name, err)
+	}
+	return root, nil
+}
+
+// templateFiles returns the modification times of a template and the partials in its
+// directory
+func templateFiles(templatePath string) (map[string]time.Time, error) {
+	info, err := os.Stat(templatePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read template file '%s': %w", templatePath, err)
+	}
+	files := map[string]time.Time{templatePath: info.ModTime()}
+
+	matches, err := filepath.Glob(filepath.Join(filepath.Dir(templatePath), PartialPrefix+"*"))
+	if err != nil {
+		return nil, err
+	}
+	for _, match := range matches {
+		info, err := os.Stat(match)
+		if err != nil || info.IsDir() || match == templatePath {
+			continue
+		}
+		files[match] = info.ModTime()
+	}
+	return files, nil
+}
+
+// partialName returns the name a partial is used by: its file name without the prefix
+// and extension
+func partialName(path string) string {
+	base := strings.TrimPrefix(filepath.Base(path), PartialPrefix)
+	return strings.TrimSuffix(base, filepath.Ext(base))
+}
+
+// checkReferences reports templates and includes of partials that don't exist, which
+// text/template would only find when rendering reaches them
+func checkReferences(root *template.Template) error {
+	defined := make(map[string]bool)
+	for _, t := range root.Templates() {
+		defined[t.Name()] = true
+	}
+
+	var missing []string
+	var walk func(node parse.Node)
+	walk = func(node parse.Node) {
+		switch n := node.(type) {
+		case *parse.ListNode:
+			if n == nil {
+				return
+			}
+			for _, child := range n.Nodes {
+				walk(child)
+			}
+		case *parse.IfNode:
+			walk(n.Pipe)
+			walk(n.List)
+			walk(n.ElseList)
+		case *parse.RangeNode:
+			walk(n.Pipe)
+			walk(n.List)
+			walk(n.ElseList)
+		case *parse.WithNode:
+			walk(n.Pipe)
+			walk(n.List)
+			walk(n.ElseList)
+		case *parse.TemplateNode:
+			if !defined[n.Name] {
+				missing = append(missing, n.Name)
+			}
+			walk(n.Pipe)
+		case *parse.ActionNode:
+			walk(n.Pipe)
+		case *parse.PipeNode:
+			if n == nil {
+				return
+			}
+			for _, cmd := range n.Cmds {
+				walk(cmd)
+			}
+		case *parse.CommandNode:
+			if len(n.Args) >= 2 {
+				if ident, ok := n.Args[0].(*parse.IdentifierNode); ok && ident.Ident == "include" {
+					if name, ok := n.Args[1].(*parse.StringNode); ok && !defined[name.Text] {
+						missing = append(missing, name.Text)
+					}
+				}
+			}
+			for _, arg := range n.Args {
+				walk(arg)
+			}
+		}
+	}
+	for _, t := range root.Templates() {
+		if t.Tree != nil {
+			walk(t.Tree.Root)
+		}
+	}
+
+	if len(missing) > 0 {
+		return fmt.Errorf("no such partial: %s", strings.Join(missing, ", "))
+	}
+	return nil
+}
@@ -0,0 +1,386 @@
+package templating
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/yegors/co-atc/internal/adsb"
+	"github.com/yegors/co-atc/internal/config"
+)
+
+// charsPerToken is the rough number of characters in a token of English and numbers, used to
+// keep formatted aircraft within a profile's token budget
+const charsPerToken = 4
+
+// phaseOrder lists flight phases in the order omitted aircraft are summarized
+var phaseOrder = []string{"T/O", "APP", "T/D", "ARR", "DEP", "TAX", "NEW", "CRZ", ""}
+
+// FormatAircraftWithProfile formats aircraft data and their annotations as a formatting
+// profile sets: a detailed line per aircraft, a compact table or verbose JSON. Aircraft are
+// listed by priority, and those past the token budget are summarized by phase.
+func FormatAircraftWithProfile(aircraft []*adsb.Aircraft, airport AirportInfo, annotations map[string]*AircraftAnnotation, profile FormattingProfile) string {
+	sorted := make([]*adsb.Aircraft, len(aircraft))
+	copy(sorted, aircraft)
+	prioritizeAircraft(sorted)
+
+	switch profile.Format {
+	case config.FormatTable:
+		return formatAircraftTable(sorted, airport, annotations, profile.TokenBudget)
+	case config.FormatJSON:
+		return formatAircraftJSON(sorted, airport, annotations, profile.TokenBudget)
+	}
+	if profile.TokenBudget <= 0 {
+		return FormatAircraftData(sorted, airport, annotations)
+	}
+
+	lines := make([]string, len(sorted))
+	for i, ac := range sorted {
+		lines[i] = FormatAircraft(ac, airport, annotations[ac.Hex])
+	}
+	listed := withinBudget(lines, profile.TokenBudget)
+	text := FormatAircraftData(sorted[:listed], airport, annotations)
+	if listed < len(sorted) {
+		text += summarizeOmitted(sorted[listed:]) + "\n"
+	}
+	return text
+}
+
+// prioritizeAircraft sorts aircraft by priority, then nearest first
+func prioritizeAircraft(aircraft []*adsb.Aircraft) {
+	sort.SliceStable(aircraft, func(i, j int) bool {
+		pi, pj := aircraftPriority(aircraft[i]), aircraftPriority(aircraft[j])
+		if pi != pj {
+			return pi < pj
+		}
+		return distanceOrMax(aircraft[i]) < distanceOrMax(aircraft[j])
+	})
+}
+
+// aircraftPriority ranks aircraft by how much they matter to a controller, lowest first:
+// emergencies, aircraft on or near the runways, arrivals and departures, taxiing aircraft,
+// then the rest
+func aircraftPriority(ac *adsb.Aircraft) int {
+	if ac.ADSB != nil {
+		if _, emergency := emergencySquawks[ac.ADSB.Squawk]; emergency {
+			return 0
+		}
+	}
+	switch currentPhase(ac) {
+	case "T/O", "APP", "T/D":
+		return 1
+	case "ARR", "DEP":
+		return 2
+	case "TAX":
+		return 3
+	case "CRZ":
+		return 5
+	}
+	if ac.OnGround {
+		return 6
+	}
+	return 4
+}
+
+// distanceOrMax returns the distance of an aircraft from the airport, or a large one if it's
+// unknown, so those aircraft sort last
+func distanceOrMax(ac *adsb.Aircraft) float64 {
+	if ac.Distance == nil {
+		return 1e9
+	}
+	return *ac.Distance
+}
+
+// estimateTokens estimates the tokens of text
+func estimateTokens(text string) int {
+	return (len(text) + charsPerToken - 1) / charsPerToken
+}
+
+// withinBudget returns how many of the entries, in order, fit in a token budget. At least
+// one is listed, so a small budget still shows the most important aircraft.
+func withinBudget(entries []string, budget int) int {
+	if budget <= 0 {
+		return len(entries)
+	}
+	used := 0
+	for i, entry := range entries {
+		used += estimateTokens(entry) + 1 // Separator
+		if used > budget && i > 0 {
+			return i
+		}
+	}
+	return len(entries)
+}
+
+// omittedByPhase counts omitted aircraft by full phase name, in phase order
+func omittedByPhase(aircraft []*adsb.Aircraft) ([]string, map[string]int) {
+	counts := make(map[string]int)
+	for _, ac := range aircraft {
+		counts[currentPhase(ac)]++
+	}
+	var names []string
+	byName := make(map[string]int, len(counts))
+	for _, phase := range phaseOrder {
+		if counts[phase] == 0 {
+			continue
+		}
+		name := strings.ToLower(getFullPhaseName(phase))
+		if phase == "" {
+			name = "unknown phase"
+		}
+		names = append(names, name)
+		byName[name] = counts[phase]
+		delete(counts, phase)
+	}
+	// Phases without a place in the order, by code
+	var others []string
+	for phase := range counts {
+		others = append(others, phase)
+	}
+	sort.Strings(others)
+	for _, phase := range others {
+		names = append(names, phase)
+		byName[phase] = counts[phase]
+	}
+	return names, byName
+}
+
+// summarizeOmitted summarizes aircraft left out for the token budget
+// ("+12 more aircraft not listed: 2 approach, 6 cruise, 4 new")
+func summarizeOmitted(aircraft []*adsb.Aircraft) string {
+	names, counts := omittedByPhase(aircraft)
+	parts := make([]string, len(names))
+	for i, name := range names {
+		parts[i] = fmt.Sprintf("%d %s", counts[name], name)
+	}
+	return fmt.Sprintf("+%d more aircraft not listed: %s", len(aircraft), strings.Join(parts, ", "))
+}
+
+// formatAircraftTable formats aircraft as a compact table, a row per aircraft
+func formatAircraftTable(aircraft []*adsb.Aircraft, airport AirportInfo, annotations map[string]*AircraftAnnotation, budget int) string {
+	if len(aircraft) == 0 {
+		return "No aircraft currently in the airspace."
+	}
+
+	header := fmt.Sprintf("%-8s %-4s %-6s %3s %3s %5s %5s %3s %3s %-7s %-5s %-4s",
+		"CALLSIGN", "TYPE", "ALT", "GS", "HDG", "VS", "DIST", "BFS", "CLK", "TREND", "PHASE", "SQWK")
+	rows := make([]string, len(aircraft))
+	for i, ac := range aircraft {
+		rows[i] = aircraftTableRow(ac, airport, annotations[ac.Hex])
+	}
+	if budget > 0 {
+		budget = max(budget-estimateTokens(header), 1)
+	}
+	listed := withinBudget(rows, budget)
+
+	var builder strings.Builder
+	builder.WriteString(header)
+	builder.WriteString("\n")
+	for _, row := range rows[:listed] {
+		builder.WriteString(row)
+		builder.WriteString("\n")
+	}
+	if listed < len(aircraft) {
+		builder.WriteString(summarizeOmitted(aircraft[listed:]))
+		builder.WriteString("\n")
+	}
+	return builder.String()
+}
+
+// aircraftTableRow formats an aircraft as a table row. ALT is GND on the ground, BFS is the
+// bearing from the airport to the aircraft, CLK the clock position of the airport from the
+// aircraft and TREND its vertical and speed trends (CLB/DES/LVL, ACC/DEC).
+func aircraftTableRow(ac *adsb.Aircraft, airport AirportInfo, annotation *AircraftAnnotation) string {
+	callsign := ac.Flight
+	if callsign == "" {
+		callsign = ac.Hex
+	}
+	aircraftType, alt, gs, hdg, vs, squawk := "-", "-", "-", "-", "-", "-"
+	if ac.ADSB != nil {
+		if ac.ADSB.AircraftType != "" {
+			aircraftType = ac.ADSB.AircraftType
+		}
+		if ac.ADSB.AltBaro != 0 {
+			alt = fmt.Sprintf("%.0f", ac.ADSB.AltBaro)
+		}
+		if ac.ADSB.GS != 0 {
+			gs = fmt.Sprintf("%.0f", ac.ADSB.GS)
+		}
+		if ac.ADSB.MagHeading >= 0 && ac.ADSB.MagHeading <= 360 {
+			hdg = fmt.Sprintf("%03.0f", ac.ADSB.MagHeading)
+		} else if ac.ADSB.Track != 0 {
+			hdg = fmt.Sprintf("%03.0f", ac.ADSB.Track)
+		}
+		if ac.ADSB.BaroRate != 0 {
+			vs = fmt.Sprintf("%+.0f", ac.ADSB.BaroRate)
+		}
+		if ac.ADSB.Squawk != "" {
+			squawk = ac.ADSB.Squawk
+		}
+	}
+	if ac.OnGround {
+		alt = "GND"
+	}
+	dist, bfs := "-", "-"
+	if bearing, ok := bearingFromAirport(ac, airport); ok {
+		dist = fmt.Sprintf("%.1f", *ac.Distance)
+		bfs = fmt.Sprintf("%03.0f", bearing)
+	}
+	clock, trend := "-", "-"
+	if annotation != nil {
+		if annotation.ClockPosition != 0 {
+			clock = fmt.Sprintf("%d", annotation.ClockPosition)
+		}
+		var trends []string
+		if code, ok := trendCodes[annotation.VerticalTrend]; ok {
+			trends = append(trends, code)
+		}
+		if code, ok := trendCodes[annotation.SpeedTrend]; ok {
+			trends = append(trends, code)
+		}
+		if len(trends) > 0 {
+			trend = strings.Join(trends, "/")
+		}
+	}
+	phase := currentPhase(ac)
+	if phase == "" {
+		phase = "-"
+	}
+	return fmt.Sprintf("%-8s %-4s %-6s %3s %3s %5s %5s %3s %3s %-7s %-5s %-4s",
+		callsign, aircraftType, alt, gs, hdg, vs, dist, bfs, clock, trend, phase, squawk)
+}
+
+// trendCodes abbreviate trends in the table format; steady speed is left out
+var trendCodes = map[string]string{
+	"climbing":     "CLB",
+	"descending":   "DES",
+	"level":        "LVL",
+	"accelerating": "ACC",
+	"decelerating": "DEC",
+}
+
+// bearingFromAirport returns the bearing from the airport to an aircraft, if its distance
+// and position are known
+func bearingFromAirport(ac *adsb.Aircraft, airport AirportInfo) (float64, bool) {
+	if ac.Distance == nil || ac.ADSB == nil || ac.ADSB.Lat == 0 || ac.ADSB.Lon == 0 || len(airport.Coordinates) < 2 {
+		return 0, false
+	}
+	bearing := adsb.CalculateBearing(airport.Coordinates[0], airport.Coordinates[1], ac.ADSB.Lat, ac.ADSB.Lon)
+	return bearing, true
+}
+
+// jsonAircraft is an aircraft in the JSON format
+type jsonAircraft struct {
+	Hex                string     `json:"hex"`
+	Callsign           string     `json:"callsign,omitempty"`
+	Airline            string     `json:"airline,omitempty"`
+	Type               string     `json:"type,omitempty"`
+	Category           string     `json:"category,omitempty"`
+	Lat                float64    `json:"lat,omitempty"`
+	Lon                float64    `json:"lon,omitempty"`
+	AltitudeFt         float64    `json:"altitude_ft,omitempty"`
+	GroundSpeedKt      float64    `json:"ground_speed_kt,omitempty"`
+	TrueAirspeedKt     float64    `json:"true_airspeed_kt,omitempty"`
+	Heading            *float64   `json:"heading,omitempty"`
+	VerticalRateFPM    float64    `json:"vertical_rate_fpm,omitempty"`
+	Squawk             string     `json:"squawk,omitempty"`
+	OnGround           bool       `json:"on_ground"`
+	DistanceNM         *float64   `json:"distance_nm,omitempty"`
+	BearingFromAirport *float64   `json:"bearing_from_airport,omitempty"`
+	Direction          string     `json:"direction,omitempty"`      // Of the aircraft from the airport
+	ClockPosition      int        `json:"clock_position,omitempty"` // Of the airport from the aircraft
+	VerticalTrend      string     `json:"vertical_trend,omitempty"`
+	SpeedTrend         string     `json:"speed_trend,omitempty"`
+	Phase              string     `json:"phase,omitempty"`
+	PhaseSince         *time.Time `json:"phase_since,omitempty"`
+	Intent             string     `json:"intent,omitempty"` // Inferred from clearances and track
+	TookOff            *time.Time `json:"took_off,omitempty"`
+	Status             string     `json:"status"`
+	LastSeen           time.Time  `json:"last_seen"`
+}
+
+// jsonOmitted summarizes aircraft left out of the JSON format for the token budget
+type jsonOmitted struct {
+	Count   int            `json:"count"`
+	ByPhase map[string]int `json:"by_phase"`
+}
+
+// formatAircraftJSON formats aircraft as indented JSON with all their data
+func formatAircraftJSON(aircraft []*adsb.Aircraft, airport AirportInfo, annotations map[string]*AircraftAnnotation, budget int) string {
+	items := make([]jsonAircraft, len(aircraft))
+	entries := make([]string, len(aircraft))
+	for i, ac := range aircraft {
+		items[i] = toJSONAircraft(ac, airport, annotations[ac.Hex])
+		entry, _ := json.MarshalIndent(items[i], "    ", "  ")
+		entries[i] = string(entry)
+	}
+	listed := withinBudget(entries, budget)
+
+	output := struct {
+		Aircraft []jsonAircraft `json:"aircraft"`
+		Omitted  *jsonOmitted   `json:"omitted,omitempty"`
+	}{Aircraft: items[:listed]}
+	if listed < len(aircraft) {
+		_, counts := omittedByPhase(aircraft[listed:])
+		output.Omitted = &jsonOmitted{Count: len(aircraft) - listed, ByPhase: counts}
+	}
+
+	data, err := json.MarshalIndent(output, "", "  ")
+	if err != nil {
+		return fmt.Sprintf("Failed to format aircraft: %v", err)
+	}
+	return string(data)
+}
+
+// toJSONAircraft converts an aircraft and its annotation to the JSON format
+func toJSONAircraft(ac *adsb.Aircraft, airport AirportInfo, annotation *AircraftAnnotation) jsonAircraft {
+	item := jsonAircraft{
+		Hex:        ac.Hex,
+		Callsign:   ac.Flight,
+		Airline:    ac.Airline,
+		OnGround:   ac.OnGround,
+		DistanceNM: ac.Distance,
+		TookOff:    ac.DateTookoff,
+		Status:     ac.Status,
+		LastSeen:   ac.LastSeen,
+	}
+	if ac.ADSB != nil {
+		item.Type = ac.ADSB.AircraftType
+		item.Category = ac.ADSB.Category
+		item.Lat = ac.ADSB.Lat
+		item.Lon = ac.ADSB.Lon
+		item.AltitudeFt = ac.ADSB.AltBaro
+		item.GroundSpeedKt = ac.ADSB.GS
+		item.TrueAirspeedKt = ac.ADSB.TAS
+		item.VerticalRateFPM = ac.ADSB.BaroRate
+		item.Squawk = ac.ADSB.Squawk
+		if ac.ADSB.MagHeading >= 0 && ac.ADSB.MagHeading <= 360 {
+			heading := ac.ADSB.MagHeading
+			item.Heading = &heading
+		} else if ac.ADSB.Track != 0 {
+			heading := ac.ADSB.Track
+			item.Heading = &heading
+		}
+	}
+	if bearing, ok := bearingFromAirport(ac, airport); ok {
+		bearing = math.Round(bearing)
+		item.BearingFromAirport = &bearing
+	}
+	if annotation != nil {
+		item.Direction = annotation.Direction
+		item.ClockPosition = annotation.ClockPosition
+		item.VerticalTrend = annotation.VerticalTrend
+		item.SpeedTrend = annotation.SpeedTrend
+	}
+	if ac.Phase != nil && len(ac.Phase.Current) > 0 {
+		item.Phase = getFullPhaseName(ac.Phase.Current[0].Phase)
+		item.PhaseSince = &ac.Phase.Current[0].Timestamp
+	}
+	if ac.Intent != nil {
+		item.Intent = ac.Intent.Summary
+	}
+	return item
+}
@@ -0,0 +1,337 @@
+package templating
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/yegors/co-atc/internal/storage/sqlite"
+)
+
+// Kinds of prompt templates, which decide the data they're rendered with
+const (
+	PromptKindATCChat        = "atc_chat"
+	PromptKindPostProcessing = "post_processing"
+)
+
+var (
+	ErrPromptsUnavailable = errors.New("prompt management is not available")
+	ErrUnknownPrompt      = errors.New("unknown prompt template")
+	ErrUnknownVersion     = errors.New("unknown prompt version")
+	ErrInvalidPrompt      = errors.New("invalid prompt template")
+)
+
+// PromptTemplate is a prompt template file the configuration uses, and the stored version
+// used instead of it, if any
+type PromptTemplate struct {
+	Name          string    `json:"name"` // File name without extension
+	Path          string    `json:"path"`
+	Kinds         []string  `json:"kinds"`                    // "atc_chat" and/or "post_processing"
+	UsedBy        []string  `json:"used_by"`                  // "post_processing", "post_processing:<frequency>", "atc_chat", "atc_chat:<persona>"
+	Source        string    `json:"source"`                   // "file" or "database"
+	ActiveVersion int       `json:"active_version,omitempty"` // Version used instead of the file
+	Versions      int       `json:"versions"`                 // Stored versions
+	UpdatedAt     time.Time `json:"updated_at,omitempty"`     // When the active version was stored
+	Content       string    `json:"content,omitempty"`        // Only when a single prompt is fetched
+}
+
+// PromptValidation is the result of checking prompt template content
+type PromptValidation struct {
+	Valid    bool              `json:"valid"`
+	Error    string            `json:"error,omitempty"`
+	Rendered map[string]string `json:"rendered,omitempty"` // Output with current data, by kind
+}
+
+// SetPromptStorage enables versioned prompt edits stored in storage and applies the active
+// versions
+func (s *Service) SetPromptStorage(storage *sqlite.PromptStorage) error {
+	s.promptMu.Lock()
+	defer s.promptMu.Unlock()
+	s.prompts = storage
+
+	active, err := storage.ActiveVersions()
+	if err != nil {
+		return err
+	}
+	templates := s.promptTemplates()
+	for _, version := range active {
+		prompt, ok := templates[version.Name]
+		if !ok {
+			continue // No longer used by the configuration
+		}
+		if err := s.engine.SetOverride(prompt.Path, version.Content); err != nil {
+			s.logger.Error(fmt.Sprintf("Stored prompt %s version %d no longer parses, using the file: %v",
+				version.Name, version.Version, err))
+			continue
+		}
+		s.logger.Info(fmt.Sprintf("Using stored prompt %s version %d", version.Name, version.Version))
+	}
+	return nil
+}
+
+// PromptTemplates returns the prompt templates the configuration uses, by name
+func (s *Service) PromptTemplates() ([]*PromptTemplate, error) {
+	s.promptMu.Lock()
+	defer s.promptMu.Unlock()
+	if s.prompts == nil {
+		return nil, ErrPromptsUnavailable
+	}
+
+	templates := s.promptTemplates()
+	list := make([]*PromptTemplate, 0, len(templates))
+	for _, prompt := range templates {
+		if err := s.describePrompt(prompt); err != nil {
+			return nil, err
+		}
+		list = append(list, prompt)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Name < list[j].Name })
+	return list, nil
+}
+
+// PromptTemplate returns a prompt template with the content in use
+func (s *Service) PromptTemplate(name string) (*PromptTemplate, error) {
+	s.promptMu.Lock()
+	defer s.promptMu.Unlock()
+	return s.promptWithContent(name)
+}
+
+// PromptVersions returns the stored versions of a prompt template, newest first
+func (s *Service) PromptVersions(name string) ([]*sqlite.PromptVersion, error) {
+	s.promptMu.Lock()
+	defer s.promptMu.Unlock()
+	if _, err := s.lookupPrompt(name); err != nil {
+		return nil, err
+	}
+	return s.prompts.ListVersions(name)
+}
+
+// ValidatePrompt checks prompt template content: it must parse, and render with current data
+// for each kind of use of the template
+func (s *Service) ValidatePrompt(name, content string) (*PromptValidation, error) {
+	s.promptMu.Lock()
+	defer s.promptMu.Unlock()
+	prompt, err := s.lookupPrompt(name)
+	if err != nil {
+		return nil, err
+	}
+	return s.validatePrompt(prompt, content), nil
+}
+
+// UpdatePrompt stores content as the next version of a prompt template and uses it instead of
+// the file. The first edit of a prompt also stores the file as version 1, to roll back to.
+func (s *Service) UpdatePrompt(name, content, comment string) (*PromptTemplate, error) {
+	s.promptMu.Lock()
+	defer s.promptMu.Unlock()
+	prompt, err := s.lookupPrompt(name)
+	if err != nil {
+		return nil, err
+	}
+	if validation := s.validatePrompt(prompt, content); !validation.Valid {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidPrompt, validation.Error)
+	}
+
+	versions, err := s.prompts.ListVersions(name)
+	if err != nil {
+		return nil, err
+	}
+	if len(versions) == 0 {
+		original, err := s.engine.GetRawTemplate(prompt.Path)
+		if err != nil {
+			return nil, err
+		}
+		if err := s.prompts.AddVersion(&sqlite.PromptVersion{
+			Name:      name,
+			Content:   original,
+			Comment:   "Original file",
+			CreatedAt: time.Now().UTC(),
+		}); err != nil {
+			return nil, err
+		}
+	}
+
+	version := &sqlite.PromptVersion{
+		Name:      name,
+		Content:   content,
+		Comment:   comment,
+		Active:    true,
+		CreatedAt: time.Now().UTC(),
+	}
+	if err := s.prompts.AddVersion(version); err != nil {
+		return nil, err
+	}
+	if err := s.engine.SetOverride(prompt.Path, content); err != nil {
+		return nil, err
+	}
+
+	s.logger.Info(fmt.Sprintf("Updated prompt %s to version %d", name, version.Version))
+	return s.promptWithContent(name)
+}
+
+// RollbackPrompt uses a stored version of a prompt template instead of the file, or the file
+// again if version is 0
+func (s *Service) RollbackPrompt(name string, version int) (*PromptTemplate, error) {
+	s.promptMu.Lock()
+	defer s.promptMu.Unlock()
+	prompt, err := s.lookupPrompt(name)
+	if err != nil {
+		return nil, err
+	}
+
+	if version == 0 {
+		if _, err := s.prompts.ActivateVersion(name, 0); err != nil {
+			return nil, err
+		}
+		s.engine.ClearOverride(prompt.Path)
+		s.logger.Info(fmt.Sprintf("Reverted prompt %s to its file", name))
+		return s.promptWithContent(name)
+	}
+
+	stored, err := s.prompts.GetVersion(name, version)
+	if err != nil {
+		return nil, err
+	}
+	if stored == nil {
+		return nil, fmt.Errorf("%w: %s version %d", ErrUnknownVersion, name, version)
+	}
+	// Partials may have changed since the version was stored
+	if err := s.engine.SetOverride(prompt.Path, stored.Content); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidPrompt, err)
+	}
+	if _, err := s.prompts.ActivateVersion(name, version); err != nil {
+		return nil, err
+	}
+
+	s.logger.Info(fmt.Sprintf("Rolled back prompt %s to version %d", name, version))
+	return s.promptWithContent(name)
+}
+
+// promptTemplates collects the prompt templates of post-processing, per-frequency
+// post-processing, ATC chat and its personas, by name
+func (s *Service) promptTemplates() map[string]*PromptTemplate {
+	cfg := s.aggregator.config
+	byPath := make(map[string]*PromptTemplate)
+	add := func(path, kind, usedBy string) {
+		if path == "" {
+			return
+		}
+		prompt, ok := byPath[path]
+		if !ok {
+			prompt = &PromptTemplate{Path: path}
+			byPath[path] = prompt
+		}
+		if !containsString(prompt.Kinds, kind) {
+			prompt.Kinds = append(prompt.Kinds, kind)
+		}
+		prompt.UsedBy = append(prompt.UsedBy, usedBy)
+	}
+
+	add(cfg.PostProcessing.SystemPromptPath, PromptKindPostProcessing, PromptKindPostProcessing)
+	for _, freq := range cfg.Frequencies.Sources {
+		add(freq.PostProcessing.SystemPromptPath, PromptKindPostProcessing, PromptKindPostProcessing+":"+freq.ID)
+	}
+	add(cfg.ATCChat.SystemPromptPath, PromptKindATCChat, PromptKindATCChat)
+	for _, persona := range cfg.ATCChat.Personas {
+		add(persona.SystemPromptPath, PromptKindATCChat, PromptKindATCChat+":"+persona.Name)
+	}
+
+	// Named by file; the same file name in another directory gets a number
+	paths := make([]string, 0, len(byPath))
+	for path := range byPath {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	templates := make(map[string]*PromptTemplate, len(byPath))
+	for _, path := range paths {
+		base := strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+		name := base
+		for i := 2; templates[name] != nil; i++ {
+			name = fmt.Sprintf("%s_%d", base, i)
+		}
+		byPath[path].Name = name
+		templates[name] = byPath[path]
+	}
+	return templates
+}
+
+// lookupPrompt returns a prompt template by name. Must be called with the prompt lock held.
+func (s *Service) lookupPrompt(name string) (*PromptTemplate, error) {
+	if s.prompts == nil {
+		return nil, ErrPromptsUnavailable
+	}
+	prompt, ok := s.promptTemplates()[name]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownPrompt, name)
+	}
+	return prompt, nil
+}
+
+// describePrompt fills in the stored versions of a prompt template. Must be called with the
+// prompt lock held.
+func (s *Service) describePrompt(prompt *PromptTemplate) error {
+	versions, err := s.prompts.ListVersions(prompt.Name)
+	if err != nil {
+		return err
+	}
+	prompt.Source = "file"
+	prompt.Versions = len(versions)
+	for _, version := range versions {
+		if version.Active {
+			prompt.Source = "database"
+			prompt.ActiveVersion = version.Version
+			prompt.UpdatedAt = version.CreatedAt
+		}
+	}
+	return nil
+}
+
+// promptWithContent returns a prompt template with its stored versions and the content in
+// use. Must be called with the prompt lock held.
+func (s *Service) promptWithContent(name string) (*PromptTemplate, error) {
+	prompt, err := s.lookupPrompt(name)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.describePrompt(prompt); err != nil {
+		return nil, err
+	}
+	prompt.Content, err = s.engine.GetRawTemplate(prompt.Path)
+	if err != nil {
+		return nil, err
+	}
+	return prompt, nil
+}
+
+// validatePrompt parses and renders content for each kind of use of a prompt template
+func (s *Service) validatePrompt(prompt *PromptTemplate, content string) *PromptValidation {
+	if strings.TrimSpace(content) == "" {
+		return &PromptValidation{Error: "content is empty"}
+	}
+
+	rendered := make(map[string]string, len(prompt.Kinds))
+	for _, kind := range prompt.Kinds {
+		opts := s.postProcessorOptions()
+		if kind == PromptKindATCChat {
+			opts = s.atcChatOptions()
+		}
+		output, err := s.engine.RenderContent(prompt.Path, content, opts)
+		if err != nil {
+			return &PromptValidation{Error: fmt.Sprintf("%s: %v", kind, err)}
+		}
+		rendered[kind] = output
+	}
+	return &PromptValidation{Valid: true, Rendered: rendered}
+}
+
+// containsString reports whether a list holds a string
+func containsString(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
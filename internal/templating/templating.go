@@ -1,9 +1,16 @@
 package templating
 
 import (
+	"context"
+	"sync"
+	"time"
+
 	"github.com/yegors/co-atc/internal/adsb"
+	"github.com/yegors/co-atc/internal/approach"
+	"github.com/yegors/co-atc/internal/atis"
 	"github.com/yegors/co-atc/internal/config"
 	"github.com/yegors/co-atc/internal/frequencies"
+	"github.com/yegors/co-atc/internal/sequence"
 	"github.com/yegors/co-atc/internal/storage/sqlite"
 	"github.com/yegors/co-atc/internal/weather"
 	"github.com/yegors/co-atc/pkg/logger"
@@ -13,6 +20,8 @@ import (
 type Service struct {
 	engine     *Engine
 	aggregator *DataAggregator
+	prompts    *sqlite.PromptStorage // nil = prompt edits not enabled
+	promptMu   sync.Mutex
 	logger     *logger.Logger
 }
 
@@ -45,16 +54,110 @@ func NewService(
 	}
 }
 
+// SetFrequenciesService adds frequency names to the transcription history of the template
+// context. The frequencies service renders templates itself, so it's created afterwards.
+func (s *Service) SetFrequenciesService(frequencyService *frequencies.Service) {
+	s.aggregator.frequencyService = frequencyService
+}
+
+// SetApproachService adds recent unstable approaches to the ATC chat template context
+func (s *Service) SetApproachService(approachService *approach.Service) {
+	s.aggregator.SetApproachService(approachService)
+}
+
+// SetSequenceService adds the arrival sequence to the ATC chat template context
+func (s *Service) SetSequenceService(sequenceService *sequence.Service) {
+	s.aggregator.SetSequenceService(sequenceService)
+}
+
+// SetATISService adds the current ATIS to the template context
+func (s *Service) SetATISService(atisService *atis.Service) {
+	s.aggregator.SetATISService(atisService)
+}
+
 // RenderATCChatTemplate renders the ATC chat template with full context
 func (s *Service) RenderATCChatTemplate(templatePath string) (string, error) {
-	opts := ATCChatFormattingOptions()
-	return s.engine.RenderTemplate(templatePath, opts)
+	return s.engine.RenderTemplate(templatePath, s.atcChatOptions())
 }
 
 // RenderPostProcessorTemplate renders the post-processor template without transcription history
 func (s *Service) RenderPostProcessorTemplate(templatePath string) (string, error) {
-	opts := PostProcessorFormattingOptions()
-	return s.engine.RenderTemplate(templatePath, opts)
+	return s.engine.RenderTemplate(templatePath, s.postProcessorOptions())
+}
+
+// atcChatOptions returns the ATC chat formatting options with its configured profile
+func (s *Service) atcChatOptions() FormattingOptions {
+	return s.withProfile(ATCChatFormattingOptions(), s.aggregator.config.Templating.ATCChat.Profile)
+}
+
+// postProcessorOptions returns the post-processor formatting options with its configured
+// profile
+func (s *Service) postProcessorOptions() FormattingOptions {
+	return s.withProfile(PostProcessorFormattingOptions(), s.aggregator.config.Templating.PostProcessing.Profile)
+}
+
+// withProfile sets the formatting profile of options to a configured one, leaving the
+// detailed text format if it isn't configured
+func (s *Service) withProfile(opts FormattingOptions, name string) FormattingOptions {
+	profile, ok := s.aggregator.config.Templating.Profiles[name]
+	if !ok {
+		return opts
+	}
+	opts.Profile = FormattingProfile{
+		Name:        name,
+		Format:      profile.Format,
+		MaxAircraft: profile.MaxAircraft,
+		TokenBudget: profile.TokenBudget,
+	}
+	return opts
+}
+
+// CanonicalCallsign returns the callsign of the active aircraft a callsign, registration
+// or hex code refers to, or the normalized input if none matches
+func (s *Service) CanonicalCallsign(callsign string) string {
+	if s.aggregator.adsbService == nil {
+		return adsb.NormalizeCallsign(callsign)
+	}
+	return s.aggregator.adsbService.Callsigns().Canonical(callsign)
+}
+
+// AircraftHex returns the hex code of the active aircraft a callsign refers to, spoken or
+// written, or an empty string if none matches
+func (s *Service) AircraftHex(callsign string) string {
+	if s.aggregator.adsbService == nil {
+		return ""
+	}
+	entry, ok := s.aggregator.adsbService.Callsigns().Correlate(callsign)
+	if !ok {
+		return ""
+	}
+	return entry.Hex
+}
+
+// TranscriptionVocabulary returns up to maxTerms callsigns, runways and local names, as
+// spoken, to bias transcription toward
+func (s *Service) TranscriptionVocabulary(maxTerms int) []string {
+	return s.aggregator.getVocabulary(maxTerms)
+}
+
+// GetBriefingData gets the spoken data of an airspace briefing
+func (s *Service) GetBriefingData(information string) (*BriefingData, error) {
+	return s.aggregator.GetBriefingData(information)
+}
+
+// RenderBriefingTemplate renders a briefing template as a single paragraph of spoken text
+func (s *Service) RenderBriefingTemplate(templatePath string, data *BriefingData) (string, error) {
+	return s.engine.RenderBriefing(templatePath, data)
+}
+
+// GetATISConditions gets the spoken conditions of a synthesized ATIS
+func (s *Service) GetATISConditions(maxNOTAMs int) (*atis.Conditions, error) {
+	return s.aggregator.GetATISConditions(maxNOTAMs)
+}
+
+// RenderATISTemplate renders a synthesized ATIS template as a single paragraph of spoken text
+func (s *Service) RenderATISTemplate(templatePath string, conditions *atis.Conditions) (string, error) {
+	return s.engine.RenderATIS(templatePath, conditions)
 }
 
 // RenderTemplate renders a template with custom formatting options
@@ -82,6 +185,11 @@ func (s *Service) ReloadAllTemplates() error {
 	return s.engine.ReloadAllTemplates()
 }
 
+// WatchTemplates reloads templates when their files change, until ctx is done
+func (s *Service) WatchTemplates(ctx context.Context, pollInterval time.Duration) {
+	s.engine.Watch(ctx, pollInterval)
+}
+
 // ClearCache clears the template cache
 func (s *Service) ClearCache() {
 	s.engine.ClearCache()
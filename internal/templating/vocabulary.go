@@ -0,0 +1,120 @@
+package templating
+
+import (
+	"math"
+	"sort"
+	"strings"
+
+	"github.com/yegors/co-atc/internal/adsb"
+)
+
+// spokenDigits are the words digits are transcribed as
+var spokenDigits = map[rune]string{
+	'0': "zero", '1': "one", '2': "two", '3': "three", '4': "four",
+	'5': "five", '6': "six", '7': "seven", '8': "eight", '9': "nine",
+}
+
+// phoneticAlphabet is the ICAO spelling alphabet
+var phoneticAlphabet = map[rune]string{
+	'A': "Alpha", 'B': "Bravo", 'C': "Charlie", 'D': "Delta", 'E': "Echo", 'F': "Foxtrot",
+	'G': "Golf", 'H': "Hotel", 'I': "India", 'J': "Juliett", 'K': "Kilo", 'L': "Lima",
+	'M': "Mike", 'N': "November", 'O': "Oscar", 'P': "Papa", 'Q': "Quebec", 'R': "Romeo",
+	'S': "Sierra", 'T': "Tango", 'U': "Uniform", 'V': "Victor", 'W': "Whiskey", 'X': "X-ray",
+	'Y': "Yankee", 'Z': "Zulu",
+}
+
+// runwaySides are the words runway designator suffixes are spoken as
+var runwaySides = map[rune]string{'L': "left", 'R': "right", 'C': "center"}
+
+// getVocabulary builds the terms transcription is biased toward, most useful first: the
+// spoken callsigns of nearby aircraft, the open runways and the configured local names.
+// Numbers are spelled out the way the transcription prompt asks for them.
+func (da *DataAggregator) getVocabulary(maxTerms int) []string {
+	var terms []string
+	seen := make(map[string]bool)
+	add := func(term string) {
+		if term == "" || seen[term] || len(terms) >= maxTerms {
+			return
+		}
+		seen[term] = true
+		terms = append(terms, term)
+	}
+
+	// Callsigns of active aircraft, closest first
+	if da.adsbService != nil {
+		aircraft, err := da.getAircraftData(math.MaxInt32)
+		if err == nil {
+			sort.SliceStable(aircraft, func(i, j int) bool {
+				return aircraftDistance(aircraft[i]) < aircraftDistance(aircraft[j])
+			})
+			for _, ac := range aircraft {
+				add(spokenCallsign(ac.Flight))
+			}
+		}
+
+		runways, err := da.getRunwayData()
+		if err == nil {
+			for _, runway := range runways {
+				if !runway.Closed {
+					add("runway " + spokenRunway(runway.Name))
+				}
+			}
+		}
+	}
+
+	for _, name := range da.config.Transcription.LocalVocabulary {
+		add(strings.TrimSpace(name))
+	}
+
+	return terms
+}
+
+// aircraftDistance returns an aircraft's distance from the airport, or a large value if unknown
+func aircraftDistance(ac *adsb.Aircraft) float64 {
+	if ac.Distance == nil {
+		return 1e9
+	}
+	return *ac.Distance
+}
+
+// spokenCallsign returns how a callsign is said on the radio: the airline's telephony
+// designator and the flight number in words ("Air Canada one two three"), or a
+// registration in the spelling alphabet. Callsigns that fit neither are left out.
+func spokenCallsign(callsign string) string {
+	if telephony := adsb.Telephony(callsign); telephony != "" {
+		designator, number, _ := strings.Cut(telephony, " ")
+		return designator + " " + spellOut(number)
+	}
+
+	normalized := adsb.NormalizeCallsign(callsign)
+	if normalized == "" || !adsb.IsTailNumber(normalized) {
+		return ""
+	}
+	return spellOut(normalized)
+}
+
+// spokenRunway spells out a runway designator ("06L" is "zero six left")
+func spokenRunway(name string) string {
+	var words []string
+	for _, r := range strings.ToUpper(name) {
+		if digit, ok := spokenDigits[r]; ok {
+			words = append(words, digit)
+		} else if side, ok := runwaySides[r]; ok {
+			words = append(words, side)
+		}
+	}
+	return strings.Join(words, " ")
+}
+
+// spellOut spells digits as words and letters in the spelling alphabet
+func spellOut(s string) string {
+	var words []string
+	for _, r := range strings.ToUpper(s) {
+		if digit, ok := spokenDigits[r]; ok {
+			words = append(words, digit)
+		} else if letter, ok := phoneticAlphabet[r]; ok {
+			words = append(words, letter)
+		}
+	}
+	return strings.Join(words, " ")
+}
@@ -0,0 +1,159 @@
+package terrain
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/yegors/co-atc/internal/adsb"
+)
+
+// cellDegrees is the size of the grid cells obstacles are indexed by
+const cellDegrees = 0.1
+
+// ErrObstacleColumns is returned for an obstacle file without latitude, longitude and amsl_ft columns
+var ErrObstacleColumns = errors.New("obstacle file needs latitude, longitude and amsl_ft columns")
+
+// Obstacle is a man-made obstacle such as a tower, chimney or wind turbine
+type Obstacle struct {
+	Name      string  `json:"name,omitempty"`
+	Type      string  `json:"type,omitempty"`
+	Latitude  float64 `json:"latitude"`
+	Longitude float64 `json:"longitude"`
+	AMSLFeet  float64 `json:"amsl_ft"`          // Elevation of the top above mean sea level
+	AGLFeet   float64 `json:"agl_ft,omitempty"` // Height above ground, if known
+}
+
+// Obstacles are the obstacles of an area, indexed by grid cell
+type Obstacles struct {
+	cells map[cellKey][]Obstacle
+	count int
+}
+
+// cellKey identifies a grid cell
+type cellKey struct {
+	lat, lon int
+}
+
+// LoadObstacles reads the obstacles of an area from a CSV file with a header row. The
+// latitude, longitude and amsl_ft columns are required; agl_ft, type and name are optional
+// and columns may come in any order ("lat" and "lon" are accepted too).
+func LoadObstacles(path string, minLat, minLon, maxLat, maxLon float64) (*Obstacles, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open obstacle file: %w", err)
+	}
+	defer file.Close()
+
+	reader := csv.NewReader(file)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read obstacle file header: %w", err)
+	}
+
+	columns := map[string]int{}
+	for i, name := range header {
+		name = strings.ToLower(strings.TrimSpace(name))
+		switch name {
+		case "lat":
+			name = "latitude"
+		case "lon":
+			name = "longitude"
+		}
+		columns[name] = i
+	}
+	for _, required := range []string{"latitude", "longitude", "amsl_ft"} {
+		if _, ok := columns[required]; !ok {
+			return nil, ErrObstacleColumns
+		}
+	}
+
+	o := &Obstacles{cells: make(map[cellKey][]Obstacle)}
+	for line := 2; ; line++ {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read obstacle file line %d: %w", line, err)
+		}
+
+		field := func(name string) string {
+			if i, ok := columns[name]; ok && i < len(record) {
+				return strings.TrimSpace(record[i])
+			}
+			return ""
+		}
+		lat, latErr := strconv.ParseFloat(field("latitude"), 64)
+		lon, lonErr := strconv.ParseFloat(field("longitude"), 64)
+		amsl, amslErr := strconv.ParseFloat(field("amsl_ft"), 64)
+		if latErr != nil || lonErr != nil || amslErr != nil {
+			return nil, fmt.Errorf("invalid obstacle on line %d", line)
+		}
+		if lat < minLat || lat > maxLat || lon < minLon || lon > maxLon {
+			continue
+		}
+
+		obstacle := Obstacle{
+			Name:      field("name"),
+			Type:      field("type"),
+			Latitude:  lat,
+			Longitude: lon,
+			AMSLFeet:  amsl,
+		}
+		if agl, err := strconv.ParseFloat(field("agl_ft"), 64); err == nil {
+			obstacle.AGLFeet = agl
+		}
+		key := cellOf(lat, lon)
+		o.cells[key] = append(o.cells[key], obstacle)
+		o.count++
+	}
+	return o, nil
+}
+
+// Count returns the number of obstacles loaded
+func (o *Obstacles) Count() int {
+	if o == nil {
+		return 0
+	}
+	return o.count
+}
+
+// Highest returns the highest obstacle within a distance of a location
+func (o *Obstacles) Highest(lat, lon, radiusNM float64) (*Obstacle, bool) {
+	if o == nil || o.count == 0 {
+		return nil, false
+	}
+
+	latCells := int(math.Ceil(radiusNM / 60 / cellDegrees))
+	lonCells := int(math.Ceil(radiusNM / (60 * math.Max(math.Cos(lat*math.Pi/180), 0.01)) / cellDegrees))
+	center := cellOf(lat, lon)
+
+	var highest *Obstacle
+	for dLat := -latCells; dLat <= latCells; dLat++ {
+		for dLon := -lonCells; dLon <= lonCells; dLon++ {
+			cell := o.cells[cellKey{center.lat + dLat, center.lon + dLon}]
+			for i := range cell {
+				if highest != nil && cell[i].AMSLFeet <= highest.AMSLFeet {
+					continue
+				}
+				if adsb.MetersToNM(adsb.Haversine(lat, lon, cell[i].Latitude, cell[i].Longitude)) <= radiusNM {
+					highest = &cell[i]
+				}
+			}
+		}
+	}
+	return highest, highest != nil
+}
+
+// cellOf returns the grid cell of a location
+func cellOf(lat, lon float64) cellKey {
+	return cellKey{int(math.Floor(lat / cellDegrees)), int(math.Floor(lon / cellDegrees))}
+}
@@ -0,0 +1,401 @@
+// Package terrain raises minimum safe altitude warnings (MSAW): descending aircraft whose
+// predicted path comes too close to terrain, from SRTM elevation tiles, or to obstacles.
+package terrain
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/yegors/co-atc/internal/adsb"
+	"github.com/yegors/co-atc/internal/config"
+	"github.com/yegors/co-atc/internal/websocket"
+	"github.com/yegors/co-atc/pkg/logger"
+)
+
+// Conflict types
+const (
+	ConflictTerrain  = "terrain"
+	ConflictObstacle = "obstacle"
+)
+
+const (
+	// predictionStep is the time between the points of a predicted path
+	predictionStep = 5 * time.Second
+	// finalApproachHeadingTolerance is how far the course may be from the runway heading on final
+	finalApproachHeadingTolerance = 30.0
+	// maxEvents is how many recent alerts are kept for review
+	maxEvents = 200
+)
+
+// Event is an aircraft predicted to come too close to terrain or an obstacle
+type Event struct {
+	Hex               string    `json:"hex"`
+	Callsign          string    `json:"callsign"`
+	Conflict          string    `json:"conflict"` // "terrain" or "obstacle"
+	Lat               float64   `json:"lat"`
+	Lon               float64   `json:"lon"`
+	Altitude          float64   `json:"altitude"`      // Altitude when detected (ft), corrected to the QNH the aircraft sends
+	VerticalRate      float64   `json:"vertical_rate"` // ft/min
+	SecondsAhead      int       `json:"seconds_ahead"` // When the predicted path reaches the conflict
+	ConflictLat       float64   `json:"conflict_lat"`
+	ConflictLon       float64   `json:"conflict_lon"`
+	PredictedAltitude float64   `json:"predicted_altitude_ft"`
+	ElevationFeet     float64   `json:"elevation_ft"` // Terrain, or top of the obstacle
+	ClearanceFeet     float64   `json:"clearance_ft"` // Predicted height above it; negative below
+	Obstacle          *Obstacle `json:"obstacle,omitempty"`
+	DetectedAt        time.Time `json:"detected_at"`
+}
+
+// Status describes the terrain and obstacle data loaded
+type Status struct {
+	Tiles        int      `json:"tiles"`         // SRTM tiles loaded
+	MissingTiles []string `json:"missing_tiles"` // Tiles of the area not found; their terrain is unknown
+	MaxTerrainFt float64  `json:"max_terrain_ft"`
+	Obstacles    int      `json:"obstacles"`
+}
+
+// AlertNotifier receives alerts that should reach users outside the web UI
+type AlertNotifier interface {
+	NotifyAlert(alertType, title, body string, data map[string]interface{})
+}
+
+// sample is what checking needs from an aircraft in a poll cycle
+type sample struct {
+	hex          string
+	flight       string
+	lat, lon     float64
+	altitude     float64
+	verticalRate float64
+	groundSpeed  float64
+	course       float64 // True
+	replay       bool
+}
+
+// cycle is the descending aircraft of a poll cycle and the runways they may be landing on
+type cycle struct {
+	samples []sample
+	runways []adsb.RunwayEnd
+}
+
+// Service predicts the path of descending aircraft and raises an alert when it comes within
+// the minimum clearance of terrain or an obstacle away from the final approach paths
+type Service struct {
+	config      config.TerrainConfig
+	adsbService *adsb.Service
+	wsServer    *websocket.Server
+	notifier    AlertNotifier
+	logger      *logger.Logger
+
+	elevation *Elevation
+	obstacles *Obstacles
+	status    Status
+
+	cycles chan cycle
+
+	// Aircraft alerted, by hex, with the last time they were in conflict. Only used by the
+	// worker goroutine.
+	alerted map[string]time.Time
+
+	events   []Event // Most recent last
+	eventsMu sync.RWMutex
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// NewService creates a new terrain warning service
+func NewService(cfg config.TerrainConfig, adsbService *adsb.Service, wsServer *websocket.Server, logger *logger.Logger) *Service {
+	return &Service{
+		config:      cfg,
+		adsbService: adsbService,
+		wsServer:    wsServer,
+		logger:      logger.Named("terrain"),
+		cycles:      make(chan cycle, 4),
+		alerted:     make(map[string]time.Time),
+	}
+}
+
+// SetAlertNotifier sets the notifier that receives terrain alerts. Must be called before Start.
+func (s *Service) SetAlertNotifier(notifier AlertNotifier) {
+	s.notifier = notifier
+}
+
+// Start loads the terrain and obstacles around the station and starts checking aircraft
+func (s *Service) Start(ctx context.Context) error {
+	lat, lon := s.adsbService.GetEffectiveStationCoords()
+	radius := float64(s.config.RadiusNM)
+	latSpan := radius / 60
+	lonSpan := radius / (60 * math.Max(math.Cos(lat*math.Pi/180), 0.01))
+	minLat, maxLat := lat-latSpan, lat+latSpan
+	minLon, maxLon := lon-lonSpan, lon+lonSpan
+
+	elevation, missing, err := LoadElevation(s.config.SRTMDir, minLat, minLon, maxLat, maxLon)
+	if err != nil {
+		return fmt.Errorf("failed to load terrain: %w", err)
+	}
+	s.elevation = elevation
+	s.status = Status{
+		Tiles:        elevation.Tiles(),
+		MissingTiles: missing,
+		MaxTerrainFt: math.Round(elevation.MaxFeet()),
+	}
+	if len(missing) > 0 {
+		s.logger.Warn("SRTM tiles missing; terrain there is unknown",
+			logger.Any("tiles", missing))
+	}
+
+	if s.config.ObstaclesPath != "" {
+		obstacles, err := LoadObstacles(s.config.ObstaclesPath, minLat, minLon, maxLat, maxLon)
+		if err != nil {
+			return fmt.Errorf("failed to load obstacles: %w", err)
+		}
+		s.obstacles = obstacles
+		s.status.Obstacles = obstacles.Count()
+	}
+
+	s.ctx, s.cancel = context.WithCancel(ctx)
+	s.wg.Add(1)
+	go s.run()
+
+	s.adsbService.OnUpdate(s.handleUpdate)
+
+	s.logger.Info("Terrain warnings started",
+		logger.Int("tiles", s.status.Tiles),
+		logger.Float64("max_terrain_ft", s.status.MaxTerrainFt),
+		logger.Int("obstacles", s.status.Obstacles),
+		logger.Int("min_clearance_feet", s.config.MinClearanceFeet),
+		logger.Int("lookahead_seconds", s.config.LookaheadSeconds))
+	return nil
+}
+
+// Stop stops checking aircraft
+func (s *Service) Stop() {
+	if s.cancel != nil {
+		s.cancel()
+	}
+	s.wg.Wait()
+}
+
+// Status returns the terrain and obstacle data loaded
+func (s *Service) Status() Status {
+	return s.status
+}
+
+// Events returns the terrain alerts raised since startup, most recent first
+func (s *Service) Events(limit int) []Event {
+	s.eventsMu.RLock()
+	defer s.eventsMu.RUnlock()
+
+	events := make([]Event, 0, min(limit, len(s.events)))
+	for i := len(s.events) - 1; i >= 0 && len(events) < limit; i-- {
+		events = append(events, s.events[i])
+	}
+	return events
+}
+
+// handleUpdate hands the descending aircraft of a poll cycle to the worker without
+// blocking polling
+func (s *Service) handleUpdate(aircraft []*adsb.Aircraft) {
+	declination := s.adsbService.Declination()
+	c := cycle{runways: s.adsbService.RunwayEnds()}
+	for _, a := range aircraft {
+		if a.ADSB == nil || a.OnGround || a.Status != "active" || (a.ADSB.Lat == 0 && a.ADSB.Lon == 0) {
+			continue
+		}
+		verticalRate := a.ADSB.BaroRate
+		if verticalRate == 0 {
+			verticalRate = a.ADSB.GeomRate
+		}
+		if verticalRate > -float64(s.config.MinDescentRateFPM) || a.ADSB.GS <= 0 {
+			continue
+		}
+
+		c.samples = append(c.samples, sample{
+			hex:          strings.ToLower(a.Hex),
+			flight:       strings.TrimSpace(a.Flight),
+			lat:          a.ADSB.Lat,
+			lon:          a.ADSB.Lon,
+			altitude:     adsb.AltitudeMSL(a.ADSB),
+			verticalRate: verticalRate,
+			groundSpeed:  a.ADSB.GS,
+			course:       adsb.TrueCourse(a.ADSB, declination),
+			replay:       a.ADSB.Type == adsb.TargetTypeReplay,
+		})
+	}
+
+	select {
+	case s.cycles <- c:
+	default:
+		s.logger.Debug("Terrain worker busy, skipping poll cycle")
+	}
+}
+
+// run checks poll cycles until the service stops
+func (s *Service) run() {
+	defer s.wg.Done()
+
+	for {
+		select {
+		case <-s.ctx.Done():
+			return
+		case c := <-s.cycles:
+			s.check(c, time.Now().UTC())
+		}
+	}
+}
+
+// check predicts the path of each descending aircraft and alerts on the first conflict.
+// An aircraft is alerted again only after it has been clear for realert_minutes.
+func (s *Service) check(c cycle, now time.Time) {
+	for _, smp := range c.samples {
+		if s.onFinalApproach(smp, c.runways) {
+			continue
+		}
+		event, conflict := s.predict(smp)
+		if !conflict {
+			continue
+		}
+
+		if _, alerted := s.alerted[smp.hex]; !alerted {
+			event.DetectedAt = now
+			s.raise(event, smp.replay)
+		}
+		s.alerted[smp.hex] = now
+	}
+
+	realert := time.Duration(s.config.RealertMinutes) * time.Minute
+	for hex, last := range s.alerted {
+		if now.Sub(last) > realert {
+			delete(s.alerted, hex)
+		}
+	}
+}
+
+// predict follows an aircraft's course, speed and descent rate for lookahead_seconds and
+// returns the first point that comes within the minimum clearance of terrain or an obstacle
+func (s *Service) predict(smp sample) (Event, bool) {
+	minClearance := float64(s.config.MinClearanceFeet)
+	lookahead := time.Duration(s.config.LookaheadSeconds) * time.Second
+
+	for ahead := time.Duration(0); ahead <= lookahead; ahead += predictionStep {
+		lat, lon := adsb.DestinationPoint(smp.lat, smp.lon, smp.course, smp.groundSpeed*ahead.Hours())
+		altitude := smp.altitude + smp.verticalRate*ahead.Minutes()
+
+		event := Event{
+			Hex:               smp.hex,
+			Callsign:          smp.flight,
+			Lat:               smp.lat,
+			Lon:               smp.lon,
+			Altitude:          math.Round(smp.altitude),
+			VerticalRate:      smp.verticalRate,
+			SecondsAhead:      int(ahead.Seconds()),
+			ConflictLat:       lat,
+			ConflictLon:       lon,
+			PredictedAltitude: math.Round(altitude),
+		}
+		if obstacle, ok := s.obstacles.Highest(lat, lon, s.config.ObstacleRadiusNM); ok && altitude-obstacle.AMSLFeet < minClearance {
+			event.Conflict = ConflictObstacle
+			event.ElevationFeet = obstacle.AMSLFeet
+			event.Obstacle = obstacle
+		} else if terrain, ok := s.elevation.ElevationFeet(lat, lon); ok && altitude-terrain < minClearance {
+			event.Conflict = ConflictTerrain
+			event.ElevationFeet = math.Round(terrain)
+		} else {
+			continue
+		}
+		event.ClearanceFeet = math.Round(altitude - event.ElevationFeet)
+		return event, true
+	}
+	return Event{}, false
+}
+
+// onFinalApproach reports whether an aircraft is on the final approach path of a runway:
+// within final_approach_nm before its threshold, final_approach_width_nm of the extended
+// centerline, and flying the runway heading
+func (s *Service) onFinalApproach(smp sample, runways []adsb.RunwayEnd) bool {
+	for _, runway := range runways {
+		if adsb.HeadingDifference(smp.course, runway.Heading) > finalApproachHeadingTolerance {
+			continue
+		}
+
+		// Position relative to the approach path, which extends from the threshold away
+		// from the runway
+		along, right := adsb.RunwayPosition(runway, smp.lat, smp.lon)
+		if math.Hypot(along, right) > s.config.FinalApproachNM {
+			continue
+		}
+		if along >= -runway.LandingLengthNM && math.Abs(right) <= s.config.FinalApproachWidthNM {
+			return true
+		}
+	}
+	return false
+}
+
+// raise records a terrain alert and tells the UI and notifier about it
+func (s *Service) raise(event Event, replay bool) {
+	s.eventsMu.Lock()
+	s.events = append(s.events, event)
+	if len(s.events) > maxEvents {
+		s.events = s.events[len(s.events)-maxEvents:]
+	}
+	s.eventsMu.Unlock()
+
+	s.logger.Warn("Aircraft predicted too close to terrain",
+		logger.String("hex", event.Hex),
+		logger.String("callsign", event.Callsign),
+		logger.String("conflict", event.Conflict),
+		logger.Float64("altitude", event.Altitude),
+		logger.Float64("clearance_ft", event.ClearanceFeet),
+		logger.Int("seconds_ahead", event.SecondsAhead))
+
+	data := map[string]interface{}{
+		"hex":                   event.Hex,
+		"callsign":              event.Callsign,
+		"conflict":              event.Conflict,
+		"lat":                   event.Lat,
+		"lon":                   event.Lon,
+		"altitude":              event.Altitude,
+		"vertical_rate":         event.VerticalRate,
+		"seconds_ahead":         event.SecondsAhead,
+		"conflict_lat":          event.ConflictLat,
+		"conflict_lon":          event.ConflictLon,
+		"predicted_altitude_ft": event.PredictedAltitude,
+		"elevation_ft":          event.ElevationFeet,
+		"clearance_ft":          event.ClearanceFeet,
+		"detected_at":           event.DetectedAt,
+	}
+	what := "terrain"
+	if event.Obstacle != nil {
+		data["obstacle"] = event.Obstacle
+		what = "an obstacle"
+		if event.Obstacle.Type != "" {
+			what = "a " + strings.ToLower(event.Obstacle.Type)
+		}
+	}
+
+	if s.wsServer != nil {
+		s.wsServer.Broadcast(&websocket.Message{
+			Type: "terrain_alert",
+			Data: data,
+		})
+	}
+
+	if s.notifier != nil && !replay {
+		callsign := event.Callsign
+		if callsign == "" {
+			callsign = event.Hex
+		}
+		s.notifier.NotifyAlert(
+			"terrain",
+			fmt.Sprintf("Low altitude alert: %s", callsign),
+			fmt.Sprintf("%s at %.0f ft descending %.0f ft/min, predicted %.0f ft above %s (%.0f ft) in %ds",
+				callsign, event.Altitude, -event.VerticalRate, event.ClearanceFeet, what, event.ElevationFeet, event.SecondsAhead),
+			data,
+		)
+	}
+}
@@ -0,0 +1,143 @@
+package terrain
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+)
+
+const (
+	feetPerMeter = 3.28084
+	voidSample   = -32768 // SRTM samples without data
+)
+
+// ErrTileSize is returned for a tile that isn't a 1 or 3 arc-second SRTM tile
+var ErrTileSize = errors.New("unexpected SRTM tile size")
+
+// Elevation is a terrain elevation model made of the SRTM tiles covering an area
+type Elevation struct {
+	tiles map[tileKey]*tile
+}
+
+// tileKey is the latitude and longitude of a tile's south-west corner
+type tileKey struct {
+	lat, lon int
+}
+
+// tile is a 1°x1° SRTM tile: rows from north to south of samples from west to east, in meters
+type tile struct {
+	size    int // Samples per side: 1201 (3 arc-second) or 3601 (1 arc-second)
+	samples []int16
+	max     int16
+}
+
+// LoadElevation loads the .hgt tiles of dir covering an area. Tiles missing from dir, usually
+// over the sea, are returned by name; their terrain is unknown.
+func LoadElevation(dir string, minLat, minLon, maxLat, maxLon float64) (*Elevation, []string, error) {
+	e := &Elevation{tiles: make(map[tileKey]*tile)}
+	missing := make([]string, 0)
+	for lat := int(math.Floor(minLat)); lat <= int(math.Floor(maxLat)); lat++ {
+		for lon := int(math.Floor(minLon)); lon <= int(math.Floor(maxLon)); lon++ {
+			name := tileName(lat, lon)
+			t, err := loadTile(filepath.Join(dir, name))
+			if errors.Is(err, os.ErrNotExist) {
+				missing = append(missing, name)
+				continue
+			}
+			if err != nil {
+				return nil, nil, fmt.Errorf("failed to load %s: %w", name, err)
+			}
+			e.tiles[tileKey{lat, lon}] = t
+		}
+	}
+	return e, missing, nil
+}
+
+// Tiles returns the number of tiles loaded
+func (e *Elevation) Tiles() int {
+	return len(e.tiles)
+}
+
+// MaxFeet returns the highest terrain loaded, in feet
+func (e *Elevation) MaxFeet() float64 {
+	highest := 0.0
+	for _, t := range e.tiles {
+		highest = math.Max(highest, float64(t.max)*feetPerMeter)
+	}
+	return highest
+}
+
+// ElevationFeet returns the terrain elevation at a location in feet, interpolated between
+// the four samples around it. It's unknown outside the tiles loaded and in data voids.
+func (e *Elevation) ElevationFeet(lat, lon float64) (float64, bool) {
+	key := tileKey{int(math.Floor(lat)), int(math.Floor(lon))}
+	t, ok := e.tiles[key]
+	if !ok {
+		return 0, false
+	}
+
+	last := float64(t.size - 1)
+	row := (1 - (lat - float64(key.lat))) * last
+	col := (lon - float64(key.lon)) * last
+	r0, c0 := int(math.Floor(row)), int(math.Floor(col))
+	r1, c1 := min(r0+1, t.size-1), min(c0+1, t.size-1)
+	fr, fc := row-float64(r0), col-float64(c0)
+
+	corners := [4]int16{t.at(r0, c0), t.at(r0, c1), t.at(r1, c0), t.at(r1, c1)}
+	for _, sample := range corners {
+		if sample == voidSample {
+			return 0, false
+		}
+	}
+	top := float64(corners[0])*(1-fc) + float64(corners[1])*fc
+	bottom := float64(corners[2])*(1-fc) + float64(corners[3])*fc
+	return (top*(1-fr) + bottom*fr) * feetPerMeter, true
+}
+
+// at returns the sample at a row and column
+func (t *tile) at(row, col int) int16 {
+	return t.samples[row*t.size+col]
+}
+
+// loadTile reads an .hgt file: big-endian 16-bit samples, with its size from the file length
+func loadTile(path string) (*tile, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	size := int(math.Sqrt(float64(len(data) / 2)))
+	if (size != 1201 && size != 3601) || size*size*2 != len(data) {
+		return nil, fmt.Errorf("%w: %d bytes", ErrTileSize, len(data))
+	}
+
+	t := &tile{size: size, samples: make([]int16, size*size)}
+	for i := range t.samples {
+		t.samples[i] = int16(binary.BigEndian.Uint16(data[i*2:]))
+		t.max = max(t.max, t.samples[i])
+	}
+	return t, nil
+}
+
+// tileName returns the file name of the tile with a south-west corner, e.g. N43W080.hgt
+func tileName(lat, lon int) string {
+	ns, ew := "N", "E"
+	if lat < 0 {
+		ns = "S"
+	}
+	if lon < 0 {
+		ew = "W"
+	}
+	return fmt.Sprintf("%s%02d%s%03d.hgt", ns, abs(lat), ew, abs(lon))
+}
+
+// abs returns the absolute value of an integer
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
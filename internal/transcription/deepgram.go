@@ -0,0 +1,407 @@
+package transcription
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/yegors/co-atc/pkg/logger"
+)
+
+// Deepgram defaults
+const (
+	DefaultDeepgramModel          = "nova-2"
+	DefaultDeepgramEndpointingMs  = 500  // Silence that ends an utterance
+	DefaultDeepgramUtteranceEndMs = 1000 // Gap between words that ends an utterance when endpointing misses it
+)
+
+// deepgramKeepAliveInterval is how often an idle stream is kept open. Deepgram closes
+// streams that receive no audio for 10 seconds, which is common with silence gating.
+const deepgramKeepAliveInterval = 5 * time.Second
+
+// DeepgramConfig is the configuration of the Deepgram provider
+type DeepgramConfig struct {
+	APIKey                string
+	Model                 string
+	EndpointingMs         int
+	UtteranceEndMs        int
+	RetryMaxAttempts      int
+	RetryInitialBackoffMs int
+	RetryMaxBackoffMs     int
+	ConnectsPerMinute     int
+	Diarize               bool // Label the speaker of each word
+}
+
+// deepgramProvider streams audio to Deepgram's live transcription API
+type deepgramProvider struct {
+	config  Config
+	policy  RetryPolicy
+	limiter *connectLimiter
+	logger  *logger.Logger
+}
+
+// deepgramSession is a live transcription stream on Deepgram. Deepgram has no turn
+// detection of its own, so the session derives transmissions from its endpointing:
+// interim results become deltas, and the final segments of an utterance are joined into
+// one completed transcript.
+type deepgramSession struct {
+	id       string
+	conn     *websocket.Conn
+	writeMu  sync.Mutex
+	lastSent time.Time // Protected by writeMu
+	closed   bool      // Protected by writeMu
+	done     chan struct{}
+
+	// Only used by Receive
+	pending    []ProviderEvent
+	itemID     string   // Utterance in progress, empty between utterances
+	itemCount  int      // Utterances started in this session
+	finalParts []string // Final segments of the utterance in progress
+	finalWords []ProviderWord
+	lastEnd    time.Duration
+}
+
+// newDeepgramProvider creates the Deepgram provider
+func newDeepgramProvider(config Config, logger *logger.Logger) *deepgramProvider {
+	dg := config.Deepgram
+	policy := retryPolicyWithOverrides(RetryPolicy{
+		MaxReconnectAttempts: 5,
+		InitialBackoff:       2 * time.Second,
+		MaxBackoff:           time.Minute,
+	}, dg.RetryMaxAttempts, dg.RetryInitialBackoffMs, dg.RetryMaxBackoffMs)
+	policy.ConnectsPerMinute = dg.ConnectsPerMinute
+
+	return &deepgramProvider{
+		config:  config,
+		policy:  policy,
+		limiter: newConnectLimiter(policy.ConnectsPerMinute),
+		logger:  logger.Named("deepgram"),
+	}
+}
+
+// Name returns the provider name
+func (p *deepgramProvider) Name() string {
+	return ProviderDeepgram
+}
+
+// Model returns the Deepgram model
+func (p *deepgramProvider) Model() string {
+	if p.config.Deepgram.Model == "" {
+		return DefaultDeepgramModel
+	}
+	return p.config.Deepgram.Model
+}
+
+// RetryPolicy returns how streams are reconnected. Streams don't expire, so they're never
+// refreshed.
+func (p *deepgramProvider) RetryPolicy() RetryPolicy {
+	return p.policy
+}
+
+// streamURL builds the live transcription URL for the audio format, options and vocabulary
+func (p *deepgramProvider) streamURL(vocabulary []string) string {
+	dg := p.config.Deepgram
+	model := p.Model()
+	endpointing := dg.EndpointingMs
+	if endpointing <= 0 {
+		endpointing = DefaultDeepgramEndpointingMs
+	}
+	utteranceEnd := dg.UtteranceEndMs
+	if utteranceEnd <= 0 {
+		utteranceEnd = DefaultDeepgramUtteranceEndMs
+	}
+
+	query := url.Values{}
+	query.Set("model", model)
+	query.Set("encoding", "linear16")
+	query.Set("sample_rate", strconv.Itoa(p.config.FFmpegSampleRate))
+	query.Set("channels", strconv.Itoa(p.config.FFmpegChannels))
+	query.Set("interim_results", "true")
+	query.Set("vad_events", "true")
+	query.Set("punctuate", "true")
+	query.Set("endpointing", strconv.Itoa(endpointing))
+	query.Set("utterance_end_ms", strconv.Itoa(utteranceEnd))
+	if dg.Diarize {
+		query.Set("diarize", "true")
+	}
+	if p.config.Language != "" {
+		query.Set("language", p.config.Language)
+	}
+	// Nova-3 takes key terms as they're spoken; older models boost individual keywords
+	for _, term := range vocabulary {
+		if strings.HasPrefix(model, "nova-3") {
+			query.Add("keyterm", term)
+		} else {
+			query.Add("keywords", term)
+		}
+	}
+
+	return "wss://api.deepgram.com/v1/listen?" + query.Encode()
+}
+
+// Connect opens a live transcription stream. Deepgram can't change the vocabulary of an
+// open stream, so new terms apply from the next stream.
+func (p *deepgramProvider) Connect(ctx context.Context, vocabulary []string) (ProviderSession, error) {
+	if err := p.limiter.Wait(ctx); err != nil {
+		return nil, err
+	}
+
+	dialer := websocket.Dialer{
+		HandshakeTimeout: 30 * time.Second,
+	}
+	headers := http.Header{}
+	headers.Set("Authorization", "Token "+p.config.Deepgram.APIKey)
+
+	conn, resp, err := dialer.DialContext(ctx, p.streamURL(vocabulary), headers)
+	if err != nil {
+		if resp != nil && resp.StatusCode == http.StatusTooManyRequests {
+			return nil, fmt.Errorf("failed to connect to Deepgram: %w", ErrRateLimited)
+		}
+		if resp != nil {
+			return nil, fmt.Errorf("failed to connect to Deepgram: %w (status %d)", err, resp.StatusCode)
+		}
+		return nil, fmt.Errorf("failed to connect to Deepgram: %w", err)
+	}
+
+	id := resp.Header.Get("dg-request-id")
+	if id == "" {
+		id = logger.NewCorrelationID("dg")
+	}
+	p.logger.Debug("Connected to Deepgram", logger.String("request_id", id))
+
+	session := &deepgramSession{
+		id:       id,
+		conn:     conn,
+		lastSent: time.Now(),
+		done:     make(chan struct{}),
+	}
+	go session.keepAlive()
+
+	return session, nil
+}
+
+// ID returns the Deepgram request ID of the stream
+func (s *deepgramSession) ID() string {
+	return s.id
+}
+
+// SendAudio streams raw PCM16 audio
+func (s *deepgramSession) SendAudio(pcm []byte) error {
+	s.writeMu.Lock()
+	defer s.writeMu.Unlock()
+
+	if s.closed {
+		return fmt.Errorf("Deepgram stream is closed")
+	}
+	if err := s.conn.WriteMessage(websocket.BinaryMessage, pcm); err != nil {
+		return err
+	}
+	s.lastSent = time.Now()
+	return nil
+}
+
+// keepAlive keeps the stream open while no audio is sent
+func (s *deepgramSession) keepAlive() {
+	ticker := time.NewTicker(deepgramKeepAliveInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-s.done:
+			return
+		case <-ticker.C:
+			s.writeMu.Lock()
+			if !s.closed && time.Since(s.lastSent) >= deepgramKeepAliveInterval {
+				if err := s.conn.WriteMessage(websocket.TextMessage, []byte(`{"type":"KeepAlive"}`)); err == nil {
+					s.lastSent = time.Now()
+				}
+			}
+			s.writeMu.Unlock()
+		}
+	}
+}
+
+// Receive reads the next event from the stream
+func (s *deepgramSession) Receive() (ProviderEvent, error) {
+	for len(s.pending) == 0 {
+		_, message, err := s.conn.ReadMessage()
+		if err != nil {
+			return ProviderEvent{}, err
+		}
+		s.handleMessage(message)
+	}
+
+	event := s.pending[0]
+	s.pending = s.pending[1:]
+	return event, nil
+}
+
+// handleMessage turns a Deepgram message into pending events
+func (s *deepgramSession) handleMessage(message []byte) {
+	var msg struct {
+		Type        string          `json:"type"`
+		Start       float64         `json:"start"`
+		Duration    float64         `json:"duration"`
+		IsFinal     bool            `json:"is_final"`
+		SpeechFinal bool            `json:"speech_final"`
+		Timestamp   *float64        `json:"timestamp"`
+		LastWordEnd *float64        `json:"last_word_end"`
+		Channel     json.RawMessage `json:"channel"`
+		Description string          `json:"description"`
+		Message     string          `json:"message"`
+	}
+	if err := json.Unmarshal(message, &msg); err != nil {
+		s.pending = append(s.pending, ProviderEvent{Type: EventError, Text: fmt.Sprintf("error parsing event: %v", err)})
+		return
+	}
+
+	switch msg.Type {
+	case "SpeechStarted":
+		s.startUtterance(secondsOffset(msg.Timestamp))
+
+	case "Results":
+		var channel struct {
+			Alternatives []struct {
+				Transcript string `json:"transcript"`
+				Words      []struct {
+					Word           string  `json:"word"`
+					PunctuatedWord string  `json:"punctuated_word"`
+					Start          float64 `json:"start"`
+					End            float64 `json:"end"`
+					Confidence     float64 `json:"confidence"`
+					Speaker        *int    `json:"speaker"`
+				} `json:"words"`
+			} `json:"alternatives"`
+		}
+		if err := json.Unmarshal(msg.Channel, &channel); err != nil || len(channel.Alternatives) == 0 {
+			return
+		}
+		alternative := channel.Alternatives[0]
+		transcript := strings.TrimSpace(alternative.Transcript)
+
+		if transcript != "" {
+			start := msg.Start
+			s.startUtterance(secondsOffset(&start))
+			if msg.IsFinal {
+				s.finalParts = append(s.finalParts, transcript)
+				for _, word := range alternative.Words {
+					text := word.PunctuatedWord
+					if text == "" {
+						text = word.Word
+					}
+					s.finalWords = append(s.finalWords, ProviderWord{
+						Text:       text,
+						Start:      secondsDuration(word.Start),
+						End:        secondsDuration(word.End),
+						Confidence: word.Confidence,
+						Speaker:    word.Speaker,
+					})
+				}
+				s.lastEnd = secondsDuration(msg.Start + msg.Duration)
+			} else {
+				s.pending = append(s.pending, ProviderEvent{
+					Type:   EventDelta,
+					ItemID: s.itemID,
+					Text:   strings.Join(append(append([]string{}, s.finalParts...), transcript), " "),
+				})
+			}
+		}
+		if msg.SpeechFinal {
+			end := secondsDuration(msg.Start + msg.Duration)
+			s.finishUtterance(&end)
+		}
+
+	case "UtteranceEnd":
+		s.finishUtterance(secondsOffset(msg.LastWordEnd))
+
+	case "Error":
+		text := msg.Description
+		if text == "" {
+			text = msg.Message
+		}
+		s.pending = append(s.pending, ProviderEvent{Type: EventError, Text: text})
+	}
+}
+
+// startUtterance opens an utterance unless one is in progress
+func (s *deepgramSession) startUtterance(offset *time.Duration) {
+	if s.itemID != "" {
+		return
+	}
+	s.itemCount++
+	s.itemID = fmt.Sprintf("%s-%d", s.id, s.itemCount)
+	s.finalParts = nil
+	s.finalWords = nil
+	s.pending = append(s.pending, ProviderEvent{Type: EventSpeechStarted, ItemID: s.itemID, AudioOffset: offset})
+}
+
+// finishUtterance closes the utterance in progress and completes its transcript
+func (s *deepgramSession) finishUtterance(end *time.Duration) {
+	if s.itemID == "" {
+		return
+	}
+	if end == nil && s.lastEnd > 0 {
+		lastEnd := s.lastEnd
+		end = &lastEnd
+	}
+
+	s.pending = append(s.pending, ProviderEvent{Type: EventSpeechStopped, ItemID: s.itemID, AudioOffset: end})
+	// An utterance that was only noise stops without a transcript
+	if len(s.finalParts) > 0 {
+		completed := ProviderEvent{
+			Type:   EventCompleted,
+			ItemID: s.itemID,
+			Text:   strings.Join(s.finalParts, " "),
+			Words:  s.finalWords,
+		}
+		// The transcript's confidence is the mean of its words'
+		if len(s.finalWords) > 0 {
+			sum := 0.0
+			for _, word := range s.finalWords {
+				sum += word.Confidence
+			}
+			confidence := sum / float64(len(s.finalWords))
+			completed.Confidence = &confidence
+		}
+		s.pending = append(s.pending, completed)
+	}
+
+	s.itemID = ""
+	s.finalParts = nil
+	s.finalWords = nil
+}
+
+// Close asks Deepgram to finish the stream and closes the connection
+func (s *deepgramSession) Close() error {
+	s.writeMu.Lock()
+	defer s.writeMu.Unlock()
+
+	if s.closed {
+		return nil
+	}
+	s.closed = true
+	close(s.done)
+	s.conn.WriteMessage(websocket.TextMessage, []byte(`{"type":"CloseStream"}`))
+	return s.conn.Close()
+}
+
+// secondsOffset converts an optional offset in seconds
+func secondsOffset(seconds *float64) *time.Duration {
+	if seconds == nil {
+		return nil
+	}
+	offset := secondsDuration(*seconds)
+	return &offset
+}
+
+// secondsDuration converts seconds to a duration
+func secondsDuration(seconds float64) time.Duration {
+	return time.Duration(seconds * float64(time.Second))
+}
@@ -4,6 +4,8 @@ package transcription
 type ProcessorInterface interface {
 	Start() error
 	Stop() error
+	Done() <-chan struct{} // Closed when the processor gives up on its own
+	Err() error            // Why it gave up, once Done is closed
 }
 
 // Ensure the processor implements the interface
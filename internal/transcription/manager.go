@@ -3,18 +3,21 @@ package transcription
 import (
 	"context"
 	"fmt"
+	"sort"
 	"sync"
 	"time"
 
 	"github.com/yegors/co-atc/internal/audio"
+	"github.com/yegors/co-atc/internal/llm"
 	"github.com/yegors/co-atc/internal/storage/sqlite"
+	"github.com/yegors/co-atc/internal/usage"
 	"github.com/yegors/co-atc/internal/websocket"
 	"github.com/yegors/co-atc/pkg/logger"
 )
 
 // TranscriptionManager manages transcription processors for frequencies
 type TranscriptionManager struct {
-	processors           map[string]ProcessorInterface
+	supervisor           *supervisor // Runs one worker per transcribed frequency
 	mu                   sync.RWMutex
 	wsServer             *websocket.Server
 	transcriptionStorage *sqlite.TranscriptionStorage
@@ -23,10 +26,14 @@ type TranscriptionManager struct {
 	logger               *logger.Logger
 	openAIAPIKey         string
 	transcriptionConfig  Config
+	provider             Provider // Speech-to-text provider shared by all processors (nil if not configured)
 	postProcessor        *PostProcessor
 	postProcessingConfig PostProcessingConfig
 	templateRenderer     TemplateRenderer
-	frequencyNames       map[string]string // Map of frequency IDs to names
+	usageTracker         *usage.Tracker                         // Accounts for API usage (nil = not tracked)
+	frequencyNames       *FrequencyNames                        // Map of frequency IDs to names
+	gateStats            map[string]*GateStats                  // Silence gating counters by frequency ID, protected by mu
+	audioDelay           func(frequencyID string) time.Duration // Delay transcript times are moved back by (nil = none), protected by mu
 }
 
 // NewTranscriptionManager creates a new transcription manager
@@ -40,16 +47,23 @@ func NewTranscriptionManager(
 	transcriptionConfig Config,
 	postProcessingConfig PostProcessingConfig,
 	templateRenderer TemplateRenderer,
+	usageTracker *usage.Tracker,
 	frequencyConfigs []FrequencyConfig,
 ) *TranscriptionManager {
 	// Create map of frequency IDs to names
-	frequencyNames := make(map[string]string)
+	frequencyNames := NewFrequencyNames()
 	for _, freq := range frequencyConfigs {
-		frequencyNames[freq.ID] = freq.Name
+		frequencyNames.Set(freq.ID, freq.Name)
 	}
 
-	return &TranscriptionManager{
-		processors:           make(map[string]ProcessorInterface),
+	provider, err := NewProvider(transcriptionConfig, logger)
+	if err != nil {
+		logger.Warn("Transcription disabled - speech-to-text provider not available", Error(err))
+	} else if usageTracker != nil {
+		provider = newMeteredProvider(provider, usageTracker, transcriptionConfig)
+	}
+
+	m := &TranscriptionManager{
 		wsServer:             wsServer,
 		transcriptionStorage: transcriptionStorage,
 		aircraftStorage:      aircraftStorage,
@@ -57,10 +71,24 @@ func NewTranscriptionManager(
 		logger:               logger,
 		openAIAPIKey:         openAIAPIKey,
 		transcriptionConfig:  transcriptionConfig,
+		provider:             provider,
 		postProcessingConfig: postProcessingConfig,
 		templateRenderer:     templateRenderer,
+		usageTracker:         usageTracker,
 		frequencyNames:       frequencyNames,
+		gateStats:            make(map[string]*GateStats),
+	}
+	m.supervisor = newSupervisor(transcriptionConfig, m.newProcessor, logger)
+	return m
+}
+
+// vocabularySource returns where processors get the terms transcription is biased toward,
+// or nil if vocabulary biasing is disabled
+func (m *TranscriptionManager) vocabularySource() VocabularySource {
+	if !m.transcriptionConfig.VocabularyBiasing || m.templateRenderer == nil {
+		return nil
 	}
+	return m.templateRenderer
 }
 
 // FrequencyConfig represents a frequency configuration
@@ -69,98 +97,63 @@ type FrequencyConfig struct {
 	Name string
 }
 
-// StartTranscription starts transcription for a frequency
-func (m *TranscriptionManager) StartTranscription(
-	ctx context.Context,
-	frequencyID string,
-	frequencyName string,
-	audioURL string,
-	transcribeAudio bool,
-) error {
-	// Skip if transcription is not enabled for this frequency
-	if !transcribeAudio {
-		m.logger.Info("Transcription not enabled for frequency",
-			logger.String("id", frequencyID),
-			logger.String("name", frequencyName))
-		return nil
-	}
-
-	m.mu.Lock()
-	defer m.mu.Unlock()
-
-	// Check if processor already exists
-	if _, exists := m.processors[frequencyID]; exists {
-		m.logger.Info("Transcription already started for frequency",
-			logger.String("id", frequencyID),
-			logger.String("name", frequencyName))
-		return nil
-	}
-
-	m.logger.Info("Starting transcription for frequency",
-		logger.String("id", frequencyID),
-		logger.String("name", frequencyName),
-		logger.String("url", audioURL))
-
-	// Create a CentralAudioProcessor for this frequency
-	audioConfig := audio.CentralProcessorConfig{
-		FFmpegPath:               m.transcriptionConfig.FFmpegPath,
-		SampleRate:               m.transcriptionConfig.FFmpegSampleRate,
-		Channels:                 m.transcriptionConfig.FFmpegChannels,
-		Format:                   m.transcriptionConfig.FFmpegFormat,
-		ReconnectDelay:           time.Duration(m.transcriptionConfig.ReconnectIntervalSec) * time.Second,
-		FFmpegTimeoutSecs:        0, // Default no timeout for transcription
-		FFmpegReconnectDelaySecs: 2, // Default reconnect delay for transcription
-	}
+// FrequencyNames maps frequency IDs to display names. Frequencies can be added
+// and renamed at runtime, so access is synchronized.
+type FrequencyNames struct {
+	names map[string]string
+	mu    sync.RWMutex
+}
 
-	audioProcessor, err := audio.NewCentralAudioProcessor(
-		ctx,
-		frequencyID,
-		audioURL,
-		audioConfig,
-		m.logger.Named("audio"),
-	)
-	if err != nil {
-		return fmt.Errorf("failed to create audio processor: %w", err)
+// NewFrequencyNames creates an empty frequency name map
+func NewFrequencyNames() *FrequencyNames {
+	return &FrequencyNames{
+		names: make(map[string]string),
 	}
+}
 
-	// Start the audio processor
-	if err := audioProcessor.Start(); err != nil {
-		return fmt.Errorf("failed to start audio processor: %w", err)
-	}
+// Get returns the name of a frequency
+func (n *FrequencyNames) Get(id string) (string, bool) {
+	n.mu.RLock()
+	defer n.mu.RUnlock()
+	name, ok := n.names[id]
+	return name, ok
+}
 
-	// Create a reader from the audio processor
-	reader, err := audioProcessor.CreateReader(fmt.Sprintf("transcription-%s", frequencyID))
-	if err != nil {
-		audioProcessor.Stop()
-		return fmt.Errorf("failed to create audio reader: %w", err)
-	}
+// Set sets the name of a frequency
+func (n *FrequencyNames) Set(id, name string) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.names[id] = name
+}
 
-	// Create a processor that uses the reader
-	processor, err := NewProcessor(
-		ctx,
-		frequencyID,
-		reader,
-		m.transcriptionConfig,
-		m.wsServer,
-		m.transcriptionStorage,
-		m.logger,
-	)
-	if err != nil {
-		return err
-	}
+// Delete removes a frequency
+func (n *FrequencyNames) Delete(id string) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	delete(n.names, id)
+}
 
-	// Start processor
-	if err := processor.Start(); err != nil {
-		return err
-	}
+// SetFrequencyName registers or renames a frequency for post-processing prompts
+func (m *TranscriptionManager) SetFrequencyName(frequencyID, name string) {
+	m.frequencyNames.Set(frequencyID, name)
+}
 
-	// Store processor
-	m.processors[frequencyID] = processor
+// SetAudioDelay sets how far each frequency's audio lags the air. Processors created after
+// the call time transcripts by when they were spoken rather than when their audio arrived.
+func (m *TranscriptionManager) SetAudioDelay(delay func(frequencyID string) time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.audioDelay = delay
+}
 
-	return nil
+// RemoveFrequencyName forgets a frequency that has been removed
+func (m *TranscriptionManager) RemoveFrequencyName(frequencyID string) {
+	m.frequencyNames.Delete(frequencyID)
 }
 
-// StartTranscriptionWithExternalAudio starts transcription for a frequency using an external audio processor
+// StartTranscriptionWithExternalAudio starts a supervised worker transcribing a frequency's
+// audio. The worker restarts its processor when it fails, and waits for a session slot if
+// max_concurrent_sessions frequencies are already transcribed.
 func (m *TranscriptionManager) StartTranscriptionWithExternalAudio(
 	ctx context.Context,
 	frequencyID string,
@@ -176,113 +169,157 @@ func (m *TranscriptionManager) StartTranscriptionWithExternalAudio(
 		return nil
 	}
 
-	// Skip if no OpenAI API key is provided
-	if m.openAIAPIKey == "" {
-		m.logger.Info("Transcription disabled - no OpenAI API key provided",
+	// Skip if no speech-to-text provider is configured
+	if m.provider == nil {
+		m.logger.Info("Transcription disabled - no speech-to-text provider configured",
 			logger.String("id", frequencyID),
 			logger.String("name", frequencyName))
 		return nil
 	}
 
-	m.mu.Lock()
-	defer m.mu.Unlock()
+	// We only support CentralAudioProcessor now
+	ap, ok := audioProcessor.(*audio.CentralAudioProcessor)
+	if !ok {
+		return fmt.Errorf("unsupported audio processor type: %T, only CentralAudioProcessor is supported", audioProcessor)
+	}
 
-	// Check if processor already exists
-	if _, exists := m.processors[frequencyID]; exists {
+	w := m.supervisor.add(ctx, frequencyID, frequencyName, ap)
+	if w == nil {
 		m.logger.Info("Transcription already started for frequency",
 			logger.String("id", frequencyID),
 			logger.String("name", frequencyName))
 		return nil
 	}
 
-	m.logger.Info("Starting transcription with external audio for frequency",
+	m.logger.Info("Started transcription worker for frequency",
 		logger.String("id", frequencyID),
 		logger.String("name", frequencyName))
 
-	// Create external processor based on the type of audio processor
-	var processor ProcessorInterface
-	var err error
+	// Transmissions detected by the squelch mark where a transcript can be split
+	if m.transcriptionConfig.SplitTurns {
+		ap.OnSquelch(func(event audio.SquelchEvent) {
+			if p, ok := w.current().(*Processor); ok && !event.Open && p.ctx.Err() == nil {
+				p.recordTransmission(event.StartedAt, event.EndedAt)
+			}
+		})
+	}
 
-	// We only support CentralAudioProcessor now
-	ap, ok := audioProcessor.(*audio.CentralAudioProcessor)
-	if !ok {
-		return fmt.Errorf("unsupported audio processor type: %T, only CentralAudioProcessor is supported", audioProcessor)
+	return nil
+}
+
+// newProcessor creates a processor transcribing a frequency's audio; the supervisor's
+// workers call it on every (re)start
+func (m *TranscriptionManager) newProcessor(ctx context.Context, frequencyID string, ap *audio.CentralAudioProcessor) (ProcessorInterface, error) {
+	reader, err := ap.CreateReader(fmt.Sprintf("transcription-%s", frequencyID))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create reader from central processor: %w", err)
 	}
 
-	// Create a reader from the central processor
-	reader, readerErr := ap.CreateReader(fmt.Sprintf("transcription-%s", frequencyID))
-	if readerErr != nil {
-		return fmt.Errorf("failed to create reader from central processor: %w", readerErr)
+	m.mu.Lock()
+	gateStats := m.gateStatsFor(frequencyID)
+	var audioDelay func() time.Duration
+	if m.audioDelay != nil {
+		delay := m.audioDelay
+		audioDelay = func() time.Duration { return delay(frequencyID) }
 	}
+	m.mu.Unlock()
 
-	// Create a processor that uses the reader
-	processor, err = NewProcessor(
+	processor, err := NewProcessor(
 		ctx,
 		frequencyID,
 		reader,
 		m.transcriptionConfig,
 		m.wsServer,
 		m.transcriptionStorage,
+		m.provider,
+		gateStats,
+		m.vocabularySource(),
+		audioDelay,
 		m.logger,
 	)
 	if err != nil {
-		return fmt.Errorf("failed to create external processor: %w", err)
+		reader.Close()
+		return nil, fmt.Errorf("failed to create external processor: %w", err)
 	}
+	return processor, nil
+}
 
-	// Start processor
-	if err := processor.Start(); err != nil {
-		return fmt.Errorf("failed to start external processor: %w", err)
+// gateStatsFor returns the silence gating counters of a frequency. Must be called with mu held.
+func (m *TranscriptionManager) gateStatsFor(frequencyID string) *GateStats {
+	stats, ok := m.gateStats[frequencyID]
+	if !ok {
+		stats = &GateStats{}
+		m.gateStats[frequencyID] = stats
 	}
+	return stats
+}
 
-	// Store processor
-	m.processors[frequencyID] = processor
+// Workers returns the state of the transcription workers, or false if no speech-to-text
+// provider is configured
+func (m *TranscriptionManager) Workers() (WorkerReport, bool) {
+	if m.provider == nil {
+		return WorkerReport{}, false
+	}
+	return m.supervisor.report(), true
+}
 
-	return nil
+// ProviderName returns the speech-to-text provider in use, or "" if none is configured
+func (m *TranscriptionManager) ProviderName() string {
+	if m.provider == nil {
+		return ""
+	}
+	return m.provider.Name()
 }
 
-// StopTranscription stops transcription for a frequency
-func (m *TranscriptionManager) StopTranscription(frequencyID string) {
-	m.mu.Lock()
-	defer m.mu.Unlock()
+// SilenceGatingEnabled reports whether silence between transmissions is held back from the provider
+func (m *TranscriptionManager) SilenceGatingEnabled() bool {
+	return m.transcriptionConfig.SilenceGating
+}
 
-	// Check if processor exists
-	processor, exists := m.processors[frequencyID]
-	if !exists {
-		m.logger.Info("No transcription processor found for frequency", logger.String("id", frequencyID))
-		return
+// GateReports returns how much audio each transcribed frequency streamed to the provider and how
+// much the silence gate held back since startup
+func (m *TranscriptionManager) GateReports() []GateReport {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	reports := make([]GateReport, 0, len(m.gateStats))
+	for id, stats := range m.gateStats {
+		reports = append(reports, stats.report(id))
 	}
+	sort.Slice(reports, func(i, j int) bool {
+		return reports[i].FrequencyID < reports[j].FrequencyID
+	})
+	return reports
+}
 
+// StopTranscription stops transcription for a frequency
+func (m *TranscriptionManager) StopTranscription(frequencyID string) {
 	m.logger.Info("Stopping transcription for frequency", logger.String("id", frequencyID))
 
-	// Stop processor
-	if err := processor.Stop(); err != nil {
-		m.logger.Error("Error stopping transcription processor",
-			logger.String("id", frequencyID),
-			logger.Error(err))
+	// Stop the worker and its processor
+	if !m.supervisor.remove(frequencyID) {
+		m.logger.Info("No transcription worker found for frequency", logger.String("id", frequencyID))
+		return
 	}
 
-	// Remove processor
-	delete(m.processors, frequencyID)
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	if m.transcriptionConfig.SilenceGating {
+		if stats, ok := m.gateStats[frequencyID]; ok {
+			report := stats.report(frequencyID)
+			m.logger.Info("Silence gating summary",
+				logger.String("id", frequencyID),
+				logger.Float64("streamed_minutes", report.StreamedMinutes),
+				logger.Float64("estimated_minutes_saved", report.EstimatedMinutesSaved))
+		}
+	}
 }
 
 // StopAllTranscriptions stops all transcription processors and post-processing
 func (m *TranscriptionManager) StopAllTranscriptions() {
-	m.mu.Lock()
-	defer m.mu.Unlock()
-
-	m.logger.Info("Stopping all transcription processors", logger.Int("count", len(m.processors)))
-
-	// Stop all processors
-	for id, processor := range m.processors {
-		if err := processor.Stop(); err != nil {
-			m.logger.Error("Error stopping transcription processor",
-				logger.String("id", id),
-				logger.Error(err))
-		}
-	}
-
-	// Clear processors
-	m.processors = make(map[string]ProcessorInterface)
+	// Stop all workers and their processors
+	stopped := m.supervisor.removeAll()
+	m.logger.Info("Stopped all transcription workers", logger.Int("count", stopped))
 
 	// Stop post-processor
 	m.StopPostProcessing()
@@ -295,25 +332,23 @@ func (m *TranscriptionManager) StartPostProcessing(ctx context.Context) error {
 		return nil
 	}
 
-	// Skip if no OpenAI API key is provided
-	if m.openAIAPIKey == "" {
-		m.logger.Info("Post-processing disabled - no OpenAI API key provided")
+	// Skip if the configured LLM can't be used
+	llmProvider, err := llm.NewProvider(m.postProcessingConfig.LLM, m.logger)
+	if err != nil {
+		m.logger.Info("Post-processing disabled - LLM provider not available", Error(err))
 		return nil
 	}
 
-	// Create OpenAI client for post-processing
-	openaiClient := NewOpenAIClient(m.openAIAPIKey, m.postProcessingConfig.Model, m.postProcessingConfig.TimeoutSeconds, m.logger)
-
 	// Create post-processor
-	var err error
 	m.postProcessor, err = NewPostProcessor(
 		ctx,
 		m.transcriptionStorage,
 		m.aircraftStorage,
 		m.clearanceStorage,
-		openaiClient,
+		llmProvider,
 		m.wsServer,
 		m.templateRenderer,
+		m.usageTracker,
 		m.postProcessingConfig,
 		m.logger,
 		m.frequencyNames,
@@ -331,6 +366,15 @@ func (m *TranscriptionManager) StartPostProcessing(ctx context.Context) error {
 	return nil
 }
 
+// PostProcessingStats returns the state of the post-processing queue, or false if
+// post-processing isn't running
+func (m *TranscriptionManager) PostProcessingStats() (PostProcessingStats, bool) {
+	if m.postProcessor == nil || !m.postProcessingConfig.Enabled {
+		return PostProcessingStats{}, false
+	}
+	return m.postProcessor.Stats(), true
+}
+
 // StopPostProcessing stops the post-processing of transcriptions
 func (m *TranscriptionManager) StopPostProcessing() {
 	if m.postProcessor == nil {
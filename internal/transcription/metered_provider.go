@@ -0,0 +1,76 @@
+package transcription
+
+import (
+	"context"
+
+	"github.com/yegors/co-atc/internal/usage"
+)
+
+// meteredProvider accounts for the sessions a provider opens and the audio streamed to
+// them, which is what speech-to-text providers bill for
+type meteredProvider struct {
+	Provider
+	tracker        *usage.Tracker
+	bytesPerSecond int // Of PCM16 audio in the transcription format
+}
+
+// newMeteredProvider wraps a provider to account for its usage
+func newMeteredProvider(provider Provider, tracker *usage.Tracker, config Config) Provider {
+	return &meteredProvider{
+		Provider:       provider,
+		tracker:        tracker,
+		bytesPerSecond: config.FFmpegSampleRate * config.FFmpegChannels * 2,
+	}
+}
+
+// Connect opens a session whose audio is accounted for
+func (p *meteredProvider) Connect(ctx context.Context, vocabulary []string) (ProviderSession, error) {
+	session, err := p.Provider.Connect(ctx, vocabulary)
+	if err != nil {
+		return nil, err
+	}
+	p.tracker.Record(usage.SubsystemTranscription, p.Model(), usage.Usage{Requests: 1})
+
+	metered := &meteredSession{
+		ProviderSession: session,
+		tracker:         p.tracker,
+		model:           p.Model(),
+		bytesPerSecond:  p.bytesPerSecond,
+	}
+	// Keep live vocabulary updates available to processors
+	if updater, ok := session.(VocabularyUpdater); ok {
+		return &meteredVocabularySession{meteredSession: metered, updater: updater}, nil
+	}
+	return metered, nil
+}
+
+// meteredSession accounts for the audio sent to a session
+type meteredSession struct {
+	ProviderSession
+	tracker        *usage.Tracker
+	model          string
+	bytesPerSecond int
+}
+
+// SendAudio sends audio and accounts for its duration once it's sent
+func (s *meteredSession) SendAudio(pcm []byte) error {
+	if err := s.ProviderSession.SendAudio(pcm); err != nil {
+		return err
+	}
+	if s.bytesPerSecond > 0 {
+		seconds := float64(len(pcm)) / float64(s.bytesPerSecond)
+		s.tracker.Record(usage.SubsystemTranscription, s.model, usage.Usage{AudioSeconds: seconds})
+	}
+	return nil
+}
+
+// meteredVocabularySession is a metered session whose vocabulary can change while it's open
+type meteredVocabularySession struct {
+	*meteredSession
+	updater VocabularyUpdater
+}
+
+// UpdateVocabulary updates the vocabulary of the wrapped session
+func (s *meteredVocabularySession) UpdateVocabulary(vocabulary []string) error {
+	return s.updater.UpdateVocabulary(vocabulary)
+}
@@ -2,18 +2,28 @@ package transcription
 
 import (
 	"time"
+
+	"github.com/yegors/co-atc/internal/storage/sqlite"
 )
 
 // TranscriptionEvent represents a transcription event
 type TranscriptionEvent struct {
-	Type      string    // "delta" or "completed"
-	Text      string    // The transcription text
-	Timestamp time.Time // When the event occurred
+	Type          string        // "delta" or "completed"
+	Text          string        // The transcription text
+	Timestamp     time.Time     // When the event occurred
+	CorrelationID string        // Correlation ID of the transmission
+	AudioStart    *time.Time    // When the transmission started on the frequency (nil if unknown)
+	AudioEnd      *time.Time    // When the transmission ended on the frequency (nil if unknown)
+	Confidence    *float64      // Confidence of the transcript (0-1, nil if unknown)
+	AudioDelay    time.Duration // How far the times were moved back from when the audio arrived
+	Words         []sqlite.TranscriptionWord
 }
 
 // Config represents the configuration for the transcription service
 type Config struct {
+	Provider              string // Speech-to-text provider: "openai" (default) or "deepgram"
 	OpenAIAPIKey          string
+	OpenAIBaseURL         string // OpenAI API base URL (empty = OpenAI's API)
 	Model                 string
 	Language              string
 	NoiseReduction        string
@@ -32,7 +42,21 @@ type Config struct {
 	RetryMaxAttempts      int
 	RetryInitialBackoffMs int
 	RetryMaxBackoffMs     int
+	ConnectsPerMinute     int // New OpenAI sessions per minute across all frequencies (0 = no limit)
+	MaxConcurrentSessions int // Most frequencies transcribed at once, one realtime session each (0 = no limit)
+	WorkerRestartSec      int // Wait before restarting a failed frequency worker, doubled for each failure in a row
+	WorkerMaxRestartSec   int // Longest wait before restarting a failed frequency worker
 	PromptPath            string
-	Prompt                string // Loaded from PromptPath
-	TimeoutSeconds        int    // HTTP timeout for OpenAI API requests
+	Prompt                string  // Loaded from PromptPath
+	TimeoutSeconds        int     // HTTP timeout for OpenAI API requests
+	PreRollMs             int     // Recent audio replayed into a new session after a reconnect (0 = default, negative = off)
+	SilenceGating         bool    // Only stream audio to OpenAI while a transmission is detected locally
+	SilenceThresholdDB    float64 // RMS level in dBFS that counts as a transmission
+	SilenceHangMs         int     // Audio streamed after a transmission ends
+	SilencePaddingMs      int     // Audio streamed before a transmission starts
+	VocabularyBiasing     bool    // Bias transcription toward the callsigns, runways and fixes currently in use
+	VocabularyMaxTerms    int     // Maximum number of terms passed to the provider
+	VocabularyRefreshSec  int     // How often the terms are rebuilt from the airspace
+	SplitTurns            bool    // Store one transcription per transmission or speaker within a transcript
+	Deepgram              DeepgramConfig
 }
@@ -20,6 +20,7 @@ import (
 type OpenAIClient struct {
 	apiKey     string
 	model      string
+	baseURL    string // REST base URL; the WebSocket URL is derived from it
 	httpClient *http.Client
 	logger     *logger.Logger
 }
@@ -32,21 +33,29 @@ type OpenAIWebSocketConn struct {
 	closeChan chan struct{}
 }
 
-// NewOpenAIClient creates a new OpenAI client
-func NewOpenAIClient(apiKey, model string, timeoutSeconds int, logger *logger.Logger) *OpenAIClient {
+// DefaultOpenAIBaseURL is the base URL of OpenAI's API
+const DefaultOpenAIBaseURL = "https://api.openai.com/v1"
+
+// NewOpenAIClient creates a new OpenAI client. An empty baseURL uses OpenAI's API.
+func NewOpenAIClient(apiKey, model, baseURL string, timeoutSeconds int, logger *logger.Logger) *OpenAIClient {
 	timeout := time.Duration(timeoutSeconds) * time.Second
 	if timeout <= 0 {
 		timeout = 120 * time.Second // Default to 2 minutes if not specified
 	}
 
 	if apiKey == "" {
-		logger.Warn("OpenAI API key is empty - transcription and post-processing features will not work")
+		logger.Warn("OpenAI API key is empty - transcription will not work")
+	}
+
+	if baseURL == "" {
+		baseURL = DefaultOpenAIBaseURL
 	}
 
 	return &OpenAIClient{
-		apiKey: apiKey,
-		model:  model,
-		logger: logger.Named("openai"),
+		apiKey:  apiKey,
+		model:   model,
+		baseURL: strings.TrimSuffix(baseURL, "/"),
+		logger:  logger.Named("openai"),
 		httpClient: &http.Client{
 			Timeout: timeout,
 		},
@@ -83,6 +92,7 @@ func (c *OpenAIClient) CreateSession(ctx context.Context, config Config) (string
 	}
 
 	type TranscriptionSessionRequest struct {
+		Include                  []string                  `json:"include,omitempty"`
 		InputAudioFormat         string                    `json:"input_audio_format"`
 		InputAudioTranscription  *InputAudioTranscription  `json:"input_audio_transcription"`
 		InputAudioNoiseReduction *InputAudioNoiseReduction `json:"input_audio_noise_reduction,omitempty"`
@@ -91,6 +101,8 @@ func (c *OpenAIClient) CreateSession(ctx context.Context, config Config) (string
 
 	// Create the request body
 	reqBody := TranscriptionSessionRequest{
+		// Token log probabilities give each transcript a confidence
+		Include:          []string{"item.input_audio_transcription.logprobs"},
 		InputAudioFormat: "pcm16",
 		InputAudioTranscription: &InputAudioTranscription{
 			Model:    c.model,
@@ -137,7 +149,7 @@ func (c *OpenAIClient) CreateSession(ctx context.Context, config Config) (string
 	}
 
 	// Create request
-	req, err := http.NewRequestWithContext(ctx, "POST", "https://api.openai.com/v1/realtime/transcription_sessions", bytes.NewBuffer(jsonData))
+	req, err := http.NewRequestWithContext(ctx, "POST", c.baseURL+"/realtime/transcription_sessions", bytes.NewBuffer(jsonData))
 	if err != nil {
 		return "", "", fmt.Errorf("failed to create request: %w", err)
 	}
@@ -155,6 +167,9 @@ func (c *OpenAIClient) CreateSession(ctx context.Context, config Config) (string
 	defer resp.Body.Close()
 
 	// Check response status
+	if resp.StatusCode == http.StatusTooManyRequests {
+		return "", "", ErrRateLimited
+	}
 	if resp.StatusCode != http.StatusOK {
 		bodyBytes, _ := io.ReadAll(resp.Body)
 		return "", "", fmt.Errorf("unexpected status code: %d, response: %s", resp.StatusCode, string(bodyBytes))
@@ -193,10 +208,18 @@ func (c *OpenAIClient) CreateSession(ctx context.Context, config Config) (string
 	return result.SessionID, result.ClientSecret.Value, nil
 }
 
+// webSocketBaseURL returns the base URL with the WebSocket scheme matching its HTTP scheme
+func (c *OpenAIClient) webSocketBaseURL() string {
+	if rest, ok := strings.CutPrefix(c.baseURL, "http://"); ok {
+		return "ws://" + rest
+	}
+	return "wss://" + strings.TrimPrefix(c.baseURL, "https://")
+}
+
 // ConnectWebSocket establishes a WebSocket connection to the transcription API with reconnection logic
 func (c *OpenAIClient) ConnectWebSocket(ctx context.Context, sessionID, clientSecret string) (*OpenAIWebSocketConn, error) {
 	// Create WebSocket URL
-	wsURL := fmt.Sprintf("wss://api.openai.com/v1/realtime?session_id=%s", url.QueryEscape(sessionID))
+	wsURL := fmt.Sprintf("%s/realtime?session_id=%s", c.webSocketBaseURL(), url.QueryEscape(sessionID))
 	c.logger.Debug("Connecting to OpenAI WebSocket", logger.String("url", wsURL))
 
 	// Create WebSocket dialer
@@ -290,262 +313,3 @@ func (ws *OpenAIWebSocketConn) Close() error {
 	close(ws.closeChan)
 	return ws.conn.Close()
 }
-
-// PostProcessTranscription sends a transcription to OpenAI for post-processing
-func (c *OpenAIClient) PostProcessTranscription(ctx context.Context, content string, systemPrompt string, model string) (*PostProcessingResult, error) {
-	// Check if OpenAI API key is provided - fail fast if missing
-	if c.apiKey == "" {
-		return nil, fmt.Errorf("OpenAI API key is required for post-processing")
-	}
-
-	c.logger.Debug("Post-processing transcription",
-		logger.String("content", content),
-		logger.String("model", model))
-
-	// Create request body
-	type Message struct {
-		Role    string `json:"role"`
-		Content string `json:"content"`
-	}
-
-	type Request struct {
-		Model       string    `json:"model"`
-		Messages    []Message `json:"messages"`
-		MaxTokens   int       `json:"max_tokens,omitempty"`
-		Temperature float64   `json:"temperature"`
-	}
-
-	// Create messages
-	messages := []Message{
-		{
-			Role:    "system",
-			Content: systemPrompt,
-		},
-		{
-			Role:    "user",
-			Content: content,
-		},
-	}
-
-	// Create request
-	request := Request{
-		Model:       model,
-		Messages:    messages,
-		MaxTokens:   2048, // Adjust as needed
-		Temperature: 0.0,  // Set temperature to 0 for deterministic output
-	}
-
-	// Marshal request to JSON
-	jsonData, err := json.Marshal(request)
-	if err != nil {
-		return nil, fmt.Errorf("failed to marshal request: %w", err)
-	}
-
-	// Create HTTP request
-	req, err := http.NewRequestWithContext(ctx, "POST", "https://api.openai.com/v1/chat/completions", bytes.NewBuffer(jsonData))
-	if err != nil {
-		return nil, fmt.Errorf("failed to create request: %w", err)
-	}
-
-	// Set headers
-	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", c.apiKey))
-
-	// Execute request
-	resp, err := c.httpClient.Do(req)
-	if err != nil {
-		return nil, fmt.Errorf("failed to execute request: %w", err)
-	}
-	defer resp.Body.Close()
-
-	// Check response status
-	if resp.StatusCode != http.StatusOK {
-		bodyBytes, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("unexpected status code: %d, response: %s", resp.StatusCode, string(bodyBytes))
-	}
-
-	// Parse response
-	var result struct {
-		Choices []struct {
-			Message struct {
-				Content string `json:"content"`
-			} `json:"message"`
-		} `json:"choices"`
-	}
-
-	// Read response body
-	bodyBytes, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return nil, fmt.Errorf("failed to read response body: %w", err)
-	}
-
-	// Parse response
-	if err := json.Unmarshal(bodyBytes, &result); err != nil {
-		return nil, fmt.Errorf("failed to parse response: %w", err)
-	}
-
-	// Check if we have choices
-	if len(result.Choices) == 0 {
-		return nil, fmt.Errorf("no choices in response")
-	}
-
-	// Parse the content as JSON
-	var processingResult PostProcessingResult
-	if err := json.Unmarshal([]byte(result.Choices[0].Message.Content), &processingResult); err != nil {
-		return nil, fmt.Errorf("failed to parse processing result: %w", err)
-	}
-
-	return &processingResult, nil
-}
-
-// PostProcessBatch sends a batch of transcriptions to OpenAI for post-processing
-func (c *OpenAIClient) PostProcessBatch(ctx context.Context, systemPrompt string, userInput string, model string) ([]TranscriptionBatch, error) {
-	// Check if OpenAI API key is provided - fail fast if missing
-	if c.apiKey == "" {
-		return nil, fmt.Errorf("OpenAI API key is required for post-processing")
-	}
-
-	c.logger.Debug("Post-processing batch of transcriptions",
-		logger.String("model", model),
-		logger.Int("system_prompt_length", len(systemPrompt)),
-		logger.Int("user_input_length", len(userInput)))
-
-	// Create request body
-	type Message struct {
-		Role    string `json:"role"`
-		Content string `json:"content"`
-	}
-
-	type Request struct {
-		Model       string    `json:"model"`
-		Messages    []Message `json:"messages"`
-		MaxTokens   int       `json:"max_tokens,omitempty"`
-		Temperature float64   `json:"temperature"`
-	}
-
-	// Create messages
-	messages := []Message{
-		{
-			Role:    "system",
-			Content: systemPrompt,
-		},
-		{
-			Role:    "user",
-			Content: userInput,
-		},
-	}
-
-	// Create request
-	request := Request{
-		Model:       model,
-		Messages:    messages,
-		MaxTokens:   4096, // Increased for batch processing
-		Temperature: 0.0,  // Set temperature to 0 for deterministic output
-	}
-
-	// Marshal request to JSON
-	jsonData, err := json.Marshal(request)
-	if err != nil {
-		return nil, fmt.Errorf("failed to marshal request: %w", err)
-	}
-
-	// Log the full request at info level for auditing
-	prettyRequest, _ := json.MarshalIndent(request, "", "  ")
-	c.logger.Debug("OpenAI post-processing request",
-		logger.String("model", model),
-		logger.Int("system_prompt_length", len(systemPrompt)),
-		logger.Int("user_input_length", len(userInput)),
-		logger.String("system_prompt", systemPrompt),
-		logger.String("user_input", userInput),
-		logger.String("full_request", string(prettyRequest)))
-
-	// Create HTTP request
-	req, err := http.NewRequestWithContext(ctx, "POST", "https://api.openai.com/v1/chat/completions", bytes.NewBuffer(jsonData))
-	if err != nil {
-		return nil, fmt.Errorf("failed to create request: %w", err)
-	}
-
-	// Set headers
-	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", c.apiKey))
-
-	// Execute request
-	resp, err := c.httpClient.Do(req)
-	if err != nil {
-		return nil, fmt.Errorf("failed to execute request: %w", err)
-	}
-	defer resp.Body.Close()
-
-	// Check response status
-	if resp.StatusCode != http.StatusOK {
-		bodyBytes, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("unexpected status code: %d, response: %s", resp.StatusCode, string(bodyBytes))
-	}
-
-	// Parse response
-	var result struct {
-		Choices []struct {
-			Message struct {
-				Content string `json:"content"`
-			} `json:"message"`
-		} `json:"choices"`
-	}
-
-	// Read response body
-	bodyBytes, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return nil, fmt.Errorf("failed to read response body: %w", err)
-	}
-
-	// Log the response at info level for auditing
-	c.logger.Info("OpenAI post-processing response",
-		logger.Int("status_code", resp.StatusCode),
-		logger.Int("response_length", len(bodyBytes)),
-		logger.String("response", string(bodyBytes)))
-
-	// Parse response
-	if err := json.Unmarshal(bodyBytes, &result); err != nil {
-		return nil, fmt.Errorf("failed to parse response: %w", err)
-	}
-
-	// Check if we have choices
-	if len(result.Choices) == 0 {
-		return nil, fmt.Errorf("no choices in response")
-	}
-
-	// Extract the content from the response
-	content := result.Choices[0].Message.Content
-
-	// Find the JSON array in the content (in case there's additional text)
-	startIdx := strings.Index(content, "[")
-	endIdx := strings.LastIndex(content, "]")
-
-	if startIdx == -1 || endIdx == -1 || startIdx >= endIdx {
-		// Log error with full response content for debugging
-		c.logger.Error("Failed to find JSON array in OpenAI response - this indicates the LLM is not following the expected format",
-			logger.String("full_response", content),
-			logger.String("model", model))
-		return nil, fmt.Errorf("OpenAI response does not contain valid JSON array: %s", content)
-	}
-
-	jsonContent := content[startIdx : endIdx+1]
-
-	// Parse the content as JSON array of TranscriptionBatch
-	var results []TranscriptionBatch
-	if err := json.Unmarshal([]byte(jsonContent), &results); err != nil {
-		// Log error with extracted JSON content for debugging
-		c.logger.Error("Failed to unmarshal OpenAI response as JSON array",
-			logger.String("error", err.Error()),
-			logger.String("extracted_json", jsonContent),
-			logger.String("full_response", content),
-			logger.String("model", model))
-		return nil, fmt.Errorf("failed to parse OpenAI response as JSON: %w", err)
-	}
-
-	// Log successful parsing with result count
-	c.logger.Debug("Successfully parsed OpenAI response",
-		logger.Int("result_count", len(results)),
-		logger.String("model", model))
-
-	return results, nil
-}
@@ -0,0 +1,219 @@
+package transcription
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/yegors/co-atc/pkg/logger"
+)
+
+// openAIProvider streams audio to OpenAI's Realtime Transcription API
+type openAIProvider struct {
+	client  *OpenAIClient
+	config  Config
+	policy  RetryPolicy
+	limiter *connectLimiter
+	logger  *logger.Logger
+}
+
+// openAISession is a transcription session on OpenAI's Realtime API
+type openAISession struct {
+	id     string
+	conn   *OpenAIWebSocketConn
+	config Config
+	model  string
+}
+
+// newOpenAIProvider creates the OpenAI provider
+func newOpenAIProvider(config Config, logger *logger.Logger) *openAIProvider {
+	policy := retryPolicyWithOverrides(RetryPolicy{
+		MaxReconnectAttempts: 5,
+		InitialBackoff:       time.Second,
+		MaxBackoff:           time.Minute,
+		// OpenAI sessions expire after 30 minutes, so refresh at 25 minutes to be safe
+		MaxSessionDuration: 25 * time.Minute,
+	}, config.RetryMaxAttempts, config.RetryInitialBackoffMs, config.RetryMaxBackoffMs)
+	policy.ConnectsPerMinute = config.ConnectsPerMinute
+
+	return &openAIProvider{
+		client:  NewOpenAIClient(config.OpenAIAPIKey, config.Model, config.OpenAIBaseURL, config.TimeoutSeconds, logger),
+		config:  config,
+		policy:  policy,
+		limiter: newConnectLimiter(policy.ConnectsPerMinute),
+		logger:  logger.Named("openai-stt"),
+	}
+}
+
+// Name returns the provider name
+func (p *openAIProvider) Name() string {
+	return ProviderOpenAI
+}
+
+// Model returns the transcription model
+func (p *openAIProvider) Model() string {
+	return p.config.Model
+}
+
+// RetryPolicy returns how sessions are reconnected
+func (p *openAIProvider) RetryPolicy() RetryPolicy {
+	return p.policy
+}
+
+// Connect creates a transcription session and connects to its WebSocket
+func (p *openAIProvider) Connect(ctx context.Context, vocabulary []string) (ProviderSession, error) {
+	if err := p.limiter.Wait(ctx); err != nil {
+		return nil, err
+	}
+
+	config := p.config
+	config.Prompt = vocabularyPrompt(p.config.Prompt, vocabulary)
+	sessionID, clientSecret, err := p.client.CreateSession(ctx, config)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create transcription session: %w", err)
+	}
+
+	conn, err := p.client.ConnectWebSocket(ctx, sessionID, clientSecret)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to WebSocket: %w", err)
+	}
+
+	return &openAISession{id: sessionID, conn: conn, config: p.config, model: p.client.model}, nil
+}
+
+// vocabularyPrompt appends the vocabulary terms to the transcription prompt. The model
+// treats the prompt as context, so listing the terms makes them the likely spelling.
+func vocabularyPrompt(prompt string, vocabulary []string) string {
+	if len(vocabulary) == 0 {
+		return prompt
+	}
+	terms := "Callsigns, runways and fixes likely to be heard: " + strings.Join(vocabulary, ", ") + "."
+	if prompt = strings.TrimSpace(prompt); prompt == "" {
+		return terms
+	}
+	return prompt + "\n\n" + terms
+}
+
+// ID returns the OpenAI session ID
+func (s *openAISession) ID() string {
+	return s.id
+}
+
+// SendAudio appends audio to the session's input buffer
+func (s *openAISession) SendAudio(pcm []byte) error {
+	message := map[string]interface{}{
+		"type":  "input_audio_buffer.append",
+		"audio": base64.StdEncoding.EncodeToString(pcm),
+	}
+
+	data, err := json.Marshal(message)
+	if err != nil {
+		return fmt.Errorf("failed to marshal audio chunk message: %w", err)
+	}
+
+	return s.conn.Send(string(data))
+}
+
+// UpdateVocabulary replaces the vocabulary in the session's prompt
+func (s *openAISession) UpdateVocabulary(vocabulary []string) error {
+	message := map[string]interface{}{
+		"type": "transcription_session.update",
+		"session": map[string]interface{}{
+			"input_audio_transcription": map[string]interface{}{
+				"model":    s.model,
+				"language": s.config.Language,
+				"prompt":   vocabularyPrompt(s.config.Prompt, vocabulary),
+			},
+		},
+	}
+
+	data, err := json.Marshal(message)
+	if err != nil {
+		return fmt.Errorf("failed to marshal session update message: %w", err)
+	}
+
+	return s.conn.Send(string(data))
+}
+
+// Receive reads the next event from the session
+func (s *openAISession) Receive() (ProviderEvent, error) {
+	message, err := s.conn.Receive()
+	if err != nil {
+		return ProviderEvent{}, err
+	}
+
+	var event struct {
+		Type         string   `json:"type"`
+		ItemID       string   `json:"item_id"`
+		AudioStartMs *float64 `json:"audio_start_ms"`
+		AudioEndMs   *float64 `json:"audio_end_ms"`
+		Delta        string   `json:"delta"`
+		Transcript   *string  `json:"transcript"`
+		Logprobs     []struct {
+			Logprob float64 `json:"logprob"`
+		} `json:"logprobs"`
+		Error *struct {
+			Code    string `json:"code"`
+			Message string `json:"message"`
+		} `json:"error"`
+	}
+	if err := json.Unmarshal([]byte(message), &event); err != nil {
+		return ProviderEvent{Type: EventError, Text: fmt.Sprintf("error parsing event: %v", err)}, nil
+	}
+
+	switch event.Type {
+	case "input_audio_buffer.speech_started":
+		return ProviderEvent{Type: EventSpeechStarted, ItemID: event.ItemID, AudioOffset: msOffset(event.AudioStartMs)}, nil
+
+	case "input_audio_buffer.speech_stopped":
+		return ProviderEvent{Type: EventSpeechStopped, ItemID: event.ItemID, AudioOffset: msOffset(event.AudioEndMs)}, nil
+
+	case "conversation.item.input_audio_transcription.delta":
+		return ProviderEvent{Type: EventDelta, ItemID: event.ItemID, Text: event.Delta}, nil
+
+	case "conversation.item.input_audio_transcription.completed":
+		if event.Transcript == nil {
+			return ProviderEvent{Type: EventError, Text: "completed event missing transcript field"}, nil
+		}
+		completed := ProviderEvent{Type: EventCompleted, ItemID: event.ItemID, Text: *event.Transcript}
+		// The confidence is the geometric mean of the token probabilities
+		if len(event.Logprobs) > 0 {
+			sum := 0.0
+			for _, token := range event.Logprobs {
+				sum += token.Logprob
+			}
+			confidence := math.Exp(sum / float64(len(event.Logprobs)))
+			completed.Confidence = &confidence
+		}
+		return completed, nil
+
+	case "error":
+		if event.Error == nil {
+			return ProviderEvent{Type: EventError, Text: "error event missing error field"}, nil
+		}
+		if event.Error.Code == "session_expired" {
+			return ProviderEvent{Type: EventSessionExpired, Text: event.Error.Message}, nil
+		}
+		return ProviderEvent{Type: EventError, Text: event.Error.Message}, nil
+	}
+
+	return ProviderEvent{Type: EventIgnored}, nil
+}
+
+// Close closes the session's WebSocket
+func (s *openAISession) Close() error {
+	return s.conn.Close()
+}
+
+// msOffset converts an optional millisecond offset
+func msOffset(ms *float64) *time.Duration {
+	if ms == nil {
+		return nil
+	}
+	offset := time.Duration(*ms * float64(time.Millisecond))
+	return &offset
+}
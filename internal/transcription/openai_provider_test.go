@@ -0,0 +1,25 @@
+package transcription
+
+import "testing"
+
+func TestVocabularyPrompt(t *testing.T) {
+	vocabulary := []string{"ACA123", "24R"}
+	terms := "Callsigns, runways and fixes likely to be heard: ACA123, 24R."
+
+	tests := []struct {
+		prompt string
+		want   string
+	}{
+		{"", terms},
+		{"  \n", terms},
+		{"ATC radio. ", "ATC radio.\n\n" + terms},
+	}
+	for _, tt := range tests {
+		if got := vocabularyPrompt(tt.prompt, vocabulary); got != tt.want {
+			t.Errorf("vocabularyPrompt(%q) = %q, want %q", tt.prompt, got, tt.want)
+		}
+	}
+	if got := vocabularyPrompt("ATC radio.", nil); got != "ATC radio." {
+		t.Errorf("vocabularyPrompt without vocabulary = %q, want the prompt unchanged", got)
+	}
+}
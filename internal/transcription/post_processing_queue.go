@@ -0,0 +1,197 @@
+package transcription
+
+import (
+	"time"
+
+	"github.com/yegors/co-atc/internal/llm"
+	"github.com/yegors/co-atc/internal/storage/sqlite"
+	"github.com/yegors/co-atc/pkg/logger"
+)
+
+// PostProcessingStats reports how post-processing is keeping up
+type PostProcessingStats struct {
+	Backlog              int        `json:"backlog"`                 // Transcriptions waiting to be processed
+	InFlightBatches      int        `json:"in_flight_batches"`       // Batches being processed
+	MaxConcurrentBatches int        `json:"max_concurrent_batches"`  // Limit on batches in flight
+	BackoffUntil         *time.Time `json:"backoff_until,omitempty"` // Set while paused after a rate limit
+	RateLimits           int64      `json:"rate_limits"`             // Batches rate-limited or refused as overloaded since startup
+	ProcessedBatches     int64      `json:"processed_batches"`       // Batches processed since startup
+	FailedBatches        int64      `json:"failed_batches"`          // Batches that failed for other reasons since startup
+}
+
+// batchJob is unprocessed transcriptions of one frequency, post-processed together
+type batchJob struct {
+	frequencyID string
+	records     []*sqlite.TranscriptionRecord
+}
+
+// runQueue dispatches batches until the post-processor stops. It looks for new
+// transcriptions every interval, and again as soon as a batch finishes, so a backlog drains
+// as fast as the LLM allows.
+func (p *PostProcessor) runQueue() {
+	defer p.wg.Done()
+
+	for {
+		if err := p.dispatch(); err != nil {
+			p.logger.Error("Failed to dispatch post-processing batches", logger.Error(err))
+		}
+
+		wait := p.processingInterval
+		p.queueMu.Lock()
+		if remaining := time.Until(p.backoffUntil); remaining > 0 && remaining < wait {
+			wait = remaining
+		}
+		p.queueMu.Unlock()
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-p.ctx.Done():
+			timer.Stop()
+			p.logger.Info("Post-processing queue stopped due to context cancellation")
+			return
+		case <-timer.C:
+		case <-p.wake:
+			timer.Stop()
+		}
+	}
+}
+
+// dispatch starts batches for as many frequencies as there are free slots, newest
+// transcriptions first so the live view is served before older transcriptions are
+// backfilled. A frequency has one batch in flight at a time, so each batch gets the
+// results of the one before it as context.
+func (p *PostProcessor) dispatch() error {
+	p.queueMu.Lock()
+	free := p.config.MaxConcurrentBatches - len(p.busy)
+	backingOff := time.Now().Before(p.backoffUntil)
+	busy := make([]string, 0, len(p.busy))
+	for frequencyID := range p.busy {
+		busy = append(busy, frequencyID)
+	}
+	p.queueMu.Unlock()
+
+	if free <= 0 || backingOff {
+		return nil
+	}
+
+	records, err := p.transcriptionStorage.GetUnprocessedTranscriptions(p.batchSize*free, busy)
+	if err != nil {
+		return err
+	}
+	if len(records) == 0 {
+		p.logger.Debug("No unprocessed transcriptions found")
+		return nil
+	}
+
+	for _, job := range groupBatchJobs(records, p.batchSize, free) {
+		p.queueMu.Lock()
+		p.busy[job.frequencyID] = true
+		p.queueMu.Unlock()
+
+		p.logger.Debug("Dispatching post-processing batch",
+			logger.String("frequency_id", job.frequencyID),
+			logger.Int("count", len(job.records)))
+
+		p.wg.Add(1)
+		go p.runJob(job)
+	}
+	return nil
+}
+
+// groupBatchJobs splits transcriptions, newest first, into up to maxJobs batches of one
+// frequency each. Batches are ordered by their newest transcription.
+func groupBatchJobs(records []*sqlite.TranscriptionRecord, batchSize, maxJobs int) []batchJob {
+	var jobs []batchJob
+	index := make(map[string]int)
+	for _, record := range records {
+		i, ok := index[record.FrequencyID]
+		if !ok {
+			if len(jobs) == maxJobs {
+				continue
+			}
+			i = len(jobs)
+			index[record.FrequencyID] = i
+			jobs = append(jobs, batchJob{frequencyID: record.FrequencyID})
+		}
+		if len(jobs[i].records) < batchSize {
+			jobs[i].records = append(jobs[i].records, record)
+		}
+	}
+	return jobs
+}
+
+// runJob processes a batch and backs the queue off if the LLM rate-limited it
+func (p *PostProcessor) runJob(job batchJob) {
+	defer p.wg.Done()
+
+	err := p.processFrequencyBatch(job.frequencyID, job.records)
+	retryAfter, rateLimited := llm.Retryable(err)
+
+	p.queueMu.Lock()
+	delete(p.busy, job.frequencyID)
+	var delay time.Duration
+	switch {
+	case rateLimited:
+		p.rateLimits++
+		delay = p.config.BackoffInitial << min(p.rateLimitStreak, 16)
+		if delay > p.config.BackoffMax {
+			delay = p.config.BackoffMax
+		}
+		if retryAfter > delay {
+			delay = retryAfter
+		}
+		p.rateLimitStreak++
+		if until := time.Now().Add(delay); until.After(p.backoffUntil) {
+			p.backoffUntil = until
+		}
+	case err != nil:
+		p.rateLimitStreak = 0
+		p.failedBatches++
+	default:
+		p.rateLimitStreak = 0
+		p.processedBatches++
+	}
+	streak := p.rateLimitStreak
+	p.queueMu.Unlock()
+
+	if rateLimited {
+		p.logger.Warn("LLM rate-limited post-processing, backing off",
+			logger.String("frequency_id", job.frequencyID),
+			logger.Duration("delay", delay),
+			logger.Int("consecutive", streak),
+			logger.Error(err))
+	} else if err != nil {
+		p.logger.Error("Error processing batch",
+			logger.String("frequency_id", job.frequencyID),
+			logger.Error(err))
+	}
+
+	select {
+	case p.wake <- struct{}{}:
+	default:
+	}
+}
+
+// Stats returns the state of the post-processing queue
+func (p *PostProcessor) Stats() PostProcessingStats {
+	p.queueMu.Lock()
+	stats := PostProcessingStats{
+		InFlightBatches:      len(p.busy),
+		MaxConcurrentBatches: p.config.MaxConcurrentBatches,
+		RateLimits:           p.rateLimits,
+		ProcessedBatches:     p.processedBatches,
+		FailedBatches:        p.failedBatches,
+	}
+	if time.Now().Before(p.backoffUntil) {
+		until := p.backoffUntil
+		stats.BackoffUntil = &until
+	}
+	p.queueMu.Unlock()
+
+	backlog, err := p.transcriptionStorage.CountUnprocessedTranscriptions()
+	if err != nil {
+		p.logger.Error("Failed to count unprocessed transcriptions", logger.Error(err))
+	}
+	stats.Backlog = backlog
+	return stats
+}
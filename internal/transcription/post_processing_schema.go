@@ -0,0 +1,148 @@
+package transcription
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/yegors/co-atc/internal/llm"
+)
+
+// Speaker types and clearance types post-processing may report
+var (
+	validSpeakerTypes   = []string{"ATC", "PILOT"}
+	validClearanceTypes = []string{"takeoff", "landing", "approach", "taxi", "altitude", "heading"}
+)
+
+// batchResponse is the reply post-processing asks for when the reply is constrained to a
+// schema. Strict schemas need an object at the top level, so the batch is wrapped.
+type batchResponse struct {
+	Transcriptions []TranscriptionBatch `json:"transcriptions"`
+}
+
+// batchResponseSchema is the JSON schema of batchResponse. Every property is required, as
+// strict schemas demand; an empty string stands for a missing callsign or runway, and zero
+// for a missing altitude or heading.
+var batchResponseSchema = &llm.Schema{
+	Name:        "processed_transcriptions",
+	Description: "The batch of transmissions with content_processed, speaker_type, callsign and clearances filled in",
+	Definition: schemaObject(map[string]interface{}{
+		"transcriptions": map[string]interface{}{
+			"type": "array",
+			"items": schemaObject(map[string]interface{}{
+				"id":                map[string]interface{}{"type": "integer"},
+				"content":           map[string]interface{}{"type": "string"},
+				"content_processed": map[string]interface{}{"type": "string"},
+				"speaker_type":      map[string]interface{}{"type": "string", "enum": validSpeakerTypes},
+				"callsign":          map[string]interface{}{"type": "string"},
+				"timestamp":         map[string]interface{}{"type": "string"},
+				"clearances": map[string]interface{}{
+					"type": "array",
+					"items": schemaObject(map[string]interface{}{
+						"callsign": map[string]interface{}{"type": "string"},
+						"type":     map[string]interface{}{"type": "string", "enum": validClearanceTypes},
+						"text":     map[string]interface{}{"type": "string"},
+						"runway":   map[string]interface{}{"type": "string"},
+						"altitude": map[string]interface{}{"type": "integer"},
+						"heading":  map[string]interface{}{"type": "integer"},
+					}),
+				},
+			}),
+		},
+	}),
+}
+
+// schemaObject returns the schema of an object with the given properties, all of them required
+func schemaObject(properties map[string]interface{}) map[string]interface{} {
+	required := make([]string, 0, len(properties))
+	for name := range properties {
+		required = append(required, name)
+	}
+	sort.Strings(required) // Keep requests identical from one batch to the next
+	return map[string]interface{}{
+		"type":                 "object",
+		"properties":           properties,
+		"required":             required,
+		"additionalProperties": false,
+	}
+}
+
+// validateBatchResults checks the results for the transcriptions being processed. It
+// returns the valid results by ID and a description of each problem found, which is fed
+// back to the model when the batch is repaired. Results for other IDs, such as context
+// transcriptions, are ignored.
+func validateBatchResults(results []TranscriptionBatch, pending map[int64]bool) (map[int64]TranscriptionBatch, []string) {
+	valid := make(map[int64]TranscriptionBatch)
+	var problems []string
+
+	for _, result := range results {
+		if !pending[result.ID] {
+			continue
+		}
+		if _, ok := valid[result.ID]; ok {
+			problems = append(problems, fmt.Sprintf("id %d: returned more than once", result.ID))
+			continue
+		}
+
+		var resultProblems []string
+		result.ContentProcessed = strings.TrimSpace(result.ContentProcessed)
+		if result.ContentProcessed == "" {
+			resultProblems = append(resultProblems, "content_processed is empty")
+		}
+		result.SpeakerType = strings.ToUpper(strings.TrimSpace(result.SpeakerType))
+		if !contains(validSpeakerTypes, result.SpeakerType) {
+			resultProblems = append(resultProblems, fmt.Sprintf("speaker_type %q is not ATC or PILOT", result.SpeakerType))
+		}
+		for i, clearance := range result.Clearances {
+			if !contains(validClearanceTypes, clearance.Type) {
+				resultProblems = append(resultProblems, fmt.Sprintf("clearance %d has type %q, not takeoff, landing, approach, taxi, altitude or heading", i+1, clearance.Type))
+			}
+			if clearance.Type == "altitude" && (clearance.Altitude < 100 || clearance.Altitude > 60000) {
+				resultProblems = append(resultProblems, fmt.Sprintf("clearance %d is an altitude clearance without an altitude in feet", i+1))
+			}
+			if clearance.Type == "heading" && (clearance.Heading < 1 || clearance.Heading > 360) {
+				resultProblems = append(resultProblems, fmt.Sprintf("clearance %d is a heading clearance without a heading from 1 to 360", i+1))
+			}
+			if strings.TrimSpace(clearance.Callsign) == "" {
+				resultProblems = append(resultProblems, fmt.Sprintf("clearance %d has no callsign", i+1))
+			}
+			if strings.TrimSpace(clearance.Text) == "" {
+				resultProblems = append(resultProblems, fmt.Sprintf("clearance %d has no text", i+1))
+			}
+		}
+
+		if len(resultProblems) > 0 {
+			problems = append(problems, fmt.Sprintf("id %d: %s", result.ID, strings.Join(resultProblems, "; ")))
+			continue
+		}
+		valid[result.ID] = result
+	}
+
+	for id := range pending {
+		if _, ok := valid[id]; ok {
+			continue
+		}
+		returned := false
+		for _, result := range results {
+			if result.ID == id {
+				returned = true
+				break
+			}
+		}
+		if !returned {
+			problems = append(problems, fmt.Sprintf("id %d: missing from the reply", id))
+		}
+	}
+
+	return valid, problems
+}
+
+// contains reports whether a list holds a value
+func contains(list []string, value string) bool {
+	for _, item := range list {
+		if item == value {
+			return true
+		}
+	}
+	return false
+}
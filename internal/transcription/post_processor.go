@@ -5,10 +5,13 @@ import (
 	"encoding/json"
 	"fmt"
 	"sort"
+	"strings"
 	"sync"
 	"time"
 
+	"github.com/yegors/co-atc/internal/llm"
 	"github.com/yegors/co-atc/internal/storage/sqlite"
+	"github.com/yegors/co-atc/internal/usage"
 	"github.com/yegors/co-atc/internal/websocket"
 	"github.com/yegors/co-atc/pkg/logger"
 )
@@ -16,25 +19,54 @@ import (
 // PostProcessingConfig represents configuration for post-processing
 type PostProcessingConfig struct {
 	Enabled               bool
-	Model                 string
+	LLM                   llm.Config // Model batches are processed with, including its provider and timeout
 	IntervalSeconds       int
 	BatchSize             int
 	ContextTranscriptions int
 	SystemPromptPath      string
-	TimeoutSeconds        int
+	RepairAttempts        int                                // Times invalid results are sent back to the model to fix
+	MaxConcurrentBatches  int                                // Batches in flight at once, each for a different frequency
+	BackoffInitial        time.Duration                      // Pause after a rate limit, doubled on each repeat
+	BackoffMax            time.Duration                      // Longest pause after repeated rate limits
+	Frequencies           map[string]FrequencyPostProcessing // Overrides by frequency ID
 }
 
-// PostProcessingResult represents the structured result from the LLM
-type PostProcessingResult struct {
-	ProcessedContent string                      `json:"processed_content"`
-	SpeakerType      string                      `json:"speaker_type,omitempty"`
-	Callsign         string                      `json:"callsign,omitempty"`
-	Clearances       []sqlite.ExtractedClearance `json:"clearances,omitempty"`
+// FrequencyPostProcessing overrides post-processing for one frequency. Tower, ground and
+// approach controllers issue different instructions in different phraseology.
+type FrequencyPostProcessing struct {
+	SystemPromptPath string   // Empty = the default prompt
+	Model            string   // Empty = the default model, on the same provider
+	ClearanceTypes   []string // Clearance types stored from this frequency (nil = all, empty = none)
 }
 
+// pipeline is how the transcriptions of a frequency are post-processed
+type pipeline struct {
+	systemPromptPath string
+	llm              llm.Provider
+	clearanceTypes   []string // nil = all
+}
+
+// postProcessingMaxTokens bounds the reply to a batch
+const postProcessingMaxTokens = 4096
+
 // TemplateRenderer is an interface for rendering templates with airspace data
 type TemplateRenderer interface {
 	RenderPostProcessorTemplate(templatePath string) (string, error)
+	CallsignResolver
+	VocabularySource
+}
+
+// CallsignResolver maps callsigns as extracted from transcriptions to the callsigns of
+// tracked aircraft, so transcriptions and clearances link to the aircraft they refer to
+type CallsignResolver interface {
+	CanonicalCallsign(callsign string) string
+	AircraftHex(callsign string) string
+}
+
+// VocabularySource provides the terms, as spoken, that transcription is biased toward:
+// the callsigns, runways and local fixes currently in use
+type VocabularySource interface {
+	TranscriptionVocabulary(maxTerms int) []string
 }
 
 // PostProcessor manages the post-processing of transcriptions
@@ -44,15 +76,28 @@ type PostProcessor struct {
 	transcriptionStorage *sqlite.TranscriptionStorage
 	aircraftStorage      *sqlite.AircraftStorage
 	clearanceStorage     *sqlite.ClearanceStorage
-	openaiClient         *OpenAIClient
+	llm                  llm.Provider
 	wsServer             *websocket.Server
 	templateRenderer     TemplateRenderer
+	usageTracker         *usage.Tracker // Accounts for LLM tokens (nil = not tracked)
 	logger               *logger.Logger
 	config               PostProcessingConfig
 	processingInterval   time.Duration
 	batchSize            int
 	wg                   sync.WaitGroup
-	frequencyNames       map[string]string // Map of frequency IDs to names
+	frequencyNames       *FrequencyNames // Map of frequency IDs to names
+	defaultPipeline      *pipeline
+	pipelines            map[string]*pipeline // Frequencies with overrides, by ID
+
+	// Work queue state, protected by queueMu
+	queueMu          sync.Mutex
+	busy             map[string]bool // Frequencies with a batch in flight
+	backoffUntil     time.Time       // No batches are dispatched before this after a rate limit
+	rateLimitStreak  int             // Consecutive batches rate-limited, for the backoff
+	rateLimits       int64
+	processedBatches int64
+	failedBatches    int64
+	wake             chan struct{} // Signals the dispatcher that a batch finished
 }
 
 // NewPostProcessor creates a new post-processor
@@ -61,12 +106,13 @@ func NewPostProcessor(
 	transcriptionStorage *sqlite.TranscriptionStorage,
 	aircraftStorage *sqlite.AircraftStorage,
 	clearanceStorage *sqlite.ClearanceStorage,
-	openaiClient *OpenAIClient,
+	llmProvider llm.Provider,
 	wsServer *websocket.Server,
 	templateRenderer TemplateRenderer,
+	usageTracker *usage.Tracker,
 	config PostProcessingConfig,
 	logger *logger.Logger,
-	frequencyNames map[string]string,
+	frequencyNames *FrequencyNames,
 ) (*PostProcessor, error) {
 	// Create context with cancellation
 	procCtx, procCancel := context.WithCancel(ctx)
@@ -78,48 +124,89 @@ func NewPostProcessor(
 		transcriptionStorage: transcriptionStorage,
 		aircraftStorage:      aircraftStorage,
 		clearanceStorage:     clearanceStorage,
-		openaiClient:         openaiClient,
+		llm:                  llmProvider,
 		wsServer:             wsServer,
 		templateRenderer:     templateRenderer,
+		usageTracker:         usageTracker,
 		logger:               logger.Named("post-processor"),
 		config:               config,
 		processingInterval:   time.Duration(config.IntervalSeconds) * time.Second,
 		batchSize:            config.BatchSize,
 		frequencyNames:       frequencyNames,
+		defaultPipeline: &pipeline{
+			systemPromptPath: config.SystemPromptPath,
+			llm:              llmProvider,
+		},
+		pipelines: make(map[string]*pipeline),
+		busy:      make(map[string]bool),
+		wake:      make(chan struct{}, 1),
+	}
+
+	// Frequencies that override the model share a provider per model
+	providers := map[string]llm.Provider{llmProvider.Model(): llmProvider}
+	for frequencyID, override := range config.Frequencies {
+		pl := &pipeline{
+			systemPromptPath: config.SystemPromptPath,
+			llm:              llmProvider,
+			clearanceTypes:   override.ClearanceTypes,
+		}
+		if override.SystemPromptPath != "" {
+			pl.systemPromptPath = override.SystemPromptPath
+		}
+		if override.Model != "" {
+			provider, ok := providers[override.Model]
+			if !ok {
+				llmConfig := config.LLM
+				llmConfig.Model = override.Model
+				var err error
+				if provider, err = llm.NewProvider(llmConfig, logger); err != nil {
+					procCancel()
+					return nil, fmt.Errorf("failed to create LLM provider for frequency %s: %w", frequencyID, err)
+				}
+				providers[override.Model] = provider
+			}
+			pl.llm = provider
+		}
+		processor.pipelines[frequencyID] = pl
+
+		clearanceTypes := "all"
+		if pl.clearanceTypes != nil {
+			clearanceTypes = strings.Join(pl.clearanceTypes, ",")
+		}
+		processor.logger.Info("Frequency has its own post-processing pipeline",
+			String("frequency_id", frequencyID),
+			String("system_prompt_path", pl.systemPromptPath),
+			String("model", pl.llm.Model()),
+			String("clearance_types", clearanceTypes))
 	}
 
 	return processor, nil
 }
 
-// Start starts the post-processing loop
+// pipelineFor returns the pipeline a frequency's transcriptions are processed with
+func (p *PostProcessor) pipelineFor(frequencyID string) *pipeline {
+	if pl, ok := p.pipelines[frequencyID]; ok {
+		return pl
+	}
+	return p.defaultPipeline
+}
+
+// Start starts the post-processing queue
 func (p *PostProcessor) Start() error {
 	if !p.config.Enabled {
 		p.logger.Info("Post-processing is disabled, not starting")
 		return nil
 	}
 
-	p.logger.Info("Starting post-processing loop",
+	p.logger.Info("Starting post-processing queue",
+		logger.String("provider", p.llm.Name()),
+		logger.String("model", p.llm.Model()),
 		logger.Int("interval_seconds", p.config.IntervalSeconds),
-		logger.Int("batch_size", p.batchSize))
+		logger.Int("batch_size", p.batchSize),
+		logger.Int("max_concurrent_batches", p.config.MaxConcurrentBatches))
 
 	p.wg.Add(1)
-	go func() {
-		defer p.wg.Done()
-		ticker := time.NewTicker(p.processingInterval)
-		defer ticker.Stop()
-
-		for {
-			select {
-			case <-p.ctx.Done():
-				p.logger.Info("Post-processing loop stopped due to context cancellation")
-				return
-			case <-ticker.C:
-				if err := p.processNextBatch(); err != nil {
-					p.logger.Error("Error processing batch", logger.Error(err))
-				}
-			}
-		}
-	}()
+	go p.runQueue()
 	return nil
 }
 
@@ -142,38 +229,28 @@ type TranscriptionBatch struct {
 	Timestamp        time.Time                   `json:"timestamp"`
 }
 
-// processNextBatch processes the next batch of unprocessed transcriptions
-func (p *PostProcessor) processNextBatch() error {
-	// Get unprocessed transcriptions
-	records, err := p.transcriptionStorage.GetUnprocessedTranscriptions(p.batchSize)
-	if err != nil {
-		return fmt.Errorf("failed to get unprocessed transcriptions: %w", err)
-	}
-
-	if len(records) == 0 {
-		p.logger.Debug("No unprocessed transcriptions found")
-		return nil // Nothing to process
-	}
-
-	p.logger.Debug("Processing batch of transcriptions", logger.Int("count", len(records)))
+// processFrequencyBatch post-processes unprocessed transcriptions of one frequency
+func (p *PostProcessor) processFrequencyBatch(frequencyID string, records []*sqlite.TranscriptionRecord) error {
+	pl := p.pipelineFor(frequencyID)
 
-	// Get frequency name for the first record (assuming all records are from the same frequency)
-	var frequencyName string
-	var frequencyID string
-	if len(records) > 0 {
-		frequencyID = records[0].FrequencyID
-		var err error
-		frequencyName, err = p.getFrequencyName(frequencyID)
-		if err != nil {
-			p.logger.Error("Failed to get frequency name", logger.Error(err))
-			frequencyName = frequencyID // Use ID as fallback
-		}
+	frequencyName, err := p.getFrequencyName(frequencyID)
+	if err != nil {
+		p.logger.Error("Failed to get frequency name", logger.Error(err))
+		frequencyName = frequencyID // Use ID as fallback
 	}
 
 	// Get the last N processed transcriptions for context
 	var contextRecords []*sqlite.TranscriptionRecord
 	if frequencyID != "" && p.config.ContextTranscriptions > 0 {
-		contextRecords, err = p.transcriptionStorage.GetLastProcessedTranscriptions(frequencyID, p.config.ContextTranscriptions)
+		// Context comes from before the batch, which may be backfill older than the latest
+		// processed transcriptions
+		oldest := records[0].CreatedAt
+		for _, record := range records[1:] {
+			if record.CreatedAt.Before(oldest) {
+				oldest = record.CreatedAt
+			}
+		}
+		contextRecords, err = p.transcriptionStorage.GetLastProcessedTranscriptions(frequencyID, oldest, p.config.ContextTranscriptions)
 		if err != nil {
 			p.logger.Error("Failed to get context transcriptions", logger.Error(err))
 			// Continue without context
@@ -220,7 +297,7 @@ func (p *PostProcessor) processNextBatch() error {
 	}
 
 	// Use template renderer to generate system prompt with current airspace data
-	systemPrompt, err := p.templateRenderer.RenderPostProcessorTemplate(p.config.SystemPromptPath)
+	systemPrompt, err := p.templateRenderer.RenderPostProcessorTemplate(pl.systemPromptPath)
 	if err != nil {
 		p.logger.Error("Failed to render system prompt template", logger.Error(err))
 		// Mark all records as failed to prevent infinite retry
@@ -230,6 +307,7 @@ func (p *PostProcessor) processNextBatch() error {
 				"[TEMPLATE_RENDER_FAILED]",
 				"UNKNOWN",
 				"",
+				"",
 			); updateErr != nil {
 				p.logger.Error("Failed to mark transcription as failed",
 					logger.Int64("id", record.ID),
@@ -245,8 +323,19 @@ func (p *PostProcessor) processNextBatch() error {
 		string(batchJSON))
 
 	// Process the batch
-	results, err := p.processBatch(systemPrompt, userInput)
-	if err != nil {
+	pending := make(map[int64]bool, len(records))
+	for _, record := range records {
+		pending[record.ID] = true
+	}
+	results, err := p.processBatch(pl.llm, systemPrompt, userInput, pending)
+	var rateLimited error
+	if _, retryable := llm.Retryable(err); retryable {
+		// Leave the transcriptions without a result unprocessed, for after the backoff
+		if len(results) == 0 {
+			return err
+		}
+		rateLimited = err
+	} else if err != nil {
 		p.logger.Error("Failed to process batch", logger.Error(err))
 		// Mark all records as failed to prevent infinite retry
 		for _, record := range records {
@@ -255,6 +344,7 @@ func (p *PostProcessor) processNextBatch() error {
 				"[PROCESSING_FAILED]",
 				"UNKNOWN",
 				"",
+				"",
 			); updateErr != nil {
 				p.logger.Error("Failed to mark transcription as failed",
 					logger.Int64("id", record.ID),
@@ -264,59 +354,48 @@ func (p *PostProcessor) processNextBatch() error {
 		return err
 	}
 
-	// Check if we got any results
-	if len(results) == 0 {
-		p.logger.Warn("No results returned from OpenAI API, marking batch as failed")
-		// Mark all records as failed to prevent infinite retry
-		for _, record := range records {
+	// Update database with processed transcriptions. Context transcriptions were only
+	// there to help the model and are left as they are.
+	for _, record := range records {
+		result, ok := results[record.ID]
+		if !ok && rateLimited != nil {
+			continue
+		}
+		if !ok {
+			// No valid result even after repairs: mark it failed to prevent infinite retry
 			if updateErr := p.transcriptionStorage.UpdateProcessedTranscription(
 				record.ID,
-				"[NO_RESULTS_FROM_API]",
+				"[INVALID_RESULT]",
 				"UNKNOWN",
 				"",
+				"",
 			); updateErr != nil {
 				p.logger.Error("Failed to mark transcription as failed",
 					logger.Int64("id", record.ID),
 					logger.Error(updateErr))
 			}
-		}
-		return nil
-	}
-
-	// Update database with processed transcriptions
-	for _, result := range results {
-		// Skip results with empty processed content or already processed transcriptions (context)
-		if result.ContentProcessed == "" {
-			p.logger.Warn("Skipping result with empty processed content - this indicates OpenAI returned a result but didn't fill in the content_processed field",
-				logger.Int64("id", result.ID),
-				logger.String("original_content", result.Content),
-				logger.String("speaker_type", result.SpeakerType),
-				logger.String("callsign", result.Callsign))
 			continue
 		}
 
-		// Skip context transcriptions that were already processed
-		isContextRecord := false
-		for _, contextRecord := range contextRecords {
-			if contextRecord.ID == result.ID {
-				isContextRecord = true
-				break
-			}
-		}
-		if isContextRecord {
-			p.logger.Debug("Skipping context record that was already processed",
-				logger.Int64("id", result.ID))
-			continue
+		// Link the transmission to the tracked aircraft its callsign refers to
+		aircraftHex := ""
+		if result.Callsign != "" {
+			aircraftHex = p.templateRenderer.AircraftHex(result.Callsign)
+			result.Callsign = p.templateRenderer.CanonicalCallsign(result.Callsign)
 		}
 
+		// Carry the transmission's correlation ID through to clearances and broadcasts
+		recordLogger := p.logger.WithCorrelationID(record.CorrelationID)
+
 		// Update database
 		if err := p.transcriptionStorage.UpdateProcessedTranscription(
 			result.ID,
 			result.ContentProcessed,
 			result.SpeakerType,
 			result.Callsign,
+			aircraftHex,
 		); err != nil {
-			p.logger.Error("Failed to update processed transcription",
+			recordLogger.Error("Failed to update processed transcription",
 				logger.Int64("id", result.ID),
 				logger.Error(err))
 			continue
@@ -325,20 +404,30 @@ func (p *PostProcessor) processNextBatch() error {
 		// Process clearances if this is an ATC transmission with clearances
 		if result.SpeakerType == "ATC" && len(result.Clearances) > 0 {
 			for _, clearance := range result.Clearances {
+				if pl.clearanceTypes != nil && !contains(pl.clearanceTypes, clearance.Type) {
+					recordLogger.Debug("Skipping clearance type not extracted on this frequency",
+						logger.String("callsign", clearance.Callsign),
+						logger.String("type", clearance.Type))
+					continue
+				}
 				clearanceRecord := &sqlite.ClearanceRecord{
 					TranscriptionID: result.ID,
-					Callsign:        clearance.Callsign,
+					Callsign:        p.templateRenderer.CanonicalCallsign(clearance.Callsign),
+					AircraftHex:     p.templateRenderer.AircraftHex(clearance.Callsign),
 					ClearanceType:   clearance.Type,
 					ClearanceText:   clearance.Text,
 					Runway:          clearance.Runway,
+					Altitude:        clearance.Altitude,
+					Heading:         clearance.Heading,
 					Timestamp:       result.Timestamp,
 					Status:          "issued",
 					CreatedAt:       time.Now().UTC(),
+					CorrelationID:   record.CorrelationID,
 				}
 
 				clearanceID, err := p.clearanceStorage.StoreClearance(clearanceRecord)
 				if err != nil {
-					p.logger.Error("Failed to store clearance",
+					recordLogger.Error("Failed to store clearance",
 						logger.String("callsign", clearance.Callsign),
 						logger.String("type", clearance.Type),
 						logger.Error(err))
@@ -351,7 +440,7 @@ func (p *PostProcessor) processNextBatch() error {
 				// Broadcast clearance event via WebSocket
 				p.broadcastClearanceEvent(clearanceRecord)
 
-				p.logger.Info("Stored clearance",
+				recordLogger.Info("Stored clearance",
 					logger.String("callsign", clearance.Callsign),
 					logger.String("type", clearance.Type),
 					logger.String("runway", clearance.Runway),
@@ -359,49 +448,125 @@ func (p *PostProcessor) processNextBatch() error {
 			}
 		}
 
-		// Find the original record to broadcast
-		var record *sqlite.TranscriptionRecord
-		for _, r := range records {
-			if r.ID == result.ID {
-				record = r
-				break
-			}
-		}
-
-		if record == nil {
-			p.logger.Error("Failed to find original record for broadcasting",
-				logger.Int64("id", result.ID))
-			continue
-		}
-
 		// Update the record with processed content
 		record.ContentProcessed = result.ContentProcessed
 		record.SpeakerType = result.SpeakerType
 		record.Callsign = result.Callsign
+		record.AircraftHex = aircraftHex
 		record.IsProcessed = true
 
 		// Log the processed transcription instead of broadcasting
 		p.logProcessedTranscription(record)
 	}
 
-	return nil
+	return rateLimited
 }
 
-// processBatch processes a batch of transcriptions
-func (p *PostProcessor) processBatch(systemPrompt string, userInput string) ([]TranscriptionBatch, error) {
-	// Call OpenAI API to process the batch
-	results, err := p.openaiClient.PostProcessBatch(p.ctx, systemPrompt, userInput, p.config.Model)
-	if err != nil {
-		return nil, fmt.Errorf("failed to post-process batch: %w", err)
+// processBatch sends a batch of transcriptions to the LLM and returns the valid results
+// for the pending transcriptions by ID. When results are missing or invalid, the problems
+// are sent back to the model for it to repair, up to RepairAttempts times; results that
+// were valid in an earlier reply are kept. An error means the LLM couldn't be reached; if
+// it rate-limited a repair, the results that were already valid are returned with the error.
+func (p *PostProcessor) processBatch(provider llm.Provider, systemPrompt string, userInput string, pending map[int64]bool) (map[int64]TranscriptionBatch, error) {
+	valid := make(map[int64]TranscriptionBatch)
+	prompt := userInput
+
+	for attempt := 0; attempt <= max(p.config.RepairAttempts, 0); attempt++ {
+		p.logger.Debug("Post-processing request",
+			logger.String("provider", provider.Name()),
+			logger.String("model", provider.Model()),
+			logger.Int("attempt", attempt+1),
+			logger.String("system_prompt", systemPrompt),
+			logger.String("user_input", prompt))
+
+		reply, err := provider.Complete(p.ctx, llm.Request{
+			System:      systemPrompt,
+			User:        prompt,
+			MaxTokens:   postProcessingMaxTokens,
+			Temperature: 0.0, // Deterministic output
+			Schema:      batchResponseSchema,
+		})
+		if err != nil {
+			if attempt == 0 {
+				return nil, fmt.Errorf("failed to post-process batch: %w", err)
+			}
+			if _, retryable := llm.Retryable(err); retryable {
+				return valid, fmt.Errorf("failed to repair post-processing results: %w", err)
+			}
+			p.logger.Warn("Failed to repair post-processing results", logger.Error(err))
+			break
+		}
+
+		p.usageTracker.Record(usage.SubsystemPostProcessing, provider.Model(), usage.Usage{
+			Requests:     1,
+			InputTokens:  reply.InputTokens,
+			OutputTokens: reply.OutputTokens,
+		})
+		content := reply.Text
+		p.logger.Debug("Post-processing response", logger.String("response", content))
+
+		remaining := make(map[int64]bool, len(pending))
+		for id := range pending {
+			if _, ok := valid[id]; !ok {
+				remaining[id] = true
+			}
+		}
+
+		var problems []string
+		results, err := parseBatchResults(content)
+		if err != nil {
+			problems = []string{err.Error()}
+		} else {
+			var replyValid map[int64]TranscriptionBatch
+			replyValid, problems = validateBatchResults(results, remaining)
+			for id, result := range replyValid {
+				valid[id] = result
+			}
+		}
+		if len(valid) == len(pending) {
+			return valid, nil
+		}
+
+		p.logger.Warn("Post-processing results failed validation",
+			logger.Int("attempt", attempt+1),
+			logger.Int("invalid", len(pending)-len(valid)),
+			logger.String("problems", strings.Join(problems, "; ")),
+			logger.String("response", content))
+		prompt = fmt.Sprintf("%s\n\nYour previous reply had these problems:\n- %s\n\nReply again with the whole batch, fixing them.",
+			userInput, strings.Join(problems, "\n- "))
 	}
 
+	return valid, nil
+}
+
+// parseBatchResults extracts the processed transcriptions from an LLM reply: the object
+// a schema-constrained reply holds them in, or else the JSON array the prompt asks for.
+// Models, local ones especially, may wrap the array in prose or a code fence.
+func parseBatchResults(content string) ([]TranscriptionBatch, error) {
+	if trimmed := strings.TrimSpace(content); strings.HasPrefix(trimmed, "{") {
+		var response batchResponse
+		if err := json.Unmarshal([]byte(trimmed), &response); err == nil && response.Transcriptions != nil {
+			return response.Transcriptions, nil
+		}
+	}
+
+	startIdx := strings.Index(content, "[")
+	endIdx := strings.LastIndex(content, "]")
+	if startIdx == -1 || endIdx == -1 || startIdx >= endIdx {
+		return nil, fmt.Errorf("the reply does not contain a JSON array of transcriptions")
+	}
+
+	var results []TranscriptionBatch
+	if err := json.Unmarshal([]byte(content[startIdx:endIdx+1]), &results); err != nil {
+		return nil, fmt.Errorf("the reply is not valid JSON: %v", err)
+	}
 	return results, nil
 }
 
 // getFrequencyName retrieves the name of a frequency from its ID
 func (p *PostProcessor) getFrequencyName(frequencyID string) (string, error) {
 	// Check if we have the frequency name in our cache
-	if name, ok := p.frequencyNames[frequencyID]; ok {
+	if name, ok := p.frequencyNames.Get(frequencyID); ok {
 		return name, nil
 	}
 
@@ -414,7 +579,7 @@ func (p *PostProcessor) getFrequencyName(frequencyID string) (string, error) {
 // logProcessedTranscription logs a processed transcription to the server console and broadcasts it to WebSocket clients
 func (p *PostProcessor) logProcessedTranscription(record *sqlite.TranscriptionRecord) {
 	// Log the processed transcription at debug level
-	p.logger.Debug("Processed transcription",
+	p.logger.WithCorrelationID(record.CorrelationID).Debug("Processed transcription",
 		logger.Int64("id", record.ID),
 		logger.String("frequency_id", record.FrequencyID),
 		logger.String("original_content", record.Content),
@@ -436,6 +601,8 @@ func (p *PostProcessor) logProcessedTranscription(record *sqlite.TranscriptionRe
 			"content_processed": record.ContentProcessed,
 			"speaker_type":      record.SpeakerType,
 			"callsign":          record.Callsign,
+			"aircraft_hex":      record.AircraftHex,
+			"correlation_id":    record.CorrelationID,
 		},
 	}
 
@@ -463,16 +630,20 @@ func (p *PostProcessor) broadcastClearanceEvent(clearance *sqlite.ClearanceRecor
 		Data: map[string]interface{}{
 			"id":             clearance.ID,
 			"callsign":       clearance.Callsign,
+			"aircraft_hex":   clearance.AircraftHex,
 			"clearance_type": clearance.ClearanceType,
 			"clearance_text": clearance.ClearanceText,
 			"runway":         clearance.Runway,
+			"altitude":       clearance.Altitude,
+			"heading":        clearance.Heading,
 			"timestamp":      clearance.Timestamp,
 			"status":         clearance.Status,
+			"correlation_id": clearance.CorrelationID,
 		},
 	}
 
 	// Log the message we're about to send
-	p.logger.Debug("Broadcasting clearance event to WebSocket clients",
+	p.logger.WithCorrelationID(clearance.CorrelationID).Debug("Broadcasting clearance event to WebSocket clients",
 		logger.Int64("id", clearance.ID),
 		logger.String("callsign", clearance.Callsign),
 		logger.String("type", clearance.ClearanceType))
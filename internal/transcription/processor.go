@@ -2,10 +2,10 @@ package transcription
 
 import (
 	"context"
-	"encoding/base64"
-	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
+	"slices"
 	"strings"
 	"sync"
 	"time"
@@ -28,7 +28,8 @@ var (
 type Processor struct {
 	frequencyID         string
 	audioReader         io.ReadCloser
-	openaiClient        *OpenAIClient
+	provider            Provider
+	retryPolicy         RetryPolicy
 	wsServer            *websocket.Server
 	storage             *sqlite.TranscriptionStorage
 	ctx                 context.Context
@@ -36,13 +37,55 @@ type Processor struct {
 	logger              *logger.Logger
 	audioChunker        *audio.AudioChunker
 	sessionID           string
-	clientSecret        string
-	wsConn              *OpenAIWebSocketConn
+	session             ProviderSession
+	sessionMu           sync.RWMutex // Protects session and sessionID while they're replaced
 	chunkCount          int
 	chunkCountMu        sync.Mutex
 	transcriptionConfig Config
 	sessionStartTime    time.Time
 	sessionRefreshMu    sync.Mutex
+	transmissionIDs     map[string]string        // Provider item ID -> transmission correlation ID
+	speechWindows       map[string]*speechWindow // Provider item ID -> when the transmission was on the air
+	sessionSpans        []audioSpan              // Contiguous stretches of audio sent in the current session
+	sessionAudioSent    time.Duration            // Audio sent in the current session
+	openSpeechStart     time.Time                // Start of the transmission in progress, zero between transmissions
+	sessionAudioMu      sync.Mutex               // Protects sessionSpans, sessionAudioSent and openSpeechStart
+	preRoll             *audio.PreRollBuffer     // Recent audio, replayed into a new session after a reconnect (nil if disabled)
+	sendMu              sync.Mutex               // Keeps live chunks and replayed pre-roll in order
+	gate                *silenceGate             // Holds back silence between transmissions (nil if disabled)
+	vocabularySource    VocabularySource         // Terms to bias transcription toward (nil if disabled)
+	vocabulary          []string                 // Terms the current session is biased toward
+	vocabularyMu        sync.Mutex               // Protects vocabulary
+	squelchSpans        []squelchSpan            // Recent transmissions detected by the squelch, for splitting transcripts
+	squelchMu           sync.Mutex               // Protects squelchSpans
+	audioDelay          func() time.Duration     // How far the frequency's audio lags the air, configured plus estimated (nil = live)
+	done                chan struct{}            // Closed when the processor gives up on its own
+	doneOnce            sync.Once
+	exitErr             error // Why the processor gave up
+}
+
+// DefaultPreRollMs is how much recent audio is kept for replay when pre_roll_ms is not set.
+// It covers all but the longest transmissions.
+const DefaultPreRollMs = 10000
+
+// Vocabulary biasing defaults, used when the configuration doesn't set them
+const (
+	DefaultVocabularyMaxTerms   = 60
+	DefaultVocabularyRefreshSec = 60
+)
+
+// audioSpan is a stretch of audio sent without gaps. Providers report offsets into
+// the session's audio, which only match wall-clock time within one span once silence is
+// skipped or the stream drops out.
+type audioSpan struct {
+	offset time.Duration // Position of the span's first audio in the session's audio
+	start  time.Time     // Wall-clock time the span's first audio was on the air
+}
+
+// speechWindow is the wall-clock time span of a transmission, as detected by the provider
+type speechWindow struct {
+	start *time.Time
+	end   *time.Time
 }
 
 // NewProcessor creates a new transcription processor with a provided reader
@@ -53,30 +96,49 @@ func NewProcessor(
 	config Config,
 	wsServer *websocket.Server,
 	storage *sqlite.TranscriptionStorage,
+	provider Provider,
+	gateStats *GateStats,
+	vocabularySource VocabularySource,
+	audioDelay func() time.Duration,
 	logger *logger.Logger,
 ) (ProcessorInterface, error) {
-	// Check if OpenAI API key is provided - fail fast if missing
-	if config.OpenAIAPIKey == "" {
-		return nil, fmt.Errorf("OpenAI API key is required for transcription processor")
+	if provider == nil {
+		return nil, fmt.Errorf("a speech-to-text provider is required for transcription processor")
 	}
 
 	procCtx, procCancel := context.WithCancel(ctx)
 
-	// Create OpenAI client
-	openaiClient := NewOpenAIClient(config.OpenAIAPIKey, config.Model, config.TimeoutSeconds, logger)
-
 	// Create processor
 	processor := &Processor{
 		frequencyID:         frequencyID,
 		audioReader:         audioReader,
-		openaiClient:        openaiClient,
+		provider:            provider,
+		retryPolicy:         provider.RetryPolicy(),
 		wsServer:            wsServer,
 		storage:             storage,
 		ctx:                 procCtx,
 		cancel:              procCancel,
-		logger:              logger.Named("custom-xscribe").With(String("frequency_id", frequencyID)),
+		logger:              logger.Named("custom-xscribe").With(String("frequency_id", frequencyID), String("provider", provider.Name())),
 		audioChunker:        audio.NewAudioChunker(config.FFmpegSampleRate, config.FFmpegChannels, config.ChunkMs),
 		transcriptionConfig: config,
+		transmissionIDs:     make(map[string]string),
+		speechWindows:       make(map[string]*speechWindow),
+		vocabularySource:    vocabularySource,
+		audioDelay:          audioDelay,
+		done:                make(chan struct{}),
+	}
+
+	// A negative pre-roll disables the replay
+	preRollMs := config.PreRollMs
+	if preRollMs == 0 {
+		preRollMs = DefaultPreRollMs
+	}
+	if preRollMs > 0 {
+		processor.preRoll = audio.NewPreRollBuffer(time.Duration(preRollMs) * time.Millisecond)
+	}
+
+	if config.SilenceGating && gateStats != nil {
+		processor.gate = newSilenceGate(config, gateStats)
 	}
 
 	return processor, nil
@@ -87,30 +149,28 @@ func (p *Processor) Start() error {
 	p.logger.Info("Starting custom transcription processor",
 		String("frequency_id", p.frequencyID))
 
-	// Create OpenAI transcription session
-	var err error
-	p.sessionID, p.clientSecret, err = p.openaiClient.CreateSession(p.ctx, p.transcriptionConfig)
+	// Open the streaming session
+	session, err := p.provider.Connect(p.ctx, p.refreshVocabulary())
 	if err != nil {
 		p.audioReader.Close()
-		return fmt.Errorf("failed to create transcription session: %w", err)
+		return fmt.Errorf("failed to connect to %s: %w", p.provider.Name(), err)
 	}
-	p.logger.Info("Created transcription session", String("session_id", p.sessionID))
+	p.session = session
+	p.sessionID = session.ID()
+	p.logger.Info("Connected transcription session", String("session_id", p.currentSessionID()))
 
 	// Record session start time
 	p.sessionStartTime = time.Now()
 
-	// Connect to OpenAI WebSocket
-	p.wsConn, err = p.openaiClient.ConnectWebSocket(p.ctx, p.sessionID, p.clientSecret)
-	if err != nil {
-		p.audioReader.Close()
-		return fmt.Errorf("failed to connect to WebSocket: %w", err)
-	}
-	p.logger.Info("Connected to OpenAI WebSocket")
-
 	// Start processing in goroutines
 	go p.processAudio()
 	go p.processTranscriptions()
-	go p.monitorSessionDuration()
+	if p.retryPolicy.MaxSessionDuration > 0 {
+		go p.monitorSessionDuration()
+	}
+	if p.vocabularySource != nil {
+		go p.monitorVocabulary()
+	}
 
 	return nil
 }
@@ -122,9 +182,9 @@ func (p *Processor) Stop() error {
 	// Cancel context to stop all operations
 	p.cancel()
 
-	// Close WebSocket connection
-	if p.wsConn != nil {
-		p.wsConn.Close()
+	// Close the streaming session
+	if session := p.currentSession(); session != nil {
+		session.Close()
 	}
 
 	// Close audio reader
@@ -135,6 +195,34 @@ func (p *Processor) Stop() error {
 	return nil
 }
 
+// Done returns a channel closed when the processor gives up on its own: its audio ended or
+// its session couldn't be kept open. It isn't closed by Stop.
+func (p *Processor) Done() <-chan struct{} {
+	return p.done
+}
+
+// Err returns why the processor gave up, once Done is closed
+func (p *Processor) Err() error {
+	select {
+	case <-p.done:
+		return p.exitErr
+	default:
+		return nil
+	}
+}
+
+// exit records why the processor gave up and closes Done. Exits caused by Stop aren't
+// reported.
+func (p *Processor) exit(err error) {
+	if p.ctx.Err() != nil {
+		return
+	}
+	p.doneOnce.Do(func() {
+		p.exitErr = err
+		close(p.done)
+	})
+}
+
 // processAudio processes audio from the reader
 func (p *Processor) processAudio() {
 	p.logger.Info("Starting audio processing")
@@ -158,9 +246,11 @@ func (p *Processor) processAudio() {
 			if err != nil {
 				if err == io.EOF {
 					p.logger.Info("Audio source ended")
+					p.exit(errors.New("audio source ended"))
 					return
 				}
 				p.logger.Error("Error reading from audio source", Error(err))
+				p.exit(fmt.Errorf("failed to read audio: %w", err))
 				return
 			}
 
@@ -172,13 +262,9 @@ func (p *Processor) processAudio() {
 					continue
 				}
 
-				// Send chunks to OpenAI
-				for _, chunk := range chunks {
-					// Base64 encode the chunk
-					encoded := base64.StdEncoding.EncodeToString(chunk)
-
-					// Send to OpenAI
-					if err := p.sendAudioChunk(encoded); err != nil {
+				// Send chunks to the provider, less the silence between transmissions
+				for _, chunk := range p.gateChunks(chunks) {
+					if err := p.sendLiveChunk(chunk.data, chunk.capturedAt); err != nil {
 						consecutiveErrors++
 
 						// Log with appropriate level based on consecutive errors
@@ -201,14 +287,14 @@ func (p *Processor) processAudio() {
 
 						// After several consecutive errors, try to reconnect
 						if consecutiveErrors >= maxConsecutiveErrors && !reconnectAttempted {
-							p.logger.Info("Too many consecutive errors, attempting to reconnect WebSocket")
+							p.logger.Info("Too many consecutive errors, attempting to reconnect")
 
 							// Try to reconnect
-							if err := p.reconnectOpenAI(); err != nil {
-								p.logger.Error("Failed to reconnect to OpenAI", Error(err))
+							if err := p.reconnect(); err != nil {
+								p.logger.Error("Failed to reconnect transcription session", Error(err))
 								reconnectAttempted = true // Only try once per error burst
 							} else {
-								p.logger.Info("Successfully reconnected to OpenAI")
+								p.logger.Info("Successfully reconnected transcription session")
 								consecutiveErrors = 0
 								reconnectAttempted = false
 							}
@@ -245,20 +331,82 @@ func min(x, y int) int {
 	return y
 }
 
-// sendAudioChunk sends an audio chunk to OpenAI
-func (p *Processor) sendAudioChunk(encodedChunk string) error {
-	// Create message
-	message := map[string]interface{}{
-		"type":  "input_audio_buffer.append",
-		"audio": encodedChunk,
+// gateChunks returns the chunks read from the frequency that should be streamed
+func (p *Processor) gateChunks(chunks [][]byte) []gatedChunk {
+	now := time.Now()
+	var gated []gatedChunk
+	for _, chunk := range chunks {
+		if p.gate == nil {
+			gated = append(gated, gatedChunk{data: chunk, capturedAt: now})
+			continue
+		}
+		gated = append(gated, p.gate.filter(chunk, now)...)
 	}
+	return gated
+}
 
-	// Marshal to JSON
-	data, err := json.Marshal(message)
-	if err != nil {
-		return fmt.Errorf("failed to marshal audio chunk message: %w", err)
+// sendLiveChunk keeps a chunk read from the frequency in the pre-roll and sends it to the provider
+func (p *Processor) sendLiveChunk(chunk []byte, capturedAt time.Time) error {
+	p.sendMu.Lock()
+	defer p.sendMu.Unlock()
+
+	// Buffer before sending, so a chunk that fails to send is replayed after the reconnect
+	if p.preRoll != nil {
+		p.preRoll.Add(chunk, capturedAt)
+	}
+
+	return p.sendAudioChunk(chunk, capturedAt)
+}
+
+// replayPreRoll sends the buffered audio of the transmission in progress into a new session,
+// so a reconnect in the middle of a call doesn't lose its beginning
+func (p *Processor) replayPreRoll() {
+	if p.preRoll == nil {
+		return
+	}
+
+	p.sessionAudioMu.Lock()
+	speechStart := p.openSpeechStart
+	p.openSpeechStart = time.Time{}
+	p.sessionAudioMu.Unlock()
+
+	// Between transmissions there's nothing to recover, and replaying a transmission the
+	// previous session already completed would transcribe it twice
+	if speechStart.IsZero() {
+		return
+	}
+
+	// Include the prefix padding the VAD would have included
+	since := speechStart.Add(-time.Duration(p.transcriptionConfig.PrefixPaddingMs) * time.Millisecond)
+
+	p.sendMu.Lock()
+	defer p.sendMu.Unlock()
+
+	chunks := p.preRoll.Since(since)
+	if len(chunks) == 0 {
+		return
+	}
+
+	// VAD offsets of the new session count from the first replayed chunk, not from now
+	sent := 0
+	for _, chunk := range chunks {
+		if err := p.sendAudioChunk(chunk.Data, chunk.CapturedAt); err != nil {
+			p.logger.Warn("Failed to replay pre-roll audio",
+				Error(err),
+				Int("sent_chunks", sent),
+				Int("total_chunks", len(chunks)))
+			return
+		}
+		sent++
 	}
 
+	p.logger.Info("Replayed pre-roll audio into new session",
+		Int("chunks", sent),
+		String("transmission_start", speechStart.Format(time.RFC3339Nano)))
+}
+
+// sendAudioChunk sends an audio chunk, read from the frequency at capturedAt, to the provider
+func (p *Processor) sendAudioChunk(chunk []byte, capturedAt time.Time) error {
 	// Log every 100th chunk to avoid excessive logging
 	p.chunkCountMu.Lock()
 	p.chunkCount++
@@ -269,25 +417,55 @@ func (p *Processor) sendAudioChunk(encodedChunk string) error {
 		p.logger.Debug("Sending audio chunk", Int("chunk_number", chunkCount))
 	}
 
-	// Send to OpenAI
-	if err := p.wsConn.Send(string(data)); err != nil {
+	if err := p.currentSession().SendAudio(chunk); err != nil {
 		return fmt.Errorf("failed to send audio chunk: %w", err)
 	}
 
+	p.trackSentAudio(p.chunkDuration(chunk), capturedAt)
+
 	return nil
 }
 
-// processTranscriptions processes transcription events from OpenAI
+// trackSentAudio extends the session's audio timeline with a sent chunk, which holds the
+// audio captured over the duration before capturedAt. A chunk that doesn't follow on from
+// the previous one (skipped silence, a stream dropout or a new session) starts a new span.
+func (p *Processor) trackSentAudio(duration time.Duration, capturedAt time.Time) {
+	p.sessionAudioMu.Lock()
+	defer p.sessionAudioMu.Unlock()
+
+	start := capturedAt.Add(-duration)
+	if n := len(p.sessionSpans); n == 0 {
+		p.sessionSpans = append(p.sessionSpans, audioSpan{offset: p.sessionAudioSent, start: start})
+	} else {
+		last := p.sessionSpans[n-1]
+		expected := last.start.Add(p.sessionAudioSent - last.offset)
+		if start.Sub(expected) > duration {
+			p.sessionSpans = append(p.sessionSpans, audioSpan{offset: p.sessionAudioSent, start: start})
+		}
+	}
+	p.sessionAudioSent += duration
+}
+
+// chunkDuration returns the length of a PCM16 chunk in the transcription format
+func (p *Processor) chunkDuration(chunk []byte) time.Duration {
+	bytesPerSecond := p.transcriptionConfig.FFmpegSampleRate * p.transcriptionConfig.FFmpegChannels * 2
+	if bytesPerSecond <= 0 {
+		return time.Duration(p.transcriptionConfig.ChunkMs) * time.Millisecond
+	}
+	return time.Duration(len(chunk)) * time.Second / time.Duration(bytesPerSecond)
+}
+
+// processTranscriptions processes transcription events from the provider
 func (p *Processor) processTranscriptions() {
 	p.logger.Info("Starting transcription processing",
 		String("frequency_id", p.frequencyID),
-		String("session_id", p.sessionID))
+		String("session_id", p.currentSessionID()))
 
 	// Track reconnection attempts
 	reconnectAttempts := 0
-	maxReconnectAttempts := 5
+	maxReconnectAttempts := p.retryPolicy.MaxReconnectAttempts
 	lastReconnectTime := time.Now()
-	reconnectBackoffSeconds := 1
+	reconnectBackoff := p.retryPolicy.InitialBackoff
 
 	for {
 		select {
@@ -295,8 +473,8 @@ func (p *Processor) processTranscriptions() {
 			p.logger.Info("Transcription processing stopped due to context cancellation")
 			return
 		default:
-			// Receive message from OpenAI
-			message, err := p.wsConn.Receive()
+			// Receive the next event from the provider
+			event, err := p.currentSession().Receive()
 			if err != nil {
 				// Check if context is canceled or connection is closed
 				select {
@@ -304,7 +482,7 @@ func (p *Processor) processTranscriptions() {
 					// This is an expected error during shutdown
 					p.logger.Info("WebSocket connection closed during shutdown",
 						String("frequency_id", p.frequencyID),
-						String("session_id", p.sessionID))
+						String("session_id", p.currentSessionID()))
 					return
 				default:
 					// Categorize the error
@@ -338,13 +516,13 @@ func (p *Processor) processTranscriptions() {
 						p.logger.Warn("WebSocket connection issue detected",
 							Error(err),
 							String("frequency_id", p.frequencyID),
-							String("session_id", p.sessionID),
+							String("session_id", p.currentSessionID()),
 							Int("reconnect_attempts", reconnectAttempts))
 					} else {
 						p.logger.Error("Error receiving WebSocket message",
 							Error(err),
 							String("frequency_id", p.frequencyID),
-							String("session_id", p.sessionID))
+							String("session_id", p.currentSessionID()))
 					}
 
 					// Don't immediately return on network errors during shutdown
@@ -362,44 +540,45 @@ func (p *Processor) processTranscriptions() {
 								p.logger.Info("Resetting reconnection counter after cooling period",
 									String("frequency_id", p.frequencyID))
 								reconnectAttempts = 0
-								reconnectBackoffSeconds = 1
+								reconnectBackoff = p.retryPolicy.InitialBackoff
 							} else {
 								p.logger.Error("Exceeded maximum reconnection attempts",
 									String("frequency_id", p.frequencyID),
 									Int("max_attempts", maxReconnectAttempts))
+								p.exit(fmt.Errorf("exceeded %d reconnection attempts: %w", maxReconnectAttempts, err))
 								return
 							}
 						}
 
 						// Apply exponential backoff
-						backoffDuration := time.Duration(reconnectBackoffSeconds) * time.Second
 						p.logger.Info("WebSocket connection closed, waiting before reconnect attempt",
 							String("frequency_id", p.frequencyID),
-							String("backoff_duration", backoffDuration.String()),
+							String("backoff_duration", reconnectBackoff.String()),
 							Int("attempt", reconnectAttempts+1))
 
-						time.Sleep(backoffDuration)
+						time.Sleep(reconnectBackoff)
 
 						// Attempt to reconnect
-						if err := p.reconnectOpenAI(); err != nil {
+						if err := p.reconnect(); err != nil {
 							reconnectAttempts++
-							reconnectBackoffSeconds = min(reconnectBackoffSeconds*2, 60) // Cap at 60 seconds
-							p.logger.Error("Failed to reconnect to OpenAI",
+							reconnectBackoff = p.nextBackoff(reconnectBackoff, err)
+							p.logger.Error("Failed to reconnect transcription session",
 								Error(err),
 								Int("reconnect_attempts", reconnectAttempts),
-								Int("next_backoff_seconds", reconnectBackoffSeconds))
+								String("next_backoff", reconnectBackoff.String()))
 						} else {
-							p.logger.Info("Successfully reconnected to OpenAI WebSocket",
+							p.logger.Info("Successfully reconnected transcription session",
 								String("frequency_id", p.frequencyID),
-								String("session_id", p.sessionID))
+								String("session_id", p.currentSessionID()))
 							reconnectAttempts = 0
-							reconnectBackoffSeconds = 1
+							reconnectBackoff = p.retryPolicy.InitialBackoff
 							lastReconnectTime = time.Now()
 						}
 						continue
 					}
 
 					// For other unexpected errors, return
+					p.exit(err)
 					return
 				}
 			}
@@ -407,100 +586,162 @@ func (p *Processor) processTranscriptions() {
 			// Reset reconnect attempts on successful message
 			if reconnectAttempts > 0 {
 				reconnectAttempts = 0
-				reconnectBackoffSeconds = 1
+				reconnectBackoff = p.retryPolicy.InitialBackoff
 			}
 
-			// p.logger.Debug("Received message from OpenAI",
-			// 	String("frequency_id", p.frequencyID),
-			// 	String("message_length", fmt.Sprintf("%d bytes", len(message))))
-
-			// Parse message
-			var event map[string]interface{}
-			if err := json.Unmarshal([]byte(message), &event); err != nil {
-				p.logger.Error("Error parsing event", Error(err))
-				continue
-			}
+			// Process event based on type
+			switch event.Type {
+			case EventSpeechStarted:
+				// Record when the transmission started, to link it to the recorded audio
+				if event.ItemID != "" {
+					start := p.audioTime(event.AudioOffset, -time.Duration(p.transcriptionConfig.PrefixPaddingMs+p.transcriptionConfig.ChunkMs)*time.Millisecond)
+					p.speechWindows[event.ItemID] = &speechWindow{start: &start}
+					p.setOpenSpeech(start)
+				}
 
-			// Get event type
-			eventType, ok := event["type"].(string)
-			if !ok {
-				p.logger.Error("Event missing type field", String("event", message))
-				continue
-			}
+				// Drop windows of items that never completed, e.g. failed transcriptions
+				for id, window := range p.speechWindows {
+					if time.Since(*window.start) > 5*time.Minute {
+						delete(p.speechWindows, id)
+					}
+				}
 
-			// Process event based on type
-			switch eventType {
-			case "conversation.item.input_audio_transcription.delta":
-				// Handle partial transcript
-				deltaText, ok := event["delta"].(string)
-				if !ok {
-					p.logger.Error("Delta event missing delta field", String("event", message))
-					continue
+			case EventSpeechStopped:
+				if window, ok := p.speechWindows[event.ItemID]; ok {
+					end := p.audioTime(event.AudioOffset, -time.Duration(p.transcriptionConfig.SilenceDurationMs)*time.Millisecond)
+					window.end = &end
 				}
+				p.setOpenSpeech(time.Time{})
 
+			case EventDelta:
 				// Log the delta but don't send to WebSocket clients
 				p.logger.Debug("Received delta transcription",
 					String("frequency_id", p.frequencyID),
-					String("text", deltaText))
-
-			case "conversation.item.input_audio_transcription.completed":
-				// Handle completed transcript
-				transcript, ok := event["transcript"].(string)
-				if !ok {
-					p.logger.Error("Completed event missing transcript field", String("event", message))
-					continue
-				}
+					String("correlation_id", p.transmissionID(event.ItemID, false)),
+					String("text", event.Text))
 
+			case EventCompleted:
 				// Create transcription event
 				transcriptionEvent := &TranscriptionEvent{
-					Type:      "completed",
-					Text:      transcript,
-					Timestamp: time.Now().UTC(),
+					Type:          "completed",
+					Text:          event.Text,
+					Timestamp:     time.Now().UTC(),
+					CorrelationID: p.transmissionID(event.ItemID, true),
+					Confidence:    event.Confidence,
+					Words:         p.wordTimes(event.Words),
 				}
 
-				// Process the event
-				if err := p.processTranscriptionEvent(transcriptionEvent); err != nil {
-					p.logger.Error("Error processing completed transcription", Error(err))
+				if window, ok := p.speechWindows[event.ItemID]; ok {
+					transcriptionEvent.AudioStart = window.start
+					transcriptionEvent.AudioEnd = window.end
+					delete(p.speechWindows, event.ItemID)
 				}
 
-			case "error":
-				// Handle error
-				errorObj, ok := event["error"].(map[string]interface{})
-				if !ok {
-					p.logger.Error("Error event missing error field", String("event", message))
-					continue
+				// Store one transcription per speaker turn if the transcript spans several
+				turns := []*TranscriptionEvent{transcriptionEvent}
+				if p.transcriptionConfig.SplitTurns {
+					turns = p.splitTurns(transcriptionEvent)
+					if len(turns) > 1 {
+						p.logger.Debug("Split transcript into speaker turns",
+							String("correlation_id", transcriptionEvent.CorrelationID),
+							Int("turns", len(turns)))
+					}
 				}
 
-				errorMessage, ok := errorObj["message"].(string)
-				if !ok {
-					p.logger.Error("Error object missing message field", String("event", message))
-					continue
+				for _, turn := range turns {
+					if err := p.processTranscriptionEvent(turn); err != nil {
+						p.logger.Error("Error processing completed transcription", Error(err))
+					}
 				}
 
-				p.logger.Error("Received error from OpenAI", String("error", errorMessage))
+			case EventError:
+				p.logger.Error("Received error from transcription provider", String("error", event.Text))
 
-				// Check if session expired
-				errorCode, ok := errorObj["code"].(string)
-				if ok && errorCode == "session_expired" {
-					p.logger.Info("Session expired, reconnecting")
-					if err := p.reconnectOpenAI(); err != nil {
-						p.logger.Error("Failed to reconnect to OpenAI", Error(err))
-						return
-					}
+			case EventSessionExpired:
+				p.logger.Info("Session expired, reconnecting", String("error", event.Text))
+				if err := p.reconnect(); err != nil {
+					p.logger.Error("Failed to reconnect transcription session", Error(err))
+					p.exit(err)
+					return
 				}
 			}
 		}
 	}
 }
 
+// audioTime converts an audio offset reported by the provider into wall-clock time.
+// If the offset or the session's audio start is unknown, the time is estimated from
+// when the event arrived, corrected by fallbackOffset.
+func (p *Processor) audioTime(audioOffset *time.Duration, fallbackOffset time.Duration) time.Time {
+	p.sessionAudioMu.Lock()
+	defer p.sessionAudioMu.Unlock()
+
+	if audioOffset != nil && len(p.sessionSpans) > 0 {
+		offset := *audioOffset
+		span := p.sessionSpans[0]
+		for _, s := range p.sessionSpans[1:] {
+			if s.offset > offset {
+				break
+			}
+			span = s
+		}
+		return span.start.Add(offset - span.offset).UTC()
+	}
+
+	return time.Now().Add(fallbackOffset).UTC()
+}
+
+// wordTimes places the words of a transcript on the wall clock
+func (p *Processor) wordTimes(words []ProviderWord) []sqlite.TranscriptionWord {
+	if len(words) == 0 {
+		return nil
+	}
+
+	timed := make([]sqlite.TranscriptionWord, 0, len(words))
+	for _, word := range words {
+		start, end := word.Start, word.End
+		timed = append(timed, sqlite.TranscriptionWord{
+			Word:       word.Text,
+			Start:      p.audioTime(&start, 0),
+			End:        p.audioTime(&end, 0),
+			Confidence: word.Confidence,
+			Speaker:    word.Speaker,
+		})
+	}
+	return timed
+}
+
+// transmissionID returns the correlation ID for the transmission a provider event
+// belongs to. Delta and completed events for the same item share one ID; the
+// mapping is released once the item completes.
+func (p *Processor) transmissionID(itemID string, completed bool) string {
+	if itemID == "" {
+		return logger.NewCorrelationID("tx")
+	}
+
+	id, ok := p.transmissionIDs[itemID]
+	if !ok {
+		id = logger.NewCorrelationID("tx")
+		p.transmissionIDs[itemID] = id
+	}
+	if completed {
+		delete(p.transmissionIDs, itemID)
+	}
+
+	return id
+}
+
 // processTranscriptionEvent processes a transcription event
 func (p *Processor) processTranscriptionEvent(event *TranscriptionEvent) error {
+	eventLogger := p.logger.WithCorrelationID(event.CorrelationID)
+
 	// Log the event
 	if event.Type == "delta" {
-		p.logger.Debug("Received delta transcription", String("text", event.Text))
+		eventLogger.Debug("Received delta transcription", String("text", event.Text))
 	} else {
-		p.logger.Debug("Received completed transcription", String("text", event.Text))
+		eventLogger.Debug("Received completed transcription", String("text", event.Text))
 	}
+	p.shiftToAir(event)
 
 	// Store completed transcriptions in the database
 	if event.Type == "completed" {
@@ -512,6 +753,12 @@ func (p *Processor) processTranscriptionEvent(event *TranscriptionEvent) error {
 			IsComplete:       true,
 			IsProcessed:      false,
 			ContentProcessed: "",
+			CorrelationID:    event.CorrelationID,
+			AudioStart:       event.AudioStart,
+			AudioEnd:         event.AudioEnd,
+			Confidence:       event.Confidence,
+			Words:            event.Words,
+			AudioDelayMs:     int(event.AudioDelay.Milliseconds()),
 			// SpeakerType and Callsign will be empty for now
 		}
 
@@ -521,7 +768,7 @@ func (p *Processor) processTranscriptionEvent(event *TranscriptionEvent) error {
 			return fmt.Errorf("failed to store transcription: %w", err)
 		}
 
-		p.logger.Debug("Stored transcription in database", Int64("id", id))
+		eventLogger.Debug("Stored transcription in database", Int64("id", id))
 
 		// Update the record with the ID
 		record.ID = id
@@ -537,10 +784,16 @@ func (p *Processor) processTranscriptionEvent(event *TranscriptionEvent) error {
 				"is_complete":       event.Type == "completed",
 				"is_processed":      false,
 				"content_processed": "",
+				"correlation_id":    event.CorrelationID,
+				"audio_start":       event.AudioStart,
+				"audio_end":         event.AudioEnd,
+				"confidence":        event.Confidence,
+				"words":             event.Words,
+				"audio_delay_ms":    event.AudioDelay.Milliseconds(),
 			},
 		}
 
-		p.logger.Debug("Broadcasting transcription to WebSocket clients",
+		eventLogger.Debug("Broadcasting transcription to WebSocket clients",
 			String("frequency_id", p.frequencyID),
 			String("text", event.Text),
 			String("type", event.Type),
@@ -562,6 +815,7 @@ func (p *Processor) processTranscriptionEvent(event *TranscriptionEvent) error {
 			"is_complete":       event.Type == "completed",
 			"is_processed":      false,
 			"content_processed": "",
+			"correlation_id":    event.CorrelationID,
 		},
 	}
 
@@ -570,41 +824,162 @@ func (p *Processor) processTranscriptionEvent(event *TranscriptionEvent) error {
 	return nil
 }
 
-// reconnectOpenAI reconnects to OpenAI
-func (p *Processor) reconnectOpenAI() error {
+// shiftToAir moves an event's times back by the frequency's audio delay, to when the
+// transmission was on the air rather than when its audio arrived
+func (p *Processor) shiftToAir(event *TranscriptionEvent) {
+	if p.audioDelay == nil {
+		return
+	}
+	delay := p.audioDelay()
+	if delay <= 0 {
+		return
+	}
+
+	event.AudioDelay = delay
+	event.Timestamp = event.Timestamp.Add(-delay)
+	if event.AudioStart != nil {
+		start := event.AudioStart.Add(-delay)
+		event.AudioStart = &start
+	}
+	if event.AudioEnd != nil {
+		end := event.AudioEnd.Add(-delay)
+		event.AudioEnd = &end
+	}
+	for i := range event.Words {
+		event.Words[i].Start = event.Words[i].Start.Add(-delay)
+		event.Words[i].End = event.Words[i].End.Add(-delay)
+	}
+}
+
+// setOpenSpeech records the start of the transmission in progress, or zero when it ends
+func (p *Processor) setOpenSpeech(start time.Time) {
+	p.sessionAudioMu.Lock()
+	p.openSpeechStart = start
+	p.sessionAudioMu.Unlock()
+}
+
+// currentSession returns the streaming session in use
+func (p *Processor) currentSession() ProviderSession {
+	p.sessionMu.RLock()
+	defer p.sessionMu.RUnlock()
+	return p.session
+}
+
+// currentSessionID returns the ID of the streaming session in use
+func (p *Processor) currentSessionID() string {
+	p.sessionMu.RLock()
+	defer p.sessionMu.RUnlock()
+	return p.sessionID
+}
+
+// nextBackoff doubles the reconnect backoff up to the provider's maximum. A provider that
+// is rate limiting gets the maximum straight away.
+func (p *Processor) nextBackoff(current time.Duration, err error) time.Duration {
+	if errors.Is(err, ErrRateLimited) {
+		return p.retryPolicy.MaxBackoff
+	}
+	next := current * 2
+	if next > p.retryPolicy.MaxBackoff {
+		next = p.retryPolicy.MaxBackoff
+	}
+	return next
+}
+
+// reconnect replaces the streaming session with a new one
+func (p *Processor) reconnect() error {
 	p.sessionRefreshMu.Lock()
 	defer p.sessionRefreshMu.Unlock()
 
-	// Close existing connection
-	if p.wsConn != nil {
-		p.wsConn.Close()
+	// Close existing session
+	if session := p.currentSession(); session != nil {
+		session.Close()
 	}
 
-	// Create new session
-	var err error
-	p.sessionID, p.clientSecret, err = p.openaiClient.CreateSession(p.ctx, p.transcriptionConfig)
+	session, err := p.provider.Connect(p.ctx, p.refreshVocabulary())
 	if err != nil {
-		return fmt.Errorf("failed to create new transcription session: %w", err)
+		return fmt.Errorf("failed to connect to %s: %w", p.provider.Name(), err)
 	}
-	p.logger.Info("Created new transcription session", String("session_id", p.sessionID))
+
+	// Audio offsets reported by the new session start from its first chunk
+	p.sessionAudioMu.Lock()
+	p.sessionSpans = nil
+	p.sessionAudioSent = 0
+	p.sessionAudioMu.Unlock()
+
+	p.sessionMu.Lock()
+	p.session = session
+	p.sessionID = session.ID()
+	p.sessionMu.Unlock()
 
 	// Reset session start time
 	p.sessionStartTime = time.Now()
+	p.logger.Info("Connected new transcription session", String("session_id", session.ID()))
 
-	// Connect to WebSocket
-	p.wsConn, err = p.openaiClient.ConnectWebSocket(p.ctx, p.sessionID, p.clientSecret)
-	if err != nil {
-		return fmt.Errorf("failed to connect to WebSocket: %w", err)
-	}
-	p.logger.Info("Reconnected to OpenAI WebSocket")
+	p.replayPreRoll()
 
 	return nil
 }
 
+// refreshVocabulary rebuilds the terms transcription is biased toward from the airspace
+// and returns them
+func (p *Processor) refreshVocabulary() []string {
+	if p.vocabularySource == nil {
+		return nil
+	}
+
+	maxTerms := p.transcriptionConfig.VocabularyMaxTerms
+	if maxTerms <= 0 {
+		maxTerms = DefaultVocabularyMaxTerms
+	}
+	terms := p.vocabularySource.TranscriptionVocabulary(maxTerms)
+
+	p.vocabularyMu.Lock()
+	p.vocabulary = terms
+	p.vocabularyMu.Unlock()
+
+	return terms
+}
+
+// monitorVocabulary keeps the session's vocabulary in step with the airspace. Sessions
+// that can't be updated while open get the new terms when they're next reconnected.
+func (p *Processor) monitorVocabulary() {
+	interval := time.Duration(p.transcriptionConfig.VocabularyRefreshSec) * time.Second
+	if interval <= 0 {
+		interval = DefaultVocabularyRefreshSec * time.Second
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-p.ctx.Done():
+			return
+		case <-ticker.C:
+			p.vocabularyMu.Lock()
+			previous := p.vocabulary
+			p.vocabularyMu.Unlock()
+
+			terms := p.refreshVocabulary()
+			if slices.Equal(terms, previous) {
+				continue
+			}
+
+			updater, ok := p.currentSession().(VocabularyUpdater)
+			if !ok {
+				continue
+			}
+			if err := updater.UpdateVocabulary(terms); err != nil {
+				p.logger.Warn("Failed to update transcription vocabulary", Error(err))
+				continue
+			}
+			p.logger.Debug("Updated transcription vocabulary", Int("terms", len(terms)))
+		}
+	}
+}
+
 // monitorSessionDuration monitors the session duration and refreshes it before it expires
 func (p *Processor) monitorSessionDuration() {
-	// OpenAI sessions expire after 30 minutes, so refresh at 25 minutes to be safe
-	sessionRefreshInterval := 25 * time.Minute
+	sessionRefreshInterval := p.retryPolicy.MaxSessionDuration
 
 	for {
 		select {
@@ -621,7 +996,7 @@ func (p *Processor) monitorSessionDuration() {
 					String("session_duration", sessionDuration.String()),
 					String("refresh_interval", sessionRefreshInterval.String()))
 
-				if err := p.reconnectOpenAI(); err != nil {
+				if err := p.reconnect(); err != nil {
 					p.logger.Error("Failed to proactively refresh session",
 						String("frequency_id", p.frequencyID),
 						Error(err))
@@ -0,0 +1,162 @@
+package transcription
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/yegors/co-atc/pkg/logger"
+)
+
+// Speech-to-text providers
+const (
+	ProviderOpenAI   = "openai"
+	ProviderDeepgram = "deepgram"
+)
+
+// ErrRateLimited is returned when a provider refuses a connection because of its rate limits
+var ErrRateLimited = errors.New("rate limited by transcription provider")
+
+// ProviderEventType is the kind of event a provider session reports
+type ProviderEventType int
+
+const (
+	EventIgnored        ProviderEventType = iota // Provider housekeeping the processor doesn't need
+	EventSpeechStarted                           // A transmission started
+	EventSpeechStopped                           // A transmission ended
+	EventDelta                                   // Partial transcript of a transmission
+	EventCompleted                               // Final transcript of a transmission
+	EventError                                   // Error reported by the provider; the session may still be usable
+	EventSessionExpired                          // The session can't be used anymore and must be replaced
+)
+
+// ProviderEvent is an event of a streaming transcription session, normalized across providers
+type ProviderEvent struct {
+	Type        ProviderEventType
+	ItemID      string         // Identifies the transmission; shared by its start, stop, delta and completed events
+	Text        string         // Transcript (delta or completed) or error message
+	AudioOffset *time.Duration // Position of a speech start or stop in the session's audio, if known
+	Confidence  *float64       // Confidence of a completed transcript (0-1), if the provider reports one
+	Words       []ProviderWord // Word timings of a completed transcript, if the provider reports them
+}
+
+// ProviderWord is a word of a completed transcript, positioned in the session's audio
+type ProviderWord struct {
+	Text       string
+	Start      time.Duration
+	End        time.Duration
+	Confidence float64
+	Speaker    *int // Speaker label from diarization (nil if the provider doesn't diarize)
+}
+
+// RetryPolicy is how the processor reconnects to a provider. Providers differ in how long
+// sessions live and how quickly new connections are accepted.
+type RetryPolicy struct {
+	MaxReconnectAttempts int           // Consecutive failed reconnects before giving up
+	InitialBackoff       time.Duration // Wait before the first reconnect; doubles on every failure
+	MaxBackoff           time.Duration
+	MaxSessionDuration   time.Duration // Sessions are refreshed before this age (0 = no limit)
+	ConnectsPerMinute    int           // New sessions per minute across all frequencies (0 = no limit)
+}
+
+// Provider is a streaming speech-to-text service. One provider is shared by the
+// processors of every frequency.
+type Provider interface {
+	Name() string
+	// Model returns the speech-to-text model sessions transcribe with
+	Model() string
+	// Connect opens a streaming session for one frequency, biased toward the vocabulary
+	// terms (callsigns, runways and fixes as spoken) if there are any
+	Connect(ctx context.Context, vocabulary []string) (ProviderSession, error)
+	RetryPolicy() RetryPolicy
+}
+
+// ProviderSession is a streaming connection to a provider. The audio sent is PCM16 in the
+// transcription format; SendAudio and Receive may be called from different goroutines.
+type ProviderSession interface {
+	ID() string
+	SendAudio(pcm []byte) error
+	// Receive blocks until the provider reports an event
+	Receive() (ProviderEvent, error)
+	Close() error
+}
+
+// VocabularyUpdater is implemented by sessions whose vocabulary can change while they're
+// open. Other sessions pick up a new vocabulary when they're reconnected.
+type VocabularyUpdater interface {
+	UpdateVocabulary(vocabulary []string) error
+}
+
+// NewProvider creates the provider selected in the configuration
+func NewProvider(config Config, logger *logger.Logger) (Provider, error) {
+	switch config.Provider {
+	case "", ProviderOpenAI:
+		if config.OpenAIAPIKey == "" {
+			return nil, fmt.Errorf("OpenAI API key is required for the openai transcription provider")
+		}
+		return newOpenAIProvider(config, logger), nil
+	case ProviderDeepgram:
+		if config.Deepgram.APIKey == "" {
+			return nil, fmt.Errorf("Deepgram API key is required for the deepgram transcription provider")
+		}
+		return newDeepgramProvider(config, logger), nil
+	default:
+		return nil, fmt.Errorf("unknown transcription provider: %s", config.Provider)
+	}
+}
+
+// retryPolicyWithOverrides applies the configured retry settings to a provider's defaults
+func retryPolicyWithOverrides(policy RetryPolicy, maxAttempts, initialBackoffMs, maxBackoffMs int) RetryPolicy {
+	if maxAttempts > 0 {
+		policy.MaxReconnectAttempts = maxAttempts
+	}
+	if initialBackoffMs > 0 {
+		policy.InitialBackoff = time.Duration(initialBackoffMs) * time.Millisecond
+	}
+	if maxBackoffMs > 0 {
+		policy.MaxBackoff = time.Duration(maxBackoffMs) * time.Millisecond
+	}
+	return policy
+}
+
+// connectLimiter spaces out new sessions so reconnect storms across frequencies stay within
+// a provider's rate limits
+type connectLimiter struct {
+	interval time.Duration
+	next     time.Time
+	mu       sync.Mutex
+}
+
+// newConnectLimiter creates a limiter for the given connections per minute (0 = no limit)
+func newConnectLimiter(perMinute int) *connectLimiter {
+	limiter := &connectLimiter{}
+	if perMinute > 0 {
+		limiter.interval = time.Minute / time.Duration(perMinute)
+	}
+	return limiter
+}
+
+// Wait blocks until another connection may be opened
+func (l *connectLimiter) Wait(ctx context.Context) error {
+	if l.interval == 0 {
+		return nil
+	}
+
+	l.mu.Lock()
+	now := time.Now()
+	slot := l.next
+	if slot.Before(now) {
+		slot = now
+	}
+	l.next = slot.Add(l.interval)
+	l.mu.Unlock()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-time.After(time.Until(slot)):
+		return nil
+	}
+}
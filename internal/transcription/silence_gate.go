@@ -0,0 +1,109 @@
+package transcription
+
+import (
+	"sync/atomic"
+	"time"
+
+	"github.com/yegors/co-atc/internal/audio"
+)
+
+// GateStats counts the audio of a frequency that was streamed to the provider and the audio the
+// silence gate held back. It outlives processors, so restarts don't reset the totals.
+type GateStats struct {
+	sent    atomic.Int64 // Nanoseconds of audio streamed
+	skipped atomic.Int64 // Nanoseconds of audio held back as silence
+}
+
+// GateReport is the silence gating summary of a frequency
+type GateReport struct {
+	FrequencyID           string  `json:"frequency_id"`
+	StreamedMinutes       float64 `json:"streamed_minutes"`
+	SkippedMinutes        float64 `json:"skipped_minutes"`
+	EstimatedMinutesSaved float64 `json:"estimated_minutes_saved"`
+	SavedPercent          float64 `json:"saved_percent"`
+}
+
+// report summarizes the counters of a frequency
+func (s *GateStats) report(frequencyID string) GateReport {
+	sent := time.Duration(s.sent.Load()).Minutes()
+	skipped := time.Duration(s.skipped.Load()).Minutes()
+
+	report := GateReport{
+		FrequencyID:     frequencyID,
+		StreamedMinutes: sent,
+		SkippedMinutes:  skipped,
+		// Streaming providers bill by the minute of audio streamed
+		EstimatedMinutesSaved: skipped,
+	}
+	if total := sent + skipped; total > 0 {
+		report.SavedPercent = skipped / total * 100
+	}
+	return report
+}
+
+// gatedChunk is a chunk of audio and when it was read from the frequency
+type gatedChunk struct {
+	data       []byte
+	capturedAt time.Time
+}
+
+// silenceGate decides which audio chunks are worth streaming to the provider. A level squelch
+// detects transmissions locally; audio is streamed from silence_padding_ms before a
+// transmission until the hang time after it, so the provider's VAD still sees the start of
+// speech and enough silence to end the turn.
+type silenceGate struct {
+	meter      *audio.LevelMeter
+	padding    time.Duration
+	lead       []gatedChunk // Recent silent audio, streamed as lead-in when a transmission starts
+	leadLength time.Duration
+	bytesPerMs int
+	stats      *GateStats
+}
+
+// newSilenceGate creates a silence gate for PCM16 audio in the transcription format
+func newSilenceGate(config Config, stats *GateStats) *silenceGate {
+	return &silenceGate{
+		meter: audio.NewLevelMeter(audio.SquelchConfig{
+			ThresholdDB: config.SilenceThresholdDB,
+			HangTime:    time.Duration(config.SilenceHangMs) * time.Millisecond,
+		}, config.FFmpegSampleRate, config.FFmpegChannels),
+		padding:    time.Duration(config.SilencePaddingMs) * time.Millisecond,
+		bytesPerMs: config.FFmpegSampleRate * config.FFmpegChannels * 2 / 1000,
+		stats:      stats,
+	}
+}
+
+// filter measures a chunk and returns the chunks to stream: nothing during silence, the
+// lead-in followed by the chunk when a transmission starts, and the chunk while one is open
+func (g *silenceGate) filter(chunk []byte, capturedAt time.Time) []gatedChunk {
+	g.meter.Write(chunk)
+	current := gatedChunk{data: chunk, capturedAt: capturedAt}
+
+	if !g.meter.SquelchOpen() {
+		g.lead = append(g.lead, current)
+		g.leadLength += g.duration(chunk)
+		for len(g.lead) > 0 && g.leadLength > g.padding {
+			dropped := g.lead[0]
+			g.lead = g.lead[1:]
+			g.leadLength -= g.duration(dropped.data)
+			g.stats.skipped.Add(int64(g.duration(dropped.data)))
+		}
+		return nil
+	}
+
+	chunks := append(g.lead, current)
+	g.lead = nil
+	g.leadLength = 0
+	for _, c := range chunks {
+		g.stats.sent.Add(int64(g.duration(c.data)))
+	}
+	return chunks
+}
+
+// duration returns the length of a PCM16 chunk
+func (g *silenceGate) duration(chunk []byte) time.Duration {
+	if g.bytesPerMs <= 0 {
+		return 0
+	}
+	return time.Duration(len(chunk)/g.bytesPerMs) * time.Millisecond
+}
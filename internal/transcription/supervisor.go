@@ -0,0 +1,337 @@
+package transcription
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/yegors/co-atc/internal/audio"
+	"github.com/yegors/co-atc/pkg/logger"
+)
+
+// Worker restart defaults, used when the configuration doesn't set them
+const (
+	DefaultWorkerRestartSec    = 5
+	DefaultWorkerMaxRestartSec = 300
+)
+
+// workerStableAfter is how long a worker must run before its failures in a row are forgotten
+const workerStableAfter = 2 * time.Minute
+
+// Worker states
+const (
+	WorkerWaiting    = "waiting"    // Waiting for one of the max_concurrent_sessions slots
+	WorkerStarting   = "starting"   // Connecting to the provider
+	WorkerRunning    = "running"    // Transcribing
+	WorkerRestarting = "restarting" // Waiting to restart after a failure
+)
+
+// WorkerStatus is the state of a frequency's transcription worker
+type WorkerStatus struct {
+	FrequencyID  string     `json:"frequency_id"`
+	Name         string     `json:"name"`
+	State        string     `json:"state"`
+	Restarts     int        `json:"restarts"` // Restarts after failures since the worker was added
+	Failures     int        `json:"failures"` // Failures in a row, reset after running for a while
+	LastError    string     `json:"last_error,omitempty"`
+	LastErrorAt  *time.Time `json:"last_error_at,omitempty"`
+	RunningSince *time.Time `json:"running_since,omitempty"`
+	RestartAt    *time.Time `json:"restart_at,omitempty"`
+}
+
+// WorkerReport is the state of all transcription workers
+type WorkerReport struct {
+	MaxSessions    int            `json:"max_sessions"` // 0 = no limit
+	ActiveSessions int            `json:"active_sessions"`
+	Workers        []WorkerStatus `json:"workers"`
+}
+
+// processorFactory creates the processor of a frequency reading from its audio
+type processorFactory func(ctx context.Context, frequencyID string, ap *audio.CentralAudioProcessor) (ProcessorInterface, error)
+
+// supervisor runs one worker per transcribed frequency. Each worker starts a processor,
+// restarts it with its own backoff when it fails or gives up, and holds one of a limited
+// number of session slots while connected, so the number of concurrent realtime sessions
+// stays within the cap.
+type supervisor struct {
+	newProcessor processorFactory
+	slots        chan struct{} // One per concurrent session; nil = no limit
+	maxSessions  int
+	restartMin   time.Duration
+	restartMax   time.Duration
+	logger       *logger.Logger
+
+	mu      sync.Mutex
+	workers map[string]*worker
+}
+
+// worker supervises the transcription of one frequency
+type worker struct {
+	frequencyID string
+	audio       *audio.CentralAudioProcessor
+	cancel      context.CancelFunc
+	stopped     chan struct{}
+
+	mu        sync.Mutex
+	status    WorkerStatus
+	processor ProcessorInterface
+}
+
+// newSupervisor creates a supervisor for the transcription configuration
+func newSupervisor(config Config, newProcessor processorFactory, logger *logger.Logger) *supervisor {
+	restartMin := time.Duration(config.WorkerRestartSec) * time.Second
+	if restartMin <= 0 {
+		restartMin = DefaultWorkerRestartSec * time.Second
+	}
+	restartMax := time.Duration(config.WorkerMaxRestartSec) * time.Second
+	if restartMax <= 0 {
+		restartMax = DefaultWorkerMaxRestartSec * time.Second
+	}
+	if restartMax < restartMin {
+		restartMax = restartMin
+	}
+
+	s := &supervisor{
+		newProcessor: newProcessor,
+		maxSessions:  config.MaxConcurrentSessions,
+		restartMin:   restartMin,
+		restartMax:   restartMax,
+		logger:       logger.Named("xscribe-sup"),
+		workers:      make(map[string]*worker),
+	}
+	if config.MaxConcurrentSessions > 0 {
+		s.slots = make(chan struct{}, config.MaxConcurrentSessions)
+	}
+	return s
+}
+
+// add starts a worker for a frequency and returns it, or nil if the frequency already has one
+func (s *supervisor) add(ctx context.Context, frequencyID, name string, ap *audio.CentralAudioProcessor) *worker {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, exists := s.workers[frequencyID]; exists {
+		return nil
+	}
+
+	workerCtx, cancel := context.WithCancel(ctx)
+	w := &worker{
+		frequencyID: frequencyID,
+		audio:       ap,
+		cancel:      cancel,
+		stopped:     make(chan struct{}),
+		status: WorkerStatus{
+			FrequencyID: frequencyID,
+			Name:        name,
+			State:       WorkerWaiting,
+		},
+	}
+	s.workers[frequencyID] = w
+
+	go s.run(workerCtx, w)
+	return w
+}
+
+// remove stops a frequency's worker and waits for its processor to stop
+func (s *supervisor) remove(frequencyID string) bool {
+	s.mu.Lock()
+	w, exists := s.workers[frequencyID]
+	delete(s.workers, frequencyID)
+	s.mu.Unlock()
+
+	if !exists {
+		return false
+	}
+	w.cancel()
+	<-w.stopped
+	return true
+}
+
+// removeAll stops every worker
+func (s *supervisor) removeAll() int {
+	s.mu.Lock()
+	workers := s.workers
+	s.workers = make(map[string]*worker)
+	s.mu.Unlock()
+
+	for _, w := range workers {
+		w.cancel()
+	}
+	for _, w := range workers {
+		<-w.stopped
+	}
+	return len(workers)
+}
+
+// report returns the state of every worker, by frequency ID
+func (s *supervisor) report() WorkerReport {
+	s.mu.Lock()
+	workers := make([]*worker, 0, len(s.workers))
+	for _, w := range s.workers {
+		workers = append(workers, w)
+	}
+	s.mu.Unlock()
+
+	report := WorkerReport{
+		MaxSessions: s.maxSessions,
+		Workers:     make([]WorkerStatus, 0, len(workers)),
+	}
+	for _, w := range workers {
+		w.mu.Lock()
+		status := w.status
+		w.mu.Unlock()
+		if status.State == WorkerRunning || status.State == WorkerStarting {
+			report.ActiveSessions++
+		}
+		report.Workers = append(report.Workers, status)
+	}
+	sort.Slice(report.Workers, func(i, j int) bool {
+		return report.Workers[i].FrequencyID < report.Workers[j].FrequencyID
+	})
+	return report
+}
+
+// run keeps a frequency's processor running until the worker is removed
+func (s *supervisor) run(ctx context.Context, w *worker) {
+	defer close(w.stopped)
+	defer w.setState(WorkerWaiting) // Drops the processor
+	log := s.logger.With(logger.String("frequency_id", w.frequencyID))
+
+	for {
+		w.setState(WorkerWaiting)
+		if !s.acquire(ctx) {
+			return
+		}
+
+		w.setState(WorkerStarting)
+		startedAt := time.Now()
+		processor, err := s.start(ctx, w)
+		if err == nil {
+			w.running(processor, startedAt)
+			log.Info("Transcription worker running")
+
+			select {
+			case <-ctx.Done():
+				processor.Stop()
+				s.release()
+				return
+			case <-processor.Done():
+				err = processor.Err()
+				processor.Stop()
+			}
+		}
+		s.release()
+		if ctx.Err() != nil {
+			return
+		}
+		if err == nil {
+			err = errors.New("processor stopped")
+		}
+
+		wait := w.failed(err, time.Since(startedAt) >= workerStableAfter, s.restartMin, s.restartMax)
+		log.Warn("Transcription worker failed, restarting",
+			logger.Error(err),
+			logger.Duration("restart_in", wait))
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(wait):
+		}
+	}
+}
+
+// start creates and starts a worker's processor
+func (s *supervisor) start(ctx context.Context, w *worker) (ProcessorInterface, error) {
+	processor, err := s.newProcessor(ctx, w.frequencyID, w.audio)
+	if err != nil {
+		return nil, err
+	}
+	if err := processor.Start(); err != nil {
+		return nil, err
+	}
+	return processor, nil
+}
+
+// acquire takes a session slot, waiting for one if all are taken. It returns false if the
+// worker was removed while waiting.
+func (s *supervisor) acquire(ctx context.Context) bool {
+	if s.slots == nil {
+		return ctx.Err() == nil
+	}
+	select {
+	case s.slots <- struct{}{}:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+// release gives back a session slot
+func (s *supervisor) release() {
+	if s.slots != nil {
+		<-s.slots
+	}
+}
+
+// current returns the worker's running processor, or nil
+func (w *worker) current() ProcessorInterface {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.processor
+}
+
+// setState records a worker's state, clearing what only applies to other states
+func (w *worker) setState(state string) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.status.State = state
+	w.status.RunningSince = nil
+	w.status.RestartAt = nil
+	if state != WorkerRunning {
+		w.processor = nil
+	}
+}
+
+// running records a worker's started processor
+func (w *worker) running(processor ProcessorInterface, since time.Time) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.processor = processor
+	w.status.State = WorkerRunning
+	w.status.RunningSince = &since
+}
+
+// failed records a failure and returns how long to wait before restarting: the minimum,
+// doubled for each failure in a row up to the maximum. A worker that ran for a while
+// starts again from the minimum.
+func (w *worker) failed(err error, ranStably bool, restartMin, restartMax time.Duration) time.Duration {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if ranStably {
+		w.status.Failures = 0
+	}
+	w.status.Failures++
+	w.status.Restarts++
+	now := time.Now()
+	w.status.LastError = err.Error()
+	w.status.LastErrorAt = &now
+
+	wait := restartMin
+	for i := 1; i < w.status.Failures && wait < restartMax; i++ {
+		wait *= 2
+	}
+	if wait > restartMax {
+		wait = restartMax
+	}
+
+	restartAt := now.Add(wait)
+	w.status.State = WorkerRestarting
+	w.status.RunningSince = nil
+	w.status.RestartAt = &restartAt
+	w.processor = nil
+	return wait
+}
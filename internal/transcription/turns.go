@@ -0,0 +1,209 @@
+package transcription
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/yegors/co-atc/internal/storage/sqlite"
+)
+
+// squelchHistory is how long transmissions detected by the squelch are kept for splitting
+// transcripts. Transcripts complete within seconds of the audio, so this is generous.
+const squelchHistory = 5 * time.Minute
+
+// squelchSpan is a transmission detected by the frequency's level squelch
+type squelchSpan struct {
+	start time.Time
+	end   time.Time
+}
+
+// recordTransmission keeps a transmission detected by the squelch, so a transcript that
+// covers several transmissions can be split between them
+func (p *Processor) recordTransmission(start, end time.Time) {
+	p.squelchMu.Lock()
+	defer p.squelchMu.Unlock()
+
+	cutoff := time.Now().Add(-squelchHistory)
+	kept := p.squelchSpans[:0]
+	for _, span := range p.squelchSpans {
+		if span.end.After(cutoff) {
+			kept = append(kept, span)
+		}
+	}
+	p.squelchSpans = append(kept, squelchSpan{start: start, end: end})
+}
+
+// transmissionBoundaries returns where one transmission hands over to the next within a
+// transcript's time span: the middle of the gap between transmissions the squelch saw
+func (p *Processor) transmissionBoundaries(start, end time.Time) []time.Time {
+	p.squelchMu.Lock()
+	defer p.squelchMu.Unlock()
+
+	var boundaries []time.Time
+	var previous *squelchSpan
+	for i := range p.squelchSpans {
+		span := &p.squelchSpans[i]
+		if !span.end.After(start) || !span.start.Before(end) {
+			continue
+		}
+		if previous != nil && span.start.After(previous.end) {
+			boundaries = append(boundaries, previous.end.Add(span.start.Sub(previous.end)/2))
+		}
+		previous = span
+	}
+	return boundaries
+}
+
+// splitTurns splits a completed transcript into one event per speaker turn. Turns end at
+// the boundaries between transmissions the squelch detected and, with diarization, where
+// the speaker changes. Words place the split exactly; without them, the text is split at
+// the punctuation nearest to where the boundary falls in time.
+func (p *Processor) splitTurns(event *TranscriptionEvent) []*TranscriptionEvent {
+	var boundaries []time.Time
+	if event.AudioStart != nil && event.AudioEnd != nil {
+		boundaries = p.transmissionBoundaries(*event.AudioStart, *event.AudioEnd)
+	}
+
+	var turns []*TranscriptionEvent
+	if len(event.Words) > 0 {
+		turns = splitWords(event, boundaries)
+	} else if len(boundaries) > 0 {
+		turns = splitText(event, boundaries)
+	}
+	if len(turns) < 2 {
+		return []*TranscriptionEvent{event}
+	}
+
+	// The first turn keeps the transmission's correlation ID, so delta events still match it
+	for i, turn := range turns {
+		if i > 0 {
+			turn.CorrelationID = fmt.Sprintf("%s-%d", event.CorrelationID, i+1)
+		}
+	}
+	return turns
+}
+
+// splitWords groups a transcript's words into turns at the boundaries and at speaker changes
+func splitWords(event *TranscriptionEvent, boundaries []time.Time) []*TranscriptionEvent {
+	var groups [][]sqlite.TranscriptionWord
+	next := 0 // Next boundary to cross
+	for i, word := range event.Words {
+		newTurn := i == 0
+		middle := word.Start.Add(word.End.Sub(word.Start) / 2)
+		for next < len(boundaries) && !middle.Before(boundaries[next]) {
+			next++
+			newTurn = true
+		}
+		if i > 0 {
+			previous := event.Words[i-1].Speaker
+			if previous != nil && word.Speaker != nil && *previous != *word.Speaker {
+				newTurn = true
+			}
+		}
+
+		if newTurn {
+			groups = append(groups, nil)
+		}
+		groups[len(groups)-1] = append(groups[len(groups)-1], word)
+	}
+
+	turns := make([]*TranscriptionEvent, 0, len(groups))
+	for i, words := range groups {
+		texts := make([]string, len(words))
+		sum := 0.0
+		for j, word := range words {
+			texts[j] = word.Word
+			sum += word.Confidence
+		}
+		confidence := sum / float64(len(words))
+		start, end := words[0].Start, words[len(words)-1].End
+
+		turn := *event
+		turn.Text = strings.Join(texts, " ")
+		turn.Words = words
+		turn.Confidence = &confidence
+		if i > 0 || turn.AudioStart == nil {
+			turn.AudioStart = &start
+		}
+		if i < len(groups)-1 || turn.AudioEnd == nil {
+			turn.AudioEnd = &end
+		}
+		turns = append(turns, &turn)
+	}
+	return turns
+}
+
+// splitText divides a transcript without word timings at the boundaries. The text is
+// assumed to be spoken at an even pace, so a boundary 40% into the audio falls near 40%
+// into the text; the split is made at the nearest punctuation, or not at all if there is
+// none close by.
+func splitText(event *TranscriptionEvent, boundaries []time.Time) []*TranscriptionEvent {
+	text := event.Text
+	span := event.AudioEnd.Sub(*event.AudioStart)
+	if span <= 0 || len(text) == 0 {
+		return nil
+	}
+
+	// Positions just after punctuation that can end a transmission
+	var candidates []int
+	for i := 0; i < len(text)-1; i++ {
+		if strings.ContainsRune(".?!,;", rune(text[i])) && text[i+1] == ' ' {
+			candidates = append(candidates, i+1)
+		}
+	}
+
+	maxDistance := len(text) / 4
+	var splits []int
+	var splitTimes []time.Time
+	for _, boundary := range boundaries {
+		target := int(float64(len(text)) * float64(boundary.Sub(*event.AudioStart)) / float64(span))
+		best := -1
+		for _, candidate := range candidates {
+			if len(splits) > 0 && candidate <= splits[len(splits)-1] {
+				continue
+			}
+			distance := abs(candidate - target)
+			if distance <= maxDistance && (best < 0 || distance < abs(best-target)) {
+				best = candidate
+			}
+		}
+		if best >= 0 {
+			splits = append(splits, best)
+			splitTimes = append(splitTimes, boundary)
+		}
+	}
+
+	turns := make([]*TranscriptionEvent, 0, len(splits)+1)
+	from := 0
+	for i := 0; i <= len(splits); i++ {
+		to := len(text)
+		if i < len(splits) {
+			to = splits[i]
+		}
+
+		turn := *event
+		turn.Text = strings.TrimSpace(text[from:to])
+		if i > 0 {
+			start := splitTimes[i-1]
+			turn.AudioStart = &start
+		}
+		if i < len(splits) {
+			end := splitTimes[i]
+			turn.AudioEnd = &end
+		}
+		if turn.Text != "" {
+			turns = append(turns, &turn)
+		}
+		from = to
+	}
+	return turns
+}
+
+// abs returns the absolute value of an int
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
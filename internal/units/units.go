@@ -0,0 +1,198 @@
+package units
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"strings"
+)
+
+// System is a unit system API and WebSocket payloads can be converted to
+type System string
+
+// Unit systems. Aviation is what the payloads are built in: feet, knots, nautical miles,
+// feet per minute and statute miles of visibility.
+const (
+	Aviation System = "aviation" // ft, kt, NM, ft/min, SM
+	Imperial System = "imperial" // ft, mph, mi, ft/min, SM
+	Metric   System = "metric"   // m, km/h, km, m/s, km
+)
+
+// Header is the request header selecting a unit system, overridden by the units query parameter
+const Header = "X-Units"
+
+// ErrUnknownSystem is returned for a unit system that isn't aviation, imperial or metric
+var ErrUnknownSystem = errors.New("unknown unit system")
+
+// quantity is a kind of value payloads carry, with its unit in each system
+type quantity struct {
+	name     string
+	suffixes []string // Key suffixes naming the aviation unit, replaced when converted
+	decimals int      // Converted values are rounded to this many decimals
+	units    map[System]unit
+}
+
+// unit is how a quantity is expressed in a system
+type unit struct {
+	symbol string
+	suffix string  // Key suffix in this system
+	factor float64 // Multiplies the aviation value
+}
+
+var (
+	altitude = quantity{name: "altitude", suffixes: []string{"_ft", "_feet"}, decimals: 0, units: map[System]unit{
+		Aviation: {"ft", "_ft", 1},
+		Imperial: {"ft", "_ft", 1},
+		Metric:   {"m", "_m", 0.3048},
+	}}
+	speed = quantity{name: "speed", suffixes: []string{"_kt"}, decimals: 1, units: map[System]unit{
+		Aviation: {"kt", "_kt", 1},
+		Imperial: {"mph", "_mph", 1.150779},
+		Metric:   {"km/h", "_kmh", 1.852},
+	}}
+	distance = quantity{name: "distance", suffixes: []string{"_nm"}, decimals: 2, units: map[System]unit{
+		Aviation: {"NM", "_nm", 1},
+		Imperial: {"mi", "_mi", 1.150779},
+		Metric:   {"km", "_km", 1.852},
+	}}
+	verticalRate = quantity{name: "vertical_rate", suffixes: []string{"_fpm"}, decimals: 2, units: map[System]unit{
+		Aviation: {"ft/min", "_fpm", 1},
+		Imperial: {"ft/min", "_fpm", 1},
+		Metric:   {"m/s", "_ms", 0.00508},
+	}}
+	visibility = quantity{name: "visibility", suffixes: []string{"_sm"}, decimals: 1, units: map[System]unit{
+		Aviation: {"SM", "_sm", 1},
+		Imperial: {"SM", "_sm", 1},
+		Metric:   {"km", "_km", 1.609344},
+	}}
+)
+
+// quantities are the quantities converted, in the order their units are listed
+var quantities = []*quantity{&altitude, &speed, &distance, &verticalRate, &visibility}
+
+// fields are the payload keys without a unit suffix that are converted. Keys ending in a
+// quantity's suffix are converted too.
+var fields = map[string]*quantity{
+	"alt": &altitude, "alt_baro": &altitude, "alt_geom": &altitude, "altitude": &altitude,
+	"current_altitude": &altitude, "nav_altitude_fms": &altitude, "nav_altitude_mcp": &altitude,
+	"rel_altitude": &altitude,
+
+	"gs": &speed, "ias": &speed, "tas": &speed, "speed_gs": &speed, "speed_true": &speed,
+	"target_speed": &speed,
+
+	"distance": &distance, "rel_distance": &distance,
+
+	"baro_rate": &verticalRate, "geom_rate": &verticalRate, "vert_rate": &verticalRate,
+	"vertical_rate": &verticalRate, "vertical_speed": &verticalRate, "target_vertical_rate": &verticalRate,
+}
+
+// Parse returns the unit system with a name; an empty name is aviation
+func Parse(name string) (System, error) {
+	switch System(strings.ToLower(strings.TrimSpace(name))) {
+	case "", Aviation:
+		return Aviation, nil
+	case Imperial:
+		return Imperial, nil
+	case Metric:
+		return Metric, nil
+	}
+	return "", fmt.Errorf("%w: %s (use aviation, imperial or metric)", ErrUnknownSystem, name)
+}
+
+// FromRequest returns the unit system a request selects with the units query parameter or
+// the X-Units header
+func FromRequest(r *http.Request) (System, error) {
+	if name := r.URL.Query().Get("units"); name != "" {
+		return Parse(name)
+	}
+	return Parse(r.Header.Get(Header))
+}
+
+// Units returns the unit of each quantity in the system, e.g. {"altitude": "m"}
+func (s System) Units() map[string]string {
+	units := make(map[string]string, len(quantities))
+	for _, q := range quantities {
+		units[q.name] = q.units[s].symbol
+	}
+	return units
+}
+
+// ConvertJSON converts the values of a JSON document from aviation units to the system.
+// Keys with the suffix of a converted unit are renamed to the system's (altitude_ft becomes
+// altitude_m).
+func (s System) ConvertJSON(data []byte) ([]byte, error) {
+	if s == Aviation || s == "" {
+		return data, nil
+	}
+
+	// Numbers are kept as written unless they're converted, so large IDs survive
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	var document interface{}
+	if err := decoder.Decode(&document); err != nil {
+		return nil, fmt.Errorf("failed to decode payload: %w", err)
+	}
+	converted, err := json.Marshal(s.convert(document))
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode payload: %w", err)
+	}
+	return converted, nil
+}
+
+// convert converts the values of a decoded JSON value
+func (s System) convert(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		converted := make(map[string]interface{}, len(v))
+		for key, item := range v {
+			q, renamed := s.field(key)
+			if q == nil {
+				converted[key] = s.convert(item)
+				continue
+			}
+			switch number := item.(type) {
+			case json.Number:
+				if value, err := number.Float64(); err == nil {
+					scale := math.Pow(10, float64(q.decimals))
+					converted[renamed] = math.Round(value*q.units[s].factor*scale) / scale
+					continue
+				}
+			case nil:
+				// Unknown values keep the key of the unit they'd be in
+				converted[renamed] = nil
+				continue
+			}
+			converted[key] = s.convert(item)
+		}
+		return converted
+	case []interface{}:
+		for i, item := range v {
+			v[i] = s.convert(item)
+		}
+		return v
+	}
+	return value
+}
+
+// field returns the quantity of a key, if it's converted, and the key in the system
+func (s System) field(key string) (*quantity, string) {
+	if q, ok := fields[key]; ok {
+		return q, key
+	}
+	for _, q := range quantities {
+		for _, suffix := range q.suffixes {
+			if !strings.HasSuffix(key, suffix) {
+				continue
+			}
+			if q.units[s].factor == 1 {
+				// Same unit as aviation; the key stays as it is
+				return q, key
+			}
+			return q, strings.TrimSuffix(key, suffix) + q.units[s].suffix
+		}
+	}
+	return nil, key
+}
@@ -0,0 +1,57 @@
+package usage
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/yegors/co-atc/internal/storage/sqlite"
+)
+
+// WritePrometheus writes the usage since startup and the current budget spending in the
+// Prometheus text exposition format
+func (t *Tracker) WritePrometheus(w io.Writer) error {
+	t.mu.Lock()
+	totals := make([]sqlite.UsageDay, 0, len(t.totals))
+	for _, d := range t.totals {
+		totals = append(totals, *d)
+	}
+	dayCost, monthCost := t.dayCost, t.monthCost
+	t.mu.Unlock()
+
+	sort.Slice(totals, func(i, j int) bool {
+		if totals[i].Subsystem != totals[j].Subsystem {
+			return totals[i].Subsystem < totals[j].Subsystem
+		}
+		return totals[i].Model < totals[j].Model
+	})
+
+	var b strings.Builder
+	counter := func(name, help string, value func(i int) string) {
+		fmt.Fprintf(&b, "# HELP %s %s\n# TYPE %s counter\n", name, help, name)
+		for i, total := range totals {
+			fmt.Fprintf(&b, "%s{subsystem=%q,model=%q} %s\n", name, total.Subsystem, total.Model, value(i))
+		}
+	}
+	counter("co_atc_api_requests_total", "API requests and sessions since startup.",
+		func(i int) string { return fmt.Sprint(totals[i].Requests) })
+	counter("co_atc_api_input_tokens_total", "Input tokens used since startup.",
+		func(i int) string { return fmt.Sprint(totals[i].InputTokens) })
+	counter("co_atc_api_output_tokens_total", "Output tokens used since startup.",
+		func(i int) string { return fmt.Sprint(totals[i].OutputTokens) })
+	counter("co_atc_api_audio_seconds_total", "Seconds of audio streamed since startup.",
+		func(i int) string { return fmt.Sprint(totals[i].AudioSeconds) })
+	counter("co_atc_api_cost_usd_total", "Estimated API cost in US dollars since startup.",
+		func(i int) string { return fmt.Sprint(totals[i].CostUSD) })
+
+	fmt.Fprintf(&b, "# HELP co_atc_api_cost_usd Estimated API cost in US dollars of the current period (UTC).\n# TYPE co_atc_api_cost_usd gauge\n")
+	fmt.Fprintf(&b, "co_atc_api_cost_usd{period=\"daily\"} %v\n", dayCost)
+	fmt.Fprintf(&b, "co_atc_api_cost_usd{period=\"monthly\"} %v\n", monthCost)
+	fmt.Fprintf(&b, "# HELP co_atc_api_budget_usd Configured API budget in US dollars (0 = none).\n# TYPE co_atc_api_budget_usd gauge\n")
+	fmt.Fprintf(&b, "co_atc_api_budget_usd{period=\"daily\"} %v\n", t.config.DailyBudgetUSD)
+	fmt.Fprintf(&b, "co_atc_api_budget_usd{period=\"monthly\"} %v\n", t.config.MonthlyBudgetUSD)
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
@@ -0,0 +1,74 @@
+package usage
+
+import (
+	"strings"
+
+	"github.com/yegors/co-atc/internal/config"
+)
+
+// defaultPrices are the list prices in US dollars of the models co-atc uses out of the box.
+// They're estimates: providers change prices and bill some usage, such as cached or audio
+// tokens, at other rates. Prices in the configuration override them.
+var defaultPrices = map[string]config.UsagePrice{
+	// Streaming transcription, billed by audio minute
+	"gpt-4o-transcribe":      {AudioPerMinute: 0.006},
+	"gpt-4o-mini-transcribe": {AudioPerMinute: 0.003},
+	"whisper-1":              {AudioPerMinute: 0.006},
+	"nova-3":                 {AudioPerMinute: 0.0077},
+	"nova-2":                 {AudioPerMinute: 0.0058},
+
+	// Post-processing
+	"gpt-4o":       {InputPerMillion: 2.50, OutputPerMillion: 10.00},
+	"gpt-4o-mini":  {InputPerMillion: 0.15, OutputPerMillion: 0.60},
+	"gpt-4.1":      {InputPerMillion: 2.00, OutputPerMillion: 8.00},
+	"gpt-4.1-mini": {InputPerMillion: 0.40, OutputPerMillion: 1.60},
+
+	// ATC chat realtime sessions, billed by token with audio at the audio token rate
+	"gpt-4o-realtime-preview":      {InputPerMillion: 40.00, OutputPerMillion: 80.00},
+	"gpt-4o-mini-realtime-preview": {InputPerMillion: 10.00, OutputPerMillion: 20.00},
+
+	// Briefing text-to-speech, estimated per minute of speech
+	"gpt-4o-mini-tts": {AudioPerMinute: 0.015},
+	"tts-1":           {AudioPerMinute: 0.015},
+	"tts-1-hd":        {AudioPerMinute: 0.030},
+}
+
+// priceList finds the price of a model
+type priceList map[string]config.UsagePrice
+
+// newPriceList merges the configured prices over the defaults
+func newPriceList(overrides map[string]config.UsagePrice) priceList {
+	prices := make(priceList, len(defaultPrices)+len(overrides))
+	for model, price := range defaultPrices {
+		prices[model] = price
+	}
+	for model, price := range overrides {
+		prices[model] = price
+	}
+	return prices
+}
+
+// lookup returns the price of a model. Dated snapshots such as
+// gpt-4o-realtime-preview-2024-12-17 use the price of the longest model name they start with.
+func (p priceList) lookup(model string) (config.UsagePrice, bool) {
+	if price, ok := p[model]; ok {
+		return price, true
+	}
+	best := ""
+	for name := range p {
+		if strings.HasPrefix(model, name+"-") && len(name) > len(best) {
+			best = name
+		}
+	}
+	if best == "" {
+		return config.UsagePrice{}, false
+	}
+	return p[best], true
+}
+
+// cost returns the estimated cost of usage at a price
+func cost(price config.UsagePrice, u Usage) float64 {
+	return float64(u.InputTokens)/1e6*price.InputPerMillion +
+		float64(u.OutputTokens)/1e6*price.OutputPerMillion +
+		u.AudioSeconds/60*price.AudioPerMinute
+}
@@ -0,0 +1,377 @@
+// Package usage accounts for the tokens, audio minutes and estimated cost of the paid APIs
+// co-atc calls, and raises alerts when spending nears a budget
+package usage
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/yegors/co-atc/internal/config"
+	"github.com/yegors/co-atc/internal/storage/sqlite"
+	"github.com/yegors/co-atc/internal/websocket"
+	"github.com/yegors/co-atc/pkg/logger"
+)
+
+// Subsystems that use paid APIs
+const (
+	SubsystemTranscription  = "transcription"   // Streaming speech-to-text
+	SubsystemPostProcessing = "post_processing" // LLM clean-up of transcriptions
+	SubsystemATCChat        = "atc_chat"        // ATC chat realtime voice sessions
+	SubsystemBriefing       = "briefing"        // Text-to-speech of airspace briefings
+	SubsystemATIS           = "atis"            // Text-to-speech of the synthesized ATIS
+)
+
+// dayFormat is how days are keyed in the daily aggregates
+const dayFormat = "2006-01-02"
+
+// Usage is what one or more API calls used
+type Usage struct {
+	Requests     int64
+	InputTokens  int64
+	OutputTokens int64
+	AudioSeconds float64
+}
+
+// key identifies the usage of one model by one subsystem
+type key struct {
+	subsystem string
+	model     string
+}
+
+// Summary is the response of the usage endpoint
+type Summary struct {
+	From           string                      `json:"from"`
+	To             string                      `json:"to"`
+	Days           []sqlite.UsageDay           `json:"days"`
+	Subsystems     map[string]*SubsystemTotals `json:"subsystems"`
+	TotalCost      float64                     `json:"total_cost_usd"`
+	Budgets        Budgets                     `json:"budgets"`
+	UnpricedModels []string                    `json:"unpriced_models,omitempty"` // Models used without a known price, counted at no cost
+	Timestamp      time.Time                   `json:"timestamp"`
+}
+
+// SubsystemTotals is the usage of a subsystem over the summary's days
+type SubsystemTotals struct {
+	Requests     int64   `json:"requests"`
+	InputTokens  int64   `json:"input_tokens"`
+	OutputTokens int64   `json:"output_tokens"`
+	AudioSeconds float64 `json:"audio_seconds"`
+	CostUSD      float64 `json:"cost_usd"`
+}
+
+// Budgets is the spending of the current day and month (UTC) against their budgets
+type Budgets struct {
+	DailyBudgetUSD   float64 `json:"daily_budget_usd,omitempty"`
+	DailyCostUSD     float64 `json:"daily_cost_usd"`
+	MonthlyBudgetUSD float64 `json:"monthly_budget_usd,omitempty"`
+	MonthlyCostUSD   float64 `json:"monthly_cost_usd"`
+}
+
+// Tracker accounts for API usage. Usage is kept in memory and added to the daily
+// aggregates in the settings database every flush interval. A nil Tracker ignores usage,
+// so subsystems can record unconditionally.
+type Tracker struct {
+	config   config.UsageConfig
+	prices   priceList
+	storage  *sqlite.UsageStorage
+	wsServer *websocket.Server
+	logger   *logger.Logger
+
+	mu        sync.Mutex
+	pending   map[string]map[key]*sqlite.UsageDay // Usage not yet flushed, by day
+	totals    map[key]*sqlite.UsageDay            // Usage since startup, for metrics
+	day       string                              // Current day (UTC)
+	month     string                              // Current month (UTC), YYYY-MM
+	dayCost   float64
+	monthCost float64
+	alerted   map[string]bool // Budget alerts raised, by period and level
+	unpriced  map[string]bool // Models used without a known price
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// NewTracker creates a new usage tracker
+func NewTracker(cfg config.UsageConfig, storage *sqlite.UsageStorage, wsServer *websocket.Server, logger *logger.Logger) *Tracker {
+	return &Tracker{
+		config:   cfg,
+		prices:   newPriceList(cfg.Prices),
+		storage:  storage,
+		wsServer: wsServer,
+		logger:   logger.Named("usage"),
+		pending:  make(map[string]map[key]*sqlite.UsageDay),
+		totals:   make(map[key]*sqlite.UsageDay),
+		alerted:  make(map[string]bool),
+		unpriced: make(map[string]bool),
+	}
+}
+
+// Start loads the spending of the current day and month and starts flushing usage
+func (t *Tracker) Start(ctx context.Context) error {
+	now := time.Now().UTC()
+	t.day = now.Format(dayFormat)
+	t.month = now.Format("2006-01")
+
+	days, err := t.storage.GetDailyUsage(t.month+"-01", t.day)
+	if err != nil {
+		return fmt.Errorf("failed to load usage: %w", err)
+	}
+	for _, d := range days {
+		t.monthCost += d.CostUSD
+		if d.Day == t.day {
+			t.dayCost += d.CostUSD
+		}
+	}
+
+	t.ctx, t.cancel = context.WithCancel(ctx)
+	t.wg.Add(1)
+	go t.run()
+
+	t.logger.Info("Usage tracking started",
+		logger.Float64("daily_cost_usd", t.dayCost),
+		logger.Float64("monthly_cost_usd", t.monthCost))
+	return nil
+}
+
+// Stop stops flushing and writes the usage not flushed yet
+func (t *Tracker) Stop() {
+	if t.cancel != nil {
+		t.cancel()
+	}
+	t.wg.Wait()
+	if err := t.flush(); err != nil {
+		t.logger.Error("Failed to flush usage", logger.Error(err))
+	}
+}
+
+// run flushes usage every flush interval until the tracker stops
+func (t *Tracker) run() {
+	defer t.wg.Done()
+
+	ticker := time.NewTicker(time.Duration(t.config.FlushIntervalSeconds) * time.Second)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-t.ctx.Done():
+			return
+		case <-ticker.C:
+			if err := t.flush(); err != nil {
+				t.logger.Error("Failed to flush usage", logger.Error(err))
+			}
+		}
+	}
+}
+
+// Record accounts for usage of a model by a subsystem
+func (t *Tracker) Record(subsystem, model string, u Usage) {
+	if t == nil || model == "" {
+		return
+	}
+
+	price, priced := t.prices.lookup(model)
+	c := cost(price, u)
+	now := time.Now().UTC()
+	day := now.Format(dayFormat)
+	k := key{subsystem: subsystem, model: model}
+
+	t.mu.Lock()
+	if !priced && !t.unpriced[model] {
+		t.unpriced[model] = true
+		t.logger.Warn("No price known for model, its usage is counted at no cost",
+			logger.String("model", model),
+			logger.String("subsystem", subsystem))
+	}
+
+	days, ok := t.pending[day]
+	if !ok {
+		days = make(map[key]*sqlite.UsageDay)
+		t.pending[day] = days
+	}
+	for _, agg := range []map[key]*sqlite.UsageDay{days, t.totals} {
+		d, ok := agg[k]
+		if !ok {
+			d = &sqlite.UsageDay{Day: day, Subsystem: subsystem, Model: model}
+			agg[k] = d
+		}
+		d.Requests += u.Requests
+		d.InputTokens += u.InputTokens
+		d.OutputTokens += u.OutputTokens
+		d.AudioSeconds += u.AudioSeconds
+		d.CostUSD += c
+	}
+
+	if day != t.day {
+		t.day = day
+		t.dayCost = 0
+	}
+	if month := now.Format("2006-01"); month != t.month {
+		t.month = month
+		t.monthCost = 0
+	}
+	t.dayCost += c
+	t.monthCost += c
+	alerts := t.checkBudgetsLocked()
+	t.mu.Unlock()
+
+	for _, alert := range alerts {
+		t.raiseAlert(alert)
+	}
+}
+
+// budgetAlert is spending that crossed an alert level of a budget
+type budgetAlert struct {
+	period  string // "daily" or "monthly"
+	budget  float64
+	cost    float64
+	percent float64 // Alert level, percent of the budget
+}
+
+// checkBudgetsLocked returns the alerts of budget levels crossed for the first time in
+// the current period. The caller must hold t.mu.
+func (t *Tracker) checkBudgetsLocked() []budgetAlert {
+	var alerts []budgetAlert
+	check := func(period, id string, budget, spent float64) {
+		if budget <= 0 {
+			return
+		}
+		// Only the highest level crossed is raised
+		for _, level := range []float64{100, t.config.AlertThresholdPercent} {
+			if spent < budget*level/100 {
+				continue
+			}
+			alertKey := fmt.Sprintf("%s:%s:%v", period, id, level)
+			if !t.alerted[alertKey] {
+				t.alerted[alertKey] = true
+				alerts = append(alerts, budgetAlert{period: period, budget: budget, cost: spent, percent: level})
+			}
+			return
+		}
+	}
+	check("daily", t.day, t.config.DailyBudgetUSD, t.dayCost)
+	check("monthly", t.month, t.config.MonthlyBudgetUSD, t.monthCost)
+	return alerts
+}
+
+// raiseAlert logs a budget alert and broadcasts it to clients
+func (t *Tracker) raiseAlert(alert budgetAlert) {
+	t.logger.Warn("API spending reached budget alert level",
+		logger.String("period", alert.period),
+		logger.Float64("percent", alert.percent),
+		logger.Float64("cost_usd", alert.cost),
+		logger.Float64("budget_usd", alert.budget))
+
+	if t.wsServer == nil {
+		return
+	}
+	t.wsServer.Broadcast(&websocket.Message{
+		Type: "usage_alert",
+		Data: map[string]interface{}{
+			"period":     alert.period,
+			"percent":    alert.percent,
+			"cost_usd":   alert.cost,
+			"budget_usd": alert.budget,
+			"timestamp":  time.Now().UTC(),
+		},
+	})
+}
+
+// flush adds the pending usage to the daily aggregates. If the write fails, the usage is
+// kept for the next flush.
+func (t *Tracker) flush() error {
+	t.mu.Lock()
+	pending := t.pending
+	t.pending = make(map[string]map[key]*sqlite.UsageDay)
+	t.mu.Unlock()
+
+	var usage []sqlite.UsageDay
+	for _, days := range pending {
+		for _, d := range days {
+			usage = append(usage, *d)
+		}
+	}
+	if len(usage) == 0 {
+		return nil
+	}
+
+	if err := t.storage.AddUsage(usage); err != nil {
+		t.mu.Lock()
+		for day, days := range pending {
+			current, ok := t.pending[day]
+			if !ok {
+				t.pending[day] = days
+				continue
+			}
+			for k, d := range days {
+				if c, ok := current[k]; ok {
+					c.Requests += d.Requests
+					c.InputTokens += d.InputTokens
+					c.OutputTokens += d.OutputTokens
+					c.AudioSeconds += d.AudioSeconds
+					c.CostUSD += d.CostUSD
+				} else {
+					current[k] = d
+				}
+			}
+		}
+		t.mu.Unlock()
+		return err
+	}
+	return nil
+}
+
+// GetSummary returns the usage of the days from fromDay to toDay inclusive (YYYY-MM-DD)
+// with totals by subsystem and the current budget spending
+func (t *Tracker) GetSummary(fromDay, toDay string) (*Summary, error) {
+	if err := t.flush(); err != nil {
+		return nil, fmt.Errorf("failed to flush usage: %w", err)
+	}
+
+	days, err := t.storage.GetDailyUsage(fromDay, toDay)
+	if err != nil {
+		return nil, err
+	}
+	if days == nil {
+		days = []sqlite.UsageDay{}
+	}
+
+	summary := &Summary{
+		From:       fromDay,
+		To:         toDay,
+		Days:       days,
+		Subsystems: make(map[string]*SubsystemTotals),
+		Timestamp:  time.Now().UTC(),
+	}
+	for _, d := range days {
+		totals, ok := summary.Subsystems[d.Subsystem]
+		if !ok {
+			totals = &SubsystemTotals{}
+			summary.Subsystems[d.Subsystem] = totals
+		}
+		totals.Requests += d.Requests
+		totals.InputTokens += d.InputTokens
+		totals.OutputTokens += d.OutputTokens
+		totals.AudioSeconds += d.AudioSeconds
+		totals.CostUSD += d.CostUSD
+		summary.TotalCost += d.CostUSD
+	}
+
+	t.mu.Lock()
+	summary.Budgets = Budgets{
+		DailyBudgetUSD:   t.config.DailyBudgetUSD,
+		DailyCostUSD:     t.dayCost,
+		MonthlyBudgetUSD: t.config.MonthlyBudgetUSD,
+		MonthlyCostUSD:   t.monthCost,
+	}
+	for model := range t.unpriced {
+		summary.UnpricedModels = append(summary.UnpricedModels, model)
+	}
+	t.mu.Unlock()
+	sort.Strings(summary.UnpricedModels)
+
+	return summary, nil
+}
@@ -0,0 +1,55 @@
+package weather
+
+import (
+	"fmt"
+	"net/url"
+)
+
+// aviationWeatherBaseURL is the data API of the Aviation Weather Center (aviationweather.gov)
+const aviationWeatherBaseURL = "https://aviationweather.gov/api/data"
+
+// aviationWeatherProvider fetches METARs and TAFs from the NOAA Aviation Weather Center's
+// free data API. It has no NOTAMs.
+type aviationWeatherProvider struct {
+	client *Client
+}
+
+// Name returns the provider name
+func (p *aviationWeatherProvider) Name() string {
+	return ProviderAviationWeather
+}
+
+// FetchMETAR fetches the latest METAR
+func (p *aviationWeatherProvider) FetchMETAR(airportCode string) (*Report, error) {
+	return p.fetch("metar", "rawOb", WeatherTypeMETAR, airportCode)
+}
+
+// FetchTAF fetches the current TAF
+func (p *aviationWeatherProvider) FetchTAF(airportCode string) (*Report, error) {
+	return p.fetch("taf", "rawTAF", WeatherTypeTAF, airportCode)
+}
+
+// FetchNOTAMs isn't supported
+func (p *aviationWeatherProvider) FetchNOTAMs(airportCode string) (*Report, error) {
+	return nil, ErrNotSupported
+}
+
+// fetch requests a product in JSON, which is a list of reports with the raw text in rawField
+func (p *aviationWeatherProvider) fetch(product, rawField string, weatherType WeatherType, airportCode string) (*Report, error) {
+	requestURL := fmt.Sprintf("%s/%s?ids=%s&format=json", aviationWeatherBaseURL, product, url.QueryEscape(airportCode))
+	data, err := p.client.fetchWithRetry(requestURL, nil, weatherType, airportCode)
+	if err != nil {
+		return nil, err
+	}
+
+	reports, ok := data.([]interface{})
+	if !ok || len(reports) == 0 {
+		return nil, fmt.Errorf("no %s for %s", weatherType, airportCode)
+	}
+	latest, ok := reports[0].(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("unexpected %s response format", weatherType)
+	}
+	raw, _ := latest[rawField].(string)
+	return &Report{Data: latest, Raw: raw}, nil
+}
@@ -37,7 +37,7 @@ func (c *Cache) Get() *WeatherData {
 	}
 
 	// Check if this is just the default empty data (no actual weather data fetched)
-	if data.METAR == nil && data.TAF == nil && data.NOTAMs == nil && len(data.FetchErrors) == 0 {
+	if data.METAR == nil && data.TAF == nil && data.NOTAMs == nil && data.WindsAloft == nil && data.Hazards == nil && len(data.FetchErrors) == 0 {
 		return nil
 	}
 
@@ -81,9 +81,21 @@ func (c *Cache) Update(results []FetchResult, airportCode string) {
 		METAR:       currentData.METAR,
 		TAF:         currentData.TAF,
 		NOTAMs:      currentData.NOTAMs,
+		WindsAloft:  currentData.WindsAloft,
+		Hazards:     currentData.Hazards,
+		Sources:     make(map[string]string),
 		LastUpdated: time.Now(),
 		FetchErrors: []string{},
 	}
+	for weatherType, provider := range currentData.Sources {
+		newData.Sources[weatherType] = provider
+	}
+
+	// Keep the decoded reports of types that fail to refresh
+	parsed := &ParsedWeather{}
+	if currentData.Parsed != nil {
+		*parsed = *currentData.Parsed
+	}
 
 	// Process fetch results
 	for _, result := range results {
@@ -96,8 +108,11 @@ func (c *Cache) Update(results []FetchResult, airportCode string) {
 					logger.Error(result.Err))
 			} else {
 				newData.METAR = result.Data
+				newData.Sources[string(result.Type)] = result.Provider
+				parsed.METAR = c.parseMETAR(result.Raw, airportCode)
 				c.logger.Debug("METAR data updated",
-					logger.String("airport", airportCode))
+					logger.String("airport", airportCode),
+					logger.String("provider", result.Provider))
 			}
 
 		case WeatherTypeTAF:
@@ -108,8 +123,11 @@ func (c *Cache) Update(results []FetchResult, airportCode string) {
 					logger.Error(result.Err))
 			} else {
 				newData.TAF = result.Data
+				newData.Sources[string(result.Type)] = result.Provider
+				parsed.TAF = c.parseTAF(result.Raw, airportCode)
 				c.logger.Debug("TAF data updated",
-					logger.String("airport", airportCode))
+					logger.String("airport", airportCode),
+					logger.String("provider", result.Provider))
 			}
 
 		case WeatherTypeNOTAMs:
@@ -120,12 +138,46 @@ func (c *Cache) Update(results []FetchResult, airportCode string) {
 					logger.Error(result.Err))
 			} else {
 				newData.NOTAMs = result.Data
+				newData.Sources[string(result.Type)] = result.Provider
 				c.logger.Debug("NOTAM data updated",
-					logger.String("airport", airportCode))
+					logger.String("airport", airportCode),
+					logger.String("provider", result.Provider))
+			}
+
+		case WeatherTypeWindsAloft:
+			if result.Err != nil {
+				newData.FetchErrors = append(newData.FetchErrors, fmt.Sprintf("Winds aloft: %s", result.Err.Error()))
+				c.logger.Warn("Failed to fetch winds aloft",
+					logger.String("airport", airportCode),
+					logger.Error(result.Err))
+			} else if winds, ok := result.Data.(*WindsAloft); ok {
+				newData.WindsAloft = winds
+				newData.Sources[string(result.Type)] = result.Provider
+				c.logger.Debug("Winds aloft updated",
+					logger.String("airport", airportCode),
+					logger.Time("valid_at", winds.ValidAt))
+			}
+
+		case WeatherTypeHazards:
+			if result.Err != nil {
+				newData.FetchErrors = append(newData.FetchErrors, fmt.Sprintf("Hazards: %s", result.Err.Error()))
+				c.logger.Warn("Failed to fetch weather hazards",
+					logger.String("airport", airportCode),
+					logger.Error(result.Err))
+			} else if hazards, ok := result.Data.(*Hazards); ok {
+				newData.Hazards = hazards
+				newData.Sources[string(result.Type)] = result.Provider
+				c.logger.Debug("Weather hazards updated",
+					logger.String("airport", airportCode),
+					logger.Int("count", len(hazards.Features)))
 			}
 		}
 	}
 
+	if parsed.METAR != nil || parsed.TAF != nil {
+		newData.Parsed = parsed
+	}
+
 	// Update cache with new data
 	expiryDuration := time.Duration(c.config.CacheExpiryMinutes) * time.Minute
 	c.cache.Set(newData, expiryDuration)
@@ -139,6 +191,36 @@ func (c *Cache) Update(results []FetchResult, airportCode string) {
 		logger.Time("expires_at", time.Now().Add(expiryDuration)))
 }
 
+// parseMETAR decodes a fetched METAR, or returns nil if it has no raw text or doesn't decode
+func (c *Cache) parseMETAR(raw, airportCode string) *METAR {
+	if raw == "" {
+		return nil
+	}
+	metar, err := ParseMETAR(raw, time.Now().UTC())
+	if err != nil {
+		c.logger.Debug("METAR could not be decoded",
+			logger.String("airport", airportCode),
+			logger.Error(err))
+		return nil
+	}
+	return metar
+}
+
+// parseTAF decodes a fetched TAF, or returns nil if it has no raw text or doesn't decode
+func (c *Cache) parseTAF(raw, airportCode string) *TAF {
+	if raw == "" {
+		return nil
+	}
+	taf, err := ParseTAF(raw, time.Now().UTC())
+	if err != nil {
+		c.logger.Debug("TAF could not be decoded",
+			logger.String("airport", airportCode),
+			logger.Error(err))
+		return nil
+	}
+	return taf
+}
+
 // Invalidate clears the cache
 func (c *Cache) Invalidate() {
 	c.mu.Lock()
@@ -167,6 +249,8 @@ func (c *Cache) GetStats() map[string]interface{} {
 		stats["has_metar"] = data.METAR != nil
 		stats["has_taf"] = data.TAF != nil
 		stats["has_notams"] = data.NOTAMs != nil
+		stats["has_winds_aloft"] = data.WindsAloft != nil
+		stats["has_hazards"] = data.Hazards != nil
 	}
 
 	return stats
@@ -0,0 +1,56 @@
+package weather
+
+import (
+	"fmt"
+	"net/url"
+)
+
+// checkWXBaseURL is the CheckWX API
+const checkWXBaseURL = "https://api.checkwx.com"
+
+// checkWXProvider fetches METARs and TAFs from CheckWX, which needs an API key. It has no NOTAMs.
+type checkWXProvider struct {
+	client *Client
+	apiKey string
+}
+
+// Name returns the provider name
+func (p *checkWXProvider) Name() string {
+	return ProviderCheckWX
+}
+
+// FetchMETAR fetches the latest METAR
+func (p *checkWXProvider) FetchMETAR(airportCode string) (*Report, error) {
+	return p.fetch("metar", WeatherTypeMETAR, airportCode)
+}
+
+// FetchTAF fetches the current TAF
+func (p *checkWXProvider) FetchTAF(airportCode string) (*Report, error) {
+	return p.fetch("taf", WeatherTypeTAF, airportCode)
+}
+
+// FetchNOTAMs isn't supported
+func (p *checkWXProvider) FetchNOTAMs(airportCode string) (*Report, error) {
+	return nil, ErrNotSupported
+}
+
+// fetch requests a product's raw text, which CheckWX returns as {"results": n, "data": ["..."]}
+func (p *checkWXProvider) fetch(product string, weatherType WeatherType, airportCode string) (*Report, error) {
+	requestURL := fmt.Sprintf("%s/%s/%s", checkWXBaseURL, product, url.PathEscape(airportCode))
+	headers := map[string]string{"X-API-Key": p.apiKey}
+	data, err := p.client.fetchWithRetry(requestURL, headers, weatherType, airportCode)
+	if err != nil {
+		return nil, err
+	}
+
+	response, ok := data.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("unexpected %s response format", weatherType)
+	}
+	reports, ok := response["data"].([]interface{})
+	if !ok || len(reports) == 0 {
+		return nil, fmt.Errorf("no %s for %s", weatherType, airportCode)
+	}
+	raw, _ := reports[0].(string)
+	return &Report{Data: map[string]interface{}{"raw": raw}, Raw: raw}, nil
+}
@@ -2,51 +2,92 @@ package weather
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
+	"strings"
 	"time"
 
 	"github.com/yegors/co-atc/pkg/logger"
 )
 
-// Client handles HTTP requests to weather APIs
+// Client fetches weather data from the configured providers, failing over to the next
+// provider when one can't serve a weather type
 type Client struct {
 	config     WeatherConfig
 	httpClient *http.Client
+	providers  []Provider
 	logger     *logger.Logger
 }
 
 // NewClient creates a new weather API client
 func NewClient(config WeatherConfig, logger *logger.Logger) *Client {
-	return &Client{
+	c := &Client{
 		config: config,
 		httpClient: &http.Client{
 			Timeout: time.Duration(config.RequestTimeoutSeconds) * time.Second,
 		},
 		logger: logger.Named("weather-client"),
 	}
-}
 
-// FetchMETAR fetches METAR data for the specified airport
-func (c *Client) FetchMETAR(airportCode string) (interface{}, error) {
-	url := fmt.Sprintf("%s/metar/%s", c.config.APIBaseURL, airportCode)
-	return c.fetchWithRetry(url, WeatherTypeMETAR, airportCode)
+	c.addProviders(config.Providers)
+	return c
 }
 
-// FetchTAF fetches TAF data for the specified airport
-func (c *Client) FetchTAF(airportCode string) (interface{}, error) {
-	url := fmt.Sprintf("%s/taf/%s", c.config.APIBaseURL, airportCode)
-	return c.fetchWithRetry(url, WeatherTypeTAF, airportCode)
+// addProviders creates the named providers in failover order, windy if none are named
+func (c *Client) addProviders(names []string) {
+	if len(names) == 0 {
+		names = []string{ProviderWindy}
+	}
+	for _, name := range names {
+		provider, err := newProvider(name, c)
+		if err != nil {
+			c.logger.Warn("Skipping weather provider", logger.Error(err))
+			continue
+		}
+		c.providers = append(c.providers, provider)
+	}
 }
 
-// FetchNOTAMs fetches NOTAM data for the specified airport
-func (c *Client) FetchNOTAMs(airportCode string) (interface{}, error) {
-	url := fmt.Sprintf("%s/notams/%s", c.config.APIBaseURL, airportCode)
-	return c.fetchWithRetry(url, WeatherTypeNOTAMs, airportCode)
+// fetch fetches a weather type from the first provider that serves it
+func (c *Client) fetch(weatherType WeatherType, airportCode string) FetchResult {
+	var failures []string
+	for i, provider := range c.providers {
+		var report *Report
+		var err error
+		switch weatherType {
+		case WeatherTypeMETAR:
+			report, err = provider.FetchMETAR(airportCode)
+		case WeatherTypeTAF:
+			report, err = provider.FetchTAF(airportCode)
+		case WeatherTypeNOTAMs:
+			report, err = provider.FetchNOTAMs(airportCode)
+		}
+
+		if err == nil {
+			return FetchResult{Type: weatherType, Data: report.Data, Raw: report.Raw, Provider: provider.Name()}
+		}
+		if errors.Is(err, ErrNotSupported) {
+			continue
+		}
+
+		failures = append(failures, fmt.Sprintf("%s: %v", provider.Name(), err))
+		if i < len(c.providers)-1 {
+			c.logger.Warn("Weather provider failed, trying the next one",
+				logger.String("type", string(weatherType)),
+				logger.String("provider", provider.Name()),
+				logger.Error(err))
+		}
+	}
+
+	if len(failures) == 0 {
+		return FetchResult{Type: weatherType, Err: fmt.Errorf("no configured provider serves %s", weatherType)}
+	}
+	return FetchResult{Type: weatherType, Err: errors.New(strings.Join(failures, "; "))}
 }
 
 // fetchWithRetry performs HTTP request with retry logic and exponential backoff
-func (c *Client) fetchWithRetry(url string, weatherType WeatherType, airportCode string) (interface{}, error) {
+func (c *Client) fetchWithRetry(url string, headers map[string]string, weatherType WeatherType, airportCode string) (interface{}, error) {
 	var lastErr error
 	var data interface{}
 
@@ -64,7 +105,14 @@ func (c *Client) fetchWithRetry(url string, weatherType WeatherType, airportCode
 		}
 
 		// Make the request
-		resp, err := c.httpClient.Get(url)
+		req, err := http.NewRequest("GET", url, nil)
+		if err != nil {
+			return nil, fmt.Errorf("error creating weather API request: %w", err)
+		}
+		for name, value := range headers {
+			req.Header.Set(name, value)
+		}
+		resp, err := c.httpClient.Do(req)
 		if err != nil {
 			lastErr = fmt.Errorf("error making request to weather API: %w", err)
 			c.logger.Warn("Weather API request failed, may retry",
@@ -124,31 +172,44 @@ func (c *Client) fetchWithRetry(url string, weatherType WeatherType, airportCode
 
 // FetchAll fetches all enabled weather data types concurrently
 func (c *Client) FetchAll(airportCode string) []FetchResult {
-	results := make(chan FetchResult, 3)
+	results := make(chan FetchResult, 5)
 	var fetchCount int
 
 	// Start concurrent fetches for enabled weather types
 	if c.config.FetchMETAR {
 		fetchCount++
 		go func() {
-			data, err := c.FetchMETAR(airportCode)
-			results <- FetchResult{Type: WeatherTypeMETAR, Data: data, Err: err}
+			results <- c.fetch(WeatherTypeMETAR, airportCode)
 		}()
 	}
 
 	if c.config.FetchTAF {
 		fetchCount++
 		go func() {
-			data, err := c.FetchTAF(airportCode)
-			results <- FetchResult{Type: WeatherTypeTAF, Data: data, Err: err}
+			results <- c.fetch(WeatherTypeTAF, airportCode)
 		}()
 	}
 
 	if c.config.FetchNOTAMs {
 		fetchCount++
 		go func() {
-			data, err := c.FetchNOTAMs(airportCode)
-			results <- FetchResult{Type: WeatherTypeNOTAMs, Data: data, Err: err}
+			results <- c.fetch(WeatherTypeNOTAMs, airportCode)
+		}()
+	}
+
+	if c.config.FetchWindsAloft {
+		fetchCount++
+		go func() {
+			winds, err := c.FetchWindsAloft(airportCode)
+			results <- FetchResult{Type: WeatherTypeWindsAloft, Data: winds, Provider: "open-meteo", Err: err}
+		}()
+	}
+
+	if c.config.FetchHazards {
+		fetchCount++
+		go func() {
+			hazards, err := c.FetchHazards(airportCode)
+			results <- FetchResult{Type: WeatherTypeHazards, Data: hazards, Provider: ProviderAviationWeather, Err: err}
 		}()
 	}
 
@@ -0,0 +1,399 @@
+package weather
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/yegors/co-atc/internal/adsb"
+	"github.com/yegors/co-atc/pkg/logger"
+)
+
+// Kinds of weather hazards
+const (
+	HazardKindSIGMET = "SIGMET"
+	HazardKindAIRMET = "AIRMET"
+	HazardKindPIREP  = "PIREP"
+)
+
+// pirepMaxAgeHours is how far back pilot reports are fetched
+const pirepMaxAgeHours = 2
+
+// HazardGeometry is a GeoJSON geometry: a Polygon for SIGMETs and AIRMETs, a Point for PIREPs.
+// Coordinates are longitude first, as GeoJSON requires.
+type HazardGeometry struct {
+	Type        string      `json:"type"`
+	Coordinates interface{} `json:"coordinates"`
+}
+
+// HazardProperties describes a weather hazard
+type HazardProperties struct {
+	Kind         string     `json:"kind"`                    // "SIGMET", "AIRMET" or "PIREP"
+	Hazard       string     `json:"hazard"`                  // "CONVECTIVE", "TURB", "ICE", "IFR", "MTN OBSCN", ...
+	Severity     string     `json:"severity,omitempty"`      // Intensity, "URGENT" for urgent PIREPs
+	ValidFrom    *time.Time `json:"valid_from,omitempty"`    // PIREPs: observation time
+	ValidTo      *time.Time `json:"valid_to,omitempty"`      // Not set for PIREPs
+	BaseFt       *int       `json:"base_ft,omitempty"`       // Lowest altitude affected, if reported
+	TopFt        *int       `json:"top_ft,omitempty"`        // Highest altitude affected, or the PIREP's altitude
+	AircraftType string     `json:"aircraft_type,omitempty"` // PIREPs only
+	DistanceNM   float64    `json:"distance_nm"`             // From the station to the hazard, 0 if the station is inside it
+	BearingDeg   int        `json:"bearing_deg"`             // True bearing from the station to the nearest point of the hazard
+	Raw          string     `json:"raw"`
+}
+
+// HazardFeature is a GeoJSON feature for a SIGMET, AIRMET or PIREP
+type HazardFeature struct {
+	Type       string           `json:"type"` // Always "Feature"
+	ID         string           `json:"id"`
+	Geometry   HazardGeometry   `json:"geometry"`
+	Properties HazardProperties `json:"properties"`
+}
+
+// Hazards is a GeoJSON feature collection of the SIGMETs, AIRMETs and PIREPs within the
+// hazard radius of the station, nearest first
+type Hazards struct {
+	Type      string          `json:"type"` // Always "FeatureCollection"
+	Features  []HazardFeature `json:"features"`
+	RadiusNM  int             `json:"radius_nm"`
+	FetchedAt time.Time       `json:"fetched_at"`
+}
+
+// Nearby returns up to limit hazards, nearest first
+func (h *Hazards) Nearby(limit int) []HazardFeature {
+	if h == nil {
+		return nil
+	}
+	if limit > len(h.Features) {
+		limit = len(h.Features)
+	}
+	return h.Features[:limit]
+}
+
+// FetchHazards fetches the SIGMETs, AIRMETs and PIREPs within the hazard radius of the station
+// from the Aviation Weather Center. It fails only if every product fails.
+func (c *Client) FetchHazards(airportCode string) (*Hazards, error) {
+	lat, lon := c.config.StationLatitude, c.config.StationLongitude
+	radius := float64(c.config.HazardsRadiusNM)
+
+	products := []struct {
+		name  string
+		url   string
+		parse func(map[string]interface{}) (HazardFeature, bool)
+	}{
+		{"airsigmet", aviationWeatherBaseURL + "/airsigmet?format=json", parseAirSigmet},
+		{"isigmet", aviationWeatherBaseURL + "/isigmet?format=json", parseInternationalSigmet},
+		{"gairmet", aviationWeatherBaseURL + "/gairmet?format=json", parseGAirmet},
+		{"pirep", fmt.Sprintf("%s/pirep?id=%s&distance=%d&age=%d&format=json", aviationWeatherBaseURL,
+			url.QueryEscape(airportCode), c.config.HazardsRadiusNM, pirepMaxAgeHours), parsePIREP},
+	}
+
+	hazards := &Hazards{
+		Type:      "FeatureCollection",
+		Features:  []HazardFeature{},
+		RadiusNM:  c.config.HazardsRadiusNM,
+		FetchedAt: time.Now().UTC(),
+	}
+	var failures []string
+	for _, product := range products {
+		data, err := c.fetchWithRetry(product.url, nil, WeatherTypeHazards, airportCode)
+		if err != nil {
+			failures = append(failures, fmt.Sprintf("%s: %v", product.name, err))
+			continue
+		}
+		// An empty product is returned as null
+		entries, _ := data.([]interface{})
+		for i, entry := range entries {
+			fields, ok := entry.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			feature, ok := product.parse(fields)
+			if !ok || (feature.Properties.ValidTo != nil && feature.Properties.ValidTo.Before(hazards.FetchedAt)) {
+				continue
+			}
+			distance, bearing := distanceToGeometry(lat, lon, feature.Geometry)
+			if distance > radius {
+				continue
+			}
+			feature.Type = "Feature"
+			feature.ID = fmt.Sprintf("%s-%d", product.name, i)
+			feature.Properties.DistanceNM = math.Round(distance*10) / 10
+			feature.Properties.BearingDeg = int(math.Round(bearing)) % 360
+			hazards.Features = append(hazards.Features, feature)
+		}
+	}
+	if len(failures) == len(products) {
+		return nil, errors.New(strings.Join(failures, "; "))
+	}
+	if len(failures) > 0 {
+		c.logger.Warn("Some weather hazard products failed to fetch",
+			logger.String("airport", airportCode),
+			logger.String("failures", strings.Join(failures, "; ")))
+	}
+
+	sort.SliceStable(hazards.Features, func(i, j int) bool {
+		return hazards.Features[i].Properties.DistanceNM < hazards.Features[j].Properties.DistanceNM
+	})
+	return hazards, nil
+}
+
+// parseAirSigmet parses a US domestic SIGMET or AIRMET
+func parseAirSigmet(fields map[string]interface{}) (HazardFeature, bool) {
+	kind := strings.ToUpper(stringField(fields, "airSigmetType"))
+	if kind != HazardKindSIGMET && kind != HazardKindAIRMET {
+		return HazardFeature{}, false // Outlooks
+	}
+	geometry, ok := polygonGeometry(fields["coords"])
+	if !ok {
+		return HazardFeature{}, false
+	}
+	return HazardFeature{
+		Geometry: geometry,
+		Properties: HazardProperties{
+			Kind:      kind,
+			Hazard:    strings.ToUpper(stringField(fields, "hazard")),
+			Severity:  severityName(fields["severity"]),
+			ValidFrom: timeField(fields["validTimeFrom"]),
+			ValidTo:   timeField(fields["validTimeTo"]),
+			BaseFt:    altitudeField(fields["altitudeLow1"], 1),
+			TopFt:     altitudeField(fields["altitudeHi1"], 1),
+			Raw:       stringField(fields, "rawAirSigmet"),
+		},
+	}, true
+}
+
+// parseInternationalSigmet parses an international SIGMET
+func parseInternationalSigmet(fields map[string]interface{}) (HazardFeature, bool) {
+	geometry, ok := polygonGeometry(fields["coords"])
+	if !ok {
+		return HazardFeature{}, false
+	}
+	return HazardFeature{
+		Geometry: geometry,
+		Properties: HazardProperties{
+			Kind:      HazardKindSIGMET,
+			Hazard:    strings.ToUpper(stringField(fields, "hazard")),
+			Severity:  strings.ToUpper(stringField(fields, "qualifier")),
+			ValidFrom: timeField(fields["validTimeFrom"]),
+			ValidTo:   timeField(fields["validTimeTo"]),
+			BaseFt:    altitudeField(fields["base"], 1),
+			TopFt:     altitudeField(fields["top"], 1),
+			Raw:       stringField(fields, "rawSigmet"),
+		},
+	}, true
+}
+
+// parseGAirmet parses a graphical AIRMET. Only the current forecast hour is kept.
+func parseGAirmet(fields map[string]interface{}) (HazardFeature, bool) {
+	if forecast, ok := numberField(fields["forecast"]); ok && forecast != 0 {
+		return HazardFeature{}, false
+	}
+	geometry, ok := polygonGeometry(fields["coords"])
+	if !ok {
+		return HazardFeature{}, false
+	}
+	hazard := strings.ToUpper(stringField(fields, "hazard"))
+	if dueTo := stringField(fields, "due_to"); dueTo != "" {
+		hazard += " (" + dueTo + ")"
+	}
+	return HazardFeature{
+		Geometry: geometry,
+		Properties: HazardProperties{
+			Kind:      HazardKindAIRMET,
+			Hazard:    hazard,
+			Severity:  strings.ToUpper(stringField(fields, "severity")),
+			ValidFrom: timeField(fields["validTime"]),
+			ValidTo:   timeField(fields["expireTime"]),
+			BaseFt:    altitudeField(fields["base"], 100),
+			TopFt:     altitudeField(fields["top"], 100),
+			Raw:       strings.TrimSpace(stringField(fields, "product") + " " + stringField(fields, "tag")),
+		},
+	}, true
+}
+
+// parsePIREP parses a pilot report. Its hazard is the turbulence, icing and weather reported.
+func parsePIREP(fields map[string]interface{}) (HazardFeature, bool) {
+	lat, okLat := numberField(fields["lat"])
+	lon, okLon := numberField(fields["lon"])
+	if !okLat || !okLon {
+		return HazardFeature{}, false
+	}
+
+	var hazards []string
+	severity := ""
+	if turbulence := stringField(fields, "tbInt1"); turbulence != "" && turbulence != "NEG" {
+		hazards = append(hazards, "TURB")
+		severity = turbulence
+	}
+	if icing := stringField(fields, "icgInt1"); icing != "" && icing != "NEG" {
+		hazards = append(hazards, "ICE")
+		if severity == "" {
+			severity = icing
+		}
+	}
+	if weather := stringField(fields, "wxString"); weather != "" {
+		hazards = append(hazards, weather)
+	}
+	if len(hazards) == 0 {
+		hazards = append(hazards, "NONE")
+	}
+
+	raw := stringField(fields, "rawOb")
+	if strings.Contains(raw, " UUA ") {
+		severity = "URGENT"
+	}
+
+	return HazardFeature{
+		Geometry: HazardGeometry{Type: "Point", Coordinates: []float64{lon, lat}},
+		Properties: HazardProperties{
+			Kind:         HazardKindPIREP,
+			Hazard:       strings.Join(hazards, " "),
+			Severity:     severity,
+			ValidFrom:    timeField(fields["obsTime"]),
+			TopFt:        altitudeField(fields["fltLvl"], 100),
+			AircraftType: stringField(fields, "acType"),
+			Raw:          raw,
+		},
+	}, true
+}
+
+// polygonGeometry builds a closed GeoJSON polygon from a list of {lat, lon} points
+func polygonGeometry(coords interface{}) (HazardGeometry, bool) {
+	points, ok := coords.([]interface{})
+	if !ok || len(points) < 3 {
+		return HazardGeometry{}, false
+	}
+
+	ring := make([][]float64, 0, len(points)+1)
+	for _, point := range points {
+		fields, ok := point.(map[string]interface{})
+		if !ok {
+			return HazardGeometry{}, false
+		}
+		lat, okLat := numberField(fields["lat"])
+		lon, okLon := numberField(fields["lon"])
+		if !okLat || !okLon {
+			return HazardGeometry{}, false
+		}
+		ring = append(ring, []float64{lon, lat})
+	}
+	if first, last := ring[0], ring[len(ring)-1]; first[0] != last[0] || first[1] != last[1] {
+		ring = append(ring, []float64{first[0], first[1]})
+	}
+
+	return HazardGeometry{Type: "Polygon", Coordinates: [][][]float64{ring}}, true
+}
+
+// distanceToGeometry returns the distance in nautical miles and the true bearing from a point
+// to the nearest point of a hazard's geometry. Polygons are measured on a flat projection
+// around the point, which is close enough within a few hundred miles.
+func distanceToGeometry(lat, lon float64, geometry HazardGeometry) (float64, float64) {
+	switch coordinates := geometry.Coordinates.(type) {
+	case []float64:
+		return adsb.MetersToNM(adsb.Haversine(lat, lon, coordinates[1], coordinates[0])), adsb.CalculateBearing(lat, lon, coordinates[1], coordinates[0])
+	case [][][]float64:
+		ring := coordinates[0]
+		scale := math.Cos(lat * math.Pi / 180)
+		project := func(point []float64) (float64, float64) {
+			return (point[0] - lon) * 60 * scale, (point[1] - lat) * 60
+		}
+
+		inside := false
+		best, bestX, bestY := math.Inf(1), 0.0, 0.0
+		for i := 1; i < len(ring); i++ {
+			x1, y1 := project(ring[i-1])
+			x2, y2 := project(ring[i])
+			if (y1 > 0) != (y2 > 0) && x1+(0-y1)*(x2-x1)/(y2-y1) > 0 {
+				inside = !inside
+			}
+
+			// Nearest point of the edge to the origin
+			dx, dy := x2-x1, y2-y1
+			t := 0.0
+			if length := dx*dx + dy*dy; length > 0 {
+				t = math.Max(0, math.Min(1, -(x1*dx+y1*dy)/length))
+			}
+			x, y := x1+t*dx, y1+t*dy
+			if distance := math.Hypot(x, y); distance < best {
+				best, bestX, bestY = distance, x, y
+			}
+		}
+		if inside {
+			return 0, 0
+		}
+		return best, math.Mod(math.Atan2(bestX, bestY)*180/math.Pi+360, 360)
+	}
+	return math.Inf(1), 0
+}
+
+// stringField returns a string field of a report, or an empty string
+func stringField(fields map[string]interface{}, name string) string {
+	switch value := fields[name].(type) {
+	case string:
+		return strings.TrimSpace(value)
+	case float64:
+		return strconv.FormatFloat(value, 'f', -1, 64)
+	}
+	return ""
+}
+
+// numberField returns a numeric field, which the data API sends as a number or a string
+func numberField(value interface{}) (float64, bool) {
+	switch value := value.(type) {
+	case float64:
+		return value, true
+	case string:
+		number, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+		return number, err == nil
+	}
+	return 0, false
+}
+
+// altitudeField returns an altitude in feet from a field in units of scale feet. Altitudes
+// that aren't numbers, such as "SFC" or "FZL", are unknown.
+func altitudeField(value interface{}, scale int) *int {
+	number, ok := numberField(value)
+	if !ok {
+		return nil
+	}
+	feet := int(number) * scale
+	return &feet
+}
+
+// timeField returns a time sent as Unix seconds or as an ISO 8601 string
+func timeField(value interface{}) *time.Time {
+	var t time.Time
+	switch value := value.(type) {
+	case float64:
+		t = time.Unix(int64(value), 0).UTC()
+	case string:
+		var err error
+		if t, err = time.Parse(time.RFC3339, value); err != nil {
+			if t, err = time.Parse("2006-01-02 15:04:05", value); err != nil {
+				return nil
+			}
+		}
+		t = t.UTC()
+	default:
+		return nil
+	}
+	return &t
+}
+
+// severityName returns a SIGMET or AIRMET severity, which may be sent as a number
+func severityName(value interface{}) string {
+	switch value := value.(type) {
+	case string:
+		return strings.ToUpper(strings.TrimSpace(value))
+	case float64:
+		if value > 0 {
+			return strconv.Itoa(int(value))
+		}
+	}
+	return ""
+}
@@ -0,0 +1,403 @@
+package weather
+
+import (
+	"math"
+	"time"
+
+	"github.com/yegors/co-atc/internal/storage/sqlite"
+	"github.com/yegors/co-atc/pkg/logger"
+)
+
+const (
+	// projectionWindow is how much recent history the wind projection is fitted to
+	projectionWindow = 3 * time.Hour
+
+	// projectionAhead is how far ahead the wind is projected
+	projectionAhead = time.Hour
+
+	// steadyPressureHPa is the largest three-hour pressure change reported as steady
+	steadyPressureHPa = 1.0
+
+	// steadyWindDeg is the largest wind direction change reported as steady
+	steadyWindDeg = 20
+)
+
+// WindTrend is how the surface wind changed over a period. Directions are true, where the
+// wind blows from.
+type WindTrend struct {
+	LatestDirectionDeg    *int   `json:"latest_direction_deg,omitempty"` // Nil for calm or variable wind
+	LatestSpeedKt         *int   `json:"latest_speed_kt,omitempty"`
+	MeanDirectionDeg      *int   `json:"mean_direction_deg,omitempty"` // Vector mean over the period
+	MeanSpeedKt           *int   `json:"mean_speed_kt,omitempty"`
+	DirectionChangeDeg    *int   `json:"direction_change_deg,omitempty"` // First to latest; positive is veering (clockwise)
+	SpeedChangeKt         *int   `json:"speed_change_kt,omitempty"`
+	MaxGustKt             int    `json:"max_gust_kt,omitempty"`
+	Shift                 string `json:"shift,omitempty"`                   // "veering", "backing" or "steady"
+	ProjectedDirectionDeg *int   `json:"projected_direction_deg,omitempty"` // Wind an hour from now if the last three hours' trend continues
+	ProjectedSpeedKt      *int   `json:"projected_speed_kt,omitempty"`
+}
+
+// PressureTrend is how the pressure changed over a period
+type PressureTrend struct {
+	LatestHPa          *float64 `json:"latest_hpa,omitempty"`
+	LatestInHg         *float64 `json:"latest_inhg,omitempty"`
+	ChangeHPa          *float64 `json:"change_hpa,omitempty"`            // First to latest
+	ThreeHourChangeHPa *float64 `json:"three_hour_change_hpa,omitempty"` // Over the last three hours
+	Tendency           string   `json:"tendency,omitempty"`              // "rising", "falling" or "steady", over the last three hours
+}
+
+// VisibilityTrend is how visibility and ceiling changed over a period
+type VisibilityTrend struct {
+	LatestSM        *float64 `json:"latest_sm,omitempty"`
+	MinSM           *float64 `json:"min_sm,omitempty"`
+	MaxSM           *float64 `json:"max_sm,omitempty"`
+	LatestCeilingFt *int     `json:"latest_ceiling_ft,omitempty"` // Nil without a ceiling
+	MinCeilingFt    *int     `json:"min_ceiling_ft,omitempty"`
+	FlightCategory  string   `json:"flight_category,omitempty"` // Latest
+	Tendency        string   `json:"tendency,omitempty"`        // "improving", "deteriorating" or "steady", by flight category
+}
+
+// Trends summarizes the stored METARs of a period
+type Trends struct {
+	Airport      string          `json:"airport"`
+	Hours        int             `json:"hours"`
+	Observations int             `json:"observations"`
+	From         *time.Time      `json:"from,omitempty"` // First and latest METAR of the period
+	To           *time.Time      `json:"to,omitempty"`
+	Wind         WindTrend       `json:"wind"`
+	Pressure     PressureTrend   `json:"pressure"`
+	Visibility   VisibilityTrend `json:"visibility"`
+}
+
+// SetHistoryStorage stores every new METAR in storage, keeping retention of history. Must be
+// called before Start.
+func (s *Service) SetHistoryStorage(storage *sqlite.WeatherHistoryStorage, retention time.Duration) {
+	s.history = storage
+	s.historyRetention = retention
+}
+
+// HasHistory reports whether METARs are stored
+func (s *Service) HasHistory() bool {
+	return s.history != nil
+}
+
+// History returns the METARs observed in the last hours, oldest first
+func (s *Service) History(hours int) ([]*sqlite.WeatherObservationRecord, error) {
+	return s.history.ListObservations(s.airportCode, time.Now().UTC().Add(-time.Duration(hours)*time.Hour))
+}
+
+// Trends summarizes how the wind, pressure and visibility changed in the last hours
+func (s *Service) Trends(hours int) (*Trends, error) {
+	observations, err := s.History(hours)
+	if err != nil {
+		return nil, err
+	}
+	return ComputeTrends(s.airportCode, hours, observations), nil
+}
+
+// recordObservation stores the cached METAR, if it's new, and prunes history past retention
+func (s *Service) recordObservation() {
+	if s.history == nil {
+		return
+	}
+	data := s.cache.Get()
+	if data == nil || data.Parsed == nil || data.Parsed.METAR == nil {
+		return
+	}
+
+	metar := data.Parsed.METAR
+	record := &sqlite.WeatherObservationRecord{
+		Airport:        s.airportCode,
+		ObservedAt:     metar.ObservedAt,
+		Raw:            metar.Raw,
+		Provider:       data.Sources[string(WeatherTypeMETAR)],
+		VisibilitySM:   metar.VisibilitySM,
+		CeilingFt:      metar.CeilingFt,
+		AltimeterInHg:  metar.AltimeterInHg,
+		QNHHPa:         metar.QNHHPa,
+		TemperatureC:   metar.TemperatureC,
+		DewpointC:      metar.DewpointC,
+		FlightCategory: metar.FlightCategory,
+		FetchedAt:      time.Now().UTC(),
+	}
+	if wind := metar.Wind; wind != nil {
+		speed := wind.SpeedKt
+		record.WindSpeedKt = &speed
+		if wind.SpeedKt > 0 {
+			record.WindDirectionDeg = wind.DirectionDeg
+		}
+		if wind.GustKt > 0 {
+			gust := wind.GustKt
+			record.WindGustKt = &gust
+		}
+	}
+
+	added, err := s.history.AddObservation(record)
+	if err != nil {
+		s.logger.Error("Failed to store METAR", logger.Error(err))
+		return
+	}
+	if !added {
+		return
+	}
+
+	if deleted, err := s.history.DeleteObservationsBefore(time.Now().UTC().Add(-s.historyRetention)); err != nil {
+		s.logger.Error("Failed to prune weather history", logger.Error(err))
+	} else if deleted > 0 {
+		s.logger.Debug("Pruned weather history", logger.Int64("deleted", deleted))
+	}
+}
+
+// ComputeTrends summarizes METARs, oldest first
+func ComputeTrends(airport string, hours int, observations []*sqlite.WeatherObservationRecord) *Trends {
+	trends := &Trends{
+		Airport:      airport,
+		Hours:        hours,
+		Observations: len(observations),
+	}
+	if len(observations) == 0 {
+		return trends
+	}
+
+	first, latest := observations[0], observations[len(observations)-1]
+	trends.From = &first.ObservedAt
+	trends.To = &latest.ObservedAt
+
+	trends.Wind = windTrend(observations)
+	trends.Pressure = pressureTrend(observations)
+	trends.Visibility = visibilityTrend(observations)
+	return trends
+}
+
+// windTrend summarizes the surface wind of METARs
+func windTrend(observations []*sqlite.WeatherObservationRecord) WindTrend {
+	var trend WindTrend
+	latest := observations[len(observations)-1]
+	trend.LatestDirectionDeg = latest.WindDirectionDeg
+	trend.LatestSpeedKt = latest.WindSpeedKt
+
+	var sumU, sumV, sumSpeed float64
+	var count int
+	var firstDirection, firstSpeed *int
+	for _, observation := range observations {
+		if observation.WindGustKt != nil && *observation.WindGustKt > trend.MaxGustKt {
+			trend.MaxGustKt = *observation.WindGustKt
+		}
+		if observation.WindSpeedKt == nil {
+			continue
+		}
+		if firstSpeed == nil {
+			firstSpeed = observation.WindSpeedKt
+		}
+		sumSpeed += float64(*observation.WindSpeedKt)
+		count++
+		if observation.WindDirectionDeg != nil {
+			if firstDirection == nil {
+				firstDirection = observation.WindDirectionDeg
+			}
+			u, v := windVector(*observation.WindDirectionDeg, *observation.WindSpeedKt)
+			sumU, sumV = sumU+u, sumV+v
+		}
+	}
+	if count == 0 {
+		return trend
+	}
+
+	meanSpeed := int(math.Round(sumSpeed / float64(count)))
+	trend.MeanSpeedKt = &meanSpeed
+	if sumU != 0 || sumV != 0 {
+		meanDirection := vectorDirection(sumU, sumV)
+		trend.MeanDirectionDeg = &meanDirection
+	}
+	if latest.WindSpeedKt != nil {
+		speedChange := *latest.WindSpeedKt - *firstSpeed
+		trend.SpeedChangeKt = &speedChange
+	}
+	if firstDirection != nil && latest.WindDirectionDeg != nil {
+		change := ((*latest.WindDirectionDeg-*firstDirection)%360+540)%360 - 180
+		trend.DirectionChangeDeg = &change
+		switch {
+		case change > steadyWindDeg:
+			trend.Shift = "veering"
+		case change < -steadyWindDeg:
+			trend.Shift = "backing"
+		default:
+			trend.Shift = "steady"
+		}
+	}
+
+	trend.ProjectedDirectionDeg, trend.ProjectedSpeedKt = projectWind(observations)
+	return trend
+}
+
+// projectWind fits a line to each wind component over the last three hours and extrapolates
+// it an hour past the latest METAR. Fitting components rather than directions keeps a wind
+// backing through north from averaging to south.
+func projectWind(observations []*sqlite.WeatherObservationRecord) (*int, *int) {
+	latest := observations[len(observations)-1].ObservedAt
+	var times, us, vs []float64
+	for _, observation := range observations {
+		if latest.Sub(observation.ObservedAt) > projectionWindow || observation.WindSpeedKt == nil {
+			continue
+		}
+		u, v := 0.0, 0.0
+		if observation.WindDirectionDeg != nil {
+			u, v = windVector(*observation.WindDirectionDeg, *observation.WindSpeedKt)
+		}
+		times = append(times, observation.ObservedAt.Sub(latest).Hours())
+		us = append(us, u)
+		vs = append(vs, v)
+	}
+	if len(times) < 3 {
+		return nil, nil
+	}
+
+	ahead := projectionAhead.Hours()
+	u := linearFit(times, us, ahead)
+	v := linearFit(times, vs, ahead)
+	speed := int(math.Round(math.Hypot(u, v)))
+	if speed == 0 {
+		return nil, &speed
+	}
+	direction := vectorDirection(u, v)
+	return &direction, &speed
+}
+
+// pressureTrend summarizes the pressure of METARs
+func pressureTrend(observations []*sqlite.WeatherObservationRecord) PressureTrend {
+	var trend PressureTrend
+	latest := observations[len(observations)-1]
+	latestHPa, ok := pressureHPa(latest)
+	if !ok {
+		return trend
+	}
+	trend.LatestHPa = &latestHPa
+	latestInHg := math.Round(latestHPa/inHgToHPa*100) / 100
+	trend.LatestInHg = &latestInHg
+
+	for _, observation := range observations {
+		if hPa, ok := pressureHPa(observation); ok {
+			change := roundTenth(latestHPa - hPa)
+			trend.ChangeHPa = &change
+			break
+		}
+	}
+
+	// Earliest METAR within the last three hours, at least an hour before the latest
+	for _, observation := range observations {
+		age := latest.ObservedAt.Sub(observation.ObservedAt)
+		if age > 3*time.Hour || age < time.Hour {
+			continue
+		}
+		if hPa, ok := pressureHPa(observation); ok {
+			change := roundTenth(latestHPa - hPa)
+			trend.ThreeHourChangeHPa = &change
+			switch {
+			case change > steadyPressureHPa:
+				trend.Tendency = "rising"
+			case change < -steadyPressureHPa:
+				trend.Tendency = "falling"
+			default:
+				trend.Tendency = "steady"
+			}
+			break
+		}
+	}
+	return trend
+}
+
+// visibilityTrend summarizes the visibility and ceiling of METARs
+func visibilityTrend(observations []*sqlite.WeatherObservationRecord) VisibilityTrend {
+	var trend VisibilityTrend
+	latest := observations[len(observations)-1]
+	trend.LatestSM = latest.VisibilitySM
+	trend.LatestCeilingFt = latest.CeilingFt
+	trend.FlightCategory = latest.FlightCategory
+
+	firstCategory := ""
+	for _, observation := range observations {
+		if visibility := observation.VisibilitySM; visibility != nil {
+			if trend.MinSM == nil || *visibility < *trend.MinSM {
+				trend.MinSM = visibility
+			}
+			if trend.MaxSM == nil || *visibility > *trend.MaxSM {
+				trend.MaxSM = visibility
+			}
+		}
+		if ceiling := observation.CeilingFt; ceiling != nil && (trend.MinCeilingFt == nil || *ceiling < *trend.MinCeilingFt) {
+			trend.MinCeilingFt = ceiling
+		}
+		if firstCategory == "" {
+			firstCategory = observation.FlightCategory
+		}
+	}
+
+	first, last := flightCategoryRank[firstCategory], flightCategoryRank[latest.FlightCategory]
+	if first > 0 && last > 0 {
+		switch {
+		case last > first:
+			trend.Tendency = "improving"
+		case last < first:
+			trend.Tendency = "deteriorating"
+		default:
+			trend.Tendency = "steady"
+		}
+	}
+	return trend
+}
+
+// flightCategoryRank orders flight categories from worst to best
+var flightCategoryRank = map[string]int{"LIFR": 1, "IFR": 2, "MVFR": 3, "VFR": 4}
+
+// inHgToHPa converts inches of mercury to hectopascals
+const inHgToHPa = 33.8639
+
+// pressureHPa returns the pressure of a METAR in hectopascals
+func pressureHPa(observation *sqlite.WeatherObservationRecord) (float64, bool) {
+	switch {
+	case observation.AltimeterInHg != nil:
+		return roundTenth(*observation.AltimeterInHg * inHgToHPa), true
+	case observation.QNHHPa != nil:
+		return float64(*observation.QNHHPa), true
+	}
+	return 0, false
+}
+
+// windVector returns the east and north components of a wind from a direction
+func windVector(directionDeg, speedKt int) (float64, float64) {
+	radians := float64(directionDeg) * math.Pi / 180
+	return float64(speedKt) * math.Sin(radians), float64(speedKt) * math.Cos(radians)
+}
+
+// vectorDirection returns the direction, 1-360 as METARs report it, of a wind vector
+func vectorDirection(u, v float64) int {
+	direction := int(math.Round(math.Mod(math.Atan2(u, v)*180/math.Pi+360, 360)))
+	if direction == 0 {
+		direction = 360
+	}
+	return direction
+}
+
+// linearFit fits a least-squares line to points and returns its value at x
+func linearFit(xs, ys []float64, x float64) float64 {
+	n := float64(len(xs))
+	var sumX, sumY, sumXY, sumXX float64
+	for i := range xs {
+		sumX += xs[i]
+		sumY += ys[i]
+		sumXY += xs[i] * ys[i]
+		sumXX += xs[i] * xs[i]
+	}
+	denominator := n*sumXX - sumX*sumX
+	if denominator == 0 {
+		return sumY / n
+	}
+	slope := (n*sumXY - sumX*sumY) / denominator
+	intercept := (sumY - slope*sumX) / n
+	return intercept + slope*x
+}
+
+// roundTenth rounds to one decimal place
+func roundTenth(value float64) float64 {
+	return math.Round(value*10) / 10
+}
@@ -7,11 +7,15 @@ import (
 
 // WeatherData represents the complete weather information for an airport
 type WeatherData struct {
-	METAR       interface{} `json:"metar,omitempty"`
-	TAF         interface{} `json:"taf,omitempty"`
-	NOTAMs      interface{} `json:"notams,omitempty"`
-	LastUpdated time.Time   `json:"last_updated"`
-	FetchErrors []string    `json:"fetch_errors,omitempty"`
+	METAR       interface{}       `json:"metar,omitempty"`
+	TAF         interface{}       `json:"taf,omitempty"`
+	NOTAMs      interface{}       `json:"notams,omitempty"`
+	Parsed      *ParsedWeather    `json:"parsed,omitempty"`  // METAR and TAF decoded into typed fields
+	Sources     map[string]string `json:"sources,omitempty"` // Provider each weather type came from
+	WindsAloft  *WindsAloft       `json:"winds_aloft,omitempty"`
+	Hazards     *Hazards          `json:"hazards,omitempty"` // SIGMETs, AIRMETs and PIREPs near the station
+	LastUpdated time.Time         `json:"last_updated"`
+	FetchErrors []string          `json:"fetch_errors,omitempty"`
 }
 
 // WeatherCache represents cached weather data with expiration
@@ -23,30 +27,42 @@ type WeatherCache struct {
 
 // WeatherConfig represents the weather service configuration
 type WeatherConfig struct {
-	RefreshIntervalMinutes int    `toml:"refresh_interval_minutes"`
-	APIBaseURL             string `toml:"api_base_url"`
-	RequestTimeoutSeconds  int    `toml:"request_timeout_seconds"`
-	MaxRetries             int    `toml:"max_retries"`
-	FetchMETAR             bool   `toml:"fetch_metar"`
-	FetchTAF               bool   `toml:"fetch_taf"`
-	FetchNOTAMs            bool   `toml:"fetch_notams"`
-	CacheExpiryMinutes     int    `toml:"cache_expiry_minutes"`
+	RefreshIntervalMinutes int      `toml:"refresh_interval_minutes"`
+	Providers              []string `toml:"providers"`
+	APIBaseURL             string   `toml:"api_base_url"`
+	CheckWXAPIKey          string   `toml:"checkwx_api_key"`
+	RequestTimeoutSeconds  int      `toml:"request_timeout_seconds"`
+	MaxRetries             int      `toml:"max_retries"`
+	FetchMETAR             bool     `toml:"fetch_metar"`
+	FetchTAF               bool     `toml:"fetch_taf"`
+	FetchNOTAMs            bool     `toml:"fetch_notams"`
+	FetchWindsAloft        bool     `toml:"fetch_winds_aloft"`
+	WindsAloftRadiusNM     int      `toml:"winds_aloft_radius_nm"`
+	FetchHazards           bool     `toml:"fetch_hazards"`
+	HazardsRadiusNM        int      `toml:"hazards_radius_nm"`
+	CacheExpiryMinutes     int      `toml:"cache_expiry_minutes"`
+	StationLatitude        float64  `toml:"-"` // Station location, for winds aloft and hazards
+	StationLongitude       float64  `toml:"-"`
 }
 
 // WeatherType represents the type of weather data
 type WeatherType string
 
 const (
-	WeatherTypeMETAR  WeatherType = "metar"
-	WeatherTypeTAF    WeatherType = "taf"
-	WeatherTypeNOTAMs WeatherType = "notams"
+	WeatherTypeMETAR      WeatherType = "metar"
+	WeatherTypeTAF        WeatherType = "taf"
+	WeatherTypeNOTAMs     WeatherType = "notams"
+	WeatherTypeWindsAloft WeatherType = "winds_aloft"
+	WeatherTypeHazards    WeatherType = "hazards"
 )
 
 // FetchResult represents the result of fetching weather data
 type FetchResult struct {
-	Type WeatherType
-	Data interface{}
-	Err  error
+	Type     WeatherType
+	Data     interface{}
+	Raw      string // Raw report text, if the provider has it
+	Provider string // Provider the data came from
+	Err      error
 }
 
 // IsExpired checks if the cached data has expired
@@ -82,6 +98,7 @@ func NewWeatherCache() *WeatherCache {
 func DefaultWeatherConfig() WeatherConfig {
 	return WeatherConfig{
 		RefreshIntervalMinutes: 10,
+		Providers:              []string{ProviderWindy},
 		APIBaseURL:             "https://node.windy.com/airports",
 		RequestTimeoutSeconds:  10,
 		MaxRetries:             2,
@@ -95,26 +112,42 @@ func DefaultWeatherConfig() WeatherConfig {
 // ConfigWeatherConfig represents the config package's WeatherConfig
 // This is used to avoid circular imports
 type ConfigWeatherConfig struct {
-	RefreshIntervalMinutes int    `toml:"refresh_interval_minutes"`
-	APIBaseURL             string `toml:"api_base_url"`
-	RequestTimeoutSeconds  int    `toml:"request_timeout_seconds"`
-	MaxRetries             int    `toml:"max_retries"`
-	FetchMETAR             bool   `toml:"fetch_metar"`
-	FetchTAF               bool   `toml:"fetch_taf"`
-	FetchNOTAMs            bool   `toml:"fetch_notams"`
-	CacheExpiryMinutes     int    `toml:"cache_expiry_minutes"`
+	RefreshIntervalMinutes int      `toml:"refresh_interval_minutes"`
+	Providers              []string `toml:"providers"`
+	APIBaseURL             string   `toml:"api_base_url"`
+	CheckWXAPIKey          string   `toml:"checkwx_api_key"`
+	RequestTimeoutSeconds  int      `toml:"request_timeout_seconds"`
+	MaxRetries             int      `toml:"max_retries"`
+	FetchMETAR             bool     `toml:"fetch_metar"`
+	FetchTAF               bool     `toml:"fetch_taf"`
+	FetchNOTAMs            bool     `toml:"fetch_notams"`
+	FetchWindsAloft        bool     `toml:"fetch_winds_aloft"`
+	WindsAloftRadiusNM     int      `toml:"winds_aloft_radius_nm"`
+	FetchHazards           bool     `toml:"fetch_hazards"`
+	HazardsRadiusNM        int      `toml:"hazards_radius_nm"`
+	CacheExpiryMinutes     int      `toml:"cache_expiry_minutes"`
+	StationLatitude        float64  `toml:"-"` // Station location, for winds aloft and hazards
+	StationLongitude       float64  `toml:"-"`
 }
 
 // FromConfigWeatherConfig converts a config.WeatherConfig to weather.WeatherConfig
 func FromConfigWeatherConfig(cfg ConfigWeatherConfig) WeatherConfig {
 	return WeatherConfig{
 		RefreshIntervalMinutes: cfg.RefreshIntervalMinutes,
+		Providers:              cfg.Providers,
 		APIBaseURL:             cfg.APIBaseURL,
+		CheckWXAPIKey:          cfg.CheckWXAPIKey,
 		RequestTimeoutSeconds:  cfg.RequestTimeoutSeconds,
 		MaxRetries:             cfg.MaxRetries,
 		FetchMETAR:             cfg.FetchMETAR,
 		FetchTAF:               cfg.FetchTAF,
 		FetchNOTAMs:            cfg.FetchNOTAMs,
+		FetchWindsAloft:        cfg.FetchWindsAloft,
+		WindsAloftRadiusNM:     cfg.WindsAloftRadiusNM,
+		FetchHazards:           cfg.FetchHazards,
+		HazardsRadiusNM:        cfg.HazardsRadiusNM,
 		CacheExpiryMinutes:     cfg.CacheExpiryMinutes,
+		StationLatitude:        cfg.StationLatitude,
+		StationLongitude:       cfg.StationLongitude,
 	}
 }
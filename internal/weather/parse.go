@@ -0,0 +1,543 @@
+package weather
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Flight categories, from the ceiling and visibility
+const (
+	FlightCategoryVFR  = "VFR"
+	FlightCategoryMVFR = "MVFR"
+	FlightCategoryIFR  = "IFR"
+	FlightCategoryLIFR = "LIFR"
+)
+
+// hPaPerInHg converts between inches of mercury and hectopascals
+const hPaPerInHg = 33.8639
+
+// Wind is a decoded wind group
+type Wind struct {
+	DirectionDeg *int `json:"direction_deg,omitempty"` // True direction the wind blows from; nil if variable
+	Variable     bool `json:"variable,omitempty"`      // VRB: direction too variable to report
+	SpeedKt      int  `json:"speed_kt"`
+	GustKt       int  `json:"gust_kt,omitempty"`
+	VariableFrom *int `json:"variable_from_deg,omitempty"` // Direction varies between these (e.g. 210V270)
+	VariableTo   *int `json:"variable_to_deg,omitempty"`
+}
+
+// CloudLayer is a decoded cloud group
+type CloudLayer struct {
+	Cover  string `json:"cover"`          // FEW, SCT, BKN, OVC or VV (vertical visibility)
+	BaseFt int    `json:"base_ft"`        // Height above ground
+	Type   string `json:"type,omitempty"` // CB or TCU
+}
+
+// Conditions are the decoded weather elements a METAR and each TAF period share
+type Conditions struct {
+	Wind           *Wind        `json:"wind,omitempty"`
+	VisibilitySM   *float64     `json:"visibility_sm,omitempty"` // Statute miles; 10 for CAVOK and 9999 m
+	CAVOK          bool         `json:"cavok,omitempty"`
+	Weather        []string     `json:"weather,omitempty"` // Present weather groups, e.g. -RA, BR, +TSRA
+	Clouds         []CloudLayer `json:"clouds,omitempty"`
+	CeilingFt      *int         `json:"ceiling_ft,omitempty"`      // Lowest broken, overcast or vertical visibility layer
+	FlightCategory string       `json:"flight_category,omitempty"` // VFR, MVFR, IFR or LIFR; empty if unknown
+}
+
+// METAR is a decoded METAR report
+type METAR struct {
+	Raw        string    `json:"raw"`
+	Station    string    `json:"station"`
+	ObservedAt time.Time `json:"observed_at"`
+	Conditions
+	TemperatureC  *int     `json:"temperature_c,omitempty"`
+	DewpointC     *int     `json:"dewpoint_c,omitempty"`
+	AltimeterInHg *float64 `json:"altimeter_inhg,omitempty"`
+	QNHHPa        *int     `json:"qnh_hpa,omitempty"`
+	AltimeterUnit string   `json:"altimeter_unit,omitempty"` // Unit the METAR reports: "inHg" (A) or "hPa" (Q)
+	Remarks       string   `json:"remarks,omitempty"`
+}
+
+// TAFPeriod is a forecast period of a TAF: the base forecast or a change group
+type TAFPeriod struct {
+	Type        string    `json:"type"`                  // BASE, FM, BECMG, TEMPO or PROB
+	Probability int       `json:"probability,omitempty"` // PROB30 / PROB40
+	From        time.Time `json:"from"`
+	To          time.Time `json:"to"`
+	Conditions
+}
+
+// TAF is a decoded terminal aerodrome forecast
+type TAF struct {
+	Raw       string      `json:"raw"`
+	Station   string      `json:"station"`
+	IssuedAt  time.Time   `json:"issued_at"`
+	ValidFrom time.Time   `json:"valid_from"`
+	ValidTo   time.Time   `json:"valid_to"`
+	Periods   []TAFPeriod `json:"periods"`
+}
+
+// ParsedWeather is the METAR and TAF decoded into typed fields
+type ParsedWeather struct {
+	METAR *METAR `json:"metar,omitempty"`
+	TAF   *TAF   `json:"taf,omitempty"`
+}
+
+var (
+	stationPattern     = regexp.MustCompile(`^[A-Z][A-Z0-9]{3}$`)
+	dayTimePattern     = regexp.MustCompile(`^(\d{2})(\d{2})(\d{2})Z$`)
+	windPattern        = regexp.MustCompile(`^(\d{3}|VRB)(\d{2,3})(?:G(\d{2,3}))?(KT|MPS|KMH)$`)
+	windVarPattern     = regexp.MustCompile(`^(\d{3})V(\d{3})$`)
+	visSMPattern       = regexp.MustCompile(`^([PM])?(?:(\d+) ?)?(?:(\d)/(\d{1,2}))?SM$`)
+	visMetersPattern   = regexp.MustCompile(`^(\d{4})(?:NDV)?$`)
+	cloudPattern       = regexp.MustCompile(`^(FEW|SCT|BKN|OVC|VV)(\d{3}|///)(CB|TCU)?$`)
+	tempPattern        = regexp.MustCompile(`^(M?\d{2})/(M?\d{2})?$`)
+	altimeterPattern   = regexp.MustCompile(`^([AQ])(\d{4})$`)
+	validityPattern    = regexp.MustCompile(`^(\d{2})(\d{2})/(\d{2})(\d{2})$`)
+	fromPattern        = regexp.MustCompile(`^FM(\d{2})(\d{2})(\d{2})$`)
+	probPattern        = regexp.MustCompile(`^PROB(\d{2})$`)
+	weatherPattern     = regexp.MustCompile(`^(?:-|\+|VC)?(?:MI|PR|BC|DR|BL|SH|TS|FZ)?(?:DZ|RA|SN|SG|IC|PL|GR|GS|UP|BR|FG|FU|VA|DU|SA|HZ|PY|PO|SQ|FC|SS|DS)*$`)
+	runwayVisPattern   = regexp.MustCompile(`^R\d{2}[LCR]?/`)
+	clearSkyIndicators = map[string]bool{"SKC": true, "CLR": true, "NSC": true, "NCD": true}
+)
+
+// ParseMETAR decodes a METAR. now places the day-of-month timestamp in a month.
+func ParseMETAR(raw string, now time.Time) (*METAR, error) {
+	raw = strings.TrimSpace(strings.TrimSuffix(strings.TrimSpace(raw), "="))
+	tokens := strings.Fields(raw)
+	if len(tokens) > 0 && (tokens[0] == "METAR" || tokens[0] == "SPECI") {
+		tokens = tokens[1:]
+	}
+	if len(tokens) < 2 || !stationPattern.MatchString(tokens[0]) {
+		return nil, fmt.Errorf("not a METAR: %q", raw)
+	}
+
+	metar := &METAR{Raw: raw, Station: tokens[0]}
+	observedAt, ok := parseDayTime(tokens[1], now)
+	if !ok {
+		return nil, fmt.Errorf("invalid METAR observation time: %s", tokens[1])
+	}
+	metar.ObservedAt = observedAt
+
+	for i := 2; i < len(tokens); i++ {
+		token := tokens[i]
+		if token == "RMK" {
+			metar.Remarks = strings.Join(tokens[i+1:], " ")
+			break
+		}
+		// Trend forecasts aren't the observation
+		if token == "NOSIG" || token == "BECMG" || token == "TEMPO" {
+			break
+		}
+
+		if consumed := metar.Conditions.parseToken(tokens, i); consumed > 0 {
+			i += consumed - 1
+			continue
+		}
+
+		if match := tempPattern.FindStringSubmatch(token); match != nil {
+			metar.TemperatureC = parseTemperature(match[1])
+			metar.DewpointC = parseTemperature(match[2])
+			continue
+		}
+		if match := altimeterPattern.FindStringSubmatch(token); match != nil {
+			value, _ := strconv.Atoi(match[2])
+			if match[1] == "A" {
+				inHg := float64(value) / 100
+				hPa := int(math.Round(inHg * hPaPerInHg))
+				metar.AltimeterInHg, metar.QNHHPa = &inHg, &hPa
+				metar.AltimeterUnit = "inHg"
+			} else {
+				inHg := math.Round(float64(value)/hPaPerInHg*100) / 100
+				metar.AltimeterInHg, metar.QNHHPa = &inHg, &value
+				metar.AltimeterUnit = "hPa"
+			}
+		}
+	}
+
+	metar.Conditions.finish()
+	return metar, nil
+}
+
+// ParseTAF decodes a TAF. now places the day-of-month timestamps in a month.
+func ParseTAF(raw string, now time.Time) (*TAF, error) {
+	raw = strings.TrimSpace(strings.TrimSuffix(strings.TrimSpace(raw), "="))
+	tokens := strings.Fields(raw)
+	for len(tokens) > 0 && (tokens[0] == "TAF" || tokens[0] == "AMD" || tokens[0] == "COR") {
+		tokens = tokens[1:]
+	}
+	if len(tokens) < 3 || !stationPattern.MatchString(tokens[0]) {
+		return nil, fmt.Errorf("not a TAF: %q", raw)
+	}
+
+	taf := &TAF{Raw: raw, Station: tokens[0]}
+	i := 1
+	if issuedAt, ok := parseDayTime(tokens[i], now); ok {
+		taf.IssuedAt = issuedAt
+		i++
+	}
+	if i >= len(tokens) {
+		return nil, fmt.Errorf("TAF has no validity period: %q", raw)
+	}
+	reference := taf.IssuedAt
+	if reference.IsZero() {
+		reference = now
+	}
+	validFrom, validTo, ok := parseValidity(tokens[i], reference)
+	if !ok {
+		return nil, fmt.Errorf("invalid TAF validity period: %s", tokens[i])
+	}
+	taf.ValidFrom, taf.ValidTo = validFrom, validTo
+	i++
+
+	period := &TAFPeriod{Type: "BASE", From: validFrom, To: validTo}
+	for ; i < len(tokens); i++ {
+		token := tokens[i]
+		if token == "RMK" {
+			break
+		}
+
+		var next *TAFPeriod
+		switch {
+		case fromPattern.MatchString(token):
+			match := fromPattern.FindStringSubmatch(token)
+			from, ok := parseDayTime(match[1]+match[2]+match[3]+"Z", validTo)
+			if ok {
+				next = &TAFPeriod{Type: "FM", From: from, To: validTo}
+			}
+		case token == "BECMG" || token == "TEMPO" || probPattern.MatchString(token):
+			next = &TAFPeriod{Type: token}
+			if match := probPattern.FindStringSubmatch(token); match != nil {
+				next.Type = "PROB"
+				next.Probability, _ = strconv.Atoi(match[1])
+				// PROB30 TEMPO is a probable temporary change
+				if i+1 < len(tokens) && tokens[i+1] == "TEMPO" {
+					i++
+				}
+			}
+			if i+1 < len(tokens) {
+				if from, to, ok := parseValidity(tokens[i+1], validFrom); ok {
+					next.From, next.To = from, to
+					i++
+				}
+			}
+		}
+		if next != nil {
+			period.Conditions.finish()
+			taf.Periods = append(taf.Periods, *period)
+			period = next
+			continue
+		}
+
+		if consumed := period.Conditions.parseToken(tokens, i); consumed > 0 {
+			i += consumed - 1
+		}
+	}
+	period.Conditions.finish()
+	taf.Periods = append(taf.Periods, *period)
+
+	// A FM group ends where the next one starts
+	lastFM := -1
+	for j := range taf.Periods {
+		if taf.Periods[j].Type == "FM" || taf.Periods[j].Type == "BASE" {
+			if lastFM >= 0 {
+				taf.Periods[lastFM].To = taf.Periods[j].From
+			}
+			lastFM = j
+		}
+	}
+
+	return taf, nil
+}
+
+// ConditionsAt returns the forecast prevailing at a time: the latest FM or base period that
+// started, with BECMG changes that have begun applied over it. TEMPO and PROB periods are
+// left out because they don't prevail.
+func (t *TAF) ConditionsAt(at time.Time) (Conditions, bool) {
+	if t == nil || at.Before(t.ValidFrom) || !at.Before(t.ValidTo) {
+		return Conditions{}, false
+	}
+
+	var conditions Conditions
+	for _, period := range t.Periods {
+		switch period.Type {
+		case "BASE", "FM":
+			if !at.Before(period.From) {
+				conditions = period.Conditions
+			}
+		case "BECMG":
+			if !at.Before(period.From) {
+				conditions = conditions.merge(period.Conditions)
+			}
+		}
+	}
+	return conditions, true
+}
+
+// FlightCategory returns the flight category of a ceiling and visibility, either of which
+// may be unknown. Unknown values don't lower the category.
+func FlightCategory(ceilingFt *int, visibilitySM *float64) string {
+	if ceilingFt == nil && visibilitySM == nil {
+		return ""
+	}
+
+	ceiling := math.MaxInt32
+	if ceilingFt != nil {
+		ceiling = *ceilingFt
+	}
+	visibility := math.Inf(1)
+	if visibilitySM != nil {
+		visibility = *visibilitySM
+	}
+
+	switch {
+	case ceiling < 500 || visibility < 1:
+		return FlightCategoryLIFR
+	case ceiling < 1000 || visibility < 3:
+		return FlightCategoryIFR
+	case ceiling <= 3000 || visibility <= 5:
+		return FlightCategoryMVFR
+	default:
+		return FlightCategoryVFR
+	}
+}
+
+// parseToken decodes the weather element at tokens[i] and returns how many tokens it used,
+// or 0 if it isn't one
+func (c *Conditions) parseToken(tokens []string, i int) int {
+	token := tokens[i]
+
+	if match := windPattern.FindStringSubmatch(token); match != nil {
+		c.Wind = parseWind(match)
+		return 1
+	}
+	if match := windVarPattern.FindStringSubmatch(token); match != nil && c.Wind != nil {
+		from, _ := strconv.Atoi(match[1])
+		to, _ := strconv.Atoi(match[2])
+		c.Wind.VariableFrom, c.Wind.VariableTo = &from, &to
+		return 1
+	}
+	if token == "CAVOK" {
+		visibility := 10.0
+		c.VisibilitySM = &visibility
+		c.CAVOK = true
+		return 1
+	}
+
+	// Visibility in statute miles can be split over two tokens: 1 1/2SM
+	if i+1 < len(tokens) && isWholeNumber(token) && strings.HasSuffix(tokens[i+1], "SM") {
+		if visibility, ok := parseVisibilitySM(token + " " + tokens[i+1]); ok {
+			c.VisibilitySM = &visibility
+			return 2
+		}
+	}
+	if strings.HasSuffix(token, "SM") {
+		if visibility, ok := parseVisibilitySM(token); ok {
+			c.VisibilitySM = &visibility
+			return 1
+		}
+	}
+	if match := visMetersPattern.FindStringSubmatch(token); match != nil && c.VisibilitySM == nil {
+		meters, _ := strconv.Atoi(match[1])
+		visibility := math.Round(float64(meters)/1609.344*100) / 100
+		if meters == 9999 {
+			visibility = 10
+		}
+		c.VisibilitySM = &visibility
+		return 1
+	}
+
+	if match := cloudPattern.FindStringSubmatch(token); match != nil {
+		layer := CloudLayer{Cover: match[1], Type: match[3]}
+		if height, err := strconv.Atoi(match[2]); err == nil {
+			layer.BaseFt = height * 100
+		}
+		c.Clouds = append(c.Clouds, layer)
+		return 1
+	}
+	if clearSkyIndicators[token] {
+		return 1
+	}
+
+	if runwayVisPattern.MatchString(token) {
+		return 1
+	}
+	if token != "" && weatherPattern.MatchString(token) && token != "-" && token != "+" && token != "VC" {
+		c.Weather = append(c.Weather, token)
+		return 1
+	}
+
+	return 0
+}
+
+// finish works out the ceiling and flight category once all elements are decoded
+func (c *Conditions) finish() {
+	c.CeilingFt = nil
+	for _, layer := range c.Clouds {
+		if layer.Cover != "BKN" && layer.Cover != "OVC" && layer.Cover != "VV" {
+			continue
+		}
+		if c.CeilingFt == nil || layer.BaseFt < *c.CeilingFt {
+			base := layer.BaseFt
+			c.CeilingFt = &base
+		}
+	}
+
+	// Clouds reported without a ceiling, or a clear-sky indicator, mean unlimited ceiling
+	ceiling := c.CeilingFt
+	if ceiling == nil && (len(c.Clouds) > 0 || c.CAVOK || c.VisibilitySM != nil) {
+		unlimited := math.MaxInt32
+		ceiling = &unlimited
+	}
+	c.FlightCategory = FlightCategory(ceiling, c.VisibilitySM)
+}
+
+// merge applies the elements a change group reports over these conditions
+func (c Conditions) merge(change Conditions) Conditions {
+	if change.Wind != nil {
+		c.Wind = change.Wind
+	}
+	if change.VisibilitySM != nil {
+		c.VisibilitySM = change.VisibilitySM
+		c.CAVOK = change.CAVOK
+	}
+	if len(change.Weather) > 0 {
+		c.Weather = change.Weather
+	}
+	if len(change.Clouds) > 0 {
+		c.Clouds = change.Clouds
+	}
+	c.finish()
+	return c
+}
+
+// parseWind decodes a matched wind group into knots
+func parseWind(match []string) *Wind {
+	wind := &Wind{}
+	if match[1] == "VRB" {
+		wind.Variable = true
+	} else {
+		direction, _ := strconv.Atoi(match[1])
+		wind.DirectionDeg = &direction
+	}
+
+	toKnots := func(value string) int {
+		speed, _ := strconv.Atoi(value)
+		switch match[4] {
+		case "MPS":
+			return int(math.Round(float64(speed) * 1.94384))
+		case "KMH":
+			return int(math.Round(float64(speed) / 1.852))
+		}
+		return speed
+	}
+	wind.SpeedKt = toKnots(match[2])
+	if match[3] != "" {
+		wind.GustKt = toKnots(match[3])
+	}
+	return wind
+}
+
+// parseVisibilitySM decodes a visibility in statute miles (10SM, 1/2SM, 1 1/2SM, P6SM, M1/4SM)
+func parseVisibilitySM(token string) (float64, bool) {
+	match := visSMPattern.FindStringSubmatch(token)
+	if match == nil || (match[2] == "" && match[3] == "") {
+		return 0, false
+	}
+
+	var visibility float64
+	if match[2] != "" {
+		value, _ := strconv.Atoi(match[2])
+		visibility = float64(value)
+	}
+	if match[3] != "" {
+		numerator, _ := strconv.Atoi(match[3])
+		denominator, _ := strconv.Atoi(match[4])
+		if denominator == 0 {
+			return 0, false
+		}
+		visibility += float64(numerator) / float64(denominator)
+	}
+	return visibility, true
+}
+
+// parseTemperature decodes a temperature, with M for minus
+func parseTemperature(value string) *int {
+	if value == "" {
+		return nil
+	}
+	negative := strings.HasPrefix(value, "M")
+	temperature, err := strconv.Atoi(strings.TrimPrefix(value, "M"))
+	if err != nil {
+		return nil
+	}
+	if negative {
+		temperature = -temperature
+	}
+	return &temperature
+}
+
+// parseDayTime decodes a DDHHMMZ time in the month of the reference time, or the month before
+// when the day is after the reference's day
+func parseDayTime(token string, reference time.Time) (time.Time, bool) {
+	match := dayTimePattern.FindStringSubmatch(token)
+	if match == nil {
+		return time.Time{}, false
+	}
+	day, _ := strconv.Atoi(match[1])
+	hour, _ := strconv.Atoi(match[2])
+	minute, _ := strconv.Atoi(match[3])
+	return dayHour(reference, day, hour, minute)
+}
+
+// parseValidity decodes a DDHH/DDHH validity period that starts near the reference time
+func parseValidity(token string, reference time.Time) (time.Time, time.Time, bool) {
+	match := validityPattern.FindStringSubmatch(token)
+	if match == nil {
+		return time.Time{}, time.Time{}, false
+	}
+	fromDay, _ := strconv.Atoi(match[1])
+	fromHour, _ := strconv.Atoi(match[2])
+	toDay, _ := strconv.Atoi(match[3])
+	toHour, _ := strconv.Atoi(match[4])
+
+	from, ok := dayHour(reference.Add(48*time.Hour), fromDay, fromHour, 0)
+	if !ok {
+		return time.Time{}, time.Time{}, false
+	}
+	to, ok := dayHour(from.Add(31*24*time.Hour), toDay, toHour, 0)
+	if !ok {
+		return time.Time{}, time.Time{}, false
+	}
+	return from, to, true
+}
+
+// dayHour returns the latest time on or before the reference with the day of month, hour and
+// minute. Hour 24 is midnight at the end of the day.
+func dayHour(reference time.Time, day, hour, minute int) (time.Time, bool) {
+	if day < 1 || day > 31 || hour > 24 || minute > 59 {
+		return time.Time{}, false
+	}
+	reference = reference.UTC()
+	for months := 0; months < 3; months++ {
+		year, month, _ := reference.AddDate(0, -months, 0).Date()
+		// Skip months without the day (the 31st in a 30-day month)
+		if time.Date(year, month, day, 0, 0, 0, 0, time.UTC).Day() != day {
+			continue
+		}
+		t := time.Date(year, month, day, hour, minute, 0, 0, time.UTC)
+		if !t.After(reference.Add(time.Hour)) {
+			return t, true
+		}
+	}
+	return time.Time{}, false
+}
+
+// isWholeNumber reports whether a token is a small whole number, the first half of 1 1/2SM
+func isWholeNumber(token string) bool {
+	return len(token) <= 2 && token != "" && strings.Trim(token, "0123456789") == ""
+}
@@ -0,0 +1,44 @@
+package weather
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Weather providers
+const (
+	ProviderWindy           = "windy"
+	ProviderAviationWeather = "aviationweather"
+	ProviderCheckWX         = "checkwx"
+)
+
+// ErrNotSupported is returned by providers for weather types they don't serve
+var ErrNotSupported = errors.New("not supported by this provider")
+
+// Report is weather data fetched from a provider
+type Report struct {
+	Data interface{} // Provider's response, served as-is by the API
+	Raw  string      // Raw METAR or TAF text, decoded into ParsedWeather
+}
+
+// Provider fetches weather data from one weather API
+type Provider interface {
+	Name() string
+	FetchMETAR(airportCode string) (*Report, error)
+	FetchTAF(airportCode string) (*Report, error)
+	FetchNOTAMs(airportCode string) (*Report, error)
+}
+
+// newProvider creates the named provider
+func newProvider(name string, client *Client) (Provider, error) {
+	switch name {
+	case ProviderWindy:
+		return &windyProvider{client: client, baseURL: client.config.APIBaseURL}, nil
+	case ProviderAviationWeather:
+		return &aviationWeatherProvider{client: client}, nil
+	case ProviderCheckWX:
+		return &checkWXProvider{client: client, apiKey: client.config.CheckWXAPIKey}, nil
+	default:
+		return nil, fmt.Errorf("unknown weather provider: %s", name)
+	}
+}
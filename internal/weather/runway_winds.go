@@ -0,0 +1,86 @@
+package weather
+
+import "math"
+
+// RunwayWindLimits are the wind components above which a runway is flagged
+type RunwayWindLimits struct {
+	CrosswindKt int `json:"crosswind_kt"` // 0 = no limit
+	TailwindKt  int `json:"tailwind_kt"`  // 0 or less flags any tailwind
+}
+
+// RunwayWind is the wind relative to a runway. Components are for the steady wind; the max
+// components are the worst case with gusts and, for variable wind, across the variable range.
+type RunwayWind struct {
+	HeadingDeg       int    `json:"heading_deg"`              // True heading of the runway
+	HeadwindKt       int    `json:"headwind_kt"`              // 0 with a tailwind
+	TailwindKt       int    `json:"tailwind_kt"`              // 0 with a headwind
+	CrosswindKt      int    `json:"crosswind_kt"`             // Always positive; see crosswind_from
+	CrosswindFrom    string `json:"crosswind_from,omitempty"` // "left" or "right", empty without crosswind
+	MaxCrosswindKt   int    `json:"max_crosswind_kt"`
+	MaxTailwindKt    int    `json:"max_tailwind_kt"`
+	ExceedsCrosswind bool   `json:"exceeds_crosswind"`
+	ExceedsTailwind  bool   `json:"exceeds_tailwind"`
+}
+
+// ComputeRunwayWind resolves a wind into components along and across a runway and checks
+// them against the limits. A wind without a direction (VRB) is taken as blowing from
+// anywhere, so its full speed counts as both crosswind and tailwind.
+func ComputeRunwayWind(headingDeg float64, wind *Wind, limits RunwayWindLimits) RunwayWind {
+	result := RunwayWind{HeadingDeg: int(math.Round(headingDeg)) % 360}
+	if result.HeadingDeg == 0 {
+		result.HeadingDeg = 360
+	}
+	if wind == nil || wind.SpeedKt == 0 {
+		return result
+	}
+
+	peak := wind.SpeedKt
+	if wind.GustKt > peak {
+		peak = wind.GustKt
+	}
+
+	if wind.DirectionDeg == nil {
+		result.MaxCrosswindKt = peak
+		result.MaxTailwindKt = peak
+	} else {
+		head, cross := windComponentsFor(headingDeg, float64(*wind.DirectionDeg), float64(wind.SpeedKt))
+		if head >= 0 {
+			result.HeadwindKt = int(math.Round(head))
+		} else {
+			result.TailwindKt = int(math.Round(-head))
+		}
+		result.CrosswindKt = int(math.Round(math.Abs(cross)))
+		if result.CrosswindKt > 0 {
+			result.CrosswindFrom = "right"
+			if cross < 0 {
+				result.CrosswindFrom = "left"
+			}
+		}
+
+		// Worst case over the gust and the directions the wind varies between
+		directions := []float64{float64(*wind.DirectionDeg)}
+		if wind.VariableFrom != nil && wind.VariableTo != nil {
+			span := float64((*wind.VariableTo - *wind.VariableFrom + 360) % 360)
+			for offset := 0.0; offset <= span; offset += 5 {
+				directions = append(directions, float64(*wind.VariableFrom)+offset)
+			}
+			directions = append(directions, float64(*wind.VariableTo))
+		}
+		for _, direction := range directions {
+			head, cross := windComponentsFor(headingDeg, direction, float64(peak))
+			result.MaxCrosswindKt = max(result.MaxCrosswindKt, int(math.Round(math.Abs(cross))))
+			result.MaxTailwindKt = max(result.MaxTailwindKt, int(math.Round(-head)))
+		}
+	}
+
+	result.ExceedsCrosswind = limits.CrosswindKt > 0 && result.MaxCrosswindKt > limits.CrosswindKt
+	result.ExceedsTailwind = result.MaxTailwindKt > max(limits.TailwindKt, 0)
+	return result
+}
+
+// windComponentsFor returns the headwind (negative for a tailwind) and crosswind (positive
+// from the right) of a wind on a runway heading
+func windComponentsFor(headingDeg, directionDeg, speedKt float64) (float64, float64) {
+	angle := (directionDeg - headingDeg) * math.Pi / 180
+	return speedKt * math.Cos(angle), speedKt * math.Sin(angle)
+}
@@ -6,6 +6,7 @@ import (
 	"sync"
 	"time"
 
+	"github.com/yegors/co-atc/internal/storage/sqlite"
 	"github.com/yegors/co-atc/pkg/logger"
 )
 
@@ -27,6 +28,16 @@ type Service struct {
 	// Initial data readiness
 	initialDataReady chan struct{}
 	initialDataOnce  sync.Once
+
+	// Delivers a new refresh interval to the background refresh
+	refreshIntervalCh chan time.Duration
+
+	// Called after every refresh
+	refreshListeners []func()
+
+	// METAR history, if stored
+	history          *sqlite.WeatherHistoryStorage
+	historyRetention time.Duration
 }
 
 // NewService creates a new weather service
@@ -37,14 +48,15 @@ func NewService(configWeather ConfigWeatherConfig, airportCode string, logger *l
 	ctx, cancel := context.WithCancel(context.Background())
 
 	return &Service{
-		config:           weatherConfig,
-		airportCode:      airportCode,
-		client:           NewClient(weatherConfig, logger),
-		cache:            NewCache(weatherConfig, logger),
-		logger:           logger.Named("weather-service"),
-		ctx:              ctx,
-		cancel:           cancel,
-		initialDataReady: make(chan struct{}),
+		config:            weatherConfig,
+		airportCode:       airportCode,
+		client:            NewClient(weatherConfig, logger),
+		cache:             NewCache(weatherConfig, logger),
+		logger:            logger.Named("weather-service"),
+		ctx:               ctx,
+		cancel:            cancel,
+		initialDataReady:  make(chan struct{}),
+		refreshIntervalCh: make(chan time.Duration, 1),
 	}
 }
 
@@ -69,10 +81,11 @@ func (s *Service) Start() error {
 	}()
 
 	// Start background refresh goroutine
+	refreshInterval := time.Duration(s.config.RefreshIntervalMinutes) * time.Minute
 	s.wg.Add(1)
 	go func() {
 		defer s.wg.Done()
-		s.backgroundRefresh()
+		s.backgroundRefresh(refreshInterval)
 	}()
 
 	s.started = true
@@ -136,6 +149,36 @@ func (s *Service) RefreshNow() {
 	go s.fetchAndUpdateCache()
 }
 
+// GetWindsAloft returns the latest winds aloft, or nil if they aren't fetched or haven't
+// arrived yet. Unlike GetWeatherData it doesn't wait for the first fetch.
+func (s *Service) GetWindsAloft() *WindsAloft {
+	data := s.cache.Get()
+	if data == nil {
+		return nil
+	}
+	return data.WindsAloft
+}
+
+// GetHazards returns the latest SIGMETs, AIRMETs and PIREPs near the station, or nil if they
+// aren't fetched or haven't arrived yet
+func (s *Service) GetHazards() *Hazards {
+	data := s.cache.Get()
+	if data == nil {
+		return nil
+	}
+	return data.Hazards
+}
+
+// WindAt returns the forecast wind (direction from, degrees true, and speed in knots) at a
+// position and altitude, for trajectory prediction. It reports false without recent winds aloft.
+func (s *Service) WindAt(lat, lon, altitudeFt float64) (float64, float64, bool) {
+	winds := s.GetWindsAloft()
+	if winds == nil || time.Since(winds.FetchedAt) > windsAloftMaxAge {
+		return 0, 0, false
+	}
+	return winds.WindAt(lat, lon, altitudeFt)
+}
+
 // GetCacheStats returns cache statistics
 func (s *Service) GetCacheStats() map[string]interface{} {
 	return s.cache.GetStats()
@@ -162,9 +205,37 @@ func (s *Service) performInitialFetch() {
 	})
 }
 
+// OnRefresh registers a function that is called after every weather data refresh
+func (s *Service) OnRefresh(fn func()) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.refreshListeners = append(s.refreshListeners, fn)
+}
+
+// SetRefreshInterval changes how often weather data is refreshed
+func (s *Service) SetRefreshInterval(minutes int) {
+	if minutes <= 0 {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.config.RefreshIntervalMinutes == minutes {
+		return
+	}
+	s.config.RefreshIntervalMinutes = minutes
+
+	// Replace any interval the background refresh has not picked up yet
+	select {
+	case <-s.refreshIntervalCh:
+	default:
+	}
+	s.refreshIntervalCh <- time.Duration(minutes) * time.Minute
+}
+
 // backgroundRefresh runs the periodic weather data refresh
-func (s *Service) backgroundRefresh() {
-	refreshInterval := time.Duration(s.config.RefreshIntervalMinutes) * time.Minute
+func (s *Service) backgroundRefresh(refreshInterval time.Duration) {
 	ticker := time.NewTicker(refreshInterval)
 	defer ticker.Stop()
 
@@ -179,6 +250,10 @@ func (s *Service) backgroundRefresh() {
 		case <-ticker.C:
 			s.logger.Debug("Periodic weather refresh triggered")
 			s.fetchAndUpdateCache()
+		case refreshInterval = <-s.refreshIntervalCh:
+			ticker.Reset(refreshInterval)
+			s.logger.Info("Weather refresh interval changed",
+				logger.String("interval", refreshInterval.String()))
 		}
 	}
 }
@@ -195,12 +270,22 @@ func (s *Service) fetchAndUpdateCache() {
 
 	// Update cache with results
 	s.cache.Update(results, s.airportCode)
+	s.recordObservation()
 
 	duration := time.Since(startTime)
 	s.logger.Info("Weather data fetch completed",
 		logger.String("airport", s.airportCode),
 		logger.String("duration", duration.String()),
 		logger.Int("total_requests", len(results)))
+
+	s.mu.RLock()
+	listeners := make([]func(), len(s.refreshListeners))
+	copy(listeners, s.refreshListeners)
+	s.mu.RUnlock()
+
+	for _, fn := range listeners {
+		fn()
+	}
 }
 
 // ValidateConfig validates the weather service configuration
@@ -221,8 +306,20 @@ func ValidateConfig(config WeatherConfig) error {
 		return fmt.Errorf("cache_expiry_minutes must be greater than 0")
 	}
 
-	if config.APIBaseURL == "" {
-		return fmt.Errorf("api_base_url cannot be empty")
+	for _, provider := range config.Providers {
+		switch provider {
+		case ProviderWindy:
+			if config.APIBaseURL == "" {
+				return fmt.Errorf("api_base_url cannot be empty")
+			}
+		case ProviderAviationWeather:
+		case ProviderCheckWX:
+			if config.CheckWXAPIKey == "" {
+				return fmt.Errorf("checkwx_api_key is required for the checkwx provider")
+			}
+		default:
+			return fmt.Errorf("unknown weather provider: %s", provider)
+		}
 	}
 
 	// At least one weather type must be enabled
@@ -0,0 +1,241 @@
+package weather
+
+import (
+	"fmt"
+	"math"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/yegors/co-atc/internal/adsb"
+)
+
+const (
+	// windsAloftURL is Open-Meteo's GFS forecast API, which serves winds at pressure levels
+	// for any location
+	windsAloftURL = "https://api.open-meteo.com/v1/gfs"
+
+	// windsAloftMaxAge is how old winds aloft can be before trajectory prediction stops using them
+	windsAloftMaxAge = 6 * time.Hour
+)
+
+// windsAloftLevels are the pressure levels fetched, from the surface up to the flight levels
+var windsAloftLevels = []int{1000, 925, 850, 700, 600, 500, 400, 300, 250, 200}
+
+// WindLevel is the forecast wind and temperature at a pressure level
+type WindLevel struct {
+	PressureHPa  int     `json:"pressure_hpa"`
+	AltitudeFt   int     `json:"altitude_ft"` // Geopotential height of the level
+	DirectionDeg int     `json:"direction_deg"`
+	SpeedKt      int     `json:"speed_kt"`
+	TemperatureC float64 `json:"temperature_c"`
+}
+
+// WindPoint is the winds aloft forecast at one location
+type WindPoint struct {
+	Name   string      `json:"name"` // "station", or the direction of the point from it ("N", "E", ...)
+	Lat    float64     `json:"lat"`
+	Lon    float64     `json:"lon"`
+	Levels []WindLevel `json:"levels"` // Lowest first
+}
+
+// WindsAloft is the winds aloft forecast for the station and the area around it
+type WindsAloft struct {
+	Source    string      `json:"source"`
+	ValidAt   time.Time   `json:"valid_at"` // Forecast hour the winds are for
+	FetchedAt time.Time   `json:"fetched_at"`
+	RadiusNM  int         `json:"radius_nm"` // Distance of the surrounding points from the station
+	Points    []WindPoint `json:"points"`    // Station first
+}
+
+// windsAloftPoints returns the station and four points around it at the radius
+func windsAloftPoints(lat, lon float64, radiusNM int) []WindPoint {
+	points := []WindPoint{{Name: "station", Lat: lat, Lon: lon}}
+	if radiusNM <= 0 {
+		return points
+	}
+	for _, direction := range []struct {
+		name    string
+		bearing float64
+	}{{"N", 0}, {"E", 90}, {"S", 180}, {"W", 270}} {
+		pointLat, pointLon := adsb.DestinationPoint(lat, lon, direction.bearing, float64(radiusNM))
+		points = append(points, WindPoint{Name: direction.name, Lat: pointLat, Lon: pointLon})
+	}
+	return points
+}
+
+// FetchWindsAloft fetches the GFS winds aloft for the station and the area around it
+func (c *Client) FetchWindsAloft(airportCode string) (*WindsAloft, error) {
+	points := windsAloftPoints(c.config.StationLatitude, c.config.StationLongitude, c.config.WindsAloftRadiusNM)
+
+	var lats, lons, variables []string
+	for _, point := range points {
+		lats = append(lats, strconv.FormatFloat(point.Lat, 'f', 4, 64))
+		lons = append(lons, strconv.FormatFloat(point.Lon, 'f', 4, 64))
+	}
+	for _, level := range windsAloftLevels {
+		for _, variable := range []string{"wind_speed", "wind_direction", "temperature", "geopotential_height"} {
+			variables = append(variables, fmt.Sprintf("%s_%dhPa", variable, level))
+		}
+	}
+
+	query := url.Values{}
+	query.Set("latitude", strings.Join(lats, ","))
+	query.Set("longitude", strings.Join(lons, ","))
+	query.Set("hourly", strings.Join(variables, ","))
+	query.Set("wind_speed_unit", "kn")
+	query.Set("timezone", "GMT")
+	query.Set("forecast_days", "1")
+	query.Set("timeformat", "unixtime")
+
+	data, err := c.fetchWithRetry(windsAloftURL+"?"+query.Encode(), nil, WeatherTypeWindsAloft, airportCode)
+	if err != nil {
+		return nil, err
+	}
+
+	// A single location is returned as an object, several as a list
+	responses, ok := data.([]interface{})
+	if !ok {
+		responses = []interface{}{data}
+	}
+	if len(responses) != len(points) {
+		return nil, fmt.Errorf("winds aloft response has %d locations, expected %d", len(responses), len(points))
+	}
+
+	now := time.Now().UTC()
+	winds := &WindsAloft{
+		Source:    "gfs",
+		FetchedAt: now,
+		RadiusNM:  c.config.WindsAloftRadiusNM,
+	}
+	for i, response := range responses {
+		location, ok := response.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("unexpected winds aloft response format")
+		}
+		hourly, ok := location["hourly"].(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("winds aloft response has no hourly data")
+		}
+
+		hour, validAt := closestHour(hourly["time"], now)
+		if hour < 0 {
+			return nil, fmt.Errorf("winds aloft response has no forecast hours")
+		}
+		winds.ValidAt = validAt
+
+		for _, level := range windsAloftLevels {
+			value := func(variable string) (float64, bool) {
+				return hourlyValue(hourly, fmt.Sprintf("%s_%dhPa", variable, level), hour)
+			}
+			speed, okSpeed := value("wind_speed")
+			direction, okDirection := value("wind_direction")
+			height, okHeight := value("geopotential_height")
+			if !okSpeed || !okDirection || !okHeight {
+				continue
+			}
+			temperature, _ := value("temperature")
+			points[i].Levels = append(points[i].Levels, WindLevel{
+				PressureHPa:  level,
+				AltitudeFt:   int(math.Round(height * 3.28084)),
+				DirectionDeg: int(math.Round(direction)) % 360,
+				SpeedKt:      int(math.Round(speed)),
+				TemperatureC: temperature,
+			})
+		}
+	}
+	winds.Points = points
+
+	return winds, nil
+}
+
+// WindAt returns the forecast wind at a position and pressure altitude, from the nearest point
+// with winds, interpolated between the levels above and below. The direction is where the wind
+// blows from, in degrees true.
+func (w *WindsAloft) WindAt(lat, lon, altitudeFt float64) (float64, float64, bool) {
+	if w == nil {
+		return 0, 0, false
+	}
+
+	var nearest *WindPoint
+	nearestDistance := math.Inf(1)
+	for i := range w.Points {
+		point := &w.Points[i]
+		if len(point.Levels) == 0 {
+			continue
+		}
+		if distance := adsb.MetersToNM(adsb.Haversine(lat, lon, point.Lat, point.Lon)); distance < nearestDistance {
+			nearest, nearestDistance = point, distance
+		}
+	}
+	if nearest == nil {
+		return 0, 0, false
+	}
+
+	levels := nearest.Levels
+	if altitudeFt <= float64(levels[0].AltitudeFt) {
+		return float64(levels[0].DirectionDeg), float64(levels[0].SpeedKt), true
+	}
+	for i := 1; i < len(levels); i++ {
+		below, above := levels[i-1], levels[i]
+		if altitudeFt > float64(above.AltitudeFt) {
+			continue
+		}
+		// Interpolate the wind vectors, so winds either side of north don't average to south
+		fraction := (altitudeFt - float64(below.AltitudeFt)) / float64(above.AltitudeFt-below.AltitudeFt)
+		belowU, belowV := windComponents(below)
+		aboveU, aboveV := windComponents(above)
+		u := belowU + (aboveU-belowU)*fraction
+		v := belowV + (aboveV-belowV)*fraction
+		direction := math.Mod(math.Atan2(u, v)*180/math.Pi+360, 360)
+		return direction, math.Hypot(u, v), true
+	}
+	top := levels[len(levels)-1]
+	return float64(top.DirectionDeg), float64(top.SpeedKt), true
+}
+
+// StationLevels returns the levels of the station's forecast
+func (w *WindsAloft) StationLevels() []WindLevel {
+	if w == nil || len(w.Points) == 0 {
+		return nil
+	}
+	return w.Points[0].Levels
+}
+
+// windComponents returns the east and north components of the direction a wind blows from
+func windComponents(level WindLevel) (float64, float64) {
+	radians := float64(level.DirectionDeg) * math.Pi / 180
+	return float64(level.SpeedKt) * math.Sin(radians), float64(level.SpeedKt) * math.Cos(radians)
+}
+
+// closestHour returns the index and time of the forecast hour closest to now
+func closestHour(times interface{}, now time.Time) (int, time.Time) {
+	list, ok := times.([]interface{})
+	if !ok {
+		return -1, time.Time{}
+	}
+
+	best, bestTime := -1, time.Time{}
+	for i, value := range list {
+		seconds, ok := value.(float64)
+		if !ok {
+			continue
+		}
+		t := time.Unix(int64(seconds), 0).UTC()
+		if best < 0 || math.Abs(t.Sub(now).Seconds()) < math.Abs(bestTime.Sub(now).Seconds()) {
+			best, bestTime = i, t
+		}
+	}
+	return best, bestTime
+}
+
+// hourlyValue returns the value of an hourly variable at an hour
+func hourlyValue(hourly map[string]interface{}, variable string, hour int) (float64, bool) {
+	values, ok := hourly[variable].([]interface{})
+	if !ok || hour >= len(values) {
+		return 0, false
+	}
+	value, ok := values[hour].(float64)
+	return value, ok
+}
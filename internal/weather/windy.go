@@ -0,0 +1,58 @@
+package weather
+
+import "fmt"
+
+// windyProvider fetches weather from Windy's airport endpoints
+type windyProvider struct {
+	client  *Client
+	baseURL string
+}
+
+// Name returns the provider name
+func (p *windyProvider) Name() string {
+	return ProviderWindy
+}
+
+// FetchMETAR fetches the METAR trend; the raw text is the latest entry's
+func (p *windyProvider) FetchMETAR(airportCode string) (*Report, error) {
+	url := fmt.Sprintf("%s/metar/%s", p.baseURL, airportCode)
+	data, err := p.client.fetchWithRetry(url, nil, WeatherTypeMETAR, airportCode)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &Report{Data: data}
+	if metarMap, ok := data.(map[string]interface{}); ok {
+		if trend, ok := metarMap["trend"].([]interface{}); ok && len(trend) > 0 {
+			if latest, ok := trend[0].(map[string]interface{}); ok {
+				report.Raw, _ = latest["metar"].(string)
+			}
+		}
+	}
+	return report, nil
+}
+
+// FetchTAF fetches the TAF
+func (p *windyProvider) FetchTAF(airportCode string) (*Report, error) {
+	url := fmt.Sprintf("%s/taf/%s", p.baseURL, airportCode)
+	data, err := p.client.fetchWithRetry(url, nil, WeatherTypeTAF, airportCode)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &Report{Data: data}
+	if tafMap, ok := data.(map[string]interface{}); ok {
+		report.Raw, _ = tafMap["raw"].(string)
+	}
+	return report, nil
+}
+
+// FetchNOTAMs fetches the NOTAMs
+func (p *windyProvider) FetchNOTAMs(airportCode string) (*Report, error) {
+	url := fmt.Sprintf("%s/notams/%s", p.baseURL, airportCode)
+	data, err := p.client.fetchWithRetry(url, nil, WeatherTypeNOTAMs, airportCode)
+	if err != nil {
+		return nil, err
+	}
+	return &Report{Data: data}, nil
+}
@@ -0,0 +1,115 @@
+package websocket
+
+import (
+	"encoding/json"
+
+	"github.com/yegors/co-atc/pkg/logger"
+)
+
+// aircraftBatch is a decoded aircraft_batch message that can be filtered for each client
+type aircraftBatch struct {
+	message *Message
+	adds    []map[string]interface{}
+	updates []map[string]interface{}
+	removes interface{}
+	// decoded is false if the aircraft could not be converted for filtering,
+	// in which case the batch is sent unfiltered
+	decoded bool
+}
+
+// newAircraftBatch converts the aircraft in a batch message to maps for filtering
+func newAircraftBatch(message *Message, logger *logger.Logger) *aircraftBatch {
+	batch := &aircraftBatch{
+		message: message,
+		removes: message.Data["removes"],
+	}
+
+	var err error
+	if batch.adds, err = toAircraftMaps(message.Data["adds"]); err != nil {
+		logger.Error("Failed to convert batch adds for filtering", Error(err))
+		return batch
+	}
+	if batch.updates, err = toAircraftMaps(message.Data["updates"]); err != nil {
+		logger.Error("Failed to convert batch updates for filtering", Error(err))
+		return batch
+	}
+
+	batch.decoded = true
+	return batch
+}
+
+// forClient returns the batch filtered by the client's filters, or nil if nothing is left to send.
+// Removals are always sent so clients can drop aircraft they are displaying. Batches that
+// couldn't be decoded aren't sent to clients that may not see every aircraft.
+func (b *aircraftBatch) forClient(client *Client) *Message {
+	if !b.decoded {
+		if client.hidesAny() {
+			return nil
+		}
+		return b.message
+	}
+	if client.GetFilters() == nil && !client.hidesAny() {
+		return b.message
+	}
+
+	adds := filterAircraft(client, b.adds)
+	updates := filterAircraft(client, b.updates)
+	if len(adds) == 0 && len(updates) == 0 && isEmptyList(b.removes) {
+		return nil
+	}
+
+	data := make(map[string]interface{}, len(b.message.Data))
+	for k, v := range b.message.Data {
+		data[k] = v
+	}
+	data["adds"] = adds
+	data["updates"] = updates
+
+	return &Message{
+		Type: b.message.Type,
+		Data: data,
+	}
+}
+
+// filterAircraft returns the aircraft that match the client's filters
+func filterAircraft(client *Client, aircraft []map[string]interface{}) []map[string]interface{} {
+	result := make([]map[string]interface{}, 0, len(aircraft))
+	for _, a := range aircraft {
+		if client.MatchesFilters(a) {
+			result = append(result, a)
+		}
+	}
+	return result
+}
+
+// toAircraftMaps converts a list of aircraft structs to maps using JSON marshaling
+func toAircraftMaps(aircraft interface{}) ([]map[string]interface{}, error) {
+	if aircraft == nil {
+		return nil, nil
+	}
+
+	jsonBytes, err := json.Marshal(aircraft)
+	if err != nil {
+		return nil, err
+	}
+
+	var result []map[string]interface{}
+	if err := json.Unmarshal(jsonBytes, &result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// isEmptyList reports whether a JSON-encodable list has no elements
+func isEmptyList(list interface{}) bool {
+	switch l := list.(type) {
+	case nil:
+		return true
+	case []string:
+		return len(l) == 0
+	case []interface{}:
+		return len(l) == 0
+	default:
+		return false
+	}
+}
@@ -7,6 +7,7 @@ import (
 	"sync"
 
 	"github.com/gorilla/websocket"
+	"github.com/yegors/co-atc/internal/units"
 	"github.com/yegors/co-atc/pkg/logger"
 )
 
@@ -15,6 +16,7 @@ const (
 	MessageTypeAircraftAdded        = "aircraft_added"
 	MessageTypeAircraftUpdate       = "aircraft_update"
 	MessageTypeAircraftRemoved      = "aircraft_removed"
+	MessageTypeAircraftBatch        = "aircraft_batch"         // Server sends coalesced changes for a poll cycle
 	MessageTypeAircraftBulkRequest  = "aircraft_bulk_request"  // Client requests bulk data
 	MessageTypeAircraftBulkResponse = "aircraft_bulk_response" // Server sends bulk data
 	MessageTypeFilterUpdate         = "filter_update"          // Client sends filter preferences
@@ -53,6 +55,8 @@ type Client struct {
 	closed    bool
 	closeChan chan struct{}
 	filters   *ClientFilters // Active filters for this client
+	units     units.System   // Unit system messages are converted to
+	admin     bool           // Connected with the admin token; sees hidden aircraft
 }
 
 // Server represents a WebSocket server
@@ -64,7 +68,10 @@ type Server struct {
 	upgrader       websocket.Upgrader
 	logger         *logger.Logger
 	mu             sync.RWMutex
-	messageHandler MessageHandler // Handler for incoming messages
+	messageHandler MessageHandler   // Handler for incoming messages
+	listeners      []func(*Message) // Called with every broadcast message
+	hideMilitary   bool             // Leave military aircraft out of non-admin clients' aircraft messages
+	hideBlocked    bool             // Leave blocked aircraft out of non-admin clients' aircraft messages
 }
 
 // NewServer creates a new WebSocket server
@@ -90,6 +97,21 @@ func (s *Server) SetMessageHandler(handler MessageHandler) {
 	s.messageHandler = handler
 }
 
+// SetHiddenAircraft sets whether military and blocked aircraft are left out of the aircraft
+// messages of clients that didn't connect as admin. Must be called before clients connect.
+func (s *Server) SetHiddenAircraft(military, blocked bool) {
+	s.hideMilitary = military
+	s.hideBlocked = blocked
+}
+
+// OnBroadcast registers a function that is called with every broadcast message, e.g. to
+// forward events elsewhere. Listeners must not block or modify the message.
+func (s *Server) OnBroadcast(fn func(message *Message)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.listeners = append(s.listeners, fn)
+}
+
 // Run starts the WebSocket server
 func (s *Server) Run() {
 	s.logger.Info("Starting WebSocket server")
@@ -119,6 +141,12 @@ func (s *Server) Run() {
 			s.logger.Debug("Client unregistered", String("client_count", fmt.Sprintf("%d", clientCount)))
 
 		case message := <-s.broadcast:
+			// Aircraft batches are filtered per client, so decode them once up front
+			var batch *aircraftBatch
+			if message.Type == MessageTypeAircraftBatch {
+				batch = newAircraftBatch(message, s.logger)
+			}
+
 			s.mu.RLock()
 			clientsToRemove := make([]*Client, 0)
 			for client := range s.clients {
@@ -132,13 +160,18 @@ func (s *Server) Run() {
 				client.mu.Unlock()
 
 				// Filter aircraft updates based on client preferences
-				shouldSend := s.shouldSendToClient(client, message)
-				if !shouldSend {
+				outgoing := message
+				if batch != nil {
+					outgoing = batch.forClient(client)
+					if outgoing == nil {
+						continue
+					}
+				} else if !s.shouldSendToClient(client, message) {
 					continue
 				}
 
 				select {
-				case client.send <- message:
+				case client.send <- outgoing:
 					// Message sent successfully
 				default:
 					// Channel is full, mark for removal
@@ -169,9 +202,27 @@ func (s *Server) Run() {
 
 // HandleConnection handles a WebSocket connection
 func (s *Server) HandleConnection(w http.ResponseWriter, r *http.Request) {
+	s.connect(w, r, false)
+}
+
+// HandleAdminConnection handles a WebSocket connection of an authenticated admin, which is
+// sent hidden aircraft too
+func (s *Server) HandleAdminConnection(w http.ResponseWriter, r *http.Request) {
+	s.connect(w, r, true)
+}
+
+// connect upgrades a connection and registers its client
+func (s *Server) connect(w http.ResponseWriter, r *http.Request, admin bool) {
 	s.logger.Info("Handling new WebSocket connection request",
 		String("remote_addr", r.RemoteAddr),
-		String("user_agent", r.UserAgent()))
+		String("user_agent", r.UserAgent()),
+		String("admin", fmt.Sprintf("%t", admin)))
+
+	system, err := units.FromRequest(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
 
 	// Upgrade HTTP connection to WebSocket
 	conn, err := s.upgrader.Upgrade(w, r, nil)
@@ -191,6 +242,8 @@ func (s *Server) HandleConnection(w http.ResponseWriter, r *http.Request) {
 		send:      make(chan *Message, 256),
 		server:    s,
 		closeChan: make(chan struct{}),
+		units:     system,
+		admin:     admin,
 	}
 
 	// Register client
@@ -203,15 +256,24 @@ func (s *Server) HandleConnection(w http.ResponseWriter, r *http.Request) {
 
 // Broadcast sends a message to all connected clients
 func (s *Server) Broadcast(message *Message) {
+	s.mu.RLock()
+	clientCount := len(s.clients)
+	listeners := s.listeners
+	s.mu.RUnlock()
+
 	s.logger.Debug("Broadcasting message to all clients",
 		String("message_type", message.Type),
-		String("client_count", fmt.Sprintf("%d", len(s.clients))))
+		String("client_count", fmt.Sprintf("%d", clientCount)))
 
 	// Log the message content for debugging
 	if messageData, err := json.Marshal(message); err == nil {
 		s.logger.Debug("Message content", String("content", string(messageData)))
 	}
 
+	for _, fn := range listeners {
+		fn(message)
+	}
+
 	s.broadcast <- message
 }
 
@@ -311,6 +373,11 @@ func (c *Client) writePump() {
 				c.mu.Unlock()
 				continue
 			}
+			if data, err = c.units.ConvertJSON(data); err != nil {
+				c.server.logger.Error("Failed to convert message units", Error(err))
+				c.mu.Unlock()
+				continue
+			}
 
 			// Write message
 			c.server.logger.Debug("Sending message to client",
@@ -392,8 +459,27 @@ func (c *Client) GetFilters() *ClientFilters {
 	return filtersCopy
 }
 
+// Hides reports whether an aircraft is left out of the client's messages
+func (c *Client) Hides(military, blocked bool) bool {
+	if c.admin {
+		return false
+	}
+	return (military && c.server.hideMilitary) || (blocked && c.server.hideBlocked)
+}
+
+// hidesAny reports whether any aircraft may be left out of the client's messages
+func (c *Client) hidesAny() bool {
+	return !c.admin && (c.server.hideMilitary || c.server.hideBlocked)
+}
+
 // MatchesFilters checks if an aircraft matches the client's active filters
 func (c *Client) MatchesFilters(aircraft map[string]interface{}) bool {
+	military, _ := aircraft["military"].(bool)
+	blocked, _ := aircraft["blocked"].(bool)
+	if c.Hides(military, blocked) {
+		return false
+	}
+
 	filters := c.GetFilters()
 	if filters == nil {
 		// No filters set, show everything
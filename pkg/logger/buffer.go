@@ -0,0 +1,192 @@
+package logger
+
+import (
+	"strings"
+	"sync"
+	"time"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// Entry is a log entry kept in memory
+type Entry struct {
+	Seq     uint64                 `json:"seq"` // Order the entry was logged in
+	Time    time.Time              `json:"time"`
+	Level   string                 `json:"level"`
+	Logger  string                 `json:"logger,omitempty"`
+	Message string                 `json:"message"`
+	Caller  string                 `json:"caller,omitempty"`
+	Fields  map[string]interface{} `json:"fields,omitempty"`
+	Stack   string                 `json:"stack,omitempty"`
+}
+
+// EntryFilter selects log entries. Zero values match everything.
+type EntryFilter struct {
+	Level  zapcore.Level // Lowest level
+	Logger string        // Covers the loggers an override of the name would
+	Since  time.Time
+	Until  time.Time
+	Text   string // In the message or fields, case-insensitive
+	Limit  int    // Most entries returned, the newest
+}
+
+// Buffer keeps the latest log entries in memory, overwriting the oldest when it's full
+type Buffer struct {
+	entries []Entry
+	next    int    // Index the next entry is written at
+	seq     uint64 // Entries logged so far
+	mu      sync.RWMutex
+}
+
+// NewBuffer creates a buffer of a number of entries
+func NewBuffer(size int) *Buffer {
+	return &Buffer{entries: make([]Entry, 0, size)}
+}
+
+// Size returns the most entries the buffer keeps
+func (b *Buffer) Size() int {
+	return cap(b.entries)
+}
+
+// Logged returns how many entries have been logged, including those overwritten
+func (b *Buffer) Logged() uint64 {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	return b.seq
+}
+
+// add keeps an entry, overwriting the oldest if the buffer is full
+func (b *Buffer) add(entry Entry) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.seq++
+	entry.Seq = b.seq
+	if len(b.entries) < cap(b.entries) {
+		b.entries = append(b.entries, entry)
+		return
+	}
+	b.entries[b.next] = entry
+	b.next = (b.next + 1) % len(b.entries)
+}
+
+// Entries returns the entries a filter selects, oldest first
+func (b *Buffer) Entries(filter EntryFilter) []Entry {
+	text := strings.ToLower(filter.Text)
+
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	// Newest first, so a limit keeps the latest
+	var entries []Entry
+	for i := 0; i < len(b.entries); i++ {
+		entry := b.entries[(b.next-1-i+2*len(b.entries))%len(b.entries)]
+		if filter.Limit > 0 && len(entries) >= filter.Limit {
+			break
+		}
+		if !filter.matches(entry, text) {
+			continue
+		}
+		entries = append(entries, entry)
+	}
+
+	for i, j := 0, len(entries)-1; i < j; i, j = i+1, j-1 {
+		entries[i], entries[j] = entries[j], entries[i]
+	}
+	return entries
+}
+
+// matches reports whether a filter selects an entry
+func (f EntryFilter) matches(entry Entry, text string) bool {
+	if level, err := zapcore.ParseLevel(entry.Level); err == nil && level < f.Level {
+		return false
+	}
+	if f.Logger != "" && !coversLogger(f.Logger, entry.Logger) {
+		return false
+	}
+	if !f.Since.IsZero() && entry.Time.Before(f.Since) {
+		return false
+	}
+	if !f.Until.IsZero() && entry.Time.After(f.Until) {
+		return false
+	}
+	if text != "" && !strings.Contains(strings.ToLower(entry.Message), text) && !fieldsContain(entry.Fields, text) {
+		return false
+	}
+	return true
+}
+
+// coversLogger reports whether a name covers a logger: it's the logger's name, or the
+// logger's name continues it with a "-" or "."
+func coversLogger(name, logger string) bool {
+	if !strings.HasPrefix(logger, name) {
+		return false
+	}
+	rest := logger[len(name):]
+	return rest == "" || rest[0] == '-' || rest[0] == '.'
+}
+
+// fieldsContain reports whether lowercase text is in the string values of fields
+func fieldsContain(fields map[string]interface{}, text string) bool {
+	for _, value := range fields {
+		if s, ok := value.(string); ok && strings.Contains(strings.ToLower(s), text) {
+			return true
+		}
+	}
+	return false
+}
+
+// bufferCore keeps the entries it's given in a buffer
+type bufferCore struct {
+	buffer *Buffer
+	fields []zapcore.Field
+}
+
+// Enabled takes every level; the level core in front of it filters
+func (c *bufferCore) Enabled(zapcore.Level) bool {
+	return true
+}
+
+// With adds fields to the entries kept
+func (c *bufferCore) With(fields []zapcore.Field) zapcore.Core {
+	combined := make([]zapcore.Field, 0, len(c.fields)+len(fields))
+	combined = append(combined, c.fields...)
+	combined = append(combined, fields...)
+	return &bufferCore{buffer: c.buffer, fields: combined}
+}
+
+// Check adds the core to an entry
+func (c *bufferCore) Check(entry zapcore.Entry, checked *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	return checked.AddCore(entry, c)
+}
+
+// Write keeps an entry and its fields
+func (c *bufferCore) Write(entry zapcore.Entry, fields []zapcore.Field) error {
+	encoder := zapcore.NewMapObjectEncoder()
+	for _, field := range c.fields {
+		field.AddTo(encoder)
+	}
+	for _, field := range fields {
+		field.AddTo(encoder)
+	}
+
+	kept := Entry{
+		Time:    entry.Time.UTC(),
+		Level:   entry.Level.String(),
+		Logger:  entry.LoggerName,
+		Message: entry.Message,
+		Stack:   entry.Stack,
+	}
+	if entry.Caller.Defined {
+		kept.Caller = entry.Caller.TrimmedPath()
+	}
+	if len(encoder.Fields) > 0 {
+		kept.Fields = encoder.Fields
+	}
+	c.buffer.add(kept)
+	return nil
+}
+
+// Sync does nothing; entries are kept as they're written
+func (c *bufferCore) Sync() error {
+	return nil
+}
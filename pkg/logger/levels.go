@@ -0,0 +1,173 @@
+package logger
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// Levels holds the level of a logger and its named loggers, with overrides by name that can
+// be changed at runtime. An override applies to the loggers named by it and those whose name
+// starts with it and a "-" or ".": "sqlite" covers "sqlite-tx" and "sqlite-prompts". The
+// longest matching override wins.
+type Levels struct {
+	mu        sync.RWMutex
+	level     zapcore.Level
+	overrides map[string]zapcore.Level
+	min       zapcore.Level // Lowest of the level and overrides
+}
+
+// NewLevels creates levels with a default level and overrides by logger name
+func NewLevels(level string, overrides map[string]string) (*Levels, error) {
+	l := &Levels{overrides: make(map[string]zapcore.Level)}
+	if err := l.SetLevel(level); err != nil {
+		return nil, err
+	}
+	for name, override := range overrides {
+		if err := l.SetOverride(name, override); err != nil {
+			return nil, err
+		}
+	}
+	return l, nil
+}
+
+// Enabled reports whether a logger logs entries of a level
+func (l *Levels) Enabled(name string, level zapcore.Level) bool {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	return level >= l.levelOf(name)
+}
+
+// Level returns the default level
+func (l *Levels) Level() string {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	return l.level.String()
+}
+
+// SetLevel changes the default level
+func (l *Levels) SetLevel(level string) error {
+	parsed, err := parseLogLevel(level)
+	if err != nil {
+		return err
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.level = parsed
+	l.updateMin()
+	return nil
+}
+
+// Overrides returns the level overrides by logger name
+func (l *Levels) Overrides() map[string]string {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	overrides := make(map[string]string, len(l.overrides))
+	for name, level := range l.overrides {
+		overrides[name] = level.String()
+	}
+	return overrides
+}
+
+// SetOverride sets the level of the loggers a name covers
+func (l *Levels) SetOverride(name, level string) error {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return fmt.Errorf("logger name is required")
+	}
+	parsed, err := parseLogLevel(level)
+	if err != nil {
+		return fmt.Errorf("logger %s: %w", name, err)
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.overrides[name] = parsed
+	l.updateMin()
+	return nil
+}
+
+// ClearOverride returns the loggers a name covers to the default level
+func (l *Levels) ClearOverride(name string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	delete(l.overrides, name)
+	l.updateMin()
+}
+
+// minLevel returns the lowest level any logger logs at
+func (l *Levels) minLevel() zapcore.Level {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	return l.min
+}
+
+// levelOf returns the level of a named logger. Must be called with the lock held.
+func (l *Levels) levelOf(name string) zapcore.Level {
+	if len(l.overrides) == 0 {
+		return l.level
+	}
+	for candidate := name; candidate != ""; {
+		if level, ok := l.overrides[candidate]; ok {
+			return level
+		}
+		cut := strings.LastIndexAny(candidate, "-.")
+		if cut < 0 {
+			break
+		}
+		candidate = candidate[:cut]
+	}
+	return l.level
+}
+
+// updateMin works out the lowest level. Must be called with the lock held.
+func (l *Levels) updateMin() {
+	l.min = l.level
+	for _, level := range l.overrides {
+		if level < l.min {
+			l.min = level
+		}
+	}
+}
+
+// String describes the levels ("info, adsb=debug, api=warn")
+func (l *Levels) String() string {
+	overrides := l.Overrides()
+	names := make([]string, 0, len(overrides))
+	for name := range overrides {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	parts := []string{l.Level()}
+	for _, name := range names {
+		parts = append(parts, name+"="+overrides[name])
+	}
+	return strings.Join(parts, ", ")
+}
+
+// levelCore filters the entries of a core by the level of the logger that wrote them. The
+// core it wraps must be enabled at every level.
+type levelCore struct {
+	zapcore.Core
+	levels *Levels
+}
+
+// Enabled reports whether any logger logs entries of a level; Check decides for each entry
+func (c *levelCore) Enabled(level zapcore.Level) bool {
+	return level >= c.levels.minLevel()
+}
+
+// With adds fields to the wrapped core
+func (c *levelCore) With(fields []zapcore.Field) zapcore.Core {
+	return &levelCore{Core: c.Core.With(fields), levels: c.levels}
+}
+
+// Check passes an entry to the wrapped core if its logger logs entries of its level
+func (c *levelCore) Check(entry zapcore.Entry, checked *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if !c.levels.Enabled(entry.LoggerName, entry.Level) {
+		return checked
+	}
+	return c.Core.Check(entry, checked)
+}
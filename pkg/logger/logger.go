@@ -1,6 +1,8 @@
 package logger
 
 import (
+	"crypto/rand"
+	"encoding/hex"
 	"fmt"
 	"os"
 	"strings"
@@ -37,12 +39,17 @@ var (
 // Logger is a wrapper around zap.Logger
 type Logger struct {
 	*zap.Logger
+	levels *Levels // nil if the logger wasn't created by New
+	buffer *Buffer // nil if entries aren't kept in memory
 }
 
 // Config represents logger configuration
 type Config struct {
-	Level  string // debug, info, warn, error
-	Format string // json, console
+	Level  string            // debug, info, warn, error
+	Format string            // json, console
+	Levels map[string]string // Level overrides by logger name
+	File   FileConfig        // Rotating log file, written besides the console
+	Buffer int               // Latest entries kept in memory, 0 = none
 }
 
 // Custom level encoder that adds colors for console output
@@ -79,12 +86,55 @@ func fixedWidthNameEncoder(loggerName string, enc zapcore.PrimitiveArrayEncoder)
 
 // New creates a new logger with the given configuration
 func New(config Config) (*Logger, error) {
-	// Parse log level
-	level, err := parseLogLevel(config.Level)
+	// Parse log levels
+	levels, err := NewLevels(config.Level, config.Levels)
 	if err != nil {
 		return nil, err
 	}
+	level := levels.minLevel()
 
+	console, err := newEncoder(config.Format, level, true)
+	if err != nil {
+		return nil, err
+	}
+	// Entries are filtered by the level of their logger, so the cores take every level
+	cores := []zapcore.Core{zapcore.NewCore(console, zapcore.AddSync(os.Stdout), zapcore.DebugLevel)}
+	if config.File.Path != "" {
+		file, err := newEncoder(config.File.Format, level, false)
+		if err != nil {
+			return nil, err
+		}
+		writer, err := NewRotatingFile(config.File)
+		if err != nil {
+			return nil, err
+		}
+		cores = append(cores, zapcore.NewCore(file, writer, zapcore.DebugLevel))
+	}
+	var buffer *Buffer
+	if config.Buffer > 0 {
+		buffer = NewBuffer(config.Buffer)
+		cores = append(cores, &bufferCore{buffer: buffer})
+	}
+	core := &levelCore{Core: zapcore.NewTee(cores...), levels: levels}
+
+	// Create logger options
+	opts := []zap.Option{
+		zap.AddStacktrace(zapcore.ErrorLevel),
+	}
+
+	// Only add caller info if a logger logs at debug level
+	if level == zapcore.DebugLevel {
+		opts = append(opts, zap.AddCaller(), zap.AddCallerSkip(1))
+	}
+
+	// Create logger
+	logger := zap.New(core, opts...)
+
+	return &Logger{Logger: logger, levels: levels, buffer: buffer}, nil
+}
+
+// newEncoder creates the encoder of a sink. Colors are only used on the console.
+func newEncoder(format string, level zapcore.Level, colored bool) (zapcore.Encoder, error) {
 	// Create encoder config
 	encoderConfig := zapcore.EncoderConfig{
 		TimeKey:        "time",
@@ -100,7 +150,7 @@ func New(config Config) (*Logger, error) {
 	}
 
 	// Set encoding options based on format
-	if config.Format == "console" {
+	if format == "console" && colored {
 		encoderConfig.EncodeLevel = coloredLevelEncoder
 		encoderConfig.EncodeName = fixedWidthNameEncoder
 	} else {
@@ -117,37 +167,14 @@ func New(config Config) (*Logger, error) {
 	}
 
 	// Create encoder based on format
-	var encoder zapcore.Encoder
-	switch config.Format {
+	switch format {
 	case "json":
-		encoder = zapcore.NewJSONEncoder(encoderConfig)
+		return zapcore.NewJSONEncoder(encoderConfig), nil
 	case "console":
-		encoder = zapcore.NewConsoleEncoder(encoderConfig)
+		return zapcore.NewConsoleEncoder(encoderConfig), nil
 	default:
-		return nil, fmt.Errorf("unsupported log format: %s", config.Format)
+		return nil, fmt.Errorf("unsupported log format: %s", format)
 	}
-
-	// Create core
-	core := zapcore.NewCore(
-		encoder,
-		zapcore.AddSync(os.Stdout),
-		level,
-	)
-
-	// Create logger options
-	opts := []zap.Option{
-		zap.AddStacktrace(zapcore.ErrorLevel),
-	}
-
-	// Only add caller info for debug level
-	if level == zapcore.DebugLevel {
-		opts = append(opts, zap.AddCaller(), zap.AddCallerSkip(1))
-	}
-
-	// Create logger
-	logger := zap.New(core, opts...)
-
-	return &Logger{Logger: logger}, nil
 }
 
 // parseLogLevel parses the log level string
@@ -168,12 +195,23 @@ func parseLogLevel(level string) (zapcore.Level, error) {
 
 // With returns a logger with the given fields
 func (l *Logger) With(fields ...zapcore.Field) *Logger {
-	return &Logger{Logger: l.Logger.With(fields...)}
+	return &Logger{Logger: l.Logger.With(fields...), levels: l.levels, buffer: l.buffer}
 }
 
 // Named returns a logger with the given name
 func (l *Logger) Named(name string) *Logger {
-	return &Logger{Logger: l.Logger.Named(name)}
+	return &Logger{Logger: l.Logger.Named(name), levels: l.levels, buffer: l.buffer}
+}
+
+// Buffer returns the latest entries kept in memory, or nil if they aren't kept
+func (l *Logger) Buffer() *Buffer {
+	return l.buffer
+}
+
+// Levels returns the levels of the logger and its named loggers, which can be changed at
+// runtime, or nil if the logger wasn't created by New
+func (l *Logger) Levels() *Levels {
+	return l.levels
 }
 
 // WithRequestID returns a logger with the request ID field
@@ -181,6 +219,22 @@ func (l *Logger) WithRequestID(requestID string) *Logger {
 	return l.With(zap.String("request_id", requestID))
 }
 
+// WithCorrelationID returns a logger with the correlation ID field
+func (l *Logger) WithCorrelationID(correlationID string) *Logger {
+	return l.With(zap.String("correlation_id", correlationID))
+}
+
+// NewCorrelationID generates a short correlation ID with the given prefix
+// (e.g. "poll-3f9a1c2e4b5d6f70"). Correlation IDs tie together the logs,
+// storage rows and WebSocket messages produced by a single unit of work.
+func NewCorrelationID(prefix string) string {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		return prefix
+	}
+	return prefix + "-" + hex.EncodeToString(b)
+}
+
 // WithError returns a logger with the error field
 func (l *Logger) WithError(err error) *Logger {
 	return l.With(zap.Error(err))
@@ -0,0 +1,220 @@
+package logger
+
+import (
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// backupTimeFormat is the timestamp in the names of rotated log files
+// ("co-atc-2025-06-01T12-00-04.000.log")
+const backupTimeFormat = "2006-01-02T15-04-05.000"
+
+// FileConfig configures a rotating log file
+type FileConfig struct {
+	Path       string // Empty = no log file
+	Format     string // json, console
+	MaxSizeMB  int    // Size the file is rotated at
+	MaxBackups int    // Rotated files kept, 0 = all
+	MaxAgeDays int    // Days rotated files are kept, 0 = no limit
+	Compress   bool   // Gzip rotated files
+}
+
+// RotatingFile is a log file that's rotated when it reaches a size: it's renamed with a
+// timestamp and a new one started. Rotated files are compressed if enabled, and the oldest
+// removed past a count or age.
+type RotatingFile struct {
+	config    FileConfig
+	file      *os.File
+	size      int64
+	mu        sync.Mutex
+	cleanupMu sync.Mutex
+}
+
+// NewRotatingFile opens a log file for appending, creating it and its directory if needed
+func NewRotatingFile(config FileConfig) (*RotatingFile, error) {
+	if config.MaxSizeMB <= 0 {
+		return nil, fmt.Errorf("log file max size must be positive")
+	}
+	f := &RotatingFile{config: config}
+	if err := f.open(); err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+// Write writes to the file, rotating it first if the write would take it past its size
+func (f *RotatingFile) Write(p []byte) (int, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.size > 0 && f.size+int64(len(p)) > f.maxSize() {
+		if err := f.rotate(); err != nil {
+			return 0, err
+		}
+	}
+	n, err := f.file.Write(p)
+	f.size += int64(n)
+	return n, err
+}
+
+// Sync commits the file to disk
+func (f *RotatingFile) Sync() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.file.Sync()
+}
+
+// Close closes the file
+func (f *RotatingFile) Close() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.file.Close()
+}
+
+// maxSize returns the size in bytes the file is rotated at
+func (f *RotatingFile) maxSize() int64 {
+	return int64(f.config.MaxSizeMB) * 1024 * 1024
+}
+
+// open opens the file for appending. Must be called with the lock held.
+func (f *RotatingFile) open() error {
+	if err := os.MkdirAll(filepath.Dir(f.config.Path), 0755); err != nil {
+		return fmt.Errorf("failed to create log directory: %w", err)
+	}
+	file, err := os.OpenFile(f.config.Path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to open log file: %w", err)
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return fmt.Errorf("failed to open log file: %w", err)
+	}
+	f.file = file
+	f.size = info.Size()
+	return nil
+}
+
+// rotate renames the file with the current time, starts a new one and cleans up rotated
+// files in the background. Must be called with the lock held.
+func (f *RotatingFile) rotate() error {
+	if err := f.file.Close(); err != nil {
+		return fmt.Errorf("failed to close log file: %w", err)
+	}
+	prefix, ext := f.backupPrefix()
+	backup := prefix + time.Now().UTC().Format(backupTimeFormat) + ext
+	if err := os.Rename(f.config.Path, backup); err != nil {
+		return fmt.Errorf("failed to rotate log file: %w", err)
+	}
+	if err := f.open(); err != nil {
+		return err
+	}
+	go f.cleanup()
+	return nil
+}
+
+// backupPrefix returns the start and extension of the names of rotated files
+func (f *RotatingFile) backupPrefix() (string, string) {
+	ext := filepath.Ext(f.config.Path)
+	return strings.TrimSuffix(f.config.Path, ext) + "-", ext
+}
+
+// rotatedFile is a rotated log file and when it was rotated
+type rotatedFile struct {
+	path      string
+	rotatedAt time.Time
+}
+
+// cleanup compresses rotated files if enabled and removes those past the count or age
+func (f *RotatingFile) cleanup() {
+	f.cleanupMu.Lock()
+	defer f.cleanupMu.Unlock()
+
+	files, err := f.rotatedFiles()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to list rotated log files: %v\n", err)
+		return
+	}
+
+	cutoff := time.Time{}
+	if f.config.MaxAgeDays > 0 {
+		cutoff = time.Now().Add(-time.Duration(f.config.MaxAgeDays) * 24 * time.Hour)
+	}
+	for i, file := range files {
+		expired := (f.config.MaxBackups > 0 && i >= f.config.MaxBackups) || file.rotatedAt.Before(cutoff)
+		if expired {
+			if err := os.Remove(file.path); err != nil {
+				fmt.Fprintf(os.Stderr, "Failed to remove rotated log file: %v\n", err)
+			}
+			continue
+		}
+		if f.config.Compress && !strings.HasSuffix(file.path, ".gz") {
+			if err := compressFile(file.path); err != nil {
+				fmt.Fprintf(os.Stderr, "Failed to compress rotated log file: %v\n", err)
+			}
+		}
+	}
+}
+
+// rotatedFiles lists the rotated log files, newest first
+func (f *RotatingFile) rotatedFiles() ([]rotatedFile, error) {
+	prefix, ext := f.backupPrefix()
+	matches, err := filepath.Glob(prefix + "*")
+	if err != nil {
+		return nil, err
+	}
+
+	var files []rotatedFile
+	for _, match := range matches {
+		stamp := strings.TrimPrefix(match, prefix)
+		stamp = strings.TrimSuffix(stamp, ".gz")
+		if !strings.HasSuffix(stamp, ext) {
+			continue
+		}
+		rotatedAt, err := time.Parse(backupTimeFormat, strings.TrimSuffix(stamp, ext))
+		if err != nil {
+			continue // Not a rotated file
+		}
+		files = append(files, rotatedFile{path: match, rotatedAt: rotatedAt})
+	}
+	sort.Slice(files, func(i, j int) bool { return files[i].rotatedAt.After(files[j].rotatedAt) })
+	return files, nil
+}
+
+// compressFile gzips a file and removes the original
+func compressFile(path string) error {
+	src, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	dst, err := os.OpenFile(path+".gz", os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0644)
+	if err != nil {
+		return err
+	}
+	gz := gzip.NewWriter(dst)
+	if _, err := io.Copy(gz, src); err != nil {
+		dst.Close()
+		os.Remove(path + ".gz")
+		return err
+	}
+	if err := gz.Close(); err != nil {
+		dst.Close()
+		os.Remove(path + ".gz")
+		return err
+	}
+	if err := dst.Close(); err != nil {
+		os.Remove(path + ".gz")
+		return err
+	}
+	src.Close()
+	return os.Remove(path)
+}
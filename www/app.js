@@ -167,6 +167,7 @@ document.addEventListener('alpine:init', () => {
         lastAudioUpdateIntervalId: null, // Interval ID for updating secondsSinceLastAudio
         frequencyTranscriptions: {}, // Stores transcriptions per frequency_id
         transcriptionViewerVisible: {}, // Stores visibility state for each frequency's viewer
+        transmittingFrequencies: {}, // Frequencies whose squelch is open (freqId -> true)
         isReconnecting: false, // Flag to prevent duplicate reconnection attempts
 
         // Settings
@@ -2511,6 +2512,17 @@ disableAircraftStreamingInWebSocket() {
                 this.handleClearanceIssued(data);
             });
 
+            // Squelch events light up the frequency that is transmitting
+            wsClient.listeners.transmission_started = [];
+            wsClient.listeners.transmission_ended = [];
+            wsClient.addEventListener('transmission_started', (data) => {
+                this.transmittingFrequencies = { ...this.transmittingFrequencies, [data.frequency_id]: true };
+            });
+            wsClient.addEventListener('transmission_ended', (data) => {
+                const { [data.frequency_id]: _, ...rest } = this.transmittingFrequencies;
+                this.transmittingFrequencies = rest;
+            });
+
             // Add new aircraft streaming handlers
             wsClient.addEventListener('aircraft_added', (data) => {
                 this.handleAircraftAdded(data);
@@ -245,6 +245,16 @@ class ATCChat {
         }
     }
 
+    // Random identity kept in local storage, so the server can recall this browser's previous session
+    getClientId() {
+        let clientId = localStorage.getItem('atcChatClientId');
+        if (!clientId) {
+            clientId = (window.crypto && crypto.randomUUID) ? crypto.randomUUID() : `client-${Date.now()}-${Math.random().toString(36).slice(2)}`;
+            localStorage.setItem('atcChatClientId', clientId);
+        }
+        return clientId;
+    }
+
     async startChat() {
         try {
             console.log('[ATC-Chat] Starting ATC Chat...');
@@ -256,7 +266,8 @@ class ATCChat {
                 method: 'POST',
                 headers: {
                     'Content-Type': 'application/json'
-                }
+                },
+                body: JSON.stringify({ client_id: this.getClientId() })
             });
 
             console.log('[ATC-Chat] Session response status:', response.status);
@@ -470,6 +481,9 @@ class ATCChat {
                 case 'connection_error':
                     console.error('[ATC-Chat] Connection error:', message.error);
                     break;
+                case 'relay_error':
+                    console.warn('[ATC-Chat] Event not relayed:', message.event_type, message.error);
+                    break;
                 case 'session.update':
                     // Log session update events with full payload
                     console.log('[ATC-Chat] Session update received:', message);
@@ -546,6 +560,13 @@ class ATCChat {
                     }
                     break;
                 case 'response.done':
+                    // A response that only called functions is followed by the real answer
+                    if (message.response && Array.isArray(message.response.output) &&
+                        message.response.output.some(item => item.type === 'function_call')) {
+                        console.log('[ATC-Chat] AI looking up live data');
+                        this.showStatusIndicator('processing', 'Looking up...');
+                        break;
+                    }
                     // Response completely finished - return to ready state
                     console.log('[ATC-Chat] AI Response completed');
                     this.showStatusIndicator('connected', 'PTT - Hold Space');
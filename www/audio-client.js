@@ -46,6 +46,10 @@ class AudioClient {
         } else {
             streamUrl = `${streamUrl}?id=${this.store.clientID}`;
         }
+        // Opus is a fraction of the bandwidth of WAV; fall back to WAV where it can't play
+        if (audioElement.canPlayType('audio/webm; codecs="opus"')) {
+            streamUrl += '&format=webm';
+        }
 
         audioElement.addEventListener('error', (e) => {
             console.error(`Audio error for ${frequency.id}:`, e.target.error ? e.target.error.message : 'Unknown error');
@@ -0,0 +1,175 @@
+// Push Client for Co-ATC
+// Subscribes the browser to Web Push alerts through the Co-ATC API.
+class PushClient {
+    constructor() {
+        this.subscriptionIdKey = 'pushSubscriptionId';
+    }
+
+    isSupported() {
+        return 'serviceWorker' in navigator && 'PushManager' in window && 'Notification' in window;
+    }
+
+    get subscriptionId() {
+        return localStorage.getItem(this.subscriptionIdKey);
+    }
+
+    // Returns the server's public key and alert types, or null if push is disabled on the server
+    async getServerInfo() {
+        const response = await fetch('/api/v1/push/vapid-public-key');
+        if (!response.ok) {
+            return null;
+        }
+        return response.json();
+    }
+
+    // Returns the alert types of the current subscription, or null if not subscribed
+    async getAlertTypes() {
+        const id = this.subscriptionId;
+        if (!id) {
+            return null;
+        }
+
+        const response = await fetch(`/api/v1/push/subscriptions/${id}`);
+        if (response.status === 404) {
+            // The server dropped the subscription (e.g. it expired)
+            localStorage.removeItem(this.subscriptionIdKey);
+            return null;
+        }
+        if (!response.ok) {
+            throw new Error(`Failed to load push subscription: ${response.status}`);
+        }
+        const subscription = await response.json();
+        return subscription.alert_types || [];
+    }
+
+    // Subscribes to the given alert types, or updates them if already subscribed
+    async subscribe(alertTypes) {
+        const info = await this.getServerInfo();
+        if (!info) {
+            throw new Error('Push notifications are not enabled on the server');
+        }
+
+        const permission = await Notification.requestPermission();
+        if (permission !== 'granted') {
+            throw new Error('Notification permission was not granted');
+        }
+
+        const registration = await navigator.serviceWorker.register('/push-sw.js');
+        await navigator.serviceWorker.ready;
+
+        let subscription = await registration.pushManager.getSubscription();
+        if (!subscription) {
+            subscription = await registration.pushManager.subscribe({
+                userVisibleOnly: true,
+                applicationServerKey: this._urlBase64ToUint8Array(info.public_key)
+            });
+        }
+
+        const response = await fetch('/api/v1/push/subscriptions', {
+            method: 'POST',
+            headers: { 'Content-Type': 'application/json' },
+            body: JSON.stringify({ ...subscription.toJSON(), alert_types: alertTypes })
+        });
+        if (!response.ok) {
+            throw new Error(`Failed to register push subscription: ${await response.text()}`);
+        }
+
+        const registered = await response.json();
+        localStorage.setItem(this.subscriptionIdKey, registered.id);
+        return registered;
+    }
+
+    // Removes the subscription from the server and the browser
+    async unsubscribe() {
+        const id = this.subscriptionId;
+        if (id) {
+            await fetch(`/api/v1/push/subscriptions/${id}`, { method: 'DELETE' });
+            localStorage.removeItem(this.subscriptionIdKey);
+        }
+
+        const registration = await navigator.serviceWorker.getRegistration('/push-sw.js');
+        const subscription = await registration?.pushManager.getSubscription();
+        if (subscription) {
+            await subscription.unsubscribe();
+        }
+    }
+
+    async sendTest() {
+        const id = this.subscriptionId;
+        if (!id) {
+            throw new Error('Not subscribed');
+        }
+        const response = await fetch(`/api/v1/push/subscriptions/${id}/test`, { method: 'POST' });
+        if (!response.ok) {
+            throw new Error(`Failed to send test notification: ${await response.text()}`);
+        }
+        return response.json();
+    }
+
+    _urlBase64ToUint8Array(base64String) {
+        const padding = '='.repeat((4 - base64String.length % 4) % 4);
+        const base64 = (base64String + padding).replace(/-/g, '+').replace(/_/g, '/');
+        const raw = atob(base64);
+        return Uint8Array.from(raw, (c) => c.charCodeAt(0));
+    }
+}
+
+// Alpine component for the notification settings section
+function pushSettings() {
+    const client = new PushClient();
+
+    return {
+        available: false,
+        alertTypes: [],
+        selected: {},
+        status: '',
+
+        async init() {
+            if (!client.isSupported()) {
+                return;
+            }
+            try {
+                const info = await client.getServerInfo();
+                if (!info) {
+                    return;
+                }
+                this.alertTypes = info.alert_types;
+                const subscribed = await client.getAlertTypes();
+                for (const type of this.alertTypes) {
+                    this.selected[type] = !!subscribed && subscribed.includes(type);
+                }
+                this.available = true;
+            } catch (e) {
+                console.error('Failed to load push notification settings', e);
+            }
+        },
+
+        async update() {
+            const types = this.alertTypes.filter((type) => this.selected[type]);
+            try {
+                if (types.length === 0) {
+                    await client.unsubscribe();
+                    this.status = 'Notifications off';
+                } else {
+                    await client.subscribe(types);
+                    this.status = 'Notifications on';
+                }
+            } catch (e) {
+                console.error('Failed to update push subscription', e);
+                this.status = e.message;
+                for (const type of this.alertTypes) {
+                    this.selected[type] = false;
+                }
+            }
+        },
+
+        async test() {
+            try {
+                const delivery = await client.sendTest();
+                this.status = `Test ${delivery.status}`;
+            } catch (e) {
+                this.status = e.message;
+            }
+        }
+    };
+}
@@ -0,0 +1,39 @@
+// Service worker for Co-ATC push notifications.
+// Shows alerts delivered through Web Push, even when no Co-ATC tab is open.
+
+self.addEventListener('push', (event) => {
+    if (!event.data) {
+        return;
+    }
+
+    let alert;
+    try {
+        alert = event.data.json();
+    } catch (e) {
+        alert = { title: 'Co-ATC', body: event.data.text() };
+    }
+
+    event.waitUntil(self.registration.showNotification(alert.title || 'Co-ATC', {
+        body: alert.body || '',
+        tag: alert.tag || undefined,
+        renotify: !!alert.tag,
+        requireInteraction: alert.type === 'emergency',
+        timestamp: alert.timestamp ? Date.parse(alert.timestamp) : Date.now(),
+        data: { url: alert.url || '/', alert: alert }
+    }));
+});
+
+self.addEventListener('notificationclick', (event) => {
+    event.notification.close();
+    const url = event.notification.data?.url || '/';
+
+    // Focus an open Co-ATC tab if there is one, otherwise open a new one
+    event.waitUntil(self.clients.matchAll({ type: 'window', includeUncontrolled: true }).then((clients) => {
+        for (const client of clients) {
+            if (new URL(client.url).origin === self.location.origin && 'focus' in client) {
+                return client.focus();
+            }
+        }
+        return self.clients.openWindow(url);
+    }));
+});
@@ -16,10 +16,13 @@ class WebSocketClient {
             aircraft_added: [],         // NEW
             aircraft_update: [],        // NEW
             aircraft_removed: [],       // NEW
+            aircraft_batch: [],         // Coalesced changes for a poll cycle
             aircraft_bulk_response: [], // NEW - for bulk data responses
             status_update: [], // Add new listener type for status updates
             phase_change: [], // Add new listener type for phase changes
             clearance_issued: [], // Add new listener type for clearance events
+            transmission_started: [], // Squelch opened on a frequency
+            transmission_ended: [],   // Squelch closed on a frequency
             open: [],
             close: [],
             error: []
@@ -86,7 +89,9 @@ class WebSocketClient {
                     const message = JSON.parse(event.data);
                     
                     // Handle aircraft streaming messages
-                    if (message.type === 'aircraft_added') {
+                    if (message.type === 'aircraft_batch') {
+                        this._handleAircraftBatch(message.data);
+                    } else if (message.type === 'aircraft_added') {
                         console.log(`Aircraft ADDED: ${message.data.aircraft?.flight || message.data.hex}`);
                         this._notifyListeners('aircraft_added', message.data);
                     } else if (message.type === 'aircraft_update') {
@@ -124,6 +129,8 @@ class WebSocketClient {
                         }
                         
                         this._notifyListeners('status_update', message.data);
+                    } else if (message.type === 'transmission_started' || message.type === 'transmission_ended') {
+                        this._notifyListeners(message.type, message.data);
                     } else if (message.type === 'phase_change') {
                         // Log phase change messages to console
                         console.log(`Phase Change: ${message.data.flight || message.data.hex} ${message.data.transition}`, message.data);
@@ -215,6 +222,22 @@ class WebSocketClient {
         }
     }
 
+    // Fan a coalesced aircraft batch out to the per-aircraft listeners
+    _handleAircraftBatch(data) {
+        this._notifyListeners('aircraft_batch', data);
+
+        const correlationId = data.correlation_id;
+        (data.adds || []).forEach(aircraft => {
+            this._notifyListeners('aircraft_added', { type: 'added', hex: aircraft.hex, aircraft, correlation_id: correlationId });
+        });
+        (data.updates || []).forEach(aircraft => {
+            this._notifyListeners('aircraft_update', { type: 'updated', hex: aircraft.hex, aircraft, correlation_id: correlationId });
+        });
+        (data.removes || []).forEach(hex => {
+            this._notifyListeners('aircraft_removed', { type: 'removed', hex, correlation_id: correlationId });
+        });
+    }
+
     // Notify all listeners of an event
     _notifyListeners(type, data) {
         if (this.listeners[type]) {